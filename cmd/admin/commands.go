@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	dateLayout = "2006-01-02"
+)
+
+func formatDate(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return "-"
+	}
+
+	return t.Time.UTC().Format(dateLayout)
+}
+
+// admin tool has its own in-memory cache, so running servers do not see changes of cached entities right away
+const (
+	staleUserNote   = "Running servers cache users for up to 15 minutes and can still accept the deleted user until then. Restart servers to apply it immediately."
+	staleAPIKeyNote = "Running servers cache API keys for up to 12 hours and can still accept the previous secret until then. Restart servers to revoke it immediately."
+)
+
+func noteUsage(fs *flag.FlagSet, note string) {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nNOTE: %s\n", note)
+	}
+}
+
+func userFlags(fs *flag.FlagSet) (*int, *string) {
+	userID := fs.Int("user", 0, "ID of the user")
+	email := fs.String("email", "", "Email of the user (if ID is not set)")
+	return userID, email
+}
+
+func listUsers(ctx context.Context, a *admin, args []string) error {
+	fs := flag.NewFlagSet("list-users", flag.ExitOnError)
+	offset := fs.Int("offset", 0, "Number of users to skip")
+	limit := fs.Int("limit", 50, "Maximum number of users to list")
+	_ = fs.Parse(args)
+
+	users, err := a.store.Impl().RetrieveUsersPage(ctx, *offset, *limit)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]int32, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID)
+	}
+
+	subscriptions := make(map[int32]*dbgen.GetUsersWithSubscriptionsRow, len(users))
+	if rows, err := a.store.Impl().RetrieveUsersWithSubscriptions(ctx, userIDs); err == nil {
+		for _, row := range rows {
+			subscriptions[row.User.ID] = row
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tPLAN\tSTATUS\tSOURCE\tCREATED")
+	for _, u := range users {
+		plan, status, source := "-", "-", "-"
+		if row, ok := subscriptions[u.ID]; ok && row.ExternalProductID.Valid {
+			plan, status, source = row.ExternalProductID.String, row.Status.String, string(row.Source.SubscriptionSource)
+			if p, err := a.planService.FindPlan(row.ExternalProductID.String, row.ExternalPriceID.String, a.stage,
+				db.IsInternalSubscription(row.Source.SubscriptionSource)); err == nil {
+				plan = p.Name()
+			}
+		}
+		fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.Name, plan, status, source, formatDate(u.CreatedAt))
+	}
+
+	return w.Flush()
+}
+
+func (a *admin) findTrialPlan(productID string) (billing.Plan, error) {
+	if len(productID) == 0 {
+		return a.planService.GetInternalTrialPlan(), nil
+	}
+
+	for _, p := range a.planService.TrialPlans(a.stage) {
+		if p.ProductID() == productID {
+			return p, nil
+		}
+	}
+
+	return nil, billing.ErrUnknownProductID
+}
+
+func (a *admin) trialParams(plan billing.Plan, days int) *dbgen.CreateSubscriptionParams {
+	if days <= 0 {
+		days = plan.TrialDays()
+	}
+
+	priceIDMonthly, priceIDYearly := plan.PriceIDs()
+	priceID := priceIDMonthly
+	if len(priceID) == 0 {
+		priceID = priceIDYearly
+	}
+
+	return &dbgen.CreateSubscriptionParams{
+		ExternalProductID:      plan.ProductID(),
+		ExternalPriceID:        priceID,
+		ExternalSubscriptionID: pgtype.Text{},
+		ExternalCustomerID:     pgtype.Text{},
+		Status:                 a.planService.ActiveTrialStatus(),
+		Source:                 dbgen.SubscriptionSourceInternal,
+		TrialEndsAt:            db.Timestampz(time.Now().AddDate(0, 0, days)),
+		NextBilledAt:           db.Timestampz(time.Time{}),
+	}
+}
+
+func createUser(ctx context.Context, a *admin, args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	email := fs.String("email", "", "Email of the new user")
+	name := fs.String("name", "", "Name of the new user")
+	orgName := fs.String("org", common.DefaultOrgName, "Name of the default organization")
+	trial := fs.Bool("trial", false, "Grant internal trial subscription")
+	_ = fs.Parse(args)
+
+	if len(*email) == 0 {
+		return fmt.Errorf("email is required")
+	}
+
+	var params *dbgen.CreateSubscriptionParams
+	if *trial {
+		params = a.trialParams(a.planService.GetInternalTrialPlan(), 0 /*days*/)
+	}
+
+	var user *dbgen.User
+	var org *dbgen.Organization
+
+	if _, err := a.store.Impl().FindUserByEmail(ctx, *email); err == nil {
+		return db.ErrDuplicateAccount
+	}
+
+	auditEvents, err := a.store.WithTx(ctx, func(impl *db.BusinessStoreImpl) ([]*common.AuditLogEvent, error) {
+		var err error
+		var auditEvents []*common.AuditLogEvent
+		user, org, auditEvents, err = impl.CreateNewAccount(ctx, params, *email, *name, *orgName, -1 /*existingUserID*/)
+		return auditEvents, err
+	})
+	if err != nil {
+		return err
+	}
+
+	a.recordEvents(ctx, auditEvents)
+
+	fmt.Printf("Created user %v (%s) with organization %v\n", user.ID, user.Email, org.ID)
+
+	return nil
+}
+
+func deleteUser(ctx context.Context, a *admin, args []string) error {
+	fs := flag.NewFlagSet("delete-user", flag.ExitOnError)
+	userID, email := userFlags(fs)
+	noteUsage(fs, staleUserNote)
+	_ = fs.Parse(args)
+
+	user, err := a.findUser(ctx, *userID, *email)
+	if err != nil {
+		return err
+	}
+
+	auditEvents, err := a.store.WithTx(ctx, func(impl *db.BusinessStoreImpl) ([]*common.AuditLogEvent, error) {
+		auditEvent, err := impl.SoftDeleteUser(ctx, user)
+		return []*common.AuditLogEvent{auditEvent}, err
+	})
+	if err != nil {
+		return err
+	}
+
+	a.recordEvents(ctx, auditEvents)
+
+	fmt.Printf("Soft-deleted user %v (%s)\n", user.ID, user.Email)
+	fmt.Println(staleUserNote)
+
+	return nil
+}
+
+func grantTrial(ctx context.Context, a *admin, args []string) error {
+	fs := flag.NewFlagSet("grant-trial", flag.ExitOnError)
+	userID, email := userFlags(fs)
+	productID := fs.String("product", "", "Product ID of the plan (internal trial plan if empty)")
+	days := fs.Int("days", 0, "Duration of the trial in days (plan default if zero)")
+	_ = fs.Parse(args)
+
+	user, err := a.findUser(ctx, *userID, *email)
+	if err != nil {
+		return err
+	}
+
+	plan, err := a.findTrialPlan(*productID)
+	if err != nil {
+		return err
+	}
+
+	params := a.trialParams(plan, *days)
+
+	// for existing user this only replaces internal subscription (or sets a new one)
+	auditEvents, err := a.store.WithTx(ctx, func(impl *db.BusinessStoreImpl) ([]*common.AuditLogEvent, error) {
+		_, _, auditEvents, err := impl.CreateNewAccount(ctx, params, user.Email, user.Name, common.DefaultOrgName, user.ID)
+		return auditEvents, err
+	})
+	if err != nil {
+		return err
+	}
+
+	a.recordEvents(ctx, auditEvents)
+
+	fmt.Printf("Granted %s trial to user %v until %s\n", plan.Name(), user.ID, formatDate(params.TrialEndsAt))
+
+	return nil
+}
+
+func listAPIKeys(ctx context.Context, a *admin, args []string) error {
+	fs := flag.NewFlagSet("list-keys", flag.ExitOnError)
+	userID, email := userFlags(fs)
+	_ = fs.Parse(args)
+
+	user, err := a.findUser(ctx, *userID, *email)
+	if err != nil {
+		return err
+	}
+
+	keys, err := a.store.Impl().RetrieveUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSCOPE\tENABLED\tCREATED\tEXPIRES")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%v\t%s\t%s\t%v\t%s\t%s\n", key.ID, key.Name, key.Scope, key.Enabled.Bool, formatDate(key.CreatedAt), formatDate(key.ExpiresAt))
+	}
+
+	return w.Flush()
+}
+
+func rotateAPIKey(ctx context.Context, a *admin, args []string) error {
+	fs := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	userID, email := userFlags(fs)
+	keyID := fs.Int("key", 0, "ID of the API key")
+	overlap := fs.Duration("overlap", 0, "For how long previous secret remains valid")
+	noteUsage(fs, staleAPIKeyNote)
+	_ = fs.Parse(args)
+
+	if *keyID <= 0 {
+		return fmt.Errorf("API key ID is required")
+	}
+
+	user, err := a.findUser(ctx, *userID, *email)
+	if err != nil {
+		return err
+	}
+
+	// rotation looks up the previous key in (this process') cache for the audit log
+	if _, err := a.store.Impl().RetrieveUserAPIKeys(ctx, user.ID); err != nil {
+		return err
+	}
+
+	key, auditEvent, err := a.store.Impl().RotateAPIKey(ctx, user, int32(*keyID), *overlap)
+	if err != nil {
+		return err
+	}
+
+	a.recordEvents(ctx, []*common.AuditLogEvent{auditEvent})
+
+	fmt.Printf("Rotated API key %v (%s), new secret: %s\n", key.ID, key.Name, db.UUIDToSecret(key.ExternalID))
+	fmt.Println(staleAPIKeyNote)
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+var (
+	envFileFlag = flag.String("env", "", "Path to .env file, 'stdin' or empty")
+)
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errUserRequired   = errors.New("user ID or email is required")
+)
+
+type admin struct {
+	store       *db.BusinessStore
+	auditLog    *db.AuditLog
+	planService billing.PlanService
+	stage       string
+}
+
+type command struct {
+	description string
+	run         func(ctx context.Context, a *admin, args []string) error
+}
+
+var commands = map[string]*command{
+	"list-users":  {"List active users", listUsers},
+	"create-user": {"Create user with a default organization", createUser},
+	"delete-user": {"Soft-delete user, their organizations and API keys", deleteUser},
+	"grant-trial": {"Grant internal trial subscription to existing user", grantTrial},
+	"list-keys":   {"List API keys of the user", listAPIKeys},
+	"rotate-key":  {"Generate new secret for the API key of the user", rotateAPIKey},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [-env path] <command> [arguments]\n\nCommands:\n", os.Args[0])
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].description)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' to see command arguments\n", os.Args[0])
+}
+
+func (a *admin) findUser(ctx context.Context, userID int, email string) (*dbgen.User, error) {
+	if userID > 0 {
+		return a.store.Impl().RetrieveUser(ctx, int32(userID))
+	}
+
+	if len(email) > 0 {
+		return a.store.Impl().FindUserByEmail(ctx, email)
+	}
+
+	return nil, errUserRequired
+}
+
+func (a *admin) recordEvents(ctx context.Context, events []*common.AuditLogEvent) {
+	if err := a.auditLog.StoreEvents(ctx, events, common.AuditLogSourceCLI); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to store audit log: %s\n", err)
+	}
+}
+
+func run(ctx context.Context, cfg common.ConfigStore) error {
+	name := flag.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		return fmt.Errorf("%w: %q", errUnknownCommand, name)
+	}
+
+	pool, clickhouse, err := db.Connect(ctx, cfg, 5*time.Second, false /*admin*/)
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	if clickhouse != nil {
+		defer clickhouse.Close()
+	}
+
+	a := &admin{
+		store:       db.NewBusiness(pool),
+		auditLog:    db.NewAuditLog(dbgen.New(pool), 1 /*batch size*/),
+		planService: billing.NewPlanService(nil),
+		stage:       cfg.Get(common.StageKey).Value(),
+	}
+
+	return cmd.run(ctx, a, flag.Args()[1:])
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	env, err := common.NewEnvMap(*envFileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, opts))
+	slog.SetDefault(logger)
+
+	cfg := config.NewEnvConfig(env.Get)
+
+	if err := run(context.Background(), cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/benchmark"
+)
+
+var (
+	baselineFlag  = flag.String("baseline", "pkg/benchmark/testdata/baseline.txt", "Path to the stored baseline `go test -bench` output")
+	currentFlag   = flag.String("current", "", "Path to the current `go test -bench` output")
+	thresholdFlag = flag.Float64("threshold", 10.0, "Maximum allowed slowdown in percent")
+)
+
+func parseFile(path string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return benchmark.ParseResults(f)
+}
+
+func main() {
+	flag.Parse()
+
+	baseline, err := parseFile(*baselineFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading baseline: %v\n", err)
+		os.Exit(1)
+	}
+
+	current, err := parseFile(*currentFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading current results: %v\n", err)
+		os.Exit(2)
+	}
+
+	for name, value := range current {
+		if base, ok := baseline[name]; ok {
+			fmt.Printf("%-40s %12.1f ns/op -> %12.1f ns/op\n", name, base, value)
+		} else {
+			fmt.Printf("%-40s %12s       -> %12.1f ns/op\n", name, "(new)", value)
+		}
+	}
+
+	regressions := benchmark.Compare(baseline, current, *thresholdFlag)
+	if len(regressions) == 0 {
+		fmt.Printf("No regressions over %.1f%%\n", *thresholdFlag)
+		return
+	}
+
+	for _, r := range regressions {
+		fmt.Fprintf(os.Stderr, "REGRESSION %s: %.1f ns/op -> %.1f ns/op (+%.1f%%)\n", r.Name, r.Baseline, r.Current, r.Percent())
+	}
+
+	os.Exit(3)
+}
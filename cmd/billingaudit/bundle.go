@@ -0,0 +1,170 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	bundleSummaryFile   = "summary.json"
+	bundleAuditFile     = "audit.csv"
+	bundleReceiptsFile  = "receipts.csv"
+	bundleChecksumsFile = "SHA256SUMS"
+)
+
+type evidenceOrgAudit struct {
+	OrgID      string  `json:"org_id"`
+	Receipts   uint64  `json:"receipts"`
+	Estimated  uint64  `json:"estimated"`
+	Recorded   uint64  `json:"recorded"`
+	Deviation  float64 `json:"deviation"`
+	Consistent bool    `json:"consistent"`
+}
+
+type evidenceAudit struct {
+	UserID      string              `json:"user_id"`
+	Period      string              `json:"period"`
+	SampleRate  int                 `json:"sample_rate"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Orgs        []*evidenceOrgAudit `json:"orgs"`
+}
+
+func newEvidenceAudit(userID int32, from time.Time, stats []*common.IssuanceAuditStat, hasher common.IdentifierHasher) *evidenceAudit {
+	audit := &evidenceAudit{
+		UserID:      hasher.Encrypt(int(userID)),
+		Period:      from.Format(periodLayout),
+		SampleRate:  common.IssuanceReceiptSampleRate,
+		GeneratedAt: time.Now().UTC(),
+		Orgs:        make([]*evidenceOrgAudit, 0, len(stats)),
+	}
+
+	for _, s := range stats {
+		deviation := s.Deviation()
+		if math.IsInf(deviation, 0) {
+			// JSON does not support infinity
+			deviation = 1.0
+		}
+
+		audit.Orgs = append(audit.Orgs, &evidenceOrgAudit{
+			OrgID:      hasher.Encrypt(int(s.OrgID)),
+			Receipts:   s.Receipts,
+			Estimated:  s.Estimated(),
+			Recorded:   s.Recorded,
+			Deviation:  deviation,
+			Consistent: s.Consistent(),
+		})
+	}
+
+	return audit
+}
+
+func writeAuditCSV(w io.Writer, audit *evidenceAudit) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"org_id", "receipts", "estimated", "recorded", "deviation", "consistent"}); err != nil {
+		return err
+	}
+
+	for _, s := range audit.Orgs {
+		row := []string{
+			s.OrgID,
+			strconv.FormatUint(s.Receipts, 10),
+			strconv.FormatUint(s.Estimated, 10),
+			strconv.FormatUint(s.Recorded, 10),
+			strconv.FormatFloat(s.Deviation, 'f', 4, 64),
+			strconv.FormatBool(s.Consistent),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeReceiptsCSV(w io.Writer, receipts []*common.IssuanceReceipt, hasher common.IdentifierHasher) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"puzzle_id", "org_id", "property_id", "sitekey", "ip_prefix_hash", "timestamp"}); err != nil {
+		return err
+	}
+
+	for _, r := range receipts {
+		row := []string{
+			strconv.FormatUint(r.PuzzleID, 16),
+			hasher.Encrypt(int(r.OrgID)),
+			hasher.Encrypt(int(r.PropertyID)),
+			r.Sitekey,
+			strconv.FormatUint(r.IPPrefixHash, 16),
+			r.Timestamp.UTC().Format(time.RFC3339),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeEvidenceBundle writes zip archive with the audit summary, all receipts of the billing period
+// and checksums of the files (to detect modifications after the bundle was shared)
+func writeEvidenceBundle(w io.Writer, audit *evidenceAudit, receipts []*common.IssuanceReceipt, hasher common.IdentifierHasher) error {
+	archive := zip.NewWriter(w)
+
+	checksums := make([]string, 0, 3)
+	addFile := func(name string, writeFunc func(io.Writer) error) error {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: audit.GeneratedAt})
+		if err != nil {
+			return err
+		}
+
+		hash := sha256.New()
+		if err := writeFunc(io.MultiWriter(f, hash)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+
+		checksums = append(checksums, fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash.Sum(nil)), name))
+		return nil
+	}
+
+	if err := addFile(bundleSummaryFile, func(fw io.Writer) error {
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(audit)
+	}); err != nil {
+		return err
+	}
+
+	if err := addFile(bundleAuditFile, func(fw io.Writer) error { return writeAuditCSV(fw, audit) }); err != nil {
+		return err
+	}
+
+	if err := addFile(bundleReceiptsFile, func(fw io.Writer) error { return writeReceiptsCSV(fw, receipts, hasher) }); err != nil {
+		return err
+	}
+
+	f, err := archive.Create(bundleChecksumsFile)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range checksums {
+		if _, err := io.WriteString(f, line); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	periodLayout = "2006-01"
+)
+
+var (
+	envFileFlag = flag.String("env", "", "Path to .env file, 'stdin' or empty")
+	userFlag    = flag.Int("user", 0, "ID of the billed user (organizations owner)")
+	periodFlag  = flag.String("period", "", "Billing period (YYYY-MM), previous month if empty")
+	outFlag     = flag.String("out", "", "Path to evidence bundle (zip), only report is printed if empty")
+)
+
+func billingPeriod(value string, tnow time.Time) (time.Time, time.Time, error) {
+	var from time.Time
+	if len(value) == 0 {
+		from = time.Date(tnow.Year(), tnow.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	} else {
+		t, err := time.Parse(periodLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = t.UTC()
+	}
+
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+func printReport(audit *evidenceAudit) {
+	fmt.Printf("User %v, billing period %s, sample rate 1/%v\n\n", audit.UserID, audit.Period, audit.SampleRate)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ORG\tRECEIPTS\tESTIMATED\tRECORDED\tDEVIATION\tCONSISTENT")
+	for _, s := range audit.Orgs {
+		fmt.Fprintf(w, "%s\t%v\t%v\t%v\t%.2f%%\t%v\n", s.OrgID, s.Receipts, s.Estimated, s.Recorded, s.Deviation*100.0, s.Consistent)
+	}
+	_ = w.Flush()
+}
+
+func run(ctx context.Context, cfg common.ConfigStore) error {
+	if *userFlag <= 0 {
+		return fmt.Errorf("user ID is required")
+	}
+
+	from, to, err := billingPeriod(*periodFlag, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	pool, clickhouse, err := db.Connect(ctx, cfg, 5*time.Second, false /*admin*/)
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	if clickhouse == nil {
+		return fmt.Errorf("issuance receipts are only kept in ClickHouse")
+	}
+
+	defer clickhouse.Close()
+
+	timeSeries := db.NewTimeSeries(clickhouse, db.NewStaticCache[db.CacheKey, any](100 /*capacity*/, nil /*missing value*/))
+	idHasher := common.NewIDHasher(cfg.Get(common.IDHasherSaltKey))
+	userID := int32(*userFlag)
+
+	stats, err := timeSeries.RetrieveIssuanceAudit(ctx, userID, from, to)
+	if err != nil {
+		return err
+	}
+
+	audit := newEvidenceAudit(userID, from, stats, idHasher)
+	printReport(audit)
+
+	if len(*outFlag) == 0 {
+		return nil
+	}
+
+	receipts, err := timeSeries.RetrieveIssuanceReceipts(ctx, userID, from, to)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*outFlag)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := writeEvidenceBundle(f, audit, receipts, idHasher); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Written evidence bundle", "path", *outFlag, "receipts", len(receipts))
+
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	env, err := common.NewEnvMap(*envFileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, opts))
+	slog.SetDefault(logger)
+
+	cfg := config.NewEnvConfig(env.Get)
+
+	if err := run(context.Background(), cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 )
 
 type hardcodedKey struct {
@@ -17,7 +21,7 @@ type hardcodedKey struct {
 	KeyData string `json:"KeyData"`
 }
 
-func handleWrite(filePath string, useBase64 bool) {
+func readKeys(filePath string) []hardcodedKey {
 	jsonFile, err := os.ReadFile(filePath)
 	if err != nil {
 		log.Fatalf("Failed to read file: %v", err)
@@ -28,6 +32,40 @@ func handleWrite(filePath string, useBase64 bool) {
 		log.Fatalf("Failed to unmarshal JSON: %v", err)
 	}
 
+	return keys
+}
+
+func writeOutput(buf *bytes.Buffer, useBase64 bool) {
+	if useBase64 {
+		encodedString := base64.StdEncoding.EncodeToString(buf.Bytes())
+		if _, err := os.Stdout.WriteString(encodedString); err != nil {
+			log.Fatalf("Failed to write to stdout: %v", err)
+		}
+	} else {
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			log.Fatalf("Failed to write to stdout: %v", err)
+		}
+	}
+}
+
+func writeKey(buf *bytes.Buffer, keyID uint16, data []byte) {
+	if len(data) > 255 {
+		log.Fatalf("Decoded data size does not fit into 1 byte")
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, keyID); err != nil {
+		log.Fatalf("Failed to write KeyID: %v", err)
+	}
+	if err := binary.Write(buf, binary.LittleEndian, uint8(len(data))); err != nil {
+		log.Fatalf("Failed to write data length: %v", err)
+	}
+	if _, err := buf.Write(data); err != nil {
+		log.Fatalf("Failed to write key data: %v", err)
+	}
+}
+
+func handleWrite(filePath string, useBase64 bool) {
+	keys := readKeys(filePath)
 	var buf bytes.Buffer
 
 	for _, k := range keys {
@@ -35,34 +73,45 @@ func handleWrite(filePath string, useBase64 bool) {
 		if err != nil {
 			log.Fatalf("Failed to decode base64 data for KeyID %d: %v", k.KeyID, err)
 		}
-		if len(decodedData) > 256 {
-			log.Fatalf("Decoded data size does not fit into 1 byte")
-		}
 
-		keyID := uint16(k.KeyID)
-		dataLen := uint8(len(decodedData))
+		writeKey(&buf, uint16(k.KeyID), decodedData)
+	}
 
-		if err := binary.Write(&buf, binary.LittleEndian, keyID); err != nil {
-			log.Fatalf("Failed to write KeyID: %v", err)
-		}
-		if err := binary.Write(&buf, binary.LittleEndian, dataLen); err != nil {
-			log.Fatalf("Failed to write data length: %v", err)
-		}
-		if _, err := buf.Write(decodedData); err != nil {
-			log.Fatalf("Failed to write key data: %v", err)
+	writeOutput(&buf, useBase64)
+}
+
+// handleDerive packs per-property keys (e.g. for verifyproxy) so that puzzle salts themselves are not given out
+func handleDerive(filePath string, sitekeys []string, useBase64 bool) {
+	keys := readKeys(filePath)
+	var buf bytes.Buffer
+
+	for _, sitekey := range sitekeys {
+		sitekey = strings.TrimSpace(sitekey)
+		if len(sitekey) == 0 {
+			continue
 		}
-	}
 
-	if useBase64 {
-		encodedString := base64.StdEncoding.EncodeToString(buf.Bytes())
-		if _, err := os.Stdout.WriteString(encodedString); err != nil {
-			log.Fatalf("Failed to write to stdout: %v", err)
+		propertyID, err := hex.DecodeString(sitekey)
+		if err != nil || len(propertyID) != puzzle.PropertyIDSize {
+			log.Fatalf("Invalid sitekey: %v", sitekey)
 		}
-	} else {
-		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
-			log.Fatalf("Failed to write to stdout: %v", err)
+
+		for _, k := range keys {
+			decodedData, err := base64.StdEncoding.DecodeString(k.KeyData)
+			if err != nil {
+				log.Fatalf("Failed to decode base64 data for KeyID %d: %v", k.KeyID, err)
+			}
+
+			derived, err := puzzle.NewSalt(decodedData).PropertySalt([puzzle.PropertyIDSize]byte(propertyID)).MarshalBinary()
+			if err != nil {
+				log.Fatalf("Failed to derive key %d for sitekey %v: %v", k.KeyID, sitekey, err)
+			}
+
+			writeKey(&buf, uint16(k.KeyID), derived)
 		}
 	}
+
+	writeOutput(&buf, useBase64)
 }
 
 func handleRead(useBase64 bool) {
@@ -120,8 +169,9 @@ func handleRead(useBase64 bool) {
 
 func main() {
 	log.SetOutput(os.Stderr)
-	mode := flag.String("mode", "", "Mode of operation: read or write")
-	filePath := flag.String("file", "", "Path to the JSON file (for write mode)")
+	mode := flag.String("mode", "", "Mode of operation: read, write or derive")
+	filePath := flag.String("file", "", "Path to the JSON file (for write and derive modes)")
+	sitekeys := flag.String("sitekeys", "", "Comma-separated list of sitekeys (for derive mode)")
 	useBase64 := flag.Bool("base64", false, "Use base64 encoding for input/output")
 	flag.Parse()
 
@@ -131,12 +181,17 @@ func main() {
 			log.Fatal("The -file argument is required for write mode")
 		}
 		handleWrite(*filePath, *useBase64)
+	case "derive":
+		if (*filePath == "") || (*sitekeys == "") {
+			log.Fatal("The -file and -sitekeys arguments are required for derive mode")
+		}
+		handleDerive(*filePath, strings.Split(*sitekeys, ","), *useBase64)
 	case "read":
 		if *filePath != "" {
 			log.Fatal("The -file argument is not used for read mode")
 		}
 		handleRead(*useBase64)
 	default:
-		log.Fatal("Invalid mode. Please use 'read' or 'write'")
+		log.Fatal("Invalid mode. Please use 'read', 'write' or 'derive'")
 	}
 }
@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+// solverwasm exposes puzzle.ComputeSolver to JavaScript so that the widget and third-party apps can use the exact
+// same solving logic as the server expects. It registers global privateCaptchaSolve(puzzle) function, that returns
+// an object with either "solution" (in the same format as the widget payload) or "error" field.
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"syscall/js"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	solveFunctionName = "privateCaptchaSolve"
+)
+
+var (
+	errInvalidArgs = errors.New("puzzle argument is required")
+)
+
+func solve(puzzleStr string) (string, error) {
+	encodedPuzzle, _, _ := strings.Cut(puzzleStr, ".")
+	decodedData, err := base64.StdEncoding.DecodeString(encodedPuzzle)
+	if err != nil {
+		return "", err
+	}
+
+	p := new(puzzle.ComputePuzzle)
+	if err := p.UnmarshalBinary(decodedData); err != nil {
+		return "", err
+	}
+
+	solver := &puzzle.ComputeSolver{Wasm: true}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		return "", err
+	}
+
+	return solutions.String() + "." + puzzleStr, nil
+}
+
+func solveFunc(this js.Value, args []js.Value) any {
+	if (len(args) == 0) || (args[0].Type() != js.TypeString) {
+		return map[string]any{"error": errInvalidArgs.Error()}
+	}
+
+	solution, err := solve(args[0].String())
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+
+	return map[string]any{"solution": solution}
+}
+
+func main() {
+	js.Global().Set(solveFunctionName, js.FuncOf(solveFunc))
+
+	// functions exported to JS are only callable while the program is running
+	select {}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+var (
+	errNoKeys = errors.New("no verification keys found")
+)
+
+// loadKeys reads per-property puzzle salts packed with cmd/keyspack (derive mode), the original salt is never given to
+// the proxy. Multiple keys are supported so that salt can be rotated (signature fingerprint defines which key has to
+// be used).
+func loadKeys(path string, useBase64 bool) ([]*puzzle.Salt, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if useBase64 {
+		reader = base64.NewDecoder(base64.StdEncoding, file)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKeys(data)
+}
+
+func parseKeys(data []byte) ([]*puzzle.Salt, error) {
+	reader := bytes.NewReader(data)
+	salts := make([]*puzzle.Salt, 0)
+
+	for reader.Len() > 0 {
+		var keyID uint16
+		if err := binary.Read(reader, binary.LittleEndian, &keyID); err != nil {
+			return nil, fmt.Errorf("failed to read key ID: %w", err)
+		}
+
+		var dataLen uint8
+		if err := binary.Read(reader, binary.LittleEndian, &dataLen); err != nil {
+			return nil, fmt.Errorf("failed to read length of key %d: %w", keyID, err)
+		}
+
+		keyData := make([]byte, dataLen)
+		if _, err := io.ReadFull(reader, keyData); err != nil {
+			return nil, fmt.Errorf("failed to read data of key %d: %w", keyID, err)
+		}
+
+		salt, err := puzzle.UnmarshalPropertySalt(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %d: %w", keyID, err)
+		}
+
+		salts = append(salts, salt)
+	}
+
+	if len(salts) == 0 {
+		return nil, errNoKeys
+	}
+
+	return salts, nil
+}
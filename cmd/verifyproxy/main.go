@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	envAPIKey           = "PC_API_KEY"
+	maxSolutionsSize    = 256 * 1024
+	_cleanupInterval    = 1 * time.Minute
+	_shutdownPeriod     = 10 * time.Second
+	_upstreamTimeout    = 10 * time.Second
+	_defaultAPIURL      = "https://api.privatecaptcha.com"
+	_defaultReportDelay = 5 * time.Second
+)
+
+var (
+	GitCommit          string
+	envFileFlag        = flag.String("env", "", "Path to .env file, 'stdin' or empty")
+	addressFlag        = flag.String("address", "localhost:8081", "Address to listen on")
+	apiURLFlag         = flag.String("api", _defaultAPIURL, "Private Captcha API URL")
+	keysFileFlag       = flag.String("keys", "", "Path to verification keys packed with keyspack")
+	base64Flag         = flag.Bool("base64", false, "Verification keys file is base64 encoded")
+	reportIntervalFlag = flag.Duration("report-interval", _defaultReportDelay, "How often to report verifications to the API")
+	verboseFlag        = flag.Bool("verbose", false, "Enable verbose logs")
+	versionFlag        = flag.Bool("version", false, "Print version and exit")
+	errAPIKeyMissing   = errors.New("API key is not configured")
+)
+
+func run(ctx context.Context, apiKey string) error {
+	salts, err := loadKeys(*keysFileFlag, *base64Flag)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load verification keys", "path", *keysFileFlag, common.ErrAttr(err))
+		return err
+	}
+
+	apiURL := strings.TrimSuffix(*apiURLFlag, "/")
+	p := &proxy{
+		apiURL:   apiURL,
+		apiKey:   apiKey,
+		verifier: newLocalVerifier(salts),
+		reporter: newReporter(apiURL, apiKey),
+		client:   &http.Client{Timeout: _upstreamTimeout},
+	}
+
+	if len(p.verifier.salts) == 0 {
+		slog.WarnContext(ctx, "No property keys configured, all verifications will be forwarded to the API")
+	}
+
+	router := http.NewServeMux()
+	p.setup(router)
+
+	reportCtx, cancelReports := context.WithCancel(common.TraceContext(context.Background(), "verify_reports"))
+	defer cancelReports()
+	go p.reporter.Run(reportCtx, *reportIntervalFlag)
+	go common.RunPeriodicJob(common.TraceContext(reportCtx, "replay_cleanup"), p)
+
+	httpServer := &http.Server{
+		Addr:              *addressFlag,
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-signals
+		slog.DebugContext(ctx, "Received signal", "signal", sig)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), _shutdownPeriod)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			slog.ErrorContext(ctx, "Failed to shutdown HTTP server", common.ErrAttr(err))
+		}
+	}()
+
+	slog.InfoContext(ctx, "Listening", "address", *addressFlag, "api", apiURL, "keys", len(salts),
+		"sitekeys", len(p.verifier.salts), "version", GitCommit)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.ErrorContext(ctx, "Error serving", common.ErrAttr(err))
+		return err
+	}
+
+	// give reporter a chance to flush the last batch
+	time.Sleep(min(*reportIntervalFlag, _shutdownPeriod))
+	p.reporter.Shutdown()
+
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	if *versionFlag {
+		fmt.Print(GitCommit)
+		return
+	}
+
+	env, err := common.NewEnvMap(*envFileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	common.SetupLogs("" /*stage*/, *verboseFlag)
+	ctx := common.TraceContext(context.Background(), "main")
+
+	apiKey := env.Get(envAPIKey)
+	if len(apiKey) == 0 {
+		fmt.Fprintf(os.Stderr, "%s (%s)\n", errAPIKeyMissing, envAPIKey)
+		os.Exit(1)
+	}
+
+	if err := run(ctx, apiKey); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+// API key of the proxy is also required from its clients, which makes the proxy a drop-in replacement of the API
+func (p *proxy) isAuthorized(key string) bool {
+	return (len(key) > 0) && (subtle.ConstantTimeCompare([]byte(key), []byte(p.apiKey)) == 1)
+}
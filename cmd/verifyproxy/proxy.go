@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	recaptchaCompatV3 = "rcV3"
+)
+
+var (
+	forwardedHeaders = []string{
+		common.HeaderContentType,
+		common.HeaderAPIKey,
+		common.HeaderSitekey,
+		common.HeaderCaptchaCompat,
+	}
+)
+
+// mirrors api.VerificationResponse
+type verificationResponse struct {
+	Success   bool               `json:"success"`
+	Code      puzzle.VerifyError `json:"code"`
+	Timestamp common.JSONTime    `json:"timestamp,omitempty"`
+	Claims    map[string]string  `json:"claims,omitempty"`
+}
+
+// mirrors api.VerifyResponseRecaptchaV2
+type verifyResponseRecaptchaV2 struct {
+	Success     bool              `json:"success"`
+	ErrorCodes  []string          `json:"error-codes,omitempty"`
+	ChallengeTS common.JSONTime   `json:"challenge_ts"`
+	Hostname    string            `json:"hostname"`
+	Claims      map[string]string `json:"claims,omitempty"`
+}
+
+type verifyResponseRecaptchaV3 struct {
+	verifyResponseRecaptchaV2
+	Score  float64 `json:"score"`
+	Action string  `json:"action"`
+}
+
+type proxy struct {
+	apiURL   string
+	apiKey   string
+	verifier *localVerifier
+	reporter *reporter
+	client   *http.Client
+}
+
+var _ common.PeriodicJob = (*proxy)(nil)
+
+func (p *proxy) setup(router *http.ServeMux) {
+	router.Handle(http.MethodPost+" /"+common.VerifyEndpoint, http.MaxBytesHandler(http.HandlerFunc(p.pcVerifyHandler), maxSolutionsSize))
+	router.Handle(http.MethodPost+" /"+common.SiteVerifyEndpoint, http.MaxBytesHandler(http.HandlerFunc(p.recaptchaVerifyHandler), maxSolutionsSize))
+	router.HandleFunc(http.MethodGet+" /"+common.LiveEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func (p *proxy) forward(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+r.URL.Path, bytes.NewReader(body))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create upstream request", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	for _, header := range forwardedHeaders {
+		if value := r.Header.Get(header); len(value) > 0 {
+			req.Header.Set(header, value)
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to forward verification", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, header := range []string{common.HeaderContentType, common.HeaderCacheControl} {
+		if value := resp.Header.Get(header); len(value) > 0 {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		slog.ErrorContext(ctx, "Failed to copy upstream response", common.ErrAttr(err))
+	}
+}
+
+func (p *proxy) report(ctx context.Context, data []byte, verr puzzle.VerifyError, tstart time.Time) {
+	if verr == puzzle.ParseResponseError {
+		// there's nothing to account for on the API side
+		return
+	}
+
+	p.reporter.Report(ctx, &common.VerifyReport{
+		Payload:    string(data),
+		Timestamp:  tstart.Unix(),
+		DurationUs: uint32(min(time.Since(tstart).Microseconds(), math.MaxUint32)),
+		Code:       int(verr),
+	})
+}
+
+// Private Captcha format: puzzle response is the whole body, API key is in header
+func (p *proxy) pcVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	tstart := time.Now()
+	ctx := r.Context()
+
+	if !p.isAuthorized(r.Header.Get(common.HeaderAPIKey)) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	result, local := p.verifier.Verify(ctx, data, r.Header.Get(common.HeaderSitekey), tstart.UTC())
+	if !local {
+		p.forward(w, r, data)
+		return
+	}
+
+	if result.Error == puzzle.ParseResponseError {
+		http.Error(w, "Failed to parse payload", http.StatusBadRequest)
+		return
+	}
+
+	response := &verificationResponse{
+		Success:   result.Success(),
+		Code:      result.Error,
+		Timestamp: common.JSONTime(tstart.UTC()),
+		Claims:    result.Claims,
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+
+	p.report(ctx, data, result.Error, tstart)
+}
+
+// reCAPTCHA format: puzzle response is in form field "response", API key is in form field "secret"
+func (p *proxy) recaptchaVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	tstart := time.Now()
+	ctx := r.Context()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request form", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if !p.isAuthorized(form.Get(common.ParamSecret)) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	data := form.Get(common.ParamResponse)
+	if len(data) == 0 {
+		slog.ErrorContext(ctx, "Empty captcha response")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	result, local := p.verifier.Verify(ctx, []byte(data), form.Get(common.ParamSiteKey), tstart.UTC())
+	if !local {
+		p.forward(w, r, body)
+		return
+	}
+
+	if result.Error == puzzle.ParseResponseError {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	vr2 := &verifyResponseRecaptchaV2{
+		Success:     result.Success(),
+		ErrorCodes:  result.ErrorsToStrings(),
+		ChallengeTS: common.JSONTime(tstart.UTC()),
+		Claims:      result.Claims,
+	}
+
+	var response interface{} = vr2
+	if r.Header.Get(common.HeaderCaptchaCompat) == recaptchaCompatV3 {
+		response = &verifyResponseRecaptchaV3{
+			verifyResponseRecaptchaV2: *vr2,
+			Action:                    "",
+			Score:                     0.5,
+		}
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+
+	p.report(ctx, []byte(data), result.Error, tstart)
+}
+
+func (p *proxy) Interval() time.Duration {
+	return _cleanupInterval
+}
+
+func (p *proxy) Jitter() time.Duration {
+	return 1
+}
+
+func (p *proxy) Timeout() time.Duration {
+	return 5 * time.Second
+}
+
+func (p *proxy) Name() string {
+	return "replay_cleanup_job"
+}
+
+func (p *proxy) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (p *proxy) NewParams() any {
+	return struct{}{}
+}
+
+func (p *proxy) RunOnce(ctx context.Context, params any) error {
+	if deleted := p.verifier.cleanup(time.Now().UTC()); deleted > 0 {
+		slog.DebugContext(ctx, "Cleaned up verified puzzles", "count", deleted)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	reportBatchSize    = 100
+	maxPendingReports  = 10_000
+	reportFlushTimeout = 10 * time.Second
+)
+
+// reporter sends results of local verifications to the API asynchronously (for stats and replay accounting)
+type reporter struct {
+	url     string
+	apiKey  string
+	client  *http.Client
+	reports chan *common.VerifyReport
+}
+
+func newReporter(apiURL, apiKey string) *reporter {
+	return &reporter{
+		url:     apiURL + "/" + common.VerifyEndpoint + "/" + common.ReportEndpoint,
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: reportFlushTimeout},
+		reports: make(chan *common.VerifyReport, maxPendingReports),
+	}
+}
+
+func (r *reporter) Report(ctx context.Context, report *common.VerifyReport) {
+	select {
+	case r.reports <- report:
+	default:
+		// verification must never wait for reporting
+		slog.WarnContext(ctx, "Dropping verify report as the queue is full")
+	}
+}
+
+func (r *reporter) Run(ctx context.Context, interval time.Duration) {
+	common.ProcessBatchArray(ctx, r.reports, interval, reportBatchSize, maxPendingReports, r.send)
+}
+
+func (r *reporter) Shutdown() {
+	close(r.reports)
+}
+
+func (r *reporter) send(ctx context.Context, batch []*common.VerifyReport) error {
+	// batch can grow bigger than API allows if previous attempts failed
+	for start := 0; start < len(batch); start += reportBatchSize {
+		end := min(start+reportBatchSize, len(batch))
+		if err := r.sendChunk(ctx, batch[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *reporter) sendChunk(ctx context.Context, reports []*common.VerifyReport) error {
+	data, err := json.Marshal(&common.VerifyReportRequest{Reports: reports, SentAt: time.Now().Unix()})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize verify reports", common.ErrAttr(err))
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, r.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+	req.Header.Set(common.HeaderAPIKey, r.apiKey)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send verify reports", "count", len(reports), common.ErrAttr(err))
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case (resp.StatusCode == http.StatusTooManyRequests) || (resp.StatusCode >= 500):
+		slog.WarnContext(ctx, "Verify reports will be retried", "status", resp.StatusCode, "count", len(reports))
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		// retrying will not help (e.g. API key was revoked)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		slog.ErrorContext(ctx, "Verify reports were rejected", "status", resp.StatusCode, "count", len(reports), "body", string(body))
+		return nil
+	}
+
+	response := &common.VerifyReportResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		slog.WarnContext(ctx, "Failed to decode verify reports response", common.ErrAttr(err))
+		return nil
+	}
+
+	for i, result := range response.Results {
+		if (i < len(reports)) && (result.Code != reports[i].Code) {
+			slog.WarnContext(ctx, "API verification result differs from local", "local", reports[i].Code, "api", result.Code)
+		}
+	}
+
+	slog.DebugContext(ctx, "Sent verify reports", "count", len(reports))
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+type localVerifier struct {
+	// derived salts (by sitekey) of properties that belong to the API key owner, puzzles of other properties are not
+	// verified locally
+	salts map[string][]*puzzle.Salt
+	mux   sync.Mutex
+	// puzzle hash key -> expiration
+	verified map[uint64]time.Time
+}
+
+func newLocalVerifier(salts []*puzzle.Salt) *localVerifier {
+	v := &localVerifier{
+		salts:    make(map[string][]*puzzle.Salt),
+		verified: make(map[uint64]time.Time),
+	}
+
+	for _, salt := range salts {
+		if propertyID, ok := salt.PropertyID(); ok {
+			sitekey := hex.EncodeToString(propertyID[:])
+			v.salts[sitekey] = append(v.salts[sitekey], salt)
+		}
+	}
+
+	return v
+}
+
+func (v *localVerifier) verifySignature(ctx context.Context, payload puzzle.SolutionPayload, salts []*puzzle.Salt) (puzzle.VerifyError, bool) {
+	for _, salt := range salts {
+		err := payload.VerifySignature(ctx, salt, nil /*extra salt*/)
+		if err == nil {
+			return puzzle.VerifyNoError, true
+		}
+
+		if err != puzzle.ErrSignKeyMismatch {
+			return puzzle.IntegrityError, true
+		}
+	}
+
+	// salt was probably rotated on the API side
+	slog.WarnContext(ctx, "No verification key found for puzzle signature")
+	return puzzle.VerifyNoError, false
+}
+
+// isReplay checks and marks the puzzle as verified. Replays are not rejected locally because the property can allow
+// them (max replay count), which is known only to the API
+func (v *localVerifier) isReplay(p puzzle.Puzzle) bool {
+	key := p.HashKey()
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	if _, ok := v.verified[key]; ok {
+		return true
+	}
+
+	v.verified[key] = p.Expiration()
+	return false
+}
+
+func (v *localVerifier) cleanup(tnow time.Time) int {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+
+	deleted := 0
+	for key, expiration := range v.verified {
+		if expiration.Before(tnow) {
+			delete(v.verified, key)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// Verify returns false if the payload cannot be verified locally and has to be forwarded to the API
+func (v *localVerifier) Verify(ctx context.Context, data []byte, sitekey string, tnow time.Time) (*puzzle.VerifyResult, bool) {
+	payload, err := puzzle.ParseVerifyPayload[puzzle.ComputePuzzle](ctx, data)
+	if err != nil {
+		slog.Log(ctx, common.LevelTrace, "Failed to parse solution payload", common.ErrAttr(err))
+		return &puzzle.VerifyResult{Error: puzzle.ParseResponseError}, true
+	}
+
+	p := payload.Puzzle()
+	propertyID := p.PropertyID()
+	puzzleSitekey := hex.EncodeToString(propertyID[:])
+
+	// test puzzles, per-property salts, remember window and clock skew all depend on property settings
+	if p.IsZero() || p.IsRemembered() || payload.NeedsExtraSalt() {
+		return nil, false
+	}
+
+	salts, ok := v.salts[puzzleSitekey]
+	if !ok {
+		slog.DebugContext(ctx, "Puzzle property is not served locally", "sitekey", puzzleSitekey)
+		return nil, false
+	}
+
+	// cross-property verification (trust groups) is decided by the API
+	if (len(sitekey) > 0) && !strings.EqualFold(sitekey, puzzleSitekey) {
+		return nil, false
+	}
+
+	if !tnow.Before(p.Expiration()) {
+		return nil, false
+	}
+
+	result := &puzzle.VerifyResult{PuzzleID: p.PuzzleID()}
+
+	if verr, ok := v.verifySignature(ctx, payload, salts); !ok {
+		return nil, false
+	} else if verr != puzzle.VerifyNoError {
+		result.SetError(verr)
+		return result, true
+	}
+
+	if _, verr := payload.VerifySolutions(ctx); verr != puzzle.VerifyNoError {
+		slog.WarnContext(ctx, "Failed to verify solutions", "result", verr.String(), "puzzleID", p.PuzzleID())
+		result.SetError(verr)
+		return result, true
+	}
+
+	if v.isReplay(p) {
+		slog.DebugContext(ctx, "Puzzle was verified before", "puzzleID", p.PuzzleID())
+		return nil, false
+	}
+
+	result.Claims = p.Claims()
+
+	return result, true
+}
@@ -65,6 +65,8 @@ func serveExecute(templateBody string, r *http.Request, w http.ResponseWriter) e
 		CurrentYear int
 		CDNURL      string
 		UserName    string
+		// batch API keys
+		APIKeysCount int
 	}{
 		APIKeyExpirationContext: email.APIKeyExpirationContext{
 			APIKeyContext: email.APIKeyContext{
@@ -88,10 +90,11 @@ func serveExecute(templateBody string, r *http.Request, w http.ResponseWriter) e
 			OS:       agent.OS().String(),
 			Location: "EE",
 		},
-		UserName:    "John Doe",
-		CDNURL:      "https://cdn.privatecaptcha.com",
-		PortalURL:   "https://portal.privatecaptcha.com",
-		CurrentYear: time.Now().Year(),
+		UserName:     "John Doe",
+		CDNURL:       "https://cdn.privatecaptcha.com",
+		PortalURL:    "https://portal.privatecaptcha.com",
+		CurrentYear:  time.Now().Year(),
+		APIKeysCount: 12,
 	}
 
 	var htmlBodyTpl bytes.Buffer
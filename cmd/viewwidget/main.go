@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 	"github.com/PrivateCaptcha/PrivateCaptcha/web"
@@ -28,6 +29,7 @@ func main() {
 
 	router.Handle("/", monitoring.Logged(corsDefault.Handler(staticHandler())))
 	router.Handle("GET "+"/widget/", http.StripPrefix("/widget/", widget.Static("")))
+	router.Handle("GET "+"/"+common.TurnstileEndpoint+"/api.js", widget.TurnstileScript(widget.Static("")))
 	router.Handle("GET "+"/assets/", http.StripPrefix("/assets/", web.Static("")))
 	srv.Setup(router)
 
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+// isAccessDenied evaluates property access lists: denylists always win, non-empty allowlist denies everybody else
+func (c *ipRangesCache) isAccessDenied(ctx context.Context, list *dbgen.PropertyAccessList, addr netip.Addr, asn int64) (bool, error) {
+	if (asn > 0) && slices.Contains(list.AsnDenylist, asn) {
+		return true, nil
+	}
+
+	if len(list.IpDenylist) > 0 {
+		denied, err := c.prefixes(ctx, ipRangesKey{kind: ipRangesDenylist, id: list.PropertyID}, list.IpDenylist)
+		if err != nil {
+			return false, err
+		}
+
+		if common.IsIPAllowed(denied, addr) {
+			return true, nil
+		}
+	}
+
+	if len(list.IpAllowlist) > 0 {
+		allowed, err := c.prefixes(ctx, ipRangesKey{kind: ipRangesAllowlist, id: list.PropertyID}, list.IpAllowlist)
+		if err != nil {
+			return false, err
+		}
+
+		return !common.IsIPAllowed(allowed, addr), nil
+	}
+
+	return false, nil
+}
+
+// requestASN reads autonomous system number of the client from the header set by CDN (if configured)
+func (s *Server) requestASN(r *http.Request) int64 {
+	header := s.asnHeader.Load()
+	if (header == nil) || (len(*header) == 0) {
+		return 0
+	}
+
+	value := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(r.Header.Get(*header))), "AS")
+	asn, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return asn
+}
+
+func (s *Server) updateASNHeader(ctx context.Context, cfg common.ConfigStore) {
+	header := http.CanonicalHeaderKey(strings.TrimSpace(cfg.Get(common.ASNHeaderKey).Value()))
+	s.asnHeader.Store(&header)
+
+	if len(header) > 0 {
+		slog.DebugContext(ctx, "Updated ASN header", "header", header)
+	}
+}
+
+// accessListPrefilter applies property IP and ASN access lists and returns minimal puzzle difficulty
+func (s *Server) accessListPrefilter(r *http.Request) (uint8, bool) {
+	ctx := r.Context()
+	property, ok := ctx.Value(common.PropertyContextKey).(*dbgen.Property)
+	if !ok || (property == nil) {
+		return 0, false
+	}
+
+	list, err := s.BusinessDB.Impl().RetrievePropertyAccessList(ctx, property.ID)
+	if err != nil {
+		if (err != db.ErrNegativeCacheHit) && (err != db.ErrRecordNotFound) && (err != db.ErrMaintenance) {
+			slog.ErrorContext(ctx, "Failed to retrieve property access list", "propID", property.ID, common.ErrAttr(err))
+		}
+		// access lists are a convenience on top of PoW, so we do not fail requests because of them
+		return 0, false
+	}
+
+	addr, _ := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr)
+	asn := s.requestASN(r)
+
+	denied, err := cachedIPRanges.isAccessDenied(ctx, list, addr, asn)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to evaluate property access list", "propID", property.ID, common.ErrAttr(err))
+		return 0, false
+	}
+
+	if !denied {
+		return 0, false
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Request denied by property access list", "propID", property.ID, "asn", asn,
+		"policy", list.Policy)
+
+	switch list.Policy {
+	case dbgen.BotPolicyBlock:
+		return 0, true
+	case dbgen.BotPolicyMaxDifficulty:
+		return uint8(common.MaxDifficultyLevel), false
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,173 @@
+//go:build enterprise
+
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func accessListToOutput(list *dbgen.PropertyAccessList) *apiPropertyAccessList {
+	result := &apiPropertyAccessList{
+		IPAllowlist: []string{},
+		IPDenylist:  []string{},
+		ASNDenylist: []int64{},
+		Policy:      string(dbgen.BotPolicyBlock),
+	}
+
+	if list != nil {
+		result.IPAllowlist = append(result.IPAllowlist, list.IpAllowlist...)
+		result.IPDenylist = append(result.IPDenylist, list.IpDenylist...)
+		result.ASNDenylist = append(result.ASNDenylist, list.AsnDenylist...)
+		result.Policy = string(list.Policy)
+	}
+
+	return result
+}
+
+func validateAccessListInput(request *apiPropertyAccessList) (*dbgen.UpsertPropertyAccessListParams, common.StatusCode) {
+	params := &dbgen.UpsertPropertyAccessListParams{
+		Policy: dbgen.BotPolicy(request.Policy),
+	}
+
+	if len(params.Policy) == 0 {
+		params.Policy = dbgen.BotPolicyBlock
+	}
+
+	var err error
+	if params.IpAllowlist, err = db.NormalizeIPList(request.IPAllowlist); err != nil {
+		return nil, common.StatusPropertyAccessListError
+	}
+
+	if params.IpDenylist, err = db.NormalizeIPList(request.IPDenylist); err != nil {
+		return nil, common.StatusPropertyAccessListError
+	}
+
+	if params.AsnDenylist, err = db.NormalizeASNList(request.ASNDenylist); err != nil {
+		return nil, common.StatusPropertyAccessListError
+	}
+
+	return params, common.StatusOK
+}
+
+// propertyAccessList returns nil (and no error) if property does not have access lists
+func (s *Server) propertyAccessList(ctx context.Context, property *dbgen.Property) (*dbgen.PropertyAccessList, error) {
+	list, err := s.BusinessDB.Impl().RetrievePropertyAccessList(ctx, property.ID)
+	if (err == db.ErrNegativeCacheHit) || (err == db.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return list, err
+}
+
+func (s *Server) getPropertyAccessList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, false /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	list, err := s.propertyAccessList(ctx, property)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	s.sendAPISuccessResponse(ctx, accessListToOutput(list), w)
+}
+
+func (s *Server) putPropertyAccessList(w http.ResponseWriter, r *http.Request) {
+	s.updatePropertyAccessList(w, r, true /*replace*/)
+}
+
+func (s *Server) deletePropertyAccessList(w http.ResponseWriter, r *http.Request) {
+	s.updatePropertyAccessList(w, r, false /*replace*/)
+}
+
+func (s *Server) updatePropertyAccessList(w http.ResponseWriter, r *http.Request, replace bool) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, false /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	params := &dbgen.UpsertPropertyAccessListParams{Policy: dbgen.BotPolicyBlock}
+	if replace {
+		request := &apiPropertyAccessList{}
+		if reqErr := decodeRequestBody(r, request); reqErr != nil {
+			slog.WarnContext(ctx, "Failed to deserialize access list request", "errors", len(reqErr.Errors))
+			s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+			return
+		}
+
+		var code common.StatusCode
+		if params, code = validateAccessListInput(request); !code.Success() {
+			slog.WarnContext(ctx, "Invalid access list request", "code", code.String())
+			s.sendAPIErrorResponse(ctx, code, r, w)
+			return
+		}
+	}
+
+	oldList, err := s.propertyAccessList(ctx, property)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	list, auditEvent, err := s.BusinessDB.Impl().UpdatePropertyAccessList(ctx, user, property, oldList, params)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	s.BusinessDB.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourceAPI)
+
+	s.sendAPISuccessResponse(ctx, accessListToOutput(list), w)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestIsAccessDenied(t *testing.T) {
+	t.Parallel()
+
+	list := &dbgen.PropertyAccessList{
+		IpAllowlist: []string{"203.0.113.0/24", "2001:db8::/32"},
+		IpDenylist:  []string{"203.0.113.7/32"},
+		AsnDenylist: []int64{64496},
+	}
+	ranges := newIPRangesCache(10)
+
+	for i, tc := range []struct {
+		addr   string
+		asn    int64
+		denied bool
+	}{
+		{"203.0.113.1", 0, false},
+		{"203.0.113.7", 0, true},
+		{"203.0.113.1", 64496, true},
+		{"203.0.113.1", 64497, false},
+		{"198.51.100.1", 0, true},
+		{"2001:db8::1", 0, false},
+		{"::ffff:203.0.113.1", 0, false},
+	} {
+		denied, err := ranges.isAccessDenied(t.Context(), list, netip.MustParseAddr(tc.addr), tc.asn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if denied != tc.denied {
+			t.Errorf("Unexpected result for test case %v (%v): %v", i, tc.addr, denied)
+		}
+	}
+}
+
+func TestIsAccessDeniedWithoutAllowlist(t *testing.T) {
+	t.Parallel()
+
+	list := &dbgen.PropertyAccessList{
+		IpDenylist: []string{"198.51.100.0/24"},
+	}
+	ranges := newIPRangesCache(10)
+
+	if denied, _ := ranges.isAccessDenied(t.Context(), list, netip.MustParseAddr("203.0.113.1"), 0); denied {
+		t.Error("Address outside of denylist was denied")
+	}
+
+	if denied, _ := ranges.isAccessDenied(t.Context(), list, netip.MustParseAddr("198.51.100.1"), 0); !denied {
+		t.Error("Address in denylist was not denied")
+	}
+}
+
+func TestRequestASN(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+	header := "X-Asn"
+	s.asnHeader.Store(&header)
+
+	for _, tc := range []struct {
+		value    string
+		expected int64
+	}{
+		{"64496", 64496},
+		{"AS64496", 64496},
+		{" as64496 ", 64496},
+		{"", 0},
+		{"foo", 0},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(header, tc.value)
+
+		if asn := s.requestASN(req); asn != tc.expected {
+			t.Errorf("Unexpected ASN for %q: %v", tc.value, asn)
+		}
+	}
+}
+
+func TestIPRangesCacheUpdate(t *testing.T) {
+	t.Parallel()
+
+	ranges := newIPRangesCache(10)
+	list := &dbgen.PropertyAccessList{PropertyID: 1, IpDenylist: []string{"198.51.100.0/24"}}
+	addr := netip.MustParseAddr("198.51.100.1")
+
+	if denied, _ := ranges.isAccessDenied(t.Context(), list, addr, 0); !denied {
+		t.Error("Address in denylist was not denied")
+	}
+
+	// same property with the updated list (as if it was reloaded into business cache)
+	list = &dbgen.PropertyAccessList{PropertyID: 1, IpDenylist: []string{"203.0.113.0/24"}}
+
+	if denied, _ := ranges.isAccessDenied(t.Context(), list, addr, 0); denied {
+		t.Error("Stale parsed denylist was used")
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
+)
+
+const (
+	maxAPIKeysBatchSize              = 100
+	maxAPIKeysPageSize               = 100
+	minAPIKeyNameLength              = 3
+	maxAPIKeyNameLength              = 128
+	apiKeyNameIndexPlaceholder       = "{index}"
+	apiKeyExpirationNotificationDays = 14
+)
+
+// renders names of the batch from the template, placeholder has to be present exactly once
+func apiKeyNamesFromTemplate(ctx context.Context, template string, startIndex, count int) ([]string, bool) {
+	if strings.Count(template, apiKeyNameIndexPlaceholder) != 1 {
+		slog.WarnContext(ctx, "API key name template has to contain index placeholder once", "template", template)
+		return nil, false
+	}
+
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		name := strings.Replace(template, apiKeyNameIndexPlaceholder, strconv.Itoa(startIndex+i), 1)
+		if (len(name) < minAPIKeyNameLength) || (len(name) > maxAPIKeyNameLength) {
+			slog.WarnContext(ctx, "API key name length is invalid", "length", len(name))
+			return nil, false
+		}
+
+		if !db.CheckAPIKeyNameValid(ctx, name) {
+			return nil, false
+		}
+
+		names = append(names, name)
+	}
+
+	return names, true
+}
+
+func parseAPIKeyScope(scope string) (dbgen.ApiKeyScope, bool) {
+	switch scope {
+	case string(dbgen.ApiKeyScopePuzzle):
+		return dbgen.ApiKeyScopePuzzle, true
+	case string(dbgen.ApiKeyScopePortal):
+		return dbgen.ApiKeyScopePortal, true
+	default:
+		return "", false
+	}
+}
+
+// same set of expiration periods as available in the portal
+func isAPIKeyExpirationDaysValid(days int) bool {
+	switch days {
+	case 1, 30, 90, 180, 365:
+		return true
+	default:
+		return false
+	}
+}
+
+// mirrors initial API key limits logic of the portal
+func apiKeyRateLimits(scope dbgen.ApiKeyScope, limits *db.PlanLimits) (float64, int32) {
+	const minAPIKeyRequestsBurst = 20
+
+	requestsPerSecond := 1.0
+	if (limits != nil) && (limits.APIRequestsPerSecond > 0) {
+		if scope == dbgen.ApiKeyScopePuzzle {
+			requestsPerSecond = limits.APIRequestsPerSecond
+		} else {
+			requestsPerSecond = max(1.0, math.Ceil(math.Log(limits.APIRequestsPerSecond)))
+		}
+	}
+
+	return requestsPerSecond, max(minAPIKeyRequestsBurst, int32(requestsPerSecond*5))
+}
+
+// NOTE: ReferenceID logic should stay the same forever for correct deduplication in DB
+func apiKeysBatchExpirationReference(firstKeyID int32) string {
+	return fmt.Sprintf("apikeys/batch/%v/expiration", firstKeyID)
+}
+
+// NOTE: ReferenceID logic should stay the same forever for correct deduplication in DB
+func apiKeysBatchExpiredReference(firstKeyID int32) string {
+	return fmt.Sprintf("apikeys/batch/%v/expired", firstKeyID)
+}
+
+func apiKeysBatchContext(template string, count int) *email.APIKeyBatchContext {
+	return &email.APIKeyBatchContext{
+		APIKeyExpirationContext: email.APIKeyExpirationContext{
+			APIKeyContext: email.APIKeyContext{
+				APIKeyName:         template,
+				APIKeySettingsPath: fmt.Sprintf("%s?%s=%s", common.SettingsEndpoint, common.ParamTab, common.APIKeysEndpoint),
+			},
+			ExpireDays: apiKeyExpirationNotificationDays,
+		},
+		APIKeysCount: count,
+	}
+}
+
+// one reminder (and one "expired" notification) per batch instead of one per key
+func createAPIKeysBatchNotifications(template string, keys []*dbgen.APIKey, tnow time.Time) []*common.ScheduledNotification {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// all keys in the batch share the expiration
+	first := keys[0]
+	data := apiKeysBatchContext(template, len(keys))
+	notifications := make([]*common.ScheduledNotification, 0, 2)
+
+	minNotificationDate := tnow.AddDate(0, 0, apiKeyExpirationNotificationDays)
+	if first.ExpiresAt.Valid && first.ExpiresAt.Time.After(minNotificationDate) {
+		notifications = append(notifications, &common.ScheduledNotification{
+			ReferenceID:  apiKeysBatchExpirationReference(first.ID),
+			UserID:       first.UserID.Int32,
+			Subject:      fmt.Sprintf("[%s] %d of your API keys will expire soon", common.PrivateCaptcha, len(keys)),
+			Data:         data,
+			DateTime:     first.ExpiresAt.Time.AddDate(0, 0, -apiKeyExpirationNotificationDays),
+			TemplateHash: email.APIKeyBatchExpirationTemplate.Hash(),
+			Persistent:   false,
+			Condition:    common.NotificationWithSubscription,
+		})
+	}
+
+	notifications = append(notifications, &common.ScheduledNotification{
+		ReferenceID:  apiKeysBatchExpiredReference(first.ID),
+		UserID:       first.UserID.Int32,
+		Subject:      fmt.Sprintf("[%s] %d of your API keys have expired", common.PrivateCaptcha, len(keys)),
+		Data:         data,
+		DateTime:     first.ExpiresAt.Time,
+		TemplateHash: email.APIKeyBatchExpiredTemplate.Hash(),
+		Persistent:   false,
+		Condition:    common.NotificationWithSubscription,
+	})
+
+	return notifications
+}
+
+// filterAPIKeys returns keys visible to the requester (org-scoped requester only sees keys of its org) in (created_at, id) order
+func filterAPIKeys(keys []*dbgen.APIKey, orgID *int32) []*dbgen.APIKey {
+	result := make([]*dbgen.APIKey, 0, len(keys))
+	for _, key := range keys {
+		if (orgID == nil) || (key.OrgID.Valid && (key.OrgID.Int32 == *orgID)) {
+			result = append(result, key)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b *dbgen.APIKey) int {
+		if c := a.CreatedAt.Time.Compare(b.CreatedAt.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return result
+}
+
+func apiKeyCursor(key *dbgen.APIKey) *pagination.Cursor {
+	return pagination.NewCursor(key.CreatedAt.Time, int64(key.ID))
+}
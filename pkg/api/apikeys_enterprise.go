@@ -0,0 +1,267 @@
+//go:build enterprise
+
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *Server) createAPIKeysBatchNotifications(ctx context.Context, template string, keys []*dbgen.APIKey) error {
+	var errs []error
+
+	for _, n := range createAPIKeysBatchNotifications(template, keys, time.Now().UTC()) {
+		if _, err := s.BusinessDB.Impl().CreateUserNotification(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Server) apiKeyToAPIKeyOutput(key *dbgen.APIKey) *apiAPIKeyOutput {
+	output := &apiAPIKeyOutput{
+		ID:           s.IDHasher.Encrypt(int(key.ID)),
+		Name:         key.Name,
+		Secret:       db.UUIDToSecret(key.ExternalID),
+		Scope:        string(key.Scope),
+		Readonly:     key.Readonly,
+		CreatedAt:    key.CreatedAt.Time.UTC().Format(time.RFC3339),
+		ExpiresAt:    key.ExpiresAt.Time.UTC().Format(time.RFC3339),
+		AllowedCIDRs: key.AllowedCidrs,
+	}
+
+	if key.OrgID.Valid {
+		output.OrgID = s.IDHasher.Encrypt(int(key.OrgID.Int32))
+	}
+
+	return output
+}
+
+// resolves organization the new keys will be scoped to. Keys created with an org-scoped key are always scoped to the same org
+func (s *Server) apiKeysBatchOrg(ctx context.Context, user *dbgen.User, apiKey *dbgen.APIKey, value string) (pgtype.Int4, error) {
+	if len(value) == 0 {
+		return apiKey.OrgID, nil
+	}
+
+	orgID, err := s.IDHasher.Decrypt(value)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to decrypt org ID", "value", value, common.ErrAttr(err))
+		return db.InvalidInt, db.ErrInvalidInput
+	}
+
+	if apiKey.OrgID.Valid && (apiKey.OrgID.Int32 != int32(orgID)) {
+		slog.WarnContext(ctx, "Requested organization is not allowed for this requester", "allowedOrgID", apiKey.OrgID.Int32, "requestedOrgID", orgID)
+		return db.InvalidInt, db.ErrPermissions
+	}
+
+	if err := s.checkOrgIPAllowlist(ctx, int32(orgID)); err != nil {
+		return db.InvalidInt, err
+	}
+
+	org, err := s.BusinessDB.Impl().RetrieveUserOrganization(ctx, user, int32(orgID))
+	if err != nil {
+		return db.InvalidInt, err
+	}
+
+	return db.Int(org.ID), nil
+}
+
+func (s *Server) postAPIKeysBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return
+	}
+
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	request := &apiAPIKeysBatchInput{}
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize API keys batch request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+		return
+	}
+
+	if (request.Count <= 0) || (request.Count > maxAPIKeysBatchSize) {
+		slog.WarnContext(ctx, "Invalid API keys batch size", "count", request.Count, "max", maxAPIKeysBatchSize)
+		s.sendAPIErrorResponse(ctx, common.StatusAPIKeysCountError, r, w)
+		return
+	}
+
+	scope, ok := parseAPIKeyScope(request.Scope)
+	if !ok {
+		slog.WarnContext(ctx, "Invalid API key scope", "scope", request.Scope)
+		s.sendAPIErrorResponse(ctx, common.StatusAPIKeyScopeError, r, w)
+		return
+	}
+
+	if !isAPIKeyExpirationDaysValid(request.ExpirationDays) {
+		slog.WarnContext(ctx, "Invalid API key expiration", "days", request.ExpirationDays)
+		s.sendAPIErrorResponse(ctx, common.StatusAPIKeyExpirationError, r, w)
+		return
+	}
+
+	allowedCIDRs, err := db.NormalizeIPList(request.AllowedCIDRs)
+	if err != nil {
+		slog.WarnContext(ctx, "Invalid API key allowed IP ranges", common.ErrAttr(err))
+		s.sendAPIErrorResponse(ctx, common.StatusAPIKeyAllowedIPsError, r, w)
+		return
+	}
+
+	template := strings.TrimSpace(request.NameTemplate)
+	names, ok := apiKeyNamesFromTemplate(ctx, template, request.StartIndex, request.Count)
+	if !ok {
+		s.sendAPIErrorResponse(ctx, common.StatusAPIKeyNameTemplateError, r, w)
+		return
+	}
+
+	existingKeys, err := s.BusinessDB.Impl().RetrieveUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user API keys", "userID", user.ID, common.ErrAttr(err))
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	existingNames := make(map[string]struct{}, len(existingKeys))
+	for _, key := range existingKeys {
+		existingNames[key.Name] = struct{}{}
+	}
+
+	for _, name := range names {
+		if _, ok := existingNames[name]; ok {
+			slog.WarnContext(ctx, "API key name duplicate found", "name", name)
+			s.sendAPIErrorResponse(ctx, common.StatusAPIKeyNameDuplicateError, r, w)
+			return
+		}
+	}
+
+	orgID, err := s.apiKeysBatchOrg(ctx, user, apiKey, request.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	var limits *db.PlanLimits
+	if subscr, err := s.BusinessDB.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32); err == nil {
+		if limits, err = s.SubscriptionLimits.Limits(ctx, subscr); err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve subscription limits", "userID", user.ID, common.ErrAttr(err))
+		}
+	}
+
+	// current logic is that initial values will be set per plan and adjusted manually in DB if requested by customer
+	requestsPerSecond, burst := apiKeyRateLimits(scope, limits)
+	tnow := time.Now().UTC()
+	period := time.Duration(request.ExpirationDays) * 24 * time.Hour
+	readOnly := request.Readonly && (scope == dbgen.ApiKeyScopePortal)
+
+	keys := make([]*dbgen.APIKey, 0, len(names))
+	auditEvents, err := s.BusinessDB.WithTx(ctx, func(impl *db.BusinessStoreImpl) ([]*common.AuditLogEvent, error) {
+		events := make([]*common.AuditLogEvent, 0, len(names))
+		for _, name := range names {
+			key, auditEvent, err := impl.CreateAPIKey(ctx, user, &dbgen.CreateAPIKeyParams{
+				Name:              name,
+				ExpiresAt:         db.Timestampz(tnow.Add(period)),
+				RequestsPerSecond: requestsPerSecond,
+				RequestsBurst:     burst,
+				Period:            period,
+				Scope:             scope,
+				Readonly:          readOnly,
+				OrgID:             orgID,
+				AllowedCidrs:      allowedCIDRs,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+			events = append(events, auditEvent)
+		}
+		return events, nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create API keys batch", "count", len(names), common.ErrAttr(err))
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	s.BusinessDB.AuditLog().RecordEvents(ctx, auditEvents, common.AuditLogSourceAPI)
+
+	go common.RunAdHocFunc(common.CopyTraceID(ctx, context.Background()), func(bctx context.Context) error {
+		return s.createAPIKeysBatchNotifications(bctx, template, keys)
+	})
+
+	outputs := make([]*apiAPIKeyOutput, 0, len(keys))
+	for _, key := range keys {
+		outputs = append(outputs, s.apiKeyToAPIKeyOutput(key))
+	}
+
+	s.sendAPISuccessResponse(ctx, outputs, w)
+}
+
+func (s *Server) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	request, err := pagination.ParseRequest(ctx, r, maxAPIKeysPageSize, s.IDHasher)
+	if err != nil {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return
+	}
+
+	keys, err := s.BusinessDB.Impl().RetrieveUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user API keys", "userID", user.ID, common.ErrAttr(err))
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	var orgID *int32
+	if apiKey.OrgID.Valid {
+		orgID = &apiKey.OrgID.Int32
+	}
+
+	keys = filterAPIKeys(keys, orgID)
+	page, hasMore, next := pagination.Slice(keys, request, apiKeyCursor)
+
+	outputs := make([]*apiAPIKeyOutput, 0, len(page))
+	for _, key := range page {
+		output := s.apiKeyToAPIKeyOutput(key)
+		output.Secret = ""
+		outputs = append(outputs, output)
+	}
+
+	response := &APIResponse{
+		Data:       outputs,
+		Pagination: request.NewResponse(hasMore, next, s.IDHasher),
+	}
+
+	if request.IncludeTotal {
+		total := int64(len(keys))
+		response.Pagination.TotalEstimate = &total
+	}
+
+	s.sendAPISuccessResponseEx(ctx, response, w, common.NoCacheHeaders)
+}
@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestAPIKeyNamesFromTemplate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	testCases := []struct {
+		template string
+		start    int
+		count    int
+		ok       bool
+		last     string
+	}{
+		{"ci-{index}", 1, 3, true, "ci-3"},
+		{"service {index} (prod)", 0, 2, true, "service 1 (prod)"},
+		{"ci-", 1, 3, false, ""},
+		{"ci-{index}-{index}", 1, 3, false, ""},
+		{"{index}", 1, 3, false, ""},
+		{"ci/{index}", 1, 3, false, ""},
+	}
+
+	for _, tc := range testCases {
+		names, ok := apiKeyNamesFromTemplate(ctx, tc.template, tc.start, tc.count)
+		if ok != tc.ok {
+			t.Errorf("Unexpected result for template %q: %v", tc.template, ok)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if len(names) != tc.count {
+			t.Errorf("Unexpected names count for template %q: %v", tc.template, len(names))
+		} else if actual := names[len(names)-1]; actual != tc.last {
+			t.Errorf("Unexpected last name for template %q: %v (expected %v)", tc.template, actual, tc.last)
+		}
+	}
+}
+
+func TestAPIKeySourceAllowed(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		cidrs   []string
+		addr    string
+		allowed bool
+	}{
+		{[]string{}, "198.51.100.1", true},
+		{[]string{"203.0.113.0/24"}, "203.0.113.42", true},
+		{[]string{"203.0.113.0/24"}, "198.51.100.1", false},
+		{[]string{"198.51.100.1/32", "2001:db8::/32"}, "2001:db8::1", true},
+		{[]string{"203.0.113.0/24"}, "::ffff:203.0.113.42", true},
+		{[]string{"not an ip"}, "203.0.113.42", false},
+	}
+
+	for i, tc := range testCases {
+		key := &dbgen.APIKey{ID: int32(i), AllowedCidrs: tc.cidrs}
+		if actual := isAPIKeySourceAllowed(context.TODO(), key, netip.MustParseAddr(tc.addr)); actual != tc.allowed {
+			t.Errorf("Unexpected result for %v in %v: %v", tc.addr, tc.cidrs, actual)
+		}
+	}
+
+	if isAPIKeySourceAllowed(context.TODO(), &dbgen.APIKey{AllowedCidrs: []string{"0.0.0.0/0"}}, netip.Addr{}) {
+		t.Errorf("Invalid address is allowed")
+	}
+}
+
+func TestCachedAPIKeyUsage(t *testing.T) {
+	t.Parallel()
+
+	memCache, err := db.NewMemoryCache[db.CacheKey, any]("test", 100, &struct{}{}, time.Minute, 3*time.Minute, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no DB needed as key is served from cache
+	testStore := db.NewBusinessEx(nil, memCache)
+	testStore.UpdateAPIKeyUsage(true)
+
+	key := &dbgen.APIKey{
+		ID:         1,
+		ExternalID: pgtype.UUID{Bytes: [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, Valid: true},
+		UserID:     db.Int(1),
+		Enabled:    pgtype.Bool{Bool: true, Valid: true},
+		ExpiresAt:  db.Timestampz(time.Now().UTC().Add(time.Hour)),
+		Scope:      dbgen.ApiKeyScopePuzzle,
+	}
+	secret := db.UUIDToSecret(key.ExternalID)
+	if err := memCache.Set(t.Context(), db.APIKeyCacheKey(secret), key); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := NewAuthMiddleware(testStore, NewUserLimiter(testStore), nil /*plan service*/)
+	handler := auth.APIKey(headerAPIKey, dbgen.ApiKeyScopePuzzle)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(common.HeaderAPIKey, secret)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Unexpected status code: %v", w.Code)
+		}
+	}
+
+	if usage := testStore.PendingAPIKeyUsage(key.ID); usage != 2 {
+		t.Errorf("Unexpected API key usage: %v", usage)
+	}
+}
+
+func TestFilterAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC()
+	keys := []*dbgen.APIKey{
+		{ID: 3, CreatedAt: db.Timestampz(tnow), OrgID: db.Int(1)},
+		{ID: 2, CreatedAt: db.Timestampz(tnow)},
+		{ID: 1, CreatedAt: db.Timestampz(tnow.Add(time.Minute)), OrgID: db.Int(2)},
+		{ID: 4, CreatedAt: db.Timestampz(tnow.Add(-time.Minute)), OrgID: db.Int(1)},
+	}
+
+	all := filterAPIKeys(keys, nil)
+	if (len(all) != 4) || (all[0].ID != 4) || (all[1].ID != 2) || (all[2].ID != 3) || (all[3].ID != 1) {
+		t.Errorf("Unexpected order of keys")
+	}
+
+	orgID := int32(1)
+	scoped := filterAPIKeys(keys, &orgID)
+	if (len(scoped) != 2) || (scoped[0].ID != 4) || (scoped[1].ID != 3) {
+		t.Errorf("Unexpected org-scoped keys")
+	}
+}
+
+func TestAPIKeysBatchNotifications(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC()
+	newKeys := func(days int) []*dbgen.APIKey {
+		keys := make([]*dbgen.APIKey, 0, 3)
+		for i := 0; i < 3; i++ {
+			keys = append(keys, &dbgen.APIKey{
+				ID:        int32(10 + i),
+				UserID:    db.Int(1),
+				ExpiresAt: db.Timestampz(tnow.AddDate(0, 0, days)),
+			})
+		}
+		return keys
+	}
+
+	notifications := createAPIKeysBatchNotifications("ci-{index}", newKeys(90), tnow)
+	if len(notifications) != 2 {
+		t.Fatalf("Unexpected notifications count: %v", len(notifications))
+	}
+
+	if notifications[0].TemplateHash != email.APIKeyBatchExpirationTemplate.Hash() {
+		t.Error("First notification is not an expiration reminder")
+	}
+
+	if ref := notifications[1].ReferenceID; ref != apiKeysBatchExpiredReference(10) {
+		t.Errorf("Unexpected reference ID: %v", ref)
+	}
+
+	if data, ok := notifications[1].Data.(*email.APIKeyBatchContext); !ok || (data.APIKeysCount != 3) {
+		t.Errorf("Unexpected notification data: %v", notifications[1].Data)
+	}
+
+	// reminder would be in the past for short-lived keys
+	if notifications := createAPIKeysBatchNotifications("ci-{index}", newKeys(1), tnow); len(notifications) != 1 {
+		t.Errorf("Unexpected notifications count for short expiration: %v", len(notifications))
+	}
+}
+
+func TestAPICreateAPIKeysBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, _, apiKey, err := setupAPISuite(t.Context(), t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := &apiAPIKeysBatchInput{
+		NameTemplate:   "ci-{index}",
+		Count:          5,
+		StartIndex:     1,
+		Scope:          string(dbgen.ApiKeyScopePuzzle),
+		ExpirationDays: 90,
+	}
+
+	endpoint := "/" + common.APIKeysEndpoint + "/" + common.BatchEndpoint
+
+	keys, meta, err := requestResponseAPISuite[[]*apiAPIKeyOutput](ctx, input, http.MethodPost, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !meta.Code.Success() {
+		t.Fatalf("Unexpected status code: %v", meta.Description)
+	}
+
+	if len(keys) != input.Count {
+		t.Fatalf("Unexpected keys count: %v", len(keys))
+	}
+
+	for _, key := range keys {
+		if len(key.Secret) == 0 {
+			t.Errorf("Secret of key %v is empty", key.Name)
+		}
+	}
+
+	if _, err := s.BusinessDB.Impl().FindUserAPIKeyByName(ctx, user, "ci-5"); err != nil {
+		t.Errorf("Failed to find created key: %v", err)
+	}
+
+	// same names cannot be created twice
+	_, meta, err = requestResponseAPISuite[[]*apiAPIKeyOutput](ctx, input, http.MethodPost, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta.Code != common.StatusAPIKeyNameDuplicateError {
+		t.Errorf("Unexpected status code: %v", meta.Code)
+	}
+}
+
+func TestAPICreateAPIKeysBatchOrgScope(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, org, apiKey, err := setupAPISuiteEx(t.Context(), t.Name(), dbgen.ApiKeyScopePortal, false /*read-only*/, true /*org scope*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherOrg, _, err := s.BusinessDB.Impl().CreateNewOrganization(ctx, t.Name()+"-other", user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := &apiAPIKeysBatchInput{
+		NameTemplate:   "svc-{index}",
+		Count:          2,
+		Scope:          string(dbgen.ApiKeyScopePortal),
+		ExpirationDays: 30,
+		OrgID:          s.IDHasher.Encrypt(int(otherOrg.ID)),
+	}
+
+	endpoint := "/" + common.APIKeysEndpoint + "/" + common.BatchEndpoint
+
+	resp, err := apiRequestSuite(ctx, input, http.MethodPost, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected status code: %v", resp.StatusCode)
+	}
+
+	// without explicit org, keys inherit scope of the requester
+	input.OrgID = ""
+	keys, meta, err := requestResponseAPISuite[[]*apiAPIKeyOutput](ctx, input, http.MethodPost, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !meta.Code.Success() {
+		t.Fatalf("Unexpected status code: %v", meta.Description)
+	}
+
+	expectedOrgID := s.IDHasher.Encrypt(int(org.ID))
+	for _, key := range keys {
+		if key.OrgID != expectedOrgID {
+			t.Errorf("Unexpected org of key %v: %v", key.Name, key.OrgID)
+		}
+	}
+}
+
+func TestAPIGetAPIKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	_, _, apiKey, err := setupAPISuite(t.Context(), t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := &apiAPIKeysBatchInput{
+		NameTemplate:   "list-{index}",
+		Count:          4,
+		Scope:          string(dbgen.ApiKeyScopePuzzle),
+		ExpirationDays: 30,
+	}
+
+	if _, meta, err := requestResponseAPISuite[[]*apiAPIKeyOutput](ctx, input, http.MethodPost, "/"+common.APIKeysEndpoint+"/"+common.BatchEndpoint, apiKey); err != nil {
+		t.Fatal(err)
+	} else if !meta.Code.Success() {
+		t.Fatalf("Unexpected status code: %v", meta.Description)
+	}
+
+	// portal key of the suite and the batch
+	const expectedCount = 5
+	ids := make(map[string]struct{})
+	cursor := ""
+
+	endpoint := fmt.Sprintf("/%s?limit=2", common.APIKeysEndpoint)
+
+	for i := 0; i < expectedCount; i++ {
+		resp, err := apiRequestSuite(ctx, nil, http.MethodGet, endpoint+cursor, apiKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var response struct {
+			APIResponse
+			Data []*apiAPIKeyOutput `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&response)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.Pagination == nil {
+			t.Fatal("Pagination is missing")
+		}
+
+		for _, key := range response.Data {
+			if len(key.Secret) > 0 {
+				t.Errorf("Secret of key %v is returned", key.Name)
+			}
+
+			if _, ok := ids[key.ID]; ok {
+				t.Fatalf("Key %v was returned twice", key.Name)
+			}
+			ids[key.ID] = struct{}{}
+		}
+
+		if !response.Pagination.HasMore {
+			break
+		}
+
+		cursor = "&cursor=" + response.Pagination.NextCursor
+	}
+
+	if len(ids) != expectedCount {
+		t.Errorf("Unexpected keys count: %v", len(ids))
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/attestation"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	// attestation is meant to be passed to a downstream service right after verification
+	attestationTokenTTL = 5 * time.Minute
+	// new keys are used for signing only after cached JWKS (with previous keys) expire
+	attestationKeysCacheTTL = 1 * time.Hour
+)
+
+var (
+	attestationKeysCacheHeaders = map[string][]string{
+		common.HeaderCacheControl: []string{"public, max-age=" + strconv.Itoa(int(attestationKeysCacheTTL.Seconds()))},
+	}
+)
+
+// attestationSigner holds keys (base64 of cmd/keyspack output) for signing attestation tokens
+type attestationSigner struct {
+	configItem common.ConfigItem
+	signer     atomic.Pointer[attestation.Signer]
+}
+
+func NewAttestationSigner(configItem common.ConfigItem) *attestationSigner {
+	return &attestationSigner{
+		configItem: configItem,
+	}
+}
+
+func (as *attestationSigner) Update() error {
+	value := strings.TrimSpace(as.configItem.Value())
+	if len(value) == 0 {
+		as.signer.Store(nil)
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+
+	keys, err := attestation.ParseKeys(data)
+	if err != nil {
+		return err
+	}
+
+	signer, err := attestation.NewSigner(keys, attestationKeysCacheTTL, as.signer.Load(), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	as.signer.Store(signer)
+
+	return nil
+}
+
+func (as *attestationSigner) Signer() *attestation.Signer {
+	return as.signer.Load()
+}
+
+// attestationToken returns token for the successful verification if the client asked for it
+func (v *Verifier) attestationToken(ctx context.Context, r *http.Request, result *puzzle.VerifyResult, crossProperty bool) string {
+	if !result.Success() {
+		return ""
+	}
+
+	if requested, _ := strconv.ParseBool(r.Header.Get(common.HeaderAttestation)); !requested {
+		return ""
+	}
+
+	signer := v.Attestation.Signer()
+	if signer == nil {
+		slog.WarnContext(ctx, "Attestation was requested, but signing keys are not configured")
+		return ""
+	}
+
+	claims := &attestation.Claims{
+		Subject:         result.SiteKey,
+		Origin:          result.Domain,
+		PuzzleTimestamp: result.CreatedAt.Unix(),
+		CrossProperty:   crossProperty,
+		Claims:          result.Claims,
+	}
+
+	if result.VisitorClass == common.VisitorClassBypass {
+		claims.VisitorClass = result.VisitorClass.String()
+	}
+
+	token, err := signer.Sign(claims, attestationTokenTTL, time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign attestation token", common.ErrAttr(err))
+		return ""
+	}
+
+	return token
+}
+
+// attestationKeys publishes public keys (JWKS) so that downstream services can validate attestation tokens offline.
+// New key is used for signing only after it was published for as long as keys are cached.
+func (s *Server) attestationKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	signer := s.Verifier.Attestation.Signer()
+	if signer == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	common.SendJSONResponse(ctx, w, signer.JWKS(), attestationKeysCacheHeaders)
+}
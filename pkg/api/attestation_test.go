@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/attestation"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func TestAttestationToken(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))
+	_ = binary.Write(&buf, binary.LittleEndian, uint8(32))
+	buf.Write(bytes.Repeat([]byte{7}, 32))
+
+	signer := NewAttestationSigner(config.NewStaticValue(common.AttestationKeysKey, base64.StdEncoding.EncodeToString(buf.Bytes())))
+	if err := signer.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := &Verifier{Attestation: signer}
+	result := &puzzle.VerifyResult{
+		SiteKey:   "sitekey",
+		Domain:    "example.com",
+		CreatedAt: time.Now().UTC(),
+		Error:     puzzle.VerifyNoError,
+	}
+
+	req := httptest.NewRequest("POST", "/"+common.VerifyEndpoint, nil)
+	if token := verifier.attestationToken(t.Context(), req, result, false); len(token) > 0 {
+		t.Error("Attestation was issued without request")
+	}
+
+	req.Header.Set(common.HeaderAttestation, "true")
+	token := verifier.attestationToken(t.Context(), req, result, false)
+	if len(token) == 0 {
+		t.Fatal("Attestation was not issued")
+	}
+
+	validator, err := attestation.NewValidator(signer.Signer().JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := validator.Validate(token, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (claims.Subject != result.SiteKey) || (claims.Origin != result.Domain) || (len(claims.VisitorClass) > 0) {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	result.VisitorClass = common.VisitorClassBypass
+	if claims, err := validator.Validate(verifier.attestationToken(t.Context(), req, result, false), time.Now()); (err != nil) || (claims.VisitorClass != "bypass") {
+		t.Errorf("Unexpected bypass claims: %+v (%v)", claims, err)
+	}
+
+	result.Error = puzzle.InvalidSolutionError
+	if token := verifier.attestationToken(t.Context(), req, result, false); len(token) > 0 {
+		t.Error("Attestation was issued for failed verification")
+	}
+}
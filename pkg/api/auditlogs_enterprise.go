@@ -0,0 +1,91 @@
+//go:build enterprise
+
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
+)
+
+const (
+	maxAuditLogsPageSize = 100
+)
+
+func (s *Server) auditLogsToAPIAuditLogs(r *http.Request, logs []*dbgen.GetUserAuditLogsBeforeRow) []*apiAuditLogOutput {
+	ctx := r.Context()
+
+	result := make([]*apiAuditLogOutput, 0, len(logs))
+	for _, userLog := range logs {
+		log := &userLog.AuditLog
+		event := db.NewStoredEvent(log)
+		if event == nil {
+			slog.WarnContext(ctx, "Skipping audit log of unknown event type", "auditLogID", log.ID, "table", log.EntityTable)
+			continue
+		}
+
+		result = append(result, &apiAuditLogOutput{
+			Event:    event,
+			ID:       s.IDHasher.Encrypt64(log.ID),
+			UserID:   s.IDHasher.Encrypt(int(log.UserID.Int32)),
+			EntityID: s.IDHasher.Encrypt64(log.EntityID.Int64),
+			Table:    log.EntityTable,
+		})
+	}
+
+	return result
+}
+
+// audit logs are returned newest first, so the cursor points to the oldest log of the page
+func (s *Server) getUserAuditLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	// audit logs belong to the user and are not filtered by organization
+	if apiKey.OrgID.Valid {
+		slog.WarnContext(ctx, "API key is scoped to the organization", "orgID", apiKey.OrgID.Int32)
+		s.sendHTTPErrorResponse(db.ErrPermissions, r, w)
+		return
+	}
+
+	request, err := pagination.ParseRequest(ctx, r, maxAuditLogsPageSize, s.IDHasher)
+	if err != nil {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return
+	}
+
+	var beforeCreatedAt time.Time
+	var beforeID int64
+	if request.Cursor != nil {
+		beforeCreatedAt, beforeID = request.Cursor.CreatedAt, request.Cursor.ID
+	}
+
+	logs, hasMore, err := s.BusinessDB.Impl().RetrieveUserAuditLogsBefore(ctx, user, user.CreatedAt.Time, beforeCreatedAt, beforeID, request.Offset(), request.Limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user audit logs", common.ErrAttr(err))
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	var next *pagination.Cursor
+	if len(logs) > 0 {
+		last := logs[len(logs)-1]
+		next = pagination.NewCursor(last.AuditLog.CreatedAt.Time, last.AuditLog.ID)
+	}
+
+	response := &APIResponse{
+		Data:       s.auditLogsToAPIAuditLogs(r, logs),
+		Pagination: request.NewResponse(hasMore, next, s.IDHasher),
+	}
+
+	s.sendAPISuccessResponseEx(ctx, response, w, common.NoCacheHeaders)
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	bypassAuditWindow    = 1 * time.Minute
+	maxBypassAuditTokens = 10_000
+)
+
+var (
+	errBypassSignature = errors.New("bypass token is not signed")
+)
+
+// bypassAuditThrottle allows one audit event per token per window, because bypass tokens are meant for automated
+// test suites that can use them thousands of times (uses are still counted in DB and verify logs)
+type bypassAuditThrottle struct {
+	lock     sync.Mutex
+	window   time.Duration
+	reported map[int32]time.Time
+}
+
+func newBypassAuditThrottle(window time.Duration) *bypassAuditThrottle {
+	return &bypassAuditThrottle{
+		window:   window,
+		reported: make(map[int32]time.Time),
+	}
+}
+
+func (t *bypassAuditThrottle) allow(tokenID int32, tnow time.Time) bool {
+	if t == nil {
+		return true
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if last, ok := t.reported[tokenID]; ok && (tnow.Sub(last) < t.window) {
+		return false
+	}
+
+	if len(t.reported) >= maxBypassAuditTokens {
+		for id, last := range t.reported {
+			if tnow.Sub(last) >= t.window {
+				delete(t.reported, id)
+			}
+		}
+	}
+
+	t.reported[tokenID] = tnow
+
+	return true
+}
+
+// bypassPayload is a property bypass token, submitted by trusted automation instead of the puzzle solution
+type bypassPayload struct {
+	// only carries property ID so that token can be checked against expected sitekey like a regular puzzle
+	puzzle     puzzle.Puzzle
+	secretHash []byte
+}
+
+var _ puzzle.SolutionPayload = (*bypassPayload)(nil)
+
+func (bp *bypassPayload) Puzzle() puzzle.Puzzle { return bp.puzzle }
+func (bp *bypassPayload) NeedsExtraSalt() bool  { return false }
+
+// bypass tokens are only accepted by Verifier.Verify() so any other code path has to fail
+func (bp *bypassPayload) VerifySolutions(ctx context.Context) (*puzzle.Metadata, puzzle.VerifyError) {
+	return nil, puzzle.InvalidSolutionError
+}
+
+func (bp *bypassPayload) VerifySignature(ctx context.Context, salt *puzzle.Salt, extraSalt []byte) error {
+	return errBypassSignature
+}
+
+// ParseVerifyPayload parses payload of server-side verification, where bypass and handoff tokens are allowed in
+// addition to solutions
+func (v *Verifier) ParseVerifyPayload(ctx context.Context, data []byte) (puzzle.SolutionPayload, error) {
+	if bytes.HasPrefix(data, []byte(db.BypassTokenPrefix)) {
+		propertyID, secretHash, err := db.ParseBypassToken(string(data))
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to parse bypass token", "length", len(data), common.ErrAttr(err))
+			return nil, err
+		}
+
+		return &bypassPayload{
+			puzzle:     puzzle.NewComputePuzzle(0 /*puzzle ID*/, propertyID.Bytes, 0 /*difficulty*/),
+			secretHash: secretHash,
+		}, nil
+	}
+
+	// solution of the handoff session is verified as if it was submitted directly
+	if bytes.HasPrefix(data, []byte(handoffVerifyPrefix)) {
+		solution, err := v.takeHandoffSolution(ctx, string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		data = solution
+	}
+
+	return v.ParseSolutionPayload(ctx, data)
+}
+
+func (v *Verifier) verifyBypass(ctx context.Context, payload *bypassPayload, expectedOwner puzzle.OwnerIDSource, tnow time.Time) (*puzzle.VerifyResult, error) {
+	result := &puzzle.VerifyResult{VisitorClass: common.VisitorClassBypass}
+
+	propertyID := payload.puzzle.PropertyID()
+	sitekey := db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: propertyID})
+	property, err := v.Store.Impl().RetrievePropertyBySitekey(ctx, sitekey)
+	if err != nil {
+		switch err {
+		case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
+			result.SetError(puzzle.InvalidPropertyError)
+		default:
+			// unlike puzzles, bypass tokens cannot be checked (nor counted) without DB so they are never accepted
+			// in maintenance mode
+			slog.ErrorContext(ctx, "Failed to find bypass token property", "sitekey", sitekey, common.ErrAttr(err))
+			result.SetError(puzzle.VerifyErrorOther)
+		}
+		return result, nil
+	}
+
+	result.UserID = property.OrgOwnerID.Int32
+	result.OrgID = property.OrgID.Int32
+	result.PropertyID = property.ID
+	result.SiteKey = sitekey
+	result.Domain = property.Domain
+	result.CreatedAt = tnow
+
+	oerr, err := v.checkOwner(ctx, property, expectedOwner, tnow)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to fetch valid owner ID for bypass token", "propID", property.ID, common.ErrAttr(err))
+		return nil, errPuzzleOwner
+	}
+
+	if oerr != puzzle.VerifyNoError {
+		result.SetError(oerr)
+		return result, nil
+	}
+
+	token, err := v.Store.Impl().UsePropertyBypassToken(ctx, property, payload.secretHash, tnow)
+	if err != nil {
+		if err == db.ErrRecordNotFound {
+			slog.WarnContext(ctx, "Bypass token is not valid, expired or used up", "propID", property.ID)
+			result.SetError(puzzle.InvalidSolutionError)
+		} else {
+			result.SetError(puzzle.VerifyErrorOther)
+		}
+		return result, nil
+	}
+
+	slog.InfoContext(ctx, "Verified with bypass token", "propID", property.ID, "tokenID", token.ID, "uses", token.Uses,
+		"maxUses", token.MaxUses)
+
+	if v.BypassAudit.allow(token.ID, tnow) {
+		v.Store.AuditLog().RecordEvent(ctx, db.NewBypassTokenUsedAuditLogEvent(result.UserID, property, token), common.AuditLogSourceAPI)
+	}
+
+	return result, nil
+}
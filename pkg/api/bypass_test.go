@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBypassAuditThrottle(t *testing.T) {
+	t.Parallel()
+
+	throttle := newBypassAuditThrottle(time.Minute)
+	tnow := time.Now()
+
+	if !throttle.allow(1, tnow) {
+		t.Errorf("First use was not reported")
+	}
+
+	if throttle.allow(1, tnow.Add(time.Second)) {
+		t.Errorf("Second use was reported")
+	}
+
+	if !throttle.allow(2, tnow.Add(time.Second)) {
+		t.Errorf("First use of another token was not reported")
+	}
+
+	if !throttle.allow(1, tnow.Add(time.Minute)) {
+		t.Errorf("First use after the window was not reported")
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	maxImageChallengeBodySize = 4 * 1024
+)
+
+// imageChallengeKey is used to derive image challenge codes from puzzles. Rotating API salt invalidates both
+// puzzle signatures and image challenges at the same time.
+func (v *Verifier) imageChallengeKey() []byte {
+	return v.Salt.Value().Data()
+}
+
+// imageChallengeHandler renders image challenge for the puzzle that was issued to the widget (without solutions).
+// Widget shows it as a fallback for end users that cannot solve compute puzzle (e.g. very slow or restricted devices).
+func (s *Server) imageChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	property, ok := ctx.Value(common.PropertyContextKey).(*dbgen.Property)
+	if !ok || (property == nil) {
+		sitekey, _ := ctx.Value(common.SitekeyContextKey).(string)
+		var err error
+		property, err = s.BusinessDB.Impl().RetrievePropertyBySitekey(ctx, sitekey)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to retrieve property for image challenge", "sitekey", sitekey, common.ErrAttr(err))
+			switch err {
+			case db.ErrMaintenance:
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			default:
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			}
+			return
+		}
+	}
+
+	if (puzzle.WidgetFlags(property.WidgetFlags) & puzzle.WidgetFlagImageChallenge) == 0 {
+		slog.WarnContext(ctx, "Image challenge is not enabled for property", "propID", property.ID)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	payload, err := puzzle.ParsePuzzlePayload[puzzle.ComputePuzzle](ctx, bytes.TrimSpace(data))
+	if err != nil {
+		slog.Log(ctx, common.LevelTrace, "Failed to parse puzzle payload", common.ErrAttr(err))
+		http.Error(w, "Failed to parse payload", http.StatusBadRequest)
+		return
+	}
+
+	p := payload.Puzzle()
+	if propertyID := p.PropertyID(); !bytes.Equal(propertyID[:], property.ExternalID.Bytes[:]) {
+		slog.WarnContext(ctx, "Puzzle was issued for a different property", "propID", property.ID)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	// remembered and stub puzzles do not need to be solved, so there is nothing to fall back from
+	if p.IsZero() || p.IsStub() || p.IsRemembered() || ((p.WidgetFlags() & puzzle.WidgetFlagImageChallenge) == 0) {
+		slog.WarnContext(ctx, "Image challenge is not available for the puzzle", "puzzleID", p.PuzzleID())
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if tnow := time.Now().UTC(); !tnow.Before(p.Expiration()) {
+		slog.WarnContext(ctx, "Puzzle is expired", "puzzleID", p.PuzzleID(), "expiration", p.Expiration(), "now", tnow)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var extraSalt []byte
+	if payload.NeedsExtraSalt() {
+		extraSalt = property.Salt
+	}
+
+	if err := payload.VerifySignature(ctx, s.Verifier.Salt.Value(), extraSalt); err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	image, err := puzzle.RenderImageChallenge(payload.ImageChallengeCode(s.Verifier.imageChallengeKey()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to render image challenge", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	slog.DebugContext(ctx, "Rendered image challenge", "puzzleID", p.PuzzleID(), "propID", property.ID)
+
+	common.WriteHeaders(w, common.NoCacheHeaders)
+	w.Header().Set(common.HeaderContentType, common.ContentTypePNG)
+	w.Header().Set(common.HeaderContentLength, strconv.Itoa(len(image)))
+	_, _ = w.Write(image)
+}
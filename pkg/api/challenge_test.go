@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func imageChallengeSuite(sitekey, domain, puzzleStr string) (*http.Response, error) {
+	srv := http.NewServeMux()
+	s.Setup("", true /*verbose*/, common.NoopMiddleware).Register(srv)
+
+	req, err := http.NewRequest(http.MethodPost, "/"+common.PuzzleEndpoint+"/"+common.ImageEndpoint+"?"+common.ParamSiteKey+"="+sitekey, strings.NewReader(puzzleStr))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Origin", common_test.PrependProtocol(domain))
+	req.Header.Set(common.HeaderContentType, common.ContentTypePlain)
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result(), nil
+}
+
+func imagePuzzleSuite(ctx context.Context, sitekey, domain string) (string, error) {
+	resp, err := puzzleSuite(ctx, sitekey, domain)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+func TestImageChallenge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain)
+	params.WidgetFlags = int16(puzzle.WidgetFlagImageChallenge)
+	property, _, err := store.Impl().CreateNewProperty(ctx, params, org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	puzzleStr, err := imagePuzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := imageChallengeSuite(sitekey, property.Domain, puzzleStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected image status code %d", resp.StatusCode)
+	}
+
+	if _, err := png.Decode(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := puzzle.ParsePuzzlePayload[puzzle.ComputePuzzle](ctx, []byte(puzzleStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyParams := db_tests.CreateNewPuzzleAPIKeyParams(t.Name()+"-apikey", time.Now(), 1*time.Hour, 10.0 /*rps*/)
+	apikey, _, err := store.Impl().CreateAPIKey(ctx, user, keyParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := db.UUIDToSecret(apikey.ExternalID)
+
+	code := payload.ImageChallengeCode(s.Verifier.imageChallengeKey())
+
+	// answer is not case-sensitive
+	resp, err = verifySuite(puzzle.ImageSolutionPrefix+strings.ToLower(code)+"."+puzzleStr, secret, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+
+	// puzzle only gets a single attempt at the image challenge
+	puzzleStr, err = imagePuzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err = puzzle.ParsePuzzlePayload[puzzle.ComputePuzzle](ctx, []byte(puzzleStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code = payload.ImageChallengeCode(s.Verifier.imageChallengeKey())
+	wrongCode := strings.Repeat("0", puzzle.ImageCodeLength)
+
+	resp, err = verifySuite(puzzle.ImageSolutionPrefix+wrongCode+"."+puzzleStr, secret, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.InvalidSolutionError); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = verifySuite(puzzle.ImageSolutionPrefix+code+"."+puzzleStr, secret, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifiedBeforeError); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImageChallengeDisabled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	puzzleStr, err := imagePuzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := imageChallengeSuite(sitekey, property.Domain, puzzleStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected image status code %d", resp.StatusCode)
+	}
+}
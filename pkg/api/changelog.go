@@ -0,0 +1,178 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	ChangeAdded      = "added"
+	ChangeChanged    = "changed"
+	ChangeDeprecated = "deprecated"
+	ChangeRemoved    = "removed"
+)
+
+var (
+	errInvalidVersion    = errors.New("invalid version")
+	errInvalidChangelog  = errors.New("changelog entries are not sorted by version")
+	errInvalidChangeType = errors.New("invalid change type")
+)
+
+//go:embed changelog.json
+var changelogData []byte
+
+type ChangelogChange struct {
+	Type        string `json:"type"`
+	Endpoint    string `json:"endpoint,omitempty"`
+	Description string `json:"description"`
+	// date after which deprecated functionality can be removed
+	Sunset string `json:"sunset,omitempty"`
+}
+
+type ChangelogEntry struct {
+	Version string             `json:"version"`
+	Date    string             `json:"date"`
+	Changes []*ChangelogChange `json:"changes"`
+}
+
+func (e *ChangelogEntry) HasDeprecations() bool {
+	for _, c := range e.Changes {
+		if c.Type == ChangeDeprecated {
+			return true
+		}
+	}
+
+	return false
+}
+
+type apiChangelogOutput struct {
+	Latest  string            `json:"latest"`
+	Entries []*ChangelogEntry `json:"entries"`
+}
+
+// parseVersion parses semantic version in "major.minor.patch" format
+func parseVersion(version string) ([3]int, error) {
+	var result [3]int
+
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) == 0 || len(parts) > len(result) {
+		return result, errInvalidVersion
+	}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return result, errInvalidVersion
+		}
+		result[i] = n
+	}
+
+	return result, nil
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+
+	return 0
+}
+
+func parseChangelog(data []byte) ([]*ChangelogEntry, error) {
+	var entries []*ChangelogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	var prev [3]int
+	for i, e := range entries {
+		version, err := parseVersion(e.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, e.Version)
+		}
+
+		// newest entries go first
+		if (i > 0) && (compareVersions(version, prev) >= 0) {
+			return nil, fmt.Errorf("%w: %s", errInvalidChangelog, e.Version)
+		}
+		prev = version
+
+		for _, c := range e.Changes {
+			switch c.Type {
+			case ChangeAdded, ChangeChanged, ChangeDeprecated, ChangeRemoved:
+			default:
+				return nil, fmt.Errorf("%w: %s", errInvalidChangeType, c.Type)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+var loadChangelog = sync.OnceValues(func() ([]*ChangelogEntry, error) {
+	return parseChangelog(changelogData)
+})
+
+// Changelog returns API changes sourced from the repository, newest first
+func Changelog() []*ChangelogEntry {
+	entries, err := loadChangelog()
+	if err != nil {
+		slog.Error("Failed to parse API changelog", common.ErrAttr(err))
+		return []*ChangelogEntry{}
+	}
+
+	return entries
+}
+
+// ChangelogSince returns entries that are newer than version (all entries if version is empty)
+func ChangelogSince(version string) ([]*ChangelogEntry, error) {
+	entries := Changelog()
+	if len(version) == 0 {
+		return entries, nil
+	}
+
+	since, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range entries {
+		// versions were validated during parsing
+		if v, _ := parseVersion(e.Version); compareVersions(v, since) <= 0 {
+			return entries[:i], nil
+		}
+	}
+
+	return entries, nil
+}
+
+// getChangelog allows SDKs to discover new endpoints and deprecations newer than the version they were built against
+func (s *Server) getChangelog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	since := r.URL.Query().Get(common.ParamSince)
+	entries, err := ChangelogSince(since)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse changelog version", "since", since, common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	output := &apiChangelogOutput{Entries: entries}
+	if all := Changelog(); len(all) > 0 {
+		output.Latest = all[0].Version
+	}
+
+	common.SendJSONResponse(ctx, w, output)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+func TestEmbeddedChangelog(t *testing.T) {
+	t.Parallel()
+
+	entries, err := parseChangelog(changelogData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) == 0 {
+		t.Fatal("Changelog is empty")
+	}
+
+	for _, e := range entries {
+		if (len(e.Date) == 0) || (len(e.Changes) == 0) {
+			t.Errorf("Incomplete changelog entry %v", e.Version)
+		}
+	}
+}
+
+func TestParseChangelogErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		data string
+		err  error
+	}{
+		{`[{"version": "1.0.0"}, {"version": "1.1.0"}]`, errInvalidChangelog},
+		{`[{"version": "1.1.0"}, {"version": "1.1.0"}]`, errInvalidChangelog},
+		{`[{"version": "1.x"}]`, errInvalidVersion},
+		{`[{"version": "1.0.0", "changes": [{"type": "fixed"}]}]`, errInvalidChangeType},
+	}
+
+	for i, tc := range testCases {
+		if _, err := parseChangelog([]byte(tc.data)); !errors.Is(err, tc.err) {
+			t.Errorf("Unexpected error for test case %v: %v", i, err)
+		}
+	}
+}
+
+func TestChangelogSince(t *testing.T) {
+	t.Parallel()
+
+	entries := Changelog()
+
+	if latest, _ := ChangelogSince(entries[0].Version); len(latest) != 0 {
+		t.Errorf("Unexpected entries since latest version: %v", len(latest))
+	}
+
+	if all, _ := ChangelogSince("0.1"); len(all) != len(entries) {
+		t.Errorf("Unexpected entries since first version: %v", len(all))
+	}
+
+	if all, _ := ChangelogSince(""); len(all) != len(entries) {
+		t.Errorf("Unexpected entries without version: %v", len(all))
+	}
+}
+
+func TestGetChangelog(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/"+common.ChangelogEndpoint, nil)
+	w := httptest.NewRecorder()
+
+	(&Server{}).getChangelog(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code: %v", w.Code)
+	}
+
+	output := &apiChangelogOutput{}
+	if err := json.NewDecoder(w.Body).Decode(output); err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(output.Entries) != len(Changelog())) || (output.Latest != output.Entries[0].Version) {
+		t.Errorf("Unexpected changelog output: %v entries, latest %v", len(output.Entries), output.Latest)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/"+common.ChangelogEndpoint+"?"+common.ParamSince+"=foo", nil)
+	w = httptest.NewRecorder()
+
+	(&Server{}).getChangelog(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Unexpected status code for invalid version: %v", w.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	maxPropertyClaims     = 8
+	claimTemplateEnv      = "{environment}"
+	claimTemplateSitekey  = "{sitekey}"
+	maxClaimsSitekeyValue = "aaaaaaaabbbbccccddddeeeeeeeeeeee"
+)
+
+var (
+	claimKeyRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+func claimsReplacer(environment, sitekey string) *strings.Replacer {
+	return strings.NewReplacer(claimTemplateEnv, environment, claimTemplateSitekey, sitekey)
+}
+
+func resolveClaims(claims url.Values, replacer *strings.Replacer) map[string]string {
+	result := make(map[string]string, len(claims))
+	for key := range claims {
+		result[key] = replacer.Replace(claims.Get(key))
+	}
+	return result
+}
+
+// encodePropertyClaims validates claims (as they come from the API user) for storing in DB. Templates are checked
+// with their longest possible values so that resolved claims always fit into the puzzle.
+func encodePropertyClaims(ctx context.Context, claims map[string]string) (string, bool) {
+	if len(claims) == 0 {
+		return "", true
+	}
+
+	if len(claims) > maxPropertyClaims {
+		slog.WarnContext(ctx, "Too many property claims", "count", len(claims), "max", maxPropertyClaims)
+		return "", false
+	}
+
+	values := make(url.Values, len(claims))
+	for key, value := range claims {
+		if !claimKeyRegexp.MatchString(key) {
+			slog.WarnContext(ctx, "Invalid property claim key", "key", key)
+			return "", false
+		}
+		values.Set(key, value)
+	}
+
+	// "production" is the longest environment name
+	replacer := claimsReplacer(string(dbgen.PropertyEnvironmentProduction), maxClaimsSitekeyValue)
+
+	p := new(puzzle.ComputePuzzle)
+	if err := p.SetClaims(resolveClaims(values, replacer)); err != nil {
+		slog.WarnContext(ctx, "Property claims are too large", "count", len(claims), common.ErrAttr(err))
+		return "", false
+	}
+
+	return values.Encode(), true
+}
+
+func decodePropertyClaims(ctx context.Context, encoded string) url.Values {
+	if len(encoded) == 0 {
+		return nil
+	}
+
+	values, err := url.ParseQuery(encoded)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse property claims", common.ErrAttr(err))
+		return nil
+	}
+
+	return values
+}
+
+// propertyClaims returns claims of the property with resolved templates
+func propertyClaims(ctx context.Context, property *dbgen.Property) map[string]string {
+	values := decodePropertyClaims(ctx, property.Claims)
+	if len(values) == 0 {
+		return nil
+	}
+
+	replacer := claimsReplacer(string(property.Environment), db.UUIDToSiteKey(property.ExternalID))
+
+	return resolveClaims(values, replacer)
+}
+
+func setPuzzleClaims(ctx context.Context, p puzzle.Puzzle, property *dbgen.Property) {
+	if len(property.Claims) == 0 {
+		return
+	}
+
+	if err := p.SetClaims(propertyClaims(ctx, property)); err != nil {
+		// this should have been validated when claims were saved
+		slog.ErrorContext(ctx, "Failed to set puzzle claims", "propID", property.ID, common.ErrAttr(err))
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestEncodePropertyClaims(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	testCases := []struct {
+		claims map[string]string
+		valid  bool
+	}{
+		{nil, true},
+		{map[string]string{"form": "signup", "env": "{environment}"}, true},
+		{map[string]string{"Form": "signup"}, false},
+		{map[string]string{"1form": "signup"}, false},
+		{map[string]string{"form": strings.Repeat("a", 100)}, false},
+		// template is short, but resolved sitekey does not fit
+		{map[string]string{"a": "{sitekey}", "b": "{sitekey}"}, false},
+	}
+
+	for i, tc := range testCases {
+		if _, ok := encodePropertyClaims(ctx, tc.claims); ok != tc.valid {
+			t.Errorf("Unexpected result for claims %v (%v): %v", i, tc.claims, ok)
+		}
+	}
+}
+
+func TestPropertyClaimsTemplates(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	encoded, ok := encodePropertyClaims(ctx, map[string]string{"env": "{environment}", "key": "{sitekey}", "form": "signup"})
+	if !ok {
+		t.Fatal("Failed to encode claims")
+	}
+
+	property := &dbgen.Property{
+		ExternalID:  *randomUUID(),
+		Environment: dbgen.PropertyEnvironmentStaging,
+		Claims:      encoded,
+	}
+
+	claims := propertyClaims(ctx, property)
+
+	if claims["env"] != string(dbgen.PropertyEnvironmentStaging) {
+		t.Errorf("Unexpected environment claim: %v", claims["env"])
+	}
+
+	if claims["key"] != db.UUIDToSiteKey(property.ExternalID) {
+		t.Errorf("Unexpected sitekey claim: %v", claims["key"])
+	}
+
+	if claims["form"] != "signup" {
+		t.Errorf("Unexpected static claim: %v", claims["form"])
+	}
+}
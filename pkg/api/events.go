@@ -0,0 +1,19 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+type apiEventsCatalogOutput struct {
+	Events []*db.EventCatalogEntry `json:"events"`
+}
+
+var eventsCatalog = &apiEventsCatalogOutput{Events: db.EventsCatalog()}
+
+// getEventsCatalog publishes JSON Schemas of all event types so that consumers can validate payloads and generate types
+func (s *Server) getEventsCatalog(w http.ResponseWriter, r *http.Request) {
+	common.SendJSONResponse(r.Context(), w, eventsCatalog)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"math"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	maxExperimentDurationDays     = 90
+	minExperimentSamples          = 100
+	defaultExperimentSamples      = 1000
+	defaultExperimentDegradation  = 1.0
+	maxExperimentDegradation      = 100.0
+	maxExperimentVariantWeight    = 99
+	propertyExperimentsFetchLimit = 1
+)
+
+// validateExperimentInput fills defaults and checks the rest of the input
+func validateExperimentInput(input *apiExperimentInput, property *dbgen.Property) common.StatusCode {
+	if input.ControlLevel == 0 {
+		input.ControlLevel = int(property.Level.Int16)
+	}
+
+	if input.MinSamples == 0 {
+		input.MinSamples = defaultExperimentSamples
+	}
+
+	if input.MaxDegradation == 0 {
+		input.MaxDegradation = defaultExperimentDegradation
+	}
+
+	isLevelValid := func(level int) bool {
+		return (level > 0) && (level <= int(common.MaxDifficultyLevel))
+	}
+
+	if !isLevelValid(input.ControlLevel) || !isLevelValid(input.VariantLevel) || (input.ControlLevel == input.VariantLevel) {
+		return common.StatusExperimentLevelError
+	}
+
+	if (input.VariantWeight <= 0) || (input.VariantWeight > maxExperimentVariantWeight) {
+		return common.StatusExperimentWeightError
+	}
+
+	if (input.DurationDays <= 0) || (input.DurationDays > maxExperimentDurationDays) {
+		return common.StatusExperimentDurationError
+	}
+
+	if (input.MinSamples < minExperimentSamples) || (input.MinSamples > math.MaxInt32) ||
+		(input.MaxDegradation < 0) || (input.MaxDegradation > maxExperimentDegradation) {
+		return common.StatusExperimentSamplesError
+	}
+
+	return common.StatusOK
+}
+
+func experimentArmToOutput(arm common.ExperimentArm, level int16, stats []*common.ExperimentArmStats) *apiExperimentArmOutput {
+	result := &apiExperimentArmOutput{
+		Arm:   arm.String(),
+		Level: int(level),
+	}
+
+	for _, s := range stats {
+		if s.Arm == arm {
+			result.Requests = s.RequestsCount
+			result.Successes = s.SuccessCount
+			result.Failures = s.FailureCount
+			result.AbandonmentRate = s.AbandonmentRate()
+			result.FailureRate = s.FailureRate()
+			break
+		}
+	}
+
+	return result
+}
+
+func experimentToOutput(e *dbgen.DifficultyExperiment, id string, stats []*common.ExperimentArmStats) *apiExperimentOutput {
+	result := &apiExperimentOutput{
+		ID:             id,
+		ControlLevel:   int(e.ControlLevel),
+		VariantLevel:   int(e.VariantLevel),
+		VariantWeight:  int(e.VariantWeight),
+		MinSamples:     int(e.MinSamples),
+		MaxDegradation: float64(e.MaxDegradation),
+		CreatedAt:      e.CreatedAt.Time.Format(time.RFC3339),
+		EndsAt:         e.EndsAt.Time.Format(time.RFC3339),
+		Outcome:        e.Outcome,
+		Conclusion:     e.Conclusion,
+		Arms: []*apiExperimentArmOutput{
+			experimentArmToOutput(common.ExperimentArmControl, e.ControlLevel, stats),
+			experimentArmToOutput(common.ExperimentArmVariant, e.VariantLevel, stats),
+		},
+	}
+
+	if e.ConcludedAt.Valid {
+		result.ConcludedAt = e.ConcludedAt.Time.Format(time.RFC3339)
+	}
+
+	return result
+}
@@ -0,0 +1,206 @@
+//go:build enterprise
+
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
+)
+
+// refreshExperiments makes changes visible on this instance right away, other instances will catch up with the periodic job
+func (s *Server) refreshExperiments(ctx context.Context) {
+	if experiments, err := s.BusinessDB.Impl().RetrieveRunningDifficultyExperiments(ctx); err == nil {
+		s.Verifier.Experiments.Update(experiments)
+	}
+}
+
+func (s *Server) lastPropertyExperiment(ctx context.Context, property *dbgen.Property) (*dbgen.DifficultyExperiment, error) {
+	experiments, err := s.BusinessDB.Impl().RetrievePropertyDifficultyExperiments(ctx, property, propertyExperimentsFetchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(experiments) == 0 {
+		return nil, db.ErrRecordNotFound
+	}
+
+	return experiments[0], nil
+}
+
+func (s *Server) experimentOutput(ctx context.Context, e *dbgen.DifficultyExperiment) *apiExperimentOutput {
+	to := time.Now().UTC()
+	if e.ConcludedAt.Valid {
+		to = e.ConcludedAt.Time
+	}
+
+	stats, err := s.TimeSeries.RetrieveExperimentStats(ctx, e.OrgID, e.PropertyID, e.CreatedAt.Time, to)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve experiment stats", "experimentID", e.ID, common.ErrAttr(err))
+	}
+
+	return experimentToOutput(e, s.IDHasher.Encrypt(int(e.ID)), stats)
+}
+
+func (s *Server) getPropertyExperiment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, false /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	experiment, err := s.lastPropertyExperiment(ctx, property)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	s.sendAPISuccessResponse(ctx, s.experimentOutput(ctx, experiment), w)
+}
+
+func (s *Server) postPropertyExperiment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, false /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	request := &apiExperimentInput{}
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize experiment request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+		return
+	}
+
+	if code := validateExperimentInput(request, property); !code.Success() {
+		slog.WarnContext(ctx, "Invalid experiment request", "code", code.String())
+		s.sendAPIErrorResponse(ctx, code, r, w)
+		return
+	}
+
+	if last, err := s.lastPropertyExperiment(ctx, property); (err == nil) && !last.ConcludedAt.Valid {
+		slog.WarnContext(ctx, "Difficulty experiment is already running", "experimentID", last.ID, "propID", property.ID)
+		s.sendAPIErrorResponse(ctx, common.StatusExperimentRunningError, r, w)
+		return
+	} else if (err != nil) && (err != db.ErrRecordNotFound) {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	experiment, err := s.BusinessDB.Impl().CreateDifficultyExperiment(ctx, &dbgen.CreateDifficultyExperimentParams{
+		PropertyID:     property.ID,
+		OrgID:          org.ID,
+		ControlLevel:   int16(request.ControlLevel),
+		VariantLevel:   int16(request.VariantLevel),
+		VariantWeight:  int16(request.VariantWeight),
+		MinSamples:     int32(request.MinSamples),
+		MaxDegradation: float32(request.MaxDegradation),
+		EndsAt:         db.Timestampz(time.Now().UTC().AddDate(0, 0, request.DurationDays)),
+	})
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	s.refreshExperiments(ctx)
+
+	s.sendAPISuccessResponse(ctx, experimentToOutput(experiment, s.IDHasher.Encrypt(int(experiment.ID)), nil /*stats*/), w)
+}
+
+func (s *Server) deletePropertyExperiment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, false /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	last, err := s.lastPropertyExperiment(ctx, property)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	if last.ConcludedAt.Valid {
+		s.sendHTTPErrorResponse(db.ErrRecordNotFound, r, w)
+		return
+	}
+
+	experiment, err := s.BusinessDB.Impl().ConcludeDifficultyExperiment(ctx, last.ID, difficulty.ExperimentOutcomeStopped, "stopped manually", time.Now().UTC())
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	s.refreshExperiments(ctx)
+
+	s.sendAPISuccessResponse(ctx, s.experimentOutput(ctx, experiment), w)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
+)
+
+func TestValidateExperimentInput(t *testing.T) {
+	t.Parallel()
+
+	property := &dbgen.Property{Level: db.Int2(int16(common.DifficultyLevelSmall))}
+
+	testCases := []struct {
+		input apiExperimentInput
+		code  common.StatusCode
+	}{
+		{apiExperimentInput{VariantLevel: 110, VariantWeight: 10, DurationDays: 14}, common.StatusOK},
+		{apiExperimentInput{VariantLevel: 80, VariantWeight: 10, DurationDays: 14}, common.StatusExperimentLevelError},
+		{apiExperimentInput{VariantLevel: 300, VariantWeight: 10, DurationDays: 14}, common.StatusExperimentLevelError},
+		{apiExperimentInput{VariantLevel: 110, VariantWeight: 100, DurationDays: 14}, common.StatusExperimentWeightError},
+		{apiExperimentInput{VariantLevel: 110, VariantWeight: 10, DurationDays: 365}, common.StatusExperimentDurationError},
+		{apiExperimentInput{VariantLevel: 110, VariantWeight: 10, DurationDays: 14, MinSamples: 10}, common.StatusExperimentSamplesError},
+		{apiExperimentInput{VariantLevel: 110, VariantWeight: 10, DurationDays: 14, MaxDegradation: -1}, common.StatusExperimentSamplesError},
+	}
+
+	for i, tc := range testCases {
+		input := tc.input
+		if code := validateExperimentInput(&input, property); code != tc.code {
+			t.Errorf("Unexpected status code for input %v: %v (expected %v)", i, code, tc.code)
+		}
+	}
+}
+
+func TestVerifierExperimentProperty(t *testing.T) {
+	t.Parallel()
+
+	verifier := &Verifier{Experiments: difficulty.NewExperiments()}
+	property := &dbgen.Property{ID: 123, Level: db.Int2(int16(common.DifficultyLevelSmall))}
+
+	if p, arm := verifier.experimentProperty(property, 1); (p != property) || (arm != common.ExperimentArmNone) {
+		t.Errorf("Unexpected arm without experiment: %v", arm)
+	}
+
+	verifier.Experiments.Update([]*dbgen.DifficultyExperiment{
+		{ID: 1, PropertyID: property.ID, ControlLevel: 80, VariantLevel: 110, VariantWeight: 50},
+	})
+
+	for puzzleID := uint64(1); puzzleID < 100; puzzleID++ {
+		p, arm := verifier.experimentProperty(property, puzzleID)
+		if arm != verifier.experimentArm(property.ID, puzzleID) {
+			t.Fatalf("Arm at verification does not match arm at issuance for puzzle %v", puzzleID)
+		}
+
+		if (arm == common.ExperimentArmVariant) && (p.Level.Int16 != 110) {
+			t.Errorf("Unexpected variant level: %v", p.Level.Int16)
+		}
+	}
+
+	if property.Level.Int16 != int16(common.DifficultyLevelSmall) {
+		t.Errorf("Original property was modified")
+	}
+}
+
+func TestAPIPropertyExperiment(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, org, apiKey, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, db_test.CreateNewPropertyParams(user.ID, "example.com"), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s", common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID)),
+		common.PropertyEndpoint, s.IDHasher.Encrypt(int(property.ID)), common.ExperimentEndpoint)
+
+	input := &apiExperimentInput{
+		VariantLevel:  int(common.DifficultyLevelHigh),
+		VariantWeight: 10,
+		DurationDays:  14,
+	}
+
+	output, meta, err := requestResponseAPISuite[*apiExperimentOutput](ctx, input, http.MethodPost, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !meta.Code.Success() {
+		t.Fatalf("Unexpected status code: %v", meta.Description)
+	}
+
+	if output.ControlLevel != int(property.Level.Int16) {
+		t.Errorf("Unexpected control level: %v", output.ControlLevel)
+	}
+
+	if _, ok := s.Verifier.Experiments.Get(property.ID); !ok {
+		t.Error("Experiment is not running after creation")
+	}
+
+	// only one experiment can run at a time
+	_, meta, err = requestResponseAPISuite[*apiExperimentOutput](ctx, input, http.MethodPost, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta.Code != common.StatusExperimentRunningError {
+		t.Errorf("Unexpected status code: %v", meta.Code)
+	}
+
+	output, meta, err = requestResponseAPISuite[*apiExperimentOutput](ctx, nil, http.MethodDelete, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !meta.Code.Success() {
+		t.Fatalf("Unexpected status code: %v", meta.Description)
+	}
+
+	if output.Outcome != difficulty.ExperimentOutcomeStopped {
+		t.Errorf("Unexpected experiment outcome: %v", output.Outcome)
+	}
+
+	if _, ok := s.Verifier.Experiments.Get(property.ID); ok {
+		t.Error("Experiment is still running after stop")
+	}
+}
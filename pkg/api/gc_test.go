@@ -137,3 +137,38 @@ func TestGCUserData(t *testing.T) {
 		return err
 	}, t)
 }
+
+func TestCleanupOrphanedPropertyData(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	_, org, err := db_test.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := db_test.CreatePropertyForOrg(ctx, store, org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gcDataTestSuite(ctx, property, func(p *dbgen.Property) error {
+		// hard delete skips time series, same as if garbage collection failed half-way
+		if err := store.Impl().DeleteProperties(ctx, []int32{p.ID}); err != nil {
+			return err
+		}
+
+		job := &maintenance.CleanupOrphanedDataJob{
+			Store:             store,
+			TimeSeries:        timeSeries,
+			BatchSize:         1_000_000,
+			MaxDeletes:        1_000,
+			MaxPendingDeletes: 1_000_000,
+		}
+
+		return job.RunOnce(ctx, job.NewParams())
+	}, t)
+}
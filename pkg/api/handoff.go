@@ -0,0 +1,451 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	maxHandoffBodySize  = 4 * 1024
+	handoffSessionTTL   = 10 * time.Minute
+	handoffIDLen        = 16
+	handoffTokenLen     = 32
+	handoffCachePrefix  = "handoff/"
+	handoffStatusWait   = "pending"
+	handoffStatusSolved = "completed"
+	// kiosk backend submits this token to /verify instead of the solution
+	handoffVerifyPrefix = "handoff:"
+)
+
+var (
+	errHandoffURL       = errors.New("handoff URL is not valid")
+	errHandoffToken     = errors.New("handoff verify token is not valid")
+	errHandoffNotSolved = errors.New("handoff session is not solved")
+	handoffTokenDomain  = []byte("pc-handoff")
+)
+
+// handoffSession binds a puzzle solved on a user's phone to the kiosk device that requested it.
+// Only the hash of the device token is stored so that leaking the cache does not allow to claim the solution.
+// Solution itself never leaves the server: kiosk receives a signed verify token that can be used only once.
+type handoffSession struct {
+	Sitekey   string    `json:"sitekey"`
+	TokenHash string    `json:"token_hash"`
+	Solution  string    `json:"solution,omitempty"`
+	Delivered bool      `json:"delivered,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type handoffCreateResponse struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	Token     string          `json:"token"`
+	ExpiresAt common.JSONTime `json:"expires_at"`
+}
+
+type handoffStatusResponse struct {
+	Status string `json:"status"`
+	Token  string `json:"token,omitempty"`
+}
+
+func handoffCacheKey(id string) string {
+	return handoffCachePrefix + id
+}
+
+func hashHandoffToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// verify tokens are signed with the same key as image challenges, but in a separate domain
+func (v *Verifier) handoffKey() []byte {
+	return v.Salt.Value().Data()
+}
+
+func handoffTokenSignature(key []byte, id string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(handoffTokenDomain)
+	_, _ = mac.Write([]byte(id))
+	return mac.Sum(nil)
+}
+
+// handoffVerifyToken is delivered to the kiosk instead of the solution of the handoff session
+func handoffVerifyToken(key []byte, id string) string {
+	return handoffVerifyPrefix + id + "." + base64.RawURLEncoding.EncodeToString(handoffTokenSignature(key, id))
+}
+
+func parseHandoffVerifyToken(key []byte, token string) (string, error) {
+	id, signature, ok := strings.Cut(strings.TrimPrefix(token, handoffVerifyPrefix), ".")
+	if !ok || (len(id) != hex.EncodedLen(handoffIDLen)) {
+		return "", errHandoffToken
+	}
+
+	actual, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return "", errHandoffToken
+	}
+
+	if !hmac.Equal(actual, handoffTokenSignature(key, id)) {
+		return "", errHandoffToken
+	}
+
+	return id, nil
+}
+
+func randomHandoffString(size int, encode func([]byte) string) (string, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return encode(data), nil
+}
+
+// handoff URL is the page (on the customer's domain) that hosts the widget and will be opened on the phone
+func handoffURL(rawURL string, property *dbgen.Property, id string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	if (u.Scheme != "https") && (u.Scheme != "http") {
+		return "", errHandoffURL
+	}
+
+	if !isOriginAllowed(u.Hostname(), property) {
+		return "", errHandoffURL
+	}
+
+	query := u.Query()
+	query.Set(common.ParamHandoff, id)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func unmarshalHandoffSession(ctx context.Context, data []byte) (*handoffSession, error) {
+	session := &handoffSession{}
+	if err := json.Unmarshal(data, session); err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal handoff session", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// retrieveHandoffSession returns the session together with its serialized value, to be used for updating it
+func (s *Server) retrieveHandoffSession(ctx context.Context, id string) (*handoffSession, []byte, error) {
+	if len(id) != hex.EncodedLen(handoffIDLen) {
+		return nil, nil, db.ErrInvalidInput
+	}
+
+	data, err := s.BusinessDB.Impl().RetrieveFromCache(ctx, handoffCacheKey(id))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := unmarshalHandoffSession(ctx, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return session, data, nil
+}
+
+func (s *Server) storeHandoffSession(ctx context.Context, id string, session *handoffSession, tnow time.Time) error {
+	ttl := session.ExpiresAt.Sub(tnow)
+	if ttl <= 0 {
+		return db.ErrCacheMiss
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal handoff session", common.ErrAttr(err))
+		return err
+	}
+
+	return s.BusinessDB.Impl().StoreInCache(ctx, handoffCacheKey(id), data, ttl)
+}
+
+// updateHandoffSession only succeeds if session was not changed concurrently since it was retrieved
+func (s *Server) updateHandoffSession(ctx context.Context, id string, oldData []byte, session *handoffSession) (bool, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal handoff session", common.ErrAttr(err))
+		return false, err
+	}
+
+	return s.BusinessDB.Impl().CompareAndSwapInCache(ctx, handoffCacheKey(id), oldData, data)
+}
+
+// takeHandoffSolution resolves the verify token into the solution of the handoff session. Session is deleted in
+// the same operation, so every token can be used only once.
+func (v *Verifier) takeHandoffSolution(ctx context.Context, token string) ([]byte, error) {
+	id, err := parseHandoffVerifyToken(v.handoffKey(), token)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse handoff verify token", "length", len(token), common.ErrAttr(err))
+		return nil, err
+	}
+
+	data, err := v.Store.Impl().TakeFromCache(ctx, handoffCacheKey(id))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to take handoff session", "handoffID", id, common.ErrAttr(err))
+		return nil, err
+	}
+
+	session, err := unmarshalHandoffSession(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if (len(session.Solution) == 0) || !session.Delivered {
+		slog.WarnContext(ctx, "Handoff session was not delivered", "handoffID", id)
+		return nil, errHandoffNotSolved
+	}
+
+	return []byte(session.Solution), nil
+}
+
+func sendHandoffErrorResponse(err error, w http.ResponseWriter) {
+	switch err {
+	case db.ErrCacheMiss:
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case db.ErrInvalidInput:
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	case db.ErrMaintenance:
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// createHandoff is called by the kiosk device that cannot solve the captcha itself (e.g. no keyboard).
+// Kiosk displays returned URL as a QR code and polls for the solution using the returned device token.
+func (s *Server) createHandoff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	property, ok := ctx.Value(common.PropertyContextKey).(*dbgen.Property)
+	if !ok || (property == nil) {
+		sitekey, _ := ctx.Value(common.SitekeyContextKey).(string)
+		var err error
+		property, err = s.BusinessDB.Impl().RetrievePropertyBySitekey(ctx, sitekey)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to retrieve property for handoff", "sitekey", sitekey, common.ErrAttr(err))
+			switch err {
+			case db.ErrMaintenance:
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			default:
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			}
+			return
+		}
+	}
+
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to read request form", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	id, err := randomHandoffString(handoffIDLen, hex.EncodeToString)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate handoff ID", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	phoneURL, err := handoffURL(r.FormValue(common.ParamURL), property, id)
+	if err != nil {
+		slog.WarnContext(ctx, "Invalid handoff URL", "domain", property.Domain, common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	token, err := randomHandoffString(handoffTokenLen, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate handoff token", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	tnow := time.Now().UTC()
+	session := &handoffSession{
+		Sitekey:   db.UUIDToSiteKey(property.ExternalID),
+		TokenHash: hashHandoffToken(token),
+		ExpiresAt: tnow.Add(handoffSessionTTL),
+	}
+
+	if err := s.storeHandoffSession(ctx, id, session, tnow); err != nil {
+		sendHandoffErrorResponse(err, w)
+		return
+	}
+
+	slog.DebugContext(ctx, "Created handoff session", "propID", property.ID, "handoffID", id)
+
+	response := &handoffCreateResponse{
+		ID:        id,
+		URL:       phoneURL,
+		Token:     token,
+		ExpiresAt: common.JSONTime(session.ExpiresAt),
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders, s.APIHeaders)
+}
+
+// completeHandoff is called by the widget on the phone after the puzzle is solved.
+// Solution is only checked here (not recorded as verified) so that kiosk backend can still use regular /verify
+func (s *Server) completeHandoff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := common.StrPathArg(r, common.ParamID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse handoff ID from URL", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	session, sessionData, err := s.retrieveHandoffSession(ctx, id)
+	if err != nil {
+		sendHandoffErrorResponse(err, w)
+		return
+	}
+
+	if len(session.Solution) > 0 {
+		slog.WarnContext(ctx, "Handoff session is already completed", "handoffID", id)
+		http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	payload, err := s.Verifier.ParseSolutionPayload(ctx, bytes.TrimSpace(data))
+	if err != nil {
+		slog.Log(ctx, common.LevelTrace, "Failed to parse solution payload", common.ErrAttr(err))
+		http.Error(w, "Failed to parse payload", http.StatusBadRequest)
+		return
+	}
+
+	propertyID := payload.Puzzle().PropertyID()
+	if propertyExternalID := db.UUIDFromSiteKey(session.Sitekey); !bytes.Equal(propertyExternalID.Bytes[:], propertyID[:]) {
+		slog.WarnContext(ctx, "Handoff property ID does not match", "expected", session.Sitekey, "actual", hex.EncodeToString(propertyID[:]))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	tnow := time.Now().UTC()
+	_, property, _, verr := s.Verifier.verifyPuzzleValid(ctx, payload, tnow)
+	if verr != puzzle.VerifyNoError {
+		slog.WarnContext(ctx, "Handoff puzzle is not valid", "handoffID", id, "code", verr.String())
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	originHost, err := common.ParseDomainName(r.Header.Get("Origin"))
+	if (err != nil) || (property == nil) || !isOriginAllowed(originHost, property) {
+		slog.WarnContext(ctx, "Handoff origin is not allowed", "origin", originHost, common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if _, verr := payload.VerifySolutions(ctx); verr != puzzle.VerifyNoError {
+		slog.WarnContext(ctx, "Handoff solutions are not valid", "handoffID", id, "code", verr.String())
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	// another phone could have completed the same session in the meantime
+	session.Solution = string(bytes.TrimSpace(data))
+	if ok, err := s.updateHandoffSession(ctx, id, sessionData, session); err != nil {
+		sendHandoffErrorResponse(err, w)
+		return
+	} else if !ok {
+		slog.WarnContext(ctx, "Handoff session was changed concurrently", "handoffID", id)
+		http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+		return
+	}
+
+	slog.DebugContext(ctx, "Completed handoff session", "propID", property.ID, "handoffID", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handoffStatus is polled by the kiosk device. Verify token (that kiosk backend submits instead of the solution) is
+// delivered exactly once and only to the device that holds the token issued when the session was created.
+func (s *Server) handoffStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := common.StrPathArg(r, common.ParamID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse handoff ID from URL", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	token := r.Header.Get(common.HeaderHandoffToken)
+	if len(token) == 0 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	session, sessionData, err := s.retrieveHandoffSession(ctx, id)
+	if err != nil {
+		sendHandoffErrorResponse(err, w)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashHandoffToken(token)), []byte(session.TokenHash)) != 1 {
+		slog.WarnContext(ctx, "Handoff device token does not match", "handoffID", id)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if session.Delivered {
+		slog.WarnContext(ctx, "Handoff verify token was already delivered", "handoffID", id)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	response := &handoffStatusResponse{Status: handoffStatusWait}
+
+	if len(session.Solution) > 0 {
+		session.Delivered = true
+		if ok, err := s.updateHandoffSession(ctx, id, sessionData, session); err != nil {
+			sendHandoffErrorResponse(err, w)
+			return
+		} else if !ok {
+			slog.WarnContext(ctx, "Handoff session was changed concurrently", "handoffID", id)
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		response.Status = handoffStatusSolved
+		response.Token = handoffVerifyToken(s.Verifier.handoffKey(), id)
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders, s.APIHeaders)
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func handoffRequestSuite(req *http.Request, domain string) *http.Response {
+	srv := http.NewServeMux()
+	s.Setup("", true /*verbose*/, common.NoopMiddleware).Register(srv)
+
+	if len(domain) > 0 {
+		req.Header.Set("Origin", common_test.PrependProtocol(domain))
+	}
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result()
+}
+
+func createHandoffSuite(sitekey, domain, pageURL string) (*http.Response, error) {
+	data := url.Values{}
+	data.Set(common.ParamURL, pageURL)
+
+	req, err := http.NewRequest(http.MethodPost, "/"+common.HandoffEndpoint+"?"+common.ParamSiteKey+"="+sitekey, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+
+	return handoffRequestSuite(req, domain), nil
+}
+
+func completeHandoffSuite(id, payload, domain string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/%s/%s", common.HandoffEndpoint, id), strings.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return handoffRequestSuite(req, domain), nil
+}
+
+func handoffStatusSuite(id, token string) (*handoffStatusResponse, int, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/%s/%s", common.HandoffEndpoint, id), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set(common.HeaderHandoffToken, token)
+
+	resp := handoffRequestSuite(req, "" /*domain*/)
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, nil
+	}
+
+	response := &handoffStatusResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, resp.StatusCode, err
+	}
+
+	return response, resp.StatusCode, nil
+}
+
+func TestHandoffURL(t *testing.T) {
+	t.Parallel()
+
+	property := &dbgen.Property{Domain: "example.com", AllowSubdomains: true}
+
+	testCases := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://example.com/kiosk", true},
+		{"https://tickets.example.com/kiosk?lang=en", true},
+		{"https://example.org/kiosk", false},
+		{"javascript:alert(1)", false},
+		{"ftp://example.com/kiosk", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		result, err := handoffURL(tc.url, property, "abc")
+		if tc.valid != (err == nil) {
+			t.Errorf("Unexpected result for URL %q: %v", tc.url, err)
+			continue
+		}
+
+		if tc.valid {
+			u, _ := url.Parse(result)
+			if id := u.Query().Get(common.ParamHandoff); id != "abc" {
+				t.Errorf("Unexpected handoff ID in URL %q: %v", result, id)
+			}
+		}
+	}
+}
+
+func TestHandoffFlow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	if resp, err := createHandoffSuite(sitekey, property.Domain, "https://example.org/kiosk"); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unexpected status code for foreign URL: %v", resp.StatusCode)
+	}
+
+	resp, err := createHandoffSuite(sitekey, property.Domain, "https://"+property.Domain+"/kiosk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected create status code %d", resp.StatusCode)
+	}
+
+	handoff := &handoffCreateResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(handoff); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(handoff.URL, common.ParamHandoff+"="+handoff.ID) {
+		t.Errorf("Handoff URL does not contain ID: %v", handoff.URL)
+	}
+
+	if status, code, err := handoffStatusSuite(handoff.ID, handoff.Token); err != nil {
+		t.Fatal(err)
+	} else if (code != http.StatusOK) || (status.Status != handoffStatusWait) {
+		t.Errorf("Unexpected pending status: %v (code %v)", status, code)
+	}
+
+	if _, code, _ := handoffStatusSuite(handoff.ID, "wrong-token"); code != http.StatusForbidden {
+		t.Errorf("Unexpected status code for wrong token: %v", code)
+	}
+
+	puzzleStr, solutionsStr, err := solutionsSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := fmt.Sprintf("%s.%s", solutionsStr, puzzleStr)
+
+	if resp, err := completeHandoffSuite(handoff.ID, payload, "example.org"); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected status code for foreign origin: %v", resp.StatusCode)
+	}
+
+	if resp, err := completeHandoffSuite(handoff.ID, payload, property.Domain); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Unexpected complete status code %d", resp.StatusCode)
+	}
+
+	if resp, err := completeHandoffSuite(handoff.ID, payload, property.Domain); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Unexpected status code for repeated completion: %v", resp.StatusCode)
+	}
+
+	status, code, err := handoffStatusSuite(handoff.ID, handoff.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (code != http.StatusOK) || (status.Status != handoffStatusSolved) || !strings.HasPrefix(status.Token, handoffVerifyPrefix) {
+		t.Fatalf("Unexpected completed status: %v (code %v)", status, code)
+	}
+
+	if _, code, _ := handoffStatusSuite(handoff.ID, handoff.Token); code != http.StatusNotFound {
+		t.Errorf("Unexpected status code after solution was delivered: %v", code)
+	}
+
+	// kiosk backend verifies delivered token instead of the solution
+	apikey, _, err := store.Impl().CreateAPIKey(ctx, user, db_tests.CreateNewPuzzleAPIKeyParams(t.Name()+"-apikey", time.Now(), 1*time.Hour, 10.0 /*rps*/))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := db.UUIDToSecret(apikey.ExternalID)
+
+	forged := handoffVerifyPrefix + handoff.ID + ".AAAA"
+	if resp, err := verifySuite(forged, secret, sitekey); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unexpected status code for forged token: %v", resp.StatusCode)
+	}
+
+	resp, err = verifySuite(status.Token, secret, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err := verifySuite(status.Token, secret, sitekey); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unexpected status code for reused token: %v", resp.StatusCode)
+	}
+}
+
+func TestHandoffVerifyToken(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("key")
+	id := strings.Repeat("ab", handoffIDLen)
+	token := handoffVerifyToken(key, id)
+
+	if actual, err := parseHandoffVerifyToken(key, token); (err != nil) || (actual != id) {
+		t.Errorf("Failed to parse token: %v (%v)", actual, err)
+	}
+
+	if _, err := parseHandoffVerifyToken([]byte("other"), token); err != errHandoffToken {
+		t.Errorf("Unexpected error for other key: %v", err)
+	}
+
+	if _, err := parseHandoffVerifyToken(key, handoffVerifyPrefix+id); err != errHandoffToken {
+		t.Errorf("Unexpected error for unsigned token: %v", err)
+	}
+}
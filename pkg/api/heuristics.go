@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+type botHeuristic string
+
+const (
+	botHeuristicNone                  botHeuristic = ""
+	botHeuristicMissingAcceptLanguage botHeuristic = "missing_accept_language"
+	botHeuristicHeadlessUserAgent     botHeuristic = "headless_user_agent"
+	botHeuristicClientHintsMismatch   botHeuristic = "client_hints_mismatch"
+	botHeuristicMissingClientHints    botHeuristic = "missing_client_hints"
+	// Chromium sends low-entropy client hints by default to secure origins since this version
+	minClientHintsChromeVersion = 90
+)
+
+var (
+	headlessUserAgentMarkers = []string{
+		"headlesschrome",
+		"phantomjs",
+		"puppeteer",
+		"playwright",
+		"selenium",
+		"python-requests",
+		"python-urllib",
+		"curl/",
+		"wget/",
+		"go-http-client",
+		"node-fetch",
+		"axios/",
+	}
+	// values of Sec-CH-UA-Platform mapped to what has to be present in the user agent
+	clientHintsPlatforms = map[string][]string{
+		"Windows": {"Windows"},
+		"macOS":   {"Macintosh", "Mac OS X"},
+		// "desktop mode" in mobile Chrome keeps Android platform hint with a desktop Linux user agent
+		"Android":   {"Android", "Linux"},
+		"Linux":     {"Linux", "X11"},
+		"Chrome OS": {"CrOS"},
+	}
+)
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func chromeMajorVersion(ua string) int {
+	_, version, ok := strings.Cut(ua, "Chrome/")
+	if !ok {
+		return 0
+	}
+
+	major, _, _ := strings.Cut(version, ".")
+	result, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+
+	return result
+}
+
+func clientHintsMismatch(ua, brands, mobile, platform string) bool {
+	if (len(brands) > 0) && !strings.Contains(ua, "Chrome/") && !strings.Contains(ua, "Chromium/") {
+		return true
+	}
+
+	if (mobile == "?1") && !strings.Contains(ua, "Mobile") && !strings.Contains(ua, "Android") {
+		return true
+	}
+
+	if markers, ok := clientHintsPlatforms[strings.Trim(platform, `"`)]; ok && !containsAny(ua, markers) {
+		return true
+	}
+
+	return false
+}
+
+// detectBotHeuristic evaluates cheap request signals and returns the first heuristic that matched.
+// None of them is a proof on its own, so they are only acted upon according to the property bot policy
+func detectBotHeuristic(r *http.Request) botHeuristic {
+	ua := r.UserAgent()
+
+	if lowerUA := strings.ToLower(ua); containsAny(lowerUA, headlessUserAgentMarkers) {
+		return botHeuristicHeadlessUserAgent
+	}
+
+	brands := r.Header.Get(common.HeaderSecCHUA)
+	if strings.Contains(brands, "HeadlessChrome") {
+		return botHeuristicHeadlessUserAgent
+	}
+
+	if len(r.Header.Get(common.HeaderAcceptLanguage)) == 0 {
+		return botHeuristicMissingAcceptLanguage
+	}
+
+	if clientHintsMismatch(ua, brands, r.Header.Get(common.HeaderSecCHUAMobile), r.Header.Get(common.HeaderSecCHUAPlatform)) {
+		return botHeuristicClientHintsMismatch
+	}
+
+	// browsers that send fetch metadata, but not client hints, while claiming to be a recent Chrome
+	if (len(brands) == 0) && (len(r.Header.Get(common.HeaderSecFetchMode)) > 0) &&
+		(chromeMajorVersion(ua) >= minClientHintsChromeVersion) {
+		return botHeuristicMissingClientHints
+	}
+
+	return botHeuristicNone
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	testChromeUserAgent  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+	testFirefoxUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0"
+	testChromeBrands     = `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`
+)
+
+func TestDetectBotHeuristic(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		headers   map[string]string
+		heuristic botHeuristic
+	}{
+		{map[string]string{"User-Agent": testFirefoxUserAgent, common.HeaderAcceptLanguage: "en-US"}, botHeuristicNone},
+		{map[string]string{
+			"User-Agent":                 testChromeUserAgent,
+			common.HeaderAcceptLanguage:  "en-US",
+			common.HeaderSecCHUA:         testChromeBrands,
+			common.HeaderSecCHUAMobile:   "?0",
+			common.HeaderSecCHUAPlatform: `"Windows"`,
+			common.HeaderSecFetchMode:    "cors",
+		}, botHeuristicNone},
+		{map[string]string{"User-Agent": testFirefoxUserAgent}, botHeuristicMissingAcceptLanguage},
+		{map[string]string{"User-Agent": "curl/8.5.0", common.HeaderAcceptLanguage: "en-US"}, botHeuristicHeadlessUserAgent},
+		{map[string]string{
+			"User-Agent":                "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/124.0.0.0 Safari/537.36",
+			common.HeaderAcceptLanguage: "en-US",
+		}, botHeuristicHeadlessUserAgent},
+		{map[string]string{
+			"User-Agent":                testChromeUserAgent,
+			common.HeaderAcceptLanguage: "en-US",
+			common.HeaderSecCHUA:        `"HeadlessChrome";v="124"`,
+		}, botHeuristicHeadlessUserAgent},
+		{map[string]string{
+			"User-Agent":                testFirefoxUserAgent,
+			common.HeaderAcceptLanguage: "en-US",
+			common.HeaderSecCHUA:        testChromeBrands,
+		}, botHeuristicClientHintsMismatch},
+		{map[string]string{
+			"User-Agent":                testChromeUserAgent,
+			common.HeaderAcceptLanguage: "en-US",
+			common.HeaderSecCHUA:        testChromeBrands,
+			common.HeaderSecCHUAMobile:  "?1",
+		}, botHeuristicClientHintsMismatch},
+		{map[string]string{
+			"User-Agent":                 testChromeUserAgent,
+			common.HeaderAcceptLanguage:  "en-US",
+			common.HeaderSecCHUA:         testChromeBrands,
+			common.HeaderSecCHUAPlatform: `"macOS"`,
+		}, botHeuristicClientHintsMismatch},
+		{map[string]string{
+			"User-Agent":                testChromeUserAgent,
+			common.HeaderAcceptLanguage: "en-US",
+			common.HeaderSecFetchMode:   "cors",
+		}, botHeuristicMissingClientHints},
+		// old Chrome versions do not send client hints
+		{map[string]string{
+			"User-Agent":                "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/80.0.3987.149 Safari/537.36",
+			common.HeaderAcceptLanguage: "en-US",
+			common.HeaderSecFetchMode:   "cors",
+		}, botHeuristicNone},
+	}
+
+	for i, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/puzzle", nil)
+		for k, v := range tc.headers {
+			r.Header.Set(k, v)
+		}
+
+		if heuristic := detectBotHeuristic(r); heuristic != tc.heuristic {
+			t.Errorf("Unexpected heuristic for test case %v: %v (expected %v)", i, heuristic, tc.heuristic)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	HotPropertiesService  = "hot_properties"
+	hotPropertiesInterval = 1 * time.Minute
+	maxHotProperties      = 1_000
+	// property has to sustain ~10 puzzles per second (on this instance) to be pinned
+	minHotPropertyRequests = 10 * 60
+)
+
+// HotProperties pins the most requested properties into an immutable map that is swapped atomically, so that
+// /puzzle can resolve them without going through the generic cache. Properties are selected by traffic that
+// each instance observes and pinned entries are refreshed when properties change.
+type HotProperties struct {
+	store db.Implementor
+	// max number of pinned properties, 0 means disabled
+	limit    atomic.Int32
+	pinned   atomic.Pointer[map[string]*dbgen.Property]
+	counters atomic.Pointer[sync.Map]
+	// serializes writers of the pinned map
+	pinnedMux   sync.Mutex
+	ChangedChan chan string
+	BatchSize   int
+	trigger     chan struct{}
+	cancel      context.CancelFunc
+}
+
+var _ common.PeriodicJob = (*HotProperties)(nil)
+
+func NewHotProperties(store db.Implementor) *HotProperties {
+	const batchSize = 10
+
+	hp := &HotProperties{
+		store:       store,
+		ChangedChan: make(chan string, 10*batchSize),
+		BatchSize:   batchSize,
+		trigger:     make(chan struct{}, 1),
+		cancel:      func() {},
+	}
+
+	hp.counters.Store(&sync.Map{})
+
+	return hp
+}
+
+func (hp *HotProperties) Start(backfillDelay time.Duration) {
+	var ctx context.Context
+	baseCtx := context.WithValue(context.Background(), common.ServiceContextKey, HotPropertiesService)
+	ctx, hp.cancel = context.WithCancel(context.WithValue(baseCtx, common.TraceIDContextKey, "hot_properties_refresh"))
+	go common.ProcessBatchMap(ctx, hp.ChangedChan, backfillDelay, hp.BatchSize, hp.BatchSize*10, hp.refreshChanged)
+}
+
+func (hp *HotProperties) Shutdown() {
+	slog.Debug("Shutting down hot properties")
+	// NOTE: channel is not closed as property listener can be invoked by the portal until the very end
+	hp.cancel()
+}
+
+func (hp *HotProperties) UpdateConfig(ctx context.Context, cfg common.ConfigStore) {
+	limit := int32(min(max(config.AsInt(cfg.Get(common.HotPropertiesKey), 0), 0), maxHotProperties))
+
+	if prev := hp.limit.Swap(limit); prev != limit {
+		slog.InfoContext(ctx, "Updated hot properties limit", "previous", prev, "limit", limit)
+
+		select {
+		case hp.trigger <- struct{}{}:
+		default:
+			// refresh is already pending
+		}
+	}
+}
+
+// Get returns pinned property without touching any cache
+func (hp *HotProperties) Get(sitekey string) (*dbgen.Property, bool) {
+	pinned := hp.pinned.Load()
+	if pinned == nil {
+		return nil, false
+	}
+
+	property, ok := (*pinned)[sitekey]
+	return property, ok
+}
+
+// Count records a request for the existing property so that it can be pinned during the next refresh
+func (hp *HotProperties) Count(sitekey string) {
+	if hp.limit.Load() == 0 {
+		return
+	}
+
+	counters := hp.counters.Load()
+	counter, ok := counters.Load(sitekey)
+	if !ok {
+		counter, _ = counters.LoadOrStore(sitekey, &atomic.Uint64{})
+	}
+
+	counter.(*atomic.Uint64).Add(1)
+}
+
+// OnPropertyChanged is a property listener of the business store. Changed property is unpinned right away
+// (so requests fall back to the generic cache) and is pinned again after it is refetched in the background.
+func (hp *HotProperties) OnPropertyChanged(ctx context.Context, sitekey string) {
+	if _, ok := hp.Get(sitekey); !ok {
+		return
+	}
+
+	hp.updatePinned(func(pinned map[string]*dbgen.Property) {
+		delete(pinned, sitekey)
+	})
+
+	select {
+	case hp.ChangedChan <- sitekey:
+		slog.DebugContext(ctx, "Unpinned changed hot property", "sitekey", sitekey)
+	default:
+		slog.WarnContext(ctx, "Hot properties channel is full", "sitekey", sitekey)
+	}
+}
+
+func (hp *HotProperties) updatePinned(fn func(map[string]*dbgen.Property)) {
+	hp.pinnedMux.Lock()
+	defer hp.pinnedMux.Unlock()
+
+	current := hp.pinned.Load()
+	if current == nil {
+		return
+	}
+
+	pinned := maps.Clone(*current)
+	fn(pinned)
+	hp.pinned.Store(&pinned)
+}
+
+func (hp *HotProperties) refreshChanged(ctx context.Context, batch map[string]uint) error {
+	properties, err := hp.store.Impl().RetrievePropertiesBySitekey(ctx, batch, 0 /*min missing count*/)
+	if err == db.ErrNegativeCacheHit {
+		slog.DebugContext(ctx, "Changed hot properties were deleted", "count", len(batch))
+		return nil
+	} else if err != nil {
+		// NOTE: we do not retry as properties will be pinned again during the next refresh if they are still hot
+		slog.WarnContext(ctx, "Failed to retrieve changed hot properties", "count", len(batch), common.ErrAttr(err))
+		return nil
+	}
+
+	hp.updatePinned(func(pinned map[string]*dbgen.Property) {
+		for _, p := range properties {
+			pinned[db.UUIDToSiteKey(p.ExternalID)] = p
+		}
+	})
+
+	slog.DebugContext(ctx, "Pinned changed hot properties", "count", len(properties), "batch", len(batch))
+
+	return nil
+}
+
+// hottest returns up to limit most requested sitekeys since the previous call
+func (hp *HotProperties) hottest(limit int) map[string]uint {
+	counters := hp.counters.Swap(&sync.Map{})
+
+	type candidate struct {
+		sitekey string
+		count   uint64
+	}
+
+	candidates := make([]candidate, 0, limit)
+	counters.Range(func(key, value any) bool {
+		if count := value.(*atomic.Uint64).Load(); count >= minHotPropertyRequests {
+			candidates = append(candidates, candidate{sitekey: key.(string), count: count})
+		}
+		return true
+	})
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(b.count, a.count)
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	result := make(map[string]uint, len(candidates))
+	for _, c := range candidates {
+		result[c.sitekey] = uint(c.count)
+	}
+
+	return result
+}
+
+func (hp *HotProperties) Timeout() time.Duration {
+	return 30 * time.Second
+}
+
+func (hp *HotProperties) Interval() time.Duration {
+	return hotPropertiesInterval
+}
+
+func (hp *HotProperties) Jitter() time.Duration {
+	return 1
+}
+
+func (hp *HotProperties) Name() string {
+	return "refresh_hot_properties_job"
+}
+
+func (hp *HotProperties) Trigger() <-chan struct{} {
+	return hp.trigger
+}
+
+func (hp *HotProperties) NewParams() any {
+	return struct{}{}
+}
+
+// RunOnce selects properties with the most traffic during the last interval and pins their fresh versions
+func (hp *HotProperties) RunOnce(ctx context.Context, params any) error {
+	limit := int(hp.limit.Load())
+	if limit == 0 {
+		hp.pinnedMux.Lock()
+		defer hp.pinnedMux.Unlock()
+
+		if prev := hp.pinned.Swap(nil); prev != nil {
+			slog.InfoContext(ctx, "Unpinned all hot properties", "count", len(*prev))
+		}
+
+		return nil
+	}
+
+	batch := hp.hottest(limit)
+	pinned := make(map[string]*dbgen.Property, len(batch))
+
+	if len(batch) > 0 {
+		properties, err := hp.store.Impl().RetrievePropertiesBySitekey(ctx, batch, 0 /*min missing count*/)
+		if (err != nil) && (err != db.ErrNegativeCacheHit) {
+			slog.ErrorContext(ctx, "Failed to retrieve hot properties", "count", len(batch), common.ErrAttr(err))
+			return err
+		}
+
+		for _, p := range properties {
+			pinned[db.UUIDToSiteKey(p.ExternalID)] = p
+		}
+	}
+
+	hp.pinnedMux.Lock()
+	prev := hp.pinned.Swap(&pinned)
+	hp.pinnedMux.Unlock()
+
+	prevCount := 0
+	if prev != nil {
+		prevCount = len(*prev)
+	}
+
+	slog.DebugContext(ctx, "Refreshed hot properties", "count", len(pinned), "previous", prevCount, "limit", limit)
+
+	return nil
+}
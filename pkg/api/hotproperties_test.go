@@ -0,0 +1,152 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func hotPropertiesSuite(t *testing.T, limit int, properties ...*dbgen.Property) (*HotProperties, *db.BusinessStore) {
+	cache, err := db.NewMemoryCache[db.CacheKey, any]("hot_properties", 100, &struct{}{}, 1*time.Minute, 3*time.Minute, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without DB pool properties are read only from cache
+	store := db.NewBusinessEx(nil /*pool*/, cache)
+	for _, p := range properties {
+		_ = cache.Set(t.Context(), db.PropertyBySitekeyCacheKey(db.UUIDToSiteKey(p.ExternalID)), p)
+	}
+
+	cfg := config.NewBaseConfig(config.NewEnvConfig(func(string) string { return "" }))
+	cfg.Add(config.NewStaticValue(common.HotPropertiesKey, strconv.Itoa(limit)))
+
+	hp := NewHotProperties(store)
+	hp.UpdateConfig(t.Context(), cfg)
+	store.SetPropertyListener(hp.OnPropertyChanged)
+
+	return hp, store
+}
+
+func TestHotPropertiesSelection(t *testing.T) {
+	t.Parallel()
+
+	hot := &dbgen.Property{ID: 1, ExternalID: *randomUUID(), Domain: testPropertyDomain}
+	hotter := &dbgen.Property{ID: 2, ExternalID: *randomUUID(), Domain: testPropertyDomain}
+	cold := &dbgen.Property{ID: 3, ExternalID: *randomUUID(), Domain: testPropertyDomain}
+
+	hp, _ := hotPropertiesSuite(t, 1 /*limit*/, hot, hotter, cold)
+
+	select {
+	case <-hp.Trigger():
+	default:
+		t.Fatal("Config update did not trigger refresh")
+	}
+
+	counts := map[*dbgen.Property]int{hot: minHotPropertyRequests, hotter: minHotPropertyRequests + 1, cold: 1}
+	for p, count := range counts {
+		for range count {
+			hp.Count(db.UUIDToSiteKey(p.ExternalID))
+		}
+	}
+
+	if err := hp.RunOnce(t.Context(), hp.NewParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	for p, expected := range map[*dbgen.Property]bool{hot: false, hotter: true, cold: false} {
+		if _, ok := hp.Get(db.UUIDToSiteKey(p.ExternalID)); ok != expected {
+			t.Errorf("Unexpected pinned state of property %v: %v", p.ID, ok)
+		}
+	}
+
+	// without traffic during the next interval property is unpinned
+	if err := hp.RunOnce(t.Context(), hp.NewParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := hp.Get(db.UUIDToSiteKey(hotter.ExternalID)); ok {
+		t.Error("Property is still pinned without traffic")
+	}
+}
+
+func TestHotPropertiesChange(t *testing.T) {
+	t.Parallel()
+
+	property := &dbgen.Property{ID: 1, ExternalID: *randomUUID(), Domain: testPropertyDomain}
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	hp, store := hotPropertiesSuite(t, 10 /*limit*/, property)
+
+	for range minHotPropertyRequests {
+		hp.Count(sitekey)
+	}
+
+	if err := hp.RunOnce(t.Context(), hp.NewParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := hp.Get(sitekey); !ok {
+		t.Fatal("Property was not pinned")
+	}
+
+	updated := *property
+	updated.Domain = "example.org"
+	_ = store.Cache.Set(t.Context(), db.PropertyBySitekeyCacheKey(sitekey), &updated)
+
+	hp.OnPropertyChanged(t.Context(), sitekey)
+
+	if _, ok := hp.Get(sitekey); ok {
+		t.Fatal("Changed property is still pinned")
+	}
+
+	if len(hp.ChangedChan) != 1 {
+		t.Fatalf("Unexpected number of changed properties: %v", len(hp.ChangedChan))
+	}
+
+	if err := hp.refreshChanged(t.Context(), map[string]uint{<-hp.ChangedChan: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if p, ok := hp.Get(sitekey); !ok || (p.Domain != updated.Domain) {
+		t.Errorf("Changed property was not pinned again: %v", ok)
+	}
+}
+
+func TestHotPropertiesDisabled(t *testing.T) {
+	t.Parallel()
+
+	property := &dbgen.Property{ID: 1, ExternalID: *randomUUID(), Domain: testPropertyDomain}
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	hp, _ := hotPropertiesSuite(t, 10 /*limit*/, property)
+
+	for range minHotPropertyRequests {
+		hp.Count(sitekey)
+	}
+
+	if err := hp.RunOnce(t.Context(), hp.NewParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewBaseConfig(config.NewEnvConfig(func(string) string { return "" }))
+	hp.UpdateConfig(t.Context(), cfg)
+
+	if err := hp.RunOnce(t.Context(), hp.NewParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := hp.Get(sitekey); ok {
+		t.Error("Property is pinned while hot properties are disabled")
+	}
+
+	hp.Count(sitekey)
+	if hottest := hp.hottest(10); len(hottest) != 0 {
+		t.Errorf("Requests were counted while hot properties are disabled")
+	}
+}
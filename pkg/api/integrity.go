@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+var (
+	errInvalidScriptHash = errors.New("invalid widget script hash")
+)
+
+type scriptHash = [puzzle.ScriptHashLength]byte
+
+// widgetIntegrity contains (truncated) hashes of all published widget scripts, that clients are expected to report
+type widgetIntegrity struct {
+	hashes map[scriptHash]struct{}
+}
+
+// parseWidgetIntegrity reads configuration in the form of hex-encoded SHA-256 hashes of the published widget
+// versions, separated by commas or spaces. Hash of the bundled widget, if any, is always expected.
+func parseWidgetIntegrity(value string, bundled []byte) (*widgetIntegrity, error) {
+	wi := &widgetIntegrity{hashes: make(map[scriptHash]struct{})}
+
+	if len(bundled) >= puzzle.ScriptHashLength {
+		wi.hashes[scriptHash(bundled[:puzzle.ScriptHashLength])] = struct{}{}
+	}
+
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return (r == ',') || (r == ' ') || (r == ';') }) {
+		hash, err := hex.DecodeString(field)
+		if err != nil || (len(hash) < puzzle.ScriptHashLength) {
+			return nil, fmt.Errorf("%w: %q", errInvalidScriptHash, field)
+		}
+
+		wi.hashes[scriptHash(hash[:puzzle.ScriptHashLength])] = struct{}{}
+	}
+
+	if len(wi.hashes) == 0 {
+		return nil, nil
+	}
+
+	return wi, nil
+}
+
+// check only treats unknown script hash as tampering: legitimate pages commonly wrap native functions (zone.js,
+// error trackers, polyfills) so patched environment is reported separately as a soft signal
+func (wi *widgetIntegrity) check(metadata *puzzle.Metadata) common.IntegrityStatus {
+	hash := metadata.ScriptHash()
+	if (wi != nil) && (hash != nil) {
+		if _, ok := wi.hashes[scriptHash(hash)]; !ok {
+			return common.IntegrityTampered
+		}
+	}
+
+	if metadata.IntegrityFlags()&puzzle.IntegrityFlagNativePatched != 0 {
+		return common.IntegrityPatched
+	}
+
+	if (wi == nil) || (hash == nil) {
+		return common.IntegrityUnknown
+	}
+
+	return common.IntegrityOK
+}
+
+func (v *Verifier) UpdateIntegrity(ctx context.Context, cfg common.ConfigStore) {
+	wi, err := parseWidgetIntegrity(cfg.Get(common.WidgetIntegrityHashesKey).Value(), v.WidgetScriptHash)
+	if err != nil {
+		// keep the previous (valid) configuration
+		slog.ErrorContext(ctx, "Failed to parse widget integrity hashes", common.ErrAttr(err))
+		return
+	}
+
+	v.integrity.Store(wi)
+
+	if wi != nil {
+		slog.DebugContext(ctx, "Updated widget integrity hashes", "count", len(wi.hashes))
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func integrityMetadata(t *testing.T, flags puzzle.IntegrityFlags, hash []byte) *puzzle.Metadata {
+	data := make([]byte, 1+1+1+4+1+puzzle.ScriptHashLength)
+	data[0] = 2
+	data[7] = byte(flags)
+	copy(data[8:], hash)
+
+	solutions, err := puzzle.NewSolutions([]byte(base64.StdEncoding.EncodeToString(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return solutions.Metadata
+}
+
+func TestWidgetIntegrityCheck(t *testing.T) {
+	t.Parallel()
+
+	bundled := sha256.Sum256([]byte("bundled"))
+	published := sha256.Sum256([]byte("published"))
+	other := sha256.Sum256([]byte("other"))
+
+	wi, err := parseWidgetIntegrity(hex.EncodeToString(published[:]), bundled[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range []struct {
+		integrity *widgetIntegrity
+		flags     puzzle.IntegrityFlags
+		hash      []byte
+		expected  common.IntegrityStatus
+	}{
+		{wi, 0, bundled[:], common.IntegrityOK},
+		{wi, 0, published[:], common.IntegrityOK},
+		{wi, 0, other[:], common.IntegrityTampered},
+		{wi, puzzle.IntegrityFlagNativePatched, bundled[:], common.IntegrityPatched},
+		{wi, puzzle.IntegrityFlagNativePatched, other[:], common.IntegrityTampered},
+		{nil, puzzle.IntegrityFlagNativePatched, nil, common.IntegrityPatched},
+		{wi, puzzle.IntegrityFlagNoScript, nil, common.IntegrityUnknown},
+		{nil, 0, other[:], common.IntegrityUnknown},
+	} {
+		if actual := tc.integrity.check(integrityMetadata(t, tc.flags, tc.hash)); actual != tc.expected {
+			t.Errorf("Unexpected integrity status at %d: %v (expected %v)", i, actual, tc.expected)
+		}
+	}
+}
+
+func TestParseWidgetIntegrityErrors(t *testing.T) {
+	t.Parallel()
+
+	if wi, err := parseWidgetIntegrity("", nil); (err != nil) || (wi != nil) {
+		t.Errorf("Unexpected result for empty config: %v, %v", wi, err)
+	}
+
+	if _, err := parseWidgetIntegrity("abcd", nil); !errors.Is(err, errInvalidScriptHash) {
+		t.Errorf("Unexpected error for short hash: %v", err)
+	}
+
+	if _, err := parseWidgetIntegrity("not-a-hash-at-all", nil); !errors.Is(err, errInvalidScriptHash) {
+		t.Errorf("Unexpected error for invalid hash: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	maxCachedIPRanges = 10_000
+)
+
+type ipRangesKind uint8
+
+const (
+	ipRangesAPIKey ipRangesKind = iota
+	ipRangesAllowlist
+	ipRangesDenylist
+)
+
+type ipRangesKey struct {
+	kind ipRangesKind
+	id   int32
+}
+
+// ipRanges are parsed CIDRs together with the (cached) strings they were parsed from
+type ipRanges struct {
+	source   []string
+	prefixes []netip.Prefix
+}
+
+// ipRangesCache keeps parsed CIDRs of API keys and access lists so that we do not parse them on every request.
+// Entries are validated against the source strings, which come from business cache, so updates of keys or
+// access lists do not need explicit invalidation here
+type ipRangesCache struct {
+	cache *db.StaticCache[ipRangesKey, *ipRanges]
+}
+
+func newIPRangesCache(capacity int) *ipRangesCache {
+	return &ipRangesCache{
+		cache: db.NewStaticCache[ipRangesKey, *ipRanges](capacity, nil /*missing value*/),
+	}
+}
+
+// shared by API key checks that happen both in middlewares and in owner sources
+var cachedIPRanges = newIPRangesCache(maxCachedIPRanges)
+
+func (c *ipRangesCache) prefixes(ctx context.Context, key ipRangesKey, source []string) ([]netip.Prefix, error) {
+	if ranges, err := c.cache.Get(ctx, key); (err == nil) && slices.Equal(ranges.source, source) {
+		return ranges.prefixes, nil
+	}
+
+	prefixes, err := common.ParseIPAllowlist(source)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = c.cache.Set(ctx, key, &ipRanges{source: source, prefixes: prefixes})
+
+	return prefixes, nil
+}
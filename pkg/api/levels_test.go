@@ -24,7 +24,7 @@ func TestBackfillLevels(t *testing.T) {
 	}
 
 	// minutes per bucket
-	levels := difficulty.NewLevels(timeSeries, 200, testBucketSize)
+	levels := difficulty.NewLevels(timeSeries, 200, testBucketSize, nil /*footprint*/)
 	levels.Init(500*time.Millisecond /*access log*/, 700*time.Millisecond /*backfill*/)
 	defer levels.Shutdown()
 	tnow := time.Now()
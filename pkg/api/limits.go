@@ -0,0 +1,153 @@
+//go:build enterprise
+
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func (s *Server) monthlyRequestsUsage(ctx context.Context, userID int32, tnow time.Time) int64 {
+	monthStart := db.QuotaPeriodStart(tnow)
+
+	sandboxIDs, err := s.BusinessDB.Impl().RetrieveUserSandboxOrgIDs(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve sandbox orgs", "userID", userID, common.ErrAttr(err))
+	}
+
+	stats, err := s.TimeSeries.RetrieveAccountStats(ctx, userID, monthStart, sandboxIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve account stats", "userID", userID, common.ErrAttr(err))
+		return 0
+	}
+
+	var count int64
+	for _, st := range stats {
+		count += int64(st.Count)
+	}
+
+	return count
+}
+
+// getLimits returns effective limits and their current consumption in one call, so that clients
+// do not need to fetch (and interpret) subscription plans themselves
+func (s *Server) getLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	subscr, err := s.BusinessDB.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user subscription", "userID", user.ID, common.ErrAttr(err))
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	limits, err := s.SubscriptionLimits.Limits(ctx, subscr)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	orgs, err := s.BusinessDB.Impl().RetrieveUserOrganizations(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user organizations", common.ErrAttr(err))
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	var orgsCount int64
+	for _, org := range orgs {
+		if org.Level == dbgen.AccessLevelOwner {
+			orgsCount++
+		}
+	}
+
+	propertiesCount, err := s.BusinessDB.Impl().RetrieveUserPropertiesCount(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve properties count", "userID", user.ID, common.ErrAttr(err))
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	response := &apiLimitsOutput{
+		Properties:       apiUsageLimit{Used: propertiesCount, Limit: int64(limits.Properties)},
+		Orgs:             apiUsageLimit{Used: orgsCount, Limit: int64(limits.Orgs)},
+		OrgMembersLimit:  limits.OrgMembers,
+		MonthlyRequests:  apiUsageLimit{Used: s.monthlyRequestsUsage(ctx, user.ID, time.Now().UTC()), Limit: limits.Requests},
+		PuzzlesPerSecond: limits.PuzzleRequestsPerSecond,
+	}
+
+	if limits.Seats > 0 {
+		if seats, err := s.SubscriptionLimits.SeatsCount(ctx, user.ID); err == nil {
+			response.Seats = &apiUsageLimit{Used: int64(seats), Limit: int64(limits.Seats)}
+		}
+	}
+
+	if apiKey != nil {
+		response.RateLimit = apiRateLimit{RequestsPerSecond: apiKey.RequestsPerSecond, Burst: int(apiKey.RequestsBurst)}
+	}
+
+	s.sendAPISuccessResponse(ctx, response, w)
+}
+
+// getQuota returns the state of the monthly requests quota, which is enforced gradually when it's over the limit
+func (s *Server) getQuota(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _, err := s.requestUser(ctx, true /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	subscr, err := s.BusinessDB.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user subscription", "userID", user.ID, common.ErrAttr(err))
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	limits, err := s.SubscriptionLimits.Limits(ctx, subscr)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	tnow := time.Now().UTC()
+	periodStart := db.QuotaPeriodStart(tnow)
+	used := s.monthlyRequestsUsage(ctx, user.ID, tnow)
+
+	response := &apiQuotaOutput{
+		PeriodStart:  periodStart.Format(time.RFC3339),
+		PeriodEnd:    periodStart.AddDate(0, 1, 0).Format(time.RFC3339),
+		Requests:     apiUsageLimit{Used: used, Limit: limits.Requests},
+		UsagePercent: db.QuotaUsagePercent(used, limits.Requests),
+		Stage:        string(dbgen.QuotaStageNormal),
+		Thresholds: apiQuotaThresholds{
+			Warning:  db.QuotaWarningPercent,
+			Degraded: db.QuotaDegradedPercent,
+			Exceeded: db.QuotaExceededPercent,
+		},
+	}
+
+	if quota, err := s.BusinessDB.Impl().RetrieveUserQuota(ctx, user.ID); err == nil {
+		if quota.PeriodStart.Time.Equal(periodStart) {
+			response.Stage = string(quota.Stage)
+			response.UpdatedAt = quota.UpdatedAt.Time.Format(time.RFC3339)
+		}
+	} else if err != db.ErrRecordNotFound {
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	s.sendAPISuccessResponse(ctx, response, w)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/maypok86/otter/v2"
@@ -38,6 +39,7 @@ type AuthMiddleware struct {
 	SitekeyBackfillCancel context.CancelFunc
 	UsersBackfillCancel   context.CancelFunc
 	Limiter               UserLimiter
+	HotProperties         *HotProperties
 	// this is a simple way to control negative cache spam, disabled by default
 	NegativeSitekeyThreshold uint
 }
@@ -140,6 +142,7 @@ func NewAuthMiddleware(store db.Implementor,
 	am := &AuthMiddleware{
 		Store:                 store,
 		Limiter:               userLimiter,
+		HotProperties:         NewHotProperties(store),
 		PlanService:           planService,
 		SitekeyChan:           make(chan string, 100*batchSize),
 		UsersChan:             make(chan int32, 10*batchSize),
@@ -164,12 +167,15 @@ func (am *AuthMiddleware) StartBackfill(backfillDelay time.Duration) {
 		context.WithValue(userBackfillBaseCtx, common.TraceIDContextKey, "users_backfill"))
 	// NOTE: we use the same backfill delay because users processing is slower and sitekey channel will block on it
 	go common.ProcessBatchMap(usersBackfillCtx, am.UsersChan, backfillDelay, am.BatchSize, am.BatchSize*10, am.backfillUsersImpl)
+
+	am.HotProperties.Start(backfillDelay)
 }
 
 func (am *AuthMiddleware) Shutdown() {
 	slog.Debug("Shutting down auth middleware")
 	am.SitekeyBackfillCancel()
 	am.UsersBackfillCancel()
+	am.HotProperties.Shutdown()
 	close(am.SitekeyChan)
 	close(am.UsersChan)
 }
@@ -240,7 +246,7 @@ func (am *AuthMiddleware) originAllowed(r *http.Request, origin string) (bool, [
 
 func isOriginAllowed(origin string, property *dbgen.Property) bool {
 	if common.IsLocalhost(origin) {
-		return property.AllowLocalhost
+		return property.AllowLocalhost || (property.Environment == dbgen.PropertyEnvironmentStaging)
 	}
 
 	if property.AllowSubdomains {
@@ -286,7 +292,12 @@ func (am *AuthMiddleware) Sitekey(next http.Handler) http.Handler {
 
 		// we verify sitekey in underlying DB call
 		sitekey := r.URL.Query().Get(common.ParamSiteKey)
-		property, err := am.Store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, am.refreshPropertyBySitekey)
+		// pinned properties skip the generic cache, but not the checks below
+		property, pinned := am.HotProperties.Get(sitekey)
+		var err error
+		if !pinned {
+			property, err = am.Store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, am.refreshPropertyBySitekey)
+		}
 		if err != nil {
 			switch err {
 			// this will happen when the user does not have such property or it was deleted
@@ -333,6 +344,7 @@ func (am *AuthMiddleware) Sitekey(next http.Handler) http.Handler {
 				return
 			}
 
+			am.HotProperties.Count(sitekey)
 			ctx = context.WithValue(ctx, common.PropertyContextKey, property)
 		} else {
 			ctx = context.WithValue(ctx, common.SitekeyContextKey, sitekey)
@@ -342,7 +354,7 @@ func (am *AuthMiddleware) Sitekey(next http.Handler) http.Handler {
 	})
 }
 
-func isAPIKeyValid(ctx context.Context, key *dbgen.APIKey, tnow time.Time) bool {
+func isAPIKeyValid(ctx context.Context, key *dbgen.APIKey, secret string, tnow time.Time) bool {
 	if key == nil {
 		return false
 	}
@@ -357,6 +369,16 @@ func isAPIKeyValid(ctx context.Context, key *dbgen.APIKey, tnow time.Time) bool
 		return false
 	}
 
+	// key was resolved using previous secret (before rotation)
+	if secret != db.UUIDToSecret(key.ExternalID) {
+		if !key.PreviousExpiresAt.Valid || key.PreviousExpiresAt.Time.Before(tnow) {
+			slog.WarnContext(ctx, "Previous API key secret is expired", "keyID", key.ID, "previousExpiresAt", key.PreviousExpiresAt)
+			return false
+		}
+
+		slog.Log(ctx, common.LevelTrace, "Using previous API key secret", "keyID", key.ID)
+	}
+
 	return true
 }
 
@@ -404,7 +426,7 @@ func (am *AuthMiddleware) APIKey(keyFunc func(r *http.Request) string, scope dbg
 
 			if apiKey != nil {
 				now := time.Now().UTC()
-				if !isAPIKeyValid(ctx, apiKey, now) {
+				if !isAPIKeyValid(ctx, apiKey, secret, now) {
 					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 					return
 				}
@@ -415,6 +437,13 @@ func (am *AuthMiddleware) APIKey(keyFunc func(r *http.Request) string, scope dbg
 					return
 				}
 
+				// checked before anything else that might need DB access
+				addr, _ := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr)
+				if !checkAPIKeySource(ctx, am.Store, apiKey, addr) {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+
 				// if user is not an active subscriber, their properties and orgs might still exist but should not allow API
 				if softRestriction, err := am.Limiter.EvaluateAPIAccess(ctx, apiKey.UserID.Int32); (err == nil) && !softRestriction {
 					slog.WarnContext(ctx, "User is limited for API access", "userID", apiKey.UserID.Int32)
@@ -422,12 +451,94 @@ func (am *AuthMiddleware) APIKey(keyFunc func(r *http.Request) string, scope dbg
 					return
 				}
 
+				// usage of keys with postponed lookup is recorded in apiKeyOwnerSource
+				am.Store.Impl().RecordAPIKeyUsage(apiKey)
+
 				ctx = context.WithValue(ctx, common.APIKeyContextKey, apiKey)
-			} else {
-				ctx = context.WithValue(ctx, common.SecretContextKey, secret)
 			}
 
+			ctx = context.WithValue(ctx, common.SecretContextKey, secret)
+
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// isAPIKeySourceAllowed checks source IP address against ranges that the key itself is restricted to (if any)
+func isAPIKeySourceAllowed(ctx context.Context, apiKey *dbgen.APIKey, addr netip.Addr) bool {
+	if len(apiKey.AllowedCidrs) == 0 {
+		return true
+	}
+
+	prefixes, err := cachedIPRanges.prefixes(ctx, ipRangesKey{kind: ipRangesAPIKey, id: apiKey.ID}, apiKey.AllowedCidrs)
+	if err != nil {
+		// ranges are validated on input so this should not happen, but we fail closed anyways
+		slog.ErrorContext(ctx, "Failed to parse API key allowed CIDRs", "keyID", apiKey.ID, common.ErrAttr(err))
+		return false
+	}
+
+	return common.IsIPAllowed(prefixes, addr)
+}
+
+// checkAPIKeySource records violations (in audit log too) if key is used from outside of its allowed ranges
+func checkAPIKeySource(ctx context.Context, store db.Implementor, apiKey *dbgen.APIKey, addr netip.Addr) bool {
+	if isAPIKeySourceAllowed(ctx, apiKey, addr) {
+		return true
+	}
+
+	slog.WarnContext(ctx, "API key is used outside of its allowed IP ranges", "keyID", apiKey.ID, "ip", addr.String())
+
+	if auditEvent := store.Impl().RecordAPIKeyIPViolation(ctx, apiKey, addr); auditEvent != nil {
+		store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourceAPI)
+	}
+
+	return false
+}
+
+// IPAllowlist enforces org IP allowlist for org-scoped portal API keys. It has to be used after APIKey() middleware.
+// For unscoped keys only the org being accessed is checked, see Server.checkOrgIPAllowlist()
+func (am *AuthMiddleware) IPAllowlist(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		apiKey, ok := ctx.Value(common.APIKeyContextKey).(*dbgen.APIKey)
+		if !ok || (apiKey == nil) {
+			// this is the "postponed" DB access mentioned in APIKey() middleware (portal API is not a hot path)
+			secret, _ := ctx.Value(common.SecretContextKey).(string)
+			key, err := am.Store.Impl().RetrieveAPIKey(ctx, secret)
+			if (err != nil) || (key == nil) {
+				// handler will report invalid API key
+				next.ServeHTTP(w, r)
+				return
+			}
+			apiKey = key
+		}
+
+		addr, _ := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr)
+		if !ok && !checkAPIKeySource(ctx, am.Store, apiKey, addr) {
+			// cached keys were already checked in APIKey() middleware
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if !apiKey.OrgID.Valid {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, err := am.Store.Impl().IsOrgIPAllowed(ctx, apiKey.OrgID.Int32, addr)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to check IP allowlist for API key", "userID", apiKey.UserID.Int32, common.ErrAttr(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			slog.WarnContext(ctx, "API key is used outside of org IP allowlist", "userID", apiKey.UserID.Int32, "orgID", apiKey.OrgID.Int32, "ip", addr.String())
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
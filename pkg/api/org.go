@@ -3,15 +3,20 @@
 package api
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
-	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
+)
+
+const (
+	maxOrgsPageSize = 100
 )
 
 func orgToAPIOrg(org *dbgen.Organization, hasher common.IdentifierHasher) *apiOrgOutput {
@@ -21,20 +26,41 @@ func orgToAPIOrg(org *dbgen.Organization, hasher common.IdentifierHasher) *apiOr
 	}
 }
 
-func orgsToAPIOrgs(orgs []*dbgen.GetUserOrganizationsRow, hasher common.IdentifierHasher, onlyOwned bool, orgID *int32) []*apiOrgOutput {
-	result := make([]*apiOrgOutput, 0, len(orgs))
+// filterOrgs returns orgs in the stable (created_at, id) order, that pagination relies on
+func filterOrgs(orgs []*dbgen.GetUserOrganizationsRow, onlyOwned bool, orgID *int32) []*dbgen.GetUserOrganizationsRow {
+	result := make([]*dbgen.GetUserOrganizationsRow, 0, len(orgs))
 	for _, org := range orgs {
 		if (!onlyOwned || (org.Level == dbgen.AccessLevelOwner)) &&
 			((orgID == nil) || (org.Organization.ID == *orgID)) {
-			result = append(result, &apiOrgOutput{
-				Name: org.Organization.Name,
-				ID:   hasher.Encrypt(int(org.Organization.ID)),
-			})
+			result = append(result, org)
 		}
 	}
+
+	slices.SortFunc(result, func(a, b *dbgen.GetUserOrganizationsRow) int {
+		if c := a.Organization.CreatedAt.Time.Compare(b.Organization.CreatedAt.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Organization.ID, b.Organization.ID)
+	})
+
+	return result
+}
+
+func orgsToAPIOrgs(orgs []*dbgen.GetUserOrganizationsRow, hasher common.IdentifierHasher) []*apiOrgOutput {
+	result := make([]*apiOrgOutput, 0, len(orgs))
+	for _, org := range orgs {
+		result = append(result, &apiOrgOutput{
+			Name: org.Organization.Name,
+			ID:   hasher.Encrypt(int(org.Organization.ID)),
+		})
+	}
 	return result
 }
 
+func orgCursor(org *dbgen.GetUserOrganizationsRow) *pagination.Cursor {
+	return pagination.NewCursor(org.Organization.CreatedAt.Time, int64(org.Organization.ID))
+}
+
 // the difference from portal server is that we are more strict here
 func (s *Server) validateOrgsLimit(ctx context.Context, user *dbgen.User) (bool, error) {
 	var subscr *dbgen.Subscription
@@ -51,6 +77,14 @@ func (s *Server) validateOrgsLimit(ctx context.Context, user *dbgen.User) (bool,
 	ok, extra, err := s.SubscriptionLimits.CheckOrgsLimit(ctx, user.ID, subscr)
 	if err != nil {
 		if err == db.ErrNoActiveSubscription {
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceOrgs,
+				Code:         common.StatusOrgLimitError,
+				UserID:       user.ID,
+				Subscription: subscr,
+				Requested:    1,
+				Err:          err,
+			})
 			return false, nil
 		}
 		return false, err
@@ -60,6 +94,15 @@ func (s *Server) validateOrgsLimit(ctx context.Context, user *dbgen.User) (bool,
 		slog.WarnContext(ctx, "Organizations limit check failed", "extra", extra, "userID", user.ID, "subscriptionID", subscr.ID,
 			"internal", db.IsInternalSubscription(subscr.Source))
 
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceOrgs,
+			Code:         common.StatusOrgLimitError,
+			UserID:       user.ID,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    1,
+		})
+
 		return false, nil
 	}
 
@@ -70,7 +113,13 @@ func (s *Server) getUserOrgs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	request, err := pagination.ParseRequest(ctx, r, maxOrgsPageSize, s.IDHasher)
+	if err != nil {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
 		return
 	}
 
@@ -86,35 +135,45 @@ func (s *Server) getUserOrgs(w http.ResponseWriter, r *http.Request) {
 		orgID = &apiKey.OrgID.Int32
 	}
 
-	orgsOutput := orgsToAPIOrgs(orgs, s.IDHasher, true /*only owned*/, orgID)
-	s.sendAPISuccessResponseEx(ctx, &APIResponse{Data: orgsOutput}, w, common.NoCacheHeaders)
+	orgs = filterOrgs(orgs, true /*only owned*/, orgID)
+	page, hasMore, next := pagination.Slice(orgs, request, orgCursor)
+
+	response := &APIResponse{
+		Data:       orgsToAPIOrgs(page, s.IDHasher),
+		Pagination: request.NewResponse(hasMore, next, s.IDHasher),
+	}
+
+	if request.IncludeTotal {
+		total := int64(len(orgs))
+		response.Pagination.TotalEstimate = &total
+	}
+
+	s.sendAPISuccessResponseEx(ctx, response, w, common.NoCacheHeaders)
 }
 
 func (s *Server) postNewOrg(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
 		return
 	}
 
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
 	if apiKey.OrgID.Valid {
 		slog.WarnContext(ctx, "API key is scoped to the organization", "orgID", apiKey.OrgID.Int32)
-		s.sendHTTPErrorResponse(db.ErrPermissions, w)
+		s.sendHTTPErrorResponse(db.ErrPermissions, r, w)
 		return
 	}
 
 	request := &apiOrgInput{}
-	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
-		if err != io.EOF {
-			slog.WarnContext(ctx, "Failed to deserialize post org request", common.ErrAttr(err))
-		}
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize post org request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
 		return
 	}
 
@@ -149,23 +208,21 @@ func (s *Server) postNewOrg(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) updateOrg(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
 		return
 	}
 
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
 	request := &apiOrgInput{}
-	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
-		if err != io.EOF {
-			slog.WarnContext(ctx, "Failed to deserialize update org request", common.ErrAttr(err))
-		}
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize update org request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
 		return
 	}
 
@@ -190,7 +247,12 @@ func (s *Server) updateOrg(w http.ResponseWriter, r *http.Request) {
 
 	if apiKey.OrgID.Valid && (int32(orgID) != apiKey.OrgID.Int32) {
 		slog.WarnContext(ctx, "API key org scope mismatch", "actualOrgID", orgID, "apiOrgID", apiKey.OrgID.Int32)
-		s.sendHTTPErrorResponse(db.ErrPermissions, w)
+		s.sendHTTPErrorResponse(db.ErrPermissions, r, w)
+		return
+	}
+
+	if err := s.checkOrgIPAllowlist(ctx, int32(orgID)); err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
@@ -233,23 +295,21 @@ func (s *Server) updateOrg(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) deleteOrg(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
 		return
 	}
 
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
 	request := &apiOrgInput{}
-	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
-		if err != io.EOF {
-			slog.WarnContext(ctx, "Failed to deserialize delete org request", common.ErrAttr(err))
-		}
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize delete org request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
 		return
 	}
 
@@ -268,7 +328,12 @@ func (s *Server) deleteOrg(w http.ResponseWriter, r *http.Request) {
 
 	if apiKey.OrgID.Valid && (int32(orgID) != apiKey.OrgID.Int32) {
 		slog.WarnContext(ctx, "API key org scope mismatch", "actualOrgID", orgID, "apiOrgID", apiKey.OrgID.Int32)
-		s.sendHTTPErrorResponse(db.ErrPermissions, w)
+		s.sendHTTPErrorResponse(db.ErrPermissions, r, w)
+		return
+	}
+
+	if err := s.checkOrgIPAllowlist(ctx, int32(orgID)); err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
@@ -0,0 +1,489 @@
+//go:build enterprise
+
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/orgarchive"
+)
+
+const (
+	exportOrgHandlerID = "api-export-org"
+	importOrgHandlerID = "api-import-org"
+
+	orgImportEntityOrg      = "organization"
+	orgImportEntityProperty = "property"
+	orgImportEntityMember   = "member"
+	orgImportEntityAPIKey   = "api_key"
+)
+
+type asyncTaskExportOrg struct {
+	OrgID int32           `json:"org_id"`
+	Key   *orgarchive.Key `json:"key"`
+}
+
+// archive is only opened in the async task as deriving the key is expensive. Passphrase is kept in the task input
+// for as long as the task itself (the same as the derived key of the export task)
+type asyncTaskImportOrg struct {
+	Envelope   *orgarchive.Envelope `json:"envelope"`
+	Passphrase string               `json:"passphrase"`
+}
+
+func (s *Server) scheduleAsyncTask(ctx context.Context, request any, handlerID string, user *dbgen.User, apiKey *dbgen.APIKey, w http.ResponseWriter, r *http.Request) {
+	referenceID := db.UUIDToSecret(apiKey.ExternalID)
+
+	buffer := 5 * time.Minute
+	// we schedule it for later, making "room" for immediate attempt first
+	scheduledAt := time.Now().UTC().Add(buffer)
+	task, err := s.BusinessDB.Impl().CreateNewAsyncTask(ctx, request, handlerID, user, scheduledAt, referenceID)
+	if err != nil {
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	output := &apiAsyncTaskOutput{
+		ID: db.UUIDToString(task.ID),
+	}
+
+	s.sendAPISuccessResponse(ctx, output, w)
+
+	go func(bctx context.Context) {
+		handlerCtx, cancel := context.WithTimeout(bctx, buffer)
+		defer cancel()
+		if err := s.AsyncTasks.Execute(handlerCtx, task); err != nil {
+			slog.ErrorContext(bctx, "Failed to execute async task", "taskID", output.ID, common.ErrAttr(err))
+		}
+	}(common.CopyTraceID(ctx, context.Background()))
+}
+
+func (s *Server) postOrgExport(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return
+	}
+
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, true /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	request := &apiOrgExportInput{}
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize org export request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+		return
+	}
+
+	key, err := orgarchive.NewKey(request.Passphrase)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to derive org archive key", common.ErrAttr(err))
+		if err == orgarchive.ErrPassphrase {
+			s.sendAPIRequestErrorResponse(ctx, newAPIRequestError(common.StatusOrgPassphraseError, "passphrase"), r, w)
+		} else {
+			s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		}
+		return
+	}
+
+	s.scheduleAsyncTask(ctx, &asyncTaskExportOrg{OrgID: org.ID, Key: key}, exportOrgHandlerID, user, apiKey, w, r)
+}
+
+func (s *Server) handleExportOrg(ctx context.Context, task *dbgen.AsyncTask) ([]byte, error) {
+	taskID := db.UUIDToString(task.ID)
+	tlog := slog.With("taskID", taskID)
+
+	tlog.DebugContext(ctx, "Processing export org task")
+
+	params := &asyncTaskExportOrg{}
+	if err := json.Unmarshal(task.Input, params); err != nil {
+		tlog.ErrorContext(ctx, "Failed to unmarshal export org async task input", common.ErrAttr(err))
+		return nil, err
+	}
+
+	user, err := s.BusinessDB.Impl().RetrieveUser(ctx, task.UserID.Int32)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve user", "userID", task.UserID.Int32, common.ErrAttr(err))
+		return nil, err
+	}
+
+	org, err := s.BusinessDB.Impl().RetrieveUserOrganization(ctx, user, params.OrgID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve org", "orgID", params.OrgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	if !org.UserID.Valid || (org.UserID.Int32 != user.ID) {
+		tlog.WarnContext(ctx, "Only org owner can export the org", "userID", user.ID, "orgID", org.ID)
+		return nil, db.ErrPermissions
+	}
+
+	archive, err := orgarchive.Export(ctx, s.BusinessDB.Impl(), user, org)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := params.Key.Seal(archive)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to sign org archive", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return json.Marshal(&apiOrgExportOutput{
+		Archive:    envelope,
+		Properties: len(archive.Properties),
+		Members:    len(archive.Members),
+		APIKeys:    len(archive.APIKeys),
+	})
+}
+
+func (s *Server) postOrgImport(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return
+	}
+
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	if apiKey.OrgID.Valid {
+		slog.WarnContext(ctx, "API key is scoped to the organization", "orgID", apiKey.OrgID.Int32)
+		s.sendHTTPErrorResponse(db.ErrPermissions, r, w)
+		return
+	}
+
+	request := &apiOrgImportInput{}
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize org import request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+		return
+	}
+
+	if err := request.Archive.Check(); err != nil {
+		slog.WarnContext(ctx, "Org archive is not valid", common.ErrAttr(err))
+		s.sendAPIRequestErrorResponse(ctx, newAPIRequestError(common.StatusOrgArchiveError, "archive"), r, w)
+		return
+	}
+
+	if ok, err := s.validateOrgsLimit(ctx, user); !ok || err != nil {
+		s.sendAPIErrorResponse(ctx, common.StatusOrgLimitError, r, w)
+		return
+	}
+
+	task := &asyncTaskImportOrg{Envelope: request.Archive, Passphrase: request.Passphrase}
+	s.scheduleAsyncTask(ctx, task, importOrgHandlerID, user, apiKey, w, r)
+}
+
+func (s *Server) handleImportOrg(ctx context.Context, task *dbgen.AsyncTask) ([]byte, error) {
+	taskID := db.UUIDToString(task.ID)
+	tlog := slog.With("taskID", taskID)
+
+	tlog.DebugContext(ctx, "Processing import org task")
+
+	params := &asyncTaskImportOrg{}
+	if err := json.Unmarshal(task.Input, params); (err != nil) || (params.Envelope == nil) {
+		tlog.ErrorContext(ctx, "Failed to unmarshal import org async task input", common.ErrAttr(err))
+		return nil, cmp.Or(err, db.ErrInvalidInput)
+	}
+
+	user, err := s.BusinessDB.Impl().RetrieveUser(ctx, task.UserID.Int32)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve user", "userID", task.UserID.Int32, common.ErrAttr(err))
+		return nil, err
+	}
+
+	var results []*apiOrgImportResult
+
+	// opening the archive will not succeed on retry either, so it is reported as a result instead of an error
+	if archive, err := orgarchive.Open(params.Envelope, params.Passphrase); err != nil {
+		tlog.WarnContext(ctx, "Failed to open org archive", common.ErrAttr(err))
+		code := common.StatusOrgArchiveError
+		if errors.Is(err, orgarchive.ErrSignature) {
+			code = common.StatusOrgArchiveSignatureError
+		}
+		results = []*apiOrgImportResult{{Entity: orgImportEntityOrg, Code: code}}
+	} else {
+		results = s.doImportOrg(ctx, tlog, user, archive)
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to serialize results", common.ErrAttr(err))
+		data = nil
+	}
+
+	return data, nil
+}
+
+func (s *Server) doImportOrg(ctx context.Context, tlog *slog.Logger, user *dbgen.User, archive *orgarchive.Archive) []*apiOrgImportResult {
+	results := make([]*apiOrgImportResult, 0, 1+len(archive.Properties)+len(archive.Members)+len(archive.APIKeys))
+
+	orgResult := &apiOrgImportResult{Entity: orgImportEntityOrg, Name: archive.Organization.Name, Code: common.StatusFailure}
+	results = append(results, orgResult)
+
+	if nameStatus := s.BusinessDB.Impl().ValidateOrgName(ctx, archive.Organization.Name, user); !nameStatus.Success() {
+		tlog.WarnContext(ctx, "Organization name is not valid", "code", nameStatus)
+		orgResult.Code = nameStatus
+		return results
+	}
+
+	if ok, err := s.validateOrgsLimit(ctx, user); !ok || err != nil {
+		orgResult.Code = common.StatusOrgLimitError
+		return results
+	}
+
+	org, auditEvent, err := s.BusinessDB.Impl().CreateNewOrganization(ctx, archive.Organization.Name, user.ID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to create the organization", common.ErrAttr(err))
+		return results
+	}
+
+	s.BusinessDB.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourceAPI)
+
+	orgResult.Code = common.StatusOK
+	orgResult.ID = s.IDHasher.Encrypt(int(org.ID))
+
+	results = append(results, s.doImportOrgProperties(ctx, tlog, user, org, archive.Properties)...)
+	results = append(results, s.doImportOrgMembers(ctx, tlog, user, org, archive.Members)...)
+
+	for _, key := range archive.APIKeys {
+		results = append(results, &apiOrgImportResult{Entity: orgImportEntityAPIKey, Name: key.Name, Code: common.StatusAPIKeyNotImportedError})
+	}
+
+	tlog.InfoContext(ctx, "Imported organization", "orgID", org.ID, "properties", len(archive.Properties),
+		"members", len(archive.Members), "apiKeys", len(archive.APIKeys))
+
+	return results
+}
+
+func (s *Server) doImportOrgProperties(ctx context.Context, tlog *slog.Logger, user *dbgen.User, org *dbgen.Organization, properties []*orgarchive.Property) []*apiOrgImportResult {
+	results := make([]*apiOrgImportResult, 0, len(properties))
+	if len(properties) == 0 {
+		return results
+	}
+
+	allowed := len(properties)
+
+	owner, subscr, err := s.BusinessDB.Impl().RetrieveOrgOwnerWithSubscription(ctx, org, user)
+	if err == nil {
+		// extra == (count - plan.limit()) so negative "extra" means we have left (-extra) space for new properties
+		ok, extra, err := s.SubscriptionLimits.CheckOrgPropertiesLimit(ctx, org, owner.ID, subscr)
+		if (err != nil) || !ok {
+			allowed = 0
+		} else {
+			allowed = min(allowed, -extra)
+		}
+
+		if allowed < len(properties) {
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceProperties,
+				Code:         common.StatusSubscriptionPropertyLimitError,
+				UserID:       user.ID,
+				Org:          org,
+				Subscription: subscr,
+				Extra:        extra,
+				Requested:    len(properties),
+				Err:          err,
+			})
+		}
+	} else {
+		tlog.ErrorContext(ctx, "Failed to retrieve org owner with subscription", common.ErrAttr(err))
+		allowed = 0
+	}
+
+	// twins can be only created after their production properties
+	sorted := slices.Clone(properties)
+	slices.SortStableFunc(sorted, func(a, b *orgarchive.Property) int {
+		return cmp.Compare(len(a.TwinRef), len(b.TwinRef))
+	})
+
+	created := make(map[string]int32, len(sorted))
+
+	for i, p := range sorted {
+		result := &apiOrgImportResult{Entity: orgImportEntityProperty, Name: p.Name}
+		results = append(results, result)
+
+		if i >= allowed {
+			result.Code = common.StatusSubscriptionPropertyLimitError
+			continue
+		}
+
+		input := &apiCreatePropertyInput{
+			apiPropertySettings: apiPropertySettings{
+				Name:                   p.Name,
+				Level:                  p.Level,
+				Growth:                 p.Growth,
+				ValiditySeconds:        p.ValiditySeconds,
+				AllowSubdomains:        p.AllowSubdomains,
+				AllowLocalhost:         p.AllowLocalhost,
+				MaxReplayCount:         p.MaxReplayCount,
+				ClockSkewSec:           p.ClockSkewSeconds,
+				RememberSec:            p.RememberSeconds,
+				DifferentialDifficulty: p.DifferentialDifficulty,
+				BotPolicy:              p.BotPolicy,
+				DifficultyStrategy:     p.DifficultyStrategy,
+				FailureURL:             p.FailureURL,
+				FailureMessage:         p.FailureMessage,
+				RequireInteraction:     p.RequireInteraction,
+				NoAutoRefresh:          p.NoAutoRefresh,
+				ImageChallenge:         p.ImageChallenge,
+				TrustGroup:             p.TrustGroup,
+				Claims:                 p.Claims,
+			},
+			Domain:      p.Domain,
+			Environment: p.Environment,
+		}
+
+		if nameStatus := s.BusinessDB.Impl().ValidatePropertyName(ctx, input.Name, nil /*org*/); !nameStatus.Success() {
+			result.Code = nameStatus
+			continue
+		}
+
+		if _, ok := input.PropertyEnvironment(); !ok {
+			result.Code = common.StatusPropertyEnvironmentError
+			continue
+		}
+
+		if _, ok := encodePropertyClaims(ctx, input.Claims); !ok {
+			result.Code = common.StatusPropertyClaimsError
+			continue
+		}
+
+		if len(p.TwinRef) > 0 {
+			twinID, ok := created[p.TwinRef]
+			if !ok {
+				result.Code = common.StatusPropertyTwinError
+				continue
+			}
+			input.TwinID = s.IDHasher.Encrypt(int(twinID))
+		}
+
+		property, status := s.doCreateProperty(ctx, tlog.With("index", i), input, user, org)
+		result.Code = status
+		if property != nil {
+			created[p.Ref] = property.ID
+			result.ID = s.IDHasher.Encrypt(int(property.ID))
+		}
+	}
+
+	return results
+}
+
+func (s *Server) doImportOrgMembers(ctx context.Context, tlog *slog.Logger, user *dbgen.User, org *dbgen.Organization, members []*orgarchive.Member) []*apiOrgImportResult {
+	results := make([]*apiOrgImportResult, 0, len(members))
+	if len(members) == 0 {
+		return results
+	}
+
+	var subscr *dbgen.Subscription
+	if user.SubscriptionID.Valid {
+		var err error
+		if subscr, err = s.BusinessDB.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32); err != nil {
+			tlog.ErrorContext(ctx, "Failed to retrieve user subscription", "userID", user.ID, common.ErrAttr(err))
+		}
+	}
+
+	orgURLPath := "/" + common.OrgEndpoint + "/" + s.IDHasher.Encrypt(int(org.ID))
+	seen := make(map[int32]struct{}, len(members))
+	invited := false
+
+	for _, m := range members {
+		// NOTE: we do not disclose member emails in results as they are not necessarily known to the importer
+		result := &apiOrgImportResult{Entity: orgImportEntityMember, Name: common.MaskEmail(m.Email, '*')}
+		results = append(results, result)
+
+		member, err := s.BusinessDB.Impl().FindUserByEmail(ctx, m.Email)
+		if err != nil {
+			result.Code = common.StatusOrgMemberNotFoundError
+			continue
+		}
+
+		if _, ok := seen[member.ID]; ok || (member.ID == user.ID) {
+			result.Code = common.StatusOK
+			continue
+		}
+		seen[member.ID] = struct{}{}
+
+		if ok, extra, err := s.SubscriptionLimits.CheckOrgMembersLimit(ctx, org.ID, subscr); (err != nil) || !ok {
+			result.Code = common.StatusOrgMembersLimitError
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceOrgMembers,
+				Code:         common.StatusOrgMembersLimitError,
+				UserID:       user.ID,
+				Org:          org,
+				Subscription: subscr,
+				Extra:        extra,
+				Requested:    1,
+				Err:          err,
+			})
+			continue
+		}
+
+		if ok, extra, err := s.SubscriptionLimits.CheckSeatsLimit(ctx, user.ID, subscr); (err != nil) || !ok {
+			result.Code = common.StatusSubscriptionSeatsLimitError
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceSeats,
+				Code:         common.StatusSubscriptionSeatsLimitError,
+				UserID:       user.ID,
+				Org:          org,
+				Subscription: subscr,
+				Extra:        extra,
+				Requested:    1,
+				Err:          err,
+			})
+			continue
+		}
+
+		// membership has to be accepted again in any case
+		auditEvent, err := s.BusinessDB.Impl().InviteUserToOrg(ctx, user, org, member)
+		if err != nil {
+			tlog.ErrorContext(ctx, "Failed to invite user to org", "userID", member.ID, common.ErrAttr(err))
+			result.Code = common.StatusFailure
+			continue
+		}
+
+		s.BusinessDB.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourceAPI)
+		result.Code = common.StatusOK
+		invited = true
+
+		if err := s.Mailer.SendOrgInvite(ctx, member.Email, common.GuessFirstName(member.Name),
+			org.Name, user.Email, common.GuessFirstName(user.Name), orgURLPath); err != nil {
+			tlog.ErrorContext(ctx, "Failed to send org invite", "userID", member.ID, common.ErrAttr(err))
+		}
+	}
+
+	// seats are billed only for committed memberships
+	if invited {
+		if err := s.SubscriptionLimits.SyncSeats(ctx, user.ID, subscr); err != nil {
+			tlog.ErrorContext(ctx, "Failed to sync subscription seats", "userID", user.ID, common.ErrAttr(err))
+		}
+	}
+
+	return results
+}
@@ -695,3 +695,45 @@ func TestAPIOrgInvalidRequests(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIOrgIPAllowlistOnlyAccessedOrg(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, org, apiKey, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	org2, _, err := store.Impl().CreateNewOrganization(ctx, t.Name()+"-another-org", user.ID)
+	if err != nil {
+		t.Fatalf("Failed to create extra org: %v", err)
+	}
+
+	// random test IPs are never from TEST-NET-1
+	if _, err := store.Impl().UpdateOrgIPAllowlist(ctx, user, org2, []string{}, []string{"192.0.2.0/24"}, false /*recovery*/); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		org    *dbgen.Organization
+		status int
+	}{
+		{org, http.StatusOK},
+		{org2, http.StatusForbidden},
+	} {
+		resp, err := apiRequestSuite(ctx, nil, http.MethodGet,
+			fmt.Sprintf("/%s/%s/%s", common.OrgEndpoint, s.IDHasher.Encrypt(int(tc.org.ID)), common.PropertiesEndpoint),
+			apiKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != tc.status {
+			t.Errorf("Unexpected status code for org %v: %v (expected %v)", tc.org.ID, resp.StatusCode, tc.status)
+		}
+	}
+}
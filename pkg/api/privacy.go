@@ -0,0 +1,44 @@
+package api
+
+import (
+	"hash"
+	"net/netip"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"golang.org/x/crypto/blake2b"
+)
+
+// privacyMode returns the effective privacy mode for the property (installation mode can only be made stricter)
+func (v *Verifier) privacyMode(property *dbgen.Property) common.PrivacyMode {
+	var installation, override common.PrivacyMode
+
+	if v.PrivacyMode != nil {
+		installation = common.ParsePrivacyMode(v.PrivacyMode.Value())
+	}
+
+	if property != nil {
+		override = common.ParsePrivacyMode(property.PrivacyMode)
+	}
+
+	return common.EffectivePrivacyMode(installation, override)
+}
+
+// newIPHash creates keyed hash for identifiers derived from IP address that end up in storage
+func (v *Verifier) newIPHash(mode common.PrivacyMode) (hash.Hash, error) {
+	key := v.UserFingerprintKey.Value()
+	if mode.RotatesSalt() && (v.PrivacySalt != nil) {
+		key = v.PrivacySalt.Value()
+	}
+
+	return blake2b.New256(key)
+}
+
+// privateIP returns address that can be used as the input of the hash in the given privacy mode
+func privateIP(ip netip.Addr, mode common.PrivacyMode) netip.Addr {
+	if mode.Truncates() {
+		return common.TruncateIP(ip)
+	}
+
+	return ip
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestVerifierPrivacyMode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		installation string
+		property     string
+		expected     common.PrivacyMode
+	}{
+		{"", "", common.PrivacyModeNone},
+		{"", "hash", common.PrivacyModeHash},
+		{"truncate", "", common.PrivacyModeTruncate},
+		// property cannot weaken installation mode
+		{"hash", "none", common.PrivacyModeHash},
+		{"Truncate", "hash", common.PrivacyModeHash},
+		{"invalid", "invalid", common.PrivacyModeNone},
+	}
+
+	for i, tc := range testCases {
+		verifier := &Verifier{PrivacyMode: config.NewStaticValue(common.PrivacyModeKey, tc.installation)}
+		if mode := verifier.privacyMode(&dbgen.Property{PrivacyMode: tc.property}); mode != tc.expected {
+			t.Errorf("Unexpected privacy mode (%v): %v", i, mode)
+		}
+	}
+}
+
+func TestPrivateIP(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		ip       string
+		mode     common.PrivacyMode
+		expected string
+	}{
+		{"192.168.1.10", common.PrivacyModeNone, "192.168.1.10"},
+		{"192.168.1.10", common.PrivacyModeTruncate, "192.168.1.0"},
+		{"::ffff:192.168.1.10", common.PrivacyModeHash, "192.168.1.0"},
+		{"2001:db8:1:2:3:4:5:6", common.PrivacyModeTruncate, "2001:db8:1::"},
+	}
+
+	for _, tc := range testCases {
+		if ip := privateIP(netip.MustParseAddr(tc.ip), tc.mode); ip.String() != tc.expected {
+			t.Errorf("Unexpected private IP for %v (%v): %v", tc.ip, tc.mode, ip)
+		}
+	}
+}
+
+func TestReceiptIPPrefixHashRotatingSalt(t *testing.T) {
+	t.Parallel()
+
+	verifier := &Verifier{UserFingerprintKey: NewUserFingerprintKey(nil), PrivacySalt: db.NewPrivacySalt()}
+	ip := netip.MustParseAddr("192.168.1.10")
+
+	keyed := verifier.ipPrefixHash(ip, common.PrivacyModeTruncate)
+	salted := verifier.ipPrefixHash(ip, common.PrivacyModeHash)
+	if (keyed == 0) || (salted == 0) || (keyed == salted) {
+		t.Errorf("Unexpected hashes: keyed=%v salted=%v", keyed, salted)
+	}
+
+	verifier.PrivacySalt.Update([]byte("another salt"))
+	if rotated := verifier.ipPrefixHash(ip, common.PrivacyModeHash); rotated == salted {
+		t.Error("Hash did not change after salt rotation")
+	}
+}
@@ -15,8 +15,11 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jpillora/backoff"
 	"golang.org/x/net/idna"
 )
@@ -43,9 +46,54 @@ type asyncTaskUpdateProperties struct {
 	Properties   []*apiUpdatePropertyInput `json:"properties"`
 }
 
+func (p *apiPropertySettings) WidgetFlags() int16 {
+	var flags puzzle.WidgetFlags
+	if p.RequireInteraction {
+		flags |= puzzle.WidgetFlagRequireInteraction
+	}
+	if p.NoAutoRefresh {
+		flags |= puzzle.WidgetFlagNoAutoRefresh
+	}
+	if p.ImageChallenge {
+		flags |= puzzle.WidgetFlagImageChallenge
+	}
+	return int16(flags)
+}
+
+func (p *apiCreatePropertyInput) PropertyEnvironment() (dbgen.PropertyEnvironment, bool) {
+	switch p.Environment {
+	case "", string(dbgen.PropertyEnvironmentProduction):
+		return dbgen.PropertyEnvironmentProduction, true
+	case string(dbgen.PropertyEnvironmentStaging):
+		return dbgen.PropertyEnvironmentStaging, true
+	default:
+		return "", false
+	}
+}
+
+func (s *Server) parseTwinID(ctx context.Context, value string) (pgtype.Int4, bool) {
+	if len(value) == 0 {
+		return pgtype.Int4{}, true
+	}
+
+	twinID, err := s.IDHasher.Decrypt(value)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to decrypt twin property ID", "id", value, common.ErrAttr(err))
+		return pgtype.Int4{}, false
+	}
+
+	return db.Int(int32(twinID)), true
+}
+
 func (p *apiPropertySettings) Normalize() {
 	p.Name = strings.TrimSpace(p.Name)
 
+	const maxTrustGroupLength = 64
+	p.TrustGroup = strings.ToLower(strings.TrimSpace(p.TrustGroup))
+	if runes := []rune(p.TrustGroup); len(runes) > maxTrustGroupLength {
+		p.TrustGroup = string(runes[:maxTrustGroupLength])
+	}
+
 	const (
 		minDifficultyLevel = 1
 		maxDifficultyLevel = int(common.MaxDifficultyLevel)
@@ -58,6 +106,9 @@ func (p *apiPropertySettings) Normalize() {
 	)
 	p.MaxReplayCount = max(minMaxReplayValue, min(p.MaxReplayCount, maxMaxReplayValue))
 
+	p.ClockSkewSec = max(0, min(p.ClockSkewSec, int(puzzle.MaxClockSkew.Seconds())))
+	p.RememberSec = max(0, min(p.RememberSec, int(puzzle.MaxRememberWindow.Seconds())))
+
 	switch p.Growth {
 	case string(dbgen.DifficultyGrowthConstant),
 		string(dbgen.DifficultyGrowthFast),
@@ -67,6 +118,18 @@ func (p *apiPropertySettings) Normalize() {
 		p.Growth = string(dbgen.DifficultyGrowthMedium)
 	}
 
+	p.FailureURL = strings.TrimSpace(p.FailureURL)
+	p.FailureMessage = db.NormalizeFailureMessage(p.FailureMessage)
+	p.DifficultyStrategy = strings.TrimSpace(p.DifficultyStrategy)
+
+	switch p.BotPolicy {
+	case string(dbgen.BotPolicyMonitor),
+		string(dbgen.BotPolicyMaxDifficulty),
+		string(dbgen.BotPolicyBlock):
+	default:
+		p.BotPolicy = string(dbgen.BotPolicyMonitor)
+	}
+
 	if p.ValiditySeconds > 0 {
 		validityIndex := puzzle.ValidityIntervalToIndex(time.Duration(p.ValiditySeconds) * time.Second)
 		p.ValiditySeconds = int(puzzle.ValidityDurations[validityIndex].Seconds())
@@ -76,9 +139,9 @@ func (p *apiPropertySettings) Normalize() {
 	}
 }
 
-func (s *Server) readCreatePropertiesRequest(ctx context.Context, r *http.Request, orgID int32) ([]*apiCreatePropertyInput, common.StatusCode, error) {
+func (s *Server) readCreatePropertiesRequest(ctx context.Context, r *http.Request, orgID int32) ([]*apiCreatePropertyInput, *apiRequestError, error) {
 	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
-		return nil, 0, db.ErrInvalidInput
+		return nil, nil, db.ErrInvalidInput
 	}
 
 	namesMap := make(map[string]struct{}, maxPropertiesBatchSize/2)
@@ -98,21 +161,28 @@ func (s *Server) readCreatePropertiesRequest(ctx context.Context, r *http.Reques
 		if err != io.EOF {
 			slog.WarnContext(ctx, "Failed to parse new properties request: expected '['", common.ErrAttr(err))
 		}
-		return nil, 0, db.ErrInvalidInput
+		return nil, newSchemaRequestError(&apiFieldError{Message: "expected array"}), nil
 	}
 
 	for decoder.More() {
 		if len(inputs) >= maxPropertiesBatchSize {
 			slog.WarnContext(ctx, "Too many properties in a batch", "count", len(inputs), "max", maxPropertiesBatchSize)
-			return nil, common.StatusPropertiesTooManyError, nil
+			return nil, newAPIRequestError(common.StatusPropertiesTooManyError, indexPath("", maxPropertiesBatchSize)), nil
 		}
 
+		path := indexPath("", len(inputs))
+
 		var input apiCreatePropertyInput
 		if err := decoder.Decode(&input); err != nil {
 			if err != io.EOF {
 				slog.WarnContext(ctx, "Failed to parse new properties request", common.ErrAttr(err))
 			}
-			return nil, 0, db.ErrInvalidInput
+			return nil, newSchemaRequestError(decodeFieldError(path, err)), nil
+		}
+
+		if errs := validateSchema(path, &input); len(errs) > 0 {
+			slog.WarnContext(ctx, "New property failed schema validation", "index", len(inputs), "errors", len(errs))
+			return nil, newSchemaRequestError(errs...), nil
 		}
 
 		ilog := slog.With("index", len(inputs), "domain", input.Domain, "name", input.Name)
@@ -120,40 +190,40 @@ func (s *Server) readCreatePropertiesRequest(ctx context.Context, r *http.Reques
 		name := strings.TrimSpace(input.Name)
 		if _, ok := namesMap[name]; ok {
 			ilog.WarnContext(ctx, "Property name duplicate found")
-			return nil, common.StatusPropertyNameDuplicateError, nil
+			return nil, newAPIRequestError(common.StatusPropertyNameDuplicateError, fieldPath(path, "name")), nil
 		}
 
 		if nameStatus := s.BusinessDB.Impl().ValidatePropertyName(ctx, name, nil /*org*/); !nameStatus.Success() {
 			ilog.WarnContext(ctx, "Property name failed validation", "reason", nameStatus.String())
-			return nil, nameStatus, nil
+			return nil, newAPIRequestError(nameStatus, fieldPath(path, "name")), nil
 		}
 
 		namesMap[name] = struct{}{}
 
-		if len(input.Domain) == 0 {
-			ilog.WarnContext(ctx, "Property domain name is empty")
-			return nil, common.StatusPropertyDomainEmptyError, nil
+		if _, status := validatePropertyDomain(ctx, ilog, input.Domain); !status.Success() {
+			return nil, newAPIRequestError(status, fieldPath(path, "domain")), nil
 		}
 
-		domain, err := common.ParseDomainName(input.Domain)
-		if err != nil {
-			ilog.WarnContext(ctx, "Failed to parse domain name", common.ErrAttr(err))
-			return nil, common.StatusPropertyDomainFormatError, nil
+		if _, ok := input.PropertyEnvironment(); !ok {
+			ilog.WarnContext(ctx, "Property environment is not valid", "environment", input.Environment)
+			return nil, newAPIRequestError(common.StatusPropertyEnvironmentError, fieldPath(path, "environment")), nil
+		}
+
+		if _, ok := s.parseTwinID(ctx, input.TwinID); !ok {
+			return nil, newAPIRequestError(common.StatusPropertyTwinError, fieldPath(path, "twin_id")), nil
 		}
 
-		if common.IsLocalhost(domain) {
-			ilog.WarnContext(ctx, "Property domain name is localhost")
-			return nil, common.StatusPropertyDomainLocalhostError, nil
+		if _, ok := encodePropertyClaims(ctx, input.Claims); !ok {
+			return nil, newAPIRequestError(common.StatusPropertyClaimsError, fieldPath(path, "claims")), nil
 		}
 
-		if common.IsIPAddress(domain) {
-			ilog.WarnContext(ctx, "Property domain name is IP")
-			return nil, common.StatusPropertyDomainIPAddrError, nil
+		if !db.CheckFailureURLValid(ctx, strings.TrimSpace(input.FailureURL)) {
+			return nil, newAPIRequestError(common.StatusPropertyFailureURLError, fieldPath(path, "failure_url")), nil
 		}
 
-		if _, err := idna.Lookup.ToASCII(domain); err != nil {
-			ilog.WarnContext(ctx, "Failed to convert domain name to ASCII", common.ErrAttr(err))
-			return nil, common.StatusPropertyDomainNameInvalidError, nil
+		if !difficulty.IsValidStrategy(strings.TrimSpace(input.DifficultyStrategy)) {
+			ilog.WarnContext(ctx, "Property difficulty strategy is not registered", "strategy", input.DifficultyStrategy)
+			return nil, newAPIRequestError(common.StatusPropertyDifficultyStrategyError, fieldPath(path, "difficulty_strategy")), nil
 		}
 
 		inputs = append(inputs, &input)
@@ -163,17 +233,73 @@ func (s *Server) readCreatePropertiesRequest(ctx context.Context, r *http.Reques
 		if err != io.EOF {
 			slog.WarnContext(ctx, "Failed to parse new properties request: expected ']'", common.ErrAttr(err))
 		}
-		return nil, 0, db.ErrInvalidInput
+		return nil, newSchemaRequestError(&apiFieldError{Message: "expected end of array"}), nil
 	}
 
-	return inputs, common.StatusOK, nil
+	return inputs, nil, nil
+}
+
+func validatePropertyDomain(ctx context.Context, ilog *slog.Logger, value string) (string, common.StatusCode) {
+	if len(value) == 0 {
+		ilog.WarnContext(ctx, "Property domain name is empty")
+		return "", common.StatusPropertyDomainEmptyError
+	}
+
+	domain, err := common.ParseDomainName(value)
+	if err != nil {
+		ilog.WarnContext(ctx, "Failed to parse domain name", common.ErrAttr(err))
+		return "", common.StatusPropertyDomainFormatError
+	}
+
+	if common.IsLocalhost(domain) {
+		ilog.WarnContext(ctx, "Property domain name is localhost")
+		return "", common.StatusPropertyDomainLocalhostError
+	}
+
+	if common.IsIPAddress(domain) {
+		ilog.WarnContext(ctx, "Property domain name is IP")
+		return "", common.StatusPropertyDomainIPAddrError
+	}
+
+	if _, err := idna.Lookup.ToASCII(domain); err != nil {
+		ilog.WarnContext(ctx, "Failed to convert domain name to ASCII", common.ErrAttr(err))
+		return "", common.StatusPropertyDomainNameInvalidError
+	}
+
+	return domain, common.StatusOK
+}
+
+// checkNewPropertiesLimit verifies that all count new properties fit into the subscription of the org owner
+func (s *Server) checkNewPropertiesLimit(ctx context.Context, user *dbgen.User, org *dbgen.Organization, count int) common.StatusCode {
+	owner, subscr, err := s.BusinessDB.Impl().RetrieveOrgOwnerWithSubscription(ctx, org, user)
+	if err != nil {
+		return common.StatusFailure
+	}
+
+	// extra == (count - plan.limit()) so negative "extra" means we have left (-extra) space for new properties
+	if ok, extra, err := s.SubscriptionLimits.CheckOrgPropertiesLimit(ctx, org, owner.ID, subscr); (err != nil) || !ok || (count > (-extra)) {
+		slog.WarnContext(ctx, "User hit subscription limits", "count", count, "ok", ok, "extra", extra, common.ErrAttr(err))
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceProperties,
+			Code:         common.StatusSubscriptionPropertyLimitError,
+			UserID:       user.ID,
+			Org:          org,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    count,
+			Err:          err,
+		})
+		return common.StatusSubscriptionPropertyLimitError
+	}
+
+	return common.StatusOK
 }
 
 func (s *Server) postNewProperties(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
@@ -182,31 +308,23 @@ func (s *Server) postNewProperties(w http.ResponseWriter, r *http.Request) {
 		if err == db.ErrInvalidInput {
 			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
 		} else {
-			s.sendHTTPErrorResponse(err, w)
+			s.sendHTTPErrorResponse(err, r, w)
 		}
 		return
 	}
 
-	inputs, status, err := s.readCreatePropertiesRequest(ctx, r, org.ID)
+	inputs, reqErr, err := s.readCreatePropertiesRequest(ctx, r, org.ID)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
-	if status != common.StatusOK {
-		s.sendAPIErrorResponse(ctx, status, r, w)
-		return
-	}
-
-	owner, subscr, err := s.BusinessDB.Impl().RetrieveOrgOwnerWithSubscription(ctx, org, user)
-	if err != nil {
-		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+	if reqErr != nil {
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
 		return
 	}
 
-	// extra == (count - plan.limit()) so negative "extra" means we have left (-extra) space for new properties
-	if ok, extra, err := s.SubscriptionLimits.CheckPropertiesLimit(ctx, owner.ID, subscr); (err != nil) || !ok || (len(inputs) > (-extra)) {
-		slog.WarnContext(ctx, "User hit subscription limits", "count", len(inputs), "ok", ok, "extra", extra, common.ErrAttr(err))
-		s.sendAPIErrorResponse(ctx, common.StatusSubscriptionPropertyLimitError, r, w)
+	if status := s.checkNewPropertiesLimit(ctx, user, org, len(inputs)); !status.Success() {
+		s.sendAPIErrorResponse(ctx, status, r, w)
 		return
 	}
 
@@ -281,58 +399,118 @@ func (s *Server) doCreateProperties(ctx context.Context, tlog *slog.Logger, user
 		return nil, err
 	}
 
+	results := make([]*operationResult, len(params.Properties))
+	createParams := make([]*dbgen.CreatePropertyParams, len(params.Properties))
+
+	for i, property := range params.Properties {
+		p, status := s.newCreatePropertyParams(ctx, tlog.With("index", i), property, user)
+		if status != common.StatusOK {
+			results[i] = &operationResult{Code: status}
+			continue
+		}
+
+		if err := s.BusinessDB.Impl().CheckPropertyTwin(ctx, org.ID, p.Environment, p.TwinID); err != nil {
+			code := common.StatusFailure
+			if err == db.ErrInvalidTwin {
+				code = common.StatusPropertyTwinError
+			}
+			results[i] = &operationResult{Code: code}
+			continue
+		}
+
+		createParams[i] = p
+	}
+
+	if err := s.createPropertiesBatch(ctx, tlog, user, org, createParams, results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// createPropertiesBatch creates properties that fit into the subscription limit and fills in the missing results
+// (params[i] is nil when results[i] is already known)
+func (s *Server) createPropertiesBatch(ctx context.Context, tlog *slog.Logger, user *dbgen.User, org *dbgen.Organization, params []*dbgen.CreatePropertyParams, results []*operationResult) error {
 	owner, subscr, err := s.BusinessDB.Impl().RetrieveOrgOwnerWithSubscription(ctx, org, user)
 	if err != nil {
 		tlog.ErrorContext(ctx, "Failed to retrieve org owner with subscription", common.ErrAttr(err))
-		return nil, err
+		return err
 	}
 
-	b := &backoff.Backoff{
-		Min:    200 * time.Millisecond,
-		Max:    1 * time.Second,
-		Factor: 1.1,
-		Jitter: true,
+	ok, extra, err := s.SubscriptionLimits.CheckOrgPropertiesLimit(ctx, org, owner.ID, subscr)
+	allowance := 0
+	if err == nil {
+		allowance = db.NewPropertiesAllowance(ok, extra, len(params))
 	}
 
-	results := make([]*operationResult, 0, len(params.Properties))
-	limitCheckIndex := 1
+	createParams := make([]*dbgen.CreatePropertyParams, 0, allowance)
+	createIndices := make([]int, 0, allowance)
+	limited := 0
 
-	for i, property := range params.Properties {
-		if i > 0 {
-			time.Sleep(b.Duration())
+	for i, p := range params {
+		if p == nil {
+			continue
 		}
 
-		// TODO: Create properties in batches instead of one by one
-		// the only reason why it's not done is that it's not clear if this is a bottleneck right now AND
-		// maybe it will not be the most popular API
-		status := s.doCreateProperty(ctx, tlog.With("index", i), property, user, org)
-		results = append(results, &operationResult{Code: status})
+		if len(createParams) >= allowance {
+			results[i] = &operationResult{Code: common.StatusSubscriptionPropertyLimitError}
+			limited++
+			continue
+		}
 
-		// check user limits with a logarithmic step to make less DB round trips
-		if i == limitCheckIndex {
-			limitCheckIndex *= 2
+		createParams = append(createParams, p)
+		createIndices = append(createIndices, i)
+	}
 
-			if ok, _, err := s.SubscriptionLimits.CheckPropertiesLimit(ctx, owner.ID, subscr); (err != nil) || !ok {
-				tlog.WarnContext(ctx, "Skipping property creation due to subscription limit", "subscrID", subscr.ID)
-				break
+	if limited > 0 {
+		tlog.WarnContext(ctx, "Skipping property creation due to subscription limit", "subscrID", subscr.ID, "skipped", limited)
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceProperties,
+			Code:         common.StatusSubscriptionPropertyLimitError,
+			UserID:       user.ID,
+			Org:          org,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    limited,
+			Err:          err,
+		})
+	}
+
+	if len(createParams) > 0 {
+		properties, auditEvents, err := s.BusinessDB.Impl().CreateNewProperties(ctx, createParams, org)
+		if err != nil {
+			tlog.ErrorContext(ctx, "Failed to create properties", "count", len(createParams), common.ErrAttr(err))
+		} else {
+			s.BusinessDB.AuditLog().RecordEvents(ctx, auditEvents, common.AuditLogSourceAPI)
+		}
+
+		for j, i := range createIndices {
+			switch {
+			case err != nil:
+				results[i] = &operationResult{Code: common.StatusFailure}
+			case properties[j] == nil:
+				results[i] = &operationResult{Code: common.StatusPropertyNameDuplicateError}
+			default:
+				results[i] = &operationResult{Code: common.StatusOK}
 			}
 		}
 	}
 
-	for len(results) < len(params.Properties) {
-		results = append(results, &operationResult{Code: common.StatusSubscriptionPropertyLimitError})
+	progress := taskProgressFromContext(ctx)
+	for i, result := range results {
+		progress.result(i, result)
 	}
 
-	return results, nil
+	return nil
 }
 
-func (s *Server) doCreateProperty(ctx context.Context, tlog *slog.Logger, property *apiCreatePropertyInput, user *dbgen.User, org *dbgen.Organization) common.StatusCode {
+func (s *Server) newCreatePropertyParams(ctx context.Context, tlog *slog.Logger, property *apiCreatePropertyInput, user *dbgen.User) (*dbgen.CreatePropertyParams, common.StatusCode) {
 	// this should have been filtered out when we validated user request
 	// but we repeat this here because we save to DB _exact_ user request
 	domain, err := common.ParseDomainName(property.Domain)
 	if err != nil {
 		tlog.WarnContext(ctx, "Failed to parse domain name", "domain", property.Domain, common.ErrAttr(err))
-		return common.StatusPropertyDomainFormatError
+		return nil, common.StatusPropertyDomainFormatError
 	}
 
 	// NOTE: we do NOT validate property name "for real" (against other org properties) due to too many DB roundtrips.
@@ -341,30 +519,62 @@ func (s *Server) doCreateProperty(ctx context.Context, tlog *slog.Logger, proper
 
 	property.Normalize()
 
-	_, auditEvent, err := s.BusinessDB.Impl().CreateNewProperty(ctx, &dbgen.CreatePropertyParams{
-		Name:             property.Name,
-		CreatorID:        db.Int(user.ID),
-		Domain:           domain,
-		Level:            db.Int2(int16(property.Level)),
-		Growth:           dbgen.DifficultyGrowth(property.Growth),
-		ValidityInterval: time.Duration(property.ValiditySeconds) * time.Second,
-		AllowSubdomains:  property.AllowSubdomains,
-		AllowLocalhost:   property.AllowLocalhost,
-		MaxReplayCount:   int32(property.MaxReplayCount),
-	}, org)
+	if !db.CheckFailureURLValid(ctx, property.FailureURL) {
+		return nil, common.StatusPropertyFailureURLError
+	}
+
+	environment, _ := property.PropertyEnvironment()
+	twinID, _ := s.parseTwinID(ctx, property.TwinID)
+	claims, _ := encodePropertyClaims(ctx, property.Claims)
+
+	return &dbgen.CreatePropertyParams{
+		Name:                   property.Name,
+		CreatorID:              db.Int(user.ID),
+		Domain:                 domain,
+		Level:                  db.Int2(int16(property.Level)),
+		Growth:                 dbgen.DifficultyGrowth(property.Growth),
+		ValidityInterval:       time.Duration(property.ValiditySeconds) * time.Second,
+		AllowSubdomains:        property.AllowSubdomains,
+		AllowLocalhost:         property.AllowLocalhost,
+		MaxReplayCount:         int32(property.MaxReplayCount),
+		AllowedClockSkew:       time.Duration(property.ClockSkewSec) * time.Second,
+		RememberWindow:         time.Duration(property.RememberSec) * time.Second,
+		DifferentialDifficulty: property.DifferentialDifficulty,
+		BotPolicy:              dbgen.BotPolicy(property.BotPolicy),
+		DifficultyStrategy:     property.DifficultyStrategy,
+		FailureURL:             property.FailureURL,
+		FailureMessage:         property.FailureMessage,
+		WidgetFlags:            property.WidgetFlags(),
+		Environment:            environment,
+		TwinID:                 twinID,
+		TrustGroup:             property.TrustGroup,
+		Claims:                 claims,
+	}, common.StatusOK
+}
+
+func (s *Server) doCreateProperty(ctx context.Context, tlog *slog.Logger, property *apiCreatePropertyInput, user *dbgen.User, org *dbgen.Organization) (*dbgen.Property, common.StatusCode) {
+	params, status := s.newCreatePropertyParams(ctx, tlog, property, user)
+	if status != common.StatusOK {
+		return nil, status
+	}
+
+	result, auditEvent, err := s.BusinessDB.Impl().CreateNewProperty(ctx, params, org)
 	if err != nil {
+		if err == db.ErrInvalidTwin {
+			return nil, common.StatusPropertyTwinError
+		}
 		tlog.ErrorContext(ctx, "Failed to create the property", common.ErrAttr(err))
-		return common.StatusFailure
+		return nil, common.StatusFailure
 	}
 
 	s.BusinessDB.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourceAPI)
 
-	return common.StatusOK
+	return result, common.StatusOK
 }
 
-func (s *Server) readDeletePropertiesRequest(ctx context.Context, r *http.Request) ([]int32, common.StatusCode, error) {
+func (s *Server) readDeletePropertiesRequest(ctx context.Context, r *http.Request) ([]int32, *apiRequestError, error) {
 	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
-		return nil, 0, db.ErrInvalidInput
+		return nil, nil, db.ErrInvalidInput
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -373,7 +583,7 @@ func (s *Server) readDeletePropertiesRequest(ctx context.Context, r *http.Reques
 		if err != io.EOF {
 			slog.WarnContext(ctx, "Failed to parse delete properties request: expected '['", common.ErrAttr(err))
 		}
-		return nil, 0, db.ErrInvalidInput
+		return nil, newSchemaRequestError(&apiFieldError{Message: "expected array"}), nil
 	}
 
 	idsToDelete := make(map[int]struct{}, maxPropertiesBatchSize/2)
@@ -382,7 +592,7 @@ func (s *Server) readDeletePropertiesRequest(ctx context.Context, r *http.Reques
 	for decoder.More() {
 		if len(propertyIDs) >= maxPropertiesBatchSize {
 			slog.WarnContext(ctx, "Too many properties in a batch", "count", len(propertyIDs), "max", maxPropertiesBatchSize)
-			return nil, common.StatusPropertiesTooManyError, nil
+			return nil, newAPIRequestError(common.StatusPropertiesTooManyError, indexPath("", maxPropertiesBatchSize)), nil
 		}
 
 		var encID string
@@ -390,13 +600,13 @@ func (s *Server) readDeletePropertiesRequest(ctx context.Context, r *http.Reques
 			if err != io.EOF {
 				slog.WarnContext(ctx, "Failed to parse delete properties request", common.ErrAttr(err))
 			}
-			return nil, 0, db.ErrInvalidInput
+			return nil, newSchemaRequestError(decodeFieldError(indexPath("", len(propertyIDs)), err)), nil
 		}
 
 		id, err := s.IDHasher.Decrypt(encID)
 		if err != nil {
 			slog.WarnContext(ctx, "Failed to decode property ID", "id", encID, common.ErrAttr(err))
-			return nil, 0, db.ErrInvalidInput
+			return nil, newSchemaRequestError(&apiFieldError{Path: indexPath("", len(propertyIDs)), Message: "invalid property ID"}), nil
 		}
 
 		if _, ok := idsToDelete[id]; ok {
@@ -412,32 +622,32 @@ func (s *Server) readDeletePropertiesRequest(ctx context.Context, r *http.Reques
 		if err != io.EOF {
 			slog.WarnContext(ctx, "Failed to parse delete properties request: expected ']'", common.ErrAttr(err))
 		}
-		return nil, 0, db.ErrInvalidInput
+		return nil, newSchemaRequestError(&apiFieldError{Message: "expected end of array"}), nil
 	}
 
 	if len(propertyIDs) == 0 {
 		slog.WarnContext(ctx, "Empty delete properties list")
-		return nil, 0, db.ErrInvalidInput
+		return nil, nil, db.ErrInvalidInput
 	}
 
-	return propertyIDs, common.StatusOK, nil
+	return propertyIDs, nil, nil
 }
 
 func (s *Server) deleteProperties(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
-	propertyIDs, status, err := s.readDeletePropertiesRequest(ctx, r)
+	propertyIDs, reqErr, err := s.readDeletePropertiesRequest(ctx, r)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
-	if status != common.StatusOK {
-		s.sendAPIErrorResponse(ctx, status, r, w)
+	if reqErr != nil {
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
 		return
 	}
 
@@ -525,6 +735,7 @@ func (s *Server) doDeleteProperties(ctx context.Context, tlog *slog.Logger, user
 
 	s.BusinessDB.AuditLog().RecordEvents(ctx, auditEvents, common.AuditLogSourceAPI)
 
+	progress := taskProgressFromContext(ctx)
 	results := make([]*operationResult, 0, len(params.PropertyIDs))
 
 	for i, propertyID := range params.PropertyIDs {
@@ -534,14 +745,15 @@ func (s *Server) doDeleteProperties(ctx context.Context, tlog *slog.Logger, user
 			result.Code = common.StatusFailure
 		}
 		results = append(results, result)
+		progress.result(i, result)
 	}
 
 	return results, nil
 }
 
-func (s *Server) readUpdatePropertiesRequest(ctx context.Context, r *http.Request) ([]*apiUpdatePropertyInput, common.StatusCode, error) {
+func (s *Server) readUpdatePropertiesRequest(ctx context.Context, r *http.Request) ([]*apiUpdatePropertyInput, *apiRequestError, error) {
 	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
-		return nil, 0, db.ErrInvalidInput
+		return nil, nil, db.ErrInvalidInput
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -550,7 +762,7 @@ func (s *Server) readUpdatePropertiesRequest(ctx context.Context, r *http.Reques
 		if err != io.EOF {
 			slog.WarnContext(ctx, "Failed to parse update properties request: expected '['", common.ErrAttr(err))
 		}
-		return nil, 0, db.ErrInvalidInput
+		return nil, newSchemaRequestError(&apiFieldError{Message: "expected array"}), nil
 	}
 
 	var inputs []*apiUpdatePropertyInput
@@ -560,27 +772,34 @@ func (s *Server) readUpdatePropertiesRequest(ctx context.Context, r *http.Reques
 	for decoder.More() {
 		if len(inputs) >= maxPropertiesBatchSize {
 			slog.WarnContext(ctx, "Too many properties in a batch", "count", len(inputs), "max", maxPropertiesBatchSize)
-			return nil, common.StatusPropertiesTooManyError, nil
+			return nil, newAPIRequestError(common.StatusPropertiesTooManyError, indexPath("", maxPropertiesBatchSize)), nil
 		}
 
+		path := indexPath("", len(inputs))
+
 		var input apiUpdatePropertyInput
 		if err := decoder.Decode(&input); err != nil {
 			if err != io.EOF {
 				slog.WarnContext(ctx, "Failed to parse update properties request", common.ErrAttr(err))
 			}
-			return nil, 0, db.ErrInvalidInput
+			return nil, newSchemaRequestError(decodeFieldError(path, err)), nil
+		}
+
+		if errs := validateSchema(path, &input); len(errs) > 0 {
+			slog.WarnContext(ctx, "Updated property failed schema validation", "index", len(inputs), "errors", len(errs))
+			return nil, newSchemaRequestError(errs...), nil
 		}
 
 		ilog := slog.With("index", len(inputs), "id", input.ID, "name", input.Name)
 
 		if len(input.ID) == 0 {
 			ilog.WarnContext(ctx, "Property ID is empty")
-			return nil, common.StatusPropertyIDEmptyError, nil
+			return nil, newAPIRequestError(common.StatusPropertyIDEmptyError, fieldPath(path, "id")), nil
 		}
 
 		if _, ok := idsMap[input.ID]; ok {
 			ilog.WarnContext(ctx, "Property ID duplicate found")
-			return nil, common.StatusPropertyIDDuplicateError, nil
+			return nil, newAPIRequestError(common.StatusPropertyIDDuplicateError, fieldPath(path, "id")), nil
 		}
 
 		idsMap[input.ID] = struct{}{}
@@ -588,16 +807,29 @@ func (s *Server) readUpdatePropertiesRequest(ctx context.Context, r *http.Reques
 		name := strings.TrimSpace(input.Name)
 		if _, ok := nameMap[name]; ok {
 			ilog.WarnContext(ctx, "Property name duplicate found")
-			return nil, common.StatusPropertyNameDuplicateError, nil
+			return nil, newAPIRequestError(common.StatusPropertyNameDuplicateError, fieldPath(path, "name")), nil
 		}
 
 		if nameStatus := s.BusinessDB.Impl().ValidatePropertyName(ctx, name, nil /*org*/); !nameStatus.Success() {
 			ilog.WarnContext(ctx, "Property name failed validation", "reason", nameStatus.String())
-			return nil, nameStatus, nil
+			return nil, newAPIRequestError(nameStatus, fieldPath(path, "name")), nil
 		}
 
 		nameMap[name] = struct{}{}
 
+		if _, ok := encodePropertyClaims(ctx, input.Claims); !ok {
+			return nil, newAPIRequestError(common.StatusPropertyClaimsError, fieldPath(path, "claims")), nil
+		}
+
+		if !db.CheckFailureURLValid(ctx, strings.TrimSpace(input.FailureURL)) {
+			return nil, newAPIRequestError(common.StatusPropertyFailureURLError, fieldPath(path, "failure_url")), nil
+		}
+
+		if !difficulty.IsValidStrategy(strings.TrimSpace(input.DifficultyStrategy)) {
+			ilog.WarnContext(ctx, "Property difficulty strategy is not registered", "strategy", input.DifficultyStrategy)
+			return nil, newAPIRequestError(common.StatusPropertyDifficultyStrategyError, fieldPath(path, "difficulty_strategy")), nil
+		}
+
 		inputs = append(inputs, &input)
 	}
 
@@ -605,32 +837,32 @@ func (s *Server) readUpdatePropertiesRequest(ctx context.Context, r *http.Reques
 		if err != io.EOF {
 			slog.WarnContext(ctx, "Failed to parse update properties request: expected ']'", common.ErrAttr(err))
 		}
-		return nil, 0, db.ErrInvalidInput
+		return nil, newSchemaRequestError(&apiFieldError{Message: "expected end of array"}), nil
 	}
 
 	if len(inputs) == 0 {
 		slog.WarnContext(ctx, "Empty update properties list")
-		return nil, 0, db.ErrInvalidInput
+		return nil, nil, db.ErrInvalidInput
 	}
 
-	return inputs, common.StatusOK, nil
+	return inputs, nil, nil
 }
 
 func (s *Server) updateProperties(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
-	inputs, status, err := s.readUpdatePropertiesRequest(ctx, r)
+	inputs, reqErr, err := s.readUpdatePropertiesRequest(ctx, r)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
-	if status != common.StatusOK {
-		s.sendAPIErrorResponse(ctx, status, r, w)
+	if reqErr != nil {
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
 		return
 	}
 
@@ -708,6 +940,7 @@ func (s *Server) doUpdateProperties(ctx context.Context, tlog *slog.Logger, user
 		Jitter: true,
 	}
 
+	progress := taskProgressFromContext(ctx)
 	results := make([]*operationResult, 0, len(params.Properties))
 
 	var org *dbgen.Organization
@@ -726,6 +959,7 @@ func (s *Server) doUpdateProperties(ctx context.Context, tlog *slog.Logger, user
 
 		status := s.doUpdateProperty(ctx, tlog.With("index", i), property, user, org)
 		results = append(results, &operationResult{Code: status})
+		progress.result(i, results[i])
 	}
 
 	return results, nil
@@ -738,23 +972,50 @@ func (s *Server) doUpdateProperty(ctx context.Context, tlog *slog.Logger, proper
 		return common.StatusPropertyIDInvalidError
 	}
 
+	twinID, ok := s.parseTwinID(ctx, propertyInput.TwinID)
+	if !ok || (twinID.Valid && (twinID.Int32 == int32(propertyID))) {
+		return common.StatusPropertyTwinError
+	}
+
+	claims, ok := encodePropertyClaims(ctx, propertyInput.Claims)
+	if !ok {
+		return common.StatusPropertyClaimsError
+	}
+
 	propertyInput.Normalize()
 
+	if !db.CheckFailureURLValid(ctx, propertyInput.FailureURL) {
+		return common.StatusPropertyFailureURLError
+	}
+
 	params := &dbgen.UpdatePropertyParams{
-		ID:               int32(propertyID),
-		Name:             propertyInput.Name,
-		Level:            db.Int2(int16(propertyInput.Level)),
-		Growth:           dbgen.DifficultyGrowth(propertyInput.Growth),
-		ValidityInterval: time.Duration(propertyInput.ValiditySeconds) * time.Second,
-		AllowSubdomains:  propertyInput.AllowSubdomains,
-		AllowLocalhost:   propertyInput.AllowLocalhost,
-		MaxReplayCount:   int32(propertyInput.MaxReplayCount),
+		ID:                     int32(propertyID),
+		Name:                   propertyInput.Name,
+		Level:                  db.Int2(int16(propertyInput.Level)),
+		Growth:                 dbgen.DifficultyGrowth(propertyInput.Growth),
+		ValidityInterval:       time.Duration(propertyInput.ValiditySeconds) * time.Second,
+		AllowSubdomains:        propertyInput.AllowSubdomains,
+		AllowLocalhost:         propertyInput.AllowLocalhost,
+		MaxReplayCount:         int32(propertyInput.MaxReplayCount),
+		AllowedClockSkew:       time.Duration(propertyInput.ClockSkewSec) * time.Second,
+		RememberWindow:         time.Duration(propertyInput.RememberSec) * time.Second,
+		DifferentialDifficulty: propertyInput.DifferentialDifficulty,
+		BotPolicy:              dbgen.BotPolicy(propertyInput.BotPolicy),
+		DifficultyStrategy:     propertyInput.DifficultyStrategy,
+		FailureURL:             propertyInput.FailureURL,
+		FailureMessage:         propertyInput.FailureMessage,
+		WidgetFlags:            propertyInput.WidgetFlags(),
+		TwinID:                 twinID,
+		TrustGroup:             propertyInput.TrustGroup,
+		Claims:                 claims,
 	}
 
 	_, auditEvent, err := s.BusinessDB.Impl().UpdateProperty(ctx, org, user, params)
 	if err != nil {
 		if err == db.ErrPermissions {
 			return common.StatusOrgPermissionsError
+		} else if err == db.ErrInvalidTwin {
+			return common.StatusPropertyTwinError
 		}
 		tlog.ErrorContext(ctx, "Failed to update the property", common.ErrAttr(err))
 		return common.StatusFailure
@@ -785,7 +1046,7 @@ func (s *Server) getOrgProperties(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
@@ -794,65 +1055,56 @@ func (s *Server) getOrgProperties(w http.ResponseWriter, r *http.Request) {
 		if err == db.ErrInvalidInput {
 			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
 		} else {
-			s.sendHTTPErrorResponse(err, w)
+			s.sendHTTPErrorResponse(err, r, w)
 		}
 		return
 	}
 
-	pageParam := r.URL.Query().Get(common.ParamPage)
-	page := 0
-	if len(pageParam) > 0 {
-		if page, err = strconv.Atoi(pageParam); err != nil {
-			slog.ErrorContext(ctx, "Failed to convert page parameter", "page", pageParam, common.ErrAttr(err))
-			page = 0
-		}
-		if page < 0 {
-			s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
-			return
-		}
-	}
-
-	perPageParam := r.URL.Query().Get(common.ParamPerPage)
-	perPage := db.MaxOrgPropertiesPageSize
-	if len(perPageParam) > 0 {
-		if perPage, err = strconv.Atoi(perPageParam); err != nil {
-			slog.ErrorContext(ctx, "Failed to convert per_page parameter", "perPage", perPageParam, common.ErrAttr(err))
-			perPage = 0
-		}
-		if perPage <= 0 {
-			s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
-			return
-		}
+	request, err := pagination.ParseRequest(ctx, r, db.MaxOrgPropertiesPageSize, s.IDHasher)
+	if err != nil {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return
 	}
 
-	validatedPerPage := min(db.MaxOrgPropertiesPageSize, max(perPage, 0))
-	offset := max(page, 0) * validatedPerPage
-
 	// NOTE: we might need to add more things to etag like org.updated_at later
-	etag := common.GenerateETag(strconv.Itoa(int(user.ID)), strconv.Itoa(int(org.ID)),
-		strconv.Itoa(offset), strconv.Itoa(validatedPerPage))
+	etag := common.GenerateETag(append([]string{strconv.Itoa(int(user.ID)), strconv.Itoa(int(org.ID))}, request.Key()...)...)
 	if etagHeader := r.Header.Get(common.HeaderIfNoneMatch); len(etagHeader) > 0 && (etagHeader == etag) {
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	properties, hasMore, err := s.BusinessDB.Impl().RetrieveOrgProperties(ctx, org, offset, validatedPerPage)
+	var properties []*dbgen.Property
+	var hasMore bool
+	if request.Cursor != nil {
+		properties, hasMore, err = s.BusinessDB.Impl().RetrieveOrgPropertiesAfter(ctx, org, request.Cursor.CreatedAt, int32(request.Cursor.ID), request.Limit)
+	} else {
+		properties, hasMore, err = s.BusinessDB.Impl().RetrieveOrgProperties(ctx, org, request.Offset(), request.Limit)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to retrieve org properties", common.ErrAttr(err))
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
-	slog.DebugContext(ctx, "Retrieved org properties", "count", len(properties), "more", hasMore, "page", page, "perPage", validatedPerPage)
+	slog.DebugContext(ctx, "Retrieved org properties", "count", len(properties), "more", hasMore, "page", request.Page, "perPage", request.Limit)
+
+	var next *pagination.Cursor
+	if len(properties) > 0 {
+		last := properties[len(properties)-1]
+		next = pagination.NewCursor(last.CreatedAt.Time, int64(last.ID))
+	}
 
 	response := &APIResponse{
-		Data: propertiesToApiOrgProperties(properties, s.IDHasher),
-		Pagination: &Pagination{
-			Page:    page,
-			PerPage: validatedPerPage,
-			HasMore: hasMore,
-		},
+		Data:       propertiesToApiOrgProperties(properties, s.IDHasher),
+		Pagination: request.NewResponse(hasMore, next, s.IDHasher),
+	}
+
+	if request.IncludeTotal {
+		if count, err := s.BusinessDB.Impl().RetrieveOrgPropertiesCount(ctx, org.ID); err == nil {
+			response.Pagination.TotalEstimate = &count
+		}
 	}
+
 	cacheHeaders := map[string][]string{
 		common.HeaderETag:         []string{etag},
 		common.HeaderCacheControl: common.PrivateCacheControl15s,
@@ -864,7 +1116,7 @@ func (s *Server) getOrgProperty(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, apiKey, err := s.requestUser(ctx, true /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
 		return
 	}
 
@@ -873,7 +1125,7 @@ func (s *Server) getOrgProperty(w http.ResponseWriter, r *http.Request) {
 		if err == db.ErrInvalidInput {
 			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
 		} else {
-			s.sendHTTPErrorResponse(err, w)
+			s.sendHTTPErrorResponse(err, r, w)
 		}
 		return
 	}
@@ -883,23 +1135,168 @@ func (s *Server) getOrgProperty(w http.ResponseWriter, r *http.Request) {
 		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
 			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
 		} else {
-			s.sendHTTPErrorResponse(err, w)
+			s.sendHTTPErrorResponse(err, r, w)
 		}
 		return
 	}
 
 	data := &apiPropertyOutput{
-		ID:              s.IDHasher.Encrypt(int(property.ID)),
-		Name:            property.Name,
-		Domain:          property.Domain,
-		Sitekey:         db.UUIDToSiteKey(property.ExternalID),
-		Level:           int(property.Level.Int16),
-		Growth:          string(property.Growth),
-		ValiditySeconds: int(property.ValidityInterval.Seconds()),
-		AllowSubdomains: property.AllowSubdomains,
-		AllowLocalhost:  property.AllowLocalhost,
-		MaxReplayCount:  int(property.MaxReplayCount),
+		ID:                 s.IDHasher.Encrypt(int(property.ID)),
+		Name:               property.Name,
+		Domain:             property.Domain,
+		Sitekey:            db.UUIDToSiteKey(property.ExternalID),
+		Level:              int(property.Level.Int16),
+		Growth:             string(property.Growth),
+		ValiditySeconds:    int(property.ValidityInterval.Seconds()),
+		AllowSubdomains:    property.AllowSubdomains,
+		AllowLocalhost:     property.AllowLocalhost,
+		MaxReplayCount:     int(property.MaxReplayCount),
+		ClockSkewSec:       int(property.AllowedClockSkew.Seconds()),
+		RememberSec:        int(property.RememberWindow.Seconds()),
+		Differential:       property.DifferentialDifficulty,
+		BotPolicy:          string(property.BotPolicy),
+		DifficultyStrategy: property.DifficultyStrategy,
+		FailureURL:         property.FailureURL,
+		FailureMessage:     property.FailureMessage,
+		Environment:        string(property.Environment),
+		TrustGroup:         property.TrustGroup,
+	}
+
+	if claims := decodePropertyClaims(ctx, property.Claims); len(claims) > 0 {
+		data.Claims = resolveClaims(claims, strings.NewReplacer() /*keep templates*/)
+	}
+
+	if property.TwinID.Valid {
+		data.TwinID = s.IDHasher.Encrypt(int(property.TwinID.Int32))
+	}
+
+	flags := puzzle.WidgetFlags(property.WidgetFlags)
+	data.RequireInteraction = (flags & puzzle.WidgetFlagRequireInteraction) != 0
+	data.NoAutoRefresh = (flags & puzzle.WidgetFlagNoAutoRefresh) != 0
+	data.ImageChallenge = (flags & puzzle.WidgetFlagImageChallenge) != 0
+
+	if db.IsPropertyEmergency(property, time.Now()) {
+		data.EmergencyUntil = property.EmergencyUntil.Time.UTC().Format(time.RFC3339)
 	}
 
 	s.sendAPISuccessResponse(ctx, data, w)
 }
+
+func (s *Server) promoteProperty(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, false /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	_, auditEvent, err := s.BusinessDB.Impl().PromoteProperty(ctx, org, user, property)
+	if err != nil {
+		switch err {
+		case db.ErrInvalidInput, db.ErrSoftDeleted, db.ErrRecordNotFound:
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyTwinError, r, w)
+		case db.ErrPermissions:
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyPermissionsError, r, w)
+		default:
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	s.BusinessDB.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourceAPI)
+
+	s.sendAPISuccessResponse(ctx, &operationResult{Code: common.StatusOK}, w)
+}
+
+func (s *Server) postPropertyEmergency(w http.ResponseWriter, r *http.Request) {
+	s.updatePropertyEmergency(w, r, true /*activate*/)
+}
+
+func (s *Server) deletePropertyEmergency(w http.ResponseWriter, r *http.Request) {
+	s.updatePropertyEmergency(w, r, false /*activate*/)
+}
+
+// updatePropertyEmergency toggles emergency mode ("panic button") that is used during an active attack on the property
+func (s *Server) updatePropertyEmergency(w http.ResponseWriter, r *http.Request, activate bool) {
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, false /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	var until time.Time
+	if activate {
+		request := &apiPropertyEmergencyInput{}
+		// request body is optional
+		if r.ContentLength != 0 {
+			if reqErr := decodeRequestBody(r, request); reqErr != nil {
+				slog.WarnContext(ctx, "Failed to deserialize emergency request", "errors", len(reqErr.Errors))
+				s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+				return
+			}
+		}
+
+		duration := db.NormalizeEmergencyDuration(time.Duration(request.DurationSeconds) * time.Second)
+		until = time.Now().UTC().Add(duration)
+	}
+
+	updatedProperty, auditEvent, err := s.BusinessDB.Impl().UpdatePropertyEmergency(ctx, user, property, org, until)
+	if err != nil {
+		if err == db.ErrRecordNotFound {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	s.BusinessDB.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourceAPI)
+
+	response := &apiPropertyEmergencyOutput{Active: db.IsPropertyEmergency(updatedProperty, time.Now())}
+	if response.Active {
+		response.Until = updatedProperty.EmergencyUntil.Time.UTC().Format(time.RFC3339)
+	}
+
+	s.sendAPISuccessResponse(ctx, response, w)
+}
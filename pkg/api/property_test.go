@@ -269,6 +269,108 @@ func TestApiPostProperties(t *testing.T) {
 	}
 }
 
+func TestApiCreatePropertiesBatchDuplicates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, org, _, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := db_test.CreateNewPropertyParams(user.ID, "existing.com")
+	if _, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, existing, org); err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"first", "second", "first", existing.Name}
+	params := &asyncTaskCreateProperties{OrgID: org.ID}
+	for i, name := range names {
+		params.Properties = append(params.Properties, &apiCreatePropertyInput{
+			apiPropertySettings: apiPropertySettings{Name: name},
+			Domain:              fmt.Sprintf("example%d.com", i),
+		})
+	}
+
+	results, err := s.doCreateProperties(ctx, slog.Default(), user, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []common.StatusCode{common.StatusOK, common.StatusOK, common.StatusPropertyNameDuplicateError, common.StatusPropertyNameDuplicateError}
+	for i, result := range results {
+		if result.Code != expected[i] {
+			t.Errorf("Unexpected result at %v: %v", i, result.Code)
+		}
+	}
+}
+
+func TestApiCloneProperty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, org, _, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sourceParams := db_test.CreateNewPropertyParams(user.ID, "source.com")
+	sourceParams.Growth = dbgen.DifficultyGrowthFast
+	sourceParams.MaxReplayCount = 7
+	sourceParams.PrivacyMode = string(common.PrivacyModeTruncate)
+	source, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, sourceParams, org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := &asyncTaskCloneProperty{
+		OrgID:      org.ID,
+		PropertyID: source.ID,
+		Targets: []*db.PropertyCloneTarget{
+			{Domain: "clone1.com"},
+			{Domain: "clone2.com", Name: t.Name()},
+			{Domain: "clone3.com", Name: source.Name},
+		},
+	}
+
+	results, err := s.doCloneProperty(ctx, slog.Default(), user, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []common.StatusCode{common.StatusOK, common.StatusOK, common.StatusPropertyNameDuplicateError}
+	for i, result := range results {
+		if result.Code != expected[i] {
+			t.Errorf("Unexpected result at %v: %v", i, result.Code)
+		}
+	}
+
+	properties, _, err := s.BusinessDB.Impl().RetrieveOrgProperties(ctx, org, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := 0
+	for _, p := range properties {
+		if (p.Domain == "clone1.com") || (p.Domain == "clone2.com") {
+			found++
+			if (p.Growth != source.Growth) || (p.MaxReplayCount != source.MaxReplayCount) || (p.PrivacyMode != source.PrivacyMode) {
+				t.Errorf("Settings were not cloned for %v", p.Domain)
+			}
+		}
+	}
+
+	if found != 2 {
+		t.Errorf("Unexpected number of cloned properties: %v", found)
+	}
+}
+
 func TestApiPostPropertiesNoSubscription(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -312,6 +414,84 @@ func TestApiPostPropertiesNoSubscription(t *testing.T) {
 	if resp.StatusCode != http.StatusPaymentRequired {
 		t.Fatalf("Unexpected status code: %v", resp.StatusCode)
 	}
+
+	decisions, err := store.Impl().RetrieveUserLimitDecisions(ctx, user.ID, time.Now().UTC().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(decisions) != 1) || (decisions[0].Resource != db.LimitResourceSubscription) {
+		t.Errorf("Unexpected limit decisions: %v", decisions)
+	}
+}
+
+func TestApiCreatePropertiesTwin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, org, _, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	production, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, db_test.CreateNewPropertyParams(user.ID, "production.com"), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stagingParams := db_test.CreateNewPropertyParams(user.ID, "staging.com")
+	stagingParams.Environment = dbgen.PropertyEnvironmentStaging
+	staging, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, stagingParams, org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherUser, otherOrg, err := db_test.CreateNewAccountForTest(ctx, store, t.Name()+"_another", testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foreign, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, db_test.CreateNewPropertyParams(otherUser.ID, "foreign.com"), otherOrg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		environment string
+		twinID      int32
+		expected    common.StatusCode
+	}{
+		{string(dbgen.PropertyEnvironmentStaging), production.ID, common.StatusOK},
+		{string(dbgen.PropertyEnvironmentStaging), foreign.ID, common.StatusPropertyTwinError},
+		{string(dbgen.PropertyEnvironmentStaging), staging.ID, common.StatusPropertyTwinError},
+		{string(dbgen.PropertyEnvironmentProduction), production.ID, common.StatusPropertyTwinError},
+	}
+
+	params := &asyncTaskCreateProperties{OrgID: org.ID}
+	for i, tc := range testCases {
+		params.Properties = append(params.Properties, &apiCreatePropertyInput{
+			apiPropertySettings: apiPropertySettings{
+				Name:   fmt.Sprintf("%s %d", t.Name(), i),
+				TwinID: s.IDHasher.Encrypt(int(tc.twinID)),
+			},
+			Domain:      fmt.Sprintf("example%d.com", i),
+			Environment: tc.environment,
+		})
+	}
+
+	results, err := s.doCreateProperties(ctx, slog.Default(), user, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, result := range results {
+		if result.Code != testCases[i].expected {
+			t.Errorf("Unexpected result at %v: %v", i, result.Code)
+		}
+	}
 }
 
 func TestApiPostPropertiesOtherOrg(t *testing.T) {
@@ -639,6 +819,67 @@ func TestApiGetProperties(t *testing.T) {
 	}
 }
 
+func TestApiGetPropertiesCursor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+	user, org, apiKey, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 3*db.MaxOrgPropertiesPageSize/2 + 1
+	for i := 0; i < count; i++ {
+		if _, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, fmt.Sprintf("example%v.com", i)), org); err != nil {
+			t.Fatalf("Failed to create new property: %v", err)
+		}
+	}
+
+	seen := make(map[string]struct{})
+	endpoint := fmt.Sprintf("/%s/%v/%s?limit=%d&include_total=true", common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID)), common.PropertiesEndpoint, db.MaxOrgPropertiesPageSize/2)
+	cursor := ""
+
+	for i := 0; i < count; i++ {
+		resp, err := apiRequestSuite(ctx, nil, http.MethodGet, endpoint+cursor, apiKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var response struct {
+			APIResponse
+			Data []*apiOrgPropertyOutput `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&response)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (response.Pagination == nil) || (response.Pagination.TotalEstimate == nil) || (*response.Pagination.TotalEstimate != count) {
+			t.Fatalf("Unexpected pagination: %+v", response.Pagination)
+		}
+
+		for _, p := range response.Data {
+			if _, ok := seen[p.ID]; ok {
+				t.Fatalf("Property %v was returned twice", p.ID)
+			}
+			seen[p.ID] = struct{}{}
+		}
+
+		if !response.Pagination.HasMore {
+			break
+		}
+
+		cursor = "&cursor=" + response.Pagination.NextCursor
+	}
+
+	if len(seen) != count {
+		t.Errorf("Expected %v properties, but got %v", count, len(seen))
+	}
+}
+
 func TestApiGetPropertyInvalidOrgID(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -770,6 +1011,67 @@ func TestApiGetProperty(t *testing.T) {
 	}
 }
 
+func TestApiPropertyEmergency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, org, apiKey, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, db_test.CreateNewPropertyParams(user.ID, "example.com"), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint := fmt.Sprintf("/%s/%s/%s/%s/%s", common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID)),
+		common.PropertyEndpoint, s.IDHasher.Encrypt(int(property.ID)), common.EmergencyEndpoint)
+
+	output, meta, err := requestResponseAPISuite[*apiPropertyEmergencyOutput](ctx, &apiPropertyEmergencyInput{DurationSeconds: 600},
+		http.MethodPost, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !meta.Code.Success() {
+		t.Fatalf("Unexpected status code: %v", meta.Description)
+	}
+
+	if !output.Active || (len(output.Until) == 0) {
+		t.Errorf("Emergency mode is not active: %v", output.Until)
+	}
+
+	updated, err := s.BusinessDB.Impl().RetrievePropertyBySitekey(ctx, db.UUIDToSiteKey(property.ExternalID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !db.IsPropertyEmergency(updated, time.Now()) {
+		t.Error("Property is not in emergency mode")
+	}
+
+	if db.IsPropertyEmergency(updated, time.Now().Add(11*time.Minute)) {
+		t.Error("Emergency mode did not expire")
+	}
+
+	output, meta, err = requestResponseAPISuite[*apiPropertyEmergencyOutput](ctx, nil, http.MethodDelete, endpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !meta.Code.Success() {
+		t.Fatalf("Unexpected status code: %v", meta.Description)
+	}
+
+	if output.Active {
+		t.Error("Emergency mode is still active")
+	}
+}
+
 func TestApiGetPropertyPermissions(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -0,0 +1,217 @@
+//go:build enterprise
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	clonePropertyHandlerID = "api-clone-property"
+)
+
+type asyncTaskCloneProperty struct {
+	Targets    []*db.PropertyCloneTarget `json:"targets"`
+	OrgID      int32                     `json:"org_id"`
+	PropertyID int32                     `json:"property_id"`
+}
+
+// validates targets, normalizing their domains in place
+func (s *Server) validatePropertyCloneTargets(ctx context.Context, targets []*db.PropertyCloneTarget, orgID int32) *apiRequestError {
+	if len(targets) > db.MaxPropertyCloneTargets {
+		slog.WarnContext(ctx, "Too many property clone targets", "count", len(targets), "max", db.MaxPropertyCloneTargets)
+		return newAPIRequestError(common.StatusPropertiesTooManyError, indexPath("targets", db.MaxPropertyCloneTargets))
+	}
+
+	namesMap := make(map[string]struct{}, len(targets))
+	if properties, err := s.BusinessDB.Impl().GetCachedOrgProperties(ctx, orgID); err == nil {
+		for _, property := range properties {
+			namesMap[property.Name] = struct{}{}
+		}
+	}
+
+	for i, target := range targets {
+		path := indexPath("targets", i)
+		ilog := slog.With("index", i, "domain", target.Domain, "name", target.Name)
+
+		domain, status := validatePropertyDomain(ctx, ilog, target.Domain)
+		if !status.Success() {
+			return newAPIRequestError(status, fieldPath(path, "domain"))
+		}
+
+		target.Domain = domain
+
+		name := target.PropertyName()
+		if _, ok := namesMap[name]; ok {
+			ilog.WarnContext(ctx, "Property name duplicate found")
+			return newAPIRequestError(common.StatusPropertyNameDuplicateError, fieldPath(path, "name"))
+		}
+
+		if nameStatus := s.BusinessDB.Impl().ValidatePropertyName(ctx, name, nil /*org*/); !nameStatus.Success() {
+			ilog.WarnContext(ctx, "Property name failed validation", "reason", nameStatus.String())
+			return newAPIRequestError(nameStatus, fieldPath(path, "name"))
+		}
+
+		namesMap[name] = struct{}{}
+	}
+
+	return nil
+}
+
+func (s *Server) postPropertyClone(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(common.HeaderContentType) != common.ContentTypeJSON {
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return
+	}
+
+	ctx := r.Context()
+	user, apiKey, err := s.requestUser(ctx, false /*read-only*/)
+	if err != nil {
+		s.sendHTTPErrorResponse(err, r, w)
+		return
+	}
+
+	org, err := s.requestOrg(user, r, true /*only owner*/, &apiKey.OrgID)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			s.sendAPIErrorResponse(ctx, common.StatusOrgIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	property, err := s.requestProperty(org, r)
+	if err != nil {
+		if (err == db.ErrSoftDeleted) || (err == db.ErrInvalidInput) {
+			s.sendAPIErrorResponse(ctx, common.StatusPropertyIDInvalidError, r, w)
+		} else {
+			s.sendHTTPErrorResponse(err, r, w)
+		}
+		return
+	}
+
+	request := &apiPropertyCloneInput{}
+	if reqErr := decodeRequestBody(r, request); reqErr != nil {
+		slog.WarnContext(ctx, "Failed to deserialize property clone request", "errors", len(reqErr.Errors))
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+		return
+	}
+
+	if reqErr := s.validatePropertyCloneTargets(ctx, request.Targets, org.ID); reqErr != nil {
+		s.sendAPIRequestErrorResponse(ctx, reqErr, r, w)
+		return
+	}
+
+	if status := s.checkNewPropertiesLimit(ctx, user, org, len(request.Targets)); !status.Success() {
+		s.sendAPIErrorResponse(ctx, status, r, w)
+		return
+	}
+
+	referenceID := db.UUIDToSecret(apiKey.ExternalID)
+	input := &asyncTaskCloneProperty{
+		Targets:    request.Targets,
+		OrgID:      org.ID,
+		PropertyID: property.ID,
+	}
+
+	buffer := 5 * time.Minute
+	// we schedule it for later, making "room" for immediate attempt first
+	scheduledAt := time.Now().UTC().Add(buffer)
+	task, err := s.BusinessDB.Impl().CreateNewAsyncTask(ctx, input, clonePropertyHandlerID, user, scheduledAt, referenceID)
+	if err != nil {
+		s.sendAPIErrorResponse(ctx, common.StatusFailure, r, w)
+		return
+	}
+
+	output := &apiAsyncTaskOutput{
+		ID: db.UUIDToString(task.ID),
+	}
+
+	slog.InfoContext(ctx, "Scheduled property clone", "propertyID", property.ID, "targets", len(request.Targets), "taskID", output.ID)
+
+	s.sendAPISuccessResponse(ctx, output, w)
+
+	go func(bctx context.Context) {
+		handlerCtx, cancel := context.WithTimeout(bctx, buffer)
+		defer cancel()
+		if err := s.AsyncTasks.Execute(handlerCtx, task); err != nil {
+			slog.ErrorContext(bctx, "Failed to execute async task", "taskID", output.ID, common.ErrAttr(err))
+		}
+	}(common.CopyTraceID(ctx, context.Background()))
+}
+
+func (s *Server) handleCloneProperty(ctx context.Context, task *dbgen.AsyncTask) ([]byte, error) {
+	taskID := db.UUIDToString(task.ID)
+	tlog := slog.With("taskID", taskID)
+
+	tlog.DebugContext(ctx, "Processing clone property task")
+
+	params := &asyncTaskCloneProperty{}
+	if err := json.Unmarshal(task.Input, params); err != nil {
+		tlog.ErrorContext(ctx, "Failed to unmarshal clone property async task input", common.ErrAttr(err))
+		return nil, err
+	}
+
+	user, err := s.BusinessDB.Impl().RetrieveUser(ctx, task.UserID.Int32)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve user", "userID", task.UserID.Int32, common.ErrAttr(err))
+		return nil, err
+	}
+
+	results, err := s.doCloneProperty(ctx, tlog, user, params)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to clone property", common.ErrAttr(err))
+		return nil, err
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to serialize results", common.ErrAttr(err))
+		data = nil
+	}
+
+	return data, nil
+}
+
+func (s *Server) doCloneProperty(ctx context.Context, tlog *slog.Logger, user *dbgen.User, params *asyncTaskCloneProperty) ([]*operationResult, error) {
+	org, err := s.BusinessDB.Impl().RetrieveUserOrganization(ctx, user, params.OrgID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve org", common.ErrAttr(err))
+		return nil, err
+	}
+
+	source, err := s.BusinessDB.Impl().RetrieveOrgProperty(ctx, org, params.PropertyID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve source property", "propertyID", params.PropertyID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	results := make([]*operationResult, len(params.Targets))
+	createParams := make([]*dbgen.CreatePropertyParams, len(params.Targets))
+
+	for i, target := range params.Targets {
+		// domains are normalized when the task is scheduled
+		if _, err := common.ParseDomainName(target.Domain); err != nil {
+			tlog.WarnContext(ctx, "Failed to parse domain name", "index", i, "domain", target.Domain, common.ErrAttr(err))
+			results[i] = &operationResult{Code: common.StatusPropertyDomainFormatError}
+			continue
+		}
+
+		createParams[i] = db.ClonePropertyParams(source, user.ID, target)
+	}
+
+	if err := s.createPropertiesBatch(ctx, tlog, user, org, createParams, results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
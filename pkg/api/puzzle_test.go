@@ -3,8 +3,10 @@ package api
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"io"
 	"log/slog"
+	"maps"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
@@ -15,7 +17,9 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -28,7 +32,7 @@ func puzzleSuite(ctx context.Context, sitekey, domain string) (*http.Response, e
 	return puzzleSuiteEx(ctx, http.MethodGet, sitekey, domain)
 }
 
-func puzzleSuiteEx(ctx context.Context, method, sitekey, domain string) (*http.Response, error) {
+func puzzleSuiteEx(ctx context.Context, method, sitekey, domain string, headers ...map[string][]string) (*http.Response, error) {
 	slog.Log(ctx, common.LevelTrace, "Running puzzle suite", "domain", domain, "sitekey", sitekey)
 	srv := http.NewServeMux()
 	s.Setup("", true /*verbose*/, common.NoopMiddleware).Register(srv)
@@ -42,6 +46,9 @@ func puzzleSuiteEx(ctx context.Context, method, sitekey, domain string) (*http.R
 
 	req.Header.Set("Origin", common_test.PrependProtocol(domain))
 	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+	for _, hh := range headers {
+		maps.Copy(req.Header, hh)
+	}
 
 	q := req.URL.Query()
 	q.Add(common.ParamSiteKey, sitekey)
@@ -225,6 +232,285 @@ func TestGetPuzzle(t *testing.T) {
 	}
 }
 
+func TestGetPuzzleExceededQuota(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Verifier.Quotas.Update([]*dbgen.UserQuota{{UserID: user.ID, Stage: dbgen.QuotaStageExceeded}})
+	defer s.Verifier.Quotas.Update(nil)
+
+	resp, err := puzzleSuite(ctx, db.UUIDToSiteKey(property.ExternalID), property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	p, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.PuzzleID() != 0 {
+		t.Errorf("Expected stub puzzle, got puzzle ID %v", p.PuzzleID())
+	}
+}
+
+func TestGetPuzzleStickyDifficulty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+	// sticky puzzles are bound to the end user
+	ipHeader := http.CanonicalHeaderKey(cfg.Get(common.RateLimitHeaderKey).Value())
+	clientIP := common_test.GenerateRandomIPv4()
+
+	resp, err := puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, map[string][]string{ipHeader: {clientIP}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, puzzleStr, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pinned.StickySince().IsZero() {
+		t.Fatal("Sticky window is not set")
+	}
+
+	cached, err := store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// this should be still cached so we don't need to actually update DB
+	cached.Level = pgtype.Int2{Int16: int16(pinned.Difficulty()) + 2*difficulty.StickyDifficultyDrift, Valid: true}
+
+	resp, err = puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, map[string][]string{
+		common.HeaderCaptchaSticky: {puzzleStr},
+		ipHeader:                   {clientIP},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sticky, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !sticky.StickySince().Equal(pinned.StickySince()) {
+		t.Errorf("Sticky window was not preserved: %v (expected %v)", sticky.StickySince(), pinned.StickySince())
+	}
+
+	if expected := pinned.Difficulty() + difficulty.StickyDifficultyDrift; sticky.Difficulty() != expected {
+		t.Errorf("Unexpected sticky difficulty %v (expected %v)", sticky.Difficulty(), expected)
+	}
+
+	// puzzle of another end user does not pin difficulty
+	resp, err = puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, map[string][]string{common.HeaderCaptchaSticky: {puzzleStr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.Difficulty() < uint8(cached.Level.Int16) {
+		t.Errorf("Unexpected difficulty %v with sticky puzzle of another end user", other.Difficulty())
+	}
+
+	// tampered puzzle does not pin difficulty
+	resp, err = puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, map[string][]string{
+		common.HeaderCaptchaSticky: {"a" + puzzleStr},
+		ipHeader:                   {clientIP},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fresh, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fresh.Difficulty() < uint8(cached.Level.Int16) {
+		t.Errorf("Unexpected difficulty %v with invalid sticky puzzle", fresh.Difficulty())
+	}
+}
+
+func TestGetPuzzleLeasedDifficulty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain)
+	params.Growth = dbgen.DifficultyGrowthConstant
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, params, org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	resp, err := puzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leased, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if leased.DifficultyLease().IsZero() {
+		t.Fatal("Difficulty lease is not set")
+	}
+
+	if !leased.StickySince().IsZero() {
+		t.Errorf("Unexpected sticky window with leased difficulty: %v", leased.StickySince())
+	}
+
+	if leased.Difficulty() != uint8(property.Level.Int16) {
+		t.Errorf("Unexpected leased difficulty %v (expected %v)", leased.Difficulty(), property.Level.Int16)
+	}
+
+	cached, err := store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// change of the settings invalidates the lease (this should be still cached so we don't need to actually update DB)
+	cached.Level = pgtype.Int2{Int16: property.Level.Int16 + common.DifficultyDelta, Valid: true}
+	cached.UpdatedAt = db.Timestampz(time.Now())
+
+	resp, err = puzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.Difficulty() != uint8(cached.Level.Int16) {
+		t.Errorf("Unexpected difficulty %v after settings change (expected %v)", updated.Difficulty(), cached.Level.Int16)
+	}
+}
+
+func TestGetPuzzleBotPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain)
+	params.BotPolicy = dbgen.BotPolicyBlock
+	params.FailureMessage = "Please contact support"
+	property, _, err := store.Impl().CreateNewProperty(ctx, params, org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	// puzzle suite does not send Accept-Language
+	resp, err := puzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	var failure puzzleFailureResponse
+	if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+		t.Fatal(err)
+	}
+
+	if failure.FailureMessage != params.FailureMessage {
+		t.Errorf("Unexpected failure message: %v", failure.FailureMessage)
+	}
+
+	resp, err = puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, map[string][]string{common.HeaderAcceptLanguage: {"en-US"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	cached, err := store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// this should be still cached so we don't need to actually update DB
+	cached.BotPolicy = dbgen.BotPolicyMaxDifficulty
+
+	resp, err = puzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Difficulty() != uint8(common.MaxDifficultyLevel) {
+		t.Errorf("Unexpected difficulty %v", p.Difficulty())
+	}
+}
+
 func TestGetTestPuzzle(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -285,3 +571,30 @@ func TestPuzzleCachePriority(t *testing.T) {
 		t.Errorf("Unexpected status code %d", resp.StatusCode)
 	}
 }
+
+func TestIsOriginAllowed(t *testing.T) {
+	t.Parallel()
+
+	production := &dbgen.Property{Domain: "example.com", Environment: dbgen.PropertyEnvironmentProduction}
+	staging := &dbgen.Property{Domain: "example.com", Environment: dbgen.PropertyEnvironmentStaging}
+
+	testCases := []struct {
+		origin   string
+		property *dbgen.Property
+		allowed  bool
+	}{
+		{"example.com", production, true},
+		{"localhost", production, false},
+		{"127.0.0.1", production, false},
+		{"example.com", staging, true},
+		{"localhost", staging, true},
+		{"127.0.0.1", staging, true},
+		{"example.org", staging, false},
+	}
+
+	for _, tc := range testCases {
+		if actual := isOriginAllowed(tc.origin, tc.property); actual != tc.allowed {
+			t.Errorf("Unexpected result for origin %q (environment %v): %v", tc.origin, tc.property.Environment, actual)
+		}
+	}
+}
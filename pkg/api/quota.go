@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/maypok86/otter/v2"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/leakybucket"
+)
+
+const (
+	QuotaService      = "quota"
+	QuotaBatchSize    = 100
+	maxQuotaBatchSize = 10_000
+	MaxQuotaBuckets   = 100_000
+	// how many seconds of the sustained puzzle rate a property can spend at once
+	quotaBurstSeconds = 30
+	maxQuotaOwners    = 10_000
+	quotaRatesTTL     = 30 * time.Minute
+	quotaKeyPrefix    = "pc:quota:"
+	quotaExceededCode = "quota_exceeded"
+	// staging properties are meant for development and are not billed, so their traffic is capped regardless of the plan
+	stagingPuzzlesPerSecond = 1.0
+	stagingPuzzlesBurst     = 100
+)
+
+// IssuanceBuckets keep a token bucket of puzzles per property
+type IssuanceBuckets interface {
+	// Take consumes a single token and returns how long to wait for the next one if there was none
+	Take(ctx context.Context, propertyID int32, rate float64, burst int, tnow time.Time) (bool, time.Duration)
+}
+
+type localIssuanceBuckets struct {
+	buckets *leakybucket.Manager[int32, leakybucket.ConstLeakyBucket[int32], *leakybucket.ConstLeakyBucket[int32]]
+}
+
+var _ IssuanceBuckets = (*localIssuanceBuckets)(nil)
+
+// NewLocalIssuanceBuckets keeps buckets in memory, so with multiple API nodes each of them enforces the quota separately
+func NewLocalIssuanceBuckets(maxBuckets int) *localIssuanceBuckets {
+	return &localIssuanceBuckets{
+		// default limits are never used as we always pass them explicitly
+		buckets: leakybucket.NewManager[int32, leakybucket.ConstLeakyBucket[int32]](maxBuckets, 1 /*capacity*/, time.Second),
+	}
+}
+
+func (b *localIssuanceBuckets) Take(ctx context.Context, propertyID int32, rate float64, burst int, tnow time.Time) (bool, time.Duration) {
+	leakInterval := time.Duration(float64(time.Second) / rate)
+	result := b.buckets.AddEx(propertyID, 1, tnow, leakybucket.TLevel(burst), leakInterval)
+	return result.Added > 0, result.RetryAfter
+}
+
+// RedisCommander is the subset of the Redis client that distributed buckets need
+type RedisCommander interface {
+	Do(ctx context.Context, args ...string) (any, error)
+}
+
+// tokens are refilled using Redis server time so that clock skew of API nodes does not matter
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, retry}
+`
+
+type redisIssuanceBuckets struct {
+	client   RedisCommander
+	fallback *localIssuanceBuckets
+}
+
+var _ IssuanceBuckets = (*redisIssuanceBuckets)(nil)
+
+// NewRedisIssuanceBuckets shares buckets between API nodes and falls back to local buckets if Redis is not reachable
+func NewRedisIssuanceBuckets(client RedisCommander, maxBuckets int) *redisIssuanceBuckets {
+	return &redisIssuanceBuckets{
+		client:   client,
+		fallback: NewLocalIssuanceBuckets(maxBuckets),
+	}
+}
+
+func (b *redisIssuanceBuckets) Take(ctx context.Context, propertyID int32, rate float64, burst int, tnow time.Time) (bool, time.Duration) {
+	reply, err := b.client.Do(ctx, "EVAL", tokenBucketScript, "1", quotaKeyPrefix+strconv.Itoa(int(propertyID)),
+		strconv.FormatFloat(rate, 'f', -1, 64), strconv.Itoa(burst))
+	if err != nil {
+		return b.fallback.Take(ctx, propertyID, rate, burst, tnow)
+	}
+
+	items, ok := reply.([]any)
+	if !ok || (len(items) != 2) {
+		slog.ErrorContext(ctx, "Unexpected token bucket reply", "propID", propertyID, "reply", reply)
+		return b.fallback.Take(ctx, propertyID, rate, burst, tnow)
+	}
+
+	allowed, _ := items[0].(int64)
+	retryMillis, _ := items[1].(int64)
+
+	return allowed == 1, time.Duration(retryMillis) * time.Millisecond
+}
+
+// PropertyQuota enforces the rate of puzzles issued per property, derived from subscription plan of the property owner
+type PropertyQuota struct {
+	Store      db.Implementor
+	Limits     db.SubscriptionLimits
+	Buckets    IssuanceBuckets
+	OwnersChan chan int32
+	BatchSize  int
+	rates      common.Cache[int32, float64]
+	cancel     context.CancelFunc
+}
+
+func NewPropertyQuota(store db.Implementor, limits db.SubscriptionLimits, buckets IssuanceBuckets) *PropertyQuota {
+	const batchSize = 10
+	var rates common.Cache[int32, float64]
+	var err error
+	// missing value is "no quota" and it is cached for the same time to not hammer DB for users without subscription
+	rates, err = db.NewMemoryCacheEx[int32, float64]("quota_rates", maxQuotaOwners, -1.0 /*missing value*/, quotaRatesTTL,
+		func(o *otter.Options[int32, float64]) {
+			o.ExpiryCalculator = otter.ExpiryWriting[int32, float64](quotaRatesTTL)
+		})
+	if err != nil {
+		slog.Error("Failed to create memory cache for quota rates", common.ErrAttr(err))
+		rates = db.NewStaticCache[int32, float64](maxQuotaOwners, -1.0 /*missing data*/)
+	}
+
+	return &PropertyQuota{
+		Store:      store,
+		Limits:     limits,
+		Buckets:    buckets,
+		OwnersChan: make(chan int32, 10*batchSize),
+		BatchSize:  batchSize,
+		rates:      rates,
+		cancel:     func() {},
+	}
+}
+
+func (q *PropertyQuota) Start(backfillDelay time.Duration) {
+	var ctx context.Context
+	baseCtx := context.WithValue(context.Background(), common.ServiceContextKey, QuotaService)
+	ctx, q.cancel = context.WithCancel(context.WithValue(baseCtx, common.TraceIDContextKey, "quota_backfill"))
+	go common.ProcessBatchMap(ctx, q.OwnersChan, backfillDelay, q.BatchSize, q.BatchSize*10, q.backfillRates)
+}
+
+func (q *PropertyQuota) Shutdown() {
+	slog.Debug("Shutting down property quota")
+	q.cancel()
+	close(q.OwnersChan)
+}
+
+func (q *PropertyQuota) ownerRate(ctx context.Context, ownerID int32) (float64, error) {
+	user, err := q.Store.Impl().RetrieveUser(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !user.SubscriptionID.Valid {
+		return 0, db.ErrNoActiveSubscription
+	}
+
+	subscr, err := q.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		return 0, err
+	}
+
+	limits, err := q.Limits.Limits(ctx, subscr)
+	if err != nil {
+		return 0, err
+	}
+
+	return limits.PuzzleRequestsPerSecond, nil
+}
+
+func (q *PropertyQuota) backfillRates(ctx context.Context, batch map[int32]uint) error {
+	for ownerID := range batch {
+		rate, err := q.ownerRate(ctx, ownerID)
+		if (err != nil) || (rate <= 0) {
+			// users without subscription are handled by the user limiter
+			slog.WarnContext(ctx, "Failed to find puzzle quota of the owner", "userID", ownerID, common.ErrAttr(err))
+			_ = q.rates.SetMissing(ctx, ownerID)
+			continue
+		}
+
+		_ = q.rates.Set(ctx, ownerID, rate)
+	}
+
+	slog.DebugContext(ctx, "Backfilled puzzle quota rates", "count", len(batch))
+
+	return nil
+}
+
+// Allow checks the quota of the property and returns how long to wait if it is exceeded. Until we know the plan of
+// the owner, puzzles are not limited in order to not access DB on the hot path. Staging properties have a fixed cap.
+func (q *PropertyQuota) Allow(ctx context.Context, property *dbgen.Property, tnow time.Time) (bool, time.Duration) {
+	if property == nil {
+		return true, 0
+	}
+
+	if property.Environment == dbgen.PropertyEnvironmentStaging {
+		return q.Buckets.Take(ctx, property.ID, stagingPuzzlesPerSecond, stagingPuzzlesBurst, tnow)
+	}
+
+	if !property.OrgOwnerID.Valid {
+		return true, 0
+	}
+
+	ownerID := property.OrgOwnerID.Int32
+
+	rate, err := q.rates.Get(ctx, ownerID)
+	if err == db.ErrCacheMiss {
+		select {
+		case q.OwnersChan <- ownerID:
+		default:
+			slog.Log(ctx, common.LevelTrace, "Quota backfill channel is full", "userID", ownerID)
+		}
+		return true, 0
+	}
+
+	if (err != nil) || (rate <= 0) {
+		return true, 0
+	}
+
+	burst := int(math.Ceil(rate * quotaBurstSeconds))
+
+	return q.Buckets.Take(ctx, property.ID, rate, burst, tnow)
+}
+
+func (s *Server) addQuotaRecord(ctx context.Context, property *dbgen.Property, tnow time.Time) {
+	record := &common.QuotaRecord{
+		UserID:     property.OrgOwnerID.Int32,
+		OrgID:      property.OrgID.Int32,
+		PropertyID: property.ID,
+		Timestamp:  tnow.UTC(),
+	}
+
+	// under sustained overload we prefer losing some of the records to blocking puzzle requests
+	select {
+	case s.QuotaLogChan <- record:
+	default:
+		slog.Log(ctx, common.LevelTrace, "Quota log channel is full", "propID", property.ID)
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+)
+
+type fakeRedisCommander struct {
+	reply any
+	err   error
+	calls int
+}
+
+func (f *fakeRedisCommander) Do(ctx context.Context, args ...string) (any, error) {
+	f.calls++
+	return f.reply, f.err
+}
+
+func TestLocalIssuanceBuckets(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	buckets := NewLocalIssuanceBuckets(100)
+	tnow := time.Now().Truncate(time.Second)
+
+	const burst = 2
+	for i := 0; i < burst; i++ {
+		if ok, _ := buckets.Take(ctx, 1, 1.0 /*rate*/, burst, tnow); !ok {
+			t.Fatalf("Token %v was not allowed", i)
+		}
+	}
+
+	ok, retryAfter := buckets.Take(ctx, 1, 1.0 /*rate*/, burst, tnow)
+	if ok {
+		t.Fatal("Token over the burst was allowed")
+	}
+
+	if retryAfter <= 0 {
+		t.Errorf("Unexpected retry after: %v", retryAfter)
+	}
+
+	// other properties have their own buckets
+	if ok, _ := buckets.Take(ctx, 2, 1.0 /*rate*/, burst, tnow); !ok {
+		t.Error("Token of another property was not allowed")
+	}
+
+	if ok, _ := buckets.Take(ctx, 1, 1.0 /*rate*/, burst, tnow.Add(1*time.Second)); !ok {
+		t.Error("Token was not refilled")
+	}
+}
+
+func TestStagingPropertyQuota(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	quota := NewPropertyQuota(nil /*store*/, nil /*limits*/, NewLocalIssuanceBuckets(100))
+	tnow := time.Now().Truncate(time.Second)
+
+	// staging cap does not depend on the plan of the owner, which is not even known here
+	staging := &dbgen.Property{ID: 1, Environment: dbgen.PropertyEnvironmentStaging}
+	for i := 0; i < stagingPuzzlesBurst; i++ {
+		if ok, _ := quota.Allow(ctx, staging, tnow); !ok {
+			t.Fatalf("Puzzle %v was not allowed", i)
+		}
+	}
+
+	if ok, _ := quota.Allow(ctx, staging, tnow); ok {
+		t.Error("Staging puzzle over the cap was allowed")
+	}
+
+	production := &dbgen.Property{ID: 2, Environment: dbgen.PropertyEnvironmentProduction}
+	for i := 0; i < stagingPuzzlesBurst+1; i++ {
+		if ok, _ := quota.Allow(ctx, production, tnow); !ok {
+			t.Fatalf("Production puzzle %v was not allowed", i)
+		}
+	}
+}
+
+func TestRedisIssuanceBuckets(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	client := &fakeRedisCommander{reply: []any{int64(0), int64(1500)}}
+	buckets := NewRedisIssuanceBuckets(client, 100)
+
+	ok, retryAfter := buckets.Take(ctx, 1, 1.0 /*rate*/, 1 /*burst*/, time.Now())
+	if ok {
+		t.Error("Token was allowed")
+	}
+
+	if retryAfter != 1500*time.Millisecond {
+		t.Errorf("Unexpected retry after: %v", retryAfter)
+	}
+
+	client.err = errors.New("connection refused")
+
+	if ok, _ := buckets.Take(ctx, 1, 1.0 /*rate*/, 1 /*burst*/, time.Now()); !ok {
+		t.Error("Fallback bucket did not allow the first token")
+	}
+
+	if client.calls != 2 {
+		t.Errorf("Unexpected Redis calls count: %v", client.calls)
+	}
+}
+
+func TestGetPuzzleQuotaExceeded(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.1 puzzles per second gives the burst of 3 puzzles
+	if err := s.Quota.rates.Set(ctx, user.ID, 0.1); err != nil {
+		t.Fatal(err)
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	for i := 0; i < 3; i++ {
+		resp, err := puzzleSuite(ctx, sitekey, property.Domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected status code %d for puzzle %v", resp.StatusCode, i)
+		}
+	}
+
+	resp, err := puzzleSuite(ctx, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	if len(resp.Header.Get(common.HeaderRetryAfter)) == 0 {
+		t.Error("Retry-After header is missing")
+	}
+
+	var failure puzzleFailureResponse
+	if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+		t.Fatal(err)
+	}
+
+	if failure.Code != quotaExceededCode {
+		t.Errorf("Unexpected failure code: %v", failure.Code)
+	}
+}
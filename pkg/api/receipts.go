@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+// ipPrefixHash allows to correlate receipts coming from the same network without storing the actual address
+func (v *Verifier) ipPrefixHash(ip netip.Addr, mode common.PrivacyMode) uint64 {
+	prefix := common.TruncateIP(ip)
+	if !prefix.IsValid() {
+		return 0
+	}
+
+	hash, err := v.newIPHash(mode)
+	if err != nil {
+		return 0
+	}
+
+	hash.Write(prefix.AsSlice())
+
+	return binary.BigEndian.Uint64(hash.Sum(nil)[:8])
+}
+
+// isBillablePuzzle mirrors the conditions under which access is recorded in difficulty levels
+func isBillablePuzzle(p puzzle.Puzzle, property *dbgen.Property) bool {
+	return (p != nil) && (property != nil) && !p.IsStub() && !p.IsRemembered() &&
+		(property.Environment != dbgen.PropertyEnvironmentStaging)
+}
+
+func (s *Server) addIssuanceReceipt(ctx context.Context, p puzzle.Puzzle, property *dbgen.Property) {
+	if !isBillablePuzzle(p, property) || !common.IsIssuanceReceiptSampled(p.PuzzleID()) {
+		return
+	}
+
+	var ipHash uint64
+	if ip, ok := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr); ok {
+		ipHash = s.Verifier.ipPrefixHash(ip, s.Verifier.privacyMode(property))
+	}
+
+	receipt := &common.IssuanceReceipt{
+		PuzzleID:     p.PuzzleID(),
+		UserID:       property.OrgOwnerID.Int32,
+		OrgID:        property.OrgID.Int32,
+		PropertyID:   property.ID,
+		Sitekey:      db.UUIDToSiteKey(property.ExternalID),
+		IPPrefixHash: ipHash,
+		Timestamp:    time.Now().UTC(),
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Sampled puzzle issuance receipt", "puzzleID", receipt.PuzzleID, "propID", property.ID)
+
+	s.ReceiptChan <- receipt
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func TestReceiptIPPrefixHash(t *testing.T) {
+	t.Parallel()
+
+	verifier := &Verifier{UserFingerprintKey: NewUserFingerprintKey(nil)}
+
+	testCases := []struct {
+		first  string
+		second string
+		same   bool
+	}{
+		{"192.168.1.10", "192.168.1.200", true},
+		{"192.168.1.10", "::ffff:192.168.1.20", true},
+		{"192.168.1.10", "192.168.2.10", false},
+		{"2001:db8:1:1::1", "2001:db8:1:2::1", true},
+		{"2001:db8:1::1", "2001:db8:2::1", false},
+	}
+
+	for i, tc := range testCases {
+		first := verifier.ipPrefixHash(netip.MustParseAddr(tc.first), common.PrivacyModeNone)
+		second := verifier.ipPrefixHash(netip.MustParseAddr(tc.second), common.PrivacyModeNone)
+
+		if first == 0 || second == 0 {
+			t.Fatalf("Empty hash in test case %v", i)
+		}
+
+		if (first == second) != tc.same {
+			t.Errorf("Unexpected hash equality for %v and %v", tc.first, tc.second)
+		}
+	}
+
+	if hash := verifier.ipPrefixHash(netip.Addr{}, common.PrivacyModeNone); hash != 0 {
+		t.Errorf("Unexpected hash of invalid address: %v", hash)
+	}
+}
+
+func TestIsBillablePuzzle(t *testing.T) {
+	t.Parallel()
+
+	propertyID := [puzzle.PropertyIDSize]byte{1}
+	property := &dbgen.Property{Environment: dbgen.PropertyEnvironmentProduction}
+	staging := &dbgen.Property{Environment: dbgen.PropertyEnvironmentStaging}
+
+	regular := puzzle.NewComputePuzzle(123, propertyID, 100)
+	stub := puzzle.NewComputePuzzle(0, propertyID, 100)
+	remembered := puzzle.NewRememberedPuzzle(123, propertyID)
+
+	if !isBillablePuzzle(regular, property) {
+		t.Error("Regular puzzle is not billable")
+	}
+
+	if isBillablePuzzle(stub, property) || isBillablePuzzle(remembered, property) || isBillablePuzzle(regular, staging) {
+		t.Error("Non-billable puzzle is considered billable")
+	}
+}
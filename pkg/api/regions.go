@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	defaultRegionCountry = "*"
+)
+
+var (
+	errInvalidRegion = errors.New("invalid verification region")
+)
+
+type verifyRegion struct {
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	Countries []string `json:"countries,omitempty"`
+	Default   bool     `json:"default,omitempty"`
+}
+
+type apiRegionsOutput struct {
+	Regions []*verifyRegion `json:"regions"`
+	Nearest string          `json:"nearest,omitempty"`
+}
+
+// regionMap resolves the verification endpoint closest to the client based on the country code header set by CDN
+type regionMap struct {
+	countryHeader string
+	regions       []*verifyRegion
+	byCountry     map[string]*verifyRegion
+	fallback      *verifyRegion
+}
+
+// parseRegionMap reads configuration in the form "eu https://eu.example.com DE FR NL; us https://us.example.com US CA *",
+// where "*" marks the region used for all other countries
+func parseRegionMap(value, countryHeader string) (*regionMap, error) {
+	rm := &regionMap{
+		countryHeader: countryHeader,
+		regions:       make([]*verifyRegion, 0),
+		byCountry:     make(map[string]*verifyRegion),
+	}
+
+	for _, entry := range strings.Split(value, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%w: %q", errInvalidRegion, strings.TrimSpace(entry))
+		}
+
+		u, err := url.Parse(fields[1])
+		if err != nil || ((u.Scheme != "https") && (u.Scheme != "http")) || (len(u.Host) == 0) {
+			return nil, fmt.Errorf("%w: bad URL %q", errInvalidRegion, fields[1])
+		}
+
+		region := &verifyRegion{
+			Name: fields[0],
+			URL:  strings.TrimRight(u.String(), "/"),
+		}
+
+		for _, country := range fields[2:] {
+			if country == defaultRegionCountry {
+				if rm.fallback != nil {
+					return nil, fmt.Errorf("%w: more than one default region", errInvalidRegion)
+				}
+				region.Default = true
+				rm.fallback = region
+				continue
+			}
+
+			country = strings.ToUpper(country)
+			if _, ok := rm.byCountry[country]; ok {
+				return nil, fmt.Errorf("%w: country %v is used more than once", errInvalidRegion, country)
+			}
+			rm.byCountry[country] = region
+			region.Countries = append(region.Countries, country)
+		}
+
+		rm.regions = append(rm.regions, region)
+	}
+
+	if len(rm.regions) == 0 {
+		return nil, nil
+	}
+
+	return rm, nil
+}
+
+func (rm *regionMap) nearest(r *http.Request) *verifyRegion {
+	if len(rm.countryHeader) > 0 {
+		if country := strings.ToUpper(strings.TrimSpace(r.Header.Get(rm.countryHeader))); len(country) > 0 {
+			if region, ok := rm.byCountry[country]; ok {
+				return region
+			}
+		}
+	}
+
+	return rm.fallback
+}
+
+func (s *Server) updateRegions(ctx context.Context, cfg common.ConfigStore) {
+	rm, err := parseRegionMap(cfg.Get(common.VerifyRegionsKey).Value(), cfg.Get(common.CountryCodeHeaderKey).Value())
+	if err != nil {
+		// keep the previous (valid) configuration
+		slog.ErrorContext(ctx, "Failed to parse verification regions", common.ErrAttr(err))
+		return
+	}
+
+	s.regions.Store(rm)
+
+	if rm != nil {
+		slog.DebugContext(ctx, "Updated verification regions", "count", len(rm.regions))
+	}
+}
+
+func (s *Server) writeRegionHeader(w http.ResponseWriter, r *http.Request) {
+	rm := s.regions.Load()
+	if rm == nil {
+		return
+	}
+
+	if region := rm.nearest(r); region != nil {
+		w.Header()[common.HeaderVerifyRegion] = []string{region.Name}
+	}
+}
+
+func (s *Server) getRegions(w http.ResponseWriter, r *http.Request) {
+	response := &apiRegionsOutput{Regions: []*verifyRegion{}}
+
+	if rm := s.regions.Load(); rm != nil {
+		response.Regions = rm.regions
+		if region := rm.nearest(r); region != nil {
+			response.Nearest = region.Name
+		}
+	}
+
+	// nearest region depends on the client so response cannot be shared
+	common.SendJSONResponse(r.Context(), w, response, common.NoCacheHeaders)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRegionMap(t *testing.T) {
+	t.Parallel()
+
+	rm, err := parseRegionMap("eu https://eu.example.com/ de FR; us https://us.example.com US CA *", "X-Country")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(rm.regions) != 2) || (rm.regions[0].URL != "https://eu.example.com") || !rm.regions[1].Default {
+		t.Fatalf("Unexpected regions: %+v", rm.regions)
+	}
+
+	for _, tc := range []struct {
+		country  string
+		expected string
+	}{
+		{"DE", "eu"},
+		{"fr", "eu"},
+		{"CA", "us"},
+		{"JP", "us"},
+		{"", "us"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Country", tc.country)
+
+		if region := rm.nearest(req); (region == nil) || (region.Name != tc.expected) {
+			t.Errorf("Unexpected region for country %q: %+v", tc.country, region)
+		}
+	}
+}
+
+func TestParseRegionMapErrors(t *testing.T) {
+	t.Parallel()
+
+	if rm, err := parseRegionMap(" ", ""); (rm != nil) || (err != nil) {
+		t.Errorf("Unexpected result for empty config: %v %v", rm, err)
+	}
+
+	for _, value := range []string{
+		"eu",
+		"eu eu.example.com",
+		"eu https://eu.example.com *; us https://us.example.com *",
+		"eu https://eu.example.com DE; us https://us.example.com DE",
+	} {
+		if _, err := parseRegionMap(value, ""); !errors.Is(err, errInvalidRegion) {
+			t.Errorf("Expected error for %q, got %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const (
+	maxVerifyReportsCount   = 100
+	maxVerifyReportBodySize = maxVerifyReportsCount * 4 * 1024
+	// reports are sent asynchronously, but they should not be too far behind
+	maxVerifyReportDelay = 10 * time.Minute
+	// includes the time it takes to deliver the request
+	maxVerifyReportClockSkew = 1 * time.Minute
+)
+
+// verifyReportTime returns the time that the report should be verified "at", so that puzzles which were valid
+// when the proxy verified them are not treated as expired due to reporting delay. Report timestamp is only trusted
+// relative to the time the request was sent and only if proxy clock agrees with ours, otherwise it's "now"
+func verifyReportTime(timestamp, sentAt int64, tnow time.Time) time.Time {
+	if sentAt == 0 {
+		return tnow
+	}
+
+	sent := time.Unix(sentAt, 0).UTC()
+	offset := tnow.Sub(sent)
+	if offset.Abs() > maxVerifyReportClockSkew {
+		return tnow
+	}
+
+	t := time.Unix(timestamp, 0).UTC()
+	if t.After(sent) {
+		return tnow
+	}
+
+	// translate to our clock
+	t = t.Add(offset)
+	if t.After(tnow) || (tnow.Sub(t) > maxVerifyReportDelay) {
+		return tnow
+	}
+
+	return t
+}
+
+// verifyReportHandler accounts verifications made by the edge verification proxy. Each payload is verified again
+// so that stats and replay protection stay authoritative on our side, but the proxy has already answered its client.
+func (s *Server) verifyReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	request := &common.VerifyReportRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		slog.WarnContext(ctx, "Failed to decode verify reports", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if count := len(request.Reports); (count == 0) || (count > maxVerifyReportsCount) {
+		slog.WarnContext(ctx, "Invalid verify reports count", "count", count)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	tnow := time.Now().UTC()
+	ownerSource := &apiKeyOwnerSource{Store: s.BusinessDB, scope: dbgen.ApiKeyScopePuzzle}
+	response := &common.VerifyReportResponse{
+		Results: make([]*common.VerifyReportResult, 0, len(request.Reports)),
+	}
+
+	diverged := 0
+
+	for _, report := range request.Reports {
+		code := puzzle.ParseResponseError
+
+		if payload, err := s.Verifier.ParseSolutionPayload(ctx, []byte(report.Payload)); err == nil {
+			result, err := s.Verifier.Verify(ctx, payload, ownerSource, verifyReportTime(report.Timestamp, request.SentAt, tnow))
+			if err != nil {
+				if err == errPuzzleOwner {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				} else {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+				return
+			}
+
+			if result.Valid() {
+				s.addVerifyRecord(ctx, result, time.Duration(report.DurationUs)*time.Microsecond)
+			}
+
+			code = result.Error
+		}
+
+		if int(code) != report.Code {
+			diverged++
+		}
+
+		response.Results = append(response.Results, &common.VerifyReportResult{Code: int(code)})
+	}
+
+	if diverged > 0 {
+		// most often this means that solution was replayed to multiple proxies (or proxy and API)
+		slog.WarnContext(ctx, "Verify reports diverged from proxy results", "count", len(request.Reports), "diverged", diverged)
+	} else {
+		slog.DebugContext(ctx, "Processed verify reports", "count", len(request.Reports))
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders, s.APIHeaders)
+}
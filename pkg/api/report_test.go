@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	common_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common/tests"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func verifyReportSuite(reports []*common.VerifyReport, secret string) (*http.Response, error) {
+	srv := http.NewServeMux()
+	s.Setup("", true /*verbose*/, common.NoopMiddleware).Register(srv)
+
+	data, err := json.Marshal(&common.VerifyReportRequest{Reports: reports, SentAt: time.Now().Unix()})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/"+common.VerifyEndpoint+"/"+common.ReportEndpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+	req.Header.Set(common.HeaderAPIKey, secret)
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	return w.Result(), nil
+}
+
+func TestVerifyReportThenReplay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, sitekey, err := setupVerifySuite(t.Context(), t.Name(), dbgen.ApiKeyScopePuzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports := []*common.VerifyReport{
+		{Payload: payload, Timestamp: time.Now().Unix(), DurationUs: 100, Code: int(puzzle.VerifyNoError)},
+		{Payload: "invalid", Timestamp: time.Now().Unix(), Code: int(puzzle.VerifyNoError)},
+	}
+
+	resp, err := verifyReportSuite(reports, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected report status code %d", resp.StatusCode)
+	}
+
+	response := &common.VerifyReportResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Results) != len(reports) {
+		t.Fatalf("Unexpected results count: %v", len(response.Results))
+	}
+
+	if code := puzzle.VerifyError(response.Results[0].Code); code != puzzle.VerifyNoError {
+		t.Errorf("Unexpected first result: %v", code)
+	}
+
+	if code := puzzle.VerifyError(response.Results[1].Code); code != puzzle.ParseResponseError {
+		t.Errorf("Unexpected second result: %v", code)
+	}
+
+	// reported solution is accounted as verified
+	resp, err = verifySuite(payload, apiKey, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifiedBeforeError); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyReportTime(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC().Truncate(time.Second)
+	past := tnow.Add(-5 * time.Minute)
+
+	for i, tc := range []struct {
+		timestamp time.Time
+		sentAt    time.Time
+		expected  time.Time
+	}{
+		{past, tnow, past},
+		// proxy clock is behind ours
+		{past.Add(-30 * time.Second), tnow.Add(-30 * time.Second), past},
+		// proxy clock is too far off
+		{past.Add(-time.Hour), tnow.Add(-time.Hour), tnow},
+		{past, time.Time{}, tnow},
+		// verification "after" sending
+		{tnow.Add(time.Second), tnow, tnow},
+		{tnow.Add(-2 * maxVerifyReportDelay), tnow, tnow},
+	} {
+		var sentAt int64
+		if !tc.sentAt.IsZero() {
+			sentAt = tc.sentAt.Unix()
+		}
+
+		if actual := verifyReportTime(tc.timestamp.Unix(), sentAt, tnow); !actual.Equal(tc.expected) {
+			t.Errorf("Unexpected verify time at %d: %v (expected %v)", i, actual, tc.expected)
+		}
+	}
+}
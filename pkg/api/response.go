@@ -1,23 +1,35 @@
 package api
 
-import "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+import (
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/orgarchive"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
+)
 
 type ResponseMetadata struct {
-	Code        common.StatusCode `json:"code"`
-	RequestID   string            `json:"request_id,omitempty"`
-	Description string            `json:"description,omitempty"`
+	Code common.StatusCode `json:"code"`
+	// stable name of the code, set only for errors
+	Error       string `json:"error,omitempty"`
+	RequestID   string `json:"request_id,omitempty"`
+	Description string `json:"description,omitempty"`
+	DocsURL     string `json:"docs_url,omitempty"`
+	// locations of invalid values in the request body
+	Errors []*apiFieldError `json:"errors,omitempty"`
 }
 
 type APIResponse struct {
-	Meta       ResponseMetadata `json:"meta"`
-	Data       interface{}      `json:"data,omitempty"`
-	Pagination *Pagination      `json:"pagination,omitempty"`
+	Meta       ResponseMetadata     `json:"meta"`
+	Data       interface{}          `json:"data,omitempty"`
+	Pagination *pagination.Response `json:"pagination,omitempty"`
 }
 
-type Pagination struct {
-	Page    int  `json:"page"`
-	PerPage int  `json:"per_page"`
-	HasMore bool `json:"has_more"`
+// returned instead of a puzzle when it cannot be served and property has custom failure settings (or quota is exceeded)
+type puzzleFailureResponse struct {
+	Error          string `json:"error"`
+	Code           string `json:"code,omitempty"`
+	FailureURL     string `json:"failure_url,omitempty"`
+	FailureMessage string `json:"failure_message,omitempty"`
 }
 
 type apiOrgInput struct {
@@ -38,17 +50,44 @@ type apiOrgPropertyOutput struct {
 
 type apiPropertySettings struct {
 	Name            string `json:"name"`
-	Level           int    `json:"level,omitempty"`
-	Growth          string `json:"growth,omitempty"`
-	ValiditySeconds int    `json:"validity_seconds,omitempty"`
+	Level           int    `json:"level,omitempty" validate:"min=0"`
+	Growth          string `json:"growth,omitempty" validate:"oneof=constant slow medium fast"`
+	ValiditySeconds int    `json:"validity_seconds,omitempty" validate:"min=0"`
 	AllowSubdomains bool   `json:"allow_subdomains,omitempty"`
 	AllowLocalhost  bool   `json:"allow_localhost,omitempty"`
-	MaxReplayCount  int    `json:"max_replay_count,omitempty"`
+	MaxReplayCount  int    `json:"max_replay_count,omitempty" validate:"min=0"`
+	ClockSkewSec    int    `json:"clock_skew_seconds,omitempty" validate:"min=0"`
+	RememberSec     int    `json:"remember_seconds,omitempty" validate:"min=0"`
+	// easier puzzles for returning (remembered) visitors and harder for first-seen ones under attack
+	DifferentialDifficulty bool `json:"differential_difficulty,omitempty"`
+	// what to do with requests that look automated before serving a puzzle
+	BotPolicy string `json:"bot_policy,omitempty" validate:"oneof=monitor max_difficulty block"`
+	// name of the custom difficulty strategy registered by the deployment (empty means growth curve)
+	DifficultyStrategy string `json:"difficulty_strategy,omitempty"`
+	// shown to end users (or where they are redirected) when puzzle cannot be served, e.g. request is blocked
+	FailureURL     string `json:"failure_url,omitempty"`
+	FailureMessage string `json:"failure_message,omitempty"`
+	// widget behavior flags (delivered to the widget with each puzzle)
+	RequireInteraction bool `json:"require_interaction,omitempty"`
+	NoAutoRefresh      bool `json:"no_auto_refresh,omitempty"`
+	ImageChallenge     bool `json:"image_challenge,omitempty"`
+	// production twin of the staging property (target of promotion)
+	TwinID string `json:"twin_id,omitempty"`
+	// properties in the same org with the same trust group accept each other's solutions
+	TrustGroup string `json:"trust_group,omitempty"`
+	// static or templated claims returned with successful verifications
+	Claims map[string]string `json:"claims,omitempty"`
 }
 
 type apiCreatePropertyInput struct {
 	apiPropertySettings
-	Domain string `json:"domain"`
+	Domain      string `json:"domain"`
+	Environment string `json:"environment,omitempty"`
+}
+
+type apiPropertyCloneInput struct {
+	// new properties get all settings of the source property
+	Targets []*db.PropertyCloneTarget `json:"targets" validate:"min=1"`
 }
 
 type apiUpdatePropertyInput struct {
@@ -71,14 +110,186 @@ type apiAsyncTaskResultOutput struct {
 }
 
 type apiPropertyOutput struct {
-	ID              string `json:"id"`
-	Name            string `json:"name"`
-	Domain          string `json:"domain"`
-	Sitekey         string `json:"sitekey"`
-	Level           int    `json:"level,omitempty"`
-	Growth          string `json:"growth,omitempty"`
-	ValiditySeconds int    `json:"validity_seconds,omitempty"`
-	AllowSubdomains bool   `json:"allow_subdomains,omitempty"`
-	AllowLocalhost  bool   `json:"allow_localhost,omitempty"`
-	MaxReplayCount  int    `json:"max_replay_count,omitempty"`
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	Domain             string            `json:"domain"`
+	Sitekey            string            `json:"sitekey"`
+	Level              int               `json:"level,omitempty"`
+	Growth             string            `json:"growth,omitempty"`
+	ValiditySeconds    int               `json:"validity_seconds,omitempty"`
+	AllowSubdomains    bool              `json:"allow_subdomains,omitempty"`
+	AllowLocalhost     bool              `json:"allow_localhost,omitempty"`
+	MaxReplayCount     int               `json:"max_replay_count,omitempty"`
+	ClockSkewSec       int               `json:"clock_skew_seconds,omitempty"`
+	RememberSec        int               `json:"remember_seconds,omitempty"`
+	Differential       bool              `json:"differential_difficulty,omitempty"`
+	BotPolicy          string            `json:"bot_policy,omitempty"`
+	DifficultyStrategy string            `json:"difficulty_strategy,omitempty"`
+	FailureURL         string            `json:"failure_url,omitempty"`
+	FailureMessage     string            `json:"failure_message,omitempty"`
+	RequireInteraction bool              `json:"require_interaction,omitempty"`
+	NoAutoRefresh      bool              `json:"no_auto_refresh,omitempty"`
+	ImageChallenge     bool              `json:"image_challenge,omitempty"`
+	EmergencyUntil     string            `json:"emergency_until,omitempty"`
+	Environment        string            `json:"environment"`
+	TwinID             string            `json:"twin_id,omitempty"`
+	TrustGroup         string            `json:"trust_group,omitempty"`
+	Claims             map[string]string `json:"claims,omitempty"`
+}
+
+type apiPropertyEmergencyInput struct {
+	// defaults to 1 hour, clamped to [5 minutes, 24 hours]
+	DurationSeconds int `json:"duration_seconds,omitempty" validate:"min=0"`
+}
+
+type apiPropertyEmergencyOutput struct {
+	Active bool   `json:"active"`
+	Until  string `json:"until,omitempty"`
+}
+
+// all empty lists remove the restriction, denylists are checked before the allowlist
+type apiPropertyAccessList struct {
+	IPAllowlist []string `json:"ip_allowlist" validate:"max=50"`
+	IPDenylist  []string `json:"ip_denylist" validate:"max=50"`
+	ASNDenylist []int64  `json:"asn_denylist" validate:"max=50"`
+	// what to do with denied requests
+	Policy string `json:"policy,omitempty" validate:"oneof=monitor max_difficulty block"`
+}
+
+type apiUsageLimit struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+type apiRateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// zero limit means "unlimited"
+type apiLimitsOutput struct {
+	Properties      apiUsageLimit `json:"properties"`
+	Orgs            apiUsageLimit `json:"orgs"`
+	OrgMembersLimit int           `json:"org_members_limit"`
+	MonthlyRequests apiUsageLimit `json:"monthly_requests"`
+	RateLimit       apiRateLimit  `json:"rate_limit"`
+	// sustained rate of puzzles that each property can issue
+	PuzzlesPerSecond float64 `json:"puzzles_per_second"`
+	// only for per-seat plans, where limit is the number of included seats
+	Seats *apiUsageLimit `json:"seats,omitempty"`
+}
+
+type apiQuotaThresholds struct {
+	Warning  int `json:"warning"`
+	Degraded int `json:"degraded"`
+	Exceeded int `json:"exceeded"`
+}
+
+// monthly requests quota of the account, thresholds are in percent of the limit (zero limit means "unlimited")
+type apiQuotaOutput struct {
+	PeriodStart  string        `json:"period_start"`
+	PeriodEnd    string        `json:"period_end"`
+	Requests     apiUsageLimit `json:"requests"`
+	UsagePercent float64       `json:"usage_percent"`
+	// stage that is currently enforced, it is re-evaluated periodically
+	Stage      string             `json:"stage"`
+	Thresholds apiQuotaThresholds `json:"thresholds"`
+	// when the stage was last evaluated (empty if it was not evaluated within the period yet)
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type apiAPIKeysBatchInput struct {
+	// has to contain "{index}" placeholder exactly once, e.g. "ci-{index}"
+	NameTemplate   string `json:"name_template"`
+	Count          int    `json:"count"`
+	StartIndex     int    `json:"start_index,omitempty"`
+	Scope          string `json:"scope"`
+	Readonly       bool   `json:"readonly,omitempty"`
+	ExpirationDays int    `json:"expiration_days"`
+	OrgID          string `json:"org_id,omitempty"`
+	// IP addresses or CIDR ranges the keys can be used from (any, if empty)
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty" validate:"max=50"`
+}
+
+// secret is returned only once, on creation
+type apiAPIKeyOutput struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Secret    string `json:"secret,omitempty"`
+	Scope     string `json:"scope"`
+	Readonly  bool   `json:"readonly"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+	OrgID     string `json:"org_id,omitempty"`
+	// IP addresses or CIDR ranges the key can be used from
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty"`
+}
+
+// published audit log event (the same that is streamed to SIEM) with obfuscated IDs
+type apiAuditLogOutput struct {
+	*db.Event
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	EntityID string `json:"entity_id"`
+	Table    string `json:"entity_table"`
+}
+
+type apiExperimentInput struct {
+	// defaults to the current property level
+	ControlLevel int `json:"control_level,omitempty"`
+	VariantLevel int `json:"variant_level"`
+	// percent of puzzles issued with variant level
+	VariantWeight int `json:"variant_weight"`
+	DurationDays  int `json:"duration_days"`
+	MinSamples    int `json:"min_samples,omitempty"`
+	// in percentage points
+	MaxDegradation float64 `json:"max_degradation,omitempty"`
+}
+
+type apiExperimentArmOutput struct {
+	Arm             string  `json:"arm"`
+	Level           int     `json:"level"`
+	Requests        int     `json:"requests"`
+	Successes       int     `json:"successes"`
+	Failures        int     `json:"failures"`
+	AbandonmentRate float64 `json:"abandonment_rate"`
+	FailureRate     float64 `json:"failure_rate"`
+}
+
+type apiExperimentOutput struct {
+	ID             string                    `json:"id"`
+	ControlLevel   int                       `json:"control_level"`
+	VariantLevel   int                       `json:"variant_level"`
+	VariantWeight  int                       `json:"variant_weight"`
+	MinSamples     int                       `json:"min_samples"`
+	MaxDegradation float64                   `json:"max_degradation"`
+	CreatedAt      string                    `json:"created_at"`
+	EndsAt         string                    `json:"ends_at"`
+	ConcludedAt    string                    `json:"concluded_at,omitempty"`
+	Outcome        string                    `json:"outcome,omitempty"`
+	Conclusion     string                    `json:"conclusion,omitempty"`
+	Arms           []*apiExperimentArmOutput `json:"arms"`
+}
+
+type apiOrgExportInput struct {
+	Passphrase string `json:"passphrase" validate:"required"`
+}
+
+type apiOrgExportOutput struct {
+	Archive    *orgarchive.Envelope `json:"archive"`
+	Properties int                  `json:"properties"`
+	Members    int                  `json:"members"`
+	APIKeys    int                  `json:"api_keys"`
+}
+
+type apiOrgImportInput struct {
+	Passphrase string               `json:"passphrase" validate:"required"`
+	Archive    *orgarchive.Envelope `json:"archive" validate:"required"`
+}
+
+type apiOrgImportResult struct {
+	Entity string            `json:"entity"`
+	Name   string            `json:"name"`
+	Code   common.StatusCode `json:"code"`
+	ID     string            `json:"id,omitempty"`
 }
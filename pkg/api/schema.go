@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	schemaTag = "validate"
+)
+
+// apiFieldError points to the invalid value in the request body, e.g. "[2].claims"
+type apiFieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// apiRequestError is an API status code with locations of invalid values in the request body
+type apiRequestError struct {
+	Code   common.StatusCode
+	Errors []*apiFieldError
+}
+
+func newAPIRequestError(code common.StatusCode, path string) *apiRequestError {
+	return &apiRequestError{
+		Code:   code,
+		Errors: []*apiFieldError{{Path: path, Message: code.String()}},
+	}
+}
+
+func newSchemaRequestError(errs ...*apiFieldError) *apiRequestError {
+	return &apiRequestError{Code: common.StatusSchemaError, Errors: errs}
+}
+
+func fieldPath(parent, name string) string {
+	if len(parent) == 0 {
+		return name
+	}
+
+	if len(name) == 0 {
+		return parent
+	}
+
+	return parent + "." + name
+}
+
+func indexPath(parent string, index int) string {
+	return fmt.Sprintf("%s[%d]", parent, index)
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return t.String()
+	}
+}
+
+// decodeFieldError converts JSON decoding error of the value at path to a field error
+func decodeFieldError(path string, err error) *apiFieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &apiFieldError{
+			Path:    fieldPath(path, typeErr.Field),
+			Message: fmt.Sprintf("expected %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &apiFieldError{
+			Path:    path,
+			Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset),
+		}
+	}
+
+	if (err == io.EOF) || (err == io.ErrUnexpectedEOF) {
+		return &apiFieldError{Path: path, Message: "unexpected end of input"}
+	}
+
+	return &apiFieldError{Path: path, Message: "invalid value"}
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if len(name) == 0 {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+// checkSchemaRule returns error message if value does not satisfy the rule
+func checkSchemaRule(value reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case "required":
+		if value.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return ""
+		}
+
+		var actual float64
+		var what string
+		switch value.Kind() {
+		case reflect.String:
+			actual, what = float64(len([]rune(value.String()))), "length"
+		case reflect.Slice, reflect.Map, reflect.Array:
+			actual, what = float64(value.Len()), "size"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			actual, what = float64(value.Int()), "value"
+		case reflect.Float32, reflect.Float64:
+			actual, what = value.Float(), "value"
+		default:
+			return ""
+		}
+
+		if (name == "min") && (actual < limit) {
+			return fmt.Sprintf("%s has to be at least %s", what, arg)
+		}
+
+		if (name == "max") && (actual > limit) {
+			return fmt.Sprintf("%s has to be at most %s", what, arg)
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		actual := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if option == actual {
+				return ""
+			}
+		}
+		return fmt.Sprintf("has to be one of: %s", strings.Join(options, ", "))
+	}
+
+	return ""
+}
+
+func validateSchemaValue(path string, value reflect.Value, errs []*apiFieldError) []*apiFieldError {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return errs
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			errs = validateSchemaValue(indexPath(path, i), value.Index(i), errs)
+		}
+		return errs
+	case reflect.Struct:
+	default:
+		return errs
+	}
+
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+
+		if field.Anonymous {
+			// embedded structs are flattened in JSON
+			errs = validateSchemaValue(path, fieldValue, errs)
+			continue
+		}
+
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		fpath := fieldPath(path, name)
+
+		if tag := field.Tag.Get(schemaTag); len(tag) > 0 {
+			rules := strings.Split(tag, ",")
+			// same as with "omitempty", only required rule applies to absent values
+			required := slices.Contains(rules, "required")
+			if !fieldValue.IsZero() || required {
+				for _, rule := range rules {
+					if msg := checkSchemaRule(fieldValue, rule); len(msg) > 0 {
+						errs = append(errs, &apiFieldError{Path: fpath, Message: msg})
+						break
+					}
+				}
+			}
+		}
+
+		errs = validateSchemaValue(fpath, fieldValue, errs)
+	}
+
+	return errs
+}
+
+// decodeRequestBody decodes JSON request body into v and validates it against the schema
+func decodeRequestBody(r *http.Request, v any) *apiRequestError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return newSchemaRequestError(decodeFieldError("", err))
+	}
+
+	if errs := validateSchema("", v); len(errs) > 0 {
+		return newSchemaRequestError(errs...)
+	}
+
+	return nil
+}
+
+// validateSchema checks "validate" struct tags of the request value (and nested values). Supported rules are
+// "required", "min=N" and "max=N" (value for numbers, length for strings and collections) and "oneof=a b c".
+// Returned field paths are relative to path (JSON path of the value within request body)
+func validateSchema(path string, v any) []*apiFieldError {
+	return validateSchemaValue(path, reflect.ValueOf(v), nil)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input *apiCreatePropertyInput
+		paths []string
+	}{
+		{&apiCreatePropertyInput{Domain: "example.com"}, nil},
+		{&apiCreatePropertyInput{apiPropertySettings: apiPropertySettings{Growth: "fast", Level: 10}}, nil},
+		{&apiCreatePropertyInput{apiPropertySettings: apiPropertySettings{Growth: "invalid"}}, []string{"[1].growth"}},
+		{&apiCreatePropertyInput{apiPropertySettings: apiPropertySettings{Level: -1, ValiditySeconds: -100}}, []string{"[1].level", "[1].validity_seconds"}},
+	}
+
+	for i, tc := range testCases {
+		errs := validateSchema(indexPath("", 1), tc.input)
+
+		paths := make([]string, 0, len(errs))
+		for _, e := range errs {
+			paths = append(paths, e.Path)
+		}
+
+		if !slices.Equal(paths, tc.paths) {
+			t.Errorf("Unexpected errors (%v) for test case %v: expected %v", paths, i, tc.paths)
+		}
+	}
+}
+
+func TestDecodeFieldError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		body    string
+		path    string
+		message string
+	}{
+		{`{"level": "high"}`, "[0].level", "expected integer"},
+		{`{"claims": {"form": 1}}`, "[0].claims.form", "expected string"},
+		{`{"name": }`, "[0]", "malformed JSON"},
+		{``, "[0]", "unexpected end of input"},
+	}
+
+	for i, tc := range testCases {
+		var input apiCreatePropertyInput
+		err := json.NewDecoder(strings.NewReader(tc.body)).Decode(&input)
+		if err == nil {
+			t.Fatalf("Expected decoding error for test case %v", i)
+		}
+
+		fieldErr := decodeFieldError(indexPath("", 0), err)
+		if fieldErr.Path != tc.path {
+			t.Errorf("Unexpected path (%v) for test case %v: expected %v", fieldErr.Path, i, tc.path)
+		}
+
+		if !strings.HasPrefix(fieldErr.Message, tc.message) {
+			t.Errorf("Unexpected message (%v) for test case %v: expected %v", fieldErr.Message, i, tc.message)
+		}
+	}
+}
@@ -6,15 +6,22 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ipreputation"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
@@ -28,8 +35,14 @@ const (
 	PropertyBucketSize    = 5 * time.Minute
 	updateLimitsBatchSize = 100
 	maxVerifyBatchSize    = 100_000
+	ReceiptBatchSize      = 100
+	maxReceiptBatchSize   = 10_000
 	ApiService            = "api"
 	recaptchaCompatV3     = "rcV3"
+	maxShadowRequests     = 100
+	// per-client limits for puzzle requests of a property in emergency mode
+	emergencyLeakyBucketCap = 5
+	emergencyLeakInterval   = 10 * time.Second
 )
 
 var (
@@ -37,6 +50,7 @@ var (
 	errInvalidAPIKey  = errors.New("API key is not valid")
 	errAPIKeyScope    = errors.New("API key scope is not valid")
 	errAPIKeyReadOnly = errors.New("API key read-write mode mismatch")
+	errAPIKeySource   = errors.New("API key is used from not allowed IP address")
 	errPuzzleOwner    = errors.New("error fetching puzzle owner")
 	errInvalidArg     = errors.New("invalid arguments")
 	errTestSolutions  = errors.New("invalid test solutions")
@@ -79,6 +93,9 @@ type Server struct {
 	Auth               *AuthMiddleware
 	VerifyLogChan      chan *common.VerifyRecord
 	VerifyLogCancel    context.CancelFunc
+	ReceiptChan        chan *common.IssuanceReceipt
+	QuotaLogChan       chan *common.QuotaRecord
+	Quota              *PropertyQuota
 	Cors               *cors.Cors
 	Metrics            common.APIMetrics
 	Mailer             common.Mailer
@@ -87,6 +104,16 @@ type Server struct {
 	SubscriptionLimits db.SubscriptionLimits
 	IDHasher           common.IdentifierHasher
 	AsyncTasks         db.AsyncTasks
+	// alternate implementation of the verify endpoint (e.g. a rewrite), receives shadow traffic
+	VerifyShadow http.Handler
+	verifyShadow *common.ShadowHandler
+	LoadShedder  *common.LoadShedder
+	Footprint    *common.Footprint
+	// optional, raises difficulty for clients from low-reputation networks
+	IPReputation *ipreputation.Reputation
+	taskProgress *taskProgress
+	regions      atomic.Pointer[regionMap]
+	asnHeader    atomic.Pointer[string]
 }
 
 type apiKeyOwnerSource struct {
@@ -125,7 +152,8 @@ func (a *apiKeyOwnerSource) OwnerID(ctx context.Context, tnow time.Time) (int32,
 		return -1, nil, err
 	}
 
-	if !isAPIKeyValid(ctx, apiKey, tnow) {
+	secret, _ := ctx.Value(common.SecretContextKey).(string)
+	if !isAPIKeyValid(ctx, apiKey, secret, tnow) {
 		return -1, nil, errInvalidAPIKey
 	}
 
@@ -133,6 +161,16 @@ func (a *apiKeyOwnerSource) OwnerID(ctx context.Context, tnow time.Time) (int32,
 		return -1, nil, errAPIKeyScope
 	}
 
+	// postponed lookup means that APIKey() middleware did not check allowed ranges of the key (nor recorded its usage)
+	if _, cached := ctx.Value(common.APIKeyContextKey).(*dbgen.APIKey); !cached {
+		addr, _ := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr)
+		if !checkAPIKeySource(ctx, a.Store, apiKey, addr) {
+			return -1, nil, errAPIKeySource
+		}
+
+		a.Store.Impl().RecordAPIKeyUsage(apiKey)
+	}
+
 	var orgID *int32
 	if apiKey.OrgID.Valid {
 		orgID = new(int32)
@@ -147,6 +185,12 @@ type VerificationResponse struct {
 	Code      puzzle.VerifyError `json:"code"`
 	Origin    string             `json:"origin,omitempty"`
 	Timestamp common.JSONTime    `json:"timestamp,omitempty"`
+	// solution was issued for another property from the same trust group
+	CrossProperty bool `json:"cross_property,omitempty"`
+	// claims of the property owner that were signed into the puzzle
+	Claims map[string]string `json:"claims,omitempty"`
+	// signed token for downstream services (only if requested with header)
+	Attestation string `json:"attestation,omitempty"`
 }
 
 type VerifyResponseRecaptchaV2 struct {
@@ -154,6 +198,10 @@ type VerifyResponseRecaptchaV2 struct {
 	ErrorCodes  []string        `json:"error-codes,omitempty"`
 	ChallengeTS common.JSONTime `json:"challenge_ts"`
 	Hostname    string          `json:"hostname"`
+	// solution was issued for another property from the same trust group
+	CrossProperty bool `json:"cross_property,omitempty"`
+	// claims of the property owner that were signed into the puzzle
+	Claims map[string]string `json:"claims,omitempty"`
 }
 
 type VerifyResponseRecaptchaV3 struct {
@@ -174,22 +222,50 @@ func (s *Server) Init(ctx context.Context, verifyFlushInterval, authBackfillDela
 	s.Auth.StartBackfill(authBackfillDelay)
 	s.RegisterTaskHandlers(ctx)
 
+	if s.VerifyShadow != nil {
+		s.verifyShadow = common.NewShadowHandler(common.VerifyEndpoint, http.HandlerFunc(s.pcVerifyHandler), s.VerifyShadow, maxShadowRequests)
+	}
+
 	baseVerifyCtx := context.WithValue(context.Background(), common.ServiceContextKey, ApiService)
 	var cancelVerifyCtx context.Context
 	cancelVerifyCtx, s.VerifyLogCancel = context.WithCancel(context.WithValue(baseVerifyCtx, common.TraceIDContextKey, "flush_verify_log"))
 
-	go common.ProcessBatchArray(cancelVerifyCtx, s.VerifyLogChan, verifyFlushInterval, VerifyBatchSize, maxVerifyBatchSize, s.TimeSeries.WriteVerifyLogBatch)
+	go common.ProcessBatchArray(cancelVerifyCtx, s.VerifyLogChan, verifyFlushInterval, s.Footprint.Size(VerifyBatchSize), maxVerifyBatchSize, s.TimeSeries.WriteVerifyLogBatch)
+
+	// receipts are rare so they share cancellation with verify log
+	receiptsCtx := context.WithValue(cancelVerifyCtx, common.TraceIDContextKey, "flush_issuance_receipts")
+	go common.ProcessBatchArray(receiptsCtx, s.ReceiptChan, verifyFlushInterval, s.Footprint.Size(ReceiptBatchSize), maxReceiptBatchSize, s.TimeSeries.WriteIssuanceReceiptBatch)
+
+	s.Quota.Start(authBackfillDelay)
+	quotaCtx := context.WithValue(cancelVerifyCtx, common.TraceIDContextKey, "flush_quota_log")
+	go common.ProcessBatchArray(quotaCtx, s.QuotaLogChan, verifyFlushInterval, s.Footprint.Size(QuotaBatchSize), maxQuotaBatchSize, s.TimeSeries.WriteQuotaLogBatch)
 
 	return nil
 }
 
+func (s *Server) UpdateConfig(ctx context.Context, cfg common.ConfigStore) {
+	s.updateRegions(ctx, cfg)
+	s.updateASNHeader(ctx, cfg)
+	s.Verifier.UpdateIntegrity(ctx, cfg)
+	s.Auth.HotProperties.UpdateConfig(ctx, cfg)
+
+	if s.IPReputation != nil {
+		s.IPReputation.UpdateConfig(ctx, cfg)
+	}
+
+	if s.verifyShadow != nil {
+		s.verifyShadow.SetPercent(config.AsInt(cfg.Get(common.ShadowVerifyPercentKey), 0))
+	}
+}
+
 func (s *Server) Setup(domain string, verbose bool, security alice.Constructor) *common.RouteGenerator {
 	corsOpts := cors.Options{
 		// NOTE: due to the implementation of rs/cors, we need not to set "*" as AllowOrigin as this will ruin the response
 		// (in case of "*" allowed origin, response contains the same, while we want to restrict the response to domain)
 		AllowOriginVaryRequestFunc: s.Auth.originAllowed,
-		AllowedHeaders:             []string{common.HeaderCaptchaVersion, "accept", "content-type", "x-requested-with"},
-		AllowedMethods:             []string{http.MethodGet},
+		AllowedHeaders:             []string{common.HeaderCaptchaVersion, common.HeaderCaptchaRemember, common.HeaderCaptchaSticky, common.HeaderHandoffToken, "accept", "content-type", "x-requested-with"},
+		AllowedMethods:             []string{http.MethodGet, http.MethodPost},
+		ExposedHeaders:             []string{common.HeaderVerifyRegion},
 		AllowPrivateNetwork:        true,
 		OptionsPassthrough:         true,
 		Debug:                      verbose,
@@ -215,20 +291,28 @@ func (s *Server) Setup(domain string, verbose bool, security alice.Constructor)
 func (s *Server) Shutdown() {
 	s.Levels.Shutdown()
 	s.Auth.Shutdown()
+	s.Quota.Shutdown()
 
 	slog.Debug("Shutting down API server routines")
 	s.VerifyLogCancel()
 	close(s.VerifyLogChan)
+	close(s.ReceiptChan)
+	close(s.QuotaLogChan)
 }
 
 func (s *Server) setupWithPrefix(rg *common.RouteGenerator, corsHandler, security alice.Constructor) {
 	svc := common.ServiceMiddleware(ApiService)
 	publicChain := alice.New(svc, common.Recovered, security)
 	// NOTE: auth middleware provides rate limiting internally
-	puzzleChain := publicChain.Append(s.Metrics.Handler, s.RateLimiter.RateLimit, monitoring.Traced, common.TimeoutHandler(1*time.Second))
+	// critical endpoints are never shed, but they are accounted for in the server load
+	critical := s.LoadShedder.Middleware(common.PriorityCritical)
+	puzzleChain := publicChain.Append(s.Metrics.Handler, critical, s.RateLimiter.RateLimit, monitoring.Traced, common.TimeoutHandler(1*time.Second))
 	rg.Handle(rg.Get(common.PuzzleEndpoint), puzzleChain.Append(corsHandler, s.Auth.Sitekey), http.HandlerFunc(s.puzzleHandler))
 	rg.Handle(rg.Options(common.PuzzleEndpoint), puzzleChain.Append(common.Cached, corsHandler, s.Auth.SitekeyOptions), http.HandlerFunc(s.puzzlePreFlight))
 
+	imageChain := publicChain.Append(s.Metrics.Handler, s.LoadShedder.Middleware(common.PriorityNormal), s.RateLimiter.RateLimit, monitoring.Traced, common.TimeoutHandler(5*time.Second))
+	rg.Handle(rg.Post(common.PuzzleEndpoint, common.ImageEndpoint), imageChain.Append(corsHandler, s.Auth.Sitekey), http.MaxBytesHandler(http.HandlerFunc(s.imageChallengeHandler), maxImageChallengeBodySize))
+
 	const (
 		// NOTE: these defaults will be adjusted per API key quota almost immediately after verifying API key
 		// requests burst
@@ -238,14 +322,43 @@ func (s *Server) setupWithPrefix(rg *common.RouteGenerator, corsHandler, securit
 	)
 	apiRateLimiter := s.RateLimiter.RateLimitExFunc(apiKeyLeakyBucketCap, apiKeyLeakInterval)
 
-	verifyChain := publicChain.Append(s.Metrics.Handler, apiRateLimiter, monitoring.Traced, common.TimeoutHandler(5*time.Second))
+	verifyChain := publicChain.Append(s.Metrics.Handler, critical, apiRateLimiter, monitoring.Traced, common.TimeoutHandler(5*time.Second))
 	// reCAPTCHA compatibility
 	// the difference from our side is _when_ we fetch API key: for reCAPTCHA it comes in form field "secret" and
 	// we want to put it _behind_ the MaxBytesHandler, while for Private Captcha format (header) it can be before
 	formAPIAuth := s.Auth.APIKey(formSecretAPIKey, dbgen.ApiKeyScopePuzzle)
 	rg.Handle(rg.Post(common.SiteVerifyEndpoint), verifyChain, http.MaxBytesHandler(formAPIAuth(http.HandlerFunc(s.recaptchaVerifyHandler)), maxSolutionsBodySize))
+	// Turnstile compatibility (same path as Cloudflare so that only the host needs to be changed)
+	rg.Handle(rg.Post(common.TurnstileEndpoint, common.SiteVerifyEndpoint), verifyChain, http.MaxBytesHandler(turnstileForm(formAPIAuth(http.HandlerFunc(s.turnstileVerifyHandler))), maxSolutionsBodySize))
 	// Private Captcha format
-	rg.Handle(rg.Post(common.VerifyEndpoint), verifyChain.Append(s.Auth.APIKey(headerAPIKey, dbgen.ApiKeyScopePuzzle)), http.MaxBytesHandler(http.HandlerFunc(s.pcVerifyHandler), maxSolutionsBodySize))
+	var verifyHandler http.Handler = http.HandlerFunc(s.pcVerifyHandler)
+	if s.verifyShadow != nil {
+		verifyHandler = s.verifyShadow
+	}
+	rg.Handle(rg.Post(common.VerifyEndpoint), verifyChain.Append(s.Auth.APIKey(headerAPIKey, dbgen.ApiKeyScopePuzzle)), http.MaxBytesHandler(verifyHandler, maxSolutionsBodySize))
+	// asynchronous reports from the edge verification proxy can be retried, so they are shed first
+	reportChain := publicChain.Append(s.Metrics.Handler, s.LoadShedder.Middleware(common.PriorityLow), apiRateLimiter, monitoring.Traced, common.TimeoutHandler(10*time.Second))
+	rg.Handle(rg.Post(common.VerifyEndpoint, common.ReportEndpoint), reportChain.Append(s.Auth.APIKey(headerAPIKey, dbgen.ApiKeyScopePuzzle)), http.MaxBytesHandler(http.HandlerFunc(s.verifyReportHandler), maxVerifyReportBodySize))
+
+	arg := func(s string) string {
+		return fmt.Sprintf("{%s}", s)
+	}
+
+	// kiosk devices hand off solving the captcha to a phone (via QR code) and poll for the result
+	handoffChain := publicChain.Append(s.Metrics.Handler, s.LoadShedder.Middleware(common.PriorityNormal), s.RateLimiter.RateLimit, monitoring.Traced, common.TimeoutHandler(5*time.Second))
+	rg.Handle(rg.Post(common.HandoffEndpoint), handoffChain.Append(corsHandler, s.Auth.Sitekey), http.MaxBytesHandler(http.HandlerFunc(s.createHandoff), maxHandoffBodySize))
+	rg.Handle(rg.Post(common.HandoffEndpoint, arg(common.ParamID)), handoffChain.Append(corsHandler), http.MaxBytesHandler(http.HandlerFunc(s.completeHandoff), maxSolutionsBodySize))
+	rg.Handle(rg.Get(common.HandoffEndpoint, arg(common.ParamID)), handoffChain.Append(corsHandler), http.HandlerFunc(s.handoffStatus))
+	rg.Handle(rg.Options(common.HandoffEndpoint, arg(common.ParamID)), handoffChain.Append(common.Cached, corsHandler), common.HttpStatus(http.StatusNoContent))
+
+	catalogChain := publicChain.Append(s.Metrics.Handler, s.LoadShedder.Middleware(common.PriorityLow), s.RateLimiter.RateLimit, common.Cached)
+	rg.Handle(rg.Get(common.EventsEndpoint, common.CatalogEndpoint), catalogChain, http.HandlerFunc(s.getEventsCatalog))
+	rg.Handle(rg.Get(common.AttestationEndpoint, common.KeysEndpoint), catalogChain, http.HandlerFunc(s.attestationKeys))
+	rg.Handle(rg.Get(common.StatusCodesEndpoint), catalogChain, http.HandlerFunc(s.getStatusCodes))
+	rg.Handle(rg.Get(common.ChangelogEndpoint), catalogChain, http.HandlerFunc(s.getChangelog))
+
+	regionsChain := publicChain.Append(s.Metrics.Handler, s.LoadShedder.Middleware(common.PriorityLow), s.RateLimiter.RateLimit)
+	rg.Handle(rg.Get(common.RegionsEndpoint), regionsChain, http.HandlerFunc(s.getRegions))
 
 	s.setupEnterprise(rg, publicChain, apiRateLimiter)
 
@@ -264,9 +377,130 @@ func (s *Server) puzzlePreFlight(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// botPrefilter applies property bot policy to requests that match bot heuristics and returns minimal puzzle difficulty
+func (s *Server) botPrefilter(r *http.Request) (uint8, bool) {
+	ctx := r.Context()
+	property, ok := ctx.Value(common.PropertyContextKey).(*dbgen.Property)
+	if !ok || (property == nil) {
+		return 0, false
+	}
+
+	heuristic := detectBotHeuristic(r)
+	if heuristic == botHeuristicNone {
+		return 0, false
+	}
+
+	policy := property.BotPolicy
+	if len(policy) == 0 {
+		policy = dbgen.BotPolicyMonitor
+	}
+
+	s.Metrics.ObserveBotHeuristic(string(heuristic), string(policy))
+	slog.Log(ctx, common.LevelTrace, "Request matched bot heuristic", "propID", property.ID, "heuristic", heuristic,
+		"policy", policy)
+
+	switch policy {
+	case dbgen.BotPolicyBlock:
+		return 0, true
+	case dbgen.BotPolicyMaxDifficulty:
+		return uint8(common.MaxDifficultyLevel), false
+	default:
+		return 0, false
+	}
+}
+
+// reputationPrefilter returns minimal puzzle difficulty for clients listed in IP reputation feeds
+func (s *Server) reputationPrefilter(r *http.Request) uint8 {
+	if s.IPReputation == nil {
+		return 0
+	}
+
+	ctx := r.Context()
+	addr, _ := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr)
+
+	difficulty, listed := s.IPReputation.Difficulty(addr)
+	if listed {
+		slog.Log(ctx, common.LevelTrace, "Request matched IP reputation feed", "difficulty", difficulty)
+	}
+
+	return difficulty
+}
+
+// sendPuzzleFailure lets the widget show property's failure message (or redirect) instead of a generic error
+func (s *Server) sendPuzzleFailure(ctx context.Context, w http.ResponseWriter, status int) {
+	property, ok := ctx.Value(common.PropertyContextKey).(*dbgen.Property)
+	if !ok || (property == nil) || ((len(property.FailureURL) == 0) && (len(property.FailureMessage) == 0)) {
+		http.Error(w, "", status)
+		return
+	}
+
+	s.writePuzzleFailure(ctx, w, status, &puzzleFailureResponse{
+		Error:          http.StatusText(status),
+		FailureURL:     property.FailureURL,
+		FailureMessage: property.FailureMessage,
+	})
+}
+
+// sendQuotaExceeded lets the widget show a specific state instead of retrying (and degrading) like for rate limits
+func (s *Server) sendQuotaExceeded(ctx context.Context, w http.ResponseWriter, property *dbgen.Property, retryAfter time.Duration) {
+	w.Header().Set(common.HeaderRetryAfter, strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+
+	s.writePuzzleFailure(ctx, w, http.StatusTooManyRequests, &puzzleFailureResponse{
+		Error:          http.StatusText(http.StatusTooManyRequests),
+		Code:           quotaExceededCode,
+		FailureURL:     property.FailureURL,
+		FailureMessage: property.FailureMessage,
+	})
+}
+
+func (s *Server) writePuzzleFailure(ctx context.Context, w http.ResponseWriter, status int, response *puzzleFailureResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize puzzle failure response", common.ErrAttr(err))
+		http.Error(w, "", status)
+		return
+	}
+
+	common.WriteHeaders(w, common.JSONContentHeaders)
+	common.WriteHeaders(w, common.NoCacheHeaders)
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		slog.ErrorContext(ctx, "Failed to write puzzle failure response", common.ErrAttr(err))
+	}
+}
+
 func (s *Server) puzzleHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	puzzle, property, err := s.Verifier.PuzzleForRequest(r, s.Levels)
+
+	minDifficulty, blocked := s.accessListPrefilter(r)
+	if !blocked {
+		botDifficulty, botBlocked := s.botPrefilter(r)
+		minDifficulty, blocked = max(minDifficulty, botDifficulty), botBlocked
+	}
+
+	if blocked {
+		s.sendPuzzleFailure(ctx, w, http.StatusForbidden)
+		return
+	}
+
+	minDifficulty = max(minDifficulty, s.reputationPrefilter(r))
+
+	if property, ok := ctx.Value(common.PropertyContextKey).(*dbgen.Property); ok && (property != nil) {
+		tnow := time.Now()
+
+		if db.IsPropertyEmergency(property, tnow) {
+			s.RateLimiter.UpdateRequestLimits(r, emergencyLeakyBucketCap, emergencyLeakInterval)
+		}
+
+		if allowed, retryAfter := s.Quota.Allow(ctx, property, tnow); !allowed {
+			slog.Log(ctx, common.LevelTrace, "Property puzzle quota exceeded", "propID", property.ID, "retryAfter", retryAfter)
+			s.addQuotaRecord(ctx, property, tnow)
+			s.sendQuotaExceeded(ctx, w, property, retryAfter)
+			return
+		}
+	}
+
+	puzzle, property, err := s.Verifier.PuzzleForRequest(r, s.Levels, minDifficulty)
 	if err != nil {
 		if err == db.ErrTestProperty {
 			common.WriteHeaders(w, common.CachedHeaders)
@@ -295,51 +529,92 @@ func (s *Server) puzzleHandler(w http.ResponseWriter, r *http.Request) {
 		extraSalt = property.Salt
 	}
 
+	s.writeRegionHeader(w, r)
+
 	if err := s.Verifier.Write(ctx, puzzle, extraSalt, w); err != nil {
 		slog.ErrorContext(ctx, "Failed to write puzzle", common.ErrAttr(err))
 	}
 
+	if property != nil {
+		s.addIssuanceReceipt(ctx, puzzle, property)
+		s.Metrics.ObservePropertyPuzzleCreated(db.UUIDToSiteKey(property.ExternalID))
+	}
+
 	s.Metrics.ObservePuzzleCreated(userID)
 }
 
 // reCAPTCHA format: puzzle response is in form field "response", API key is in form field "secret"
 // https://developers.google.com/recaptcha/docs/verify
 func (s *Server) recaptchaVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	s.siteVerify(w, r, acceptsTurnstile(r))
+}
+
+// Turnstile format: request is the same as for reCAPTCHA, but response fields and error codes follow Turnstile
+func (s *Server) turnstileVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	s.siteVerify(w, r, true /*turnstile*/)
+}
+
+func (s *Server) siteVerify(w http.ResponseWriter, r *http.Request, turnstile bool) {
+	tstart := time.Now()
 	ctx := r.Context()
 
 	if err := r.ParseForm(); err != nil {
 		slog.ErrorContext(ctx, "Failed to read request form", common.ErrAttr(err))
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		if turnstile {
+			sendTurnstileError(w, r, turnstileBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		}
 		return
 	}
 
 	data := r.FormValue(common.ParamResponse)
 	if len(data) == 0 {
 		slog.ErrorContext(ctx, "Empty captcha response")
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		if turnstile {
+			sendTurnstileError(w, r, turnstileMissingResponse)
+		} else {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		}
 		return
 	}
 
-	payload, err := s.Verifier.ParseSolutionPayload(ctx, []byte(data))
+	payload, err := s.Verifier.ParseVerifyPayload(ctx, []byte(data))
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		if turnstile {
+			sendTurnstileError(w, r, turnstileInvalidResponse)
+		} else {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		}
 		return
 	}
 
+	crossProperty := false
 	if sitekey := r.FormValue(common.ParamSiteKey); db.CanBeValidSitekey(sitekey) {
 		propertyID := payload.Puzzle().PropertyID()
 		if propertyExternalID := db.UUIDFromSiteKey(sitekey); !bytes.Equal(propertyExternalID.Bytes[:], propertyID[:]) {
-			slog.WarnContext(ctx, "Expected property ID does not match", "expected", sitekey, "actual", hex.EncodeToString(propertyID[:]))
-			common.SendReponse(ctx, w, invalidPropertyRecaptchaResponse, common.JSONContentHeaders, common.NoCacheHeaders, s.APIHeaders)
-			return
+			if !s.Verifier.IsTrustedProperty(ctx, sitekey, propertyID) {
+				slog.WarnContext(ctx, "Expected property ID does not match", "expected", sitekey, "actual", hex.EncodeToString(propertyID[:]))
+				if turnstile {
+					sendTurnstileError(w, r, turnstileInvalidResponse)
+				} else {
+					common.SendReponse(ctx, w, invalidPropertyRecaptchaResponse, common.JSONContentHeaders, common.NoCacheHeaders, s.APIHeaders)
+				}
+				return
+			}
+			crossProperty = true
 		}
 	}
 
 	ownerSource := &apiKeyOwnerSource{Store: s.BusinessDB, scope: dbgen.ApiKeyScopePuzzle}
 	result, err := s.Verifier.Verify(ctx, payload, ownerSource, time.Now().UTC())
 	if err != nil {
-		switch err {
-		case errPuzzleOwner:
+		switch {
+		case turnstile && (err == errPuzzleOwner):
+			sendTurnstileError(w, r, turnstileInvalidSecret)
+		case turnstile:
+			sendTurnstileError(w, r, turnstileInternalError)
+		case err == errPuzzleOwner:
 			// "late" auth check (we postpone API key check in case it's not cached in Auth)
 			// in this case we also automatically set "API key" (or whatever is passed) as missing in cache
 			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
@@ -350,7 +625,7 @@ func (s *Server) recaptchaVerifyHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	if result.Valid() {
-		s.addVerifyRecord(ctx, result)
+		s.addVerifyRecord(ctx, result, time.Since(tstart))
 	}
 
 	if apiKey := ownerSource.cachedKey; apiKey != nil {
@@ -360,11 +635,21 @@ func (s *Server) recaptchaVerifyHandler(w http.ResponseWriter, r *http.Request)
 		s.RateLimiter.UpdateRequestLimits(r, uint32(apiKey.RequestsBurst), time.Duration(interval))
 	}
 
+	if turnstile {
+		common.SendJSONResponse(r.Context(), w, newTurnstileResponse(result, crossProperty), common.NoCacheHeaders)
+		return
+	}
+
 	vr2 := &VerifyResponseRecaptchaV2{
-		Success:     result.Success(),
-		ErrorCodes:  result.ErrorsToStrings(),
-		ChallengeTS: common.JSONTime(result.CreatedAt),
-		Hostname:    result.Domain,
+		Success:       result.Success(),
+		ErrorCodes:    result.ErrorsToStrings(),
+		ChallengeTS:   common.JSONTime(result.CreatedAt),
+		Hostname:      result.Domain,
+		CrossProperty: crossProperty && result.Success(),
+	}
+
+	if result.Success() {
+		vr2.Claims = result.Claims
 	}
 
 	var response interface{} = vr2
@@ -381,6 +666,7 @@ func (s *Server) recaptchaVerifyHandler(w http.ResponseWriter, r *http.Request)
 
 // Private Captcha format: puzzle response is the whole body, API key is in header
 func (s *Server) pcVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	tstart := time.Now()
 	ctx := r.Context()
 
 	data, err := io.ReadAll(r.Body)
@@ -390,19 +676,23 @@ func (s *Server) pcVerifyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	payload, err := s.Verifier.ParseSolutionPayload(ctx, data)
+	payload, err := s.Verifier.ParseVerifyPayload(ctx, data)
 	if err != nil {
 		slog.Log(ctx, common.LevelTrace, "Failed to parse solution payload", common.ErrAttr(err))
 		http.Error(w, "Failed to parse payload", http.StatusBadRequest)
 		return
 	}
 
+	crossProperty := false
 	if sitekey := r.Header.Get(common.HeaderSitekey); db.CanBeValidSitekey(sitekey) {
 		propertyID := payload.Puzzle().PropertyID()
 		if propertyExternalID := db.UUIDFromSiteKey(sitekey); !bytes.Equal(propertyExternalID.Bytes[:], propertyID[:]) {
-			slog.WarnContext(ctx, "Expected property ID does not match", "expected", sitekey, "actual", hex.EncodeToString(propertyID[:]))
-			common.SendReponse(ctx, w, invalidPropertyResponse, common.JSONContentHeaders, common.NoCacheHeaders, s.APIHeaders)
-			return
+			if !s.Verifier.IsTrustedProperty(ctx, sitekey, propertyID) {
+				slog.WarnContext(ctx, "Expected property ID does not match", "expected", sitekey, "actual", hex.EncodeToString(propertyID[:]))
+				common.SendReponse(ctx, w, invalidPropertyResponse, common.JSONContentHeaders, common.NoCacheHeaders, s.APIHeaders)
+				return
+			}
+			crossProperty = true
 		}
 	}
 
@@ -421,7 +711,7 @@ func (s *Server) pcVerifyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if result.Valid() {
-		s.addVerifyRecord(ctx, result)
+		s.addVerifyRecord(ctx, result, time.Since(tstart))
 	}
 
 	if apiKey := ownerSource.cachedKey; apiKey != nil {
@@ -432,16 +722,22 @@ func (s *Server) pcVerifyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := &VerificationResponse{
-		Success:   result.Success(),
-		Code:      result.Error,
-		Origin:    result.Domain,
-		Timestamp: common.JSONTime(result.CreatedAt),
+		Success:       result.Success(),
+		Code:          result.Error,
+		Origin:        result.Domain,
+		Timestamp:     common.JSONTime(result.CreatedAt),
+		CrossProperty: crossProperty && result.Success(),
+	}
+
+	if result.Success() {
+		response.Claims = result.Claims
+		response.Attestation = s.Verifier.attestationToken(ctx, r, result, response.CrossProperty)
 	}
 
 	common.SendJSONResponse(r.Context(), w, response, common.NoCacheHeaders, s.APIHeaders)
 }
 
-func (s *Server) addVerifyRecord(ctx context.Context, result *puzzle.VerifyResult) {
+func (s *Server) addVerifyRecord(ctx context.Context, result *puzzle.VerifyResult, duration time.Duration) {
 	vr := &common.VerifyRecord{
 		UserID:     result.UserID,
 		OrgID:      result.OrgID,
@@ -449,22 +745,42 @@ func (s *Server) addVerifyRecord(ctx context.Context, result *puzzle.VerifyResul
 		PuzzleID:   result.PuzzleID,
 		Timestamp:  time.Now().UTC(),
 		Status:     int8(result.Error),
+		// NOTE: verification errors are also recorded as they are the failures of experiment arm
+		ExperimentArm: result.ExperimentArm,
+		VisitorClass:  result.VisitorClass,
+		TraceID:       common.TraceID(ctx),
+		Integrity:     result.Integrity,
+	}
+
+	if duration > 0 {
+		// at least 1us as zero means "not measured"
+		vr.DurationUs = uint32(min(max(duration.Microseconds(), 1), math.MaxUint32))
 	}
 
 	s.VerifyLogChan <- vr
 
 	s.Metrics.ObservePuzzleVerified(vr.UserID, result.Error.String(), (result.PuzzleID == 0) /*is stub*/)
+	s.Metrics.ObservePropertyPuzzleVerified(result.SiteKey, result.Error.String(), duration)
+
+	if result.SkewTolerated {
+		slog.DebugContext(ctx, "Accepted expired puzzle within allowed clock skew", "propID", result.PropertyID, "result", result.Error.String())
+		s.Metrics.ObserveVerifySkewTolerated(result.Error.String())
+	}
 
 	// we do not record access for stub puzzles in /puzzle initially, but now they are "verified" so we can backfill
-	if (result.PuzzleID == 0) && !result.CreatedAt.IsZero() {
+	// (bypass tokens are not puzzles and should not affect difficulty)
+	if (result.PuzzleID == 0) && !result.CreatedAt.IsZero() && (result.VisitorClass != common.VisitorClassBypass) {
 		s.Levels.BackfillAccess(result)
 	}
 }
 
 func (s *Server) ReportingVerifier() puzzle.Engine {
 	return &reportingVerifier{
-		verifier:   s.Verifier,
-		reportFunc: s.addVerifyRecord,
+		verifier: s.Verifier,
+		reportFunc: func(ctx context.Context, result *puzzle.VerifyResult) {
+			// portal verifications are not server-to-server calls, so we don't account their latency
+			s.addVerifyRecord(ctx, result, 0)
+		},
 	}
 }
 
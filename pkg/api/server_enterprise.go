@@ -4,9 +4,12 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
@@ -22,6 +25,7 @@ const (
 	maxPostPropertiesBodySize   = 1024 * 1024
 	maxDeletePropertiesBodySize = 128 * 1024
 	maxUpdatePropertiesBodySize = 1024 * 1024
+	maxOrgImportBodySize        = 8 * 1024 * 1024
 )
 
 func (s *Server) setupEnterprise(rg *common.RouteGenerator, publicChain alice.Chain, apiRateLimiter func(next http.Handler) http.Handler) {
@@ -30,32 +34,67 @@ func (s *Server) setupEnterprise(rg *common.RouteGenerator, publicChain alice.Ch
 	}
 
 	// "portal" API
-	portalAPIChain := publicChain.Append(s.Metrics.HandlerIDFunc(rg.LastPath), apiRateLimiter, monitoring.Traced, common.TimeoutHandler(5*time.Second), s.Auth.APIKey(headerAPIKey, dbgen.ApiKeyScopePortal))
+	portalAPIChain := publicChain.Append(s.Metrics.HandlerIDFunc(rg.LastPath), s.LoadShedder.Middleware(common.PriorityNormal), apiRateLimiter, monitoring.Traced, common.TimeoutHandler(5*time.Second), s.Auth.APIKey(headerAPIKey, dbgen.ApiKeyScopePortal), s.Auth.IPAllowlist)
 	// tasks
 	rg.Handle(rg.Get(common.AsyncTaskEndpoint, arg(common.ParamID)), portalAPIChain, http.HandlerFunc(s.getAsyncTask))
+	// event stream outlives request timeout and would skew latency metrics
+	taskEventsChain := publicChain.Append(s.LoadShedder.Middleware(common.PriorityLow), apiRateLimiter, monitoring.Traced, s.Auth.APIKey(headerAPIKey, dbgen.ApiKeyScopePortal), s.Auth.IPAllowlist)
+	rg.Handle(rg.Get(common.AsyncTaskEndpoint, arg(common.ParamID), common.EventsEndpoint), taskEventsChain, http.HandlerFunc(s.getAsyncTaskEvents))
+	// limits
+	rg.Handle(rg.Get(common.LimitsEndpoint), portalAPIChain, http.HandlerFunc(s.getLimits))
+	rg.Handle(rg.Get(common.LimitsEndpoint, common.QuotaEndpoint), portalAPIChain, http.HandlerFunc(s.getQuota))
+	// api keys
+	rg.Handle(rg.Get(common.APIKeysEndpoint), portalAPIChain, http.HandlerFunc(s.getAPIKeys))
+	rg.Handle(rg.Post(common.APIKeysEndpoint, common.BatchEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postAPIKeysBatch), maxAPIPostBodySize))
+	// audit logs
+	rg.Handle(rg.Get(common.AuditLogsEndpoint), portalAPIChain, http.HandlerFunc(s.getUserAuditLogs))
 	// orgs
 	rg.Handle(rg.Get(common.OrganizationsEndpoint), portalAPIChain, http.HandlerFunc(s.getUserOrgs))
 	rg.Handle(rg.Post(common.OrgEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postNewOrg), maxAPIPostBodySize))
 	rg.Handle(rg.Put(common.OrgEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.updateOrg), maxAPIPostBodySize))
 	rg.Handle(rg.Delete(common.OrgEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.deleteOrg), maxAPIPostBodySize))
+	rg.Handle(rg.Post(common.OrgEndpoint, common.ImportEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postOrgImport), maxOrgImportBodySize))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.ExportEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postOrgExport), maxAPIPostBodySize))
 	// properties
 	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertiesEndpoint), portalAPIChain, http.HandlerFunc(s.getOrgProperties))
 	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertiesEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postNewProperties), maxPostPropertiesBodySize))
 	rg.Handle(rg.Delete(common.PropertiesEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.deleteProperties), maxDeletePropertiesBodySize))
 	rg.Handle(rg.Put(common.PropertiesEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.updateProperties), maxUpdatePropertiesBodySize))
 	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty)), portalAPIChain, http.HandlerFunc(s.getOrgProperty))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.PromoteEndpoint), portalAPIChain, http.HandlerFunc(s.promoteProperty))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.CloneEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postPropertyClone), maxPostPropertiesBodySize))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.EmergencyEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postPropertyEmergency), maxAPIPostBodySize))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.EmergencyEndpoint), portalAPIChain, http.HandlerFunc(s.deletePropertyEmergency))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.AccessListEndpoint), portalAPIChain, http.HandlerFunc(s.getPropertyAccessList))
+	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.AccessListEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.putPropertyAccessList), maxAPIPostBodySize))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.AccessListEndpoint), portalAPIChain, http.HandlerFunc(s.deletePropertyAccessList))
+	// difficulty experiments
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.ExperimentEndpoint), portalAPIChain, http.HandlerFunc(s.getPropertyExperiment))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.ExperimentEndpoint), portalAPIChain, http.MaxBytesHandler(http.HandlerFunc(s.postPropertyExperiment), maxAPIPostBodySize))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.ExperimentEndpoint), portalAPIChain, http.HandlerFunc(s.deletePropertyExperiment))
 }
 
 func (s *Server) RegisterTaskHandlers(ctx context.Context) {
-	if ok := s.AsyncTasks.Register(createPropertiesHandlerID, s.handleCreateProperties); !ok {
+	s.taskProgress = newTaskProgress()
+
+	if ok := s.AsyncTasks.Register(createPropertiesHandlerID, s.taskProgress.observe(s.handleCreateProperties)); !ok {
 		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", createPropertiesHandlerID)
 	}
-	if ok := s.AsyncTasks.Register(deletePropertiesHandlerID, s.handleDeleteProperties); !ok {
+	if ok := s.AsyncTasks.Register(clonePropertyHandlerID, s.taskProgress.observe(s.handleCloneProperty)); !ok {
+		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", clonePropertyHandlerID)
+	}
+	if ok := s.AsyncTasks.Register(deletePropertiesHandlerID, s.taskProgress.observe(s.handleDeleteProperties)); !ok {
 		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", deletePropertiesHandlerID)
 	}
-	if ok := s.AsyncTasks.Register(updatePropertiesHandlerID, s.handleUpdateProperties); !ok {
+	if ok := s.AsyncTasks.Register(updatePropertiesHandlerID, s.taskProgress.observe(s.handleUpdateProperties)); !ok {
 		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", updatePropertiesHandlerID)
 	}
+	if ok := s.AsyncTasks.Register(exportOrgHandlerID, s.taskProgress.observe(s.handleExportOrg)); !ok {
+		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", exportOrgHandlerID)
+	}
+	if ok := s.AsyncTasks.Register(importOrgHandlerID, s.taskProgress.observe(s.handleImportOrg)); !ok {
+		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", importOrgHandlerID)
+	}
 }
 
 func (s *Server) requestUser(ctx context.Context, readOnly bool) (*dbgen.User, *dbgen.APIKey, error) {
@@ -77,12 +116,35 @@ func (s *Server) requestUser(ctx context.Context, readOnly bool) (*dbgen.User, *
 	}
 
 	if !user.SubscriptionID.Valid {
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource: db.LimitResourceSubscription,
+			Code:     common.StatusSubscriptionInactiveError,
+			UserID:   user.ID,
+			Err:      db.ErrNoActiveSubscription,
+		})
 		return nil, nil, db.ErrNoActiveSubscription
 	}
 
 	return user, portalOwnerSource.cachedKey, nil
 }
 
+// checkOrgIPAllowlist checks request address against the allowlist of the org being accessed (and not of other user's orgs)
+func (s *Server) checkOrgIPAllowlist(ctx context.Context, orgID int32) error {
+	addr, _ := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr)
+	allowed, err := s.BusinessDB.Impl().IsOrgIPAllowed(ctx, orgID, addr)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check org IP allowlist", "orgID", orgID, common.ErrAttr(err))
+		return err
+	}
+
+	if !allowed {
+		slog.WarnContext(ctx, "API key is used outside of org IP allowlist", "orgID", orgID, "ip", addr.String())
+		return db.ErrPermissions
+	}
+
+	return nil
+}
+
 func (s *Server) requestOrg(user *dbgen.User, r *http.Request, onlyOwner bool, allowedOrgID *pgtype.Int4) (*dbgen.Organization, error) {
 	ctx := r.Context()
 
@@ -97,6 +159,10 @@ func (s *Server) requestOrg(user *dbgen.User, r *http.Request, onlyOwner bool, a
 		return nil, db.ErrPermissions
 	}
 
+	if err := s.checkOrgIPAllowlist(ctx, orgID); err != nil {
+		return nil, err
+	}
+
 	org, err := s.BusinessDB.Impl().RetrieveUserOrganization(ctx, user, orgID)
 	if err != nil {
 		return nil, err
@@ -128,23 +194,53 @@ func (s *Server) requestProperty(org *dbgen.Organization, r *http.Request) (*dbg
 	return property, nil
 }
 
-func (s *Server) sendHTTPErrorResponse(err error, w http.ResponseWriter) {
+// httpErrorStatusCode maps errors of request handling to the API status code and HTTP status
+func httpErrorStatusCode(err error) (common.StatusCode, int) {
 	switch err {
 	case db.ErrRecordNotFound:
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return common.StatusNotFoundError, http.StatusNotFound
 	case db.ErrInvalidInput:
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return common.StatusInvalidInputError, http.StatusBadRequest
 	case db.ErrNoActiveSubscription:
-		http.Error(w, http.StatusText(http.StatusPaymentRequired), http.StatusPaymentRequired)
+		return common.StatusSubscriptionInactiveError, http.StatusPaymentRequired
 	case db.ErrMaintenance:
-		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
-	case errAPIKeyScope, errInvalidAPIKey, errAPIKeyNotSet, errAPIKeyReadOnly, db.ErrPermissions:
-		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return common.StatusMaintenanceError, http.StatusServiceUnavailable
+	case errAPIKeyScope:
+		return common.StatusAPIKeyScopeError, http.StatusForbidden
+	case errInvalidAPIKey, errAPIKeyNotSet:
+		return common.StatusAPIKeyInvalidError, http.StatusForbidden
+	case errAPIKeyReadOnly:
+		return common.StatusAPIKeyReadOnlyError, http.StatusForbidden
+	case errAPIKeySource:
+		return common.StatusAPIKeySourceError, http.StatusForbidden
+	case db.ErrPermissions:
+		return common.StatusPermissionsError, http.StatusForbidden
 	case db.ErrSoftDeleted:
-		http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+		return common.StatusSoftDeletedError, http.StatusConflict
 	default:
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return common.StatusFailure, http.StatusInternalServerError
+	}
+}
+
+// unlike sendAPIErrorResponse, HTTP status of the response reflects the error (error code is still in the body)
+func (s *Server) sendHTTPErrorResponse(err error, r *http.Request, w http.ResponseWriter) {
+	ctx := r.Context()
+	code, httpStatus := httpErrorStatusCode(err)
+
+	data, jerr := json.Marshal(s.newAPIErrorResponse(ctx, code, nil /*field errors*/))
+	if jerr != nil {
+		slog.ErrorContext(ctx, "Failed to serialise response", common.ErrAttr(jerr))
+		http.Error(w, http.StatusText(httpStatus), httpStatus)
+		return
 	}
+
+	header := w.Header()
+	header[common.HeaderContentType] = common.HeaderValueContentTypeJSON
+	maps.Copy(header, common.NoCacheHeaders)
+	w.WriteHeader(httpStatus)
+	_, _ = w.Write(data)
+
+	s.Metrics.ObserveApiError(r.URL.Path, r.Method, int(code))
 }
 
 func (s *Server) sendAPISuccessResponse(ctx context.Context, data interface{}, w http.ResponseWriter) {
@@ -164,11 +260,23 @@ func (s *Server) sendAPISuccessResponseEx(ctx context.Context, response *APIResp
 	common.SendJSONResponse(ctx, w, response, headers...)
 }
 
+func (s *Server) recordLimitDecision(ctx context.Context, decision *db.LimitDecision) {
+	decision.Source = common.AuditLogSourceAPI
+	s.SubscriptionLimits.RecordDecision(ctx, decision)
+}
+
 func (s *Server) sendAPIErrorResponse(ctx context.Context, code common.StatusCode, r *http.Request, w http.ResponseWriter) {
+	s.sendAPIErrorResponseEx(ctx, code, nil /*field errors*/, r, w)
+}
+
+func (s *Server) newAPIErrorResponse(ctx context.Context, code common.StatusCode, fieldErrors []*apiFieldError) *APIResponse {
 	response := &APIResponse{
 		Meta: ResponseMetadata{
 			Code:        code,
+			Error:       code.Name(),
 			Description: code.String(),
+			DocsURL:     code.DocsURL(),
+			Errors:      fieldErrors,
 		},
 	}
 
@@ -176,9 +284,40 @@ func (s *Server) sendAPIErrorResponse(ctx context.Context, code common.StatusCod
 		response.Meta.RequestID = tid
 	}
 
+	return response
+}
+
+func (s *Server) sendAPIErrorResponseEx(ctx context.Context, code common.StatusCode, fieldErrors []*apiFieldError, r *http.Request, w http.ResponseWriter) {
+	response := s.newAPIErrorResponse(ctx, code, fieldErrors)
+
 	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
 
-	slog.WarnContext(ctx, "Returned API error response", "code", int(code))
+	slog.WarnContext(ctx, "Returned API error response", "code", int(code), "errors", len(fieldErrors))
 
 	s.Metrics.ObserveApiError(r.URL.Path, r.Method, int(code))
 }
+
+// schema errors (malformed request body) are returned with 400 status unlike other API errors
+func (s *Server) sendAPIRequestErrorResponse(ctx context.Context, reqErr *apiRequestError, r *http.Request, w http.ResponseWriter) {
+	if reqErr.Code != common.StatusSchemaError {
+		s.sendAPIErrorResponseEx(ctx, reqErr.Code, reqErr.Errors, r, w)
+		return
+	}
+
+	data, err := json.Marshal(s.newAPIErrorResponse(ctx, reqErr.Code, reqErr.Errors))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialise response", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	header := w.Header()
+	header[common.HeaderContentType] = common.HeaderValueContentTypeJSON
+	maps.Copy(header, common.NoCacheHeaders)
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write(data)
+
+	slog.WarnContext(ctx, "Returned API schema error response", "errors", len(reqErr.Errors))
+
+	s.Metrics.ObserveApiError(r.URL.Path, r.Method, int(reqErr.Code))
+}
@@ -87,10 +87,13 @@ func TestMain(m *testing.M) {
 		RateLimiter:        &ratelimit.StubRateLimiter{Header: cfg.Get(common.RateLimitHeaderKey).Value()},
 		Auth:               NewAuthMiddleware(store, NewUserLimiter(store), planService),
 		VerifyLogChan:      make(chan *common.VerifyRecord, 10*VerifyBatchSize),
+		ReceiptChan:        make(chan *common.IssuanceReceipt, 10*ReceiptBatchSize),
+		QuotaLogChan:       make(chan *common.QuotaRecord, 10*QuotaBatchSize),
+		Quota:              NewPropertyQuota(store, db.NewSubscriptionLimits(common.StageTest, store, planService), NewLocalIssuanceBuckets(MaxQuotaBuckets)),
 		Verifier:           NewVerifier(cfg, store),
 		Metrics:            metrics,
 		Mailer:             &email.StubMailer{},
-		Levels:             difficulty.NewLevels(timeSeries, 100 /*levelsBatchSize*/, PropertyBucketSize),
+		Levels:             difficulty.NewLevels(timeSeries, 100 /*levelsBatchSize*/, PropertyBucketSize, nil /*footprint*/),
 		VerifyLogCancel:    func() {},
 		SubscriptionLimits: db.NewSubscriptionLimits(common.StageTest, store, planService),
 		IDHasher:           common.NewIDHasher(cfg.Get(common.IDHasherSaltKey)),
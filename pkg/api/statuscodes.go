@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+type apiStatusCode struct {
+	Code        common.StatusCode `json:"code"`
+	Error       string            `json:"error"`
+	Description string            `json:"description"`
+	DocsURL     string            `json:"docs_url"`
+}
+
+type apiStatusCodesOutput struct {
+	Codes []*apiStatusCode `json:"codes"`
+}
+
+func newStatusCodesCatalog() *apiStatusCodesOutput {
+	codes := common.StatusCodes()
+	result := &apiStatusCodesOutput{Codes: make([]*apiStatusCode, 0, len(codes))}
+
+	for _, code := range codes {
+		result.Codes = append(result.Codes, &apiStatusCode{
+			Code:        code,
+			Error:       code.Name(),
+			Description: code.String(),
+			DocsURL:     code.DocsURL(),
+		})
+	}
+
+	return result
+}
+
+var statusCodesCatalog = newStatusCodesCatalog()
+
+// getStatusCodes publishes all codes that can be returned in API responses so that SDKs can map them without descriptions
+func (s *Server) getStatusCodes(w http.ResponseWriter, r *http.Request) {
+	common.SendJSONResponse(r.Context(), w, statusCodesCatalog)
+}
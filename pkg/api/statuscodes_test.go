@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+)
+
+func TestGetStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/"+common.StatusCodesEndpoint, nil)
+	w := httptest.NewRecorder()
+
+	(&Server{}).getStatusCodes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code: %v", w.Code)
+	}
+
+	output := &apiStatusCodesOutput{}
+	if err := json.NewDecoder(w.Body).Decode(output); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(output.Codes) != len(common.StatusCodes()) {
+		t.Fatalf("Unexpected number of codes: %v", len(output.Codes))
+	}
+
+	for _, c := range output.Codes {
+		if c.Code == common.StatusOrgNotFoundError {
+			if (c.Error != "org_not_found") || (c.DocsURL != common.StatusCodesDocsURL+"#1105") {
+				t.Errorf("Unexpected catalog entry: %+v", c)
+			}
+			return
+		}
+	}
+
+	t.Error("Organization not found code is missing")
+}
+
+func TestSendHTTPErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		err    error
+		status int
+		code   common.StatusCode
+	}{
+		{db.ErrRecordNotFound, http.StatusNotFound, common.StatusNotFoundError},
+		{db.ErrPermissions, http.StatusForbidden, common.StatusPermissionsError},
+		{errAPIKeyReadOnly, http.StatusForbidden, common.StatusAPIKeyReadOnlyError},
+		{db.ErrSoftDeleted, http.StatusConflict, common.StatusSoftDeletedError},
+		{errors.New("test"), http.StatusInternalServerError, common.StatusFailure},
+	}
+
+	srv := &Server{Metrics: monitoring.NewStub()}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		w := httptest.NewRecorder()
+
+		srv.sendHTTPErrorResponse(tc.err, req, w)
+
+		if w.Code != tc.status {
+			t.Errorf("Unexpected status code for %v: %v", tc.err, w.Code)
+			continue
+		}
+
+		response := &APIResponse{}
+		if err := json.NewDecoder(w.Body).Decode(response); err != nil {
+			t.Fatal(err)
+		}
+
+		if (response.Meta.Code != tc.code) || (response.Meta.Error != tc.code.Name()) {
+			t.Errorf("Unexpected response metadata for %v: %+v", tc.err, response.Meta)
+		}
+	}
+}
@@ -3,39 +3,60 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 )
 
-func (s *Server) getAsyncTask(w http.ResponseWriter, r *http.Request) {
+const (
+	taskEventsPollInterval = 3 * time.Second
+	taskEventsWriteTimeout = 10 * time.Second
+	taskEventsMaxDuration  = 5 * time.Minute
+)
+
+func (s *Server) requestAsyncTask(w http.ResponseWriter, r *http.Request) (string, *dbgen.User, *dbgen.AsyncTask, bool) {
 	ctx := r.Context()
 	user, _, err := s.requestUser(ctx, true /*read-only*/)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
-		return
+		s.sendHTTPErrorResponse(err, r, w)
+		return "", nil, nil, false
 	}
 
 	id, err := common.StrPathArg(r, common.ParamID)
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to parse request ID from URL", common.ErrAttr(err))
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
-		return
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return "", nil, nil, false
 	}
 
 	uuid := db.UUIDFromString(id)
 	if !uuid.Valid {
 		slog.WarnContext(ctx, "Failed to parse id arg from URL", "id", id)
-		s.sendHTTPErrorResponse(db.ErrInvalidInput, w)
-		return
+		s.sendHTTPErrorResponse(db.ErrInvalidInput, r, w)
+		return "", nil, nil, false
 	}
 
 	task, err := s.BusinessDB.Impl().RetrieveAsyncTask(ctx, uuid, user)
 	if err != nil {
-		s.sendHTTPErrorResponse(err, w)
+		s.sendHTTPErrorResponse(err, r, w)
+		return "", nil, nil, false
+	}
+
+	return id, user, task, true
+}
+
+func (s *Server) getAsyncTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, _, task, ok := s.requestAsyncTask(w, r)
+	if !ok {
 		return
 	}
 
@@ -55,3 +76,115 @@ func (s *Server) getAsyncTask(w http.ResponseWriter, r *http.Request) {
 
 	s.sendAPISuccessResponse(ctx, response, w)
 }
+
+type taskEventsWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (tw *taskEventsWriter) write(ctx context.Context, event *taskEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize task event", "event", event.Name, common.ErrAttr(err))
+		return err
+	}
+
+	return tw.send(fmt.Sprintf("event: %s\ndata: %s\n\n", event.Name, data))
+}
+
+func (tw *taskEventsWriter) send(message string) error {
+	// server-wide write timeout is shorter than the stream, not every writer in the chain supports this though
+	_ = tw.rc.SetWriteDeadline(time.Now().Add(taskEventsWriteTimeout))
+
+	if _, err := io.WriteString(tw.w, message); err != nil {
+		return err
+	}
+
+	return tw.rc.Flush()
+}
+
+// getAsyncTaskEvents streams async task progress as server-sent events. Progress of tasks, executed on this node,
+// is pushed as it happens, while completion of tasks, executed elsewhere, is discovered by polling the database.
+func (s *Server) getAsyncTaskEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, user, task, ok := s.requestAsyncTask(w, r)
+	if !ok {
+		return
+	}
+
+	common.WriteHeaders(w, common.NoCacheHeaders)
+	w.Header().Set(common.HeaderContentType, common.ContentTypeEventStream)
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	tw := &taskEventsWriter{w: w, rc: http.NewResponseController(w)}
+
+	if task.ProcessedAt.Valid {
+		_ = tw.write(ctx, &taskEvent{Name: taskEventDone, Data: newAsyncTaskResultOutput(id, task.Output)})
+		return
+	}
+
+	var replay []*taskEvent
+	var events <-chan *taskEvent
+	if s.taskProgress != nil {
+		var unsubscribe func()
+		replay, events, unsubscribe = s.taskProgress.subscribe(id)
+		defer unsubscribe()
+	}
+
+	if len(replay) == 0 {
+		replay = append(replay, &taskEvent{Name: taskEventState, Data: &taskStateEvent{ID: id, State: taskStatePending}})
+	}
+
+	for _, event := range replay {
+		if err := tw.write(ctx, event); err != nil {
+			return
+		}
+		if event.Name == taskEventDone {
+			return
+		}
+	}
+
+	pollTicker := time.NewTicker(taskEventsPollInterval)
+	defer pollTicker.Stop()
+
+	deadline := time.NewTimer(taskEventsMaxDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			slog.DebugContext(ctx, "Task events stream reached max duration", "taskID", id)
+			return
+		case event, ok := <-events:
+			if !ok {
+				// either task finished or we were too slow, in both cases database has the final word
+				events = nil
+				continue
+			}
+			if err := tw.write(ctx, event); err != nil {
+				return
+			}
+			if event.Name == taskEventDone {
+				return
+			}
+		case <-pollTicker.C:
+			task, err := s.BusinessDB.Impl().RetrieveAsyncTask(ctx, task.ID, user)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to poll async task", "taskID", id, common.ErrAttr(err))
+				return
+			}
+
+			if task.ProcessedAt.Valid {
+				_ = tw.write(ctx, &taskEvent{Name: taskEventDone, Data: newAsyncTaskResultOutput(id, task.Output)})
+				return
+			}
+
+			if err := tw.send(": ping\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
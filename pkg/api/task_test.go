@@ -1,7 +1,9 @@
 package api
 
 import (
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -105,3 +107,47 @@ func TestGetAsyncTaskReadOnlyKey(t *testing.T) {
 		t.Fatalf("Unexpected status code: %v", meta.Description)
 	}
 }
+
+func TestGetAsyncTaskEventsFinished(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	user, _, apiKey, err := setupAPISuite(ctx, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := s.BusinessDB.Impl().CreateNewAsyncTask(ctx, struct{}{}, xid.New().String(), user, time.Now().UTC().Add(24*time.Hour), t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.BusinessDB.Impl().UpdateAsyncTask(ctx, task.ID, []byte(`[{"code":0}]`), time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := apiRequestSuite(ctx, nil, http.MethodGet, "/"+common.AsyncTaskEndpoint+"/"+db.UUIDToString(task.ID)+"/"+common.EventsEndpoint, apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code: %v", resp.StatusCode)
+	}
+
+	if ct := resp.Header.Get(common.HeaderContentType); ct != common.ContentTypeEventStream {
+		t.Errorf("Unexpected content type: %v", ct)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(body), "event: "+taskEventDone+"\n") {
+		t.Errorf("Unexpected events stream: %s", body)
+	}
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	taskStatePending  = "pending"
+	taskStateRunning  = "running"
+	taskStateFailed   = "failed"
+	taskStateFinished = "finished"
+
+	taskEventState  = "state"
+	taskEventResult = "result"
+	taskEventDone   = "done"
+
+	taskSubscriberBuffer = 64
+	// finished tasks are kept around so that late subscribers still get the replay
+	taskProgressRetention = 1 * time.Minute
+)
+
+type taskProgressContextKey struct{}
+
+type taskEvent struct {
+	Name string
+	Data any
+}
+
+type taskStateEvent struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+}
+
+type taskResultEvent struct {
+	Index  int `json:"index"`
+	Result any `json:"result"`
+}
+
+type taskProgressEntry struct {
+	id          string
+	mux         sync.Mutex
+	state       string
+	events      []*taskEvent
+	subscribers map[chan *taskEvent]struct{}
+	finishedAt  time.Time
+}
+
+func (e *taskProgressEntry) publishLocked(event *taskEvent) {
+	e.events = append(e.events, event)
+
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// slow subscriber will reconnect and get the replay
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (e *taskProgressEntry) setState(state string) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.state = state
+	e.publishLocked(&taskEvent{Name: taskEventState, Data: &taskStateEvent{ID: e.id, State: state}})
+}
+
+// result is safe to call on nil entry, which is the case when task is not observed
+func (e *taskProgressEntry) result(index int, result any) {
+	if e == nil {
+		return
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.publishLocked(&taskEvent{Name: taskEventResult, Data: &taskResultEvent{Index: index, Result: result}})
+}
+
+func (e *taskProgressEntry) fail() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.state = taskStateFailed
+	e.finishedAt = time.Now()
+	e.publishLocked(&taskEvent{Name: taskEventState, Data: &taskStateEvent{ID: e.id, State: taskStateFailed}})
+}
+
+func (e *taskProgressEntry) finish(output *apiAsyncTaskResultOutput) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.state = taskStateFinished
+	e.finishedAt = time.Now()
+	e.publishLocked(&taskEvent{Name: taskEventState, Data: &taskStateEvent{ID: e.id, State: taskStateFinished}})
+	e.publishLocked(&taskEvent{Name: taskEventDone, Data: output})
+
+	for ch := range e.subscribers {
+		close(ch)
+	}
+	clear(e.subscribers)
+}
+
+// taskProgress keeps track of async tasks executed on this node and fans out their progress to subscribers
+type taskProgress struct {
+	mux   sync.Mutex
+	tasks map[string]*taskProgressEntry
+}
+
+func newTaskProgress() *taskProgress {
+	return &taskProgress{
+		tasks: make(map[string]*taskProgressEntry),
+	}
+}
+
+func taskProgressFromContext(ctx context.Context) *taskProgressEntry {
+	entry, _ := ctx.Value(taskProgressContextKey{}).(*taskProgressEntry)
+	return entry
+}
+
+func (tp *taskProgress) entryLocked(id string) *taskProgressEntry {
+	entry, ok := tp.tasks[id]
+	if !ok {
+		entry = &taskProgressEntry{
+			id:          id,
+			state:       taskStatePending,
+			subscribers: make(map[chan *taskEvent]struct{}),
+		}
+		tp.tasks[id] = entry
+	}
+
+	return entry
+}
+
+func (tp *taskProgress) cleanupLocked(tnow time.Time) {
+	for id, entry := range tp.tasks {
+		entry.mux.Lock()
+		expired := !entry.finishedAt.IsZero() && tnow.Sub(entry.finishedAt) > taskProgressRetention
+		entry.mux.Unlock()
+
+		if expired {
+			delete(tp.tasks, id)
+		}
+	}
+}
+
+func (tp *taskProgress) start(id string) *taskProgressEntry {
+	tp.mux.Lock()
+	defer tp.mux.Unlock()
+
+	tp.cleanupLocked(time.Now())
+
+	entry := tp.entryLocked(id)
+
+	entry.mux.Lock()
+	// task can be retried after failure
+	entry.events = entry.events[:0]
+	entry.finishedAt = time.Time{}
+	entry.mux.Unlock()
+
+	entry.setState(taskStateRunning)
+
+	return entry
+}
+
+// subscribe returns events published so far and a channel for the next ones, that is closed when task finishes.
+// Subscribing to a task, that did not start on this node yet, creates a pending entry.
+func (tp *taskProgress) subscribe(id string) ([]*taskEvent, <-chan *taskEvent, func()) {
+	tp.mux.Lock()
+	defer tp.mux.Unlock()
+
+	entry := tp.entryLocked(id)
+
+	entry.mux.Lock()
+	defer entry.mux.Unlock()
+
+	replay := make([]*taskEvent, len(entry.events))
+	copy(replay, entry.events)
+
+	ch := make(chan *taskEvent, taskSubscriberBuffer)
+	if entry.state == taskStateFinished {
+		close(ch)
+	} else {
+		entry.subscribers[ch] = struct{}{}
+	}
+
+	unsubscribe := func() {
+		tp.mux.Lock()
+		defer tp.mux.Unlock()
+
+		entry.mux.Lock()
+		defer entry.mux.Unlock()
+
+		if _, ok := entry.subscribers[ch]; ok {
+			delete(entry.subscribers, ch)
+			close(ch)
+		}
+
+		if (entry.state == taskStatePending) && (len(entry.subscribers) == 0) && (tp.tasks[id] == entry) {
+			delete(tp.tasks, id)
+		}
+	}
+
+	return replay, ch, unsubscribe
+}
+
+// observe wraps async task handler to publish its state transitions
+func (tp *taskProgress) observe(handler db.AsyncTaskHandler) db.AsyncTaskHandler {
+	return func(ctx context.Context, task *dbgen.AsyncTask) ([]byte, error) {
+		id := db.UUIDToString(task.ID)
+		entry := tp.start(id)
+
+		output, err := handler(context.WithValue(ctx, taskProgressContextKey{}, entry), task)
+		if err != nil {
+			entry.fail()
+			return output, err
+		}
+
+		entry.finish(newAsyncTaskResultOutput(id, output))
+
+		return output, nil
+	}
+}
+
+func newAsyncTaskResultOutput(id string, output []byte) *apiAsyncTaskResultOutput {
+	response := &apiAsyncTaskResultOutput{ID: id, Finished: true}
+	if json.Valid(output) {
+		response.Result = json.RawMessage(output)
+	}
+
+	return response
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestTaskProgressEvents(t *testing.T) {
+	tp := newTaskProgress()
+	task := &dbgen.AsyncTask{ID: *randomUUID()}
+	id := db.UUIDToString(task.ID)
+
+	replay, events, unsubscribe := tp.subscribe(id)
+	defer unsubscribe()
+
+	if len(replay) != 0 {
+		t.Fatalf("Unexpected replay for pending task: %v", len(replay))
+	}
+
+	handler := tp.observe(func(ctx context.Context, task *dbgen.AsyncTask) ([]byte, error) {
+		progress := taskProgressFromContext(ctx)
+		results := []*operationResult{{Code: common.StatusOK}, {Code: common.StatusFailure}}
+		for i, r := range results {
+			progress.result(i, r)
+		}
+		return json.Marshal(results)
+	})
+
+	if _, err := handler(t.Context(), task); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{taskEventState, taskEventResult, taskEventResult, taskEventState, taskEventDone}
+	actual := make([]string, 0, len(expected))
+	for event := range events {
+		actual = append(actual, event.Name)
+	}
+
+	if len(actual) != len(expected) {
+		t.Fatalf("Unexpected events: %v", actual)
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Unexpected event at %v: %v", i, actual[i])
+		}
+	}
+
+	// late subscriber gets everything as replay
+	replay, events, unsubscribeLate := tp.subscribe(id)
+	defer unsubscribeLate()
+
+	if len(replay) != len(expected) {
+		t.Errorf("Unexpected replay length: %v", len(replay))
+	}
+
+	if _, ok := <-events; ok {
+		t.Error("Events channel of finished task is not closed")
+	}
+}
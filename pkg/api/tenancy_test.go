@@ -0,0 +1,346 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_test "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/rs/xid"
+)
+
+const (
+	tenancyVictimName = "tenancy-victim"
+	tenancyPwnedName  = "tenancy-pwned"
+)
+
+var (
+	routeParamRegexp = regexp.MustCompile(`\{[^}]+\}`)
+)
+
+// routes that do not reference resources of other tenants: they either operate on the key owner only
+// or are public/puzzle-scoped (ownership there is covered by verification tests)
+var tenancyAgnosticRoutes = map[string]string{
+	"GET /" + common.PuzzleEndpoint:                                       "sitekey auth",
+	"OPTIONS /" + common.PuzzleEndpoint:                                   "sitekey auth",
+	"POST /" + common.SiteVerifyEndpoint:                                  "puzzle scope",
+	"POST /" + common.TurnstileEndpoint + "/" + common.SiteVerifyEndpoint: "puzzle scope",
+	"POST /" + common.VerifyEndpoint:                                      "puzzle scope",
+	"POST /" + common.VerifyEndpoint + "/" + common.ReportEndpoint:        "puzzle scope",
+	"POST /" + common.HandoffEndpoint:                                     "sitekey auth",
+	"POST /" + common.HandoffEndpoint + "/{" + common.ParamID + "}":       "public by design",
+	"GET /" + common.HandoffEndpoint + "/{" + common.ParamID + "}":        "public by design",
+	"OPTIONS /" + common.HandoffEndpoint + "/{" + common.ParamID + "}":    "public by design",
+	"/{$}":                          "catch-all",
+	"GET /" + common.LimitsEndpoint: "key owner only",
+	"GET /" + common.LimitsEndpoint + "/" + common.QuotaEndpoint: "key owner only",
+	"GET /" + common.OrganizationsEndpoint:                       "key owner only",
+	"POST /" + common.OrgEndpoint:                                "key owner only",
+}
+
+// tenantFixture is a set of resources that belong to a single tenant
+type tenantFixture struct {
+	user     *dbgen.User
+	org      *dbgen.Organization
+	property *dbgen.Property
+	task     *dbgen.AsyncTask
+	apiKey   string
+}
+
+func newTenantFixture(ctx context.Context, name string) (*tenantFixture, error) {
+	user, org, apiKey, err := setupAPISuite(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	propertyParams := db_test.CreateNewPropertyParams(user.ID, "example.com")
+	propertyParams.Name = tenancyVictimName
+	property, _, err := s.BusinessDB.Impl().CreateNewProperty(ctx, propertyParams, org)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.BusinessDB.Impl().CreateNewAsyncTask(ctx, struct{}{}, xid.New().String(), user, time.Now().UTC().Add(24*time.Hour), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenantFixture{
+		user:     user,
+		org:      org,
+		property: property,
+		task:     task,
+		apiKey:   apiKey,
+	}, nil
+}
+
+func (f *tenantFixture) orgID() string {
+	return s.IDHasher.Encrypt(int(f.org.ID))
+}
+
+func (f *tenantFixture) propertyID() string {
+	return s.IDHasher.Encrypt(int(f.property.ID))
+}
+
+// tenancyRouteBody returns request body that would be a valid request, if only resources belonged to the caller
+func tenancyRouteBody(pattern string, victim *tenantFixture) any {
+	switch pattern {
+	case "PUT /" + common.OrgEndpoint:
+		return &apiOrgInput{ID: victim.orgID(), Name: tenancyPwnedName}
+	case "DELETE /" + common.OrgEndpoint:
+		return &apiOrgInput{ID: victim.orgID()}
+	case "DELETE /" + common.PropertiesEndpoint:
+		return []string{victim.propertyID()}
+	case "PUT /" + common.PropertiesEndpoint:
+		return []*apiUpdatePropertyInput{{ID: victim.propertyID(), apiPropertySettings: apiPropertySettings{Name: tenancyPwnedName}}}
+	case "POST /" + common.APIKeysEndpoint + "/" + common.BatchEndpoint:
+		return &apiAPIKeysBatchInput{NameTemplate: tenancyPwnedName + "-{index}", Count: 1, Scope: string(dbgen.ApiKeyScopePortal), ExpirationDays: 30, OrgID: victim.orgID()}
+	}
+
+	switch {
+	case strings.HasSuffix(pattern, "/"+common.PropertiesEndpoint) && strings.HasPrefix(pattern, http.MethodPost):
+		return []*apiCreatePropertyInput{{apiPropertySettings: apiPropertySettings{Name: tenancyPwnedName}, Domain: "example.com"}}
+	case strings.HasSuffix(pattern, "/"+common.ExperimentEndpoint) && strings.HasPrefix(pattern, http.MethodPost):
+		return &apiExperimentInput{VariantLevel: int(common.DifficultyLevelHigh), VariantWeight: 10, DurationDays: 14}
+	}
+
+	return nil
+}
+
+// tenancyRoutePath substitutes path parameters of the route with IDs of victim's resources
+func tenancyRoutePath(pattern string, orgID, propertyID string, victim *tenantFixture) (string, string, error) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return "", "", fmt.Errorf("unexpected route pattern: %s", pattern)
+	}
+
+	replacer := strings.NewReplacer(
+		"{"+common.ParamOrg+"}", orgID,
+		"{"+common.ParamProperty+"}", propertyID,
+	)
+	path = replacer.Replace(path)
+
+	if strings.HasPrefix(path, "/"+common.AsyncTaskEndpoint+"/") {
+		path = strings.Replace(path, "{"+common.ParamID+"}", db.UUIDToString(victim.task.ID), 1)
+	}
+
+	if param := routeParamRegexp.FindString(path); len(param) > 0 {
+		return "", "", fmt.Errorf("route %s has unsupported parameter %s", pattern, param)
+	}
+
+	return method, path, nil
+}
+
+// isTenancyViolation checks if cross-tenant request was successful (it is allowed to be "accepted" only if the
+// operation is asynchronous, in which case the actual state is validated separately)
+func isTenancyViolation(resp *http.Response) (bool, *apiAsyncTaskOutput, error) {
+	if resp.StatusCode != http.StatusOK {
+		return false, nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var envelope APIResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		// non-JSON (or non-envelope) OK response is definitely not expected
+		return true, nil, nil
+	}
+
+	if !envelope.Meta.Code.Success() {
+		return false, nil, nil
+	}
+
+	raw, _ := json.Marshal(envelope.Data)
+	task := &apiAsyncTaskOutput{}
+	if err := json.Unmarshal(raw, task); (err == nil) && (len(task.ID) > 0) {
+		return false, task, nil
+	}
+
+	return true, nil, nil
+}
+
+func waitForAsyncTask(ctx context.Context, taskID string, user *dbgen.User) error {
+	uuid := db.UUIDFromString(taskID)
+
+	for i := 0; i < 50; i++ {
+		task, err := s.BusinessDB.Impl().RetrieveAsyncTask(ctx, uuid, user)
+		if err != nil {
+			return err
+		}
+
+		if task.ProcessedAt.Valid {
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("async task %s was not processed in time", taskID)
+}
+
+func checkVictimIntact(ctx context.Context, t *testing.T, victim, attacker *tenantFixture) {
+	org, err := s.BusinessDB.Impl().RetrieveUserOrganization(ctx, victim.user, victim.org.ID)
+	if err != nil {
+		t.Fatalf("Victim org is not accessible: %v", err)
+	}
+
+	if org.Name != victim.org.Name {
+		t.Errorf("Victim org was renamed to %v", org.Name)
+	}
+
+	property, err := s.BusinessDB.Impl().RetrieveOrgProperty(ctx, org, victim.property.ID)
+	if err != nil {
+		t.Fatalf("Victim property is not accessible: %v", err)
+	}
+
+	if property.Name != tenancyVictimName {
+		t.Errorf("Victim property was renamed to %v", property.Name)
+	}
+
+	if experiments, err := s.BusinessDB.Impl().RetrievePropertyDifficultyExperiments(ctx, property, 10); (err == nil) && (len(experiments) > 0) {
+		t.Errorf("Experiment was created for victim property")
+	}
+
+	keys, err := s.BusinessDB.Impl().RetrieveUserAPIKeys(ctx, attacker.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range keys {
+		if key.OrgID.Valid && (key.OrgID.Int32 == victim.org.ID) {
+			t.Errorf("Attacker created API key scoped to victim org")
+		}
+	}
+}
+
+func TestAPITenantIsolation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	victim, err := newTenantFixture(ctx, t.Name()+"_victim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attacker, err := newTenantFixture(ctx, t.Name()+"_attacker")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rg := s.Setup("", true /*verbose*/, common.NoopMiddleware)
+
+	for _, pattern := range rg.Patterns() {
+		if _, ok := tenancyAgnosticRoutes[pattern]; ok {
+			continue
+		}
+
+		method, path, err := tenancyRoutePath(pattern, victim.orgID(), victim.propertyID(), victim)
+		if err != nil {
+			// new routes have to be either supported here or explicitly listed as agnostic
+			t.Errorf("Unclassified route: %v", err)
+			continue
+		}
+
+		t.Run(strings.ReplaceAll(pattern, "/", "_"), func(t *testing.T) {
+			resp, err := apiRequestSuite(ctx, tenancyRouteBody(pattern, victim), method, path, attacker.apiKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			violation, task, err := isTenancyViolation(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if violation {
+				t.Fatalf("Cross-tenant request %s %s succeeded", method, path)
+			}
+
+			if task != nil {
+				if err := waitForAsyncTask(ctx, task.ID, attacker.user); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+
+	checkVictimIntact(ctx, t, victim, attacker)
+}
+
+// TestAPITenantIDEnumeration emulates guessing of hashed identifiers of resources next to own ones
+func TestAPITenantIDEnumeration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	victim, err := newTenantFixture(ctx, t.Name()+"_victim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attacker, err := newTenantFixture(ctx, t.Name()+"_attacker")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guesses := func(own, target int32) []string {
+		result := []string{"0", "-1", "aaaaaaaa", strings.Repeat("z", 128), fmt.Sprint(target)}
+		for _, id := range []int32{target - 1, target, target + 1, own - 1, own + 1} {
+			if (id > 0) && (id != own) {
+				result = append(result, s.IDHasher.Encrypt(int(id)))
+			}
+		}
+		return result
+	}
+
+	rg := s.Setup("", true /*verbose*/, common.NoopMiddleware)
+
+	for _, pattern := range rg.Patterns() {
+		// only read-only routes with both identifiers to not interfere with other tests
+		if !strings.HasPrefix(pattern, http.MethodGet+" ") || !strings.Contains(pattern, "{"+common.ParamOrg+"}") {
+			continue
+		}
+
+		for _, orgID := range guesses(attacker.org.ID, victim.org.ID) {
+			for _, propertyID := range guesses(attacker.property.ID, victim.property.ID) {
+				method, path, err := tenancyRoutePath(pattern, orgID, propertyID, victim)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				resp, err := apiRequestSuite(ctx, nil, method, path, attacker.apiKey)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				violation, _, err := isTenancyViolation(resp)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if violation {
+					t.Errorf("Guessed request %s %s succeeded", method, path)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+// https://developers.cloudflare.com/turnstile/get-started/server-side-validation/
+const (
+	turnstileMissingSecret   = "missing-input-secret"
+	turnstileInvalidSecret   = "invalid-input-secret"
+	turnstileMissingResponse = "missing-input-response"
+	turnstileInvalidResponse = "invalid-input-response"
+	turnstileBadRequest      = "bad-request"
+	turnstileTimeout         = "timeout-or-duplicate"
+	turnstileInternalError   = "internal-error"
+)
+
+// Turnstile format: request is the same as reCAPTCHA one (or a JSON object with the same fields)
+type VerifyResponseTurnstile struct {
+	Success bool `json:"success"`
+	// unlike reCAPTCHA, Turnstile always sends error codes
+	ErrorCodes  []string        `json:"error-codes"`
+	ChallengeTS common.JSONTime `json:"challenge_ts,omitempty"`
+	Hostname    string          `json:"hostname,omitempty"`
+	Action      string          `json:"action"`
+	CData       string          `json:"cdata"`
+	// solution was issued for another property from the same trust group
+	CrossProperty bool `json:"cross_property,omitempty"`
+	// claims of the property owner that were signed into the puzzle
+	Claims map[string]string `json:"claims,omitempty"`
+}
+
+type turnstileRequest struct {
+	Secret   string `json:"secret"`
+	Response string `json:"response"`
+	RemoteIP string `json:"remoteip"`
+	SiteKey  string `json:"sitekey"`
+}
+
+func turnstileErrorCode(verr puzzle.VerifyError) string {
+	switch verr {
+	case puzzle.VerifyNoError, puzzle.MaintenanceModeError, puzzle.TestPropertyError:
+		return ""
+	case puzzle.PuzzleExpiredError, puzzle.VerifiedBeforeError:
+		return turnstileTimeout
+	case puzzle.WrongOwnerError, puzzle.OrgScopeError:
+		return turnstileInvalidSecret
+	case puzzle.VerifyErrorOther:
+		return turnstileInternalError
+	default:
+		return turnstileInvalidResponse
+	}
+}
+
+func newTurnstileResponse(result *puzzle.VerifyResult, crossProperty bool) *VerifyResponseTurnstile {
+	response := &VerifyResponseTurnstile{
+		Success:       result.Success(),
+		ErrorCodes:    []string{},
+		ChallengeTS:   common.JSONTime(result.CreatedAt),
+		Hostname:      result.Domain,
+		CrossProperty: crossProperty && result.Success(),
+	}
+
+	if code := turnstileErrorCode(result.Error); len(code) > 0 {
+		response.ErrorCodes = append(response.ErrorCodes, code)
+	}
+
+	if result.Success() {
+		response.Claims = result.Claims
+	}
+
+	return response
+}
+
+func sendTurnstileError(w http.ResponseWriter, r *http.Request, code string) {
+	response := &VerifyResponseTurnstile{
+		Success:    false,
+		ErrorCodes: []string{code},
+	}
+
+	common.SendJSONResponse(r.Context(), w, response, common.NoCacheHeaders)
+}
+
+// acceptsTurnstile checks if client asked for Turnstile-compatible response format on the generic siteverify endpoint
+func acceptsTurnstile(r *http.Request) bool {
+	for _, value := range strings.Split(r.Header.Get(common.HeaderAccept), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(value)); (err == nil) && (mediaType == common.ContentTypeTurnstile) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// turnstileForm makes JSON requests look like form ones (Turnstile accepts both) and reports problems with the secret
+// in Turnstile format, as otherwise API key middleware would respond only with a status code
+func turnstileForm(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get(common.HeaderContentType)); mediaType == common.ContentTypeJSON {
+			request := &turnstileRequest{}
+			if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+				slog.WarnContext(ctx, "Failed to decode Turnstile request", common.ErrAttr(err))
+				sendTurnstileError(w, r, turnstileBadRequest)
+				return
+			}
+
+			form := url.Values{}
+			form.Set(common.ParamSecret, request.Secret)
+			form.Set(common.ParamResponse, request.Response)
+			if len(request.SiteKey) > 0 {
+				form.Set(common.ParamSiteKey, request.SiteKey)
+			}
+			r.PostForm = form
+			r.Form = form
+		}
+
+		if err := r.ParseForm(); err != nil {
+			slog.WarnContext(ctx, "Failed to read request form", common.ErrAttr(err))
+			sendTurnstileError(w, r, turnstileBadRequest)
+			return
+		}
+
+		switch secret := r.PostFormValue(common.ParamSecret); {
+		case len(secret) == 0:
+			sendTurnstileError(w, r, turnstileMissingSecret)
+		case len(secret) != db.SecretLen:
+			sendTurnstileError(w, r, turnstileInvalidSecret)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
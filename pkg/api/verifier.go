@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/netip"
+	"sync/atomic"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
@@ -18,7 +19,11 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 	"github.com/jackc/pgx/v5/pgtype"
-	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// how many remembered puzzles can be issued based on a single solved puzzle
+	maxRememberedPuzzles = 20
 )
 
 var (
@@ -28,10 +33,20 @@ var (
 type Verifier struct {
 	Salt               *puzzleSalt
 	UserFingerprintKey *userFingerprintKey
+	Attestation        *attestationSigner
 	Store              db.Implementor
 	TestPuzzle         puzzle.Puzzle
 	TestPuzzleData     *puzzle.PuzzlePayload
 	TestSolutions      puzzle.SolutionPayload
+	Experiments        *difficulty.Experiments
+	Quotas             *db.MonthlyQuotas
+	CountryCodeHeader  common.ConfigItem
+	PrivacyMode        common.ConfigItem
+	PrivacySalt        *db.PrivacySalt
+	BypassAudit        *bypassAuditThrottle
+	// SHA-256 of the widget script, served by this instance
+	WidgetScriptHash []byte
+	integrity        atomic.Pointer[widgetIntegrity]
 }
 
 var _ puzzle.Engine = (*Verifier)(nil)
@@ -41,9 +56,16 @@ func NewVerifier(cfg common.ConfigStore, store db.Implementor) *Verifier {
 	return &Verifier{
 		Salt:               NewPuzzleSalt(cfg.Get(common.APISaltKey)),
 		UserFingerprintKey: NewUserFingerprintKey(cfg.Get(common.UserFingerprintIVKey)),
+		Attestation:        NewAttestationSigner(cfg.Get(common.AttestationKeysKey)),
 		Store:              store,
 		TestPuzzle:         testPuzzle,
 		TestSolutions:      puzzle.NewStubPayload(testPuzzle),
+		Experiments:        difficulty.NewExperiments(),
+		Quotas:             db.NewMonthlyQuotas(),
+		CountryCodeHeader:  cfg.Get(common.CountryCodeHeaderKey),
+		PrivacyMode:        cfg.Get(common.PrivacyModeKey),
+		PrivacySalt:        db.NewPrivacySalt(),
+		BypassAudit:        newBypassAuditThrottle(bypassAuditWindow),
 	}
 }
 
@@ -65,6 +87,11 @@ func (v *Verifier) Update(ctx context.Context) error {
 		return err
 	}
 
+	if err := v.Attestation.Update(); err != nil {
+		slog.ErrorContext(ctx, "Failed to update attestation keys", common.ErrAttr(err))
+		return err
+	}
+
 	return nil
 }
 
@@ -105,28 +132,35 @@ func (v *Verifier) ParseSolutionPayload(ctx context.Context, data []byte) (puzzl
 		return v.TestSolutions, nil
 	}
 
+	if bytes.HasPrefix(data, []byte(puzzle.ImageSolutionPrefix)) {
+		return puzzle.ParseImageVerifyPayload[puzzle.ComputePuzzle](ctx, data, v.imageChallengeKey())
+	}
+
 	return puzzle.ParseVerifyPayload[puzzle.ComputePuzzle](ctx, data)
 }
 
-func (v *Verifier) verifyPuzzleValid(ctx context.Context, payload puzzle.SolutionPayload, tnow time.Time) (puzzle.Puzzle, *dbgen.Property, puzzle.VerifyError) {
+func (v *Verifier) verifyPuzzleValid(ctx context.Context, payload puzzle.SolutionPayload, tnow time.Time) (puzzle.Puzzle, *dbgen.Property, bool, puzzle.VerifyError) {
 	p := payload.Puzzle()
 	plog := slog.With("puzzleID", p.PuzzleID())
 
 	propertyID := p.PropertyID()
 	if p.IsZero() && bytes.Equal(propertyID[:], db.TestPropertyUUID.Bytes[:]) {
 		plog.Log(ctx, common.LevelTrace, "Verifying test puzzle")
-		return p, nil, puzzle.TestPropertyError
+		return p, nil, false, puzzle.TestPropertyError
 	}
 
-	if expiration := p.Expiration(); !tnow.Before(expiration) {
+	expiration := p.Expiration()
+	expired := !tnow.Before(expiration)
+	// stub puzzles are not bound to the property settings so skew can only be checked for "real" puzzles
+	if expired && (p.IsStub() || !tnow.Before(expiration.Add(puzzle.MaxClockSkew))) {
 		plog.WarnContext(ctx, "Puzzle is expired", "expiration", expiration, "now", tnow)
-		return p, nil, puzzle.PuzzleExpiredError
+		return p, nil, false, puzzle.PuzzleExpiredError
 	}
 
 	// "else" branch is handled below _after_ we fetch the property from DB
 	if !payload.NeedsExtraSalt() {
 		if serr := payload.VerifySignature(ctx, v.Salt.Value(), nil /*extra salt*/); serr != nil {
-			return p, nil, puzzle.IntegrityError
+			return p, nil, false, puzzle.IntegrityError
 		}
 	}
 
@@ -135,34 +169,101 @@ func (v *Verifier) verifyPuzzleValid(ctx context.Context, payload puzzle.Solutio
 	sitekey := db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: propertyID})
 	property, err := v.Store.Impl().RetrievePropertyBySitekey(ctx, sitekey)
 	if err != nil {
+		if expired {
+			plog.WarnContext(ctx, "Puzzle is expired", "expiration", expiration, "now", tnow)
+			return p, nil, false, puzzle.PuzzleExpiredError
+		}
+
 		switch err {
 		case db.ErrNegativeCacheHit, db.ErrRecordNotFound, db.ErrSoftDeleted:
-			return p, nil, puzzle.InvalidPropertyError
+			return p, nil, false, puzzle.InvalidPropertyError
 		case db.ErrMaintenance:
-			return p, nil, puzzle.MaintenanceModeError
+			return p, nil, false, puzzle.MaintenanceModeError
 		default:
 			plog.ErrorContext(ctx, "Failed to find property by sitekey", "sitekey", sitekey, common.ErrAttr(err))
-			return p, nil, puzzle.VerifyErrorOther
+			return p, nil, false, puzzle.VerifyErrorOther
 		}
 	}
 
+	if p.IsRemembered() && ((property == nil) || (property.RememberWindow == 0)) {
+		plog.WarnContext(ctx, "Remembered puzzle for property without remember window")
+		return p, nil, false, puzzle.InvalidSolutionError
+	}
+
+	if p.IsRemembered() && db.IsPropertyEmergency(property, tnow) {
+		plog.WarnContext(ctx, "Remembered puzzle for property in emergency mode")
+		return p, nil, false, puzzle.InvalidSolutionError
+	}
+
+	skewTolerated := false
+	if expired {
+		if (property == nil) || !tnow.Before(expiration.Add(property.AllowedClockSkew)) {
+			plog.WarnContext(ctx, "Puzzle is expired", "expiration", expiration, "now", tnow)
+			return p, nil, false, puzzle.PuzzleExpiredError
+		}
+
+		plog.InfoContext(ctx, "Puzzle is expired within allowed clock skew", "expiration", expiration, "now", tnow,
+			"skew", property.AllowedClockSkew, "propID", property.ID)
+		skewTolerated = true
+	}
+
 	var maxCount uint32 = 1
 	if (property != nil) && (property.MaxReplayCount > 0) {
 		maxCount = uint32(property.MaxReplayCount)
 	}
 
-	if v.Store.CheckVerifiedPuzzle(ctx, p, maxCount) {
+	// shadow verification runs after the primary one, which already cached the puzzle
+	if !common.IsShadowRequest(ctx) && v.Store.CheckVerifiedPuzzle(ctx, p, maxCount) {
 		plog.WarnContext(ctx, "Puzzle is already cached", "count", maxCount)
-		return p, nil, puzzle.VerifiedBeforeError
+		return p, nil, false, puzzle.VerifiedBeforeError
 	}
 
 	if payload.NeedsExtraSalt() {
 		if serr := payload.VerifySignature(ctx, v.Salt.Value(), property.Salt); serr != nil {
-			return p, nil, puzzle.IntegrityError
+			return p, nil, false, puzzle.IntegrityError
 		}
 	}
 
-	return p, property, puzzle.VerifyNoError
+	return p, property, skewTolerated, puzzle.VerifyNoError
+}
+
+func isTrustedPropertyPair(expected, actual *dbgen.Property) bool {
+	return (expected != nil) && (actual != nil) &&
+		(len(expected.TrustGroup) > 0) &&
+		(expected.TrustGroup == actual.TrustGroup) &&
+		expected.OrgID.Valid &&
+		(expected.OrgID == actual.OrgID)
+}
+
+// IsTrustedProperty checks if solutions of the puzzle property can be accepted on behalf of the expected property
+// (both properties have to be in the same trust group of the same org)
+func (v *Verifier) IsTrustedProperty(ctx context.Context, expectedSitekey string, propertyID [puzzle.PropertyIDSize]byte) bool {
+	expected, err := v.Store.Impl().RetrievePropertyBySitekey(ctx, expectedSitekey)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve expected property", "sitekey", expectedSitekey, common.ErrAttr(err))
+		return false
+	}
+
+	if len(expected.TrustGroup) == 0 {
+		return false
+	}
+
+	sitekey := db.UUIDToSiteKey(pgtype.UUID{Valid: true, Bytes: propertyID})
+	actual, err := v.Store.Impl().RetrievePropertyBySitekey(ctx, sitekey)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to retrieve puzzle property", "sitekey", sitekey, common.ErrAttr(err))
+		return false
+	}
+
+	if !isTrustedPropertyPair(expected, actual) {
+		slog.WarnContext(ctx, "Properties are not in the same trust group", "expectedPropID", expected.ID, "propID", actual.ID)
+		return false
+	}
+
+	slog.DebugContext(ctx, "Accepting solution from trusted property", "expectedPropID", expected.ID, "propID", actual.ID,
+		"group", expected.TrustGroup)
+
+	return true
 }
 
 func (v *Verifier) checkUserPermissions(ctx context.Context, property *dbgen.Property, userID int32) bool {
@@ -189,12 +290,40 @@ func (v *Verifier) checkUserPermissions(ctx context.Context, property *dbgen.Pro
 	return false
 }
 
+// checkOwner returns error only if the owner itself cannot be determined (e.g. API key is not valid)
+func (v *Verifier) checkOwner(ctx context.Context, property *dbgen.Property, expectedOwner puzzle.OwnerIDSource, tnow time.Time) (puzzle.VerifyError, error) {
+	ownerID, ownerOrgID, err := expectedOwner.OwnerID(ctx, tnow)
+	if err != nil {
+		return puzzle.VerifyErrorOther, err
+	}
+
+	if !v.checkUserPermissions(ctx, property, ownerID) {
+		return puzzle.WrongOwnerError, nil
+	}
+
+	// for scoped API keys, we want to take org ID into account
+	if (ownerOrgID != nil) && property.OrgID.Valid && (property.OrgID.Int32 != *ownerOrgID) {
+		slog.WarnContext(ctx, "Owner org scope does not match property org", "propertyOrgID", property.OrgID.Int32, "ownerOrgID", *ownerOrgID)
+		return puzzle.OrgScopeError, nil
+	}
+
+	return puzzle.VerifyNoError, nil
+}
+
 func (v *Verifier) Verify(ctx context.Context, verifyPayload puzzle.SolutionPayload, expectedOwner puzzle.OwnerIDSource, tnow time.Time) (*puzzle.VerifyResult, error) {
+	if bp, ok := verifyPayload.(*bypassPayload); ok {
+		return v.verifyBypass(ctx, bp, expectedOwner, tnow)
+	}
+
 	result := &puzzle.VerifyResult{}
-	puzzleObject, property, perr := v.verifyPuzzleValid(ctx, verifyPayload, tnow)
+	puzzleObject, property, skewTolerated, perr := v.verifyPuzzleValid(ctx, verifyPayload, tnow)
 	result.SetError(perr)
+	result.SkewTolerated = skewTolerated
 	if puzzleObject != nil && !puzzleObject.IsZero() {
 		result.PuzzleID = puzzleObject.PuzzleID()
+		result.Remembered = puzzleObject.IsRemembered()
+		result.Claims = puzzleObject.Claims()
+		result.VisitorClass = puzzleObject.VisitorClass()
 		validityPeriod := puzzle.DefaultValidityPeriod
 		if property != nil {
 			// NOTE: user could have changed property validity interval of course in between but it should be an edge-case
@@ -207,7 +336,11 @@ func (v *Verifier) Verify(ctx context.Context, verifyPayload puzzle.SolutionPayl
 		result.UserID = property.OrgOwnerID.Int32
 		result.OrgID = property.OrgID.Int32
 		result.PropertyID = property.ID
+		result.SiteKey = db.UUIDToSiteKey(property.ExternalID)
 		result.Domain = property.Domain
+		if (result.PuzzleID != 0) && !result.Remembered {
+			result.ExperimentArm = v.experimentArm(property.ID, result.PuzzleID)
+		}
 	}
 	if perr != puzzle.VerifyNoError && perr != puzzle.MaintenanceModeError {
 		return result, nil
@@ -216,25 +349,21 @@ func (v *Verifier) Verify(ctx context.Context, verifyPayload puzzle.SolutionPayl
 	if property != nil {
 		// position in code where expected owner is checked is a tradeoff between compute for verifying solutions (below)
 		// and IO for accessing DB of potentially malicious request (in case not-yet-checked API key turns out invalid)
-		if ownerID, ownerOrgID, err := expectedOwner.OwnerID(ctx, tnow); err == nil {
-			if !v.checkUserPermissions(ctx, property, ownerID) {
-				result.SetError(puzzle.WrongOwnerError)
-				return result, nil
-			}
-
-			// for scoped API keys, we want to take org ID into account
-			if (ownerOrgID != nil) && property.OrgID.Valid && (property.OrgID.Int32 != *ownerOrgID) {
-				slog.WarnContext(ctx, "Owner org scope does not match property org", "propertyOrgID", property.OrgID.Int32, "ownerOrgID", *ownerOrgID)
-				result.SetError(puzzle.OrgScopeError)
-				return result, nil
-			}
-		} else {
+		oerr, err := v.checkOwner(ctx, property, expectedOwner, tnow)
+		if err != nil {
 			slog.ErrorContext(ctx, "Failed to fetch valid owner ID", "puzzleID", puzzleObject.PuzzleID(), common.ErrAttr(err))
 			return nil, errPuzzleOwner
 		}
+
+		if oerr != puzzle.VerifyNoError {
+			result.SetError(oerr)
+			return result, nil
+		}
 	}
 
-	if metadata, verr := verifyPayload.VerifySolutions(ctx); verr != puzzle.VerifyNoError {
+	metadata, verr := verifyPayload.VerifySolutions(ctx)
+	result.Integrity = v.integrity.Load().check(metadata)
+	if verr != puzzle.VerifyNoError {
 		// NOTE: unlike solutions/puzzle, diagnostics bytes can be totally tampered
 		vlog := slog.With("result", verr.String(), "clientError", metadata.ErrorCode(), "elapsedMillis", metadata.ElapsedMillis(), "puzzleID", puzzleObject.PuzzleID())
 		if property != nil {
@@ -242,12 +371,24 @@ func (v *Verifier) Verify(ctx context.Context, verifyPayload puzzle.SolutionPayl
 		}
 		vlog.WarnContext(ctx, "Failed to verify solutions")
 
+		// unlike solutions, image challenge answer can be guessed so every puzzle only gets a single attempt
+		if _, ok := verifyPayload.(*puzzle.ImageVerifyPayload); ok && (puzzleObject != nil) && !common.IsShadowRequest(ctx) {
+			skew := time.Duration(0)
+			if property != nil {
+				skew = property.AllowedClockSkew
+			}
+			v.Store.BurnPuzzle(ctx, puzzleObject, tnow.Add(-skew))
+		}
+
 		result.SetError(verr)
 		return result, nil
 	}
 
-	if (puzzleObject != nil) && (property != nil) && (property.MaxReplayCount > 0) {
-		v.Store.CacheVerifiedPuzzle(ctx, puzzleObject, tnow)
+	if common.IsShadowRequest(ctx) {
+		slog.Log(ctx, common.LevelTrace, "Skipping caching puzzle in shadow request")
+	} else if (puzzleObject != nil) && (property != nil) && (property.MaxReplayCount > 0) {
+		// puzzle has to stay cached for the whole duration of skew tolerance too
+		v.Store.CacheVerifiedPuzzle(ctx, puzzleObject, tnow.Add(-property.AllowedClockSkew))
 	} else if puzzleObject != nil {
 		slog.Log(ctx, common.LevelTrace, "Skipping caching puzzle", "puzzleID", puzzleObject.PuzzleID())
 	}
@@ -273,7 +414,7 @@ func (v *Verifier) baseDifficultyOverride(r *http.Request) uint8 {
 	return 0
 }
 
-func (v *Verifier) PuzzleForRequest(r *http.Request, levels *difficulty.Levels) (puzzle.Puzzle, *dbgen.Property, error) {
+func (v *Verifier) PuzzleForRequest(r *http.Request, levels *difficulty.Levels, minDifficulty uint8) (puzzle.Puzzle, *dbgen.Property, error) {
 	ctx := r.Context()
 	property, isProperty := ctx.Value(common.PropertyContextKey).(*dbgen.Property)
 	contextIP := ctx.Value(common.RateLimitKeyContextKey)
@@ -296,19 +437,26 @@ func (v *Verifier) PuzzleForRequest(r *http.Request, levels *difficulty.Levels)
 		// NOTE: we potentially can include user fingerprint stats into the calculation of difficulty
 		// but it's besides the point of "quickly returning smth valid from public endpoint"
 		// (all valid properties should be more or less aggressively cached all of the time anyways)
-		stubPuzzle := v.Create(0 /*puzzle ID*/, uuid.Bytes, uint8(common.DifficultyLevelMedium))
-		// if it's a legit request, then puzzle will be also legit (verifiable) with this PropertyID
-		if err := stubPuzzle.Init(puzzle.DefaultValidityPeriod); err != nil {
-			slog.ErrorContext(ctx, "Failed to init stub puzzle", common.ErrAttr(err))
-		}
+		stubPuzzle := v.stubPuzzle(ctx, uuid.Bytes, uint8(common.DifficultyLevelMedium))
 
 		slog.Log(ctx, common.LevelTrace, "Returning stub puzzle before auth is backfilled", "puzzleID", stubPuzzle.PuzzleID(),
 			"sitekey", sitekey, "difficulty", stubPuzzle.Difficulty())
 		return stubPuzzle, nil, nil
 	}
 
+	// owners that are way over the monthly requests limit only get stub puzzles that are not tracked in stats
+	quotaStage := v.Quotas.Stage(property.OrgOwnerID.Int32)
+	if quotaStage == dbgen.QuotaStageExceeded {
+		stubPuzzle := v.stubPuzzle(ctx, property.ExternalID.Bytes, max(uint8(common.DifficultyLevelMedium), minDifficulty))
+
+		slog.Log(ctx, common.LevelTrace, "Returning stub puzzle due to exceeded monthly quota", "propID", property.ID,
+			"userID", property.OrgOwnerID.Int32, "difficulty", stubPuzzle.Difficulty())
+		return stubPuzzle, nil, nil
+	}
+
 	var fingerprint common.TFingerprint
-	hash, err := blake2b.New256(v.UserFingerprintKey.Value())
+	privacyMode := v.privacyMode(property)
+	hash, err := v.newIPHash(privacyMode)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to create blake2b hmac", common.ErrAttr(err))
 		fingerprint = common.RandomFingerprint()
@@ -318,7 +466,7 @@ func (v *Verifier) PuzzleForRequest(r *http.Request, levels *difficulty.Levels)
 		// hash.Write([]byte(r.UserAgent()))
 		if ip, ok := contextIP.(netip.Addr); ok {
 			// if IP is not valid (empty), we do want for fingerprint to be the same as this is fishy enough
-			hash.Write(ip.AsSlice())
+			hash.Write(privateIP(ip, privacyMode).AsSlice())
 		} else {
 			// this stays as "Error" because we shouldn't even end up here
 			slog.ErrorContext(ctx, "Rate limit context key type mismatch", "ip", ip)
@@ -330,17 +478,232 @@ func (v *Verifier) PuzzleForRequest(r *http.Request, levels *difficulty.Levels)
 	}
 
 	tnow := time.Now()
-	baseDifficulty := v.baseDifficultyOverride(r)
-	puzzleDifficulty, _ := levels.DifficultyEx(fingerprint, property, baseDifficulty, tnow)
+	visitorClass := common.VisitorClassNone
+
+	emergency := db.IsPropertyEmergency(property, tnow)
+	if emergency {
+		minDifficulty = uint8(common.MaxDifficultyLevel)
+	}
+
+	if (property.RememberWindow > 0) && !emergency {
+		proof := r.Header.Get(common.HeaderCaptchaRemember)
+		remembered := (len(proof) > 0) && v.checkRememberProof(ctx, property, []byte(proof), tnow)
+
+		if property.DifferentialDifficulty {
+			// with differential difficulty returning visitors still solve a puzzle, but an easier one
+			visitorClass = common.VisitorClassFirstSeen
+			if remembered {
+				visitorClass = common.VisitorClassReturning
+			}
+		} else if remembered && (minDifficulty == 0) {
+			// remembered puzzles do not have difficulty so they cannot be issued when bot policy, access lists or
+			// reputation set the floor (returning visitors will solve a regular puzzle instead)
+			result := puzzle.NewRememberedPuzzle(puzzle.NextPuzzleID(), property.ExternalID.Bytes)
+			result.SetWidgetFlags(puzzle.WidgetFlags(property.WidgetFlags))
+			setPuzzleClaims(ctx, result, property)
+			if err := result.Init(property.ValidityInterval); err != nil {
+				slog.ErrorContext(ctx, "Failed to init remembered puzzle", common.ErrAttr(err))
+			}
+
+			slog.Log(ctx, common.LevelTrace, "Prepared remembered puzzle", "propID", property.ID, "puzzleID", result.PuzzleID())
+
+			return result, property, nil
+		}
+	}
 
 	puzzleID := puzzle.NextPuzzleID()
+	difficultyProperty, arm := v.experimentProperty(property, puzzleID)
+
+	baseDifficulty := v.baseDifficultyOverride(r)
+
+	var puzzleDifficulty uint8
+	var leaseUntil time.Time
+	// difficulty of constant-growth properties does not depend on traffic so it is leased instead of computed
+	leased := (arm == common.ExperimentArmNone) && difficulty.IsLeaseable(difficultyProperty)
+	if leased {
+		puzzleDifficulty, leaseUntil = levels.LeasedDifficulty(fingerprint, difficultyProperty, baseDifficulty, visitorClass, tnow)
+	} else {
+		country := r.Header.Get(v.CountryCodeHeader.Value())
+		puzzleDifficulty, _ = levels.DifficultyTagged(fingerprint, difficultyProperty, baseDifficulty, arm, visitorClass, country, tnow)
+	}
+
+	if visitorClass != common.VisitorClassNone {
+		minDifficulty := uint8(max(difficultyProperty.Level.Int16, int16(baseDifficulty)))
+		puzzleDifficulty = difficulty.VisitorDifficulty(puzzleDifficulty, minDifficulty, visitorClass)
+	}
+
+	if quotaStage == dbgen.QuotaStageDegraded {
+		// over the monthly requests limit difficulty does not scale with traffic (access is still recorded above)
+		puzzleDifficulty = uint8(max(difficultyProperty.Level.Int16, int16(baseDifficulty)))
+	}
+
+	// individual end users keep (roughly) the same difficulty within the sticky window even if property difficulty changes
+	// (leased difficulty does not change within the lease, so there's nothing to pin)
+	var stickySince time.Time
+	if !leased {
+		stickySince = tnow
+		if pinned, since, ok := v.checkStickyPuzzle(ctx, property, []byte(r.Header.Get(common.HeaderCaptchaSticky)), fingerprint, tnow); ok {
+			puzzleDifficulty = difficulty.StickyDifficulty(puzzleDifficulty, pinned)
+			stickySince = since
+		}
+	}
+
+	// set by the bot policy or emergency mode of the property (neither sticky nor visitor class can lower it)
+	puzzleDifficulty = max(puzzleDifficulty, minDifficulty)
+
 	result := v.Create(puzzleID, property.ExternalID.Bytes, puzzleDifficulty)
+	result.SetWidgetFlags(puzzle.WidgetFlags(property.WidgetFlags))
+	result.SetVisitorClass(visitorClass)
+	result.SetStickySince(stickySince)
+	result.SetStickyFingerprint(stickyFingerprint(fingerprint))
+	result.SetDifficultyLease(leaseUntil)
+	setPuzzleClaims(ctx, result, property)
 	if err := result.Init(property.ValidityInterval); err != nil {
 		slog.ErrorContext(ctx, "Failed to init puzzle", common.ErrAttr(err))
 	}
 
 	slog.Log(ctx, common.LevelTrace, "Prepared new puzzle", "propID", property.ID, "difficulty", result.Difficulty(),
-		"puzzleID", result.PuzzleID(), "userID", property.OrgOwnerID.Int32)
+		"puzzleID", result.PuzzleID(), "userID", property.OrgOwnerID.Int32, "arm", arm, "visitor", visitorClass, "sticky", stickySince, "lease", leaseUntil)
 
 	return result, property, nil
 }
+
+// stubPuzzle is returned without the property, so it is signed without property salt and is not tracked in stats
+func (v *Verifier) stubPuzzle(ctx context.Context, propertyID [puzzle.PropertyIDSize]byte, difficulty uint8) puzzle.Puzzle {
+	result := v.Create(0 /*puzzle ID*/, propertyID, difficulty)
+	// if it's a legit request, then puzzle will be also legit (verifiable) with this PropertyID
+	if err := result.Init(puzzle.DefaultValidityPeriod); err != nil {
+		slog.ErrorContext(ctx, "Failed to init stub puzzle", common.ErrAttr(err))
+	}
+
+	return result
+}
+
+// experimentProperty returns property with difficulty level of the experiment arm that puzzle is assigned to
+func (v *Verifier) experimentProperty(property *dbgen.Property, puzzleID uint64) (*dbgen.Property, common.ExperimentArm) {
+	experiment, ok := v.Experiments.Get(property.ID)
+	if !ok {
+		return property, common.ExperimentArmNone
+	}
+
+	arm := experiment.Arm(puzzleID)
+	if arm == common.ExperimentArmNone {
+		return property, arm
+	}
+
+	// shallow copy is enough as we only override the level
+	p := *property
+	p.Level = db.Int2(int16(experiment.Level(arm)))
+
+	return &p, arm
+}
+
+// experimentArm reproduces assignment of the puzzle to the experiment arm done in PuzzleForRequest()
+// NOTE: puzzles issued right before experiment start (or conclusion) can be attributed to the wrong arm,
+// but their amount is bounded by puzzle validity period
+func (v *Verifier) experimentArm(propertyID int32, puzzleID uint64) common.ExperimentArm {
+	if experiment, ok := v.Experiments.Get(propertyID); ok {
+		return experiment.Arm(puzzleID)
+	}
+
+	return common.ExperimentArmNone
+}
+
+// stickyFingerprint is a part of end user fingerprint that is enough to bind sticky puzzles to the end user
+func stickyFingerprint(fingerprint common.TFingerprint) uint32 {
+	return uint32(fingerprint)
+}
+
+// checkStickyPuzzle verifies puzzle, previously issued to the end user, and returns its (pinned) difficulty
+// if the sticky window has not passed yet
+func (v *Verifier) checkStickyPuzzle(ctx context.Context, property *dbgen.Property, data []byte, fingerprint common.TFingerprint, tnow time.Time) (uint8, time.Time, bool) {
+	if len(data) == 0 {
+		return 0, time.Time{}, false
+	}
+
+	payload, err := puzzle.ParsePuzzlePayload[puzzle.ComputePuzzle](ctx, data)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+
+	p := payload.Puzzle()
+	plog := slog.With("puzzleID", p.PuzzleID(), "propID", property.ID)
+
+	if p.IsZero() || p.IsStub() || p.IsRemembered() {
+		plog.Log(ctx, common.LevelTrace, "Puzzle cannot be used to pin difficulty")
+		return 0, time.Time{}, false
+	}
+
+	if propertyID := p.PropertyID(); !bytes.Equal(propertyID[:], property.ExternalID.Bytes[:]) {
+		plog.WarnContext(ctx, "Sticky puzzle property does not match")
+		return 0, time.Time{}, false
+	}
+
+	since := p.StickySince()
+	if since.IsZero() || !tnow.Before(since.Add(difficulty.StickyDifficultyWindow)) {
+		plog.Log(ctx, common.LevelTrace, "Sticky puzzle is outside of sticky window", "since", since)
+		return 0, time.Time{}, false
+	}
+
+	// puzzles are public so without this anybody could pin (low) difficulty of a single solved puzzle for a botnet
+	if p.StickyFingerprint() != stickyFingerprint(fingerprint) {
+		plog.Log(ctx, common.LevelTrace, "Sticky puzzle was issued to another end user")
+		return 0, time.Time{}, false
+	}
+
+	if serr := payload.VerifySignature(ctx, v.Salt.Value(), property.Salt); serr != nil {
+		return 0, time.Time{}, false
+	}
+
+	return p.Difficulty(), since, true
+}
+
+// checkRememberProof verifies that end user solved a regular puzzle for the property within the remember window
+func (v *Verifier) checkRememberProof(ctx context.Context, property *dbgen.Property, data []byte, tnow time.Time) bool {
+	payload, err := v.ParseSolutionPayload(ctx, data)
+	if err != nil {
+		return false
+	}
+
+	// answering image challenge does not take any work so it cannot be "remembered"
+	if _, ok := payload.(*puzzle.ImageVerifyPayload); ok {
+		slog.Log(ctx, common.LevelTrace, "Image challenge cannot be used as remember proof")
+		return false
+	}
+
+	p := payload.Puzzle()
+	plog := slog.With("puzzleID", p.PuzzleID(), "propID", property.ID)
+
+	if p.IsZero() || p.IsStub() || p.IsRemembered() {
+		plog.Log(ctx, common.LevelTrace, "Puzzle cannot be used as remember proof")
+		return false
+	}
+
+	if propertyID := p.PropertyID(); !bytes.Equal(propertyID[:], property.ExternalID.Bytes[:]) {
+		plog.WarnContext(ctx, "Remember proof property does not match")
+		return false
+	}
+
+	// NOTE: property validity interval could have changed since, but this only affects precision of the window
+	created := p.Expiration().Add(-property.ValidityInterval)
+	if !tnow.Before(created.Add(property.RememberWindow)) {
+		plog.Log(ctx, common.LevelTrace, "Remember proof is outside of remember window", "created", created)
+		return false
+	}
+
+	if serr := payload.VerifySignature(ctx, v.Salt.Value(), property.Salt); serr != nil {
+		return false
+	}
+
+	if _, verr := payload.VerifySolutions(ctx); verr != puzzle.VerifyNoError {
+		plog.WarnContext(ctx, "Remember proof has invalid solutions", "result", verr.String())
+		return false
+	}
+
+	if !v.Store.CountRememberedPuzzle(ctx, p, maxRememberedPuzzles, property.RememberWindow) {
+		plog.WarnContext(ctx, "Too many remembered puzzles issued", "max", maxRememberedPuzzles)
+		return false
+	}
+
+	return true
+}
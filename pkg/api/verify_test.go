@@ -323,6 +323,105 @@ func TestSiteVerifyWrongExpectedSitekey(t *testing.T) {
 	}
 }
 
+func turnstileVerifySuite(body string, contentType string) (*VerifyResponseTurnstile, error) {
+	srv := http.NewServeMux()
+	s.Setup("", true /*verbose*/, common.NoopMiddleware).Register(srv)
+
+	req, err := http.NewRequest(http.MethodPost, "/"+common.TurnstileEndpoint+"/"+common.SiteVerifyEndpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(common.HeaderContentType, contentType)
+	req.Header.Set(cfg.Get(common.RateLimitHeaderKey).Value(), common_test.GenerateRandomIPv4())
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code %d", w.Code)
+	}
+
+	response := &VerifyResponseTurnstile{}
+	if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func TestTurnstileVerifyPuzzle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, _, err := setupVerifySuite(t.Context(), t.Name(), dbgen.ApiKeyScopePuzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(&turnstileRequest{Secret: apiKey, Response: payload})
+	response, err := turnstileVerifySuite(string(body), common.ContentTypeJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !response.Success || (response.ErrorCodes == nil) || (len(response.ErrorCodes) > 0) || (response.Hostname != testPropertyDomain) {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+func TestTurnstileVerifyErrors(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	payload, apiKey, _, err := setupVerifySuite(t.Context(), t.Name(), dbgen.ApiKeyScopePuzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		form     url.Values
+		expected string
+	}{
+		{url.Values{common.ParamResponse: []string{payload}}, turnstileMissingSecret},
+		{url.Values{common.ParamSecret: []string{"abc"}, common.ParamResponse: []string{payload}}, turnstileInvalidSecret},
+		{url.Values{common.ParamSecret: []string{apiKey}}, turnstileMissingResponse},
+		{url.Values{common.ParamSecret: []string{apiKey}, common.ParamResponse: []string{"a.b.c"}}, turnstileInvalidResponse},
+	} {
+		response, err := turnstileVerifySuite(tc.form.Encode(), common.ContentTypeURLEncoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if response.Success || (len(response.ErrorCodes) != 1) || (response.ErrorCodes[0] != tc.expected) {
+			t.Errorf("Unexpected response %+v, expected error %v", response, tc.expected)
+		}
+	}
+}
+
+func TestAcceptsTurnstile(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		accept   string
+		expected bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{common.ContentTypeTurnstile, true},
+		{"application/json, " + common.ContentTypeTurnstile + ";q=0.9", true},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/"+common.SiteVerifyEndpoint, nil)
+		req.Header.Set(common.HeaderAccept, tc.accept)
+
+		if actual := acceptsTurnstile(req); actual != tc.expected {
+			t.Errorf("Unexpected result for %q: %v", tc.accept, actual)
+		}
+	}
+}
+
 func checkSiteVerifyError(resp *http.Response, expected puzzle.VerifyError) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -419,6 +518,57 @@ func TestVerifyPuzzleReplay(t *testing.T) {
 	}
 }
 
+func TestVerifyBypassToken(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, testPropertyDomain), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := store.Impl().CreatePropertyBypassToken(ctx, user, property, "ci token", 1 /*max uses*/, time.Now().Add(1*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyParams := tests.CreateNewPuzzleAPIKeyParams(t.Name()+"-apikey", time.Now(), 1*time.Hour, 10.0 /*rps*/)
+	apikey, _, err := store.Impl().CreateAPIKey(ctx, user, keyParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKey := db.UUIDToSecret(apikey.ExternalID)
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+
+	resp, err := verifySuite(token, apiKey, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+
+	// token can be used only once
+	resp, err = verifySuite(token, apiKey, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.InvalidSolutionError); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // in this case "Site" part of the SiteVerify (reCAPTCHA compatibility) does not bring anything else
 // except of checking _any_ error in the reCAPTCHA format
 func TestSiteVerifyPuzzleReplay(t *testing.T) {
@@ -499,6 +649,204 @@ func TestVerifyPuzzleAllowReplay(t *testing.T) {
 	}
 }
 
+func TestVerifyPuzzleClockSkew(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	payload, apiKey, sitekey, err := setupVerifySuite(ctx, t.Name(), dbgen.ApiKeyScopePuzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solutionPayload, err := s.Verifier.ParseSolutionPayload(ctx, []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ownerSource := &apiKeyOwnerSource{Store: s.BusinessDB, scope: dbgen.ApiKeyScopePuzzle}
+	ctx = context.WithValue(ctx, common.SecretContextKey, apiKey)
+	tnow := solutionPayload.Puzzle().Expiration().Add(1 * time.Minute)
+
+	result, err := s.Verifier.Verify(ctx, solutionPayload, ownerSource, tnow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Error != puzzle.PuzzleExpiredError {
+		t.Errorf("Unexpected verify result without skew: %v", result.Error)
+	}
+
+	// this should be still cached so we don't need to actually update DB
+	property.AllowedClockSkew = 5 * time.Minute
+
+	result, err = s.Verifier.Verify(ctx, solutionPayload, ownerSource, tnow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (result.Error != puzzle.VerifyNoError) || !result.SkewTolerated {
+		t.Errorf("Unexpected verify result with skew: %v (skew: %v)", result.Error, result.SkewTolerated)
+	}
+
+	// replay protection should still work within skew tolerance
+	result, err = s.Verifier.Verify(ctx, solutionPayload, ownerSource, tnow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Error != puzzle.VerifiedBeforeError {
+		t.Errorf("Unexpected verify result for replay: %v", result.Error)
+	}
+}
+
+func TestVerifyRememberedPuzzle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	payload, apiKey, sitekey, err := setupVerifySuite(ctx, t.Name(), dbgen.ApiKeyScopePuzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rememberHeaders := map[string][]string{common.HeaderCaptchaRemember: {payload}}
+
+	// remember window is disabled by default
+	resp, err := puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, rememberHeaders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.IsRemembered() {
+		t.Fatal("Puzzle is remembered without remember window")
+	}
+
+	// this should be still cached so we don't need to actually update DB
+	property.RememberWindow = 1 * time.Hour
+
+	resp, err = puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, rememberHeaders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, puzzleStr, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.IsRemembered() {
+		t.Fatal("Puzzle is not remembered")
+	}
+
+	solver := &puzzle.ComputeSolver{}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = verifySuite(fmt.Sprintf("%s.%s", solutions.String(), puzzleStr), apiKey, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyDifferentialDifficulty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+
+	payload, apiKey, sitekey, err := setupVerifySuite(ctx, t.Name(), dbgen.ApiKeyScopePuzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	property, err := store.Impl().GetCachedPropertyBySitekey(ctx, sitekey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// this should be still cached so we don't need to actually update DB
+	property.RememberWindow = 1 * time.Hour
+	property.DifferentialDifficulty = true
+
+	resp, err := puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstSeen, _, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if firstSeen.VisitorClass() != common.VisitorClassFirstSeen {
+		t.Errorf("Unexpected visitor class without remember proof: %v", firstSeen.VisitorClass())
+	}
+
+	resp, err = puzzleSuiteEx(ctx, http.MethodGet, sitekey, property.Domain, map[string][]string{common.HeaderCaptchaRemember: {payload}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	returning, puzzleStr, err := parsePuzzle(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if returning.IsRemembered() {
+		t.Fatal("Puzzle is remembered with differential difficulty")
+	}
+
+	if returning.VisitorClass() != common.VisitorClassReturning {
+		t.Errorf("Unexpected visitor class with remember proof: %v", returning.VisitorClass())
+	}
+
+	if returning.Difficulty() >= firstSeen.Difficulty() {
+		t.Errorf("Returning visitor difficulty (%v) is not lower than first-seen (%v)", returning.Difficulty(), firstSeen.Difficulty())
+	}
+
+	solver := &puzzle.ComputeSolver{}
+	solutions, err := solver.Solve(returning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = verifySuite(fmt.Sprintf("%s.%s", solutions.String(), puzzleStr), apiKey, sitekey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkVerifyError(resp, puzzle.VerifyNoError); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // same as successful test (TestVerifyPuzzle), but invalidates api key in cache
 func TestVerifyCachePriority(t *testing.T) {
 	if testing.Short() {
@@ -853,3 +1201,28 @@ func TestVerifyByOrgMember(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestIsTrustedPropertyPair(t *testing.T) {
+	t.Parallel()
+
+	expected := &dbgen.Property{ID: 1, OrgID: db.Int(1), TrustGroup: "sso"}
+
+	testCases := []struct {
+		expected *dbgen.Property
+		actual   *dbgen.Property
+		trusted  bool
+	}{
+		{expected, &dbgen.Property{ID: 2, OrgID: db.Int(1), TrustGroup: "sso"}, true},
+		{expected, &dbgen.Property{ID: 2, OrgID: db.Int(1), TrustGroup: "other"}, false},
+		{expected, &dbgen.Property{ID: 2, OrgID: db.Int(1)}, false},
+		{expected, &dbgen.Property{ID: 2, OrgID: db.Int(2), TrustGroup: "sso"}, false},
+		{&dbgen.Property{ID: 1, OrgID: db.Int(1)}, &dbgen.Property{ID: 2, OrgID: db.Int(1)}, false},
+		{expected, nil, false},
+	}
+
+	for i, tc := range testCases {
+		if actual := isTrustedPropertyPair(tc.expected, tc.actual); actual != tc.trusted {
+			t.Errorf("Unexpected result for case %d: %v", i, actual)
+		}
+	}
+}
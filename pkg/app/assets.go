@@ -0,0 +1,27 @@
+package app
+
+import (
+	"io/fs"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/assets"
+	"github.com/PrivateCaptcha/PrivateCaptcha/web"
+	"github.com/PrivateCaptcha/PrivateCaptcha/widget"
+)
+
+// StaticAssets returns manifest of embedded portal and widget static files, prefixed same as CDN routes
+func StaticAssets() (*assets.Manifest, error) {
+	portalFS, err := fs.Sub(web.StaticFiles(), "static")
+	if err != nil {
+		return nil, err
+	}
+
+	widgetFS, err := fs.Sub(widget.StaticFiles(), "static")
+	if err != nil {
+		return nil, err
+	}
+
+	return assets.NewManifest(map[string]fs.FS{
+		"portal": portalFS,
+		"widget": widgetFS,
+	})
+}
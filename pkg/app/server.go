@@ -0,0 +1,724 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/api"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/assets"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/auditstream"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ipreputation"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/leakybucket"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session/store/redis"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/sso"
+	"github.com/PrivateCaptcha/PrivateCaptcha/web"
+	"github.com/PrivateCaptcha/PrivateCaptcha/widget"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/justinas/alice"
+)
+
+const (
+	sessionPersistInterval = 10 * time.Second
+	auditLogInterval       = 10 * time.Second
+	auditStreamBatchSize   = 50
+	defaultConnectTimeout  = 30 * time.Second
+	// nodes receive reload signal at about the same time, but the same change can be legitimately repeated later
+	configReloadLockDuration = 1 * time.Minute
+)
+
+const (
+	// for puzzles the logic is that if something becomes popular, there will be a spike, but normal usage should be "low"
+	// NOTE: this assumes correct configuration of the whole chain of reverse proxies
+	// the main problem are NATs/VPNs that make possible for clump of legitimate users to actually come from 1 public IP
+	generalLeakyBucketCap = 20
+	generalLeakInterval   = 1 * time.Second
+	// public defaults are reasonably low but we assume we should be fully cached on CDN level
+	publicLeakyBucketCap = 8
+	publicLeakInterval   = 2 * time.Second
+	// catch call defaults are even lower
+	catchAllLeakyBucketCap = 2
+	catchAllLeakInterval   = 30 * time.Second
+)
+
+type Options struct {
+	Config    common.ConfigStore
+	GitCommit string
+	// portal session cookies will have "Secure" attribute
+	SecureCookie   bool
+	ConnectTimeout time.Duration
+	// when set, API, portal and CDN routes are registered without domain prefixes (useful for embedding)
+	IgnoreDomains bool
+	// TLS config of the main listener (if served directly), used to share session ticket keys between instances
+	TLSConfig *tls.Config
+}
+
+// Server wires all logical parts of Private Captcha (API, Portal and maintenance jobs) together so that they can
+// be served either from cmd/server or mounted into an existing Go service
+type Server struct {
+	Stage         string
+	Config        common.ConfigStore
+	GitCommit     string
+	Pool          *pgxpool.Pool
+	ClickHouse    *sql.DB
+	BusinessDB    *db.BusinessStore
+	TimeSeries    db.TimeSeriesBackend
+	API           *api.Server
+	Portal        *portal.Server
+	Metrics       *monitoring.Service
+	Jobs          *maintenance.Jobs
+	HealthCheck   *maintenance.HealthCheckJob
+	IPRateLimiter ratelimit.HTTPRateLimiter
+	LoadShedder   *common.LoadShedder
+	PlanService   billing.PlanService
+	Footprint     *common.Footprint
+	Sender        email.Sender
+	// delivery with failover between providers (wrapped by regional sender)
+	emailProviders *email.FailoverSender
+	Mailer         *portal.PortalMailer
+	AsyncTasks     *maintenance.AsyncTasksJob
+	// optional streaming of audit log events to external SIEM
+	AuditStream  *auditstream.Streamer
+	TLSConfig    *tls.Config
+	Standby      bool
+	apiDomain    string
+	portalDomain string
+	cdnDomain    string
+	sessionStore session.Store
+	redisClient  *redis.Client
+	// configuration at the previous reload (for audit of config changes)
+	configSnapshot *db.AuditLogConfig
+	// set only when portal templates reference content-hashed static assets
+	assets *assets.Manifest
+}
+
+func newIPAddrBuckets(cfg common.ConfigStore, footprint *common.Footprint) *ratelimit.IPAddrBuckets {
+	const (
+		// number of simultaneous different clients for public APIs (/puzzle, /siteverify etc.), before forcing cleanup
+		maxBuckets = 1_000_000
+	)
+
+	puzzleBucketRate := cfg.Get(common.RateLimitRateKey)
+	puzzleBucketBurst := cfg.Get(common.RateLimitBurstKey)
+
+	size := footprint.Size(maxBuckets)
+	buckets := ratelimit.NewIPAddrBuckets(size,
+		leakybucket.Cap(puzzleBucketBurst.Value(), generalLeakyBucketCap),
+		leakybucket.Interval(puzzleBucketRate.Value(), generalLeakInterval))
+	footprint.Track("ip_buckets", size, buckets.Len)
+
+	return buckets
+}
+
+// New connects to databases and initializes API and Portal servers. Maintenance jobs are not started.
+func New(ctx context.Context, opts *Options) (*Server, error) {
+	cfg := opts.Config
+	stage := cfg.Get(common.StageKey).Value()
+
+	connectTimeout := opts.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+
+	pool, clickhouse, dberr := db.Connect(ctx, cfg, connectTimeout, false /*admin*/)
+	if dberr != nil {
+		return nil, dberr
+	}
+
+	s := &Server{
+		Stage:       stage,
+		Config:      cfg,
+		GitCommit:   opts.GitCommit,
+		Pool:        pool,
+		ClickHouse:  clickhouse,
+		PlanService: billing.NewPlanService(nil),
+		Metrics:     monitoring.NewService(),
+		TLSConfig:   opts.TLSConfig,
+	}
+
+	if err := s.init(ctx, opts); err != nil {
+		s.closeDB()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Server) init(ctx context.Context, opts *Options) error {
+	cfg := s.Config
+	verbose := config.AsBool(cfg.Get(common.VerboseKey))
+
+	s.Footprint = common.NewFootprint(cfg.Get(common.FootprintKey).Value())
+	s.BusinessDB = db.NewBusinessWithFootprint(s.Pool, s.Footprint)
+	s.Standby = config.AsBool(cfg.Get(common.StandbyModeKey))
+	s.BusinessDB.ReadOnly.Store(s.Standby)
+	if s.Standby {
+		// secondary deployment serves read-only portal from a streaming replica (disaster recovery)
+		slog.WarnContext(ctx, "Running in standby (read-only) mode")
+	}
+	s.TimeSeries = db.NewTimeSeriesBackend(cfg, s.Pool, s.ClickHouse, s.BusinessDB.Cache)
+
+	cdnURLConfig := config.AsURL(ctx, cfg.Get(common.CDNBaseURLKey))
+	portalURLConfig := config.AsURL(ctx, cfg.Get(common.PortalBaseURLKey))
+	apiURLConfig := config.AsURL(ctx, cfg.Get(common.APIBaseURLKey))
+
+	if !opts.IgnoreDomains {
+		s.apiDomain = apiURLConfig.Domain()
+		s.portalDomain = portalURLConfig.Domain()
+		s.cdnDomain = cdnURLConfig.Domain()
+	}
+
+	s.emailProviders = email.NewFailoverSenderFromConfig(cfg)
+	s.Sender = email.NewRegionalSender(cfg, s.emailProviders)
+	s.Mailer = portal.NewPortalMailer("https:"+cdnURLConfig.URL(), "https:"+portalURLConfig.URL(), s.Sender, cfg)
+
+	rateLimitHeader := cfg.Get(common.RateLimitHeaderKey).Value()
+	ipRateLimiter := ratelimit.NewIPAddrRateLimiter(rateLimitHeader, newIPAddrBuckets(cfg, s.Footprint), s.Metrics)
+	s.IPRateLimiter = ipRateLimiter
+	// shared between API and portal as they are served by the same process
+	s.LoadShedder = common.NewLoadShedder(s.Metrics)
+	userLimiter := api.NewUserLimiter(s.BusinessDB)
+	subscriptionLimits := db.NewSubscriptionLimits(s.Stage, s.BusinessDB, s.PlanService)
+	idHasher := common.NewIDHasher(cfg.Get(common.IDHasherSaltKey))
+
+	// special case for async jobs (register handlers before adding)
+	s.AsyncTasks = maintenance.NewAsyncTasksJob(s.BusinessDB)
+
+	var err error
+	s.redisClient, err = newRedisClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	s.AuditStream, err = auditstream.NewStreamerFromConfig(ctx, cfg, auditStreamBatchSize)
+	if err != nil {
+		return err
+	}
+	if s.AuditStream != nil {
+		s.BusinessDB.SetAuditLogSink(s.AuditStream)
+	}
+
+	verifier := api.NewVerifier(cfg, s.BusinessDB)
+	verifier.WidgetScriptHash = widget.ScriptHash()
+
+	s.API = &api.Server{
+		Stage:              s.Stage,
+		BusinessDB:         s.BusinessDB,
+		TimeSeries:         s.TimeSeries,
+		RateLimiter:        ipRateLimiter,
+		Auth:               api.NewAuthMiddleware(s.BusinessDB, userLimiter, s.PlanService),
+		VerifyLogChan:      make(chan *common.VerifyRecord, 10*s.Footprint.Size(api.VerifyBatchSize)),
+		ReceiptChan:        make(chan *common.IssuanceReceipt, 10*s.Footprint.Size(api.ReceiptBatchSize)),
+		QuotaLogChan:       make(chan *common.QuotaRecord, 10*s.Footprint.Size(api.QuotaBatchSize)),
+		Quota:              api.NewPropertyQuota(s.BusinessDB, subscriptionLimits, s.newIssuanceBuckets(ctx)),
+		Verifier:           verifier,
+		Metrics:            s.Metrics,
+		Mailer:             s.Mailer,
+		Levels:             difficulty.NewLevels(s.TimeSeries, 100 /*levelsBatchSize*/, api.PropertyBucketSize, s.Footprint),
+		VerifyLogCancel:    func() {},
+		SubscriptionLimits: subscriptionLimits,
+		IDHasher:           idHasher,
+		AsyncTasks:         s.AsyncTasks,
+		LoadShedder:        s.LoadShedder,
+		Footprint:          s.Footprint,
+		IPReputation:       ipreputation.New(),
+	}
+	s.BusinessDB.SetPropertyListener(s.API.Auth.HotProperties.OnPropertyChanged)
+	s.Footprint.Track("verify_log_buffer", cap(s.API.VerifyLogChan), func() int { return len(s.API.VerifyLogChan) })
+	s.Footprint.Track("receipts_buffer", cap(s.API.ReceiptChan), func() int { return len(s.API.ReceiptChan) })
+	s.Footprint.Track("quota_log_buffer", cap(s.API.QuotaLogChan), func() int { return len(s.API.QuotaLogChan) })
+
+	if err := s.API.Init(ctx, 10*time.Second /*flush interval*/, 1*time.Second /*backfill duration*/); err != nil {
+		return err
+	}
+
+	dataCtx, err := web.LoadData()
+	if err != nil {
+		return err
+	}
+
+	s.sessionStore, err = s.newSessionStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	xsrfKey := cfg.Get(common.XSRFKeyKey)
+	s.Portal = &portal.Server{
+		Stage:      s.Stage,
+		Store:      s.BusinessDB,
+		TimeSeries: s.TimeSeries,
+		XSRF:       &common.XSRFMiddleware{Key: xsrfKey.Value(), Timeout: 1 * time.Hour},
+		Sessions: &session.Manager{
+			CookieName:   "pcsid",
+			Store:        s.sessionStore,
+			MaxLifetime:  s.sessionStore.TTL(),
+			SecureCookie: opts.SecureCookie,
+		},
+		PlanService:        s.PlanService,
+		APIURL:             apiURLConfig.URL(),
+		CDNURL:             cdnURLConfig.URL(),
+		PuzzleEngine:       s.API.ReportingVerifier(),
+		Metrics:            s.Metrics,
+		Mailer:             s.Mailer,
+		RateLimiter:        ipRateLimiter,
+		DataCtx:            dataCtx,
+		IDHasher:           idHasher,
+		CountryCodeHeader:  cfg.Get(common.CountryCodeHeaderKey),
+		UserLimiter:        userLimiter,
+		SubscriptionLimits: subscriptionLimits,
+		EmailVerifier:      &portal.PortalEmailVerifier{},
+		AsyncTasks:         s.AsyncTasks,
+		LoadShedder:        s.LoadShedder,
+		AdminEmail:         cfg.Get(common.AdminEmailKey),
+		EmailFrom:          cfg.Get(common.EmailFromKey),
+		EmailProviders:     s.emailProviders,
+		SSO:                sso.NewOIDCProviderFromConfig(ctx, cfg, "https:"+portalURLConfig.URL()+"/"+common.SSOEndpoint+"/"+common.CallbackEndpoint),
+		SSODefaultOrg:      cfg.Get(common.SSODefaultOrgKey),
+		SSOProvisioning:    cfg.Get(common.SSOProvisioningKey),
+		PrivacyMode:        cfg.Get(common.PrivacyModeKey),
+		Standby:            s.Standby,
+	}
+
+	templatesBuilder := portal.NewTemplatesBuilder()
+	if err := templatesBuilder.AddFS(ctx, web.Templates(), "core"); err != nil {
+		return err
+	}
+
+	if config.AsBool(cfg.Get(common.AssetsHashedNamesKey)) {
+		manifest, err := StaticAssets()
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to build static assets manifest", common.ErrAttr(err))
+			return err
+		}
+
+		slog.InfoContext(ctx, "Using content-hashed static assets", "files", len(manifest.Files()))
+		templatesBuilder.SetRewriter(manifest.RewriteTemplate)
+		s.assets = manifest
+	}
+
+	if err := s.Portal.Init(ctx, templatesBuilder, s.GitCommit, sessionPersistInterval); err != nil {
+		return err
+	}
+
+	s.HealthCheck = &maintenance.HealthCheckJob{
+		BusinessDB:    s.BusinessDB,
+		Pool:          s.Pool,
+		TimeSeriesDB:  s.TimeSeries,
+		CheckInterval: cfg.Get(common.HealthCheckIntervalKey),
+		Metrics:       s.Metrics,
+		Footprint:     s.Footprint,
+	}
+	s.Jobs = maintenance.NewJobs(s.BusinessDB, s.Metrics)
+	s.Portal.MaintenanceJobs = s.Jobs
+
+	slog.DebugContext(ctx, "Initialized server", "stage", s.Stage, "verbose", verbose)
+	for _, u := range s.Footprint.Usage() {
+		slog.InfoContext(ctx, "Sized in-memory component", "footprint", s.Footprint.Name, "component", u.Name, "capacity", u.Capacity)
+	}
+
+	return nil
+}
+
+// newRedisClient connects to Redis when it is configured (for multi-node deployments) and returns nil otherwise
+func newRedisClient(ctx context.Context, cfg common.ConfigStore) (*redis.Client, error) {
+	addr := cfg.Get(common.RedisAddressKey).Value()
+	if len(addr) == 0 {
+		return nil, nil
+	}
+
+	client := redis.NewClient(addr, cfg.Get(common.RedisPasswordKey).Value(), config.AsInt(cfg.Get(common.RedisDBKey), 0))
+	if err := client.Ping(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Redis", "address", addr, common.ErrAttr(err))
+		client.Close()
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Connected to Redis", "address", addr)
+
+	return client, nil
+}
+
+// newSessionStore uses Redis for sessions when it is configured and DB cache otherwise
+func (s *Server) newSessionStore(cfg common.ConfigStore) (session.Store, error) {
+	// standby can only use sessions replicated from the primary database
+	if s.Standby || (s.redisClient == nil) {
+		store := db.NewSessionStore(s.BusinessDB, session.KeyPersistent)
+		store.ReadOnly = s.Standby
+		return store, nil
+	}
+
+	return redis.NewStore(s.redisClient, session.KeyPersistent)
+}
+
+// newIssuanceBuckets shares puzzle quota buckets between nodes via Redis when it is configured
+func (s *Server) newIssuanceBuckets(ctx context.Context) api.IssuanceBuckets {
+	size := s.Footprint.Size(api.MaxQuotaBuckets)
+
+	if s.redisClient == nil {
+		return api.NewLocalIssuanceBuckets(size)
+	}
+
+	slog.InfoContext(ctx, "Using Redis for puzzle quota")
+
+	return api.NewRedisIssuanceBuckets(s.redisClient, size)
+}
+
+// UpdateConfig re-reads dynamic configuration (e.g. after SIGHUP)
+func (s *Server) UpdateConfig(ctx context.Context) {
+	cfg := s.Config
+	cfg.Update(ctx)
+
+	bucketRate := cfg.Get(common.RateLimitRateKey)
+	bucketBurst := cfg.Get(common.RateLimitBurstKey)
+	s.IPRateLimiter.UpdateLimits(
+		leakybucket.Cap(bucketBurst.Value(), generalLeakyBucketCap),
+		leakybucket.Interval(bucketRate.Value(), generalLeakInterval))
+
+	s.LoadShedder.UpdateLimits(
+		config.AsInt(cfg.Get(common.LoadShedMaxInflightKey), common.DefaultLoadShedMaxInflight),
+		time.Duration(config.AsInt(cfg.Get(common.LoadShedLatencyKey), int(common.DefaultLoadShedLatency.Milliseconds())))*time.Millisecond)
+
+	maintenanceMode := config.AsBool(cfg.Get(common.MaintenanceModeKey))
+	s.BusinessDB.UpdateConfig(maintenanceMode)
+	s.BusinessDB.UpdateAPIKeyUsage(config.AsBool(cfg.Get(common.APIKeyUsageAuditKey)))
+	s.TimeSeries.UpdateConfig(maintenanceMode)
+	s.TimeSeries.UpdateVerifyRetention(db.NewVerifyRetention(cfg))
+	s.Portal.UpdateConfig(ctx, cfg)
+	s.API.UpdateConfig(ctx, cfg)
+	s.Jobs.UpdateConfig(cfg)
+
+	// initial load (at startup) is marked by deploy event instead
+	snapshot := db.NewAuditLogConfig(cfg)
+	if s.configSnapshot != nil {
+		slog.InfoContext(ctx, "Configuration was reloaded", "maintenance", snapshot.MaintenanceMode,
+			"maintenanceBefore", s.configSnapshot.MaintenanceMode)
+		s.recordConfigReload(ctx, s.configSnapshot, snapshot)
+	}
+	s.configSnapshot = snapshot
+}
+
+// recordConfigReload records the audit event only from the node that reloaded the change first
+func (s *Server) recordConfigReload(ctx context.Context, oldValue, newValue *db.AuditLogConfig) {
+	lockName, err := db.ConfigReloadLockName(oldValue, newValue)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create config reload lock name", common.ErrAttr(err))
+		return
+	}
+
+	if _, err := s.BusinessDB.Impl().AcquireLock(ctx, lockName, nil /*data*/, time.Now().UTC().Add(configReloadLockDuration)); err != nil {
+		slog.DebugContext(ctx, "Config reload is recorded by another node", "lock", lockName, common.ErrAttr(err))
+		return
+	}
+
+	s.BusinessDB.AuditLog().RecordEvent(ctx, db.NewConfigReloadAuditLogEvent(oldValue, newValue), common.AuditLogSourceSystem)
+}
+
+// Register adds API, Portal and CDN routes to the router
+func (s *Server) Register(router *http.ServeMux) {
+	verbose := config.AsBool(s.Config.Get(common.VerboseKey))
+	s.API.Setup(s.apiDomain, verbose, common.NoopMiddleware).Register(router)
+	s.Portal.Setup(s.portalDomain, common.NoopMiddleware).Register(router)
+
+	ipRateLimiter := s.IPRateLimiter
+	rateLimiter := ipRateLimiter.RateLimitExFunc(publicLeakyBucketCap, publicLeakInterval)
+	cdnChain := alice.New(common.Recovered, s.Metrics.CDNHandler, rateLimiter)
+	var portalStatic, widgetStatic http.Handler = web.Static(s.GitCommit), widget.Static(s.GitCommit)
+	if s.assets != nil {
+		portalStatic, widgetStatic = s.assets.Handler("portal", portalStatic), s.assets.Handler("widget", widgetStatic)
+	}
+	router.Handle("GET "+s.cdnDomain+"/portal/", http.StripPrefix("/portal/", cdnChain.Then(portalStatic)))
+	router.Handle("GET "+s.cdnDomain+"/widget/", http.StripPrefix("/widget/", cdnChain.Then(widgetStatic)))
+	// Turnstile compatibility (same path as Cloudflare so that only the host needs to be changed)
+	router.Handle("GET "+s.cdnDomain+"/"+common.TurnstileEndpoint+"/api.js", cdnChain.Then(widget.TurnstileScript(widgetStatic)))
+	// "protection" (NOTE: different than usual order of monitoring)
+	publicChain := alice.New(common.Recovered, s.Metrics.IgnoredHandler, rateLimiter)
+	s.Portal.SetupCatchAll(router, s.portalDomain, publicChain)
+}
+
+// Handler returns a standalone handler with all routes, including a catch-all with stricter rate limiting
+func (s *Server) Handler() http.Handler {
+	router := http.NewServeMux()
+	s.Register(router)
+
+	catchAllRateLimiter := s.IPRateLimiter.RateLimitExFunc(catchAllLeakyBucketCap, catchAllLeakInterval)
+	catchAllChain := alice.New(common.Recovered, s.Metrics.IgnoredHandler, catchAllRateLimiter)
+	router.Handle("/", catchAllChain.ThenFunc(common.CatchAll))
+
+	return router
+}
+
+// StartJobs schedules and starts maintenance jobs. quitFunc is invoked when the server has to stop (e.g. license expired)
+func (s *Server) StartJobs(ctx context.Context, quitFunc func(ctx context.Context)) error {
+	cfg := s.Config
+
+	checkLicenseJob, err := maintenance.NewCheckLicenseJob(s.BusinessDB, cfg, s.GitCommit, quitFunc)
+	if err != nil {
+		return err
+	}
+	// nolint:errcheck
+	go common.RunPeriodicJobOnce(common.TraceContext(context.Background(), "check_license"), checkLicenseJob, checkLicenseJob.NewParams())
+
+	if s.AuditStream != nil {
+		s.AuditStream.Start(ctx, auditLogInterval)
+	}
+	s.BusinessDB.Start(ctx, auditLogInterval)
+
+	jobs := s.Jobs
+	jobs.Spawn(s.HealthCheck)
+	// cache is local to each instance so validation runs everywhere
+	jobs.Spawn(&maintenance.ValidateCacheJob{Store: s.BusinessDB, Metrics: s.Metrics, SampleSize: 100})
+	jobs.Spawn(&maintenance.RefreshIPReputationJob{Reputation: s.API.IPReputation})
+	jobs.Spawn(s.API.Auth.HotProperties)
+	jobs.AddOneOff(&maintenance.WarmupPortalAuthJob{
+		Store:               s.BusinessDB,
+		RegistrationAllowed: config.AsBool(cfg.Get(common.RegistrationAllowedKey)),
+	})
+	jobs.AddOneOff(&maintenance.WarmupAPICacheJob{
+		Store:      s.BusinessDB,
+		TimeSeries: s.TimeSeries,
+		Backoff:    200 * time.Millisecond,
+		Limit:      50,
+	})
+
+	if s.Standby {
+		// everything below writes to the database, which is a read-only replica in standby
+		jobs.RunAll()
+		return nil
+	}
+
+	// start maintenance jobs
+	jobs.Add(&maintenance.CleanupDBCacheJob{Store: s.BusinessDB})
+	jobs.Add(&maintenance.CleanupDeletedRecordsJob{Store: s.BusinessDB, Age: 365 * 24 * time.Hour})
+	jobs.AddLocked(24*time.Hour, &maintenance.GarbageCollectDataJob{
+		Age:               30 * 24 * time.Hour,
+		BusinessDB:        s.BusinessDB,
+		TimeSeries:        s.TimeSeries,
+		MaxPendingDeletes: 100,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.CleanupOrphanedDataJob{
+		Store:             s.BusinessDB,
+		TimeSeries:        s.TimeSeries,
+		BatchSize:         1000,
+		MaxDeletes:        100,
+		MaxPendingDeletes: 20,
+	})
+	jobs.AddLocked(2*time.Hour, checkLicenseJob)
+	jobs.AddOneOff(&maintenance.RegisterEmailTemplatesJob{
+		Templates: email.Templates(),
+		Store:     s.BusinessDB,
+	})
+	jobs.AddOneOff(&maintenance.RecordDeployJob{
+		BusinessDB: s.BusinessDB,
+		GitCommit:  s.GitCommit,
+		Stage:      s.Stage,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.UserEmailNotificationsJob{
+		RunInterval:       3 * time.Hour, // overlap few locked intervals to cover for possible unprocessed notifications
+		Store:             s.BusinessDB,
+		Templates:         email.Templates(),
+		OptionalTemplates: email.OptionalTemplates(),
+		Sender:            s.Sender,
+		ChunkSize:         50,
+		MaxAttempts:       5,
+		EmailFrom:         cfg.Get(common.EmailFromKey),
+		ReplyToEmail:      cfg.Get(common.ReplyToEmailKey),
+		PlanService:       s.PlanService,
+		CDNURL:            s.Mailer.CDNURL,
+		PortalURL:         s.Mailer.PortalURL,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupUserNotificationsJob{
+		Store:              s.BusinessDB,
+		NotificationMonths: 6,
+		TemplateMonths:     7,
+	})
+	jobs.AddLocked(3*time.Hour, &maintenance.ExpireInternalTrialsJob{
+		PastInterval: 3 * time.Hour,
+		Age:          24 * time.Hour,
+		BusinessDB:   s.BusinessDB,
+		PlanService:  s.PlanService,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupAuditLogJob{
+		PastInterval: portal.MaxAuditLogsRetention(cfg),
+		BusinessDB:   s.BusinessDB,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupLimitDecisionsJob{
+		PastInterval: 90 * 24 * time.Hour,
+		BusinessDB:   s.BusinessDB,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupAsyncTasksJob{
+		PastInterval: 30 * 24 * time.Hour,
+		BusinessDB:   s.BusinessDB,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupPropertyShareLinksJob{
+		PastInterval: 7 * 24 * time.Hour,
+		BusinessDB:   s.BusinessDB,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupPropertyBypassTokensJob{
+		PastInterval: 7 * 24 * time.Hour,
+		BusinessDB:   s.BusinessDB,
+	})
+	jobs.AddLocked(24*time.Hour, &maintenance.CleanupAPIKeyUsageJob{
+		PastInterval: 90 * 24 * time.Hour,
+		BusinessDB:   s.BusinessDB,
+	})
+	jobs.AddLocked(10*time.Minute, s.AsyncTasks)
+	jobs.Spawn(&maintenance.RefreshDifficultyExperimentsJob{
+		BusinessDB:  s.BusinessDB,
+		Experiments: s.API.Verifier.Experiments,
+	})
+	jobs.Spawn(&maintenance.RefreshMonthlyQuotasJob{
+		BusinessDB: s.BusinessDB,
+		Quotas:     s.API.Verifier.Quotas,
+	})
+	jobs.Spawn(&maintenance.RefreshPrivacySaltJob{
+		BusinessDB: s.BusinessDB,
+		Salt:       s.API.Verifier.PrivacySalt,
+	})
+	if pgTimeSeries, ok := s.TimeSeries.(*db.PostgresTimeSeries); ok {
+		jobs.AddLocked(1*time.Hour, &maintenance.CleanupTimeSeriesJob{
+			TimeSeries: pgTimeSeries,
+		})
+	} else {
+		jobs.AddLocked(15*time.Minute, &maintenance.BackfillClickHouseJob{
+			Store:        s.BusinessDB,
+			TimeSeries:   s.TimeSeries,
+			Backfills:    db.ClickHouseBackfills,
+			ChunksPerRun: 10,
+		})
+	}
+	jobs.AddLocked(1*time.Hour, &maintenance.CheckPropertyDomainsJob{
+		BusinessDB:    s.BusinessDB,
+		Resolver:      &net.Resolver{},
+		IDHasher:      s.Portal.IDHasher,
+		CheckInterval: 7 * 24 * time.Hour,
+		BatchSize:     200,
+		Delay:         100 * time.Millisecond,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.ConcludeDifficultyExperimentsJob{
+		BusinessDB: s.BusinessDB,
+		TimeSeries: s.TimeSeries,
+	})
+	jobs.AddLocked(15*time.Minute, &maintenance.CheckPropertyBaselinesJob{
+		BusinessDB: s.BusinessDB,
+		TimeSeries: s.TimeSeries,
+		IDHasher:   s.Portal.IDHasher,
+		Sigma:      config.AsFloat(cfg.Get(common.AlertSigmaKey), 4.0),
+		MinSamples: 4,
+		Window:     8,
+		MinVolume:  50,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.StatsDigestJob{
+		BusinessDB: s.BusinessDB,
+		TimeSeries: s.TimeSeries,
+		IDHasher:   s.Portal.IDHasher,
+		BatchSize:  500,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.OrgSummaryJob{
+		BusinessDB: s.BusinessDB,
+		TimeSeries: s.TimeSeries,
+		BatchSize:  200,
+	})
+	jobs.AddLocked(1*time.Hour, &maintenance.ResetSandboxOrgsJob{
+		BusinessDB: s.BusinessDB,
+		TimeSeries: s.TimeSeries,
+		Hour:       3,
+		BatchSize:  100,
+	})
+	jobs.AddLocked(30*time.Minute, &maintenance.MonthlyQuotaJob{
+		BusinessDB:  s.BusinessDB,
+		TimeSeries:  s.TimeSeries,
+		PlanService: s.PlanService,
+		Stage:       s.Stage,
+	})
+	if rotation := time.Duration(config.AsInt(cfg.Get(common.TLSTicketRotationKey), 0)) * time.Hour; (s.TLSConfig != nil) && (rotation > 0) {
+		jobs.AddLocked(30*time.Minute, &maintenance.RotateSessionTicketKeysJob{
+			Store:    s.BusinessDB,
+			Rotation: rotation,
+		})
+		jobs.Spawn(&maintenance.RefreshSessionTicketKeysJob{
+			Store:     s.BusinessDB,
+			TLSConfig: s.TLSConfig,
+		})
+	}
+
+	profilingURL := cfg.Get(common.ProfilingUploadURLKey).Value()
+	// labels are useful for both uploaded profiles and the ones collected manually via pprof endpoints
+	s.Metrics.EnableProfilingLabels((len(profilingURL) > 0) || config.AsBool(cfg.Get(common.ProfilingEnabledKey)))
+
+	if len(profilingURL) > 0 {
+		host, _ := os.Hostname()
+		jobs.Spawn(&maintenance.UploadProfilesJob{
+			URL:         profilingURL,
+			AppName:     "privatecaptcha",
+			Labels:      map[string]string{"stage": s.Stage, "host": host},
+			CPUDuration: 10 * time.Second,
+			Client:      &http.Client{Timeout: 30 * time.Second},
+		})
+	}
+
+	jobs.RunAll()
+
+	return nil
+}
+
+// SetupLocal adds metrics, maintenance and health endpoints, intended for a private (local) listener
+func (s *Server) SetupLocal(router *http.ServeMux) {
+	s.Metrics.Setup(router, config.AsBool(s.Config.Get(common.ProfilingEnabledKey)))
+	s.Jobs.AddReport(&maintenance.CostReport{
+		BusinessDB:  s.BusinessDB,
+		TimeSeries:  s.TimeSeries,
+		PlanService: s.PlanService,
+		Stage:       s.Stage,
+	})
+	announcements := &maintenance.AnnouncementsAPI{BusinessDB: s.BusinessDB}
+	s.Jobs.AddHandler(http.MethodGet+" /maintenance/announcements", http.HandlerFunc(announcements.List))
+	s.Jobs.AddHandler(http.MethodPost+" /maintenance/announcements", http.HandlerFunc(announcements.Create))
+	s.Jobs.AddHandler(http.MethodDelete+" /maintenance/announcements/{id}", http.HandlerFunc(announcements.Cancel))
+	incident := &maintenance.IncidentAPI{BusinessDB: s.BusinessDB, TimeSeries: s.TimeSeries}
+	s.Jobs.AddHandler(http.MethodGet+" /maintenance/incident", http.HandlerFunc(incident.Timeline))
+	s.Jobs.Setup(router, s.Config)
+	router.Handle(http.MethodGet+" /"+common.LiveEndpoint, common.Recovered(http.HandlerFunc(s.HealthCheck.LiveHandler)))
+	router.Handle(http.MethodGet+" /"+common.ReadyEndpoint, common.Recovered(http.HandlerFunc(s.HealthCheck.ReadyHandler)))
+	router.Handle(http.MethodGet+" /"+common.SchemaEndpoint, common.Recovered(http.HandlerFunc(s.HealthCheck.SchemaHandler)))
+}
+
+// Shutdown stops background routines and closes DB connections. Serving HTTP has to be stopped before.
+func (s *Server) Shutdown() {
+	slog.Debug("Shutting down server")
+	s.Jobs.Shutdown()
+	s.sessionStore.Shutdown()
+	s.API.Shutdown()
+	s.BusinessDB.Shutdown()
+	if s.AuditStream != nil {
+		s.AuditStream.Shutdown()
+	}
+	s.closeDB()
+}
+
+func (s *Server) closeDB() {
+	if s.Pool != nil {
+		s.Pool.Close()
+	}
+
+	if s.ClickHouse != nil {
+		if err := s.ClickHouse.Close(); err != nil {
+			slog.Error("Failed to close ClickHouse connection", common.ErrAttr(err))
+		}
+	}
+}
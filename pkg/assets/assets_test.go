@@ -0,0 +1,108 @@
+package assets
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testManifest(t *testing.T) *Manifest {
+	manifest, err := NewManifest(map[string]fs.FS{
+		"portal": fstest.MapFS{
+			"js/bundle.js":    {Data: []byte("console.log(1);")},
+			"css/style.css":   {Data: []byte("body {}")},
+			"img/favicon.ico": {Data: []byte("icon")},
+		},
+		"widget": fstest.MapFS{
+			"js/privatecaptcha.js": {Data: []byte("widget")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return manifest
+}
+
+func TestRewriteTemplate(t *testing.T) {
+	t.Parallel()
+
+	manifest := testManifest(t)
+	bundle, _ := manifest.Hashed("portal/js/bundle.js")
+	style, _ := manifest.Hashed("portal/css/style.css")
+
+	if !strings.HasPrefix(bundle, "portal/js/bundle.") || !strings.HasSuffix(bundle, ".js") || (len(bundle) != len("portal/js/bundle.js")+hashLength+1) {
+		t.Fatalf("Unexpected hashed name: %v", bundle)
+	}
+
+	source := `<script src="{{$.Ctx.CDN}}/portal/js/bundle.js"></script>
+<link href="{{ $.Ctx.CDN }}/portal/css/style.css?v={{$.Platform.GitCommit}}" rel="stylesheet">
+<img src="{{$.Ctx.CDN}}/portal/{{$item.icon}}">
+<img src="{{$.Ctx.CDN}}/portal/img/missing.png">`
+
+	expected := `<script src="{{$.Ctx.CDN}}/` + bundle + `"></script>
+<link href="{{ $.Ctx.CDN }}/` + style + `?v={{$.Platform.GitCommit}}" rel="stylesheet">
+<img src="{{$.Ctx.CDN}}/portal/{{$item.icon}}">
+<img src="{{$.Ctx.CDN}}/portal/img/missing.png">`
+
+	if actual := manifest.RewriteTemplate(source); actual != expected {
+		t.Errorf("Unexpected rewritten template:\n%v", actual)
+	}
+
+	handler := manifest.Handler("portal", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+strings.TrimPrefix(bundle, "portal/"), nil))
+	if body := w.Body.String(); body != "js/bundle.js" {
+		t.Errorf("Hashed asset was served as %v", body)
+	}
+}
+
+type memoryStorage struct {
+	objects map[string]string
+}
+
+func (s *memoryStorage) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+func (s *memoryStorage) Put(ctx context.Context, key string, content []byte, contentType, cacheControl string) error {
+	s.objects[key] = cacheControl
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	manifest := testManifest(t)
+	storage := &memoryStorage{objects: make(map[string]string)}
+
+	stats, err := Sync(t.Context(), storage, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (stats.Uploaded != 8) || (stats.Skipped != 0) {
+		t.Errorf("Unexpected first sync stats: %+v", stats)
+	}
+
+	hashed, _ := manifest.Hashed("widget/js/privatecaptcha.js")
+	if storage.objects[hashed] != immutableCacheControl {
+		t.Errorf("Hashed asset is not immutable: %v", storage.objects[hashed])
+	}
+
+	if stats, err = Sync(t.Context(), storage, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if (stats.Uploaded != 4) || (stats.Skipped != 4) {
+		t.Errorf("Unexpected second sync stats: %+v", stats)
+	}
+}
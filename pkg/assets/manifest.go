@@ -0,0 +1,141 @@
+// Package assets publishes embedded portal and widget static files to S3-compatible object storage under
+// content-hashed names, so that they can be served from a CDN origin with long-lived caching.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	hashLength = 12
+)
+
+var (
+	// asset references in portal templates, e.g. {{$.Ctx.CDN}}/portal/js/bundle.js
+	templateAssetRegexp = regexp.MustCompile(`(\{\{\s*\$\.Ctx\.CDN\s*\}\})/([A-Za-z0-9_-]+/[A-Za-z0-9_./-]+)`)
+)
+
+// HashedName inserts content hash before the file extension, e.g. js/bundle.js becomes js/bundle.0123456789ab.js
+func HashedName(name string, content []byte) string {
+	hash := sha256.Sum256(content)
+	suffix := hex.EncodeToString(hash[:])[:hashLength]
+
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + suffix + ext
+}
+
+type File struct {
+	// original path, including source prefix (e.g. "portal/js/bundle.js")
+	Path string
+	// content-hashed path
+	HashedPath string
+	Content    []byte
+}
+
+// Manifest maps original asset paths to content-hashed ones
+type Manifest struct {
+	files    []*File
+	hashed   map[string]string
+	original map[string]string
+}
+
+// NewManifest reads all files from sources, indexed by path prefix (e.g. "portal" or "widget")
+func NewManifest(sources map[string]fs.FS) (*Manifest, error) {
+	m := &Manifest{
+		files:    make([]*File, 0),
+		hashed:   make(map[string]string),
+		original: make(map[string]string),
+	}
+
+	prefixes := make([]string, 0, len(sources))
+	for prefix := range sources {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		fsys := sources[prefix]
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+
+			f := &File{
+				Path:       path.Join(prefix, name),
+				HashedPath: path.Join(prefix, HashedName(name, content)),
+				Content:    content,
+			}
+
+			m.files = append(m.files, f)
+			m.hashed[f.Path] = f.HashedPath
+			m.original[f.HashedPath] = f.Path
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Manifest) Files() []*File {
+	return m.files
+}
+
+// Hashed returns content-hashed path of the asset
+func (m *Manifest) Hashed(name string) (string, bool) {
+	hashed, ok := m.hashed[name]
+	return hashed, ok
+}
+
+// RewriteTemplate replaces CDN asset URLs in the template source with content-hashed ones. Unknown assets (e.g.
+// paths built dynamically in the template) are left as is, because original names are published as well
+func (m *Manifest) RewriteTemplate(content string) string {
+	return templateAssetRegexp.ReplaceAllStringFunc(content, func(match string) string {
+		parts := templateAssetRegexp.FindStringSubmatch(match)
+		if hashed, ok := m.hashed[parts[2]]; ok {
+			return parts[1] + "/" + hashed
+		}
+
+		return match
+	})
+}
+
+// Handler serves content-hashed names of the assets with the prefix (in addition to the original ones) by the
+// handler that serves original names
+func (m *Manifest) Handler(prefix string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if original, ok := m.original[path.Join(prefix, r.URL.Path)]; ok {
+			slog.Log(r.Context(), common.LevelTrace, "Serving hashed asset", "path", r.URL.Path, "original", original)
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = strings.TrimPrefix(original, prefix+"/")
+			r2.URL.RawPath = ""
+			next.ServeHTTP(w, r2)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,139 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	s3RequestTimeout = 30 * time.Second
+	s3DefaultRegion  = "us-east-1"
+	s3Service        = "s3"
+)
+
+var (
+	errNoS3Endpoint    = errors.New("S3 endpoint is not configured")
+	errNoS3Credentials = errors.New("S3 credentials are not configured")
+)
+
+// S3Client is a minimal client of S3-compatible object storage (AWS S3, Cloudflare R2, MinIO etc.) that signs
+// requests with AWS Signature Version 4
+type S3Client struct {
+	client    *http.Client
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+}
+
+// NewS3Client uses path-style requests if bucket is set, otherwise endpoint has to point to the bucket itself
+func NewS3Client(endpoint, bucket, region, accessKey, secretKey string) (*S3Client, error) {
+	if len(endpoint) == 0 {
+		return nil, errNoS3Endpoint
+	}
+
+	if (len(accessKey) == 0) || (len(secretKey) == 0) {
+		return nil, errNoS3Credentials
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(region) == 0 {
+		region = s3DefaultRegion
+	}
+
+	return &S3Client{
+		client:    &http.Client{Timeout: s3RequestTimeout},
+		endpoint:  u,
+		bucket:    bucket,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+	}, nil
+}
+
+func NewS3ClientFromConfig(cfg common.ConfigStore) (*S3Client, error) {
+	return NewS3Client(
+		cfg.Get(common.AssetsS3EndpointKey).Value(),
+		cfg.Get(common.AssetsS3BucketKey).Value(),
+		cfg.Get(common.AssetsS3RegionKey).Value(),
+		cfg.Get(common.AssetsS3AccessKeyKey).Value(),
+		cfg.Get(common.AssetsS3SecretKeyKey).Value())
+}
+
+func (c *S3Client) objectURL(key string) *url.URL {
+	u := *c.endpoint
+	if len(c.bucket) > 0 {
+		u.Path += "/" + c.bucket
+	}
+	u.Path += "/" + key
+	return &u
+}
+
+func (c *S3Client) do(ctx context.Context, method, key string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	common.SignAWSRequest(req, body, c.accessKey, c.secretKey, c.region, s3Service, time.Now().UTC())
+
+	return c.client.Do(req)
+}
+
+// Exists checks if the object with the key is already in the storage
+func (c *S3Client) Exists(ctx context.Context, key string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected S3 status code %d for %s", resp.StatusCode, key)
+	}
+}
+
+func (c *S3Client) Put(ctx context.Context, key string, content []byte, contentType, cacheControl string) error {
+	headers := map[string]string{
+		common.HeaderCacheControl: cacheControl,
+	}
+	if len(contentType) > 0 {
+		headers[common.HeaderContentType] = contentType
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, key, content, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected S3 status code %d for %s: %s", resp.StatusCode, key, message)
+	}
+
+	return nil
+}
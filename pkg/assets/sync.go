@@ -0,0 +1,64 @@
+package assets
+
+import (
+	"context"
+	"log/slog"
+	"mime"
+	"path"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	// same as when assets are served by the server itself
+	originalCacheControl = "public, max-age=86400"
+)
+
+type Storage interface {
+	Exists(ctx context.Context, key string) (bool, error)
+	Put(ctx context.Context, key string, content []byte, contentType, cacheControl string) error
+}
+
+type SyncStats struct {
+	Uploaded int
+	Skipped  int
+}
+
+// Sync uploads assets under content-hashed names (unless they are already present) and under original names,
+// which are still referenced outside of portal templates (e.g. widget script on customers' websites)
+func Sync(ctx context.Context, storage Storage, manifest *Manifest) (*SyncStats, error) {
+	stats := &SyncStats{}
+
+	for _, f := range manifest.Files() {
+		contentType := mime.TypeByExtension(path.Ext(f.Path))
+
+		exists, err := storage.Exists(ctx, f.HashedPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to check asset in storage", "path", f.HashedPath, common.ErrAttr(err))
+			return stats, err
+		}
+
+		if exists {
+			slog.DebugContext(ctx, "Asset is already in storage", "path", f.HashedPath)
+			stats.Skipped++
+		} else {
+			if err := storage.Put(ctx, f.HashedPath, f.Content, contentType, immutableCacheControl); err != nil {
+				slog.ErrorContext(ctx, "Failed to upload asset", "path", f.HashedPath, common.ErrAttr(err))
+				return stats, err
+			}
+			stats.Uploaded++
+		}
+
+		if err := storage.Put(ctx, f.Path, f.Content, contentType, originalCacheControl); err != nil {
+			slog.ErrorContext(ctx, "Failed to upload asset", "path", f.Path, common.ErrAttr(err))
+			return stats, err
+		}
+		stats.Uploaded++
+	}
+
+	slog.InfoContext(ctx, "Synced static assets", "files", len(manifest.Files()), "uploaded", stats.Uploaded,
+		"skipped", stats.Skipped)
+
+	return stats, nil
+}
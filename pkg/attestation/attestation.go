@@ -0,0 +1,317 @@
+// Package attestation issues short-lived tokens that prove that a captcha solution was verified, so that
+// downstream services can check it offline. Tokens are JWTs signed with Ed25519 (EdDSA) keys that are
+// distributed in the cmd/keyspack format (key data is a 32-byte Ed25519 seed).
+package attestation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Algorithm  = "EdDSA"
+	Issuer     = "privatecaptcha"
+	tokenType  = "JWT"
+	tokenIDLen = 16
+)
+
+var (
+	ErrNoKeys         = errors.New("no attestation keys found")
+	ErrKeySize        = errors.New("attestation key has invalid size")
+	ErrMalformedToken = errors.New("attestation token is malformed")
+	ErrUnknownKey     = errors.New("attestation token is signed with unknown key")
+	ErrSignature      = errors.New("attestation token signature is not valid")
+	ErrExpired        = errors.New("attestation token is expired")
+)
+
+type Key struct {
+	ID         uint16
+	privateKey ed25519.PrivateKey
+}
+
+func (k *Key) KeyID() string {
+	return strconv.Itoa(int(k.ID))
+}
+
+func (k *Key) Public() ed25519.PublicKey {
+	return k.privateKey.Public().(ed25519.PublicKey)
+}
+
+// ParseKeys reads Ed25519 seeds packed with cmd/keyspack
+func ParseKeys(data []byte) ([]*Key, error) {
+	reader := bytes.NewReader(data)
+	keys := make([]*Key, 0)
+
+	for reader.Len() > 0 {
+		var keyID uint16
+		if err := binary.Read(reader, binary.LittleEndian, &keyID); err != nil {
+			return nil, fmt.Errorf("failed to read key ID: %w", err)
+		}
+
+		var dataLen uint8
+		if err := binary.Read(reader, binary.LittleEndian, &dataLen); err != nil {
+			return nil, fmt.Errorf("failed to read length of key %d: %w", keyID, err)
+		}
+
+		if int(dataLen) != ed25519.SeedSize {
+			return nil, fmt.Errorf("key %d: %w", keyID, ErrKeySize)
+		}
+
+		seed := make([]byte, dataLen)
+		if _, err := io.ReadFull(reader, seed); err != nil {
+			return nil, fmt.Errorf("failed to read data of key %d: %w", keyID, err)
+		}
+
+		keys = append(keys, &Key{ID: keyID, privateKey: ed25519.NewKeyFromSeed(seed)})
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	return keys, nil
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+type Claims struct {
+	Issuer    string `json:"iss"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	// sitekey of the property that puzzle was issued for
+	Subject string `json:"sub"`
+	Origin  string `json:"origin"`
+	// when the verified puzzle was created
+	PuzzleTimestamp int64 `json:"puzzle_ts"`
+	CrossProperty   bool  `json:"cross_property,omitempty"`
+	// "bypass" when verification was passed with a bypass token (trusted automation) instead of a solved puzzle
+	VisitorClass string `json:"visitor_class,omitempty"`
+	// claims of the property owner that were signed into the puzzle
+	Claims map[string]string `json:"claims,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+func encodeSegment(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(data), nil
+}
+
+// Signer signs tokens with the key that has the largest ID among the ones published for at least the activation
+// delay (lifetime of cached JWKS), so that validators do not see tokens signed with a key they did not fetch yet.
+// The rest of the keys are only published, so that tokens issued before key rotation can still be validated.
+type Signer struct {
+	keys        []*Key
+	publishedAt map[uint16]time.Time
+	delay       time.Duration
+}
+
+// NewSigner keeps publication times of the keys known to the previous signer (can be nil), other keys are
+// considered published at tnow
+func NewSigner(keys []*Key, delay time.Duration, previous *Signer, tnow time.Time) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	publishedAt := make(map[uint16]time.Time, len(keys))
+	for _, k := range keys {
+		publishedAt[k.ID] = tnow
+		if previous != nil {
+			if t, ok := previous.publishedAt[k.ID]; ok {
+				publishedAt[k.ID] = t
+			}
+		}
+	}
+
+	return &Signer{keys: keys, publishedAt: publishedAt, delay: delay}, nil
+}
+
+// activeKey falls back to the key with the smallest ID when no key was published long enough (e.g. after restart),
+// as it's the one that validators most likely know
+func (s *Signer) activeKey(tnow time.Time) *Key {
+	var active, oldest *Key
+
+	for _, k := range s.keys {
+		if (oldest == nil) || (k.ID < oldest.ID) {
+			oldest = k
+		}
+
+		if tnow.Sub(s.publishedAt[k.ID]) < s.delay {
+			continue
+		}
+
+		if (active == nil) || (k.ID > active.ID) {
+			active = k
+		}
+	}
+
+	if active == nil {
+		return oldest
+	}
+
+	return active
+}
+
+// Sign fills registered claims (issuer, ID and times) and returns the compact JWT
+func (s *Signer) Sign(claims *Claims, ttl time.Duration, tnow time.Time) (string, error) {
+	id := make([]byte, tokenIDLen)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	claims.Issuer = Issuer
+	claims.ID = hex.EncodeToString(id)
+	claims.IssuedAt = tnow.Unix()
+	claims.ExpiresAt = tnow.Add(ttl).Unix()
+
+	active := s.activeKey(tnow)
+
+	h, err := encodeSegment(&header{Algorithm: Algorithm, Type: tokenType, KeyID: active.KeyID()})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := h + "." + payload
+	signature := ed25519.Sign(active.privateKey, []byte(signingInput))
+
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+// JWK is the public Ed25519 key in the JSON Web Key format (RFC 8037)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+func (s *Signer) JWKS() *JWKS {
+	result := &JWKS{Keys: make([]*JWK, 0, len(s.keys))}
+
+	for _, k := range s.keys {
+		result.Keys = append(result.Keys, &JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         encoding.EncodeToString(k.Public()),
+			KeyID:     k.KeyID(),
+			Algorithm: Algorithm,
+			Use:       "sig",
+		})
+	}
+
+	return result
+}
+
+// Validator checks tokens offline using the published public keys
+type Validator struct {
+	keys map[string]ed25519.PublicKey
+}
+
+func NewValidator(jwks *JWKS) (*Validator, error) {
+	keys := make(map[string]ed25519.PublicKey)
+
+	for _, k := range jwks.Keys {
+		if (k.KeyType != "OKP") || (k.Curve != "Ed25519") {
+			continue
+		}
+
+		x, err := encoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %s: %w", k.KeyID, err)
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: %w", k.KeyID, ErrKeySize)
+		}
+
+		keys[k.KeyID] = ed25519.PublicKey(x)
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	return &Validator{keys: keys}, nil
+}
+
+func (v *Validator) Validate(token string, tnow time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	headerData, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	h := &header{}
+	if err := json.Unmarshal(headerData, h); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if h.Algorithm != Algorithm {
+		return nil, ErrMalformedToken
+	}
+
+	key, ok := v.keys[h.KeyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if !ed25519.Verify(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrSignature
+	}
+
+	payload, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	if !tnow.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrExpired
+	}
+
+	return claims, nil
+}
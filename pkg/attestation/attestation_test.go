@@ -0,0 +1,164 @@
+package attestation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func packKeys(t *testing.T, seeds map[uint16][]byte) []byte {
+	var buf bytes.Buffer
+
+	for id, seed := range seeds {
+		_ = binary.Write(&buf, binary.LittleEndian, id)
+		_ = binary.Write(&buf, binary.LittleEndian, uint8(len(seed)))
+		buf.Write(seed)
+	}
+
+	return buf.Bytes()
+}
+
+func testSigner(t *testing.T) *Signer {
+	keys, err := ParseKeys(packKeys(t, map[uint16][]byte{
+		1: bytes.Repeat([]byte{1}, 32),
+		2: bytes.Repeat([]byte{2}, 32),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys were published long ago
+	signer, err := NewSigner(keys, time.Hour, nil /*previous*/, time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+func TestParseKeysFail(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseKeys(nil); err != ErrNoKeys {
+		t.Errorf("Unexpected error for empty keys: %v", err)
+	}
+
+	if _, err := ParseKeys(packKeys(t, map[uint16][]byte{1: []byte("short")})); !errors.Is(err, ErrKeySize) {
+		t.Errorf("Unexpected error for short key: %v", err)
+	}
+}
+
+func TestSignAndValidate(t *testing.T) {
+	t.Parallel()
+
+	signer := testSigner(t)
+	tnow := time.Now()
+	if active := signer.activeKey(tnow); active.ID != 2 {
+		t.Errorf("Unexpected active key: %v", active.ID)
+	}
+
+	token, err := signer.Sign(&Claims{Subject: "sitekey", Origin: "example.com", Claims: map[string]string{"form": "signup"}}, time.Minute, tnow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator, err := NewValidator(signer.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := validator.Validate(token, tnow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (claims.Subject != "sitekey") || (claims.Origin != "example.com") || (claims.Claims["form"] != "signup") || (claims.Issuer != Issuer) {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	if _, err := validator.Validate(token, tnow.Add(time.Minute)); err != ErrExpired {
+		t.Errorf("Unexpected error for expired token: %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered, _ := encodeSegment(&Claims{Subject: "other", ExpiresAt: tnow.Add(time.Hour).Unix()})
+	if _, err := validator.Validate(parts[0]+"."+tampered+"."+parts[2], tnow); err != ErrSignature {
+		t.Errorf("Unexpected error for tampered token: %v", err)
+	}
+
+	if _, err := validator.Validate("foo.bar", tnow); err != ErrMalformedToken {
+		t.Errorf("Unexpected error for malformed token: %v", err)
+	}
+}
+
+func TestValidateUnknownKey(t *testing.T) {
+	t.Parallel()
+
+	signer := testSigner(t)
+	token, err := signer.Sign(&Claims{}, time.Minute, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := signer.JWKS()
+	for i, k := range jwks.Keys {
+		if k.KeyID == signer.activeKey(time.Now()).KeyID() {
+			jwks.Keys = append(jwks.Keys[:i], jwks.Keys[i+1:]...)
+			break
+		}
+	}
+
+	validator, err := NewValidator(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := validator.Validate(token, time.Now()); err != ErrUnknownKey {
+		t.Errorf("Unexpected error for unknown key: %v", err)
+	}
+}
+
+func TestSignerKeyActivation(t *testing.T) {
+	t.Parallel()
+
+	keys, err := ParseKeys(packKeys(t, map[uint16][]byte{1: bytes.Repeat([]byte{1}, 32)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tstart := time.Now()
+	previous, err := NewSigner(keys, time.Hour, nil /*previous*/, tstart)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the only key is used right away
+	if active := previous.activeKey(tstart); active.ID != 1 {
+		t.Errorf("Unexpected active key: %v", active.ID)
+	}
+
+	keys, err = ParseKeys(packKeys(t, map[uint16][]byte{
+		1: bytes.Repeat([]byte{1}, 32),
+		2: bytes.Repeat([]byte{2}, 32),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tupdate := tstart.Add(2 * time.Hour)
+	signer, err := NewSigner(keys, time.Hour, previous, tupdate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active := signer.activeKey(tupdate.Add(time.Minute)); active.ID != 1 {
+		t.Errorf("New key is used before it was published long enough: %v", active.ID)
+	}
+
+	if active := signer.activeKey(tupdate.Add(time.Hour)); active.ID != 2 {
+		t.Errorf("New key is not used after activation delay: %v", active.ID)
+	}
+}
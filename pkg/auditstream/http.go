@@ -0,0 +1,92 @@
+package auditstream
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	HeaderSignature = "X-PC-Signature"
+	HeaderTimestamp = "X-PC-Timestamp"
+	signaturePrefix = "sha256="
+	httpTimeout     = 10 * time.Second
+)
+
+type httpPayload struct {
+	Events []*db.Event `json:"events"`
+}
+
+// HTTPTransport posts batches as JSON. Receiver validates the request with Verify() (or an equivalent HMAC-SHA256
+// of "<timestamp>.<body>" with the shared secret) and should reject requests with stale timestamps.
+type HTTPTransport struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+func NewHTTPTransport(url string, secret []byte) *HTTPTransport {
+	return &HTTPTransport{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func (t *HTTPTransport) Ship(ctx context.Context, events []*db.Event) error {
+	body, err := json.Marshal(&httpPayload{Events: events})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(t.secret, timestamp, body))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		return fmt.Errorf("unexpected audit stream response status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (t *HTTPTransport) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
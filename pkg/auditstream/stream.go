@@ -0,0 +1,142 @@
+// Package auditstream ships audit log events to an external SIEM as they are recorded, either as syslog messages
+// (RFC 5424) or as batches posted to an HTTPS endpoint and signed with HMAC. Events have the same shape as the ones
+// published in the events catalog (see db.Event).
+package auditstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	schemeHTTPS     = "https"
+	schemeSyslog    = "syslog"
+	schemeSyslogUDP = "syslog+udp"
+	schemeSyslogTCP = "syslog+tcp"
+	schemeSyslogTLS = "syslog+tls"
+)
+
+var (
+	ErrUnsupportedScheme = errors.New("unsupported audit stream URL scheme")
+	ErrMissingSecret     = errors.New("audit stream secret is required for HTTP endpoints")
+)
+
+// Transport delivers a batch of events. Failed batch is retried (together with newer events) on the next attempt.
+type Transport interface {
+	Ship(ctx context.Context, events []*db.Event) error
+	Close() error
+}
+
+// NewTransport picks transport by the URL scheme: https:// (POST with HMAC signature), syslog:// (same as
+// syslog+udp://), syslog+tcp:// or syslog+tls://. Plain http:// is not supported as events contain PII
+func NewTransport(rawURL string, secret string) (Transport, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case schemeHTTPS:
+		if len(secret) == 0 {
+			return nil, ErrMissingSecret
+		}
+		return NewHTTPTransport(u.String(), []byte(secret)), nil
+	case schemeSyslog, schemeSyslogUDP:
+		return NewSyslogTransport("udp", u.Host), nil
+	case schemeSyslogTCP:
+		return NewSyslogTransport("tcp", u.Host), nil
+	case schemeSyslogTLS:
+		return NewSyslogTransport("tls", u.Host), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, u.Scheme)
+	}
+}
+
+// Streamer buffers events and ships them in batches in background
+type Streamer struct {
+	transport Transport
+	events    chan *db.Event
+	batchSize int
+	cancel    context.CancelFunc
+	// events channel is never closed as Send() can be called concurrently with Shutdown()
+	closed atomic.Bool
+}
+
+var _ db.AuditLogSink = (*Streamer)(nil)
+
+func NewStreamer(transport Transport, batchSize int) *Streamer {
+	return &Streamer{
+		transport: transport,
+		events:    make(chan *db.Event, 10*batchSize),
+		batchSize: batchSize,
+		cancel:    func() {},
+	}
+}
+
+// NewStreamerFromConfig returns nil when audit stream is not configured
+func NewStreamerFromConfig(ctx context.Context, cfg common.ConfigStore, batchSize int) (*Streamer, error) {
+	rawURL := cfg.Get(common.AuditStreamURLKey).Value()
+	if len(rawURL) == 0 {
+		return nil, nil
+	}
+
+	transport, err := NewTransport(rawURL, cfg.Get(common.AuditStreamSecretKey).Value())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create audit stream transport", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return NewStreamer(transport, batchSize), nil
+}
+
+func (s *Streamer) Start(ctx context.Context, interval time.Duration) {
+	var cancelCtx context.Context
+	cancelCtx, s.cancel = context.WithCancel(
+		context.WithValue(ctx, common.TraceIDContextKey, "audit_stream"))
+	go common.ProcessBatchArray(cancelCtx, s.events, interval, s.batchSize, s.batchSize*10, s.ship)
+}
+
+func (s *Streamer) Shutdown() {
+	slog.Debug("Shutting down audit stream")
+	s.closed.Store(true)
+	s.cancel()
+	if err := s.transport.Close(); err != nil {
+		slog.Error("Failed to close audit stream transport", common.ErrAttr(err))
+	}
+}
+
+// Send does not block the caller: events are dropped when SIEM is not keeping up (they are still persisted in DB)
+func (s *Streamer) Send(ctx context.Context, event *db.Event) {
+	if s.closed.Load() {
+		return
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		slog.WarnContext(ctx, "Dropping audit stream event", "type", event.Type, "action", event.Action)
+	}
+}
+
+func (s *Streamer) ship(ctx context.Context, batch []*db.Event) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := s.transport.Ship(ctx, batch); err != nil {
+		slog.ErrorContext(ctx, "Failed to ship audit log events", "count", len(batch), common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Shipped audit log events", "count", len(batch))
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package auditstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+func testEvent() *db.Event {
+	return &db.Event{
+		Type:      "property",
+		Version:   1,
+		Action:    common.AuditLogActionUpdate.String(),
+		Source:    common.AuditLogSourcePortal.String(),
+		EntityID:  123,
+		UserID:    1,
+		CreatedAt: common.JSONTimeNow(),
+		NewValue:  &db.AuditLogProperty{Name: "test"},
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	testCases := []struct {
+		url    string
+		secret string
+		err    error
+	}{
+		{"https://siem.example.com/ingest", "secret", nil},
+		{"https://siem.example.com/ingest", "", ErrMissingSecret},
+		{"syslog://localhost:514", "", nil},
+		{"syslog+tcp://localhost:601", "", nil},
+		{"syslog+tls://localhost:6514", "", nil},
+		{"http://siem.example.com/ingest", "secret", ErrUnsupportedScheme},
+		{"ftp://localhost", "", ErrUnsupportedScheme},
+	}
+
+	for _, tc := range testCases {
+		if _, err := NewTransport(tc.url, tc.secret); !errors.Is(err, tc.err) {
+			t.Errorf("Unexpected error for %s: %v (expected %v)", tc.url, err, tc.err)
+		}
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	secret := []byte("secret")
+	received := make(chan []*db.Event, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		payload := &struct {
+			Events []*db.Event `json:"events"`
+		}{}
+		if err := json.Unmarshal(body, payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- payload.Events
+	}))
+	defer srv.Close()
+
+	transport := NewHTTPTransport(srv.URL, secret)
+	if err := transport.Ship(t.Context(), []*db.Event{testEvent()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if events := <-received; (len(events) != 1) || (events[0].EntityID != 123) {
+		t.Errorf("Unexpected received events: %v", events)
+	}
+
+	// signature with a different secret is rejected
+	if err := NewHTTPTransport(srv.URL, []byte("other")).Ship(t.Context(), []*db.Event{testEvent()}); err == nil {
+		t.Error("Expected error for invalid signature")
+	}
+}
+
+func TestSyslogTransport(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('}')
+		received <- line
+	}()
+
+	transport := NewSyslogTransport("tcp", listener.Addr().String())
+	defer transport.Close()
+
+	if err := transport.Ship(t.Context(), []*db.Event{testEvent()}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case message := <-received:
+		// octet-counting framing, then RFC 5424 header
+		length, rest, _ := strings.Cut(message, " ")
+		if (len(length) == 0) || !strings.HasPrefix(rest, "<110>1 ") || !strings.Contains(rest, " privatecaptcha - property - {") {
+			t.Errorf("Unexpected syslog message: %s", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Syslog message was not received")
+	}
+}
+
+func TestStreamerSendAfterShutdown(t *testing.T) {
+	transport := NewHTTPTransport("https://localhost/ingest", []byte("secret"))
+	streamer := NewStreamer(transport, 2 /*batch size*/)
+	streamer.Start(t.Context(), time.Second)
+	streamer.Shutdown()
+
+	// must not panic
+	streamer.Send(t.Context(), testEvent())
+}
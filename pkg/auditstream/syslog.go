@@ -0,0 +1,130 @@
+package auditstream
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	// facility "log audit" (13) with severity "informational" (6)
+	syslogPriority = 13*8 + 6
+	syslogAppName  = "privatecaptcha"
+	syslogTimeout  = 5 * time.Second
+)
+
+// SyslogTransport writes every event as a separate RFC 5424 message with JSON body. Stream transports (TCP and TLS)
+// use octet-counting framing (RFC 6587), UDP sends one message per datagram.
+type SyslogTransport struct {
+	network  string
+	address  string
+	hostname string
+	lock     sync.Mutex
+	conn     net.Conn
+}
+
+func NewSyslogTransport(network, address string) *SyslogTransport {
+	hostname, err := os.Hostname()
+	if (err != nil) || (len(hostname) == 0) {
+		hostname = "-"
+	}
+
+	return &SyslogTransport{
+		network:  network,
+		address:  address,
+		hostname: hostname,
+	}
+}
+
+func (t *SyslogTransport) connect(ctx context.Context) (net.Conn, error) {
+	if t.conn != nil {
+		return t.conn, nil
+	}
+
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+
+	var conn net.Conn
+	var err error
+	if t.network == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", t.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, t.network, t.address)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.conn = conn
+
+	return conn, nil
+}
+
+func (t *SyslogTransport) format(event *db.Event) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	message := fmt.Sprintf("<%d>1 %s %s %s - %s - %s", syslogPriority, time.Now().UTC().Format(time.RFC3339Nano),
+		t.hostname, syslogAppName, event.Type, payload)
+
+	if t.network == "udp" {
+		return []byte(message), nil
+	}
+
+	return fmt.Appendf(nil, "%d %s", len(message), message), nil
+}
+
+func (t *SyslogTransport) Ship(ctx context.Context, events []*db.Event) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	conn, err := t.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+
+	for _, event := range events {
+		message, err := t.format(event)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to format audit stream event", "type", event.Type, common.ErrAttr(err))
+			continue
+		}
+
+		if _, err := conn.Write(message); err != nil {
+			// reconnect on the next attempt (already written events will be sent again, i.e. delivery is at-least-once)
+			conn.Close()
+			t.conn = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *SyslogTransport) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.conn == nil {
+		return nil
+	}
+
+	err := t.conn.Close()
+	t.conn = nil
+
+	return err
+}
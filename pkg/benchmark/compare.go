@@ -0,0 +1,90 @@
+package benchmark
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	errNoResults = errors.New("no benchmark results found")
+	// matches "BenchmarkName-8   	  12345	     9876 ns/op ..." lines of `go test -bench` output
+	benchLineRegex = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+([0-9.]+) ns/op`)
+)
+
+type Regression struct {
+	Name     string
+	Baseline float64
+	Current  float64
+}
+
+func (r *Regression) Percent() float64 {
+	if r.Baseline == 0 {
+		return 0
+	}
+
+	return (r.Current - r.Baseline) * 100.0 / r.Baseline
+}
+
+// ParseResults returns average ns/op per benchmark (multiple runs with -count are averaged)
+func ParseResults(r io.Reader) (map[string]float64, error) {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		matches := benchLineRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(matches[2], 64)
+		if err != nil {
+			return nil, err
+		}
+
+		sums[matches[1]] += value
+		counts[matches[1]]++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(sums) == 0 {
+		return nil, errNoResults
+	}
+
+	results := make(map[string]float64, len(sums))
+	for name, sum := range sums {
+		results[name] = sum / float64(counts[name])
+	}
+
+	return results, nil
+}
+
+// Compare returns benchmarks that became slower than baseline by more than thresholdPercent.
+// Benchmarks missing in either of the sets are ignored.
+func Compare(baseline, current map[string]float64, thresholdPercent float64) []*Regression {
+	regressions := make([]*Regression, 0)
+
+	for name, base := range baseline {
+		value, ok := current[name]
+		if !ok || (base <= 0) {
+			continue
+		}
+
+		if value > base*(1.0+thresholdPercent/100.0) {
+			regressions = append(regressions, &Regression{Name: name, Baseline: base, Current: value})
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool { return regressions[i].Name < regressions[j].Name })
+
+	return regressions
+}
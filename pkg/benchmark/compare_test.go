@@ -0,0 +1,60 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleOutput = `goos: linux
+goarch: amd64
+pkg: github.com/PrivateCaptcha/PrivateCaptcha/pkg/benchmark
+BenchmarkVerifyParse-8       	    2000	       300.0 ns/op	     360 B/op	       7 allocs/op
+BenchmarkVerifyParse-8       	    2000	       400.0 ns/op	     360 B/op	       7 allocs/op
+BenchmarkVerifyPipeline      	    2000	      8000 ns/op	    3531 B/op	      81 allocs/op
+PASS
+`
+
+func TestParseResults(t *testing.T) {
+	t.Parallel()
+
+	results, err := ParseResults(strings.NewReader(sampleOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Unexpected results count: %v", len(results))
+	}
+
+	if value := results["BenchmarkVerifyParse"]; value != 350.0 {
+		t.Errorf("Unexpected averaged value: %v", value)
+	}
+
+	if value := results["BenchmarkVerifyPipeline"]; value != 8000.0 {
+		t.Errorf("Unexpected value: %v", value)
+	}
+}
+
+func TestParseEmptyResults(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseResults(strings.NewReader("PASS\n")); err != errNoResults {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	t.Parallel()
+
+	baseline := map[string]float64{"A": 100.0, "B": 100.0, "C": 100.0, "D": 100.0}
+	current := map[string]float64{"A": 105.0, "B": 111.0, "C": 50.0, "E": 1000.0}
+
+	regressions := Compare(baseline, current, 10.0)
+	if len(regressions) != 1 {
+		t.Fatalf("Unexpected regressions count: %v", len(regressions))
+	}
+
+	if regressions[0].Name != "B" {
+		t.Errorf("Unexpected regression: %v", regressions[0].Name)
+	}
+}
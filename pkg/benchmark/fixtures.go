@@ -0,0 +1,162 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/api"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	fixtureOwnerID = 1
+	fixtureOrgID   = 1
+)
+
+type difficultyWeight struct {
+	Level  common.DifficultyLevel
+	Weight int
+}
+
+// roughly what production traffic looks like: most of the puzzles are issued at the base level
+// and only a small fraction of them gets scaled up for suspicious clients
+var DifficultyDistribution = []difficultyWeight{
+	{Level: common.DifficultyLevelSmall, Weight: 60},
+	{Level: common.DifficultyLevelMedium, Weight: 30},
+	{Level: common.DifficultyLevelHigh, Weight: 10},
+}
+
+type ownerSource struct {
+	ownerID int32
+}
+
+var _ puzzle.OwnerIDSource = (*ownerSource)(nil)
+
+func (s *ownerSource) OwnerID(ctx context.Context, tnow time.Time) (int32, *int32, error) {
+	return s.ownerID, nil, nil
+}
+
+// VerifyFixture is a self-contained verify environment that does not need a database
+type VerifyFixture struct {
+	Verifier *api.Verifier
+	Store    *db.BusinessStore
+	Property *dbgen.Property
+	Owner    puzzle.OwnerIDSource
+	// solved payloads, in the same format as sent by the widget
+	Payloads [][]byte
+}
+
+func difficultyForIndex(i int) uint8 {
+	total := 0
+	for _, dw := range DifficultyDistribution {
+		total += dw.Weight
+	}
+
+	bucket := i % total
+	for _, dw := range DifficultyDistribution {
+		if bucket < dw.Weight {
+			return uint8(dw.Level)
+		}
+		bucket -= dw.Weight
+	}
+
+	return uint8(common.DifficultyLevelSmall)
+}
+
+func newFixtureProperty() (*dbgen.Property, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	externalID := pgtype.UUID{Valid: true}
+	if _, err := rand.Read(externalID.Bytes[:]); err != nil {
+		return nil, err
+	}
+
+	return &dbgen.Property{
+		ID:               1,
+		Name:             "benchmark",
+		ExternalID:       externalID,
+		OrgID:            db.Int(fixtureOrgID),
+		CreatorID:        db.Int(fixtureOwnerID),
+		OrgOwnerID:       db.Int(fixtureOwnerID),
+		Domain:           "example.com",
+		Level:            db.Int2(int16(common.DifficultyLevelSmall)),
+		Salt:             salt,
+		Growth:           dbgen.DifficultyGrowthMedium,
+		ValidityInterval: puzzle.DefaultValidityPeriod,
+		// replay protection is exercised (check and record) but should never trigger during the benchmark
+		MaxReplayCount: 1_000_000_000,
+	}, nil
+}
+
+// NewVerifyFixture prepares count solved puzzles with difficulty following DifficultyDistribution.
+// Solving is expensive so this is meant to be called outside of the benchmark timer.
+func NewVerifyFixture(ctx context.Context, count int) (*VerifyFixture, error) {
+	cfg := config.NewBaseConfig(config.NewEnvConfig(func(string) string { return "" }))
+	cfg.Add(config.NewStaticValue(common.APISaltKey, "benchmark-salt"))
+	cfg.Add(config.NewStaticValue(common.UserFingerprintIVKey, "0123456789abcdef0123456789abcdef"))
+
+	store := db.NewBusiness(nil /*pool*/)
+
+	verifier := api.NewVerifier(cfg, store)
+	if err := verifier.Update(ctx); err != nil {
+		return nil, err
+	}
+
+	property, err := newFixtureProperty()
+	if err != nil {
+		return nil, err
+	}
+
+	sitekey := db.UUIDToSiteKey(property.ExternalID)
+	if err := store.Cache.Set(ctx, db.PropertyBySitekeyCacheKey(sitekey), property); err != nil {
+		return nil, err
+	}
+
+	solver := &puzzle.ComputeSolver{}
+	payloads := make([][]byte, 0, count)
+
+	for i := 0; i < count; i++ {
+		p := puzzle.NewComputePuzzle(puzzle.NextPuzzleID(), property.ExternalID.Bytes, difficultyForIndex(i))
+		if err := p.Init(property.ValidityInterval); err != nil {
+			return nil, err
+		}
+
+		pp, err := p.Serialize(ctx, verifier.Salt.Value(), property.Salt)
+		if err != nil {
+			return nil, err
+		}
+
+		solutions, err := solver.Solve(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to solve puzzle %d: %w", i, err)
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(solutions.String())
+		buf.WriteByte('.')
+		if err := pp.Write(&buf); err != nil {
+			return nil, err
+		}
+
+		payloads = append(payloads, buf.Bytes())
+	}
+
+	return &VerifyFixture{
+		Verifier: verifier,
+		Store:    store,
+		Property: property,
+		Owner:    &ownerSource{ownerID: fixtureOwnerID},
+		Payloads: payloads,
+	}, nil
+}
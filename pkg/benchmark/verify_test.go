@@ -0,0 +1,153 @@
+package benchmark
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+const fixturePuzzlesCount = 100
+
+var (
+	benchmarkFixture     *VerifyFixture
+	benchmarkFixtureErr  error
+	benchmarkFixtureOnce sync.Once
+)
+
+// solving puzzles is expensive so fixture is shared between all benchmarks and their runs
+func newBenchmarkFixture(b *testing.B) *VerifyFixture {
+	b.Helper()
+
+	benchmarkFixtureOnce.Do(func() {
+		benchmarkFixture, benchmarkFixtureErr = NewVerifyFixture(context.TODO(), fixturePuzzlesCount)
+	})
+
+	if benchmarkFixtureErr != nil {
+		b.Fatal(benchmarkFixtureErr)
+	}
+
+	return benchmarkFixture
+}
+
+func parsePayloads(b *testing.B, ctx context.Context, fixture *VerifyFixture) []puzzle.SolutionPayload {
+	b.Helper()
+
+	payloads := make([]puzzle.SolutionPayload, 0, len(fixture.Payloads))
+	for _, data := range fixture.Payloads {
+		payload, err := fixture.Verifier.ParseSolutionPayload(ctx, data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		payloads = append(payloads, payload)
+	}
+
+	return payloads
+}
+
+func BenchmarkVerifyParse(b *testing.B) {
+	ctx := context.TODO()
+	fixture := newBenchmarkFixture(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		data := fixture.Payloads[i%len(fixture.Payloads)]
+		if _, err := fixture.Verifier.ParseSolutionPayload(ctx, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifySolutions(b *testing.B) {
+	ctx := context.TODO()
+	fixture := newBenchmarkFixture(b)
+	payloads := parsePayloads(b, ctx, fixture)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, verr := payloads[i%len(payloads)].VerifySolutions(ctx); verr != puzzle.VerifyNoError {
+			b.Fatalf("Unexpected verify result: %v", verr)
+		}
+	}
+}
+
+func BenchmarkVerifyReplayCheck(b *testing.B) {
+	ctx := context.TODO()
+	fixture := newBenchmarkFixture(b)
+	payloads := parsePayloads(b, ctx, fixture)
+	maxCount := uint32(fixture.Property.MaxReplayCount)
+	tnow := time.Now()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p := payloads[i%len(payloads)].Puzzle()
+		if fixture.Store.CheckVerifiedPuzzle(ctx, p, maxCount) {
+			b.Fatal("Puzzle was verified before")
+		}
+		fixture.Store.CacheVerifiedPuzzle(ctx, p, tnow)
+	}
+}
+
+func BenchmarkVerifyPipeline(b *testing.B) {
+	ctx := context.TODO()
+	fixture := newBenchmarkFixture(b)
+	tnow := time.Now()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		payload, err := fixture.Verifier.ParseSolutionPayload(ctx, fixture.Payloads[i%len(fixture.Payloads)])
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		result, err := fixture.Verifier.Verify(ctx, payload, fixture.Owner, tnow)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if result.Error != puzzle.VerifyNoError {
+			b.Fatalf("Unexpected verify result: %v", result.Error)
+		}
+	}
+}
+
+func TestVerifyFixture(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	fixture, err := NewVerifyFixture(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, data := range fixture.Payloads {
+		payload, err := fixture.Verifier.ParseSolutionPayload(ctx, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := difficultyForIndex(i); payload.Puzzle().Difficulty() != expected {
+			t.Errorf("Unexpected difficulty for puzzle %d: %v (expected %v)", i, payload.Puzzle().Difficulty(), expected)
+		}
+
+		result, err := fixture.Verifier.Verify(ctx, payload, fixture.Owner, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Error != puzzle.VerifyNoError {
+			t.Errorf("Unexpected verify result for puzzle %d: %v", i, result.Error)
+		}
+	}
+}
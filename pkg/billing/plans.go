@@ -23,6 +23,7 @@ type basePlan struct {
 	priceMonthly         int
 	priceYearly          int
 	orgsLimit            int
+	includedSeats        int
 	version              int
 	requestsLimit        int64
 	throttleLimit        int64
@@ -46,15 +47,26 @@ func (plan *basePlan) Equals(productID string, priceID string) bool {
 func (p *basePlan) Name() string                  { return p.name }
 func (p *basePlan) ProductID() string             { return p.productID }
 func (p *basePlan) PriceIDs() (string, string)    { return p.priceIDMonthly, p.priceIDYearly }
+func (p *basePlan) Prices() (int, int)            { return p.priceMonthly, p.priceYearly }
 func (p *basePlan) TrialDays() int                { return 14 }
 func (p *basePlan) RequestsLimit() int64          { return p.requestsLimit }
 func (p *basePlan) APIRequestsPerSecond() float64 { return p.apiRequestsPerSecond }
 func (p *basePlan) PropertiesLimit() int          { return 50 }
 func (p *basePlan) OrgsLimit() int                { return p.orgsLimit }
 func (p *basePlan) OrgMembersLimit() int          { return 10 }
+func (p *basePlan) IncludedSeats() int            { return p.includedSeats }
+
+// PuzzleRequestsPerSecond is derived from the monthly throttle limit with a headroom for traffic peaks
+func (p *basePlan) PuzzleRequestsPerSecond() float64 {
+	const secondsPerMonth = 30 * 24 * 60 * 60
+	return max(minPuzzleRequestsPerSecond, float64(p.throttleLimit)*puzzlePeakRatio/secondsPerMonth)
+}
 
 const (
 	version1 = 1
+	// how many times per-property puzzle rate can exceed the average rate allowed by the plan
+	puzzlePeakRatio            = 100
+	minPuzzleRequestsPerSecond = 10
 )
 
 var (
@@ -67,6 +79,8 @@ type Plan interface {
 	Name() string
 	ProductID() string
 	PriceIDs() (string, string)
+	// monthly and yearly prices
+	Prices() (int, int)
 	IsValid() bool
 	Equals(productID string, priceID string) bool
 	TrialDays() int
@@ -74,7 +88,12 @@ type Plan interface {
 	PropertiesLimit() int
 	OrgsLimit() int
 	OrgMembersLimit() int
+	// for per-seat plans, number of seats (members across all owned organizations, including the owner) that are
+	// included in the base price; 0 means the plan is not billed per seat
+	IncludedSeats() int
 	APIRequestsPerSecond() float64
+	// sustained rate of puzzles issued per property
+	PuzzleRequestsPerSecond() float64
 }
 
 type PlanService interface {
@@ -83,8 +102,15 @@ type PlanService interface {
 	ActiveTrialStatus() string
 	ExpiredTrialStatus() string
 	CancelSubscription(ctx context.Context, sid string) error
+	UpdateSubscriptionSeats(ctx context.Context, sid string, seats int) error
+	// ChangeSubscriptionPlan moves external subscription to another price (with proration by the billing provider)
+	ChangeSubscriptionPlan(ctx context.Context, sid string, productID string, priceID string) error
 	GetInternalAdminPlan() Plan
 	GetInternalTrialPlan() Plan
+	// plans that can be granted as internal trial by sales
+	TrialPlans(stage string) []Plan
+	// plans available for self-service subscription changes
+	Plans(stage string) []Plan
 }
 
 type CorePlanService struct {
@@ -147,6 +173,27 @@ func (s *CorePlanService) GetInternalTrialPlan() Plan {
 	return internalTrialPlan
 }
 
+func (s *CorePlanService) TrialPlans(stage string) []Plan {
+	s.Lock.RLock()
+	defer s.Lock.RUnlock()
+
+	plans := make([]Plan, 0, len(s.StagePlans[stage])+1)
+	plans = append(plans, internalTrialPlan)
+	plans = append(plans, s.StagePlans[stage]...)
+
+	return plans
+}
+
+func (s *CorePlanService) Plans(stage string) []Plan {
+	s.Lock.RLock()
+	defer s.Lock.RUnlock()
+
+	plans := make([]Plan, 0, len(s.StagePlans[stage]))
+	plans = append(plans, s.StagePlans[stage]...)
+
+	return plans
+}
+
 func (s *CorePlanService) FindPlan(productID string, priceID string, stage string, internal bool) (Plan, error) {
 	if (stage == "") || (productID == "") || (priceID == "") {
 		return nil, ErrInvalidArgument
@@ -168,6 +215,15 @@ func (s *CorePlanService) FindPlan(productID string, priceID string, stage strin
 		}
 	}
 
+	// internal trials can be granted for regular plans too
+	if internal {
+		for _, p := range s.StagePlans[stage] {
+			if p.Equals(productID, priceID) {
+				return p, nil
+			}
+		}
+	}
+
 	return nil, ErrUnknownProductID
 }
 
@@ -184,6 +240,16 @@ func (s *CorePlanService) CancelSubscription(ctx context.Context, sid string) er
 	return nil
 }
 
+func (s *CorePlanService) UpdateSubscriptionSeats(ctx context.Context, sid string, seats int) error {
+	// BUMP
+	return nil
+}
+
+func (s *CorePlanService) ChangeSubscriptionPlan(ctx context.Context, sid string, productID string, priceID string) error {
+	// BUMP
+	return nil
+}
+
 func (s *CorePlanService) IsSubscriptionActive(status string) bool {
 	switch status {
 	case InternalStatusTrialing:
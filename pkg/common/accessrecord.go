@@ -8,6 +8,10 @@ type AccessRecord struct {
 	OrgID       int32
 	PropertyID  int32
 	Timestamp   time.Time
+	// difficulty experiment arm the puzzle was issued from
+	ExperimentArm ExperimentArm
+	// classification of the end user by differential difficulty
+	VisitorClass VisitorClass
 }
 
 type VerifyRecord struct {
@@ -17,4 +21,22 @@ type VerifyRecord struct {
 	PuzzleID   uint64
 	Timestamp  time.Time
 	Status     int8
+	// server-side verification latency, 0 if not measured
+	DurationUs uint32
+	// difficulty experiment arm the puzzle was issued from
+	ExperimentArm ExperimentArm
+	// classification of the end user by differential difficulty
+	VisitorClass VisitorClass
+	// ID of the request that verified the puzzle
+	TraceID string
+	// result of checking the widget integrity beacon
+	Integrity IntegrityStatus
+}
+
+// QuotaRecord is a puzzle request that was rejected by the issuance quota of the property
+type QuotaRecord struct {
+	UserID     int32
+	OrgID      int32
+	PropertyID int32
+	Timestamp  time.Time
 }
@@ -50,6 +50,8 @@ const (
 	AuditLogSourceUnknown AuditLogSource = iota
 	AuditLogSourcePortal
 	AuditLogSourceAPI
+	AuditLogSourceCLI
+	AuditLogSourceSystem
 	// Add new fields _above_
 	AUDIT_LOG_SOURCES_COUNT
 )
@@ -62,6 +64,10 @@ func (als AuditLogSource) String() string {
 		return "portal"
 	case AuditLogSourceAPI:
 		return "api"
+	case AuditLogSourceCLI:
+		return "cli"
+	case AuditLogSourceSystem:
+		return "system"
 	default:
 		return strconv.Itoa(int(als))
 	}
@@ -77,6 +83,7 @@ type AuditLogEvent struct {
 	EntityID  int64
 	TableName string
 	SessionID string
+	TraceID   string
 	OldValue  interface{}
 	NewValue  interface{}
 	Timestamp time.Time
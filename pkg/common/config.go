@@ -45,6 +45,55 @@ const (
 	CountryCodeHeaderKey
 	EnterpriseAuditLogDaysKey
 	ClickHouseOptionalKey
+	ShadowVerifyPercentKey
+	LoadShedMaxInflightKey
+	LoadShedLatencyKey
+	TLSTicketRotationKey
+	TLSCurvesKey
+	ProfilingEnabledKey
+	ProfilingUploadURLKey
+	AlertSigmaKey
+	RedisAddressKey
+	RedisPasswordKey
+	RedisDBKey
+	VerifyRegionsKey
+	WidgetIntegrityHashesKey
+	APIKeyUsageAuditKey
+	ASNHeaderKey
+	VerifyRawRetentionDaysKey
+	VerifyHourlyRetentionDaysKey
+	VerifyDailyRetentionDaysKey
+	FootprintKey
+	TimeSeriesBackendKey
+	SSOIssuerKey
+	SSOClientIDKey
+	SSOClientSecretKey
+	SSODefaultOrgKey
+	SSOProvisioningKey
+	StandbyModeKey
+	AttestationKeysKey
+	AuditStreamURLKey
+	AuditStreamSecretKey
+	PrivacyModeKey
+	IPReputationFeedsKey
+	IPReputationDifficultyKey
+	SmtpEndpointEUKey
+	SmtpUsernameEUKey
+	SmtpPasswordEUKey
+	AssetsS3EndpointKey
+	AssetsS3BucketKey
+	AssetsS3RegionKey
+	AssetsS3AccessKeyKey
+	AssetsS3SecretKeyKey
+	AssetsHashedNamesKey
+	EmailProvidersKey
+	SESRegionKey
+	SESAccessKeyKey
+	SESSecretKeyKey
+	MailgunDomainKey
+	MailgunAPIKeyKey
+	MailgunEndpointKey
+	HotPropertiesKey
 	// Add new fields _above_
 	COMMON_CONFIG_KEYS_COUNT
 )
@@ -3,57 +3,104 @@ package common
 import "net/http"
 
 const (
-	DefaultOrgName        = "My Organization"
-	PrivateCaptcha        = "Private Captcha"
-	PrivateCaptchaTeam    = "Private Captcha Team"
-	StageDev              = "dev"
-	StageStaging          = "staging"
-	StageTest             = "test"
-	ContentTypePlain      = "text/plain"
-	ContentTypeHTML       = "text/html; charset=utf-8"
-	ContentTypeJSON       = "application/json"
-	ContentTypeURLEncoded = "application/x-www-form-urlencoded"
-	ContentTypeCSV        = "text/csv"
-	ParamSiteKey          = "sitekey"
-	ParamSecret           = "secret"
-	ParamResponse         = "response"
-	ParamEmail            = "email"
-	ParamName             = "name"
-	ParamCSRFToken        = "csrf_token"
-	ParamVerificationCode = "vcode"
-	ParamDomain           = "domain"
-	ParamDifficulty       = "difficulty"
-	ParamGrowth           = "growth"
-	ParamTab              = "tab"
-	ParamNew              = "new"
-	ParamDays             = "days"
-	ParamOrg              = "org"
-	ParamUser             = "user"
-	ParamPeriod           = "period"
-	ParamProperty         = "property"
-	ParamKey              = "key"
-	ParamCode             = "code"
-	ParamID               = "id"
-	ParamValidityInterval = "validity_interval"
-	ParamAllowSubdomains  = "allow_subdomains"
-	ParamAllowLocalhost   = "allow_localhost"
-	ParamAllowReplay      = "allow_replay"
-	ParamIgnoreError      = "ignore_error"
-	ParamLicenseKey       = "lid"
-	ParamHardwareID       = "hwid"
-	ParamVersion          = "version"
-	ParamPortalSolution   = "pc_portal_solution"
-	ParamTerms            = "terms"
-	ParamMaxReplayCount   = "max_replay_count"
-	ParamPage             = "page"
-	ParamPerPage          = "per_page"
-	ParamScope            = "scope"
-	All                   = "all"
+	DefaultOrgName         = "My Organization"
+	PrivateCaptcha         = "Private Captcha"
+	PrivateCaptchaTeam     = "Private Captcha Team"
+	StageDev               = "dev"
+	StageStaging           = "staging"
+	StageTest              = "test"
+	ContentTypePlain       = "text/plain"
+	ContentTypeHTML        = "text/html; charset=utf-8"
+	ContentTypeJSON        = "application/json"
+	ContentTypeURLEncoded  = "application/x-www-form-urlencoded"
+	ContentTypeCSV         = "text/csv"
+	ContentTypeEventStream = "text/event-stream"
+	ContentTypeZip         = "application/zip"
+	ContentTypeTurnstile   = "application/vnd.turnstile+json"
+	ContentTypePNG         = "image/png"
+	ParamSiteKey           = "sitekey"
+	ParamSecret            = "secret"
+	ParamResponse          = "response"
+	ParamEmail             = "email"
+	ParamName              = "name"
+	ParamCSRFToken         = "csrf_token"
+	ParamVerificationCode  = "vcode"
+	ParamDomain            = "domain"
+	ParamDifficulty        = "difficulty"
+	ParamGrowth            = "growth"
+	ParamTab               = "tab"
+	ParamNew               = "new"
+	ParamDays              = "days"
+	ParamOrg               = "org"
+	ParamUser              = "user"
+	ParamPeriod            = "period"
+	ParamProperty          = "property"
+	ParamKey               = "key"
+	ParamCode              = "code"
+	ParamID                = "id"
+	ParamValidityInterval  = "validity_interval"
+	ParamAllowSubdomains   = "allow_subdomains"
+	ParamAllowLocalhost    = "allow_localhost"
+	ParamAllowReplay       = "allow_replay"
+	ParamImageChallenge    = "image_challenge"
+	ParamIgnoreError       = "ignore_error"
+	ParamLicenseKey        = "lid"
+	ParamHardwareID        = "hwid"
+	ParamVersion           = "version"
+	ParamPortalSolution    = "pc_portal_solution"
+	ParamTerms             = "terms"
+	ParamMaxReplayCount    = "max_replay_count"
+	ParamPage              = "page"
+	ParamPerPage           = "per_page"
+	ParamCursor            = "cursor"
+	ParamLimit             = "limit"
+	ParamIncludeTotal      = "include_total"
+	ParamScope             = "scope"
+	ParamOverlap           = "overlap"
+	ParamFile              = "file"
+	ParamURL               = "url"
+	ParamHandoff           = "pc-handoff"
+	ParamTemplate          = "template"
+	ParamNotifyOwner       = "notify_owner"
+	ParamMonth             = "month"
+	ParamAllowlist         = "allowlist"
+	ParamToken             = "token"
+	ParamMessage           = "message"
+	ParamSeverity          = "severity"
+	ParamMarkdown          = "markdown"
+	ParamStart             = "start"
+	ParamEnd               = "end"
+	ParamStage             = "stage"
+	ParamProduct           = "product"
+	ParamFormat            = "format"
+	ParamPassphrase        = "passphrase"
+	ParamFailureURL        = "failure_url"
+	ParamFailureMessage    = "failure_message"
+	ParamDuration          = "duration"
+	ParamTraceID           = "trace_id"
+	ParamMaxUses           = "max_uses"
+	ParamRole              = "role"
+	ParamSearch            = "search"
+	ParamSort              = "sort"
+	ParamOrder             = "order"
+	ParamIPAllowlist       = "ip_allowlist"
+	ParamIPDenylist        = "ip_denylist"
+	ParamASNDenylist       = "asn_denylist"
+	ParamPolicy            = "policy"
+	ParamAutoJoin          = "auto_join"
+	ParamDigest            = "digest"
+	ParamPrivacyMode       = "privacy_mode"
+	ParamDomains           = "domains"
+	ParamPrice             = "price"
+	ParamConfirm           = "confirm"
+	ParamSince             = "since"
+	All                    = "all"
 )
 
 var (
 	HeaderCDNTag              = http.CanonicalHeaderKey("CDN-Tag")
 	HeaderContentType         = http.CanonicalHeaderKey("Content-Type")
+	HeaderAccept              = http.CanonicalHeaderKey("Accept")
 	HeaderContentLength       = http.CanonicalHeaderKey("Content-Length")
 	HeaderAuthorization       = http.CanonicalHeaderKey("Authorization")
 	HeaderCSRFToken           = http.CanonicalHeaderKey("X-CSRF-Token")
@@ -66,5 +113,16 @@ var (
 	HeaderETag                = http.CanonicalHeaderKey("ETag")
 	HeaderIfNoneMatch         = http.CanonicalHeaderKey("If-None-Match")
 	HeaderSitekey             = http.CanonicalHeaderKey("X-PC-Sitekey")
+	HeaderCaptchaRemember     = http.CanonicalHeaderKey("X-PC-Remember")
+	HeaderCaptchaSticky       = http.CanonicalHeaderKey("X-PC-Sticky")
+	HeaderHandoffToken        = http.CanonicalHeaderKey("X-PC-Handoff-Token")
+	HeaderAttestation         = http.CanonicalHeaderKey("X-PC-Attestation")
+	HeaderVerifyRegion        = http.CanonicalHeaderKey("X-PC-Verify-Region")
 	HeaderCacheControl        = http.CanonicalHeaderKey("Cache-Control")
+	HeaderAcceptLanguage      = http.CanonicalHeaderKey("Accept-Language")
+	HeaderSecCHUA             = http.CanonicalHeaderKey("Sec-CH-UA")
+	HeaderSecCHUAMobile       = http.CanonicalHeaderKey("Sec-CH-UA-Mobile")
+	HeaderSecCHUAPlatform     = http.CanonicalHeaderKey("Sec-CH-UA-Platform")
+	HeaderSecFetchMode        = http.CanonicalHeaderKey("Sec-Fetch-Mode")
+	HeaderRetryAfter          = http.CanonicalHeaderKey("Retry-After")
 )
@@ -14,6 +14,8 @@ const (
 	SessionIDContextKey
 	ServiceContextKey
 	TimeContextKey
+	// set for requests that are replayed to the shadow handler
+	ShadowContextKey
 	// Add new fields _above_
 	CONTEXT_KEYS_COUNT
 )
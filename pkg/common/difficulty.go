@@ -10,3 +10,49 @@ const (
 	DifficultyLevelHigh   DifficultyLevel = DifficultyLevelMedium + DifficultyDelta
 	MaxDifficultyLevel    DifficultyLevel = 255
 )
+
+// ExperimentArm is the branch of a difficulty experiment that the puzzle was issued from
+type ExperimentArm uint8
+
+const (
+	ExperimentArmNone    ExperimentArm = 0
+	ExperimentArmControl ExperimentArm = 1
+	ExperimentArmVariant ExperimentArm = 2
+)
+
+func (ea ExperimentArm) String() string {
+	switch ea {
+	case ExperimentArmControl:
+		return "control"
+	case ExperimentArmVariant:
+		return "variant"
+	default:
+		return "none"
+	}
+}
+
+// VisitorClass is the classification of the end user by differential difficulty of the property
+type VisitorClass uint8
+
+const (
+	VisitorClassNone VisitorClass = 0
+	// end user presented a valid remember proof (recently solved a puzzle)
+	VisitorClassReturning VisitorClass = 1
+	// end user without remember proof
+	VisitorClassFirstSeen VisitorClass = 2
+	// end user did not solve a puzzle, but presented a bypass token of the property (trusted automation)
+	VisitorClassBypass VisitorClass = 3
+)
+
+func (vc VisitorClass) String() string {
+	switch vc {
+	case VisitorClassReturning:
+		return "returning"
+	case VisitorClassFirstSeen:
+		return "first_seen"
+	case VisitorClassBypass:
+		return "bypass"
+	default:
+		return "none"
+	}
+}
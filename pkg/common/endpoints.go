@@ -4,6 +4,7 @@ const (
 	PuzzleEndpoint        = "puzzle"
 	EchoPuzzleEndpoint    = "echopuzzle"
 	SiteVerifyEndpoint    = "siteverify"
+	TurnstileEndpoint     = "turnstile/v0"
 	VerifyEndpoint        = "verify"
 	LoginEndpoint         = "login"
 	TwoFactorEndpoint     = "2fa"
@@ -33,12 +34,51 @@ const (
 	UsageEndpoint         = "usage"
 	ReadyEndpoint         = "ready"
 	LiveEndpoint          = "live"
+	SchemaEndpoint        = "schema"
 	MoveEndpoint          = "move"
 	NotificationEndpoint  = "notification"
+	NotificationsEndpoint = "notifications"
 	SelfHostedEndpoint    = "selfhosted"
 	ActivationEndpoint    = "activation"
 	AuditLogsEndpoint     = "auditlogs"
 	EventsEndpoint        = "events"
 	ExportEndpoint        = "export"
+	ImportEndpoint        = "import"
+	LimitsEndpoint        = "limits"
+	TraceEndpoint         = "trace"
+	CatalogEndpoint       = "catalog"
+	RegionsEndpoint       = "regions"
+	HandoffEndpoint       = "handoff"
+	PromoteEndpoint       = "promote"
+	CloneEndpoint         = "clone"
+	EmergencyEndpoint     = "emergency"
 	AsyncTaskEndpoint     = "asynctask"
+	ReportEndpoint        = "report"
+	BatchEndpoint         = "batch"
+	ExperimentEndpoint    = "experiment"
+	BillingEndpoint       = "billing"
+	AllowlistEndpoint     = "allowlist"
+	AccessListEndpoint    = "accesslist"
+	RecoverEndpoint       = "recover"
+	AdminEndpoint         = "admin"
+	AnnouncementsEndpoint = "announcements"
+	TrialsEndpoint        = "trials"
+	ShareEndpoint         = "share"
+	DomainsEndpoint       = "domains"
+	SSOEndpoint           = "sso"
+	CallbackEndpoint      = "callback"
+	LinkEndpoint          = "link"
+	ImageEndpoint         = "image"
+	AttestationEndpoint   = "attestation"
+	KeysEndpoint          = "keys"
+	QuotaEndpoint         = "quota"
+	SandboxEndpoint       = "sandbox"
+	PrivacyEndpoint       = "privacy"
+	BypassEndpoint        = "bypass"
+	JobsEndpoint          = "jobs"
+	StatusEndpoint        = "status"
+	DowngradeEndpoint     = "downgrade"
+	StatusCodesEndpoint   = "status-codes"
+	SessionsEndpoint      = "sessions"
+	ChangelogEndpoint     = "changelog"
 )
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	FootprintDefault = "default"
+	// targets instances with 512MB-1GB of RAM (e.g. small ARM64 VMs)
+	FootprintSmall = "small"
+
+	smallFootprintDivisor = 20
+	minFootprintSize      = 10
+)
+
+type ComponentUsage struct {
+	Name     string
+	Entries  int
+	Capacity int
+}
+
+type footprintComponent struct {
+	name     string
+	capacity int
+	entries  func() int
+}
+
+// Footprint scales in-memory capacities (caches, rate limiter buckets, batches) coherently for the profile and keeps
+// track of the sized components to report their actual usage. Nil Footprint is the default profile without tracking.
+type Footprint struct {
+	Name       string
+	divisor    int
+	lock       sync.Mutex
+	components []*footprintComponent
+}
+
+func NewFootprint(name string) *Footprint {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case FootprintSmall:
+		return &Footprint{Name: FootprintSmall, divisor: smallFootprintDivisor}
+	default:
+		return &Footprint{Name: FootprintDefault, divisor: 1}
+	}
+}
+
+// Size scales default size n for the profile, but never below a small minimum (or n, if it is smaller)
+func (f *Footprint) Size(n int) int {
+	if (f == nil) || (f.divisor <= 1) {
+		return n
+	}
+
+	return max(n/f.divisor, min(n, minFootprintSize))
+}
+
+// Track registers component of the given (already scaled) capacity with a function that returns number of its entries
+func (f *Footprint) Track(name string, capacity int, entries func() int) {
+	if (f == nil) || (entries == nil) {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.components = append(f.components, &footprintComponent{name: name, capacity: capacity, entries: entries})
+}
+
+func (f *Footprint) Usage() []*ComponentUsage {
+	if f == nil {
+		return nil
+	}
+
+	f.lock.Lock()
+	components := slices.Clone(f.components)
+	f.lock.Unlock()
+
+	result := make([]*ComponentUsage, 0, len(components))
+	for _, c := range components {
+		result = append(result, &ComponentUsage{Name: c.name, Entries: c.entries(), Capacity: c.capacity})
+	}
+
+	return result
+}
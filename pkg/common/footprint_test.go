@@ -0,0 +1,59 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestFootprintSize(t *testing.T) {
+	var nilFootprint *Footprint
+	if actual := nilFootprint.Size(1_000_000); actual != 1_000_000 {
+		t.Errorf("Unexpected nil footprint size: %v", actual)
+	}
+
+	if actual := NewFootprint("").Size(1_000_000); actual != 1_000_000 {
+		t.Errorf("Unexpected default footprint size: %v", actual)
+	}
+
+	small := NewFootprint(" Small ")
+	if small.Name != FootprintSmall {
+		t.Fatalf("Unexpected footprint: %v", small.Name)
+	}
+
+	testCases := []struct {
+		size     int
+		expected int
+	}{
+		{1_000_000, 50_000},
+		{100, 10},
+		{50, 10},
+		{5, 5},
+	}
+
+	for _, tc := range testCases {
+		if actual := small.Size(tc.size); actual != tc.expected {
+			t.Errorf("Unexpected small footprint size for %v: %v (expected %v)", tc.size, actual, tc.expected)
+		}
+	}
+}
+
+func TestFootprintUsage(t *testing.T) {
+	var nilFootprint *Footprint
+	nilFootprint.Track("test", 10, func() int { return 1 })
+	if usage := nilFootprint.Usage(); len(usage) != 0 {
+		t.Errorf("Nil footprint reported usage")
+	}
+
+	footprint := NewFootprint(FootprintSmall)
+	entries := 3
+	footprint.Track("test", 10, func() int { return entries })
+	entries = 5
+
+	usage := footprint.Usage()
+	if len(usage) != 1 {
+		t.Fatalf("Unexpected usage count: %v", len(usage))
+	}
+
+	if (usage[0].Name != "test") || (usage[0].Entries != 5) || (usage[0].Capacity != 10) {
+		t.Errorf("Unexpected usage: %+v", usage[0])
+	}
+}
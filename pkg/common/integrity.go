@@ -0,0 +1,27 @@
+package common
+
+// IntegrityStatus is the result of checking the integrity beacon, sent by the widget with solutions
+type IntegrityStatus uint8
+
+const (
+	// beacon is missing (older widget) or expected script hashes are not known
+	IntegrityUnknown IntegrityStatus = 0
+	IntegrityOK      IntegrityStatus = 1
+	// script hash does not match published widget
+	IntegrityTampered IntegrityStatus = 2
+	// native functions were wrapped (e.g. by monitoring tools or polyfills), which is only a soft signal
+	IntegrityPatched IntegrityStatus = 3
+)
+
+func (is IntegrityStatus) String() string {
+	switch is {
+	case IntegrityOK:
+		return "ok"
+	case IntegrityTampered:
+		return "tampered"
+	case IntegrityPatched:
+		return "patched"
+	default:
+		return "unknown"
+	}
+}
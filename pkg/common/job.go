@@ -31,6 +31,20 @@ type PeriodicJob interface {
 	Trigger() <-chan struct{}
 }
 
+// ScheduledJob can be optionally implemented by periodic jobs (or their wrappers) to know when the next run is due
+type ScheduledJob interface {
+	Scheduled(at time.Time)
+}
+
+// JobDurationBuckets are upper bounds (in seconds) of the maintenance job duration histogram
+var JobDurationBuckets = []float64{0.1, 1, 10, 60, 300, 1800}
+
+func NotifyJobScheduled(j PeriodicJob, at time.Time) {
+	if sj, ok := j.(ScheduledJob); ok {
+		sj.Scheduled(at)
+	}
+}
+
 type StubOneOffJob struct{}
 
 var _ OneOffJob = (*StubOneOffJob)(nil)
@@ -97,6 +111,7 @@ func RunPeriodicJob(ctx context.Context, j PeriodicJob) {
 
 		delay := interval + time.Duration(randv2.Int64N(int64(jitter)))
 		timer := time.NewTimer(delay)
+		NotifyJobScheduled(j, time.Now().Add(delay))
 
 		var runJob bool
 
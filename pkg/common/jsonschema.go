@@ -0,0 +1,154 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+)
+
+var (
+	jsonTimeType = reflect.TypeFor[JSONTime]()
+)
+
+// JSONSchema is the subset of JSON Schema that is needed to describe payloads produced from Go types
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	// only used for maps
+	AdditionalProperties *JSONSchema `json:"additionalProperties,omitempty"`
+}
+
+// NewJSONSchema describes how encoding/json serializes values of the type t
+func NewJSONSchema(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == jsonTimeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: NewJSONSchema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: NewJSONSchema(t.Elem())}
+	case reflect.Struct:
+		s := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+		addStructProperties(s, t)
+		return s
+	default:
+		// interfaces can hold anything
+		return &JSONSchema{}
+	}
+}
+
+func addStructProperties(s *JSONSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && (len(name) == 0) {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructProperties(s, ft)
+				continue
+			}
+		}
+
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		s.Properties[name] = NewJSONSchema(field.Type)
+
+		if !slices.Contains(strings.Split(opts, ","), "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// BreakingChanges lists changes in next schema that can make payloads invalid for consumers validating them with
+// the current one: removed or retyped properties, optional (previously required) properties and new enum values
+func (s *JSONSchema) BreakingChanges(path string, next *JSONSchema) []string {
+	if next == nil {
+		return []string{fmt.Sprintf("%s: removed", path)}
+	}
+
+	var changes []string
+
+	if (s.Type != next.Type) || (s.Format != next.Format) {
+		changes = append(changes, fmt.Sprintf("%s: type changed from %q to %q", path, s.Type+s.Format, next.Type+next.Format))
+		return changes
+	}
+
+	if len(s.Enum) > 0 {
+		for _, value := range next.Enum {
+			if !slices.Contains(s.Enum, value) {
+				changes = append(changes, fmt.Sprintf("%s: new enum value %q", path, value))
+			}
+		}
+	}
+
+	for _, name := range s.Required {
+		if !slices.Contains(next.Required, name) {
+			changes = append(changes, fmt.Sprintf("%s.%s: no longer required", path, name))
+		}
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		changes = append(changes, s.Properties[name].BreakingChanges(path+"."+name, next.Properties[name])...)
+	}
+
+	if s.Items != nil {
+		changes = append(changes, s.Items.BreakingChanges(path+"[]", next.Items)...)
+	}
+
+	if s.AdditionalProperties != nil {
+		changes = append(changes, s.AdditionalProperties.BreakingChanges(path+"{}", next.AdditionalProperties)...)
+	}
+
+	return changes
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type jsonSchemaTestV1 struct {
+	Name    string   `json:"name"`
+	Count   int      `json:"count,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Created JSONTime `json:"created"`
+}
+
+type jsonSchemaTestV2 struct {
+	Name    string   `json:"name,omitempty"`
+	Count   string   `json:"count,omitempty"`
+	Created JSONTime `json:"created"`
+	Extra   bool     `json:"extra"`
+}
+
+func TestJSONSchemaBreakingChanges(t *testing.T) {
+	t.Parallel()
+
+	v1 := NewJSONSchema(reflect.TypeFor[jsonSchemaTestV1]())
+
+	if f := v1.Properties["created"].Format; f != "date-time" {
+		t.Errorf("Unexpected time format: %v", f)
+	}
+
+	if changes := v1.BreakingChanges("v1", NewJSONSchema(reflect.TypeFor[jsonSchemaTestV1]())); len(changes) > 0 {
+		t.Errorf("Unexpected changes for the same schema: %v", changes)
+	}
+
+	v2 := NewJSONSchema(reflect.TypeFor[jsonSchemaTestV2]())
+	changes := v1.BreakingChanges("v1", v2)
+	expected := []string{
+		"v1.name: no longer required",
+		`v1.count: type changed from "integer" to "string"`,
+		"v1.tags: removed",
+	}
+
+	if !slices.Equal(changes, expected) {
+		t.Errorf("Unexpected changes: %q", changes)
+	}
+
+	// new properties are not breaking
+	if changes := v2.BreakingChanges("v2", v2); len(changes) > 0 {
+		t.Errorf("Unexpected changes: %v", changes)
+	}
+}
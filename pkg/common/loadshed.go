@@ -0,0 +1,146 @@
+package common
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+type RequestPriority int
+
+const (
+	// critical requests (puzzle, siteverify) are never shed
+	PriorityCritical RequestPriority = iota
+	PriorityNormal
+	PriorityLow
+)
+
+const (
+	DefaultLoadShedMaxInflight = 1000
+	DefaultLoadShedLatency     = 500 * time.Millisecond
+	// low priority requests start to be shed earlier than normal ones
+	loadShedLowPriorityFactor = 0.75
+	// weight of the latest observation in the latency moving average
+	loadShedLatencyAlpha     = 0.1
+	loadShedRetryAfterLow    = 10
+	loadShedRetryAfterNormal = 2
+)
+
+func (p RequestPriority) String() string {
+	switch p {
+	case PriorityCritical:
+		return "critical"
+	case PriorityNormal:
+		return "normal"
+	case PriorityLow:
+		return "low"
+	default:
+		return "unknown"
+	}
+}
+
+type LoadShedMetrics interface {
+	ObserveRequestShed(service string, priority string)
+}
+
+// LoadShedder tracks in-flight requests and their latency across all services and rejects
+// lower priority requests first when the server is overloaded
+type LoadShedder struct {
+	metrics     LoadShedMetrics
+	inflight    atomic.Int64
+	maxInflight atomic.Int64
+	// nanoseconds
+	latencyTarget atomic.Int64
+	latencyAvg    atomic.Int64
+}
+
+func NewLoadShedder(metrics LoadShedMetrics) *LoadShedder {
+	ls := &LoadShedder{metrics: metrics}
+	ls.UpdateLimits(DefaultLoadShedMaxInflight, DefaultLoadShedLatency)
+	return ls
+}
+
+// UpdateLimits sets limits for shedding. Non-positive value disables the respective check.
+func (ls *LoadShedder) UpdateLimits(maxInflight int, latencyTarget time.Duration) {
+	ls.maxInflight.Store(int64(maxInflight))
+	ls.latencyTarget.Store(int64(latencyTarget))
+}
+
+// load returns current utilization where 1.0 means the server is at capacity
+func (ls *LoadShedder) load() float64 {
+	load := 0.0
+
+	if maxInflight := ls.maxInflight.Load(); maxInflight > 0 {
+		load = float64(ls.inflight.Load()) / float64(maxInflight)
+	}
+
+	if target := ls.latencyTarget.Load(); target > 0 {
+		load = math.Max(load, float64(ls.latencyAvg.Load())/float64(target))
+	}
+
+	return load
+}
+
+func (ls *LoadShedder) shouldShed(priority RequestPriority) bool {
+	switch priority {
+	case PriorityCritical:
+		return false
+	case PriorityLow:
+		return ls.load() >= loadShedLowPriorityFactor
+	default:
+		return ls.load() >= 1.0
+	}
+}
+
+func (ls *LoadShedder) observeLatency(d time.Duration) {
+	for {
+		old := ls.latencyAvg.Load()
+		next := int64(float64(old)*(1.0-loadShedLatencyAlpha) + float64(d)*loadShedLatencyAlpha)
+		if ls.latencyAvg.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (ls *LoadShedder) Middleware(priority RequestPriority) func(http.Handler) http.Handler {
+	if ls == nil {
+		return NoopMiddleware
+	}
+
+	retryAfter := []string{strconv.Itoa(loadShedRetryAfterNormal)}
+	if priority == PriorityLow {
+		retryAfter = []string{strconv.Itoa(loadShedRetryAfterLow)}
+	}
+	priorityLabel := priority.String()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			if ls.shouldShed(priority) {
+				service, _ := ctx.Value(ServiceContextKey).(string)
+				slog.Log(ctx, LevelTrace, "Shedding request", "priority", priorityLabel, "path", r.URL.Path)
+				if ls.metrics != nil {
+					ls.metrics.ObserveRequestShed(service, priorityLabel)
+				}
+				// shed requests count as instant so that average latency recovers without probing traffic
+				ls.observeLatency(0)
+				w.Header()[HeaderRetryAfter] = retryAfter
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+
+			ls.inflight.Add(1)
+			t := time.Now()
+			defer func() {
+				ls.inflight.Add(-1)
+				ls.observeLatency(time.Since(t))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
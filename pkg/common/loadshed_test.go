@@ -0,0 +1,104 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type shedCounter struct {
+	mux    sync.Mutex
+	counts map[string]int
+}
+
+func (c *shedCounter) ObserveRequestShed(service string, priority string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.counts[priority]++
+}
+
+func TestLoadShedderPriorities(t *testing.T) {
+	metrics := &shedCounter{counts: make(map[string]int)}
+	ls := NewLoadShedder(metrics)
+	ls.UpdateLimits(4, 0 /*latency check disabled*/)
+
+	release := make(chan struct{})
+	blocking := ls.Middleware(PriorityCritical)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	blocked := 0
+	block := func(count int) {
+		blocked += count
+		for range count {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				blocking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/puzzle", nil))
+			}()
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for ls.inflight.Load() < int64(blocked) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(priority RequestPriority) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ls.Middleware(priority)(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	block(3)
+
+	if w := serve(PriorityLow); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected low priority status: %v", w.Code)
+	} else if len(w.Header().Get("Retry-After")) == 0 {
+		t.Error("Retry-After header is missing")
+	}
+
+	if w := serve(PriorityNormal); w.Code != http.StatusOK {
+		t.Errorf("Unexpected normal priority status: %v", w.Code)
+	}
+
+	block(1)
+
+	if w := serve(PriorityNormal); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected normal priority status under overload: %v", w.Code)
+	}
+
+	if w := serve(PriorityCritical); w.Code != http.StatusOK {
+		t.Errorf("Unexpected critical priority status under overload: %v", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if w := serve(PriorityLow); w.Code != http.StatusOK {
+		t.Errorf("Unexpected low priority status after load is gone: %v", w.Code)
+	}
+
+	if metrics.counts[PriorityLow.String()] != 1 || metrics.counts[PriorityNormal.String()] != 1 {
+		t.Errorf("Unexpected shed counters: %v", metrics.counts)
+	}
+}
+
+func TestNilLoadShedder(t *testing.T) {
+	var ls *LoadShedder
+	h := ls.Middleware(PriorityLow)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Unexpected status: %v", w.Code)
+	}
+}
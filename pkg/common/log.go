@@ -19,6 +19,7 @@ type contextHandler struct {
 func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
 	if ctx != nil {
 		if tid, ok := ctx.Value(TraceIDContextKey).(string); ok && (len(tid) > 0) {
+			recentTraceLogs.add(tid, &r)
 			r.AddAttrs(TraceIDAttr(tid))
 		}
 
@@ -63,6 +64,14 @@ func TraceContext(ctx context.Context, traceID string) context.Context {
 	return ctx
 }
 
+func TraceID(ctx context.Context) string {
+	if tid, ok := ctx.Value(TraceIDContextKey).(string); ok {
+		return tid
+	}
+
+	return ""
+}
+
 func CopyTraceID(from context.Context, to context.Context) context.Context {
 	if tid, ok := from.Value(TraceIDContextKey).(string); ok && (len(tid) > 0) {
 		return context.WithValue(to, TraceIDContextKey, tid)
@@ -16,6 +16,8 @@ type Mailer interface {
 	SendTwoFactor(ctx context.Context, email string, code int, ua string, location string) error
 	SendWelcome(ctx context.Context, email, name string) error
 	SendOrgInvite(ctx context.Context, email, name string, orgName, orgOwnerEmail, orgOwnerName, orgURL string) error
+	SendIPAllowlistRecovery(ctx context.Context, email, orgName, clientIP, recoveryURL string) error
+	SendNewSignIn(ctx context.Context, email string, ua string, location string) error
 }
 
 type NotificationCondition int
@@ -27,8 +29,10 @@ const (
 )
 
 type ScheduledNotification struct {
-	ReferenceID  string
-	UserID       int32
+	ReferenceID string
+	UserID      int32
+	// organization the notification is about (zero if none), billing contacts of it receive billing notifications
+	OrgID        int32
 	Subject      string
 	Data         interface{}
 	DateTime     time.Time
@@ -37,6 +41,12 @@ type ScheduledNotification struct {
 	Condition    NotificationCondition
 }
 
+// BillingRecipients are extra addresses (besides the owner of organization) that receive billing notifications
+type BillingRecipients struct {
+	Contacts    []string
+	NotifyOwner bool
+}
+
 func NewEmailTemplate(name, contentHTML, contentText string) *EmailTemplate {
 	return &EmailTemplate{
 		name:        name,
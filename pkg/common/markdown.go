@@ -0,0 +1,64 @@
+package common
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownLinkRegex   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s()]+)\)`)
+	markdownBoldRegex   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	markdownItalicRegex = regexp.MustCompile(`\*([^*]+)\*`)
+)
+
+func renderMarkdownInline(text string) string {
+	var sb strings.Builder
+
+	// odd parts are inside `code` spans and are not formatted
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		escaped := html.EscapeString(part)
+
+		if (i%2 == 1) && (i < len(parts)-1) {
+			sb.WriteString("<code>")
+			sb.WriteString(escaped)
+			sb.WriteString("</code>")
+			continue
+		}
+
+		if i%2 == 1 {
+			// unmatched backtick
+			sb.WriteString("`")
+		}
+
+		escaped = markdownLinkRegex.ReplaceAllString(escaped, `<a href="$2" target="_blank" rel="noopener noreferrer" class="underline">$1</a>`)
+		escaped = markdownBoldRegex.ReplaceAllString(escaped, "<strong>$1</strong>")
+		escaped = markdownItalicRegex.ReplaceAllString(escaped, "<em>$1</em>")
+		sb.WriteString(escaped)
+	}
+
+	return sb.String()
+}
+
+// RenderMarkdown converts a small subset of markdown (paragraphs, line breaks, **bold**, *italic*, `code`
+// and [links](https://...)) to HTML that is safe to embed inline. Everything else is escaped.
+func RenderMarkdown(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n")
+
+	paragraphs := make([]string, 0)
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.TrimSpace(p); len(p) == 0 {
+			continue
+		}
+
+		lines := strings.Split(p, "\n")
+		for i, line := range lines {
+			lines[i] = renderMarkdownInline(strings.TrimSpace(line))
+		}
+
+		paragraphs = append(paragraphs, strings.Join(lines, "<br />"))
+	}
+
+	return strings.Join(paragraphs, "<br /><br />")
+}
@@ -0,0 +1,29 @@
+package common
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"**bold** and *italic*", "<strong>bold</strong> and <em>italic</em>"},
+		{"run `rm -rf *` now", "run <code>rm -rf *</code> now"},
+		{"unmatched ` tick", "unmatched ` tick"},
+		{"see [docs](https://docs.example.com/a?b=1&c=2)", `see <a href="https://docs.example.com/a?b=1&amp;c=2" target="_blank" rel="noopener noreferrer" class="underline">docs</a>`},
+		{"[bad](javascript:alert(1))", "[bad](javascript:alert(1))"},
+		{"<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"[x](https://a.com\" onclick=\"alert(1))", "[x](https://a.com&#34; onclick=&#34;alert(1))"},
+		{"line 1\nline 2\r\n\r\nparagraph", "line 1<br />line 2<br /><br />paragraph"},
+	}
+
+	for i, tc := range testCases {
+		if actual := RenderMarkdown(tc.input); actual != tc.expected {
+			t.Errorf("Test case %v: expected %q, got %q", i, tc.expected, actual)
+		}
+	}
+}
@@ -280,6 +280,16 @@ func (rg *RouteGenerator) Handle(pattern string, chain alice.Chain, handler http
 	})
 }
 
+// Patterns returns all registered patterns (in the order of registration)
+func (rg *RouteGenerator) Patterns() []string {
+	result := make([]string, 0, len(rg.routes))
+	for _, route := range rg.routes {
+		result = append(result, route.pattern)
+	}
+
+	return result
+}
+
 func (rg *RouteGenerator) Register(router *http.ServeMux) {
 	for _, route := range rg.routes {
 		router.Handle(route.pattern, route.chain.Then(route.handler))
@@ -2,7 +2,10 @@ package common
 
 import (
 	"fmt"
+	"net/http"
 	"testing"
+
+	"github.com/justinas/alice"
 )
 
 func TestRouteGenerator(t *testing.T) {
@@ -30,3 +33,18 @@ func TestRouteGenerator(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteGeneratorPatterns(t *testing.T) {
+	rg := &RouteGenerator{Prefix: "/"}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	rg.Handle(rg.Get("org", "{org}"), alice.New(), handler)
+	rg.Handle(rg.Delete("org", "{org}"), alice.New(), handler)
+	// re-registration replaces handler but does not duplicate the pattern
+	rg.Handle(rg.Get("org", "{org}"), alice.New(), handler)
+
+	patterns := rg.Patterns()
+	if (len(patterns) != 2) || (patterns[0] != "GET /org/{org}") || (patterns[1] != "DELETE /org/{org}") {
+		t.Errorf("Unexpected patterns: %v", patterns)
+	}
+}
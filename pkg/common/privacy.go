@@ -0,0 +1,99 @@
+package common
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// PrivacyMode controls how IP address of the end user is turned into identifiers that are stored (fingerprints in
+// request stats and network hashes of issuance receipts). Rate limiters work with full addresses in memory only.
+type PrivacyMode string
+
+const (
+	// property inherits the mode of the installation
+	PrivacyModeDefault PrivacyMode = ""
+	// identifiers are keyed hashes of the full address
+	PrivacyModeNone PrivacyMode = "none"
+	// address is truncated to the network (/24 for IPv4 and /48 for IPv6) before hashing
+	PrivacyModeTruncate PrivacyMode = "truncate"
+	// truncated address is hashed with a salt that rotates daily and is never persisted beyond rotation
+	PrivacyModeHash PrivacyMode = "hash"
+)
+
+const (
+	PrivacyPrefixBitsIPv4 = 24
+	PrivacyPrefixBitsIPv6 = 48
+)
+
+func ParsePrivacyMode(value string) PrivacyMode {
+	switch mode := PrivacyMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case PrivacyModeNone, PrivacyModeTruncate, PrivacyModeHash:
+		return mode
+	default:
+		return PrivacyModeDefault
+	}
+}
+
+func (m PrivacyMode) strictness() int {
+	switch m {
+	case PrivacyModeTruncate:
+		return 1
+	case PrivacyModeHash:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// EffectivePrivacyMode returns the stricter of the modes, so property override cannot weaken the installation mode
+func EffectivePrivacyMode(installation, property PrivacyMode) PrivacyMode {
+	if property.strictness() > installation.strictness() {
+		return property
+	}
+
+	if installation == PrivacyModeDefault {
+		return PrivacyModeNone
+	}
+
+	return installation
+}
+
+func (m PrivacyMode) Truncates() bool {
+	return m.strictness() >= PrivacyModeTruncate.strictness()
+}
+
+func (m PrivacyMode) RotatesSalt() bool {
+	return m == PrivacyModeHash
+}
+
+// Description is meant for data processing documentation
+func (m PrivacyMode) Description() string {
+	switch m {
+	case PrivacyModeTruncate:
+		return "IP addresses are truncated to /24 (IPv4) or /48 (IPv6) networks before hashing."
+	case PrivacyModeHash:
+		return "IP addresses are truncated to /24 (IPv4) or /48 (IPv6) networks and hashed with a salt that rotates daily."
+	default:
+		return "IP addresses are hashed with a secret key."
+	}
+}
+
+// TruncateIP returns the network address of the IP (IPv4-mapped addresses are treated as IPv4)
+func TruncateIP(ip netip.Addr) netip.Addr {
+	if !ip.IsValid() {
+		return ip
+	}
+
+	bits := PrivacyPrefixBitsIPv6
+	if ip.Is4() || ip.Is4In6() {
+		ip = ip.Unmap()
+		bits = PrivacyPrefixBitsIPv4
+	}
+
+	prefix, err := ip.Prefix(bits)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return prefix.Addr()
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// one in IssuanceReceiptSampleRate of issued puzzles gets a receipt
+	IssuanceReceiptSampleRate = 1000
+)
+
+// IssuanceReceipt is a sampled record of an issued (billable) puzzle, kept as evidence for billing disputes
+type IssuanceReceipt struct {
+	PuzzleID   uint64
+	UserID     int32
+	OrgID      int32
+	PropertyID int32
+	Sitekey    string
+	// keyed hash of the /24 (IPv4) or /48 (IPv6) prefix of the client address
+	IPPrefixHash uint64
+	Timestamp    time.Time
+}
+
+// IsIssuanceReceiptSampled decides if puzzle gets a receipt. Puzzle IDs are uniformly distributed hashes so sampling
+// by the ID is random, but also verifiable afterwards: anybody can check that receipts were not cherry-picked.
+func IsIssuanceReceiptSampled(puzzleID uint64) bool {
+	return (puzzleID != 0) && (puzzleID%IssuanceReceiptSampleRate == 0)
+}
+
+// IssuanceAuditStat correlates receipts with aggregated (billed) requests of an organization
+type IssuanceAuditStat struct {
+	OrgID    int32
+	Receipts uint64
+	Recorded uint64
+}
+
+// Estimated returns count of requests as extrapolated from the receipts
+func (s *IssuanceAuditStat) Estimated() uint64 {
+	return s.Receipts * IssuanceReceiptSampleRate
+}
+
+// Deviation returns relative difference between recorded and estimated requests counts
+func (s *IssuanceAuditStat) Deviation() float64 {
+	if s.Recorded == 0 {
+		if s.Receipts == 0 {
+			return 0.0
+		}
+		return math.Inf(1)
+	}
+
+	return (float64(s.Estimated()) - float64(s.Recorded)) / float64(s.Recorded)
+}
+
+// Consistent checks if receipts count is within 3 standard deviations from the count expected for recorded requests
+// (receipts count is binomially distributed with mean and variance both close to Recorded/SampleRate)
+func (s *IssuanceAuditStat) Consistent() bool {
+	expected := float64(s.Recorded) / IssuanceReceiptSampleRate
+	tolerance := 3.0 * math.Sqrt(max(expected, 1.0))
+
+	return math.Abs(float64(s.Receipts)-expected) <= tolerance
+}
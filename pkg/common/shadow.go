@@ -0,0 +1,194 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxShadowPercent = 100
+)
+
+type ShadowResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// ShadowHandler duplicates a percentage of requests to an alternate implementation and compares responses
+// asynchronously. Shadow handler is expected to be free of side-effects as its response is discarded. Because it runs
+// after the primary, it also has to skip checks that depend on the primary's side-effects (e.g. replay protection)
+// for requests marked with IsShadowRequest().
+type ShadowHandler struct {
+	Name    string
+	Primary http.Handler
+	Shadow  http.Handler
+	Timeout time.Duration
+	// returns true if responses are equivalent, defaults to comparing status and body
+	Compare func(primary, shadow *ShadowResponse) bool
+	percent atomic.Int32
+	// limits amount of concurrently running shadow requests (excess is dropped)
+	slots chan struct{}
+}
+
+func NewShadowHandler(name string, primary, shadow http.Handler, maxConcurrent int) *ShadowHandler {
+	return &ShadowHandler{
+		Name:    name,
+		Primary: primary,
+		Shadow:  shadow,
+		Timeout: 5 * time.Second,
+		slots:   make(chan struct{}, maxConcurrent),
+	}
+}
+
+// IsShadowRequest returns true if request with this context is served by the shadow handler
+func IsShadowRequest(ctx context.Context) bool {
+	shadow, ok := ctx.Value(ShadowContextKey).(bool)
+	return ok && shadow
+}
+
+func (sh *ShadowHandler) SetPercent(percent int) {
+	percent = max(0, min(percent, maxShadowPercent))
+	if old := sh.percent.Swap(int32(percent)); int(old) != percent {
+		slog.Info("Shadow traffic percent changed", "name", sh.Name, "old", old, "new", percent)
+	}
+}
+
+func (sh *ShadowHandler) Percent() int {
+	return int(sh.percent.Load())
+}
+
+func (sh *ShadowHandler) sampled() bool {
+	percent := sh.percent.Load()
+	if (percent <= 0) || (sh.Shadow == nil) {
+		return false
+	}
+
+	return rand.Int32N(maxShadowPercent) < percent
+}
+
+func (sh *ShadowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !sh.sampled() {
+		sh.Primary.ServeHTTP(w, r)
+		return
+	}
+
+	ctx := r.Context()
+
+	select {
+	case sh.slots <- struct{}{}:
+	default:
+		slog.Log(ctx, LevelTrace, "Skipping shadow request due to concurrency limit", "name", sh.Name)
+		sh.Primary.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		<-sh.slots
+		slog.ErrorContext(ctx, "Failed to read request body for shadowing", "name", sh.Name, ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	shadowRequest := r.Clone(context.WithValue(context.WithoutCancel(ctx), ShadowContextKey, true))
+	shadowRequest.Body = io.NopCloser(bytes.NewReader(body))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	tw := &teeResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	sh.Primary.ServeHTTP(tw, r)
+
+	primary := &ShadowResponse{Status: tw.status, Header: w.Header().Clone(), Body: tw.body.Bytes()}
+
+	go sh.runShadow(shadowRequest, primary)
+}
+
+func (sh *ShadowHandler) runShadow(r *http.Request, primary *ShadowResponse) {
+	defer func() {
+		<-sh.slots
+		if rvr := recover(); rvr != nil {
+			slog.ErrorContext(r.Context(), "Shadow handler crashed", "name", sh.Name, "panic", rvr)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(r.Context(), sh.Timeout)
+	defer cancel()
+
+	sw := &shadowResponseWriter{header: make(http.Header), status: http.StatusOK}
+	start := time.Now()
+	sh.Shadow.ServeHTTP(sw, r.WithContext(ctx))
+	elapsed := time.Since(start)
+
+	shadow := &ShadowResponse{Status: sw.status, Header: sw.header, Body: sw.body.Bytes()}
+
+	compare := sh.Compare
+	if compare == nil {
+		compare = defaultShadowCompare
+	}
+
+	if !compare(primary, shadow) {
+		// bodies can contain sensitive data (e.g. verification results) so they are only logged at trace level
+		slog.WarnContext(ctx, "Shadow response diverged", "name", sh.Name, "path", r.URL.Path,
+			"primaryStatus", primary.Status, "shadowStatus", shadow.Status,
+			"primarySize", len(primary.Body), "shadowSize", len(shadow.Body), "elapsed", elapsed)
+		slog.Log(ctx, LevelTrace, "Diverged shadow response bodies", "name", sh.Name,
+			"primaryBody", string(primary.Body), "shadowBody", string(shadow.Body))
+		return
+	}
+
+	slog.Log(ctx, LevelTrace, "Shadow response matched", "name", sh.Name, "path", r.URL.Path, "elapsed", elapsed)
+}
+
+func defaultShadowCompare(primary, shadow *ShadowResponse) bool {
+	return (primary.Status == shadow.Status) && bytes.Equal(primary.Body, shadow.Body)
+}
+
+type teeResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (tw *teeResponseWriter) WriteHeader(code int) {
+	if !tw.wroteHeader {
+		tw.status = code
+		tw.wroteHeader = true
+	}
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *teeResponseWriter) Write(b []byte) (int, error) {
+	tw.wroteHeader = true
+	tw.body.Write(b)
+	return tw.ResponseWriter.Write(b)
+}
+
+type shadowResponseWriter struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (sw *shadowResponseWriter) Header() http.Header {
+	return sw.header
+}
+
+func (sw *shadowResponseWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.status = code
+		sw.wroteHeader = true
+	}
+}
+
+func (sw *shadowResponseWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.body.Write(b)
+}
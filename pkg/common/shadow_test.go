@@ -0,0 +1,93 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShadowHandler(t *testing.T) {
+	var shadowCalls atomic.Int32
+	done := make(chan string, 1)
+
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	})
+
+	shadow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsShadowRequest(r.Context()) {
+			shadowCalls.Add(1)
+		}
+		body, _ := io.ReadAll(r.Body)
+		done <- string(body)
+		_, _ = w.Write(body)
+	})
+
+	sh := NewShadowHandler("test", primary, shadow, 1)
+	var diverged atomic.Bool
+	sh.Compare = func(p, s *ShadowResponse) bool {
+		result := defaultShadowCompare(p, s)
+		diverged.Store(!result)
+		return result
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader("payload"))
+	w := httptest.NewRecorder()
+	sh.ServeHTTP(w, req)
+
+	if shadowCalls.Load() != 0 {
+		t.Fatal("Shadow handler was called with zero percent")
+	}
+
+	sh.SetPercent(100)
+
+	req = httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader("payload"))
+	w = httptest.NewRecorder()
+	sh.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated || w.Body.String() != "payload" {
+		t.Errorf("Unexpected primary response: %v %v", w.Code, w.Body.String())
+	}
+
+	select {
+	case body := <-done:
+		if body != "payload" {
+			t.Errorf("Unexpected shadow request body: %v", body)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Shadow handler was not called")
+	}
+
+	if shadowCalls.Load() != 1 {
+		t.Error("Shadow request was not marked")
+	}
+
+	// wait for shadow slot to be released after comparison
+	for i := 0; (i < 100) && (len(sh.slots) > 0); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !diverged.Load() {
+		t.Error("Expected responses to diverge due to different status")
+	}
+}
+
+func TestShadowPercentBounds(t *testing.T) {
+	sh := NewShadowHandler("test", HttpStatus(http.StatusOK), HttpStatus(http.StatusOK), 1)
+
+	sh.SetPercent(200)
+	if sh.Percent() != 100 {
+		t.Errorf("Unexpected percent: %v", sh.Percent())
+	}
+
+	sh.SetPercent(-1)
+	if sh.Percent() != 0 {
+		t.Errorf("Unexpected percent: %v", sh.Percent())
+	}
+}
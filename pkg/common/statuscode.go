@@ -2,15 +2,25 @@ package common
 
 import "strconv"
 
+const (
+	StatusCodesDocsURL = "https://docs.privatecaptcha.com/docs/reference/api-errors/"
+)
+
 type StatusCode int
 
 const (
 	// common errors
-	StatusOK             StatusCode = 1000
-	StatusFailure        StatusCode = 1001
-	StatusUndefined      StatusCode = 1002
-	StatusNotImplemented StatusCode = 1003
-	StatusApiDeprecated  StatusCode = 1004
+	StatusOK                StatusCode = 1000
+	StatusFailure           StatusCode = 1001
+	StatusUndefined         StatusCode = 1002
+	StatusNotImplemented    StatusCode = 1003
+	StatusApiDeprecated     StatusCode = 1004
+	StatusSchemaError       StatusCode = 1005
+	StatusNotFoundError     StatusCode = 1006
+	StatusInvalidInputError StatusCode = 1007
+	StatusMaintenanceError  StatusCode = 1008
+	StatusPermissionsError  StatusCode = 1009
+	StatusSoftDeletedError  StatusCode = 1010
 	// organization errors
 	StatusOrgNameEmptyError          StatusCode = 1100
 	StatusOrgNameTooLongError        StatusCode = 1101
@@ -22,6 +32,11 @@ const (
 	StatusOrgIDNotEmptyError         StatusCode = 1107
 	StatusOrgIDEmptyError            StatusCode = 1108
 	StatusOrgIDInvalidError          StatusCode = 1109
+	StatusOrgArchiveError            StatusCode = 1110
+	StatusOrgArchiveSignatureError   StatusCode = 1111
+	StatusOrgPassphraseError         StatusCode = 1112
+	StatusOrgMemberNotFoundError     StatusCode = 1113
+	StatusOrgMembersLimitError       StatusCode = 1114
 	// properties errors
 	StatusPropertiesTooManyError          StatusCode = 1200
 	StatusPropertyNameEmptyError          StatusCode = 1201
@@ -38,79 +53,150 @@ const (
 	StatusPropertyIDInvalidError          StatusCode = 1212
 	StatusPropertyIDDuplicateError        StatusCode = 1213
 	StatusPropertyPermissionsError        StatusCode = 1214
+	StatusPropertyEnvironmentError        StatusCode = 1215
+	StatusPropertyTwinError               StatusCode = 1216
+	StatusPropertyClaimsError             StatusCode = 1217
+	StatusExperimentRunningError          StatusCode = 1218
+	StatusExperimentLevelError            StatusCode = 1219
+	StatusExperimentWeightError           StatusCode = 1220
+	StatusExperimentDurationError         StatusCode = 1221
+	StatusExperimentSamplesError          StatusCode = 1222
+	StatusPropertyFailureURLError         StatusCode = 1223
+	StatusPropertyAccessListError         StatusCode = 1224
+	StatusPropertyDifficultyStrategyError StatusCode = 1225
 	// subscription errors
 	StatusSubscriptionPropertyLimitError StatusCode = 1300
+	StatusSubscriptionSeatsLimitError    StatusCode = 1301
+	StatusSubscriptionRequestsLimitError StatusCode = 1302
+	StatusSubscriptionInactiveError      StatusCode = 1303
+	// api key errors
+	StatusAPIKeyNameTemplateError  StatusCode = 1400
+	StatusAPIKeyNameDuplicateError StatusCode = 1401
+	StatusAPIKeysCountError        StatusCode = 1402
+	StatusAPIKeyScopeError         StatusCode = 1403
+	StatusAPIKeyExpirationError    StatusCode = 1404
+	StatusAPIKeyNotImportedError   StatusCode = 1405
+	StatusAPIKeyAllowedIPsError    StatusCode = 1406
+	StatusAPIKeyInvalidError       StatusCode = 1407
+	StatusAPIKeyReadOnlyError      StatusCode = 1408
+	StatusAPIKeySourceError        StatusCode = 1409
 )
 
+type statusCodeInfo struct {
+	code        StatusCode
+	name        string
+	description string
+}
+
+// statusCodesTable is the only place where names and descriptions are defined, sorted by code
+var statusCodesTable = []statusCodeInfo{
+	{StatusOK, "ok", "OK"},
+	{StatusFailure, "failure", "Failure"},
+	{StatusUndefined, "undefined", "Undefined"},
+	{StatusNotImplemented, "not_implemented", "Not implemented"},
+	{StatusApiDeprecated, "api_deprecated", "API is deprecated"},
+	{StatusSchemaError, "schema_invalid", "Request body is not valid."},
+	{StatusNotFoundError, "not_found", "Requested resource does not exist."},
+	{StatusInvalidInputError, "invalid_input", "Request parameters are not valid."},
+	{StatusMaintenanceError, "maintenance", "Service is under maintenance, please retry later."},
+	{StatusPermissionsError, "permissions", "You do not have permissions to perform this action."},
+	{StatusSoftDeletedError, "soft_deleted", "Requested resource is scheduled for deletion."},
+	{StatusOrgNameEmptyError, "org_name_empty", "Name cannot be empty."},
+	{StatusOrgNameTooLongError, "org_name_too_long", "Name is too long."},
+	{StatusOrgNameInvalidSymbolsError, "org_name_invalid_symbols", "Organization name contains invalid characters."},
+	{StatusOrgNameDuplicateError, "org_name_duplicate", "Organization with this name already exists."},
+	{StatusOrgLimitError, "org_limit", "Organizations limit reached on your current plan, please upgrade to create more."},
+	{StatusOrgNotFoundError, "org_not_found", "Requested organization does not seem to exist."},
+	{StatusOrgPermissionsError, "org_permissions", "You do not have permissions to access this organization."},
+	{StatusOrgIDNotEmptyError, "org_id_not_empty", "Organization ID must be empty."},
+	{StatusOrgIDEmptyError, "org_id_empty", "Organization ID must not be empty."},
+	{StatusOrgIDInvalidError, "org_id_invalid", "Organization ID is not valid."},
+	{StatusOrgArchiveError, "org_archive", "Organization archive is not valid."},
+	{StatusOrgArchiveSignatureError, "org_archive_signature", "Organization archive signature does not match the passphrase."},
+	{StatusOrgPassphraseError, "org_passphrase", "Passphrase is too short."},
+	{StatusOrgMemberNotFoundError, "org_member_not_found", "User with this email does not exist."},
+	{StatusOrgMembersLimitError, "org_members_limit", "Organization members limit reached on your current plan."},
+	{StatusPropertiesTooManyError, "properties_too_many", "Properties batch limit size was exceeded."},
+	{StatusPropertyNameEmptyError, "property_name_empty", "Name cannot be empty."},
+	{StatusPropertyNameTooLongError, "property_name_too_long", "Name is too long."},
+	{StatusPropertyNameInvalidSymbolsError, "property_name_invalid_symbols", "Property name contains invalid characters."},
+	{StatusPropertyNameDuplicateError, "property_name_duplicate", "Property with this name already exists."},
+	{StatusPropertyDomainEmptyError, "property_domain_empty", "Domain name cannot be empty."},
+	{StatusPropertyDomainLocalhostError, "property_domain_localhost", "Localhost is not allowed as a domain."},
+	{StatusPropertyDomainIPAddrError, "property_domain_ip_addr", "IP address cannot be used as a domain."},
+	{StatusPropertyDomainNameInvalidError, "property_domain_name_invalid", "Domain name is not valid."},
+	{StatusPropertyDomainResolveError, "property_domain_resolve", "Failed to resolve domain name."},
+	{StatusPropertyDomainFormatError, "property_domain_format", "Invalid format of domain name."},
+	{StatusPropertyIDEmptyError, "property_id_empty", "Property ID cannot be empty."},
+	{StatusPropertyIDInvalidError, "property_id_invalid", "Property ID is not valid."},
+	{StatusPropertyIDDuplicateError, "property_id_duplicate", "Duplicate property ID found in request."},
+	{StatusPropertyPermissionsError, "property_permissions", "Insufficient permissions to update settings."},
+	{StatusPropertyEnvironmentError, "property_environment", "Property environment is not valid."},
+	{StatusPropertyTwinError, "property_twin", "Production twin of the property is not valid."},
+	{StatusPropertyClaimsError, "property_claims", "Property claims are not valid."},
+	{StatusExperimentRunningError, "experiment_running", "Difficulty experiment is already running for this property."},
+	{StatusExperimentLevelError, "experiment_level", "Experiment difficulty levels are not valid."},
+	{StatusExperimentWeightError, "experiment_weight", "Experiment variant weight is not valid."},
+	{StatusExperimentDurationError, "experiment_duration", "Experiment duration is not valid."},
+	{StatusExperimentSamplesError, "experiment_samples", "Experiment conclusion rules are not valid."},
+	{StatusPropertyFailureURLError, "property_failure_url", "Failure redirect URL is not valid."},
+	{StatusPropertyAccessListError, "property_access_list", "Property access list is not valid."},
+	{StatusPropertyDifficultyStrategyError, "property_difficulty_strategy", "Difficulty strategy is not valid."},
+	{StatusSubscriptionPropertyLimitError, "subscription_property_limit", "Property limit reached for current subscription plan."},
+	{StatusSubscriptionSeatsLimitError, "subscription_seats_limit", "All seats of your current plan are taken."},
+	{StatusSubscriptionRequestsLimitError, "subscription_requests_limit", "Requests limit of your current plan would be exceeded."},
+	{StatusSubscriptionInactiveError, "subscription_inactive", "Active subscription is required to use the API."},
+	{StatusAPIKeyNameTemplateError, "api_key_name_template", "API key name template is not valid."},
+	{StatusAPIKeyNameDuplicateError, "api_key_name_duplicate", "API key with such name already exists."},
+	{StatusAPIKeysCountError, "api_keys_count", "Number of API keys is not valid."},
+	{StatusAPIKeyScopeError, "api_key_scope", "API key scope is not valid."},
+	{StatusAPIKeyExpirationError, "api_key_expiration", "API key expiration is not valid."},
+	{StatusAPIKeyNotImportedError, "api_key_not_imported", "API keys are not imported, new keys have to be created."},
+	{StatusAPIKeyAllowedIPsError, "api_key_allowed_ips", "API key allowed IP ranges are not valid."},
+	{StatusAPIKeyInvalidError, "api_key_invalid", "API key is missing or not valid."},
+	{StatusAPIKeyReadOnlyError, "api_key_read_only", "API key is read-only."},
+	{StatusAPIKeySourceError, "api_key_source", "Requests from this source are not allowed for this API key."},
+}
+
+var statusCodesIndex = func() map[StatusCode]*statusCodeInfo {
+	index := make(map[StatusCode]*statusCodeInfo, len(statusCodesTable))
+	for i := range statusCodesTable {
+		index[statusCodesTable[i].code] = &statusCodesTable[i]
+	}
+	return index
+}()
+
 func (sc StatusCode) Success() bool {
 	return sc == StatusOK
 }
 
 func (sc StatusCode) String() string {
-	switch sc {
-	case StatusOK:
-		return "OK"
-	case StatusFailure:
-		return "Failure"
-	case StatusUndefined:
-		return "Undefined"
-	case StatusNotImplemented:
-		return "Not implemented"
-	case StatusApiDeprecated:
-		return "API is deprecated"
-	case StatusOrgNameEmptyError:
-		return "Name cannot be empty."
-	case StatusOrgNameTooLongError:
-		return "Name is too long."
-	case StatusOrgNameInvalidSymbolsError:
-		return "Organization name contains invalid characters."
-	case StatusOrgNameDuplicateError:
-		return "Organization with this name already exists."
-	case StatusOrgLimitError:
-		return "Organizations limit reached on your current plan, please upgrade to create more."
-	case StatusOrgNotFoundError:
-		return "Requested organization does not seem to exist."
-	case StatusOrgPermissionsError:
-		return "You do not have permissions to access this organization."
-	case StatusOrgIDNotEmptyError:
-		return "Organization ID must be empty."
-	case StatusOrgIDEmptyError:
-		return "Organization ID must not be empty."
-	case StatusOrgIDInvalidError:
-		return "Organization ID is not valid."
-	case StatusPropertiesTooManyError:
-		return "Properties batch limit size was exceeded."
-	case StatusPropertyNameEmptyError:
-		return "Name cannot be empty."
-	case StatusPropertyNameTooLongError:
-		return "Name is too long."
-	case StatusPropertyNameInvalidSymbolsError:
-		return "Property name contains invalid characters."
-	case StatusPropertyNameDuplicateError:
-		return "Property with this name already exists."
-	case StatusPropertyDomainEmptyError:
-		return "Domain name cannot be empty."
-	case StatusPropertyDomainLocalhostError:
-		return "Localhost is not allowed as a domain."
-	case StatusPropertyDomainIPAddrError:
-		return "IP address cannot be used as a domain."
-	case StatusPropertyDomainNameInvalidError:
-		return "Domain name is not valid."
-	case StatusPropertyDomainResolveError:
-		return "Failed to resolve domain name."
-	case StatusPropertyDomainFormatError:
-		return "Invalid format of domain name."
-	case StatusPropertyIDEmptyError:
-		return "Property ID cannot be empty."
-	case StatusPropertyIDInvalidError:
-		return "Property ID is not valid."
-	case StatusPropertyIDDuplicateError:
-		return "Duplicate property ID found in request."
-	case StatusSubscriptionPropertyLimitError:
-		return "Property limit reached for current subscription plan."
-	case StatusPropertyPermissionsError:
-		return "Insufficient permissions to update settings."
-	default:
-		return strconv.Itoa(int(sc))
+	if info, ok := statusCodesIndex[sc]; ok {
+		return info.description
+	}
+
+	return strconv.Itoa(int(sc))
+}
+
+// Name is a stable identifier of the status code that clients can rely on (unlike the description)
+func (sc StatusCode) Name() string {
+	if info, ok := statusCodesIndex[sc]; ok {
+		return info.name
+	}
+
+	return "unknown"
+}
+
+func (sc StatusCode) DocsURL() string {
+	return StatusCodesDocsURL + "#" + strconv.Itoa(int(sc))
+}
+
+// StatusCodes returns all known status codes in ascending order
+func StatusCodes() []StatusCode {
+	result := make([]StatusCode, 0, len(statusCodesTable))
+	for _, info := range statusCodesTable {
+		result = append(result, info.code)
 	}
+
+	return result
 }
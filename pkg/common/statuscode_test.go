@@ -0,0 +1,27 @@
+package common
+
+import "testing"
+
+func TestStatusCodeNames(t *testing.T) {
+	t.Parallel()
+
+	names := make(map[string]StatusCode)
+	var prev StatusCode
+
+	for _, code := range StatusCodes() {
+		if code <= prev {
+			t.Errorf("Status code %v is not sorted after %v", code, prev)
+		}
+		prev = code
+
+		name := code.Name()
+		if name == StatusCode(0).Name() {
+			t.Errorf("Status code %v does not have a name", int(code))
+		}
+
+		if other, ok := names[name]; ok {
+			t.Errorf("Status codes %v and %v have the same name %v", int(code), int(other), name)
+		}
+		names[name] = code
+	}
+}
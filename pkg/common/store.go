@@ -46,17 +46,51 @@ type TimeSeriesStore interface {
 	WriteAccessLogBatch(ctx context.Context, records []*AccessRecord) error
 	WriteVerifyLogBatch(ctx context.Context, records []*VerifyRecord) error
 	RetrievePropertyStatsSince(ctx context.Context, r *BackfillRequest, from time.Time) ([]*TimeCount, error)
-	RetrieveAccountStats(ctx context.Context, userID int32, from time.Time) ([]*TimeCount, error)
+	// excluded orgs are the ones that do not count towards the limits (sandboxes)
+	RetrieveAccountStats(ctx context.Context, userID int32, from time.Time, excludeOrgIDs []int32) ([]*TimeCount, error)
 	RetrievePropertyStatsByPeriod(ctx context.Context, orgID, propertyID int32, period TimePeriod) ([]*TimePeriodStat, error)
+	RetrievePropertyVerifyLatencyByPeriod(ctx context.Context, orgID, propertyID int32, period TimePeriod) ([]*TimePeriodLatency, error)
+	RetrieveExperimentStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*ExperimentArmStats, error)
+	RetrieveVisitorStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*VisitorClassStats, error)
+	RetrieveIntegrityStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) (*IntegrityStats, error)
 	RetrieveRecentTopProperties(ctx context.Context, limit int) (map[int32]uint, error)
+	RetrieveOrgUsage(ctx context.Context, from, to time.Time) ([]*OrgUsageStat, error)
+	RetrievePropertiesHourlyStats(ctx context.Context, hour time.Time) ([]*PropertyHourlyStat, error)
+	// RetrievePropertiesStats returns totals of all properties with traffic within the period (daily precision)
+	RetrievePropertiesStats(ctx context.Context, from, to time.Time) ([]*PropertyHourlyStat, error)
+	SchemaVersion(ctx context.Context) (uint, bool, error)
+	RetrieveEarliestTimestamp(ctx context.Context, table string) (time.Time, error)
+	// ExecBackfill replaces data of the table within [from, to) with results of the query, so it can be safely repeated
+	ExecBackfill(ctx context.Context, table, query string, from, to time.Time) error
+	WriteIssuanceReceiptBatch(ctx context.Context, records []*IssuanceReceipt) error
+	WriteQuotaLogBatch(ctx context.Context, records []*QuotaRecord) error
+	// RetrieveQuotaStats returns hourly counts of puzzle requests rejected by the issuance quota of the property
+	RetrieveQuotaStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*TimeCount, error)
+	RetrieveIssuanceReceipts(ctx context.Context, userID int32, from, to time.Time) ([]*IssuanceReceipt, error)
+	RetrieveIssuanceAudit(ctx context.Context, userID int32, from, to time.Time) ([]*IssuanceAuditStat, error)
+	RetrieveVerifyTraces(ctx context.Context, traceID string, limit int) ([]*VerifyRecord, error)
+	// RetrieveVerifyHealth returns hourly verifications and latency of all properties within the period
+	RetrieveVerifyHealth(ctx context.Context, from, to time.Time) ([]*VerifyHealthStat, error)
 	DeletePropertiesData(ctx context.Context, propertyIDs []int32) error
 	DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error
 	DeleteUsersData(ctx context.Context, userIDs []int32) error
+	// RetrieveDataPropertyIDs returns sorted IDs (greater than afterID) of properties that have data in the storage
+	RetrieveDataPropertyIDs(ctx context.Context, afterID int32, limit int) ([]int32, error)
+	RetrieveDataOrgIDs(ctx context.Context, afterID int32, limit int) ([]int32, error)
+	// PendingDeletesCount returns the number of deletes that are accepted, but not yet finished by the storage
+	PendingDeletesCount(ctx context.Context) (int, error)
 }
 
 type PlatformMetrics interface {
 	ObserveHealth(postgres, clickhouse bool)
 	ObserveCacheHitRatio(ratio float64)
+	ObserveCacheValidation(entity string, checked, stale int)
+	ObserveComponentUsage(component string, entries, capacity int)
+}
+
+type JobMetrics interface {
+	ObserveJobRun(job string, duration time.Duration, success bool)
+	ObserveJobScheduled(job string, at time.Time)
 }
 
 type HTTPMetrics interface {
@@ -68,6 +102,11 @@ type APIMetrics interface {
 	HTTPMetrics
 	ObservePuzzleCreated(userID int32)
 	ObservePuzzleVerified(userID int32, result string, isStub bool)
+	ObservePropertyPuzzleCreated(sitekey string)
+	ObservePropertyPuzzleVerified(sitekey string, result string, duration time.Duration)
+	ObserveBotHeuristic(heuristic string, action string)
+	// ObserveVerifySkewTolerated counts puzzles accepted after expiration due to the allowed clock skew of the property
+	ObserveVerifySkewTolerated(result string)
 	ObserveApiError(handlerID string, method string, code int)
 }
 
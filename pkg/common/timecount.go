@@ -32,7 +32,125 @@ type TimePeriodStat struct {
 	VerifiesCount int
 }
 
+// latency percentiles are in milliseconds
+type TimePeriodLatency struct {
+	Timestamp time.Time
+	P50       float64
+	P90       float64
+	P99       float64
+}
+
+// VerifyHealthStat contains verifications of all properties within an hour, latency percentiles are in milliseconds
+type VerifyHealthStat struct {
+	Timestamp    time.Time
+	SuccessCount uint64
+	FailureCount uint64
+	P50          float64
+	P90          float64
+	P99          float64
+}
+
+// ErrorRate is a share of failed verifications, 0 if there were none
+func (s *VerifyHealthStat) ErrorRate() float64 {
+	if total := s.SuccessCount + s.FailureCount; total > 0 {
+		return float64(s.FailureCount) / float64(total)
+	}
+
+	return 0
+}
+
 type TimeCount struct {
 	Timestamp time.Time
 	Count     uint32
 }
+
+// ExperimentArmStats contains totals of puzzles issued and verified for one arm of the difficulty experiment
+type ExperimentArmStats struct {
+	Arm           ExperimentArm
+	RequestsCount int
+	SuccessCount  int
+	FailureCount  int
+}
+
+// AbandonmentRate is a share of issued puzzles that were never verified
+func (s *ExperimentArmStats) AbandonmentRate() float64 {
+	if s.RequestsCount == 0 {
+		return 0.0
+	}
+
+	verified := s.SuccessCount + s.FailureCount
+	return max(0.0, 1.0-float64(verified)/float64(s.RequestsCount))
+}
+
+// FailureRate is a share of failed verifications
+func (s *ExperimentArmStats) FailureRate() float64 {
+	verified := s.SuccessCount + s.FailureCount
+	if verified == 0 {
+		return 0.0
+	}
+
+	return float64(s.FailureCount) / float64(verified)
+}
+
+// VisitorClassStats contains totals of puzzles issued and verified for one class of visitors (differential difficulty)
+type VisitorClassStats struct {
+	Class         VisitorClass
+	RequestsCount int
+	SuccessCount  int
+	FailureCount  int
+}
+
+// SolveRate is a share of issued puzzles that were successfully verified
+func (s *VisitorClassStats) SolveRate() float64 {
+	if s.RequestsCount == 0 {
+		return 0.0
+	}
+
+	return min(1.0, float64(s.SuccessCount)/float64(s.RequestsCount))
+}
+
+// IntegrityStats contains totals of verifications by the result of widget integrity check
+type IntegrityStats struct {
+	OKCount       int
+	TamperedCount int
+	PatchedCount  int
+	UnknownCount  int
+}
+
+// TamperedRate is a share of tampered clients among verifications with a known integrity status
+func (s *IntegrityStats) TamperedRate() float64 {
+	known := s.OKCount + s.TamperedCount + s.PatchedCount
+	if known == 0 {
+		return 0.0
+	}
+
+	return float64(s.TamperedCount) / float64(known)
+}
+
+// OrgUsageStat is the usage of an organization that contributes to serving costs
+type OrgUsageStat struct {
+	UserID        int32
+	OrgID         int32
+	Requests      uint64
+	Verifications uint64
+	// estimated share of the whole time series storage (not limited to the requested period)
+	StorageBytes uint64
+}
+
+// PropertyHourlyStat is the traffic of a property within a single hour
+type PropertyHourlyStat struct {
+	OrgID        int32
+	PropertyID   int32
+	Requests     uint64
+	SuccessCount uint64
+	FailureCount uint64
+}
+
+// SuccessRate is a share of successful verifications, -1 if there were none
+func (s *PropertyHourlyStat) SuccessRate() float64 {
+	if total := s.SuccessCount + s.FailureCount; total > 0 {
+		return float64(s.SuccessCount) / float64(total)
+	}
+
+	return -1.0
+}
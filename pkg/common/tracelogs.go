@@ -0,0 +1,88 @@
+package common
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	recentTraceLogsCapacity = 10_000
+)
+
+type TraceLogLine struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"msg"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+type traceLogEntry struct {
+	traceID string
+	record  slog.Record
+}
+
+// traceLogBuffer keeps last log lines (that had a trace ID) of this process so that they can be looked up by support.
+// It's a lock-free ring buffer as it's written on every log line: records are only converted to lines on lookup.
+type traceLogBuffer struct {
+	entries []atomic.Pointer[traceLogEntry]
+	next    atomic.Uint64
+}
+
+var recentTraceLogs = newTraceLogBuffer(recentTraceLogsCapacity)
+
+func newTraceLogBuffer(capacity int) *traceLogBuffer {
+	return &traceLogBuffer{
+		entries: make([]atomic.Pointer[traceLogEntry], capacity),
+	}
+}
+
+func (b *traceLogBuffer) add(tid string, r *slog.Record) {
+	// clone is needed as attributes are added to the record after it was buffered
+	entry := &traceLogEntry{traceID: tid, record: r.Clone()}
+	index := (b.next.Add(1) - 1) % uint64(len(b.entries))
+	b.entries[index].Store(entry)
+}
+
+func newTraceLogLine(r *slog.Record) *TraceLogLine {
+	line := &TraceLogLine{
+		Time:    r.Time.UTC(),
+		Level:   r.Level.String(),
+		Message: r.Message,
+	}
+
+	if r.NumAttrs() > 0 {
+		line.Attrs = make(map[string]string, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			line.Attrs[a.Key] = a.Value.Resolve().String()
+			return true
+		})
+	}
+
+	return line
+}
+
+// find can miss or reorder lines that are written concurrently, which is fine for support lookups
+func (b *traceLogBuffer) find(tid string) []*TraceLogLine {
+	result := make([]*TraceLogLine, 0)
+	capacity := uint64(len(b.entries))
+	next := b.next.Load()
+
+	for i := uint64(0); i < capacity; i++ {
+		e := b.entries[(next+i)%capacity].Load()
+		if (e != nil) && (e.traceID == tid) {
+			result = append(result, newTraceLogLine(&e.record))
+		}
+	}
+
+	return result
+}
+
+// RecentTraceLogs returns log lines of the trace that are still kept in memory of this process, oldest first
+func RecentTraceLogs(tid string) []*TraceLogLine {
+	if len(tid) == 0 {
+		return []*TraceLogLine{}
+	}
+
+	return recentTraceLogs.find(tid)
+}
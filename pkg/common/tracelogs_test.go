@@ -0,0 +1,67 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTraceLogBufferWraps(t *testing.T) {
+	t.Parallel()
+
+	b := newTraceLogBuffer(3)
+
+	for i := 0; i < 5; i++ {
+		tid := "a"
+		if i%2 == 1 {
+			tid = "b"
+		}
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, fmt.Sprintf("message %d", i), 0)
+		r.AddAttrs(slog.Int("index", i))
+		b.add(tid, &r)
+	}
+
+	lines := b.find("a")
+	if len(lines) != 2 {
+		t.Fatalf("Unexpected number of lines: %v", len(lines))
+	}
+
+	if (lines[0].Message != "message 2") || (lines[1].Message != "message 4") {
+		t.Errorf("Unexpected lines order: %v, %v", lines[0].Message, lines[1].Message)
+	}
+
+	if lines[1].Attrs["index"] != "4" {
+		t.Errorf("Unexpected attributes: %v", lines[1].Attrs)
+	}
+
+	if lines := b.find("c"); len(lines) != 0 {
+		t.Errorf("Unexpected lines for unknown trace: %v", len(lines))
+	}
+}
+
+func TestTraceLogBufferConcurrent(t *testing.T) {
+	t.Parallel()
+
+	b := newTraceLogBuffer(100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				r := slog.NewRecord(time.Now(), slog.LevelInfo, "message", 0)
+				b.add("a", &r)
+				_ = b.find("a")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if lines := b.find("a"); len(lines) != 100 {
+		t.Errorf("Unexpected number of lines: %v", len(lines))
+	}
+}
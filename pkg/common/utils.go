@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/netip"
 	"net/url"
@@ -239,6 +240,78 @@ func IsIPAddress(str string) bool {
 	return err == nil
 }
 
+// DomainResolver is implemented by net.Resolver
+type DomainResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// LookupDomainName resolves IP addresses of the domain and reports if any of them is not a loopback one
+func LookupDomainName(ctx context.Context, resolver DomainResolver, domain string, timeout time.Duration) ([]net.IPAddr, bool, error) {
+	rctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	names, err := resolver.LookupIPAddr(rctx, domain)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, n := range names {
+		if !n.IP.IsLoopback() {
+			return names, true, nil
+		}
+	}
+
+	return names, false, nil
+}
+
+// ParseIPAllowlist parses IP addresses and CIDR ranges. Single addresses become full-length prefixes
+func ParseIPAllowlist(entries []string) ([]netip.Prefix, error) {
+	result := make([]netip.Prefix, 0, len(entries))
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		addr = addr.Unmap()
+		result = append(result, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return result, nil
+}
+
+func IsIPAllowed(prefixes []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func IsSubDomainOrDomain(subDomain, domain string) bool {
 	if len(subDomain) == 0 || len(domain) == 0 {
 		return false
@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"net/netip"
 	"testing"
 )
 
@@ -124,6 +125,48 @@ func TestSubDomain(t *testing.T) {
 	}
 }
 
+func TestIPAllowlist(t *testing.T) {
+	prefixes, err := ParseIPAllowlist([]string{" 10.1.2.3/16 ", "", "192.168.1.10", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prefixes) != 3 {
+		t.Fatalf("Unexpected prefixes count: %v", len(prefixes))
+	}
+
+	if prefixes[0].String() != "10.1.0.0/16" {
+		t.Errorf("Prefix is not masked: %v", prefixes[0])
+	}
+
+	testCases := []struct {
+		addr     string
+		expected bool
+	}{
+		{"10.1.200.7", true},
+		{"10.2.0.1", false},
+		{"192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"::ffff:192.168.1.10", true},
+		{"2001:db8:1::1", true},
+		{"2001:db9::1", false},
+	}
+
+	for _, tc := range testCases {
+		if actual := IsIPAllowed(prefixes, netip.MustParseAddr(tc.addr)); actual != tc.expected {
+			t.Errorf("Unexpected result for %v: %v", tc.addr, actual)
+		}
+	}
+
+	if IsIPAllowed(prefixes, netip.Addr{}) {
+		t.Error("Invalid address is allowed")
+	}
+
+	if _, err := ParseIPAllowlist([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("Invalid range was parsed")
+	}
+}
+
 func TestGuessFirstName(t *testing.T) {
 	tests := []struct {
 		username string
@@ -0,0 +1,28 @@
+package common
+
+// VerifyReport is a verification result that was made outside of the API (e.g. by the edge verification proxy)
+// and has to be accounted for in stats and replay protection
+type VerifyReport struct {
+	// original solution payload as it was received by the proxy
+	Payload string `json:"payload"`
+	// unix time of the verification
+	Timestamp  int64  `json:"timestamp"`
+	DurationUs uint32 `json:"duration_us,omitempty"`
+	// verification result code as seen by the proxy
+	Code int `json:"code"`
+}
+
+type VerifyReportRequest struct {
+	Reports []*VerifyReport `json:"reports"`
+	// unix time when the request was sent, to compare clocks of the proxy and the API
+	SentAt int64 `json:"sent_at"`
+}
+
+type VerifyReportResult struct {
+	Code int `json:"code"`
+}
+
+// VerifyReportResponse contains results in the same order as reports in the request
+type VerifyReportResponse struct {
+	Results []*VerifyReportResult `json:"results"`
+}
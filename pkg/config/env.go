@@ -77,6 +77,55 @@ func init() {
 	configKeyToEnvName[common.CountryCodeHeaderKey] = "PC_COUNTRY_CODE_HEADER"
 	configKeyToEnvName[common.EnterpriseAuditLogDaysKey] = "EE_AUDIT_LOGS_DAYS"
 	configKeyToEnvName[common.ClickHouseOptionalKey] = "PC_CLICKHOUSE_OPTIONAL"
+	configKeyToEnvName[common.ShadowVerifyPercentKey] = "PC_SHADOW_VERIFY_PERCENT"
+	configKeyToEnvName[common.LoadShedMaxInflightKey] = "PC_LOADSHED_MAX_INFLIGHT"
+	configKeyToEnvName[common.LoadShedLatencyKey] = "PC_LOADSHED_LATENCY_MS"
+	configKeyToEnvName[common.TLSTicketRotationKey] = "PC_TLS_TICKET_ROTATION_HOURS"
+	configKeyToEnvName[common.TLSCurvesKey] = "PC_TLS_CURVES"
+	configKeyToEnvName[common.ProfilingEnabledKey] = "PC_PROFILING_ENABLED"
+	configKeyToEnvName[common.ProfilingUploadURLKey] = "PC_PROFILING_UPLOAD_URL"
+	configKeyToEnvName[common.AlertSigmaKey] = "PC_ALERT_SIGMA"
+	configKeyToEnvName[common.RedisAddressKey] = "PC_REDIS_ADDRESS"
+	configKeyToEnvName[common.RedisPasswordKey] = "PC_REDIS_PASSWORD"
+	configKeyToEnvName[common.RedisDBKey] = "PC_REDIS_DB"
+	configKeyToEnvName[common.VerifyRegionsKey] = "PC_VERIFY_REGIONS"
+	configKeyToEnvName[common.WidgetIntegrityHashesKey] = "PC_WIDGET_INTEGRITY_HASHES"
+	configKeyToEnvName[common.APIKeyUsageAuditKey] = "PC_API_KEY_USAGE_AUDIT"
+	configKeyToEnvName[common.ASNHeaderKey] = "PC_ASN_HEADER"
+	configKeyToEnvName[common.VerifyRawRetentionDaysKey] = "PC_VERIFY_RAW_RETENTION_DAYS"
+	configKeyToEnvName[common.VerifyHourlyRetentionDaysKey] = "PC_VERIFY_HOURLY_RETENTION_DAYS"
+	configKeyToEnvName[common.VerifyDailyRetentionDaysKey] = "PC_VERIFY_DAILY_RETENTION_DAYS"
+	configKeyToEnvName[common.FootprintKey] = "PC_FOOTPRINT"
+	configKeyToEnvName[common.TimeSeriesBackendKey] = "PC_TIMESERIES_BACKEND"
+	configKeyToEnvName[common.SSOIssuerKey] = "PC_SSO_OIDC_ISSUER"
+	configKeyToEnvName[common.SSOClientIDKey] = "PC_SSO_OIDC_CLIENT_ID"
+	configKeyToEnvName[common.SSOClientSecretKey] = "PC_SSO_OIDC_CLIENT_SECRET"
+	configKeyToEnvName[common.SSODefaultOrgKey] = "PC_SSO_DEFAULT_ORG"
+	configKeyToEnvName[common.SSOProvisioningKey] = "PC_SSO_PROVISIONING"
+	configKeyToEnvName[common.StandbyModeKey] = "PC_STANDBY_MODE"
+	configKeyToEnvName[common.AttestationKeysKey] = "PC_ATTESTATION_KEYS"
+	configKeyToEnvName[common.AuditStreamURLKey] = "PC_AUDIT_STREAM_URL"
+	configKeyToEnvName[common.AuditStreamSecretKey] = "PC_AUDIT_STREAM_SECRET"
+	configKeyToEnvName[common.PrivacyModeKey] = "PC_PRIVACY_MODE"
+	configKeyToEnvName[common.IPReputationFeedsKey] = "PC_IP_REPUTATION_FEEDS"
+	configKeyToEnvName[common.IPReputationDifficultyKey] = "PC_IP_REPUTATION_DIFFICULTY"
+	configKeyToEnvName[common.SmtpEndpointEUKey] = "SMTP_ENDPOINT_EU"
+	configKeyToEnvName[common.SmtpUsernameEUKey] = "SMTP_USERNAME_EU"
+	configKeyToEnvName[common.SmtpPasswordEUKey] = "SMTP_PASSWORD_EU"
+	configKeyToEnvName[common.AssetsS3EndpointKey] = "PC_ASSETS_S3_ENDPOINT"
+	configKeyToEnvName[common.AssetsS3BucketKey] = "PC_ASSETS_S3_BUCKET"
+	configKeyToEnvName[common.AssetsS3RegionKey] = "PC_ASSETS_S3_REGION"
+	configKeyToEnvName[common.AssetsS3AccessKeyKey] = "PC_ASSETS_S3_ACCESS_KEY"
+	configKeyToEnvName[common.AssetsS3SecretKeyKey] = "PC_ASSETS_S3_SECRET_KEY"
+	configKeyToEnvName[common.AssetsHashedNamesKey] = "PC_ASSETS_HASHED_NAMES"
+	configKeyToEnvName[common.EmailProvidersKey] = "PC_EMAIL_PROVIDERS"
+	configKeyToEnvName[common.SESRegionKey] = "PC_SES_REGION"
+	configKeyToEnvName[common.SESAccessKeyKey] = "PC_SES_ACCESS_KEY"
+	configKeyToEnvName[common.SESSecretKeyKey] = "PC_SES_SECRET_KEY"
+	configKeyToEnvName[common.MailgunDomainKey] = "PC_MAILGUN_DOMAIN"
+	configKeyToEnvName[common.MailgunAPIKeyKey] = "PC_MAILGUN_API_KEY"
+	configKeyToEnvName[common.MailgunEndpointKey] = "PC_MAILGUN_ENDPOINT"
+	configKeyToEnvName[common.HotPropertiesKey] = "PC_HOT_PROPERTIES"
 
 	for i, v := range configKeyToEnvName {
 		if len(v) == 0 {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
 	"net"
@@ -25,6 +26,19 @@ func AsInt(item common.ConfigItem, fallback int) int {
 	}
 }
 
+func AsFloat(item common.ConfigItem, fallback float64) float64 {
+	s := item.Value()
+	if len(s) == 0 {
+		return fallback
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err != nil {
+		return fallback
+	} else {
+		return f
+	}
+}
+
 func AsBool(item common.ConfigItem) bool {
 	return common.EnvToBool(item.Value())
 }
@@ -90,3 +104,32 @@ func AsURL(ctx context.Context, item common.ConfigItem) *urlConfig {
 
 	return &urlConfig{baseURL: baseURL, domain: domain}
 }
+
+var tlsCurves = map[string]tls.CurveID{
+	"x25519mlkem768": tls.X25519MLKEM768,
+	"x25519":         tls.X25519,
+	"p256":           tls.CurveP256,
+	"p384":           tls.CurveP384,
+	"p521":           tls.CurveP521,
+}
+
+// AsCurvePreferences parses comma-separated list of curve names (e.g. "X25519MLKEM768,X25519,P256").
+// Unknown names are skipped and nil is returned when nothing is configured (Go's defaults are used then)
+func AsCurvePreferences(ctx context.Context, item common.ConfigItem) []tls.CurveID {
+	var result []tls.CurveID
+
+	for _, name := range strings.Split(item.Value(), ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		if curve, ok := tlsCurves[strings.ToLower(strings.ReplaceAll(name, "-", ""))]; ok {
+			result = append(result, curve)
+		} else {
+			slog.WarnContext(ctx, "Skipping unknown TLS curve", "curve", name)
+		}
+	}
+
+	return result
+}
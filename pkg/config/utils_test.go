@@ -1,8 +1,13 @@
 package config
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"slices"
 	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
 
 func TestSplitHost(t *testing.T) {
@@ -26,3 +31,24 @@ func TestSplitHost(t *testing.T) {
 		})
 	}
 }
+
+func TestCurvePreferences(t *testing.T) {
+	testCases := []struct {
+		value  string
+		curves []tls.CurveID
+	}{
+		{"", nil},
+		{"X25519MLKEM768,X25519,P256", []tls.CurveID{tls.X25519MLKEM768, tls.X25519, tls.CurveP256}},
+		{" x25519 , P-384", []tls.CurveID{tls.X25519, tls.CurveP384}},
+		{"X448,P521", []tls.CurveID{tls.CurveP521}},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("curves_%v", i), func(t *testing.T) {
+			curves := AsCurvePreferences(context.TODO(), NewStaticValue(common.TLSCurvesKey, tc.value))
+			if !slices.Equal(curves, tc.curves) {
+				t.Errorf("Actual curves (%v) are different from expected (%v)", curves, tc.curves)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+// APIKeyUsage aggregates API key accesses into (key, day) counters so that we can show when key was last used.
+// Requests blocked by the IP allowlist of the key are aggregated too, but regardless of the usage being enabled.
+type APIKeyUsage struct {
+	querier       dbgen.Querier
+	persistCancel context.CancelFunc
+	enabled       atomic.Bool
+	lock          sync.Mutex
+	counts        map[int32]int64
+	violations    map[int32]int64
+}
+
+func NewAPIKeyUsage(querier dbgen.Querier) *APIKeyUsage {
+	return &APIKeyUsage{
+		querier:       querier,
+		persistCancel: func() {},
+		counts:        make(map[int32]int64),
+		violations:    make(map[int32]int64),
+	}
+}
+
+func (u *APIKeyUsage) Start(ctx context.Context, interval time.Duration) {
+	var cancelCtx context.Context
+	cancelCtx, u.persistCancel = context.WithCancel(
+		context.WithValue(ctx, common.TraceIDContextKey, "persist_apikey_usage"))
+
+	// unlike common.ProcessBatchMap(), flushing here does not depend on the rate of incoming records
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cancelCtx.Done():
+				return
+			case <-ticker.C:
+				_ = u.persist(cancelCtx)
+			}
+		}
+	}()
+}
+
+func (u *APIKeyUsage) Shutdown() {
+	slog.Debug("Shutting down persisting API key usage")
+	u.enabled.Store(false)
+	u.persistCancel()
+}
+
+func (u *APIKeyUsage) UpdateConfig(enabled bool) {
+	u.enabled.Store(enabled)
+}
+
+func (u *APIKeyUsage) Record(keyID int32) {
+	if !u.enabled.Load() {
+		return
+	}
+
+	u.lock.Lock()
+	u.counts[keyID]++
+	u.lock.Unlock()
+}
+
+// pending returns usage of the key that was recorded, but not persisted yet
+func (u *APIKeyUsage) pending(keyID int32) int64 {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	return u.counts[keyID]
+}
+
+// RecordIPViolation returns true only for the first violation of the key since the last flush, so that callers
+// can report violations without flooding (e.g. audit logs) when a leaked key is used in a loop
+func (u *APIKeyUsage) RecordIPViolation(keyID int32) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.violations[keyID]++
+
+	return u.violations[keyID] == 1
+}
+
+func (u *APIKeyUsage) persist(ctx context.Context) error {
+	u.lock.Lock()
+	batch := u.counts
+	if len(batch) > 0 {
+		u.counts = make(map[int32]int64, len(batch))
+	}
+	violations := u.violations
+	if len(violations) > 0 {
+		u.violations = make(map[int32]int64, len(violations))
+	}
+	u.lock.Unlock()
+
+	if u.querier == nil {
+		return nil
+	}
+
+	// violations are security-relevant, but counter on the key is still only informational (events are in audit logs)
+	// so failing to store them should not lose the usage batch that was already swapped out
+	violationsErr := u.persistIPViolations(ctx, violations)
+	usageErr := u.persistUsage(ctx, batch)
+
+	return errors.Join(violationsErr, usageErr)
+}
+
+func (u *APIKeyUsage) persistUsage(ctx context.Context, batch map[int32]int64) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	params := &dbgen.AddAPIKeysUsageParams{
+		Ids:    make([]int32, 0, len(batch)),
+		Counts: make([]int64, 0, len(batch)),
+	}
+
+	for keyID, count := range batch {
+		params.Ids = append(params.Ids, keyID)
+		params.Counts = append(params.Counts, count)
+	}
+
+	// usage is informational so on error this batch is simply lost
+	if err := u.querier.AddAPIKeysUsage(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to store API key usage", "count", len(batch), common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Stored API key usage", "count", len(batch))
+
+	return nil
+}
+
+func (u *APIKeyUsage) persistIPViolations(ctx context.Context, violations map[int32]int64) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	params := &dbgen.AddAPIKeysIPViolationsParams{
+		Ids:    make([]int32, 0, len(violations)),
+		Counts: make([]int64, 0, len(violations)),
+	}
+
+	for keyID, count := range violations {
+		params.Ids = append(params.Ids, keyID)
+		params.Counts = append(params.Counts, count)
+	}
+
+	if err := u.querier.AddAPIKeysIPViolations(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to store API key IP violations", "count", len(violations), common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Stored API key IP violations", "count", len(violations))
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+// only methods used by APIKeyUsage are implemented
+type apiKeyUsageQuerier struct {
+	dbgen.Querier
+	usage      int
+	violations int
+}
+
+func (q *apiKeyUsageQuerier) AddAPIKeysUsage(ctx context.Context, arg *dbgen.AddAPIKeysUsageParams) error {
+	q.usage += len(arg.Ids)
+	return nil
+}
+
+func (q *apiKeyUsageQuerier) AddAPIKeysIPViolations(ctx context.Context, arg *dbgen.AddAPIKeysIPViolationsParams) error {
+	q.violations += len(arg.Ids)
+	return errors.New("test error")
+}
+
+func TestAPIKeyUsageRecord(t *testing.T) {
+	usage := NewAPIKeyUsage(nil)
+
+	usage.Record(1)
+	if len(usage.counts) != 0 {
+		t.Fatalf("Usage was recorded while disabled")
+	}
+
+	usage.UpdateConfig(true)
+	for _, keyID := range []int32{1, 2, 1, 1} {
+		usage.Record(keyID)
+	}
+
+	if (usage.counts[1] != 3) || (usage.counts[2] != 1) {
+		t.Errorf("Unexpected usage counts: %v", usage.counts)
+	}
+
+	if err := usage.persist(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(usage.counts) != 0 {
+		t.Errorf("Usage was not reset after persisting: %v", usage.counts)
+	}
+}
+
+func TestAPIKeyUsageRecordIPViolation(t *testing.T) {
+	usage := NewAPIKeyUsage(nil)
+
+	// violations are recorded even when usage is disabled
+	if !usage.RecordIPViolation(1) {
+		t.Errorf("First violation was not reported")
+	}
+
+	if usage.RecordIPViolation(1) {
+		t.Errorf("Second violation was reported")
+	}
+
+	if !usage.RecordIPViolation(2) {
+		t.Errorf("First violation of another key was not reported")
+	}
+
+	if usage.violations[1] != 2 {
+		t.Errorf("Unexpected violations count: %v", usage.violations[1])
+	}
+
+	if err := usage.persist(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !usage.RecordIPViolation(1) {
+		t.Errorf("First violation after persisting was not reported")
+	}
+}
+
+func TestAPIKeyUsagePersistAfterViolationsError(t *testing.T) {
+	querier := &apiKeyUsageQuerier{}
+	usage := NewAPIKeyUsage(querier)
+	usage.UpdateConfig(true)
+
+	usage.Record(1)
+	usage.Record(2)
+	usage.RecordIPViolation(3)
+
+	if err := usage.persist(context.TODO()); err == nil {
+		t.Error("Violations error was not returned")
+	}
+
+	if (querier.violations != 1) || (querier.usage != 2) {
+		t.Errorf("Unexpected persisted counts: violations %v, usage %v", querier.violations, querier.usage)
+	}
+}
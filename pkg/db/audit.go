@@ -4,17 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"net/netip"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// AuditLogSink receives recorded audit log events in addition to DB (e.g. to stream them to external SIEM).
+// Send() is called on the request path so it is not expected to block.
+type AuditLogSink interface {
+	Send(ctx context.Context, event *Event)
+}
+
 type AuditLog struct {
 	querier       dbgen.Querier
 	persistChan   chan *common.AuditLogEvent
 	persistCancel context.CancelFunc
 	batchSize     int
+	sink          AuditLogSink
 }
 
 var _ common.AuditLog = (*AuditLog)(nil)
@@ -28,6 +38,11 @@ func NewAuditLog(querier dbgen.Querier, batchSize int) *AuditLog {
 	}
 }
 
+// SetSink is not expected to be called after audit log has started
+func (al *AuditLog) SetSink(sink AuditLogSink) {
+	al.sink = sink
+}
+
 func (al *AuditLog) Start(ctx context.Context, interval time.Duration) {
 	var cancelCtx context.Context
 	cancelCtx, al.persistCancel = context.WithCancel(
@@ -71,15 +86,21 @@ func (al *AuditLog) persistAuditLog(ctx context.Context, batch []*common.AuditLo
 			source = dbgen.AuditLogSourcePortal
 		case common.AuditLogSourceAPI:
 			source = dbgen.AuditLogSourceApi
+		case common.AuditLogSourceCLI:
+			source = dbgen.AuditLogSourceCli
+		case common.AuditLogSourceSystem:
+			source = dbgen.AuditLogSourceSystem
 		}
 
 		event := &dbgen.CreateAuditLogsParams{
-			UserID:      Int(e.UserID),
+			// system events are not made by any user
+			UserID:      pgtype.Int4{Int32: e.UserID, Valid: e.UserID != 0},
 			Action:      action,
 			Source:      source,
 			EntityID:    Int8(e.EntityID),
 			EntityTable: e.TableName,
 			SessionID:   e.SessionID,
+			TraceID:     e.TraceID,
 			OldValue:    nil,
 			NewValue:    nil,
 			CreatedAt:   Timestampz(e.Timestamp),
@@ -130,9 +151,36 @@ func (al *AuditLog) RecordEvents(ctx context.Context, events []*common.AuditLogE
 }
 
 func (al *AuditLog) RecordEvent(ctx context.Context, event *common.AuditLogEvent, source common.AuditLogSource) {
+	if !prepareAuditLogEvent(ctx, event, source) {
+		return
+	}
+
+	slog.DebugContext(ctx, "Queueing audit log event", "action", event.Action.String(), "table", event.TableName, "userID", event.UserID, "source", source.String())
+	al.persistChan <- event
+
+	if al.sink != nil {
+		if e := NewEvent(event); e != nil {
+			al.sink.Send(ctx, e)
+		}
+	}
+}
+
+// StoreEvents persists events right away instead of queueing them (for one-off tools that don't run the batching)
+func (al *AuditLog) StoreEvents(ctx context.Context, events []*common.AuditLogEvent, source common.AuditLogSource) error {
+	batch := make([]*common.AuditLogEvent, 0, len(events))
+	for _, event := range events {
+		if prepareAuditLogEvent(ctx, event, source) {
+			batch = append(batch, event)
+		}
+	}
+
+	return al.persistAuditLog(ctx, batch)
+}
+
+func prepareAuditLogEvent(ctx context.Context, event *common.AuditLogEvent, source common.AuditLogSource) bool {
 	if event == nil {
 		slog.ErrorContext(ctx, "Discarding nil audit log event")
-		return
+		return false
 	}
 
 	if (event.OldValue == nil) && (event.NewValue == nil) &&
@@ -142,7 +190,7 @@ func (al *AuditLog) RecordEvent(ctx context.Context, event *common.AuditLogEvent
 		slog.WarnContext(ctx, "Audit log event has no payload", "table", event.TableName, "entityID", event.EntityID, "action", event.Action.String())
 	}
 
-	if event.UserID == 0 {
+	if (event.UserID == 0) && (source != common.AuditLogSourceSystem) {
 		slog.ErrorContext(ctx, "Recording audit event without user ID", "table", event.TableName, "entityID", event.EntityID, "action", event.Action.String())
 	}
 
@@ -150,11 +198,12 @@ func (al *AuditLog) RecordEvent(ctx context.Context, event *common.AuditLogEvent
 		event.SessionID = sid
 	}
 
+	event.TraceID = common.TraceID(ctx)
+
 	event.Timestamp = time.Now().UTC()
 	event.Source = source
 
-	slog.DebugContext(ctx, "Queueing audit log event", "action", event.Action.String(), "table", event.TableName, "userID", event.UserID, "source", source.String())
-	al.persistChan <- event
+	return true
 }
 
 type DiscardAuditLog struct{}
@@ -255,6 +304,13 @@ func newUserAuditLogEvent(user *dbgen.User, subscription *dbgen.Subscription, ac
 	return event
 }
 
+// NewHardDeleteUserAuditLogEvent is recorded on behalf of the admin, because audit logs of the user are deleted too
+func NewHardDeleteUserAuditLogEvent(adminID int32, user *dbgen.User) *common.AuditLogEvent {
+	event := newUserAuditLogEvent(user, nil /*subscription*/, common.AuditLogActionDelete)
+	event.UserID = adminID
+	return event
+}
+
 func newUpdateUserSubscriptionEvent(user *dbgen.User, oldSubscription, newSubscription *dbgen.Subscription) *common.AuditLogEvent {
 	event := &common.AuditLogEvent{
 		UserID:    user.ID,
@@ -327,6 +383,22 @@ type AuditLogProperty struct {
 	MaxReplayCount      int32  `json:"max_replay_count,omitempty"`
 	AllowSubdomains     bool   `json:"allow_subdomains,omitempty"`
 	AllowLocalhost      bool   `json:"allow_localhost,omitempty"`
+	ClockSkewSec        int    `json:"clock_skew_s,omitempty"`
+	RememberSec         int    `json:"remember_s,omitempty"`
+	WidgetFlags         int    `json:"widget_flags,omitempty"`
+	Environment         string `json:"environment,omitempty"`
+	TwinID              int32  `json:"twin_id,omitempty"`
+	TrustGroup          string `json:"trust_group,omitempty"`
+	Claims              string `json:"claims,omitempty"`
+	Differential        bool   `json:"differential,omitempty"`
+	BotPolicy           string `json:"bot_policy,omitempty"`
+	FailureURL          string `json:"failure_url,omitempty"`
+	FailureMessage      string `json:"failure_message,omitempty"`
+	EmergencyUntil      string `json:"emergency_until,omitempty"`
+	DifficultyStrategy  string `json:"difficulty_strategy,omitempty"`
+	PrivacyMode         string `json:"privacy_mode,omitempty"`
+	// only set for property moves between orgs
+	MoveLimits *PropertyMoveLimits `json:"move_limits,omitempty"`
 }
 
 func newAuditLogProperty(property *dbgen.Property, org *dbgen.Organization) *AuditLogProperty {
@@ -346,6 +418,18 @@ func newAuditLogProperty(property *dbgen.Property, org *dbgen.Organization) *Aud
 		MaxReplayCount:      property.MaxReplayCount,
 		AllowSubdomains:     property.AllowSubdomains,
 		AllowLocalhost:      property.AllowLocalhost,
+		ClockSkewSec:        int(property.AllowedClockSkew.Seconds()),
+		RememberSec:         int(property.RememberWindow.Seconds()),
+		WidgetFlags:         int(property.WidgetFlags),
+		Environment:         string(property.Environment),
+		TwinID:              property.TwinID.Int32,
+		TrustGroup:          property.TrustGroup,
+		Claims:              property.Claims,
+		Differential:        property.DifferentialDifficulty,
+		BotPolicy:           string(property.BotPolicy),
+		FailureURL:          property.FailureURL,
+		FailureMessage:      property.FailureMessage,
+		DifficultyStrategy:  property.DifficultyStrategy,
 	}
 
 	if org != nil {
@@ -372,6 +456,18 @@ func newAuditLogOldProperty(property *dbgen.Property, updateRow *dbgen.UpdatePro
 		MaxReplayCount:      updateRow.OldMaxReplayCount,
 		AllowSubdomains:     updateRow.OldAllowSubdomains,
 		AllowLocalhost:      updateRow.OldAllowLocalhost,
+		ClockSkewSec:        int(updateRow.OldAllowedClockSkew.Seconds()),
+		RememberSec:         int(updateRow.OldRememberWindow.Seconds()),
+		WidgetFlags:         int(updateRow.OldWidgetFlags),
+		Environment:         string(property.Environment),
+		TwinID:              updateRow.OldTwinID.Int32,
+		TrustGroup:          updateRow.OldTrustGroup,
+		Claims:              updateRow.OldClaims,
+		Differential:        updateRow.OldDifferentialDifficulty,
+		BotPolicy:           string(updateRow.OldBotPolicy),
+		FailureURL:          updateRow.OldFailureURL,
+		FailureMessage:      updateRow.OldFailureMessage,
+		DifficultyStrategy:  updateRow.OldDifficultyStrategy,
 	}
 
 	if org != nil {
@@ -476,6 +572,207 @@ func newOrgMemberAuditLogEvent(orgID int32, orgName string, user *dbgen.User, ac
 	}
 }
 
+type AuditLogBillingContact struct {
+	OrgName     string `json:"org_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	NotifyOwner bool   `json:"notify_owner,omitempty"`
+}
+
+func newBillingContactAuditLogEvent(user *dbgen.User, org *dbgen.Organization, contact *dbgen.BillingContact, action common.AuditLogAction) *common.AuditLogEvent {
+	event := &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    action,
+		EntityID:  int64(org.ID),
+		TableName: TableNameBillingContacts,
+		OldValue:  nil,
+		NewValue:  nil,
+	}
+
+	value := &AuditLogBillingContact{OrgName: org.Name, Email: contact.Email}
+
+	switch action {
+	case common.AuditLogActionCreate:
+		event.NewValue = value
+	case common.AuditLogActionDelete:
+		event.OldValue = value
+	}
+
+	return event
+}
+
+func newBillingSettingsAuditLogEvent(user *dbgen.User, org *dbgen.Organization, oldNotifyOwner, newNotifyOwner bool) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    common.AuditLogActionUpdate,
+		EntityID:  int64(org.ID),
+		TableName: TableNameBillingContacts,
+		OldValue:  &AuditLogBillingContact{OrgName: org.Name, NotifyOwner: oldNotifyOwner},
+		NewValue:  &AuditLogBillingContact{OrgName: org.Name, NotifyOwner: newNotifyOwner},
+	}
+}
+
+type AuditLogOrgIPAllowlist struct {
+	OrgName string   `json:"org_name,omitempty"`
+	CIDRs   []string `json:"cidrs,omitempty"`
+	// allowlist was disabled using emailed recovery link
+	Recovery bool `json:"recovery,omitempty"`
+}
+
+func newOrgIPAllowlistAuditLogEvent(user *dbgen.User, org *dbgen.Organization, oldCIDRs, newCIDRs []string, recovery bool) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    common.AuditLogActionUpdate,
+		EntityID:  int64(org.ID),
+		TableName: TableNameOrgIPAllowlists,
+		OldValue:  &AuditLogOrgIPAllowlist{OrgName: org.Name, CIDRs: oldCIDRs},
+		NewValue:  &AuditLogOrgIPAllowlist{OrgName: org.Name, CIDRs: newCIDRs, Recovery: recovery},
+	}
+}
+
+type AuditLogOrgEmailDomain struct {
+	OrgName  string `json:"org_name,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Verified bool   `json:"verified,omitempty"`
+	AutoJoin bool   `json:"auto_join,omitempty"`
+}
+
+func newAuditLogOrgEmailDomain(org *dbgen.Organization, domain *dbgen.OrgEmailDomain) *AuditLogOrgEmailDomain {
+	return &AuditLogOrgEmailDomain{
+		OrgName:  org.Name,
+		Domain:   domain.Domain,
+		Verified: domain.VerifiedAt.Valid,
+		AutoJoin: domain.AutoJoin,
+	}
+}
+
+func newOrgEmailDomainAuditLogEvent(user *dbgen.User, org *dbgen.Organization, oldDomain, newDomain *dbgen.OrgEmailDomain, action common.AuditLogAction) *common.AuditLogEvent {
+	event := &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    action,
+		EntityID:  int64(org.ID),
+		TableName: TableNameOrgEmailDomains,
+		OldValue:  nil,
+		NewValue:  nil,
+	}
+
+	if oldDomain != nil {
+		event.OldValue = newAuditLogOrgEmailDomain(org, oldDomain)
+	}
+
+	if newDomain != nil {
+		event.NewValue = newAuditLogOrgEmailDomain(org, newDomain)
+	}
+
+	return event
+}
+
+type AuditLogPropertyAccessList struct {
+	PropertyName string          `json:"property_name,omitempty"`
+	IPAllowlist  []string        `json:"ip_allowlist,omitempty"`
+	IPDenylist   []string        `json:"ip_denylist,omitempty"`
+	ASNDenylist  []int64         `json:"asn_denylist,omitempty"`
+	Policy       dbgen.BotPolicy `json:"policy,omitempty"`
+}
+
+func newAuditLogPropertyAccessList(property *dbgen.Property, list *dbgen.PropertyAccessList) *AuditLogPropertyAccessList {
+	result := &AuditLogPropertyAccessList{PropertyName: property.Name}
+	if list != nil {
+		result.IPAllowlist = list.IpAllowlist
+		result.IPDenylist = list.IpDenylist
+		result.ASNDenylist = list.AsnDenylist
+		result.Policy = list.Policy
+	}
+
+	return result
+}
+
+func newPropertyAccessListAuditLogEvent(user *dbgen.User, property *dbgen.Property, oldList, newList *dbgen.PropertyAccessList) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    common.AuditLogActionUpdate,
+		EntityID:  int64(property.ID),
+		TableName: TableNamePropertyAccessLists,
+		OldValue:  newAuditLogPropertyAccessList(property, oldList),
+		NewValue:  newAuditLogPropertyAccessList(property, newList),
+	}
+}
+
+type AuditLogPropertyShareLink struct {
+	PropertyName string          `json:"property_name,omitempty"`
+	ExpiresAt    common.JSONTime `json:"expires_at,omitempty"`
+}
+
+func newPropertyShareLinkAuditLogEvent(user *dbgen.User, property *dbgen.Property, link *dbgen.PropertyShareLink, action common.AuditLogAction) *common.AuditLogEvent {
+	payload := &AuditLogPropertyShareLink{
+		PropertyName: property.Name,
+		ExpiresAt:    common.JSONTime(link.ExpiresAt.Time),
+	}
+
+	event := &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    action,
+		EntityID:  int64(property.ID),
+		TableName: TableNamePropertyShareLinks,
+	}
+
+	if action == common.AuditLogActionDelete {
+		event.OldValue = payload
+	} else {
+		event.NewValue = payload
+	}
+
+	return event
+}
+
+type AuditLogPropertyBypassToken struct {
+	PropertyName string          `json:"property_name,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	ExpiresAt    common.JSONTime `json:"expires_at,omitempty"`
+	MaxUses      int32           `json:"max_uses,omitempty"`
+	Uses         int32           `json:"uses,omitempty"`
+}
+
+func newAuditLogPropertyBypassToken(property *dbgen.Property, token *dbgen.PropertyBypassToken) *AuditLogPropertyBypassToken {
+	return &AuditLogPropertyBypassToken{
+		PropertyName: property.Name,
+		Name:         token.Name,
+		ExpiresAt:    common.JSONTime(token.ExpiresAt.Time),
+		MaxUses:      token.MaxUses,
+		Uses:         token.Uses,
+	}
+}
+
+func newPropertyBypassTokenAuditLogEvent(user *dbgen.User, property *dbgen.Property, token *dbgen.PropertyBypassToken, action common.AuditLogAction) *common.AuditLogEvent {
+	payload := newAuditLogPropertyBypassToken(property, token)
+
+	event := &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    action,
+		EntityID:  int64(property.ID),
+		TableName: TableNamePropertyBypassTokens,
+	}
+
+	if action == common.AuditLogActionDelete {
+		event.OldValue = payload
+	} else {
+		event.NewValue = payload
+	}
+
+	return event
+}
+
+// NewBypassTokenUsedAuditLogEvent flags verification that was done with a bypass token instead of solving a puzzle
+// (usage counter of the token is updated)
+func NewBypassTokenUsedAuditLogEvent(userID int32, property *dbgen.Property, token *dbgen.PropertyBypassToken) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		UserID:    userID,
+		Action:    common.AuditLogActionUpdate,
+		EntityID:  int64(property.ID),
+		TableName: TableNamePropertyBypassTokens,
+		NewValue:  newAuditLogPropertyBypassToken(property, token),
+	}
+}
+
 type AuditLogAPIKey struct {
 	Name              string          `json:"name,omitempty"`
 	ExternalID        string          `json:"external_id,omitempty"`
@@ -488,6 +785,7 @@ type AuditLogAPIKey struct {
 	Scope             string          `json:"scope,omitempty"`
 	OrgName           string          `json:"org_name,omitempty"`
 	ReadOnly          bool            `json:"readonly,omitempty"`
+	AllowedCIDRs      []string        `json:"allowed_cidrs,omitempty"`
 }
 
 func newAuditLogAPIKey(key *dbgen.APIKey, orgName string) *AuditLogAPIKey {
@@ -507,6 +805,7 @@ func newAuditLogAPIKey(key *dbgen.APIKey, orgName string) *AuditLogAPIKey {
 		Scope:             string(key.Scope),
 		OrgName:           orgName,
 		ReadOnly:          key.Readonly,
+		AllowedCIDRs:      key.AllowedCidrs,
 	}
 }
 
@@ -550,14 +849,174 @@ func newUpdateAPIKeyAuditLogEvent(user *dbgen.User, oldAPIKey, newAPIKey *dbgen.
 	}
 }
 
-func newMovePropertyAuditLogEvent(user *dbgen.User, property *dbgen.Property, oldOrgID, newOrgID int32) *common.AuditLogEvent {
+type AuditLogAPIKeyIPViolation struct {
+	Name string `json:"name,omitempty"`
+	IP   string `json:"ip,omitempty"`
+}
+
+// this event is "created" on behalf of the key owner, even though it's not the owner who made the request
+func newAPIKeyIPViolationAuditLogEvent(apiKey *dbgen.APIKey, addr netip.Addr) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		UserID:    apiKey.UserID.Int32,
+		Action:    common.AuditLogActionCreate,
+		EntityID:  int64(apiKey.ID),
+		TableName: TableNameAPIKeyIPViolations,
+		NewValue:  &AuditLogAPIKeyIPViolation{Name: apiKey.Name, IP: addr.String()},
+	}
+}
+
+func newMovePropertyAuditLogEvent(user *dbgen.User, property *dbgen.Property, oldOrg *dbgen.Organization, newOrg *dbgen.Organization, oldOwnerID int32, limits *PropertyMoveLimits) *common.AuditLogEvent {
+	oldValue := &AuditLogProperty{Name: property.Name, OrgOwnerID: oldOwnerID}
+	if oldOrg != nil {
+		oldValue.OrgID = oldOrg.ID
+		oldValue.OrgName = oldOrg.Name
+	}
+
 	return &common.AuditLogEvent{
 		UserID:    user.ID,
 		Action:    common.AuditLogActionUpdate,
 		EntityID:  int64(property.ID),
 		TableName: TableNameProperties,
-		OldValue:  &AuditLogProperty{OrgID: oldOrgID},
-		NewValue:  &AuditLogProperty{OrgID: newOrgID},
+		OldValue:  oldValue,
+		NewValue: &AuditLogProperty{
+			Name:       property.Name,
+			OrgID:      newOrg.ID,
+			OrgName:    newOrg.Name,
+			OrgOwnerID: property.OrgOwnerID.Int32,
+			MoveLimits: limits,
+		},
+	}
+}
+
+func emergencyAuditValue(until pgtype.Timestamptz) string {
+	if !until.Valid {
+		return ""
+	}
+
+	return until.Time.UTC().Format(time.RFC3339)
+}
+
+func newPropertyEmergencyAuditLogEvent(user *dbgen.User, property *dbgen.Property, oldUntil pgtype.Timestamptz, org *dbgen.Organization) *common.AuditLogEvent {
+	oldValue := &AuditLogProperty{Name: property.Name, EmergencyUntil: emergencyAuditValue(oldUntil)}
+	newValue := &AuditLogProperty{Name: property.Name, EmergencyUntil: emergencyAuditValue(property.EmergencyUntil)}
+	if org != nil {
+		oldValue.OrgName = org.Name
+		newValue.OrgName = org.Name
+	}
+
+	return &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    common.AuditLogActionUpdate,
+		EntityID:  int64(property.ID),
+		TableName: TableNameProperties,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+}
+
+func privacyModeAuditValue(mode string) string {
+	if len(mode) == 0 {
+		return "default"
+	}
+
+	return mode
+}
+
+func newPropertyPrivacyModeAuditLogEvent(user *dbgen.User, property *dbgen.Property, oldMode string, org *dbgen.Organization) *common.AuditLogEvent {
+	oldValue := &AuditLogProperty{Name: property.Name, PrivacyMode: privacyModeAuditValue(oldMode)}
+	newValue := &AuditLogProperty{Name: property.Name, PrivacyMode: privacyModeAuditValue(property.PrivacyMode)}
+	if org != nil {
+		oldValue.OrgName = org.Name
+		newValue.OrgName = org.Name
+	}
+
+	return &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    common.AuditLogActionUpdate,
+		EntityID:  int64(property.ID),
+		TableName: TableNameProperties,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+}
+
+type AuditLogPlanDowngrade struct {
+	FromPlan          string   `json:"from_plan,omitempty"`
+	ToPlan            string   `json:"to_plan,omitempty"`
+	ExternalProductID string   `json:"external_product_id,omitempty"`
+	ExternalPriceID   string   `json:"external_price_id,omitempty"`
+	FrozenProperties  int      `json:"frozen_properties,omitempty"`
+	OrgsOverLimit     int      `json:"orgs_over_limit,omitempty"`
+	APIKeysOverLimit  int      `json:"apikeys_over_limit,omitempty"`
+	LostFeatures      []string `json:"lost_features,omitempty"`
+}
+
+// NewPlanDowngradeAuditLogEvent records that user acknowledged the impact of the downgrade before it was requested
+func NewPlanDowngradeAuditLogEvent(user *dbgen.User, subscription *dbgen.Subscription, fromPlan, toPlan billing.Plan, priceID string, impact *DowngradeImpact) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		UserID:    user.ID,
+		Action:    common.AuditLogActionCreate,
+		EntityID:  int64(subscription.ID),
+		TableName: TableNamePlanDowngrades,
+		NewValue: &AuditLogPlanDowngrade{
+			FromPlan:          fromPlan.Name(),
+			ToPlan:            toPlan.Name(),
+			ExternalProductID: toPlan.ProductID(),
+			ExternalPriceID:   priceID,
+			FrozenProperties:  impact.PropertiesOverLimit(),
+			OrgsOverLimit:     impact.OrgsOverLimit(),
+			APIKeysOverLimit:  len(impact.APIKeys),
+			LostFeatures:      impact.LostFeatures(),
+		},
+	}
+}
+
+type AuditLogUserDevice struct {
+	Browser string `json:"browser,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// NewUserDeviceAuditLogEvent records sign-in from a device that user did not use before (create) or that user
+// removed from known devices (delete)
+func NewUserDeviceAuditLogEvent(userID int32, device *dbgen.UserDevice, action common.AuditLogAction) *common.AuditLogEvent {
+	payload := &AuditLogUserDevice{
+		Browser: device.Browser,
+		OS:      device.Os,
+		Country: device.Country,
+	}
+
+	event := &common.AuditLogEvent{
+		UserID:    userID,
+		Action:    action,
+		EntityID:  int64(device.ID),
+		TableName: TableNameUserDevices,
+	}
+
+	if action == common.AuditLogActionDelete {
+		event.OldValue = payload
+	} else {
+		event.NewValue = payload
+	}
+
+	return event
+}
+
+type AuditLogUserSSOLink struct {
+	Issuer  string `json:"issuer,omitempty"`
+	Subject string `json:"subject,omitempty"`
+}
+
+func NewUserSSOLinkAuditLogEvent(userID int32, link *dbgen.UserSSOLink) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		UserID:    userID,
+		Action:    common.AuditLogActionCreate,
+		EntityID:  int64(link.ID),
+		TableName: TableNameUserSSOLinks,
+		NewValue: &AuditLogUserSSOLink{
+			Issuer:  link.Issuer,
+			Subject: link.Subject,
+		},
 	}
 }
 
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"time"
+)
+
+// ClickHouseBackfill populates data that schema migration (DDL only) cannot, e.g. a new rollup table from an
+// existing one. Backfills are executed by a maintenance job in chunks of time, from the oldest data forward,
+// with progress checkpoints so that large installs do not need to run manual scripts during upgrade.
+type ClickHouseBackfill struct {
+	// unique name, used for progress checkpoints
+	Name string
+	// migration version that creates target table (backfill waits until it is applied)
+	Version uint
+	// target table is used to find where data inserted by materialized views begins (nothing is backfilled after it),
+	// data of the chunk is deleted from it before the query runs so that interrupted chunks can be repeated
+	Table string
+	// usually INSERT ... SELECT with {from:DateTime} and {to:DateTime} parameters of the processed chunk
+	Query string
+	// how far back in time data is backfilled
+	Depth time.Duration
+	// time range processed with a single query (and how target table's timestamps are aligned)
+	Chunk time.Duration
+}
+
+// ClickHouseBackfills have to be added together with migrations that need them and kept in the order of versions
+var ClickHouseBackfills = []*ClickHouseBackfill{}
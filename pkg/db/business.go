@@ -23,6 +23,8 @@ var (
 	ErrMaintenance        = errors.New("maintenance mode")
 	ErrTestProperty       = errors.New("test property")
 	ErrPermissions        = errors.New("insufficient permissions")
+	ErrInvalidTwin        = errors.New("invalid property twin")
+	ErrAlreadyLinked      = errors.New("identity is already linked to another account")
 	errInvalidCacheType   = errors.New("cache record type does not match")
 	TestPropertySitekey   = strings.ReplaceAll(TestPropertyID, "-", "")
 	PortalLoginSitekey    = strings.ReplaceAll(PortalLoginPropertyID, "-", "")
@@ -31,15 +33,23 @@ var (
 )
 
 const (
-	PortalLoginPropertyID    = "1ca8041a-5761-40a4-addf-f715a991bfea"
-	PortalRegisterPropertyID = "8981be7a-3a71-414d-bb74-e7b4456603fd"
-	TestPropertyID           = "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
-	defaultCacheTTL          = 15 * time.Minute
-	defaultCacheRefresh      = 30 * time.Minute
-	negativeCacheTTL         = 5 * time.Minute
-	auditBatchSize           = 100
+	PortalLoginPropertyID     = "1ca8041a-5761-40a4-addf-f715a991bfea"
+	PortalRegisterPropertyID  = "8981be7a-3a71-414d-bb74-e7b4456603fd"
+	TestPropertyID            = "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+	defaultCacheTTL           = 15 * time.Minute
+	defaultCacheRefresh       = 30 * time.Minute
+	negativeCacheTTL          = 5 * time.Minute
+	auditBatchSize            = 100
+	defaultMaxCacheSize       = 1_000_000
+	defaultMaxPuzzleCacheSize = 500_000
+	apiKeyUsageInterval       = 1 * time.Minute
+	// remembered puzzles counters share the cache with verified puzzles so keys have to differ
+	rememberedPuzzleKeyMask uint64 = 0x52454d454d424552
 )
 
+// PropertyListener is notified after a property was updated or deleted by this process
+type PropertyListener func(ctx context.Context, sitekey string)
+
 type BusinessStore struct {
 	Pool            *pgxpool.Pool
 	defaultImpl     *BusinessStoreImpl
@@ -47,9 +57,13 @@ type BusinessStore struct {
 	Cache           common.Cache[CacheKey, any]
 	auditLog        *AuditLog
 	discardAuditLog *DiscardAuditLog
+	apiKeyUsage     *APIKeyUsage
 	// this could have been a bloom/cuckoo filter with expiration, if they existed
 	puzzleCache     *puzzleCache
 	MaintenanceMode atomic.Bool
+	// standby deployments read from a streaming replica where any write fails
+	ReadOnly         atomic.Bool
+	propertyListener *atomic.Pointer[PropertyListener]
 }
 
 type Implementor interface {
@@ -58,6 +72,8 @@ type Implementor interface {
 	Ping(ctx context.Context) error
 	CheckVerifiedPuzzle(ctx context.Context, p puzzle.Puzzle, maxCount uint32) bool
 	CacheVerifiedPuzzle(ctx context.Context, p puzzle.Puzzle, tnow time.Time)
+	BurnPuzzle(ctx context.Context, p puzzle.Puzzle, tnow time.Time)
+	CountRememberedPuzzle(ctx context.Context, p puzzle.Puzzle, maxCount uint32, ttl time.Duration) bool
 	CheckUserPropertyAccess(ctx context.Context, property *dbgen.Property, userID int32) bool
 	CacheHitRatio() float64
 	AuditLog() common.AuditLog
@@ -66,7 +82,11 @@ type Implementor interface {
 var _ Implementor = (*BusinessStore)(nil)
 
 func NewBusiness(pool *pgxpool.Pool) *BusinessStore {
-	const maxCacheSize = 1_000_000
+	return NewBusinessWithFootprint(pool, nil /*footprint*/)
+}
+
+func NewBusinessWithFootprint(pool *pgxpool.Pool, footprint *common.Footprint) *BusinessStore {
+	maxCacheSize := footprint.Size(defaultMaxCacheSize)
 	var cache common.Cache[CacheKey, any]
 	var err error
 	cache, err = NewMemoryCache[CacheKey, any]("default", maxCacheSize, &struct{}{}, defaultCacheTTL, defaultCacheRefresh, negativeCacheTTL)
@@ -75,25 +95,42 @@ func NewBusiness(pool *pgxpool.Pool) *BusinessStore {
 		cache = NewStaticCache[CacheKey, any](maxCacheSize, &struct{}{})
 	}
 
-	return NewBusinessEx(pool, cache)
+	if sc, ok := cache.(interface{ Len() int }); ok {
+		footprint.Track("business_cache", maxCacheSize, sc.Len)
+	}
+
+	return newBusinessStore(pool, cache, footprint)
 }
 
 func NewBusinessEx(pool *pgxpool.Pool, cache common.Cache[CacheKey, any]) *BusinessStore {
+	return newBusinessStore(pool, cache, nil /*footprint*/)
+}
+
+func newBusinessStore(pool *pgxpool.Pool, cache common.Cache[CacheKey, any], footprint *common.Footprint) *BusinessStore {
 	var querier dbgen.Querier
 	if pool != nil {
 		querier = dbgen.New(pool)
 	}
 
-	auditLog := NewAuditLog(querier, auditBatchSize)
+	auditLog := NewAuditLog(querier, footprint.Size(auditBatchSize))
+	apiKeyUsage := NewAPIKeyUsage(querier)
+
+	maxPuzzleCacheSize := footprint.Size(defaultMaxPuzzleCacheSize)
+	puzzleCache := newPuzzleCache(puzzle.DefaultValidityPeriod, maxPuzzleCacheSize)
+	footprint.Track("verified_puzzles", maxPuzzleCacheSize, puzzleCache.Len)
+
+	propertyListener := &atomic.Pointer[PropertyListener]{}
 
 	return &BusinessStore{
-		Pool:            pool,
-		auditLog:        auditLog,
-		discardAuditLog: &DiscardAuditLog{},
-		defaultImpl:     &BusinessStoreImpl{cache: cache, querier: querier},
-		cacheOnlyImpl:   &BusinessStoreImpl{cache: cache},
-		Cache:           cache,
-		puzzleCache:     newPuzzleCache(puzzle.DefaultValidityPeriod),
+		Pool:             pool,
+		auditLog:         auditLog,
+		discardAuditLog:  &DiscardAuditLog{},
+		apiKeyUsage:      apiKeyUsage,
+		defaultImpl:      &BusinessStoreImpl{cache: cache, querier: querier, apiKeyUsage: apiKeyUsage, propertyListener: propertyListener},
+		cacheOnlyImpl:    &BusinessStoreImpl{cache: cache, propertyListener: propertyListener},
+		Cache:            cache,
+		puzzleCache:      puzzleCache,
+		propertyListener: propertyListener,
 	}
 }
 
@@ -101,8 +138,25 @@ func (s *BusinessStore) UpdateConfig(maintenanceMode bool) {
 	s.MaintenanceMode.Store(maintenanceMode)
 }
 
+func (s *BusinessStore) UpdateAPIKeyUsage(enabled bool) {
+	s.apiKeyUsage.UpdateConfig(enabled)
+}
+
+// PendingAPIKeyUsage returns number of requests with the key since usage was last persisted
+func (s *BusinessStore) PendingAPIKeyUsage(keyID int32) int64 {
+	return s.apiKeyUsage.pending(keyID)
+}
+
+func (s *BusinessStore) SetAuditLogSink(sink AuditLogSink) {
+	s.auditLog.SetSink(sink)
+}
+
+func (s *BusinessStore) SetPropertyListener(listener PropertyListener) {
+	s.propertyListener.Store(&listener)
+}
+
 func (s *BusinessStore) AuditLog() common.AuditLog {
-	if s.MaintenanceMode.Load() {
+	if s.MaintenanceMode.Load() || s.ReadOnly.Load() {
 		return s.discardAuditLog
 	}
 
@@ -119,10 +173,12 @@ func (s *BusinessStore) Impl() *BusinessStoreImpl {
 
 func (s *BusinessStore) Start(ctx context.Context, auditLogInterval time.Duration) {
 	s.auditLog.Start(ctx, auditLogInterval)
+	s.apiKeyUsage.Start(ctx, apiKeyUsageInterval)
 }
 
 func (s *BusinessStore) Shutdown() {
 	s.auditLog.Shutdown()
+	s.apiKeyUsage.Shutdown()
 }
 
 func (s *BusinessStore) WithTx(ctx context.Context, fn func(*BusinessStoreImpl) ([]*common.AuditLogEvent, error)) ([]*common.AuditLogEvent, error) {
@@ -144,7 +200,7 @@ func (s *BusinessStore) WithTx(ctx context.Context, fn func(*BusinessStoreImpl)
 
 	db := dbgen.New(s.Pool)
 	tmpCache := NewTxCache()
-	impl := &BusinessStoreImpl{cache: tmpCache, querier: db.WithTx(tx)}
+	impl := &BusinessStoreImpl{cache: tmpCache, querier: db.WithTx(tx), propertyListener: s.propertyListener}
 	var auditEvents []*common.AuditLogEvent
 
 	auditEvents, err = fn(impl)
@@ -199,6 +255,34 @@ func (s *BusinessStore) CacheVerifiedPuzzle(ctx context.Context, p puzzle.Puzzle
 	slog.Log(ctx, common.LevelTrace, "Cached verified puzzle", "times", value)
 }
 
+// BurnPuzzle makes puzzle p fail all further verifications regardless of the allowed replay count
+func (s *BusinessStore) BurnPuzzle(ctx context.Context, p puzzle.Puzzle, tnow time.Time) {
+	if p == nil || p.IsZero() {
+		return
+	}
+
+	expiration := p.Expiration()
+	if !tnow.Before(expiration) {
+		return
+	}
+
+	s.puzzleCache.Burn(ctx, p.HashKey(), expiration.Sub(tnow))
+	slog.Log(ctx, common.LevelTrace, "Burned puzzle", "puzzleID", p.PuzzleID())
+}
+
+// CountRememberedPuzzle counts remembered puzzles issued based on the solved puzzle p and returns false if
+// more than maxCount were issued already
+func (s *BusinessStore) CountRememberedPuzzle(ctx context.Context, p puzzle.Puzzle, maxCount uint32, ttl time.Duration) bool {
+	if p == nil || p.IsZero() {
+		return false
+	}
+
+	value := s.puzzleCache.Inc(ctx, p.HashKey()^rememberedPuzzleKeyMask, ttl)
+	slog.Log(ctx, common.LevelTrace, "Counted remembered puzzle", "puzzleID", p.PuzzleID(), "times", value)
+
+	return value <= maxCount
+}
+
 func (s *BusinessStore) CheckUserPropertyAccess(ctx context.Context, property *dbgen.Property, userID int32) bool {
 	_, level, err := s.cacheOnlyImpl.retrieveOrganizationWithAccess(ctx, userID, property.OrgID.Int32)
 	if (err == nil) && level.Valid {
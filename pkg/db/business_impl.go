@@ -6,9 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"net/netip"
 	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -27,8 +30,43 @@ const (
 	asyncTaskTTL             = 1 * time.Minute
 	MaxOrgPropertiesPageSize = 50
 	orgPropertiesCacheKeyStr = "0" // "0" as in "first page"
+	MaxOrgUsersPageSize      = 50
+	orgUsersPageCacheKeyStr  = "0"
+	orgUsersPageTTL          = 1 * time.Minute
+	maxOrgUsersEmailFilter   = 255
 )
 
+const (
+	OrgUsersSortJoined = "joined"
+	OrgUsersSortEmail  = "email"
+	OrgUsersSortName   = "name"
+	OrgUsersSortLevel  = "level"
+)
+
+// OrgUsersQuery is a filter and sort order for the page of organization members
+type OrgUsersQuery struct {
+	Level      dbgen.AccessLevel
+	Email      string
+	SortBy     string
+	Descending bool
+}
+
+func (q *OrgUsersQuery) isDefault() bool {
+	return (len(q.Level) == 0) && (len(q.Email) == 0) && ((len(q.SortBy) == 0) || (q.SortBy == OrgUsersSortJoined)) && !q.Descending
+}
+
+func (q *OrgUsersQuery) cacheKeyStr(offset, limit int) string {
+	if (offset == 0) && q.isDefault() {
+		return orgUsersPageCacheKeyStr
+	}
+
+	return fmt.Sprintf("%d/%d/%s/%s/%t/%s", offset, limit, q.Level, q.SortBy, q.Descending, q.Email)
+}
+
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 var (
 	errTransactionCache = errors.New("cache is not supported during transaction")
 	// shortcuts for nullable access levels
@@ -118,8 +156,10 @@ func (c *TxCache) Commit(ctx context.Context, cache common.Cache[CacheKey, any])
 }
 
 type BusinessStoreImpl struct {
-	querier dbgen.Querier
-	cache   common.Cache[CacheKey, any]
+	querier          dbgen.Querier
+	cache            common.Cache[CacheKey, any]
+	apiKeyUsage      *APIKeyUsage
+	propertyListener *atomic.Pointer[PropertyListener]
 }
 
 func (impl *BusinessStoreImpl) RetrieveFromCache(ctx context.Context, key string) ([]byte, error) {
@@ -163,6 +203,67 @@ func (impl *BusinessStoreImpl) StoreInCache(ctx context.Context, key string, dat
 	return nil
 }
 
+// CompareAndSwapInCache replaces cached value only if it was not changed since it was read
+func (impl *BusinessStoreImpl) CompareAndSwapInCache(ctx context.Context, key string, oldData, newData []byte) (bool, error) {
+	if (len(key) == 0) || (len(oldData) == 0) || (len(newData) == 0) {
+		return false, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return false, ErrMaintenance
+	}
+
+	rows, err := impl.querier.CompareAndSwapCache(ctx, &dbgen.CompareAndSwapCacheParams{
+		Key:      key,
+		OldValue: oldData,
+		NewValue: newData,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to swap cached value", "key", key, common.ErrAttr(err))
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// TakeFromCache atomically reads and deletes cached value, so that only one caller can get it
+func (impl *BusinessStoreImpl) TakeFromCache(ctx context.Context, key string) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	data, err := impl.querier.TakeCachedByKey(ctx, key)
+	if err == pgx.ErrNoRows {
+		return nil, ErrCacheMiss
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Failed to take from cache", "key", key, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteFromCache(ctx context.Context, key string) error {
+	if len(key) == 0 {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteCachedByKey(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete from cache", "key", key, common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
 func (impl *BusinessStoreImpl) ping(ctx context.Context) error {
 	if impl.querier == nil {
 		return ErrMaintenance
@@ -181,7 +282,11 @@ func (impl *BusinessStoreImpl) DeleteExpiredCache(ctx context.Context) error {
 		return ErrMaintenance
 	}
 
-	return impl.querier.DeleteExpiredCache(ctx)
+	if err := impl.querier.DeleteExpiredCache(ctx); err != nil {
+		return err
+	}
+
+	return impl.querier.DeleteExpiredUserSessions(ctx)
 }
 
 func (impl *BusinessStoreImpl) CreateNewSubscription(ctx context.Context, params *dbgen.CreateSubscriptionParams) (*dbgen.Subscription, error) {
@@ -277,6 +382,7 @@ func (impl *BusinessStoreImpl) CreateNewOrganization(ctx context.Context, name s
 
 		// invalidate user orgs in cache as we just created another one
 		_ = impl.cache.Delete(ctx, userOrgsCacheKey(org.UserID.Int32))
+		impl.onOrgsChanged(ctx, nil /*orgs*/, userID)
 
 		auditEvent = newOrgAuditLogEvent(userID, org, common.AuditLogActionCreate)
 	}
@@ -297,6 +403,16 @@ func (impl *BusinessStoreImpl) SoftDeleteUser(ctx context.Context, user *dbgen.U
 		slog.InfoContext(ctx, "Soft-deleted user", "userID", user.ID)
 	}
 
+	// members of user orgs will need their summaries updated
+	ownedOrgIDs := make([]int32, 0)
+	if orgs, err := impl.querier.GetUserOrganizations(ctx, Int(user.ID)); err == nil {
+		for _, org := range orgs {
+			if org.Level == dbgen.AccessLevelOwner {
+				ownedOrgIDs = append(ownedOrgIDs, org.Organization.ID)
+			}
+		}
+	}
+
 	if err := impl.querier.SoftDeleteUserOrganizations(ctx, Int(user.ID)); err != nil {
 		slog.ErrorContext(ctx, "Failed to soft-delete user organizations", "userID", user.ID, common.ErrAttr(err))
 		return nil, err
@@ -325,11 +441,73 @@ func (impl *BusinessStoreImpl) SoftDeleteUser(ctx context.Context, user *dbgen.U
 
 	_ = impl.cache.Delete(ctx, UserCacheKey(user.ID))
 
+	impl.onOrgsChanged(ctx, ownedOrgIDs)
+
 	auditEvent := newUserAuditLogEvent(user, nil, common.AuditLogActionSoftDelete)
 
 	return auditEvent, nil
 }
 
+// RetrieveUserCacheKeys returns cache keys of the user, user's organizations (and their members) and API keys.
+// They have to be collected before the user is deleted from DB, as the relations are deleted together with the user
+func (impl *BusinessStoreImpl) RetrieveUserCacheKeys(ctx context.Context, userID int32) ([]CacheKey, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	keys := []CacheKey{UserCacheKey(userID), userOrgsCacheKey(userID), UserAPIKeysCacheKey(userID)}
+
+	orgs, err := impl.querier.GetUserOrganizations(ctx, Int(userID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user organizations", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	for _, org := range orgs {
+		orgID := org.Organization.ID
+		keys = append(keys, orgCacheKey(orgID), orgPropertiesCacheKey(orgID, orgPropertiesCacheKeyStr), orgUsersCacheKey(orgID),
+			orgUsersPageCacheKey(orgID, orgUsersPageCacheKeyStr))
+
+		if org.Level != dbgen.AccessLevelOwner {
+			continue
+		}
+
+		// owned organizations are deleted together with the user
+		members, err := impl.querier.GetOrganizationUsers(ctx, orgID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve org members", "orgID", orgID, common.ErrAttr(err))
+			return nil, err
+		}
+
+		for _, member := range members {
+			keys = append(keys, userOrgsCacheKey(member.User.ID))
+		}
+	}
+
+	apiKeys, err := impl.querier.GetUserAPIKeys(ctx, Int(userID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user API keys", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	for _, key := range apiKeys {
+		keys = append(keys, APIKeyCacheKey(UUIDToSecret(key.ExternalID)))
+		if key.PreviousExternalID.Valid {
+			keys = append(keys, APIKeyCacheKey(UUIDToSecret(key.PreviousExternalID)))
+		}
+	}
+
+	return keys, nil
+}
+
+func (impl *BusinessStoreImpl) EvictFromCache(ctx context.Context, keys []CacheKey) {
+	for _, key := range keys {
+		_ = impl.cache.Delete(ctx, key)
+	}
+
+	slog.DebugContext(ctx, "Evicted cache keys", "count", len(keys))
+}
+
 func (impl *BusinessStoreImpl) doGetSessionbyID(ctx context.Context, sid string) (*session.SessionData, error) {
 	sslog := slog.With(common.SessionIDAttr(sid))
 	sessionID, _ := sessionIDFunc(sid)
@@ -367,13 +545,75 @@ func (impl *BusinessStoreImpl) DeleteUserSession(ctx context.Context, sid string
 		return ErrMaintenance
 	}
 
+	// session can still be cached in memory of other nodes, that will check the marker on read
+	if err := impl.querier.CreateCache(ctx, &dbgen.CreateCacheParams{
+		Key:     sessionRevokedKey(sid),
+		Value:   []byte{1},
+		Column3: sessionCacheTTL,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark user session as revoked", common.ErrAttr(err))
+		return err
+	}
+
 	sessionID, _ := sessionIDFunc(sid)
 	err := impl.querier.DeleteCachedByKey(ctx, sessionID)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to delete cached session from DB", common.ErrAttr(err))
+		return err
 	}
 
-	return err
+	if err := impl.querier.DeleteUserSessions(ctx, []string{sid}); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete user session from DB", common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+// RetrieveUserSessions returns persisted sessions of the user (from all nodes)
+func (impl *BusinessStoreImpl) RetrieveUserSessions(ctx context.Context, userID int32) ([]*session.SessionData, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	sids, err := impl.querier.GetUserSessionIDs(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user sessions", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make([]*session.SessionData, 0, len(sids))
+	missing := make([]string, 0)
+
+	for _, sid := range sids {
+		// sessions of other nodes are not cached here as they would get stale
+		sd, err := impl.RetrieveUserSession(ctx, sid, true /*skip cache*/)
+		if err != nil {
+			if err == otter.ErrNotFound {
+				missing = append(missing, sid)
+			} else {
+				slog.WarnContext(ctx, "Failed to retrieve user session", common.SessionIDAttr(sid), common.ErrAttr(err))
+			}
+			continue
+		}
+
+		if owner, ok := sd.UserID(); !ok || (owner != userID) {
+			missing = append(missing, sid)
+			continue
+		}
+
+		result = append(result, sd)
+	}
+
+	if len(missing) > 0 {
+		if err := impl.querier.DeleteUserSessions(ctx, missing); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete missing user sessions", "count", len(missing), common.ErrAttr(err))
+		} else {
+			slog.DebugContext(ctx, "Deleted missing user sessions", "userID", userID, "count", len(missing))
+		}
+	}
+
+	return result, nil
 }
 
 func (impl *BusinessStoreImpl) CacheUserSession(ctx context.Context, data *session.SessionData) error {
@@ -384,11 +624,38 @@ func (impl *BusinessStoreImpl) CacheUserSession(ctx context.Context, data *sessi
 	return impl.cache.Set(ctx, SessionCacheKey(data.ID()), data)
 }
 
+func (impl *BusinessStoreImpl) isSessionRevoked(ctx context.Context, sid string) (bool, error) {
+	if impl.querier == nil {
+		// sessions are not destroyed in shared storage during maintenance either
+		return false, nil
+	}
+
+	if _, err := impl.querier.GetCachedByKey(ctx, sessionRevokedKey(sid)); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to check if session is revoked", common.SessionIDAttr(sid), common.ErrAttr(err))
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (impl *BusinessStoreImpl) RetrieveUserSession(ctx context.Context, sid string, skipCache bool) (*session.SessionData, error) {
 	if len(sid) == 0 {
 		return nil, ErrInvalidInput
 	}
 
+	// session could have been destroyed on another node while still being cached in memory of this one
+	if revoked, err := impl.isSessionRevoked(ctx, sid); err != nil {
+		return nil, err
+	} else if revoked {
+		slog.DebugContext(ctx, "User session was revoked", common.SessionIDAttr(sid))
+		_ = impl.cache.Delete(ctx, SessionCacheKey(sid))
+		return nil, otter.ErrNotFound
+	}
+
 	if skipCache {
 		// we do not re-cache it yet and let external changes to be merged first
 		return impl.doGetSessionbyID(ctx, sid)
@@ -431,11 +698,38 @@ func (impl *BusinessStoreImpl) StoreUserSessions(ctx context.Context, batch map[
 
 	slog.DebugContext(ctx, "Read sessions chunk to save", "count", len(cached))
 
+	// sessions destroyed on other nodes should not be brought back
+	revoked := make(map[string]struct{})
+	if impl.querier != nil {
+		revokedKeys := make([]string, 0, len(cached))
+		for _, sd := range cached {
+			revokedKeys = append(revokedKeys, sessionRevokedKey(sd.ID()))
+		}
+
+		keys, err := impl.querier.GetCachedKeys(ctx, revokedKeys)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to check revoked sessions", "count", len(revokedKeys), common.ErrAttr(err))
+			return err
+		}
+
+		for _, key := range keys {
+			revoked[strings.TrimPrefix(key, sessionRevokedPrefix)] = struct{}{}
+		}
+	}
+
 	keys := make([]string, 0, len(batch))
 	values := make([][]byte, 0, len(batch))
 	intervals := make([]time.Duration, 0, len(batch))
+	userSessionIDs := make([]string, 0, len(batch))
+	userIDs := make([]int32, 0, len(batch))
 
 	for _, sd := range cached {
+		if _, ok := revoked[sd.ID()]; ok {
+			slog.DebugContext(ctx, "Skipping persisting revoked session", common.SessionIDAttr(sd.ID()))
+			_ = impl.cache.Delete(ctx, SessionCacheKey(sd.ID()))
+			continue
+		}
+
 		if !sd.Has(persistKey) {
 			slog.Log(ctx, common.LevelTrace, "Skipping persisting session without persist key", common.SessionIDAttr(sd.ID()))
 			continue
@@ -453,6 +747,11 @@ func (impl *BusinessStoreImpl) StoreUserSessions(ctx context.Context, batch map[
 		keys = append(keys, sidKey)
 		values = append(values, data)
 		intervals = append(intervals, ttl)
+
+		if userID, ok := sd.UserID(); ok {
+			userSessionIDs = append(userSessionIDs, sd.ID())
+			userIDs = append(userIDs, userID)
+		}
 	}
 
 	if len(keys) == 0 {
@@ -472,10 +771,22 @@ func (impl *BusinessStoreImpl) StoreUserSessions(ctx context.Context, batch map[
 
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to cache sessions", "count", len(keys), common.ErrAttr(err))
+		return err
 	}
 
 	slog.DebugContext(ctx, "Saved persisted sessions to DB", "count", len(keys))
 
+	if len(userSessionIDs) > 0 {
+		err = impl.querier.UpsertUserSessions(ctx, &dbgen.UpsertUserSessionsParams{
+			SessionIds: userSessionIDs,
+			UserIds:    userIDs,
+			Ttl:        ttl,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to save user sessions", "count", len(userSessionIDs), common.ErrAttr(err))
+		}
+	}
+
 	return err
 }
 
@@ -610,6 +921,66 @@ func (impl *BusinessStoreImpl) RetrieveAPIKey(ctx context.Context, secret string
 	return reader.Read(ctx)
 }
 
+// RecordAPIKeyUsage counts request to the API with the (already validated) key, if API key usage is recorded
+func (impl *BusinessStoreImpl) RecordAPIKeyUsage(key *dbgen.APIKey) {
+	if impl.apiKeyUsage != nil {
+		impl.apiKeyUsage.Record(key.ID)
+	}
+}
+
+// RecordAPIKeyIPViolation counts request to the API with the key from outside of its allowed ranges. Audit event is
+// returned only for the first violation since the last usage flush (can be nil)
+func (impl *BusinessStoreImpl) RecordAPIKeyIPViolation(ctx context.Context, key *dbgen.APIKey, addr netip.Addr) *common.AuditLogEvent {
+	if (impl.apiKeyUsage == nil) || !impl.apiKeyUsage.RecordIPViolation(key.ID) {
+		return nil
+	}
+
+	slog.WarnContext(ctx, "API key is used from not allowed IP address", "apikeyID", key.ID, "userID", key.UserID.Int32)
+
+	return newAPIKeyIPViolationAuditLogEvent(key, addr)
+}
+
+// RetrieveAPIKeysLastUsed returns the time when each of user's API keys was last used, if API key usage is recorded
+func (impl *BusinessStoreImpl) RetrieveAPIKeysLastUsed(ctx context.Context, userID int32) (map[int32]time.Time, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := impl.querier.GetUserAPIKeysLastUsed(ctx, Int(userID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve API keys usage", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make(map[int32]time.Time, len(rows))
+	for _, row := range rows {
+		if row.LastUsedAt.Valid {
+			result[row.ApikeyID] = row.LastUsedAt.Time
+		}
+	}
+
+	return result, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOldAPIKeysUsage(ctx context.Context, before time.Time) error {
+	if before.IsZero() {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteOldAPIKeysUsage(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete old API keys usage", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted old API keys usage", "before", before)
+
+	return nil
+}
+
 func (impl *BusinessStoreImpl) retrieveUser(ctx context.Context, userID int32) (*dbgen.User, error) {
 	reader := &StoreOneReader[int32, dbgen.User]{
 		CacheKey: UserCacheKey(userID),
@@ -756,6 +1127,23 @@ func (impl *BusinessStoreImpl) cacheProperty(ctx context.Context, property *dbge
 	_ = impl.cache.SetWithTTL(ctx, PropertyBySitekeyCacheKey(sitekey), property, propertyTTL)
 }
 
+// updateCachedProperty is used after property was modified (as opposed to just read from DB)
+func (impl *BusinessStoreImpl) updateCachedProperty(ctx context.Context, property *dbgen.Property) {
+	if property == nil {
+		return
+	}
+
+	impl.cacheProperty(ctx, property)
+	impl.notifyPropertyChanged(ctx, UUIDToSiteKey(property.ExternalID))
+}
+
+// NOTE: inside of a transaction listener is notified before the commit
+func (impl *BusinessStoreImpl) notifyPropertyChanged(ctx context.Context, sitekey string) {
+	if listener := impl.propertyListener.Load(); listener != nil {
+		(*listener)(ctx, sitekey)
+	}
+}
+
 func (impl *BusinessStoreImpl) deleteCachedProperty(ctx context.Context, property *dbgen.Property) {
 	if property == nil {
 		return
@@ -771,6 +1159,7 @@ func (impl *BusinessStoreImpl) deleteCachedProperty(ctx context.Context, propert
 	_ = impl.cache.Delete(ctx, orgPropertiesCountCacheKey(property.OrgID.Int32))
 	_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(property.CreatorID.Int32))
 	_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(property.OrgOwnerID.Int32))
+	impl.notifyPropertyChanged(ctx, sitekey)
 }
 
 func (impl *BusinessStoreImpl) GetCachedOrgProperties(ctx context.Context, orgID int32) ([]*dbgen.Property, error) {
@@ -881,6 +1270,40 @@ func (impl *BusinessStoreImpl) FindOrg(ctx context.Context, name string, user *d
 	return org, nil
 }
 
+// CheckPropertyTwin verifies that only staging properties have twins and that twin is a production property of the same org
+func (impl *BusinessStoreImpl) CheckPropertyTwin(ctx context.Context, orgID int32, environment dbgen.PropertyEnvironment, twinID pgtype.Int4) error {
+	if !twinID.Valid {
+		return nil
+	}
+
+	if environment != dbgen.PropertyEnvironmentStaging {
+		slog.WarnContext(ctx, "Only staging properties can have a twin", "orgID", orgID, "twinID", twinID.Int32, "environment", environment)
+		return ErrInvalidTwin
+	}
+
+	twin, err := impl.retrieveOrgProperty(ctx, orgID, twinID.Int32)
+	if err != nil {
+		if (err == ErrRecordNotFound) || (err == ErrNegativeCacheHit) {
+			slog.WarnContext(ctx, "Twin property does not exist", "orgID", orgID, "twinID", twinID.Int32)
+			return ErrInvalidTwin
+		}
+
+		return err
+	}
+
+	if !twin.OrgID.Valid || (twin.OrgID.Int32 != orgID) || twin.DeletedAt.Valid {
+		slog.WarnContext(ctx, "Twin property does not belong to the org", "orgID", orgID, "twinID", twin.ID, "twinOrgID", twin.OrgID.Int32)
+		return ErrInvalidTwin
+	}
+
+	if twin.Environment != dbgen.PropertyEnvironmentProduction {
+		slog.WarnContext(ctx, "Twin property is not in production", "orgID", orgID, "twinID", twin.ID, "environment", twin.Environment)
+		return ErrInvalidTwin
+	}
+
+	return nil
+}
+
 func (impl *BusinessStoreImpl) CreateNewProperty(ctx context.Context, params *dbgen.CreatePropertyParams, org *dbgen.Organization) (*dbgen.Property, *common.AuditLogEvent, error) {
 	if (params == nil) || (len(params.Domain) == 0) || (len(params.Name) == 0) {
 		return nil, nil, ErrInvalidInput
@@ -892,6 +1315,17 @@ func (impl *BusinessStoreImpl) CreateNewProperty(ctx context.Context, params *db
 
 	params.OrgID = Int(org.ID)
 	params.OrgOwnerID = org.UserID
+	if len(params.Environment) == 0 {
+		params.Environment = dbgen.PropertyEnvironmentProduction
+	}
+
+	if err := impl.CheckPropertyTwin(ctx, org.ID, params.Environment, params.TwinID); err != nil {
+		return nil, nil, err
+	}
+
+	if len(params.BotPolicy) == 0 {
+		params.BotPolicy = dbgen.BotPolicyMonitor
+	}
 
 	property, err := impl.querier.CreateProperty(ctx, params)
 	if err != nil {
@@ -908,35 +1342,17 @@ func (impl *BusinessStoreImpl) CreateNewProperty(ctx context.Context, params *db
 	_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(property.OrgOwnerID.Int32))
 	_ = impl.cache.Delete(ctx, orgPropertiesCountCacheKey(property.OrgID.Int32))
 
+	impl.onOrgPropertiesChanged(ctx, map[int32]int64{property.OrgID.Int32: 1})
+
 	auditEvent := newCreatePropertyAuditLogEvent(property, org)
 
 	return property, auditEvent, nil
 }
 
-func createPropertyFromUpdate(row *dbgen.UpdatePropertyRow) *dbgen.Property {
-	return &dbgen.Property{
-		ID:               row.ID,
-		Name:             row.Name,
-		ExternalID:       row.ExternalID,
-		OrgID:            row.OrgID,
-		CreatorID:        row.CreatorID,
-		OrgOwnerID:       row.OrgOwnerID,
-		Domain:           row.Domain,
-		Level:            row.Level,
-		Salt:             row.Salt,
-		Growth:           row.Growth,
-		CreatedAt:        row.CreatedAt,
-		UpdatedAt:        row.UpdatedAt,
-		DeletedAt:        row.DeletedAt,
-		ValidityInterval: row.ValidityInterval,
-		AllowSubdomains:  row.AllowSubdomains,
-		AllowLocalhost:   row.AllowLocalhost,
-		MaxReplayCount:   row.MaxReplayCount,
-	}
-}
-
-func (impl *BusinessStoreImpl) UpdateProperty(ctx context.Context, org *dbgen.Organization, user *dbgen.User, params *dbgen.UpdatePropertyParams) (*dbgen.Property, *common.AuditLogEvent, error) {
-	if (params == nil) || (user == nil) {
+// CreateNewProperties inserts all properties (of the same creator) in a single statement. Result is aligned with params
+// and has nil for properties that were skipped due to the name conflict (with existing properties or within params).
+func (impl *BusinessStoreImpl) CreateNewProperties(ctx context.Context, params []*dbgen.CreatePropertyParams, org *dbgen.Organization) ([]*dbgen.Property, []*common.AuditLogEvent, error) {
+	if len(params) == 0 {
 		return nil, nil, ErrInvalidInput
 	}
 
@@ -944,17 +1360,163 @@ func (impl *BusinessStoreImpl) UpdateProperty(ctx context.Context, org *dbgen.Or
 		return nil, nil, ErrMaintenance
 	}
 
-	params.CreatorID = Int(user.ID)
-	if org != nil {
-		params.OrgID = Int(org.ID)
+	creatorID := params[0].CreatorID
+	batch := &dbgen.CreatePropertiesParams{
+		OrgID:      org.ID,
+		CreatorID:  creatorID.Int32,
+		OrgOwnerID: org.UserID.Int32,
 	}
 
-	updatedProperty, err := impl.querier.UpdateProperty(ctx, params)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			plog := slog.With("propID", params.ID, "userID", user.ID)
-			if property, err := FetchCachedOne[dbgen.Property](ctx, impl.cache, propertyByIDCacheKey(params.ID)); err == nil {
-				plog = plog.With("orgOwnerID", property.OrgOwnerID.Int32, "creatorID", property.CreatorID.Int32)
+	for _, p := range params {
+		if (p == nil) || (len(p.Domain) == 0) || (len(p.Name) == 0) || (p.CreatorID != creatorID) {
+			return nil, nil, ErrInvalidInput
+		}
+
+		environment := p.Environment
+		if len(environment) == 0 {
+			environment = dbgen.PropertyEnvironmentProduction
+		}
+
+		if err := impl.CheckPropertyTwin(ctx, org.ID, environment, p.TwinID); err != nil {
+			return nil, nil, err
+		}
+
+		botPolicy := p.BotPolicy
+		if len(botPolicy) == 0 {
+			botPolicy = dbgen.BotPolicyMonitor
+		}
+
+		batch.Names = append(batch.Names, p.Name)
+		batch.Domains = append(batch.Domains, p.Domain)
+		batch.Levels = append(batch.Levels, p.Level.Int16)
+		batch.Growths = append(batch.Growths, string(p.Growth))
+		batch.ValidityIntervals = append(batch.ValidityIntervals, p.ValidityInterval)
+		batch.AllowSubdomains = append(batch.AllowSubdomains, p.AllowSubdomains)
+		batch.AllowLocalhosts = append(batch.AllowLocalhosts, p.AllowLocalhost)
+		batch.MaxReplayCounts = append(batch.MaxReplayCounts, p.MaxReplayCount)
+		batch.AllowedClockSkews = append(batch.AllowedClockSkews, p.AllowedClockSkew)
+		batch.RememberWindows = append(batch.RememberWindows, p.RememberWindow)
+		batch.WidgetFlags = append(batch.WidgetFlags, p.WidgetFlags)
+		batch.Environments = append(batch.Environments, string(environment))
+		batch.TwinIds = append(batch.TwinIds, p.TwinID.Int32)
+		batch.TrustGroups = append(batch.TrustGroups, p.TrustGroup)
+		batch.Claims = append(batch.Claims, p.Claims)
+		batch.DifferentialDifficulties = append(batch.DifferentialDifficulties, p.DifferentialDifficulty)
+		batch.BotPolicies = append(batch.BotPolicies, string(botPolicy))
+		batch.FailureUrls = append(batch.FailureUrls, p.FailureURL)
+		batch.FailureMessages = append(batch.FailureMessages, p.FailureMessage)
+		batch.DifficultyStrategies = append(batch.DifficultyStrategies, p.DifficultyStrategy)
+		batch.PrivacyModes = append(batch.PrivacyModes, p.PrivacyMode)
+	}
+
+	properties, err := impl.querier.CreateProperties(ctx, batch)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create properties in DB", "count", len(params), "org", org.ID, common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "Created new properties", "count", len(properties), "requested", len(params), "org", org.ID)
+
+	created := make(map[string]*dbgen.Property, len(properties))
+	for _, property := range properties {
+		impl.cacheProperty(ctx, property)
+		created[property.Name] = property
+	}
+
+	result := make([]*dbgen.Property, len(params))
+	auditEvents := make([]*common.AuditLogEvent, 0, len(properties))
+	for i, p := range params {
+		if property, ok := created[p.Name]; ok {
+			result[i] = property
+			auditEvents = append(auditEvents, newCreatePropertyAuditLogEvent(property, org))
+			delete(created, p.Name)
+		}
+	}
+
+	if len(properties) > 0 {
+		_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(org.ID, orgPropertiesCacheKeyStr))
+		_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(creatorID.Int32))
+		_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(org.UserID.Int32))
+		_ = impl.cache.Delete(ctx, orgPropertiesCountCacheKey(org.ID))
+
+		impl.onOrgPropertiesChanged(ctx, map[int32]int64{org.ID: int64(len(properties))})
+	}
+
+	return result, auditEvents, nil
+}
+
+func createPropertyFromUpdate(row *dbgen.UpdatePropertyRow) *dbgen.Property {
+	return &dbgen.Property{
+		ID:                     row.ID,
+		Name:                   row.Name,
+		ExternalID:             row.ExternalID,
+		OrgID:                  row.OrgID,
+		CreatorID:              row.CreatorID,
+		OrgOwnerID:             row.OrgOwnerID,
+		Domain:                 row.Domain,
+		Level:                  row.Level,
+		Salt:                   row.Salt,
+		Growth:                 row.Growth,
+		CreatedAt:              row.CreatedAt,
+		UpdatedAt:              row.UpdatedAt,
+		DeletedAt:              row.DeletedAt,
+		ValidityInterval:       row.ValidityInterval,
+		AllowSubdomains:        row.AllowSubdomains,
+		AllowLocalhost:         row.AllowLocalhost,
+		MaxReplayCount:         row.MaxReplayCount,
+		AllowedClockSkew:       row.AllowedClockSkew,
+		RememberWindow:         row.RememberWindow,
+		WidgetFlags:            row.WidgetFlags,
+		Environment:            row.Environment,
+		TwinID:                 row.TwinID,
+		TrustGroup:             row.TrustGroup,
+		Claims:                 row.Claims,
+		DifferentialDifficulty: row.DifferentialDifficulty,
+		DomainStatus:           row.DomainStatus,
+		DomainCheckedAt:        row.DomainCheckedAt,
+		BotPolicy:              row.BotPolicy,
+		FailureURL:             row.FailureURL,
+		FailureMessage:         row.FailureMessage,
+		EmergencyUntil:         row.EmergencyUntil,
+		DifficultyStrategy:     row.DifficultyStrategy,
+		PrivacyMode:            row.PrivacyMode,
+	}
+}
+
+func (impl *BusinessStoreImpl) UpdateProperty(ctx context.Context, org *dbgen.Organization, user *dbgen.User, params *dbgen.UpdatePropertyParams) (*dbgen.Property, *common.AuditLogEvent, error) {
+	if (params == nil) || (user == nil) {
+		return nil, nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	params.CreatorID = Int(user.ID)
+	if org != nil {
+		params.OrgID = Int(org.ID)
+	}
+
+	if params.TwinID.Valid {
+		property, err := impl.retrieveOrgProperty(ctx, params.OrgID.Int32, params.ID)
+		if err != nil {
+			if (err == ErrRecordNotFound) || (err == ErrNegativeCacheHit) {
+				return nil, nil, ErrPermissions
+			}
+			return nil, nil, err
+		}
+
+		if err := impl.CheckPropertyTwin(ctx, property.OrgID.Int32, property.Environment, params.TwinID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	updatedProperty, err := impl.querier.UpdateProperty(ctx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			plog := slog.With("propID", params.ID, "userID", user.ID)
+			if property, err := FetchCachedOne[dbgen.Property](ctx, impl.cache, propertyByIDCacheKey(params.ID)); err == nil {
+				plog = plog.With("orgOwnerID", property.OrgOwnerID.Int32, "creatorID", property.CreatorID.Int32)
 			}
 			plog.WarnContext(ctx, "Cannot update property in DB")
 
@@ -968,7 +1530,7 @@ func (impl *BusinessStoreImpl) UpdateProperty(ctx context.Context, org *dbgen.Or
 	slog.InfoContext(ctx, "Updated property", "name", updatedProperty.Name, "propID", updatedProperty.ID)
 
 	cacheProperty := createPropertyFromUpdate(updatedProperty)
-	impl.cacheProperty(ctx, cacheProperty)
+	impl.updateCachedProperty(ctx, cacheProperty)
 	// invalidate org properties in cache as we just created a new property
 	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(updatedProperty.OrgID.Int32, orgPropertiesCacheKeyStr))
 	_ = impl.cache.Delete(ctx, propertyAuditLogsCacheKey(updatedProperty.ID))
@@ -978,6 +1540,51 @@ func (impl *BusinessStoreImpl) UpdateProperty(ctx context.Context, org *dbgen.Or
 	return cacheProperty, auditEvent, nil
 }
 
+// PromoteProperty copies configuration of the staging property to its production twin
+func (impl *BusinessStoreImpl) PromoteProperty(ctx context.Context, org *dbgen.Organization, user *dbgen.User, staging *dbgen.Property) (*dbgen.Property, *common.AuditLogEvent, error) {
+	if (staging == nil) || (org == nil) || (staging.Environment != dbgen.PropertyEnvironmentStaging) || !staging.TwinID.Valid {
+		return nil, nil, ErrInvalidInput
+	}
+
+	twin, err := impl.RetrieveOrgProperty(ctx, org, staging.TwinID.Int32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if twin.Environment != dbgen.PropertyEnvironmentProduction {
+		slog.WarnContext(ctx, "Twin property is not in production", "propID", staging.ID, "twinID", twin.ID, "environment", twin.Environment)
+		return nil, nil, ErrInvalidInput
+	}
+
+	params := &dbgen.UpdatePropertyParams{
+		ID:               twin.ID,
+		Name:             twin.Name,
+		Level:            staging.Level,
+		Growth:           staging.Growth,
+		ValidityInterval: staging.ValidityInterval,
+		AllowSubdomains:  staging.AllowSubdomains,
+		// localhost is a development-only setting and stays as configured in production
+		AllowLocalhost:   twin.AllowLocalhost,
+		MaxReplayCount:   staging.MaxReplayCount,
+		AllowedClockSkew: staging.AllowedClockSkew,
+		RememberWindow:   staging.RememberWindow,
+		WidgetFlags:      staging.WidgetFlags,
+		TwinID:           twin.TwinID,
+		// trust groups are tied to the domain setup and are not copied from staging
+		TrustGroup:             twin.TrustGroup,
+		Claims:                 staging.Claims,
+		DifferentialDifficulty: staging.DifferentialDifficulty,
+		BotPolicy:              staging.BotPolicy,
+		FailureURL:             staging.FailureURL,
+		FailureMessage:         staging.FailureMessage,
+		DifficultyStrategy:     staging.DifficultyStrategy,
+	}
+
+	slog.DebugContext(ctx, "Promoting property settings", "propID", staging.ID, "twinID", twin.ID)
+
+	return impl.UpdateProperty(ctx, org, user, params)
+}
+
 func (impl *BusinessStoreImpl) SoftDeleteProperty(ctx context.Context, prop *dbgen.Property, org *dbgen.Organization, user *dbgen.User) (*common.AuditLogEvent, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
@@ -992,6 +1599,8 @@ func (impl *BusinessStoreImpl) SoftDeleteProperty(ctx context.Context, prop *dbg
 	slog.InfoContext(ctx, "Soft-deleted property", "propID", prop.ID)
 
 	impl.deleteCachedProperty(ctx, property)
+	impl.onOrgPropertiesChanged(ctx, map[int32]int64{property.OrgID.Int32: -1})
+
 	auditEvent := newDeletePropertyAuditLogEvent(prop, org, user)
 
 	return auditEvent, nil
@@ -1035,13 +1644,17 @@ func (impl *BusinessStoreImpl) SoftDeleteProperties(ctx context.Context, ids []i
 
 	auditEvents := make([]*common.AuditLogEvent, 0, len(properties))
 	deletedIDs := make(map[int32]struct{}, len(properties))
+	orgDeltas := make(map[int32]int64)
 
 	for _, property := range properties {
 		impl.deleteCachedProperty(ctx, property)
 		auditEvents = append(auditEvents, newDeletePropertyAuditLogEvent(property, nil /*org*/, user))
 		deletedIDs[property.ID] = struct{}{}
+		orgDeltas[property.OrgID.Int32]--
 	}
 
+	impl.onOrgPropertiesChanged(ctx, orgDeltas)
+
 	return deletedIDs, auditEvents, nil
 }
 
@@ -1095,6 +1708,34 @@ func (impl *BusinessStoreImpl) RetrieveOrgProperties(ctx context.Context, org *d
 	return properties[:min(len(properties), actualLimit)], len(properties) == int(params.Limit), nil
 }
 
+// RetrieveOrgPropertiesAfter returns the page of properties following (createdAt, id) in the stable order
+func (impl *BusinessStoreImpl) RetrieveOrgPropertiesAfter(ctx context.Context, org *dbgen.Organization, createdAt time.Time, id int32, limit int) ([]*dbgen.Property, bool, error) {
+	if limit <= 0 {
+		return nil, false, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, false, ErrMaintenance
+	}
+
+	actualLimit := min(MaxOrgPropertiesPageSize, limit)
+
+	properties, err := impl.querier.GetOrgPropertiesAfter(ctx, &dbgen.GetOrgPropertiesAfterParams{
+		OrgID:     Int(org.ID),
+		Limit:     int32(actualLimit) + 1,
+		CreatedAt: Timestampz(createdAt),
+		ID:        id,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org properties", "after", id, "limit", actualLimit, "orgID", org.ID, common.ErrAttr(err))
+		return nil, false, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved org properties", "after", id, "limit", actualLimit, "orgID", org.ID, "count", len(properties))
+
+	return properties[:min(len(properties), actualLimit)], len(properties) > actualLimit, nil
+}
+
 func (impl *BusinessStoreImpl) UpdateOrganization(ctx context.Context, user *dbgen.User, org *dbgen.Organization, name string) (*dbgen.Organization, *common.AuditLogEvent, error) {
 	if impl.querier == nil {
 		return nil, nil, ErrMaintenance
@@ -1118,6 +1759,7 @@ func (impl *BusinessStoreImpl) UpdateOrganization(ctx context.Context, user *dbg
 	_ = impl.cache.Set(ctx, cacheKey, org)
 	// invalidate user orgs in cache as we just updated name
 	_ = impl.cache.Delete(ctx, userOrgsCacheKey(org.UserID.Int32))
+	impl.onOrgsChanged(ctx, []int32{org.ID})
 
 	auditEvent := newUpdateOrgAuditLogEvent(user, org, oldName)
 
@@ -1145,6 +1787,7 @@ func (impl *BusinessStoreImpl) SoftDeleteOrganization(ctx context.Context, org *
 	// invalidate user orgs in cache as we just deleted one
 	_ = impl.cache.Delete(ctx, userOrgsCacheKey(user.ID))
 	_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(user.ID))
+	impl.onOrgsChanged(ctx, []int32{org.ID})
 
 	auditEvent := newOrgAuditLogEvent(user.ID, org, common.AuditLogActionSoftDelete)
 
@@ -1166,6 +1809,116 @@ func (impl *BusinessStoreImpl) RetrieveOrganizationUsers(ctx context.Context, or
 	return reader.Read(ctx)
 }
 
+func (impl *BusinessStoreImpl) validateOrgUsersQuery(query *OrgUsersQuery) error {
+	if len(query.Email) > maxOrgUsersEmailFilter {
+		return ErrInvalidInput
+	}
+
+	switch query.Level {
+	case "", dbgen.AccessLevelOwner, dbgen.AccessLevelMember, dbgen.AccessLevelInvited:
+	default:
+		return ErrInvalidInput
+	}
+
+	switch query.SortBy {
+	case "", OrgUsersSortJoined, OrgUsersSortEmail, OrgUsersSortName, OrgUsersSortLevel:
+		return nil
+	default:
+		return ErrInvalidInput
+	}
+}
+
+// RetrieveOrganizationUsersPage returns a filtered and sorted page of organization members (owner is not included)
+func (impl *BusinessStoreImpl) RetrieveOrganizationUsersPage(ctx context.Context, orgID int32, query *OrgUsersQuery, offset, limit int) ([]*dbgen.GetOrganizationUsersRow, bool, error) {
+	if (offset < 0) || (limit <= 0) {
+		return nil, false, ErrInvalidInput
+	}
+
+	if err := impl.validateOrgUsersQuery(query); err != nil {
+		return nil, false, err
+	}
+
+	actualLimit := min(MaxOrgUsersPageSize, limit)
+	params := &dbgen.GetOrganizationUsersPageParams{
+		OrgID:      orgID,
+		Level:      dbgen.NullAccessLevel{AccessLevel: query.Level, Valid: len(query.Level) > 0},
+		Email:      escapeLikePattern(query.Email),
+		SortBy:     query.SortBy,
+		Descending: query.Descending,
+		PageOffset: int32(offset),
+		PageLimit:  int32(actualLimit) + 1,
+	}
+
+	reader := &StoreArrayReader[*dbgen.GetOrganizationUsersPageParams, dbgen.GetOrganizationUsersPageRow]{
+		CacheKey: orgUsersPageCacheKey(orgID, query.cacheKeyStr(offset, actualLimit)),
+		Cache:    impl.cache,
+	}
+
+	// only the default first page is invalidated explicitly, other pages expire soon
+	if reader.CacheKey.StrValue == orgUsersPageCacheKeyStr {
+		params.PageLimit = MaxOrgUsersPageSize + 1
+	} else {
+		reader.TTL = orgUsersPageTTL
+	}
+
+	if impl.querier != nil {
+		reader.QueryKeyFunc = func(ck CacheKey) (*dbgen.GetOrganizationUsersPageParams, error) { return params, nil }
+		reader.QueryFunc = impl.querier.GetOrganizationUsersPage
+	}
+
+	rows, err := reader.Read(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org users page", "orgID", orgID, "offset", offset, "limit", actualLimit, common.ErrAttr(err))
+		return nil, false, err
+	}
+
+	count := min(len(rows), actualLimit)
+	result := make([]*dbgen.GetOrganizationUsersRow, 0, count)
+	for _, row := range rows[:count] {
+		result = append(result, (*dbgen.GetOrganizationUsersRow)(row))
+	}
+
+	return result, len(rows) > count, nil
+}
+
+// RetrieveOrganizationUsersCount returns number of organization members matching the filter
+func (impl *BusinessStoreImpl) RetrieveOrganizationUsersCount(ctx context.Context, orgID int32, query *OrgUsersQuery) (int64, error) {
+	if err := impl.validateOrgUsersQuery(query); err != nil {
+		return 0, err
+	}
+
+	if impl.querier == nil {
+		return 0, ErrMaintenance
+	}
+
+	count, err := impl.querier.GetOrganizationUsersCount(ctx, &dbgen.GetOrganizationUsersCountParams{
+		OrgID: orgID,
+		Level: dbgen.NullAccessLevel{AccessLevel: query.Level, Valid: len(query.Level) > 0},
+		Email: escapeLikePattern(query.Email),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org users count", "orgID", orgID, common.ErrAttr(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RetrieveUserSeatsCount returns number of distinct members (including invited) of organizations owned by the user
+func (impl *BusinessStoreImpl) RetrieveUserSeatsCount(ctx context.Context, userID int32) (int, error) {
+	if impl.querier == nil {
+		return 0, ErrMaintenance
+	}
+
+	count, err := impl.querier.GetUserSeatsCount(ctx, Int(userID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user seats count", "userID", userID, common.ErrAttr(err))
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
 func (impl *BusinessStoreImpl) InviteUserToOrg(ctx context.Context, user *dbgen.User, org *dbgen.Organization, inviteUser *dbgen.User) (*common.AuditLogEvent, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
@@ -1186,6 +1939,8 @@ func (impl *BusinessStoreImpl) InviteUserToOrg(ctx context.Context, user *dbgen.
 	// invalidate relevant caches
 	_ = impl.cache.Delete(ctx, userOrgsCacheKey(inviteUser.ID))
 	_ = impl.cache.Delete(ctx, orgUsersCacheKey(org.ID))
+	_ = impl.cache.Delete(ctx, orgUsersPageCacheKey(org.ID, orgUsersPageCacheKeyStr))
+	impl.onOrgsChanged(ctx, nil /*orgs*/, inviteUser.ID)
 
 	auditEvent := newOrgInviteAuditLogEvent(user, org, inviteUser)
 
@@ -1214,6 +1969,8 @@ func (impl *BusinessStoreImpl) JoinOrg(ctx context.Context, orgID int32, user *d
 	// invalidate relevant caches
 	_ = impl.cache.Delete(ctx, userOrgsCacheKey(user.ID))
 	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
+	_ = impl.cache.Delete(ctx, orgUsersPageCacheKey(orgID, orgUsersPageCacheKeyStr))
+	impl.onOrgsChanged(ctx, nil /*orgs*/, user.ID)
 
 	var orgName string
 	if org, err := FetchCachedOne[dbgen.Organization](ctx, impl.cache, orgCacheKey(orgID)); err == nil {
@@ -1247,6 +2004,8 @@ func (impl *BusinessStoreImpl) LeaveOrg(ctx context.Context, orgID int32, user *
 	// invalidate relevant caches
 	_ = impl.cache.Delete(ctx, userOrgsCacheKey(user.ID))
 	_ = impl.cache.Delete(ctx, orgUsersCacheKey(orgID))
+	_ = impl.cache.Delete(ctx, orgUsersPageCacheKey(orgID, orgUsersPageCacheKeyStr))
+	impl.onOrgsChanged(ctx, nil /*orgs*/, user.ID)
 
 	var orgName string
 	if org, err := FetchCachedOne[dbgen.Organization](ctx, impl.cache, orgCacheKey(orgID)); err == nil {
@@ -1278,6 +2037,8 @@ func (impl *BusinessStoreImpl) RemoveUserFromOrg(ctx context.Context, user *dbge
 	// invalidate relevant caches
 	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
 	_ = impl.cache.Delete(ctx, orgUsersCacheKey(org.ID))
+	_ = impl.cache.Delete(ctx, orgUsersPageCacheKey(org.ID, orgUsersPageCacheKeyStr))
+	impl.onOrgsChanged(ctx, nil /*orgs*/, userID)
 
 	userEmail := ""
 	if cachedUser, err := FetchCachedOne[dbgen.User](ctx, impl.cache, UserCacheKey(userID)); err == nil {
@@ -1409,6 +2170,11 @@ func (impl *BusinessStoreImpl) UpdateAPIKey(ctx context.Context, user *dbgen.Use
 		cacheKey := APIKeyCacheKey(secret)
 		_ = impl.cache.SetWithTTL(ctx, cacheKey, updatedKey, apiKeyTTL)
 
+		// previous secret (during rotation overlap) is cached with the old row
+		if updatedKey.PreviousExternalID.Valid {
+			_ = impl.cache.Delete(ctx, APIKeyCacheKey(UUIDToSecret(updatedKey.PreviousExternalID)))
+		}
+
 		// invalidate keys cache
 		_ = impl.cache.Delete(ctx, UserAPIKeysCacheKey(updatedKey.UserID.Int32))
 
@@ -1428,6 +2194,9 @@ func (impl *BusinessStoreImpl) CreateAPIKey(ctx context.Context, user *dbgen.Use
 	}
 
 	params.UserID = Int(user.ID)
+	if params.AllowedCidrs == nil {
+		params.AllowedCidrs = []string{}
+	}
 
 	key, err := impl.querier.CreateAPIKey(ctx, params)
 	if err != nil {
@@ -1460,7 +2229,9 @@ func (impl *BusinessStoreImpl) CreateAPIKey(ctx context.Context, user *dbgen.Use
 	return key, auditEvent, nil
 }
 
-func (impl *BusinessStoreImpl) RotateAPIKey(ctx context.Context, user *dbgen.User, keyID int32) (*dbgen.APIKey, *common.AuditLogEvent, error) {
+// RotateAPIKey generates a new secret for the key. Previous secret remains valid for the overlap duration
+// (zero overlap invalidates it immediately)
+func (impl *BusinessStoreImpl) RotateAPIKey(ctx context.Context, user *dbgen.User, keyID int32, overlap time.Duration) (*dbgen.APIKey, *common.AuditLogEvent, error) {
 	if impl.querier == nil {
 		return nil, nil, ErrMaintenance
 	}
@@ -1483,8 +2254,9 @@ func (impl *BusinessStoreImpl) RotateAPIKey(ctx context.Context, user *dbgen.Use
 	}
 
 	key, err := impl.querier.RotateAPIKey(ctx, &dbgen.RotateAPIKeyParams{
-		ID:     keyID,
-		UserID: Int(user.ID),
+		Overlap: max(overlap, 0),
+		ID:      keyID,
+		UserID:  Int(user.ID),
 	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -1496,11 +2268,14 @@ func (impl *BusinessStoreImpl) RotateAPIKey(ctx context.Context, user *dbgen.Use
 		return nil, nil, err
 	}
 
-	slog.InfoContext(ctx, "Rotated API Key", "keyID", keyID, "userID", user.ID)
+	slog.InfoContext(ctx, "Rotated API Key", "keyID", keyID, "userID", user.ID, "overlap", overlap)
 
 	var auditEvent *common.AuditLogEvent
 
 	if key != nil {
+		// previous secret might have been cached outside of user keys (e.g. by API access)
+		_ = impl.cache.Delete(ctx, APIKeyCacheKey(UUIDToSecret(key.PreviousExternalID)))
+
 		secret := UUIDToSecret(key.ExternalID)
 		cacheKey := APIKeyCacheKey(secret)
 		_ = impl.cache.SetWithTTL(ctx, cacheKey, key, apiKeyTTL)
@@ -1539,6 +2314,10 @@ func (impl *BusinessStoreImpl) DeleteAPIKey(ctx context.Context, user *dbgen.Use
 		secret := UUIDToSecret(key.ExternalID)
 		cacheKey := APIKeyCacheKey(secret)
 		_ = impl.cache.Delete(ctx, cacheKey)
+
+		if key.PreviousExternalID.Valid {
+			_ = impl.cache.Delete(ctx, APIKeyCacheKey(UUIDToSecret(key.PreviousExternalID)))
+		}
 	}
 
 	_ = impl.cache.Delete(ctx, UserAPIKeysCacheKey(user.ID))
@@ -1573,6 +2352,59 @@ func (impl *BusinessStoreImpl) RetrieveUsersWithoutSubscription(ctx context.Cont
 	return users, err
 }
 
+func (impl *BusinessStoreImpl) RetrieveUsersWithSubscriptions(ctx context.Context, userIDs []int32) ([]*dbgen.GetUsersWithSubscriptionsRow, error) {
+	if len(userIDs) == 0 {
+		return []*dbgen.GetUsersWithSubscriptionsRow{}, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	users, err := impl.querier.GetUsersWithSubscriptions(ctx, userIDs)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetUsersWithSubscriptionsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve users with subscriptions", "userIDs", len(userIDs), common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched users with subscriptions", "count", len(users), "userIDs", len(userIDs))
+
+	return users, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUsersPage(ctx context.Context, offset, limit int) ([]*dbgen.User, error) {
+	if (offset < 0) || (limit <= 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	users, err := impl.querier.GetUsersPage(ctx, &dbgen.GetUsersPageParams{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.User{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve users page", "offset", offset, "limit", limit, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched users page", "count", len(users), "offset", offset, "limit", limit)
+
+	return users, nil
+}
+
 func (impl *BusinessStoreImpl) RetrieveLock(ctx context.Context, name string) (*dbgen.Lock, error) {
 	if len(name) == 0 {
 		return nil, ErrInvalidInput
@@ -1699,8 +2531,28 @@ func (impl *BusinessStoreImpl) DeleteProperties(ctx context.Context, ids []int32
 	return err
 }
 
-func (impl *BusinessStoreImpl) RetrieveSoftDeletedOrganizations(ctx context.Context, before time.Time, limit int32) ([]*dbgen.GetSoftDeletedOrganizationsRow, error) {
-	if before.IsZero() {
+// FindMissingPropertyIDs returns IDs of properties that do not exist anymore (including soft-deleted ones)
+func (impl *BusinessStoreImpl) FindMissingPropertyIDs(ctx context.Context, ids []int32) ([]int32, error) {
+	if len(ids) == 0 {
+		return []int32{}, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	existing, err := impl.querier.GetExistingPropertyIDs(ctx, ids)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve existing properties", "count", len(ids), common.ErrAttr(err))
+		return nil, err
+	}
+
+	return missingIDs(ids, existing), nil
+}
+
+// RetrievePropertiesForDomainCheck returns properties, whose domains were checked before the given time (never checked go first)
+func (impl *BusinessStoreImpl) RetrievePropertiesForDomainCheck(ctx context.Context, before time.Time, limit int32) ([]*dbgen.Property, error) {
+	if before.IsZero() || (limit <= 0) {
 		return nil, ErrInvalidInput
 	}
 
@@ -1708,42 +2560,55 @@ func (impl *BusinessStoreImpl) RetrieveSoftDeletedOrganizations(ctx context.Cont
 		return nil, ErrMaintenance
 	}
 
-	organizations, err := impl.querier.GetSoftDeletedOrganizations(ctx, &dbgen.GetSoftDeletedOrganizationsParams{
-		DeletedAt: Timestampz(before),
-		Limit:     limit,
+	properties, err := impl.querier.GetPropertiesForDomainCheck(ctx, &dbgen.GetPropertiesForDomainCheckParams{
+		DomainCheckedAt: Timestampz(before),
+		Limit:           limit,
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve soft deleted organizations", "before", before, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve properties for domain check", "before", before, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched soft-deleted organizations", "count", len(organizations), "before", before)
+	slog.DebugContext(ctx, "Fetched properties for domain check", "count", len(properties), "before", before)
 
-	return organizations, nil
+	return properties, nil
 }
 
-func (impl *BusinessStoreImpl) DeleteOrganizations(ctx context.Context, ids []int32) error {
-	if len(ids) == 0 {
-		slog.WarnContext(ctx, "No organizations to delete")
-		return nil
+func (impl *BusinessStoreImpl) UpdatePropertyDomainStatus(ctx context.Context, property *dbgen.Property, status dbgen.PropertyDomainStatus, tnow time.Time) error {
+	if property == nil {
+		return ErrInvalidInput
 	}
 
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	err := impl.querier.DeleteOrganizations(ctx, ids)
+	if err := impl.querier.UpdatePropertyDomainStatus(ctx, &dbgen.UpdatePropertyDomainStatusParams{
+		ID:              property.ID,
+		DomainStatus:    status,
+		DomainCheckedAt: Timestampz(tnow),
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update property domain status", "propID", property.ID, "status", status, common.ErrAttr(err))
+		return err
+	}
 
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to delete organizations", "count", len(ids), common.ErrAttr(err))
+	if property.DomainStatus != status {
+		slog.InfoContext(ctx, "Property domain status changed", "propID", property.ID, "domain", property.Domain,
+			"old", property.DomainStatus, "new", status)
+
+		updated := *property
+		updated.DomainStatus = status
+		updated.DomainCheckedAt = Timestampz(tnow)
+		impl.updateCachedProperty(ctx, &updated)
+		_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(property.OrgID.Int32, orgPropertiesCacheKeyStr))
 	}
 
-	return err
+	return nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveSoftDeletedUsers(ctx context.Context, before time.Time, limit int32) ([]*dbgen.GetSoftDeletedUsersRow, error) {
-	if before.IsZero() {
+// RetrievePropertyBaselines returns baselines of all properties for the same hour of the week
+func (impl *BusinessStoreImpl) RetrievePropertyBaselines(ctx context.Context, weekday time.Weekday, hour int) ([]*dbgen.PropertyBaseline, error) {
+	if (hour < 0) || (hour > 23) {
 		return nil, ErrInvalidInput
 	}
 
@@ -1751,25 +2616,127 @@ func (impl *BusinessStoreImpl) RetrieveSoftDeletedUsers(ctx context.Context, bef
 		return nil, ErrMaintenance
 	}
 
-	users, err := impl.querier.GetSoftDeletedUsers(ctx, &dbgen.GetSoftDeletedUsersParams{
-		DeletedAt: Timestampz(before),
-		Limit:     limit,
+	baselines, err := impl.querier.GetPropertyBaselines(ctx, &dbgen.GetPropertyBaselinesParams{
+		Weekday: int16(weekday),
+		Hour:    int16(hour),
 	})
-
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve soft deleted users", "before", before, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to retrieve property baselines", "weekday", weekday, "hour", hour, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched soft-deleted users", "count", len(users), "before", before)
+	slog.DebugContext(ctx, "Fetched property baselines", "count", len(baselines), "weekday", weekday, "hour", hour)
 
-	return users, nil
+	return baselines, nil
 }
 
-func (impl *BusinessStoreImpl) DeleteUsers(ctx context.Context, ids []int32) error {
-	if len(ids) == 0 {
-		slog.WarnContext(ctx, "No users to delete")
-		return nil
+func (impl *BusinessStoreImpl) UpdatePropertyBaseline(ctx context.Context, arg *dbgen.UpsertPropertyBaselineParams) error {
+	if (arg == nil) || (arg.PropertyID <= 0) {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.UpsertPropertyBaseline(ctx, arg); err != nil {
+		slog.ErrorContext(ctx, "Failed to update property baseline", "propID", arg.PropertyID, common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveSoftDeletedOrganizations(ctx context.Context, before time.Time, limit int32) ([]*dbgen.GetSoftDeletedOrganizationsRow, error) {
+	if before.IsZero() {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	organizations, err := impl.querier.GetSoftDeletedOrganizations(ctx, &dbgen.GetSoftDeletedOrganizationsParams{
+		DeletedAt: Timestampz(before),
+		Limit:     limit,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve soft deleted organizations", "before", before, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched soft-deleted organizations", "count", len(organizations), "before", before)
+
+	return organizations, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOrganizations(ctx context.Context, ids []int32) error {
+	if len(ids) == 0 {
+		slog.WarnContext(ctx, "No organizations to delete")
+		return nil
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	err := impl.querier.DeleteOrganizations(ctx, ids)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete organizations", "count", len(ids), common.ErrAttr(err))
+	}
+
+	return err
+}
+
+// FindMissingOrganizationIDs returns IDs of organizations that do not exist anymore (including soft-deleted ones)
+func (impl *BusinessStoreImpl) FindMissingOrganizationIDs(ctx context.Context, ids []int32) ([]int32, error) {
+	if len(ids) == 0 {
+		return []int32{}, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	existing, err := impl.querier.GetExistingOrganizationIDs(ctx, ids)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve existing organizations", "count", len(ids), common.ErrAttr(err))
+		return nil, err
+	}
+
+	return missingIDs(ids, existing), nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveSoftDeletedUsers(ctx context.Context, before time.Time, limit int32) ([]*dbgen.GetSoftDeletedUsersRow, error) {
+	if before.IsZero() {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	users, err := impl.querier.GetSoftDeletedUsers(ctx, &dbgen.GetSoftDeletedUsersParams{
+		DeletedAt: Timestampz(before),
+		Limit:     limit,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve soft deleted users", "before", before, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched soft-deleted users", "count", len(users), "before", before)
+
+	return users, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteUsers(ctx context.Context, ids []int32) error {
+	if len(ids) == 0 {
+		slog.WarnContext(ctx, "No users to delete")
+		return nil
 	}
 
 	if impl.querier == nil {
@@ -1799,7 +2766,7 @@ func (impl *BusinessStoreImpl) RetrieveSystemNotification(ctx context.Context, i
 	return reader.Read(ctx)
 }
 
-func (impl *BusinessStoreImpl) RetrieveSystemUserNotification(ctx context.Context, tnow time.Time, userID int32) (*dbgen.SystemNotification, error) {
+func (impl *BusinessStoreImpl) RetrieveSystemUserNotification(ctx context.Context, tnow time.Time, userID int32, stage string) (*dbgen.SystemNotification, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
@@ -1807,6 +2774,7 @@ func (impl *BusinessStoreImpl) RetrieveSystemUserNotification(ctx context.Contex
 	n, err := impl.querier.GetLastActiveSystemNotification(ctx, &dbgen.GetLastActiveSystemNotificationParams{
 		Column1: Timestampz(tnow),
 		UserID:  Int(userID),
+		Stage:   Text(stage),
 	})
 
 	if err != nil {
@@ -1826,19 +2794,16 @@ func (impl *BusinessStoreImpl) RetrieveSystemUserNotification(ctx context.Contex
 }
 
 func (impl *BusinessStoreImpl) CreateSystemNotification(ctx context.Context, message string, tnow time.Time, duration *time.Duration, userID *int32) (*dbgen.SystemNotification, error) {
-	if (len(message) == 0) || tnow.IsZero() {
+	if tnow.IsZero() {
 		return nil, ErrInvalidInput
 	}
 
-	if impl.querier == nil {
-		return nil, ErrMaintenance
-	}
-
 	arg := &dbgen.CreateSystemNotificationParams{
 		Message:   message,
 		StartDate: Timestampz(tnow),
 		EndDate:   pgtype.Timestamptz{Valid: false},
 		UserID:    pgtype.Int4{Valid: false},
+		Severity:  dbgen.NotificationSeverityInfo,
 	}
 
 	if duration != nil {
@@ -1849,6 +2814,29 @@ func (impl *BusinessStoreImpl) CreateSystemNotification(ctx context.Context, mes
 		arg.UserID = Int(*userID)
 	}
 
+	return impl.ScheduleSystemNotification(ctx, arg)
+}
+
+// ScheduleSystemNotification creates a system notification (announcement) with full audience targeting
+func (impl *BusinessStoreImpl) ScheduleSystemNotification(ctx context.Context, arg *dbgen.CreateSystemNotificationParams) (*dbgen.SystemNotification, error) {
+	if (arg == nil) || (len(arg.Message) == 0) || !arg.StartDate.Valid {
+		return nil, ErrInvalidInput
+	}
+
+	if arg.EndDate.Valid && !arg.EndDate.Time.After(arg.StartDate.Time) {
+		return nil, ErrInvalidInput
+	}
+
+	severity, ok := ParseNotificationSeverity(string(arg.Severity))
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	arg.Severity = severity
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
 	n, err := impl.querier.CreateSystemNotification(ctx, arg)
 
 	if err != nil {
@@ -1861,11 +2849,55 @@ func (impl *BusinessStoreImpl) CreateSystemNotification(ctx context.Context, mes
 		_ = impl.cache.Set(ctx, cacheKey, n)
 	}
 
-	slog.InfoContext(ctx, "Created system notification", "notifID", n.ID)
+	slog.InfoContext(ctx, "Created system notification", "notifID", n.ID, "severity", n.Severity, "start", n.StartDate.Time)
 
 	return n, err
 }
 
+// RetrieveScheduledSystemNotifications returns active notifications that did not end yet (including future ones)
+func (impl *BusinessStoreImpl) RetrieveScheduledSystemNotifications(ctx context.Context, tnow time.Time) ([]*dbgen.SystemNotification, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	notifications, err := impl.querier.GetScheduledSystemNotifications(ctx, Timestampz(tnow))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.SystemNotification{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve scheduled system notifications", common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved scheduled system notifications", "count", len(notifications))
+
+	return notifications, nil
+}
+
+func (impl *BusinessStoreImpl) CancelSystemNotification(ctx context.Context, id int32) (*dbgen.SystemNotification, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	n, err := impl.querier.CancelSystemNotification(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to cancel system notification", "notifID", id, common.ErrAttr(err))
+		return nil, err
+	}
+
+	// sessions can still reference cancelled notification by ID
+	_ = impl.cache.Set(ctx, notificationCacheKey(n.ID), n)
+
+	slog.InfoContext(ctx, "Cancelled system notification", "notifID", n.ID)
+
+	return n, nil
+}
+
 func (impl *BusinessStoreImpl) RetrieveProperties(ctx context.Context, limit int) ([]*dbgen.Property, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
@@ -1909,6 +2941,27 @@ func (impl *BusinessStoreImpl) RetrieveUserPropertiesCount(ctx context.Context,
 	return count, nil
 }
 
+// RetrieveUserPropertiesOverLimit returns (up to max) newest properties of the user that do not fit into the limit
+func (impl *BusinessStoreImpl) RetrieveUserPropertiesOverLimit(ctx context.Context, userID int32, limit int, max int) ([]*dbgen.GetUserPropertiesOverLimitRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	properties, err := impl.querier.GetUserPropertiesOverLimit(ctx, &dbgen.GetUserPropertiesOverLimitParams{
+		OrgOwnerID: Int(userID),
+		Offset:     int32(limit),
+		Limit:      int32(max),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user properties over limit", "userID", userID, "limit", limit, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched user properties over limit", "userID", userID, "limit", limit, "count", len(properties))
+
+	return properties, nil
+}
+
 func (impl *BusinessStoreImpl) GetCachedPropertyBySitekey(ctx context.Context, sitekey string, refreshFunc func(string)) (*dbgen.Property, error) {
 	if sitekey == TestPropertySitekey {
 		return nil, ErrTestProperty
@@ -2105,17 +3158,31 @@ func (impl *BusinessStoreImpl) CreateUserNotification(ctx context.Context, n *co
 		return nil, err
 	}
 
+	payloadHash := NotificationPayloadHash(payload)
+	// bulk sends share the same payload so it is stored only once
+	if err := impl.querier.CreateNotificationPayload(ctx, &dbgen.CreateNotificationPayloadParams{
+		Hash:    payloadHash,
+		Payload: payload,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to create notification payload", "userID", n.UserID, "refID", n.ReferenceID, common.ErrAttr(err))
+		return nil, err
+	}
+
 	// NOTE: we don't add template to DB (again) because it should have been done with RegisterEmailTemplatesJob on startup
 	params := &dbgen.CreateUserNotificationParams{
 		UserID:      Int(n.UserID),
 		ReferenceID: n.ReferenceID,
 		TemplateID:  Text(n.TemplateHash),
 		Subject:     n.Subject,
-		Payload:     payload,
+		PayloadHash: Text(payloadHash),
 		ScheduledAt: Timestampz(n.DateTime),
 		Persistent:  n.Persistent,
 	}
 
+	if n.OrgID != 0 {
+		params.OrgID = Int(n.OrgID)
+	}
+
 	switch n.Condition {
 	case common.EmptyNotificationCondition:
 		params.RequiresSubscription = pgtype.Bool{Valid: false}
@@ -2165,11 +3232,43 @@ func (impl *BusinessStoreImpl) RetrievePendingUserNotifications(ctx context.Cont
 		return nil, err
 	}
 
+	for _, r := range result {
+		// notifications created before payloads were content-addressed have inline payload
+		if len(r.UserNotification.Payload) == 0 {
+			r.UserNotification.Payload = r.SharedPayload
+		}
+	}
+
 	slog.DebugContext(ctx, "Retrieved pending user notifications", "count", len(result), "since", since)
 
 	return result, nil
 }
 
+// RetrieveSentUserNotificationsCounts returns counts of emails sent to users within the period, indexed by user ID
+func (impl *BusinessStoreImpl) RetrieveSentUserNotificationsCounts(ctx context.Context, from, to time.Time) (map[int32]int64, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := impl.querier.GetSentUserNotificationsCounts(ctx, &dbgen.GetSentUserNotificationsCountsParams{
+		ProcessedAt:   Timestampz(from),
+		ProcessedAt_2: Timestampz(to),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve sent user notifications counts", "from", from, "to", to, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make(map[int32]int64, len(rows))
+	for _, r := range rows {
+		result[r.UserID.Int32] = r.Count
+	}
+
+	slog.DebugContext(ctx, "Retrieved sent user notifications counts", "users", len(result), "from", from, "to", to)
+
+	return result, nil
+}
+
 func (impl *BusinessStoreImpl) MarkUserNotificationsAttempted(ctx context.Context, ids []int32) error {
 	if len(ids) == 0 {
 		return nil
@@ -2211,178 +3310,1915 @@ func (impl *BusinessStoreImpl) MarkUserNotificationsProcessed(ctx context.Contex
 	return nil
 }
 
-func (impl *BusinessStoreImpl) DeleteUnusedNotificationTemplates(ctx context.Context, processedBefore, updatedBefore time.Time) error {
+func (impl *BusinessStoreImpl) MarkUserNotificationsSuppressed(ctx context.Context, ids []int32, t time.Time) error {
+	if (len(ids) == 0) || t.IsZero() {
+		return nil
+	}
+
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	if err := impl.querier.DeleteUnusedNotificationTemplates(ctx, &dbgen.DeleteUnusedNotificationTemplatesParams{
-		ProcessedAt: Timestampz(processedBefore),
-		UpdatedAt:   Timestampz(updatedBefore),
+	if err := impl.querier.UpdateSuppressedUserNotifications(ctx, &dbgen.UpdateSuppressedUserNotificationsParams{
+		ProcessedAt: Timestampz(t),
+		Column2:     ids,
 	}); err != nil {
-		slog.ErrorContext(ctx, "Failed to delete unused notification templates", common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to update suppressed user notifications", "count", len(ids), common.ErrAttr(err))
 		return err
 	}
 
-	slog.InfoContext(ctx, "Deleted unused notification templates", "delivered_before", processedBefore, "updated_before", updatedBefore)
+	slog.InfoContext(ctx, "Updated suppressed user notifications", "count", len(ids), "suppressed_at", t)
 
 	return nil
 }
 
-func (impl *BusinessStoreImpl) DeleteSentUserNotifications(ctx context.Context, before time.Time) error {
-	if before.IsZero() {
-		return ErrInvalidInput
+// RescheduleUserNotifications moves pending notifications to the new scheduled times (indexed by notification ID)
+func (impl *BusinessStoreImpl) RescheduleUserNotifications(ctx context.Context, schedule map[int32]time.Time) error {
+	if len(schedule) == 0 {
+		return nil
 	}
 
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	if err := impl.querier.DeleteProcessedUserNotifications(ctx, Timestampz(before)); err != nil {
-		slog.ErrorContext(ctx, "Failed to delete sent user notifications", common.ErrAttr(err))
+	ids := make([]int32, 0, len(schedule))
+	scheduledAt := make([]pgtype.Timestamptz, 0, len(schedule))
+	for id, t := range schedule {
+		ids = append(ids, id)
+		scheduledAt = append(scheduledAt, Timestampz(t))
+	}
+
+	if err := impl.querier.UpdateUserNotificationsSchedule(ctx, &dbgen.UpdateUserNotificationsScheduleParams{
+		Ids:         ids,
+		ScheduledAt: scheduledAt,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to reschedule user notifications", "count", len(ids), common.ErrAttr(err))
 		return err
 	}
 
-	slog.InfoContext(ctx, "Deleted sent user notifications", "before", before)
+	slog.InfoContext(ctx, "Rescheduled user notifications", "count", len(ids))
 
 	return nil
 }
 
-func (impl *BusinessStoreImpl) DeleteUnsentUserNotifications(ctx context.Context, before time.Time) error {
-	if before.IsZero() {
-		return ErrInvalidInput
+// UpdateUserLocale remembers user's time zone and country, empty values keep previously known ones
+func (impl *BusinessStoreImpl) UpdateUserLocale(ctx context.Context, userID int32, timezone, country string) error {
+	if (len(timezone) == 0) && (len(country) == 0) {
+		return nil
 	}
 
 	if impl.querier == nil {
 		return ErrMaintenance
 	}
 
-	if err := impl.querier.DeleteUnprocessedUserNotifications(ctx, Timestampz(before)); err != nil {
-		slog.ErrorContext(ctx, "Failed to delete UNsent user notifications", common.ErrAttr(err))
-		return err
+	params := &dbgen.UpsertUserLocaleParams{UserID: userID}
+	if len(timezone) > 0 {
+		params.Timezone = Text(timezone)
 	}
-
-	slog.InfoContext(ctx, "Deleted UNsent user notifications", "before", before)
-
-	return nil
-}
-
-func (impl *BusinessStoreImpl) DeletePendingUserNotification(ctx context.Context, user *dbgen.User, referenceID string) error {
-	if impl.querier == nil {
-		return ErrMaintenance
+	if len(country) > 0 {
+		params.Country = Text(country)
 	}
 
-	if err := impl.querier.DeletePendingUserNotification(ctx, &dbgen.DeletePendingUserNotificationParams{
-		UserID:      Int(user.ID),
-		ReferenceID: referenceID,
-	}); err != nil {
-		slog.ErrorContext(ctx, "Failed to delete pending user notification", "userID", user.ID, "refID", referenceID, common.ErrAttr(err))
+	if err := impl.querier.UpsertUserLocale(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to update user locale", "userID", userID, common.ErrAttr(err))
 		return err
 	}
 
-	slog.InfoContext(ctx, "Deleted pending user notification", "userID", user.ID, "refID", referenceID)
+	slog.DebugContext(ctx, "Updated user locale", "userID", userID, "timezone", timezone, "country", country)
 
 	return nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveTrialUsers(ctx context.Context, from, to time.Time, status string, maxUsers int32, internal bool) ([]*dbgen.User, error) {
-	if from.IsZero() || to.IsZero() {
-		return nil, ErrInvalidInput
-	}
-
+func (impl *BusinessStoreImpl) RetrieveUserDevices(ctx context.Context, userID int32) ([]*dbgen.UserDevice, error) {
 	if impl.querier == nil {
 		return nil, ErrMaintenance
 	}
 
-	params := &dbgen.GetTrialUsersParams{
-		TrialEndsAt:   Timestampz(from),
-		TrialEndsAt_2: Timestampz(to),
-		Status:        status,
-		Limit:         maxUsers,
-	}
-
-	if internal {
-		params.Source = dbgen.SubscriptionSourceInternal
-	} else {
-		params.Source = dbgen.SubscriptionSourceExternal
-	}
-
-	users, err := impl.querier.GetTrialUsers(ctx, params)
+	devices, err := impl.querier.GetUserDevices(ctx, userID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return []*dbgen.User{}, nil
+			return []*dbgen.UserDevice{}, nil
 		}
 
-		slog.ErrorContext(ctx, "Failed to retrieve trial users", "from", from, "to", to, "status", status, common.ErrAttr(err))
-
+		slog.ErrorContext(ctx, "Failed to retrieve user devices", "userID", userID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched trial users", "count", len(users), "from", from, "to", to, "status", status)
-
-	return users, nil
+	return devices, nil
 }
 
-func (impl *BusinessStoreImpl) ExpireInternalTrials(ctx context.Context, from, to time.Time, activeStatus, expiredStatus string) error {
+// UpdateUserDevice adds device to the known devices of the user or refreshes when it was last seen
+func (impl *BusinessStoreImpl) UpdateUserDevice(ctx context.Context, userID int32, browser, osName, country, sessionID string) (*dbgen.UserDevice, error) {
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	if err := impl.querier.UpdateInternalSubscriptions(ctx, &dbgen.UpdateInternalSubscriptionsParams{
-		TrialEndsAt:   Timestampz(from),
-		TrialEndsAt_2: Timestampz(to),
-		Status:        expiredStatus,
-		Status_2:      activeStatus,
+	device, err := impl.querier.UpsertUserDevice(ctx, &dbgen.UpsertUserDeviceParams{
+		UserID:    userID,
+		Browser:   browser,
+		Os:        osName,
+		Country:   country,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update user device", "userID", userID, "browser", browser, "os", osName, "country", country,
+			common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Updated user device", "userID", userID, "deviceID", device.ID)
+
+	return device, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteUserDevice(ctx context.Context, user *dbgen.User, deviceID int32) (*dbgen.UserDevice, error) {
+	if user == nil {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	device, err := impl.querier.DeleteUserDevice(ctx, &dbgen.DeleteUserDeviceParams{
+		ID:     deviceID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to delete user device", "userID", user.ID, "deviceID", deviceID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Deleted user device", "userID", user.ID, "deviceID", device.ID)
+
+	return device, nil
+}
+
+// FindUserBySSOLink returns user that explicitly linked the SSO identity to their account
+func (impl *BusinessStoreImpl) FindUserBySSOLink(ctx context.Context, issuer, subject string) (*dbgen.User, error) {
+	if (len(issuer) == 0) || (len(subject) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	user, err := impl.querier.GetUserBySSOLink(ctx, &dbgen.GetUserBySSOLinkParams{
+		Issuer:  issuer,
+		Subject: subject,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve user by SSO link", "issuer", issuer, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserSSOLinks(ctx context.Context, userID int32) ([]*dbgen.UserSSOLink, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	links, err := impl.querier.GetUserSSOLinks(ctx, userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.UserSSOLink{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve user SSO links", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// LinkUserSSO links SSO identity to the account, which lets user sign in with it afterwards
+func (impl *BusinessStoreImpl) LinkUserSSO(ctx context.Context, user *dbgen.User, issuer, subject string) (*common.AuditLogEvent, error) {
+	if (user == nil) || (len(issuer) == 0) || (len(subject) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	if existing, err := impl.FindUserBySSOLink(ctx, issuer, subject); err == nil {
+		if existing.ID != user.ID {
+			slog.WarnContext(ctx, "SSO identity is linked to another user", "userID", user.ID, "existingUserID", existing.ID)
+			return nil, ErrAlreadyLinked
+		}
+
+		slog.DebugContext(ctx, "SSO identity is already linked", "userID", user.ID)
+		return nil, nil
+	} else if err != ErrRecordNotFound {
+		return nil, err
+	}
+
+	link, err := impl.querier.CreateUserSSOLink(ctx, &dbgen.CreateUserSSOLinkParams{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: subject,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to link SSO identity", "userID", user.ID, "issuer", issuer, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Linked SSO identity", "userID", user.ID, "linkID", link.ID)
+
+	return NewUserSSOLinkAuditLogEvent(user.ID, link), nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserNotifications(ctx context.Context, userID int32, limit int) ([]*dbgen.UserNotification, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	notifications, err := impl.querier.GetUserNotifications(ctx, &dbgen.GetUserNotificationsParams{
+		UserID: Int(userID),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user notifications", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserNotificationOptOuts(ctx context.Context, userID int32) ([]string, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	optOuts, err := impl.querier.GetUserNotificationOptOuts(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve notification opt-outs", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return optOuts, nil
+}
+
+// RetrieveNotificationOptOuts returns opted out template names indexed by user ID
+func (impl *BusinessStoreImpl) RetrieveNotificationOptOuts(ctx context.Context, userIDs []int32) (map[int32]map[string]struct{}, error) {
+	result := make(map[int32]map[string]struct{})
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	preferences, err := impl.querier.GetNotificationOptOutsForUsers(ctx, userIDs)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve notification opt-outs", "users", len(userIDs), common.ErrAttr(err))
+		return nil, err
+	}
+
+	for _, p := range preferences {
+		if _, ok := result[p.UserID]; !ok {
+			result[p.UserID] = make(map[string]struct{})
+		}
+		result[p.UserID][p.TemplateName] = struct{}{}
+	}
+
+	return result, nil
+}
+
+func (impl *BusinessStoreImpl) UpdateNotificationOptOut(ctx context.Context, userID int32, templateName string, optOut bool) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	var err error
+	if optOut {
+		err = impl.querier.CreateNotificationOptOut(ctx, &dbgen.CreateNotificationOptOutParams{UserID: userID, TemplateName: templateName})
+	} else {
+		err = impl.querier.DeleteNotificationOptOut(ctx, &dbgen.DeleteNotificationOptOutParams{UserID: userID, TemplateName: templateName})
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update notification opt-out", "userID", userID, "template", templateName, "optOut", optOut, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Updated notification opt-out", "userID", userID, "template", templateName, "optOut", optOut)
+
+	return nil
+}
+
+// RetrieveUserStatsDigests returns digest frequency indexed by org ID for orgs where user opted in
+func (impl *BusinessStoreImpl) RetrieveUserStatsDigests(ctx context.Context, userID int32) (map[int32]dbgen.DigestFrequency, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	digests, err := impl.querier.GetUserStatsDigests(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve stats digests", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make(map[int32]dbgen.DigestFrequency, len(digests))
+	for _, d := range digests {
+		result[d.OrgID] = d.Frequency
+	}
+
+	return result, nil
+}
+
+// UpdateStatsDigest subscribes user to the org digest with the frequency or unsubscribes if frequency is not valid
+func (impl *BusinessStoreImpl) UpdateStatsDigest(ctx context.Context, userID, orgID int32, frequency dbgen.NullDigestFrequency) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	var err error
+	if frequency.Valid {
+		err = impl.querier.UpsertStatsDigest(ctx, &dbgen.UpsertStatsDigestParams{UserID: userID, OrgID: orgID, Frequency: frequency.DigestFrequency})
+	} else {
+		err = impl.querier.DeleteStatsDigest(ctx, &dbgen.DeleteStatsDigestParams{UserID: userID, OrgID: orgID})
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update stats digest", "userID", userID, "orgID", orgID, "frequency", frequency.DigestFrequency, common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Updated stats digest", "userID", userID, "orgID", orgID, "frequency", frequency.DigestFrequency)
+
+	return nil
+}
+
+// RetrievePendingStatsDigests returns digests of the frequency that were not yet sent for the period ending at sentUntil
+func (impl *BusinessStoreImpl) RetrievePendingStatsDigests(ctx context.Context, frequency dbgen.DigestFrequency, sentUntil time.Time, limit int) ([]*dbgen.GetPendingStatsDigestsRow, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	digests, err := impl.querier.GetPendingStatsDigests(ctx, &dbgen.GetPendingStatsDigestsParams{
+		Frequency: frequency,
+		SentUntil: Timestampz(sentUntil),
+		Limit:     int32(limit),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve pending stats digests", "frequency", frequency, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched pending stats digests", "count", len(digests), "frequency", frequency)
+
+	return digests, nil
+}
+
+func (impl *BusinessStoreImpl) MarkStatsDigestsSent(ctx context.Context, digests []*dbgen.StatsDigest, sentUntil time.Time) error {
+	if len(digests) == 0 {
+		return nil
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	userIDs := make([]int32, 0, len(digests))
+	orgIDs := make([]int32, 0, len(digests))
+	for _, d := range digests {
+		userIDs = append(userIDs, d.UserID)
+		orgIDs = append(orgIDs, d.OrgID)
+	}
+
+	if err := impl.querier.UpdateStatsDigestsSent(ctx, &dbgen.UpdateStatsDigestsSentParams{
+		SentUntil: Timestampz(sentUntil),
+		UserIds:   userIDs,
+		OrgIds:    orgIDs,
 	}); err != nil {
-		slog.ErrorContext(ctx, "Failed to expire internal trials", "from", from, "to", to, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to mark stats digests as sent", "count", len(digests), common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Marked stats digests as sent", "count", len(digests), "until", sentUntil)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) DeleteUnusedNotificationTemplates(ctx context.Context, processedBefore, updatedBefore time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteUnusedNotificationTemplates(ctx, &dbgen.DeleteUnusedNotificationTemplatesParams{
+		ProcessedAt: Timestampz(processedBefore),
+		UpdatedAt:   Timestampz(updatedBefore),
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete unused notification templates", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted unused notification templates", "delivered_before", processedBefore, "updated_before", updatedBefore)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) DeleteUnusedNotificationPayloads(ctx context.Context, updatedBefore time.Time) error {
+	if updatedBefore.IsZero() {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteUnusedNotificationPayloads(ctx, Timestampz(updatedBefore)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete unused notification payloads", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted unused notification payloads", "updated_before", updatedBefore)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) DeleteSentUserNotifications(ctx context.Context, before time.Time) error {
+	if before.IsZero() {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteProcessedUserNotifications(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete sent user notifications", common.ErrAttr(err))
 		return err
 	}
 
-	// NOTE: we don't update caches in this case
+	slog.InfoContext(ctx, "Deleted sent user notifications", "before", before)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) DeleteUnsentUserNotifications(ctx context.Context, before time.Time) error {
+	if before.IsZero() {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteUnprocessedUserNotifications(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete UNsent user notifications", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted UNsent user notifications", "before", before)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) DeletePendingUserNotification(ctx context.Context, user *dbgen.User, referenceID string) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeletePendingUserNotification(ctx, &dbgen.DeletePendingUserNotificationParams{
+		UserID:      Int(user.ID),
+		ReferenceID: referenceID,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete pending user notification", "userID", user.ID, "refID", referenceID, common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted pending user notification", "userID", user.ID, "refID", referenceID)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveTrialUsers(ctx context.Context, from, to time.Time, status string, maxUsers int32, internal bool) ([]*dbgen.User, error) {
+	if from.IsZero() || to.IsZero() {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	params := &dbgen.GetTrialUsersParams{
+		TrialEndsAt:   Timestampz(from),
+		TrialEndsAt_2: Timestampz(to),
+		Status:        status,
+		Limit:         maxUsers,
+	}
+
+	if internal {
+		params.Source = dbgen.SubscriptionSourceInternal
+	} else {
+		params.Source = dbgen.SubscriptionSourceExternal
+	}
+
+	users, err := impl.querier.GetTrialUsers(ctx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.User{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve trial users", "from", from, "to", to, "status", status, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched trial users", "count", len(users), "from", from, "to", to, "status", status)
+
+	return users, nil
+}
+
+func (impl *BusinessStoreImpl) ExpireInternalTrials(ctx context.Context, from, to time.Time, activeStatus, expiredStatus string) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.UpdateInternalSubscriptions(ctx, &dbgen.UpdateInternalSubscriptionsParams{
+		TrialEndsAt:   Timestampz(from),
+		TrialEndsAt_2: Timestampz(to),
+		Status:        expiredStatus,
+		Status_2:      activeStatus,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to expire internal trials", "from", from, "to", to, common.ErrAttr(err))
+		return err
+	}
+
+	// NOTE: we don't update caches in this case
+
+	slog.InfoContext(ctx, "Expired internal trials", "from", from, "to", to)
+
+	return nil
+}
+
+// MoveProperty moves property from oldOrg to org. Limits of the destination org owner's plan are supposed to be checked
+// by the caller and are only recorded in the audit log.
+func (impl *BusinessStoreImpl) MoveProperty(ctx context.Context, user *dbgen.User, property *dbgen.Property, oldOrg *dbgen.Organization, org *dbgen.GetUserOrganizationsRow, limits *PropertyMoveLimits) (*dbgen.Property, *common.AuditLogEvent, error) {
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	if property.OrgID.Int32 == org.Organization.ID {
+		slog.WarnContext(ctx, "Property is already in the destination org", "propID", property.ID, "orgID", property.OrgID.Int32)
+		return nil, nil, ErrInvalidInput
+	}
+
+	oldOrgID := property.OrgID.Int32
+	oldOwnerID := property.OrgOwnerID.Int32
+
+	updatedProperty, err := impl.querier.MoveProperty(ctx, &dbgen.MovePropertyParams{
+		ID:         property.ID,
+		OrgID:      Int(org.Organization.ID),
+		OrgOwnerID: org.Organization.UserID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to move property to another org", "propID", property.ID, "oldOrgID", property.OrgID.Int32, "newOrgID", org.Organization.ID, common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "Moved property to another org", "propID", property.ID, "oldOrgID", property.OrgID.Int32, "newOrgID", org.Organization.ID)
+
+	// Invalidate cache for both old and new organizations
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(oldOrgID, orgPropertiesCacheKeyStr))
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(updatedProperty.OrgID.Int32, orgPropertiesCacheKeyStr))
+	_ = impl.cache.Delete(ctx, orgPropertiesCountCacheKey(oldOrgID))
+	_ = impl.cache.Delete(ctx, orgPropertiesCountCacheKey(updatedProperty.OrgID.Int32))
+	// and cache property
+	impl.updateCachedProperty(ctx, updatedProperty)
+	impl.onOrgPropertiesChanged(ctx, map[int32]int64{oldOrgID: -1, updatedProperty.OrgID.Int32: 1})
+
+	if (oldOrg == nil) || (oldOrg.ID != oldOrgID) {
+		oldOrg = &dbgen.Organization{ID: oldOrgID}
+	}
+
+	auditEvent := newMovePropertyAuditLogEvent(user, updatedProperty, oldOrg, &org.Organization, oldOwnerID, limits)
+
+	return updatedProperty, auditEvent, nil
+}
+
+// UpdatePropertyEmergency activates emergency mode of the property until the given time or deactivates it (zero time)
+func (impl *BusinessStoreImpl) UpdatePropertyEmergency(ctx context.Context, user *dbgen.User, property *dbgen.Property, org *dbgen.Organization, until time.Time) (*dbgen.Property, *common.AuditLogEvent, error) {
+	if (user == nil) || (property == nil) {
+		return nil, nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	updatedProperty, err := impl.querier.UpdatePropertyEmergency(ctx, &dbgen.UpdatePropertyEmergencyParams{
+		ID:             property.ID,
+		EmergencyUntil: Timestampz(until),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to update property emergency mode", "propID", property.ID, "until", until, common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "Updated property emergency mode", "propID", property.ID, "userID", user.ID, "until", until)
+
+	impl.updateCachedProperty(ctx, updatedProperty)
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(updatedProperty.OrgID.Int32, orgPropertiesCacheKeyStr))
+	_ = impl.cache.Delete(ctx, propertyAuditLogsCacheKey(updatedProperty.ID))
+
+	auditEvent := newPropertyEmergencyAuditLogEvent(user, updatedProperty, property.EmergencyUntil, org)
+
+	return updatedProperty, auditEvent, nil
+}
+
+// UpdatePropertyPrivacyMode sets privacy mode override of the property (empty mode inherits installation mode)
+func (impl *BusinessStoreImpl) UpdatePropertyPrivacyMode(ctx context.Context, user *dbgen.User, property *dbgen.Property, org *dbgen.Organization, mode common.PrivacyMode) (*dbgen.Property, *common.AuditLogEvent, error) {
+	if (user == nil) || (property == nil) || (common.ParsePrivacyMode(string(mode)) != mode) {
+		return nil, nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	updatedProperty, err := impl.querier.UpdatePropertyPrivacyMode(ctx, &dbgen.UpdatePropertyPrivacyModeParams{
+		ID:          property.ID,
+		PrivacyMode: string(mode),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to update property privacy mode", "propID", property.ID, "mode", mode, common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "Updated property privacy mode", "propID", property.ID, "userID", user.ID, "mode", mode)
+
+	impl.updateCachedProperty(ctx, updatedProperty)
+	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(updatedProperty.OrgID.Int32, orgPropertiesCacheKeyStr))
+	_ = impl.cache.Delete(ctx, propertyAuditLogsCacheKey(updatedProperty.ID))
+
+	auditEvent := newPropertyPrivacyModeAuditLogEvent(user, updatedProperty, property.PrivacyMode, org)
+
+	return updatedProperty, auditEvent, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOldAuditLogs(ctx context.Context, before time.Time) error {
+	if before.IsZero() {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteOldAuditLogs(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to deleted old audit logs", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted old audit logs", "before", before)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) CreateLimitDecision(ctx context.Context, params *dbgen.CreateLimitDecisionParams) error {
+	if params == nil {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.CreateLimitDecision(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to create limit decision", "userID", params.UserID.Int32, "resource", params.Resource,
+			common.ErrAttr(err))
+		return err
+	}
+
+	slog.DebugContext(ctx, "Recorded limit decision", "userID", params.UserID.Int32, "resource", params.Resource,
+		"code", params.StatusCode)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserLimitDecisions(ctx context.Context, userID int32, after time.Time, limit int) ([]*dbgen.LimitDecision, error) {
+	if (limit <= 0) || after.IsZero() {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	decisions, err := impl.querier.GetUserLimitDecisions(ctx, &dbgen.GetUserLimitDecisionsParams{
+		UserID:    Int(userID),
+		CreatedAt: Timestampz(after),
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.LimitDecision{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve user limit decisions", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved user limit decisions", "userID", userID, "count", len(decisions))
+
+	return decisions, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOldLimitDecisions(ctx context.Context, before time.Time) error {
+	if before.IsZero() {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteOldLimitDecisions(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete old limit decisions", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted old limit decisions", "before", before)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) GetCachedAuditLogs(ctx context.Context, user *dbgen.User, limit int, after time.Time, cachedAfter time.Time) ([]*dbgen.GetUserAuditLogsRow, error) {
+	if (limit <= 0) || after.IsZero() || cachedAfter.IsZero() {
+		return nil, ErrInvalidInput
+	}
+
+	if after.Before(cachedAfter) {
+		slog.ErrorContext(ctx, "Audit logs cutoff date should be always after cache key", "after", after, "cachedAfter", cachedAfter)
+		return nil, ErrInvalidInput
+	}
+
+	cacheKey := userAuditLogsCacheKey(user.ID, cachedAfter.Format(time.DateOnly))
+	if logs, err := FetchCachedArray[dbgen.GetUserAuditLogsRow](ctx, impl.cache, cacheKey); err == nil {
+		if after.Equal(cachedAfter) {
+			return logs, nil
+		}
+
+		result := make([]*dbgen.GetUserAuditLogsRow, 0, len(logs))
+		for _, log := range logs {
+			if log.AuditLog.CreatedAt.Valid && !log.AuditLog.CreatedAt.Time.Before(after) {
+				result = append(result, log)
+
+				if len(result) >= limit {
+					break
+				}
+			}
+		}
+		return result, nil
+	} else {
+		return nil, err
+	}
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserAuditLogs(ctx context.Context, user *dbgen.User, limit int, after time.Time) ([]*dbgen.GetUserAuditLogsRow, error) {
+	if (limit <= 0) || after.IsZero() {
+		return nil, ErrInvalidInput
+	}
+
+	reader := &StoreArrayReader[*dbgen.GetUserAuditLogsParams, dbgen.GetUserAuditLogsRow]{
+		CacheKey: userAuditLogsCacheKey(user.ID, after.Format(time.DateOnly)),
+		Cache:    impl.cache,
+		TTL:      5 * time.Minute,
+	}
+
+	if impl.querier != nil {
+		reader.QueryKeyFunc = func(ck CacheKey) (*dbgen.GetUserAuditLogsParams, error) {
+			return &dbgen.GetUserAuditLogsParams{
+				UserID:    Int(user.ID),
+				Offset:    0,
+				Limit:     int32(limit),
+				CreatedAt: Timestampz(after),
+			}, nil
+		}
+		reader.QueryFunc = impl.querier.GetUserAuditLogs
+	}
+
+	return reader.Read(ctx)
+}
+
+// RetrieveUserAuditLogsBefore returns a page of user audit logs (newest first) older than the item (createdAt, id)
+// of the previous page. Zero createdAt means there's no previous page. Results are not cached as pages shift over time.
+func (impl *BusinessStoreImpl) RetrieveUserAuditLogsBefore(ctx context.Context, user *dbgen.User, after time.Time, createdAt time.Time, id int64, offset, limit int) ([]*dbgen.GetUserAuditLogsBeforeRow, bool, error) {
+	if (limit <= 0) || (offset < 0) || after.IsZero() {
+		return nil, false, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, false, ErrMaintenance
+	}
+
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC().Add(time.Hour)
+		id = math.MaxInt64
+	}
+
+	logs, err := impl.querier.GetUserAuditLogsBefore(ctx, &dbgen.GetUserAuditLogsBeforeParams{
+		UserID:          Int(user.ID),
+		CreatedAt:       Timestampz(after),
+		Offset:          int32(offset),
+		Limit:           int32(limit) + 1,
+		BeforeCreatedAt: Timestampz(createdAt),
+		BeforeID:        id,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user audit logs", "userID", user.ID, "before", id, "limit", limit, common.ErrAttr(err))
+		return nil, false, err
+	}
+
+	slog.DebugContext(ctx, "Retrieved user audit logs", "userID", user.ID, "before", id, "limit", limit, "count", len(logs))
+
+	return logs[:min(len(logs), limit)], len(logs) > limit, nil
+}
+
+func (impl *BusinessStoreImpl) RetrievePropertyAuditLogs(ctx context.Context, property *dbgen.Property, limit int) ([]*dbgen.GetPropertyAuditLogsRow, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	reader := &StoreArrayReader[*dbgen.GetPropertyAuditLogsParams, dbgen.GetPropertyAuditLogsRow]{
+		CacheKey: propertyAuditLogsCacheKey(property.ID),
+		Cache:    impl.cache,
+		TTL:      5 * time.Minute,
+	}
+
+	if impl.querier != nil {
+		reader.QueryKeyFunc = func(ck CacheKey) (*dbgen.GetPropertyAuditLogsParams, error) {
+			return &dbgen.GetPropertyAuditLogsParams{
+				EntityID:  Int8(int64(property.ID)),
+				CreatedAt: property.CreatedAt,
+				Offset:    0,
+				Limit:     int32(limit),
+			}, nil
+		}
+		reader.QueryFunc = impl.querier.GetPropertyAuditLogs
+	}
+
+	logs, err := reader.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// extra slice is due to possible caching in portal where for /auditlogs we can fetch and cache multiple entries
+	// but later for /property/auditlogs we will show only up to {limit}
+	return logs[0:min(len(logs), limit)], nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveOrganizationAuditLogs(ctx context.Context, org *dbgen.Organization, limit int) ([]*dbgen.GetOrgAuditLogsRow, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	reader := &StoreArrayReader[*dbgen.GetOrgAuditLogsParams, dbgen.GetOrgAuditLogsRow]{
+		CacheKey: orgAuditLogsCacheKey(org.ID),
+		Cache:    impl.cache,
+		TTL:      5 * time.Minute,
+	}
+
+	if impl.querier != nil {
+		reader.QueryKeyFunc = func(ck CacheKey) (*dbgen.GetOrgAuditLogsParams, error) {
+			return &dbgen.GetOrgAuditLogsParams{
+				EntityID:  Int8(int64(org.ID)),
+				CreatedAt: org.CreatedAt,
+				Offset:    0,
+				Limit:     int32(limit),
+			}, nil
+		}
+		reader.QueryFunc = impl.querier.GetOrgAuditLogs
+	}
+
+	logs, err := reader.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// extra slice is due to possible caching in portal where for /auditlogs we can fetch and cache multiple entries
+	// but later for /org/auditlogs we will show only up to {limit}
+	return logs[0:min(len(logs), limit)], nil
+}
+
+// search results are not cached as queries are rarely repeated
+func (impl *BusinessStoreImpl) SearchUserAuditLogs(ctx context.Context, user *dbgen.User, query string, limit int, after time.Time) ([]*dbgen.GetUserAuditLogsRow, error) {
+	if (limit <= 0) || after.IsZero() || (len(query) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	logs, err := impl.querier.SearchUserAuditLogs(ctx, &dbgen.SearchUserAuditLogsParams{
+		UserID:     Int(user.ID),
+		Query:      query,
+		CreatedAt:  Timestampz(after),
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetUserAuditLogsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to search user audit logs", "userID", user.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make([]*dbgen.GetUserAuditLogsRow, 0, len(logs))
+	for _, log := range logs {
+		result = append(result, (*dbgen.GetUserAuditLogsRow)(log))
+	}
+
+	return result, nil
+}
+
+// SearchUserAuditLogsBefore is a paginated version of SearchUserAuditLogs (see RetrieveUserAuditLogsBefore)
+func (impl *BusinessStoreImpl) SearchUserAuditLogsBefore(ctx context.Context, user *dbgen.User, query string, after time.Time, createdAt time.Time, id int64, limit int) ([]*dbgen.GetUserAuditLogsRow, bool, error) {
+	if (limit <= 0) || after.IsZero() || (len(query) == 0) {
+		return nil, false, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, false, ErrMaintenance
+	}
+
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC().Add(time.Hour)
+		id = math.MaxInt64
+	}
+
+	logs, err := impl.querier.SearchUserAuditLogsBefore(ctx, &dbgen.SearchUserAuditLogsBeforeParams{
+		UserID:          Int(user.ID),
+		Query:           query,
+		CreatedAt:       Timestampz(after),
+		BeforeCreatedAt: Timestampz(createdAt),
+		BeforeID:        id,
+		MaxResults:      int32(limit) + 1,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetUserAuditLogsRow{}, false, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to search user audit logs", "userID", user.ID, "before", id, common.ErrAttr(err))
+		return nil, false, err
+	}
+
+	result := make([]*dbgen.GetUserAuditLogsRow, 0, min(len(logs), limit))
+	for _, log := range logs[:min(len(logs), limit)] {
+		result = append(result, (*dbgen.GetUserAuditLogsRow)(log))
+	}
+
+	return result, len(logs) > limit, nil
+}
+
+func (impl *BusinessStoreImpl) SearchPropertyAuditLogs(ctx context.Context, property *dbgen.Property, query string, limit int) ([]*dbgen.GetPropertyAuditLogsRow, error) {
+	if (limit <= 0) || (len(query) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	logs, err := impl.querier.SearchPropertyAuditLogs(ctx, &dbgen.SearchPropertyAuditLogsParams{
+		EntityID:   Int8(int64(property.ID)),
+		Query:      query,
+		CreatedAt:  property.CreatedAt,
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetPropertyAuditLogsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to search property audit logs", "propID", property.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make([]*dbgen.GetPropertyAuditLogsRow, 0, len(logs))
+	for _, log := range logs {
+		result = append(result, (*dbgen.GetPropertyAuditLogsRow)(log))
+	}
+
+	return result, nil
+}
+
+func (impl *BusinessStoreImpl) SearchOrganizationAuditLogs(ctx context.Context, org *dbgen.Organization, query string, limit int) ([]*dbgen.GetOrgAuditLogsRow, error) {
+	if (limit <= 0) || (len(query) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	logs, err := impl.querier.SearchOrgAuditLogs(ctx, &dbgen.SearchOrgAuditLogsParams{
+		EntityID:   Int8(int64(org.ID)),
+		Query:      query,
+		CreatedAt:  org.CreatedAt,
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetOrgAuditLogsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to search organization audit logs", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make([]*dbgen.GetOrgAuditLogsRow, 0, len(logs))
+	for _, log := range logs {
+		result = append(result, (*dbgen.GetOrgAuditLogsRow)(log))
+	}
+
+	return result, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveTraceAuditLogs(ctx context.Context, traceID string, limit int) ([]*dbgen.GetTraceAuditLogsRow, error) {
+	if (limit <= 0) || (len(traceID) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	logs, err := impl.querier.GetTraceAuditLogs(ctx, &dbgen.GetTraceAuditLogsParams{
+		TraceID: traceID,
+		Limit:   int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetTraceAuditLogsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve trace audit logs", common.TraceIDAttr(traceID), common.ErrAttr(err))
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// RetrieveSystemAuditLogs returns audit logs of all users in the tables within the period (oldest first)
+func (impl *BusinessStoreImpl) RetrieveSystemAuditLogs(ctx context.Context, tables []string, from, to time.Time, limit int) ([]*dbgen.GetSystemAuditLogsRow, error) {
+	if (limit <= 0) || (len(tables) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	logs, err := impl.querier.GetSystemAuditLogs(ctx, &dbgen.GetSystemAuditLogsParams{
+		Tables:     tables,
+		Since:      Timestampz(from),
+		Until:      Timestampz(to),
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetSystemAuditLogsRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve system audit logs", "tables", len(tables), "from", from, "to", to, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveLatestTableAuditLog(ctx context.Context, table string) (*dbgen.AuditLog, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	log, err := impl.querier.GetLatestTableAuditLog(ctx, table)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve latest audit log", "table", table, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return log, nil
+}
+
+func (impl *BusinessStoreImpl) ValidateOrgName(ctx context.Context, name string, user *dbgen.User) common.StatusCode {
+	const maxOrgNameLength = 255
+
+	if (len(name) == 0) || (len(name) > maxOrgNameLength) {
+		slog.WarnContext(ctx, "Name length is invalid", "length", len(name))
+
+		if len(name) == 0 {
+			return common.StatusOrgNameEmptyError
+		} else {
+			return common.StatusOrgNameTooLongError
+		}
+	}
+
+	const allowedPunctuation = "'-_&.:()[]"
+
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r):
+			continue
+		case unicode.IsDigit(r):
+			continue
+		case unicode.IsSpace(r):
+			continue
+		case strings.ContainsRune(allowedPunctuation, r):
+			continue
+		default:
+			slog.WarnContext(ctx, "Name contains invalid characters", "position", i, "rune", r)
+			return common.StatusOrgNameInvalidSymbolsError
+		}
+	}
+
+	if _, err := impl.FindOrg(ctx, name, user); err != ErrRecordNotFound {
+		slog.WarnContext(ctx, "Org already exists", "name", name, common.ErrAttr(err))
+		return common.StatusOrgNameDuplicateError
+	}
+
+	return common.StatusOK
+}
+
+func (impl *BusinessStoreImpl) ValidatePropertyName(ctx context.Context, name string, org *dbgen.Organization) common.StatusCode {
+	const maxPropertyNameLength = 255
+	if (len(name) == 0) || (len(name) > maxPropertyNameLength) {
+		slog.WarnContext(ctx, "Name length is invalid", "length", len(name))
+
+		if len(name) == 0 {
+			return common.StatusPropertyNameEmptyError
+		} else {
+			return common.StatusPropertyNameTooLongError
+		}
+	}
+
+	const allowedPunctuation = "'-_.:()[]"
+
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r):
+			continue
+		case unicode.IsDigit(r):
+			continue
+		case unicode.IsSpace(r):
+			continue
+		case strings.ContainsRune(allowedPunctuation, r):
+			continue
+		default:
+			slog.WarnContext(ctx, "Name contains invalid characters", "position", i, "rune", r)
+			return common.StatusPropertyNameInvalidSymbolsError
+		}
+	}
+
+	if org != nil {
+		if _, err := impl.FindOrgProperty(ctx, name, org); err != ErrRecordNotFound {
+			slog.WarnContext(ctx, "Property already exists", "name", name, common.ErrAttr(err))
+			return common.StatusPropertyNameDuplicateError
+		}
+	}
+
+	return common.StatusOK
+}
+
+func (impl *BusinessStoreImpl) CreateNewAsyncTask(ctx context.Context, data interface{}, handler string, user *dbgen.User, scheduledAt time.Time, referenceID string) (*dbgen.AsyncTask, error) {
+	if (data == nil) && (user == nil) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize payload for async request", common.ErrAttr(err))
+		return nil, err
+	}
+
+	if scheduledAt.IsZero() {
+		scheduledAt = time.Now().UTC()
+	}
+
+	params := &dbgen.CreateAsyncTaskParams{
+		Input:       payload,
+		Handler:     handler,
+		ReferenceID: referenceID,
+		ScheduledAt: Timestampz(scheduledAt),
+	}
+	if user != nil {
+		params.UserID = Int(user.ID)
+	}
+
+	tnow := time.Now().UTC()
+	uuid, err := impl.querier.CreateAsyncTask(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create async request", common.ErrAttr(err))
+		return nil, err
+	}
+
+	taskIDStr := UUIDToString(uuid)
+
+	slog.DebugContext(ctx, "Created async task", "taskID", taskIDStr)
+
+	// yes, we "fake" the response in order not to make Postgres to send (potentially large) inputs copy back
+	task := &dbgen.AsyncTask{
+		ID:                 uuid,
+		Handler:            handler,
+		Input:              payload,
+		Output:             nil,
+		UserID:             params.UserID,
+		ReferenceID:        referenceID,
+		ProcessingAttempts: 0,
+		CreatedAt:          Timestampz(tnow),
+		ScheduledAt:        params.ScheduledAt,
+		ProcessedAt:        pgtype.Timestamptz{},
+	}
+
+	cacheKey := asyncTaskCacheKey(taskIDStr)
+	_ = impl.cache.SetWithTTL(ctx, cacheKey, task, asyncTaskTTL)
+
+	return task, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveAsyncTask(ctx context.Context, uuid pgtype.UUID, user *dbgen.User) (*dbgen.AsyncTask, error) {
+	reader := &StoreOneReader[pgtype.UUID, dbgen.AsyncTask]{
+		CacheKey: asyncTaskCacheKey(UUIDToString(uuid)),
+		Cache:    impl.cache,
+	}
+
+	if impl.querier != nil {
+		reader.QueryFunc = impl.querier.GetAsyncTask
+		reader.QueryKeyFunc = queryKeyStringUUID
+	}
+
+	task, err := reader.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.UserID.Valid && (user != nil) && (task.UserID.Int32 != user.ID) {
+		return nil, ErrPermissions
+	}
+
+	return task, nil
+}
+
+func (impl *BusinessStoreImpl) RetrievePendingAsyncTasks(ctx context.Context, count int, before time.Time, maxProcessingAttempts int) ([]*dbgen.GetPendingAsyncTasksRow, error) {
+	if count <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	tasks, err := impl.querier.GetPendingAsyncTasks(ctx, &dbgen.GetPendingAsyncTasksParams{
+		ScheduledAt:        Timestampz(before),
+		ProcessingAttempts: int32(maxProcessingAttempts),
+		Limit:              int32(count),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.GetPendingAsyncTasksRow{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve async tasks", "before", before, "count", count, "attempts", maxProcessingAttempts, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched pending async tasks", "count", len(tasks))
+
+	return tasks, nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOldAsyncTasks(ctx context.Context, before time.Time) error {
+	if before.IsZero() {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.DeleteOldAsyncTasks(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete old async request", common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) UpdateAsyncTask(ctx context.Context, uuid pgtype.UUID, output []byte, processedAt time.Time) error {
+	if !uuid.Valid {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.UpdateAsyncTask(ctx, &dbgen.UpdateAsyncTaskParams{
+		ID:          uuid,
+		Output:      output,
+		ProcessedAt: Timestampz(processedAt), // if processedAt.IsZero(), we set to NULL
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update async task", "id", UUIDToString(uuid), common.ErrAttr(err))
+		return err
+	}
+
+	cacheKey := asyncTaskCacheKey(UUIDToString(uuid))
+	impl.cache.Delete(ctx, cacheKey)
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveOrgOwnerWithSubscription(ctx context.Context, org *dbgen.Organization, activeUser *dbgen.User) (owner *dbgen.User, subscr *dbgen.Subscription, err error) {
+	isUserOrgOwner := org.UserID.Valid && (org.UserID.Int32 == activeUser.ID)
+
+	if isUserOrgOwner {
+		owner = activeUser
+		if activeUser.SubscriptionID.Valid {
+			subscr, err = impl.RetrieveSubscription(ctx, activeUser.SubscriptionID.Int32)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to retrieve active user subscription", "userID", activeUser.ID, common.ErrAttr(err))
+				return nil, nil, err
+			}
+		}
+	} else {
+		slog.DebugContext(ctx, "Active user is not org owner", "userID", activeUser.ID, "orgUserID", org.UserID.Int32)
+
+		orgUser, err := impl.RetrieveUser(ctx, org.UserID.Int32)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve org's owner user by ID", "id", org.UserID.Int32, common.ErrAttr(err))
+			return nil, nil, err
+		}
+
+		owner = orgUser
+		subscr = nil
+
+		if orgUser.SubscriptionID.Valid {
+			subscr, err = impl.RetrieveSubscription(ctx, orgUser.SubscriptionID.Int32)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to retrieve org owner's subscription", "userID", org.UserID.Int32, common.ErrAttr(err))
+				return nil, nil, err
+			}
+		}
+	}
+
+	return
+}
+
+func (impl *BusinessStoreImpl) RetrieveOrgPropertiesCount(ctx context.Context, orgID int32) (int64, error) {
+	if impl.querier == nil {
+		return 0, ErrMaintenance
+	}
+
+	cacheKey := orgPropertiesCountCacheKey(orgID)
+	if count, err := FetchCachedOne[int64](ctx, impl.cache, cacheKey); err == nil {
+		return *count, nil
+	}
+
+	count, err := impl.querier.GetOrgPropertiesCount(ctx, Int(orgID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org properties count", "orgID", orgID, common.ErrAttr(err))
+		return 0, err
+	}
+
+	slog.DebugContext(ctx, "Fetched org properties count", "orgID", orgID, "count", count)
+
+	const propertiesCountTTL = 5 * time.Minute
+	c := new(int64)
+	*c = count
+	_ = impl.cache.SetWithTTL(ctx, cacheKey, c, propertiesCountTTL)
+
+	return count, nil
+}
+
+func (impl *BusinessStoreImpl) CleanupUserCache(ctx context.Context, userID int32) {
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
+	_ = impl.cache.Delete(ctx, UserAPIKeysCacheKey(userID))
+	_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(userID))
+
+	tnow := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, cacheDays := range []int{ /*14,*/ 30, 90, 180, 365} {
+		cachedAfter := tnow.AddDate(0 /*years*/, 0 /*months*/, -cacheDays)
+		key := cachedAfter.Format(time.DateOnly)
+		_ = impl.cache.Delete(ctx, userAuditLogsCacheKey(userID, key))
+	}
+}
+
+func (impl *BusinessStoreImpl) CreateDifficultyExperiment(ctx context.Context, params *dbgen.CreateDifficultyExperimentParams) (*dbgen.DifficultyExperiment, error) {
+	if (params == nil) || (params.PropertyID <= 0) || (params.OrgID <= 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	experiment, err := impl.querier.CreateDifficultyExperiment(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create difficulty experiment", "propID", params.PropertyID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Created difficulty experiment", "experimentID", experiment.ID, "propID", experiment.PropertyID,
+		"control", experiment.ControlLevel, "variant", experiment.VariantLevel, "weight", experiment.VariantWeight)
+
+	return experiment, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveRunningDifficultyExperiments(ctx context.Context) ([]*dbgen.DifficultyExperiment, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	experiments, err := impl.querier.GetRunningDifficultyExperiments(ctx)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.DifficultyExperiment{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve running difficulty experiments", common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched running difficulty experiments", "count", len(experiments))
+
+	return experiments, nil
+}
+
+func (impl *BusinessStoreImpl) RetrievePropertyDifficultyExperiments(ctx context.Context, property *dbgen.Property, limit int) ([]*dbgen.DifficultyExperiment, error) {
+	if (property == nil) || (limit <= 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	experiments, err := impl.querier.GetPropertyDifficultyExperiments(ctx, &dbgen.GetPropertyDifficultyExperimentsParams{
+		PropertyID: property.ID,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.DifficultyExperiment{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property difficulty experiments", "propID", property.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched property difficulty experiments", "propID", property.ID, "count", len(experiments))
+
+	return experiments, nil
+}
+
+func (impl *BusinessStoreImpl) ConcludeDifficultyExperiment(ctx context.Context, experimentID int32, outcome, conclusion string, tnow time.Time) (*dbgen.DifficultyExperiment, error) {
+	if (experimentID <= 0) || (len(outcome) == 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	experiment, err := impl.querier.ConcludeDifficultyExperiment(ctx, &dbgen.ConcludeDifficultyExperimentParams{
+		ID:          experimentID,
+		ConcludedAt: Timestampz(tnow),
+		Outcome:     outcome,
+		Conclusion:  conclusion,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			// experiment was already concluded
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to conclude difficulty experiment", "experimentID", experimentID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Concluded difficulty experiment", "experimentID", experimentID, "propID", experiment.PropertyID,
+		"outcome", outcome, "conclusion", conclusion)
+
+	return experiment, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveOrgBillingContacts(ctx context.Context, org *dbgen.Organization) ([]*dbgen.BillingContact, error) {
+	if org == nil {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	contacts, err := impl.querier.GetOrgBillingContacts(ctx, org.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.BillingContact{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve org billing contacts", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Fetched org billing contacts", "orgID", org.ID, "count", len(contacts))
+
+	return contacts, nil
+}
+
+func (impl *BusinessStoreImpl) AddOrgBillingContact(ctx context.Context, user *dbgen.User, org *dbgen.Organization, email string) (*dbgen.BillingContact, *common.AuditLogEvent, error) {
+	if (org == nil) || (len(email) == 0) {
+		return nil, nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	contact, err := impl.querier.CreateOrgBillingContact(ctx, &dbgen.CreateOrgBillingContactParams{
+		OrgID: org.ID,
+		Email: email,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create org billing contact", "orgID", org.ID, common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "Added org billing contact", "orgID", org.ID, "contactID", contact.ID)
+
+	return contact, newBillingContactAuditLogEvent(user, org, contact, common.AuditLogActionCreate), nil
+}
+
+func (impl *BusinessStoreImpl) DeleteOrgBillingContact(ctx context.Context, user *dbgen.User, org *dbgen.Organization, contactID int32) (*common.AuditLogEvent, error) {
+	if (org == nil) || (contactID <= 0) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	contact, err := impl.querier.DeleteOrgBillingContact(ctx, &dbgen.DeleteOrgBillingContactParams{
+		ID:    contactID,
+		OrgID: org.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to delete org billing contact", "orgID", org.ID, "contactID", contactID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Deleted org billing contact", "orgID", org.ID, "contactID", contactID)
+
+	return newBillingContactAuditLogEvent(user, org, contact, common.AuditLogActionDelete), nil
+}
+
+// RetrieveOrgBillingNotifyOwner returns if owner of the org receives billing notifications (true by default)
+func (impl *BusinessStoreImpl) RetrieveOrgBillingNotifyOwner(ctx context.Context, org *dbgen.Organization) (bool, error) {
+	if org == nil {
+		return false, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return false, ErrMaintenance
+	}
+
+	settings, err := impl.querier.GetOrgBillingSettings(ctx, org.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return true, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve org billing settings", "orgID", org.ID, common.ErrAttr(err))
+		return false, err
+	}
+
+	return settings.NotifyOwner, nil
+}
+
+func (impl *BusinessStoreImpl) UpdateOrgBillingNotifyOwner(ctx context.Context, user *dbgen.User, org *dbgen.Organization, oldNotifyOwner, notifyOwner bool) (*common.AuditLogEvent, error) {
+	if org == nil {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	if _, err := impl.querier.UpsertOrgBillingSettings(ctx, &dbgen.UpsertOrgBillingSettingsParams{
+		OrgID:       org.ID,
+		NotifyOwner: notifyOwner,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to update org billing settings", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Updated org billing settings", "orgID", org.ID, "notifyOwner", notifyOwner)
+
+	return newBillingSettingsAuditLogEvent(user, org, oldNotifyOwner, notifyOwner), nil
+}
+
+// RetrieveBillingRecipients returns billing contacts of each of the (not deleted) organizations
+func (impl *BusinessStoreImpl) RetrieveBillingRecipients(ctx context.Context, orgIDs []int32) (map[int32]*common.BillingRecipients, error) {
+	result := make(map[int32]*common.BillingRecipients)
+	if len(orgIDs) == 0 {
+		return result, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := impl.querier.GetBillingContactsForOrgs(ctx, orgIDs)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve billing contacts", "orgs", len(orgIDs), common.ErrAttr(err))
+		return nil, err
+	}
+
+	for _, r := range rows {
+		recipients, ok := result[r.OrgID]
+		if !ok {
+			recipients = &common.BillingRecipients{NotifyOwner: r.NotifyOwner}
+			result[r.OrgID] = recipients
+		}
+
+		if !slices.Contains(recipients.Contacts, r.Email) {
+			recipients.Contacts = append(recipients.Contacts, r.Email)
+		}
+	}
+
+	return result, nil
+}
+
+// RetrieveOrgIPAllowlist returns ErrNegativeCacheHit if org does not restrict access by IP
+func (impl *BusinessStoreImpl) RetrieveOrgIPAllowlist(ctx context.Context, orgID int32) (*dbgen.OrgIPAllowlist, error) {
+	reader := &StoreOneReader[int32, dbgen.OrgIPAllowlist]{
+		CacheKey: orgIPAllowlistCacheKey(orgID),
+		Cache:    impl.cache,
+	}
+
+	if impl.querier != nil {
+		reader.QueryKeyFunc = QueryKeyInt
+		reader.QueryFunc = impl.querier.GetOrgIPAllowlist
+	}
+
+	return reader.Read(ctx)
+}
+
+// IsOrgIPAllowed checks if address is permitted by the org's IP allowlist (everything is permitted without one)
+func (impl *BusinessStoreImpl) IsOrgIPAllowed(ctx context.Context, orgID int32, addr netip.Addr) (bool, error) {
+	allowlist, err := impl.RetrieveOrgIPAllowlist(ctx, orgID)
+	if err != nil {
+		if (err == ErrNegativeCacheHit) || (err == ErrRecordNotFound) {
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	if len(allowlist.Cidrs) == 0 {
+		return true, nil
+	}
+
+	prefixes, err := common.ParseIPAllowlist(allowlist.Cidrs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse org IP allowlist", "orgID", orgID, common.ErrAttr(err))
+		return false, err
+	}
+
+	return common.IsIPAllowed(prefixes, addr), nil
+}
+
+// RetrievePropertyAccessList returns ErrNegativeCacheHit if property does not have access lists
+func (impl *BusinessStoreImpl) RetrievePropertyAccessList(ctx context.Context, propertyID int32) (*dbgen.PropertyAccessList, error) {
+	reader := &StoreOneReader[int32, dbgen.PropertyAccessList]{
+		CacheKey: propertyAccessListCacheKey(propertyID),
+		Cache:    impl.cache,
+	}
+
+	if impl.querier != nil {
+		reader.QueryKeyFunc = QueryKeyInt
+		reader.QueryFunc = impl.querier.GetPropertyAccessList
+	}
+
+	return reader.Read(ctx)
+}
 
-	slog.InfoContext(ctx, "Expired internal trials", "from", from, "to", to)
+// UpdatePropertyAccessList replaces access lists of the property, all empty lists remove the restriction
+func (impl *BusinessStoreImpl) UpdatePropertyAccessList(ctx context.Context, user *dbgen.User, property *dbgen.Property, oldList *dbgen.PropertyAccessList, params *dbgen.UpsertPropertyAccessListParams) (*dbgen.PropertyAccessList, *common.AuditLogEvent, error) {
+	if (user == nil) || (property == nil) || (params == nil) {
+		return nil, nil, ErrInvalidInput
+	}
 
-	return nil
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	cacheKey := propertyAccessListCacheKey(property.ID)
+	var list *dbgen.PropertyAccessList
+
+	if (len(params.IpAllowlist) == 0) && (len(params.IpDenylist) == 0) && (len(params.AsnDenylist) == 0) {
+		if _, err := impl.querier.DeletePropertyAccessList(ctx, property.ID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.ErrorContext(ctx, "Failed to delete property access list", "propID", property.ID, common.ErrAttr(err))
+			return nil, nil, err
+		}
+
+		_ = impl.cache.SetMissing(ctx, cacheKey)
+	} else {
+		params.PropertyID = property.ID
+
+		var err error
+		list, err = impl.querier.UpsertPropertyAccessList(ctx, params)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to update property access list", "propID", property.ID, common.ErrAttr(err))
+			return nil, nil, err
+		}
+
+		_ = impl.cache.Set(ctx, cacheKey, list)
+	}
+
+	slog.InfoContext(ctx, "Updated property access list", "propID", property.ID, "userID", user.ID, "allow", len(params.IpAllowlist),
+		"deny", len(params.IpDenylist), "asn", len(params.AsnDenylist), "policy", params.Policy)
+
+	return list, newPropertyAccessListAuditLogEvent(user, property, oldList, list), nil
 }
 
-func (impl *BusinessStoreImpl) MoveProperty(ctx context.Context, user *dbgen.User, property *dbgen.Property, org *dbgen.GetUserOrganizationsRow) (*dbgen.Property, *common.AuditLogEvent, error) {
+// RetrievePropertyShareLink returns ErrRecordNotFound if link does not exist or was revoked. Link is read from DB
+// (and not from cache) so that revocation from any node is respected right away
+func (impl *BusinessStoreImpl) RetrievePropertyShareLink(ctx context.Context, externalID pgtype.UUID) (*dbgen.PropertyShareLink, error) {
+	cacheKey := propertyShareLinkCacheKey(UUIDToString(externalID))
+
 	if impl.querier == nil {
-		return nil, nil, ErrMaintenance
+		// links that were opened before are still available in maintenance mode
+		return FetchCachedOne[dbgen.PropertyShareLink](ctx, impl.cache, cacheKey)
 	}
 
-	if property.OrgID.Int32 == org.Organization.ID {
-		slog.WarnContext(ctx, "Property is already in the destination org", "propID", property.ID, "orgID", property.OrgID.Int32)
+	link, err := impl.querier.GetPropertyShareLink(ctx, externalID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			_ = impl.cache.Delete(ctx, cacheKey)
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property share link", "externalID", UUIDToString(externalID), common.ErrAttr(err))
+		return nil, err
+	}
+
+	if link.RevokedAt.Valid {
+		_ = impl.cache.Delete(ctx, cacheKey)
+		return nil, ErrRecordNotFound
+	}
+
+	_ = impl.cache.Set(ctx, cacheKey, link)
+
+	return link, nil
+}
+
+// RetrieveSharedProperty returns property of the share link, soft-deleted properties are not shared
+func (impl *BusinessStoreImpl) RetrieveSharedProperty(ctx context.Context, link *dbgen.PropertyShareLink) (*dbgen.Property, error) {
+	reader := &StoreOneReader[int32, dbgen.Property]{
+		CacheKey: propertyByIDCacheKey(link.PropertyID),
+		Cache:    impl.cache,
+	}
+
+	if impl.querier != nil {
+		reader.QueryKeyFunc = QueryKeyInt
+		reader.QueryFunc = impl.querier.GetPropertyByID
+	}
+
+	property, err := reader.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if property.DeletedAt.Valid {
+		return nil, ErrSoftDeleted
+	}
+
+	return property, nil
+}
+
+func (impl *BusinessStoreImpl) RetrievePropertyShareLinks(ctx context.Context, property *dbgen.Property) ([]*dbgen.PropertyShareLink, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	links, err := impl.querier.GetPropertyShareLinks(ctx, property.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.PropertyShareLink{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property share links", "propID", property.ID, common.ErrAttr(err))
+
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (impl *BusinessStoreImpl) CreatePropertyShareLink(ctx context.Context, user *dbgen.User, property *dbgen.Property, expiresAt time.Time) (*dbgen.PropertyShareLink, *common.AuditLogEvent, error) {
+	if (user == nil) || (property == nil) {
 		return nil, nil, ErrInvalidInput
 	}
 
-	oldOrgID := property.OrgID.Int32
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
 
-	updatedProperty, err := impl.querier.MoveProperty(ctx, &dbgen.MovePropertyParams{
-		ID:         property.ID,
-		OrgID:      Int(org.Organization.ID),
-		OrgOwnerID: org.Organization.UserID,
+	link, err := impl.querier.CreatePropertyShareLink(ctx, &dbgen.CreatePropertyShareLinkParams{
+		PropertyID: property.ID,
+		CreatorID:  Int(user.ID),
+		ExpiresAt:  Timestampz(expiresAt),
 	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to move property to another org", "propID", property.ID, "oldOrgID", property.OrgID.Int32, "newOrgID", org.Organization.ID, common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to create property share link", "propID", property.ID, common.ErrAttr(err))
 		return nil, nil, err
 	}
 
-	slog.InfoContext(ctx, "Moved property to another org", "propID", property.ID, "oldOrgID", property.OrgID.Int32, "newOrgID", org.Organization.ID)
+	_ = impl.cache.Set(ctx, propertyShareLinkCacheKey(UUIDToString(link.ExternalID)), link)
 
-	// Invalidate cache for both old and new organizations
-	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(oldOrgID, orgPropertiesCacheKeyStr))
-	_ = impl.cache.Delete(ctx, orgPropertiesCacheKey(updatedProperty.OrgID.Int32, orgPropertiesCacheKeyStr))
-	_ = impl.cache.Delete(ctx, orgPropertiesCountCacheKey(oldOrgID))
-	_ = impl.cache.Delete(ctx, orgPropertiesCountCacheKey(updatedProperty.OrgID.Int32))
-	// and cache property
-	impl.cacheProperty(ctx, updatedProperty)
+	slog.InfoContext(ctx, "Created property share link", "propID", property.ID, "linkID", link.ID, "userID", user.ID,
+		"expiresAt", expiresAt)
 
-	auditEvent := newMovePropertyAuditLogEvent(user, updatedProperty, oldOrgID, updatedProperty.OrgID.Int32)
+	return link, newPropertyShareLinkAuditLogEvent(user, property, link, common.AuditLogActionCreate), nil
+}
 
-	return updatedProperty, auditEvent, nil
+// DeletePropertyShareLink revokes the link (it is deleted together with expired links later)
+func (impl *BusinessStoreImpl) DeletePropertyShareLink(ctx context.Context, user *dbgen.User, property *dbgen.Property, linkID int32) (*common.AuditLogEvent, error) {
+	if (user == nil) || (property == nil) {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	link, err := impl.querier.RevokePropertyShareLink(ctx, &dbgen.RevokePropertyShareLinkParams{
+		ID:         linkID,
+		PropertyID: property.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to revoke property share link", "propID", property.ID, "linkID", linkID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	_ = impl.cache.Delete(ctx, propertyShareLinkCacheKey(UUIDToString(link.ExternalID)))
+
+	slog.InfoContext(ctx, "Revoked property share link", "propID", property.ID, "linkID", link.ID, "userID", user.ID)
+
+	return newPropertyShareLinkAuditLogEvent(user, property, link, common.AuditLogActionDelete), nil
 }
 
-func (impl *BusinessStoreImpl) DeleteOldAuditLogs(ctx context.Context, before time.Time) error {
+func (impl *BusinessStoreImpl) DeleteExpiredPropertyShareLinks(ctx context.Context, before time.Time) error {
 	if before.IsZero() {
 		return ErrInvalidInput
 	}
@@ -2391,221 +5227,184 @@ func (impl *BusinessStoreImpl) DeleteOldAuditLogs(ctx context.Context, before ti
 		return ErrMaintenance
 	}
 
-	if err := impl.querier.DeleteOldAuditLogs(ctx, Timestampz(before)); err != nil {
-		slog.ErrorContext(ctx, "Failed to deleted old audit logs", common.ErrAttr(err))
+	if err := impl.querier.DeleteExpiredPropertyShareLinks(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete expired property share links", common.ErrAttr(err))
 		return err
 	}
 
-	slog.InfoContext(ctx, "Deleted old audit logs", "before", before)
+	slog.InfoContext(ctx, "Deleted expired property share links", "before", before)
 
 	return nil
 }
 
-func (impl *BusinessStoreImpl) GetCachedAuditLogs(ctx context.Context, user *dbgen.User, limit int, after time.Time, cachedAfter time.Time) ([]*dbgen.GetUserAuditLogsRow, error) {
-	if (limit <= 0) || after.IsZero() || cachedAfter.IsZero() {
-		return nil, ErrInvalidInput
-	}
-
-	if after.Before(cachedAfter) {
-		slog.ErrorContext(ctx, "Audit logs cutoff date should be always after cache key", "after", after, "cachedAfter", cachedAfter)
-		return nil, ErrInvalidInput
+func (impl *BusinessStoreImpl) RetrievePropertyBypassTokens(ctx context.Context, property *dbgen.Property) ([]*dbgen.PropertyBypassToken, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	cacheKey := userAuditLogsCacheKey(user.ID, cachedAfter.Format(time.DateOnly))
-	if logs, err := FetchCachedArray[dbgen.GetUserAuditLogsRow](ctx, impl.cache, cacheKey); err == nil {
-		if after.Equal(cachedAfter) {
-			return logs, nil
+	tokens, err := impl.querier.GetPropertyBypassTokens(ctx, property.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return []*dbgen.PropertyBypassToken{}, nil
 		}
 
-		result := make([]*dbgen.GetUserAuditLogsRow, 0, len(logs))
-		for _, log := range logs {
-			if log.AuditLog.CreatedAt.Valid && !log.AuditLog.CreatedAt.Time.Before(after) {
-				result = append(result, log)
+		slog.ErrorContext(ctx, "Failed to retrieve property bypass tokens", "propID", property.ID, common.ErrAttr(err))
 
-				if len(result) >= limit {
-					break
-				}
-			}
-		}
-		return result, nil
-	} else {
 		return nil, err
 	}
+
+	return tokens, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveUserAuditLogs(ctx context.Context, user *dbgen.User, limit int, after time.Time) ([]*dbgen.GetUserAuditLogsRow, error) {
-	if (limit <= 0) || after.IsZero() {
-		return nil, ErrInvalidInput
+// CreatePropertyBypassToken returns the token itself, which is not stored and cannot be retrieved later
+func (impl *BusinessStoreImpl) CreatePropertyBypassToken(ctx context.Context, user *dbgen.User, property *dbgen.Property, name string, maxUses int32, expiresAt time.Time) (string, *common.AuditLogEvent, error) {
+	if (user == nil) || (property == nil) || (maxUses <= 0) {
+		return "", nil, ErrInvalidInput
 	}
 
-	reader := &StoreArrayReader[*dbgen.GetUserAuditLogsParams, dbgen.GetUserAuditLogsRow]{
-		CacheKey: userAuditLogsCacheKey(user.ID, after.Format(time.DateOnly)),
-		Cache:    impl.cache,
-		TTL:      5 * time.Minute,
+	if impl.querier == nil {
+		return "", nil, ErrMaintenance
 	}
 
-	if impl.querier != nil {
-		reader.QueryKeyFunc = func(ck CacheKey) (*dbgen.GetUserAuditLogsParams, error) {
-			return &dbgen.GetUserAuditLogsParams{
-				UserID:    Int(user.ID),
-				Offset:    0,
-				Limit:     int32(limit),
-				CreatedAt: Timestampz(after),
-			}, nil
-		}
-		reader.QueryFunc = impl.querier.GetUserAuditLogs
+	secret, hash, err := NewBypassToken(property.ExternalID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate bypass token", common.ErrAttr(err))
+		return "", nil, err
 	}
 
-	return reader.Read(ctx)
+	token, err := impl.querier.CreatePropertyBypassToken(ctx, &dbgen.CreatePropertyBypassTokenParams{
+		PropertyID: property.ID,
+		CreatorID:  Int(user.ID),
+		Name:       name,
+		SecretHash: hash,
+		MaxUses:    maxUses,
+		ExpiresAt:  Timestampz(expiresAt),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create property bypass token", "propID", property.ID, common.ErrAttr(err))
+		return "", nil, err
+	}
+
+	slog.InfoContext(ctx, "Created property bypass token", "propID", property.ID, "tokenID", token.ID, "userID", user.ID,
+		"maxUses", maxUses, "expiresAt", expiresAt)
+
+	return secret, newPropertyBypassTokenAuditLogEvent(user, property, token, common.AuditLogActionCreate), nil
 }
 
-func (impl *BusinessStoreImpl) RetrievePropertyAuditLogs(ctx context.Context, property *dbgen.Property, limit int) ([]*dbgen.GetPropertyAuditLogsRow, error) {
-	if limit <= 0 {
+// UsePropertyBypassToken atomically accounts usage of the token, so it is not cached.
+// Returns ErrRecordNotFound if token does not exist, is expired, revoked or used up.
+func (impl *BusinessStoreImpl) UsePropertyBypassToken(ctx context.Context, property *dbgen.Property, secretHash []byte, tnow time.Time) (*dbgen.PropertyBypassToken, error) {
+	if (property == nil) || (len(secretHash) == 0) {
 		return nil, ErrInvalidInput
 	}
 
-	reader := &StoreArrayReader[*dbgen.GetPropertyAuditLogsParams, dbgen.GetPropertyAuditLogsRow]{
-		CacheKey: propertyAuditLogsCacheKey(property.ID),
-		Cache:    impl.cache,
-		TTL:      5 * time.Minute,
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	if impl.querier != nil {
-		reader.QueryKeyFunc = func(ck CacheKey) (*dbgen.GetPropertyAuditLogsParams, error) {
-			return &dbgen.GetPropertyAuditLogsParams{
-				EntityID:  Int8(int64(property.ID)),
-				CreatedAt: property.CreatedAt,
-				Offset:    0,
-				Limit:     int32(limit),
-			}, nil
+	token, err := impl.querier.UsePropertyBypassToken(ctx, &dbgen.UsePropertyBypassTokenParams{
+		UsedAt:     Timestampz(tnow),
+		SecretHash: secretHash,
+		PropertyID: property.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
 		}
-		reader.QueryFunc = impl.querier.GetPropertyAuditLogs
-	}
 
-	logs, err := reader.Read(ctx)
-	if err != nil {
+		slog.ErrorContext(ctx, "Failed to use property bypass token", "propID", property.ID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	// extra slice is due to possible caching in portal where for /auditlogs we can fetch and cache multiple entries
-	// but later for /property/auditlogs we will show only up to {limit}
-	return logs[0:min(len(logs), limit)], nil
+	return token, nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveOrganizationAuditLogs(ctx context.Context, org *dbgen.Organization, limit int) ([]*dbgen.GetOrgAuditLogsRow, error) {
-	if limit <= 0 {
+// DeletePropertyBypassToken revokes the token
+func (impl *BusinessStoreImpl) DeletePropertyBypassToken(ctx context.Context, user *dbgen.User, property *dbgen.Property, tokenID int32) (*common.AuditLogEvent, error) {
+	if (user == nil) || (property == nil) {
 		return nil, ErrInvalidInput
 	}
 
-	reader := &StoreArrayReader[*dbgen.GetOrgAuditLogsParams, dbgen.GetOrgAuditLogsRow]{
-		CacheKey: orgAuditLogsCacheKey(org.ID),
-		Cache:    impl.cache,
-		TTL:      5 * time.Minute,
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	if impl.querier != nil {
-		reader.QueryKeyFunc = func(ck CacheKey) (*dbgen.GetOrgAuditLogsParams, error) {
-			return &dbgen.GetOrgAuditLogsParams{
-				EntityID:  Int8(int64(org.ID)),
-				CreatedAt: org.CreatedAt,
-				Offset:    0,
-				Limit:     int32(limit),
-			}, nil
+	token, err := impl.querier.DeletePropertyBypassToken(ctx, &dbgen.DeletePropertyBypassTokenParams{
+		ID:         tokenID,
+		PropertyID: property.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrRecordNotFound
 		}
-		reader.QueryFunc = impl.querier.GetOrgAuditLogs
-	}
 
-	logs, err := reader.Read(ctx)
-	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete property bypass token", "propID", property.ID, "tokenID", tokenID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	// extra slice is due to possible caching in portal where for /auditlogs we can fetch and cache multiple entries
-	// but later for /org/auditlogs we will show only up to {limit}
-	return logs[0:min(len(logs), limit)], nil
-}
-
-func (impl *BusinessStoreImpl) ValidateOrgName(ctx context.Context, name string, user *dbgen.User) common.StatusCode {
-	const maxOrgNameLength = 255
+	slog.InfoContext(ctx, "Deleted property bypass token", "propID", property.ID, "tokenID", token.ID, "userID", user.ID)
 
-	if (len(name) == 0) || (len(name) > maxOrgNameLength) {
-		slog.WarnContext(ctx, "Name length is invalid", "length", len(name))
+	return newPropertyBypassTokenAuditLogEvent(user, property, token, common.AuditLogActionDelete), nil
+}
 
-		if len(name) == 0 {
-			return common.StatusOrgNameEmptyError
-		} else {
-			return common.StatusOrgNameTooLongError
-		}
+func (impl *BusinessStoreImpl) DeleteExpiredPropertyBypassTokens(ctx context.Context, before time.Time) error {
+	if before.IsZero() {
+		return ErrInvalidInput
 	}
-
-	const allowedPunctuation = "'-_&.:()[]"
-
-	for i, r := range name {
-		switch {
-		case unicode.IsLetter(r):
-			continue
-		case unicode.IsDigit(r):
-			continue
-		case unicode.IsSpace(r):
-			continue
-		case strings.ContainsRune(allowedPunctuation, r):
-			continue
-		default:
-			slog.WarnContext(ctx, "Name contains invalid characters", "position", i, "rune", r)
-			return common.StatusOrgNameInvalidSymbolsError
-		}
+
+	if impl.querier == nil {
+		return ErrMaintenance
 	}
 
-	if _, err := impl.FindOrg(ctx, name, user); err != ErrRecordNotFound {
-		slog.WarnContext(ctx, "Org already exists", "name", name, common.ErrAttr(err))
-		return common.StatusOrgNameDuplicateError
+	if err := impl.querier.DeleteExpiredPropertyBypassTokens(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete expired property bypass tokens", common.ErrAttr(err))
+		return err
 	}
 
-	return common.StatusOK
+	slog.InfoContext(ctx, "Deleted expired property bypass tokens", "before", before)
+
+	return nil
 }
 
-func (impl *BusinessStoreImpl) ValidatePropertyName(ctx context.Context, name string, org *dbgen.Organization) common.StatusCode {
-	const maxPropertyNameLength = 255
-	if (len(name) == 0) || (len(name) > maxPropertyNameLength) {
-		slog.WarnContext(ctx, "Name length is invalid", "length", len(name))
+// UpdateOrgIPAllowlist replaces IP allowlist of the org, empty list removes the restriction
+func (impl *BusinessStoreImpl) UpdateOrgIPAllowlist(ctx context.Context, user *dbgen.User, org *dbgen.Organization, oldCIDRs, cidrs []string, recovery bool) (*common.AuditLogEvent, error) {
+	if (user == nil) || (org == nil) {
+		return nil, ErrInvalidInput
+	}
 
-		if len(name) == 0 {
-			return common.StatusPropertyNameEmptyError
-		} else {
-			return common.StatusPropertyNameTooLongError
-		}
+	if impl.querier == nil {
+		return nil, ErrMaintenance
 	}
 
-	const allowedPunctuation = "'-_.:()[]"
+	cacheKey := orgIPAllowlistCacheKey(org.ID)
 
-	for i, r := range name {
-		switch {
-		case unicode.IsLetter(r):
-			continue
-		case unicode.IsDigit(r):
-			continue
-		case unicode.IsSpace(r):
-			continue
-		case strings.ContainsRune(allowedPunctuation, r):
-			continue
-		default:
-			slog.WarnContext(ctx, "Name contains invalid characters", "position", i, "rune", r)
-			return common.StatusPropertyNameInvalidSymbolsError
+	if len(cidrs) == 0 {
+		if _, err := impl.querier.DeleteOrgIPAllowlist(ctx, org.ID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.ErrorContext(ctx, "Failed to delete org IP allowlist", "orgID", org.ID, common.ErrAttr(err))
+			return nil, err
 		}
-	}
 
-	if org != nil {
-		if _, err := impl.FindOrgProperty(ctx, name, org); err != ErrRecordNotFound {
-			slog.WarnContext(ctx, "Property already exists", "name", name, common.ErrAttr(err))
-			return common.StatusPropertyNameDuplicateError
+		_ = impl.cache.SetMissing(ctx, cacheKey)
+	} else {
+		allowlist, err := impl.querier.UpsertOrgIPAllowlist(ctx, &dbgen.UpsertOrgIPAllowlistParams{
+			OrgID: org.ID,
+			Cidrs: cidrs,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to update org IP allowlist", "orgID", org.ID, common.ErrAttr(err))
+			return nil, err
 		}
+
+		_ = impl.cache.Set(ctx, cacheKey, allowlist)
 	}
 
-	return common.StatusOK
+	slog.InfoContext(ctx, "Updated org IP allowlist", "orgID", org.ID, "entries", len(cidrs), "recovery", recovery)
+
+	return newOrgIPAllowlistAuditLogEvent(user, org, oldCIDRs, cidrs, recovery), nil
 }
 
-func (impl *BusinessStoreImpl) CreateNewAsyncTask(ctx context.Context, data interface{}, handler string, user *dbgen.User, scheduledAt time.Time, referenceID string) (*dbgen.AsyncTask, error) {
-	if (data == nil) && (user == nil) {
+func (impl *BusinessStoreImpl) RetrieveOrgEmailDomains(ctx context.Context, org *dbgen.Organization) ([]*dbgen.OrgEmailDomain, error) {
+	if org == nil {
 		return nil, ErrInvalidInput
 	}
 
@@ -2613,82 +5412,102 @@ func (impl *BusinessStoreImpl) CreateNewAsyncTask(ctx context.Context, data inte
 		return nil, ErrMaintenance
 	}
 
-	payload, err := json.Marshal(data)
+	domains, err := impl.querier.GetOrgEmailDomains(ctx, org.ID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to serialize payload for async request", common.ErrAttr(err))
+		if err == pgx.ErrNoRows {
+			return []*dbgen.OrgEmailDomain{}, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve org email domains", "orgID", org.ID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	if scheduledAt.IsZero() {
-		scheduledAt = time.Now().UTC()
-	}
+	slog.DebugContext(ctx, "Fetched org email domains", "orgID", org.ID, "count", len(domains))
 
-	params := &dbgen.CreateAsyncTaskParams{
-		Input:       payload,
-		Handler:     handler,
-		ReferenceID: referenceID,
-		ScheduledAt: Timestampz(scheduledAt),
+	return domains, nil
+}
+
+func (impl *BusinessStoreImpl) AddOrgEmailDomain(ctx context.Context, user *dbgen.User, org *dbgen.Organization, domain, token string) (*dbgen.OrgEmailDomain, *common.AuditLogEvent, error) {
+	if (org == nil) || (len(domain) == 0) || (len(token) == 0) {
+		return nil, nil, ErrInvalidInput
 	}
-	if user != nil {
-		params.UserID = Int(user.ID)
+
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
 	}
 
-	tnow := time.Now().UTC()
-	uuid, err := impl.querier.CreateAsyncTask(ctx, params)
+	emailDomain, err := impl.querier.CreateOrgEmailDomain(ctx, &dbgen.CreateOrgEmailDomainParams{
+		OrgID:  org.ID,
+		Domain: domain,
+		Token:  token,
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to create async request", common.ErrAttr(err))
-		return nil, err
+		slog.ErrorContext(ctx, "Failed to create org email domain", "orgID", org.ID, "domain", domain, common.ErrAttr(err))
+		return nil, nil, err
 	}
 
-	taskIDStr := UUIDToString(uuid)
+	slog.InfoContext(ctx, "Added org email domain", "orgID", org.ID, "domainID", emailDomain.ID, "domain", domain)
 
-	slog.DebugContext(ctx, "Created async task", "taskID", taskIDStr)
+	return emailDomain, newOrgEmailDomainAuditLogEvent(user, org, nil, emailDomain, common.AuditLogActionCreate), nil
+}
 
-	// yes, we "fake" the response in order not to make Postgres to send (potentially large) inputs copy back
-	task := &dbgen.AsyncTask{
-		ID:                 uuid,
-		Handler:            handler,
-		Input:              payload,
-		Output:             nil,
-		UserID:             params.UserID,
-		ReferenceID:        referenceID,
-		ProcessingAttempts: 0,
-		CreatedAt:          Timestampz(tnow),
-		ScheduledAt:        params.ScheduledAt,
-		ProcessedAt:        pgtype.Timestamptz{},
+func (impl *BusinessStoreImpl) VerifyOrgEmailDomain(ctx context.Context, user *dbgen.User, org *dbgen.Organization, emailDomain *dbgen.OrgEmailDomain) (*dbgen.OrgEmailDomain, *common.AuditLogEvent, error) {
+	if (org == nil) || (emailDomain == nil) {
+		return nil, nil, ErrInvalidInput
 	}
 
-	cacheKey := asyncTaskCacheKey(taskIDStr)
-	_ = impl.cache.SetWithTTL(ctx, cacheKey, task, asyncTaskTTL)
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
 
-	return task, nil
+	verified, err := impl.querier.VerifyOrgEmailDomain(ctx, &dbgen.VerifyOrgEmailDomainParams{
+		ID:    emailDomain.ID,
+		OrgID: org.ID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to verify org email domain", "orgID", org.ID, "domainID", emailDomain.ID, common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "Verified org email domain", "orgID", org.ID, "domainID", verified.ID, "domain", verified.Domain)
+
+	return verified, newOrgEmailDomainAuditLogEvent(user, org, emailDomain, verified, common.AuditLogActionUpdate), nil
 }
 
-func (impl *BusinessStoreImpl) RetrieveAsyncTask(ctx context.Context, uuid pgtype.UUID, user *dbgen.User) (*dbgen.AsyncTask, error) {
-	reader := &StoreOneReader[pgtype.UUID, dbgen.AsyncTask]{
-		CacheKey: asyncTaskCacheKey(UUIDToString(uuid)),
-		Cache:    impl.cache,
+func (impl *BusinessStoreImpl) UpdateOrgEmailDomainAutoJoin(ctx context.Context, user *dbgen.User, org *dbgen.Organization, emailDomain *dbgen.OrgEmailDomain, autoJoin bool) (*dbgen.OrgEmailDomain, *common.AuditLogEvent, error) {
+	if (org == nil) || (emailDomain == nil) {
+		return nil, nil, ErrInvalidInput
 	}
 
-	if impl.querier != nil {
-		reader.QueryFunc = impl.querier.GetAsyncTask
-		reader.QueryKeyFunc = queryKeyStringUUID
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
 	}
 
-	task, err := reader.Read(ctx)
+	updated, err := impl.querier.UpdateOrgEmailDomainAutoJoin(ctx, &dbgen.UpdateOrgEmailDomainAutoJoinParams{
+		AutoJoin: autoJoin,
+		ID:       emailDomain.ID,
+		OrgID:    org.ID,
+	})
 	if err != nil {
-		return nil, err
-	}
+		if err == pgx.ErrNoRows {
+			return nil, nil, ErrRecordNotFound
+		}
 
-	if task.UserID.Valid && (user != nil) && (task.UserID.Int32 != user.ID) {
-		return nil, ErrPermissions
+		slog.ErrorContext(ctx, "Failed to update org email domain", "orgID", org.ID, "domainID", emailDomain.ID, common.ErrAttr(err))
+		return nil, nil, err
 	}
 
-	return task, nil
+	slog.InfoContext(ctx, "Updated org email domain", "orgID", org.ID, "domainID", updated.ID, "autoJoin", autoJoin)
+
+	return updated, newOrgEmailDomainAuditLogEvent(user, org, emailDomain, updated, common.AuditLogActionUpdate), nil
 }
 
-func (impl *BusinessStoreImpl) RetrievePendingAsyncTasks(ctx context.Context, count int, before time.Time, maxProcessingAttempts int) ([]*dbgen.GetPendingAsyncTasksRow, error) {
-	if count <= 0 {
+func (impl *BusinessStoreImpl) DeleteOrgEmailDomain(ctx context.Context, user *dbgen.User, org *dbgen.Organization, domainID int32) (*common.AuditLogEvent, error) {
+	if (org == nil) || (domainID <= 0) {
 		return nil, ErrInvalidInput
 	}
 
@@ -2696,138 +5515,128 @@ func (impl *BusinessStoreImpl) RetrievePendingAsyncTasks(ctx context.Context, co
 		return nil, ErrMaintenance
 	}
 
-	tasks, err := impl.querier.GetPendingAsyncTasks(ctx, &dbgen.GetPendingAsyncTasksParams{
-		ScheduledAt:        Timestampz(before),
-		ProcessingAttempts: int32(maxProcessingAttempts),
-		Limit:              int32(count),
+	emailDomain, err := impl.querier.DeleteOrgEmailDomain(ctx, &dbgen.DeleteOrgEmailDomainParams{
+		ID:    domainID,
+		OrgID: org.ID,
 	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return []*dbgen.GetPendingAsyncTasksRow{}, nil
+			return nil, ErrRecordNotFound
 		}
 
-		slog.ErrorContext(ctx, "Failed to retrieve async tasks", "before", before, "count", count, "attempts", maxProcessingAttempts, common.ErrAttr(err))
-
+		slog.ErrorContext(ctx, "Failed to delete org email domain", "orgID", org.ID, "domainID", domainID, common.ErrAttr(err))
 		return nil, err
 	}
 
-	slog.DebugContext(ctx, "Fetched pending async tasks", "count", len(tasks))
+	slog.InfoContext(ctx, "Deleted org email domain", "orgID", org.ID, "domainID", domainID)
 
-	return tasks, nil
+	return newOrgEmailDomainAuditLogEvent(user, org, emailDomain, nil, common.AuditLogActionDelete), nil
 }
 
-func (impl *BusinessStoreImpl) DeleteOldAsyncTasks(ctx context.Context, before time.Time) error {
-	if before.IsZero() {
-		return ErrInvalidInput
+// RetrieveVerifiedOrgEmailDomain returns organization that verified ownership of the email domain
+func (impl *BusinessStoreImpl) RetrieveVerifiedOrgEmailDomain(ctx context.Context, domain string) (*dbgen.OrgEmailDomain, *dbgen.Organization, error) {
+	if len(domain) == 0 {
+		return nil, nil, ErrInvalidInput
 	}
 
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, nil, ErrMaintenance
 	}
 
-	if err := impl.querier.DeleteOldAsyncTasks(ctx, Timestampz(before)); err != nil {
-		slog.ErrorContext(ctx, "Failed to delete old async request", common.ErrAttr(err))
-		return err
+	row, err := impl.querier.GetVerifiedOrgEmailDomain(ctx, domain)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve verified org email domain", "domain", domain, common.ErrAttr(err))
+		return nil, nil, err
 	}
 
-	return nil
+	return &row.OrgEmailDomain, &row.Organization, nil
 }
 
-func (impl *BusinessStoreImpl) UpdateAsyncTask(ctx context.Context, uuid pgtype.UUID, output []byte, processedAt time.Time) error {
-	if !uuid.Valid {
-		return ErrInvalidInput
+// AddUserToOrgByEmailDomain makes user a member of the org if email domain allows auto-join or invites them otherwise
+func (impl *BusinessStoreImpl) AddUserToOrgByEmailDomain(ctx context.Context, user *dbgen.User, org *dbgen.Organization, emailDomain *dbgen.OrgEmailDomain) (*common.AuditLogEvent, error) {
+	if (user == nil) || (org == nil) || (emailDomain == nil) {
+		return nil, ErrInvalidInput
 	}
 
 	if impl.querier == nil {
-		return ErrMaintenance
+		return nil, ErrMaintenance
 	}
 
-	if err := impl.querier.UpdateAsyncTask(ctx, &dbgen.UpdateAsyncTaskParams{
-		ID:          uuid,
-		Output:      output,
-		ProcessedAt: Timestampz(processedAt), // if processedAt.IsZero(), we set to NULL
-	}); err != nil {
-		slog.ErrorContext(ctx, "Failed to update async task", "id", UUIDToString(uuid), common.ErrAttr(err))
-		return err
+	level := dbgen.AccessLevelInvited
+	if emailDomain.AutoJoin {
+		level = dbgen.AccessLevelMember
 	}
 
-	cacheKey := asyncTaskCacheKey(UUIDToString(uuid))
-	impl.cache.Delete(ctx, cacheKey)
-
-	return nil
-}
-
-func (impl *BusinessStoreImpl) RetrieveOrgOwnerWithSubscription(ctx context.Context, org *dbgen.Organization, activeUser *dbgen.User) (owner *dbgen.User, subscr *dbgen.Subscription, err error) {
-	isUserOrgOwner := org.UserID.Valid && (org.UserID.Int32 == activeUser.ID)
+	if _, err := impl.querier.AddUserToOrg(ctx, &dbgen.AddUserToOrgParams{
+		OrgID:  org.ID,
+		UserID: user.ID,
+		Level:  level,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to add user to org by email domain", "orgID", org.ID, "userID", user.ID, common.ErrAttr(err))
+		return nil, err
+	}
 
-	if isUserOrgOwner {
-		owner = activeUser
-		if activeUser.SubscriptionID.Valid {
-			subscr, err = impl.RetrieveSubscription(ctx, activeUser.SubscriptionID.Int32)
-			if err != nil {
-				slog.ErrorContext(ctx, "Failed to retrieve active user subscription", "userID", activeUser.ID, common.ErrAttr(err))
-				return nil, nil, err
-			}
-		}
-	} else {
-		slog.DebugContext(ctx, "Active user is not org owner", "userID", activeUser.ID, "orgUserID", org.UserID.Int32)
+	slog.InfoContext(ctx, "Added user to org by email domain", "orgID", org.ID, "userID", user.ID, "domain", emailDomain.Domain,
+		"level", level)
 
-		orgUser, err := impl.RetrieveUser(ctx, org.UserID.Int32)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to retrieve org's owner user by ID", "id", org.UserID.Int32, common.ErrAttr(err))
-			return nil, nil, err
-		}
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(user.ID))
+	_ = impl.cache.Delete(ctx, orgUsersCacheKey(org.ID))
+	_ = impl.cache.Delete(ctx, orgUsersPageCacheKey(org.ID, orgUsersPageCacheKeyStr))
+	impl.onOrgsChanged(ctx, nil /*orgs*/, user.ID)
 
-		owner = orgUser
-		subscr = nil
+	return newOrgMemberAuditLogEvent(org.ID, org.Name, user, common.AuditLogActionCreate, string(level)), nil
+}
 
-		if orgUser.SubscriptionID.Valid {
-			subscr, err = impl.RetrieveSubscription(ctx, orgUser.SubscriptionID.Int32)
-			if err != nil {
-				slog.ErrorContext(ctx, "Failed to retrieve org owner's subscription", "userID", org.UserID.Int32, common.ErrAttr(err))
-				return nil, nil, err
-			}
-		}
+// AddUserToDefaultOrg makes newly provisioned (e.g. via SSO) user a member of the deployment-wide default organization.
+// canJoin verifies subscription limits of the org owner.
+func (impl *BusinessStoreImpl) AddUserToDefaultOrg(ctx context.Context, user *dbgen.User, orgID int32, canJoin func(context.Context, *dbgen.Organization) bool) (*dbgen.Organization, *common.AuditLogEvent, error) {
+	if (user == nil) || (orgID <= 0) {
+		return nil, nil, ErrInvalidInput
 	}
 
-	return
-}
-
-func (impl *BusinessStoreImpl) RetrieveOrgPropertiesCount(ctx context.Context, orgID int32) (int64, error) {
 	if impl.querier == nil {
-		return 0, ErrMaintenance
+		return nil, nil, ErrMaintenance
 	}
 
-	cacheKey := orgPropertiesCountCacheKey(orgID)
-	if count, err := FetchCachedOne[int64](ctx, impl.cache, cacheKey); err == nil {
-		return *count, nil
+	org, level, err := impl.retrieveOrganizationWithAccess(ctx, user.ID, orgID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve default org", "orgID", orgID, common.ErrAttr(err))
+		return nil, nil, err
 	}
 
-	count, err := impl.querier.GetOrgPropertiesCount(ctx, Int(orgID))
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to retrieve org properties count", "orgID", orgID, common.ErrAttr(err))
-		return 0, err
+	if org.DeletedAt.Valid {
+		slog.WarnContext(ctx, "Default organization is soft-deleted", "orgID", orgID, "deletedAt", org.DeletedAt.Time)
+		return nil, nil, ErrSoftDeleted
 	}
 
-	slog.DebugContext(ctx, "Fetched org properties count", "orgID", orgID, "count", count)
+	if level.Valid {
+		slog.DebugContext(ctx, "User already has access to default org", "orgID", orgID, "userID", user.ID, "level", level.AccessLevel)
+		return org, nil, nil
+	}
 
-	const propertiesCountTTL = 5 * time.Minute
-	c := new(int64)
-	*c = count
-	_ = impl.cache.SetWithTTL(ctx, cacheKey, c, propertiesCountTTL)
+	if !canJoin(ctx, org) {
+		return nil, nil, ErrMembersLimit
+	}
 
-	return count, nil
-}
+	if _, err := impl.querier.AddUserToOrg(ctx, &dbgen.AddUserToOrgParams{
+		OrgID:  org.ID,
+		UserID: user.ID,
+		Level:  dbgen.AccessLevelMember,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to add user to default org", "orgID", org.ID, "userID", user.ID, common.ErrAttr(err))
+		return nil, nil, err
+	}
 
-func (impl *BusinessStoreImpl) CleanupUserCache(ctx context.Context, userID int32) {
-	_ = impl.cache.Delete(ctx, userOrgsCacheKey(userID))
-	_ = impl.cache.Delete(ctx, UserAPIKeysCacheKey(userID))
-	_ = impl.cache.Delete(ctx, userPropertiesCountCacheKey(userID))
+	slog.InfoContext(ctx, "Added user to default org", "orgID", org.ID, "userID", user.ID)
 
-	tnow := time.Now().UTC().Truncate(24 * time.Hour)
-	for _, cacheDays := range []int{ /*14,*/ 30, 90, 180, 365} {
-		cachedAfter := tnow.AddDate(0 /*years*/, 0 /*months*/, -cacheDays)
-		key := cachedAfter.Format(time.DateOnly)
-		_ = impl.cache.Delete(ctx, userAuditLogsCacheKey(userID, key))
-	}
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(user.ID))
+	_ = impl.cache.Delete(ctx, orgUsersCacheKey(org.ID))
+	_ = impl.cache.Delete(ctx, orgUsersPageCacheKey(org.ID, orgUsersPageCacheKeyStr))
+	impl.onOrgsChanged(ctx, nil /*orgs*/, user.ID)
+
+	return org, newOrgMemberAuditLogEvent(org.ID, org.Name, user, common.AuditLogActionCreate, string(dbgen.AccessLevelMember)), nil
 }
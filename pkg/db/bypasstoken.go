@@ -0,0 +1,58 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	BypassTokenPrefix    = "pcbt_"
+	bypassTokenSecretLen = 32
+	// prefix + hex(property external ID) + hex(secret)
+	bypassTokenLen = len(BypassTokenPrefix) + SitekeyLen + 2*bypassTokenSecretLen
+	// automated test suites are not supposed to run forever with the same token
+	MaxBypassTokenUses = 100_000
+)
+
+var (
+	errInvalidBypassToken = errors.New("bypass token is not valid")
+)
+
+// NewBypassToken generates a random secret for the property and returns the token (to be shown to the user once)
+// together with the hash of the secret (to be stored)
+func NewBypassToken(propertyExternalID pgtype.UUID) (string, []byte, error) {
+	secret := make([]byte, bypassTokenSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+
+	token := BypassTokenPrefix + hex.EncodeToString(propertyExternalID.Bytes[:]) + hex.EncodeToString(secret)
+	hash := sha256.Sum256(secret)
+
+	return token, hash[:], nil
+}
+
+// ParseBypassToken returns external ID of the property that token was issued for and the hash of its secret.
+// Property ID is included in the token so that it can be matched against sitekey without accessing DB.
+func ParseBypassToken(token string) (pgtype.UUID, []byte, error) {
+	if (len(token) != bypassTokenLen) || !strings.HasPrefix(token, BypassTokenPrefix) {
+		return pgtype.UUID{}, nil, errInvalidBypassToken
+	}
+
+	data, err := hex.DecodeString(token[len(BypassTokenPrefix):])
+	if err != nil {
+		return pgtype.UUID{}, nil, errInvalidBypassToken
+	}
+
+	propertyID := pgtype.UUID{Valid: true}
+	copy(propertyID.Bytes[:], data[:len(propertyID.Bytes)])
+
+	hash := sha256.Sum256(data[len(propertyID.Bytes):])
+
+	return propertyID, hash[:], nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBypassTokenRoundtrip(t *testing.T) {
+	t.Parallel()
+
+	propertyID := UUIDFromSiteKey("1ca8041a5761bd24e16e7e7ab1d16f0a")
+
+	token, hash, err := NewBypassToken(propertyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedID, parsedHash, err := ParseBypassToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsedID != propertyID {
+		t.Errorf("Unexpected property ID: %v", UUIDToSiteKey(parsedID))
+	}
+
+	if !bytes.Equal(hash, parsedHash) {
+		t.Error("Secret hash does not match")
+	}
+
+	if _, _, err := ParseBypassToken(token[:len(token)-1]); err == nil {
+		t.Error("Truncated token was parsed")
+	}
+
+	if _, _, err := ParseBypassToken(APIKeyPrefix + token[len(BypassTokenPrefix):]); err == nil {
+		t.Error("Token with wrong prefix was parsed")
+	}
+}
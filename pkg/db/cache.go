@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	randv2 "math/rand/v2"
 	"strconv"
 	"sync"
 	"time"
@@ -93,6 +94,10 @@ func (c *memcache[TKey, TValue]) Missing() TValue {
 	return c.missingValue
 }
 
+func (c *memcache[TKey, TValue]) Len() int {
+	return c.store.EstimatedSize()
+}
+
 func (c *memcache[TKey, TValue]) HitRatio() float64 {
 	return c.counter.Snapshot().HitRatio()
 }
@@ -194,6 +199,34 @@ func (c *memcache[TKey, TValue]) Delete(ctx context.Context, key TKey) bool {
 	return found
 }
 
+// Sample returns up to n random entries (including negative ones) without affecting their expiration
+func (c *memcache[TKey, TValue]) Sample(n int) map[TKey]TValue {
+	size := c.store.EstimatedSize()
+	if (n <= 0) || (size == 0) {
+		return nil
+	}
+
+	probability := float64(n) / float64(size)
+	result := make(map[TKey]TValue, n)
+
+	for key, value := range c.store.All() {
+		if randv2.Float64() < probability {
+			result[key] = value
+			if len(result) >= n {
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// Peek returns current value without affecting its expiration
+func (c *memcache[TKey, TValue]) Peek(key TKey) (TValue, bool) {
+	entry, ok := c.store.GetEntryQuietly(key)
+	return entry.Value, ok
+}
+
 type CacheKeyPrefix byte
 
 const (
@@ -218,6 +251,11 @@ const (
 	propertyStatsCacheKeyPrefix
 	asyncTaskCacheKeyPrefix
 	orgPropertiesCountCacheKeyPrefix
+	propertyLatencyCacheKeyPrefix
+	orgIPAllowlistCacheKeyPrefix
+	orgUsersPageCacheKeyPrefix
+	propertyAccessListCacheKeyPrefix
+	propertyShareLinkCacheKeyPrefix
 	// Add new fields _above_
 	CACHE_KEY_PREFIXES_COUNT
 )
@@ -256,6 +294,11 @@ func init() {
 	cachePrefixToStrings[propertyStatsCacheKeyPrefix] = "propertyStats/"
 	cachePrefixToStrings[asyncTaskCacheKeyPrefix] = "asyncTask/"
 	cachePrefixToStrings[orgPropertiesCountCacheKeyPrefix] = "orgPropertiesCount/"
+	cachePrefixToStrings[propertyLatencyCacheKeyPrefix] = "propertyLatency/"
+	cachePrefixToStrings[orgIPAllowlistCacheKeyPrefix] = "orgIPAllowlist/"
+	cachePrefixToStrings[orgUsersPageCacheKeyPrefix] = "orgUsersPage/"
+	cachePrefixToStrings[propertyAccessListCacheKeyPrefix] = "propAccessList/"
+	cachePrefixToStrings[propertyShareLinkCacheKeyPrefix] = "propShareLink/"
 
 	for i, v := range cachePrefixToStrings {
 		if len(v) == 0 {
@@ -341,6 +384,9 @@ func PropertyBySitekeyCacheKey(sitekey string) CacheKey {
 }
 func userOrgsCacheKey(userID int32) CacheKey { return Int32CacheKey(userOrgsCacheKeyPrefix, userID) }
 func orgUsersCacheKey(orgID int32) CacheKey  { return Int32CacheKey(orgUsersCacheKeyPrefix, orgID) }
+func orgUsersPageCacheKey(orgID int32, key string) CacheKey {
+	return CacheKey{Prefix: orgUsersPageCacheKeyPrefix, IntValue: orgID, StrValue: key}
+}
 func UserAPIKeysCacheKey(userID int32) CacheKey {
 	return Int32CacheKey(userAPIKeysCacheKeyPrefix, userID)
 }
@@ -365,9 +411,22 @@ func userAccountStatsCacheKey(userID int32, key string) CacheKey {
 func propertyStatsCacheKey(propertyID int32, key string) CacheKey {
 	return CacheKey{Prefix: propertyStatsCacheKeyPrefix, IntValue: propertyID, StrValue: key}
 }
+func propertyLatencyCacheKey(propertyID int32, key string) CacheKey {
+	return CacheKey{Prefix: propertyLatencyCacheKeyPrefix, IntValue: propertyID, StrValue: key}
+}
 func asyncTaskCacheKey(key string) CacheKey {
 	return StringCacheKey(asyncTaskCacheKeyPrefix, key)
 }
 func orgPropertiesCountCacheKey(orgID int32) CacheKey {
 	return Int32CacheKey(orgPropertiesCountCacheKeyPrefix, orgID)
 }
+func orgIPAllowlistCacheKey(orgID int32) CacheKey {
+	return Int32CacheKey(orgIPAllowlistCacheKeyPrefix, orgID)
+}
+func propertyAccessListCacheKey(propertyID int32) CacheKey {
+	return Int32CacheKey(propertyAccessListCacheKeyPrefix, propertyID)
+}
+
+func propertyShareLinkCacheKey(externalID string) CacheKey {
+	return StringCacheKey(propertyShareLinkCacheKeyPrefix, externalID)
+}
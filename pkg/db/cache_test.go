@@ -1,9 +1,46 @@
 package db
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+)
 
 func TestRegisterCachePrefixString(t *testing.T) {
 	if err := RegisterCachePrefixString(CACHE_KEY_PREFIXES_COUNT, "count"); err != nil {
 		t.Fatal(err)
 	}
 }
+
+func TestMemoryCacheSample(t *testing.T) {
+	ctx := context.TODO()
+
+	cache, err := NewMemoryCache[CacheKey, any]("test", 1000, &struct{}{}, time.Minute, time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const total = 100
+	for i := 0; i < total; i++ {
+		_ = cache.Set(ctx, UserCacheKey(int32(i)), i)
+	}
+
+	var _ cacheSampler = cache
+
+	for _, n := range []int{1, 10, total, 2 * total} {
+		sample := cache.Sample(n)
+		if len(sample) > n {
+			t.Errorf("Sample size %v exceeds requested %v", len(sample), n)
+		}
+
+		for key, value := range sample {
+			if cached, ok := cache.Peek(key); !ok || (cached != value) {
+				t.Errorf("Sampled value for key %v does not match cached value", key)
+			}
+		}
+	}
+
+	if sample := cache.Sample(2 * total); len(sample) != total {
+		t.Errorf("Unexpected full sample size: %v", len(sample))
+	}
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// implemented by memory cache, but not by static or transactional ones
+type cacheSampler interface {
+	Sample(n int) map[CacheKey]any
+	Peek(key CacheKey) (any, bool)
+}
+
+// CacheValidationStats contains results of checking cached entities of one kind against DB
+type CacheValidationStats struct {
+	Entity  string
+	Checked int
+	Stale   int
+}
+
+// loads fresh entity from DB for a cache key, returns nil if entity does not exist
+type cacheEntityLoader func(ctx context.Context, q dbgen.Querier, key CacheKey) (any, error)
+
+func newCacheEntityLoader[TKey any, T any](queryKeyFunc func(CacheKey) (TKey, error), queryFunc func(dbgen.Querier) func(context.Context, TKey) (*T, error)) cacheEntityLoader {
+	return func(ctx context.Context, q dbgen.Querier, key CacheKey) (any, error) {
+		queryKey, err := queryKeyFunc(key)
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := queryFunc(q)(ctx, queryKey)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return (*T)(nil), nil
+			}
+			return nil, err
+		}
+
+		return t, nil
+	}
+}
+
+// NOTE: only entities that are cached "as is" from DB rows can be validated this way. Aggregates (lists, counts, stats)
+// are either short-lived or are built from several queries so they are not included.
+var cacheEntityLoaders = map[CacheKeyPrefix]cacheEntityLoader{
+	userCacheKeyPrefix: newCacheEntityLoader(QueryKeyInt, func(q dbgen.Querier) func(context.Context, int32) (*dbgen.User, error) {
+		return q.GetUserByID
+	}),
+	apiKeyCacheKeyPrefix: newCacheEntityLoader(queryKeySecretUUID, func(q dbgen.Querier) func(context.Context, pgtype.UUID) (*dbgen.APIKey, error) {
+		return q.GetAPIKeyByExternalID
+	}),
+	propertyByIDCacheKeyPrefix: newCacheEntityLoader(QueryKeyInt, func(q dbgen.Querier) func(context.Context, int32) (*dbgen.Property, error) {
+		return q.GetPropertyByID
+	}),
+	propertyBySitekeyCacheKeyPrefix: newCacheEntityLoader(queryKeySitekeyUUID, func(q dbgen.Querier) func(context.Context, pgtype.UUID) (*dbgen.Property, error) {
+		return q.GetPropertyByExternalID
+	}),
+	subscriptionCacheKeyPrefix: newCacheEntityLoader(QueryKeyInt, func(q dbgen.Querier) func(context.Context, int32) (*dbgen.Subscription, error) {
+		return q.GetSubscriptionByID
+	}),
+}
+
+func cacheEntityName(prefix CacheKeyPrefix) string {
+	if int(prefix) < len(cachePrefixToStrings) {
+		return strings.TrimSuffix(cachePrefixToStrings[prefix], "/")
+	}
+
+	return strconv.Itoa(int(prefix))
+}
+
+// ValidateCache compares a random sample of cached entities with their DB rows and evicts the ones that differ
+// so that bugs in cache invalidation do not persist until TTL expiration
+func (impl *BusinessStoreImpl) ValidateCache(ctx context.Context, sampleSize int) ([]*CacheValidationStats, error) {
+	if sampleSize <= 0 {
+		return nil, ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	sampler, ok := impl.cache.(cacheSampler)
+	if !ok {
+		slog.DebugContext(ctx, "Cache does not support sampling")
+		return []*CacheValidationStats{}, nil
+	}
+
+	stats := make(map[CacheKeyPrefix]*CacheValidationStats)
+	missing := impl.cache.Missing()
+
+	for key, cached := range sampler.Sample(sampleSize) {
+		loader, ok := cacheEntityLoaders[key.Prefix]
+		if !ok || (cached == missing) {
+			// NOTE: negative entries are not checked since soft-deleted records are still present in DB
+			continue
+		}
+
+		fresh, err := loader(ctx, impl.querier, key)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to load entity to validate cache", "key", key, common.ErrAttr(err))
+			continue
+		}
+
+		s, ok := stats[key.Prefix]
+		if !ok {
+			s = &CacheValidationStats{Entity: cacheEntityName(key.Prefix)}
+			stats[key.Prefix] = s
+		}
+		s.Checked++
+
+		if reflect.DeepEqual(cached, fresh) {
+			continue
+		}
+
+		// entry could have been legitimately updated (or evicted) after we sampled it
+		if current, ok := sampler.Peek(key); !ok || (current != cached) {
+			continue
+		}
+
+		s.Stale++
+		slog.WarnContext(ctx, "Found stale cache entry", "key", key)
+		impl.cache.Delete(ctx, key)
+	}
+
+	result := make([]*CacheValidationStats, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, s)
+	}
+
+	return result, nil
+}
@@ -19,6 +19,10 @@ import (
 //go:embed migrations/clickhouse/*.sql
 var clickhouseMigrationsFS embed.FS
 
+const (
+	clickhouseMigrationsTable = "private_captcha_migrations"
+)
+
 type ClickHouseConnectOpts struct {
 	Host     string
 	Database string
@@ -1,11 +1,23 @@
 package db
 
 const (
-	TableNameUsers         = "users"
-	TableNameOrgs          = "organizations"
-	TableNameOrgUsers      = "organization_users"
-	TableNameProperties    = "properties"
-	TableNameSubscriptions = "subscriptions"
-	TableNameAPIKeys       = "apikeys"
-	TableNameAuditLogs     = "audit_logs"
+	TableNameUsers                = "users"
+	TableNameOrgs                 = "organizations"
+	TableNameOrgUsers             = "organization_users"
+	TableNameProperties           = "properties"
+	TableNameSubscriptions        = "subscriptions"
+	TableNameAPIKeys              = "apikeys"
+	TableNameAuditLogs            = "audit_logs"
+	TableNameBillingContacts      = "billing_contacts"
+	TableNameOrgIPAllowlists      = "org_ip_allowlists"
+	TableNameOrgEmailDomains      = "org_email_domains"
+	TableNamePropertyAccessLists  = "property_access_lists"
+	TableNamePropertyShareLinks   = "property_share_links"
+	TableNamePropertyBypassTokens = "property_bypass_tokens"
+	TableNameAPIKeyIPViolations   = "apikey_ip_violations"
+	TableNameConfig               = "config"
+	TableNameDeploys              = "deploys"
+	TableNamePlanDowngrades       = "plan_downgrades"
+	TableNameUserDevices          = "user_devices"
+	TableNameUserSSOLinks         = "user_sso_links"
 )
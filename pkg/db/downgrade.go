@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	// frozen properties are only listed for confirmation, the count is always complete
+	MaxDowngradeFrozenProperties = 50
+	unlimitedValue               = "Unlimited"
+)
+
+// PlanReduction is a single plan limit (feature) that is reduced or lost with the plan change
+type PlanReduction struct {
+	Name    string
+	Current string
+	Target  string
+}
+
+// DowngradeImpact is what concretely changes for the user when subscription is moved to the plan with lower limits
+type DowngradeImpact struct {
+	PropertiesCount int
+	PropertiesLimit int
+	// newest properties above the limit of the target plan, which would be frozen
+	FrozenProperties []*dbgen.GetUserPropertiesOverLimitRow
+	OrgsCount        int
+	OrgsLimit        int
+	// enabled API keys with rate limit above the one of the target plan
+	APIKeys    []*dbgen.APIKey
+	Reductions []*PlanReduction
+}
+
+func (di *DowngradeImpact) PropertiesOverLimit() int {
+	if di.PropertiesLimit == 0 {
+		return 0
+	}
+
+	return max(0, di.PropertiesCount-di.PropertiesLimit)
+}
+
+func (di *DowngradeImpact) OrgsOverLimit() int {
+	if di.OrgsLimit == 0 {
+		return 0
+	}
+
+	return max(0, di.OrgsCount-di.OrgsLimit)
+}
+
+func (di *DowngradeImpact) IsDowngrade() bool {
+	return len(di.Reductions) > 0
+}
+
+func (di *DowngradeImpact) LostFeatures() []string {
+	result := make([]string, 0, len(di.Reductions))
+	for _, r := range di.Reductions {
+		result = append(result, r.Name)
+	}
+	return result
+}
+
+// NewPlanLimits returns limits of the plan, same as SubscriptionLimits.Limits() does for subscription's plan
+func NewPlanLimits(plan billing.Plan) *PlanLimits {
+	return &PlanLimits{
+		Requests:   plan.RequestsLimit(),
+		Properties: plan.PropertiesLimit(),
+		Orgs:       plan.OrgsLimit(),
+		OrgMembers: plan.OrgMembersLimit(),
+		Seats:      plan.IncludedSeats(),
+
+		APIRequestsPerSecond:    plan.APIRequestsPerSecond(),
+		PuzzleRequestsPerSecond: plan.PuzzleRequestsPerSecond(),
+	}
+}
+
+func formatLimit[T int | int64](value T) string {
+	if value == 0 {
+		return unlimitedValue
+	}
+
+	return strconv.FormatInt(int64(value), 10)
+}
+
+func formatRate(value float64) string {
+	if value == 0 {
+		return unlimitedValue
+	}
+
+	return fmt.Sprintf("%g/s", value)
+}
+
+// isReduced treats zero as "unlimited"
+func isReduced[T int | int64 | float64](current, target T) bool {
+	return (target != 0) && ((current == 0) || (target < current))
+}
+
+// NewPlanReductions lists limits that the target plan has lower than the current one
+func NewPlanReductions(current, target *PlanLimits) []*PlanReduction {
+	result := make([]*PlanReduction, 0)
+
+	if isReduced(current.Requests, target.Requests) {
+		result = append(result, &PlanReduction{Name: "Monthly requests", Current: formatLimit(current.Requests), Target: formatLimit(target.Requests)})
+	}
+
+	if isReduced(current.Properties, target.Properties) {
+		result = append(result, &PlanReduction{Name: "Properties", Current: formatLimit(current.Properties), Target: formatLimit(target.Properties)})
+	}
+
+	if isReduced(current.Orgs, target.Orgs) {
+		result = append(result, &PlanReduction{Name: "Organizations", Current: formatLimit(current.Orgs), Target: formatLimit(target.Orgs)})
+	}
+
+	if isReduced(current.OrgMembers, target.OrgMembers) {
+		result = append(result, &PlanReduction{Name: "Organization members", Current: formatLimit(current.OrgMembers), Target: formatLimit(target.OrgMembers)})
+	}
+
+	// zero seats means the plan is not billed per seat (as opposed to "unlimited")
+	if (current.Seats > 0) && (target.Seats < current.Seats) {
+		result = append(result, &PlanReduction{Name: "Included seats", Current: strconv.Itoa(current.Seats), Target: strconv.Itoa(target.Seats)})
+	}
+
+	if isReduced(current.APIRequestsPerSecond, target.APIRequestsPerSecond) {
+		result = append(result, &PlanReduction{Name: "API rate limit", Current: formatRate(current.APIRequestsPerSecond), Target: formatRate(target.APIRequestsPerSecond)})
+	}
+
+	if isReduced(current.PuzzleRequestsPerSecond, target.PuzzleRequestsPerSecond) {
+		result = append(result, &PlanReduction{Name: "Puzzles rate per property", Current: formatRate(current.PuzzleRequestsPerSecond), Target: formatRate(target.PuzzleRequestsPerSecond)})
+	}
+
+	return result
+}
+
+// NewDowngradeImpact compares current usage of the user with limits of the target plan
+func NewDowngradeImpact(ctx context.Context, store Implementor, userID int32, current, target *PlanLimits) (*DowngradeImpact, error) {
+	impact := &DowngradeImpact{
+		PropertiesLimit: target.Properties,
+		OrgsLimit:       target.Orgs,
+		Reductions:      NewPlanReductions(current, target),
+		APIKeys:         make([]*dbgen.APIKey, 0),
+	}
+
+	count, err := store.Impl().RetrieveUserPropertiesCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	impact.PropertiesCount = int(count)
+
+	if impact.PropertiesOverLimit() > 0 {
+		if impact.FrozenProperties, err = store.Impl().RetrieveUserPropertiesOverLimit(ctx, userID, target.Properties,
+			MaxDowngradeFrozenProperties); err != nil {
+			return nil, err
+		}
+	}
+
+	orgs, err := store.Impl().RetrieveUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range orgs {
+		// sandbox org is not counted towards the plan limit
+		if (org.Level == dbgen.AccessLevelOwner) && !org.Organization.Sandbox {
+			impact.OrgsCount++
+		}
+	}
+
+	if target.APIRequestsPerSecond > 0 {
+		keys, err := store.Impl().RetrieveUserAPIKeys(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if key.Enabled.Bool && (key.RequestsPerSecond > target.APIRequestsPerSecond) {
+				impact.APIKeys = append(impact.APIKeys, key)
+			}
+		}
+	}
+
+	slog.DebugContext(ctx, "Computed downgrade impact", "userID", userID, "properties", impact.PropertiesOverLimit(),
+		"orgs", impact.OrgsOverLimit(), "apikeys", len(impact.APIKeys), "reductions", len(impact.Reductions))
+
+	return impact, nil
+}
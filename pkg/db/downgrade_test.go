@@ -0,0 +1,62 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewPlanReductions(t *testing.T) {
+	t.Parallel()
+
+	current := &PlanLimits{
+		Requests:             100_000,
+		Properties:           50,
+		Orgs:                 0,
+		OrgMembers:           10,
+		Seats:                5,
+		APIRequestsPerSecond: 20,
+	}
+
+	target := &PlanLimits{
+		Requests:             10_000,
+		Properties:           50,
+		Orgs:                 3,
+		OrgMembers:           0,
+		Seats:                0,
+		APIRequestsPerSecond: 10,
+	}
+
+	reductions := NewPlanReductions(current, target)
+
+	names := make([]string, 0, len(reductions))
+	for _, r := range reductions {
+		names = append(names, r.Name)
+	}
+
+	expected := []string{"Monthly requests", "Organizations", "Included seats", "API rate limit"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Unexpected reductions: %v", names)
+	}
+
+	if (reductions[1].Current != unlimitedValue) || (reductions[1].Target != "3") {
+		t.Errorf("Unexpected orgs reduction: %+v", reductions[1])
+	}
+
+	if reductions := NewPlanReductions(target, current); len(reductions) != 1 {
+		t.Errorf("Unexpected reductions for upgrade: %v", len(reductions))
+	}
+}
+
+func TestDowngradeImpactOverLimit(t *testing.T) {
+	t.Parallel()
+
+	impact := &DowngradeImpact{PropertiesCount: 12, PropertiesLimit: 10, OrgsCount: 2, OrgsLimit: 0}
+
+	if actual := impact.PropertiesOverLimit(); actual != 2 {
+		t.Errorf("Unexpected properties over limit: %v", actual)
+	}
+
+	if actual := impact.OrgsOverLimit(); actual != 0 {
+		t.Errorf("Unlimited orgs are over limit: %v", actual)
+	}
+}
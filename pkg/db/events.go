@@ -0,0 +1,288 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+// Event is the shape in which audit log events are published to external consumers
+type Event struct {
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	Action    string          `json:"action"`
+	Source    string          `json:"source"`
+	EntityID  int64           `json:"entity_id"`
+	UserID    int32           `json:"user_id,omitempty"`
+	CreatedAt common.JSONTime `json:"created_at"`
+	OldValue  any             `json:"old_value,omitempty"`
+	NewValue  any             `json:"new_value,omitempty"`
+}
+
+// EventType describes events of a single kind. Version has to be incremented on any breaking change of the schema
+// (see common.JSONSchema.BreakingChanges()), which is enforced by tests against the published catalog snapshot.
+type EventType struct {
+	Name        string
+	Version     int
+	Description string
+	Table       string
+	Actions     []common.AuditLogAction
+	Payload     reflect.Type
+}
+
+type EventCatalogEntry struct {
+	Type        string             `json:"type"`
+	Version     int                `json:"version"`
+	Description string             `json:"description"`
+	Schema      *common.JSONSchema `json:"schema"`
+}
+
+var (
+	softDeletableActions = []common.AuditLogAction{common.AuditLogActionCreate, common.AuditLogActionUpdate,
+		common.AuditLogActionSoftDelete, common.AuditLogActionDelete, common.AuditLogActionRecover}
+	auditLogSources = []string{common.AuditLogSourcePortal.String(), common.AuditLogSourceAPI.String(), common.AuditLogSourceCLI.String()}
+)
+
+var EventTypes = []*EventType{
+	{
+		Name:        "user",
+		Version:     1,
+		Description: "User account was changed, or user logged in or out",
+		Table:       TableNameUsers,
+		Actions:     slices.Concat(softDeletableActions, []common.AuditLogAction{common.AuditLogActionLogin, common.AuditLogActionLogout}),
+		Payload:     reflect.TypeFor[AuditLogUser](),
+	},
+	{
+		Name:        "subscription",
+		Version:     1,
+		Description: "Subscription of the user was changed",
+		Table:       TableNameSubscriptions,
+		Actions:     []common.AuditLogAction{common.AuditLogActionUpdate},
+		Payload:     reflect.TypeFor[AuditLogSubscription](),
+	},
+	{
+		Name:        "organization",
+		Version:     1,
+		Description: "Organization was changed",
+		Table:       TableNameOrgs,
+		Actions:     softDeletableActions,
+		Payload:     reflect.TypeFor[AuditLogOrg](),
+	},
+	{
+		Name:        "organization_member",
+		Version:     1,
+		Description: "User was invited to, joined or left the organization",
+		Table:       TableNameOrgUsers,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate, common.AuditLogActionUpdate, common.AuditLogActionDelete},
+		Payload:     reflect.TypeFor[AuditLogOrgUser](),
+	},
+	{
+		Name:        "property",
+		Version:     1,
+		Description: "Property was changed or moved to another organization",
+		Table:       TableNameProperties,
+		Actions:     softDeletableActions,
+		Payload:     reflect.TypeFor[AuditLogProperty](),
+	},
+	{
+		Name:        "apikey",
+		Version:     1,
+		Description: "API key was changed",
+		Table:       TableNameAPIKeys,
+		Actions:     softDeletableActions,
+		Payload:     reflect.TypeFor[AuditLogAPIKey](),
+	},
+	{
+		Name:        "apikey_ip_violation",
+		Version:     1,
+		Description: "API key was used from an IP address outside of its allowed ranges",
+		Table:       TableNameAPIKeyIPViolations,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate},
+		Payload:     reflect.TypeFor[AuditLogAPIKeyIPViolation](),
+	},
+	{
+		Name:        "billing_contact",
+		Version:     1,
+		Description: "Billing contacts or billing settings of the organization were changed",
+		Table:       TableNameBillingContacts,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate, common.AuditLogActionUpdate, common.AuditLogActionDelete},
+		Payload:     reflect.TypeFor[AuditLogBillingContact](),
+	},
+	{
+		Name:        "org_ip_allowlist",
+		Version:     1,
+		Description: "IP allowlist of the organization was changed",
+		Table:       TableNameOrgIPAllowlists,
+		Actions:     []common.AuditLogAction{common.AuditLogActionUpdate},
+		Payload:     reflect.TypeFor[AuditLogOrgIPAllowlist](),
+	},
+	{
+		Name:        "org_email_domain",
+		Version:     1,
+		Description: "Email domain of the organization was added, verified, changed or removed",
+		Table:       TableNameOrgEmailDomains,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate, common.AuditLogActionUpdate, common.AuditLogActionDelete},
+		Payload:     reflect.TypeFor[AuditLogOrgEmailDomain](),
+	},
+	{
+		Name:        "property_access_list",
+		Version:     1,
+		Description: "IP or ASN access lists of the property were changed",
+		Table:       TableNamePropertyAccessLists,
+		Actions:     []common.AuditLogAction{common.AuditLogActionUpdate},
+		Payload:     reflect.TypeFor[AuditLogPropertyAccessList](),
+	},
+	{
+		Name:        "property_share_link",
+		Version:     1,
+		Description: "Read-only link to property reports was created or revoked",
+		Table:       TableNamePropertyShareLinks,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate, common.AuditLogActionDelete},
+		Payload:     reflect.TypeFor[AuditLogPropertyShareLink](),
+	},
+	{
+		Name:        "property_bypass_token",
+		Version:     1,
+		Description: "Bypass token for automated testing of the property was created, used or revoked",
+		Table:       TableNamePropertyBypassTokens,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate, common.AuditLogActionUpdate, common.AuditLogActionDelete},
+		Payload:     reflect.TypeFor[AuditLogPropertyBypassToken](),
+	},
+	{
+		Name:        "plan_downgrade",
+		Version:     1,
+		Description: "User confirmed the impact of the subscription downgrade to a plan with lower limits",
+		Table:       TableNamePlanDowngrades,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate},
+		Payload:     reflect.TypeFor[AuditLogPlanDowngrade](),
+	},
+	{
+		Name:        "user_device",
+		Version:     1,
+		Description: "User signed in from a new device or removed a device from known ones",
+		Table:       TableNameUserDevices,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate, common.AuditLogActionDelete},
+		Payload:     reflect.TypeFor[AuditLogUserDevice](),
+	},
+	{
+		Name:        "user_sso_link",
+		Version:     1,
+		Description: "User linked single sign-on identity to their account",
+		Table:       TableNameUserSSOLinks,
+		Actions:     []common.AuditLogAction{common.AuditLogActionCreate},
+		Payload:     reflect.TypeFor[AuditLogUserSSOLink](),
+	},
+	{
+		Name:        "access",
+		Version:     1,
+		Description: "Sensitive data was viewed",
+		Table:       "",
+		Actions:     []common.AuditLogAction{common.AuditLogActionAccess},
+		Payload:     reflect.TypeFor[AuditLogAccess](),
+	},
+}
+
+// FindEventType returns the published type of the event in the table (access events share a single type)
+func FindEventType(table string, action string) *EventType {
+	if action == common.AuditLogActionAccess.String() {
+		table = ""
+	}
+
+	for _, et := range EventTypes {
+		if et.Table == table {
+			return et
+		}
+	}
+
+	return nil
+}
+
+// NewEvent converts recorded audit log event to the published shape, returns nil for events of unknown types
+func NewEvent(e *common.AuditLogEvent) *Event {
+	et := FindEventType(e.TableName, e.Action.String())
+	if et == nil {
+		return nil
+	}
+
+	return &Event{
+		Type:      et.Name,
+		Version:   et.Version,
+		Action:    e.Action.String(),
+		Source:    e.Source.String(),
+		EntityID:  e.EntityID,
+		UserID:    e.UserID,
+		CreatedAt: common.JSONTime(e.Timestamp),
+		OldValue:  e.OldValue,
+		NewValue:  e.NewValue,
+	}
+}
+
+// NewStoredEvent is the same as NewEvent, but for audit logs retrieved from DB (payloads are passed through as is)
+func NewStoredEvent(log *dbgen.AuditLog) *Event {
+	et := FindEventType(log.EntityTable, string(log.Action))
+	if et == nil {
+		return nil
+	}
+
+	event := &Event{
+		Type:      et.Name,
+		Version:   et.Version,
+		Action:    string(log.Action),
+		Source:    string(log.Source),
+		EntityID:  log.EntityID.Int64,
+		UserID:    log.UserID.Int32,
+		CreatedAt: common.JSONTime(log.CreatedAt.Time),
+	}
+
+	if len(log.OldValue) > 0 {
+		event.OldValue = json.RawMessage(log.OldValue)
+	}
+
+	if len(log.NewValue) > 0 {
+		event.NewValue = json.RawMessage(log.NewValue)
+	}
+
+	return event
+}
+
+// Schema describes the whole published event (envelope with the payload in old and new values)
+func (et *EventType) Schema() *common.JSONSchema {
+	schema := common.NewJSONSchema(reflect.TypeFor[Event]())
+	schema.Title = et.Name
+
+	actions := make([]string, 0, len(et.Actions))
+	for _, a := range et.Actions {
+		actions = append(actions, a.String())
+	}
+
+	schema.Properties["type"].Enum = []string{et.Name}
+	schema.Properties["action"].Enum = actions
+	schema.Properties["source"].Enum = auditLogSources
+
+	payload := common.NewJSONSchema(et.Payload)
+	schema.Properties["old_value"] = payload
+	schema.Properties["new_value"] = payload
+
+	return schema
+}
+
+func EventsCatalog() []*EventCatalogEntry {
+	result := make([]*EventCatalogEntry, 0, len(EventTypes))
+
+	for _, et := range EventTypes {
+		schema := et.Schema()
+		schema.Schema = common.JSONSchemaDialect
+
+		result = append(result, &EventCatalogEntry{
+			Type:        et.Name,
+			Version:     et.Version,
+			Description: et.Description,
+			Schema:      schema,
+		})
+	}
+
+	return result
+}
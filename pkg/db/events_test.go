@@ -0,0 +1,94 @@
+package db
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	eventsCatalogSnapshot = "testdata/events_catalog.json"
+)
+
+var updateEventsCatalog = flag.Bool("update-events-catalog", false, "overwrite published events catalog snapshot")
+
+// TestEventsCatalogCompatibility enforces compatibility policy: published event type cannot be removed and its schema
+// cannot change in a breaking way without incrementing its version (after which the snapshot has to be updated)
+func TestEventsCatalogCompatibility(t *testing.T) {
+	catalog := EventsCatalog()
+
+	if *updateEventsCatalog {
+		data, err := json.MarshalIndent(catalog, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(eventsCatalogSnapshot, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(eventsCatalogSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var published []*EventCatalogEntry
+	if err := json.Unmarshal(data, &published); err != nil {
+		t.Fatal(err)
+	}
+
+	current := make(map[string]*EventCatalogEntry)
+	for _, e := range catalog {
+		if _, ok := current[e.Type]; ok {
+			t.Errorf("Duplicate event type %v", e.Type)
+		}
+		current[e.Type] = e
+	}
+
+	for _, p := range published {
+		c, ok := current[p.Type]
+		if !ok {
+			t.Errorf("Published event type %v was removed", p.Type)
+			continue
+		}
+
+		switch {
+		case c.Version < p.Version:
+			t.Errorf("Version of event type %v was decreased from %v to %v", p.Type, p.Version, c.Version)
+		case c.Version > p.Version:
+			t.Errorf("Event type %v has new version %v: update snapshot with -update-events-catalog", p.Type, c.Version)
+		default:
+			for _, change := range p.Schema.BreakingChanges(p.Type, c.Schema) {
+				t.Errorf("Breaking change without incrementing version: %v", change)
+			}
+		}
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	event := NewEvent(&common.AuditLogEvent{
+		UserID:    1,
+		Action:    common.AuditLogActionUpdate,
+		Source:    common.AuditLogSourcePortal,
+		EntityID:  2,
+		TableName: TableNameProperties,
+		NewValue:  &AuditLogProperty{Name: "test"},
+	})
+
+	if (event == nil) || (event.Type != "property") || (event.Action != "update") || (event.Source != "portal") {
+		t.Fatalf("Unexpected event: %+v", event)
+	}
+
+	// access events share single type regardless of the table
+	if event := NewEvent(&common.AuditLogEvent{Action: common.AuditLogActionAccess, TableName: TableNameProperties}); (event == nil) || (event.Type != "access") {
+		t.Errorf("Unexpected access event: %+v", event)
+	}
+
+	if event := NewEvent(&common.AuditLogEvent{Action: common.AuditLogActionCreate, TableName: "unknown"}); event != nil {
+		t.Errorf("Unexpected event of unknown table: %+v", event)
+	}
+}
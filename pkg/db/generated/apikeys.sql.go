@@ -12,8 +12,47 @@ import (
 	"time"
 )
 
+const addAPIKeysIPViolations = `-- name: AddAPIKeysIPViolations :exec
+UPDATE backend.apikeys k
+SET ip_violations = k.ip_violations + u.count,
+    last_ip_violation_at = NOW()
+FROM (SELECT unnest($1::INT[]) AS apikey_id, unnest($2::BIGINT[]) AS count) AS u
+WHERE k.id = u.apikey_id
+`
+
+type AddAPIKeysIPViolationsParams struct {
+	Ids    []int32 `db:"ids" json:"ids"`
+	Counts []int64 `db:"counts" json:"counts"`
+}
+
+func (q *Queries) AddAPIKeysIPViolations(ctx context.Context, arg *AddAPIKeysIPViolationsParams) error {
+	_, err := q.db.Exec(ctx, addAPIKeysIPViolations, arg.Ids, arg.Counts)
+	return err
+}
+
+const addAPIKeysUsage = `-- name: AddAPIKeysUsage :exec
+INSERT INTO backend.apikey_usage (apikey_id, day, count)
+SELECT u.apikey_id, CURRENT_DATE, u.count
+FROM (SELECT unnest($1::INT[]) AS apikey_id, unnest($2::BIGINT[]) AS count) AS u
+JOIN backend.apikeys k ON k.id = u.apikey_id
+ON CONFLICT (apikey_id, day)
+DO UPDATE SET
+    count = backend.apikey_usage.count + EXCLUDED.count,
+    last_used_at = NOW()
+`
+
+type AddAPIKeysUsageParams struct {
+	Ids    []int32 `db:"ids" json:"ids"`
+	Counts []int64 `db:"counts" json:"counts"`
+}
+
+func (q *Queries) AddAPIKeysUsage(ctx context.Context, arg *AddAPIKeysUsageParams) error {
+	_, err := q.db.Exec(ctx, addAPIKeysUsage, arg.Ids, arg.Counts)
+	return err
+}
+
 const createAPIKey = `-- name: CreateAPIKey :one
-INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst, period, scope, readonly, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly
+INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst, period, scope, readonly, org_id, allowed_cidrs) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at
 `
 
 type CreateAPIKeyParams struct {
@@ -26,6 +65,7 @@ type CreateAPIKeyParams struct {
 	Scope             ApiKeyScope        `db:"scope" json:"scope"`
 	Readonly          bool               `db:"readonly" json:"readonly"`
 	OrgID             pgtype.Int4        `db:"org_id" json:"org_id"`
+	AllowedCidrs      []string           `db:"allowed_cidrs" json:"allowed_cidrs"`
 }
 
 func (q *Queries) CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*APIKey, error) {
@@ -39,6 +79,7 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*A
 		arg.Scope,
 		arg.Readonly,
 		arg.OrgID,
+		arg.AllowedCidrs,
 	)
 	var i APIKey
 	err := row.Scan(
@@ -57,12 +98,17 @@ func (q *Queries) CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*A
 		&i.Period,
 		&i.Scope,
 		&i.Readonly,
+		&i.PreviousExternalID,
+		&i.PreviousExpiresAt,
+		&i.AllowedCidrs,
+		&i.IpViolations,
+		&i.LastIpViolationAt,
 	)
 	return &i, err
 }
 
 const deleteAPIKey = `-- name: DeleteAPIKey :one
-DELETE FROM backend.apikeys WHERE id=$1 AND user_id = $2 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly
+DELETE FROM backend.apikeys WHERE id=$1 AND user_id = $2 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at
 `
 
 type DeleteAPIKeyParams struct {
@@ -89,10 +135,69 @@ func (q *Queries) DeleteAPIKey(ctx context.Context, arg *DeleteAPIKeyParams) (*A
 		&i.Period,
 		&i.Scope,
 		&i.Readonly,
+		&i.PreviousExternalID,
+		&i.PreviousExpiresAt,
+		&i.AllowedCidrs,
+		&i.IpViolations,
+		&i.LastIpViolationAt,
 	)
 	return &i, err
 }
 
+const deleteOldAPIKeysUsage = `-- name: DeleteOldAPIKeysUsage :exec
+DELETE FROM backend.apikey_usage WHERE last_used_at < $1
+`
+
+func (q *Queries) DeleteOldAPIKeysUsage(ctx context.Context, lastUsedAt pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, deleteOldAPIKeysUsage, lastUsedAt)
+	return err
+}
+
+const deleteOrgAPIKeys = `-- name: DeleteOrgAPIKeys :many
+DELETE FROM backend.apikeys WHERE org_id = $1 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at
+`
+
+func (q *Queries) DeleteOrgAPIKeys(ctx context.Context, orgID pgtype.Int4) ([]*APIKey, error) {
+	rows, err := q.db.Query(ctx, deleteOrgAPIKeys, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.UserID,
+			&i.Enabled,
+			&i.RequestsPerSecond,
+			&i.RequestsBurst,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Notes,
+			&i.OrgID,
+			&i.UpdatedAt,
+			&i.Period,
+			&i.Scope,
+			&i.Readonly,
+			&i.PreviousExternalID,
+			&i.PreviousExpiresAt,
+			&i.AllowedCidrs,
+			&i.IpViolations,
+			&i.LastIpViolationAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteUserAPIKeys = `-- name: DeleteUserAPIKeys :exec
 DELETE FROM backend.apikeys WHERE user_id = $1
 `
@@ -103,7 +208,7 @@ func (q *Queries) DeleteUserAPIKeys(ctx context.Context, userID pgtype.Int4) err
 }
 
 const getAPIKeyByExternalID = `-- name: GetAPIKeyByExternalID :one
-SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly FROM backend.apikeys WHERE external_id = $1
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at FROM backend.apikeys WHERE external_id = $1 OR (previous_external_id = $1 AND previous_expires_at > NOW())
 `
 
 func (q *Queries) GetAPIKeyByExternalID(ctx context.Context, externalID pgtype.UUID) (*APIKey, error) {
@@ -125,12 +230,17 @@ func (q *Queries) GetAPIKeyByExternalID(ctx context.Context, externalID pgtype.U
 		&i.Period,
 		&i.Scope,
 		&i.Readonly,
+		&i.PreviousExternalID,
+		&i.PreviousExpiresAt,
+		&i.AllowedCidrs,
+		&i.IpViolations,
+		&i.LastIpViolationAt,
 	)
 	return &i, err
 }
 
 const getUserAPIKeyByName = `-- name: GetUserAPIKeyByName :one
-SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly FROM backend.apikeys WHERE user_id = $1 AND name = $2 AND expires_at > NOW()
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at FROM backend.apikeys WHERE user_id = $1 AND name = $2 AND expires_at > NOW()
 `
 
 type GetUserAPIKeyByNameParams struct {
@@ -157,12 +267,17 @@ func (q *Queries) GetUserAPIKeyByName(ctx context.Context, arg *GetUserAPIKeyByN
 		&i.Period,
 		&i.Scope,
 		&i.Readonly,
+		&i.PreviousExternalID,
+		&i.PreviousExpiresAt,
+		&i.AllowedCidrs,
+		&i.IpViolations,
+		&i.LastIpViolationAt,
 	)
 	return &i, err
 }
 
 const getUserAPIKeys = `-- name: GetUserAPIKeys :many
-SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly FROM backend.apikeys WHERE user_id = $1 AND expires_at > NOW()
+SELECT id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at FROM backend.apikeys WHERE user_id = $1 AND expires_at > NOW()
 `
 
 func (q *Queries) GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*APIKey, error) {
@@ -190,6 +305,11 @@ func (q *Queries) GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*AP
 			&i.Period,
 			&i.Scope,
 			&i.Readonly,
+			&i.PreviousExternalID,
+			&i.PreviousExpiresAt,
+			&i.AllowedCidrs,
+			&i.IpViolations,
+			&i.LastIpViolationAt,
 		); err != nil {
 			return nil, err
 		}
@@ -201,17 +321,51 @@ func (q *Queries) GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*AP
 	return items, nil
 }
 
+const getUserAPIKeysLastUsed = `-- name: GetUserAPIKeysLastUsed :many
+SELECT au.apikey_id, MAX(au.last_used_at)::TIMESTAMPTZ AS last_used_at
+FROM backend.apikey_usage au
+JOIN backend.apikeys k ON k.id = au.apikey_id
+WHERE k.user_id = $1
+GROUP BY au.apikey_id
+`
+
+type GetUserAPIKeysLastUsedRow struct {
+	ApikeyID   int32              `db:"apikey_id" json:"apikey_id"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+}
+
+func (q *Queries) GetUserAPIKeysLastUsed(ctx context.Context, userID pgtype.Int4) ([]*GetUserAPIKeysLastUsedRow, error) {
+	rows, err := q.db.Query(ctx, getUserAPIKeysLastUsed, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetUserAPIKeysLastUsedRow
+	for rows.Next() {
+		var i GetUserAPIKeysLastUsedRow
+		if err := rows.Scan(&i.ApikeyID, &i.LastUsedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const rotateAPIKey = `-- name: RotateAPIKey :one
-UPDATE backend.apikeys SET external_id = gen_random_uuid(), expires_at = NOW() + period, updated_at = NOW() WHERE id = $1 AND user_id = $2 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly
+UPDATE backend.apikeys SET previous_external_id = external_id, previous_expires_at = NOW() + $1::interval, external_id = gen_random_uuid(), expires_at = NOW() + period, updated_at = NOW() WHERE id = $2 AND user_id = $3 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at
 `
 
 type RotateAPIKeyParams struct {
-	ID     int32       `db:"id" json:"id"`
-	UserID pgtype.Int4 `db:"user_id" json:"user_id"`
+	Overlap time.Duration `db:"overlap" json:"overlap"`
+	ID      int32         `db:"id" json:"id"`
+	UserID  pgtype.Int4   `db:"user_id" json:"user_id"`
 }
 
 func (q *Queries) RotateAPIKey(ctx context.Context, arg *RotateAPIKeyParams) (*APIKey, error) {
-	row := q.db.QueryRow(ctx, rotateAPIKey, arg.ID, arg.UserID)
+	row := q.db.QueryRow(ctx, rotateAPIKey, arg.Overlap, arg.ID, arg.UserID)
 	var i APIKey
 	err := row.Scan(
 		&i.ID,
@@ -229,12 +383,17 @@ func (q *Queries) RotateAPIKey(ctx context.Context, arg *RotateAPIKeyParams) (*A
 		&i.Period,
 		&i.Scope,
 		&i.Readonly,
+		&i.PreviousExternalID,
+		&i.PreviousExpiresAt,
+		&i.AllowedCidrs,
+		&i.IpViolations,
+		&i.LastIpViolationAt,
 	)
 	return &i, err
 }
 
 const updateAPIKey = `-- name: UpdateAPIKey :one
-UPDATE backend.apikeys SET expires_at = $1, enabled = $2, updated_at = NOW() WHERE external_id = $3 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly
+UPDATE backend.apikeys SET expires_at = $1, enabled = $2, updated_at = NOW() WHERE external_id = $3 RETURNING id, name, external_id, user_id, enabled, requests_per_second, requests_burst, created_at, expires_at, notes, org_id, updated_at, period, scope, readonly, previous_external_id, previous_expires_at, allowed_cidrs, ip_violations, last_ip_violation_at
 `
 
 type UpdateAPIKeyParams struct {
@@ -262,6 +421,11 @@ func (q *Queries) UpdateAPIKey(ctx context.Context, arg *UpdateAPIKeyParams) (*A
 		&i.Period,
 		&i.Scope,
 		&i.Readonly,
+		&i.PreviousExternalID,
+		&i.PreviousExpiresAt,
+		&i.AllowedCidrs,
+		&i.IpViolations,
+		&i.LastIpViolationAt,
 	)
 	return &i, err
 }
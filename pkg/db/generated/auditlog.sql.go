@@ -18,6 +18,7 @@ type CreateAuditLogsParams struct {
 	EntityID    pgtype.Int8        `db:"entity_id" json:"entity_id"`
 	EntityTable string             `db:"entity_table" json:"entity_table"`
 	SessionID   string             `db:"session_id" json:"session_id"`
+	TraceID     string             `db:"trace_id" json:"trace_id"`
 	OldValue    []byte             `db:"old_value" json:"old_value"`
 	NewValue    []byte             `db:"new_value" json:"new_value"`
 	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
@@ -32,12 +33,35 @@ func (q *Queries) DeleteOldAuditLogs(ctx context.Context, createdAt pgtype.Times
 	return err
 }
 
+const getLatestTableAuditLog = `-- name: GetLatestTableAuditLog :one
+SELECT id, user_id, action, entity_id, entity_table, session_id, old_value, new_value, created_at, source, trace_id FROM backend.audit_logs WHERE entity_table = $1 ORDER BY created_at DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestTableAuditLog(ctx context.Context, entityTable string) (*AuditLog, error) {
+	row := q.db.QueryRow(ctx, getLatestTableAuditLog, entityTable)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Action,
+		&i.EntityID,
+		&i.EntityTable,
+		&i.SessionID,
+		&i.OldValue,
+		&i.NewValue,
+		&i.CreatedAt,
+		&i.Source,
+		&i.TraceID,
+	)
+	return &i, err
+}
+
 const getOrgAuditLogs = `-- name: GetOrgAuditLogs :many
-SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, u.name, u.email
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
 FROM backend.audit_logs a
 LEFT JOIN backend.users u ON u.id = a.user_id
 WHERE (
-    ((a.entity_table = 'organizations' OR a.entity_table = 'organization_users') AND a.entity_id = $1)
+    ((a.entity_table = 'organizations' OR a.entity_table = 'organization_users' OR a.entity_table = 'billing_contacts' OR a.entity_table = 'org_ip_allowlists' OR a.entity_table = 'org_email_domains') AND a.entity_id = $1)
     OR (
         a.entity_table = 'properties'
         AND ((a.old_value ->> 'org_id')::bigint = $1 OR (a.new_value ->> 'org_id')::bigint = $1)
@@ -87,6 +111,7 @@ func (q *Queries) GetOrgAuditLogs(ctx context.Context, arg *GetOrgAuditLogsParam
 			&i.AuditLog.NewValue,
 			&i.AuditLog.CreatedAt,
 			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
 			&i.Name,
 			&i.Email,
 		); err != nil {
@@ -101,7 +126,7 @@ func (q *Queries) GetOrgAuditLogs(ctx context.Context, arg *GetOrgAuditLogsParam
 }
 
 const getPropertyAuditLogs = `-- name: GetPropertyAuditLogs :many
-SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, u.name, u.email
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
 FROM backend.audit_logs a
 LEFT JOIN backend.users u ON u.id = a.user_id
 WHERE a.entity_table = 'properties' AND a.entity_id = $1 AND a.created_at >= $2
@@ -148,6 +173,122 @@ func (q *Queries) GetPropertyAuditLogs(ctx context.Context, arg *GetPropertyAudi
 			&i.AuditLog.NewValue,
 			&i.AuditLog.CreatedAt,
 			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSystemAuditLogs = `-- name: GetSystemAuditLogs :many
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE a.entity_table = ANY($1::TEXT[]) AND a.created_at >= $2::TIMESTAMPTZ AND a.created_at < $3::TIMESTAMPTZ
+ORDER BY a.created_at ASC
+LIMIT $4
+`
+
+type GetSystemAuditLogsParams struct {
+	Tables     []string           `db:"tables" json:"tables"`
+	Since      pgtype.Timestamptz `db:"since" json:"since"`
+	Until      pgtype.Timestamptz `db:"until" json:"until"`
+	MaxResults int32              `db:"max_results" json:"max_results"`
+}
+
+type GetSystemAuditLogsRow struct {
+	AuditLog AuditLog    `db:"audit_log" json:"audit_log"`
+	Name     pgtype.Text `db:"name" json:"name"`
+	Email    pgtype.Text `db:"email" json:"email"`
+}
+
+func (q *Queries) GetSystemAuditLogs(ctx context.Context, arg *GetSystemAuditLogsParams) ([]*GetSystemAuditLogsRow, error) {
+	rows, err := q.db.Query(ctx, getSystemAuditLogs,
+		arg.Tables,
+		arg.Since,
+		arg.Until,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetSystemAuditLogsRow
+	for rows.Next() {
+		var i GetSystemAuditLogsRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.UserID,
+			&i.AuditLog.Action,
+			&i.AuditLog.EntityID,
+			&i.AuditLog.EntityTable,
+			&i.AuditLog.SessionID,
+			&i.AuditLog.OldValue,
+			&i.AuditLog.NewValue,
+			&i.AuditLog.CreatedAt,
+			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTraceAuditLogs = `-- name: GetTraceAuditLogs :many
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE a.trace_id = $1
+ORDER BY a.created_at ASC
+LIMIT $2
+`
+
+type GetTraceAuditLogsParams struct {
+	TraceID string `db:"trace_id" json:"trace_id"`
+	Limit   int32  `db:"limit" json:"limit"`
+}
+
+type GetTraceAuditLogsRow struct {
+	AuditLog AuditLog    `db:"audit_log" json:"audit_log"`
+	Name     pgtype.Text `db:"name" json:"name"`
+	Email    pgtype.Text `db:"email" json:"email"`
+}
+
+func (q *Queries) GetTraceAuditLogs(ctx context.Context, arg *GetTraceAuditLogsParams) ([]*GetTraceAuditLogsRow, error) {
+	rows, err := q.db.Query(ctx, getTraceAuditLogs, arg.TraceID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetTraceAuditLogsRow
+	for rows.Next() {
+		var i GetTraceAuditLogsRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.UserID,
+			&i.AuditLog.Action,
+			&i.AuditLog.EntityID,
+			&i.AuditLog.EntityTable,
+			&i.AuditLog.SessionID,
+			&i.AuditLog.OldValue,
+			&i.AuditLog.NewValue,
+			&i.AuditLog.CreatedAt,
+			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
 			&i.Name,
 			&i.Email,
 		); err != nil {
@@ -162,7 +303,7 @@ func (q *Queries) GetPropertyAuditLogs(ctx context.Context, arg *GetPropertyAudi
 }
 
 const getUserAuditLogs = `-- name: GetUserAuditLogs :many
-SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, u.name, u.email
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
 FROM backend.audit_logs a
 LEFT JOIN backend.users u ON u.id = a.user_id
 WHERE (a.user_id = $1 OR
@@ -222,6 +363,388 @@ func (q *Queries) GetUserAuditLogs(ctx context.Context, arg *GetUserAuditLogsPar
 			&i.AuditLog.NewValue,
 			&i.AuditLog.CreatedAt,
 			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserAuditLogsBefore = `-- name: GetUserAuditLogsBefore :many
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (a.user_id = $1 OR
+    (
+        a.entity_table = 'users' AND a.entity_id = $1
+    ) OR
+    (
+        a.entity_table = 'organization_users'
+        AND ((a.old_value ->> 'user_id')::bigint = $1 OR (a.new_value ->> 'user_id')::bigint = $1)
+    ) OR
+    (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'creator_id')::bigint = $1 OR (a.new_value ->> 'creator_id')::bigint = $1)
+    )
+)
+AND a.created_at >= $2 AND (a.created_at, a.id) < ($5::TIMESTAMPTZ, $6::BIGINT)
+ORDER BY a.created_at DESC, a.id DESC
+OFFSET $3
+LIMIT $4
+`
+
+type GetUserAuditLogsBeforeParams struct {
+	UserID          pgtype.Int4        `db:"user_id" json:"user_id"`
+	CreatedAt       pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	Offset          int32              `db:"offset" json:"offset"`
+	Limit           int32              `db:"limit" json:"limit"`
+	BeforeCreatedAt pgtype.Timestamptz `db:"before_created_at" json:"before_created_at"`
+	BeforeID        int64              `db:"before_id" json:"before_id"`
+}
+
+type GetUserAuditLogsBeforeRow struct {
+	AuditLog AuditLog    `db:"audit_log" json:"audit_log"`
+	Name     pgtype.Text `db:"name" json:"name"`
+	Email    pgtype.Text `db:"email" json:"email"`
+}
+
+func (q *Queries) GetUserAuditLogsBefore(ctx context.Context, arg *GetUserAuditLogsBeforeParams) ([]*GetUserAuditLogsBeforeRow, error) {
+	rows, err := q.db.Query(ctx, getUserAuditLogsBefore,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.Offset,
+		arg.Limit,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetUserAuditLogsBeforeRow
+	for rows.Next() {
+		var i GetUserAuditLogsBeforeRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.UserID,
+			&i.AuditLog.Action,
+			&i.AuditLog.EntityID,
+			&i.AuditLog.EntityTable,
+			&i.AuditLog.SessionID,
+			&i.AuditLog.OldValue,
+			&i.AuditLog.NewValue,
+			&i.AuditLog.CreatedAt,
+			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchOrgAuditLogs = `-- name: SearchOrgAuditLogs :many
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (
+    ((a.entity_table = 'organizations' OR a.entity_table = 'organization_users' OR a.entity_table = 'billing_contacts' OR a.entity_table = 'org_ip_allowlists' OR a.entity_table = 'org_email_domains') AND a.entity_id = $1)
+    OR (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'org_id')::bigint = $1 OR (a.new_value ->> 'org_id')::bigint = $1)
+    )
+)
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', $2::text)
+    OR strpos(lower(coalesce(u.email, '')), lower($2::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower($2::text)) > 0
+)
+AND a.created_at >= $3
+ORDER BY a.created_at DESC
+LIMIT $4
+`
+
+type SearchOrgAuditLogsParams struct {
+	EntityID   pgtype.Int8        `db:"entity_id" json:"entity_id"`
+	Query      string             `db:"query" json:"query"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	MaxResults int32              `db:"max_results" json:"max_results"`
+}
+
+type SearchOrgAuditLogsRow struct {
+	AuditLog AuditLog    `db:"audit_log" json:"audit_log"`
+	Name     pgtype.Text `db:"name" json:"name"`
+	Email    pgtype.Text `db:"email" json:"email"`
+}
+
+func (q *Queries) SearchOrgAuditLogs(ctx context.Context, arg *SearchOrgAuditLogsParams) ([]*SearchOrgAuditLogsRow, error) {
+	rows, err := q.db.Query(ctx, searchOrgAuditLogs,
+		arg.EntityID,
+		arg.Query,
+		arg.CreatedAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*SearchOrgAuditLogsRow
+	for rows.Next() {
+		var i SearchOrgAuditLogsRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.UserID,
+			&i.AuditLog.Action,
+			&i.AuditLog.EntityID,
+			&i.AuditLog.EntityTable,
+			&i.AuditLog.SessionID,
+			&i.AuditLog.OldValue,
+			&i.AuditLog.NewValue,
+			&i.AuditLog.CreatedAt,
+			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPropertyAuditLogs = `-- name: SearchPropertyAuditLogs :many
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE a.entity_table = 'properties' AND a.entity_id = $1
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', $2::text)
+    OR strpos(lower(coalesce(u.email, '')), lower($2::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower($2::text)) > 0
+)
+AND a.created_at >= $3
+ORDER BY a.created_at DESC
+LIMIT $4
+`
+
+type SearchPropertyAuditLogsParams struct {
+	EntityID   pgtype.Int8        `db:"entity_id" json:"entity_id"`
+	Query      string             `db:"query" json:"query"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	MaxResults int32              `db:"max_results" json:"max_results"`
+}
+
+type SearchPropertyAuditLogsRow struct {
+	AuditLog AuditLog    `db:"audit_log" json:"audit_log"`
+	Name     pgtype.Text `db:"name" json:"name"`
+	Email    pgtype.Text `db:"email" json:"email"`
+}
+
+func (q *Queries) SearchPropertyAuditLogs(ctx context.Context, arg *SearchPropertyAuditLogsParams) ([]*SearchPropertyAuditLogsRow, error) {
+	rows, err := q.db.Query(ctx, searchPropertyAuditLogs,
+		arg.EntityID,
+		arg.Query,
+		arg.CreatedAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*SearchPropertyAuditLogsRow
+	for rows.Next() {
+		var i SearchPropertyAuditLogsRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.UserID,
+			&i.AuditLog.Action,
+			&i.AuditLog.EntityID,
+			&i.AuditLog.EntityTable,
+			&i.AuditLog.SessionID,
+			&i.AuditLog.OldValue,
+			&i.AuditLog.NewValue,
+			&i.AuditLog.CreatedAt,
+			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUserAuditLogs = `-- name: SearchUserAuditLogs :many
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (a.user_id = $1 OR
+    (
+        a.entity_table = 'users' AND a.entity_id = $1
+    ) OR
+    (
+        a.entity_table = 'organization_users'
+        AND ((a.old_value ->> 'user_id')::bigint = $1 OR (a.new_value ->> 'user_id')::bigint = $1)
+    ) OR
+    (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'creator_id')::bigint = $1 OR (a.new_value ->> 'creator_id')::bigint = $1)
+    )
+)
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', $2::text)
+    OR strpos(lower(coalesce(u.email, '')), lower($2::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower($2::text)) > 0
+)
+AND a.created_at >= $3
+ORDER BY a.created_at DESC
+LIMIT $4
+`
+
+type SearchUserAuditLogsParams struct {
+	UserID     pgtype.Int4        `db:"user_id" json:"user_id"`
+	Query      string             `db:"query" json:"query"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	MaxResults int32              `db:"max_results" json:"max_results"`
+}
+
+type SearchUserAuditLogsRow struct {
+	AuditLog AuditLog    `db:"audit_log" json:"audit_log"`
+	Name     pgtype.Text `db:"name" json:"name"`
+	Email    pgtype.Text `db:"email" json:"email"`
+}
+
+func (q *Queries) SearchUserAuditLogs(ctx context.Context, arg *SearchUserAuditLogsParams) ([]*SearchUserAuditLogsRow, error) {
+	rows, err := q.db.Query(ctx, searchUserAuditLogs,
+		arg.UserID,
+		arg.Query,
+		arg.CreatedAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*SearchUserAuditLogsRow
+	for rows.Next() {
+		var i SearchUserAuditLogsRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.UserID,
+			&i.AuditLog.Action,
+			&i.AuditLog.EntityID,
+			&i.AuditLog.EntityTable,
+			&i.AuditLog.SessionID,
+			&i.AuditLog.OldValue,
+			&i.AuditLog.NewValue,
+			&i.AuditLog.CreatedAt,
+			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUserAuditLogsBefore = `-- name: SearchUserAuditLogsBefore :many
+SELECT a.id, a.user_id, a.action, a.entity_id, a.entity_table, a.session_id, a.old_value, a.new_value, a.created_at, a.source, a.trace_id, u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (a.user_id = $1 OR
+    (
+        a.entity_table = 'users' AND a.entity_id = $1
+    ) OR
+    (
+        a.entity_table = 'organization_users'
+        AND ((a.old_value ->> 'user_id')::bigint = $1 OR (a.new_value ->> 'user_id')::bigint = $1)
+    ) OR
+    (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'creator_id')::bigint = $1 OR (a.new_value ->> 'creator_id')::bigint = $1)
+    )
+)
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', $2::text)
+    OR strpos(lower(coalesce(u.email, '')), lower($2::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower($2::text)) > 0
+)
+AND a.created_at >= $3 AND (a.created_at, a.id) < ($4::TIMESTAMPTZ, $5::BIGINT)
+ORDER BY a.created_at DESC, a.id DESC
+LIMIT $6
+`
+
+type SearchUserAuditLogsBeforeParams struct {
+	UserID          pgtype.Int4        `db:"user_id" json:"user_id"`
+	Query           string             `db:"query" json:"query"`
+	CreatedAt       pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	BeforeCreatedAt pgtype.Timestamptz `db:"before_created_at" json:"before_created_at"`
+	BeforeID        int64              `db:"before_id" json:"before_id"`
+	MaxResults      int32              `db:"max_results" json:"max_results"`
+}
+
+type SearchUserAuditLogsBeforeRow struct {
+	AuditLog AuditLog    `db:"audit_log" json:"audit_log"`
+	Name     pgtype.Text `db:"name" json:"name"`
+	Email    pgtype.Text `db:"email" json:"email"`
+}
+
+func (q *Queries) SearchUserAuditLogsBefore(ctx context.Context, arg *SearchUserAuditLogsBeforeParams) ([]*SearchUserAuditLogsBeforeRow, error) {
+	rows, err := q.db.Query(ctx, searchUserAuditLogsBefore,
+		arg.UserID,
+		arg.Query,
+		arg.CreatedAt,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*SearchUserAuditLogsBeforeRow
+	for rows.Next() {
+		var i SearchUserAuditLogsBeforeRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.UserID,
+			&i.AuditLog.Action,
+			&i.AuditLog.EntityID,
+			&i.AuditLog.EntityTable,
+			&i.AuditLog.SessionID,
+			&i.AuditLog.OldValue,
+			&i.AuditLog.NewValue,
+			&i.AuditLog.CreatedAt,
+			&i.AuditLog.Source,
+			&i.AuditLog.TraceID,
 			&i.Name,
 			&i.Email,
 		); err != nil {
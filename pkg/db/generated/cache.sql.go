@@ -11,6 +11,24 @@ import (
 	"time"
 )
 
+const compareAndSwapCache = `-- name: CompareAndSwapCache :execrows
+UPDATE backend.cache SET value = $1 WHERE key = $2 AND value = $3 AND expires_at >= NOW()
+`
+
+type CompareAndSwapCacheParams struct {
+	NewValue []byte `db:"new_value" json:"new_value"`
+	Key      string `db:"key" json:"key"`
+	OldValue []byte `db:"old_value" json:"old_value"`
+}
+
+func (q *Queries) CompareAndSwapCache(ctx context.Context, arg *CompareAndSwapCacheParams) (int64, error) {
+	result, err := q.db.Exec(ctx, compareAndSwapCache, arg.NewValue, arg.Key, arg.OldValue)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createCache = `-- name: CreateCache :exec
 INSERT INTO backend.cache (key, value, expires_at) VALUES ($1, $2, NOW() + $3::INTERVAL)
 ON CONFLICT (key) DO UPDATE 
@@ -79,6 +97,41 @@ func (q *Queries) GetCachedByKey(ctx context.Context, key string) ([]byte, error
 	return value, err
 }
 
+const getCachedKeys = `-- name: GetCachedKeys :many
+SELECT key FROM backend.cache WHERE key = ANY($1::TEXT[]) AND expires_at >= NOW()
+`
+
+func (q *Queries) GetCachedKeys(ctx context.Context, keys []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getCachedKeys, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const takeCachedByKey = `-- name: TakeCachedByKey :one
+DELETE FROM backend.cache WHERE key = $1 AND expires_at >= NOW() RETURNING value
+`
+
+func (q *Queries) TakeCachedByKey(ctx context.Context, key string) ([]byte, error) {
+	row := q.db.QueryRow(ctx, takeCachedByKey, key)
+	var value []byte
+	err := row.Scan(&value)
+	return value, err
+}
+
 const updateCacheExpiration = `-- name: UpdateCacheExpiration :exec
 UPDATE backend.cache SET expires_at = NOW() + $2::INTERVAL WHERE key = $1
 `
@@ -35,6 +35,7 @@ func (r iteratorForCreateAuditLogs) Values() ([]interface{}, error) {
 		r.rows[0].EntityID,
 		r.rows[0].EntityTable,
 		r.rows[0].SessionID,
+		r.rows[0].TraceID,
 		r.rows[0].OldValue,
 		r.rows[0].NewValue,
 		r.rows[0].CreatedAt,
@@ -46,5 +47,5 @@ func (r iteratorForCreateAuditLogs) Err() error {
 }
 
 func (q *Queries) CreateAuditLogs(ctx context.Context, arg []*CreateAuditLogsParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"backend", "audit_logs"}, []string{"user_id", "action", "source", "entity_id", "entity_table", "session_id", "old_value", "new_value", "created_at"}, &iteratorForCreateAuditLogs{rows: arg})
+	return q.db.CopyFrom(ctx, []string{"backend", "audit_logs"}, []string{"user_id", "action", "source", "entity_id", "entity_table", "session_id", "trace_id", "old_value", "new_value", "created_at"}, &iteratorForCreateAuditLogs{rows: arg})
 }
@@ -20,6 +20,23 @@ func (q *Queries) DeleteLock(ctx context.Context, name string) error {
 	return err
 }
 
+const deleteOwnedLock = `-- name: DeleteOwnedLock :execrows
+DELETE FROM backend.locks WHERE name = $1 AND data = $2
+`
+
+type DeleteOwnedLockParams struct {
+	Name string `db:"name" json:"name"`
+	Data []byte `db:"data" json:"data"`
+}
+
+func (q *Queries) DeleteOwnedLock(ctx context.Context, arg *DeleteOwnedLockParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOwnedLock, arg.Name, arg.Data)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getLock = `-- name: GetLock :one
 SELECT name, data, expires_at FROM backend.locks WHERE name = $1
 `
@@ -35,7 +52,7 @@ const insertLock = `-- name: InsertLock :one
 INSERT INTO backend.locks (name, data, expires_at)
 VALUES ($1, $2, $3)
 ON CONFLICT (name) DO UPDATE
-SET expires_at = EXCLUDED.expires_at
+SET expires_at = EXCLUDED.expires_at, data = EXCLUDED.data
 WHERE locks.expires_at <= NOW()
 RETURNING name, data, expires_at
 `
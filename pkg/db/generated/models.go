@@ -152,6 +152,8 @@ const (
 	AuditLogSourceUnknown AuditLogSource = "unknown"
 	AuditLogSourcePortal  AuditLogSource = "portal"
 	AuditLogSourceApi     AuditLogSource = "api"
+	AuditLogSourceCli     AuditLogSource = "cli"
+	AuditLogSourceSystem  AuditLogSource = "system"
 )
 
 func (e *AuditLogSource) Scan(src interface{}) error {
@@ -189,6 +191,49 @@ func (ns NullAuditLogSource) Value() (driver.Value, error) {
 	return string(ns.AuditLogSource), nil
 }
 
+type BotPolicy string
+
+const (
+	BotPolicyMonitor       BotPolicy = "monitor"
+	BotPolicyMaxDifficulty BotPolicy = "max_difficulty"
+	BotPolicyBlock         BotPolicy = "block"
+)
+
+func (e *BotPolicy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BotPolicy(s)
+	case string:
+		*e = BotPolicy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BotPolicy: %T", src)
+	}
+	return nil
+}
+
+type NullBotPolicy struct {
+	BotPolicy BotPolicy `json:"backend_bot_policy"`
+	Valid     bool      `json:"valid"` // Valid is true if BotPolicy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBotPolicy) Scan(value interface{}) error {
+	if value == nil {
+		ns.BotPolicy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BotPolicy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBotPolicy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BotPolicy), nil
+}
+
 type DifficultyGrowth string
 
 const (
@@ -233,6 +278,220 @@ func (ns NullDifficultyGrowth) Value() (driver.Value, error) {
 	return string(ns.DifficultyGrowth), nil
 }
 
+type DigestFrequency string
+
+const (
+	DigestFrequencyWeekly  DigestFrequency = "weekly"
+	DigestFrequencyMonthly DigestFrequency = "monthly"
+)
+
+func (e *DigestFrequency) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DigestFrequency(s)
+	case string:
+		*e = DigestFrequency(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DigestFrequency: %T", src)
+	}
+	return nil
+}
+
+type NullDigestFrequency struct {
+	DigestFrequency DigestFrequency `json:"backend_digest_frequency"`
+	Valid           bool            `json:"valid"` // Valid is true if DigestFrequency is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDigestFrequency) Scan(value interface{}) error {
+	if value == nil {
+		ns.DigestFrequency, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DigestFrequency.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDigestFrequency) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DigestFrequency), nil
+}
+
+type NotificationSeverity string
+
+const (
+	NotificationSeverityInfo     NotificationSeverity = "info"
+	NotificationSeverityWarning  NotificationSeverity = "warning"
+	NotificationSeverityCritical NotificationSeverity = "critical"
+)
+
+func (e *NotificationSeverity) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationSeverity(s)
+	case string:
+		*e = NotificationSeverity(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationSeverity: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationSeverity struct {
+	NotificationSeverity NotificationSeverity `json:"backend_notification_severity"`
+	Valid                bool                 `json:"valid"` // Valid is true if NotificationSeverity is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationSeverity) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationSeverity, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationSeverity.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationSeverity) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationSeverity), nil
+}
+
+type PropertyDomainStatus string
+
+const (
+	PropertyDomainStatusOk         PropertyDomainStatus = "ok"
+	PropertyDomainStatusUnresolved PropertyDomainStatus = "unresolved"
+	PropertyDomainStatusLoopback   PropertyDomainStatus = "loopback"
+)
+
+func (e *PropertyDomainStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PropertyDomainStatus(s)
+	case string:
+		*e = PropertyDomainStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PropertyDomainStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPropertyDomainStatus struct {
+	PropertyDomainStatus PropertyDomainStatus `json:"backend_property_domain_status"`
+	Valid                bool                 `json:"valid"` // Valid is true if PropertyDomainStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPropertyDomainStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PropertyDomainStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PropertyDomainStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPropertyDomainStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PropertyDomainStatus), nil
+}
+
+type PropertyEnvironment string
+
+const (
+	PropertyEnvironmentProduction PropertyEnvironment = "production"
+	PropertyEnvironmentStaging    PropertyEnvironment = "staging"
+)
+
+func (e *PropertyEnvironment) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PropertyEnvironment(s)
+	case string:
+		*e = PropertyEnvironment(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PropertyEnvironment: %T", src)
+	}
+	return nil
+}
+
+type NullPropertyEnvironment struct {
+	PropertyEnvironment PropertyEnvironment `json:"backend_property_environment"`
+	Valid               bool                `json:"valid"` // Valid is true if PropertyEnvironment is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPropertyEnvironment) Scan(value interface{}) error {
+	if value == nil {
+		ns.PropertyEnvironment, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PropertyEnvironment.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPropertyEnvironment) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PropertyEnvironment), nil
+}
+
+type QuotaStage string
+
+const (
+	QuotaStageNormal   QuotaStage = "normal"
+	QuotaStageWarning  QuotaStage = "warning"
+	QuotaStageDegraded QuotaStage = "degraded"
+	QuotaStageExceeded QuotaStage = "exceeded"
+)
+
+func (e *QuotaStage) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = QuotaStage(s)
+	case string:
+		*e = QuotaStage(s)
+	default:
+		return fmt.Errorf("unsupported scan type for QuotaStage: %T", src)
+	}
+	return nil
+}
+
+type NullQuotaStage struct {
+	QuotaStage QuotaStage `json:"backend_quota_stage"`
+	Valid      bool       `json:"valid"` // Valid is true if QuotaStage is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullQuotaStage) Scan(value interface{}) error {
+	if value == nil {
+		ns.QuotaStage, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.QuotaStage.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullQuotaStage) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.QuotaStage), nil
+}
+
 type SubscriptionSource string
 
 const (
@@ -276,21 +535,33 @@ func (ns NullSubscriptionSource) Value() (driver.Value, error) {
 }
 
 type APIKey struct {
-	ID                int32              `db:"id" json:"id"`
-	Name              string             `db:"name" json:"name"`
-	ExternalID        pgtype.UUID        `db:"external_id" json:"external_id"`
-	UserID            pgtype.Int4        `db:"user_id" json:"user_id"`
-	Enabled           pgtype.Bool        `db:"enabled" json:"enabled"`
-	RequestsPerSecond float64            `db:"requests_per_second" json:"requests_per_second"`
-	RequestsBurst     int32              `db:"requests_burst" json:"requests_burst"`
-	CreatedAt         pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	ExpiresAt         pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
-	Notes             pgtype.Text        `db:"notes" json:"notes"`
-	OrgID             pgtype.Int4        `db:"org_id" json:"org_id"`
-	UpdatedAt         pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
-	Period            time.Duration      `db:"period" json:"period"`
-	Scope             ApiKeyScope        `db:"scope" json:"scope"`
-	Readonly          bool               `db:"readonly" json:"readonly"`
+	ID                 int32              `db:"id" json:"id"`
+	Name               string             `db:"name" json:"name"`
+	ExternalID         pgtype.UUID        `db:"external_id" json:"external_id"`
+	UserID             pgtype.Int4        `db:"user_id" json:"user_id"`
+	Enabled            pgtype.Bool        `db:"enabled" json:"enabled"`
+	RequestsPerSecond  float64            `db:"requests_per_second" json:"requests_per_second"`
+	RequestsBurst      int32              `db:"requests_burst" json:"requests_burst"`
+	CreatedAt          pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ExpiresAt          pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	Notes              pgtype.Text        `db:"notes" json:"notes"`
+	OrgID              pgtype.Int4        `db:"org_id" json:"org_id"`
+	UpdatedAt          pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	Period             time.Duration      `db:"period" json:"period"`
+	Scope              ApiKeyScope        `db:"scope" json:"scope"`
+	Readonly           bool               `db:"readonly" json:"readonly"`
+	PreviousExternalID pgtype.UUID        `db:"previous_external_id" json:"previous_external_id"`
+	PreviousExpiresAt  pgtype.Timestamptz `db:"previous_expires_at" json:"previous_expires_at"`
+	AllowedCidrs       []string           `db:"allowed_cidrs" json:"allowed_cidrs"`
+	IpViolations       int64              `db:"ip_violations" json:"ip_violations"`
+	LastIpViolationAt  pgtype.Timestamptz `db:"last_ip_violation_at" json:"last_ip_violation_at"`
+}
+
+type ApikeyUsage struct {
+	ApikeyID   int32              `db:"apikey_id" json:"apikey_id"`
+	Day        pgtype.Date        `db:"day" json:"day"`
+	Count      int64              `db:"count" json:"count"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
 }
 
 type AsyncTask struct {
@@ -317,6 +588,31 @@ type AuditLog struct {
 	NewValue    []byte             `db:"new_value" json:"new_value"`
 	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	Source      AuditLogSource     `db:"source" json:"source"`
+	TraceID     string             `db:"trace_id" json:"trace_id"`
+}
+
+type BackendRequestStats5m struct {
+	UserID     int32              `db:"user_id" json:"user_id"`
+	OrgID      int32              `db:"org_id" json:"org_id"`
+	PropertyID int32              `db:"property_id" json:"property_id"`
+	Timestamp  pgtype.Timestamptz `db:"timestamp" json:"timestamp"`
+	Count      int64              `db:"count" json:"count"`
+}
+
+type BackendVerifyStats5m struct {
+	UserID       int32              `db:"user_id" json:"user_id"`
+	OrgID        int32              `db:"org_id" json:"org_id"`
+	PropertyID   int32              `db:"property_id" json:"property_id"`
+	Timestamp    pgtype.Timestamptz `db:"timestamp" json:"timestamp"`
+	SuccessCount int64              `db:"success_count" json:"success_count"`
+	FailureCount int64              `db:"failure_count" json:"failure_count"`
+}
+
+type BillingContact struct {
+	ID        int32              `db:"id" json:"id"`
+	OrgID     int32              `db:"org_id" json:"org_id"`
+	Email     string             `db:"email" json:"email"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 }
 
 type Cache struct {
@@ -334,12 +630,60 @@ type DeletedRecord struct {
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
+type DifficultyExperiment struct {
+	ID             int32              `db:"id" json:"id"`
+	PropertyID     int32              `db:"property_id" json:"property_id"`
+	OrgID          int32              `db:"org_id" json:"org_id"`
+	ControlLevel   int16              `db:"control_level" json:"control_level"`
+	VariantLevel   int16              `db:"variant_level" json:"variant_level"`
+	VariantWeight  int16              `db:"variant_weight" json:"variant_weight"`
+	MinSamples     int32              `db:"min_samples" json:"min_samples"`
+	MaxDegradation float32            `db:"max_degradation" json:"max_degradation"`
+	CreatedAt      pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	EndsAt         pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	ConcludedAt    pgtype.Timestamptz `db:"concluded_at" json:"concluded_at"`
+	Outcome        string             `db:"outcome" json:"outcome"`
+	Conclusion     string             `db:"conclusion" json:"conclusion"`
+}
+
+type LimitDecision struct {
+	ID                 int64              `db:"id" json:"id"`
+	UserID             pgtype.Int4        `db:"user_id" json:"user_id"`
+	OrgID              pgtype.Int4        `db:"org_id" json:"org_id"`
+	OrgOwnerID         pgtype.Int4        `db:"org_owner_id" json:"org_owner_id"`
+	Resource           string             `db:"resource" json:"resource"`
+	StatusCode         int32              `db:"status_code" json:"status_code"`
+	Source             AuditLogSource     `db:"source" json:"source"`
+	SubscriptionID     pgtype.Int4        `db:"subscription_id" json:"subscription_id"`
+	SubscriptionStatus string             `db:"subscription_status" json:"subscription_status"`
+	ExternalProductID  string             `db:"external_product_id" json:"external_product_id"`
+	ExternalPriceID    string             `db:"external_price_id" json:"external_price_id"`
+	CurrentCount       int32              `db:"current_count" json:"current_count"`
+	RequestedCount     int32              `db:"requested_count" json:"requested_count"`
+	PlanLimit          int32              `db:"plan_limit" json:"plan_limit"`
+	Error              string             `db:"error" json:"error"`
+	CreatedAt          pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
 type Lock struct {
 	Name      string             `db:"name" json:"name"`
 	Data      []byte             `db:"data" json:"data"`
 	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
 }
 
+type NotificationPayload struct {
+	Hash      string             `db:"hash" json:"hash"`
+	Payload   []byte             `db:"payload" json:"payload"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type NotificationPreference struct {
+	UserID       int32              `db:"user_id" json:"user_id"`
+	TemplateName string             `db:"template_name" json:"template_name"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
 type NotificationTemplate struct {
 	ID          int32              `db:"id" json:"id"`
 	Name        string             `db:"name" json:"name"`
@@ -350,6 +694,29 @@ type NotificationTemplate struct {
 	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
+type OrgBillingSetting struct {
+	OrgID       int32              `db:"org_id" json:"org_id"`
+	NotifyOwner bool               `db:"notify_owner" json:"notify_owner"`
+	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type OrgEmailDomain struct {
+	ID         int32              `db:"id" json:"id"`
+	OrgID      int32              `db:"org_id" json:"org_id"`
+	Domain     string             `db:"domain" json:"domain"`
+	Token      string             `db:"token" json:"token"`
+	AutoJoin   bool               `db:"auto_join" json:"auto_join"`
+	VerifiedAt pgtype.Timestamptz `db:"verified_at" json:"verified_at"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type OrgIPAllowlist struct {
+	OrgID     int32              `db:"org_id" json:"org_id"`
+	Cidrs     []string           `db:"cidrs" json:"cidrs"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
 type Organization struct {
 	ID        int32              `db:"id" json:"id"`
 	Name      string             `db:"name" json:"name"`
@@ -357,6 +724,7 @@ type Organization struct {
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 	DeletedAt pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
+	Sandbox   bool               `db:"sandbox" json:"sandbox"`
 }
 
 type OrganizationUser struct {
@@ -368,23 +736,93 @@ type OrganizationUser struct {
 }
 
 type Property struct {
-	ID               int32              `db:"id" json:"id"`
-	Name             string             `db:"name" json:"name"`
-	ExternalID       pgtype.UUID        `db:"external_id" json:"external_id"`
-	OrgID            pgtype.Int4        `db:"org_id" json:"org_id"`
-	CreatorID        pgtype.Int4        `db:"creator_id" json:"creator_id"`
-	OrgOwnerID       pgtype.Int4        `db:"org_owner_id" json:"org_owner_id"`
-	Domain           string             `db:"domain" json:"domain"`
-	Level            pgtype.Int2        `db:"level" json:"level"`
-	Salt             []byte             `db:"salt" json:"salt"`
-	Growth           DifficultyGrowth   `db:"growth" json:"growth"`
-	CreatedAt        pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt        pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
-	DeletedAt        pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
-	ValidityInterval time.Duration      `db:"validity_interval" json:"validity_interval"`
-	AllowSubdomains  bool               `db:"allow_subdomains" json:"allow_subdomains"`
-	AllowLocalhost   bool               `db:"allow_localhost" json:"allow_localhost"`
-	MaxReplayCount   int32              `db:"max_replay_count" json:"max_replay_count"`
+	ID                     int32                `db:"id" json:"id"`
+	Name                   string               `db:"name" json:"name"`
+	ExternalID             pgtype.UUID          `db:"external_id" json:"external_id"`
+	OrgID                  pgtype.Int4          `db:"org_id" json:"org_id"`
+	CreatorID              pgtype.Int4          `db:"creator_id" json:"creator_id"`
+	OrgOwnerID             pgtype.Int4          `db:"org_owner_id" json:"org_owner_id"`
+	Domain                 string               `db:"domain" json:"domain"`
+	Level                  pgtype.Int2          `db:"level" json:"level"`
+	Salt                   []byte               `db:"salt" json:"salt"`
+	Growth                 DifficultyGrowth     `db:"growth" json:"growth"`
+	CreatedAt              pgtype.Timestamptz   `db:"created_at" json:"created_at"`
+	UpdatedAt              pgtype.Timestamptz   `db:"updated_at" json:"updated_at"`
+	DeletedAt              pgtype.Timestamptz   `db:"deleted_at" json:"deleted_at"`
+	ValidityInterval       time.Duration        `db:"validity_interval" json:"validity_interval"`
+	AllowSubdomains        bool                 `db:"allow_subdomains" json:"allow_subdomains"`
+	AllowLocalhost         bool                 `db:"allow_localhost" json:"allow_localhost"`
+	MaxReplayCount         int32                `db:"max_replay_count" json:"max_replay_count"`
+	AllowedClockSkew       time.Duration        `db:"allowed_clock_skew" json:"allowed_clock_skew"`
+	RememberWindow         time.Duration        `db:"remember_window" json:"remember_window"`
+	WidgetFlags            int16                `db:"widget_flags" json:"widget_flags"`
+	Environment            PropertyEnvironment  `db:"environment" json:"environment"`
+	TwinID                 pgtype.Int4          `db:"twin_id" json:"twin_id"`
+	TrustGroup             string               `db:"trust_group" json:"trust_group"`
+	Claims                 string               `db:"claims" json:"claims"`
+	DifferentialDifficulty bool                 `db:"differential_difficulty" json:"differential_difficulty"`
+	DomainStatus           PropertyDomainStatus `db:"domain_status" json:"domain_status"`
+	DomainCheckedAt        pgtype.Timestamptz   `db:"domain_checked_at" json:"domain_checked_at"`
+	BotPolicy              BotPolicy            `db:"bot_policy" json:"bot_policy"`
+	FailureURL             string               `db:"failure_url" json:"failure_url"`
+	FailureMessage         string               `db:"failure_message" json:"failure_message"`
+	EmergencyUntil         pgtype.Timestamptz   `db:"emergency_until" json:"emergency_until"`
+	DifficultyStrategy     string               `db:"difficulty_strategy" json:"difficulty_strategy"`
+	PrivacyMode            string               `db:"privacy_mode" json:"privacy_mode"`
+}
+
+type PropertyAccessList struct {
+	PropertyID  int32              `db:"property_id" json:"property_id"`
+	IpAllowlist []string           `db:"ip_allowlist" json:"ip_allowlist"`
+	IpDenylist  []string           `db:"ip_denylist" json:"ip_denylist"`
+	AsnDenylist []int64            `db:"asn_denylist" json:"asn_denylist"`
+	Policy      BotPolicy          `db:"policy" json:"policy"`
+	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type PropertyBaseline struct {
+	PropertyID          int32              `db:"property_id" json:"property_id"`
+	Weekday             int16              `db:"weekday" json:"weekday"`
+	Hour                int16              `db:"hour" json:"hour"`
+	Samples             int32              `db:"samples" json:"samples"`
+	RequestsMean        float64            `db:"requests_mean" json:"requests_mean"`
+	RequestsVariance    float64            `db:"requests_variance" json:"requests_variance"`
+	SuccessSamples      int32              `db:"success_samples" json:"success_samples"`
+	SuccessRateMean     float64            `db:"success_rate_mean" json:"success_rate_mean"`
+	SuccessRateVariance float64            `db:"success_rate_variance" json:"success_rate_variance"`
+	UpdatedAt           pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type PropertyBypassToken struct {
+	ID         int32              `db:"id" json:"id"`
+	PropertyID int32              `db:"property_id" json:"property_id"`
+	CreatorID  pgtype.Int4        `db:"creator_id" json:"creator_id"`
+	Name       string             `db:"name" json:"name"`
+	SecretHash []byte             `db:"secret_hash" json:"secret_hash"`
+	MaxUses    int32              `db:"max_uses" json:"max_uses"`
+	Uses       int32              `db:"uses" json:"uses"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type PropertyShareLink struct {
+	ID         int32              `db:"id" json:"id"`
+	ExternalID pgtype.UUID        `db:"external_id" json:"external_id"`
+	PropertyID int32              `db:"property_id" json:"property_id"`
+	CreatorID  pgtype.Int4        `db:"creator_id" json:"creator_id"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	RevokedAt  pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
+type StatsDigest struct {
+	UserID    int32              `db:"user_id" json:"user_id"`
+	OrgID     int32              `db:"org_id" json:"org_id"`
+	Frequency DigestFrequency    `db:"frequency" json:"frequency"`
+	SentUntil pgtype.Timestamptz `db:"sent_until" json:"sent_until"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
 type Subscription struct {
@@ -404,12 +842,17 @@ type Subscription struct {
 }
 
 type SystemNotification struct {
-	ID        int32              `db:"id" json:"id"`
-	Message   string             `db:"message" json:"message"`
-	StartDate pgtype.Timestamptz `db:"start_date" json:"start_date"`
-	EndDate   pgtype.Timestamptz `db:"end_date" json:"end_date"`
-	UserID    pgtype.Int4        `db:"user_id" json:"user_id"`
-	IsActive  pgtype.Bool        `db:"is_active" json:"is_active"`
+	ID                int32                `db:"id" json:"id"`
+	Message           string               `db:"message" json:"message"`
+	StartDate         pgtype.Timestamptz   `db:"start_date" json:"start_date"`
+	EndDate           pgtype.Timestamptz   `db:"end_date" json:"end_date"`
+	UserID            pgtype.Int4          `db:"user_id" json:"user_id"`
+	IsActive          pgtype.Bool          `db:"is_active" json:"is_active"`
+	Severity          NotificationSeverity `db:"severity" json:"severity"`
+	IsMarkdown        bool                 `db:"is_markdown" json:"is_markdown"`
+	OrgID             pgtype.Int4          `db:"org_id" json:"org_id"`
+	ExternalProductID pgtype.Text          `db:"external_product_id" json:"external_product_id"`
+	Stage             pgtype.Text          `db:"stage" json:"stage"`
 }
 
 type User struct {
@@ -422,6 +865,25 @@ type User struct {
 	DeletedAt      pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
 }
 
+type UserDevice struct {
+	ID         int32              `db:"id" json:"id"`
+	UserID     int32              `db:"user_id" json:"user_id"`
+	Browser    string             `db:"browser" json:"browser"`
+	Os         string             `db:"os" json:"os"`
+	Country    string             `db:"country" json:"country"`
+	SessionID  string             `db:"session_id" json:"session_id"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	LastSeenAt pgtype.Timestamptz `db:"last_seen_at" json:"last_seen_at"`
+}
+
+type UserLocale struct {
+	UserID    int32              `db:"user_id" json:"user_id"`
+	Timezone  pgtype.Text        `db:"timezone" json:"timezone"`
+	Country   pgtype.Text        `db:"country" json:"country"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
 type UserNotification struct {
 	ID                   int32              `db:"id" json:"id"`
 	UserID               pgtype.Int4        `db:"user_id" json:"user_id"`
@@ -436,4 +898,38 @@ type UserNotification struct {
 	UpdatedAt            pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 	ScheduledAt          pgtype.Timestamptz `db:"scheduled_at" json:"scheduled_at"`
 	ProcessedAt          pgtype.Timestamptz `db:"processed_at" json:"processed_at"`
+	SuppressedAt         pgtype.Timestamptz `db:"suppressed_at" json:"suppressed_at"`
+	PayloadHash          pgtype.Text        `db:"payload_hash" json:"payload_hash"`
+	OrgID                pgtype.Int4        `db:"org_id" json:"org_id"`
+}
+
+type UserOrgSummary struct {
+	UserID    int32              `db:"user_id" json:"user_id"`
+	Summary   []byte             `db:"summary" json:"summary"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type UserQuota struct {
+	UserID        int32              `db:"user_id" json:"user_id"`
+	PeriodStart   pgtype.Timestamptz `db:"period_start" json:"period_start"`
+	Requests      int64              `db:"requests" json:"requests"`
+	RequestsLimit int64              `db:"requests_limit" json:"requests_limit"`
+	Stage         QuotaStage         `db:"stage" json:"stage"`
+	CreatedAt     pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type UserSSOLink struct {
+	ID        int32              `db:"id" json:"id"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	Issuer    string             `db:"issuer" json:"issuer"`
+	Subject   string             `db:"subject" json:"subject"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type UserSession struct {
+	SessionID string             `db:"session_id" json:"session_id"`
+	UserID    int32              `db:"user_id" json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
 }
@@ -11,6 +11,62 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelSystemNotification = `-- name: CancelSystemNotification :one
+UPDATE backend.system_notifications SET is_active = FALSE WHERE id = $1 AND is_active = TRUE RETURNING id, message, start_date, end_date, user_id, is_active, severity, is_markdown, org_id, external_product_id, stage
+`
+
+func (q *Queries) CancelSystemNotification(ctx context.Context, id int32) (*SystemNotification, error) {
+	row := q.db.QueryRow(ctx, cancelSystemNotification, id)
+	var i SystemNotification
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.StartDate,
+		&i.EndDate,
+		&i.UserID,
+		&i.IsActive,
+		&i.Severity,
+		&i.IsMarkdown,
+		&i.OrgID,
+		&i.ExternalProductID,
+		&i.Stage,
+	)
+	return &i, err
+}
+
+const createNotificationOptOut = `-- name: CreateNotificationOptOut :exec
+INSERT INTO backend.notification_preferences (user_id, template_name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, template_name) DO NOTHING
+`
+
+type CreateNotificationOptOutParams struct {
+	UserID       int32  `db:"user_id" json:"user_id"`
+	TemplateName string `db:"template_name" json:"template_name"`
+}
+
+func (q *Queries) CreateNotificationOptOut(ctx context.Context, arg *CreateNotificationOptOutParams) error {
+	_, err := q.db.Exec(ctx, createNotificationOptOut, arg.UserID, arg.TemplateName)
+	return err
+}
+
+const createNotificationPayload = `-- name: CreateNotificationPayload :exec
+INSERT INTO backend.notification_payloads (hash, payload)
+VALUES ($1, $2)
+ON CONFLICT (hash) DO UPDATE SET updated_at = NOW()
+WHERE backend.notification_payloads.updated_at < NOW() - INTERVAL '1 hour'
+`
+
+type CreateNotificationPayloadParams struct {
+	Hash    string `db:"hash" json:"hash"`
+	Payload []byte `db:"payload" json:"payload"`
+}
+
+func (q *Queries) CreateNotificationPayload(ctx context.Context, arg *CreateNotificationPayloadParams) error {
+	_, err := q.db.Exec(ctx, createNotificationPayload, arg.Hash, arg.Payload)
+	return err
+}
+
 const createNotificationTemplate = `-- name: CreateNotificationTemplate :one
 INSERT INTO backend.notification_templates (name, content_html, content_text, external_id)
 VALUES ($1, $2, $3, $4)
@@ -46,16 +102,21 @@ func (q *Queries) CreateNotificationTemplate(ctx context.Context, arg *CreateNot
 }
 
 const createSystemNotification = `-- name: CreateSystemNotification :one
-INSERT INTO backend.system_notifications (message, start_date, end_date, user_id)
-VALUES ($1, $2, $3, $4)
-RETURNING id, message, start_date, end_date, user_id, is_active
+INSERT INTO backend.system_notifications (message, start_date, end_date, user_id, severity, is_markdown, org_id, external_product_id, stage)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, message, start_date, end_date, user_id, is_active, severity, is_markdown, org_id, external_product_id, stage
 `
 
 type CreateSystemNotificationParams struct {
-	Message   string             `db:"message" json:"message"`
-	StartDate pgtype.Timestamptz `db:"start_date" json:"start_date"`
-	EndDate   pgtype.Timestamptz `db:"end_date" json:"end_date"`
-	UserID    pgtype.Int4        `db:"user_id" json:"user_id"`
+	Message           string               `db:"message" json:"message"`
+	StartDate         pgtype.Timestamptz   `db:"start_date" json:"start_date"`
+	EndDate           pgtype.Timestamptz   `db:"end_date" json:"end_date"`
+	UserID            pgtype.Int4          `db:"user_id" json:"user_id"`
+	Severity          NotificationSeverity `db:"severity" json:"severity"`
+	IsMarkdown        bool                 `db:"is_markdown" json:"is_markdown"`
+	OrgID             pgtype.Int4          `db:"org_id" json:"org_id"`
+	ExternalProductID pgtype.Text          `db:"external_product_id" json:"external_product_id"`
+	Stage             pgtype.Text          `db:"stage" json:"stage"`
 }
 
 func (q *Queries) CreateSystemNotification(ctx context.Context, arg *CreateSystemNotificationParams) (*SystemNotification, error) {
@@ -64,6 +125,11 @@ func (q *Queries) CreateSystemNotification(ctx context.Context, arg *CreateSyste
 		arg.StartDate,
 		arg.EndDate,
 		arg.UserID,
+		arg.Severity,
+		arg.IsMarkdown,
+		arg.OrgID,
+		arg.ExternalProductID,
+		arg.Stage,
 	)
 	var i SystemNotification
 	err := row.Scan(
@@ -73,14 +139,19 @@ func (q *Queries) CreateSystemNotification(ctx context.Context, arg *CreateSyste
 		&i.EndDate,
 		&i.UserID,
 		&i.IsActive,
+		&i.Severity,
+		&i.IsMarkdown,
+		&i.OrgID,
+		&i.ExternalProductID,
+		&i.Stage,
 	)
 	return &i, err
 }
 
 const createUserNotification = `-- name: CreateUserNotification :one
-INSERT INTO backend.user_notifications (user_id, reference_id, template_id, subject, payload, scheduled_at, persistent, requires_subscription)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, user_id, template_id, payload, subject, reference_id, processing_attempts, persistent, requires_subscription, created_at, updated_at, scheduled_at, processed_at
+INSERT INTO backend.user_notifications (user_id, reference_id, template_id, subject, payload_hash, scheduled_at, persistent, requires_subscription, org_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, template_id, payload, subject, reference_id, processing_attempts, persistent, requires_subscription, created_at, updated_at, scheduled_at, processed_at, suppressed_at, payload_hash, org_id
 `
 
 type CreateUserNotificationParams struct {
@@ -88,10 +159,11 @@ type CreateUserNotificationParams struct {
 	ReferenceID          string             `db:"reference_id" json:"reference_id"`
 	TemplateID           pgtype.Text        `db:"template_id" json:"template_id"`
 	Subject              string             `db:"subject" json:"subject"`
-	Payload              []byte             `db:"payload" json:"payload"`
+	PayloadHash          pgtype.Text        `db:"payload_hash" json:"payload_hash"`
 	ScheduledAt          pgtype.Timestamptz `db:"scheduled_at" json:"scheduled_at"`
 	Persistent           bool               `db:"persistent" json:"persistent"`
 	RequiresSubscription pgtype.Bool        `db:"requires_subscription" json:"requires_subscription"`
+	OrgID                pgtype.Int4        `db:"org_id" json:"org_id"`
 }
 
 func (q *Queries) CreateUserNotification(ctx context.Context, arg *CreateUserNotificationParams) (*UserNotification, error) {
@@ -100,10 +172,11 @@ func (q *Queries) CreateUserNotification(ctx context.Context, arg *CreateUserNot
 		arg.ReferenceID,
 		arg.TemplateID,
 		arg.Subject,
-		arg.Payload,
+		arg.PayloadHash,
 		arg.ScheduledAt,
 		arg.Persistent,
 		arg.RequiresSubscription,
+		arg.OrgID,
 	)
 	var i UserNotification
 	err := row.Scan(
@@ -120,10 +193,27 @@ func (q *Queries) CreateUserNotification(ctx context.Context, arg *CreateUserNot
 		&i.UpdatedAt,
 		&i.ScheduledAt,
 		&i.ProcessedAt,
+		&i.SuppressedAt,
+		&i.PayloadHash,
+		&i.OrgID,
 	)
 	return &i, err
 }
 
+const deleteNotificationOptOut = `-- name: DeleteNotificationOptOut :exec
+DELETE FROM backend.notification_preferences WHERE user_id = $1 AND template_name = $2
+`
+
+type DeleteNotificationOptOutParams struct {
+	UserID       int32  `db:"user_id" json:"user_id"`
+	TemplateName string `db:"template_name" json:"template_name"`
+}
+
+func (q *Queries) DeleteNotificationOptOut(ctx context.Context, arg *DeleteNotificationOptOutParams) error {
+	_, err := q.db.Exec(ctx, deleteNotificationOptOut, arg.UserID, arg.TemplateName)
+	return err
+}
+
 const deletePendingUserNotification = `-- name: DeletePendingUserNotification :exec
 DELETE FROM backend.user_notifications WHERE processed_at IS NULL AND user_id = $1 AND reference_id = $2
 `
@@ -162,6 +252,17 @@ func (q *Queries) DeleteUnprocessedUserNotifications(ctx context.Context, schedu
 	return err
 }
 
+const deleteUnusedNotificationPayloads = `-- name: DeleteUnusedNotificationPayloads :exec
+DELETE FROM backend.notification_payloads np
+WHERE np.updated_at < $1
+AND NOT EXISTS (SELECT 1 FROM backend.user_notifications un WHERE un.payload_hash = np.hash)
+`
+
+func (q *Queries) DeleteUnusedNotificationPayloads(ctx context.Context, updatedAt pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, deleteUnusedNotificationPayloads, updatedAt)
+	return err
+}
+
 const deleteUnusedNotificationTemplates = `-- name: DeleteUnusedNotificationTemplates :exec
 DELETE FROM backend.notification_templates nt
 WHERE nt.id IN (
@@ -184,24 +285,33 @@ func (q *Queries) DeleteUnusedNotificationTemplates(ctx context.Context, arg *De
 }
 
 const getLastActiveSystemNotification = `-- name: GetLastActiveSystemNotification :one
-SELECT id, message, start_date, end_date, user_id, is_active FROM backend.system_notifications
- WHERE is_active = TRUE AND
-   start_date <= $1::timestamptz AND
-   (end_date IS NULL OR end_date > $1::timestamptz) AND
-   (user_id = $2 OR user_id IS NULL)
+SELECT n.id, n.message, n.start_date, n.end_date, n.user_id, n.is_active, n.severity, n.is_markdown, n.org_id, n.external_product_id, n.stage FROM backend.system_notifications n
+ WHERE n.is_active = TRUE AND
+   n.start_date <= $1::timestamptz AND
+   (n.end_date IS NULL OR n.end_date > $1::timestamptz) AND
+   (n.user_id = $2 OR n.user_id IS NULL) AND
+   (n.stage IS NULL OR n.stage = $3) AND
+   (n.org_id IS NULL OR n.org_id IN (
+     SELECT o.id FROM backend.organizations o WHERE o.user_id = $2 AND o.deleted_at IS NULL
+     UNION
+     SELECT ou.org_id FROM backend.organization_users ou WHERE ou.user_id = $2 AND ou.level <> 'invited')) AND
+   (n.external_product_id IS NULL OR n.external_product_id = (
+     SELECT s.external_product_id FROM backend.users u JOIN backend.subscriptions s ON u.subscription_id = s.id WHERE u.id = $2))
  ORDER BY
-   CASE WHEN user_id = $2 THEN 0 ELSE 1 END,
-   start_date DESC
+   CASE WHEN n.user_id = $2 THEN 0 ELSE 1 END,
+   n.severity DESC,
+   n.start_date DESC
  LIMIT 1
 `
 
 type GetLastActiveSystemNotificationParams struct {
 	Column1 pgtype.Timestamptz `db:"column_1" json:"column_1"`
 	UserID  pgtype.Int4        `db:"user_id" json:"user_id"`
+	Stage   pgtype.Text        `db:"stage" json:"stage"`
 }
 
 func (q *Queries) GetLastActiveSystemNotification(ctx context.Context, arg *GetLastActiveSystemNotificationParams) (*SystemNotification, error) {
-	row := q.db.QueryRow(ctx, getLastActiveSystemNotification, arg.Column1, arg.UserID)
+	row := q.db.QueryRow(ctx, getLastActiveSystemNotification, arg.Column1, arg.UserID, arg.Stage)
 	var i SystemNotification
 	err := row.Scan(
 		&i.ID,
@@ -210,10 +320,39 @@ func (q *Queries) GetLastActiveSystemNotification(ctx context.Context, arg *GetL
 		&i.EndDate,
 		&i.UserID,
 		&i.IsActive,
+		&i.Severity,
+		&i.IsMarkdown,
+		&i.OrgID,
+		&i.ExternalProductID,
+		&i.Stage,
 	)
 	return &i, err
 }
 
+const getNotificationOptOutsForUsers = `-- name: GetNotificationOptOutsForUsers :many
+SELECT user_id, template_name, created_at FROM backend.notification_preferences WHERE user_id = ANY($1::INT[])
+`
+
+func (q *Queries) GetNotificationOptOutsForUsers(ctx context.Context, dollar_1 []int32) ([]*NotificationPreference, error) {
+	rows, err := q.db.Query(ctx, getNotificationOptOutsForUsers, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*NotificationPreference
+	for rows.Next() {
+		var i NotificationPreference
+		if err := rows.Scan(&i.UserID, &i.TemplateName, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNotificationTemplateByHash = `-- name: GetNotificationTemplateByHash :one
 SELECT id, name, external_id, content_html, content_text, created_at, updated_at FROM backend.notification_templates WHERE external_id = $1
 `
@@ -234,10 +373,12 @@ func (q *Queries) GetNotificationTemplateByHash(ctx context.Context, externalID
 }
 
 const getPendingUserNotifications = `-- name: GetPendingUserNotifications :many
-SELECT un.id, un.user_id, un.template_id, un.payload, un.subject, un.reference_id, un.processing_attempts, un.persistent, un.requires_subscription, un.created_at, un.updated_at, un.scheduled_at, un.processed_at, u.email, u.subscription_id, s.status
+SELECT un.id, un.user_id, un.template_id, un.payload, un.subject, un.reference_id, un.processing_attempts, un.persistent, un.requires_subscription, un.created_at, un.updated_at, un.scheduled_at, un.processed_at, un.suppressed_at, un.payload_hash, un.org_id, u.email, u.subscription_id, s.status, np.payload AS shared_payload, ul.timezone, ul.country
 FROM backend.user_notifications un
 JOIN backend.users u ON un.user_id = u.id
 LEFT JOIN backend.subscriptions s ON u.subscription_id = s.id
+LEFT JOIN backend.notification_payloads np ON un.payload_hash = np.hash
+LEFT JOIN backend.user_locales ul ON un.user_id = ul.user_id
 WHERE un.processed_at IS NULL
   AND un.scheduled_at >= $1
   AND un.scheduled_at <= NOW()
@@ -259,6 +400,9 @@ type GetPendingUserNotificationsRow struct {
 	Email            string           `db:"email" json:"email"`
 	SubscriptionID   pgtype.Int4      `db:"subscription_id" json:"subscription_id"`
 	Status           pgtype.Text      `db:"status" json:"status"`
+	SharedPayload    []byte           `db:"shared_payload" json:"shared_payload"`
+	Timezone         pgtype.Text      `db:"timezone" json:"timezone"`
+	Country          pgtype.Text      `db:"country" json:"country"`
 }
 
 func (q *Queries) GetPendingUserNotifications(ctx context.Context, arg *GetPendingUserNotificationsParams) ([]*GetPendingUserNotificationsRow, error) {
@@ -284,9 +428,15 @@ func (q *Queries) GetPendingUserNotifications(ctx context.Context, arg *GetPendi
 			&i.UserNotification.UpdatedAt,
 			&i.UserNotification.ScheduledAt,
 			&i.UserNotification.ProcessedAt,
+			&i.UserNotification.SuppressedAt,
+			&i.UserNotification.PayloadHash,
+			&i.UserNotification.OrgID,
 			&i.Email,
 			&i.SubscriptionID,
 			&i.Status,
+			&i.SharedPayload,
+			&i.Timezone,
+			&i.Country,
 		); err != nil {
 			return nil, err
 		}
@@ -298,8 +448,86 @@ func (q *Queries) GetPendingUserNotifications(ctx context.Context, arg *GetPendi
 	return items, nil
 }
 
+const getScheduledSystemNotifications = `-- name: GetScheduledSystemNotifications :many
+SELECT id, message, start_date, end_date, user_id, is_active, severity, is_markdown, org_id, external_product_id, stage FROM backend.system_notifications
+WHERE is_active = TRUE AND (end_date IS NULL OR end_date > $1)
+ORDER BY start_date ASC
+`
+
+func (q *Queries) GetScheduledSystemNotifications(ctx context.Context, endDate pgtype.Timestamptz) ([]*SystemNotification, error) {
+	rows, err := q.db.Query(ctx, getScheduledSystemNotifications, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*SystemNotification
+	for rows.Next() {
+		var i SystemNotification
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.StartDate,
+			&i.EndDate,
+			&i.UserID,
+			&i.IsActive,
+			&i.Severity,
+			&i.IsMarkdown,
+			&i.OrgID,
+			&i.ExternalProductID,
+			&i.Stage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSentUserNotificationsCounts = `-- name: GetSentUserNotificationsCounts :many
+SELECT user_id, COUNT(*) AS count
+FROM backend.user_notifications
+WHERE processed_at >= $1
+AND processed_at < $2
+AND suppressed_at IS NULL
+AND user_id IS NOT NULL
+GROUP BY user_id
+`
+
+type GetSentUserNotificationsCountsParams struct {
+	ProcessedAt   pgtype.Timestamptz `db:"processed_at" json:"processed_at"`
+	ProcessedAt_2 pgtype.Timestamptz `db:"processed_at_2" json:"processed_at_2"`
+}
+
+type GetSentUserNotificationsCountsRow struct {
+	UserID pgtype.Int4 `db:"user_id" json:"user_id"`
+	Count  int64       `db:"count" json:"count"`
+}
+
+func (q *Queries) GetSentUserNotificationsCounts(ctx context.Context, arg *GetSentUserNotificationsCountsParams) ([]*GetSentUserNotificationsCountsRow, error) {
+	rows, err := q.db.Query(ctx, getSentUserNotificationsCounts, arg.ProcessedAt, arg.ProcessedAt_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetSentUserNotificationsCountsRow
+	for rows.Next() {
+		var i GetSentUserNotificationsCountsRow
+		if err := rows.Scan(&i.UserID, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSystemNotificationById = `-- name: GetSystemNotificationById :one
-SELECT id, message, start_date, end_date, user_id, is_active FROM backend.system_notifications WHERE id = $1
+SELECT id, message, start_date, end_date, user_id, is_active, severity, is_markdown, org_id, external_product_id, stage FROM backend.system_notifications WHERE id = $1
 `
 
 func (q *Queries) GetSystemNotificationById(ctx context.Context, id int32) (*SystemNotification, error) {
@@ -312,10 +540,85 @@ func (q *Queries) GetSystemNotificationById(ctx context.Context, id int32) (*Sys
 		&i.EndDate,
 		&i.UserID,
 		&i.IsActive,
+		&i.Severity,
+		&i.IsMarkdown,
+		&i.OrgID,
+		&i.ExternalProductID,
+		&i.Stage,
 	)
 	return &i, err
 }
 
+const getUserNotificationOptOuts = `-- name: GetUserNotificationOptOuts :many
+SELECT template_name FROM backend.notification_preferences WHERE user_id = $1 ORDER BY template_name
+`
+
+func (q *Queries) GetUserNotificationOptOuts(ctx context.Context, userID int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, getUserNotificationOptOuts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var template_name string
+		if err := rows.Scan(&template_name); err != nil {
+			return nil, err
+		}
+		items = append(items, template_name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserNotifications = `-- name: GetUserNotifications :many
+SELECT id, user_id, template_id, payload, subject, reference_id, processing_attempts, persistent, requires_subscription, created_at, updated_at, scheduled_at, processed_at, suppressed_at, payload_hash, org_id FROM backend.user_notifications WHERE user_id = $1 ORDER BY scheduled_at DESC LIMIT $2
+`
+
+type GetUserNotificationsParams struct {
+	UserID pgtype.Int4 `db:"user_id" json:"user_id"`
+	Limit  int32       `db:"limit" json:"limit"`
+}
+
+func (q *Queries) GetUserNotifications(ctx context.Context, arg *GetUserNotificationsParams) ([]*UserNotification, error) {
+	rows, err := q.db.Query(ctx, getUserNotifications, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*UserNotification
+	for rows.Next() {
+		var i UserNotification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.TemplateID,
+			&i.Payload,
+			&i.Subject,
+			&i.ReferenceID,
+			&i.ProcessingAttempts,
+			&i.Persistent,
+			&i.RequiresSubscription,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ScheduledAt,
+			&i.ProcessedAt,
+			&i.SuppressedAt,
+			&i.PayloadHash,
+			&i.OrgID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateAttemptedUserNotifications = `-- name: UpdateAttemptedUserNotifications :exec
 UPDATE backend.user_notifications SET
   processing_attempts = processing_attempts + 1,
@@ -345,3 +648,38 @@ func (q *Queries) UpdateProcessedUserNotifications(ctx context.Context, arg *Upd
 	_, err := q.db.Exec(ctx, updateProcessedUserNotifications, arg.ProcessedAt, arg.Column2)
 	return err
 }
+
+const updateSuppressedUserNotifications = `-- name: UpdateSuppressedUserNotifications :exec
+UPDATE backend.user_notifications SET
+  processed_at = $1,
+  suppressed_at = $1,
+  updated_at = NOW()
+WHERE id = ANY($2::INT[])
+`
+
+type UpdateSuppressedUserNotificationsParams struct {
+	ProcessedAt pgtype.Timestamptz `db:"processed_at" json:"processed_at"`
+	Column2     []int32            `db:"column_2" json:"column_2"`
+}
+
+func (q *Queries) UpdateSuppressedUserNotifications(ctx context.Context, arg *UpdateSuppressedUserNotificationsParams) error {
+	_, err := q.db.Exec(ctx, updateSuppressedUserNotifications, arg.ProcessedAt, arg.Column2)
+	return err
+}
+
+const updateUserNotificationsSchedule = `-- name: UpdateUserNotificationsSchedule :exec
+UPDATE backend.user_notifications un
+SET scheduled_at = s.scheduled_at, updated_at = NOW()
+FROM (SELECT unnest($1::INT[]) AS id, unnest($2::TIMESTAMPTZ[]) AS scheduled_at) AS s
+WHERE un.id = s.id AND un.processed_at IS NULL
+`
+
+type UpdateUserNotificationsScheduleParams struct {
+	Ids         []int32              `db:"ids" json:"ids"`
+	ScheduledAt []pgtype.Timestamptz `db:"scheduled_at" json:"scheduled_at"`
+}
+
+func (q *Queries) UpdateUserNotificationsSchedule(ctx context.Context, arg *UpdateUserNotificationsScheduleParams) error {
+	_, err := q.db.Exec(ctx, updateUserNotificationsSchedule, arg.Ids, arg.ScheduledAt)
+	return err
+}
@@ -7,18 +7,44 @@ package generated
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addUserToOrg = `-- name: AddUserToOrg :one
+INSERT INTO backend.organization_users (org_id, user_id, level) VALUES ($1, $2, $3) RETURNING org_id, user_id, level, created_at, updated_at
+`
+
+type AddUserToOrgParams struct {
+	OrgID  int32       `db:"org_id" json:"org_id"`
+	UserID int32       `db:"user_id" json:"user_id"`
+	Level  AccessLevel `db:"level" json:"level"`
+}
+
+func (q *Queries) AddUserToOrg(ctx context.Context, arg *AddUserToOrgParams) (*OrganizationUser, error) {
+	row := q.db.QueryRow(ctx, addUserToOrg, arg.OrgID, arg.UserID, arg.Level)
+	var i OrganizationUser
+	err := row.Scan(
+		&i.OrgID,
+		&i.UserID,
+		&i.Level,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
 const getOrganizationUsers = `-- name: GetOrganizationUsers :many
-SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at, ou.level
+SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at, ou.level, ou.updated_at AS joined_at
 FROM backend.organization_users ou
 JOIN backend.users u ON ou.user_id = u.id
 WHERE ou.org_id = $1 AND u.deleted_at IS NULL
 `
 
 type GetOrganizationUsersRow struct {
-	User  User        `db:"user" json:"user"`
-	Level AccessLevel `db:"level" json:"level"`
+	User     User               `db:"user" json:"user"`
+	Level    AccessLevel        `db:"level" json:"level"`
+	JoinedAt pgtype.Timestamptz `db:"joined_at" json:"joined_at"`
 }
 
 func (q *Queries) GetOrganizationUsers(ctx context.Context, orgID int32) ([]*GetOrganizationUsersRow, error) {
@@ -39,6 +65,103 @@ func (q *Queries) GetOrganizationUsers(ctx context.Context, orgID int32) ([]*Get
 			&i.User.UpdatedAt,
 			&i.User.DeletedAt,
 			&i.Level,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrganizationUsersCount = `-- name: GetOrganizationUsersCount :one
+SELECT COUNT(*) as count
+FROM backend.organization_users ou
+JOIN backend.users u ON ou.user_id = u.id
+WHERE ou.org_id = $1 AND u.deleted_at IS NULL
+  AND ($2::backend.access_level IS NULL OR ou.level = $2::backend.access_level)
+  AND ($3::TEXT = '' OR u.email ILIKE '%' || $3::TEXT || '%')
+`
+
+type GetOrganizationUsersCountParams struct {
+	OrgID int32           `db:"org_id" json:"org_id"`
+	Level NullAccessLevel `db:"level" json:"level"`
+	Email string          `db:"email" json:"email"`
+}
+
+func (q *Queries) GetOrganizationUsersCount(ctx context.Context, arg *GetOrganizationUsersCountParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getOrganizationUsersCount, arg.OrgID, arg.Level, arg.Email)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getOrganizationUsersPage = `-- name: GetOrganizationUsersPage :many
+SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at, ou.level, ou.updated_at AS joined_at
+FROM backend.organization_users ou
+JOIN backend.users u ON ou.user_id = u.id
+WHERE ou.org_id = $1 AND u.deleted_at IS NULL
+  AND ($2::backend.access_level IS NULL OR ou.level = $2::backend.access_level)
+  AND ($3::TEXT = '' OR u.email ILIKE '%' || $3::TEXT || '%')
+ORDER BY
+  CASE WHEN $4::TEXT = 'email' AND NOT $5::BOOLEAN THEN u.email END ASC,
+  CASE WHEN $4::TEXT = 'email' AND $5::BOOLEAN THEN u.email END DESC,
+  CASE WHEN $4::TEXT = 'name' AND NOT $5::BOOLEAN THEN u.name END ASC,
+  CASE WHEN $4::TEXT = 'name' AND $5::BOOLEAN THEN u.name END DESC,
+  CASE WHEN $4::TEXT = 'level' AND NOT $5::BOOLEAN THEN ou.level END ASC,
+  CASE WHEN $4::TEXT = 'level' AND $5::BOOLEAN THEN ou.level END DESC,
+  CASE WHEN $5::BOOLEAN THEN ou.updated_at END DESC,
+  ou.updated_at, u.id
+OFFSET $6
+LIMIT $7
+`
+
+type GetOrganizationUsersPageParams struct {
+	OrgID      int32           `db:"org_id" json:"org_id"`
+	Level      NullAccessLevel `db:"level" json:"level"`
+	Email      string          `db:"email" json:"email"`
+	SortBy     string          `db:"sort_by" json:"sort_by"`
+	Descending bool            `db:"descending" json:"descending"`
+	PageOffset int32           `db:"page_offset" json:"page_offset"`
+	PageLimit  int32           `db:"page_limit" json:"page_limit"`
+}
+
+type GetOrganizationUsersPageRow struct {
+	User     User               `db:"user" json:"user"`
+	Level    AccessLevel        `db:"level" json:"level"`
+	JoinedAt pgtype.Timestamptz `db:"joined_at" json:"joined_at"`
+}
+
+func (q *Queries) GetOrganizationUsersPage(ctx context.Context, arg *GetOrganizationUsersPageParams) ([]*GetOrganizationUsersPageRow, error) {
+	rows, err := q.db.Query(ctx, getOrganizationUsersPage,
+		arg.OrgID,
+		arg.Level,
+		arg.Email,
+		arg.SortBy,
+		arg.Descending,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetOrganizationUsersPageRow
+	for rows.Next() {
+		var i GetOrganizationUsersPageRow
+		if err := rows.Scan(
+			&i.User.ID,
+			&i.User.Name,
+			&i.User.Email,
+			&i.User.SubscriptionID,
+			&i.User.CreatedAt,
+			&i.User.UpdatedAt,
+			&i.User.DeletedAt,
+			&i.Level,
+			&i.JoinedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -50,6 +173,21 @@ func (q *Queries) GetOrganizationUsers(ctx context.Context, orgID int32) ([]*Get
 	return items, nil
 }
 
+const getUserSeatsCount = `-- name: GetUserSeatsCount :one
+SELECT COUNT(DISTINCT ou.user_id) AS count
+FROM backend.organization_users ou
+JOIN backend.organizations o ON ou.org_id = o.id
+JOIN backend.users u ON ou.user_id = u.id
+WHERE o.user_id = $1 AND o.deleted_at IS NULL AND u.deleted_at IS NULL
+`
+
+func (q *Queries) GetUserSeatsCount(ctx context.Context, userID pgtype.Int4) (int64, error) {
+	row := q.db.QueryRow(ctx, getUserSeatsCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const inviteUserToOrg = `-- name: InviteUserToOrg :one
 INSERT INTO backend.organization_users (org_id, user_id, level) VALUES ($1, $2, 'invited') RETURNING org_id, user_id, level, created_at, updated_at
 `
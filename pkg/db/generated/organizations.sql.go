@@ -12,7 +12,7 @@ import (
 )
 
 const createOrganization = `-- name: CreateOrganization :one
-INSERT INTO backend.organizations (name, user_id) VALUES ($1, $2) RETURNING id, name, user_id, created_at, updated_at, deleted_at
+INSERT INTO backend.organizations (name, user_id) VALUES ($1, $2) RETURNING id, name, user_id, created_at, updated_at, deleted_at, sandbox
 `
 
 type CreateOrganizationParams struct {
@@ -30,6 +30,31 @@ func (q *Queries) CreateOrganization(ctx context.Context, arg *CreateOrganizatio
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Sandbox,
+	)
+	return &i, err
+}
+
+const createSandboxOrganization = `-- name: CreateSandboxOrganization :one
+INSERT INTO backend.organizations (name, user_id, sandbox) VALUES ($1, $2, TRUE) RETURNING id, name, user_id, created_at, updated_at, deleted_at, sandbox
+`
+
+type CreateSandboxOrganizationParams struct {
+	Name   string      `db:"name" json:"name"`
+	UserID pgtype.Int4 `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) CreateSandboxOrganization(ctx context.Context, arg *CreateSandboxOrganizationParams) (*Organization, error) {
+	row := q.db.QueryRow(ctx, createSandboxOrganization, arg.Name, arg.UserID)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Sandbox,
 	)
 	return &i, err
 }
@@ -44,7 +69,7 @@ func (q *Queries) DeleteOrganizations(ctx context.Context, dollar_1 []int32) err
 }
 
 const findUserOrgByName = `-- name: FindUserOrgByName :one
-SELECT id, name, user_id, created_at, updated_at, deleted_at from backend.organizations WHERE user_id = $1 AND name = $2 AND deleted_at IS NULL
+SELECT id, name, user_id, created_at, updated_at, deleted_at, sandbox from backend.organizations WHERE user_id = $1 AND name = $2 AND deleted_at IS NULL
 `
 
 type FindUserOrgByNameParams struct {
@@ -62,12 +87,37 @@ func (q *Queries) FindUserOrgByName(ctx context.Context, arg *FindUserOrgByNameP
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Sandbox,
 	)
 	return &i, err
 }
 
+const getExistingOrganizationIDs = `-- name: GetExistingOrganizationIDs :many
+SELECT id FROM backend.organizations WHERE id = ANY($1::INT[])
+`
+
+func (q *Queries) GetExistingOrganizationIDs(ctx context.Context, ids []int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getExistingOrganizationIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getOrganizationWithAccess = `-- name: GetOrganizationWithAccess :one
- SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, ou.level
+ SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, o.sandbox, ou.level
  FROM backend.organizations o
  LEFT JOIN backend.organization_users ou ON
      o.id = ou.org_id
@@ -96,13 +146,51 @@ func (q *Queries) GetOrganizationWithAccess(ctx context.Context, arg *GetOrganiz
 		&i.Organization.CreatedAt,
 		&i.Organization.UpdatedAt,
 		&i.Organization.DeletedAt,
+		&i.Organization.Sandbox,
 		&i.Level,
 	)
 	return &i, err
 }
 
+const getSandboxOrganizations = `-- name: GetSandboxOrganizations :many
+SELECT id, name, user_id, created_at, updated_at, deleted_at, sandbox FROM backend.organizations WHERE sandbox = TRUE AND deleted_at IS NULL AND id > $1 ORDER BY id LIMIT $2
+`
+
+type GetSandboxOrganizationsParams struct {
+	ID    int32 `db:"id" json:"id"`
+	Limit int32 `db:"limit" json:"limit"`
+}
+
+func (q *Queries) GetSandboxOrganizations(ctx context.Context, arg *GetSandboxOrganizationsParams) ([]*Organization, error) {
+	rows, err := q.db.Query(ctx, getSandboxOrganizations, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Organization
+	for rows.Next() {
+		var i Organization
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Sandbox,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSoftDeletedOrganizations = `-- name: GetSoftDeletedOrganizations :many
-SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at
+SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, o.sandbox
 FROM backend.organizations o
 JOIN backend.users u ON o.user_id = u.id
 WHERE o.deleted_at IS NOT NULL
@@ -136,6 +224,7 @@ func (q *Queries) GetSoftDeletedOrganizations(ctx context.Context, arg *GetSoftD
 			&i.Organization.CreatedAt,
 			&i.Organization.UpdatedAt,
 			&i.Organization.DeletedAt,
+			&i.Organization.Sandbox,
 		); err != nil {
 			return nil, err
 		}
@@ -148,9 +237,9 @@ func (q *Queries) GetSoftDeletedOrganizations(ctx context.Context, arg *GetSoftD
 }
 
 const getUserOrganizations = `-- name: GetUserOrganizations :many
-SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, 'owner'::backend.access_level as level FROM backend.organizations o WHERE o.user_id = $1 AND o.deleted_at IS NULL
+SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, o.sandbox, 'owner'::backend.access_level as level FROM backend.organizations o WHERE o.user_id = $1 AND o.deleted_at IS NULL
 UNION ALL
-SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, ou.level
+SELECT o.id, o.name, o.user_id, o.created_at, o.updated_at, o.deleted_at, o.sandbox, ou.level
 FROM backend.organizations o
 JOIN backend.organization_users ou ON o.id = ou.org_id
 WHERE ou.user_id = $1 AND o.deleted_at IS NULL
@@ -177,6 +266,7 @@ func (q *Queries) GetUserOrganizations(ctx context.Context, userID pgtype.Int4)
 			&i.Organization.CreatedAt,
 			&i.Organization.UpdatedAt,
 			&i.Organization.DeletedAt,
+			&i.Organization.Sandbox,
 			&i.Level,
 		); err != nil {
 			return nil, err
@@ -215,7 +305,7 @@ func (q *Queries) SoftDeleteUserOrganizations(ctx context.Context, userID pgtype
 const updateOrganization = `-- name: UpdateOrganization :one
 UPDATE backend.organizations SET name = $1, updated_at = NOW()
 WHERE id = $2
-RETURNING id, name, user_id, created_at, updated_at, deleted_at
+RETURNING id, name, user_id, created_at, updated_at, deleted_at, sandbox
 `
 
 type UpdateOrganizationParams struct {
@@ -233,6 +323,7 @@ func (q *Queries) UpdateOrganization(ctx context.Context, arg *UpdateOrganizatio
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Sandbox,
 	)
 	return &i, err
 }
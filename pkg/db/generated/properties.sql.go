@@ -12,24 +12,172 @@ import (
 	"time"
 )
 
+const createProperties = `-- name: CreateProperties :many
+INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, bot_policy, failure_url, failure_message, difficulty_strategy, privacy_mode)
+SELECT u.name, $1::INT, $2::INT, $3::INT, u.domain, u.level, u.growth::backend.difficulty_growth, u.validity_interval, u.allow_subdomains, u.allow_localhost, u.max_replay_count, u.allowed_clock_skew, u.remember_window, u.widget_flags, u.environment::backend.property_environment, NULLIF(u.twin_id, 0), u.trust_group, u.claims, u.differential_difficulty, u.bot_policy::backend.bot_policy, u.failure_url, u.failure_message, u.difficulty_strategy, u.privacy_mode
+FROM (SELECT unnest($4::TEXT[]) AS name,
+             unnest($5::TEXT[]) AS domain,
+             unnest($6::SMALLINT[]) AS level,
+             unnest($7::TEXT[]) AS growth,
+             unnest($8::INTERVAL[]) AS validity_interval,
+             unnest($9::BOOLEAN[]) AS allow_subdomains,
+             unnest($10::BOOLEAN[]) AS allow_localhost,
+             unnest($11::INT[]) AS max_replay_count,
+             unnest($12::INTERVAL[]) AS allowed_clock_skew,
+             unnest($13::INTERVAL[]) AS remember_window,
+             unnest($14::SMALLINT[]) AS widget_flags,
+             unnest($15::TEXT[]) AS environment,
+             unnest($16::INT[]) AS twin_id,
+             unnest($17::TEXT[]) AS trust_group,
+             unnest($18::TEXT[]) AS claims,
+             unnest($19::BOOLEAN[]) AS differential_difficulty,
+             unnest($20::TEXT[]) AS bot_policy,
+             unnest($21::TEXT[]) AS failure_url,
+             unnest($22::TEXT[]) AS failure_message,
+             unnest($23::TEXT[]) AS difficulty_strategy,
+             unnest($24::TEXT[]) AS privacy_mode)
+    AS u
+ON CONFLICT (name, org_id) DO NOTHING
+RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
+`
+
+type CreatePropertiesParams struct {
+	OrgID                    int32           `db:"org_id" json:"org_id"`
+	CreatorID                int32           `db:"creator_id" json:"creator_id"`
+	OrgOwnerID               int32           `db:"org_owner_id" json:"org_owner_id"`
+	Names                    []string        `db:"names" json:"names"`
+	Domains                  []string        `db:"domains" json:"domains"`
+	Levels                   []int16         `db:"levels" json:"levels"`
+	Growths                  []string        `db:"growths" json:"growths"`
+	ValidityIntervals        []time.Duration `db:"validity_intervals" json:"validity_intervals"`
+	AllowSubdomains          []bool          `db:"allow_subdomains" json:"allow_subdomains"`
+	AllowLocalhosts          []bool          `db:"allow_localhosts" json:"allow_localhosts"`
+	MaxReplayCounts          []int32         `db:"max_replay_counts" json:"max_replay_counts"`
+	AllowedClockSkews        []time.Duration `db:"allowed_clock_skews" json:"allowed_clock_skews"`
+	RememberWindows          []time.Duration `db:"remember_windows" json:"remember_windows"`
+	WidgetFlags              []int16         `db:"widget_flags" json:"widget_flags"`
+	Environments             []string        `db:"environments" json:"environments"`
+	TwinIds                  []int32         `db:"twin_ids" json:"twin_ids"`
+	TrustGroups              []string        `db:"trust_groups" json:"trust_groups"`
+	Claims                   []string        `db:"claims" json:"claims"`
+	DifferentialDifficulties []bool          `db:"differential_difficulties" json:"differential_difficulties"`
+	BotPolicies              []string        `db:"bot_policies" json:"bot_policies"`
+	FailureUrls              []string        `db:"failure_urls" json:"failure_urls"`
+	FailureMessages          []string        `db:"failure_messages" json:"failure_messages"`
+	DifficultyStrategies     []string        `db:"difficulty_strategies" json:"difficulty_strategies"`
+	PrivacyModes             []string        `db:"privacy_modes" json:"privacy_modes"`
+}
+
+func (q *Queries) CreateProperties(ctx context.Context, arg *CreatePropertiesParams) ([]*Property, error) {
+	rows, err := q.db.Query(ctx, createProperties,
+		arg.OrgID,
+		arg.CreatorID,
+		arg.OrgOwnerID,
+		arg.Names,
+		arg.Domains,
+		arg.Levels,
+		arg.Growths,
+		arg.ValidityIntervals,
+		arg.AllowSubdomains,
+		arg.AllowLocalhosts,
+		arg.MaxReplayCounts,
+		arg.AllowedClockSkews,
+		arg.RememberWindows,
+		arg.WidgetFlags,
+		arg.Environments,
+		arg.TwinIds,
+		arg.TrustGroups,
+		arg.Claims,
+		arg.DifferentialDifficulties,
+		arg.BotPolicies,
+		arg.FailureUrls,
+		arg.FailureMessages,
+		arg.DifficultyStrategies,
+		arg.PrivacyModes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Property
+	for rows.Next() {
+		var i Property
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.OrgID,
+			&i.CreatorID,
+			&i.OrgOwnerID,
+			&i.Domain,
+			&i.Level,
+			&i.Salt,
+			&i.Growth,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ValidityInterval,
+			&i.AllowSubdomains,
+			&i.AllowLocalhost,
+			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createProperty = `-- name: CreateProperty :one
-INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, validity_interval, allow_subdomains, allow_localhost, max_replay_count)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count
+INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, bot_policy, failure_url, failure_message, difficulty_strategy, privacy_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
 `
 
 type CreatePropertyParams struct {
-	Name             string           `db:"name" json:"name"`
-	OrgID            pgtype.Int4      `db:"org_id" json:"org_id"`
-	CreatorID        pgtype.Int4      `db:"creator_id" json:"creator_id"`
-	OrgOwnerID       pgtype.Int4      `db:"org_owner_id" json:"org_owner_id"`
-	Domain           string           `db:"domain" json:"domain"`
-	Level            pgtype.Int2      `db:"level" json:"level"`
-	Growth           DifficultyGrowth `db:"growth" json:"growth"`
-	ValidityInterval time.Duration    `db:"validity_interval" json:"validity_interval"`
-	AllowSubdomains  bool             `db:"allow_subdomains" json:"allow_subdomains"`
-	AllowLocalhost   bool             `db:"allow_localhost" json:"allow_localhost"`
-	MaxReplayCount   int32            `db:"max_replay_count" json:"max_replay_count"`
+	Name                   string              `db:"name" json:"name"`
+	OrgID                  pgtype.Int4         `db:"org_id" json:"org_id"`
+	CreatorID              pgtype.Int4         `db:"creator_id" json:"creator_id"`
+	OrgOwnerID             pgtype.Int4         `db:"org_owner_id" json:"org_owner_id"`
+	Domain                 string              `db:"domain" json:"domain"`
+	Level                  pgtype.Int2         `db:"level" json:"level"`
+	Growth                 DifficultyGrowth    `db:"growth" json:"growth"`
+	ValidityInterval       time.Duration       `db:"validity_interval" json:"validity_interval"`
+	AllowSubdomains        bool                `db:"allow_subdomains" json:"allow_subdomains"`
+	AllowLocalhost         bool                `db:"allow_localhost" json:"allow_localhost"`
+	MaxReplayCount         int32               `db:"max_replay_count" json:"max_replay_count"`
+	AllowedClockSkew       time.Duration       `db:"allowed_clock_skew" json:"allowed_clock_skew"`
+	RememberWindow         time.Duration       `db:"remember_window" json:"remember_window"`
+	WidgetFlags            int16               `db:"widget_flags" json:"widget_flags"`
+	Environment            PropertyEnvironment `db:"environment" json:"environment"`
+	TwinID                 pgtype.Int4         `db:"twin_id" json:"twin_id"`
+	TrustGroup             string              `db:"trust_group" json:"trust_group"`
+	Claims                 string              `db:"claims" json:"claims"`
+	DifferentialDifficulty bool                `db:"differential_difficulty" json:"differential_difficulty"`
+	BotPolicy              BotPolicy           `db:"bot_policy" json:"bot_policy"`
+	FailureURL             string              `db:"failure_url" json:"failure_url"`
+	FailureMessage         string              `db:"failure_message" json:"failure_message"`
+	DifficultyStrategy     string              `db:"difficulty_strategy" json:"difficulty_strategy"`
+	PrivacyMode            string              `db:"privacy_mode" json:"privacy_mode"`
 }
 
 func (q *Queries) CreateProperty(ctx context.Context, arg *CreatePropertyParams) (*Property, error) {
@@ -45,6 +193,19 @@ func (q *Queries) CreateProperty(ctx context.Context, arg *CreatePropertyParams)
 		arg.AllowSubdomains,
 		arg.AllowLocalhost,
 		arg.MaxReplayCount,
+		arg.AllowedClockSkew,
+		arg.RememberWindow,
+		arg.WidgetFlags,
+		arg.Environment,
+		arg.TwinID,
+		arg.TrustGroup,
+		arg.Claims,
+		arg.DifferentialDifficulty,
+		arg.BotPolicy,
+		arg.FailureURL,
+		arg.FailureMessage,
+		arg.DifficultyStrategy,
+		arg.PrivacyMode,
 	)
 	var i Property
 	err := row.Scan(
@@ -65,10 +226,84 @@ func (q *Queries) CreateProperty(ctx context.Context, arg *CreatePropertyParams)
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 	)
 	return &i, err
 }
 
+const deleteOrgProperties = `-- name: DeleteOrgProperties :many
+DELETE FROM backend.properties WHERE org_id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
+`
+
+func (q *Queries) DeleteOrgProperties(ctx context.Context, orgID pgtype.Int4) ([]*Property, error) {
+	rows, err := q.db.Query(ctx, deleteOrgProperties, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Property
+	for rows.Next() {
+		var i Property
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.OrgID,
+			&i.CreatorID,
+			&i.OrgOwnerID,
+			&i.Domain,
+			&i.Level,
+			&i.Salt,
+			&i.Growth,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ValidityInterval,
+			&i.AllowSubdomains,
+			&i.AllowLocalhost,
+			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteProperties = `-- name: DeleteProperties :exec
 DELETE FROM backend.properties WHERE id = ANY($1::INT[])
 `
@@ -78,11 +313,35 @@ func (q *Queries) DeleteProperties(ctx context.Context, dollar_1 []int32) error
 	return err
 }
 
+const getExistingPropertyIDs = `-- name: GetExistingPropertyIDs :many
+SELECT id FROM backend.properties WHERE id = ANY($1::INT[])
+`
+
+func (q *Queries) GetExistingPropertyIDs(ctx context.Context, ids []int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getExistingPropertyIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getOrgProperties = `-- name: GetOrgProperties :many
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
 FROM backend.properties
 WHERE org_id = $1 AND deleted_at IS NULL
-ORDER BY created_at
+ORDER BY created_at, id
 OFFSET $2
 LIMIT $3
 `
@@ -120,6 +379,96 @@ func (q *Queries) GetOrgProperties(ctx context.Context, arg *GetOrgPropertiesPar
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrgPropertiesAfter = `-- name: GetOrgPropertiesAfter :many
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
+FROM backend.properties
+WHERE org_id = $1 AND deleted_at IS NULL AND (created_at, id) > ($3::TIMESTAMPTZ, $4::INT)
+ORDER BY created_at, id
+LIMIT $2
+`
+
+type GetOrgPropertiesAfterParams struct {
+	OrgID     pgtype.Int4        `db:"org_id" json:"org_id"`
+	Limit     int32              `db:"limit" json:"limit"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	ID        int32              `db:"id" json:"id"`
+}
+
+func (q *Queries) GetOrgPropertiesAfter(ctx context.Context, arg *GetOrgPropertiesAfterParams) ([]*Property, error) {
+	rows, err := q.db.Query(ctx, getOrgPropertiesAfter,
+		arg.OrgID,
+		arg.Limit,
+		arg.CreatedAt,
+		arg.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Property
+	for rows.Next() {
+		var i Property
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.OrgID,
+			&i.CreatorID,
+			&i.OrgOwnerID,
+			&i.Domain,
+			&i.Level,
+			&i.Salt,
+			&i.Growth,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ValidityInterval,
+			&i.AllowSubdomains,
+			&i.AllowLocalhost,
+			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
 		); err != nil {
 			return nil, err
 		}
@@ -143,7 +492,7 @@ func (q *Queries) GetOrgPropertiesCount(ctx context.Context, orgID pgtype.Int4)
 }
 
 const getOrgPropertyByName = `-- name: GetOrgPropertyByName :one
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count from backend.properties WHERE org_id = $1 AND name = $2 AND deleted_at IS NULL
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode from backend.properties WHERE org_id = $1 AND name = $2 AND deleted_at IS NULL
 `
 
 type GetOrgPropertyByNameParams struct {
@@ -172,12 +521,52 @@ func (q *Queries) GetOrgPropertyByName(ctx context.Context, arg *GetOrgPropertyB
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 	)
 	return &i, err
 }
 
+const getOrgPropertyIDs = `-- name: GetOrgPropertyIDs :many
+SELECT id FROM backend.properties WHERE org_id = $1
+`
+
+func (q *Queries) GetOrgPropertyIDs(ctx context.Context, orgID pgtype.Int4) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getOrgPropertyIDs, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProperties = `-- name: GetProperties :many
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count FROM backend.properties LIMIT $1
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode FROM backend.properties LIMIT $1
 `
 
 func (q *Queries) GetProperties(ctx context.Context, limit int32) ([]*Property, error) {
@@ -207,6 +596,22 @@ func (q *Queries) GetProperties(ctx context.Context, limit int32) ([]*Property,
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
 		); err != nil {
 			return nil, err
 		}
@@ -219,7 +624,7 @@ func (q *Queries) GetProperties(ctx context.Context, limit int32) ([]*Property,
 }
 
 const getPropertiesByExternalID = `-- name: GetPropertiesByExternalID :many
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count from backend.properties WHERE external_id = ANY($1::UUID[])
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode from backend.properties WHERE external_id = ANY($1::UUID[])
 `
 
 func (q *Queries) GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgtype.UUID) ([]*Property, error) {
@@ -249,6 +654,22 @@ func (q *Queries) GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgty
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
 		); err != nil {
 			return nil, err
 		}
@@ -261,7 +682,7 @@ func (q *Queries) GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgty
 }
 
 const getPropertiesByID = `-- name: GetPropertiesByID :many
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count from backend.properties WHERE id = ANY($1::INT[])
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode from backend.properties WHERE id = ANY($1::INT[])
 `
 
 func (q *Queries) GetPropertiesByID(ctx context.Context, dollar_1 []int32) ([]*Property, error) {
@@ -291,6 +712,88 @@ func (q *Queries) GetPropertiesByID(ctx context.Context, dollar_1 []int32) ([]*P
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPropertiesForDomainCheck = `-- name: GetPropertiesForDomainCheck :many
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode FROM backend.properties
+WHERE deleted_at IS NULL AND (domain_checked_at IS NULL OR domain_checked_at < $1)
+ORDER BY domain_checked_at NULLS FIRST, id
+LIMIT $2
+`
+
+type GetPropertiesForDomainCheckParams struct {
+	DomainCheckedAt pgtype.Timestamptz `db:"domain_checked_at" json:"domain_checked_at"`
+	Limit           int32              `db:"limit" json:"limit"`
+}
+
+func (q *Queries) GetPropertiesForDomainCheck(ctx context.Context, arg *GetPropertiesForDomainCheckParams) ([]*Property, error) {
+	rows, err := q.db.Query(ctx, getPropertiesForDomainCheck, arg.DomainCheckedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Property
+	for rows.Next() {
+		var i Property
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ExternalID,
+			&i.OrgID,
+			&i.CreatorID,
+			&i.OrgOwnerID,
+			&i.Domain,
+			&i.Level,
+			&i.Salt,
+			&i.Growth,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ValidityInterval,
+			&i.AllowSubdomains,
+			&i.AllowLocalhost,
+			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
 		); err != nil {
 			return nil, err
 		}
@@ -303,7 +806,7 @@ func (q *Queries) GetPropertiesByID(ctx context.Context, dollar_1 []int32) ([]*P
 }
 
 const getPropertyByExternalID = `-- name: GetPropertyByExternalID :one
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count from backend.properties WHERE external_id = $1
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode from backend.properties WHERE external_id = $1
 `
 
 func (q *Queries) GetPropertyByExternalID(ctx context.Context, externalID pgtype.UUID) (*Property, error) {
@@ -327,12 +830,28 @@ func (q *Queries) GetPropertyByExternalID(ctx context.Context, externalID pgtype
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 	)
 	return &i, err
 }
 
 const getPropertyByID = `-- name: GetPropertyByID :one
-SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count from backend.properties WHERE id = $1
+SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode from backend.properties WHERE id = $1
 `
 
 func (q *Queries) GetPropertyByID(ctx context.Context, id int32) (*Property, error) {
@@ -356,12 +875,28 @@ func (q *Queries) GetPropertyByID(ctx context.Context, id int32) (*Property, err
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 	)
 	return &i, err
 }
 
 const getSoftDeletedProperties = `-- name: GetSoftDeletedProperties :many
-SELECT p.id, p.name, p.external_id, p.org_id, p.creator_id, p.org_owner_id, p.domain, p.level, p.salt, p.growth, p.created_at, p.updated_at, p.deleted_at, p.validity_interval, p.allow_subdomains, p.allow_localhost, p.max_replay_count
+SELECT p.id, p.name, p.external_id, p.org_id, p.creator_id, p.org_owner_id, p.domain, p.level, p.salt, p.growth, p.created_at, p.updated_at, p.deleted_at, p.validity_interval, p.allow_subdomains, p.allow_localhost, p.max_replay_count, p.allowed_clock_skew, p.remember_window, p.widget_flags, p.environment, p.twin_id, p.trust_group, p.claims, p.differential_difficulty, p.domain_status, p.domain_checked_at, p.bot_policy, p.failure_url, p.failure_message, p.emergency_until, p.difficulty_strategy, p.privacy_mode
 FROM backend.properties p
 JOIN backend.organizations o ON p.org_id = o.id
 JOIN backend.users u ON o.user_id = u.id
@@ -408,6 +943,22 @@ func (q *Queries) GetSoftDeletedProperties(ctx context.Context, arg *GetSoftDele
 			&i.Property.AllowSubdomains,
 			&i.Property.AllowLocalhost,
 			&i.Property.MaxReplayCount,
+			&i.Property.AllowedClockSkew,
+			&i.Property.RememberWindow,
+			&i.Property.WidgetFlags,
+			&i.Property.Environment,
+			&i.Property.TwinID,
+			&i.Property.TrustGroup,
+			&i.Property.Claims,
+			&i.Property.DifferentialDifficulty,
+			&i.Property.DomainStatus,
+			&i.Property.DomainCheckedAt,
+			&i.Property.BotPolicy,
+			&i.Property.FailureURL,
+			&i.Property.FailureMessage,
+			&i.Property.EmergencyUntil,
+			&i.Property.DifficultyStrategy,
+			&i.Property.PrivacyMode,
 		); err != nil {
 			return nil, err
 		}
@@ -420,7 +971,8 @@ func (q *Queries) GetSoftDeletedProperties(ctx context.Context, arg *GetSoftDele
 }
 
 const getUserPropertiesCount = `-- name: GetUserPropertiesCount :one
-SELECT COUNT(*) as count FROM backend.properties WHERE org_owner_id = $1 AND deleted_at IS NULL
+SELECT COUNT(*) as count FROM backend.properties p WHERE p.org_owner_id = $1 AND p.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM backend.organizations o WHERE o.id = p.org_id AND o.sandbox)
 `
 
 func (q *Queries) GetUserPropertiesCount(ctx context.Context, orgOwnerID pgtype.Int4) (int64, error) {
@@ -430,10 +982,59 @@ func (q *Queries) GetUserPropertiesCount(ctx context.Context, orgOwnerID pgtype.
 	return count, err
 }
 
+const getUserPropertiesOverLimit = `-- name: GetUserPropertiesOverLimit :many
+SELECT p.id, p.name, p.org_id, o.name AS org_name, p.created_at
+FROM backend.properties p
+JOIN backend.organizations o ON o.id = p.org_id
+WHERE p.org_owner_id = $1 AND p.deleted_at IS NULL AND NOT o.sandbox
+ORDER BY p.created_at ASC, p.id ASC
+OFFSET $2 LIMIT $3
+`
+
+type GetUserPropertiesOverLimitParams struct {
+	OrgOwnerID pgtype.Int4 `db:"org_owner_id" json:"org_owner_id"`
+	Offset     int32       `db:"offset" json:"offset"`
+	Limit      int32       `db:"limit" json:"limit"`
+}
+
+type GetUserPropertiesOverLimitRow struct {
+	ID        int32              `db:"id" json:"id"`
+	Name      string             `db:"name" json:"name"`
+	OrgID     pgtype.Int4        `db:"org_id" json:"org_id"`
+	OrgName   string             `db:"org_name" json:"org_name"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) GetUserPropertiesOverLimit(ctx context.Context, arg *GetUserPropertiesOverLimitParams) ([]*GetUserPropertiesOverLimitRow, error) {
+	rows, err := q.db.Query(ctx, getUserPropertiesOverLimit, arg.OrgOwnerID, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetUserPropertiesOverLimitRow
+	for rows.Next() {
+		var i GetUserPropertiesOverLimitRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.OrgID,
+			&i.OrgName,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const moveProperty = `-- name: MoveProperty :one
 UPDATE backend.properties SET org_id = $2, org_owner_id = $3, updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count
+RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
 `
 
 type MovePropertyParams struct {
@@ -463,12 +1064,28 @@ func (q *Queries) MoveProperty(ctx context.Context, arg *MovePropertyParams) (*P
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 	)
 	return &i, err
 }
 
 const softDeleteProperties = `-- name: SoftDeleteProperties :many
-UPDATE backend.properties SET deleted_at = NOW(), updated_at = NOW(), name = name || ' deleted_' || substr(md5(random()::text), 1, 8) WHERE id = ANY($1::INT[]) AND (creator_id = $2 OR org_owner_id = $2) AND (org_id = $3 OR $3 IS NULL) AND deleted_at IS NULL RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count
+UPDATE backend.properties SET deleted_at = NOW(), updated_at = NOW(), name = name || ' deleted_' || substr(md5(random()::text), 1, 8) WHERE id = ANY($1::INT[]) AND (creator_id = $2 OR org_owner_id = $2) AND (org_id = $3 OR $3 IS NULL) AND deleted_at IS NULL RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
 `
 
 type SoftDeletePropertiesParams struct {
@@ -504,6 +1121,22 @@ func (q *Queries) SoftDeleteProperties(ctx context.Context, arg *SoftDeletePrope
 			&i.AllowSubdomains,
 			&i.AllowLocalhost,
 			&i.MaxReplayCount,
+			&i.AllowedClockSkew,
+			&i.RememberWindow,
+			&i.WidgetFlags,
+			&i.Environment,
+			&i.TwinID,
+			&i.TrustGroup,
+			&i.Claims,
+			&i.DifferentialDifficulty,
+			&i.DomainStatus,
+			&i.DomainCheckedAt,
+			&i.BotPolicy,
+			&i.FailureURL,
+			&i.FailureMessage,
+			&i.EmergencyUntil,
+			&i.DifficultyStrategy,
+			&i.PrivacyMode,
 		); err != nil {
 			return nil, err
 		}
@@ -516,7 +1149,7 @@ func (q *Queries) SoftDeleteProperties(ctx context.Context, arg *SoftDeletePrope
 }
 
 const softDeleteProperty = `-- name: SoftDeleteProperty :one
-UPDATE backend.properties SET deleted_at = NOW(), updated_at = NOW(), name = name || ' deleted_' || substr(md5(random()::text), 1, 8) WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count
+UPDATE backend.properties SET deleted_at = NOW(), updated_at = NOW(), name = name || ' deleted_' || substr(md5(random()::text), 1, 8) WHERE id = $1 RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
 `
 
 func (q *Queries) SoftDeleteProperty(ctx context.Context, id int32) (*Property, error) {
@@ -540,13 +1173,29 @@ func (q *Queries) SoftDeleteProperty(ctx context.Context, id int32) (*Property,
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 	)
 	return &i, err
 }
 
 const updateProperty = `-- name: UpdateProperty :one
 WITH old AS (
-    SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count FROM backend.properties p
+    SELECT id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode FROM backend.properties p
     WHERE p.id = $1 AND (p.creator_id = $9 OR p.org_owner_id = $9) AND (p.org_id = $10 OR $10 IS NULL)
     FOR UPDATE
 ),
@@ -559,61 +1208,121 @@ upd AS (
         allow_subdomains = $6,
         allow_localhost = $7,
         max_replay_count = $8,
+        allowed_clock_skew = $11,
+        remember_window = $12,
+        widget_flags = $13,
+        twin_id = $14,
+        trust_group = $15,
+        claims = $16,
+        differential_difficulty = $17,
+        bot_policy = $18,
+        failure_url = $19,
+        failure_message = $20,
+        difficulty_strategy = $21,
         updated_at = NOW()
     WHERE p.id = (SELECT id FROM old)
-    RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count -- This ensures the final SELECT only returns data if the update actually happened
+    RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode -- This ensures the final SELECT only returns data if the update actually happened
 )
 SELECT
-    upd.id, upd.name, upd.external_id, upd.org_id, upd.creator_id, upd.org_owner_id, upd.domain, upd.level, upd.salt, upd.growth, upd.created_at, upd.updated_at, upd.deleted_at, upd.validity_interval, upd.allow_subdomains, upd.allow_localhost, upd.max_replay_count,
+    upd.id, upd.name, upd.external_id, upd.org_id, upd.creator_id, upd.org_owner_id, upd.domain, upd.level, upd.salt, upd.growth, upd.created_at, upd.updated_at, upd.deleted_at, upd.validity_interval, upd.allow_subdomains, upd.allow_localhost, upd.max_replay_count, upd.allowed_clock_skew, upd.remember_window, upd.widget_flags, upd.environment, upd.twin_id, upd.trust_group, upd.claims, upd.differential_difficulty, upd.domain_status, upd.domain_checked_at, upd.bot_policy, upd.failure_url, upd.failure_message, upd.emergency_until, upd.difficulty_strategy, upd.privacy_mode,
     old.name AS old_name,
     old.level AS old_level,
     old.growth AS old_growth,
     old.validity_interval AS old_validity_interval,
     old.allow_subdomains AS old_allow_subdomains,
     old.allow_localhost AS old_allow_localhost,
-    old.max_replay_count AS old_max_replay_count
+    old.max_replay_count AS old_max_replay_count,
+    old.allowed_clock_skew AS old_allowed_clock_skew,
+    old.remember_window AS old_remember_window,
+    old.widget_flags AS old_widget_flags,
+    old.twin_id AS old_twin_id,
+    old.trust_group AS old_trust_group,
+    old.claims AS old_claims,
+    old.differential_difficulty AS old_differential_difficulty,
+    old.bot_policy AS old_bot_policy,
+    old.failure_url AS old_failure_url,
+    old.failure_message AS old_failure_message,
+    old.difficulty_strategy AS old_difficulty_strategy
 FROM upd
 CROSS JOIN old
 `
 
 type UpdatePropertyParams struct {
-	ID               int32            `db:"id" json:"id"`
-	Name             string           `db:"name" json:"name"`
-	Level            pgtype.Int2      `db:"level" json:"level"`
-	Growth           DifficultyGrowth `db:"growth" json:"growth"`
-	ValidityInterval time.Duration    `db:"validity_interval" json:"validity_interval"`
-	AllowSubdomains  bool             `db:"allow_subdomains" json:"allow_subdomains"`
-	AllowLocalhost   bool             `db:"allow_localhost" json:"allow_localhost"`
-	MaxReplayCount   int32            `db:"max_replay_count" json:"max_replay_count"`
-	CreatorID        pgtype.Int4      `db:"creator_id" json:"creator_id"`
-	OrgID            pgtype.Int4      `db:"org_id" json:"org_id"`
+	ID                     int32            `db:"id" json:"id"`
+	Name                   string           `db:"name" json:"name"`
+	Level                  pgtype.Int2      `db:"level" json:"level"`
+	Growth                 DifficultyGrowth `db:"growth" json:"growth"`
+	ValidityInterval       time.Duration    `db:"validity_interval" json:"validity_interval"`
+	AllowSubdomains        bool             `db:"allow_subdomains" json:"allow_subdomains"`
+	AllowLocalhost         bool             `db:"allow_localhost" json:"allow_localhost"`
+	MaxReplayCount         int32            `db:"max_replay_count" json:"max_replay_count"`
+	CreatorID              pgtype.Int4      `db:"creator_id" json:"creator_id"`
+	OrgID                  pgtype.Int4      `db:"org_id" json:"org_id"`
+	AllowedClockSkew       time.Duration    `db:"allowed_clock_skew" json:"allowed_clock_skew"`
+	RememberWindow         time.Duration    `db:"remember_window" json:"remember_window"`
+	WidgetFlags            int16            `db:"widget_flags" json:"widget_flags"`
+	TwinID                 pgtype.Int4      `db:"twin_id" json:"twin_id"`
+	TrustGroup             string           `db:"trust_group" json:"trust_group"`
+	Claims                 string           `db:"claims" json:"claims"`
+	DifferentialDifficulty bool             `db:"differential_difficulty" json:"differential_difficulty"`
+	BotPolicy              BotPolicy        `db:"bot_policy" json:"bot_policy"`
+	FailureURL             string           `db:"failure_url" json:"failure_url"`
+	FailureMessage         string           `db:"failure_message" json:"failure_message"`
+	DifficultyStrategy     string           `db:"difficulty_strategy" json:"difficulty_strategy"`
 }
 
 type UpdatePropertyRow struct {
-	ID                  int32              `db:"id" json:"id"`
-	Name                string             `db:"name" json:"name"`
-	ExternalID          pgtype.UUID        `db:"external_id" json:"external_id"`
-	OrgID               pgtype.Int4        `db:"org_id" json:"org_id"`
-	CreatorID           pgtype.Int4        `db:"creator_id" json:"creator_id"`
-	OrgOwnerID          pgtype.Int4        `db:"org_owner_id" json:"org_owner_id"`
-	Domain              string             `db:"domain" json:"domain"`
-	Level               pgtype.Int2        `db:"level" json:"level"`
-	Salt                []byte             `db:"salt" json:"salt"`
-	Growth              DifficultyGrowth   `db:"growth" json:"growth"`
-	CreatedAt           pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt           pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
-	DeletedAt           pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
-	ValidityInterval    time.Duration      `db:"validity_interval" json:"validity_interval"`
-	AllowSubdomains     bool               `db:"allow_subdomains" json:"allow_subdomains"`
-	AllowLocalhost      bool               `db:"allow_localhost" json:"allow_localhost"`
-	MaxReplayCount      int32              `db:"max_replay_count" json:"max_replay_count"`
-	OldName             string             `db:"old_name" json:"old_name"`
-	OldLevel            pgtype.Int2        `db:"old_level" json:"old_level"`
-	OldGrowth           DifficultyGrowth   `db:"old_growth" json:"old_growth"`
-	OldValidityInterval time.Duration      `db:"old_validity_interval" json:"old_validity_interval"`
-	OldAllowSubdomains  bool               `db:"old_allow_subdomains" json:"old_allow_subdomains"`
-	OldAllowLocalhost   bool               `db:"old_allow_localhost" json:"old_allow_localhost"`
-	OldMaxReplayCount   int32              `db:"old_max_replay_count" json:"old_max_replay_count"`
+	ID                        int32                `db:"id" json:"id"`
+	Name                      string               `db:"name" json:"name"`
+	ExternalID                pgtype.UUID          `db:"external_id" json:"external_id"`
+	OrgID                     pgtype.Int4          `db:"org_id" json:"org_id"`
+	CreatorID                 pgtype.Int4          `db:"creator_id" json:"creator_id"`
+	OrgOwnerID                pgtype.Int4          `db:"org_owner_id" json:"org_owner_id"`
+	Domain                    string               `db:"domain" json:"domain"`
+	Level                     pgtype.Int2          `db:"level" json:"level"`
+	Salt                      []byte               `db:"salt" json:"salt"`
+	Growth                    DifficultyGrowth     `db:"growth" json:"growth"`
+	CreatedAt                 pgtype.Timestamptz   `db:"created_at" json:"created_at"`
+	UpdatedAt                 pgtype.Timestamptz   `db:"updated_at" json:"updated_at"`
+	DeletedAt                 pgtype.Timestamptz   `db:"deleted_at" json:"deleted_at"`
+	ValidityInterval          time.Duration        `db:"validity_interval" json:"validity_interval"`
+	AllowSubdomains           bool                 `db:"allow_subdomains" json:"allow_subdomains"`
+	AllowLocalhost            bool                 `db:"allow_localhost" json:"allow_localhost"`
+	MaxReplayCount            int32                `db:"max_replay_count" json:"max_replay_count"`
+	AllowedClockSkew          time.Duration        `db:"allowed_clock_skew" json:"allowed_clock_skew"`
+	RememberWindow            time.Duration        `db:"remember_window" json:"remember_window"`
+	WidgetFlags               int16                `db:"widget_flags" json:"widget_flags"`
+	Environment               PropertyEnvironment  `db:"environment" json:"environment"`
+	TwinID                    pgtype.Int4          `db:"twin_id" json:"twin_id"`
+	TrustGroup                string               `db:"trust_group" json:"trust_group"`
+	Claims                    string               `db:"claims" json:"claims"`
+	DifferentialDifficulty    bool                 `db:"differential_difficulty" json:"differential_difficulty"`
+	DomainStatus              PropertyDomainStatus `db:"domain_status" json:"domain_status"`
+	DomainCheckedAt           pgtype.Timestamptz   `db:"domain_checked_at" json:"domain_checked_at"`
+	BotPolicy                 BotPolicy            `db:"bot_policy" json:"bot_policy"`
+	FailureURL                string               `db:"failure_url" json:"failure_url"`
+	FailureMessage            string               `db:"failure_message" json:"failure_message"`
+	EmergencyUntil            pgtype.Timestamptz   `db:"emergency_until" json:"emergency_until"`
+	DifficultyStrategy        string               `db:"difficulty_strategy" json:"difficulty_strategy"`
+	PrivacyMode               string               `db:"privacy_mode" json:"privacy_mode"`
+	OldName                   string               `db:"old_name" json:"old_name"`
+	OldLevel                  pgtype.Int2          `db:"old_level" json:"old_level"`
+	OldGrowth                 DifficultyGrowth     `db:"old_growth" json:"old_growth"`
+	OldValidityInterval       time.Duration        `db:"old_validity_interval" json:"old_validity_interval"`
+	OldAllowSubdomains        bool                 `db:"old_allow_subdomains" json:"old_allow_subdomains"`
+	OldAllowLocalhost         bool                 `db:"old_allow_localhost" json:"old_allow_localhost"`
+	OldMaxReplayCount         int32                `db:"old_max_replay_count" json:"old_max_replay_count"`
+	OldAllowedClockSkew       time.Duration        `db:"old_allowed_clock_skew" json:"old_allowed_clock_skew"`
+	OldRememberWindow         time.Duration        `db:"old_remember_window" json:"old_remember_window"`
+	OldWidgetFlags            int16                `db:"old_widget_flags" json:"old_widget_flags"`
+	OldTwinID                 pgtype.Int4          `db:"old_twin_id" json:"old_twin_id"`
+	OldTrustGroup             string               `db:"old_trust_group" json:"old_trust_group"`
+	OldClaims                 string               `db:"old_claims" json:"old_claims"`
+	OldDifferentialDifficulty bool                 `db:"old_differential_difficulty" json:"old_differential_difficulty"`
+	OldBotPolicy              BotPolicy            `db:"old_bot_policy" json:"old_bot_policy"`
+	OldFailureURL             string               `db:"old_failure_url" json:"old_failure_url"`
+	OldFailureMessage         string               `db:"old_failure_message" json:"old_failure_message"`
+	OldDifficultyStrategy     string               `db:"old_difficulty_strategy" json:"old_difficulty_strategy"`
 }
 
 func (q *Queries) UpdateProperty(ctx context.Context, arg *UpdatePropertyParams) (*UpdatePropertyRow, error) {
@@ -628,6 +1337,17 @@ func (q *Queries) UpdateProperty(ctx context.Context, arg *UpdatePropertyParams)
 		arg.MaxReplayCount,
 		arg.CreatorID,
 		arg.OrgID,
+		arg.AllowedClockSkew,
+		arg.RememberWindow,
+		arg.WidgetFlags,
+		arg.TwinID,
+		arg.TrustGroup,
+		arg.Claims,
+		arg.DifferentialDifficulty,
+		arg.BotPolicy,
+		arg.FailureURL,
+		arg.FailureMessage,
+		arg.DifficultyStrategy,
 	)
 	var i UpdatePropertyRow
 	err := row.Scan(
@@ -648,6 +1368,22 @@ func (q *Queries) UpdateProperty(ctx context.Context, arg *UpdatePropertyParams)
 		&i.AllowSubdomains,
 		&i.AllowLocalhost,
 		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 		&i.OldName,
 		&i.OldLevel,
 		&i.OldGrowth,
@@ -655,6 +1391,136 @@ func (q *Queries) UpdateProperty(ctx context.Context, arg *UpdatePropertyParams)
 		&i.OldAllowSubdomains,
 		&i.OldAllowLocalhost,
 		&i.OldMaxReplayCount,
+		&i.OldAllowedClockSkew,
+		&i.OldRememberWindow,
+		&i.OldWidgetFlags,
+		&i.OldTwinID,
+		&i.OldTrustGroup,
+		&i.OldClaims,
+		&i.OldDifferentialDifficulty,
+		&i.OldBotPolicy,
+		&i.OldFailureURL,
+		&i.OldFailureMessage,
+		&i.OldDifficultyStrategy,
+	)
+	return &i, err
+}
+
+const updatePropertyDomainStatus = `-- name: UpdatePropertyDomainStatus :exec
+UPDATE backend.properties SET domain_status = $2, domain_checked_at = $3 WHERE id = $1
+`
+
+type UpdatePropertyDomainStatusParams struct {
+	ID              int32                `db:"id" json:"id"`
+	DomainStatus    PropertyDomainStatus `db:"domain_status" json:"domain_status"`
+	DomainCheckedAt pgtype.Timestamptz   `db:"domain_checked_at" json:"domain_checked_at"`
+}
+
+func (q *Queries) UpdatePropertyDomainStatus(ctx context.Context, arg *UpdatePropertyDomainStatusParams) error {
+	_, err := q.db.Exec(ctx, updatePropertyDomainStatus, arg.ID, arg.DomainStatus, arg.DomainCheckedAt)
+	return err
+}
+
+const updatePropertyEmergency = `-- name: UpdatePropertyEmergency :one
+UPDATE backend.properties SET emergency_until = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
+`
+
+type UpdatePropertyEmergencyParams struct {
+	ID             int32              `db:"id" json:"id"`
+	EmergencyUntil pgtype.Timestamptz `db:"emergency_until" json:"emergency_until"`
+}
+
+func (q *Queries) UpdatePropertyEmergency(ctx context.Context, arg *UpdatePropertyEmergencyParams) (*Property, error) {
+	row := q.db.QueryRow(ctx, updatePropertyEmergency, arg.ID, arg.EmergencyUntil)
+	var i Property
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.OrgID,
+		&i.CreatorID,
+		&i.OrgOwnerID,
+		&i.Domain,
+		&i.Level,
+		&i.Salt,
+		&i.Growth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ValidityInterval,
+		&i.AllowSubdomains,
+		&i.AllowLocalhost,
+		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
+	)
+	return &i, err
+}
+
+const updatePropertyPrivacyMode = `-- name: UpdatePropertyPrivacyMode :one
+UPDATE backend.properties SET privacy_mode = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, external_id, org_id, creator_id, org_owner_id, domain, level, salt, growth, created_at, updated_at, deleted_at, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, domain_status, domain_checked_at, bot_policy, failure_url, failure_message, emergency_until, difficulty_strategy, privacy_mode
+`
+
+type UpdatePropertyPrivacyModeParams struct {
+	ID          int32  `db:"id" json:"id"`
+	PrivacyMode string `db:"privacy_mode" json:"privacy_mode"`
+}
+
+func (q *Queries) UpdatePropertyPrivacyMode(ctx context.Context, arg *UpdatePropertyPrivacyModeParams) (*Property, error) {
+	row := q.db.QueryRow(ctx, updatePropertyPrivacyMode, arg.ID, arg.PrivacyMode)
+	var i Property
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ExternalID,
+		&i.OrgID,
+		&i.CreatorID,
+		&i.OrgOwnerID,
+		&i.Domain,
+		&i.Level,
+		&i.Salt,
+		&i.Growth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ValidityInterval,
+		&i.AllowSubdomains,
+		&i.AllowLocalhost,
+		&i.MaxReplayCount,
+		&i.AllowedClockSkew,
+		&i.RememberWindow,
+		&i.WidgetFlags,
+		&i.Environment,
+		&i.TwinID,
+		&i.TrustGroup,
+		&i.Claims,
+		&i.DifferentialDifficulty,
+		&i.DomainStatus,
+		&i.DomainCheckedAt,
+		&i.BotPolicy,
+		&i.FailureURL,
+		&i.FailureMessage,
+		&i.EmergencyUntil,
+		&i.DifficultyStrategy,
+		&i.PrivacyMode,
 	)
 	return &i, err
 }
@@ -11,90 +11,219 @@ import (
 )
 
 type Querier interface {
+	AddAPIKeysIPViolations(ctx context.Context, arg *AddAPIKeysIPViolationsParams) error
+	AddAPIKeysUsage(ctx context.Context, arg *AddAPIKeysUsageParams) error
+	AddRequestStats(ctx context.Context, arg *AddRequestStatsParams) error
+	AddUserToOrg(ctx context.Context, arg *AddUserToOrgParams) (*OrganizationUser, error)
+	AddVerifyStats(ctx context.Context, arg *AddVerifyStatsParams) error
+	// deltas is a JSON object of property count deltas by org ID
+	AdjustUserOrgSummariesProperties(ctx context.Context, arg *AdjustUserOrgSummariesPropertiesParams) (int64, error)
+	CancelSystemNotification(ctx context.Context, id int32) (*SystemNotification, error)
+	CompareAndSwapCache(ctx context.Context, arg *CompareAndSwapCacheParams) (int64, error)
+	ConcludeDifficultyExperiment(ctx context.Context, arg *ConcludeDifficultyExperimentParams) (*DifficultyExperiment, error)
 	CreateAPIKey(ctx context.Context, arg *CreateAPIKeyParams) (*APIKey, error)
 	CreateAsyncTask(ctx context.Context, arg *CreateAsyncTaskParams) (pgtype.UUID, error)
 	CreateAuditLogs(ctx context.Context, arg []*CreateAuditLogsParams) (int64, error)
 	CreateCache(ctx context.Context, arg *CreateCacheParams) error
 	CreateCacheMany(ctx context.Context, arg *CreateCacheManyParams) error
+	CreateDifficultyExperiment(ctx context.Context, arg *CreateDifficultyExperimentParams) (*DifficultyExperiment, error)
+	CreateLimitDecision(ctx context.Context, arg *CreateLimitDecisionParams) error
+	CreateNotificationOptOut(ctx context.Context, arg *CreateNotificationOptOutParams) error
+	CreateNotificationPayload(ctx context.Context, arg *CreateNotificationPayloadParams) error
 	CreateNotificationTemplate(ctx context.Context, arg *CreateNotificationTemplateParams) (*NotificationTemplate, error)
+	CreateOrgBillingContact(ctx context.Context, arg *CreateOrgBillingContactParams) (*BillingContact, error)
+	CreateOrgEmailDomain(ctx context.Context, arg *CreateOrgEmailDomainParams) (*OrgEmailDomain, error)
 	CreateOrganization(ctx context.Context, arg *CreateOrganizationParams) (*Organization, error)
+	CreateProperties(ctx context.Context, arg *CreatePropertiesParams) ([]*Property, error)
 	CreateProperty(ctx context.Context, arg *CreatePropertyParams) (*Property, error)
+	CreatePropertyBypassToken(ctx context.Context, arg *CreatePropertyBypassTokenParams) (*PropertyBypassToken, error)
+	CreatePropertyShareLink(ctx context.Context, arg *CreatePropertyShareLinkParams) (*PropertyShareLink, error)
+	CreateSandboxOrganization(ctx context.Context, arg *CreateSandboxOrganizationParams) (*Organization, error)
 	CreateSubscription(ctx context.Context, arg *CreateSubscriptionParams) (*Subscription, error)
 	CreateSystemNotification(ctx context.Context, arg *CreateSystemNotificationParams) (*SystemNotification, error)
 	CreateUser(ctx context.Context, arg *CreateUserParams) (*User, error)
 	CreateUserNotification(ctx context.Context, arg *CreateUserNotificationParams) (*UserNotification, error)
+	CreateUserSSOLink(ctx context.Context, arg *CreateUserSSOLinkParams) (*UserSSOLink, error)
 	DeleteAPIKey(ctx context.Context, arg *DeleteAPIKeyParams) (*APIKey, error)
 	DeleteCachedByKey(ctx context.Context, key string) error
 	DeleteDeletedRecords(ctx context.Context, deletedAt pgtype.Timestamptz) error
 	DeleteExpiredCache(ctx context.Context) error
+	DeleteExpiredPropertyBypassTokens(ctx context.Context, expiresAt pgtype.Timestamptz) error
+	DeleteExpiredPropertyShareLinks(ctx context.Context, expiresAt pgtype.Timestamptz) error
+	DeleteExpiredUserSessions(ctx context.Context) error
 	DeleteLock(ctx context.Context, name string) error
+	DeleteNotificationOptOut(ctx context.Context, arg *DeleteNotificationOptOutParams) error
+	DeleteOldAPIKeysUsage(ctx context.Context, lastUsedAt pgtype.Timestamptz) error
 	DeleteOldAsyncTasks(ctx context.Context, createdAt pgtype.Timestamptz) error
 	DeleteOldAuditLogs(ctx context.Context, createdAt pgtype.Timestamptz) error
+	DeleteOldLimitDecisions(ctx context.Context, createdAt pgtype.Timestamptz) error
+	DeleteOrgAPIKeys(ctx context.Context, orgID pgtype.Int4) ([]*APIKey, error)
+	DeleteOrgBillingContact(ctx context.Context, arg *DeleteOrgBillingContactParams) (*BillingContact, error)
+	DeleteOrgEmailDomain(ctx context.Context, arg *DeleteOrgEmailDomainParams) (*OrgEmailDomain, error)
+	DeleteOrgIPAllowlist(ctx context.Context, orgID int32) (*OrgIPAllowlist, error)
+	DeleteOrgProperties(ctx context.Context, orgID pgtype.Int4) ([]*Property, error)
 	DeleteOrganizations(ctx context.Context, dollar_1 []int32) error
+	DeleteOrganizationsStats(ctx context.Context, orgIds []int32) error
+	DeleteOwnedLock(ctx context.Context, arg *DeleteOwnedLockParams) (int64, error)
 	DeletePendingUserNotification(ctx context.Context, arg *DeletePendingUserNotificationParams) error
 	DeleteProcessedUserNotifications(ctx context.Context, processedAt pgtype.Timestamptz) error
 	DeleteProperties(ctx context.Context, dollar_1 []int32) error
+	DeletePropertiesStats(ctx context.Context, propertyIds []int32) error
+	DeletePropertyAccessList(ctx context.Context, propertyID int32) (*PropertyAccessList, error)
+	DeletePropertyBypassToken(ctx context.Context, arg *DeletePropertyBypassTokenParams) (*PropertyBypassToken, error)
+	DeleteStatsBefore(ctx context.Context, before pgtype.Timestamptz) error
+	DeleteStatsDigest(ctx context.Context, arg *DeleteStatsDigestParams) error
 	DeleteUnprocessedUserNotifications(ctx context.Context, scheduledAt pgtype.Timestamptz) error
+	DeleteUnusedNotificationPayloads(ctx context.Context, updatedAt pgtype.Timestamptz) error
 	DeleteUnusedNotificationTemplates(ctx context.Context, arg *DeleteUnusedNotificationTemplatesParams) error
 	DeleteUserAPIKeys(ctx context.Context, userID pgtype.Int4) error
+	DeleteUserDevice(ctx context.Context, arg *DeleteUserDeviceParams) (*UserDevice, error)
+	DeleteUserOrgSummaries(ctx context.Context, userIds []int32) error
+	DeleteUserSessions(ctx context.Context, sessionIds []string) error
 	DeleteUsers(ctx context.Context, dollar_1 []int32) error
+	DeleteUsersStats(ctx context.Context, userIds []int32) error
 	FindUserOrgByName(ctx context.Context, arg *FindUserOrgByNameParams) (*Organization, error)
 	GetAPIKeyByExternalID(ctx context.Context, externalID pgtype.UUID) (*APIKey, error)
 	GetAsyncTask(ctx context.Context, id pgtype.UUID) (*AsyncTask, error)
+	GetBillingContactsForOrgs(ctx context.Context, dollar_1 []int32) ([]*GetBillingContactsForOrgsRow, error)
 	GetCachedByKey(ctx context.Context, key string) ([]byte, error)
+	GetCachedKeys(ctx context.Context, keys []string) ([]string, error)
+	GetEnforcedUserQuotas(ctx context.Context, periodStart pgtype.Timestamptz) ([]*UserQuota, error)
+	GetExistingOrganizationIDs(ctx context.Context, ids []int32) ([]int32, error)
+	GetExistingPropertyIDs(ctx context.Context, ids []int32) ([]int32, error)
 	GetLastActiveSystemNotification(ctx context.Context, arg *GetLastActiveSystemNotificationParams) (*SystemNotification, error)
+	GetLatestTableAuditLog(ctx context.Context, entityTable string) (*AuditLog, error)
 	GetLock(ctx context.Context, name string) (*Lock, error)
+	GetNotificationOptOutsForUsers(ctx context.Context, dollar_1 []int32) ([]*NotificationPreference, error)
 	GetNotificationTemplateByHash(ctx context.Context, externalID string) (*NotificationTemplate, error)
 	GetOrgAuditLogs(ctx context.Context, arg *GetOrgAuditLogsParams) ([]*GetOrgAuditLogsRow, error)
+	GetOrgBillingContacts(ctx context.Context, orgID int32) ([]*BillingContact, error)
+	GetOrgBillingSettings(ctx context.Context, orgID int32) (*OrgBillingSetting, error)
+	GetOrgEmailDomains(ctx context.Context, orgID int32) ([]*OrgEmailDomain, error)
+	GetOrgIPAllowlist(ctx context.Context, orgID int32) (*OrgIPAllowlist, error)
 	GetOrgProperties(ctx context.Context, arg *GetOrgPropertiesParams) ([]*Property, error)
+	GetOrgPropertiesAfter(ctx context.Context, arg *GetOrgPropertiesAfterParams) ([]*Property, error)
 	GetOrgPropertiesCount(ctx context.Context, orgID pgtype.Int4) (int64, error)
 	GetOrgPropertyByName(ctx context.Context, arg *GetOrgPropertyByNameParams) (*Property, error)
+	GetOrgPropertyIDs(ctx context.Context, orgID pgtype.Int4) ([]int32, error)
+	GetOrgUsageStats(ctx context.Context, arg *GetOrgUsageStatsParams) ([]*GetOrgUsageStatsRow, error)
 	GetOrganizationUsers(ctx context.Context, orgID int32) ([]*GetOrganizationUsersRow, error)
+	GetOrganizationUsersCount(ctx context.Context, arg *GetOrganizationUsersCountParams) (int64, error)
+	GetOrganizationUsersPage(ctx context.Context, arg *GetOrganizationUsersPageParams) ([]*GetOrganizationUsersPageRow, error)
 	GetOrganizationWithAccess(ctx context.Context, arg *GetOrganizationWithAccessParams) (*GetOrganizationWithAccessRow, error)
+	GetOrgsMemberIDs(ctx context.Context, orgIds []int32) ([]int32, error)
+	GetOrgsPropertiesCount(ctx context.Context, orgIds []int32) ([]*GetOrgsPropertiesCountRow, error)
 	GetPendingAsyncTasks(ctx context.Context, arg *GetPendingAsyncTasksParams) ([]*GetPendingAsyncTasksRow, error)
+	GetPendingStatsDigests(ctx context.Context, arg *GetPendingStatsDigestsParams) ([]*GetPendingStatsDigestsRow, error)
 	GetPendingUserNotifications(ctx context.Context, arg *GetPendingUserNotificationsParams) ([]*GetPendingUserNotificationsRow, error)
 	GetProperties(ctx context.Context, limit int32) ([]*Property, error)
 	GetPropertiesByExternalID(ctx context.Context, dollar_1 []pgtype.UUID) ([]*Property, error)
 	GetPropertiesByID(ctx context.Context, dollar_1 []int32) ([]*Property, error)
+	GetPropertiesForDomainCheck(ctx context.Context, arg *GetPropertiesForDomainCheckParams) ([]*Property, error)
+	GetPropertiesHourlyStats(ctx context.Context, hour pgtype.Timestamptz) ([]*GetPropertiesHourlyStatsRow, error)
+	GetPropertiesStats(ctx context.Context, arg *GetPropertiesStatsParams) ([]*GetPropertiesStatsRow, error)
+	GetPropertyAccessList(ctx context.Context, propertyID int32) (*PropertyAccessList, error)
 	GetPropertyAuditLogs(ctx context.Context, arg *GetPropertyAuditLogsParams) ([]*GetPropertyAuditLogsRow, error)
+	GetPropertyBaselines(ctx context.Context, arg *GetPropertyBaselinesParams) ([]*PropertyBaseline, error)
 	GetPropertyByExternalID(ctx context.Context, externalID pgtype.UUID) (*Property, error)
 	GetPropertyByID(ctx context.Context, id int32) (*Property, error)
+	GetPropertyBypassTokens(ctx context.Context, propertyID int32) ([]*PropertyBypassToken, error)
+	GetPropertyDifficultyExperiments(ctx context.Context, arg *GetPropertyDifficultyExperimentsParams) ([]*DifficultyExperiment, error)
+	GetPropertyRequestStatsByPeriod(ctx context.Context, arg *GetPropertyRequestStatsByPeriodParams) ([]*GetPropertyRequestStatsByPeriodRow, error)
+	GetPropertyRequestStatsSince(ctx context.Context, arg *GetPropertyRequestStatsSinceParams) ([]*GetPropertyRequestStatsSinceRow, error)
+	GetPropertyShareLink(ctx context.Context, externalID pgtype.UUID) (*PropertyShareLink, error)
+	GetPropertyShareLinks(ctx context.Context, propertyID int32) ([]*PropertyShareLink, error)
+	GetPropertyVerifyStatsByPeriod(ctx context.Context, arg *GetPropertyVerifyStatsByPeriodParams) ([]*GetPropertyVerifyStatsByPeriodRow, error)
+	GetRunningDifficultyExperiments(ctx context.Context) ([]*DifficultyExperiment, error)
+	GetSandboxOrganizations(ctx context.Context, arg *GetSandboxOrganizationsParams) ([]*Organization, error)
+	GetScheduledSystemNotifications(ctx context.Context, endDate pgtype.Timestamptz) ([]*SystemNotification, error)
+	GetSentUserNotificationsCounts(ctx context.Context, arg *GetSentUserNotificationsCountsParams) ([]*GetSentUserNotificationsCountsRow, error)
 	GetSoftDeletedOrganizations(ctx context.Context, arg *GetSoftDeletedOrganizationsParams) ([]*GetSoftDeletedOrganizationsRow, error)
 	GetSoftDeletedProperties(ctx context.Context, arg *GetSoftDeletedPropertiesParams) ([]*GetSoftDeletedPropertiesRow, error)
 	GetSoftDeletedUsers(ctx context.Context, arg *GetSoftDeletedUsersParams) ([]*GetSoftDeletedUsersRow, error)
 	GetSubscriptionByID(ctx context.Context, id int32) (*Subscription, error)
+	GetSystemAuditLogs(ctx context.Context, arg *GetSystemAuditLogsParams) ([]*GetSystemAuditLogsRow, error)
 	GetSystemNotificationById(ctx context.Context, id int32) (*SystemNotification, error)
+	GetTopVerifiedProperties(ctx context.Context, limit int32) ([]int32, error)
+	GetTraceAuditLogs(ctx context.Context, arg *GetTraceAuditLogsParams) ([]*GetTraceAuditLogsRow, error)
 	GetTrialUsers(ctx context.Context, arg *GetTrialUsersParams) ([]*User, error)
 	GetUserAPIKeyByName(ctx context.Context, arg *GetUserAPIKeyByNameParams) (*APIKey, error)
 	GetUserAPIKeys(ctx context.Context, userID pgtype.Int4) ([]*APIKey, error)
+	GetUserAPIKeysLastUsed(ctx context.Context, userID pgtype.Int4) ([]*GetUserAPIKeysLastUsedRow, error)
 	GetUserAuditLogs(ctx context.Context, arg *GetUserAuditLogsParams) ([]*GetUserAuditLogsRow, error)
+	GetUserAuditLogsBefore(ctx context.Context, arg *GetUserAuditLogsBeforeParams) ([]*GetUserAuditLogsBeforeRow, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetUserByID(ctx context.Context, id int32) (*User, error)
+	GetUserBySSOLink(ctx context.Context, arg *GetUserBySSOLinkParams) (*User, error)
+	GetUserDevices(ctx context.Context, userID int32) ([]*UserDevice, error)
+	GetUserLimitDecisions(ctx context.Context, arg *GetUserLimitDecisionsParams) ([]*LimitDecision, error)
+	GetUserMonthlyRequestStats(ctx context.Context, arg *GetUserMonthlyRequestStatsParams) ([]*GetUserMonthlyRequestStatsRow, error)
+	GetUserNotificationOptOuts(ctx context.Context, userID int32) ([]string, error)
+	GetUserNotifications(ctx context.Context, arg *GetUserNotificationsParams) ([]*UserNotification, error)
+	GetUserOrgSummaries(ctx context.Context, userIds []int32) ([]*UserOrgSummary, error)
+	GetUserOrgSummary(ctx context.Context, userID int32) (*UserOrgSummary, error)
+	GetUserOrgSummaryIDsAfter(ctx context.Context, arg *GetUserOrgSummaryIDsAfterParams) ([]int32, error)
 	GetUserOrganizations(ctx context.Context, userID pgtype.Int4) ([]*GetUserOrganizationsRow, error)
 	GetUserPropertiesCount(ctx context.Context, orgOwnerID pgtype.Int4) (int64, error)
+	GetUserPropertiesOverLimit(ctx context.Context, arg *GetUserPropertiesOverLimitParams) ([]*GetUserPropertiesOverLimitRow, error)
+	GetUserQuota(ctx context.Context, userID int32) (*UserQuota, error)
+	GetUserQuotas(ctx context.Context, userIds []int32) ([]*UserQuota, error)
+	GetUserSSOLinks(ctx context.Context, userID int32) ([]*UserSSOLink, error)
+	GetUserSeatsCount(ctx context.Context, userID pgtype.Int4) (int64, error)
+	GetUserSessionIDs(ctx context.Context, userID int32) ([]string, error)
+	GetUserStatsDigests(ctx context.Context, userID int32) ([]*StatsDigest, error)
+	GetUsersPage(ctx context.Context, arg *GetUsersPageParams) ([]*User, error)
+	GetUsersWithSubscriptions(ctx context.Context, dollar_1 []int32) ([]*GetUsersWithSubscriptionsRow, error)
 	GetUsersWithoutSubscription(ctx context.Context, dollar_1 []int32) ([]*User, error)
+	GetVerifiedOrgEmailDomain(ctx context.Context, domain string) (*GetVerifiedOrgEmailDomainRow, error)
+	GetVerifyHourlyStats(ctx context.Context, arg *GetVerifyHourlyStatsParams) ([]*GetVerifyHourlyStatsRow, error)
 	InsertLock(ctx context.Context, arg *InsertLockParams) (*Lock, error)
 	InviteUserToOrg(ctx context.Context, arg *InviteUserToOrgParams) (*OrganizationUser, error)
 	MoveProperty(ctx context.Context, arg *MovePropertyParams) (*Property, error)
 	Ping(ctx context.Context) (int32, error)
 	RemoveUserFromOrg(ctx context.Context, arg *RemoveUserFromOrgParams) error
+	RevokePropertyShareLink(ctx context.Context, arg *RevokePropertyShareLinkParams) (*PropertyShareLink, error)
 	RotateAPIKey(ctx context.Context, arg *RotateAPIKeyParams) (*APIKey, error)
+	SearchOrgAuditLogs(ctx context.Context, arg *SearchOrgAuditLogsParams) ([]*SearchOrgAuditLogsRow, error)
+	SearchPropertyAuditLogs(ctx context.Context, arg *SearchPropertyAuditLogsParams) ([]*SearchPropertyAuditLogsRow, error)
+	SearchUserAuditLogs(ctx context.Context, arg *SearchUserAuditLogsParams) ([]*SearchUserAuditLogsRow, error)
+	SearchUserAuditLogsBefore(ctx context.Context, arg *SearchUserAuditLogsBeforeParams) ([]*SearchUserAuditLogsBeforeRow, error)
 	SoftDeleteProperties(ctx context.Context, arg *SoftDeletePropertiesParams) ([]*Property, error)
 	SoftDeleteProperty(ctx context.Context, id int32) (*Property, error)
 	SoftDeleteUser(ctx context.Context, id int32) (*User, error)
 	SoftDeleteUserOrganization(ctx context.Context, arg *SoftDeleteUserOrganizationParams) error
 	SoftDeleteUserOrganizations(ctx context.Context, userID pgtype.Int4) error
+	TakeCachedByKey(ctx context.Context, key string) ([]byte, error)
 	UpdateAPIKey(ctx context.Context, arg *UpdateAPIKeyParams) (*APIKey, error)
 	UpdateAsyncTask(ctx context.Context, arg *UpdateAsyncTaskParams) error
 	UpdateAttemptedUserNotifications(ctx context.Context, dollar_1 []int32) error
 	UpdateCacheExpiration(ctx context.Context, arg *UpdateCacheExpirationParams) error
 	UpdateInternalSubscriptions(ctx context.Context, arg *UpdateInternalSubscriptionsParams) error
+	UpdateOrgEmailDomainAutoJoin(ctx context.Context, arg *UpdateOrgEmailDomainAutoJoinParams) (*OrgEmailDomain, error)
 	UpdateOrgMembershipLevel(ctx context.Context, arg *UpdateOrgMembershipLevelParams) error
 	UpdateOrganization(ctx context.Context, arg *UpdateOrganizationParams) (*Organization, error)
 	UpdateProcessedUserNotifications(ctx context.Context, arg *UpdateProcessedUserNotificationsParams) error
 	UpdateProperty(ctx context.Context, arg *UpdatePropertyParams) (*UpdatePropertyRow, error)
+	UpdatePropertyDomainStatus(ctx context.Context, arg *UpdatePropertyDomainStatusParams) error
+	UpdatePropertyEmergency(ctx context.Context, arg *UpdatePropertyEmergencyParams) (*Property, error)
+	UpdatePropertyPrivacyMode(ctx context.Context, arg *UpdatePropertyPrivacyModeParams) (*Property, error)
+	UpdateStatsDigestsSent(ctx context.Context, arg *UpdateStatsDigestsSentParams) error
+	UpdateSuppressedUserNotifications(ctx context.Context, arg *UpdateSuppressedUserNotificationsParams) error
 	UpdateUserData(ctx context.Context, arg *UpdateUserDataParams) (*User, error)
+	UpdateUserNotificationsSchedule(ctx context.Context, arg *UpdateUserNotificationsScheduleParams) error
 	UpdateUserSubscription(ctx context.Context, arg *UpdateUserSubscriptionParams) (*User, error)
+	UpsertOrgBillingSettings(ctx context.Context, arg *UpsertOrgBillingSettingsParams) (*OrgBillingSetting, error)
+	UpsertOrgIPAllowlist(ctx context.Context, arg *UpsertOrgIPAllowlistParams) (*OrgIPAllowlist, error)
+	UpsertPropertyAccessList(ctx context.Context, arg *UpsertPropertyAccessListParams) (*PropertyAccessList, error)
+	UpsertPropertyBaseline(ctx context.Context, arg *UpsertPropertyBaselineParams) error
+	UpsertStatsDigest(ctx context.Context, arg *UpsertStatsDigestParams) error
+	UpsertUserDevice(ctx context.Context, arg *UpsertUserDeviceParams) (*UserDevice, error)
+	UpsertUserLocale(ctx context.Context, arg *UpsertUserLocaleParams) error
+	UpsertUserOrgSummary(ctx context.Context, arg *UpsertUserOrgSummaryParams) error
+	UpsertUserQuota(ctx context.Context, arg *UpsertUserQuotaParams) error
+	UpsertUserSessions(ctx context.Context, arg *UpsertUserSessionsParams) error
+	UsePropertyBypassToken(ctx context.Context, arg *UsePropertyBypassTokenParams) (*PropertyBypassToken, error)
+	VerifyOrgEmailDomain(ctx context.Context, arg *VerifyOrgEmailDomainParams) (*OrgEmailDomain, error)
 }
 
 var _ Querier = (*Queries)(nil)
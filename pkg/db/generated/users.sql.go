@@ -183,6 +183,90 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (*User, error) {
 	return &i, err
 }
 
+const getUsersPage = `-- name: GetUsersPage :many
+SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at FROM backend.users WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2
+`
+
+type GetUsersPageParams struct {
+	Limit  int32 `db:"limit" json:"limit"`
+	Offset int32 `db:"offset" json:"offset"`
+}
+
+func (q *Queries) GetUsersPage(ctx context.Context, arg *GetUsersPageParams) ([]*User, error) {
+	rows, err := q.db.Query(ctx, getUsersPage, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.SubscriptionID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersWithSubscriptions = `-- name: GetUsersWithSubscriptions :many
+SELECT u.id, u.name, u.email, u.subscription_id, u.created_at, u.updated_at, u.deleted_at, s.external_product_id, s.external_price_id, s.status, s.source
+FROM backend.users u
+LEFT JOIN backend.subscriptions s ON u.subscription_id = s.id
+WHERE u.id = ANY($1::INT[])
+`
+
+type GetUsersWithSubscriptionsRow struct {
+	User              User                   `db:"user" json:"user"`
+	ExternalProductID pgtype.Text            `db:"external_product_id" json:"external_product_id"`
+	ExternalPriceID   pgtype.Text            `db:"external_price_id" json:"external_price_id"`
+	Status            pgtype.Text            `db:"status" json:"status"`
+	Source            NullSubscriptionSource `db:"source" json:"source"`
+}
+
+func (q *Queries) GetUsersWithSubscriptions(ctx context.Context, dollar_1 []int32) ([]*GetUsersWithSubscriptionsRow, error) {
+	rows, err := q.db.Query(ctx, getUsersWithSubscriptions, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetUsersWithSubscriptionsRow
+	for rows.Next() {
+		var i GetUsersWithSubscriptionsRow
+		if err := rows.Scan(
+			&i.User.ID,
+			&i.User.Name,
+			&i.User.Email,
+			&i.User.SubscriptionID,
+			&i.User.CreatedAt,
+			&i.User.UpdatedAt,
+			&i.User.DeletedAt,
+			&i.ExternalProductID,
+			&i.ExternalPriceID,
+			&i.Status,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUsersWithoutSubscription = `-- name: GetUsersWithoutSubscription :many
 SELECT id, name, email, subscription_id, created_at, updated_at, deleted_at FROM backend.users where id = ANY($1::INT[]) AND (subscription_id IS NULL OR deleted_at IS NOT NULL)
 `
@@ -36,18 +36,25 @@ func MigrateClickHouse(ctx context.Context, db *sql.DB, cfg common.ConfigStore,
 	}
 
 	dbCfg := cfg.Get(common.ClickHouseDBKey)
-	const migrationsTable = "private_captcha_migrations"
 
-	return MigrateClickhouseEx(common.TraceContext(ctx, "clickhouse"), db, clickhouseMigrationsFS, dbCfg.Value(), migrationsTable, up)
+	ctx = common.TraceContext(ctx, "clickhouse")
+
+	if err := MigrateClickhouseEx(ctx, db, clickhouseMigrationsFS, dbCfg.Value(), clickhouseMigrationsTable, up); err != nil {
+		return err
+	}
+
+	if !up {
+		return nil
+	}
+
+	return ApplyVerifyRetention(ctx, db, NewVerifyRetention(cfg))
 }
 
 func MigratePostgres(ctx context.Context, pool *pgxpool.Pool, cfg common.ConfigStore, planService billing.PlanService, up bool) error {
-	const migrationTable = "private_captcha_migrations"
-
 	migrateCtx := NewPostgresMigrateContext(ctx, cfg, planService)
 	tplFS := NewTemplateFS(postgresMigrationsFS, migrateCtx)
 
-	return MigratePostgresEx(common.TraceContext(ctx, "postgres"), pool, tplFS, "migrations/postgres", migrationTable, up)
+	return MigratePostgresEx(common.TraceContext(ctx, "postgres"), pool, tplFS, postgresMigrationsPath, postgresMigrationsTable, up)
 }
 
 func clickHouseUser(cfg common.ConfigStore, admin bool) string {
@@ -74,6 +81,11 @@ func connectEx(ctx context.Context, cfg common.ConfigStore, timeout time.Duratio
 	errs, ctx := errgroup.WithContext(ctx)
 
 	errs.Go(func() error {
+		if IsPostgresTimeSeries(cfg) {
+			slog.InfoContext(ctx, "Skipping ClickHouse connection", "timeseries", TimeSeriesBackendPostgres)
+			return nil
+		}
+
 		opts := ClickHouseConnectOpts{
 			Host:     cfg.Get(common.ClickHouseHostKey).Value(),
 			Database: cfg.Get(common.ClickHouseDBKey).Value(),
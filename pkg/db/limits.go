@@ -14,13 +14,61 @@ type SubscriptionLimits interface {
 	CheckOrgsLimit(ctx context.Context, userID int32, subscr *dbgen.Subscription) (bool, int, error)
 	CheckOrgMembersLimit(ctx context.Context, orgID int32, subscr *dbgen.Subscription) (bool, int, error)
 	CheckPropertiesLimit(ctx context.Context, userID int32, subscr *dbgen.Subscription) (bool, int, error)
+	// CheckOrgPropertiesLimit is CheckPropertiesLimit() for a specific org (sandbox orgs have a fixed limit instead)
+	CheckOrgPropertiesLimit(ctx context.Context, org *dbgen.Organization, ownerID int32, subscr *dbgen.Subscription) (bool, int, error)
+	// CheckSeatsLimit verifies that organizations of the owner can take one more seat on per-seat plans
+	CheckSeatsLimit(ctx context.Context, ownerID int32, subscr *dbgen.Subscription) (bool, int, error)
+	// SyncSeats updates the seats quantity of the subscription with the billing provider after members changes
+	SyncSeats(ctx context.Context, ownerID int32, subscr *dbgen.Subscription) error
+	SeatsCount(ctx context.Context, ownerID int32) (int, error)
 	RequestsLimit(ctx context.Context, subscr *dbgen.Subscription) (int64, error)
 	PropertiesLimit(ctx context.Context, subscr *dbgen.Subscription) (int, error)
 	OrgsLimit(ctx context.Context, subscr *dbgen.Subscription) (int, error)
+	Limits(ctx context.Context, subscr *dbgen.Subscription) (*PlanLimits, error)
+	// RecordDecision stores inputs of the rejection due to limits so that support can explain it later
+	RecordDecision(ctx context.Context, decision *LimitDecision)
+}
+
+const (
+	LimitResourceOrgs         = "orgs"
+	LimitResourceOrgMembers   = "org_members"
+	LimitResourceProperties   = "properties"
+	LimitResourceRequests     = "requests"
+	LimitResourceSeats        = "seats"
+	LimitResourceSubscription = "subscription"
+)
+
+type LimitDecision struct {
+	Resource     string
+	Code         common.StatusCode
+	Source       common.AuditLogSource
+	UserID       int32
+	Org          *dbgen.Organization
+	Subscription *dbgen.Subscription
+	// the second result of the Check*Limit() (count - limit)
+	Extra     int
+	Requested int
+	Err       error
+}
+
+// PlanLimits are effective limits of the subscription plan (zero means "unlimited")
+type PlanLimits struct {
+	Requests   int64
+	Properties int
+	Orgs       int
+	OrgMembers int
+	// seats included in per-seat plans (zero means the plan is not billed per seat)
+	Seats int
+	// default rate limit for puzzle-scoped API keys
+	APIRequestsPerSecond float64
+	// rate of puzzles issued per property (issuance quota)
+	PuzzleRequestsPerSecond float64
 }
 
 var (
 	ErrNoActiveSubscription = errors.New("subscription is not active or nil")
+	ErrSeatsUpdate          = errors.New("failed to update subscription seats")
+	ErrMembersLimit         = errors.New("organization members limit reached")
 )
 
 type SubscriptionLimitsImpl struct {
@@ -56,7 +104,8 @@ func (sl *SubscriptionLimitsImpl) CheckOrgsLimit(ctx context.Context, userID int
 	// NOTE: this should be freshly cached as we should have just rendered the dashboard
 	if orgs, err := sl.store.Impl().RetrieveUserOrganizations(ctx, userID); err == nil {
 		for _, org := range orgs {
-			if org.Level == dbgen.AccessLevelOwner {
+			// sandbox org is not counted towards the plan limit
+			if (org.Level == dbgen.AccessLevelOwner) && !org.Organization.Sandbox {
 				count++
 			}
 		}
@@ -116,49 +165,211 @@ func (sl *SubscriptionLimitsImpl) CheckPropertiesLimit(ctx context.Context, user
 	return ok, int(count) - plan.PropertiesLimit(), nil
 }
 
-func (sl *SubscriptionLimitsImpl) RequestsLimit(ctx context.Context, subscr *dbgen.Subscription) (int64, error) {
-	if (subscr == nil) || !sl.planService.IsSubscriptionActive(subscr.Status) {
-		return 0, ErrNoActiveSubscription
+// NewPropertiesAllowance returns how many of the requested properties fit into the result of properties limit check
+func NewPropertiesAllowance(ok bool, extra, requested int) int {
+	if !ok {
+		return 0
 	}
 
-	plan, err := sl.planService.FindPlan(subscr.ExternalProductID, subscr.ExternalPriceID, sl.Stage,
-		IsInternalSubscription(subscr.Source))
+	// unlimited plans pass the check regardless of the count, while limited ones are always below the limit
+	if extra >= 0 {
+		return requested
+	}
+
+	return min(-extra, requested)
+}
+
+func (sl *SubscriptionLimitsImpl) CheckOrgPropertiesLimit(ctx context.Context, org *dbgen.Organization, ownerID int32, subscr *dbgen.Subscription) (bool, int, error) {
+	if !org.Sandbox {
+		return sl.CheckPropertiesLimit(ctx, ownerID, subscr)
+	}
+
+	count, err := sl.store.Impl().RetrieveOrgPropertiesCount(ctx, org.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve sandbox properties count", "orgID", org.ID, common.ErrAttr(err))
+		return false, 0, err
+	}
+
+	return count < SandboxPropertiesLimit, int(count) - SandboxPropertiesLimit, nil
+}
+
+// SeatsCount returns seats taken by organizations of the owner, including the owner themselves
+func (sl *SubscriptionLimitsImpl) SeatsCount(ctx context.Context, ownerID int32) (int, error) {
+	count, err := sl.store.Impl().RetrieveUserSeatsCount(ctx, ownerID)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to find billing plan", "productID", subscr.ExternalProductID, "priceID", subscr.ExternalPriceID, common.ErrAttr(err))
 		return 0, err
+	}
 
+	return count + 1, nil
+}
+
+// Seats above the included ones can only be added to paid subscriptions (with the billing provider), for which the
+// check passes and seats have to be synced (SyncSeats) after the member was added. For other subscriptions it fails
+// and users have to upgrade.
+func (sl *SubscriptionLimitsImpl) CheckSeatsLimit(ctx context.Context, ownerID int32, subscr *dbgen.Subscription) (bool, int, error) {
+	plan, err := sl.findPlan(ctx, subscr)
+	if err != nil {
+		return false, 0, err
 	}
-	return plan.RequestsLimit(), nil
+
+	included := plan.IncludedSeats()
+	if included == 0 {
+		return true, 0, nil
+	}
+
+	count, err := sl.SeatsCount(ctx, ownerID)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if count < included {
+		return true, count - included, nil
+	}
+
+	if IsInternalSubscription(subscr.Source) || !subscr.ExternalSubscriptionID.Valid {
+		return false, count - included, nil
+	}
+
+	return true, count - included, nil
 }
 
-func (sl *SubscriptionLimitsImpl) PropertiesLimit(ctx context.Context, subscr *dbgen.Subscription) (int, error) {
+func (sl *SubscriptionLimitsImpl) SyncSeats(ctx context.Context, ownerID int32, subscr *dbgen.Subscription) error {
+	plan, err := sl.findPlan(ctx, subscr)
+	if err != nil {
+		return err
+	}
+
+	if (plan.IncludedSeats() == 0) || IsInternalSubscription(subscr.Source) || !subscr.ExternalSubscriptionID.Valid {
+		return nil
+	}
+
+	count, err := sl.SeatsCount(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	// included seats are billed regardless of usage
+	seats := max(count, plan.IncludedSeats())
+	if err := sl.planService.UpdateSubscriptionSeats(ctx, subscr.ExternalSubscriptionID.String, seats); err != nil {
+		slog.ErrorContext(ctx, "Failed to sync subscription seats", "subscriptionID", subscr.ID, "seats", seats, common.ErrAttr(err))
+		return ErrSeatsUpdate
+	}
+
+	slog.InfoContext(ctx, "Synced subscription seats", "subscriptionID", subscr.ID, "seats", seats)
+
+	return nil
+}
+
+func (sl *SubscriptionLimitsImpl) findPlan(ctx context.Context, subscr *dbgen.Subscription) (billing.Plan, error) {
 	if (subscr == nil) || !sl.planService.IsSubscriptionActive(subscr.Status) {
-		return 0, ErrNoActiveSubscription
+		return nil, ErrNoActiveSubscription
 	}
 
 	plan, err := sl.planService.FindPlan(subscr.ExternalProductID, subscr.ExternalPriceID, sl.Stage,
 		IsInternalSubscription(subscr.Source))
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to find billing plan", "productID", subscr.ExternalProductID, "priceID", subscr.ExternalPriceID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func (sl *SubscriptionLimitsImpl) RequestsLimit(ctx context.Context, subscr *dbgen.Subscription) (int64, error) {
+	plan, err := sl.findPlan(ctx, subscr)
+	if err != nil {
 		return 0, err
+	}
+
+	return plan.RequestsLimit(), nil
+}
 
+func (sl *SubscriptionLimitsImpl) PropertiesLimit(ctx context.Context, subscr *dbgen.Subscription) (int, error) {
+	plan, err := sl.findPlan(ctx, subscr)
+	if err != nil {
+		return 0, err
 	}
+
 	return plan.PropertiesLimit(), nil
 }
 
 func (sl *SubscriptionLimitsImpl) OrgsLimit(ctx context.Context, subscr *dbgen.Subscription) (int, error) {
-	if (subscr == nil) || !sl.planService.IsSubscriptionActive(subscr.Status) {
-		return 0, ErrNoActiveSubscription
+	plan, err := sl.findPlan(ctx, subscr)
+	if err != nil {
+		return 0, err
 	}
 
-	plan, err := sl.planService.FindPlan(subscr.ExternalProductID, subscr.ExternalPriceID, sl.Stage,
-		IsInternalSubscription(subscr.Source))
+	return plan.OrgsLimit(), nil
+}
+
+func (sl *SubscriptionLimitsImpl) Limits(ctx context.Context, subscr *dbgen.Subscription) (*PlanLimits, error) {
+	plan, err := sl.findPlan(ctx, subscr)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to find billing plan", "productID", subscr.ExternalProductID, "priceID", subscr.ExternalPriceID, common.ErrAttr(err))
-		return 0, err
+		return nil, err
+	}
 
+	return NewPlanLimits(plan), nil
+}
+
+func planResourceLimit(plan billing.Plan, resource string) int {
+	switch resource {
+	case LimitResourceOrgs:
+		return plan.OrgsLimit()
+	case LimitResourceOrgMembers:
+		return plan.OrgMembersLimit()
+	case LimitResourceProperties:
+		return plan.PropertiesLimit()
+	case LimitResourceSeats:
+		return plan.IncludedSeats()
+	case LimitResourceRequests:
+		return int(plan.RequestsLimit())
+	default:
+		return 0
+	}
+}
+
+func (sl *SubscriptionLimitsImpl) RecordDecision(ctx context.Context, d *LimitDecision) {
+	if d == nil {
+		return
+	}
+
+	params := &dbgen.CreateLimitDecisionParams{
+		UserID:         Int(d.UserID),
+		Resource:       d.Resource,
+		StatusCode:     int32(d.Code),
+		Source:         dbgen.AuditLogSource(d.Source.String()),
+		RequestedCount: int32(d.Requested),
+	}
+
+	if d.Org != nil {
+		params.OrgID = Int(d.Org.ID)
+		params.OrgOwnerID = d.Org.UserID
+	}
+
+	if d.Err != nil {
+		params.Error = d.Err.Error()
+	}
+
+	if subscr := d.Subscription; subscr != nil {
+		params.SubscriptionID = Int(subscr.ID)
+		params.SubscriptionStatus = subscr.Status
+		params.ExternalProductID = subscr.ExternalProductID
+		params.ExternalPriceID = subscr.ExternalPriceID
+
+		if plan, err := sl.planService.FindPlan(subscr.ExternalProductID, subscr.ExternalPriceID, sl.Stage,
+			IsInternalSubscription(subscr.Source)); err == nil {
+			limit := planResourceLimit(plan, d.Resource)
+			params.PlanLimit = int32(limit)
+			// with errors the check did not get to counting
+			if d.Err == nil {
+				params.CurrentCount = int32(limit + d.Extra)
+			}
+		}
+	}
+
+	if err := sl.store.Impl().CreateLimitDecision(ctx, params); err != nil {
+		slog.WarnContext(ctx, "Failed to record limit decision", "userID", d.UserID, "resource", d.Resource, common.ErrAttr(err))
 	}
-	return plan.OrgsLimit(), nil
 }
 
 type StubSubscriptionLimits struct{}
@@ -172,6 +383,18 @@ func (StubSubscriptionLimits) CheckOrgMembersLimit(ctx context.Context, orgID in
 func (StubSubscriptionLimits) CheckPropertiesLimit(ctx context.Context, userID int32, subscr *dbgen.Subscription) (_ bool, _ int, _ error) {
 	return true, 0, nil
 }
+func (StubSubscriptionLimits) CheckOrgPropertiesLimit(ctx context.Context, org *dbgen.Organization, ownerID int32, subscr *dbgen.Subscription) (_ bool, _ int, _ error) {
+	return true, 0, nil
+}
+func (StubSubscriptionLimits) CheckSeatsLimit(ctx context.Context, ownerID int32, subscr *dbgen.Subscription) (_ bool, _ int, _ error) {
+	return true, 0, nil
+}
+func (StubSubscriptionLimits) SyncSeats(ctx context.Context, ownerID int32, subscr *dbgen.Subscription) error {
+	return nil
+}
+func (StubSubscriptionLimits) SeatsCount(ctx context.Context, ownerID int32) (int, error) {
+	return 0, nil
+}
 func (StubSubscriptionLimits) RequestsLimit(ctx context.Context, subscr *dbgen.Subscription) (int64, error) {
 	return 0, nil
 }
@@ -181,5 +404,9 @@ func (StubSubscriptionLimits) PropertiesLimit(ctx context.Context, subscr *dbgen
 func (StubSubscriptionLimits) OrgsLimit(ctx context.Context, subscr *dbgen.Subscription) (int, error) {
 	return 0, nil
 }
+func (StubSubscriptionLimits) Limits(ctx context.Context, subscr *dbgen.Subscription) (*PlanLimits, error) {
+	return &PlanLimits{}, nil
+}
+func (StubSubscriptionLimits) RecordDecision(ctx context.Context, decision *LimitDecision) {}
 
 var _ SubscriptionLimits = (*StubSubscriptionLimits)(nil)
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestNewPropertiesAllowance(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		ok        bool
+		extra     int
+		requested int
+		expected  int
+	}{
+		{false, 0, 10, 0},
+		{false, 5, 10, 0},
+		{true, 0, 10, 10},
+		{true, 42, 10, 10},
+		{true, -3, 10, 3},
+		{true, -20, 10, 10},
+	}
+
+	for i, tc := range testCases {
+		if actual := NewPropertiesAllowance(tc.ok, tc.extra, tc.requested); actual != tc.expected {
+			t.Errorf("Unexpected allowance at %v: %v (expected %v)", i, actual, tc.expected)
+		}
+	}
+}
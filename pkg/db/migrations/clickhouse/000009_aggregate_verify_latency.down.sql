@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS privatecaptcha.verify_latency_1d_mv;
+DROP TABLE IF EXISTS privatecaptcha.verify_latency_1d;
+DROP VIEW IF EXISTS privatecaptcha.verify_latency_1h_mv;
+DROP TABLE IF EXISTS privatecaptcha.verify_latency_1h;
+ALTER TABLE privatecaptcha.verify_logs DROP COLUMN IF EXISTS duration_us;
@@ -0,0 +1,47 @@
+ALTER TABLE privatecaptcha.verify_logs ADD COLUMN IF NOT EXISTS duration_us UInt32 DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.verify_latency_1h
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    timestamp DateTime,
+    duration_quantiles AggregateFunction(quantilesTDigest(0.5, 0.9, 0.99), UInt32)
+)
+ENGINE = AggregatingMergeTree
+ORDER BY (user_id, org_id, property_id, timestamp)
+TTL timestamp + INTERVAL 8 DAY;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_latency_1h_mv TO privatecaptcha.verify_latency_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfHour(timestamp) AS timestamp,
+    quantilesTDigestState(0.5, 0.9, 0.99)(duration_us) AS duration_quantiles
+FROM privatecaptcha.verify_logs
+WHERE duration_us > 0
+GROUP BY user_id, org_id, property_id, timestamp;
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.verify_latency_1d
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    timestamp DateTime,
+    duration_quantiles AggregateFunction(quantilesTDigest(0.5, 0.9, 0.99), UInt32)
+)
+ENGINE = AggregatingMergeTree
+ORDER BY (user_id, org_id, property_id, timestamp)
+TTL timestamp + INTERVAL 1 YEAR;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_latency_1d_mv TO privatecaptcha.verify_latency_1d AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfDay(timestamp) AS timestamp,
+    quantilesTDigestState(0.5, 0.9, 0.99)(duration_us) AS duration_quantiles
+FROM privatecaptcha.verify_logs
+WHERE duration_us > 0
+GROUP BY user_id, org_id, property_id, timestamp;
@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS privatecaptcha.experiment_verify_1h_mv;
+DROP VIEW IF EXISTS privatecaptcha.experiment_requests_1h_mv;
+DROP TABLE IF EXISTS privatecaptcha.experiment_stats_1h;
+ALTER TABLE privatecaptcha.verify_logs DROP COLUMN IF EXISTS experiment_arm;
+ALTER TABLE privatecaptcha.request_logs DROP COLUMN IF EXISTS experiment_arm;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS privatecaptcha.issuance_receipts;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS privatecaptcha.issuance_receipts
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    puzzle_id UInt64,
+    sitekey String,
+    ip_prefix_hash UInt64,
+    timestamp DateTime
+)
+ENGINE = MergeTree
+ORDER BY (user_id, org_id, timestamp)
+TTL timestamp + INTERVAL 3 YEAR;
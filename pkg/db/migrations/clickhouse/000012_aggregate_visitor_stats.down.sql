@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS privatecaptcha.visitor_verify_1h_mv;
+DROP VIEW IF EXISTS privatecaptcha.visitor_requests_1h_mv;
+DROP TABLE IF EXISTS privatecaptcha.visitor_stats_1h;
+ALTER TABLE privatecaptcha.verify_logs DROP COLUMN IF EXISTS visitor_class;
+ALTER TABLE privatecaptcha.request_logs DROP COLUMN IF EXISTS visitor_class;
@@ -0,0 +1,45 @@
+ALTER TABLE privatecaptcha.request_logs ADD COLUMN IF NOT EXISTS visitor_class UInt8 DEFAULT 0;
+ALTER TABLE privatecaptcha.verify_logs ADD COLUMN IF NOT EXISTS visitor_class UInt8 DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.visitor_stats_1h
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    visitor_class UInt8,
+    timestamp DateTime,
+    requests_count UInt64,
+    success_count UInt64,
+    failure_count UInt64
+)
+ENGINE = SummingMergeTree
+ORDER BY (user_id, org_id, property_id, visitor_class, timestamp)
+TTL timestamp + INTERVAL 1 YEAR;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.visitor_requests_1h_mv TO privatecaptcha.visitor_stats_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    visitor_class,
+    toStartOfHour(timestamp) AS timestamp,
+    count() AS requests_count,
+    toUInt64(0) AS success_count,
+    toUInt64(0) AS failure_count
+FROM privatecaptcha.request_logs
+WHERE visitor_class > 0
+GROUP BY user_id, org_id, property_id, visitor_class, timestamp;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.visitor_verify_1h_mv TO privatecaptcha.visitor_stats_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    visitor_class,
+    toStartOfHour(timestamp) AS timestamp,
+    toUInt64(0) AS requests_count,
+    countIf(status = 0) AS success_count,
+    countIf(status != 0) AS failure_count
+FROM privatecaptcha.verify_logs
+WHERE visitor_class > 0
+GROUP BY user_id, org_id, property_id, visitor_class, timestamp;
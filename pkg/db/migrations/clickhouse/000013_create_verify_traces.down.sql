@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS privatecaptcha.verify_traces_mv;
+DROP TABLE IF EXISTS privatecaptcha.verify_traces;
+ALTER TABLE privatecaptcha.verify_logs DROP COLUMN IF EXISTS trace_id;
@@ -0,0 +1,29 @@
+ALTER TABLE privatecaptcha.verify_logs ADD COLUMN IF NOT EXISTS trace_id String DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.verify_traces
+(
+    trace_id String,
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    puzzle_id UInt64,
+    status UInt8,
+    duration_us UInt32,
+    timestamp DateTime
+)
+ENGINE = MergeTree
+ORDER BY (trace_id, timestamp)
+TTL timestamp + INTERVAL 30 DAY;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.verify_traces_mv TO privatecaptcha.verify_traces AS
+SELECT
+    trace_id,
+    user_id,
+    org_id,
+    property_id,
+    puzzle_id,
+    status,
+    duration_us,
+    timestamp
+FROM privatecaptcha.verify_logs
+WHERE trace_id != '';
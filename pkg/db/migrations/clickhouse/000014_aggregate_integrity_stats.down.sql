@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS privatecaptcha.integrity_stats_1h_mv;
+DROP TABLE IF EXISTS privatecaptcha.integrity_stats_1h;
+ALTER TABLE privatecaptcha.verify_logs DROP COLUMN IF EXISTS integrity;
@@ -0,0 +1,25 @@
+ALTER TABLE privatecaptcha.verify_logs ADD COLUMN IF NOT EXISTS integrity UInt8 DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.integrity_stats_1h
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    integrity UInt8,
+    timestamp DateTime,
+    count UInt64
+)
+ENGINE = SummingMergeTree
+ORDER BY (user_id, org_id, property_id, integrity, timestamp)
+TTL timestamp + INTERVAL 1 YEAR;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.integrity_stats_1h_mv TO privatecaptcha.integrity_stats_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    integrity,
+    toStartOfHour(timestamp) AS timestamp,
+    count() AS count
+FROM privatecaptcha.verify_logs
+GROUP BY user_id, org_id, property_id, integrity, timestamp;
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS privatecaptcha.quota_stats_1h_mv;
+DROP TABLE IF EXISTS privatecaptcha.quota_stats_1h;
+DROP TABLE IF EXISTS privatecaptcha.quota_logs;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS privatecaptcha.quota_logs
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    timestamp DateTime
+)
+ENGINE = MergeTree
+ORDER BY (property_id, timestamp)
+TTL timestamp + INTERVAL 1 DAY;
+
+CREATE TABLE IF NOT EXISTS privatecaptcha.quota_stats_1h
+(
+    user_id UInt32,
+    org_id UInt32,
+    property_id UInt32,
+    timestamp DateTime,
+    count UInt64
+)
+ENGINE = SummingMergeTree
+ORDER BY (user_id, org_id, property_id, timestamp)
+TTL timestamp + INTERVAL 1 YEAR;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS privatecaptcha.quota_stats_1h_mv TO privatecaptcha.quota_stats_1h AS
+SELECT
+    user_id,
+    org_id,
+    property_id,
+    toStartOfHour(timestamp) AS timestamp,
+    count() AS count
+FROM privatecaptcha.quota_logs
+GROUP BY user_id, org_id, property_id, timestamp;
@@ -0,0 +1 @@
+ALTER TABLE backend.properties DROP COLUMN allowed_clock_skew;
@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN allowed_clock_skew INTERVAL NOT NULL DEFAULT INTERVAL '0 seconds';
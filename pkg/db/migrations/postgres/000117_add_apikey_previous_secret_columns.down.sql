@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS backend.index_apikey_previous_external_id;
+
+ALTER TABLE backend.apikeys DROP COLUMN previous_expires_at;
+ALTER TABLE backend.apikeys DROP COLUMN previous_external_id;
@@ -0,0 +1,4 @@
+ALTER TABLE backend.apikeys ADD COLUMN previous_external_id UUID;
+ALTER TABLE backend.apikeys ADD COLUMN previous_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS index_apikey_previous_external_id ON backend.apikeys(previous_external_id) WHERE previous_external_id IS NOT NULL;
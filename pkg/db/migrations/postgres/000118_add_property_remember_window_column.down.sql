@@ -0,0 +1 @@
+ALTER TABLE backend.properties DROP COLUMN remember_window;
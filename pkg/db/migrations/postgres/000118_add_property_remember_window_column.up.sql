@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN remember_window INTERVAL NOT NULL DEFAULT INTERVAL '0 seconds';
@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN widget_flags SMALLINT NOT NULL DEFAULT 0;
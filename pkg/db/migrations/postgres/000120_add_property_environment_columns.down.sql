@@ -0,0 +1,4 @@
+ALTER TABLE backend.properties DROP COLUMN twin_id;
+ALTER TABLE backend.properties DROP COLUMN environment;
+
+DROP TYPE backend.property_environment;
@@ -0,0 +1,4 @@
+CREATE TYPE backend.property_environment AS ENUM ('production', 'staging');
+
+ALTER TABLE backend.properties ADD COLUMN environment backend.property_environment NOT NULL DEFAULT 'production';
+ALTER TABLE backend.properties ADD COLUMN twin_id INT REFERENCES backend.properties(id) ON DELETE SET NULL;
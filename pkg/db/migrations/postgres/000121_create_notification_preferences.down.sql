@@ -0,0 +1,3 @@
+ALTER TABLE backend.user_notifications DROP COLUMN suppressed_at;
+
+DROP TABLE IF EXISTS backend.notification_preferences;
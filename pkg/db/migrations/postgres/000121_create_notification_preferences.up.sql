@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS backend.notification_preferences (
+    user_id INT NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    template_name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (user_id, template_name)
+);
+
+ALTER TABLE backend.user_notifications ADD COLUMN suppressed_at TIMESTAMPTZ DEFAULT NULL;
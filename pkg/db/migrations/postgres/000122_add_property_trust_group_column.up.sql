@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN trust_group TEXT NOT NULL DEFAULT '';
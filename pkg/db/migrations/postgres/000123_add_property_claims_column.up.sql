@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN claims TEXT NOT NULL DEFAULT '';
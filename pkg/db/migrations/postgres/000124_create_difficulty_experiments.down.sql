@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS backend.index_difficulty_experiment_running;
+DROP TABLE IF EXISTS backend.difficulty_experiments;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS backend.difficulty_experiments (
+    id SERIAL PRIMARY KEY,
+    property_id INT NOT NULL REFERENCES backend.properties(id) ON DELETE CASCADE,
+    org_id INT NOT NULL REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    control_level SMALLINT NOT NULL,
+    variant_level SMALLINT NOT NULL,
+    variant_weight SMALLINT NOT NULL,
+    min_samples INT NOT NULL,
+    max_degradation REAL NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    ends_at TIMESTAMPTZ NOT NULL,
+    concluded_at TIMESTAMPTZ DEFAULT NULL,
+    outcome TEXT NOT NULL DEFAULT '',
+    conclusion TEXT NOT NULL DEFAULT ''
+);
+
+-- only one running experiment per property
+CREATE UNIQUE INDEX IF NOT EXISTS index_difficulty_experiment_running ON backend.difficulty_experiments(property_id) WHERE concluded_at IS NULL;
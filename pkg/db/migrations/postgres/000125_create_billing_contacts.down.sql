@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backend.org_billing_settings;
+DROP TABLE IF EXISTS backend.billing_contacts;
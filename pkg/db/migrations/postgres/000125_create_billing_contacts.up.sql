@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS backend.billing_contacts (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (org_id, email)
+);
+
+CREATE TABLE IF NOT EXISTS backend.org_billing_settings (
+    org_id INT PRIMARY KEY REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    notify_owner BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
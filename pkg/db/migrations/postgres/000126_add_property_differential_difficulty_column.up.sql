@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN differential_difficulty BOOLEAN NOT NULL DEFAULT FALSE;
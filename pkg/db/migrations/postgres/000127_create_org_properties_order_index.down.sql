@@ -0,0 +1 @@
+DROP INDEX IF EXISTS index_org_properties_created_at;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS index_org_properties_created_at ON backend.properties(org_id, created_at, id) WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS backend.index_properties_domain_checked_at;
+
+ALTER TABLE backend.properties DROP COLUMN domain_checked_at;
+ALTER TABLE backend.properties DROP COLUMN domain_status;
+
+DROP TYPE backend.property_domain_status;
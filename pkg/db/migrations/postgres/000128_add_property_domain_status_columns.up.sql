@@ -0,0 +1,6 @@
+CREATE TYPE backend.property_domain_status AS ENUM ('ok', 'unresolved', 'loopback');
+
+ALTER TABLE backend.properties ADD COLUMN domain_status backend.property_domain_status NOT NULL DEFAULT 'ok';
+ALTER TABLE backend.properties ADD COLUMN domain_checked_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS index_properties_domain_checked_at ON backend.properties(domain_checked_at NULLS FIRST) WHERE deleted_at IS NULL;
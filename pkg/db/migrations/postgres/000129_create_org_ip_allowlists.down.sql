@@ -0,0 +1 @@
+DROP TABLE IF EXISTS backend.org_ip_allowlists;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS backend.org_ip_allowlists (
+    org_id INT PRIMARY KEY REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    cidrs TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
@@ -0,0 +1,7 @@
+ALTER TABLE backend.system_notifications DROP COLUMN stage;
+ALTER TABLE backend.system_notifications DROP COLUMN external_product_id;
+ALTER TABLE backend.system_notifications DROP COLUMN org_id;
+ALTER TABLE backend.system_notifications DROP COLUMN is_markdown;
+ALTER TABLE backend.system_notifications DROP COLUMN severity;
+
+DROP TYPE backend.notification_severity;
@@ -0,0 +1,7 @@
+CREATE TYPE backend.notification_severity AS ENUM ('info', 'warning', 'critical');
+
+ALTER TABLE backend.system_notifications ADD COLUMN severity backend.notification_severity NOT NULL DEFAULT 'info';
+ALTER TABLE backend.system_notifications ADD COLUMN is_markdown BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE backend.system_notifications ADD COLUMN org_id INTEGER DEFAULT NULL REFERENCES backend.organizations(id) ON DELETE CASCADE;
+ALTER TABLE backend.system_notifications ADD COLUMN external_product_id TEXT DEFAULT NULL;
+ALTER TABLE backend.system_notifications ADD COLUMN stage TEXT DEFAULT NULL;
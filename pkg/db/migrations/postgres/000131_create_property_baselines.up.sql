@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS backend.property_baselines (
+    property_id INT REFERENCES backend.properties(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL,
+    hour SMALLINT NOT NULL,
+    samples INT NOT NULL DEFAULT 0,
+    requests_mean DOUBLE PRECISION NOT NULL DEFAULT 0,
+    requests_variance DOUBLE PRECISION NOT NULL DEFAULT 0,
+    success_samples INT NOT NULL DEFAULT 0,
+    success_rate_mean DOUBLE PRECISION NOT NULL DEFAULT 0,
+    success_rate_variance DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (property_id, weekday, hour)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE backend.properties DROP COLUMN bot_policy;
+
+DROP TYPE backend.bot_policy;
@@ -0,0 +1,3 @@
+CREATE TYPE backend.bot_policy AS ENUM ('monitor', 'max_difficulty', 'block');
+
+ALTER TABLE backend.properties ADD COLUMN bot_policy backend.bot_policy NOT NULL DEFAULT 'monitor';
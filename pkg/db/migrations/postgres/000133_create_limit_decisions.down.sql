@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS index_limit_decisions_owner_created_at;
+
+DROP INDEX IF EXISTS index_limit_decisions_user_created_at;
+
+DROP TABLE IF EXISTS backend.limit_decisions;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS backend.limit_decisions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT REFERENCES backend.users(id) ON DELETE CASCADE,
+    org_id INT REFERENCES backend.organizations(id) ON DELETE SET NULL,
+    org_owner_id INT REFERENCES backend.users(id) ON DELETE SET NULL,
+    resource TEXT NOT NULL,
+    status_code INT NOT NULL,
+    source backend.audit_log_source NOT NULL DEFAULT 'unknown',
+    subscription_id INT REFERENCES backend.subscriptions(id) ON DELETE SET NULL,
+    subscription_status TEXT NOT NULL DEFAULT '',
+    external_product_id TEXT NOT NULL DEFAULT '',
+    external_price_id TEXT NOT NULL DEFAULT '',
+    current_count INT NOT NULL DEFAULT 0,
+    requested_count INT NOT NULL DEFAULT 0,
+    plan_limit INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_limit_decisions_user_created_at
+    ON backend.limit_decisions (user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS index_limit_decisions_owner_created_at
+    ON backend.limit_decisions (org_owner_id, created_at DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE backend.properties DROP COLUMN failure_message;
+
+ALTER TABLE backend.properties DROP COLUMN failure_url;
@@ -0,0 +1,3 @@
+ALTER TABLE backend.properties ADD COLUMN failure_url TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE backend.properties ADD COLUMN failure_message TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS backend.index_audit_logs_trace_id;
+
+ALTER TABLE backend.audit_logs DROP COLUMN IF EXISTS trace_id;
@@ -0,0 +1,4 @@
+ALTER TABLE backend.audit_logs ADD COLUMN trace_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS index_audit_logs_trace_id
+    ON backend.audit_logs (trace_id) WHERE trace_id <> '';
@@ -0,0 +1,11 @@
+UPDATE backend.user_notifications un SET payload = np.payload
+FROM backend.notification_payloads np
+WHERE un.payload_hash = np.hash AND un.payload IS NULL;
+
+DROP INDEX IF EXISTS backend.index_user_notifications_payload_hash;
+ALTER TABLE backend.user_notifications DROP COLUMN IF EXISTS payload_hash;
+
+DELETE FROM backend.user_notifications WHERE payload IS NULL;
+ALTER TABLE backend.user_notifications ALTER COLUMN payload SET NOT NULL;
+
+DROP TABLE IF EXISTS backend.notification_payloads;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS backend.notification_payloads(
+    hash VARCHAR(64) PRIMARY KEY,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+ALTER TABLE backend.user_notifications ALTER COLUMN payload DROP NOT NULL;
+ALTER TABLE backend.user_notifications ADD COLUMN payload_hash VARCHAR(64) REFERENCES backend.notification_payloads(hash) DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS index_user_notifications_payload_hash
+    ON backend.user_notifications (payload_hash) WHERE payload_hash IS NOT NULL;
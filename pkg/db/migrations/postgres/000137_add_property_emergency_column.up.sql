@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN emergency_until TIMESTAMPTZ DEFAULT NULL;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS backend.apikey_usage(
+    apikey_id INT NOT NULL REFERENCES backend.apikeys(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (apikey_id, day)
+);
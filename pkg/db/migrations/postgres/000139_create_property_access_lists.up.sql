@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS backend.property_access_lists (
+    property_id INT PRIMARY KEY REFERENCES backend.properties(id) ON DELETE CASCADE,
+    ip_allowlist TEXT[] NOT NULL DEFAULT '{}',
+    ip_denylist TEXT[] NOT NULL DEFAULT '{}',
+    asn_denylist BIGINT[] NOT NULL DEFAULT '{}',
+    policy backend.bot_policy NOT NULL DEFAULT 'block',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
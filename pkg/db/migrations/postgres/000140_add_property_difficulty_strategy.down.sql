@@ -0,0 +1 @@
+ALTER TABLE backend.properties DROP COLUMN difficulty_strategy;
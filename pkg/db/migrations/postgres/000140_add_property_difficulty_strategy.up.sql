@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN difficulty_strategy TEXT NOT NULL DEFAULT '';
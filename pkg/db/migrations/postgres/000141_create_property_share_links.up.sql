@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS backend.property_share_links (
+    id SERIAL PRIMARY KEY,
+    external_id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    property_id INT NOT NULL REFERENCES backend.properties(id) ON DELETE CASCADE,
+    creator_id INT REFERENCES backend.users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_property_share_links_property_id ON backend.property_share_links(property_id);
@@ -0,0 +1 @@
+ALTER TYPE backend.audit_log_source ADD VALUE IF NOT EXISTS 'cli';
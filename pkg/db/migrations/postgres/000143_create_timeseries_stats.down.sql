@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backend.verify_stats_5m;
+DROP TABLE IF EXISTS backend.request_stats_5m;
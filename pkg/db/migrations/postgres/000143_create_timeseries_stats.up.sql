@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS backend.request_stats_5m(
+    user_id INT NOT NULL,
+    org_id INT NOT NULL,
+    property_id INT NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (property_id, timestamp, org_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS index_request_stats_5m_user_id ON backend.request_stats_5m(user_id, timestamp);
+CREATE INDEX IF NOT EXISTS index_request_stats_5m_timestamp ON backend.request_stats_5m(timestamp);
+
+CREATE TABLE IF NOT EXISTS backend.verify_stats_5m(
+    user_id INT NOT NULL,
+    org_id INT NOT NULL,
+    property_id INT NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    success_count BIGINT NOT NULL DEFAULT 0,
+    failure_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (property_id, timestamp, org_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS index_verify_stats_5m_user_id ON backend.verify_stats_5m(user_id, timestamp);
+CREATE INDEX IF NOT EXISTS index_verify_stats_5m_timestamp ON backend.verify_stats_5m(timestamp);
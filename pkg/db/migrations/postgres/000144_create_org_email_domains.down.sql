@@ -0,0 +1 @@
+DROP TABLE IF EXISTS backend.org_email_domains;
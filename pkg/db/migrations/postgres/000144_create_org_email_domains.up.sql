@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS backend.org_email_domains (
+    id SERIAL PRIMARY KEY,
+    org_id INT NOT NULL REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL,
+    token TEXT NOT NULL,
+    auto_join BOOLEAN NOT NULL DEFAULT FALSE,
+    verified_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (org_id, domain)
+);
+
+-- verified domain can belong only to a single organization
+CREATE UNIQUE INDEX IF NOT EXISTS index_org_email_domains_verified ON backend.org_email_domains(domain) WHERE verified_at IS NOT NULL;
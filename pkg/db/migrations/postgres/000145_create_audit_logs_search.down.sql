@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS backend.index_audit_logs_search;
+
+DROP FUNCTION IF EXISTS backend.audit_log_search_vector;
@@ -0,0 +1,12 @@
+-- IMMUTABLE wrapper is required to build an expression index (regconfig is passed explicitly for the same reason)
+CREATE OR REPLACE FUNCTION backend.audit_log_search_vector(entity_table TEXT, old_value JSONB, new_value JSONB) RETURNS tsvector
+    LANGUAGE sql
+    IMMUTABLE PARALLEL SAFE
+AS $$
+    SELECT to_tsvector('simple'::regconfig, coalesce(entity_table, ''))
+        || jsonb_to_tsvector('simple'::regconfig, coalesce(old_value, '{}'::jsonb), '["string"]')
+        || jsonb_to_tsvector('simple'::regconfig, coalesce(new_value, '{}'::jsonb), '["string"]');
+$$;
+
+CREATE INDEX IF NOT EXISTS index_audit_logs_search
+    ON backend.audit_logs USING GIN (backend.audit_log_search_vector(entity_table, old_value, new_value));
@@ -0,0 +1,3 @@
+ALTER TABLE backend.apikeys DROP COLUMN last_ip_violation_at;
+ALTER TABLE backend.apikeys DROP COLUMN ip_violations;
+ALTER TABLE backend.apikeys DROP COLUMN allowed_cidrs;
@@ -0,0 +1,3 @@
+ALTER TABLE backend.apikeys ADD COLUMN allowed_cidrs TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE backend.apikeys ADD COLUMN ip_violations BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE backend.apikeys ADD COLUMN last_ip_violation_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS backend.stats_digests;
+
+DROP TYPE IF EXISTS backend.digest_frequency;
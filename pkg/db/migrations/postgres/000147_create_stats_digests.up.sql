@@ -0,0 +1,14 @@
+CREATE TYPE backend.digest_frequency AS ENUM ('weekly', 'monthly');
+
+CREATE TABLE IF NOT EXISTS backend.stats_digests (
+    user_id INT NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    org_id INT NOT NULL REFERENCES backend.organizations(id) ON DELETE CASCADE,
+    frequency backend.digest_frequency NOT NULL,
+    -- end of the period that was covered by the last digest
+    sent_until TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (user_id, org_id)
+);
+
+CREATE INDEX IF NOT EXISTS index_stats_digests_org_id ON backend.stats_digests(org_id);
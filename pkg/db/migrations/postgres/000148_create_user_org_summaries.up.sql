@@ -0,0 +1,7 @@
+-- materialized summary of user organizations for the portal landing page
+CREATE TABLE IF NOT EXISTS backend.user_org_summaries (
+    user_id INT PRIMARY KEY REFERENCES backend.users(id) ON DELETE CASCADE,
+    summary JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
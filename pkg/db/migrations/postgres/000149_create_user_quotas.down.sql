@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS backend.user_quotas;
+
+DROP TYPE IF EXISTS backend.quota_stage;
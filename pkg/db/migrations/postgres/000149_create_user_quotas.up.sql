@@ -0,0 +1,13 @@
+CREATE TYPE backend.quota_stage AS ENUM ('normal', 'warning', 'degraded', 'exceeded');
+
+-- monthly requests of org owner against the limit of the plan (updated periodically by MonthlyQuotaJob)
+CREATE TABLE IF NOT EXISTS backend.user_quotas (
+    user_id INT PRIMARY KEY REFERENCES backend.users(id) ON DELETE CASCADE,
+    -- start of the month that requests are counted for
+    period_start TIMESTAMPTZ NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    requests_limit BIGINT NOT NULL DEFAULT 0,
+    stage backend.quota_stage NOT NULL DEFAULT 'normal',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
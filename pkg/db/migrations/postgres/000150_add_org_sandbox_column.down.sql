@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS backend.index_organizations_user_sandbox;
+
+ALTER TABLE backend.organizations DROP COLUMN sandbox;
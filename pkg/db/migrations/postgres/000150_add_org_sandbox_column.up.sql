@@ -0,0 +1,4 @@
+-- sandbox organization is wiped nightly by ResetSandboxOrgsJob and is excluded from the plan limits
+ALTER TABLE backend.organizations ADD COLUMN sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS index_organizations_user_sandbox ON backend.organizations(user_id) WHERE sandbox AND deleted_at IS NULL;
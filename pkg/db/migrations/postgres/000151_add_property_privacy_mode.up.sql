@@ -0,0 +1 @@
+ALTER TABLE backend.properties ADD COLUMN privacy_mode TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- postgres does not support removing enum values
+UPDATE backend.audit_logs SET source = 'unknown'::backend.audit_log_source WHERE source = 'system'::backend.audit_log_source;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS backend.property_bypass_tokens;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS backend.property_bypass_tokens (
+    id SERIAL PRIMARY KEY,
+    property_id INT NOT NULL REFERENCES backend.properties(id) ON DELETE CASCADE,
+    creator_id INT REFERENCES backend.users(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    -- only SHA-256 of the secret is stored, the token itself is shown once on creation
+    secret_hash BYTEA NOT NULL UNIQUE,
+    max_uses INT NOT NULL,
+    uses INT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS index_property_bypass_tokens_property_id ON backend.property_bypass_tokens(property_id);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS backend.user_locales (
+    user_id INT PRIMARY KEY REFERENCES backend.users(id) ON DELETE CASCADE,
+    -- IANA time zone name as reported by the browser
+    timezone VARCHAR(64) DEFAULT NULL,
+    -- ISO 3166-1 alpha-2 code from the CDN country header
+    country VARCHAR(2) DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
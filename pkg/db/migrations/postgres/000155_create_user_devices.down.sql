@@ -0,0 +1 @@
+DROP TABLE IF EXISTS backend.user_devices;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS backend.user_devices (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    -- browser and OS names without versions, so that updates do not look like new devices
+    browser VARCHAR(64) NOT NULL,
+    os VARCHAR(64) NOT NULL,
+    -- ISO 3166-1 alpha-2 code from the CDN country header (empty if it is not configured)
+    country VARCHAR(2) NOT NULL DEFAULT '',
+    -- the most recent session that signed in from this device
+    session_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (user_id, browser, os, country)
+);
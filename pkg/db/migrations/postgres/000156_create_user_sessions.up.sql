@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS backend.user_sessions (
+    session_id VARCHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    -- follows expiration of the persisted session data
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS index_user_sessions_user_id ON backend.user_sessions (user_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS backend.user_sso_links (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES backend.users(id) ON DELETE CASCADE,
+    issuer VARCHAR(255) NOT NULL,
+    -- "sub" claim of the IdP, which (unlike email) is stable and unique per issuer
+    subject VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    UNIQUE (issuer, subject),
+    UNIQUE (user_id, issuer)
+);
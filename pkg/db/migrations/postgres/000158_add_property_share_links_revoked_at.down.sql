@@ -0,0 +1 @@
+ALTER TABLE backend.property_share_links DROP COLUMN revoked_at;
@@ -0,0 +1,2 @@
+-- revoked links are kept (until expired) so that revocation is visible to all nodes
+ALTER TABLE backend.property_share_links ADD COLUMN revoked_at TIMESTAMPTZ NULL;
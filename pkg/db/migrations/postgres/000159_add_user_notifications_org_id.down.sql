@@ -0,0 +1 @@
+ALTER TABLE backend.user_notifications DROP COLUMN org_id;
@@ -0,0 +1,2 @@
+-- organization that notification is about (if any), e.g. to deliver billing notifications to its billing contacts
+ALTER TABLE backend.user_notifications ADD COLUMN org_id INT NULL REFERENCES backend.organizations(id) ON DELETE SET NULL;
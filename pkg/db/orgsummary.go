@@ -0,0 +1,344 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// traffic in org summaries covers this period before the last refresh
+	OrgSummaryTrafficPeriod = 7 * 24 * time.Hour
+)
+
+// OrgSummary is an organization as it is shown on the portal landing page
+type OrgSummary struct {
+	ID            int32             `json:"id"`
+	Name          string            `json:"name"`
+	Level         dbgen.AccessLevel `json:"level"`
+	Sandbox       bool              `json:"sandbox,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Properties    int64             `json:"properties"`
+	Requests      uint64            `json:"requests"`
+	Verifications uint64            `json:"verifications"`
+}
+
+// UserOrgsSummary is materialized in Postgres so that the portal landing page needs a single read. Orgs and property
+// counts are maintained on writes, while traffic is only refreshed periodically (see OrgSummaryJob).
+type UserOrgsSummary struct {
+	Orgs []*OrgSummary `json:"orgs"`
+	// zero if traffic was not refreshed yet
+	TrafficUpdatedAt time.Time `json:"traffic_updated_at"`
+}
+
+// OrgTraffic is requests and verifications of the org within OrgSummaryTrafficPeriod
+type OrgTraffic struct {
+	Requests      uint64
+	Verifications uint64
+}
+
+func (s *UserOrgsSummary) org(orgID int32) *OrgSummary {
+	for _, o := range s.Orgs {
+		if o.ID == orgID {
+			return o
+		}
+	}
+
+	return nil
+}
+
+func parseUserOrgsSummary(ctx context.Context, row *dbgen.UserOrgSummary) (*UserOrgsSummary, error) {
+	summary := &UserOrgsSummary{}
+	if err := json.Unmarshal(row.Summary, summary); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse user orgs summary", "userID", row.UserID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func (impl *BusinessStoreImpl) storeUserOrgsSummary(ctx context.Context, userID int32, summary *UserOrgsSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize user orgs summary", "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	if err := impl.querier.UpsertUserOrgSummary(ctx, &dbgen.UpsertUserOrgSummaryParams{UserID: userID, Summary: data}); err != nil {
+		slog.ErrorContext(ctx, "Failed to upsert user orgs summary", "userID", userID, common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) retrieveUserOrgsSummaries(ctx context.Context, userIDs []int32) (map[int32]*UserOrgsSummary, error) {
+	rows, err := impl.querier.GetUserOrgSummaries(ctx, userIDs)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve user orgs summaries", "count", len(userIDs), common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make(map[int32]*UserOrgsSummary, len(rows))
+	for _, row := range rows {
+		// broken summaries are rebuilt from scratch
+		if summary, err := parseUserOrgsSummary(ctx, row); err == nil {
+			result[row.UserID] = summary
+		}
+	}
+
+	return result, nil
+}
+
+// RetrieveUserOrgsSummary returns the materialized summary of user orgs, building it on the first access
+func (impl *BusinessStoreImpl) RetrieveUserOrgsSummary(ctx context.Context, userID int32) (*UserOrgsSummary, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	row, err := impl.querier.GetUserOrgSummary(ctx, userID)
+	if err == nil {
+		if summary, err := parseUserOrgsSummary(ctx, row); err == nil {
+			return summary, nil
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve user orgs summary", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	summaries, err := impl.buildUserOrgsSummaries(ctx, []int32{userID}, nil /*previous*/, nil /*traffic*/, time.Time{})
+	summary, ok := summaries[userID]
+	if !ok {
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "Built user orgs summary", "userID", userID, "stored", err == nil)
+
+	return summary, nil
+}
+
+// buildUserOrgsSummaries reads orgs and property counts from the database. Traffic is taken from the refreshed
+// traffic, if it's provided, or is carried over from the previous summaries otherwise. Built summaries are returned
+// even if they could not be stored (e.g. on a standby replica).
+func (impl *BusinessStoreImpl) buildUserOrgsSummaries(ctx context.Context, userIDs []int32, previous map[int32]*UserOrgsSummary,
+	traffic map[int32]*OrgTraffic, trafficUpdatedAt time.Time) (map[int32]*UserOrgsSummary, error) {
+	userOrgs := make(map[int32][]*dbgen.GetUserOrganizationsRow, len(userIDs))
+	orgIDs := make([]int32, 0, len(userIDs))
+	uniqueOrgs := make(map[int32]struct{})
+
+	for _, userID := range userIDs {
+		orgs, err := impl.querier.GetUserOrganizations(ctx, Int(userID))
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.ErrorContext(ctx, "Failed to retrieve user organizations", "userID", userID, common.ErrAttr(err))
+			return nil, err
+		}
+
+		sort.Slice(orgs, func(i, j int) bool {
+			return orgs[i].Organization.CreatedAt.Time.Before(orgs[j].Organization.CreatedAt.Time)
+		})
+
+		userOrgs[userID] = orgs
+
+		for _, org := range orgs {
+			if _, ok := uniqueOrgs[org.Organization.ID]; !ok && (org.Level != dbgen.AccessLevelInvited) {
+				uniqueOrgs[org.Organization.ID] = struct{}{}
+				orgIDs = append(orgIDs, org.Organization.ID)
+			}
+		}
+	}
+
+	counts := make(map[int32]int64, len(orgIDs))
+	if len(orgIDs) > 0 {
+		rows, err := impl.querier.GetOrgsPropertiesCount(ctx, orgIDs)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.ErrorContext(ctx, "Failed to retrieve orgs properties count", "orgs", len(orgIDs), common.ErrAttr(err))
+			return nil, err
+		}
+
+		for _, row := range rows {
+			counts[row.OrgID] = row.Count
+		}
+	}
+
+	result := make(map[int32]*UserOrgsSummary, len(userIDs))
+	var storeErr error
+
+	for _, userID := range userIDs {
+		summary := &UserOrgsSummary{
+			Orgs:             make([]*OrgSummary, 0, len(userOrgs[userID])),
+			TrafficUpdatedAt: trafficUpdatedAt,
+		}
+
+		prev := previous[userID]
+		if (traffic == nil) && (prev != nil) {
+			summary.TrafficUpdatedAt = prev.TrafficUpdatedAt
+		}
+
+		for _, org := range userOrgs[userID] {
+			o := &OrgSummary{
+				ID:        org.Organization.ID,
+				Name:      org.Organization.Name,
+				Level:     org.Level,
+				Sandbox:   org.Organization.Sandbox,
+				CreatedAt: org.Organization.CreatedAt.Time,
+			}
+
+			// invited users don't have access to org properties yet
+			if org.Level != dbgen.AccessLevelInvited {
+				o.Properties = counts[o.ID]
+
+				if traffic != nil {
+					if t, ok := traffic[o.ID]; ok {
+						o.Requests, o.Verifications = t.Requests, t.Verifications
+					}
+				} else if prev != nil {
+					if po := prev.org(o.ID); po != nil {
+						o.Requests, o.Verifications = po.Requests, po.Verifications
+					}
+				}
+			}
+
+			summary.Orgs = append(summary.Orgs, o)
+		}
+
+		result[userID] = summary
+
+		if err := impl.storeUserOrgsSummary(ctx, userID, summary); err != nil {
+			storeErr = err
+		}
+	}
+
+	return result, storeErr
+}
+
+// RetrieveUserOrgsSummaryIDs returns users that have materialized summaries (in batches for the refresh job)
+func (impl *BusinessStoreImpl) RetrieveUserOrgsSummaryIDs(ctx context.Context, afterUserID int32, limit int) ([]int32, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	ids, err := impl.querier.GetUserOrgSummaryIDsAfter(ctx, &dbgen.GetUserOrgSummaryIDsAfterParams{
+		UserID: afterUserID,
+		Limit:  int32(limit),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve user orgs summary IDs", "after", afterUserID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// RefreshUserOrgsSummaries rebuilds summaries of the users with the recent traffic of their orgs
+func (impl *BusinessStoreImpl) RefreshUserOrgsSummaries(ctx context.Context, userIDs []int32, traffic map[int32]*OrgTraffic, tnow time.Time) error {
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	if traffic == nil {
+		traffic = make(map[int32]*OrgTraffic)
+	}
+
+	_, err := impl.buildUserOrgsSummaries(ctx, userIDs, nil /*previous*/, traffic, tnow)
+
+	return err
+}
+
+// invalidateUserOrgsSummaries is a fallback when summaries could not be updated: deleted summaries are rebuilt on the
+// next read, while stale ones could keep showing orgs that user does not have access to anymore
+func (impl *BusinessStoreImpl) invalidateUserOrgsSummaries(ctx context.Context, userIDs []int32) {
+	if err := impl.querier.DeleteUserOrgSummaries(ctx, userIDs); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete user orgs summaries", "count", len(userIDs), common.ErrAttr(err))
+	}
+}
+
+// onOrgPropertiesChanged adjusts property counts in existing summaries of all org members (delta is per org ID).
+// Adjustment happens in a single UPDATE so that concurrent changes do not overwrite each other.
+func (impl *BusinessStoreImpl) onOrgPropertiesChanged(ctx context.Context, deltas map[int32]int64) {
+	orgIDs := make([]int32, 0, len(deltas))
+	orgDeltas := make(map[string]int64, len(deltas))
+	for orgID, delta := range deltas {
+		if delta != 0 {
+			orgIDs = append(orgIDs, orgID)
+			orgDeltas[strconv.Itoa(int(orgID))] = delta
+		}
+	}
+
+	if len(orgIDs) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(orgDeltas)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to serialize org properties deltas", common.ErrAttr(err))
+		return
+	}
+
+	count, err := impl.querier.AdjustUserOrgSummariesProperties(ctx, &dbgen.AdjustUserOrgSummariesPropertiesParams{
+		Deltas: data,
+		OrgIds: orgIDs,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to adjust properties in user orgs summaries", "orgs", len(orgIDs), common.ErrAttr(err))
+
+		if userIDs, err := impl.querier.GetOrgsMemberIDs(ctx, orgIDs); (err == nil) && (len(userIDs) > 0) {
+			impl.invalidateUserOrgsSummaries(ctx, userIDs)
+		}
+
+		return
+	}
+
+	slog.DebugContext(ctx, "Adjusted properties in user orgs summaries", "orgs", len(orgIDs), "users", count)
+}
+
+// onOrgsChanged rebuilds existing summaries of members of the orgs and of the users (e.g. that just left the org).
+// Summaries of other users are not created here as they are built on the first read anyways.
+func (impl *BusinessStoreImpl) onOrgsChanged(ctx context.Context, orgIDs []int32, userIDs ...int32) {
+	if len(orgIDs) > 0 {
+		members, err := impl.querier.GetOrgsMemberIDs(ctx, orgIDs)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.ErrorContext(ctx, "Failed to retrieve orgs members", "orgs", len(orgIDs), common.ErrAttr(err))
+			return
+		}
+
+		userIDs = append(userIDs, members...)
+	}
+
+	if len(userIDs) == 0 {
+		return
+	}
+
+	previous, err := impl.retrieveUserOrgsSummaries(ctx, userIDs)
+	if err != nil {
+		impl.invalidateUserOrgsSummaries(ctx, userIDs)
+		return
+	}
+
+	if len(previous) == 0 {
+		return
+	}
+
+	ids := make([]int32, 0, len(previous))
+	for userID := range previous {
+		ids = append(ids, userID)
+	}
+
+	if _, err := impl.buildUserOrgsSummaries(ctx, ids, previous, nil /*traffic*/, time.Time{}); err != nil {
+		impl.invalidateUserOrgsSummaries(ctx, ids)
+		return
+	}
+
+	slog.DebugContext(ctx, "Rebuilt user orgs summaries", "orgs", len(orgIDs), "users", len(ids))
+}
@@ -0,0 +1,614 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	TimeSeriesBackendClickHouse = "clickhouse"
+	// for small self-hosted installs that run only with Postgres
+	TimeSeriesBackendPostgres = "postgres"
+
+	postgresStatsInterval = 5 * time.Minute
+)
+
+// TimeSeriesBackend is a time series store that server can reconfigure at runtime
+type TimeSeriesBackend interface {
+	common.TimeSeriesStore
+	UpdateConfig(maintenanceMode bool)
+	UpdateVerifyRetention(retention *VerifyRetention)
+}
+
+var _ TimeSeriesBackend = (*TimeSeriesDB)(nil)
+var _ TimeSeriesBackend = (*PostgresTimeSeries)(nil)
+
+func TimeSeriesBackendName(cfg common.ConfigStore) string {
+	switch strings.ToLower(strings.TrimSpace(cfg.Get(common.TimeSeriesBackendKey).Value())) {
+	case TimeSeriesBackendPostgres:
+		return TimeSeriesBackendPostgres
+	default:
+		return TimeSeriesBackendClickHouse
+	}
+}
+
+func IsPostgresTimeSeries(cfg common.ConfigStore) bool {
+	return TimeSeriesBackendName(cfg) == TimeSeriesBackendPostgres
+}
+
+func NewTimeSeriesBackend(cfg common.ConfigStore, pool *pgxpool.Pool, clickhouse *sql.DB, cache common.Cache[CacheKey, any]) TimeSeriesBackend {
+	if IsPostgresTimeSeries(cfg) {
+		return NewPostgresTimeSeries(pool, cache)
+	}
+
+	return NewTimeSeries(clickhouse, cache)
+}
+
+// PostgresTimeSeries keeps only 5 minute aggregates of requests and verifications in Postgres. Latency, experiments,
+// visitors, integrity, quota, issuance receipts and verify traces require ClickHouse and are returned empty.
+type PostgresTimeSeries struct {
+	pool            *pgxpool.Pool
+	queries         *dbgen.Queries
+	Cache           common.Cache[CacheKey, any]
+	maintenanceMode atomic.Bool
+	verifyRetention atomic.Pointer[VerifyRetention]
+}
+
+func NewPostgresTimeSeries(pool *pgxpool.Pool, cache common.Cache[CacheKey, any]) *PostgresTimeSeries {
+	ts := &PostgresTimeSeries{
+		pool:    pool,
+		queries: dbgen.New(pool),
+		Cache:   cache,
+	}
+
+	retention := DefaultVerifyRetention()
+	ts.verifyRetention.Store(&retention)
+
+	return ts
+}
+
+type statsKey struct {
+	userID     int32
+	orgID      int32
+	propertyID int32
+	timestamp  time.Time
+}
+
+func newStatsKey(userID, orgID, propertyID int32, t time.Time) statsKey {
+	return statsKey{userID: userID, orgID: orgID, propertyID: propertyID, timestamp: t.UTC().Truncate(postgresStatsInterval)}
+}
+
+func (ts *PostgresTimeSeries) UpdateConfig(maintenanceMode bool) {
+	ts.maintenanceMode.Store(maintenanceMode)
+}
+
+func (ts *PostgresTimeSeries) UpdateVerifyRetention(retention *VerifyRetention) {
+	ts.verifyRetention.Store(retention)
+}
+
+// Retention is for how long aggregates are kept, it matches the longest rollup in ClickHouse
+func (ts *PostgresTimeSeries) Retention() time.Duration {
+	return ts.verifyRetention.Load().Daily
+}
+
+func (ts *PostgresTimeSeries) IsAvailable() bool {
+	return !ts.maintenanceMode.Load()
+}
+
+func (ts *PostgresTimeSeries) Ping(ctx context.Context) error {
+	return ts.pool.Ping(ctx)
+}
+
+func (ts *PostgresTimeSeries) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	if len(records) == 0 {
+		slog.WarnContext(ctx, "Attempt to insert empty access log batch")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	// the same row cannot be updated twice by a single upsert
+	counts := make(map[statsKey]int64)
+	for _, r := range records {
+		counts[newStatsKey(r.UserID, r.OrgID, r.PropertyID, r.Timestamp)]++
+	}
+
+	params := &dbgen.AddRequestStatsParams{}
+	for k, count := range counts {
+		params.UserIds = append(params.UserIds, k.userID)
+		params.OrgIds = append(params.OrgIds, k.orgID)
+		params.PropertyIds = append(params.PropertyIds, k.propertyID)
+		params.Timestamps = append(params.Timestamps, Timestampz(k.timestamp))
+		params.Counts = append(params.Counts, count)
+	}
+
+	if err := ts.queries.AddRequestStats(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to insert access log batch", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Inserted batch of access records", "size", len(records), "rows", len(counts))
+
+	return nil
+}
+
+func (ts *PostgresTimeSeries) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+	if len(records) == 0 {
+		slog.WarnContext(ctx, "Attempt to insert empty verify log batch")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	counts := make(map[statsKey]*[2]int64)
+	for _, r := range records {
+		key := newStatsKey(r.UserID, r.OrgID, r.PropertyID, r.Timestamp)
+		c, ok := counts[key]
+		if !ok {
+			c = &[2]int64{}
+			counts[key] = c
+		}
+		if r.Status == 0 {
+			c[0]++
+		} else {
+			c[1]++
+		}
+	}
+
+	params := &dbgen.AddVerifyStatsParams{}
+	for k, c := range counts {
+		params.UserIds = append(params.UserIds, k.userID)
+		params.OrgIds = append(params.OrgIds, k.orgID)
+		params.PropertyIds = append(params.PropertyIds, k.propertyID)
+		params.Timestamps = append(params.Timestamps, Timestampz(k.timestamp))
+		params.SuccessCounts = append(params.SuccessCounts, c[0])
+		params.FailureCounts = append(params.FailureCounts, c[1])
+	}
+
+	if err := ts.queries.AddVerifyStats(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to insert verify log batch", common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Inserted batch of verify records", "size", len(records), "rows", len(counts))
+
+	return nil
+}
+
+func (ts *PostgresTimeSeries) WriteIssuanceReceiptBatch(ctx context.Context, records []*common.IssuanceReceipt) error {
+	slog.Log(ctx, common.LevelTrace, "Skipping issuance receipts without ClickHouse", "size", len(records))
+	return nil
+}
+
+func (ts *PostgresTimeSeries) WriteQuotaLogBatch(ctx context.Context, records []*common.QuotaRecord) error {
+	slog.Log(ctx, common.LevelTrace, "Skipping quota records without ClickHouse", "size", len(records))
+	return nil
+}
+
+func (ts *PostgresTimeSeries) RetrievePropertyStatsSince(ctx context.Context, r *common.BackfillRequest, from time.Time) ([]*common.TimeCount, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := ts.queries.GetPropertyRequestStatsSince(ctx, &dbgen.GetPropertyRequestStatsSinceParams{
+		UserID:     r.UserID,
+		OrgID:      r.OrgID,
+		PropertyID: r.PropertyID,
+		Timestamp:  Timestampz(from),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute property stats query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	results := make([]*common.TimeCount, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &common.TimeCount{Timestamp: row.Timestamp.Time.UTC(), Count: uint32(row.Count)})
+	}
+
+	slog.DebugContext(ctx, "Read property stats", "count", len(results), "from", from)
+
+	return results, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveAccountStats(ctx context.Context, userID int32, from time.Time, excludeOrgIDs []int32) ([]*common.TimeCount, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	cacheKey := userAccountStatsCacheKey(userID, from.Format(time.DateTime)+"/"+idsToString(excludeOrgIDs))
+	if stats, err := FetchCachedArray[common.TimeCount](ctx, ts.Cache, cacheKey); (err == nil) && (len(stats) > 0) {
+		slog.DebugContext(ctx, "User account stats were cached", "userID", userID, "key", cacheKey, "count", len(stats))
+		return stats, nil
+	}
+
+	if excludeOrgIDs == nil {
+		excludeOrgIDs = []int32{}
+	}
+
+	rows, err := ts.queries.GetUserMonthlyRequestStats(ctx, &dbgen.GetUserMonthlyRequestStatsParams{
+		UserID:        userID,
+		Timestamp:     Timestampz(from),
+		ExcludeOrgIds: excludeOrgIDs,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute account stats query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	results := make([]*common.TimeCount, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &common.TimeCount{Timestamp: row.Month.Time.UTC(), Count: uint32(row.Count)})
+	}
+
+	_ = ts.Cache.Set(ctx, cacheKey, results)
+
+	return results, nil
+}
+
+// postgresPeriodBuckets returns time from which to fetch stats of the period, SQL truncation unit of the
+// aggregates and how they are folded into the chart steps (same as ClickHouse ones)
+func postgresPeriodBuckets(period common.TimePeriod, tnow time.Time) (time.Time, string, func(time.Time) time.Time) {
+	switch period {
+	case common.TimePeriodWeek:
+		return tnow.AddDate(0, 0, -7).Truncate(6 * time.Hour), "hour", func(t time.Time) time.Time { return t.Truncate(6 * time.Hour) }
+	case common.TimePeriodMonth:
+		return tnow.AddDate(0, -1, 0).Truncate(day), "day", periodTruncateFunc(period)
+	case common.TimePeriodYear:
+		return tnow.AddDate(-1, 0, 0).Truncate(day), "day", periodTruncateFunc(period)
+	default:
+		return tnow.AddDate(0, 0, -1).Truncate(time.Hour), "hour", periodTruncateFunc(common.TimePeriodToday)
+	}
+}
+
+func (ts *PostgresTimeSeries) RetrievePropertyStatsByPeriod(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodStat, error) {
+	timeFrom, unit, truncate := postgresPeriodBuckets(period, time.Now().UTC())
+
+	var cacheKey *CacheKey
+	if period == common.TimePeriodToday {
+		cacheKey = new(CacheKey)
+		*cacheKey = propertyStatsCacheKey(propertyID, timeFrom.Format(time.DateTime))
+
+		if stats, err := FetchCachedArray[common.TimePeriodStat](ctx, ts.Cache, *cacheKey); (err == nil) && (len(stats) > 0) {
+			slog.DebugContext(ctx, "Property stats were cached", "orgID", orgID, "propertyID", propertyID, "key", *cacheKey, "count", len(stats))
+			return stats, nil
+		}
+	}
+
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	params := &dbgen.GetPropertyRequestStatsByPeriodParams{
+		Unit:       unit,
+		OrgID:      orgID,
+		PropertyID: propertyID,
+		Since:      Timestampz(timeFrom),
+	}
+
+	requests, err := ts.queries.GetPropertyRequestStatsByPeriod(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query property requests stats", common.ErrAttr(err))
+		return nil, err
+	}
+
+	verifies, err := ts.queries.GetPropertyVerifyStatsByPeriod(ctx, (*dbgen.GetPropertyVerifyStatsByPeriodParams)(params))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query property verify stats", common.ErrAttr(err))
+		return nil, err
+	}
+
+	statsMap := make(map[time.Time]*common.TimePeriodStat)
+	getStat := func(t pgtype.Timestamptz) *common.TimePeriodStat {
+		bucket := truncate(t.Time.UTC())
+		s, ok := statsMap[bucket]
+		if !ok {
+			s = &common.TimePeriodStat{Timestamp: bucket}
+			statsMap[bucket] = s
+		}
+		return s
+	}
+
+	for _, row := range requests {
+		getStat(row.Bucket).RequestsCount += int(row.Count)
+	}
+
+	for _, row := range verifies {
+		getStat(row.Bucket).VerifiesCount += int(row.Count)
+	}
+
+	results := make([]*common.TimePeriodStat, 0, len(statsMap))
+	for _, s := range statsMap {
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Timestamp.Before(results[j].Timestamp) })
+
+	slog.InfoContext(ctx, "Fetched time period stats", "count", len(results), "orgID", orgID, "propID", propertyID,
+		"from", timeFrom, "period", period)
+
+	if cacheKey != nil {
+		const propertyStatsCacheTTL = 5 * time.Minute
+		_ = ts.Cache.SetWithTTL(ctx, *cacheKey, results, propertyStatsCacheTTL)
+	}
+
+	return results, nil
+}
+
+func (ts *PostgresTimeSeries) RetrievePropertyVerifyLatencyByPeriod(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodLatency, error) {
+	return []*common.TimePeriodLatency{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveExperimentStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.ExperimentArmStats, error) {
+	return []*common.ExperimentArmStats{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveVisitorStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.VisitorClassStats, error) {
+	return []*common.VisitorClassStats{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveIntegrityStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) (*common.IntegrityStats, error) {
+	return &common.IntegrityStats{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveQuotaStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.TimeCount, error) {
+	return []*common.TimeCount{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveIssuanceReceipts(ctx context.Context, userID int32, from, to time.Time) ([]*common.IssuanceReceipt, error) {
+	return []*common.IssuanceReceipt{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveIssuanceAudit(ctx context.Context, userID int32, from, to time.Time) ([]*common.IssuanceAuditStat, error) {
+	return []*common.IssuanceAuditStat{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveVerifyTraces(ctx context.Context, traceID string, limit int) ([]*common.VerifyRecord, error) {
+	return []*common.VerifyRecord{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveVerifyHealth(ctx context.Context, from, to time.Time) ([]*common.VerifyHealthStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := ts.queries.GetVerifyHourlyStats(ctx, &dbgen.GetVerifyHourlyStatsParams{
+		Since: Timestampz(from.UTC().Truncate(time.Hour)),
+		Until: Timestampz(to.UTC()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query verify hourly stats", common.ErrAttr(err))
+		return nil, err
+	}
+
+	// verification latency is not stored in postgres
+	results := make([]*common.VerifyHealthStat, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &common.VerifyHealthStat{
+			Timestamp:    row.Bucket.Time.UTC(),
+			SuccessCount: uint64(row.Successes),
+			FailureCount: uint64(row.Failures),
+		})
+	}
+
+	slog.InfoContext(ctx, "Fetched verify health stats", "count", len(results), "from", from, "to", to)
+
+	return results, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveRecentTopProperties(ctx context.Context, limit int) (map[int32]uint, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := ts.queries.GetTopVerifiedProperties(ctx, int32(limit))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute top usage query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	properties := make(map[int32]uint, len(rows))
+	for _, propertyID := range rows {
+		properties[propertyID]++
+	}
+
+	return properties, nil
+}
+
+func (ts *PostgresTimeSeries) RetrievePropertiesHourlyStats(ctx context.Context, hour time.Time) ([]*common.PropertyHourlyStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := ts.queries.GetPropertiesHourlyStats(ctx, Timestampz(hour.UTC().Truncate(time.Hour)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query properties hourly stats", common.ErrAttr(err))
+		return nil, err
+	}
+
+	results := make([]*common.PropertyHourlyStat, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &common.PropertyHourlyStat{
+			OrgID:        row.OrgID,
+			PropertyID:   row.PropertyID,
+			Requests:     uint64(row.Requests),
+			SuccessCount: uint64(row.Successes),
+			FailureCount: uint64(row.Failures),
+		})
+	}
+
+	slog.InfoContext(ctx, "Fetched properties hourly stats", "count", len(results), "hour", hour)
+
+	return results, nil
+}
+
+func (ts *PostgresTimeSeries) RetrievePropertiesStats(ctx context.Context, from, to time.Time) ([]*common.PropertyHourlyStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := ts.queries.GetPropertiesStats(ctx, &dbgen.GetPropertiesStatsParams{
+		Since: Timestampz(from.UTC()),
+		Until: Timestampz(to.UTC()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query properties stats", common.ErrAttr(err))
+		return nil, err
+	}
+
+	results := make([]*common.PropertyHourlyStat, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &common.PropertyHourlyStat{
+			OrgID:        row.OrgID,
+			PropertyID:   row.PropertyID,
+			Requests:     uint64(row.Requests),
+			SuccessCount: uint64(row.Successes),
+			FailureCount: uint64(row.Failures),
+		})
+	}
+
+	slog.InfoContext(ctx, "Fetched properties stats", "count", len(results), "from", from, "to", to)
+
+	return results, nil
+}
+
+// RetrieveOrgUsage does not estimate storage as aggregates take a negligible share of Postgres
+func (ts *PostgresTimeSeries) RetrieveOrgUsage(ctx context.Context, from, to time.Time) ([]*common.OrgUsageStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	rows, err := ts.queries.GetOrgUsageStats(ctx, &dbgen.GetOrgUsageStatsParams{
+		Since: Timestampz(from.UTC()),
+		Until: Timestampz(to.UTC()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query org usage", common.ErrAttr(err))
+		return nil, err
+	}
+
+	results := make([]*common.OrgUsageStat, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &common.OrgUsageStat{
+			UserID:        row.UserID,
+			OrgID:         row.OrgID,
+			Requests:      uint64(row.Requests),
+			Verifications: uint64(row.Verifications),
+		})
+	}
+
+	slog.InfoContext(ctx, "Fetched org usage", "count", len(results), "from", from, "to", to)
+
+	return results, nil
+}
+
+// SchemaVersion is always zero as Postgres tables are created with regular migrations
+func (ts *PostgresTimeSeries) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	return 0, false, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveEarliestTimestamp(ctx context.Context, table string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (ts *PostgresTimeSeries) ExecBackfill(ctx context.Context, table, query string, from, to time.Time) error {
+	return errors.ErrUnsupported
+}
+
+func (ts *PostgresTimeSeries) DeletePropertiesData(ctx context.Context, propertyIDs []int32) error {
+	if len(propertyIDs) == 0 {
+		slog.WarnContext(ctx, "Nothing to delete from time series")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	if err := ts.queries.DeletePropertiesStats(ctx, propertyIDs); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete properties stats", common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+func (ts *PostgresTimeSeries) DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error {
+	if len(orgIDs) == 0 {
+		slog.WarnContext(ctx, "Nothing to delete from time series")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	if err := ts.queries.DeleteOrganizationsStats(ctx, orgIDs); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete organizations stats", common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+func (ts *PostgresTimeSeries) DeleteUsersData(ctx context.Context, userIDs []int32) error {
+	if len(userIDs) == 0 {
+		slog.WarnContext(ctx, "Nothing to delete from time series")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	if err := ts.queries.DeleteUsersStats(ctx, userIDs); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete users stats", common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredData removes aggregates older than retention (ClickHouse does the same with TTLs)
+func (ts *PostgresTimeSeries) DeleteExpiredData(ctx context.Context) error {
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	before := time.Now().UTC().Add(-ts.Retention())
+	if err := ts.queries.DeleteStatsBefore(ctx, Timestampz(before)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete expired stats", "before", before, common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Deleted expired stats", "before", before)
+
+	return nil
+}
+
+// RetrieveDataPropertyIDs returns nothing as stats are deleted together with properties in the same database
+func (ts *PostgresTimeSeries) RetrieveDataPropertyIDs(ctx context.Context, afterID int32, limit int) ([]int32, error) {
+	return []int32{}, nil
+}
+
+func (ts *PostgresTimeSeries) RetrieveDataOrgIDs(ctx context.Context, afterID int32, limit int) ([]int32, error) {
+	return []int32{}, nil
+}
+
+func (ts *PostgresTimeSeries) PendingDeletesCount(ctx context.Context) (int, error) {
+	return 0, nil
+}
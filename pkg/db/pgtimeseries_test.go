@@ -0,0 +1,51 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+func TestPostgresPeriodBuckets(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, time.March, 15, 13, 47, 0, 0, time.UTC)
+	sample := time.Date(2025, time.March, 14, 20, 0, 0, 0, time.UTC)
+
+	for i, tc := range []struct {
+		period   common.TimePeriod
+		unit     string
+		from     time.Time
+		expected time.Time
+	}{
+		{common.TimePeriodToday, "hour", time.Date(2025, time.March, 14, 13, 0, 0, 0, time.UTC), sample},
+		{common.TimePeriodWeek, "hour", time.Date(2025, time.March, 8, 12, 0, 0, 0, time.UTC), time.Date(2025, time.March, 14, 18, 0, 0, 0, time.UTC)},
+		{common.TimePeriodMonth, "day", time.Date(2025, time.February, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, time.March, 14, 0, 0, 0, 0, time.UTC)},
+		{common.TimePeriodYear, "day", time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		from, unit, truncate := postgresPeriodBuckets(tc.period, tnow)
+		if unit != tc.unit {
+			t.Errorf("Unexpected unit (%v): %v", i, unit)
+		}
+
+		if !from.Equal(tc.from) {
+			t.Errorf("Unexpected start time (%v): %v (expected %v)", i, from, tc.from)
+		}
+
+		if actual := truncate(sample); !actual.Equal(tc.expected) {
+			t.Errorf("Unexpected bucket (%v): %v (expected %v)", i, actual, tc.expected)
+		}
+	}
+}
+
+func TestStatsKeyTruncation(t *testing.T) {
+	t.Parallel()
+
+	first := newStatsKey(1, 2, 3, time.Date(2025, time.March, 15, 13, 41, 10, 0, time.UTC))
+	second := newStatsKey(1, 2, 3, time.Date(2025, time.March, 15, 13, 44, 59, 0, time.UTC))
+
+	if first != second {
+		t.Errorf("Records within 5 minutes have different keys: %v and %v", first, second)
+	}
+}
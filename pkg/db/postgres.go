@@ -2,25 +2,35 @@ package db
 
 import (
 	"context"
+	"crypto/rand"
 	"embed"
+	"errors"
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	config_pkg "github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/golang-migrate/migrate/v4"
 	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 )
 
 const (
 	pgMigrationsSchema                = "public"
+	postgresMigrationsTable           = "private_captcha_migrations"
+	postgresMigrationsPath            = "migrations/postgres"
+	migrationLockName                 = "postgres_migration"
+	migrationLockDuration             = 10 * time.Minute
 	pgIdleInTransactionSessionTimeout = 10 * time.Second
 	pgStatementTimeout                = 10 * time.Second
 )
@@ -211,3 +221,140 @@ func MigratePostgresEx(ctx context.Context, pool *pgxpool.Pool, migrationsFS fs.
 
 	return nil
 }
+
+type SchemaStatus struct {
+	Current  uint `json:"current"`
+	Required uint `json:"required"`
+	Dirty    bool `json:"dirty"`
+}
+
+// Ready means that code can run against current schema. Newer schema is fine since
+// migrations are backward-compatible (older nodes keep running during rolling upgrade)
+func (s *SchemaStatus) Ready() bool {
+	return !s.Dirty && (s.Current >= s.Required)
+}
+
+// RequiredPostgresSchemaVersion returns the latest migration version embedded into the binary
+func RequiredPostgresSchemaVersion() (uint, error) {
+	entries, err := fs.ReadDir(postgresMigrationsFS, postgresMigrationsPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var result uint
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		prefix, _, found := strings.Cut(name, "_")
+		if !found {
+			return 0, fmt.Errorf("unexpected migration file name: %s", name)
+		}
+
+		version, err := strconv.ParseUint(prefix, 10, 32)
+		if err != nil {
+			return 0, err
+		}
+
+		result = max(result, uint(version))
+	}
+
+	return result, nil
+}
+
+func PostgresSchemaStatus(ctx context.Context, pool *pgxpool.Pool) (*SchemaStatus, error) {
+	required, err := RequiredPostgresSchemaVersion()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find required schema version", common.ErrAttr(err))
+		return nil, err
+	}
+
+	status := &SchemaStatus{Required: required}
+
+	query := fmt.Sprintf("SELECT version, dirty FROM %s.%s LIMIT 1", pgMigrationsSchema, postgresMigrationsTable)
+	var version int64
+	if err := pool.QueryRow(ctx, query).Scan(&version, &status.Dirty); err != nil {
+		if isUndefinedTableError(err) || (err == pgx.ErrNoRows) {
+			// nothing was migrated yet
+			return status, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve schema version", common.ErrAttr(err))
+		return nil, err
+	}
+
+	status.Current = uint(max(0, version))
+
+	return status, nil
+}
+
+func isUndefinedTableError(err error) bool {
+	const undefinedTableCode = "42P01"
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == undefinedTableCode)
+}
+
+// AcquireMigrationLock serializes migrations from concurrent nodes using locks table. The lock is
+// acquired for a fixed duration so that a crashed node does not block migrations forever.
+func AcquireMigrationLock(ctx context.Context, pool *pgxpool.Pool, timeout time.Duration) (func(), error) {
+	querier := dbgen.New(pool)
+
+	// if our lock expired and another node acquired it, release must not delete the lock of that node
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return nil, err
+	}
+
+	release := func() {
+		deleted, err := querier.DeleteOwnedLock(context.WithoutCancel(ctx), &dbgen.DeleteOwnedLockParams{
+			Name: migrationLockName,
+			Data: owner,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to release migration lock", common.ErrAttr(err))
+		} else if deleted == 0 {
+			slog.WarnContext(ctx, "Migration lock expired before it was released")
+		} else {
+			slog.DebugContext(ctx, "Released migration lock")
+		}
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	timeoutExceeded := time.After(timeout)
+
+	for {
+		lock, err := querier.InsertLock(ctx, &dbgen.InsertLockParams{
+			Name:      migrationLockName,
+			Data:      owner,
+			ExpiresAt: Timestampz(time.Now().UTC().Add(migrationLockDuration)),
+		})
+
+		switch {
+		case err == nil:
+			slog.InfoContext(ctx, "Acquired migration lock", "expires_at", lock.ExpiresAt.Time)
+			return release, nil
+		case isUndefinedTableError(err):
+			// locks table is created by migrations themselves so the very first migration cannot be protected
+			slog.WarnContext(ctx, "Locks table does not exist, migrating without a lock")
+			return func() {}, nil
+		case err != pgx.ErrNoRows:
+			slog.ErrorContext(ctx, "Failed to acquire migration lock", common.ErrAttr(err))
+			return nil, err
+		}
+
+		slog.InfoContext(ctx, "Waiting for another migration to finish")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeoutExceeded:
+			slog.ErrorContext(ctx, "Timed out waiting for migration lock", "timeout", timeout)
+			return nil, ErrLocked
+		case <-ticker.C:
+		}
+	}
+}
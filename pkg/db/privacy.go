@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	privacySaltCacheKeyPrefix = "privacy_salt/"
+	privacySaltSize           = 32
+	// salt has to be available for the whole day on every instance, but not (much) longer
+	privacySaltTTL = 25 * time.Hour
+)
+
+func privacySaltCacheKey(tnow time.Time) string {
+	return privacySaltCacheKeyPrefix + tnow.UTC().Format(time.DateOnly)
+}
+
+// PrivacySalt is the in-memory copy of the daily salt of the "hash" privacy mode. It's shared between instances
+// via DB cache (so that fingerprints are the same within a day) and expires from there after rotation.
+type PrivacySalt struct {
+	value atomic.Pointer[[]byte]
+}
+
+func NewPrivacySalt() *PrivacySalt {
+	s := &PrivacySalt{}
+	// until the shared salt is fetched, use a random one (it never weakens privacy, only consistency)
+	value := make([]byte, privacySaltSize)
+	_, _ = rand.Read(value)
+	s.value.Store(&value)
+	return s
+}
+
+func (s *PrivacySalt) Value() []byte {
+	return *s.value.Load()
+}
+
+func (s *PrivacySalt) Update(value []byte) {
+	if len(value) == 0 {
+		return
+	}
+
+	s.value.Store(&value)
+}
+
+// RetrievePrivacySalt returns salt of the current day, creating it if needed
+func (impl *BusinessStoreImpl) RetrievePrivacySalt(ctx context.Context, tnow time.Time) ([]byte, error) {
+	key := privacySaltCacheKey(tnow)
+
+	if data, err := impl.RetrieveFromCache(ctx, key); err == nil {
+		return data, nil
+	} else if err != ErrCacheMiss {
+		return nil, err
+	}
+
+	value := make([]byte, privacySaltSize)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+
+	if err := impl.StoreInCache(ctx, key, value, privacySaltTTL); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Created new privacy salt", "key", key)
+
+	// another instance could have stored its salt concurrently, the last write wins for everybody
+	data, err := impl.RetrieveFromCache(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read back privacy salt", common.ErrAttr(err))
+		return value, nil
+	}
+
+	return data, nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"strings"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	MaxPropertyCloneTargets = 128
+)
+
+// PropertyCloneTarget is the new property that gets settings of the source one
+type PropertyCloneTarget struct {
+	// defaults to the domain
+	Name   string `json:"name,omitempty"`
+	Domain string `json:"domain"`
+}
+
+func (t *PropertyCloneTarget) PropertyName() string {
+	if name := strings.TrimSpace(t.Name); len(name) > 0 {
+		return name
+	}
+
+	return t.Domain
+}
+
+// ClonePropertyParams copies settings of the source property. Twin relation is not copied as it's unique for the pair.
+func ClonePropertyParams(source *dbgen.Property, creatorID int32, target *PropertyCloneTarget) *dbgen.CreatePropertyParams {
+	return &dbgen.CreatePropertyParams{
+		Name:                   target.PropertyName(),
+		CreatorID:              Int(creatorID),
+		Domain:                 target.Domain,
+		Level:                  source.Level,
+		Growth:                 source.Growth,
+		ValidityInterval:       source.ValidityInterval,
+		AllowSubdomains:        source.AllowSubdomains,
+		AllowLocalhost:         source.AllowLocalhost,
+		MaxReplayCount:         source.MaxReplayCount,
+		AllowedClockSkew:       source.AllowedClockSkew,
+		RememberWindow:         source.RememberWindow,
+		WidgetFlags:            source.WidgetFlags,
+		Environment:            source.Environment,
+		TrustGroup:             source.TrustGroup,
+		Claims:                 source.Claims,
+		DifferentialDifficulty: source.DifferentialDifficulty,
+		BotPolicy:              source.BotPolicy,
+		FailureURL:             source.FailureURL,
+		FailureMessage:         source.FailureMessage,
+		DifficultyStrategy:     source.DifficultyStrategy,
+		PrivacyMode:            source.PrivacyMode,
+	}
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestClonePropertyParams(t *testing.T) {
+	t.Parallel()
+
+	source := &dbgen.Property{
+		ID:              1,
+		Name:            "source",
+		Domain:          "example.com",
+		Level:           Int2(int16(common.DifficultyLevelHigh)),
+		Growth:          dbgen.DifficultyGrowthFast,
+		AllowSubdomains: true,
+		MaxReplayCount:  5,
+		TwinID:          Int(2),
+		PrivacyMode:     string(common.PrivacyModeHash),
+	}
+
+	params := ClonePropertyParams(source, 3, &PropertyCloneTarget{Domain: "example.org"})
+	if params.Name != "example.org" {
+		t.Errorf("Unexpected name: %v", params.Name)
+	}
+
+	if (params.Level != source.Level) || (params.Growth != source.Growth) || !params.AllowSubdomains || (params.MaxReplayCount != 5) {
+		t.Errorf("Settings were not copied: %+v", params)
+	}
+
+	if params.PrivacyMode != source.PrivacyMode {
+		t.Errorf("Privacy mode was not copied: %v", params.PrivacyMode)
+	}
+
+	if params.TwinID.Valid || (params.CreatorID.Int32 != 3) {
+		t.Errorf("Unexpected twin or creator: %v %v", params.TwinID, params.CreatorID)
+	}
+
+	params = ClonePropertyParams(source, 3, &PropertyCloneTarget{Name: " clone ", Domain: "example.org"})
+	if params.Name != "clone" {
+		t.Errorf("Unexpected name: %v", params.Name)
+	}
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"math"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+// PropertyMoveRequestsGrace is the share of the requests limit that destination org owner can go over with
+// the projected usage of the moved property (projection is only an estimate so we don't block moves at the edge)
+const PropertyMoveRequestsGrace = 0.1
+
+// PropertyMoveLimits is the evaluation of destination org owner's plan limits when property is moved between orgs.
+// Usage is attributed to the org owner at request time, so requests served before the move stay with the old owner
+// and only the rest of the current billing month is projected onto the new one.
+type PropertyMoveLimits struct {
+	OwnerChanged      bool  `json:"owner_changed"`
+	PropertiesLimit   int   `json:"properties_limit,omitempty"`
+	PropertiesExtra   int   `json:"properties_extra,omitempty"`
+	RequestsUsed      int64 `json:"requests_used,omitempty"`
+	RequestsProjected int64 `json:"requests_projected,omitempty"`
+	RequestsLimit     int64 `json:"requests_limit,omitempty"`
+	Grace             bool  `json:"grace,omitempty"`
+}
+
+// RequestsExtra returns by how much the destination owner would go over the requests limit (negative if not)
+func (l *PropertyMoveLimits) RequestsExtra() int64 {
+	return l.RequestsUsed + l.RequestsProjected - l.RequestsLimit
+}
+
+// CheckRequests returns false if projected usage exceeds the requests limit of the destination plan over the grace
+// threshold. Exceeding it within the threshold is allowed and marked as grace.
+func (l *PropertyMoveLimits) CheckRequests() bool {
+	// zero means there's no known limit (e.g. stub limits) and property without traffic adds nothing to the usage
+	if (l.RequestsLimit <= 0) || (l.RequestsProjected <= 0) {
+		l.Grace = false
+		return true
+	}
+
+	extra := l.RequestsExtra()
+	if extra <= 0 {
+		l.Grace = false
+		return true
+	}
+
+	graceLimit := int64(math.Ceil(float64(l.RequestsLimit) * PropertyMoveRequestsGrace))
+	l.Grace = extra <= graceLimit
+
+	return l.Grace
+}
+
+// ProjectMonthlyRequests prorates recent daily usage of the property (as returned for TimePeriodMonth) to the rest
+// of the billing month of tnow
+func ProjectMonthlyRequests(stats []*common.TimePeriodStat, tnow time.Time) int64 {
+	if len(stats) == 0 {
+		return 0
+	}
+
+	from := tnow
+	var total int64
+	for _, st := range stats {
+		total += int64(st.RequestsCount)
+		if st.Timestamp.Before(from) {
+			from = st.Timestamp
+		}
+	}
+
+	if total <= 0 {
+		return 0
+	}
+
+	days := max(tnow.Sub(from).Hours()/24.0, 1.0)
+
+	monthEnd := time.Date(tnow.Year(), tnow.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	remainingDays := monthEnd.Sub(tnow).Hours() / 24.0
+
+	return int64(math.Ceil(float64(total) / days * remainingDays))
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+func TestProjectMonthlyRequests(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, time.April, 21, 0, 0, 0, 0, time.UTC)
+
+	stats := make([]*common.TimePeriodStat, 0, 30)
+	for i := 30; i > 0; i-- {
+		stats = append(stats, &common.TimePeriodStat{Timestamp: tnow.AddDate(0, 0, -i), RequestsCount: 100})
+	}
+
+	// 100 requests per day for the remaining 10 days of April
+	if projected := ProjectMonthlyRequests(stats, tnow); projected != 1000 {
+		t.Errorf("Unexpected projected requests: %v", projected)
+	}
+
+	if projected := ProjectMonthlyRequests(nil, tnow); projected != 0 {
+		t.Errorf("Unexpected projected requests without stats: %v", projected)
+	}
+
+	if projected := ProjectMonthlyRequests([]*common.TimePeriodStat{{Timestamp: tnow, RequestsCount: 0}}, tnow); projected != 0 {
+		t.Errorf("Unexpected projected requests without traffic: %v", projected)
+	}
+}
+
+func TestPropertyMoveCheckRequests(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		used      int64
+		projected int64
+		limit     int64
+		ok        bool
+		grace     bool
+	}{
+		{0, 1000, 0, true, false},
+		{500, 400, 1000, true, false},
+		{500, 500, 1000, true, false},
+		{600, 500, 1000, true, true},
+		{600, 501, 1000, false, false},
+		{2000, 0, 1000, true, false},
+		{2000, 10, 1000, false, false},
+	}
+
+	for _, tc := range testCases {
+		l := &PropertyMoveLimits{RequestsUsed: tc.used, RequestsProjected: tc.projected, RequestsLimit: tc.limit}
+		if ok := l.CheckRequests(); (ok != tc.ok) || (l.Grace != tc.grace) {
+			t.Errorf("Unexpected result for %+v: ok=%v grace=%v", tc, ok, l.Grace)
+		}
+	}
+}
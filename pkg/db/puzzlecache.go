@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"math"
 	"sync/atomic"
 	"time"
 
@@ -12,14 +13,11 @@ type puzzleCache struct {
 	store *otter.Cache[uint64, *uint32]
 }
 
-func newPuzzleCache(expiryTTL time.Duration) *puzzleCache {
-	const maxSize = 500_000
-	const initialSize = 1_000
-
+func newPuzzleCache(expiryTTL time.Duration, maxSize int) *puzzleCache {
 	return &puzzleCache{
 		store: otter.Must(&otter.Options[uint64, *uint32]{
 			MaximumSize:      maxSize,
-			InitialCapacity:  initialSize,
+			InitialCapacity:  min(1_000, maxSize),
 			ExpiryCalculator: otter.ExpiryAccessing[uint64, *uint32](expiryTTL),
 			Logger:           &pcOtterLogger{},
 		}),
@@ -48,3 +46,14 @@ func (pc *puzzleCache) Inc(ctx context.Context, key uint64, ttl time.Duration) u
 
 	return result
 }
+
+// Burn makes any count check of the key fail until it expires
+func (pc *puzzleCache) Burn(ctx context.Context, key uint64, ttl time.Duration) {
+	value, _ := pc.store.ComputeIfAbsent(key, puzzleCacheMap)
+	atomic.StoreUint32(value, math.MaxUint32)
+	pc.store.SetExpiresAfter(key, ttl)
+}
+
+func (pc *puzzleCache) Len() int {
+	return pc.store.EstimatedSize()
+}
@@ -1,5 +1,5 @@
 -- name: GetAPIKeyByExternalID :one
-SELECT * FROM backend.apikeys WHERE external_id = $1;
+SELECT * FROM backend.apikeys WHERE external_id = $1 OR (previous_external_id = $1 AND previous_expires_at > NOW());
 
 -- name: GetUserAPIKeys :many
 SELECT * FROM backend.apikeys WHERE user_id = $1 AND expires_at > NOW();
@@ -8,16 +8,46 @@ SELECT * FROM backend.apikeys WHERE user_id = $1 AND expires_at > NOW();
 SELECT * FROM backend.apikeys WHERE user_id = $1 AND name = $2 AND expires_at > NOW();
 
 -- name: CreateAPIKey :one
-INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst, period, scope, readonly, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
+INSERT INTO backend.apikeys (name, user_id, expires_at, requests_per_second, requests_burst, period, scope, readonly, org_id, allowed_cidrs) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
 
 -- name: UpdateAPIKey :one
 UPDATE backend.apikeys SET expires_at = $1, enabled = $2, updated_at = NOW() WHERE external_id = $3 RETURNING *;
 
 -- name: RotateAPIKey :one
-UPDATE backend.apikeys SET external_id = gen_random_uuid(), expires_at = NOW() + period, updated_at = NOW() WHERE id = $1 AND user_id = $2 RETURNING *;
+UPDATE backend.apikeys SET previous_external_id = external_id, previous_expires_at = NOW() + sqlc.arg(overlap)::interval, external_id = gen_random_uuid(), expires_at = NOW() + period, updated_at = NOW() WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) RETURNING *;
 
 -- name: DeleteUserAPIKeys :exec
 DELETE FROM backend.apikeys WHERE user_id = $1;
 
 -- name: DeleteAPIKey :one
 DELETE FROM backend.apikeys WHERE id=$1 AND user_id = $2 RETURNING *;
+
+-- name: DeleteOrgAPIKeys :many
+DELETE FROM backend.apikeys WHERE org_id = $1 RETURNING *;
+
+-- name: AddAPIKeysUsage :exec
+INSERT INTO backend.apikey_usage (apikey_id, day, count)
+SELECT u.apikey_id, CURRENT_DATE, u.count
+FROM (SELECT unnest(@ids::INT[]) AS apikey_id, unnest(@counts::BIGINT[]) AS count) AS u
+JOIN backend.apikeys k ON k.id = u.apikey_id
+ON CONFLICT (apikey_id, day)
+DO UPDATE SET
+    count = backend.apikey_usage.count + EXCLUDED.count,
+    last_used_at = NOW();
+
+-- name: AddAPIKeysIPViolations :exec
+UPDATE backend.apikeys k
+SET ip_violations = k.ip_violations + u.count,
+    last_ip_violation_at = NOW()
+FROM (SELECT unnest(@ids::INT[]) AS apikey_id, unnest(@counts::BIGINT[]) AS count) AS u
+WHERE k.id = u.apikey_id;
+
+-- name: GetUserAPIKeysLastUsed :many
+SELECT au.apikey_id, MAX(au.last_used_at)::TIMESTAMPTZ AS last_used_at
+FROM backend.apikey_usage au
+JOIN backend.apikeys k ON k.id = au.apikey_id
+WHERE k.user_id = $1
+GROUP BY au.apikey_id;
+
+-- name: DeleteOldAPIKeysUsage :exec
+DELETE FROM backend.apikey_usage WHERE last_used_at < $1;
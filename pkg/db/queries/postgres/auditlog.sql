@@ -1,6 +1,6 @@
 -- name: CreateAuditLogs :copyfrom
-INSERT INTO backend.audit_logs (user_id, action, source, entity_id, entity_table, session_id, old_value, new_value, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+INSERT INTO backend.audit_logs (user_id, action, source, entity_id, entity_table, session_id, trace_id, old_value, new_value, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 
 -- name: DeleteOldAuditLogs :exec
 DELETE FROM backend.audit_logs WHERE created_at < $1;
@@ -27,6 +27,28 @@ ORDER BY a.created_at DESC
 OFFSET $3
 LIMIT $4;
 
+-- name: GetUserAuditLogsBefore :many
+SELECT sqlc.embed(a), u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (a.user_id = $1 OR
+    (
+        a.entity_table = 'users' AND a.entity_id = $1
+    ) OR
+    (
+        a.entity_table = 'organization_users'
+        AND ((a.old_value ->> 'user_id')::bigint = $1 OR (a.new_value ->> 'user_id')::bigint = $1)
+    ) OR
+    (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'creator_id')::bigint = $1 OR (a.new_value ->> 'creator_id')::bigint = $1)
+    )
+)
+AND a.created_at >= $2 AND (a.created_at, a.id) < (@before_created_at::TIMESTAMPTZ, @before_id::BIGINT)
+ORDER BY a.created_at DESC, a.id DESC
+OFFSET $3
+LIMIT $4;
+
 -- name: GetPropertyAuditLogs :many
 SELECT sqlc.embed(a), u.name, u.email
 FROM backend.audit_logs a
@@ -42,7 +64,7 @@ SELECT sqlc.embed(a), u.name, u.email
 FROM backend.audit_logs a
 LEFT JOIN backend.users u ON u.id = a.user_id
 WHERE (
-    ((a.entity_table = 'organizations' OR a.entity_table = 'organization_users') AND a.entity_id = $1)
+    ((a.entity_table = 'organizations' OR a.entity_table = 'organization_users' OR a.entity_table = 'billing_contacts' OR a.entity_table = 'org_ip_allowlists' OR a.entity_table = 'org_email_domains') AND a.entity_id = $1)
     OR (
         a.entity_table = 'properties'
         AND ((a.old_value ->> 'org_id')::bigint = $1 OR (a.new_value ->> 'org_id')::bigint = $1)
@@ -52,3 +74,108 @@ AND a.created_at >= $2
 ORDER BY a.created_at DESC
 OFFSET $3
 LIMIT $4;
+
+-- name: GetTraceAuditLogs :many
+SELECT sqlc.embed(a), u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE a.trace_id = $1
+ORDER BY a.created_at ASC
+LIMIT $2;
+
+-- name: GetSystemAuditLogs :many
+SELECT sqlc.embed(a), u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE a.entity_table = ANY(@tables::TEXT[]) AND a.created_at >= @since::TIMESTAMPTZ AND a.created_at < @until::TIMESTAMPTZ
+ORDER BY a.created_at ASC
+LIMIT @max_results;
+
+-- name: GetLatestTableAuditLog :one
+SELECT * FROM backend.audit_logs WHERE entity_table = $1 ORDER BY created_at DESC LIMIT 1;
+
+-- name: SearchUserAuditLogs :many
+SELECT sqlc.embed(a), u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (a.user_id = @user_id OR
+    (
+        a.entity_table = 'users' AND a.entity_id = @user_id
+    ) OR
+    (
+        a.entity_table = 'organization_users'
+        AND ((a.old_value ->> 'user_id')::bigint = @user_id OR (a.new_value ->> 'user_id')::bigint = @user_id)
+    ) OR
+    (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'creator_id')::bigint = @user_id OR (a.new_value ->> 'creator_id')::bigint = @user_id)
+    )
+)
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', @query::text)
+    OR strpos(lower(coalesce(u.email, '')), lower(@query::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower(@query::text)) > 0
+)
+AND a.created_at >= @created_at
+ORDER BY a.created_at DESC
+LIMIT @max_results;
+
+-- name: SearchUserAuditLogsBefore :many
+SELECT sqlc.embed(a), u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (a.user_id = @user_id OR
+    (
+        a.entity_table = 'users' AND a.entity_id = @user_id
+    ) OR
+    (
+        a.entity_table = 'organization_users'
+        AND ((a.old_value ->> 'user_id')::bigint = @user_id OR (a.new_value ->> 'user_id')::bigint = @user_id)
+    ) OR
+    (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'creator_id')::bigint = @user_id OR (a.new_value ->> 'creator_id')::bigint = @user_id)
+    )
+)
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', @query::text)
+    OR strpos(lower(coalesce(u.email, '')), lower(@query::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower(@query::text)) > 0
+)
+AND a.created_at >= @created_at AND (a.created_at, a.id) < (@before_created_at::TIMESTAMPTZ, @before_id::BIGINT)
+ORDER BY a.created_at DESC, a.id DESC
+LIMIT @max_results;
+
+-- name: SearchPropertyAuditLogs :many
+SELECT sqlc.embed(a), u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE a.entity_table = 'properties' AND a.entity_id = @entity_id
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', @query::text)
+    OR strpos(lower(coalesce(u.email, '')), lower(@query::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower(@query::text)) > 0
+)
+AND a.created_at >= @created_at
+ORDER BY a.created_at DESC
+LIMIT @max_results;
+
+-- name: SearchOrgAuditLogs :many
+SELECT sqlc.embed(a), u.name, u.email
+FROM backend.audit_logs a
+LEFT JOIN backend.users u ON u.id = a.user_id
+WHERE (
+    ((a.entity_table = 'organizations' OR a.entity_table = 'organization_users' OR a.entity_table = 'billing_contacts' OR a.entity_table = 'org_ip_allowlists' OR a.entity_table = 'org_email_domains') AND a.entity_id = @entity_id)
+    OR (
+        a.entity_table = 'properties'
+        AND ((a.old_value ->> 'org_id')::bigint = @entity_id OR (a.new_value ->> 'org_id')::bigint = @entity_id)
+    )
+)
+AND (
+    backend.audit_log_search_vector(a.entity_table, a.old_value, a.new_value) @@ websearch_to_tsquery('simple', @query::text)
+    OR strpos(lower(coalesce(u.email, '')), lower(@query::text)) > 0
+    OR strpos(lower(coalesce(u.name, '')), lower(@query::text)) > 0
+)
+AND a.created_at >= @created_at
+ORDER BY a.created_at DESC
+LIMIT @max_results;
@@ -0,0 +1,24 @@
+-- name: GetOrgBillingContacts :many
+SELECT * FROM backend.billing_contacts WHERE org_id = $1 ORDER BY created_at, id;
+
+-- name: CreateOrgBillingContact :one
+INSERT INTO backend.billing_contacts (org_id, email) VALUES ($1, $2) RETURNING *;
+
+-- name: DeleteOrgBillingContact :one
+DELETE FROM backend.billing_contacts WHERE id = $1 AND org_id = $2 RETURNING *;
+
+-- name: GetOrgBillingSettings :one
+SELECT * FROM backend.org_billing_settings WHERE org_id = $1;
+
+-- name: UpsertOrgBillingSettings :one
+INSERT INTO backend.org_billing_settings (org_id, notify_owner) VALUES ($1, $2)
+ON CONFLICT (org_id) DO UPDATE SET notify_owner = EXCLUDED.notify_owner, updated_at = NOW()
+RETURNING *;
+
+-- name: GetBillingContactsForOrgs :many
+SELECT bc.org_id, bc.email, COALESCE(bs.notify_owner, TRUE)::BOOLEAN AS notify_owner
+FROM backend.billing_contacts bc
+JOIN backend.organizations o ON o.id = bc.org_id
+LEFT JOIN backend.org_billing_settings bs ON bs.org_id = bc.org_id
+WHERE bc.org_id = ANY($1::INT[]) AND o.deleted_at IS NULL
+ORDER BY bc.org_id, bc.id;
@@ -24,3 +24,12 @@ DELETE FROM backend.cache WHERE key = $1;
 
 -- name: DeleteExpiredCache :exec
 DELETE FROM backend.cache WHERE expires_at < NOW();
+
+-- name: GetCachedKeys :many
+SELECT key FROM backend.cache WHERE key = ANY(@keys::TEXT[]) AND expires_at >= NOW();
+
+-- name: CompareAndSwapCache :execrows
+UPDATE backend.cache SET value = @new_value WHERE key = @key AND value = @old_value AND expires_at >= NOW();
+
+-- name: TakeCachedByKey :one
+DELETE FROM backend.cache WHERE key = $1 AND expires_at >= NOW() RETURNING value;
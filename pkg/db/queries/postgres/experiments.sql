@@ -0,0 +1,18 @@
+-- name: CreateDifficultyExperiment :one
+INSERT INTO backend.difficulty_experiments (property_id, org_id, control_level, variant_level, variant_weight, min_samples, max_degradation, ends_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetRunningDifficultyExperiments :many
+SELECT * FROM backend.difficulty_experiments WHERE concluded_at IS NULL ORDER BY id;
+
+-- name: GetPropertyDifficultyExperiments :many
+SELECT * FROM backend.difficulty_experiments WHERE property_id = $1 ORDER BY created_at DESC LIMIT $2;
+
+-- name: ConcludeDifficultyExperiment :one
+UPDATE backend.difficulty_experiments SET
+  concluded_at = $2,
+  outcome = $3,
+  conclusion = $4
+WHERE id = $1 AND concluded_at IS NULL
+RETURNING *;
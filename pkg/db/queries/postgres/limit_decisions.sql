@@ -0,0 +1,12 @@
+-- name: CreateLimitDecision :exec
+INSERT INTO backend.limit_decisions (user_id, org_id, org_owner_id, resource, status_code, source, subscription_id, subscription_status, external_product_id, external_price_id, current_count, requested_count, plan_limit, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);
+
+-- name: GetUserLimitDecisions :many
+SELECT * FROM backend.limit_decisions
+WHERE (user_id = $1 OR org_owner_id = $1) AND created_at >= $2
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: DeleteOldLimitDecisions :exec
+DELETE FROM backend.limit_decisions WHERE created_at < $1;
@@ -2,7 +2,7 @@
 INSERT INTO backend.locks (name, data, expires_at)
 VALUES ($1, $2, $3)
 ON CONFLICT (name) DO UPDATE
-SET expires_at = EXCLUDED.expires_at
+SET expires_at = EXCLUDED.expires_at, data = EXCLUDED.data
 WHERE locks.expires_at <= NOW()
 RETURNING *;
 
@@ -11,3 +11,6 @@ DELETE FROM backend.locks WHERE name = $1;
 
 -- name: GetLock :one
 SELECT * FROM backend.locks WHERE name = $1;
+
+-- name: DeleteOwnedLock :execrows
+DELETE FROM backend.locks WHERE name = $1 AND data = $2;
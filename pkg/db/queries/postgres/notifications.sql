@@ -2,21 +2,37 @@
 SELECT * FROM backend.system_notifications WHERE id = $1;
 
 -- name: GetLastActiveSystemNotification :one
-SELECT * FROM backend.system_notifications
- WHERE is_active = TRUE AND
-   start_date <= $1::timestamptz AND
-   (end_date IS NULL OR end_date > $1::timestamptz) AND
-   (user_id = $2 OR user_id IS NULL)
+SELECT n.* FROM backend.system_notifications n
+ WHERE n.is_active = TRUE AND
+   n.start_date <= $1::timestamptz AND
+   (n.end_date IS NULL OR n.end_date > $1::timestamptz) AND
+   (n.user_id = $2 OR n.user_id IS NULL) AND
+   (n.stage IS NULL OR n.stage = $3) AND
+   (n.org_id IS NULL OR n.org_id IN (
+     SELECT o.id FROM backend.organizations o WHERE o.user_id = $2 AND o.deleted_at IS NULL
+     UNION
+     SELECT ou.org_id FROM backend.organization_users ou WHERE ou.user_id = $2 AND ou.level <> 'invited')) AND
+   (n.external_product_id IS NULL OR n.external_product_id = (
+     SELECT s.external_product_id FROM backend.users u JOIN backend.subscriptions s ON u.subscription_id = s.id WHERE u.id = $2))
  ORDER BY
-   CASE WHEN user_id = $2 THEN 0 ELSE 1 END,
-   start_date DESC
+   CASE WHEN n.user_id = $2 THEN 0 ELSE 1 END,
+   n.severity DESC,
+   n.start_date DESC
  LIMIT 1;
 
 -- name: CreateSystemNotification :one
-INSERT INTO backend.system_notifications (message, start_date, end_date, user_id)
-VALUES ($1, $2, $3, $4)
+INSERT INTO backend.system_notifications (message, start_date, end_date, user_id, severity, is_markdown, org_id, external_product_id, stage)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
+-- name: GetScheduledSystemNotifications :many
+SELECT * FROM backend.system_notifications
+WHERE is_active = TRUE AND (end_date IS NULL OR end_date > $1)
+ORDER BY start_date ASC;
+
+-- name: CancelSystemNotification :one
+UPDATE backend.system_notifications SET is_active = FALSE WHERE id = $1 AND is_active = TRUE RETURNING *;
+
 -- name: CreateNotificationTemplate :one
 INSERT INTO backend.notification_templates (name, content_html, content_text, external_id)
 VALUES ($1, $2, $3, $4)
@@ -26,9 +42,15 @@ RETURNING *;
 -- name: GetNotificationTemplateByHash :one
 SELECT * FROM backend.notification_templates WHERE external_id = $1;
 
+-- name: CreateNotificationPayload :exec
+INSERT INTO backend.notification_payloads (hash, payload)
+VALUES ($1, $2)
+ON CONFLICT (hash) DO UPDATE SET updated_at = NOW()
+WHERE backend.notification_payloads.updated_at < NOW() - INTERVAL '1 hour';
+
 -- name: CreateUserNotification :one
-INSERT INTO backend.user_notifications (user_id, reference_id, template_id, subject, payload, scheduled_at, persistent, requires_subscription)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO backend.user_notifications (user_id, reference_id, template_id, subject, payload_hash, scheduled_at, persistent, requires_subscription, org_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: DeletePendingUserNotification :exec
@@ -48,10 +70,12 @@ UPDATE backend.user_notifications SET
 WHERE id = ANY($1::INT[]);
 
 -- name: GetPendingUserNotifications :many
-SELECT sqlc.embed(un), u.email, u.subscription_id, s.status
+SELECT sqlc.embed(un), u.email, u.subscription_id, s.status, np.payload AS shared_payload, ul.timezone, ul.country
 FROM backend.user_notifications un
 JOIN backend.users u ON un.user_id = u.id
 LEFT JOIN backend.subscriptions s ON u.subscription_id = s.id
+LEFT JOIN backend.notification_payloads np ON un.payload_hash = np.hash
+LEFT JOIN backend.user_locales ul ON un.user_id = ul.user_id
 WHERE un.processed_at IS NULL
   AND un.scheduled_at >= $1
   AND un.scheduled_at <= NOW()
@@ -71,6 +95,11 @@ WHERE nt.id IN (
     AND (nt2.updated_at < $2)
 );
 
+-- name: DeleteUnusedNotificationPayloads :exec
+DELETE FROM backend.notification_payloads np
+WHERE np.updated_at < $1
+AND NOT EXISTS (SELECT 1 FROM backend.user_notifications un WHERE un.payload_hash = np.hash);
+
 -- name: DeleteProcessedUserNotifications :exec
 DELETE FROM backend.user_notifications
 WHERE processed_at IS NOT NULL
@@ -82,3 +111,42 @@ DELETE FROM backend.user_notifications
 WHERE processed_at IS NULL
 AND persistent = false
 AND scheduled_at < $1;
+
+-- name: UpdateUserNotificationsSchedule :exec
+UPDATE backend.user_notifications un
+SET scheduled_at = s.scheduled_at, updated_at = NOW()
+FROM (SELECT unnest(@ids::INT[]) AS id, unnest(@scheduled_at::TIMESTAMPTZ[]) AS scheduled_at) AS s
+WHERE un.id = s.id AND un.processed_at IS NULL;
+
+-- name: UpdateSuppressedUserNotifications :exec
+UPDATE backend.user_notifications SET
+  processed_at = $1,
+  suppressed_at = $1,
+  updated_at = NOW()
+WHERE id = ANY($2::INT[]);
+
+-- name: GetUserNotificationOptOuts :many
+SELECT template_name FROM backend.notification_preferences WHERE user_id = $1 ORDER BY template_name;
+
+-- name: GetUserNotifications :many
+SELECT * FROM backend.user_notifications WHERE user_id = $1 ORDER BY scheduled_at DESC LIMIT $2;
+
+-- name: GetNotificationOptOutsForUsers :many
+SELECT * FROM backend.notification_preferences WHERE user_id = ANY($1::INT[]);
+
+-- name: CreateNotificationOptOut :exec
+INSERT INTO backend.notification_preferences (user_id, template_name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, template_name) DO NOTHING;
+
+-- name: DeleteNotificationOptOut :exec
+DELETE FROM backend.notification_preferences WHERE user_id = $1 AND template_name = $2;
+
+-- name: GetSentUserNotificationsCounts :many
+SELECT user_id, COUNT(*) AS count
+FROM backend.user_notifications
+WHERE processed_at >= $1
+AND processed_at < $2
+AND suppressed_at IS NULL
+AND user_id IS NOT NULL
+GROUP BY user_id;
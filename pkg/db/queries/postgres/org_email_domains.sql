@@ -0,0 +1,20 @@
+-- name: GetOrgEmailDomains :many
+SELECT * FROM backend.org_email_domains WHERE org_id = $1 ORDER BY created_at, id;
+
+-- name: CreateOrgEmailDomain :one
+INSERT INTO backend.org_email_domains (org_id, domain, token) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: VerifyOrgEmailDomain :one
+UPDATE backend.org_email_domains SET verified_at = NOW(), updated_at = NOW() WHERE id = $1 AND org_id = $2 RETURNING *;
+
+-- name: UpdateOrgEmailDomainAutoJoin :one
+UPDATE backend.org_email_domains SET auto_join = $1, updated_at = NOW() WHERE id = $2 AND org_id = $3 RETURNING *;
+
+-- name: DeleteOrgEmailDomain :one
+DELETE FROM backend.org_email_domains WHERE id = $1 AND org_id = $2 RETURNING *;
+
+-- name: GetVerifiedOrgEmailDomain :one
+SELECT sqlc.embed(d), sqlc.embed(o)
+FROM backend.org_email_domains d
+JOIN backend.organizations o ON o.id = d.org_id
+WHERE d.domain = $1 AND d.verified_at IS NOT NULL AND o.deleted_at IS NULL;
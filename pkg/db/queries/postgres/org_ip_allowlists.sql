@@ -0,0 +1,10 @@
+-- name: GetOrgIPAllowlist :one
+SELECT * FROM backend.org_ip_allowlists WHERE org_id = $1;
+
+-- name: UpsertOrgIPAllowlist :one
+INSERT INTO backend.org_ip_allowlists (org_id, cidrs) VALUES ($1, $2)
+ON CONFLICT (org_id) DO UPDATE SET cidrs = EXCLUDED.cidrs, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteOrgIPAllowlist :one
+DELETE FROM backend.org_ip_allowlists WHERE org_id = $1 RETURNING *;
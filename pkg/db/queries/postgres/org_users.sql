@@ -1,9 +1,39 @@
 -- name: GetOrganizationUsers :many
-SELECT sqlc.embed(u), ou.level
+SELECT sqlc.embed(u), ou.level, ou.updated_at AS joined_at
 FROM backend.organization_users ou
 JOIN backend.users u ON ou.user_id = u.id
 WHERE ou.org_id = $1 AND u.deleted_at IS NULL;
 
+-- name: GetOrganizationUsersCount :one
+SELECT COUNT(*) as count
+FROM backend.organization_users ou
+JOIN backend.users u ON ou.user_id = u.id
+WHERE ou.org_id = @org_id AND u.deleted_at IS NULL
+  AND (sqlc.narg(level)::backend.access_level IS NULL OR ou.level = sqlc.narg(level)::backend.access_level)
+  AND (@email::TEXT = '' OR u.email ILIKE '%' || @email::TEXT || '%');
+
+-- name: GetOrganizationUsersPage :many
+SELECT sqlc.embed(u), ou.level, ou.updated_at AS joined_at
+FROM backend.organization_users ou
+JOIN backend.users u ON ou.user_id = u.id
+WHERE ou.org_id = @org_id AND u.deleted_at IS NULL
+  AND (sqlc.narg(level)::backend.access_level IS NULL OR ou.level = sqlc.narg(level)::backend.access_level)
+  AND (@email::TEXT = '' OR u.email ILIKE '%' || @email::TEXT || '%')
+ORDER BY
+  CASE WHEN @sort_by::TEXT = 'email' AND NOT @descending::BOOLEAN THEN u.email END ASC,
+  CASE WHEN @sort_by::TEXT = 'email' AND @descending::BOOLEAN THEN u.email END DESC,
+  CASE WHEN @sort_by::TEXT = 'name' AND NOT @descending::BOOLEAN THEN u.name END ASC,
+  CASE WHEN @sort_by::TEXT = 'name' AND @descending::BOOLEAN THEN u.name END DESC,
+  CASE WHEN @sort_by::TEXT = 'level' AND NOT @descending::BOOLEAN THEN ou.level END ASC,
+  CASE WHEN @sort_by::TEXT = 'level' AND @descending::BOOLEAN THEN ou.level END DESC,
+  CASE WHEN @descending::BOOLEAN THEN ou.updated_at END DESC,
+  ou.updated_at, u.id
+OFFSET @page_offset
+LIMIT @page_limit;
+
+-- name: AddUserToOrg :one
+INSERT INTO backend.organization_users (org_id, user_id, level) VALUES ($1, $2, $3) RETURNING *;
+
 -- name: InviteUserToOrg :one
 INSERT INTO backend.organization_users (org_id, user_id, level) VALUES ($1, $2, 'invited') RETURNING *;
 
@@ -12,3 +42,10 @@ UPDATE backend.organization_users SET level = $1, updated_at = NOW() WHERE org_i
 
 -- name: RemoveUserFromOrg :exec
 DELETE FROM backend.organization_users WHERE org_id = $1 AND user_id = $2;
+
+-- name: GetUserSeatsCount :one
+SELECT COUNT(DISTINCT ou.user_id) AS count
+FROM backend.organization_users ou
+JOIN backend.organizations o ON ou.org_id = o.id
+JOIN backend.users u ON ou.user_id = u.id
+WHERE o.user_id = $1 AND o.deleted_at IS NULL AND u.deleted_at IS NULL;
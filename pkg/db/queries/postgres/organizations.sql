@@ -1,6 +1,9 @@
 -- name: CreateOrganization :one
 INSERT INTO backend.organizations (name, user_id) VALUES ($1, $2) RETURNING *;
 
+-- name: CreateSandboxOrganization :one
+INSERT INTO backend.organizations (name, user_id, sandbox) VALUES ($1, $2, TRUE) RETURNING *;
+
 -- name: GetOrganizationWithAccess :one
  SELECT sqlc.embed(o), ou.level
  FROM backend.organizations o
@@ -43,3 +46,9 @@ LIMIT $2;
 
 -- name: DeleteOrganizations :exec
 DELETE FROM backend.organizations WHERE id = ANY($1::INT[]);
+
+-- name: GetExistingOrganizationIDs :many
+SELECT id FROM backend.organizations WHERE id = ANY(@ids::INT[]);
+
+-- name: GetSandboxOrganizations :many
+SELECT * FROM backend.organizations WHERE sandbox = TRUE AND deleted_at IS NULL AND id > $1 ORDER BY id LIMIT $2;
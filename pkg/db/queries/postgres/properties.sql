@@ -8,8 +8,36 @@ SELECT * from backend.properties WHERE id = ANY($1::INT[]);
 SELECT * from backend.properties WHERE external_id = $1;
 
 -- name: CreateProperty :one
-INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, validity_interval, allow_subdomains, allow_localhost, max_replay_count)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, bot_policy, failure_url, failure_message, difficulty_strategy, privacy_mode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
+RETURNING *;
+
+-- name: CreateProperties :many
+INSERT INTO backend.properties (name, org_id, creator_id, org_owner_id, domain, level, growth, validity_interval, allow_subdomains, allow_localhost, max_replay_count, allowed_clock_skew, remember_window, widget_flags, environment, twin_id, trust_group, claims, differential_difficulty, bot_policy, failure_url, failure_message, difficulty_strategy, privacy_mode)
+SELECT u.name, @org_id::INT, @creator_id::INT, @org_owner_id::INT, u.domain, u.level, u.growth::backend.difficulty_growth, u.validity_interval, u.allow_subdomains, u.allow_localhost, u.max_replay_count, u.allowed_clock_skew, u.remember_window, u.widget_flags, u.environment::backend.property_environment, NULLIF(u.twin_id, 0), u.trust_group, u.claims, u.differential_difficulty, u.bot_policy::backend.bot_policy, u.failure_url, u.failure_message, u.difficulty_strategy, u.privacy_mode
+FROM (SELECT unnest(@names::TEXT[]) AS name,
+             unnest(@domains::TEXT[]) AS domain,
+             unnest(@levels::SMALLINT[]) AS level,
+             unnest(@growths::TEXT[]) AS growth,
+             unnest(@validity_intervals::INTERVAL[]) AS validity_interval,
+             unnest(@allow_subdomains::BOOLEAN[]) AS allow_subdomains,
+             unnest(@allow_localhosts::BOOLEAN[]) AS allow_localhost,
+             unnest(@max_replay_counts::INT[]) AS max_replay_count,
+             unnest(@allowed_clock_skews::INTERVAL[]) AS allowed_clock_skew,
+             unnest(@remember_windows::INTERVAL[]) AS remember_window,
+             unnest(@widget_flags::SMALLINT[]) AS widget_flags,
+             unnest(@environments::TEXT[]) AS environment,
+             unnest(@twin_ids::INT[]) AS twin_id,
+             unnest(@trust_groups::TEXT[]) AS trust_group,
+             unnest(@claims::TEXT[]) AS claims,
+             unnest(@differential_difficulties::BOOLEAN[]) AS differential_difficulty,
+             unnest(@bot_policies::TEXT[]) AS bot_policy,
+             unnest(@failure_urls::TEXT[]) AS failure_url,
+             unnest(@failure_messages::TEXT[]) AS failure_message,
+             unnest(@difficulty_strategies::TEXT[]) AS difficulty_strategy,
+             unnest(@privacy_modes::TEXT[]) AS privacy_mode)
+    AS u
+ON CONFLICT (name, org_id) DO NOTHING
 RETURNING *;
 
 -- name: UpdateProperty :one
@@ -27,6 +55,17 @@ upd AS (
         allow_subdomains = $6,
         allow_localhost = $7,
         max_replay_count = $8,
+        allowed_clock_skew = $11,
+        remember_window = $12,
+        widget_flags = $13,
+        twin_id = $14,
+        trust_group = $15,
+        claims = $16,
+        differential_difficulty = $17,
+        bot_policy = $18,
+        failure_url = $19,
+        failure_message = $20,
+        difficulty_strategy = $21,
         updated_at = NOW()
     WHERE p.id = (SELECT id FROM old)
     RETURNING * -- This ensures the final SELECT only returns data if the update actually happened
@@ -39,7 +78,18 @@ SELECT
     old.validity_interval AS old_validity_interval,
     old.allow_subdomains AS old_allow_subdomains,
     old.allow_localhost AS old_allow_localhost,
-    old.max_replay_count AS old_max_replay_count
+    old.max_replay_count AS old_max_replay_count,
+    old.allowed_clock_skew AS old_allowed_clock_skew,
+    old.remember_window AS old_remember_window,
+    old.widget_flags AS old_widget_flags,
+    old.twin_id AS old_twin_id,
+    old.trust_group AS old_trust_group,
+    old.claims AS old_claims,
+    old.differential_difficulty AS old_differential_difficulty,
+    old.bot_policy AS old_bot_policy,
+    old.failure_url AS old_failure_url,
+    old.failure_message AS old_failure_message,
+    old.difficulty_strategy AS old_difficulty_strategy
 FROM upd
 CROSS JOIN old;
 
@@ -58,10 +108,17 @@ SELECT * from backend.properties WHERE id = $1;
 SELECT *
 FROM backend.properties
 WHERE org_id = $1 AND deleted_at IS NULL
-ORDER BY created_at
+ORDER BY created_at, id
 OFFSET $2
 LIMIT $3;
 
+-- name: GetOrgPropertiesAfter :many
+SELECT *
+FROM backend.properties
+WHERE org_id = $1 AND deleted_at IS NULL AND (created_at, id) > (@created_at::TIMESTAMPTZ, @id::INT)
+ORDER BY created_at, id
+LIMIT $2;
+
 -- name: SoftDeleteProperty :one
 UPDATE backend.properties SET deleted_at = NOW(), updated_at = NOW(), name = name || ' deleted_' || substr(md5(random()::text), 1, 8) WHERE id = $1 RETURNING *;
 
@@ -82,11 +139,48 @@ LIMIT $2;
 -- name: DeleteProperties :exec
 DELETE FROM backend.properties WHERE id = ANY($1::INT[]);
 
+-- name: GetExistingPropertyIDs :many
+SELECT id FROM backend.properties WHERE id = ANY(@ids::INT[]);
+
+-- name: GetOrgPropertyIDs :many
+SELECT id FROM backend.properties WHERE org_id = $1;
+
+-- name: DeleteOrgProperties :many
+DELETE FROM backend.properties WHERE org_id = $1 RETURNING *;
+
 -- name: GetProperties :many
 SELECT * FROM backend.properties LIMIT $1;
 
 -- name: GetUserPropertiesCount :one
-SELECT COUNT(*) as count FROM backend.properties WHERE org_owner_id = $1 AND deleted_at IS NULL;
+SELECT COUNT(*) as count FROM backend.properties p WHERE p.org_owner_id = $1 AND p.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM backend.organizations o WHERE o.id = p.org_id AND o.sandbox);
+
+-- name: GetUserPropertiesOverLimit :many
+SELECT p.id, p.name, p.org_id, o.name AS org_name, p.created_at
+FROM backend.properties p
+JOIN backend.organizations o ON o.id = p.org_id
+WHERE p.org_owner_id = $1 AND p.deleted_at IS NULL AND NOT o.sandbox
+ORDER BY p.created_at ASC, p.id ASC
+OFFSET $2 LIMIT $3;
 
 -- name: GetOrgPropertiesCount :one
 SELECT COUNT(*) as count FROM backend.properties WHERE org_id = $1 AND deleted_at IS NULL;
+
+-- name: GetPropertiesForDomainCheck :many
+SELECT * FROM backend.properties
+WHERE deleted_at IS NULL AND (domain_checked_at IS NULL OR domain_checked_at < $1)
+ORDER BY domain_checked_at NULLS FIRST, id
+LIMIT $2;
+
+-- name: UpdatePropertyDomainStatus :exec
+UPDATE backend.properties SET domain_status = $2, domain_checked_at = $3 WHERE id = $1;
+
+-- name: UpdatePropertyEmergency :one
+UPDATE backend.properties SET emergency_until = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdatePropertyPrivacyMode :one
+UPDATE backend.properties SET privacy_mode = $2, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
@@ -0,0 +1,11 @@
+-- name: GetPropertyAccessList :one
+SELECT * FROM backend.property_access_lists WHERE property_id = $1;
+
+-- name: UpsertPropertyAccessList :one
+INSERT INTO backend.property_access_lists (property_id, ip_allowlist, ip_denylist, asn_denylist, policy) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (property_id) DO UPDATE SET ip_allowlist = EXCLUDED.ip_allowlist, ip_denylist = EXCLUDED.ip_denylist,
+    asn_denylist = EXCLUDED.asn_denylist, policy = EXCLUDED.policy, updated_at = NOW()
+RETURNING *;
+
+-- name: DeletePropertyAccessList :one
+DELETE FROM backend.property_access_lists WHERE property_id = $1 RETURNING *;
@@ -0,0 +1,14 @@
+-- name: GetPropertyBaselines :many
+SELECT * FROM backend.property_baselines WHERE weekday = $1 AND hour = $2;
+
+-- name: UpsertPropertyBaseline :exec
+INSERT INTO backend.property_baselines (property_id, weekday, hour, samples, requests_mean, requests_variance, success_samples, success_rate_mean, success_rate_variance)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (property_id, weekday, hour) DO UPDATE SET
+    samples = EXCLUDED.samples,
+    requests_mean = EXCLUDED.requests_mean,
+    requests_variance = EXCLUDED.requests_variance,
+    success_samples = EXCLUDED.success_samples,
+    success_rate_mean = EXCLUDED.success_rate_mean,
+    success_rate_variance = EXCLUDED.success_rate_variance,
+    updated_at = NOW();
@@ -0,0 +1,16 @@
+-- name: CreatePropertyBypassToken :one
+INSERT INTO backend.property_bypass_tokens (property_id, creator_id, name, secret_hash, max_uses, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetPropertyBypassTokens :many
+SELECT * FROM backend.property_bypass_tokens WHERE property_id = $1 AND expires_at > NOW() ORDER BY created_at DESC;
+
+-- name: UsePropertyBypassToken :one
+UPDATE backend.property_bypass_tokens SET uses = uses + 1, last_used_at = @used_at::TIMESTAMPTZ
+WHERE secret_hash = @secret_hash AND property_id = @property_id AND uses < max_uses AND expires_at > @used_at::TIMESTAMPTZ
+RETURNING *;
+
+-- name: DeletePropertyBypassToken :one
+DELETE FROM backend.property_bypass_tokens WHERE id = $1 AND property_id = $2 RETURNING *;
+
+-- name: DeleteExpiredPropertyBypassTokens :exec
+DELETE FROM backend.property_bypass_tokens WHERE expires_at < $1;
@@ -0,0 +1,14 @@
+-- name: CreatePropertyShareLink :one
+INSERT INTO backend.property_share_links (property_id, creator_id, expires_at) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetPropertyShareLink :one
+SELECT * FROM backend.property_share_links WHERE external_id = $1;
+
+-- name: GetPropertyShareLinks :many
+SELECT * FROM backend.property_share_links WHERE property_id = $1 AND expires_at > NOW() AND revoked_at IS NULL ORDER BY created_at DESC;
+
+-- name: RevokePropertyShareLink :one
+UPDATE backend.property_share_links SET revoked_at = NOW() WHERE id = $1 AND property_id = $2 AND revoked_at IS NULL RETURNING *;
+
+-- name: DeleteExpiredPropertyShareLinks :exec
+DELETE FROM backend.property_share_links WHERE expires_at < $1;
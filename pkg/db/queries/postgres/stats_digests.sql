@@ -0,0 +1,29 @@
+-- name: GetUserStatsDigests :many
+SELECT * FROM backend.stats_digests WHERE user_id = $1;
+
+-- name: UpsertStatsDigest :exec
+INSERT INTO backend.stats_digests (user_id, org_id, frequency) VALUES ($1, $2, $3)
+ON CONFLICT (user_id, org_id) DO UPDATE SET frequency = EXCLUDED.frequency, updated_at = NOW();
+
+-- name: DeleteStatsDigest :exec
+DELETE FROM backend.stats_digests WHERE user_id = $1 AND org_id = $2;
+
+-- name: GetPendingStatsDigests :many
+SELECT sqlc.embed(d), o.name AS org_name
+FROM backend.stats_digests d
+JOIN backend.organizations o ON d.org_id = o.id
+JOIN backend.users u ON d.user_id = u.id
+WHERE d.frequency = $1
+  AND (d.sent_until IS NULL OR d.sent_until < $2)
+  AND o.deleted_at IS NULL
+  AND u.deleted_at IS NULL
+  AND (o.user_id = d.user_id OR EXISTS (
+    SELECT 1 FROM backend.organization_users ou WHERE ou.org_id = d.org_id AND ou.user_id = d.user_id AND ou.level <> 'invited'))
+ORDER BY d.user_id, d.org_id
+LIMIT $3;
+
+-- name: UpdateStatsDigestsSent :exec
+UPDATE backend.stats_digests d
+SET sent_until = @sent_until::TIMESTAMPTZ
+FROM (SELECT unnest(@user_ids::INT[]) AS user_id, unnest(@org_ids::INT[]) AS org_id) AS s
+WHERE d.user_id = s.user_id AND d.org_id = s.org_id;
@@ -0,0 +1,129 @@
+-- name: AddRequestStats :exec
+INSERT INTO backend.request_stats_5m (user_id, org_id, property_id, timestamp, count)
+SELECT unnest(@user_ids::INT[]),
+       unnest(@org_ids::INT[]),
+       unnest(@property_ids::INT[]),
+       unnest(@timestamps::TIMESTAMPTZ[]),
+       unnest(@counts::BIGINT[])
+ON CONFLICT (property_id, timestamp, org_id, user_id)
+DO UPDATE SET
+    count = backend.request_stats_5m.count + EXCLUDED.count;
+
+-- name: AddVerifyStats :exec
+INSERT INTO backend.verify_stats_5m (user_id, org_id, property_id, timestamp, success_count, failure_count)
+SELECT unnest(@user_ids::INT[]),
+       unnest(@org_ids::INT[]),
+       unnest(@property_ids::INT[]),
+       unnest(@timestamps::TIMESTAMPTZ[]),
+       unnest(@success_counts::BIGINT[]),
+       unnest(@failure_counts::BIGINT[])
+ON CONFLICT (property_id, timestamp, org_id, user_id)
+DO UPDATE SET
+    success_count = backend.verify_stats_5m.success_count + EXCLUDED.success_count,
+    failure_count = backend.verify_stats_5m.failure_count + EXCLUDED.failure_count;
+
+-- name: GetPropertyRequestStatsSince :many
+SELECT timestamp, count FROM backend.request_stats_5m
+WHERE user_id = $1 AND org_id = $2 AND property_id = $3 AND timestamp >= $4
+ORDER BY timestamp;
+
+-- name: GetUserMonthlyRequestStats :many
+SELECT date_trunc('month', timestamp, 'UTC')::TIMESTAMPTZ AS month, SUM(count)::BIGINT AS count
+FROM backend.request_stats_5m
+WHERE user_id = @user_id AND timestamp >= @timestamp AND NOT (org_id = ANY(@exclude_org_ids::INT[]))
+GROUP BY month
+ORDER BY month;
+
+-- name: GetPropertyRequestStatsByPeriod :many
+SELECT date_trunc(@unit::TEXT, timestamp, 'UTC')::TIMESTAMPTZ AS bucket, SUM(count)::BIGINT AS count
+FROM backend.request_stats_5m
+WHERE org_id = @org_id AND property_id = @property_id AND timestamp >= @since
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: GetPropertyVerifyStatsByPeriod :many
+SELECT date_trunc(@unit::TEXT, timestamp, 'UTC')::TIMESTAMPTZ AS bucket, SUM(success_count + failure_count)::BIGINT AS count
+FROM backend.verify_stats_5m
+WHERE org_id = @org_id AND property_id = @property_id AND timestamp >= @since
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: GetTopVerifiedProperties :many
+SELECT property_id FROM backend.verify_stats_5m
+WHERE timestamp >= NOW() - INTERVAL '1 day'
+GROUP BY property_id
+ORDER BY SUM(success_count + failure_count) DESC
+LIMIT $1;
+
+-- name: GetPropertiesHourlyStats :many
+WITH h AS (SELECT @hour::TIMESTAMPTZ AS start)
+SELECT s.org_id, s.property_id, SUM(s.requests)::BIGINT AS requests, SUM(s.successes)::BIGINT AS successes, SUM(s.failures)::BIGINT AS failures
+FROM (
+    SELECT r.org_id, r.property_id, r.count AS requests, 0 AS successes, 0 AS failures
+    FROM backend.request_stats_5m r, h
+    WHERE r.timestamp >= h.start AND r.timestamp < h.start + INTERVAL '1 hour'
+    UNION ALL
+    SELECT v.org_id, v.property_id, 0 AS requests, v.success_count AS successes, v.failure_count AS failures
+    FROM backend.verify_stats_5m v, h
+    WHERE v.timestamp >= h.start AND v.timestamp < h.start + INTERVAL '1 hour'
+) s
+GROUP BY s.org_id, s.property_id;
+
+-- name: GetPropertiesStats :many
+SELECT s.org_id, s.property_id, SUM(s.requests)::BIGINT AS requests, SUM(s.successes)::BIGINT AS successes, SUM(s.failures)::BIGINT AS failures
+FROM (
+    SELECT org_id, property_id, count AS requests, 0 AS successes, 0 AS failures
+    FROM backend.request_stats_5m r
+    WHERE r.timestamp >= @since::TIMESTAMPTZ AND r.timestamp < @until::TIMESTAMPTZ
+    UNION ALL
+    SELECT org_id, property_id, 0 AS requests, success_count AS successes, failure_count AS failures
+    FROM backend.verify_stats_5m v
+    WHERE v.timestamp >= @since::TIMESTAMPTZ AND v.timestamp < @until::TIMESTAMPTZ
+) s
+GROUP BY s.org_id, s.property_id
+ORDER BY s.org_id, s.property_id;
+
+-- name: GetVerifyHourlyStats :many
+SELECT date_trunc('hour', timestamp, 'UTC')::TIMESTAMPTZ AS bucket, SUM(success_count)::BIGINT AS successes, SUM(failure_count)::BIGINT AS failures
+FROM backend.verify_stats_5m
+WHERE timestamp >= @since::TIMESTAMPTZ AND timestamp < @until::TIMESTAMPTZ
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: GetOrgUsageStats :many
+SELECT s.user_id, s.org_id, SUM(s.requests)::BIGINT AS requests, SUM(s.verifications)::BIGINT AS verifications
+FROM (
+    SELECT user_id, org_id, count AS requests, 0 AS verifications
+    FROM backend.request_stats_5m r
+    WHERE r.timestamp >= @since::TIMESTAMPTZ AND r.timestamp < @until::TIMESTAMPTZ
+    UNION ALL
+    SELECT user_id, org_id, 0 AS requests, success_count + failure_count AS verifications
+    FROM backend.verify_stats_5m v
+    WHERE v.timestamp >= @since::TIMESTAMPTZ AND v.timestamp < @until::TIMESTAMPTZ
+) s
+GROUP BY s.user_id, s.org_id
+ORDER BY s.org_id;
+
+-- name: DeletePropertiesStats :exec
+WITH deleted_requests AS (
+    DELETE FROM backend.request_stats_5m WHERE request_stats_5m.property_id = ANY(@property_ids::INT[])
+)
+DELETE FROM backend.verify_stats_5m WHERE verify_stats_5m.property_id = ANY(@property_ids::INT[]);
+
+-- name: DeleteOrganizationsStats :exec
+WITH deleted_requests AS (
+    DELETE FROM backend.request_stats_5m WHERE request_stats_5m.org_id = ANY(@org_ids::INT[])
+)
+DELETE FROM backend.verify_stats_5m WHERE verify_stats_5m.org_id = ANY(@org_ids::INT[]);
+
+-- name: DeleteUsersStats :exec
+WITH deleted_requests AS (
+    DELETE FROM backend.request_stats_5m WHERE request_stats_5m.user_id = ANY(@user_ids::INT[])
+)
+DELETE FROM backend.verify_stats_5m WHERE verify_stats_5m.user_id = ANY(@user_ids::INT[]);
+
+-- name: DeleteStatsBefore :exec
+WITH deleted_requests AS (
+    DELETE FROM backend.request_stats_5m WHERE request_stats_5m.timestamp < @before::TIMESTAMPTZ
+)
+DELETE FROM backend.verify_stats_5m WHERE verify_stats_5m.timestamp < @before::TIMESTAMPTZ;
@@ -0,0 +1,12 @@
+-- name: UpsertUserDevice :one
+INSERT INTO backend.user_devices (user_id, browser, os, country, session_id) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, browser, os, country) DO UPDATE SET
+  session_id = EXCLUDED.session_id,
+  last_seen_at = NOW()
+RETURNING *;
+
+-- name: GetUserDevices :many
+SELECT * FROM backend.user_devices WHERE user_id = $1 ORDER BY last_seen_at DESC;
+
+-- name: DeleteUserDevice :one
+DELETE FROM backend.user_devices WHERE id = $1 AND user_id = $2 RETURNING *;
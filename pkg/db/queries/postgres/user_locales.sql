@@ -0,0 +1,6 @@
+-- name: UpsertUserLocale :exec
+INSERT INTO backend.user_locales (user_id, timezone, country) VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET
+  timezone = COALESCE(EXCLUDED.timezone, backend.user_locales.timezone),
+  country = COALESCE(EXCLUDED.country, backend.user_locales.country),
+  updated_at = NOW();
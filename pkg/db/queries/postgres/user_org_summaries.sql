@@ -0,0 +1,43 @@
+-- name: GetUserOrgSummary :one
+SELECT * FROM backend.user_org_summaries WHERE user_id = $1;
+
+-- name: GetUserOrgSummaries :many
+SELECT * FROM backend.user_org_summaries WHERE user_id = ANY(@user_ids::INT[]);
+
+-- name: GetUserOrgSummaryIDsAfter :many
+SELECT user_id FROM backend.user_org_summaries WHERE user_id > $1 ORDER BY user_id LIMIT $2;
+
+-- name: UpsertUserOrgSummary :exec
+INSERT INTO backend.user_org_summaries (user_id, summary) VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET summary = EXCLUDED.summary, updated_at = NOW();
+
+-- name: DeleteUserOrgSummaries :exec
+DELETE FROM backend.user_org_summaries WHERE user_id = ANY(@user_ids::INT[]);
+
+-- name: GetOrgsMemberIDs :many
+SELECT o.user_id::INT AS user_id FROM backend.organizations o WHERE o.id = ANY(@org_ids::INT[]) AND o.user_id IS NOT NULL
+UNION
+SELECT ou.user_id FROM backend.organization_users ou WHERE ou.org_id = ANY(@org_ids::INT[]);
+
+-- name: GetOrgsPropertiesCount :many
+SELECT org_id::INT AS org_id, COUNT(*) as count FROM backend.properties
+WHERE org_id = ANY(@org_ids::INT[]) AND deleted_at IS NULL
+GROUP BY org_id;
+
+-- name: AdjustUserOrgSummariesProperties :execrows
+-- deltas is a JSON object of property count deltas by org ID
+UPDATE backend.user_org_summaries s
+SET summary = jsonb_set(s.summary, '{orgs}', (
+        SELECT COALESCE(jsonb_agg(
+            CASE WHEN (@deltas::JSONB ? (e.org ->> 'id')) AND (e.org ->> 'level') <> 'invited'
+                THEN jsonb_set(e.org, '{properties}', to_jsonb(GREATEST(COALESCE((e.org ->> 'properties')::BIGINT, 0) + (@deltas::JSONB ->> (e.org ->> 'id'))::BIGINT, 0)))
+                ELSE e.org
+            END ORDER BY e.idx), '[]'::JSONB)
+        FROM jsonb_array_elements(s.summary -> 'orgs') WITH ORDINALITY AS e(org, idx)
+    )),
+    updated_at = NOW()
+WHERE s.user_id IN (
+    SELECT o.user_id FROM backend.organizations o WHERE o.id = ANY(@org_ids::INT[]) AND o.user_id IS NOT NULL
+    UNION
+    SELECT ou.user_id FROM backend.organization_users ou WHERE ou.org_id = ANY(@org_ids::INT[])
+);
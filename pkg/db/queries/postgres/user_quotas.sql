@@ -0,0 +1,13 @@
+-- name: GetUserQuota :one
+SELECT * FROM backend.user_quotas WHERE user_id = $1;
+
+-- name: GetUserQuotas :many
+SELECT * FROM backend.user_quotas WHERE user_id = ANY(@user_ids::INT[]);
+
+-- name: GetEnforcedUserQuotas :many
+SELECT * FROM backend.user_quotas WHERE period_start = $1 AND stage IN ('degraded', 'exceeded');
+
+-- name: UpsertUserQuota :exec
+INSERT INTO backend.user_quotas (user_id, period_start, requests, requests_limit, stage) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE SET period_start = EXCLUDED.period_start, requests = EXCLUDED.requests,
+requests_limit = EXCLUDED.requests_limit, stage = EXCLUDED.stage, updated_at = NOW();
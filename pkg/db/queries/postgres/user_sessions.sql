@@ -0,0 +1,18 @@
+-- name: UpsertUserSessions :exec
+INSERT INTO backend.user_sessions (session_id, user_id, expires_at)
+SELECT unnest(@session_ids::TEXT[]) as session_id,
+       unnest(@user_ids::INT[]) as user_id,
+       NOW() + @ttl::INTERVAL as expires_at
+ON CONFLICT (session_id)
+DO UPDATE SET
+    user_id = EXCLUDED.user_id,
+    expires_at = EXCLUDED.expires_at;
+
+-- name: GetUserSessionIDs :many
+SELECT session_id FROM backend.user_sessions WHERE user_id = $1 AND expires_at >= NOW();
+
+-- name: DeleteUserSessions :exec
+DELETE FROM backend.user_sessions WHERE session_id = ANY(@session_ids::TEXT[]);
+
+-- name: DeleteExpiredUserSessions :exec
+DELETE FROM backend.user_sessions WHERE expires_at < NOW();
@@ -0,0 +1,10 @@
+-- name: CreateUserSSOLink :one
+INSERT INTO backend.user_sso_links (user_id, issuer, subject) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetUserBySSOLink :one
+SELECT u.* FROM backend.users u
+JOIN backend.user_sso_links l ON l.user_id = u.id
+WHERE l.issuer = $1 AND l.subject = $2 AND u.deleted_at IS NULL;
+
+-- name: GetUserSSOLinks :many
+SELECT * FROM backend.user_sso_links WHERE user_id = $1 ORDER BY created_at;
@@ -40,3 +40,12 @@ WHERE
   s.status = $4 AND
   u.deleted_at IS NULL
 LIMIT $5;
+
+-- name: GetUsersWithSubscriptions :many
+SELECT sqlc.embed(u), s.external_product_id, s.external_price_id, s.status, s.source
+FROM backend.users u
+LEFT JOIN backend.subscriptions s ON u.subscription_id = s.id
+WHERE u.id = ANY($1::INT[]);
+
+-- name: GetUsersPage :many
+SELECT * FROM backend.users WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2;
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5"
+)
+
+// monthly requests quota is enforced gradually, thresholds are in percent of the plan requests limit
+const (
+	QuotaWarningPercent  = 80
+	QuotaDegradedPercent = 100
+	QuotaExceededPercent = 120
+)
+
+// QuotaPeriodStart returns start of the month that requests are counted for
+func QuotaPeriodStart(tnow time.Time) time.Time {
+	tnow = tnow.UTC()
+	return time.Date(tnow.Year(), tnow.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// QuotaUsagePercent returns consumed share of the limit (zero limit means "unlimited")
+func QuotaUsagePercent(requests, limit int64) float64 {
+	if (limit <= 0) || (requests <= 0) {
+		return 0
+	}
+
+	return float64(requests) * 100.0 / float64(limit)
+}
+
+func QuotaStageForUsage(requests, limit int64) dbgen.QuotaStage {
+	if (limit <= 0) || (requests <= 0) {
+		return dbgen.QuotaStageNormal
+	}
+
+	// integer math to avoid float rounding right at the thresholds
+	switch {
+	case requests*100 >= limit*QuotaExceededPercent:
+		return dbgen.QuotaStageExceeded
+	case requests*100 >= limit*QuotaDegradedPercent:
+		return dbgen.QuotaStageDegraded
+	case requests*100 >= limit*QuotaWarningPercent:
+		return dbgen.QuotaStageWarning
+	default:
+		return dbgen.QuotaStageNormal
+	}
+}
+
+// QuotaStageSeverity orders stages so that escalations can be detected
+func QuotaStageSeverity(stage dbgen.QuotaStage) int {
+	switch stage {
+	case dbgen.QuotaStageWarning:
+		return 1
+	case dbgen.QuotaStageDegraded:
+		return 2
+	case dbgen.QuotaStageExceeded:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// MonthlyQuotas is the in-memory registry of org owners that are over the requests limit in the current period,
+// kept in sync with DB on every instance so that puzzle endpoint does not access DB for enforcement
+type MonthlyQuotas struct {
+	items atomic.Pointer[map[int32]dbgen.QuotaStage]
+}
+
+func NewMonthlyQuotas() *MonthlyQuotas {
+	q := &MonthlyQuotas{}
+	q.items.Store(&map[int32]dbgen.QuotaStage{})
+	return q
+}
+
+func (q *MonthlyQuotas) Update(quotas []*dbgen.UserQuota) {
+	items := make(map[int32]dbgen.QuotaStage, len(quotas))
+
+	for _, uq := range quotas {
+		if QuotaStageSeverity(uq.Stage) >= QuotaStageSeverity(dbgen.QuotaStageDegraded) {
+			items[uq.UserID] = uq.Stage
+		}
+	}
+
+	q.items.Store(&items)
+}
+
+func (q *MonthlyQuotas) Stage(userID int32) dbgen.QuotaStage {
+	items := *q.items.Load()
+	if stage, ok := items[userID]; ok {
+		return stage
+	}
+
+	return dbgen.QuotaStageNormal
+}
+
+func (q *MonthlyQuotas) Count() int {
+	return len(*q.items.Load())
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserQuota(ctx context.Context, userID int32) (*dbgen.UserQuota, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	quota, err := impl.querier.GetUserQuota(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve user quota", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return quota, nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveUserQuotas(ctx context.Context, userIDs []int32) (map[int32]*dbgen.UserQuota, error) {
+	if len(userIDs) == 0 {
+		return map[int32]*dbgen.UserQuota{}, nil
+	}
+
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	quotas, err := impl.querier.GetUserQuotas(ctx, userIDs)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve user quotas", "userIDs", len(userIDs), common.ErrAttr(err))
+		return nil, err
+	}
+
+	result := make(map[int32]*dbgen.UserQuota, len(quotas))
+	for _, q := range quotas {
+		result[q.UserID] = q
+	}
+
+	return result, nil
+}
+
+// RetrieveEnforcedUserQuotas returns quotas of the period that are at the degraded stage or above
+func (impl *BusinessStoreImpl) RetrieveEnforcedUserQuotas(ctx context.Context, periodStart time.Time) ([]*dbgen.UserQuota, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	quotas, err := impl.querier.GetEnforcedUserQuotas(ctx, Timestampz(periodStart))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "Failed to retrieve enforced user quotas", "period", periodStart, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return quotas, nil
+}
+
+func (impl *BusinessStoreImpl) UpdateUserQuota(ctx context.Context, params *dbgen.UpsertUserQuotaParams) error {
+	if params == nil {
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	if err := impl.querier.UpsertUserQuota(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to update user quota", "userID", params.UserID, "stage", params.Stage, common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestQuotaStageForUsage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		requests int64
+		limit    int64
+		expected dbgen.QuotaStage
+	}{
+		{0, 1000, dbgen.QuotaStageNormal},
+		{799, 1000, dbgen.QuotaStageNormal},
+		{800, 1000, dbgen.QuotaStageWarning},
+		{999, 1000, dbgen.QuotaStageWarning},
+		{1000, 1000, dbgen.QuotaStageDegraded},
+		{1199, 1000, dbgen.QuotaStageDegraded},
+		{1200, 1000, dbgen.QuotaStageExceeded},
+		{5000, 1000, dbgen.QuotaStageExceeded},
+		// unlimited
+		{5000, 0, dbgen.QuotaStageNormal},
+	}
+
+	for _, tc := range testCases {
+		if stage := QuotaStageForUsage(tc.requests, tc.limit); stage != tc.expected {
+			t.Errorf("Unexpected stage for %v/%v: %v (expected %v)", tc.requests, tc.limit, stage, tc.expected)
+		}
+	}
+}
+
+func TestMonthlyQuotas(t *testing.T) {
+	t.Parallel()
+
+	quotas := NewMonthlyQuotas()
+	quotas.Update([]*dbgen.UserQuota{
+		{UserID: 1, Stage: dbgen.QuotaStageWarning},
+		{UserID: 2, Stage: dbgen.QuotaStageDegraded},
+		{UserID: 3, Stage: dbgen.QuotaStageExceeded},
+	})
+
+	// warnings are not enforced
+	if quotas.Count() != 2 {
+		t.Errorf("Unexpected count: %v", quotas.Count())
+	}
+
+	if stage := quotas.Stage(1); stage != dbgen.QuotaStageNormal {
+		t.Errorf("Unexpected stage of warned user: %v", stage)
+	}
+
+	if stage := quotas.Stage(3); stage != dbgen.QuotaStageExceeded {
+		t.Errorf("Unexpected stage of exceeded user: %v", stage)
+	}
+
+	if stage := quotas.Stage(4); stage != dbgen.QuotaStageNormal {
+		t.Errorf("Unexpected stage of unknown user: %v", stage)
+	}
+}
+
+func TestQuotaPeriodStart(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, time.April, 21, 15, 4, 5, 0, time.UTC)
+	if start := QuotaPeriodStart(tnow); !start.Equal(time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected period start: %v", start)
+	}
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	SandboxOrgName = "Sandbox"
+	// sandbox is excluded from plan limits, but it still should not become a free unlimited account
+	SandboxPropertiesLimit = 10
+)
+
+var (
+	ErrSandboxExists = errors.New("sandbox organization already exists")
+)
+
+// FindSandboxOrg returns sandbox organization owned by the user (if any)
+func FindSandboxOrg(orgs []*dbgen.GetUserOrganizationsRow) *dbgen.Organization {
+	for _, org := range orgs {
+		if org.Organization.Sandbox && (org.Level == dbgen.AccessLevelOwner) {
+			return &org.Organization
+		}
+	}
+
+	return nil
+}
+
+func (impl *BusinessStoreImpl) CreateSandboxOrganization(ctx context.Context, user *dbgen.User) (*dbgen.Organization, *common.AuditLogEvent, error) {
+	if impl.querier == nil {
+		return nil, nil, ErrMaintenance
+	}
+
+	if orgs, err := impl.RetrieveUserOrganizations(ctx, user.ID); err != nil {
+		return nil, nil, err
+	} else if FindSandboxOrg(orgs) != nil {
+		slog.WarnContext(ctx, "User already has a sandbox organization", "userID", user.ID)
+		return nil, nil, ErrSandboxExists
+	}
+
+	org, err := impl.querier.CreateSandboxOrganization(ctx, &dbgen.CreateSandboxOrganizationParams{
+		Name:   SandboxOrgName,
+		UserID: Int(user.ID),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create sandbox organization in DB", "userID", user.ID, common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	slog.InfoContext(ctx, "Created sandbox organization in DB", "userID", user.ID, "id", org.ID)
+
+	_ = impl.cache.Set(ctx, orgCacheKey(org.ID), org)
+	_ = impl.cache.Delete(ctx, userOrgsCacheKey(user.ID))
+	impl.onOrgsChanged(ctx, nil /*orgs*/, user.ID)
+
+	return org, newOrgAuditLogEvent(user.ID, org, common.AuditLogActionCreate), nil
+}
+
+func (impl *BusinessStoreImpl) RetrieveSandboxOrganizations(ctx context.Context, afterID int32, limit int) ([]*dbgen.Organization, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	orgs, err := impl.querier.GetSandboxOrganizations(ctx, &dbgen.GetSandboxOrganizationsParams{
+		ID:    afterID,
+		Limit: int32(limit),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve sandbox organizations", "afterID", afterID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+// RetrieveUserSandboxOrgIDs returns sandbox organizations owned by the user, their traffic does not count towards limits
+func (impl *BusinessStoreImpl) RetrieveUserSandboxOrgIDs(ctx context.Context, userID int32) ([]int32, error) {
+	orgs, err := impl.RetrieveUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int32
+	for _, org := range orgs {
+		if org.Organization.Sandbox && (org.Level == dbgen.AccessLevelOwner) {
+			ids = append(ids, org.Organization.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+// RetrieveOrgPropertyIDs returns all properties of the org, including soft-deleted ones
+func (impl *BusinessStoreImpl) RetrieveOrgPropertyIDs(ctx context.Context, orgID int32) ([]int32, error) {
+	if impl.querier == nil {
+		return nil, ErrMaintenance
+	}
+
+	ids, err := impl.querier.GetOrgPropertyIDs(ctx, Int(orgID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org property IDs", "orgID", orgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ResetSandboxOrganization permanently deletes properties and API keys of the sandbox org. Stats of the properties
+// should be deleted by the caller beforehand. Nothing is recorded in audit log as this happens every night.
+func (impl *BusinessStoreImpl) ResetSandboxOrganization(ctx context.Context, org *dbgen.Organization) error {
+	if !org.Sandbox {
+		slog.ErrorContext(ctx, "Attempt to reset non-sandbox organization", "orgID", org.ID)
+		return ErrInvalidInput
+	}
+
+	if impl.querier == nil {
+		return ErrMaintenance
+	}
+
+	properties, err := impl.querier.DeleteOrgProperties(ctx, Int(org.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete sandbox properties", "orgID", org.ID, common.ErrAttr(err))
+		return err
+	}
+
+	var delta int64
+	for _, property := range properties {
+		impl.deleteCachedProperty(ctx, property)
+		if !property.DeletedAt.Valid {
+			delta--
+		}
+	}
+
+	if delta != 0 {
+		impl.onOrgPropertiesChanged(ctx, map[int32]int64{org.ID: delta})
+	}
+
+	keys, err := impl.querier.DeleteOrgAPIKeys(ctx, Int(org.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete sandbox API keys", "orgID", org.ID, common.ErrAttr(err))
+		return err
+	}
+
+	for _, key := range keys {
+		_ = impl.cache.Delete(ctx, APIKeyCacheKey(UUIDToSecret(key.ExternalID)))
+		_ = impl.cache.Delete(ctx, UserAPIKeysCacheKey(key.UserID.Int32))
+	}
+
+	slog.InfoContext(ctx, "Reset sandbox organization", "orgID", org.ID, "properties", len(properties), "apiKeys", len(keys))
+
+	return nil
+}
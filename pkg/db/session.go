@@ -21,6 +21,8 @@ type SessionStore struct {
 	batchSize     int
 	processCancel context.CancelFunc
 	persistKey    session.SessionKey
+	// sessions are only read from DB (e.g. recovered on a standby replica) and changes are kept in memory
+	ReadOnly bool
 }
 
 func NewSessionStore(store Implementor, persistKey session.SessionKey) *SessionStore {
@@ -79,7 +81,26 @@ func (ss *SessionStore) Destroy(ctx context.Context, sid string) error {
 	return ss.store.Impl().DeleteUserSession(ctx, sid)
 }
 
+func (ss *SessionStore) ListUser(ctx context.Context, userID int32) ([]*session.Session, error) {
+	data, err := ss.store.Impl().RetrieveUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*session.Session, 0, len(data))
+	for _, sd := range data {
+		result = append(result, session.NewSession(sd, ss))
+	}
+
+	return result, nil
+}
+
 func (ss *SessionStore) persistSessions(ctx context.Context, batch map[string]uint) error {
+	if ss.ReadOnly {
+		slog.Log(ctx, common.LevelTrace, "Skipping persisting sessions in read-only mode", "count", len(batch))
+		return nil
+	}
+
 	// we actually do not care if we failed to save sessions to cache
 	_ = ss.store.Impl().StoreUserSessions(ctx, batch, ss.persistKey, sessionCacheTTL)
 	return nil
@@ -28,6 +28,13 @@ func NewStaticCache[TKey comparable, TValue comparable](capacity int, missingVal
 	}
 }
 
+func (c *StaticCache[TKey, TValue]) Len() int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return len(c.cache)
+}
+
 func (c *StaticCache[TKey, TValue]) HitRatio() float64 {
 	// unsupported
 	return 0.0
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	config_pkg "github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+// AuditLogConfig is a snapshot of the configuration that can change on reload (SIGHUP)
+type AuditLogConfig struct {
+	MaintenanceMode     bool   `json:"maintenance_mode"`
+	Verbose             bool   `json:"verbose"`
+	APIKeyUsageAudit    bool   `json:"apikey_usage_audit"`
+	RateLimitRate       string `json:"rate_limit_rate,omitempty"`
+	RateLimitBurst      string `json:"rate_limit_burst,omitempty"`
+	LoadShedMaxInflight string `json:"load_shed_max_inflight,omitempty"`
+	LoadShedLatency     string `json:"load_shed_latency,omitempty"`
+	ShadowVerifyPercent string `json:"shadow_verify_percent,omitempty"`
+	PrivacyMode         string `json:"privacy_mode,omitempty"`
+}
+
+func NewAuditLogConfig(cfg common.ConfigStore) *AuditLogConfig {
+	return &AuditLogConfig{
+		MaintenanceMode:     config_pkg.AsBool(cfg.Get(common.MaintenanceModeKey)),
+		Verbose:             config_pkg.AsBool(cfg.Get(common.VerboseKey)),
+		APIKeyUsageAudit:    config_pkg.AsBool(cfg.Get(common.APIKeyUsageAuditKey)),
+		RateLimitRate:       cfg.Get(common.RateLimitRateKey).Value(),
+		RateLimitBurst:      cfg.Get(common.RateLimitBurstKey).Value(),
+		LoadShedMaxInflight: cfg.Get(common.LoadShedMaxInflightKey).Value(),
+		LoadShedLatency:     cfg.Get(common.LoadShedLatencyKey).Value(),
+		ShadowVerifyPercent: cfg.Get(common.ShadowVerifyPercentKey).Value(),
+		PrivacyMode:         cfg.Get(common.PrivacyModeKey).Value(),
+	}
+}
+
+type AuditLogDeploy struct {
+	GitCommit string `json:"git_commit"`
+	Stage     string `json:"stage,omitempty"`
+}
+
+// ConfigReloadLockName is the same on all nodes that reload the same change of configuration, so that the
+// change is recorded only by the node that acquires the lock
+func ConfigReloadLockName(oldValue, newValue *AuditLogConfig) (string, error) {
+	data, err := json.Marshal([]*AuditLogConfig{oldValue, newValue})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+
+	return "config_reload/" + hex.EncodeToString(hash[:8]), nil
+}
+
+// system events are not made by any user so they have to be recorded with common.AuditLogSourceSystem
+func NewConfigReloadAuditLogEvent(oldValue, newValue *AuditLogConfig) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		Action:    common.AuditLogActionUpdate,
+		TableName: TableNameConfig,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+}
+
+func NewDeployAuditLogEvent(deploy *AuditLogDeploy) *common.AuditLogEvent {
+	return &common.AuditLogEvent{
+		Action:    common.AuditLogActionCreate,
+		TableName: TableNameDeploys,
+		NewValue:  deploy,
+	}
+}
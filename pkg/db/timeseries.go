@@ -6,6 +6,8 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"math"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,11 +24,20 @@ const (
 	VerifyLogTableName    = "privatecaptcha.verify_logs"
 	VerifyLogTable1h      = "privatecaptcha.verify_logs_1h"
 	VerifyLogTable1d      = "privatecaptcha.verify_logs_1d"
+	VerifyLatencyTable1h  = "privatecaptcha.verify_latency_1h"
+	VerifyLatencyTable1d  = "privatecaptcha.verify_latency_1d"
 	AccessLogTableName    = "privatecaptcha.request_logs"
 	AccessLogTableName5m  = "privatecaptcha.request_logs_5m"
 	AccessLogTableName1h  = "privatecaptcha.request_logs_1h"
 	AccessLogTableName1d  = "privatecaptcha.request_logs_1d"
 	AccessLogTableName1mo = "privatecaptcha.request_logs_1mo"
+	ExperimentStatsTable  = "privatecaptcha.experiment_stats_1h"
+	VisitorStatsTable     = "privatecaptcha.visitor_stats_1h"
+	IntegrityStatsTable   = "privatecaptcha.integrity_stats_1h"
+	IssuanceReceiptsTable = "privatecaptcha.issuance_receipts"
+	VerifyTracesTable     = "privatecaptcha.verify_traces"
+	QuotaLogTableName     = "privatecaptcha.quota_logs"
+	QuotaStatsTable1h     = "privatecaptcha.quota_stats_1h"
 )
 
 type TimeSeriesDB struct {
@@ -34,6 +45,7 @@ type TimeSeriesDB struct {
 	Cache              common.Cache[CacheKey, any]
 	statsQueryTemplate *template.Template
 	maintenanceMode    atomic.Bool
+	verifyRetention    atomic.Pointer[VerifyRetention]
 }
 
 var _ common.TimeSeriesStore = (*TimeSeriesDB)(nil)
@@ -79,17 +91,27 @@ GROUP BY agg_time
 ORDER BY agg_time WITH FILL FROM toDateTime({{.FillFrom}}) TO now() STEP {{.Interval}}
 SETTINGS use_query_cache = true, query_cache_nondeterministic_function_handling = 'save'`
 
-	return &TimeSeriesDB{
+	ts := &TimeSeriesDB{
 		statsQueryTemplate: template.Must(template.New("stats").Parse(statsQuery)),
 		Clickhouse:         clickhouse,
 		Cache:              cache,
 	}
+
+	retention := DefaultVerifyRetention()
+	ts.verifyRetention.Store(&retention)
+
+	return ts
 }
 
 func (ts *TimeSeriesDB) UpdateConfig(maintenanceMode bool) {
 	ts.maintenanceMode.Store(maintenanceMode)
 }
 
+// UpdateVerifyRetention only affects which rollups are queried, actual TTLs are updated with ApplyVerifyRetention()
+func (ts *TimeSeriesDB) UpdateVerifyRetention(retention *VerifyRetention) {
+	ts.verifyRetention.Store(retention)
+}
+
 func (ts *TimeSeriesDB) Ping(ctx context.Context) error {
 	rows, err := ts.Clickhouse.Query("SELECT 1")
 	if err != nil {
@@ -139,7 +161,7 @@ func (ts *TimeSeriesDB) WriteAccessLogBatch(ctx context.Context, records []*comm
 	}
 
 	for i, r := range records {
-		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.Fingerprint, r.Timestamp.UTC())
+		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.Fingerprint, r.Timestamp.UTC(), uint8(r.ExperimentArm), uint8(r.VisitorClass))
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed to exec insert for record", common.ErrAttr(err), "index", i)
 			return err
@@ -179,7 +201,7 @@ func (ts *TimeSeriesDB) WriteVerifyLogBatch(ctx context.Context, records []*comm
 	}
 
 	for i, r := range records {
-		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.PuzzleID, r.Status, r.Timestamp)
+		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.PuzzleID, r.Status, r.Timestamp, r.DurationUs, uint8(r.ExperimentArm), uint8(r.VisitorClass), r.TraceID, uint8(r.Integrity))
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed to exec insert for record", common.ErrAttr(err), "index", i)
 			return err
@@ -196,6 +218,86 @@ func (ts *TimeSeriesDB) WriteVerifyLogBatch(ctx context.Context, records []*comm
 	return err
 }
 
+func (ts *TimeSeriesDB) WriteIssuanceReceiptBatch(ctx context.Context, records []*common.IssuanceReceipt) error {
+	if len(records) == 0 {
+		slog.WarnContext(ctx, "Attempt to insert empty issuance receipts batch")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	scope, err := ts.Clickhouse.Begin()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to begin batch insert", common.ErrAttr(err))
+		return err
+	}
+
+	batch, err := scope.Prepare(fmt.Sprintf("INSERT INTO %s", IssuanceReceiptsTable))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to prepare insert query", common.ErrAttr(err))
+		return err
+	}
+
+	for i, r := range records {
+		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.PuzzleID, r.Sitekey, r.IPPrefixHash, r.Timestamp.UTC())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to exec insert for receipt", common.ErrAttr(err), "index", i)
+			return err
+		}
+	}
+
+	err = scope.Commit()
+	if err == nil {
+		slog.InfoContext(ctx, "Inserted batch of issuance receipts", "size", len(records))
+	} else {
+		slog.ErrorContext(ctx, "Failed to insert issuance receipts batch", common.ErrAttr(err))
+	}
+
+	return err
+}
+
+func (ts *TimeSeriesDB) WriteQuotaLogBatch(ctx context.Context, records []*common.QuotaRecord) error {
+	if len(records) == 0 {
+		slog.WarnContext(ctx, "Attempt to insert empty quota log batch")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	scope, err := ts.Clickhouse.Begin()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to begin batch insert", common.ErrAttr(err))
+		return err
+	}
+
+	batch, err := scope.Prepare(fmt.Sprintf("INSERT INTO %s", QuotaLogTableName))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to prepare insert query", common.ErrAttr(err))
+		return err
+	}
+
+	for i, r := range records {
+		_, err = batch.Exec(r.UserID, r.OrgID, r.PropertyID, r.Timestamp.UTC())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to exec insert for quota record", common.ErrAttr(err), "index", i)
+			return err
+		}
+	}
+
+	err = scope.Commit()
+	if err == nil {
+		slog.InfoContext(ctx, "Inserted batch of quota records", "size", len(records))
+	} else {
+		slog.ErrorContext(ctx, "Failed to insert quota log batch", common.ErrAttr(err))
+	}
+
+	return err
+}
+
 func (ts *TimeSeriesDB) RetrievePropertyStatsSince(ctx context.Context, r *common.BackfillRequest, from time.Time) ([]*common.TimeCount, error) {
 	if !ts.IsAvailable() {
 		return nil, ErrMaintenance
@@ -234,25 +336,31 @@ ORDER BY timestamp`
 	return results, nil
 }
 
-func (ts *TimeSeriesDB) RetrieveAccountStats(ctx context.Context, userID int32, from time.Time) ([]*common.TimeCount, error) {
+func (ts *TimeSeriesDB) RetrieveAccountStats(ctx context.Context, userID int32, from time.Time, excludeOrgIDs []int32) ([]*common.TimeCount, error) {
 	if !ts.IsAvailable() {
 		return nil, ErrMaintenance
 	}
 
 	fromStr := from.Format(time.DateTime)
+	excludeStr := idsToString(excludeOrgIDs)
 
-	cacheKey := userAccountStatsCacheKey(userID, fromStr)
+	cacheKey := userAccountStatsCacheKey(userID, fromStr+"/"+excludeStr)
 	if stats, err := FetchCachedArray[common.TimeCount](ctx, ts.Cache, cacheKey); (err == nil) && (len(stats) > 0) {
 		slog.DebugContext(ctx, "User account stats were cached", "userID", userID, "key", cacheKey, "count", len(stats))
 		return stats, nil
 	}
 
+	var excludeClause string
+	if len(excludeOrgIDs) > 0 {
+		excludeClause = fmt.Sprintf(" AND org_id NOT IN (%s)", excludeStr)
+	}
+
 	query := `SELECT timestamp, sum(count) as count
 FROM %s FINAL
-WHERE user_id = {user_id:UInt32} AND timestamp >= {timestamp:DateTime}
+WHERE user_id = {user_id:UInt32} AND timestamp >= {timestamp:DateTime}%s
 GROUP BY timestamp
 ORDER BY timestamp`
-	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, AccessLogTableName1mo),
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, AccessLogTableName1mo, excludeClause),
 		clickhouse.Named("user_id", strconv.Itoa(int(userID))),
 		clickhouse.Named("timestamp", fromStr))
 	if err != nil {
@@ -279,10 +387,6 @@ ORDER BY timestamp`
 }
 
 func (ts *TimeSeriesDB) RetrievePropertyStatsByPeriod(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodStat, error) {
-	if !ts.IsAvailable() {
-		return nil, ErrMaintenance
-	}
-
 	tnow := time.Now().UTC()
 	var timeFrom time.Time
 	var requestsTable string
@@ -290,37 +394,36 @@ func (ts *TimeSeriesDB) RetrievePropertyStatsByPeriod(ctx context.Context, orgID
 	var timeFunction string
 	var interval string
 	var cacheKey *CacheKey
+	var step time.Duration
 
 	switch period {
 	case common.TimePeriodToday:
 		timeFrom = tnow.AddDate(0, 0, -1).Truncate(1 * time.Hour)
-		requestsTable = "request_logs_1h"
-		verificationsTable = "verify_logs_1h"
 		timeFunction = "toStartOfHour(%s)"
 		interval = "INTERVAL 1 HOUR"
+		step = time.Hour
 		// in server we only cache the "today" as this is the default chart in the UI
 		cacheKey = new(CacheKey)
 		*cacheKey = propertyStatsCacheKey(propertyID, timeFrom.Format(time.DateTime))
 	case common.TimePeriodWeek:
 		timeFrom = tnow.AddDate(0, 0, -7).Truncate(6 * time.Hour)
-		requestsTable = "request_logs_1d"
-		verificationsTable = "verify_logs_1d"
 		timeFunction = "toStartOfInterval(%s, INTERVAL 6 HOUR)"
 		interval = "INTERVAL 6 HOUR"
+		step = 6 * time.Hour
 	case common.TimePeriodMonth:
 		timeFrom = tnow.AddDate(0, -1, 0).Truncate(24 * time.Hour)
-		requestsTable = "request_logs_1d"
-		verificationsTable = "verify_logs_1d"
 		timeFunction = "toStartOfDay(%s)"
 		interval = "INTERVAL 1 DAY"
+		step = day
 	case common.TimePeriodYear:
 		timeFrom = tnow.AddDate(-1, 0, 0).Truncate(24 * time.Hour)
-		requestsTable = "request_logs_1d"
-		verificationsTable = "verify_logs_1d"
 		timeFunction = "toStartOfMonth(%s)"
 		interval = "INTERVAL 1 MONTH"
+		step = 30 * day
 	}
 
+	requestsTable, verificationsTable = ts.verifyRetention.Load().statsTables(tnow.Sub(timeFrom), step)
+
 	if cacheKey != nil {
 		if stats, err := FetchCachedArray[common.TimePeriodStat](ctx, ts.Cache, *cacheKey); (err == nil) && (len(stats) > 0) {
 			slog.DebugContext(ctx, "Property stats were cached", "orgID", orgID, "propertyID", propertyID, "key", *cacheKey, "count", len(stats))
@@ -328,6 +431,11 @@ func (ts *TimeSeriesDB) RetrievePropertyStatsByPeriod(ctx context.Context, orgID
 		}
 	}
 
+	// cached stats are still served in maintenance mode (read-only portal)
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
 	data := struct {
 		RequestsTable    string
 		VerifiesTable    string
@@ -387,242 +495,1336 @@ func (ts *TimeSeriesDB) RetrievePropertyStatsByPeriod(ctx context.Context, orgID
 	return results, nil
 }
 
-func (ts *TimeSeriesDB) RetrieveRecentTopProperties(ctx context.Context, limit int) (map[int32]uint, error) {
+func (ts *TimeSeriesDB) RetrievePropertyVerifyLatencyByPeriod(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodLatency, error) {
 	if !ts.IsAvailable() {
 		return nil, ErrMaintenance
 	}
 
-	// NOTE: we don't use FINAL here because this is just an approximation anyways
-	// that is used to warmup cache so we don't need the most precise results
-	query := `SELECT property_id
+	tnow := time.Now().UTC()
+	var timeFrom time.Time
+	var table string
+	var timeFunction string
+	var cacheKey *CacheKey
+
+	switch period {
+	case common.TimePeriodToday:
+		timeFrom = tnow.AddDate(0, 0, -1).Truncate(1 * time.Hour)
+		table = VerifyLatencyTable1h
+		timeFunction = "toStartOfHour(timestamp)"
+		cacheKey = new(CacheKey)
+		*cacheKey = propertyLatencyCacheKey(propertyID, timeFrom.Format(time.DateTime))
+	case common.TimePeriodWeek:
+		timeFrom = tnow.AddDate(0, 0, -7).Truncate(6 * time.Hour)
+		table = VerifyLatencyTable1h
+		timeFunction = "toStartOfInterval(timestamp, INTERVAL 6 HOUR)"
+	case common.TimePeriodMonth:
+		timeFrom = tnow.AddDate(0, -1, 0).Truncate(24 * time.Hour)
+		table = VerifyLatencyTable1d
+		timeFunction = "toStartOfDay(timestamp)"
+	case common.TimePeriodYear:
+		timeFrom = tnow.AddDate(-1, 0, 0).Truncate(24 * time.Hour)
+		table = VerifyLatencyTable1d
+		timeFunction = "toStartOfMonth(timestamp)"
+	}
+
+	if cacheKey != nil {
+		if stats, err := FetchCachedArray[common.TimePeriodLatency](ctx, ts.Cache, *cacheKey); (err == nil) && (len(stats) > 0) {
+			slog.DebugContext(ctx, "Property latency stats were cached", "orgID", orgID, "propertyID", propertyID, "key", *cacheKey, "count", len(stats))
+			return stats, nil
+		}
+	}
+
+	// quantiles are stored in microseconds
+	query := `SELECT
+toDateTime(%s) AS agg_time,
+quantilesTDigestMerge(0.5, 0.9, 0.99)(duration_quantiles) AS quantiles
 FROM %s
-WHERE timestamp >= now() - INTERVAL 1 DAY
-GROUP BY property_id
-ORDER BY sum(success_count + failure_count) DESC
-LIMIT %d`
-	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, VerifyLogTable1d, limit))
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {timestamp:DateTime}
+GROUP BY agg_time
+ORDER BY agg_time`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, timeFunction, table),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("timestamp", timeFrom.Format(time.DateTime)))
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to execute top usage query", common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to query property latency stats", common.ErrAttr(err))
 		return nil, err
 	}
 
 	defer rows.Close()
 
-	properties := make(map[int32]uint, limit)
+	results := make([]*common.TimePeriodLatency, 0)
 
 	for rows.Next() {
-		var propertyID int32
-		if err := rows.Scan(&propertyID); err != nil {
-			slog.ErrorContext(ctx, "Failed to read row from top usage query", common.ErrAttr(err))
+		bc := &common.TimePeriodLatency{}
+		var quantiles []float64
+		if err := rows.Scan(&bc.Timestamp, &quantiles); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from property latency query", common.ErrAttr(err))
 			return nil, err
 		}
-		properties[propertyID]++
+		if len(quantiles) != 3 {
+			slog.WarnContext(ctx, "Unexpected number of latency quantiles", "count", len(quantiles))
+			continue
+		}
+		bc.P50, bc.P90, bc.P99 = quantiles[0]/1000.0, quantiles[1]/1000.0, quantiles[2]/1000.0
+		results = append(results, bc)
 	}
 
-	return properties, nil
-}
+	slog.InfoContext(ctx, "Fetched property latency stats", "count", len(results), "orgID", orgID, "propID", propertyID,
+		"from", timeFrom, "period", period)
 
-func (ts *TimeSeriesDB) lightDelete(ctx context.Context, tables []string, column string, ids string) error {
-	for _, table := range tables {
-		query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", table, column, ids)
-		if _, err := ts.Clickhouse.Exec(query); err != nil {
-			slog.ErrorContext(ctx, "Failed to delete data", "table", table, "column", column, common.ErrAttr(err))
-			return err
-		}
-		slog.InfoContext(ctx, "Deleted data in ClickHouse", "column", column, "table", table)
+	if cacheKey != nil {
+		const propertyLatencyCacheTTL = 5 * time.Minute
+		_ = ts.Cache.SetWithTTL(ctx, *cacheKey, results, propertyLatencyCacheTTL)
 	}
 
-	return nil
+	return results, nil
 }
 
-func (ts *TimeSeriesDB) DeletePropertiesData(ctx context.Context, propertyIDs []int32) error {
-	if len(propertyIDs) == 0 {
-		slog.WarnContext(ctx, "Nothing to delete from ClickHouse")
-		return nil
+func (ts *TimeSeriesDB) RetrieveExperimentStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.ExperimentArmStats, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
 	}
 
-	if !ts.IsAvailable() {
-		return ErrMaintenance
+	query := `SELECT experiment_arm, sum(requests_count), sum(success_count), sum(failure_count)
+FROM %s
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {from:DateTime} AND timestamp <= {to:DateTime}
+GROUP BY experiment_arm
+ORDER BY experiment_arm`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, ExperimentStatsTable),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("from", from.UTC().Truncate(time.Hour).Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query experiment stats", common.ErrAttr(err))
+		return nil, err
 	}
 
-	ids := idsToString(propertyIDs)
+	defer rows.Close()
 
-	// NOTE: access table for 1 month is not included as it does not have property_id column
-	tables := []string{
-		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d,
-		VerifyLogTable1h, VerifyLogTable1d,
+	results := make([]*common.ExperimentArmStats, 0, 2)
+
+	for rows.Next() {
+		var arm uint8
+		var requests, successes, failures uint64
+		if err := rows.Scan(&arm, &requests, &successes, &failures); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from experiment stats query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, &common.ExperimentArmStats{
+			Arm:           common.ExperimentArm(arm),
+			RequestsCount: int(requests),
+			SuccessCount:  int(successes),
+			FailureCount:  int(failures),
+		})
 	}
 
-	return ts.lightDelete(ctx, tables, "property_id", ids)
+	slog.InfoContext(ctx, "Fetched experiment stats", "count", len(results), "orgID", orgID, "propID", propertyID, "from", from, "to", to)
+
+	return results, nil
 }
 
-func (ts *TimeSeriesDB) DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error {
-	if len(orgIDs) == 0 {
-		slog.WarnContext(ctx, "Nothing to delete from ClickHouse")
-		return nil
+func (ts *TimeSeriesDB) RetrieveVisitorStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.VisitorClassStats, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
 	}
 
-	if !ts.IsAvailable() {
-		return ErrMaintenance
+	query := `SELECT visitor_class, sum(requests_count), sum(success_count), sum(failure_count)
+FROM %s
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {from:DateTime} AND timestamp <= {to:DateTime}
+GROUP BY visitor_class
+ORDER BY visitor_class`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, VisitorStatsTable),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("from", from.UTC().Truncate(time.Hour).Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query visitor stats", common.ErrAttr(err))
+		return nil, err
 	}
 
-	ids := idsToString(orgIDs)
+	defer rows.Close()
 
-	tables := []string{
-		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
-		VerifyLogTable1h, VerifyLogTable1d,
+	results := make([]*common.VisitorClassStats, 0, 2)
+
+	for rows.Next() {
+		var class uint8
+		var requests, successes, failures uint64
+		if err := rows.Scan(&class, &requests, &successes, &failures); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from visitor stats query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, &common.VisitorClassStats{
+			Class:         common.VisitorClass(class),
+			RequestsCount: int(requests),
+			SuccessCount:  int(successes),
+			FailureCount:  int(failures),
+		})
 	}
 
-	return ts.lightDelete(ctx, tables, "org_id", ids)
+	slog.InfoContext(ctx, "Fetched visitor stats", "count", len(results), "orgID", orgID, "propID", propertyID, "from", from, "to", to)
+
+	return results, nil
 }
 
-func (ts *TimeSeriesDB) DeleteUsersData(ctx context.Context, userIDs []int32) error {
-	if len(userIDs) == 0 {
-		slog.WarnContext(ctx, "Nothing to delete from ClickHouse")
-		return nil
+func (ts *TimeSeriesDB) RetrieveIntegrityStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) (*common.IntegrityStats, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
 	}
 
-	if !ts.IsAvailable() {
-		return ErrMaintenance
+	query := `SELECT integrity, sum(count)
+FROM %s
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {from:DateTime} AND timestamp <= {to:DateTime}
+GROUP BY integrity`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, IntegrityStatsTable),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("from", from.UTC().Truncate(time.Hour).Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query integrity stats", common.ErrAttr(err))
+		return nil, err
 	}
 
-	ids := idsToString(userIDs)
+	defer rows.Close()
 
-	tables := []string{
-		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
-		VerifyLogTable1h, VerifyLogTable1d,
+	result := &common.IntegrityStats{}
+
+	for rows.Next() {
+		var integrity uint8
+		var count uint64
+		if err := rows.Scan(&integrity, &count); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from integrity stats query", common.ErrAttr(err))
+			return nil, err
+		}
+
+		switch common.IntegrityStatus(integrity) {
+		case common.IntegrityOK:
+			result.OKCount += int(count)
+		case common.IntegrityTampered:
+			result.TamperedCount += int(count)
+		case common.IntegrityPatched:
+			result.PatchedCount += int(count)
+		default:
+			result.UnknownCount += int(count)
+		}
 	}
 
-	return ts.lightDelete(ctx, tables, "user_id", ids)
-}
+	slog.InfoContext(ctx, "Fetched integrity stats", "orgID", orgID, "propID", propertyID, "from", from, "to", to)
 
-type MemoryTimeSeries struct {
-	mu         sync.RWMutex
-	accessLogs []*common.AccessRecord
-	verifyLogs []*common.VerifyRecord
+	return result, nil
 }
 
-var _ common.TimeSeriesStore = (*MemoryTimeSeries)(nil)
+func (ts *TimeSeriesDB) RetrieveQuotaStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.TimeCount, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
 
-func NewMemoryTimeSeries() *MemoryTimeSeries {
-	return &MemoryTimeSeries{
-		accessLogs: make([]*common.AccessRecord, 0),
-		verifyLogs: make([]*common.VerifyRecord, 0),
+	query := `SELECT timestamp, sum(count) as count
+FROM %s
+WHERE org_id = {org_id:UInt32} AND property_id = {property_id:UInt32} AND timestamp >= {from:DateTime} AND timestamp <= {to:DateTime}
+GROUP BY timestamp
+ORDER BY timestamp`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, QuotaStatsTable1h),
+		clickhouse.Named("org_id", strconv.Itoa(int(orgID))),
+		clickhouse.Named("property_id", strconv.Itoa(int(propertyID))),
+		clickhouse.Named("from", from.UTC().Truncate(time.Hour).Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query quota stats", common.ErrAttr(err))
+		return nil, err
 	}
-}
 
-func (m *MemoryTimeSeries) Ping(ctx context.Context) error {
-	return nil
-}
+	defer rows.Close()
 
-func (m *MemoryTimeSeries) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.accessLogs = append(m.accessLogs, records...)
-	return nil
+	results := make([]*common.TimeCount, 0)
+
+	for rows.Next() {
+		var timestamp time.Time
+		var count uint64
+		if err := rows.Scan(&timestamp, &count); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from quota stats query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, &common.TimeCount{Timestamp: timestamp, Count: uint32(min(count, math.MaxUint32))})
+	}
+
+	slog.DebugContext(ctx, "Fetched quota stats", "orgID", orgID, "propID", propertyID, "count", len(results), "from", from)
+
+	return results, nil
 }
 
-func (m *MemoryTimeSeries) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
+func (ts *TimeSeriesDB) RetrieveIssuanceReceipts(ctx context.Context, userID int32, from, to time.Time) ([]*common.IssuanceReceipt, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT user_id, org_id, property_id, puzzle_id, sitekey, ip_prefix_hash, timestamp
+FROM %s
+WHERE user_id = {user_id:UInt32} AND timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+ORDER BY timestamp`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, IssuanceReceiptsTable),
+		clickhouse.Named("user_id", strconv.Itoa(int(userID))),
+		clickhouse.Named("from", from.UTC().Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query issuance receipts", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.IssuanceReceipt, 0)
+
+	for rows.Next() {
+		var userID, orgID, propertyID uint32
+		r := &common.IssuanceReceipt{}
+		if err := rows.Scan(&userID, &orgID, &propertyID, &r.PuzzleID, &r.Sitekey, &r.IPPrefixHash, &r.Timestamp); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from issuance receipts query", common.ErrAttr(err))
+			return nil, err
+		}
+		r.UserID = int32(userID)
+		r.OrgID = int32(orgID)
+		r.PropertyID = int32(propertyID)
+		results = append(results, r)
+	}
+
+	slog.InfoContext(ctx, "Fetched issuance receipts", "count", len(results), "userID", userID, "from", from, "to", to)
+
+	return results, nil
+}
+
+func (ts *TimeSeriesDB) RetrieveVerifyTraces(ctx context.Context, traceID string, limit int) ([]*common.VerifyRecord, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT user_id, org_id, property_id, puzzle_id, status, duration_us, timestamp
+FROM %s
+WHERE trace_id = {trace_id:String}
+ORDER BY timestamp
+LIMIT %d`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, VerifyTracesTable, limit),
+		clickhouse.Named("trace_id", traceID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query verify traces", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.VerifyRecord, 0)
+
+	for rows.Next() {
+		var userID, orgID, propertyID uint32
+		var status uint8
+		r := &common.VerifyRecord{TraceID: traceID}
+		if err := rows.Scan(&userID, &orgID, &propertyID, &r.PuzzleID, &status, &r.DurationUs, &r.Timestamp); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from verify traces query", common.ErrAttr(err))
+			return nil, err
+		}
+		r.UserID = int32(userID)
+		r.OrgID = int32(orgID)
+		r.PropertyID = int32(propertyID)
+		r.Status = int8(status)
+		results = append(results, r)
+	}
+
+	slog.DebugContext(ctx, "Fetched verify traces", "count", len(results), common.TraceIDAttr(traceID))
+
+	return results, nil
+}
+
+// RetrieveIssuanceAudit correlates receipts with the monthly aggregates that are used for billing (so from and to
+// are expected to be aligned to months)
+// RetrieveVerifyHealth returns hourly verifications and latency of all properties within the period
+func (ts *TimeSeriesDB) RetrieveVerifyHealth(ctx context.Context, from, to time.Time) ([]*common.VerifyHealthStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	stats := make(map[time.Time]*common.VerifyHealthStat)
+	hourStats := func(t time.Time) *common.VerifyHealthStat {
+		t = t.UTC()
+		s, ok := stats[t]
+		if !ok {
+			s = &common.VerifyHealthStat{Timestamp: t}
+			stats[t] = s
+		}
+		return s
+	}
+
+	countsQuery := `SELECT timestamp, sum(success_count), sum(failure_count)
+FROM %s
+WHERE timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+GROUP BY timestamp`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(countsQuery, VerifyLogTable1h),
+		clickhouse.Named("from", from.UTC().Truncate(time.Hour).Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query verify health counts", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var timestamp time.Time
+		var successes, failures uint64
+		if err := rows.Scan(&timestamp, &successes, &failures); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from verify health counts query", common.ErrAttr(err))
+			return nil, err
+		}
+		s := hourStats(timestamp)
+		s.SuccessCount, s.FailureCount = successes, failures
+	}
+
+	// quantiles are stored in microseconds
+	latencyQuery := `SELECT timestamp, quantilesTDigestMerge(0.5, 0.9, 0.99)(duration_quantiles) AS quantiles
+FROM %s
+WHERE timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+GROUP BY timestamp`
+	latencyRows, err := ts.Clickhouse.Query(fmt.Sprintf(latencyQuery, VerifyLatencyTable1h),
+		clickhouse.Named("from", from.UTC().Truncate(time.Hour).Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query verify health latency", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer latencyRows.Close()
+
+	for latencyRows.Next() {
+		var timestamp time.Time
+		var quantiles []float64
+		if err := latencyRows.Scan(&timestamp, &quantiles); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from verify health latency query", common.ErrAttr(err))
+			return nil, err
+		}
+		if len(quantiles) != 3 {
+			slog.WarnContext(ctx, "Unexpected number of latency quantiles", "count", len(quantiles))
+			continue
+		}
+		s := hourStats(timestamp)
+		s.P50, s.P90, s.P99 = quantiles[0]/1000.0, quantiles[1]/1000.0, quantiles[2]/1000.0
+	}
+
+	results := make([]*common.VerifyHealthStat, 0, len(stats))
+	for _, s := range stats {
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Timestamp.Before(results[j].Timestamp) })
+
+	slog.InfoContext(ctx, "Fetched verify health stats", "count", len(results), "from", from, "to", to)
+
+	return results, nil
+}
+
+func (ts *TimeSeriesDB) RetrieveIssuanceAudit(ctx context.Context, userID int32, from, to time.Time) ([]*common.IssuanceAuditStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT org_id, sum(receipts) AS receipts, sum(recorded) AS recorded
+FROM (
+    SELECT org_id, count() AS receipts, toUInt64(0) AS recorded
+    FROM %s
+    WHERE user_id = {user_id:UInt32} AND timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+    GROUP BY org_id
+    UNION ALL
+    SELECT org_id, toUInt64(0) AS receipts, sum(count) AS recorded
+    FROM %s
+    WHERE user_id = {user_id:UInt32} AND timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+    GROUP BY org_id
+)
+GROUP BY org_id
+ORDER BY org_id`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, IssuanceReceiptsTable, AccessLogTableName1mo),
+		clickhouse.Named("user_id", strconv.Itoa(int(userID))),
+		clickhouse.Named("from", from.UTC().Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query issuance audit", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.IssuanceAuditStat, 0)
+
+	for rows.Next() {
+		var orgID uint32
+		s := &common.IssuanceAuditStat{}
+		if err := rows.Scan(&orgID, &s.Receipts, &s.Recorded); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from issuance audit query", common.ErrAttr(err))
+			return nil, err
+		}
+		s.OrgID = int32(orgID)
+		results = append(results, s)
+	}
+
+	slog.InfoContext(ctx, "Fetched issuance audit", "count", len(results), "userID", userID, "from", from, "to", to)
+
+	return results, nil
+}
+
+func (ts *TimeSeriesDB) RetrieveRecentTopProperties(ctx context.Context, limit int) (map[int32]uint, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	// NOTE: we don't use FINAL here because this is just an approximation anyways
+	// that is used to warmup cache so we don't need the most precise results
+	query := `SELECT property_id
+FROM %s
+WHERE timestamp >= now() - INTERVAL 1 DAY
+GROUP BY property_id
+ORDER BY sum(success_count + failure_count) DESC
+LIMIT %d`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, VerifyLogTable1d, limit))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to execute top usage query", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	properties := make(map[int32]uint, limit)
+
+	for rows.Next() {
+		var propertyID int32
+		if err := rows.Scan(&propertyID); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from top usage query", common.ErrAttr(err))
+			return nil, err
+		}
+		properties[propertyID]++
+	}
+
+	return properties, nil
+}
+
+// RetrievePropertiesHourlyStats returns requests and verifications of all properties with traffic within the hour
+func (ts *TimeSeriesDB) RetrievePropertiesHourlyStats(ctx context.Context, hour time.Time) ([]*common.PropertyHourlyStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT org_id, property_id, sum(requests), sum(successes), sum(failures)
+FROM (
+    SELECT org_id, property_id, sum(count) AS requests, toUInt64(0) AS successes, toUInt64(0) AS failures
+    FROM %s
+    WHERE timestamp = {hour:DateTime}
+    GROUP BY org_id, property_id
+    UNION ALL
+    SELECT org_id, property_id, toUInt64(0) AS requests, sum(success_count) AS successes, sum(failure_count) AS failures
+    FROM %s
+    WHERE timestamp = {hour:DateTime}
+    GROUP BY org_id, property_id
+)
+GROUP BY org_id, property_id`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, AccessLogTableName1h, VerifyLogTable1h),
+		clickhouse.Named("hour", hour.UTC().Truncate(time.Hour).Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query properties hourly stats", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.PropertyHourlyStat, 0)
+
+	for rows.Next() {
+		var orgID, propertyID uint32
+		var requests, successes, failures uint64
+		if err := rows.Scan(&orgID, &propertyID, &requests, &successes, &failures); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from properties hourly stats query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, &common.PropertyHourlyStat{
+			OrgID:        int32(orgID),
+			PropertyID:   int32(propertyID),
+			Requests:     requests,
+			SuccessCount: successes,
+			FailureCount: failures,
+		})
+	}
+
+	slog.InfoContext(ctx, "Fetched properties hourly stats", "count", len(results), "hour", hour)
+
+	return results, nil
+}
+
+// RetrievePropertiesStats returns requests and verifications of all properties within the period
+func (ts *TimeSeriesDB) RetrievePropertiesStats(ctx context.Context, from, to time.Time) ([]*common.PropertyHourlyStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	query := `SELECT org_id, property_id, sum(requests), sum(successes), sum(failures)
+FROM (
+    SELECT org_id, property_id, sum(count) AS requests, toUInt64(0) AS successes, toUInt64(0) AS failures
+    FROM %s FINAL
+    WHERE timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+    GROUP BY org_id, property_id
+    UNION ALL
+    SELECT org_id, property_id, toUInt64(0) AS requests, sum(success_count) AS successes, sum(failure_count) AS failures
+    FROM %s FINAL
+    WHERE timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+    GROUP BY org_id, property_id
+)
+GROUP BY org_id, property_id
+ORDER BY org_id, property_id`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(query, AccessLogTableName1d, VerifyLogTable1d),
+		clickhouse.Named("from", from.UTC().Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query properties stats", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*common.PropertyHourlyStat, 0)
+
+	for rows.Next() {
+		var orgID, propertyID uint32
+		var requests, successes, failures uint64
+		if err := rows.Scan(&orgID, &propertyID, &requests, &successes, &failures); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from properties stats query", common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, &common.PropertyHourlyStat{
+			OrgID:        int32(orgID),
+			PropertyID:   int32(propertyID),
+			Requests:     requests,
+			SuccessCount: successes,
+			FailureCount: failures,
+		})
+	}
+
+	slog.InfoContext(ctx, "Fetched properties stats", "count", len(results), "from", from, "to", to)
+
+	return results, nil
+}
+
+// RetrieveOrgUsage returns requests and verifications of all organizations within the period, together with their
+// estimated share of the storage (as rows share of each table's size on disk), used for cost attribution
+func (ts *TimeSeriesDB) RetrieveOrgUsage(ctx context.Context, from, to time.Time) ([]*common.OrgUsageStat, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	stats := make(map[[2]int32]*common.OrgUsageStat)
+	orgStats := func(userID, orgID uint32) *common.OrgUsageStat {
+		key := [2]int32{int32(userID), int32(orgID)}
+		s, ok := stats[key]
+		if !ok {
+			s = &common.OrgUsageStat{UserID: int32(userID), OrgID: int32(orgID)}
+			stats[key] = s
+		}
+		return s
+	}
+
+	// NOTE: we don't use FINAL here as this is an estimate anyways
+	usageQuery := `SELECT user_id, org_id, sum(requests), sum(verifications)
+FROM (
+    SELECT user_id, org_id, sum(count) AS requests, toUInt64(0) AS verifications
+    FROM %s
+    WHERE timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+    GROUP BY user_id, org_id
+    UNION ALL
+    SELECT user_id, org_id, toUInt64(0) AS requests, sum(success_count + failure_count) AS verifications
+    FROM %s
+    WHERE timestamp >= {from:DateTime} AND timestamp < {to:DateTime}
+    GROUP BY user_id, org_id
+)
+GROUP BY user_id, org_id`
+	rows, err := ts.Clickhouse.Query(fmt.Sprintf(usageQuery, AccessLogTableName1mo, VerifyLogTable1d),
+		clickhouse.Named("from", from.UTC().Format(time.DateTime)),
+		clickhouse.Named("to", to.UTC().Format(time.DateTime)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query org usage", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, orgID uint32
+		var requests, verifications uint64
+		if err := rows.Scan(&userID, &orgID, &requests, &verifications); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from org usage query", common.ErrAttr(err))
+			return nil, err
+		}
+		s := orgStats(userID, orgID)
+		s.Requests = requests
+		s.Verifications = verifications
+	}
+
+	tables := []string{
+		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
+		VerifyLogTable1h, VerifyLogTable1d,
+		VerifyLatencyTable1h, VerifyLatencyTable1d,
+		ExperimentStatsTable, VisitorStatsTable, IntegrityStatsTable, IssuanceReceiptsTable, VerifyTracesTable,
+		QuotaStatsTable1h,
+	}
+
+	tableQueries := make([]string, 0, len(tables))
+	for _, table := range tables {
+		tableQueries = append(tableQueries, fmt.Sprintf("SELECT '%[1]s' AS name, user_id, org_id, count() AS org_rows FROM %[1]s GROUP BY user_id, org_id", table))
+	}
+
+	storageQuery := `SELECT usage.user_id, usage.org_id, sum(usage.org_rows * sizes.total_bytes / sizes.total_rows)
+FROM (%s) AS usage
+JOIN (
+    SELECT concat(database, '.', table) AS name, sum(bytes_on_disk) AS total_bytes, sum(rows) AS total_rows
+    FROM system.parts
+    WHERE active
+    GROUP BY name
+    HAVING total_rows > 0
+) AS sizes ON usage.name = sizes.name
+GROUP BY usage.user_id, usage.org_id`
+	storageRows, err := ts.Clickhouse.Query(fmt.Sprintf(storageQuery, strings.Join(tableQueries, "\nUNION ALL\n")))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query org storage", common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer storageRows.Close()
+
+	for storageRows.Next() {
+		var userID, orgID uint32
+		var bytes float64
+		if err := storageRows.Scan(&userID, &orgID, &bytes); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from org storage query", common.ErrAttr(err))
+			return nil, err
+		}
+		orgStats(userID, orgID).StorageBytes = uint64(bytes)
+	}
+
+	results := make([]*common.OrgUsageStat, 0, len(stats))
+	for _, s := range stats {
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].OrgID < results[j].OrgID })
+
+	slog.InfoContext(ctx, "Fetched org usage", "count", len(results), "from", from, "to", to)
+
+	return results, nil
+}
+
+// SchemaVersion returns applied migration version and if it is dirty (failed)
+func (ts *TimeSeriesDB) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	if !ts.IsAvailable() {
+		return 0, false, ErrMaintenance
+	}
+
+	query := fmt.Sprintf("SELECT version, dirty FROM %s ORDER BY sequence DESC LIMIT 1", clickhouseMigrationsTable)
+	var version int64
+	var dirty uint8
+	if err := ts.Clickhouse.QueryRow(query).Scan(&version, &dirty); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to query schema version", common.ErrAttr(err))
+		return 0, false, err
+	}
+
+	return uint(max(0, version)), dirty != 0, nil
+}
+
+// RetrieveEarliestTimestamp returns the oldest timestamp in the table or zero time if the table is empty
+func (ts *TimeSeriesDB) RetrieveEarliestTimestamp(ctx context.Context, table string) (time.Time, error) {
+	if !ts.IsAvailable() {
+		return time.Time{}, ErrMaintenance
+	}
+
+	query := fmt.Sprintf("SELECT count(), min(timestamp) FROM %s", table)
+	var count uint64
+	var earliest time.Time
+	if err := ts.Clickhouse.QueryRow(query).Scan(&count, &earliest); err != nil {
+		slog.ErrorContext(ctx, "Failed to query earliest timestamp", "table", table, common.ErrAttr(err))
+		return time.Time{}, err
+	}
+
+	if count == 0 {
+		return time.Time{}, nil
+	}
+
+	return earliest.UTC(), nil
+}
+
+// ExecBackfill runs backfill query (usually INSERT ... SELECT) for the time range [from, to). Data of the range
+// is deleted first, because chunk could have been inserted before (e.g. node crashed before saving the checkpoint)
+func (ts *TimeSeriesDB) ExecBackfill(ctx context.Context, table, query string, from, to time.Time) error {
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	fromArg := clickhouse.Named("from", from.UTC().Format(time.DateTime))
+	toArg := clickhouse.Named("to", to.UTC().Format(time.DateTime))
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE timestamp >= {from:DateTime} AND timestamp < {to:DateTime}", table)
+	if _, err := ts.Clickhouse.Exec(deleteQuery, fromArg, toArg); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete backfill chunk", "table", table, "from", from, "to", to, common.ErrAttr(err))
+		return err
+	}
+
+	if _, err := ts.Clickhouse.Exec(query, fromArg, toArg); err != nil {
+		slog.ErrorContext(ctx, "Failed to execute backfill", "from", from, "to", to, common.ErrAttr(err))
+		return err
+	}
+
+	return nil
+}
+
+func (ts *TimeSeriesDB) lightDelete(ctx context.Context, tables []string, column string, ids string) error {
+	for _, table := range tables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", table, column, ids)
+		if _, err := ts.Clickhouse.Exec(query); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete data", "table", table, "column", column, common.ErrAttr(err))
+			return err
+		}
+		slog.InfoContext(ctx, "Deleted data in ClickHouse", "column", column, "table", table)
+	}
+
+	return nil
+}
+
+func (ts *TimeSeriesDB) DeletePropertiesData(ctx context.Context, propertyIDs []int32) error {
+	if len(propertyIDs) == 0 {
+		slog.WarnContext(ctx, "Nothing to delete from ClickHouse")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	ids := idsToString(propertyIDs)
+
+	// NOTE: access table for 1 month is not included as it does not have property_id column and issuance receipts
+	// are kept for the same reason (they are the evidence for the monthly billed counts)
+	tables := []string{
+		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d,
+		VerifyLogTable1h, VerifyLogTable1d,
+		VerifyLatencyTable1h, VerifyLatencyTable1d,
+		ExperimentStatsTable, VisitorStatsTable, IntegrityStatsTable, VerifyTracesTable, QuotaStatsTable1h,
+	}
+
+	return ts.lightDelete(ctx, tables, "property_id", ids)
+}
+
+func (ts *TimeSeriesDB) DeleteOrganizationsData(ctx context.Context, orgIDs []int32) error {
+	if len(orgIDs) == 0 {
+		slog.WarnContext(ctx, "Nothing to delete from ClickHouse")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	ids := idsToString(orgIDs)
+
+	tables := []string{
+		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
+		VerifyLogTable1h, VerifyLogTable1d,
+		VerifyLatencyTable1h, VerifyLatencyTable1d,
+		ExperimentStatsTable, VisitorStatsTable, IntegrityStatsTable, IssuanceReceiptsTable, VerifyTracesTable,
+		QuotaStatsTable1h,
+	}
+
+	return ts.lightDelete(ctx, tables, "org_id", ids)
+}
+
+func (ts *TimeSeriesDB) DeleteUsersData(ctx context.Context, userIDs []int32) error {
+	if len(userIDs) == 0 {
+		slog.WarnContext(ctx, "Nothing to delete from ClickHouse")
+		return nil
+	}
+
+	if !ts.IsAvailable() {
+		return ErrMaintenance
+	}
+
+	ids := idsToString(userIDs)
+
+	tables := []string{
+		AccessLogTableName5m, AccessLogTableName1h, AccessLogTableName1d, AccessLogTableName1mo,
+		VerifyLogTable1h, VerifyLogTable1d,
+		VerifyLatencyTable1h, VerifyLatencyTable1d,
+		ExperimentStatsTable, VisitorStatsTable, IntegrityStatsTable, IssuanceReceiptsTable, VerifyTracesTable,
+		QuotaStatsTable1h,
+	}
+
+	return ts.lightDelete(ctx, tables, "user_id", ids)
+}
+
+// retrieveDataIDs returns sorted distinct values of the column in tables, that are greater than afterID
+func (ts *TimeSeriesDB) retrieveDataIDs(ctx context.Context, tables []string, column string, afterID int32, limit int) ([]int32, error) {
+	if !ts.IsAvailable() {
+		return nil, ErrMaintenance
+	}
+
+	parts := make([]string, 0, len(tables))
+	for _, table := range tables {
+		parts = append(parts, fmt.Sprintf("SELECT DISTINCT %s AS id FROM %s WHERE %s > {after:UInt32}", column, table, column))
+	}
+
+	query := fmt.Sprintf("SELECT DISTINCT id FROM (%s) ORDER BY id LIMIT {limit:UInt32}", strings.Join(parts, " UNION ALL "))
+	rows, err := ts.Clickhouse.Query(query, clickhouse.Named("after", strconv.Itoa(int(max(0, afterID)))),
+		clickhouse.Named("limit", strconv.Itoa(limit)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query data IDs", "column", column, common.ErrAttr(err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]int32, 0, limit)
+
+	for rows.Next() {
+		var id uint32
+		if err := rows.Scan(&id); err != nil {
+			slog.ErrorContext(ctx, "Failed to read row from data IDs query", "column", column, common.ErrAttr(err))
+			return nil, err
+		}
+		results = append(results, int32(id))
+	}
+
+	slog.DebugContext(ctx, "Fetched data IDs", "column", column, "after", afterID, "count", len(results))
+
+	return results, nil
+}
+
+func (ts *TimeSeriesDB) RetrieveDataPropertyIDs(ctx context.Context, afterID int32, limit int) ([]int32, error) {
+	// tables with the longest TTL, which have data of any property that had traffic
+	tables := []string{AccessLogTableName1d, VerifyLogTable1d}
+
+	return ts.retrieveDataIDs(ctx, tables, "property_id", afterID, limit)
+}
+
+func (ts *TimeSeriesDB) RetrieveDataOrgIDs(ctx context.Context, afterID int32, limit int) ([]int32, error) {
+	tables := []string{AccessLogTableName1d, AccessLogTableName1mo, IssuanceReceiptsTable}
+
+	return ts.retrieveDataIDs(ctx, tables, "org_id", afterID, limit)
+}
+
+// PendingDeletesCount returns the number of mutations (lightweight deletes are executed as such) in progress
+func (ts *TimeSeriesDB) PendingDeletesCount(ctx context.Context) (int, error) {
+	if !ts.IsAvailable() {
+		return 0, ErrMaintenance
+	}
+
+	var count uint64
+	if err := ts.Clickhouse.QueryRow("SELECT count() FROM system.mutations WHERE database = 'privatecaptcha' AND NOT is_done").Scan(&count); err != nil {
+		slog.ErrorContext(ctx, "Failed to query pending mutations", common.ErrAttr(err))
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+type MemoryTimeSeries struct {
+	mu         sync.RWMutex
+	accessLogs []*common.AccessRecord
+	verifyLogs []*common.VerifyRecord
+	receipts   []*common.IssuanceReceipt
+	quotaLogs  []*common.QuotaRecord
+}
+
+var _ common.TimeSeriesStore = (*MemoryTimeSeries)(nil)
+
+func NewMemoryTimeSeries() *MemoryTimeSeries {
+	return &MemoryTimeSeries{
+		accessLogs: make([]*common.AccessRecord, 0),
+		verifyLogs: make([]*common.VerifyRecord, 0),
+		receipts:   make([]*common.IssuanceReceipt, 0),
+		quotaLogs:  make([]*common.QuotaRecord, 0),
+	}
+}
+
+func (m *MemoryTimeSeries) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryTimeSeries) WriteAccessLogBatch(ctx context.Context, records []*common.AccessRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accessLogs = append(m.accessLogs, records...)
+	return nil
+}
+
+func (m *MemoryTimeSeries) WriteVerifyLogBatch(ctx context.Context, records []*common.VerifyRecord) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.verifyLogs = append(m.verifyLogs, records...)
 	return nil
 }
 
-func (m *MemoryTimeSeries) RetrievePropertyStatsSince(ctx context.Context, r *common.BackfillRequest, from time.Time) ([]*common.TimeCount, error) {
+func (m *MemoryTimeSeries) WriteIssuanceReceiptBatch(ctx context.Context, records []*common.IssuanceReceipt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.receipts = append(m.receipts, records...)
+	return nil
+}
+
+func (m *MemoryTimeSeries) WriteQuotaLogBatch(ctx context.Context, records []*common.QuotaRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quotaLogs = append(m.quotaLogs, records...)
+	return nil
+}
+
+func (m *MemoryTimeSeries) RetrievePropertyStatsSince(ctx context.Context, r *common.BackfillRequest, from time.Time) ([]*common.TimeCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[time.Time]uint32)
+	for _, log := range m.accessLogs {
+		if log.OrgID == r.OrgID && log.UserID == r.UserID && log.PropertyID == r.PropertyID && !log.Timestamp.Before(from) {
+			// Real DB uses request_logs_5m which is aggregated by 5 minutes
+			ts := log.Timestamp.Truncate(5 * time.Minute)
+			counts[ts]++
+		}
+	}
+
+	return mapToTimeCount(counts), nil
+}
+
+func (m *MemoryTimeSeries) RetrieveAccountStats(ctx context.Context, userID int32, from time.Time, excludeOrgIDs []int32) ([]*common.TimeCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[time.Time]uint32)
+	for _, log := range m.accessLogs {
+		if log.UserID == userID && !log.Timestamp.Before(from) && !slices.Contains(excludeOrgIDs, log.OrgID) {
+			// Real DB uses request_logs_1mo which is aggregated by month
+			y, month, _ := log.Timestamp.Date()
+			ts := time.Date(y, month, 1, 0, 0, 0, 0, log.Timestamp.Location())
+			counts[ts]++
+		}
+	}
+
+	return mapToTimeCount(counts), nil
+}
+
+func (m *MemoryTimeSeries) RetrievePropertyStatsByPeriod(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from := getStartTime(period)
+	statsMap := make(map[time.Time]*common.TimePeriodStat)
+
+	truncate := periodTruncateFunc(period)
+
+	getStat := func(t time.Time) *common.TimePeriodStat {
+		ts := truncate(t)
+		if _, ok := statsMap[ts]; !ok {
+			statsMap[ts] = &common.TimePeriodStat{Timestamp: ts}
+		}
+		return statsMap[ts]
+	}
+
+	for _, log := range m.accessLogs {
+		if log.OrgID == orgID && log.PropertyID == propertyID && !log.Timestamp.Before(from) {
+			getStat(log.Timestamp).RequestsCount++
+		}
+	}
+
+	for _, log := range m.verifyLogs {
+		if log.OrgID == orgID && log.PropertyID == propertyID && !log.Timestamp.Before(from) {
+			getStat(log.Timestamp).VerifiesCount++
+		}
+	}
+
+	// Convert map to sorted slice
+	result := make([]*common.TimePeriodStat, 0, len(statsMap))
+	for _, v := range statsMap {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result, nil
+}
+
+// returns quantile of sorted durations (in microseconds) in milliseconds
+func durationQuantile(sorted []uint32, q float64) float64 {
+	index := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return float64(sorted[max(index, 0)]) / 1000.0
+}
+
+func (m *MemoryTimeSeries) RetrievePropertyVerifyLatencyByPeriod(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodLatency, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from := getStartTime(period)
+	truncate := periodTruncateFunc(period)
+	durations := make(map[time.Time][]uint32)
+
+	for _, log := range m.verifyLogs {
+		if log.OrgID == orgID && log.PropertyID == propertyID && (log.DurationUs > 0) && !log.Timestamp.Before(from) {
+			ts := truncate(log.Timestamp)
+			durations[ts] = append(durations[ts], log.DurationUs)
+		}
+	}
+
+	result := make([]*common.TimePeriodLatency, 0, len(durations))
+	for ts, dd := range durations {
+		slices.Sort(dd)
+		result = append(result, &common.TimePeriodLatency{
+			Timestamp: ts,
+			P50:       durationQuantile(dd, 0.5),
+			P90:       durationQuantile(dd, 0.9),
+			P99:       durationQuantile(dd, 0.99),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveExperimentStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.ExperimentArmStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[common.ExperimentArm]*common.ExperimentArmStats)
+	armStats := func(arm common.ExperimentArm) *common.ExperimentArmStats {
+		s, ok := stats[arm]
+		if !ok {
+			s = &common.ExperimentArmStats{Arm: arm}
+			stats[arm] = s
+		}
+		return s
+	}
+
+	inRange := func(t time.Time) bool {
+		return !t.Before(from.Truncate(time.Hour)) && !t.After(to)
+	}
+
+	for _, log := range m.accessLogs {
+		if log.OrgID == orgID && log.PropertyID == propertyID && (log.ExperimentArm != common.ExperimentArmNone) && inRange(log.Timestamp) {
+			armStats(log.ExperimentArm).RequestsCount++
+		}
+	}
+
+	for _, log := range m.verifyLogs {
+		if log.OrgID == orgID && log.PropertyID == propertyID && (log.ExperimentArm != common.ExperimentArmNone) && inRange(log.Timestamp) {
+			if log.Status == 0 {
+				armStats(log.ExperimentArm).SuccessCount++
+			} else {
+				armStats(log.ExperimentArm).FailureCount++
+			}
+		}
+	}
+
+	result := make([]*common.ExperimentArmStats, 0, len(stats))
+	for _, v := range stats {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Arm < result[j].Arm })
+
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveVisitorStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.VisitorClassStats, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	counts := make(map[time.Time]uint32)
+	stats := make(map[common.VisitorClass]*common.VisitorClassStats)
+	classStats := func(class common.VisitorClass) *common.VisitorClassStats {
+		s, ok := stats[class]
+		if !ok {
+			s = &common.VisitorClassStats{Class: class}
+			stats[class] = s
+		}
+		return s
+	}
+
+	inRange := func(t time.Time) bool {
+		return !t.Before(from.Truncate(time.Hour)) && !t.After(to)
+	}
+
 	for _, log := range m.accessLogs {
-		if log.OrgID == r.OrgID && log.UserID == r.UserID && log.PropertyID == r.PropertyID && !log.Timestamp.Before(from) {
-			// Real DB uses request_logs_5m which is aggregated by 5 minutes
-			ts := log.Timestamp.Truncate(5 * time.Minute)
-			counts[ts]++
+		if log.OrgID == orgID && log.PropertyID == propertyID && (log.VisitorClass != common.VisitorClassNone) && inRange(log.Timestamp) {
+			classStats(log.VisitorClass).RequestsCount++
 		}
 	}
 
-	return mapToTimeCount(counts), nil
+	for _, log := range m.verifyLogs {
+		if log.OrgID == orgID && log.PropertyID == propertyID && (log.VisitorClass != common.VisitorClassNone) && inRange(log.Timestamp) {
+			if log.Status == 0 {
+				classStats(log.VisitorClass).SuccessCount++
+			} else {
+				classStats(log.VisitorClass).FailureCount++
+			}
+		}
+	}
+
+	result := make([]*common.VisitorClassStats, 0, len(stats))
+	for _, v := range stats {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Class < result[j].Class })
+
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveIntegrityStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) (*common.IntegrityStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := &common.IntegrityStats{}
+
+	for _, log := range m.verifyLogs {
+		if log.OrgID != orgID || log.PropertyID != propertyID || log.Timestamp.Before(from.Truncate(time.Hour)) || log.Timestamp.After(to) {
+			continue
+		}
+
+		switch log.Integrity {
+		case common.IntegrityOK:
+			result.OKCount++
+		case common.IntegrityTampered:
+			result.TamperedCount++
+		case common.IntegrityPatched:
+			result.PatchedCount++
+		default:
+			result.UnknownCount++
+		}
+	}
+
+	return result, nil
 }
 
-func (m *MemoryTimeSeries) RetrieveAccountStats(ctx context.Context, userID int32, from time.Time) ([]*common.TimeCount, error) {
+func (m *MemoryTimeSeries) RetrieveQuotaStats(ctx context.Context, orgID, propertyID int32, from, to time.Time) ([]*common.TimeCount, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	counts := make(map[time.Time]uint32)
-	for _, log := range m.accessLogs {
-		if log.UserID == userID && !log.Timestamp.Before(from) {
-			// Real DB uses request_logs_1mo which is aggregated by month
-			y, month, _ := log.Timestamp.Date()
-			ts := time.Date(y, month, 1, 0, 0, 0, 0, log.Timestamp.Location())
-			counts[ts]++
+	for _, log := range m.quotaLogs {
+		if log.OrgID != orgID || log.PropertyID != propertyID || log.Timestamp.Before(from.Truncate(time.Hour)) || log.Timestamp.After(to) {
+			continue
 		}
+		// Real DB uses quota_stats_1h which is aggregated by hour
+		counts[log.Timestamp.Truncate(time.Hour)]++
 	}
 
 	return mapToTimeCount(counts), nil
 }
 
-func (m *MemoryTimeSeries) RetrievePropertyStatsByPeriod(ctx context.Context, orgID, propertyID int32, period common.TimePeriod) ([]*common.TimePeriodStat, error) {
+func (m *MemoryTimeSeries) RetrieveIssuanceReceipts(ctx context.Context, userID int32, from, to time.Time) ([]*common.IssuanceReceipt, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	from := getStartTime(period)
-	statsMap := make(map[time.Time]*common.TimePeriodStat)
+	result := make([]*common.IssuanceReceipt, 0)
+	for _, r := range m.receipts {
+		if r.UserID == userID && !r.Timestamp.Before(from) && r.Timestamp.Before(to) {
+			result = append(result, r)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
 
-	// Define truncation function based on period
-	var truncate func(time.Time) time.Time
-	switch period {
-	case common.TimePeriodToday:
-		// 1h
-		truncate = func(t time.Time) time.Time { return t.Truncate(time.Hour) }
-	case common.TimePeriodWeek:
-		// Real DB uses request_logs_1d, so effectively daily resolution
-		truncate = func(t time.Time) time.Time {
-			y, m, d := t.Date()
-			return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveVerifyTraces(ctx context.Context, traceID string, limit int) ([]*common.VerifyRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*common.VerifyRecord, 0)
+	for _, r := range m.verifyLogs {
+		if (len(traceID) > 0) && (r.TraceID == traceID) && (len(result) < limit) {
+			result = append(result, r)
 		}
-	case common.TimePeriodMonth:
-		// 1d
-		truncate = func(t time.Time) time.Time {
-			y, m, d := t.Date()
-			return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveVerifyHealth(ctx context.Context, from, to time.Time) ([]*common.VerifyHealthStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[time.Time]*common.VerifyHealthStat)
+	durations := make(map[time.Time][]uint32)
+
+	for _, log := range m.verifyLogs {
+		if log.Timestamp.Before(from) || !log.Timestamp.Before(to) {
+			continue
 		}
-	case common.TimePeriodYear:
-		// 1mo
-		truncate = func(t time.Time) time.Time {
-			y, m, _ := t.Date()
-			return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+
+		ts := log.Timestamp.UTC().Truncate(time.Hour)
+		s, ok := stats[ts]
+		if !ok {
+			s = &common.VerifyHealthStat{Timestamp: ts}
+			stats[ts] = s
+		}
+
+		if log.Status == 0 {
+			s.SuccessCount++
+		} else {
+			s.FailureCount++
+		}
+
+		if log.DurationUs > 0 {
+			durations[ts] = append(durations[ts], log.DurationUs)
 		}
-	default:
-		truncate = func(t time.Time) time.Time { return t.Truncate(time.Hour) }
 	}
 
-	getStat := func(t time.Time) *common.TimePeriodStat {
-		ts := truncate(t)
-		if _, ok := statsMap[ts]; !ok {
-			statsMap[ts] = &common.TimePeriodStat{Timestamp: ts}
+	result := make([]*common.VerifyHealthStat, 0, len(stats))
+	for ts, s := range stats {
+		if dd := durations[ts]; len(dd) > 0 {
+			slices.Sort(dd)
+			s.P50, s.P90, s.P99 = durationQuantile(dd, 0.5), durationQuantile(dd, 0.9), durationQuantile(dd, 0.99)
 		}
-		return statsMap[ts]
+		result = append(result, s)
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
 
-	for _, log := range m.accessLogs {
-		if log.OrgID == orgID && log.PropertyID == propertyID && !log.Timestamp.Before(from) {
-			getStat(log.Timestamp).RequestsCount++
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveIssuanceAudit(ctx context.Context, userID int32, from, to time.Time) ([]*common.IssuanceAuditStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[int32]*common.IssuanceAuditStat)
+	orgStats := func(orgID int32) *common.IssuanceAuditStat {
+		s, ok := stats[orgID]
+		if !ok {
+			s = &common.IssuanceAuditStat{OrgID: orgID}
+			stats[orgID] = s
 		}
+		return s
 	}
 
-	for _, log := range m.verifyLogs {
-		if log.OrgID == orgID && log.PropertyID == propertyID && !log.Timestamp.Before(from) {
-			getStat(log.Timestamp).VerifiesCount++
+	inRange := func(t time.Time) bool {
+		return !t.Before(from) && t.Before(to)
+	}
+
+	for _, r := range m.receipts {
+		if r.UserID == userID && inRange(r.Timestamp) {
+			orgStats(r.OrgID).Receipts++
 		}
 	}
 
-	// Convert map to sorted slice
-	result := make([]*common.TimePeriodStat, 0, len(statsMap))
-	for _, v := range statsMap {
+	// Real DB uses request_logs_1mo
+	for _, log := range m.accessLogs {
+		if log.UserID == userID && inRange(log.Timestamp) {
+			orgStats(log.OrgID).Recorded++
+		}
+	}
+
+	result := make([]*common.IssuanceAuditStat, 0, len(stats))
+	for _, v := range stats {
 		result = append(result, v)
 	}
-	sort.Slice(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+	sort.Slice(result, func(i, j int) bool { return result[i].OrgID < result[j].OrgID })
 
 	return result, nil
 }
@@ -660,6 +1862,201 @@ func (m *MemoryTimeSeries) RetrieveRecentTopProperties(ctx context.Context, limi
 	return limitedCounts, nil
 }
 
+func (m *MemoryTimeSeries) RetrievePropertiesHourlyStats(ctx context.Context, hour time.Time) ([]*common.PropertyHourlyStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hour = hour.Truncate(time.Hour)
+
+	stats := make(map[int32]*common.PropertyHourlyStat)
+	propertyStats := func(orgID, propertyID int32) *common.PropertyHourlyStat {
+		s, ok := stats[propertyID]
+		if !ok {
+			s = &common.PropertyHourlyStat{OrgID: orgID, PropertyID: propertyID}
+			stats[propertyID] = s
+		}
+		return s
+	}
+
+	for _, log := range m.accessLogs {
+		if log.Timestamp.Truncate(time.Hour).Equal(hour) {
+			propertyStats(log.OrgID, log.PropertyID).Requests++
+		}
+	}
+
+	for _, log := range m.verifyLogs {
+		if log.Timestamp.Truncate(time.Hour).Equal(hour) {
+			if log.Status == 0 {
+				propertyStats(log.OrgID, log.PropertyID).SuccessCount++
+			} else {
+				propertyStats(log.OrgID, log.PropertyID).FailureCount++
+			}
+		}
+	}
+
+	result := make([]*common.PropertyHourlyStat, 0, len(stats))
+	for _, v := range stats {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PropertyID < result[j].PropertyID })
+
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrievePropertiesStats(ctx context.Context, from, to time.Time) ([]*common.PropertyHourlyStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[int32]*common.PropertyHourlyStat)
+	propertyStats := func(orgID, propertyID int32) *common.PropertyHourlyStat {
+		s, ok := stats[propertyID]
+		if !ok {
+			s = &common.PropertyHourlyStat{OrgID: orgID, PropertyID: propertyID}
+			stats[propertyID] = s
+		}
+		return s
+	}
+
+	inRange := func(t time.Time) bool {
+		return !t.Before(from) && t.Before(to)
+	}
+
+	for _, log := range m.accessLogs {
+		if inRange(log.Timestamp) {
+			propertyStats(log.OrgID, log.PropertyID).Requests++
+		}
+	}
+
+	for _, log := range m.verifyLogs {
+		if inRange(log.Timestamp) {
+			if log.Status == 0 {
+				propertyStats(log.OrgID, log.PropertyID).SuccessCount++
+			} else {
+				propertyStats(log.OrgID, log.PropertyID).FailureCount++
+			}
+		}
+	}
+
+	result := make([]*common.PropertyHourlyStat, 0, len(stats))
+	for _, v := range stats {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].OrgID != result[j].OrgID {
+			return result[i].OrgID < result[j].OrgID
+		}
+		return result[i].PropertyID < result[j].PropertyID
+	})
+
+	return result, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveOrgUsage(ctx context.Context, from, to time.Time) ([]*common.OrgUsageStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[[2]int32]*common.OrgUsageStat)
+	orgStats := func(userID, orgID int32) *common.OrgUsageStat {
+		key := [2]int32{userID, orgID}
+		s, ok := stats[key]
+		if !ok {
+			s = &common.OrgUsageStat{UserID: userID, OrgID: orgID}
+			stats[key] = s
+		}
+		return s
+	}
+
+	inRange := func(t time.Time) bool {
+		return !t.Before(from) && t.Before(to)
+	}
+
+	// there's no storage to estimate in memory
+	for _, log := range m.accessLogs {
+		if inRange(log.Timestamp) {
+			orgStats(log.UserID, log.OrgID).Requests++
+		}
+	}
+
+	for _, log := range m.verifyLogs {
+		if inRange(log.Timestamp) {
+			orgStats(log.UserID, log.OrgID).Verifications++
+		}
+	}
+
+	result := make([]*common.OrgUsageStat, 0, len(stats))
+	for _, v := range stats {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].OrgID < result[j].OrgID })
+
+	return result, nil
+}
+
+// SchemaVersion reports that memory storage always has the latest schema
+func (m *MemoryTimeSeries) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	return math.MaxUint32, false, nil
+}
+
+func (m *MemoryTimeSeries) RetrieveEarliestTimestamp(ctx context.Context, table string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (m *MemoryTimeSeries) ExecBackfill(ctx context.Context, table, query string, from, to time.Time) error {
+	return nil
+}
+
+func memoryDataIDs(values map[int32]struct{}, afterID int32, limit int) []int32 {
+	result := make([]int32, 0, len(values))
+	for id := range values {
+		if id > afterID {
+			result = append(result, id)
+		}
+	}
+
+	slices.Sort(result)
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+func (m *MemoryTimeSeries) RetrieveDataPropertyIDs(ctx context.Context, afterID int32, limit int) ([]int32, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make(map[int32]struct{})
+	for _, log := range m.accessLogs {
+		ids[log.PropertyID] = struct{}{}
+	}
+	for _, log := range m.verifyLogs {
+		ids[log.PropertyID] = struct{}{}
+	}
+
+	return memoryDataIDs(ids, afterID, limit), nil
+}
+
+func (m *MemoryTimeSeries) RetrieveDataOrgIDs(ctx context.Context, afterID int32, limit int) ([]int32, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make(map[int32]struct{})
+	for _, log := range m.accessLogs {
+		ids[log.OrgID] = struct{}{}
+	}
+	for _, log := range m.verifyLogs {
+		ids[log.OrgID] = struct{}{}
+	}
+
+	return memoryDataIDs(ids, afterID, limit), nil
+}
+
+// PendingDeletesCount is always zero as memory storage deletes synchronously
+func (m *MemoryTimeSeries) PendingDeletesCount(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
 func (m *MemoryTimeSeries) DeletePropertiesData(ctx context.Context, propertyIDs []int32) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -685,6 +2082,14 @@ func (m *MemoryTimeSeries) DeletePropertiesData(ctx context.Context, propertyIDs
 	}
 	m.verifyLogs = newVerify
 
+	newQuota := m.quotaLogs[:0]
+	for _, log := range m.quotaLogs {
+		if _, ok := ids[log.PropertyID]; !ok {
+			newQuota = append(newQuota, log)
+		}
+	}
+	m.quotaLogs = newQuota
+
 	return nil
 }
 
@@ -713,6 +2118,22 @@ func (m *MemoryTimeSeries) DeleteOrganizationsData(ctx context.Context, orgIDs [
 	}
 	m.verifyLogs = newVerify
 
+	newQuota := m.quotaLogs[:0]
+	for _, log := range m.quotaLogs {
+		if _, ok := ids[log.OrgID]; !ok {
+			newQuota = append(newQuota, log)
+		}
+	}
+	m.quotaLogs = newQuota
+
+	newReceipts := m.receipts[:0]
+	for _, r := range m.receipts {
+		if _, ok := ids[r.OrgID]; !ok {
+			newReceipts = append(newReceipts, r)
+		}
+	}
+	m.receipts = newReceipts
+
 	return nil
 }
 
@@ -741,9 +2162,54 @@ func (m *MemoryTimeSeries) DeleteUsersData(ctx context.Context, userIDs []int32)
 	}
 	m.verifyLogs = newVerify
 
+	newQuota := m.quotaLogs[:0]
+	for _, log := range m.quotaLogs {
+		if _, ok := ids[log.UserID]; !ok {
+			newQuota = append(newQuota, log)
+		}
+	}
+	m.quotaLogs = newQuota
+
+	newReceipts := m.receipts[:0]
+	for _, r := range m.receipts {
+		if _, ok := ids[r.UserID]; !ok {
+			newReceipts = append(newReceipts, r)
+		}
+	}
+	m.receipts = newReceipts
+
 	return nil
 }
 
+// periodTruncateFunc defines truncation function based on period
+func periodTruncateFunc(period common.TimePeriod) func(time.Time) time.Time {
+	switch period {
+	case common.TimePeriodToday:
+		// 1h
+		return func(t time.Time) time.Time { return t.Truncate(time.Hour) }
+	case common.TimePeriodWeek:
+		// Real DB uses request_logs_1d, so effectively daily resolution
+		return func(t time.Time) time.Time {
+			y, m, d := t.Date()
+			return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		}
+	case common.TimePeriodMonth:
+		// 1d
+		return func(t time.Time) time.Time {
+			y, m, d := t.Date()
+			return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		}
+	case common.TimePeriodYear:
+		// 1mo
+		return func(t time.Time) time.Time {
+			y, m, _ := t.Date()
+			return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+		}
+	default:
+		return func(t time.Time) time.Time { return t.Truncate(time.Hour) }
+	}
+}
+
 func mapToTimeCount(m map[time.Time]uint32) []*common.TimeCount {
 	res := make([]*common.TimeCount, 0, len(m))
 	for ts, count := range m {
@@ -91,7 +91,7 @@ func TestMemoryTimeSeriesRetrieveAccountStats(t *testing.T) {
 	}
 	ts.WriteAccessLogBatch(ctx, records)
 
-	accountStats, err := ts.RetrieveAccountStats(ctx, 1, fixedTime.Add(-24*time.Hour))
+	accountStats, err := ts.RetrieveAccountStats(ctx, 1, fixedTime.Add(-24*time.Hour), nil /*exclude orgs*/)
 	if err != nil {
 		t.Error(err)
 	}
@@ -107,6 +107,18 @@ func TestMemoryTimeSeriesRetrieveAccountStats(t *testing.T) {
 			t.Errorf("RetrieveAccountStats() timestamp = %v, want %v", accountStats[0].Timestamp, expectedTs)
 		}
 	}
+
+	// traffic of the excluded (sandbox) org is not counted
+	ts.WriteAccessLogBatch(ctx, []*common.AccessRecord{{UserID: 1, OrgID: 5, Timestamp: fixedTime}})
+
+	accountStats, err = ts.RetrieveAccountStats(ctx, 1, fixedTime.Add(-24*time.Hour), []int32{5})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if (len(accountStats) != 1) || (accountStats[0].Count != 2) {
+		t.Errorf("RetrieveAccountStats() did not exclude org: %v", len(accountStats))
+	}
 }
 
 func TestMemoryTimeSeriesVerifyLogsAndStatsByPeriod(t *testing.T) {
@@ -146,6 +158,71 @@ func TestMemoryTimeSeriesVerifyLogsAndStatsByPeriod(t *testing.T) {
 	}
 }
 
+func TestMemoryTimeSeriesVerifyLatencyByPeriod(t *testing.T) {
+	ts := NewMemoryTimeSeries()
+	ctx := context.Background()
+
+	// middle of the hour so that all records end up in the same bucket
+	now := time.Now().UTC().Truncate(time.Hour).Add(-30 * time.Minute)
+
+	verifyRecords := make([]*common.VerifyRecord, 0, 101)
+	for i := 1; i <= 100; i++ {
+		verifyRecords = append(verifyRecords, &common.VerifyRecord{OrgID: 1, PropertyID: 1, Timestamp: now, DurationUs: uint32(i * 1000)})
+	}
+	// not measured
+	verifyRecords = append(verifyRecords, &common.VerifyRecord{OrgID: 1, PropertyID: 1, Timestamp: now})
+	ts.WriteVerifyLogBatch(ctx, verifyRecords)
+
+	stats, err := ts.RetrievePropertyVerifyLatencyByPeriod(ctx, 1, 1, common.TimePeriodToday)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 1 {
+		t.Fatalf("RetrievePropertyVerifyLatencyByPeriod(Today) got %d stats, want 1", len(stats))
+	}
+
+	if st := stats[0]; (st.P50 != 50) || (st.P90 != 90) || (st.P99 != 99) {
+		t.Errorf("Unexpected latency percentiles: p50=%v p90=%v p99=%v", st.P50, st.P90, st.P99)
+	}
+
+	if stats, _ := ts.RetrievePropertyVerifyLatencyByPeriod(ctx, 1, 2, common.TimePeriodToday); len(stats) != 0 {
+		t.Errorf("Unexpected latency stats for another property: %d", len(stats))
+	}
+}
+
+func TestMemoryTimeSeriesVerifyHealth(t *testing.T) {
+	ts := NewMemoryTimeSeries()
+	ctx := context.Background()
+
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+
+	ts.WriteVerifyLogBatch(ctx, []*common.VerifyRecord{
+		{OrgID: 1, PropertyID: 1, Timestamp: hour.Add(10 * time.Minute), DurationUs: 1000},
+		{OrgID: 2, PropertyID: 2, Timestamp: hour.Add(20 * time.Minute), DurationUs: 3000, Status: 1},
+		{OrgID: 1, PropertyID: 1, Timestamp: hour.Add(70 * time.Minute), DurationUs: 2000},
+		// outside of the period
+		{OrgID: 1, PropertyID: 1, Timestamp: hour.Add(-10 * time.Minute)},
+	})
+
+	stats, err := ts.RetrieveVerifyHealth(ctx, hour, hour.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("RetrieveVerifyHealth() got %d stats, want 2", len(stats))
+	}
+
+	if st := stats[0]; !st.Timestamp.Equal(hour) || (st.SuccessCount != 1) || (st.FailureCount != 1) || (st.ErrorRate() != 0.5) || (st.P99 != 3) {
+		t.Errorf("Unexpected first hour stats: %+v", st)
+	}
+
+	if st := stats[1]; (st.SuccessCount != 1) || (st.FailureCount != 0) || (st.P50 != 2) {
+		t.Errorf("Unexpected second hour stats: %+v", st)
+	}
+}
+
 func TestMemoryTimeSeriesRecentTopProperties(t *testing.T) {
 	ts := NewMemoryTimeSeries()
 	ctx := context.Background()
@@ -180,6 +257,135 @@ func TestMemoryTimeSeriesRecentTopProperties(t *testing.T) {
 	}
 }
 
+func TestMemoryTimeSeriesExperimentStats(t *testing.T) {
+	ts := NewMemoryTimeSeries()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	access := []*common.AccessRecord{
+		{OrgID: 10, PropertyID: 1, Timestamp: now, ExperimentArm: common.ExperimentArmControl},
+		{OrgID: 10, PropertyID: 1, Timestamp: now, ExperimentArm: common.ExperimentArmControl},
+		{OrgID: 10, PropertyID: 1, Timestamp: now, ExperimentArm: common.ExperimentArmVariant},
+		{OrgID: 10, PropertyID: 1, Timestamp: now}, // Not in experiment
+		{OrgID: 10, PropertyID: 2, Timestamp: now, ExperimentArm: common.ExperimentArmVariant},
+	}
+	ts.WriteAccessLogBatch(ctx, access)
+
+	verify := []*common.VerifyRecord{
+		{OrgID: 10, PropertyID: 1, Timestamp: now, Status: 0, ExperimentArm: common.ExperimentArmControl},
+		{OrgID: 10, PropertyID: 1, Timestamp: now, Status: 1, ExperimentArm: common.ExperimentArmVariant},
+	}
+	ts.WriteVerifyLogBatch(ctx, verify)
+
+	stats, err := ts.RetrieveExperimentStats(ctx, 10, 1, now.Add(-time.Hour), now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("RetrieveExperimentStats() got %d arms, want 2", len(stats))
+	}
+
+	if control := stats[0]; (control.Arm != common.ExperimentArmControl) || (control.RequestsCount != 2) || (control.SuccessCount != 1) {
+		t.Errorf("Unexpected control stats: %+v", control)
+	}
+
+	if variant := stats[1]; (variant.RequestsCount != 1) || (variant.FailureCount != 1) || (variant.FailureRate() != 1.0) {
+		t.Errorf("Unexpected variant stats: %+v", variant)
+	}
+}
+
+func TestMemoryTimeSeriesVisitorStats(t *testing.T) {
+	ts := NewMemoryTimeSeries()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	access := []*common.AccessRecord{
+		{OrgID: 10, PropertyID: 1, Timestamp: now, VisitorClass: common.VisitorClassReturning},
+		{OrgID: 10, PropertyID: 1, Timestamp: now, VisitorClass: common.VisitorClassFirstSeen},
+		{OrgID: 10, PropertyID: 1, Timestamp: now, VisitorClass: common.VisitorClassFirstSeen},
+		{OrgID: 10, PropertyID: 1, Timestamp: now}, // Differential difficulty is off
+	}
+	ts.WriteAccessLogBatch(ctx, access)
+
+	verify := []*common.VerifyRecord{
+		{OrgID: 10, PropertyID: 1, Timestamp: now, Status: 0, VisitorClass: common.VisitorClassReturning},
+		{OrgID: 10, PropertyID: 1, Timestamp: now, Status: 0, VisitorClass: common.VisitorClassFirstSeen},
+		{OrgID: 10, PropertyID: 1, Timestamp: now, Status: 1, VisitorClass: common.VisitorClassFirstSeen},
+	}
+	ts.WriteVerifyLogBatch(ctx, verify)
+
+	stats, err := ts.RetrieveVisitorStats(ctx, 10, 1, now.Add(-time.Hour), now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("RetrieveVisitorStats() got %d classes, want 2", len(stats))
+	}
+
+	if returning := stats[0]; (returning.Class != common.VisitorClassReturning) || (returning.SolveRate() != 1.0) {
+		t.Errorf("Unexpected returning visitors stats: %+v", returning)
+	}
+
+	if firstSeen := stats[1]; (firstSeen.RequestsCount != 2) || (firstSeen.FailureCount != 1) || (firstSeen.SolveRate() != 0.5) {
+		t.Errorf("Unexpected first-seen visitors stats: %+v", firstSeen)
+	}
+}
+
+func TestMemoryTimeSeriesIssuanceAudit(t *testing.T) {
+	ts := NewMemoryTimeSeries()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	const recorded = 3 * common.IssuanceReceiptSampleRate
+	access := make([]*common.AccessRecord, 0, recorded)
+	for i := 0; i < recorded; i++ {
+		access = append(access, &common.AccessRecord{UserID: 1, OrgID: 10, PropertyID: 1, Timestamp: now})
+	}
+	access = append(access, &common.AccessRecord{UserID: 2, OrgID: 20, PropertyID: 2, Timestamp: now})
+	ts.WriteAccessLogBatch(ctx, access)
+
+	ts.WriteIssuanceReceiptBatch(ctx, []*common.IssuanceReceipt{
+		{PuzzleID: 1000, UserID: 1, OrgID: 10, PropertyID: 1, Timestamp: now.Add(-time.Minute)},
+		{PuzzleID: 2000, UserID: 1, OrgID: 10, PropertyID: 1, Timestamp: now},
+		{PuzzleID: 3000, UserID: 1, OrgID: 10, PropertyID: 1, Timestamp: now.Add(-48 * time.Hour)}, // out of range
+		{PuzzleID: 4000, UserID: 2, OrgID: 20, PropertyID: 2, Timestamp: now},
+	})
+
+	from, to := now.Add(-time.Hour), now.Add(time.Minute)
+
+	receipts, err := ts.RetrieveIssuanceReceipts(ctx, 1, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(receipts) != 2) || (receipts[0].PuzzleID != 1000) {
+		t.Errorf("Unexpected receipts: %v", receipts)
+	}
+
+	stats, err := ts.RetrieveIssuanceAudit(ctx, 1, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 1 {
+		t.Fatalf("RetrieveIssuanceAudit() got %d orgs, want 1", len(stats))
+	}
+
+	if s := stats[0]; (s.OrgID != 10) || (s.Receipts != 2) || (s.Recorded != recorded) || !s.Consistent() {
+		t.Errorf("Unexpected audit stats: %+v", s)
+	}
+
+	if err := ts.DeleteUsersData(ctx, []int32{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if receipts, _ := ts.RetrieveIssuanceReceipts(ctx, 1, from, to); len(receipts) != 0 {
+		t.Errorf("Receipts were not deleted with user data")
+	}
+}
+
 func TestMemoryTimeSeriesDeletePropertiesData(t *testing.T) {
 	ts := NewMemoryTimeSeries()
 	ctx := context.Background()
@@ -226,7 +432,7 @@ func TestMemoryTimeSeriesDeleteAccountData(t *testing.T) {
 		t.Error(err)
 	}
 	// Check user 2 (Org 20)
-	stats2, _ := ts.RetrieveAccountStats(ctx, 2, time.Time{})
+	stats2, _ := ts.RetrieveAccountStats(ctx, 2, time.Time{}, nil /*exclude orgs*/)
 	if len(stats2) != 0 {
 		t.Errorf("After DeleteOrganizationsData, stats count = %d, want 0", len(stats2))
 	}
@@ -236,8 +442,40 @@ func TestMemoryTimeSeriesDeleteAccountData(t *testing.T) {
 		t.Errorf("DeleteUsersData error = %v", err)
 	}
 	// Check user 3
-	stats3, _ := ts.RetrieveAccountStats(ctx, 3, time.Time{})
+	stats3, _ := ts.RetrieveAccountStats(ctx, 3, time.Time{}, nil /*exclude orgs*/)
 	if len(stats3) != 0 {
 		t.Errorf("After DeleteUsersData, stats count = %d, want 0", len(stats3))
 	}
 }
+
+func TestRetrievePropertyStatsCachedInMaintenance(t *testing.T) {
+	cache, err := NewMemoryCache[CacheKey, any]("test", 1000, &struct{}{}, time.Minute, time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := NewTimeSeries(nil /*clickhouse*/, cache)
+	ts.UpdateConfig(true /*maintenance mode*/)
+
+	ctx := context.Background()
+	const propertyID = 123
+
+	if _, err := ts.RetrievePropertyStatsByPeriod(ctx, 1, propertyID, common.TimePeriodWeek); err != ErrMaintenance {
+		t.Errorf("Expected maintenance error for uncached period, got %v", err)
+	}
+
+	timeFrom := time.Now().UTC().AddDate(0, 0, -1).Truncate(1 * time.Hour)
+	expected := []*common.TimePeriodStat{{Timestamp: timeFrom, RequestsCount: 10, VerifiesCount: 5}}
+	if err := cache.Set(ctx, propertyStatsCacheKey(propertyID, timeFrom.Format(time.DateTime)), expected); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := ts.RetrievePropertyStatsByPeriod(ctx, 1, propertyID, common.TimePeriodToday)
+	if err != nil {
+		t.Fatalf("Expected cached stats in maintenance mode, got error: %v", err)
+	}
+
+	if len(stats) != 1 || stats[0].RequestsCount != 10 {
+		t.Errorf("Unexpected cached stats: %v", stats)
+	}
+}
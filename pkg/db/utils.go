@@ -2,11 +2,15 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"log/slog"
+	"net/url"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
+	"unicode"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
@@ -20,6 +24,18 @@ const (
 	APIKeyPrefix       = "pc_"
 	SecretLen          = len(APIKeyPrefix) + SitekeyLen
 	sessionCachePrefix = "session/"
+	// marks destroyed sessions so that other nodes do not keep or re-persist them
+	sessionRevokedPrefix = "session_revoked/"
+	// (runes) of the message shown to end users when property hard-fails
+	MaxFailureMessageLength = 200
+	maxFailureURLLength     = 2048
+	// bounds of property emergency mode (activated during an active attack)
+	MinEmergencyDuration     = 5 * time.Minute
+	DefaultEmergencyDuration = 1 * time.Hour
+	MaxEmergencyDuration     = 24 * time.Hour
+	// per list of property IP and ASN access lists
+	MaxAccessListEntries = 50
+	maxASN               = 4294967295
 )
 
 var (
@@ -139,6 +155,29 @@ func UUIDToString(uuid pgtype.UUID) string {
 	return hex.EncodeToString(uuid.Bytes[:])
 }
 
+// NotificationPayloadHash is the content address of notification payload, shared by notifications with the same data
+func NotificationPayloadHash(payload []byte) string {
+	hash := sha256.Sum256(payload)
+	return hex.EncodeToString(hash[:])
+}
+
+// missingIDs returns ids that are not in existing (preserving the order)
+func missingIDs(ids []int32, existing []int32) []int32 {
+	found := make(map[int32]struct{}, len(existing))
+	for _, id := range existing {
+		found[id] = struct{}{}
+	}
+
+	result := make([]int32, 0)
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			result = append(result, id)
+		}
+	}
+
+	return result
+}
+
 func UUIDFromSecret(s string) pgtype.UUID {
 	if !strings.HasPrefix(s, APIKeyPrefix) {
 		return invalidUUID
@@ -262,6 +301,10 @@ func sessionIDFunc(sid string) (string, error) {
 	return sessionCachePrefix + sid, nil
 }
 
+func sessionRevokedKey(sid string) string {
+	return sessionRevokedPrefix + sid
+}
+
 func IdentityKeyFunc[TKey any](key TKey) (TKey, error) {
 	return key, nil
 }
@@ -526,3 +569,120 @@ func (br *StoreBulkReader[TArg, TKey, T]) Read(ctx context.Context, args map[TAr
 
 	return cached, items, nil
 }
+
+// CheckAPIKeyNameValid checks that API key name contains only letters, digits, spaces and some punctuation
+func CheckAPIKeyNameValid(ctx context.Context, name string) bool {
+	const allowedPunctuation = "-_.()[]"
+
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r):
+			continue
+		case unicode.IsDigit(r):
+			continue
+		case unicode.IsSpace(r):
+			continue
+		case strings.ContainsRune(allowedPunctuation, r):
+			continue
+		default:
+			slog.WarnContext(ctx, "Name contains invalid characters", "position", i, "rune", r)
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseNotificationSeverity treats empty value as default (info) severity
+func ParseNotificationSeverity(value string) (dbgen.NotificationSeverity, bool) {
+	switch severity := dbgen.NotificationSeverity(value); severity {
+	case "":
+		return dbgen.NotificationSeverityInfo, true
+	case dbgen.NotificationSeverityInfo, dbgen.NotificationSeverityWarning, dbgen.NotificationSeverityCritical:
+		return severity, true
+	default:
+		return "", false
+	}
+}
+
+// CheckFailureURLValid accepts empty value (no redirect) or absolute http(s) URL that end users can be sent to
+func CheckFailureURLValid(ctx context.Context, value string) bool {
+	if len(value) == 0 {
+		return true
+	}
+
+	if len(value) > maxFailureURLLength {
+		slog.WarnContext(ctx, "Failure URL is too long", "length", len(value))
+		return false
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse failure URL", common.ErrAttr(err))
+		return false
+	}
+
+	return ((u.Scheme == "https") || (u.Scheme == "http")) && (len(u.Host) > 0) && (u.User == nil)
+}
+
+func NormalizeFailureMessage(message string) string {
+	message = strings.TrimSpace(message)
+	if runes := []rune(message); len(runes) > MaxFailureMessageLength {
+		message = string(runes[:MaxFailureMessageLength])
+	}
+
+	return message
+}
+
+// IsPropertyEmergency checks if emergency mode of the property is active. It reverts automatically once expired.
+func IsPropertyEmergency(property *dbgen.Property, tnow time.Time) bool {
+	return (property != nil) && property.EmergencyUntil.Valid && tnow.Before(property.EmergencyUntil.Time)
+}
+
+func NormalizeEmergencyDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultEmergencyDuration
+	}
+
+	return max(MinEmergencyDuration, min(MaxEmergencyDuration, d))
+}
+
+// NormalizeIPList returns unique IP ranges in canonical form (single addresses become full-length prefixes)
+func NormalizeIPList(entries []string) ([]string, error) {
+	prefixes, err := common.ParseIPAllowlist(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		if normalized := p.String(); !slices.Contains(result, normalized) {
+			result = append(result, normalized)
+		}
+	}
+
+	if len(result) > MaxAccessListEntries {
+		return nil, ErrInvalidInput
+	}
+
+	return result, nil
+}
+
+func NormalizeASNList(entries []int64) ([]int64, error) {
+	result := make([]int64, 0, len(entries))
+	for _, asn := range entries {
+		if (asn <= 0) || (asn > maxASN) {
+			return nil, ErrInvalidInput
+		}
+
+		if !slices.Contains(result, asn) {
+			result = append(result, asn)
+		}
+	}
+
+	if len(result) > MaxAccessListEntries {
+		return nil, ErrInvalidInput
+	}
+
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	config_pkg "github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+const (
+	day = 24 * time.Hour
+	// these match TTLs from the original ClickHouse migrations
+	defaultVerifyRawRetentionDays    = 30
+	defaultVerifyHourlyRetentionDays = 1
+	defaultVerifyDailyRetentionDays  = 365
+)
+
+// VerifyRetention defines for how long raw verify records (traces) and hourly and daily rollups are kept
+type VerifyRetention struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+func DefaultVerifyRetention() VerifyRetention {
+	return VerifyRetention{
+		Raw:    defaultVerifyRawRetentionDays * day,
+		Hourly: defaultVerifyHourlyRetentionDays * day,
+		Daily:  defaultVerifyDailyRetentionDays * day,
+	}
+}
+
+func NewVerifyRetention(cfg common.ConfigStore) *VerifyRetention {
+	r := &VerifyRetention{
+		Raw:    time.Duration(config_pkg.AsInt(cfg.Get(common.VerifyRawRetentionDaysKey), defaultVerifyRawRetentionDays)) * day,
+		Hourly: time.Duration(config_pkg.AsInt(cfg.Get(common.VerifyHourlyRetentionDaysKey), defaultVerifyHourlyRetentionDays)) * day,
+		Daily:  time.Duration(config_pkg.AsInt(cfg.Get(common.VerifyDailyRetentionDaysKey), defaultVerifyDailyRetentionDays)) * day,
+	}
+
+	r.normalize()
+
+	return r
+}
+
+// normalize makes sure that every level is kept at least for a day and rollups are not dropped before the data
+// they were aggregated from
+func (r *VerifyRetention) normalize() {
+	r.Raw = max(r.Raw, day)
+	r.Hourly = max(r.Hourly, day)
+	r.Daily = max(r.Daily, r.Hourly, r.Raw)
+}
+
+// statsTables prefers hourly rollups for sub-day chart steps while they still have data for the whole span (the
+// oldest step is allowed to be partial) and switches to daily rollups otherwise as they are kept the longest.
+// Requests and verifies are always of the same granularity as they are joined by time
+func (r *VerifyRetention) statsTables(span, step time.Duration) (string, string) {
+	if (step < day) && (span <= r.Hourly+step) {
+		return "request_logs_1h", "verify_logs_1h"
+	}
+
+	return "request_logs_1d", "verify_logs_1d"
+}
+
+// ApplyVerifyRetention updates TTLs of ClickHouse tables, so it requires admin connection (as migrations)
+func ApplyVerifyRetention(ctx context.Context, conn *sql.DB, r *VerifyRetention) error {
+	for _, t := range []struct {
+		table     string
+		retention time.Duration
+	}{
+		{VerifyTracesTable, r.Raw},
+		{AccessLogTableName1h, r.Hourly},
+		{VerifyLogTable1h, r.Hourly},
+		{VerifyLogTable1d, r.Daily},
+	} {
+		days := int(t.retention / day)
+
+		// modifying TTL rewrites table parts so we only do it when it actually changes
+		var engine string
+		database, name, _ := strings.Cut(t.table, ".")
+		if err := conn.QueryRowContext(ctx, "SELECT engine_full FROM system.tables WHERE database = {database:String} AND name = {name:String}",
+			clickhouse.Named("database", database), clickhouse.Named("name", name)).Scan(&engine); err != nil {
+			slog.ErrorContext(ctx, "Failed to read table engine", "table", t.table, common.ErrAttr(err))
+			return err
+		}
+
+		if strings.Contains(engine, fmt.Sprintf("toIntervalDay(%d)", days)) {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s MODIFY TTL timestamp + INTERVAL %d DAY", t.table, days)
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			slog.ErrorContext(ctx, "Failed to update table TTL", "table", t.table, "days", days, common.ErrAttr(err))
+			return err
+		}
+
+		slog.InfoContext(ctx, "Updated table TTL", "table", t.table, "days", days)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyRetentionNormalize(t *testing.T) {
+	t.Parallel()
+
+	r := &VerifyRetention{Raw: 90 * day, Hourly: 0, Daily: 30 * day}
+	r.normalize()
+
+	if r.Hourly != day {
+		t.Errorf("Unexpected hourly retention: %v", r.Hourly)
+	}
+
+	if r.Daily != r.Raw {
+		t.Errorf("Daily rollups are kept less than raw records: %v", r.Daily)
+	}
+}
+
+func TestVerifyRetentionStatsTables(t *testing.T) {
+	t.Parallel()
+
+	defaults := DefaultVerifyRetention()
+	extended := &VerifyRetention{Raw: 7 * day, Hourly: 30 * day, Daily: 3 * 365 * day}
+
+	for i, tc := range []struct {
+		retention *VerifyRetention
+		span      time.Duration
+		step      time.Duration
+		expected  string
+	}{
+		{&defaults, day + 30*time.Minute, time.Hour, "verify_logs_1h"},
+		{&defaults, 7*day + time.Hour, 6 * time.Hour, "verify_logs_1d"},
+		{&defaults, 30 * day, day, "verify_logs_1d"},
+		{extended, 7*day + time.Hour, 6 * time.Hour, "verify_logs_1h"},
+		{extended, 30 * day, day, "verify_logs_1d"},
+		{extended, 365 * day, 30 * day, "verify_logs_1d"},
+	} {
+		requests, verifies := tc.retention.statsTables(tc.span, tc.step)
+		if verifies != tc.expected {
+			t.Errorf("Unexpected verifies table for test case %v: %v", i, verifies)
+		}
+
+		if requests != "request_logs"+verifies[len("verify_logs"):] {
+			t.Errorf("Requests and verifies tables do not match for test case %v: %v", i, requests)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package difficulty
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	ExperimentOutcomeControl      = "control"
+	ExperimentOutcomeVariant      = "variant"
+	ExperimentOutcomeInconclusive = "inconclusive"
+	ExperimentOutcomeStopped      = "stopped"
+)
+
+// Experiment is a running difficulty A/B test of a single property
+type Experiment struct {
+	ID            int32
+	PropertyID    int32
+	ControlLevel  uint8
+	VariantLevel  uint8
+	VariantWeight int
+}
+
+// Level returns difficulty level that the puzzle in a given arm should be issued with
+func (e *Experiment) Level(arm common.ExperimentArm) uint8 {
+	if arm == common.ExperimentArmVariant {
+		return e.VariantLevel
+	}
+
+	return e.ControlLevel
+}
+
+// Arm deterministically assigns puzzle to one of the experiment arms so that the same
+// assignment can be reproduced during verification without storing it anywhere
+func (e *Experiment) Arm(puzzleID uint64) common.ExperimentArm {
+	if puzzleID == 0 {
+		return common.ExperimentArmNone
+	}
+
+	var buf [12]byte
+	binary.LittleEndian.PutUint64(buf[:], puzzleID)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(e.ID))
+
+	hash := fnv.New64a()
+	_, _ = hash.Write(buf[:])
+
+	if int(hash.Sum64()%100) < e.VariantWeight {
+		return common.ExperimentArmVariant
+	}
+
+	return common.ExperimentArmControl
+}
+
+// Experiments is a lock-free registry of running experiments, keyed by property ID
+type Experiments struct {
+	items atomic.Pointer[map[int32]*Experiment]
+}
+
+func NewExperiments() *Experiments {
+	e := &Experiments{}
+	e.items.Store(&map[int32]*Experiment{})
+	return e
+}
+
+func (e *Experiments) Update(experiments []*dbgen.DifficultyExperiment) {
+	items := make(map[int32]*Experiment, len(experiments))
+
+	for _, de := range experiments {
+		if de.ConcludedAt.Valid {
+			continue
+		}
+
+		items[de.PropertyID] = &Experiment{
+			ID:            de.ID,
+			PropertyID:    de.PropertyID,
+			ControlLevel:  uint8(de.ControlLevel),
+			VariantLevel:  uint8(de.VariantLevel),
+			VariantWeight: int(de.VariantWeight),
+		}
+	}
+
+	e.items.Store(&items)
+}
+
+func (e *Experiments) Get(propertyID int32) (*Experiment, bool) {
+	items := *e.items.Load()
+	experiment, ok := items[propertyID]
+	return experiment, ok
+}
+
+func (e *Experiments) Count() int {
+	return len(*e.items.Load())
+}
+
+// ConcludeExperiment applies conclusion rules to the stats of the experiment. The experiment keeps running
+// until both arms have enough samples or until it reaches its end time. Variant "loses" if either abandonment
+// or failure rate is worse than control by more than allowed degradation (in percentage points).
+func ConcludeExperiment(e *dbgen.DifficultyExperiment, stats []*common.ExperimentArmStats, tnow time.Time) (string, string, bool) {
+	control := &common.ExperimentArmStats{Arm: common.ExperimentArmControl}
+	variant := &common.ExperimentArmStats{Arm: common.ExperimentArmVariant}
+
+	for _, s := range stats {
+		switch s.Arm {
+		case common.ExperimentArmControl:
+			control = s
+		case common.ExperimentArmVariant:
+			variant = s
+		}
+	}
+
+	minSamples := int(e.MinSamples)
+	enoughSamples := (control.RequestsCount >= minSamples) && (variant.RequestsCount >= minSamples)
+	ended := e.EndsAt.Valid && !tnow.Before(e.EndsAt.Time)
+
+	if !enoughSamples {
+		if ended {
+			return ExperimentOutcomeInconclusive, fmt.Sprintf("not enough samples before end: control %d, variant %d (required %d)",
+				control.RequestsCount, variant.RequestsCount, minSamples), true
+		}
+
+		return "", "", false
+	}
+
+	maxDegradation := float64(e.MaxDegradation)
+
+	if delta := 100.0 * (variant.AbandonmentRate() - control.AbandonmentRate()); delta > maxDegradation {
+		return ExperimentOutcomeControl, fmt.Sprintf("variant abandonment rate is higher by %.2f pp (allowed %.2f pp)", delta, maxDegradation), true
+	}
+
+	if delta := 100.0 * (variant.FailureRate() - control.FailureRate()); delta > maxDegradation {
+		return ExperimentOutcomeControl, fmt.Sprintf("variant failure rate is higher by %.2f pp (allowed %.2f pp)", delta, maxDegradation), true
+	}
+
+	return ExperimentOutcomeVariant, fmt.Sprintf("variant is within %.2f pp of control after %d samples", maxDegradation, variant.RequestsCount), true
+}
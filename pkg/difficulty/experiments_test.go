@@ -0,0 +1,81 @@
+package difficulty
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestExperimentArmWeight(t *testing.T) {
+	e := &Experiment{ID: 1, PropertyID: 1, ControlLevel: 80, VariantLevel: 110, VariantWeight: 10}
+
+	const total = 100_000
+	variants := 0
+	for i := 1; i <= total; i++ {
+		arm := e.Arm(uint64(i) * 7919)
+		if arm == common.ExperimentArmVariant {
+			variants++
+		}
+
+		if arm != e.Arm(uint64(i)*7919) {
+			t.Fatalf("Arm assignment is not deterministic for puzzle %v", i)
+		}
+	}
+
+	if share := float64(variants) / total; math.Abs(share-0.1) > 0.01 {
+		t.Errorf("Unexpected variant share: %v", share)
+	}
+
+	if arm := e.Arm(0); arm != common.ExperimentArmNone {
+		t.Errorf("Stub puzzle was assigned to arm %v", arm)
+	}
+}
+
+func TestConcludeExperiment(t *testing.T) {
+	tnow := time.Now().UTC()
+
+	stats := func(requests, successes, failures int) []*common.ExperimentArmStats {
+		return []*common.ExperimentArmStats{
+			{Arm: common.ExperimentArmControl, RequestsCount: 1000, SuccessCount: 900, FailureCount: 10},
+			{Arm: common.ExperimentArmVariant, RequestsCount: requests, SuccessCount: successes, FailureCount: failures},
+		}
+	}
+
+	testCases := []struct {
+		stats   []*common.ExperimentArmStats
+		endsAt  time.Time
+		done    bool
+		outcome string
+	}{
+		{stats(50, 45, 1), tnow.Add(time.Hour), false, ""},
+		{stats(50, 45, 1), tnow.Add(-time.Hour), true, ExperimentOutcomeInconclusive},
+		{stats(1000, 890, 12), tnow.Add(time.Hour), true, ExperimentOutcomeVariant},
+		{stats(1000, 800, 10), tnow.Add(time.Hour), true, ExperimentOutcomeControl},
+		{stats(1000, 850, 60), tnow.Add(time.Hour), true, ExperimentOutcomeControl},
+		{nil, tnow.Add(-time.Hour), true, ExperimentOutcomeInconclusive},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("conclude_%v", i), func(t *testing.T) {
+			e := &dbgen.DifficultyExperiment{
+				MinSamples:     100,
+				MaxDegradation: 2.0,
+				EndsAt:         pgtype.Timestamptz{Time: tc.endsAt, Valid: true},
+			}
+
+			outcome, reason, done := ConcludeExperiment(e, tc.stats, tnow)
+			if done != tc.done {
+				t.Fatalf("Unexpected conclusion state: %v (%v)", done, reason)
+			}
+
+			if outcome != tc.outcome {
+				t.Errorf("Unexpected outcome: %v (expected %v): %v", outcome, tc.outcome, reason)
+			}
+		})
+	}
+}
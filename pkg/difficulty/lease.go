@@ -0,0 +1,106 @@
+package difficulty
+
+import (
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/maypok86/otter/v2"
+)
+
+const (
+	// for how long precomputed difficulty of the property is reused (and advertised to the widget in the puzzle)
+	DifficultyLeaseTTL = 1 * time.Minute
+)
+
+type leaseKey struct {
+	propertyID     int32
+	baseDifficulty uint8
+}
+
+// difficultyLease is the cached difficulty decision together with property settings it was made for
+type difficultyLease struct {
+	updatedAt  time.Time
+	level      int16
+	growth     dbgen.DifficultyGrowth
+	difficulty uint8
+	until      time.Time
+}
+
+func (l *difficultyLease) isValid(p *dbgen.Property, tnow time.Time) bool {
+	// any change of property settings bumps updated_at so the lease is invalidated as soon as the new
+	// version of the property is cached (level and growth are compared just in case)
+	return tnow.Before(l.until) &&
+		l.updatedAt.Equal(p.UpdatedAt.Time) &&
+		(l.level == p.Level.Int16) &&
+		(l.growth == p.Growth)
+}
+
+// IsLeaseable returns true for properties which difficulty does not depend on traffic, so it can be
+// decided once and reused without going through leaky buckets. Staging properties are excluded as
+// their traffic cap relies on the property bucket.
+func IsLeaseable(p *dbgen.Property) bool {
+	return (p != nil) &&
+		(p.Growth == dbgen.DifficultyGrowthConstant) &&
+		(len(p.DifficultyStrategy) == 0) &&
+		(p.Environment != dbgen.PropertyEnvironmentStaging)
+}
+
+type leases struct {
+	store *otter.Cache[leaseKey, *difficultyLease]
+}
+
+func newLeases(maxSize int) *leases {
+	return &leases{
+		store: otter.Must(&otter.Options[leaseKey, *difficultyLease]{
+			MaximumSize:      maxSize,
+			InitialCapacity:  max(100, maxSize/1000),
+			ExpiryCalculator: otter.ExpiryWriting[leaseKey, *difficultyLease](DifficultyLeaseTTL),
+		}),
+	}
+}
+
+func (l *leases) get(p *dbgen.Property, baseDifficulty uint8, tnow time.Time) (*difficultyLease, bool) {
+	key := leaseKey{propertyID: p.ID, baseDifficulty: baseDifficulty}
+
+	if lease, ok := l.store.GetIfPresent(key); ok && lease.isValid(p, tnow) {
+		return lease, true
+	}
+
+	minDifficulty := uint8(max(p.Level.Int16, int16(baseDifficulty)))
+	lease := &difficultyLease{
+		updatedAt: p.UpdatedAt.Time,
+		level:     p.Level.Int16,
+		growth:    p.Growth,
+		difficulty: max(GrowthStrategy{}.Difficulty(&StrategyInput{
+			Property:      p,
+			Requests:      0,
+			MinDifficulty: minDifficulty,
+			Time:          tnow,
+		}), minDifficulty),
+		until: tnow.Add(DifficultyLeaseTTL),
+	}
+
+	l.store.Set(key, lease)
+
+	return lease, false
+}
+
+func (l *leases) len() int {
+	return l.store.EstimatedSize()
+}
+
+func (l *leases) clear() {
+	l.store.InvalidateAll()
+}
+
+// LeasedDifficulty returns difficulty of the leaseable property (see IsLeaseable) without going through leaky
+// buckets, that would not change the result anyway. Access is still recorded for stats and billing. Returned time
+// is the end of the lease, during which difficulty for the property stays the same.
+func (l *Levels) LeasedDifficulty(fingerprint common.TFingerprint, p *dbgen.Property, baseDifficulty uint8, vc common.VisitorClass, tnow time.Time) (uint8, time.Time) {
+	l.recordAccess(fingerprint, p, common.ExperimentArmNone, vc, tnow)
+
+	lease, _ := l.leases.get(p, baseDifficulty, tnow)
+
+	return lease.difficulty, lease.until
+}
@@ -0,0 +1,74 @@
+package difficulty
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestIsLeaseable(t *testing.T) {
+	testCases := []struct {
+		property *dbgen.Property
+		expected bool
+	}{
+		{&dbgen.Property{Growth: dbgen.DifficultyGrowthConstant}, true},
+		{&dbgen.Property{Growth: dbgen.DifficultyGrowthMedium}, false},
+		{&dbgen.Property{Growth: dbgen.DifficultyGrowthConstant, DifficultyStrategy: "custom"}, false},
+		{&dbgen.Property{Growth: dbgen.DifficultyGrowthConstant, Environment: dbgen.PropertyEnvironmentStaging}, false},
+		{nil, false},
+	}
+
+	for i, tc := range testCases {
+		if actual := IsLeaseable(tc.property); actual != tc.expected {
+			t.Errorf("Unexpected leaseable flag (%v) in test case %v", actual, i)
+		}
+	}
+}
+
+func TestDifficultyLeases(t *testing.T) {
+	l := newLeases(100)
+	tnow := time.Now()
+
+	p := &dbgen.Property{
+		ID:        1,
+		Level:     pgtype.Int2{Int16: int16(common.DifficultyLevelSmall), Valid: true},
+		Growth:    dbgen.DifficultyGrowthConstant,
+		UpdatedAt: pgtype.Timestamptz{Time: tnow.Add(-time.Hour), Valid: true},
+	}
+
+	lease, cached := l.get(p, 0 /*base difficulty*/, tnow)
+	if cached || (lease.difficulty != uint8(common.DifficultyLevelSmall)) {
+		t.Fatalf("Unexpected first lease: cached=%v difficulty=%v", cached, lease.difficulty)
+	}
+
+	if !lease.until.Equal(tnow.Add(DifficultyLeaseTTL)) {
+		t.Errorf("Unexpected lease end: %v", lease.until)
+	}
+
+	if _, cached = l.get(p, 0 /*base difficulty*/, tnow.Add(time.Second)); !cached {
+		t.Error("Lease was not reused")
+	}
+
+	// base difficulty override is leased separately
+	if lease, cached = l.get(p, uint8(common.DifficultyLevelHigh), tnow); cached || (lease.difficulty != uint8(common.DifficultyLevelHigh)) {
+		t.Errorf("Unexpected lease with base difficulty: cached=%v difficulty=%v", cached, lease.difficulty)
+	}
+
+	// expired lease is recomputed
+	if _, cached = l.get(p, 0 /*base difficulty*/, tnow.Add(DifficultyLeaseTTL)); cached {
+		t.Error("Expired lease was reused")
+	}
+
+	// settings change invalidates the lease
+	updated := *p
+	updated.Level = pgtype.Int2{Int16: int16(common.DifficultyLevelMedium), Valid: true}
+	updated.UpdatedAt = pgtype.Timestamptz{Time: tnow, Valid: true}
+
+	lease, cached = l.get(&updated, 0 /*base difficulty*/, tnow.Add(2*time.Second))
+	if cached || (lease.difficulty != uint8(common.DifficultyLevelMedium)) {
+		t.Errorf("Unexpected lease after settings change: cached=%v difficulty=%v", cached, lease.difficulty)
+	}
+}
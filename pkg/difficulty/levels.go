@@ -14,6 +14,13 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 )
 
+const (
+	// for how long difficulty stays pinned for the end user (counted from the first puzzle in the window)
+	StickyDifficultyWindow = 5 * time.Minute
+	// how far difficulty can move from the pinned one within the sticky window
+	StickyDifficultyDrift = common.DifficultyDelta / 3
+)
+
 var (
 	errBackfillPanic = errors.New("panic during backfill")
 )
@@ -22,13 +29,14 @@ type Levels struct {
 	timeSeries      common.TimeSeriesStore
 	propertyBuckets *leakybucket.Manager[int32, leakybucket.VarLeakyBucket[int32], *leakybucket.VarLeakyBucket[int32]]
 	userBuckets     *leakybucket.Manager[common.TFingerprint, leakybucket.ConstLeakyBucket[common.TFingerprint], *leakybucket.ConstLeakyBucket[common.TFingerprint]]
+	leases          *leases
 	accessChan      chan *common.AccessRecord
 	backfillChan    chan *common.BackfillRequest
 	batchSize       int
 	accessLogCancel context.CancelFunc
 }
 
-func NewLevels(timeSeries common.TimeSeriesStore, batchSize int, bucketSize time.Duration) *Levels {
+func NewLevels(timeSeries common.TimeSeriesStore, batchSize int, bucketSize time.Duration, footprint *common.Footprint) *Levels {
 	const (
 		propertyBucketCap = math.MaxUint32
 		// below numbers are rather arbitrary as we can support "many"
@@ -43,16 +51,26 @@ func NewLevels(timeSeries common.TimeSeriesStore, batchSize int, bucketSize time
 		userBucketSize        = time.Minute / userLeakRatePerMinute
 	)
 
+	batchSize = footprint.Size(batchSize)
+	propertyBucketsSize := footprint.Size(maxPropertyBuckets)
+	userBucketsSize := footprint.Size(maxUserBuckets)
+
 	levels := &Levels{
 		timeSeries:      timeSeries,
-		propertyBuckets: leakybucket.NewManager[int32, leakybucket.VarLeakyBucket[int32]](maxPropertyBuckets, propertyBucketCap, bucketSize),
-		userBuckets:     leakybucket.NewManager[common.TFingerprint, leakybucket.ConstLeakyBucket[common.TFingerprint]](maxUserBuckets, userBucketCap, userBucketSize),
+		propertyBuckets: leakybucket.NewManager[int32, leakybucket.VarLeakyBucket[int32]](propertyBucketsSize, propertyBucketCap, bucketSize),
+		userBuckets:     leakybucket.NewManager[common.TFingerprint, leakybucket.ConstLeakyBucket[common.TFingerprint]](userBucketsSize, userBucketCap, userBucketSize),
+		leases:          newLeases(propertyBucketsSize),
 		accessChan:      make(chan *common.AccessRecord, 10*batchSize),
 		backfillChan:    make(chan *common.BackfillRequest, batchSize),
 		batchSize:       batchSize,
 		accessLogCancel: func() {},
 	}
 
+	footprint.Track("property_buckets", propertyBucketsSize, levels.propertyBuckets.Len)
+	footprint.Track("user_buckets", userBucketsSize, levels.userBuckets.Len)
+	footprint.Track("difficulty_leases", propertyBucketsSize, levels.leases.len)
+	footprint.Track("access_log_buffer", cap(levels.accessChan), func() int { return len(levels.accessChan) })
+
 	return levels
 }
 
@@ -94,6 +112,36 @@ func requestsToDifficulty(requests float64, minDifficulty float64, level dbgen.D
 	return uint8(difficulty)
 }
 
+// VisitorDifficulty adjusts puzzle difficulty for the differential difficulty of the property: returning visitors
+// always get an easier puzzle, while first-seen visitors get a harder one when difficulty is elevated above
+// the minimum (property is under attack or experiences unusual traffic)
+func VisitorDifficulty(difficulty uint8, minDifficulty uint8, vc common.VisitorClass) uint8 {
+	const (
+		minVisitorDifficulty = 1
+		visitorDelta         = common.DifficultyDelta
+	)
+
+	switch vc {
+	case common.VisitorClassReturning:
+		return uint8(max(minVisitorDifficulty, int(difficulty)-visitorDelta))
+	case common.VisitorClassFirstSeen:
+		if difficulty > minDifficulty {
+			return uint8(min(int(common.MaxDifficultyLevel), int(difficulty)+visitorDelta))
+		}
+	}
+
+	return difficulty
+}
+
+// StickyDifficulty keeps difficulty for the end user close to the one pinned at the start of the sticky window,
+// so that retries mid-form do not get a much harder (or easier) puzzle, while still following the property trend
+func StickyDifficulty(difficulty uint8, pinned uint8) uint8 {
+	lower := max(1, int(pinned)-StickyDifficultyDrift)
+	upper := min(int(common.MaxDifficultyLevel), int(pinned)+StickyDifficultyDrift)
+
+	return uint8(min(upper, max(lower, int(difficulty))))
+}
+
 func (levels *Levels) Init(accessLogInterval, backfillInterval time.Duration) {
 	const (
 		maxPendingBatchSize = 100_000
@@ -118,9 +166,15 @@ func (l *Levels) Shutdown() {
 }
 
 func (l *Levels) DifficultyEx(fingerprint common.TFingerprint, p *dbgen.Property, baseDifficulty uint8, tnow time.Time) (uint8, leakybucket.TLevel) {
-	l.recordAccess(fingerprint, p, tnow)
+	return l.DifficultyTagged(fingerprint, p, baseDifficulty, common.ExperimentArmNone, common.VisitorClassNone, "" /*country*/, tnow)
+}
+
+// DifficultyTagged is the same as DifficultyEx, but also tags recorded access with the difficulty experiment arm
+// and the visitor class (used for differential difficulty). Country is passed to the difficulty strategy
+func (l *Levels) DifficultyTagged(fingerprint common.TFingerprint, p *dbgen.Property, baseDifficulty uint8, arm common.ExperimentArm, vc common.VisitorClass, country string, tnow time.Time) (uint8, leakybucket.TLevel) {
+	l.recordAccess(fingerprint, p, arm, vc, tnow)
 
-	minDifficulty := float64(max(p.Level.Int16, int16(baseDifficulty)))
+	minDifficulty := uint8(max(p.Level.Int16, int16(baseDifficulty)))
 
 	propertyAddResult := l.propertyBuckets.Add(p.ID, 1, tnow)
 	if !propertyAddResult.Found {
@@ -134,7 +188,15 @@ func (l *Levels) DifficultyEx(fingerprint common.TFingerprint, p *dbgen.Property
 
 	// just as bucket's level is the measure of deviation of requests
 	// difficulty is the scaled deviation from minDifficulty
-	return requestsToDifficulty(float64(level), minDifficulty, p.Growth), propertyAddResult.CurrLevel
+	difficulty := FindStrategy(p.DifficultyStrategy).Difficulty(&StrategyInput{
+		Property:      p,
+		Requests:      float64(level),
+		MinDifficulty: minDifficulty,
+		Country:       country,
+		Time:          tnow,
+	})
+
+	return max(difficulty, minDifficulty), propertyAddResult.CurrLevel
 }
 
 func (l *Levels) Difficulty(fingerprint common.TFingerprint, p *dbgen.Property, tnow time.Time) uint8 {
@@ -163,19 +225,26 @@ func (l *Levels) BackfillAccess(result *puzzle.VerifyResult) {
 	l.accessChan <- ar
 }
 
-func (l *Levels) recordAccess(fingerprint common.TFingerprint, p *dbgen.Property, tnow time.Time) {
+func (l *Levels) recordAccess(fingerprint common.TFingerprint, p *dbgen.Property, arm common.ExperimentArm, vc common.VisitorClass, tnow time.Time) {
 	if (p == nil) || !p.ExternalID.Valid {
 		return
 	}
 
+	// staging traffic does not count towards billing (it is capped separately)
+	if p.Environment == dbgen.PropertyEnvironmentStaging {
+		return
+	}
+
 	ar := &common.AccessRecord{
 		Fingerprint: fingerprint,
 		// we record events for the user that owns the org where the property belongs
 		// (effectively, who is billed for the org), rather than who created it
-		UserID:     p.OrgOwnerID.Int32,
-		OrgID:      p.OrgID.Int32,
-		PropertyID: p.ID,
-		Timestamp:  tnow,
+		UserID:        p.OrgOwnerID.Int32,
+		OrgID:         p.OrgID.Int32,
+		PropertyID:    p.ID,
+		Timestamp:     tnow,
+		ExperimentArm: arm,
+		VisitorClass:  vc,
 	}
 
 	l.accessChan <- ar
@@ -184,6 +253,7 @@ func (l *Levels) recordAccess(fingerprint common.TFingerprint, p *dbgen.Property
 func (l *Levels) Reset() {
 	l.propertyBuckets.Clear()
 	l.userBuckets.Clear()
+	l.leases.clear()
 }
 
 func (l *Levels) retrievePropertyStatsSafe(ctx context.Context, r *common.BackfillRequest) (data []*common.TimeCount, err error) {
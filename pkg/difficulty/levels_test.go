@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 )
 
@@ -29,3 +30,54 @@ func TestDifficultyFormula(t *testing.T) {
 		})
 	}
 }
+
+func TestVisitorDifficulty(t *testing.T) {
+	testCases := []struct {
+		difficulty    uint8
+		minDifficulty uint8
+		class         common.VisitorClass
+		expected      uint8
+	}{
+		{100, 100, common.VisitorClassNone, 100},
+		{120, 100, common.VisitorClassNone, 120},
+		{100, 100, common.VisitorClassReturning, 100 - common.DifficultyDelta},
+		{120, 100, common.VisitorClassReturning, 120 - common.DifficultyDelta},
+		{10, 10, common.VisitorClassReturning, 1},
+		{100, 100, common.VisitorClassFirstSeen, 100},
+		{120, 100, common.VisitorClassFirstSeen, 120 + common.DifficultyDelta},
+		{250, 100, common.VisitorClassFirstSeen, 255},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("visitor_%v_%v", tc.class, i), func(t *testing.T) {
+			actual := VisitorDifficulty(tc.difficulty, tc.minDifficulty, tc.class)
+			if actual != tc.expected {
+				t.Errorf("Actual difficulty (%v) is different from expected (%v)", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestStickyDifficulty(t *testing.T) {
+	testCases := []struct {
+		difficulty uint8
+		pinned     uint8
+		expected   uint8
+	}{
+		{100, 100, 100},
+		{100 + StickyDifficultyDrift - 1, 100, 100 + StickyDifficultyDrift - 1},
+		{200, 100, 100 + StickyDifficultyDrift},
+		{80, 100, 100 - StickyDifficultyDrift},
+		{1, 2, 1},
+		{255, 253, 255},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("sticky_%v", i), func(t *testing.T) {
+			actual := StickyDifficulty(tc.difficulty, tc.pinned)
+			if actual != tc.expected {
+				t.Errorf("Actual difficulty (%v) is different from expected (%v)", actual, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package difficulty
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+var (
+	errEmptyStrategyName     = errors.New("difficulty strategy name is empty")
+	errNilStrategy           = errors.New("difficulty strategy is nil")
+	errDuplicateStrategyName = errors.New("difficulty strategy is already registered")
+	strategies               = make(map[string]DifficultyStrategy)
+	strategiesMux            sync.RWMutex
+)
+
+// StrategyInput is everything that is known about the puzzle request when its difficulty is decided
+type StrategyInput struct {
+	Property *dbgen.Property
+	// combined level of the property and end user buckets, i.e. how much traffic deviates from the "normal" one
+	Requests float64
+	// difficulty cannot go below it (property level or base difficulty override)
+	MinDifficulty uint8
+	// country code of the end user, if configured via PC_COUNTRY_CODE_HEADER
+	Country string
+	Time    time.Time
+}
+
+// DifficultyStrategy maps puzzle request to its difficulty. Custom strategies are registered at startup with
+// RegisterStrategy() and are referenced from properties by name
+type DifficultyStrategy interface {
+	Difficulty(input *StrategyInput) uint8
+}
+
+type StrategyFunc func(input *StrategyInput) uint8
+
+func (f StrategyFunc) Difficulty(input *StrategyInput) uint8 {
+	return f(input)
+}
+
+// GrowthStrategy is the built-in strategy, used by properties without a custom one, that follows
+// the growth curve of the property
+type GrowthStrategy struct{}
+
+var _ DifficultyStrategy = GrowthStrategy{}
+
+func (GrowthStrategy) Difficulty(input *StrategyInput) uint8 {
+	return requestsToDifficulty(input.Requests, float64(input.MinDifficulty), input.Property.Growth)
+}
+
+// RegisterStrategy is not expected to be called after server has started
+func RegisterStrategy(name string, strategy DifficultyStrategy) error {
+	if len(name) == 0 {
+		return errEmptyStrategyName
+	}
+
+	if strategy == nil {
+		return errNilStrategy
+	}
+
+	strategiesMux.Lock()
+	defer strategiesMux.Unlock()
+
+	if _, ok := strategies[name]; ok {
+		return errDuplicateStrategyName
+	}
+
+	strategies[name] = strategy
+
+	return nil
+}
+
+// FindStrategy returns GrowthStrategy for empty or unknown names
+func FindStrategy(name string) DifficultyStrategy {
+	if len(name) > 0 {
+		strategiesMux.RLock()
+		strategy, ok := strategies[name]
+		strategiesMux.RUnlock()
+
+		if ok {
+			return strategy
+		}
+	}
+
+	return GrowthStrategy{}
+}
+
+// IsValidStrategy checks if name can be stored in the property (empty name means built-in strategy)
+func IsValidStrategy(name string) bool {
+	if len(name) == 0 {
+		return true
+	}
+
+	strategiesMux.RLock()
+	defer strategiesMux.RUnlock()
+
+	_, ok := strategies[name]
+	return ok
+}
+
+func StrategyNames() []string {
+	strategiesMux.RLock()
+	defer strategiesMux.RUnlock()
+
+	result := make([]string, 0, len(strategies))
+	for name := range strategies {
+		result = append(result, name)
+	}
+
+	slices.Sort(result)
+
+	return result
+}
@@ -0,0 +1,68 @@
+package difficulty
+
+import (
+	"slices"
+	"testing"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestRegisterStrategy(t *testing.T) {
+	const name = "test-register"
+
+	strategy := StrategyFunc(func(input *StrategyInput) uint8 {
+		if input.Country == "DE" {
+			return input.MinDifficulty + 10
+		}
+		return input.MinDifficulty
+	})
+
+	if err := RegisterStrategy(name, strategy); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterStrategy(name, strategy); err == nil {
+		t.Error("Duplicate strategy was registered")
+	}
+
+	if err := RegisterStrategy("", strategy); err == nil {
+		t.Error("Strategy with empty name was registered")
+	}
+
+	if err := RegisterStrategy("test-nil", nil); err == nil {
+		t.Error("Nil strategy was registered")
+	}
+
+	if !IsValidStrategy(name) || !slices.Contains(StrategyNames(), name) {
+		t.Error("Registered strategy is not found")
+	}
+
+	input := &StrategyInput{MinDifficulty: 100, Country: "DE"}
+	if actual := FindStrategy(name).Difficulty(input); actual != 110 {
+		t.Errorf("Unexpected difficulty: %v", actual)
+	}
+}
+
+func TestFindStrategyFallback(t *testing.T) {
+	if !IsValidStrategy("") {
+		t.Error("Empty strategy is not valid")
+	}
+
+	if IsValidStrategy("test-missing") {
+		t.Error("Unregistered strategy is valid")
+	}
+
+	input := &StrategyInput{
+		Property:      &dbgen.Property{Growth: dbgen.DifficultyGrowthMedium},
+		Requests:      3.0,
+		MinDifficulty: 100,
+	}
+
+	expected := requestsToDifficulty(input.Requests, float64(input.MinDifficulty), dbgen.DifficultyGrowthMedium)
+
+	for _, name := range []string{"", "test-missing"} {
+		if actual := FindStrategy(name).Difficulty(input); actual != expected {
+			t.Errorf("Unexpected difficulty for strategy %q: %v", name, actual)
+		}
+	}
+}
@@ -13,9 +13,18 @@ type APIKeyExpirationContext struct {
 	ExpireDays int
 }
 
+// APIKeyBatchContext describes API keys created together, APIKeyName is the naming template of the batch
+type APIKeyBatchContext struct {
+	APIKeyExpirationContext
+	APIKeysCount int
+}
+
 var (
 	APIKeyExpirationTemplate = common.NewEmailTemplate("apikey-expiration", apiKeyExpirationHTMLTemplate, apiKeyExpirationTextTemplate)
 	APIKeyExpiredTemplate    = common.NewEmailTemplate("apikey-expired", apiKeyExpiredHTMLTemplate, apiKeyExpiredTextTemplate)
+	// grouped notifications for API keys created in batch
+	APIKeyBatchExpirationTemplate = common.NewEmailTemplate("apikey-batch-expiration", apiKeyBatchExpirationHTMLTemplate, apiKeyBatchExpirationTextTemplate)
+	APIKeyBatchExpiredTemplate    = common.NewEmailTemplate("apikey-batch-expired", apiKeyBatchExpiredHTMLTemplate, apiKeyBatchExpiredTextTemplate)
 )
 
 const (
@@ -130,6 +139,121 @@ The Private Captcha team
 
 --
 
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
+`
+
+	apiKeyBatchExpirationHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="40" src="{{.CDNURL}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:32px;margin:24px 0 16px">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              {{.APIKeysCount}} of your Private Captcha API keys, created from the name template <i>"{{.APIKeyName}}"</i>, will expire in {{.ExpireDays}} days or less.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">You can create new ones or rotate them in the <a href="{{.PortalURL}}/{{.APIKeySettingsPath}}">account settings</a>.</p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="https://privatecaptcha.com" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+
+	apiKeyBatchExpirationTextTemplate = `Hello,
+
+{{.APIKeysCount}} of your Private Captcha API keys, created from the name template "{{.APIKeyName}}", will expire in {{.ExpireDays}} days or less.
+
+You can create new ones or rotate them in the account settings ({{.PortalURL}}/{{.APIKeySettingsPath}}).
+
+Warmly,
+The Private Captcha team
+
+--
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ`
+
+	apiKeyBatchExpiredHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="40" src="{{.CDNURL}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:32px;margin:24px 0 16px">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              {{.APIKeysCount}} of your Private Captcha API keys, created from the name template <i>"{{.APIKeyName}}"</i>, have just expired.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">You can create new ones in the <a href="{{.PortalURL}}/{{.APIKeySettingsPath}}">account settings</a>.</p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="https://privatecaptcha.com" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+
+	apiKeyBatchExpiredTextTemplate = `Hello,
+
+{{.APIKeysCount}} of your Private Captcha API keys, created from the name template "{{.APIKeyName}}", have just expired.
+
+You can create new ones in the account settings ({{.PortalURL}}/{{.APIKeySettingsPath}}).
+
+Warmly,
+The Private Captcha team
+
+--
+
 PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
 `
 )
@@ -0,0 +1,109 @@
+package email
+
+import "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+
+type StatsDigestProperty struct {
+	Name          string
+	Requests      string
+	Verifications string
+	SuccessRate   string
+}
+
+type StatsDigestContext struct {
+	OrgName                   string
+	DigestFrequency           string
+	DigestPeriod              string
+	DigestRequests            string
+	DigestVerifications       string
+	DigestProperties          []*StatsDigestProperty
+	OrgPath                   string
+	NotificationsSettingsPath string
+}
+
+var (
+	StatsDigestTemplate = common.NewEmailTemplate("stats-digest", statsDigestHTMLTemplate, statsDigestTextTemplate)
+)
+
+const (
+	statsDigestHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="40" src="{{.CDNURL}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:32px;margin:24px 0 16px">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Here is your {{.DigestFrequency}} summary of the organization <i>"{{.OrgName}}"</i> for {{.DigestPeriod}}: {{.DigestRequests}} puzzle requests and {{.DigestVerifications}} verifications in total.
+            </p>
+            <table width="100%" border="0" cellpadding="6" cellspacing="0" role="presentation" style="font-size:14px;line-height:20px;margin:16px 0;border-collapse:collapse">
+              <thead>
+                <tr style="border-bottom:1px solid #cccccc;text-align:left">
+                  <th>Property</th>
+                  <th style="text-align:right">Requests</th>
+                  <th style="text-align:right">Verifications</th>
+                  <th style="text-align:right">Success rate</th>
+                </tr>
+              </thead>
+              <tbody>
+                {{range .DigestProperties}}
+                <tr style="border-bottom:1px solid #eaeaea">
+                  <td>{{.Name}}</td>
+                  <td style="text-align:right">{{.Requests}}</td>
+                  <td style="text-align:right">{{.Verifications}}</td>
+                  <td style="text-align:right">{{.SuccessRate}}</td>
+                </tr>
+                {{end}}
+              </tbody>
+            </table>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              You can check the details in the <a href="{{.PortalURL}}/{{.OrgPath}}">portal</a>. To change how often you receive this summary, visit your <a href="{{.PortalURL}}/{{.NotificationsSettingsPath}}">notification settings</a>.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="https://privatecaptcha.com" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+
+	statsDigestTextTemplate = `Hello,
+
+Here is your {{.DigestFrequency}} summary of the organization "{{.OrgName}}" for {{.DigestPeriod}}: {{.DigestRequests}} puzzle requests and {{.DigestVerifications}} verifications in total.
+{{range .DigestProperties}}
+- {{.Name}}: {{.Requests}} requests, {{.Verifications}} verifications, success rate {{.SuccessRate}}{{end}}
+
+You can check the details in the portal ({{.PortalURL}}/{{.OrgPath}}). To change how often you receive this summary, visit your notification settings ({{.PortalURL}}/{{.NotificationsSettingsPath}}).
+
+Warmly,
+The Private Captcha team
+
+--
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
+`
+)
@@ -0,0 +1,251 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	ProviderSMTP    = "smtp"
+	ProviderSES     = "ses"
+	ProviderMailgun = "mailgun"
+
+	defaultProviders        = ProviderSMTP
+	providerBackoff         = 30 * time.Second
+	providerMaxBackoff      = 10 * time.Minute
+	providerLastErrorLength = 200
+)
+
+var (
+	errNoProviders     = errors.New("no email providers are configured")
+	errUnknownProvider = errors.New("unknown email provider")
+)
+
+type providerHealth struct {
+	sent        int64
+	failed      int64
+	failures    int
+	lastError   string
+	lastFailure time.Time
+	lastSuccess time.Time
+}
+
+// backoff grows exponentially with consecutive failures, so that provider is retried sooner after a short outage
+func (h *providerHealth) healthy(tnow time.Time) bool {
+	if h.failures == 0 {
+		return true
+	}
+
+	backoff := providerMaxBackoff
+	if h.failures < 16 {
+		backoff = min(providerBackoff<<(h.failures-1), providerMaxBackoff)
+	}
+
+	return tnow.Sub(h.lastFailure) >= backoff
+}
+
+type ProviderStatus struct {
+	Name        string     `json:"name"`
+	Configured  bool       `json:"configured"`
+	Healthy     bool       `json:"healthy"`
+	Sent        int64      `json:"sent"`
+	Failed      int64      `json:"failed"`
+	Failures    int        `json:"consecutive_failures"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+// FailoverSender tries configured providers in the order from config, skipping ones that failed recently
+// (unless all of them did)
+type FailoverSender struct {
+	order     common.ConfigItem
+	providers map[string]Provider
+	lock      sync.Mutex
+	health    map[string]*providerHealth
+}
+
+var _ Sender = (*FailoverSender)(nil)
+
+func NewFailoverSender(order common.ConfigItem, providers ...Provider) *FailoverSender {
+	fs := &FailoverSender{
+		order:     order,
+		providers: make(map[string]Provider),
+		health:    make(map[string]*providerHealth),
+	}
+
+	for _, p := range providers {
+		fs.providers[p.Name()] = p
+		fs.health[p.Name()] = &providerHealth{}
+	}
+
+	return fs
+}
+
+func NewFailoverSenderFromConfig(cfg common.ConfigStore) *FailoverSender {
+	return NewFailoverSender(cfg.Get(common.EmailProvidersKey), NewMailSender(cfg), NewSESSender(cfg), NewMailgunSender(cfg))
+}
+
+func (fs *FailoverSender) providerNames() []string {
+	value := fs.order.Value()
+	if len(value) == 0 {
+		value = defaultProviders
+	}
+
+	names := make([]string, 0, len(fs.providers))
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := fs.providers[name]; ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// orderedProviders returns configured providers, healthy ones first
+func (fs *FailoverSender) orderedProviders(tnow time.Time) []Provider {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	healthy := make([]Provider, 0, len(fs.providers))
+	unhealthy := make([]Provider, 0)
+
+	for _, name := range fs.providerNames() {
+		p := fs.providers[name]
+		if !p.Configured() {
+			continue
+		}
+
+		if fs.health[name].healthy(tnow) {
+			healthy = append(healthy, p)
+		} else {
+			unhealthy = append(unhealthy, p)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+func (fs *FailoverSender) record(name string, err error, tnow time.Time) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	h := fs.health[name]
+	if err == nil {
+		h.sent++
+		h.failures = 0
+		h.lastSuccess = tnow
+		return
+	}
+
+	h.failed++
+	h.failures++
+	h.lastFailure = tnow
+	h.lastError = err.Error()
+	if len(h.lastError) > providerLastErrorLength {
+		h.lastError = h.lastError[:providerLastErrorLength]
+	}
+}
+
+func (fs *FailoverSender) send(ctx context.Context, p Provider, msg *Message) error {
+	err := p.SendEmail(ctx, msg)
+	if err != errInvalidMessage {
+		fs.record(p.Name(), err, time.Now())
+	}
+
+	return err
+}
+
+func (fs *FailoverSender) SendEmail(ctx context.Context, msg *Message) error {
+	providers := fs.orderedProviders(time.Now())
+	if len(providers) == 0 {
+		slog.ErrorContext(ctx, "Cannot send email without providers", "order", fs.order.Value())
+		return errNoProviders
+	}
+
+	var errs []error
+	for i, p := range providers {
+		err := fs.send(ctx, p, msg)
+		if err == nil {
+			if i > 0 {
+				slog.WarnContext(ctx, "Email was sent via failover provider", "provider", p.Name(), "attempt", i+1)
+			}
+			return nil
+		}
+
+		if err == errInvalidMessage {
+			return err
+		}
+
+		slog.WarnContext(ctx, "Failed to send email via provider", "provider", p.Name(), common.ErrAttr(err))
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// SendVia sends email only with the specific provider (e.g. to test its settings)
+func (fs *FailoverSender) SendVia(ctx context.Context, name string, msg *Message) error {
+	p, ok := fs.providers[name]
+	if !ok {
+		return errUnknownProvider
+	}
+
+	if !p.Configured() {
+		return errNoProviders
+	}
+
+	return fs.send(ctx, p, msg)
+}
+
+// Status returns health of all known providers, the ones in failover order first
+func (fs *FailoverSender) Status() []*ProviderStatus {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	names := fs.providerNames()
+	for _, name := range slices.Sorted(maps.Keys(fs.providers)) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	tnow := time.Now()
+	result := make([]*ProviderStatus, 0, len(names))
+
+	for _, name := range names {
+		h := fs.health[name]
+		status := &ProviderStatus{
+			Name:       name,
+			Configured: fs.providers[name].Configured(),
+			Healthy:    h.healthy(tnow),
+			Sent:       h.sent,
+			Failed:     h.failed,
+			Failures:   h.failures,
+			LastError:  h.lastError,
+		}
+
+		if !h.lastFailure.IsZero() {
+			t := h.lastFailure
+			status.LastFailure = &t
+		}
+
+		if !h.lastSuccess.IsZero() {
+			t := h.lastSuccess
+			status.LastSuccess = &t
+		}
+
+		result = append(result, status)
+	}
+
+	return result
+}
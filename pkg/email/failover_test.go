@@ -0,0 +1,175 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+type testProvider struct {
+	name  string
+	err   error
+	count int
+}
+
+func (p *testProvider) Name() string     { return p.name }
+func (p *testProvider) Configured() bool { return true }
+
+func (p *testProvider) SendEmail(ctx context.Context, msg *Message) error {
+	p.count++
+	return p.err
+}
+
+func testMessage() *Message {
+	return &Message{
+		Subject:   "Test",
+		EmailTo:   "user@example.com",
+		EmailFrom: "noreply@example.com",
+		TextBody:  "text",
+		HTMLBody:  "<p>html</p>",
+		ReplyTo:   "support@example.com",
+	}
+}
+
+func TestFailoverSender(t *testing.T) {
+	t.Parallel()
+
+	smtp := &testProvider{name: ProviderSMTP, err: errors.New("connection refused")}
+	ses := &testProvider{name: ProviderSES}
+	mailgun := &testProvider{name: ProviderMailgun}
+
+	order := config.NewStaticValue(common.EmailProvidersKey, "smtp, ses")
+	sender := NewFailoverSender(order, smtp, ses, mailgun)
+
+	if err := sender.SendEmail(t.Context(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+
+	if (smtp.count != 1) || (ses.count != 1) || (mailgun.count != 0) {
+		t.Errorf("Unexpected sends: smtp %v, ses %v, mailgun %v", smtp.count, ses.count, mailgun.count)
+	}
+
+	// failed provider is skipped during backoff
+	if err := sender.SendEmail(t.Context(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+
+	if (smtp.count != 1) || (ses.count != 2) {
+		t.Errorf("Unexpected sends after failure: smtp %v, ses %v", smtp.count, ses.count)
+	}
+
+	status := sender.Status()
+	if (len(status) != 3) || (status[0].Name != ProviderSMTP) || status[0].Healthy || (status[0].Failures != 1) ||
+		(status[1].Sent != 2) || (status[2].Name != ProviderMailgun) {
+		t.Errorf("Unexpected status: %+v %+v %+v", status[0], status[1], status[2])
+	}
+
+	ses.err = errors.New("throttled")
+	if err := sender.SendEmail(t.Context(), testMessage()); err == nil {
+		t.Error("Expected error when all providers fail")
+	}
+}
+
+func TestProviderBackoff(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now()
+	h := &providerHealth{failures: 3, lastFailure: tnow}
+
+	if h.healthy(tnow.Add(providerBackoff * 3)) {
+		t.Error("Provider is healthy before backoff")
+	}
+
+	if !h.healthy(tnow.Add(providerBackoff * 4)) {
+		t.Error("Provider is not healthy after backoff")
+	}
+
+	h.failures = 100
+	if !h.healthy(tnow.Add(providerMaxBackoff)) {
+		t.Error("Backoff exceeds the maximum")
+	}
+}
+
+func TestMailgunSender(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); (user != "api") || (pass != "key") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if (r.URL.Path != "/v3/mg.example.com/messages") || (r.FormValue("h:Reply-To") != "support@example.com") ||
+			!strings.Contains(r.FormValue("to"), "user@example.com") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"message": "Queued. Thank you."}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.NewBaseConfig(config.NewEnvConfig(func(name string) string {
+		switch name {
+		case "PC_MAILGUN_DOMAIN":
+			return "mg.example.com"
+		case "PC_MAILGUN_API_KEY":
+			return "key"
+		case "PC_MAILGUN_ENDPOINT":
+			return srv.URL
+		default:
+			return ""
+		}
+	}))
+
+	sender := NewMailgunSender(cfg)
+	if !sender.Configured() {
+		t.Fatal("Mailgun is not configured")
+	}
+
+	if err := sender.SendEmail(t.Context(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSESSender(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get(common.HeaderAuthorization), "/eu-west-1/ses/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message": "Email address is not verified."}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.NewBaseConfig(config.NewEnvConfig(func(name string) string {
+		switch name {
+		case "PC_SES_REGION":
+			return "eu-west-1"
+		case "PC_SES_ACCESS_KEY":
+			return "access"
+		case "PC_SES_SECRET_KEY":
+			return "secret"
+		default:
+			return ""
+		}
+	}))
+
+	sender := NewSESSender(cfg)
+	sender.endpoint = srv.URL
+
+	if err := sender.SendEmail(t.Context(), testMessage()); (err == nil) || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -20,6 +20,8 @@ type Message struct {
 	EmailFrom string
 	NameFrom  string
 	ReplyTo   string
+	// delivery region of the recipient (e.g. RegionEU), empty if unknown
+	Region string
 }
 
 var (
@@ -70,13 +72,29 @@ type Sender interface {
 	SendEmail(ctx context.Context, msg *Message) error
 }
 
+// Provider is a delivery service that can be one of failover options
+type Provider interface {
+	Sender
+	Name() string
+	// Configured is checked on every send so that provider can be enabled with config reload
+	Configured() bool
+}
+
 type simpleMailer struct {
 	endpoint common.ConfigItem
 	username common.ConfigItem
 	password common.ConfigItem
 }
 
-var _ Sender = (*simpleMailer)(nil)
+var _ Provider = (*simpleMailer)(nil)
+
+func (sm *simpleMailer) Name() string {
+	return ProviderSMTP
+}
+
+func (sm *simpleMailer) Configured() bool {
+	return len(sm.endpoint.Value()) > 0
+}
 
 func (sm *simpleMailer) SendEmail(ctx context.Context, msg *Message) error {
 	if !msg.Valid() {
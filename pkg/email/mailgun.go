@@ -0,0 +1,105 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	mailgunDefaultEndpoint = "https://api.mailgun.net"
+	mailgunRequestTimeout  = 10 * time.Second
+)
+
+// mailgunMailer sends emails via Mailgun messages API. Endpoint has to be changed for domains in EU region.
+type mailgunMailer struct {
+	client   *http.Client
+	domain   common.ConfigItem
+	apiKey   common.ConfigItem
+	endpoint common.ConfigItem
+}
+
+var _ Provider = (*mailgunMailer)(nil)
+
+func NewMailgunSender(cfg common.ConfigStore) *mailgunMailer {
+	return &mailgunMailer{
+		client:   &http.Client{Timeout: mailgunRequestTimeout},
+		domain:   cfg.Get(common.MailgunDomainKey),
+		apiKey:   cfg.Get(common.MailgunAPIKeyKey),
+		endpoint: cfg.Get(common.MailgunEndpointKey),
+	}
+}
+
+func (mm *mailgunMailer) Name() string {
+	return ProviderMailgun
+}
+
+func (mm *mailgunMailer) Configured() bool {
+	return len(mm.domain.Value()) > 0 && len(mm.apiKey.Value()) > 0
+}
+
+func (mm *mailgunMailer) url(domain string) string {
+	endpoint := mm.endpoint.Value()
+	if len(endpoint) == 0 {
+		endpoint = mailgunDefaultEndpoint
+	}
+
+	return strings.TrimSuffix(endpoint, "/") + "/v3/" + url.PathEscape(domain) + "/messages"
+}
+
+func newMailgunForm(msg *Message) url.Values {
+	form := url.Values{}
+	form.Set("from", (&mail.Address{Name: msg.NameFrom, Address: msg.EmailFrom}).String())
+	form.Set("to", (&mail.Address{Name: msg.NameTo, Address: msg.EmailTo}).String())
+	form.Set("subject", msg.Subject)
+
+	if len(msg.TextBody) > 0 {
+		form.Set("text", msg.TextBody)
+	}
+	if len(msg.HTMLBody) > 0 {
+		form.Set("html", msg.HTMLBody)
+	}
+	if len(msg.ReplyTo) > 0 {
+		form.Set("h:Reply-To", msg.ReplyTo)
+	}
+
+	return form
+}
+
+func (mm *mailgunMailer) SendEmail(ctx context.Context, msg *Message) error {
+	if !msg.Valid() {
+		return errInvalidMessage
+	}
+
+	domain := mm.domain.Value()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mm.url(domain), strings.NewReader(newMailgunForm(msg).Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+	req.SetBasicAuth("api", mm.apiKey.Value())
+
+	resp, err := mm.client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send an email via Mailgun", "email", msg.EmailTo, "domain", domain, common.ErrAttr(err))
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		slog.ErrorContext(ctx, "Mailgun rejected an email", "email", msg.EmailTo, "domain", domain, "code", resp.StatusCode,
+			"response", string(message))
+		return fmt.Errorf("unexpected Mailgun status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
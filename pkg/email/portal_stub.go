@@ -30,3 +30,13 @@ func (sm *StubMailer) SendOrgInvite(ctx context.Context, email, name string, org
 	slog.InfoContext(ctx, "Sent org invite email", "email", email, "name", name)
 	return nil
 }
+
+func (sm *StubMailer) SendIPAllowlistRecovery(ctx context.Context, email, orgName, clientIP, recoveryURL string) error {
+	slog.InfoContext(ctx, "Sent IP allowlist recovery email", "email", email, "org", orgName)
+	return nil
+}
+
+func (sm *StubMailer) SendNewSignIn(ctx context.Context, email string, ua string, location string) error {
+	slog.InfoContext(ctx, "Sent new sign-in email", "email", email, "location", location)
+	return nil
+}
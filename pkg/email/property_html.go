@@ -0,0 +1,147 @@
+package email
+
+import "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+
+type PropertyDomainContext struct {
+	PropertyName         string
+	PropertyDomain       string
+	DomainProblem        string
+	PropertySettingsPath string
+}
+
+type PropertyAnomalyContext struct {
+	PropertyName      string
+	AnomalyMetric     string
+	AnomalyDirection  string
+	AnomalyHour       string
+	CurrentValue      string
+	ExpectedValue     string
+	PropertyStatsPath string
+}
+
+var (
+	PropertyDomainTemplate  = common.NewEmailTemplate("property-domain", propertyDomainHTMLTemplate, propertyDomainTextTemplate)
+	PropertyAnomalyTemplate = common.NewEmailTemplate("property-anomaly", propertyAnomalyHTMLTemplate, propertyAnomalyTextTemplate)
+)
+
+const (
+	propertyDomainHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="40" src="{{.CDNURL}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:32px;margin:24px 0 16px">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              The domain <code style="background-color:#eee; padding: 1px 2px; border-radius: 2px;">{{.PropertyDomain}}</code> of your Private Captcha property <i>"{{.PropertyName}}"</i> {{.DomainProblem}}.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              This can mean that the domain has expired or was taken over. If the website has moved to another domain, please create a new property for it. Unused property can be deleted in the <a href="{{.PortalURL}}/{{.PropertySettingsPath}}">property settings</a>.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="https://privatecaptcha.com" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+
+	propertyDomainTextTemplate = `Hello,
+
+The domain "{{.PropertyDomain}}" of your Private Captcha property "{{.PropertyName}}" {{.DomainProblem}}.
+
+This can mean that the domain has expired or was taken over. If the website has moved to another domain, please create a new property for it. Unused property can be deleted in the property settings ({{.PortalURL}}/{{.PropertySettingsPath}}).
+
+Warmly,
+The Private Captcha team
+
+--
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
+`
+
+	propertyAnomalyHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="40" src="{{.CDNURL}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:32px;margin:24px 0 16px">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              The {{.AnomalyMetric}} of your Private Captcha property <i>"{{.PropertyName}}"</i> was unusually {{.AnomalyDirection}} at {{.AnomalyHour}}: {{.CurrentValue}}, while it is usually around {{.ExpectedValue}} at this time of the week.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              This can be caused by a change on your website (e.g. broken integration), an attack or a sudden change in your traffic. You can check the details in the <a href="{{.PortalURL}}/{{.PropertyStatsPath}}">property dashboard</a>.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="https://privatecaptcha.com" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+
+	propertyAnomalyTextTemplate = `Hello,
+
+The {{.AnomalyMetric}} of your Private Captcha property "{{.PropertyName}}" was unusually {{.AnomalyDirection}} at {{.AnomalyHour}}: {{.CurrentValue}}, while it is usually around {{.ExpectedValue}} at this time of the week.
+
+This can be caused by a change on your website (e.g. broken integration), an attack or a sudden change in your traffic. You can check the details in the property dashboard ({{.PortalURL}}/{{.PropertyStatsPath}}).
+
+Warmly,
+The Private Captcha team
+
+--
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
+`
+)
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	RegionEU = "eu"
+)
+
+// EU member states and the rest of EEA (ISO 3166-1 alpha-2), plus Greece as reported by some CDNs ("EL")
+var euCountries = map[string]struct{}{
+	"AT": {}, "BE": {}, "BG": {}, "HR": {}, "CY": {}, "CZ": {}, "DK": {}, "EE": {}, "FI": {}, "FR": {},
+	"DE": {}, "GR": {}, "EL": {}, "HU": {}, "IE": {}, "IT": {}, "LV": {}, "LT": {}, "LU": {}, "MT": {},
+	"NL": {}, "PL": {}, "PT": {}, "RO": {}, "SK": {}, "SI": {}, "ES": {}, "SE": {},
+	"IS": {}, "LI": {}, "NO": {},
+}
+
+// CountryRegion returns delivery region for the country code or empty string if there's no dedicated one
+func CountryRegion(country string) string {
+	if _, ok := euCountries[strings.ToUpper(strings.TrimSpace(country))]; ok {
+		return RegionEU
+	}
+
+	return ""
+}
+
+// RegionalSender delivers messages through the provider of the recipient's region, if one is configured, and
+// through the default provider otherwise
+type RegionalSender struct {
+	fallback Sender
+	regions  map[string]*simpleMailer
+}
+
+var _ Sender = (*RegionalSender)(nil)
+
+func NewRegionalSender(cfg common.ConfigStore, fallback Sender) *RegionalSender {
+	return &RegionalSender{
+		fallback: fallback,
+		regions: map[string]*simpleMailer{
+			RegionEU: {
+				endpoint: cfg.Get(common.SmtpEndpointEUKey),
+				username: cfg.Get(common.SmtpUsernameEUKey),
+				password: cfg.Get(common.SmtpPasswordEUKey),
+			},
+		},
+	}
+}
+
+func (rs *RegionalSender) SendEmail(ctx context.Context, msg *Message) error {
+	if len(msg.Region) > 0 {
+		// endpoints are checked on every send so that they can be changed with config reload
+		if sender, ok := rs.regions[msg.Region]; ok && sender.Configured() {
+			slog.Log(ctx, common.LevelTrace, "Sending email via regional provider", "region", msg.Region)
+			return sender.SendEmail(ctx, msg)
+		}
+	}
+
+	return rs.fallback.SendEmail(ctx, msg)
+}
@@ -0,0 +1,38 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+func TestRegionalSenderFallback(t *testing.T) {
+	t.Parallel()
+
+	for country, expected := range map[string]string{"de": RegionEU, "NO": RegionEU, "US": "", "": ""} {
+		if actual := CountryRegion(country); actual != expected {
+			t.Errorf("Unexpected region of %q: %v", country, actual)
+		}
+	}
+
+	fallback := &StubSender{}
+	cfg := config.NewBaseConfig(config.NewEnvConfig(func(string) string { return "" }))
+	sender := NewRegionalSender(cfg, fallback)
+
+	msg := &Message{
+		Subject:   "Digest",
+		EmailTo:   "user@example.com",
+		EmailFrom: "noreply@example.com",
+		TextBody:  "text",
+		Region:    RegionEU,
+	}
+
+	// EU provider is not configured
+	if err := sender.SendEmail(t.Context(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if fallback.Count != 1 {
+		t.Errorf("Message was not sent via default provider")
+	}
+}
@@ -0,0 +1,145 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	sesService        = "ses"
+	sesRequestTimeout = 10 * time.Second
+)
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset,omitempty"`
+}
+
+type sesBody struct {
+	Text *sesContent `json:"Text,omitempty"`
+	Html *sesContent `json:"Html,omitempty"`
+}
+
+type sesSimpleMessage struct {
+	Subject sesContent `json:"Subject"`
+	Body    sesBody    `json:"Body"`
+}
+
+type sesDestination struct {
+	ToAddresses []string `json:"ToAddresses"`
+}
+
+// sesSendEmailInput is the request of SendEmail operation of Amazon SES API v2
+type sesSendEmailInput struct {
+	FromEmailAddress string         `json:"FromEmailAddress"`
+	Destination      sesDestination `json:"Destination"`
+	ReplyToAddresses []string       `json:"ReplyToAddresses,omitempty"`
+	Content          struct {
+		Simple sesSimpleMessage `json:"Simple"`
+	} `json:"Content"`
+}
+
+// sesMailer sends emails via HTTPS API of Amazon SES (which does not require SMTP credentials)
+type sesMailer struct {
+	client    *http.Client
+	region    common.ConfigItem
+	accessKey common.ConfigItem
+	secretKey common.ConfigItem
+	// overridden in tests
+	endpoint string
+}
+
+var _ Provider = (*sesMailer)(nil)
+
+func NewSESSender(cfg common.ConfigStore) *sesMailer {
+	return &sesMailer{
+		client:    &http.Client{Timeout: sesRequestTimeout},
+		region:    cfg.Get(common.SESRegionKey),
+		accessKey: cfg.Get(common.SESAccessKeyKey),
+		secretKey: cfg.Get(common.SESSecretKeyKey),
+	}
+}
+
+func (sm *sesMailer) Name() string {
+	return ProviderSES
+}
+
+func (sm *sesMailer) Configured() bool {
+	return len(sm.region.Value()) > 0 && len(sm.accessKey.Value()) > 0 && len(sm.secretKey.Value()) > 0
+}
+
+func (sm *sesMailer) url(region string) string {
+	if len(sm.endpoint) > 0 {
+		return sm.endpoint
+	}
+
+	return fmt.Sprintf("https://email.%s.amazonaws.com/v2/email/outbound-emails", region)
+}
+
+func newSESSendEmailInput(msg *Message) *sesSendEmailInput {
+	input := &sesSendEmailInput{
+		FromEmailAddress: (&mail.Address{Name: msg.NameFrom, Address: msg.EmailFrom}).String(),
+		Destination: sesDestination{
+			ToAddresses: []string{(&mail.Address{Name: msg.NameTo, Address: msg.EmailTo}).String()},
+		},
+	}
+
+	if len(msg.ReplyTo) > 0 {
+		input.ReplyToAddresses = []string{msg.ReplyTo}
+	}
+
+	input.Content.Simple.Subject = sesContent{Data: msg.Subject, Charset: "UTF-8"}
+	if len(msg.TextBody) > 0 {
+		input.Content.Simple.Body.Text = &sesContent{Data: msg.TextBody, Charset: "UTF-8"}
+	}
+	if len(msg.HTMLBody) > 0 {
+		input.Content.Simple.Body.Html = &sesContent{Data: msg.HTMLBody, Charset: "UTF-8"}
+	}
+
+	return input
+}
+
+func (sm *sesMailer) SendEmail(ctx context.Context, msg *Message) error {
+	if !msg.Valid() {
+		return errInvalidMessage
+	}
+
+	body, err := json.Marshal(newSESSendEmailInput(msg))
+	if err != nil {
+		return err
+	}
+
+	region := sm.region.Value()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sm.url(region), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+
+	common.SignAWSRequest(req, body, sm.accessKey.Value(), sm.secretKey.Value(), region, sesService, time.Now().UTC())
+
+	resp, err := sm.client.Do(req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send an email via SES", "email", msg.EmailTo, "region", region, common.ErrAttr(err))
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		slog.ErrorContext(ctx, "SES rejected an email", "email", msg.EmailTo, "region", region, "code", resp.StatusCode,
+			"response", string(message))
+		return fmt.Errorf("unexpected SES status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
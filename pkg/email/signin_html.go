@@ -0,0 +1,108 @@
+package email
+
+import "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+
+type NewSignInEmailContext struct {
+	PortalURL            string
+	CurrentYear          int
+	CDNURL               string
+	Date                 string
+	Browser              string
+	OS                   string
+	Location             string
+	SessionsSettingsPath string
+}
+
+var (
+	NewSignInEmailTemplate = common.NewEmailTemplate("new-signin", newSignInHTMLTemplate, newSignInTextTemplate)
+)
+
+const (
+	newSignInHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-light.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body style="background-color:#fff;color:#072929">
+    <table align="center" width="100%" border="0" cellpadding="0" cellspacing="0" role="presentation"
+      style="max-width:37.5em;padding:20px;margin:0 auto;background-color:#f3f4f6">
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <table align="center" width="100%" border="0" cellpadding="0" cellspacing="0" role="presentation" style="background-color:#fff">
+              <tbody>
+                <tr>
+                  <td>
+                    <table align="center" width="100%" border="0" cellpadding="0" cellspacing="0" role="presentation"
+                      style="background-color:#072929;display:flex;padding:20px 0;align-items:center;justify-content:center">
+                      <tbody>
+                        <tr>
+                          <td>
+                            <img alt="PrivateCaptcha's Logo" height="50" src="{{.CDNURL}}/portal/img/pc-logo-light.png"
+                              style="display:block;outline:none;border:none;text-decoration:none;color:#fff" />
+                          </td>
+                        </tr>
+                      </tbody>
+                    </table>
+                    <table align="center" width="100%" border="0" cellpadding="0" cellspacing="0" role="presentation" style="padding:25px 35px">
+                      <tbody>
+                        <tr>
+                          <td>
+                            <h1 style="color:#072929;font-family:-apple-system, BlinkMacSystemFont, 'Segoe UI', 'Roboto', 'Oxygen', 'Ubuntu', 'Cantarell', 'Fira Sans', 'Droid Sans', 'Helvetica Neue', sans-serif;font-size:20px;font-weight:bold;margin-bottom:15px">
+                              New sign-in to your account
+                            </h1>
+                            <p style="font-size:14px;line-height:24px;margin:24px 0;color:#072929;font-family:-apple-system, BlinkMacSystemFont, 'Segoe UI', 'Roboto', 'Oxygen', 'Ubuntu', 'Cantarell', 'Fira Sans', 'Droid Sans', 'Helvetica Neue', sans-serif;margin-bottom:14px">
+                              Your account was just used to sign in from a browser, operating system or location that we have not seen before.
+                            </p>
+                            <p style="font-size:14px;line-height:24px;margin:24px 0;color:#072929;font-family:-apple-system, BlinkMacSystemFont, 'Segoe UI', 'Roboto', 'Oxygen', 'Ubuntu', 'Cantarell', 'Fira Sans', 'Droid Sans', 'Helvetica Neue', sans-serif;margin-bottom:14px">
+                                Please review the sign-in activity details below:
+                            </p>
+                            <table width="100%" style="margin-top: 10px; background-color: #f3f4f6; padding: 10px; font-size:14px;color:#072929;font-family:-apple-system, BlinkMacSystemFont, 'Segoe UI', 'Roboto', 'Oxygen', 'Ubuntu', 'Cantarell', 'Fira Sans', 'Droid Sans', 'Helvetica Neue', sans-serif;">
+                                <tr><td style="font-style: italic; padding-right:10px; max-width: 32px;">Date:</td><td style="max-width: 100px; word-wrap: break-word;">{{.Date}}</td></tr>
+                                <tr><td style="font-style: italic; padding-right:10px; max-width: 32px;">Browser:</td><td style="max-width: 100px; word-wrap: break-word;">{{.Browser}}</td></tr>
+                                <tr><td style="font-style: italic; padding-right:10px; max-width: 32px;">Operating system:</td><td style="max-width: 100px; word-wrap: break-word;">{{.OS}}</td></tr>
+                                {{if .Location}}<tr><td style="font-style: italic; padding-right:10px; max-width: 32px;">Location:</td><td style="max-width: 100px; word-wrap: break-word;">{{.Location}}</td></tr>{{end}}
+                            </table>
+                            <p style="font-size:14px;line-height:24px;color:#072929;font-family:-apple-system, BlinkMacSystemFont, 'Segoe UI', 'Roboto', 'Oxygen', 'Ubuntu', 'Cantarell', 'Fira Sans', 'Droid Sans', 'Helvetica Neue', sans-serif;margin-bottom:14px">
+                                If this was you, there is nothing else you need to do. If this wasn't you, please <a href="{{.PortalURL}}/{{.SessionsSettingsPath}}" style="text-decoration:underline;color:#072929;">review active sessions</a> of your account, sign out of the unfamiliar one and let us know by replying to this email.
+                            </p>
+                          </td>
+                        </tr>
+                      </tbody>
+                    </table>
+                  </td>
+                </tr>
+              </tbody>
+            </table>
+            <p style="font-size:12px;margin:24px 0 0 0;color:#072929;font-family:-apple-system, BlinkMacSystemFont, 'Segoe UI', 'Roboto', 'Oxygen', 'Ubuntu', 'Cantarell', 'Fira Sans', 'Droid Sans', 'Helvetica Neue', sans-serif;padding:0 20px">
+              You are receiving this message to help keep your account secure.
+            </p>
+            <p style="font-size:12px;color:#072929;font-family:-apple-system, BlinkMacSystemFont, 'Segoe UI', 'Roboto', 'Oxygen', 'Ubuntu', 'Cantarell', 'Fira Sans', 'Droid Sans', 'Helvetica Neue', sans-serif;padding:0 20px"><a href="https://privatecaptcha.com" style="text-decoration:underline;color:#072929;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ</p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>
+`
+	newSignInTextTemplate = `New sign-in to your account
+
+Your account was just used to sign in from a browser, operating system or location that we have not seen before.
+
+Please review the sign-in activity details below:
+Date: {{.Date}}
+Browser: {{.Browser}}
+Operating system: {{.OS}}
+{{if .Location}}Location: {{.Location}}{{end}}
+
+If this was you, there is nothing else you need to do. If this wasn't you, please review active sessions of your account ({{.PortalURL}}/{{.SessionsSettingsPath}}), sign out of the unfamiliar one and let us know by replying to this email.
+
+---
+
+You are receiving this message to help keep your account secure.
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
+`
+)
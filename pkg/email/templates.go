@@ -4,16 +4,105 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
 
+// OptionalTemplate describes a non-critical notification that users can opt out of.
+// Templates that are not listed here (e.g. security notifications) are always delivered.
+type OptionalTemplate struct {
+	Template    *common.EmailTemplate
+	Title       string
+	Description string
+}
+
 var (
 	templates = []*common.EmailTemplate{
 		APIKeyExpirationTemplate,
 		APIKeyExpiredTemplate,
+		APIKeyBatchExpirationTemplate,
+		APIKeyBatchExpiredTemplate,
 		WelcomeEmailTemplate,
 		TwoFactorEmailTemplate,
+		NewSignInEmailTemplate,
 		OrgInvitationTemplate,
+		OrgIPAllowlistRecoveryTemplate,
+		PropertyDomainTemplate,
+		PropertyAnomalyTemplate,
+		StatsDigestTemplate,
+		QuotaWarningTemplate,
+		TrialExpirationTemplate,
+		TrialExpiredTemplate,
+	}
+
+	// notifications about subscription and usage that billing contacts receive too
+	billingTemplates = []*common.EmailTemplate{
+		QuotaWarningTemplate,
+		TrialExpirationTemplate,
+		TrialExpiredTemplate,
+	}
+
+	optionalTemplates = []*OptionalTemplate{
+		{
+			Template:    APIKeyExpirationTemplate,
+			Title:       "API key expiration reminders",
+			Description: "Reminders sent ahead of time when one of your API keys is about to expire.",
+		},
+		{
+			Template:    APIKeyBatchExpirationTemplate,
+			Title:       "API key batch expiration reminders",
+			Description: "Reminders sent ahead of time when API keys created together (via API) are about to expire.",
+		},
+		{
+			Template:    PropertyDomainTemplate,
+			Title:       "Property domain warnings",
+			Description: "Warnings sent when the domain of one of your properties no longer resolves or points to localhost.",
+		},
+		{
+			Template:    PropertyAnomalyTemplate,
+			Title:       "Property traffic alerts",
+			Description: "Alerts sent when verification success rate or request volume of your property deviates from its usual level.",
+		},
+		{
+			Template:    TrialExpirationTemplate,
+			Title:       "Trial expiration reminders",
+			Description: "Reminders sent a few days before your trial ends.",
+		},
+		{
+			Template:    TrialExpiredTemplate,
+			Title:       "Trial ended notices",
+			Description: "Notices sent when your trial has ended.",
+		},
 	}
 )
 
 func Templates() []*common.EmailTemplate {
 	return templates
 }
+
+func OptionalTemplates() []*OptionalTemplate {
+	return optionalTemplates
+}
+
+func IsOptionalTemplate(name string) bool {
+	for _, ot := range optionalTemplates {
+		if ot.Template.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsBusinessHoursTemplate checks if notification is not time-critical (digests and anything users can opt out of)
+// and should be delivered during recipient's local business hours
+func IsBusinessHoursTemplate(name string) bool {
+	return (name == StatsDigestTemplate.Name()) || IsOptionalTemplate(name)
+}
+
+// IsBillingTemplate checks if notification should also be delivered to billing contacts of the organization
+func IsBillingTemplate(name string) bool {
+	for _, tpl := range billingTemplates {
+		if tpl.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 )
 
@@ -13,11 +14,44 @@ func TestEmailTemplates(t *testing.T) {
 		OrgInvitationContext
 		APIKeyExpirationContext
 		TwoFactorEmailContext
+		PropertyDomainContext
 		// heap of everything else
 		PortalURL   string
 		CurrentYear int
 		CDNURL      string
 		UserName    string
+		// batch API keys
+		APIKeysCount int
+		// IP allowlist recovery (OrgName is shared with invitation)
+		ClientIP    string
+		RecoveryURL string
+		// property anomaly (PropertyName is shared with domain context)
+		AnomalyMetric     string
+		AnomalyDirection  string
+		AnomalyHour       string
+		CurrentValue      string
+		ExpectedValue     string
+		PropertyStatsPath string
+		// stats digest (OrgName is shared with invitation)
+		DigestFrequency           string
+		DigestPeriod              string
+		DigestRequests            string
+		DigestVerifications       string
+		DigestProperties          []*StatsDigestProperty
+		OrgPath                   string
+		NotificationsSettingsPath string
+		// trials
+		PlanName            string
+		TrialEndDate        string
+		BillingSettingsPath string
+		// monthly quota
+		UsagePercent      string
+		RequestsUsed      string
+		RequestsLimit     string
+		QuotaConsequence  string
+		UsageSettingsPath string
+		// new sign-in (other details are shared with two factor)
+		SessionsSettingsPath string
 	}{
 		APIKeyExpirationContext: APIKeyExpirationContext{
 			APIKeyContext: APIKeyContext{
@@ -41,10 +75,43 @@ func TestEmailTemplates(t *testing.T) {
 			OS:       "Ubuntu",
 			Location: "EE",
 		},
-		UserName:    "John Doe",
-		CDNURL:      "https://cdn.privatecaptcha.com",
-		PortalURL:   "https://portal.privatecaptcha.com",
-		CurrentYear: time.Now().Year(),
+		PropertyDomainContext: PropertyDomainContext{
+			PropertyName:         "My Property",
+			PropertyDomain:       "example.com",
+			DomainProblem:        "no longer resolves",
+			PropertySettingsPath: "org/5/property/7?tab=settings",
+		},
+		UserName:            "John Doe",
+		CDNURL:              "https://cdn.privatecaptcha.com",
+		PortalURL:           "https://portal.privatecaptcha.com",
+		CurrentYear:         time.Now().Year(),
+		APIKeysCount:        12,
+		ClientIP:            "203.0.113.7",
+		RecoveryURL:         "https://portal.privatecaptcha.com/org/5/allowlist/recover/abcdef",
+		AnomalyMetric:       "verification success rate",
+		AnomalyDirection:    "low",
+		AnomalyHour:         "14:00 UTC",
+		CurrentValue:        "42%",
+		ExpectedValue:       "97%",
+		PropertyStatsPath:   "org/5/property/7",
+		DigestFrequency:     "weekly",
+		DigestPeriod:        "05 Oct 2026 - 11 Oct 2026",
+		DigestRequests:      "12,345",
+		DigestVerifications: "10,000",
+		DigestProperties: []*StatsDigestProperty{
+			{Name: "My Property", Requests: "12,345", Verifications: "10,000", SuccessRate: "98.5%"},
+		},
+		OrgPath:                   "org/5",
+		NotificationsSettingsPath: "settings?tab=notifications",
+		PlanName:                  "Professional",
+		TrialEndDate:              "02 Jan 2006",
+		BillingSettingsPath:       "settings?tab=billing",
+		UsagePercent:              "85%",
+		RequestsUsed:              "85,000",
+		RequestsLimit:             "100,000",
+		QuotaConsequence:          "Nothing changes for now.",
+		UsageSettingsPath:         "settings?tab=usage",
+		SessionsSettingsPath:      "settings?tab=sessions",
 	}
 
 	for _, tpl := range templates {
@@ -61,3 +128,36 @@ func TestEmailTemplates(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionalTemplates(t *testing.T) {
+	t.Parallel()
+
+	for _, tpl := range []*common.EmailTemplate{APIKeyExpirationTemplate, PropertyDomainTemplate, PropertyAnomalyTemplate,
+		TrialExpirationTemplate, TrialExpiredTemplate} {
+		if !IsOptionalTemplate(tpl.Name()) {
+			t.Errorf("Template %v is not optional", tpl.Name())
+		}
+	}
+
+	for _, tpl := range []*common.EmailTemplate{TwoFactorEmailTemplate, NewSignInEmailTemplate, OrgInvitationTemplate} {
+		if IsOptionalTemplate(tpl.Name()) {
+			t.Errorf("Template %v is optional", tpl.Name())
+		}
+	}
+}
+
+func TestBillingTemplates(t *testing.T) {
+	t.Parallel()
+
+	for _, tpl := range []*common.EmailTemplate{QuotaWarningTemplate, TrialExpirationTemplate, TrialExpiredTemplate} {
+		if !IsBillingTemplate(tpl.Name()) {
+			t.Errorf("Template %v is not billing", tpl.Name())
+		}
+	}
+
+	for _, tpl := range []*common.EmailTemplate{WelcomeEmailTemplate, APIKeyExpirationTemplate, OrgInvitationTemplate} {
+		if IsBillingTemplate(tpl.Name()) {
+			t.Errorf("Template %v is billing", tpl.Name())
+		}
+	}
+}
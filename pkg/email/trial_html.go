@@ -0,0 +1,135 @@
+package email
+
+import "github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+
+type TrialContext struct {
+	PlanName            string
+	TrialEndDate        string
+	BillingSettingsPath string
+}
+
+type TrialExpirationContext struct {
+	TrialContext
+	ExpireDays int
+}
+
+var (
+	TrialExpirationTemplate = common.NewEmailTemplate("trial-expiration", trialExpirationHTMLTemplate, trialExpirationTextTemplate)
+	TrialExpiredTemplate    = common.NewEmailTemplate("trial-expired", trialExpiredHTMLTemplate, trialExpiredTextTemplate)
+)
+
+const (
+	trialExpirationHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="40" src="{{.CDNURL}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:32px;margin:24px 0 16px">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Your Private Captcha trial of the <i>{{.PlanName}}</i> plan will end in {{.ExpireDays}} days or less (on {{.TrialEndDate}}).
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">To keep your properties working after that, please choose a subscription in the <a href="{{.PortalURL}}/{{.BillingSettingsPath}}">account settings</a>.</p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="https://privatecaptcha.com" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+	trialExpirationTextTemplate = `Hello,
+
+Your Private Captcha trial of the "{{.PlanName}}" plan will end in {{.ExpireDays}} days or less (on {{.TrialEndDate}}).
+
+To keep your properties working after that, please choose a subscription in the account settings ({{.PortalURL}}/{{.BillingSettingsPath}}).
+
+Warmly,
+The Private Captcha team
+
+--
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
+`
+
+	trialExpiredHTMLTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html dir="ltr" lang="en">
+  <head>
+    <link rel="preload" as="image" href="{{.CDNURL}}/portal/img/pc-logo-dark.png" />
+    <meta content="text/html; charset=UTF-8" http-equiv="Content-Type" />
+    <meta name="x-apple-disable-message-reformatting" />
+  </head>
+  <body
+    style='background-color:#ffffff;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Oxygen-Sans,Ubuntu,Cantarell,"Helvetica Neue",sans-serif'
+  >
+    <table
+      align="center"
+      width="100%"
+      border="0"
+      cellpadding="0"
+      cellspacing="0"
+      role="presentation"
+      style="max-width:37.5em;margin:0 auto;padding:20px 0 48px"
+    >
+      <tbody>
+        <tr style="width:100%">
+          <td>
+            <img alt="Private Captcha" height="40" src="{{.CDNURL}}/portal/img/pc-logo-dark.png" style="display:block;outline:none;border:none;text-decoration:none" />
+            <p style="font-size:16px;line-height:32px;margin:24px 0 16px">
+              Hello,
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Your Private Captcha trial of the <i>{{.PlanName}}</i> plan has ended.
+            </p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">You can choose a subscription in the <a href="{{.PortalURL}}/{{.BillingSettingsPath}}">account settings</a> at any time.</p>
+            <p style="font-size:16px;line-height:26px;margin:16px 0">
+              Warmly,<br />The Private Captcha team
+            </p>
+            <hr style="width:100%;border:none;border-top:1px solid #eaeaea;border-color:#cccccc;margin:20px 0" />
+            <p style="font-size:14px;line-height:24px;margin:16px 0;color:#9ca299;margin-bottom:10px">
+                <a href="https://privatecaptcha.com" style="text-decoration:underline;color:#9ca299;">PrivateCaptcha</a> © {{.CurrentYear}} Intmaker OÜ
+            </p>
+          </td>
+        </tr>
+      </tbody>
+    </table>
+  </body>
+</html>`
+	trialExpiredTextTemplate = `Hello,
+
+Your Private Captcha trial of the "{{.PlanName}}" plan has ended.
+
+You can choose a subscription in the account settings ({{.PortalURL}}/{{.BillingSettingsPath}}) at any time.
+
+Warmly,
+The Private Captcha team
+
+--
+
+PrivateCaptcha © {{.CurrentYear}} Intmaker OÜ
+`
+)
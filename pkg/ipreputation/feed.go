@@ -0,0 +1,122 @@
+package ipreputation
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+const (
+	maxFeedSize = 64 * 1024 * 1024
+)
+
+var (
+	errFeedTooLarge = errors.New("feed is too large")
+)
+
+func isRemoteFeed(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+func parseFeedEntry(entry string) (netip.Prefix, bool) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+
+		return prefix, true
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+// ParseFeed reads one IP address or CIDR per line and returns the number of skipped lines. Anything after '#' or ';'
+// is a comment and only the first field of the line is used, which covers plain lists (e.g. Tor bulk exit list),
+// FireHOL netsets and Spamhaus DROP
+func ParseFeed(r io.Reader) ([]netip.Prefix, int, error) {
+	prefixes := make([]netip.Prefix, 0)
+	skipped := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		prefix, ok := parseFeedEntry(fields[0])
+		if !ok {
+			skipped++
+			continue
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, skipped, err
+	}
+
+	return prefixes, skipped, nil
+}
+
+func openFeed(ctx context.Context, client *http.Client, source string) (io.ReadCloser, error) {
+	if !isRemoteFeed(source) {
+		return os.Open(strings.TrimPrefix(source, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected feed status code %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+func loadFeed(ctx context.Context, client *http.Client, source string) ([]netip.Prefix, int, error) {
+	reader, err := openFeed(ctx, client, source)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer reader.Close()
+
+	limited := &io.LimitedReader{R: reader, N: maxFeedSize + 1}
+	prefixes, skipped, err := ParseFeed(limited)
+	if err != nil {
+		return nil, skipped, err
+	}
+
+	if limited.N <= 0 {
+		return nil, skipped, errFeedTooLarge
+	}
+
+	return prefixes, skipped, nil
+}
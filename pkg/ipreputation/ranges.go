@@ -0,0 +1,85 @@
+package ipreputation
+
+import (
+	"net/netip"
+	"slices"
+	"sort"
+)
+
+type addrRange struct {
+	first netip.Addr
+	last  netip.Addr
+}
+
+// Ranges is an immutable set of IP address ranges. Feeds can contain hundreds of thousands of entries so, unlike
+// property access lists, prefixes are merged into sorted non-overlapping ranges for binary search
+type Ranges struct {
+	ranges []addrRange
+}
+
+func lastAddr(p netip.Prefix) netip.Addr {
+	bytes := p.Addr().AsSlice()
+	for i := p.Bits(); i < len(bytes)*8; i++ {
+		bytes[i/8] |= 0x80 >> (i % 8)
+	}
+
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
+
+func NewRanges(prefixes []netip.Prefix) *Ranges {
+	ranges := make([]addrRange, 0, len(prefixes))
+	for _, p := range prefixes {
+		if !p.IsValid() {
+			continue
+		}
+
+		p = p.Masked()
+		ranges = append(ranges, addrRange{first: p.Addr(), last: lastAddr(p)})
+	}
+
+	slices.SortFunc(ranges, func(a, b addrRange) int {
+		return a.first.Compare(b.first)
+	})
+
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if n := len(merged); n > 0 {
+			prev := &merged[n-1]
+			// overlapping or adjacent (IPv4 and IPv6 ranges are never adjacent as Next() does not cross families)
+			if (r.first.Compare(prev.last) <= 0) || (prev.last.Next() == r.first) {
+				if r.last.Compare(prev.last) > 0 {
+					prev.last = r.last
+				}
+				continue
+			}
+		}
+
+		merged = append(merged, r)
+	}
+
+	return &Ranges{ranges: slices.Clip(merged)}
+}
+
+func (r *Ranges) Len() int {
+	if r == nil {
+		return 0
+	}
+
+	return len(r.ranges)
+}
+
+func (r *Ranges) Contains(addr netip.Addr) bool {
+	if (r == nil) || !addr.IsValid() {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	// index of the first range that starts after the address
+	i := sort.Search(len(r.ranges), func(i int) bool {
+		return r.ranges[i].first.Compare(addr) > 0
+	})
+
+	return (i > 0) && (addr.Compare(r.ranges[i-1].last) <= 0)
+}
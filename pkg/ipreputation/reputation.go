@@ -0,0 +1,135 @@
+// Package ipreputation keeps lists of low-reputation IP ranges (proxies, VPNs, Tor exits) from optional external
+// feeds. Feeds are local files (e.g. dropped by cron) or HTTP(S) lists, they are reloaded periodically and on SIGHUP.
+// If a feed cannot be loaded, its previous version is kept.
+package ipreputation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+const (
+	feedTimeout = 30 * time.Second
+)
+
+type Reputation struct {
+	client     *http.Client
+	ranges     atomic.Pointer[Ranges]
+	difficulty atomic.Uint32
+	trigger    chan struct{}
+	sourcesMux sync.Mutex
+	sources    []string
+	// serializes refreshes and guards the cache
+	refreshMux sync.Mutex
+	// last successfully loaded prefixes of each source
+	cache map[string][]netip.Prefix
+}
+
+func New() *Reputation {
+	r := &Reputation{
+		client:  &http.Client{Timeout: feedTimeout},
+		trigger: make(chan struct{}, 1),
+		cache:   make(map[string][]netip.Prefix),
+	}
+
+	r.difficulty.Store(uint32(common.DifficultyLevelHigh))
+
+	return r
+}
+
+func parseSources(value string) []string {
+	sources := make([]string, 0)
+
+	for _, source := range strings.Split(value, ",") {
+		if source = strings.TrimSpace(source); (len(source) > 0) && !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
+
+// UpdateConfig re-reads configured feeds and schedules their reload. Feeds are reloaded even if configuration did
+// not change, as this is also the way to pick up updated file drops
+func (r *Reputation) UpdateConfig(ctx context.Context, cfg common.ConfigStore) {
+	sources := parseSources(cfg.Get(common.IPReputationFeedsKey).Value())
+
+	difficulty := config.AsInt(cfg.Get(common.IPReputationDifficultyKey), int(common.DifficultyLevelHigh))
+	difficulty = min(int(common.MaxDifficultyLevel), max(1, difficulty))
+	r.difficulty.Store(uint32(difficulty))
+
+	r.sourcesMux.Lock()
+	r.sources = sources
+	r.sourcesMux.Unlock()
+
+	slog.DebugContext(ctx, "Updated IP reputation config", "feeds", len(sources), "difficulty", difficulty)
+
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+		// reload is already pending
+	}
+}
+
+// Trigger fires when feeds have to be reloaded outside of the schedule
+func (r *Reputation) Trigger() <-chan struct{} {
+	return r.trigger
+}
+
+// Refresh loads all configured feeds and atomically replaces the lookup set
+func (r *Reputation) Refresh(ctx context.Context) error {
+	r.sourcesMux.Lock()
+	sources := slices.Clone(r.sources)
+	r.sourcesMux.Unlock()
+
+	r.refreshMux.Lock()
+	defer r.refreshMux.Unlock()
+
+	prefixes := make([]netip.Prefix, 0)
+	cache := make(map[string][]netip.Prefix, len(sources))
+	var errs []error
+
+	for _, source := range sources {
+		feed, skipped, err := loadFeed(ctx, r.client, source)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load IP reputation feed", "source", source, "cached", len(r.cache[source]),
+				common.ErrAttr(err))
+			errs = append(errs, err)
+			feed = r.cache[source]
+		} else {
+			slog.DebugContext(ctx, "Loaded IP reputation feed", "source", source, "entries", len(feed), "skipped", skipped)
+		}
+
+		cache[source] = feed
+		prefixes = append(prefixes, feed...)
+	}
+
+	ranges := NewRanges(prefixes)
+	r.ranges.Store(ranges)
+	r.cache = cache
+
+	slog.InfoContext(ctx, "Refreshed IP reputation", "feeds", len(sources), "entries", len(prefixes), "ranges", ranges.Len(),
+		"failed", len(errs))
+
+	return errors.Join(errs...)
+}
+
+// Difficulty returns minimal puzzle difficulty for the address and true if it is listed in any of the feeds
+func (r *Reputation) Difficulty(addr netip.Addr) (uint8, bool) {
+	if !r.ranges.Load().Contains(addr) {
+		return 0, false
+	}
+
+	return uint8(r.difficulty.Load()), true
+}
@@ -0,0 +1,143 @@
+package ipreputation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+func TestParseFeed(t *testing.T) {
+	t.Parallel()
+
+	const feed = `# Tor exits
+1.2.3.4
+  10.0.0.0/8 ; SBL123
+2001:db8::/32
+::ffff:5.6.7.8
+not-an-ip
+
+ExitAddress 9.9.9.9 2026-01-01`
+
+	prefixes, skipped, err := ParseFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prefixes) != 4 {
+		t.Errorf("Unexpected number of prefixes: %v", prefixes)
+	}
+
+	if skipped != 2 {
+		t.Errorf("Unexpected number of skipped lines: %v", skipped)
+	}
+}
+
+func TestRangesContains(t *testing.T) {
+	t.Parallel()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/24"),
+		netip.MustParsePrefix("10.0.1.0/24"),
+		netip.MustParsePrefix("10.0.0.128/25"),
+		netip.MustParsePrefix("192.168.1.7/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+
+	ranges := NewRanges(prefixes)
+	if ranges.Len() != 3 {
+		t.Errorf("Ranges were not merged: %v", ranges.Len())
+	}
+
+	testCases := []struct {
+		addr     string
+		expected bool
+	}{
+		{"10.0.0.0", true},
+		{"10.0.1.255", true},
+		{"10.0.2.0", false},
+		{"9.255.255.255", false},
+		{"192.168.1.7", true},
+		{"192.168.1.8", false},
+		{"::ffff:10.0.0.1", true},
+		{"2001:db8:1::1", true},
+		{"2001:db9::1", false},
+	}
+
+	for _, tc := range testCases {
+		if actual := ranges.Contains(netip.MustParseAddr(tc.addr)); actual != tc.expected {
+			t.Errorf("Unexpected result for %v: %v", tc.addr, actual)
+		}
+	}
+
+	if ranges.Contains(netip.Addr{}) {
+		t.Error("Invalid address is contained")
+	}
+
+	var empty *Ranges
+	if empty.Contains(netip.MustParseAddr("10.0.0.1")) {
+		t.Error("Empty ranges contain address")
+	}
+}
+
+func TestRefreshKeepsCachedFeed(t *testing.T) {
+	t.Parallel()
+
+	var unavailable atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			http.Error(w, "", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("5.6.7.0/24\n"))
+	}))
+	defer srv.Close()
+
+	fileFeed := filepath.Join(t.TempDir(), "tor.txt")
+	if err := os.WriteFile(fileFeed, []byte("1.2.3.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewBaseConfig(config.NewEnvConfig(func(string) string { return "" }))
+	cfg.Add(config.NewStaticValue(common.IPReputationFeedsKey, fileFeed+", "+srv.URL))
+	cfg.Add(config.NewStaticValue(common.IPReputationDifficultyKey, "150"))
+
+	r := New()
+	r.UpdateConfig(t.Context(), cfg)
+
+	select {
+	case <-r.Trigger():
+	default:
+		t.Fatal("Config update did not trigger reload")
+	}
+
+	if err := r.Refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if d, ok := r.Difficulty(netip.MustParseAddr("5.6.7.8")); !ok || (d != 150) {
+		t.Errorf("Unexpected difficulty of the listed address: %v", d)
+	}
+
+	unavailable.Store(true)
+	if err := os.WriteFile(fileFeed, []byte("4.3.2.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Refresh(t.Context()); err == nil {
+		t.Error("Expected refresh error")
+	}
+
+	for addr, expected := range map[string]bool{"5.6.7.8": true, "4.3.2.1": true, "1.2.3.4": false} {
+		if _, ok := r.Difficulty(netip.MustParseAddr(addr)); ok != expected {
+			t.Errorf("Unexpected listing of %v: %v", addr, ok)
+		}
+	}
+}
@@ -151,6 +151,10 @@ func (m *Manager[TKey, T, TBucket]) AddEx(key TKey, n TLevel, tnow time.Time, in
 	return bu.result
 }
 
+func (m *Manager[TKey, T, TBucket]) Len() int {
+	return m.buckets.EstimatedSize()
+}
+
 func (m *Manager[TKey, T, TBucket]) Clear() {
 	m.buckets.InvalidateAll()
 }
@@ -0,0 +1,196 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errAnnouncementMessage  = errors.New("message is empty")
+	errAnnouncementSeverity = errors.New("unknown severity")
+	errAnnouncementPeriod   = errors.New("end has to be after start")
+)
+
+// AnnouncementsAPI allows to schedule, list and cancel system-wide announcements (system notifications) via local API
+type AnnouncementsAPI struct {
+	BusinessDB db.Implementor
+}
+
+type announcementRequest struct {
+	Message  string     `json:"message"`
+	Markdown bool       `json:"markdown"`
+	Severity string     `json:"severity"`
+	Start    *time.Time `json:"start,omitempty"`
+	End      *time.Time `json:"end,omitempty"`
+	// audience (all are optional)
+	UserID    *int32 `json:"user_id,omitempty"`
+	OrgID     *int32 `json:"org_id,omitempty"`
+	ProductID string `json:"product_id,omitempty"`
+	Stage     string `json:"stage,omitempty"`
+}
+
+type announcementResponse struct {
+	ID        int32      `json:"id"`
+	Message   string     `json:"message"`
+	Markdown  bool       `json:"markdown"`
+	Severity  string     `json:"severity"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+	Active    bool       `json:"active"`
+	UserID    *int32     `json:"user_id,omitempty"`
+	OrgID     *int32     `json:"org_id,omitempty"`
+	ProductID string     `json:"product_id,omitempty"`
+	Stage     string     `json:"stage,omitempty"`
+}
+
+func (ar *announcementRequest) params(tnow time.Time) (*dbgen.CreateSystemNotificationParams, error) {
+	if len(ar.Message) == 0 {
+		return nil, errAnnouncementMessage
+	}
+
+	severity, ok := db.ParseNotificationSeverity(ar.Severity)
+	if !ok {
+		return nil, errAnnouncementSeverity
+	}
+
+	start := tnow
+	if ar.Start != nil {
+		start = ar.Start.UTC()
+	}
+
+	arg := &dbgen.CreateSystemNotificationParams{
+		Message:    ar.Message,
+		StartDate:  db.Timestampz(start),
+		Severity:   severity,
+		IsMarkdown: ar.Markdown,
+	}
+
+	if ar.End != nil {
+		if !ar.End.After(start) {
+			return nil, errAnnouncementPeriod
+		}
+		arg.EndDate = db.Timestampz(ar.End.UTC())
+	}
+
+	if ar.UserID != nil {
+		arg.UserID = db.Int(*ar.UserID)
+	}
+
+	if ar.OrgID != nil {
+		arg.OrgID = db.Int(*ar.OrgID)
+	}
+
+	if len(ar.ProductID) > 0 {
+		arg.ExternalProductID = db.Text(ar.ProductID)
+	}
+
+	if len(ar.Stage) > 0 {
+		arg.Stage = db.Text(ar.Stage)
+	}
+
+	return arg, nil
+}
+
+func optionalInt(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+func newAnnouncementResponse(n *dbgen.SystemNotification) *announcementResponse {
+	resp := &announcementResponse{
+		ID:        n.ID,
+		Message:   n.Message,
+		Markdown:  n.IsMarkdown,
+		Severity:  string(n.Severity),
+		Start:     n.StartDate.Time,
+		Active:    n.IsActive.Bool,
+		UserID:    optionalInt(n.UserID),
+		OrgID:     optionalInt(n.OrgID),
+		ProductID: n.ExternalProductID.String,
+		Stage:     n.Stage.String,
+	}
+
+	if n.EndDate.Valid {
+		resp.End = &n.EndDate.Time
+	}
+
+	return resp
+}
+
+func (a *AnnouncementsAPI) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	notifications, err := a.BusinessDB.Impl().RetrieveScheduledSystemNotifications(ctx, time.Now().UTC())
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]*announcementResponse, 0, len(notifications))
+	for _, n := range notifications {
+		response = append(response, newAnnouncementResponse(n))
+	}
+
+	common.SendJSONResponse(ctx, w, response)
+}
+
+func (a *AnnouncementsAPI) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	request := &announcementRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		slog.WarnContext(ctx, "Failed to decode announcement", common.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	arg, err := request.params(time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := a.BusinessDB.Impl().ScheduleSystemNotification(ctx, arg)
+	if err != nil {
+		if err == db.ErrInvalidInput {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.SendJSONResponse(ctx, w, newAnnouncementResponse(n))
+}
+
+func (a *AnnouncementsAPI) Cancel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, _, err := common.IntPathArg(r, common.ParamID, nil /*hasher*/)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := a.BusinessDB.Impl().CancelSystemNotification(ctx, id)
+	if err != nil {
+		if err == db.ErrRecordNotFound {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.SendJSONResponse(ctx, w, newAnnouncementResponse(n))
+}
@@ -0,0 +1,55 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestAnnouncementRequestParams(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC()
+	past := tnow.Add(-time.Hour)
+	future := tnow.Add(time.Hour)
+	orgID := int32(123)
+
+	testCases := []struct {
+		name    string
+		request announcementRequest
+		err     error
+	}{
+		{"empty", announcementRequest{}, errAnnouncementMessage},
+		{"severity", announcementRequest{Message: "test", Severity: "urgent"}, errAnnouncementSeverity},
+		{"period", announcementRequest{Message: "test", End: &past}, errAnnouncementPeriod},
+		{"scheduled", announcementRequest{Message: "test", Start: &future, End: &past}, errAnnouncementPeriod},
+		{"default", announcementRequest{Message: "test"}, nil},
+		{"targeted", announcementRequest{Message: "test", Severity: "critical", End: &future, OrgID: &orgID, Stage: "prod"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			arg, err := tc.request.params(tnow)
+			if err != tc.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !arg.StartDate.Valid || (arg.EndDate.Valid != (tc.request.End != nil)) {
+				t.Errorf("Unexpected period: %v - %v", arg.StartDate, arg.EndDate)
+			}
+
+			if (tc.request.Severity == "") && (arg.Severity != dbgen.NotificationSeverityInfo) {
+				t.Errorf("Unexpected default severity: %v", arg.Severity)
+			}
+
+			if arg.OrgID.Valid != (tc.request.OrgID != nil) || arg.Stage.Valid != (len(tc.request.Stage) > 0) || arg.ExternalProductID.Valid {
+				t.Errorf("Unexpected audience: org %v, stage %v, product %v", arg.OrgID, arg.Stage, arg.ExternalProductID)
+			}
+		})
+	}
+}
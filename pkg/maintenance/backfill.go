@@ -0,0 +1,210 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	backfillCacheKeyPrefix = "clickhouse_backfill/"
+	// checkpoints should outlive any realistic upgrade (finished backfill is not repeated while it's cached)
+	backfillCheckpointTTL = 5 * 365 * 24 * time.Hour
+)
+
+// backfillCheckpoint is the progress of a backfill, stored in DB cache
+type backfillCheckpoint struct {
+	From  time.Time `json:"from"`
+	Until time.Time `json:"until"`
+	// start of the next chunk to process
+	Next time.Time `json:"next"`
+	Done bool      `json:"done"`
+}
+
+// newBackfillCheckpoint plans backfill up to the earliest data in the target table, that was inserted by
+// materialized views after migration (partial chunk with that data is skipped to avoid double counting)
+func newBackfillCheckpoint(b *db.ClickHouseBackfill, earliest, tnow time.Time) *backfillCheckpoint {
+	until := earliest
+	if until.IsZero() {
+		until = tnow
+	}
+	until = until.UTC().Truncate(b.Chunk)
+	from := until.Add(-b.Depth).Truncate(b.Chunk)
+
+	return &backfillCheckpoint{
+		From:  from,
+		Until: until,
+		Next:  from,
+	}
+}
+
+// nextChunk returns time range of the next chunk and if there's anything left to process
+func (cp *backfillCheckpoint) nextChunk(b *db.ClickHouseBackfill) (time.Time, time.Time, bool) {
+	if cp.Done || !cp.Next.Before(cp.Until) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	to := cp.Next.Add(b.Chunk)
+	if to.After(cp.Until) {
+		to = cp.Until
+	}
+
+	return cp.Next, to, true
+}
+
+// BackfillClickHouseJob executes data backfills of ClickHouse migrations, few chunks at a time, so that
+// it can be resumed from the last checkpoint on any node (e.g. after restart during rolling upgrade)
+type BackfillClickHouseJob struct {
+	Store        db.Implementor
+	TimeSeries   common.TimeSeriesStore
+	Backfills    []*db.ClickHouseBackfill
+	ChunksPerRun int
+}
+
+var _ common.PeriodicJob = (*BackfillClickHouseJob)(nil)
+
+func (j *BackfillClickHouseJob) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *BackfillClickHouseJob) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *BackfillClickHouseJob) Jitter() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *BackfillClickHouseJob) Name() string {
+	return "backfill_clickhouse_job"
+}
+
+func (j *BackfillClickHouseJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *BackfillClickHouseJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *BackfillClickHouseJob) loadCheckpoint(ctx context.Context, b *db.ClickHouseBackfill) (*backfillCheckpoint, error) {
+	data, err := j.Store.Impl().RetrieveFromCache(ctx, backfillCacheKeyPrefix+b.Name)
+	if err == db.ErrCacheMiss {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	cp := &backfillCheckpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode backfill checkpoint", "backfill", b.Name, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+func (j *BackfillClickHouseJob) saveCheckpoint(ctx context.Context, b *db.ClickHouseBackfill, cp *backfillCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	return j.Store.Impl().StoreInCache(ctx, backfillCacheKeyPrefix+b.Name, data, backfillCheckpointTTL)
+}
+
+// runBackfill processes next chunks of the backfill and returns if it is finished
+func (j *BackfillClickHouseJob) runBackfill(ctx context.Context, b *db.ClickHouseBackfill, version uint) (bool, error) {
+	blog := slog.With("backfill", b.Name)
+
+	if version < b.Version {
+		blog.DebugContext(ctx, "Backfill migration is not applied yet", "version", version, "required", b.Version)
+		return false, nil
+	}
+
+	cp, err := j.loadCheckpoint(ctx, b)
+	if err != nil {
+		return false, err
+	}
+
+	if cp == nil {
+		earliest, err := j.TimeSeries.RetrieveEarliestTimestamp(ctx, b.Table)
+		if err != nil {
+			return false, err
+		}
+
+		cp = newBackfillCheckpoint(b, earliest, time.Now())
+		// backfilled data would be taken for the data of materialized views if we planned again after a crash
+		if err := j.saveCheckpoint(ctx, b, cp); err != nil {
+			return false, err
+		}
+
+		blog.InfoContext(ctx, "Starting backfill", "from", cp.From, "until", cp.Until)
+	}
+
+	for i := 0; i < j.ChunksPerRun; i++ {
+		from, to, ok := cp.nextChunk(b)
+		if !ok {
+			break
+		}
+
+		if err := j.TimeSeries.ExecBackfill(ctx, b.Table, b.Query, from, to); err != nil {
+			return false, err
+		}
+
+		cp.Next = to
+		if err := j.saveCheckpoint(ctx, b, cp); err != nil {
+			return false, err
+		}
+
+		blog.DebugContext(ctx, "Backfilled chunk", "from", from, "to", to)
+	}
+
+	if !cp.Done && !cp.Next.Before(cp.Until) {
+		cp.Done = true
+		if err := j.saveCheckpoint(ctx, b, cp); err != nil {
+			return false, err
+		}
+
+		blog.InfoContext(ctx, "Finished backfill", "from", cp.From, "until", cp.Until)
+	} else if !cp.Done {
+		blog.InfoContext(ctx, "Backfill is in progress", "next", cp.Next, "until", cp.Until)
+	}
+
+	return cp.Done, nil
+}
+
+func (j *BackfillClickHouseJob) RunOnce(ctx context.Context, params any) error {
+	if len(j.Backfills) == 0 {
+		return nil
+	}
+
+	version, dirty, err := j.TimeSeries.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		slog.WarnContext(ctx, "Skipping backfills with dirty ClickHouse schema", "version", version)
+		return nil
+	}
+
+	// backfills are processed strictly in order as later ones can depend on data of earlier ones
+	for _, b := range j.Backfills {
+		done, err := j.runBackfill(ctx, b, version)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to run backfill", "backfill", b.Name, common.ErrAttr(err))
+			return err
+		}
+
+		if !done {
+			break
+		}
+	}
+
+	return nil
+}
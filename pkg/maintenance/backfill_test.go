@@ -0,0 +1,67 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+func TestBackfillCheckpointChunks(t *testing.T) {
+	t.Parallel()
+
+	b := &db.ClickHouseBackfill{Name: "test", Depth: 24 * time.Hour, Chunk: time.Hour}
+	earliest := time.Date(2025, time.March, 10, 12, 34, 56, 0, time.UTC)
+
+	cp := newBackfillCheckpoint(b, earliest, time.Now())
+
+	// partial chunk with data, inserted by materialized views, is skipped
+	expectedUntil := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+	if !cp.Until.Equal(expectedUntil) || !cp.From.Equal(expectedUntil.Add(-b.Depth)) || !cp.Next.Equal(cp.From) {
+		t.Fatalf("Unexpected checkpoint: %+v", cp)
+	}
+
+	chunks := 0
+	last := time.Time{}
+	for {
+		from, to, ok := cp.nextChunk(b)
+		if !ok {
+			break
+		}
+
+		if !from.Equal(cp.Next) || (to.Sub(from) != b.Chunk) {
+			t.Fatalf("Unexpected chunk from %v to %v", from, to)
+		}
+
+		chunks++
+		last = to
+		cp.Next = to
+	}
+
+	if chunks != 24 {
+		t.Errorf("Unexpected chunks count: %v", chunks)
+	}
+
+	if !last.Equal(cp.Until) {
+		t.Errorf("Last chunk (%v) does not end at the checkpoint end (%v)", last, cp.Until)
+	}
+}
+
+func TestBackfillCheckpointEmptyTable(t *testing.T) {
+	t.Parallel()
+
+	b := &db.ClickHouseBackfill{Name: "test", Depth: 24 * time.Hour, Chunk: 24 * time.Hour}
+	tnow := time.Date(2025, time.March, 10, 12, 34, 56, 0, time.UTC)
+
+	cp := newBackfillCheckpoint(b, time.Time{}, tnow)
+
+	expectedUntil := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	if !cp.Until.Equal(expectedUntil) {
+		t.Errorf("Unexpected checkpoint end: %v", cp.Until)
+	}
+
+	cp.Done = true
+	if _, _, ok := cp.nextChunk(b); ok {
+		t.Error("Finished checkpoint has chunks to process")
+	}
+}
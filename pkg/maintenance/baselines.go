@@ -0,0 +1,286 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+const (
+	anomalyMetricSuccessRate = "verification success rate"
+	anomalyMetricRequests    = "request volume"
+	// verifications are written in batches so the last hour needs some time to settle
+	baselineSettleTime = 10 * time.Minute
+	// floor of success rate deviation, otherwise perfectly stable properties would alert on a single failure
+	minSuccessRateStdDev = 0.01
+)
+
+// CheckPropertyBaselinesJob maintains baselines of verification success rate and request volume per property and
+// per hour of the week (as exponentially smoothed mean and variance) and notifies property owners when the last
+// hour deviates from the baseline by more than Sigma standard deviations. Baselines are used instead of static
+// thresholds since traffic of different properties differs by orders of magnitude.
+type CheckPropertyBaselinesJob struct {
+	BusinessDB db.Implementor
+	TimeSeries common.TimeSeriesStore
+	IDHasher   common.IdentifierHasher
+	// how many standard deviations from the baseline are considered an anomaly
+	Sigma float64
+	// how many samples (weeks) baseline needs before it can be trusted
+	MinSamples int
+	// number of recent samples (weeks) that baseline effectively follows
+	Window int
+	// hours with less requests (or verifications for success rate) are too noisy to alert on
+	MinVolume int
+}
+
+var _ common.PeriodicJob = (*CheckPropertyBaselinesJob)(nil)
+
+type CheckPropertyBaselinesParams struct {
+	Sigma      float64 `json:"sigma"`
+	MinSamples int     `json:"min_samples"`
+	Window     int     `json:"window"`
+	MinVolume  int     `json:"min_volume"`
+}
+
+func (j *CheckPropertyBaselinesJob) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *CheckPropertyBaselinesJob) Interval() time.Duration {
+	return 20 * time.Minute
+}
+
+func (j *CheckPropertyBaselinesJob) Jitter() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *CheckPropertyBaselinesJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *CheckPropertyBaselinesJob) Name() string {
+	return "check_property_baselines_job"
+}
+
+func (j *CheckPropertyBaselinesJob) NewParams() any {
+	return &CheckPropertyBaselinesParams{
+		Sigma:      j.Sigma,
+		MinSamples: j.MinSamples,
+		Window:     j.Window,
+		MinVolume:  j.MinVolume,
+	}
+}
+
+type propertyAnomaly struct {
+	Metric   string
+	Current  float64
+	Expected float64
+}
+
+func (a *propertyAnomaly) formatValue(value float64) string {
+	if a.Metric == anomalyMetricSuccessRate {
+		return fmt.Sprintf("%.1f%%", value*100.0)
+	}
+
+	return fmt.Sprintf("%.0f requests", value)
+}
+
+func (a *propertyAnomaly) direction() string {
+	if a.Current < a.Expected {
+		return "low"
+	}
+
+	return "high"
+}
+
+// smoothBaseline adds value to exponentially smoothed mean and variance. Until there are enough samples to fill
+// the window, it's the same as the plain (population) mean and variance of all samples
+func smoothBaseline(mean, variance float64, samples int32, value float64, window int) (float64, float64) {
+	alpha := 1.0 / float64(max(1, min(int(samples)+1, window)))
+	delta := value - mean
+	mean += alpha * delta
+	variance = (1.0 - alpha) * (variance + alpha*delta*delta)
+	return mean, variance
+}
+
+func nextPropertyBaseline(b *dbgen.PropertyBaseline, stat *common.PropertyHourlyStat, hour time.Time, window int) *dbgen.UpsertPropertyBaselineParams {
+	arg := &dbgen.UpsertPropertyBaselineParams{
+		PropertyID: stat.PropertyID,
+		Weekday:    int16(hour.Weekday()),
+		Hour:       int16(hour.Hour()),
+	}
+
+	if b != nil {
+		arg.Samples = b.Samples
+		arg.RequestsMean = b.RequestsMean
+		arg.RequestsVariance = b.RequestsVariance
+		arg.SuccessSamples = b.SuccessSamples
+		arg.SuccessRateMean = b.SuccessRateMean
+		arg.SuccessRateVariance = b.SuccessRateVariance
+	}
+
+	arg.RequestsMean, arg.RequestsVariance = smoothBaseline(arg.RequestsMean, arg.RequestsVariance, arg.Samples, float64(stat.Requests), window)
+	arg.Samples++
+
+	if rate := stat.SuccessRate(); rate >= 0.0 {
+		arg.SuccessRateMean, arg.SuccessRateVariance = smoothBaseline(arg.SuccessRateMean, arg.SuccessRateVariance, arg.SuccessSamples, rate, window)
+		arg.SuccessSamples++
+	}
+
+	return arg
+}
+
+// detectPropertyAnomalies compares stats of the hour with the baseline for the same hour of the week
+func detectPropertyAnomalies(b *dbgen.PropertyBaseline, stat *common.PropertyHourlyStat, p *CheckPropertyBaselinesParams) []*propertyAnomaly {
+	if b == nil {
+		return nil
+	}
+
+	var anomalies []*propertyAnomaly
+
+	requests := float64(stat.Requests)
+	if (int(b.Samples) >= p.MinSamples) && (max(requests, b.RequestsMean) >= float64(p.MinVolume)) {
+		// request counts are at least as noisy as Poisson process
+		stddev := max(math.Sqrt(b.RequestsVariance), math.Sqrt(b.RequestsMean), 1.0)
+		if math.Abs(requests-b.RequestsMean)/stddev > p.Sigma {
+			anomalies = append(anomalies, &propertyAnomaly{Metric: anomalyMetricRequests, Current: requests, Expected: b.RequestsMean})
+		}
+	}
+
+	verifications := stat.SuccessCount + stat.FailureCount
+	if rate := stat.SuccessRate(); (rate >= 0.0) && (int(b.SuccessSamples) >= p.MinSamples) && (verifications >= uint64(p.MinVolume)) {
+		mean := b.SuccessRateMean
+		// sampling error of the current hour's rate is added to the baseline's own variance
+		sampling := mean * (1.0 - mean) / float64(verifications)
+		stddev := max(math.Sqrt(b.SuccessRateVariance+sampling), minSuccessRateStdDev)
+		if math.Abs(rate-mean)/stddev > p.Sigma {
+			anomalies = append(anomalies, &propertyAnomaly{Metric: anomalyMetricSuccessRate, Current: rate, Expected: mean})
+		}
+	}
+
+	return anomalies
+}
+
+func (j *CheckPropertyBaselinesJob) notify(ctx context.Context, p *dbgen.Property, a *propertyAnomaly, hour time.Time) error {
+	statsPath := fmt.Sprintf("%s/%s/%s/%s", common.OrgEndpoint, j.IDHasher.Encrypt(int(p.OrgID.Int32)),
+		common.PropertyEndpoint, j.IDHasher.Encrypt(int(p.ID)))
+
+	_, err := j.BusinessDB.Impl().CreateUserNotification(ctx, &common.ScheduledNotification{
+		// at most one notification per day for the same metric
+		ReferenceID: fmt.Sprintf("property/%v/anomaly/%s/%s", p.ID, a.Metric, hour.Format(time.DateOnly)),
+		UserID:      p.OrgOwnerID.Int32,
+		Subject:     fmt.Sprintf("[%s] Unusual %s of your property", common.PrivateCaptcha, a.Metric),
+		Data: &email.PropertyAnomalyContext{
+			PropertyName:      p.Name,
+			AnomalyMetric:     a.Metric,
+			AnomalyDirection:  a.direction(),
+			AnomalyHour:       hour.Format("02 Jan 2006 15:04 MST"),
+			CurrentValue:      a.formatValue(a.Current),
+			ExpectedValue:     a.formatValue(a.Expected),
+			PropertyStatsPath: statsPath,
+		},
+		DateTime:     time.Now().UTC(),
+		TemplateHash: email.PropertyAnomalyTemplate.Hash(),
+		Persistent:   false,
+		Condition:    common.NotificationWithSubscription,
+	})
+
+	return err
+}
+
+func (j *CheckPropertyBaselinesJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*CheckPropertyBaselinesParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*CheckPropertyBaselinesParams)
+	}
+
+	// the last complete (and settled) hour
+	hour := time.Now().UTC().Add(-baselineSettleTime).Truncate(time.Hour).Add(-time.Hour)
+	hourEnd := hour.Add(time.Hour)
+
+	stats, err := j.TimeSeries.RetrievePropertiesHourlyStats(ctx, hour)
+	if err != nil {
+		return err
+	}
+
+	baselines, err := j.BusinessDB.Impl().RetrievePropertyBaselines(ctx, hour.Weekday(), hour.Hour())
+	if err != nil {
+		return err
+	}
+
+	statsMap := make(map[int32]*common.PropertyHourlyStat, len(stats))
+	for _, s := range stats {
+		statsMap[s.PropertyID] = s
+	}
+
+	baselinesMap := make(map[int32]*dbgen.PropertyBaseline, len(baselines))
+	for _, b := range baselines {
+		baselinesMap[b.PropertyID] = b
+		// properties without any traffic are still part of the baseline (and can alert on the volume drop)
+		if _, ok := statsMap[b.PropertyID]; !ok {
+			statsMap[b.PropertyID] = &common.PropertyHourlyStat{PropertyID: b.PropertyID}
+		}
+	}
+
+	anomalies := make(map[int32][]*propertyAnomaly)
+	updated := 0
+
+	for propertyID, stat := range statsMap {
+		b := baselinesMap[propertyID]
+		if (b != nil) && !b.UpdatedAt.Time.Before(hourEnd) {
+			// this hour was already processed
+			continue
+		}
+
+		if a := detectPropertyAnomalies(b, stat, p); len(a) > 0 {
+			anomalies[propertyID] = a
+		}
+
+		if err := j.BusinessDB.Impl().UpdatePropertyBaseline(ctx, nextPropertyBaseline(b, stat, hour, p.Window)); err != nil {
+			slog.WarnContext(ctx, "Failed to update property baseline", "propID", propertyID, common.ErrAttr(err))
+			continue
+		}
+
+		updated++
+	}
+
+	if len(anomalies) > 0 {
+		batch := make(map[int32]uint, len(anomalies))
+		for propertyID := range anomalies {
+			batch[propertyID] = 1
+		}
+
+		properties, err := j.BusinessDB.Impl().RetrievePropertiesByID(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		for _, prop := range properties {
+			if prop.DeletedAt.Valid || (prop.Environment != dbgen.PropertyEnvironmentProduction) {
+				continue
+			}
+
+			for _, a := range anomalies[prop.ID] {
+				slog.InfoContext(ctx, "Detected property anomaly", "propID", prop.ID, "metric", a.Metric, "current", a.Current,
+					"expected", a.Expected)
+
+				if err := j.notify(ctx, prop, a, hour); err != nil {
+					slog.ErrorContext(ctx, "Failed to create property anomaly notification", "propID", prop.ID, common.ErrAttr(err))
+				}
+			}
+		}
+	}
+
+	slog.InfoContext(ctx, "Checked property baselines", "hour", hour, "properties", len(statsMap), "updated", updated,
+		"anomalies", len(anomalies))
+
+	return nil
+}
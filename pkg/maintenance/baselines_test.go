@@ -0,0 +1,100 @@
+package maintenance
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestSmoothBaseline(t *testing.T) {
+	t.Parallel()
+
+	values := []float64{10, 20, 30, 40}
+
+	var mean, variance float64
+	for i, v := range values {
+		mean, variance = smoothBaseline(mean, variance, int32(i), v, len(values))
+	}
+
+	// while window is not filled, it is the same as plain mean and variance
+	if math.Abs(mean-25.0) > 1e-9 {
+		t.Errorf("Unexpected mean: %v", mean)
+	}
+
+	if math.Abs(variance-125.0) > 1e-9 {
+		t.Errorf("Unexpected variance: %v", variance)
+	}
+
+	// after that, recent values have more weight
+	mean, _ = smoothBaseline(mean, variance, int32(len(values)), 100, len(values))
+	if math.Abs(mean-43.75) > 1e-9 {
+		t.Errorf("Unexpected smoothed mean: %v", mean)
+	}
+}
+
+func TestNextPropertyBaseline(t *testing.T) {
+	t.Parallel()
+
+	hour := time.Date(2026, time.March, 4, 13, 0, 0, 0, time.UTC)
+
+	arg := nextPropertyBaseline(nil, &common.PropertyHourlyStat{PropertyID: 1, Requests: 100}, hour, 8)
+	if (arg.Weekday != int16(time.Wednesday)) || (arg.Hour != 13) {
+		t.Errorf("Unexpected slot: %v %v", arg.Weekday, arg.Hour)
+	}
+
+	if (arg.Samples != 1) || (arg.RequestsMean != 100) {
+		t.Errorf("Unexpected requests baseline: %v %v", arg.Samples, arg.RequestsMean)
+	}
+
+	// no verifications means success rate is not known
+	if arg.SuccessSamples != 0 {
+		t.Errorf("Unexpected success samples: %v", arg.SuccessSamples)
+	}
+}
+
+func TestDetectPropertyAnomalies(t *testing.T) {
+	t.Parallel()
+
+	params := &CheckPropertyBaselinesParams{Sigma: 4, MinSamples: 4, MinVolume: 50}
+
+	baseline := &dbgen.PropertyBaseline{
+		Samples:             8,
+		RequestsMean:        1000,
+		RequestsVariance:    100 * 100,
+		SuccessSamples:      8,
+		SuccessRateMean:     0.95,
+		SuccessRateVariance: 0.01 * 0.01,
+	}
+
+	testCases := []struct {
+		baseline *dbgen.PropertyBaseline
+		stat     *common.PropertyHourlyStat
+		metrics  []string
+	}{
+		{baseline, &common.PropertyHourlyStat{Requests: 1100, SuccessCount: 950, FailureCount: 50}, nil},
+		{nil, &common.PropertyHourlyStat{Requests: 1100, SuccessCount: 10, FailureCount: 990}, nil},
+		{baseline, &common.PropertyHourlyStat{Requests: 0}, []string{anomalyMetricRequests}},
+		{baseline, &common.PropertyHourlyStat{Requests: 1000, SuccessCount: 500, FailureCount: 500}, []string{anomalyMetricSuccessRate}},
+		// too few verifications to judge success rate
+		{baseline, &common.PropertyHourlyStat{Requests: 1000, SuccessCount: 5, FailureCount: 5}, []string{}},
+		{&dbgen.PropertyBaseline{Samples: 2, RequestsMean: 1000, SuccessSamples: 2, SuccessRateMean: 0.95},
+			&common.PropertyHourlyStat{Requests: 0}, nil},
+	}
+
+	for i, tc := range testCases {
+		anomalies := detectPropertyAnomalies(tc.baseline, tc.stat, params)
+		if len(anomalies) != len(tc.metrics) {
+			t.Errorf("Unexpected anomalies count (%v) for test case %v: expected %v", len(anomalies), i, len(tc.metrics))
+			continue
+		}
+
+		for j, a := range anomalies {
+			if a.Metric != tc.metrics[j] {
+				t.Errorf("Unexpected anomaly metric (%v) for test case %v: expected %v", a.Metric, i, tc.metrics[j])
+			}
+		}
+	}
+}
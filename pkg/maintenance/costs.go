@@ -0,0 +1,343 @@
+package maintenance
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	costsMonthFormat = "2006-01"
+	formatCSV        = "csv"
+	// default unit costs, in the same currency units as plan prices
+	defaultRequestsCostRate = 0.5 // per million of requests (puzzles and verifications)
+	defaultStorageCostRate  = 0.1 // per GB of time series storage per month
+	defaultEmailsCostRate   = 1.0 // per thousand of sent emails
+	paramRequestsRate       = "requests_rate"
+	paramStorageRate        = "storage_rate"
+	paramEmailsRate         = "emails_rate"
+)
+
+// CostRates are unit costs used to estimate serving cost of a tenant
+type CostRates struct {
+	// per million of requests
+	Requests float64 `json:"requests"`
+	// per GB of storage
+	Storage float64 `json:"storage"`
+	// per thousand of emails
+	Emails float64 `json:"emails"`
+}
+
+type OrgCost struct {
+	OrgID         int32  `json:"org_id"`
+	Requests      uint64 `json:"requests"`
+	Verifications uint64 `json:"verifications"`
+	StorageBytes  uint64 `json:"storage_bytes"`
+}
+
+// SubscriptionCost is estimated serving cost of all organizations, owned (and billed to) the same user
+type SubscriptionCost struct {
+	UserID        int32      `json:"user_id"`
+	Email         string     `json:"email"`
+	Plan          string     `json:"plan"`
+	Status        string     `json:"status"`
+	Active        bool       `json:"active"`
+	Requests      uint64     `json:"requests"`
+	Verifications uint64     `json:"verifications"`
+	StorageBytes  uint64     `json:"storage_bytes"`
+	Emails        int64      `json:"emails"`
+	Revenue       float64    `json:"revenue"`
+	Cost          float64    `json:"cost"`
+	Margin        float64    `json:"margin"`
+	Orgs          []*OrgCost `json:"orgs"`
+}
+
+func (sc *SubscriptionCost) estimate(rates *CostRates) {
+	const (
+		million  = 1_000_000.0
+		gigabyte = 1024.0 * 1024.0 * 1024.0
+		thousand = 1_000.0
+	)
+
+	sc.Cost = float64(sc.Requests+sc.Verifications)/million*rates.Requests +
+		float64(sc.StorageBytes)/gigabyte*rates.Storage +
+		float64(sc.Emails)/thousand*rates.Emails
+	sc.Cost = math.Round(sc.Cost*100.0) / 100.0
+	sc.Margin = math.Round((sc.Revenue-sc.Cost)*100.0) / 100.0
+}
+
+type CostReportResponse struct {
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	Rates         *CostRates          `json:"rates"`
+	Subscriptions []*SubscriptionCost `json:"subscriptions"`
+}
+
+// CostReport attributes serving costs (ClickHouse storage, request volume and sent emails) to subscriptions and
+// compares them with plan revenue to find loss-making tenants. Served on the local API only.
+type CostReport struct {
+	BusinessDB  db.Implementor
+	TimeSeries  common.TimeSeriesStore
+	PlanService billing.PlanService
+	Stage       string
+}
+
+func (cr *CostReport) Name() string {
+	return "costs"
+}
+
+// monthlyRevenue returns the price of the subscription plan, normalized per month
+func (cr *CostReport) monthlyRevenue(plan billing.Plan, priceID string) float64 {
+	priceIDMonthly, priceIDYearly := plan.PriceIDs()
+	priceMonthly, priceYearly := plan.Prices()
+
+	switch priceID {
+	case priceIDMonthly:
+		return float64(priceMonthly)
+	case priceIDYearly:
+		return float64(priceYearly) / 12.0
+	default:
+		return 0.0
+	}
+}
+
+func (cr *CostReport) subscriptionCost(user *dbgen.GetUsersWithSubscriptionsRow) *SubscriptionCost {
+	sc := &SubscriptionCost{
+		UserID: user.User.ID,
+		Email:  user.User.Email,
+		Status: user.Status.String,
+		Orgs:   []*OrgCost{},
+	}
+
+	if !user.User.SubscriptionID.Valid {
+		return sc
+	}
+
+	sc.Active = cr.PlanService.IsSubscriptionActive(user.Status.String)
+
+	internal := user.Source.Valid && db.IsInternalSubscription(user.Source.SubscriptionSource)
+	plan, err := cr.PlanService.FindPlan(user.ExternalProductID.String, user.ExternalPriceID.String, cr.Stage, internal)
+	if err != nil {
+		slog.Warn("Failed to find billing plan for cost report", "userID", user.User.ID, common.ErrAttr(err))
+		return sc
+	}
+
+	sc.Plan = plan.Name()
+	if sc.Active {
+		sc.Revenue = cr.monthlyRevenue(plan, user.ExternalPriceID.String)
+	}
+
+	return sc
+}
+
+// Build aggregates usage per subscription and sorts results from the least profitable one
+func (cr *CostReport) Build(usage []*common.OrgUsageStat, emails map[int32]int64, users []*dbgen.GetUsersWithSubscriptionsRow, rates *CostRates) []*SubscriptionCost {
+	subscriptions := make(map[int32]*SubscriptionCost, len(users))
+	for _, user := range users {
+		subscriptions[user.User.ID] = cr.subscriptionCost(user)
+	}
+
+	subscription := func(userID int32) *SubscriptionCost {
+		sc, ok := subscriptions[userID]
+		if !ok {
+			// user could have been deleted already, but their data is still there
+			sc = &SubscriptionCost{UserID: userID, Orgs: []*OrgCost{}}
+			subscriptions[userID] = sc
+		}
+		return sc
+	}
+
+	for _, u := range usage {
+		sc := subscription(u.UserID)
+		sc.Requests += u.Requests
+		sc.Verifications += u.Verifications
+		sc.StorageBytes += u.StorageBytes
+		sc.Orgs = append(sc.Orgs, &OrgCost{
+			OrgID:         u.OrgID,
+			Requests:      u.Requests,
+			Verifications: u.Verifications,
+			StorageBytes:  u.StorageBytes,
+		})
+	}
+
+	for userID, count := range emails {
+		subscription(userID).Emails += count
+	}
+
+	result := make([]*SubscriptionCost, 0, len(subscriptions))
+	for _, sc := range subscriptions {
+		sc.estimate(rates)
+		result = append(result, sc)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Margin != result[j].Margin {
+			return result[i].Margin < result[j].Margin
+		}
+		return result[i].UserID < result[j].UserID
+	})
+
+	return result
+}
+
+func costRateParam(r *http.Request, name string, fallback float64) (float64, error) {
+	value := r.URL.Query().Get(name)
+	if len(value) == 0 {
+		return fallback, nil
+	}
+
+	rate, err := strconv.ParseFloat(value, 64)
+	if (err != nil) || (rate < 0) {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+
+	return rate, nil
+}
+
+func parseCostRates(r *http.Request) (*CostRates, error) {
+	var err error
+	rates := &CostRates{}
+
+	if rates.Requests, err = costRateParam(r, paramRequestsRate, defaultRequestsCostRate); err != nil {
+		return nil, err
+	}
+
+	if rates.Storage, err = costRateParam(r, paramStorageRate, defaultStorageCostRate); err != nil {
+		return nil, err
+	}
+
+	if rates.Emails, err = costRateParam(r, paramEmailsRate, defaultEmailsCostRate); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// parseCostsMonth returns the requested month (previous full month by default)
+func parseCostsMonth(r *http.Request, tnow time.Time) (time.Time, error) {
+	if value := r.URL.Query().Get(common.ParamMonth); len(value) > 0 {
+		return time.Parse(costsMonthFormat, value)
+	}
+
+	return time.Date(tnow.Year(), tnow.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0), nil
+}
+
+func (cr *CostReport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	from, err := parseCostsMonth(r, time.Now().UTC())
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse month", common.ErrAttr(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to := from.AddDate(0, 1, 0)
+
+	rates, err := parseCostRates(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	usage, err := cr.TimeSeries.RetrieveOrgUsage(ctx, from, to)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	emails, err := cr.BusinessDB.Impl().RetrieveSentUserNotificationsCounts(ctx, from, to)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	userIDs := make([]int32, 0, len(emails)+len(usage))
+	for _, u := range usage {
+		userIDs = append(userIDs, u.UserID)
+	}
+	for userID := range emails {
+		userIDs = append(userIDs, userID)
+	}
+	slices.Sort(userIDs)
+	userIDs = slices.Compact(userIDs)
+
+	users, err := cr.BusinessDB.Impl().RetrieveUsersWithSubscriptions(ctx, userIDs)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	subscriptions := cr.Build(usage, emails, users, rates)
+
+	slog.InfoContext(ctx, "Built cost report", "subscriptions", len(subscriptions), "from", from, "to", to)
+
+	if r.URL.Query().Get(common.ParamFormat) == formatCSV {
+		cr.writeCSV(w, r, subscriptions, from)
+		return
+	}
+
+	response := &CostReportResponse{
+		From:          from,
+		To:            to,
+		Rates:         rates,
+		Subscriptions: subscriptions,
+	}
+
+	w.Header().Set(common.HeaderContentType, common.ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(ctx, "Failed to encode cost report", common.ErrAttr(err))
+	}
+}
+
+func (cr *CostReport) writeCSV(w http.ResponseWriter, r *http.Request, subscriptions []*SubscriptionCost, from time.Time) {
+	ctx := r.Context()
+
+	filename := fmt.Sprintf("private-captcha-costs-%s.csv", from.Format(costsMonthFormat))
+	w.Header().Set(common.HeaderContentType, common.ContentTypeCSV)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	header := []string{"user_id", "email", "plan", "status", "active", "orgs", "requests", "verifications", "storage_bytes", "emails", "revenue", "cost", "margin"}
+	if err := writer.Write(header); err != nil {
+		slog.ErrorContext(ctx, "Failed to write CSV header", common.ErrAttr(err))
+		return
+	}
+
+	for _, sc := range subscriptions {
+		row := []string{
+			strconv.Itoa(int(sc.UserID)),
+			sc.Email,
+			sc.Plan,
+			sc.Status,
+			strconv.FormatBool(sc.Active),
+			strconv.Itoa(len(sc.Orgs)),
+			strconv.FormatUint(sc.Requests, 10),
+			strconv.FormatUint(sc.Verifications, 10),
+			strconv.FormatUint(sc.StorageBytes, 10),
+			strconv.FormatInt(sc.Emails, 10),
+			strconv.FormatFloat(sc.Revenue, 'f', 2, 64),
+			strconv.FormatFloat(sc.Cost, 'f', 2, 64),
+			strconv.FormatFloat(sc.Margin, 'f', 2, 64),
+		}
+
+		if err := writer.Write(row); err != nil {
+			slog.ErrorContext(ctx, "Failed to write CSV row", "userID", sc.UserID, common.ErrAttr(err))
+			return
+		}
+	}
+}
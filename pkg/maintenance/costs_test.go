@@ -0,0 +1,102 @@
+package maintenance
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCostReportBuild(t *testing.T) {
+	t.Parallel()
+
+	planService := billing.NewPlanService(nil)
+	trialPlan := planService.GetInternalTrialPlan()
+	_, priceID := trialPlan.PriceIDs()
+
+	report := &CostReport{PlanService: planService, Stage: "test"}
+
+	users := []*dbgen.GetUsersWithSubscriptionsRow{
+		{
+			User:              dbgen.User{ID: 1, Email: "trial@example.com", SubscriptionID: pgtype.Int4{Int32: 1, Valid: true}},
+			ExternalProductID: pgtype.Text{String: trialPlan.ProductID(), Valid: true},
+			ExternalPriceID:   pgtype.Text{String: priceID, Valid: true},
+			Status:            pgtype.Text{String: planService.ActiveTrialStatus(), Valid: true},
+			Source:            dbgen.NullSubscriptionSource{SubscriptionSource: dbgen.SubscriptionSourceInternal, Valid: true},
+		},
+		{
+			User: dbgen.User{ID: 2, Email: "free@example.com"},
+		},
+	}
+
+	usage := []*common.OrgUsageStat{
+		{UserID: 1, OrgID: 10, Requests: 1_000_000, Verifications: 1_000_000},
+		{UserID: 1, OrgID: 11, StorageBytes: 1024 * 1024 * 1024},
+		{UserID: 2, OrgID: 20, Requests: 100},
+		// user deleted since
+		{UserID: 3, OrgID: 30, Requests: 10_000_000},
+	}
+
+	emails := map[int32]int64{2: 1000}
+	rates := &CostRates{Requests: 1.0, Storage: 1.0, Emails: 1.0}
+
+	subscriptions := report.Build(usage, emails, users, rates)
+	if len(subscriptions) != 3 {
+		t.Fatalf("Unexpected subscriptions count: %v", len(subscriptions))
+	}
+
+	// sorted from the least profitable one
+	if (subscriptions[0].UserID != 3) || (subscriptions[0].Cost != 10.0) {
+		t.Errorf("Unexpected first subscription: %+v", subscriptions[0])
+	}
+
+	trial := subscriptions[1]
+	if (trial.UserID != 1) || (trial.Plan != trialPlan.Name()) || !trial.Active || (len(trial.Orgs) != 2) {
+		t.Errorf("Unexpected trial subscription: %+v", trial)
+	}
+
+	if (trial.Cost != 3.0) || (trial.Revenue != 0.0) || (trial.Margin != -3.0) {
+		t.Errorf("Unexpected trial subscription cost: %v (margin %v)", trial.Cost, trial.Margin)
+	}
+
+	free := subscriptions[2]
+	if (free.UserID != 2) || (free.Emails != 1000) || (free.Plan != "") || (free.Cost != 1.0) {
+		t.Errorf("Unexpected free user: %+v", free)
+	}
+}
+
+func TestParseCostsMonth(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		query    string
+		expected time.Time
+		err      bool
+	}{
+		{"", time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC), false},
+		{"?month=2024-06", time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), false},
+		{"?month=2024-13", time.Time{}, true},
+		{"?month=qwerty", time.Time{}, true},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/maintenance/report/costs"+tc.query, nil)
+		month, err := parseCostsMonth(r, tnow)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Expected error for query %q", tc.query)
+			}
+			continue
+		}
+
+		if (err != nil) || !month.Equal(tc.expected) {
+			t.Errorf("Unexpected month %v for query %q (err %v)", month, tc.query, err)
+		}
+	}
+}
@@ -20,6 +20,8 @@ type GarbageCollectDataJob struct {
 	Age        time.Duration
 	BusinessDB db.Implementor
 	TimeSeries common.TimeSeriesStore
+	// zero means no limit
+	MaxPendingDeletes int
 }
 
 var _ common.PeriodicJob = (*GarbageCollectDataJob)(nil)
@@ -45,12 +47,14 @@ func (j *GarbageCollectDataJob) Trigger() <-chan struct{} {
 }
 
 type GarbageCollectDataParams struct {
-	Age time.Duration `json:"age"`
+	Age               time.Duration `json:"age"`
+	MaxPendingDeletes int           `json:"max_pending_deletes"`
 }
 
 func (j *GarbageCollectDataJob) NewParams() any {
 	return &GarbageCollectDataParams{
-		Age: j.Age,
+		Age:               j.Age,
+		MaxPendingDeletes: j.MaxPendingDeletes,
 	}
 }
 
@@ -87,6 +91,15 @@ func (j *GarbageCollectDataJob) purgeOrganizations(ctx context.Context, before t
 
 }
 
+// PurgeUsers permanently deletes users with all their data: first analytics, then everything else (via cascade)
+func PurgeUsers(ctx context.Context, businessDB db.Implementor, timeSeries common.TimeSeriesStore, ids []int32) error {
+	if err := timeSeries.DeleteUsersData(ctx, ids); err != nil {
+		return err
+	}
+
+	return businessDB.Impl().DeleteUsers(ctx, ids)
+}
+
 func (j *GarbageCollectDataJob) purgeUsers(ctx context.Context, before time.Time) error {
 	if users, err := j.BusinessDB.Impl().RetrieveSoftDeletedUsers(ctx, before, maxSoftDeletedUsers); (err == nil) && (len(users) > 0) {
 		ids := make([]int32, 0, len(users))
@@ -94,9 +107,7 @@ func (j *GarbageCollectDataJob) purgeUsers(ctx context.Context, before time.Time
 			ids = append(ids, p.User.ID)
 		}
 
-		if err := j.TimeSeries.DeleteUsersData(ctx, ids); err == nil {
-			_ = j.BusinessDB.Impl().DeleteUsers(ctx, ids)
-		}
+		_ = PurgeUsers(ctx, j.BusinessDB, j.TimeSeries, ids)
 	}
 
 	return nil
@@ -110,6 +121,16 @@ func (j *GarbageCollectDataJob) RunOnce(ctx context.Context, params any) error {
 		p = j.NewParams().(*GarbageCollectDataParams)
 	}
 
+	if p.MaxPendingDeletes > 0 {
+		// purge is retried on the next run as soft-deleted records are kept until their data is deleted
+		if pending, err := j.TimeSeries.PendingDeletesCount(ctx); err != nil {
+			return err
+		} else if pending >= p.MaxPendingDeletes {
+			slog.WarnContext(ctx, "Postponing data purge due to pending deletes", "pending", pending, "max", p.MaxPendingDeletes)
+			return nil
+		}
+	}
+
 	before := time.Now().UTC().Add(-p.Age)
 	if err := j.purgeProperties(ctx, before); err != nil {
 		return err
@@ -225,6 +246,53 @@ func (j *CleanupAuditLogJob) Name() string {
 	return "cleanup_audit_log_job"
 }
 
+type CleanupLimitDecisionsJob struct {
+	BusinessDB   db.Implementor
+	PastInterval time.Duration
+}
+
+var _ common.PeriodicJob = (*CleanupLimitDecisionsJob)(nil)
+
+type CleanupLimitDecisionsParams struct {
+	PastInterval time.Duration `json:"past_interval"`
+}
+
+func (j *CleanupLimitDecisionsJob) NewParams() any {
+	return &CleanupLimitDecisionsParams{
+		PastInterval: j.PastInterval,
+	}
+}
+
+func (j *CleanupLimitDecisionsJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*CleanupLimitDecisionsParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*CleanupLimitDecisionsParams)
+	}
+
+	return j.BusinessDB.Impl().DeleteOldLimitDecisions(ctx, time.Now().UTC().Add(-p.PastInterval))
+}
+
+func (j *CleanupLimitDecisionsJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *CleanupLimitDecisionsJob) Timeout() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *CleanupLimitDecisionsJob) Interval() time.Duration {
+	return 6 * time.Hour
+}
+
+func (j *CleanupLimitDecisionsJob) Jitter() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *CleanupLimitDecisionsJob) Name() string {
+	return "cleanup_limit_decisions_job"
+}
+
 type CleanupAsyncTasksJob struct {
 	BusinessDB   db.Implementor
 	PastInterval time.Duration
@@ -270,3 +338,179 @@ func (j *CleanupAsyncTasksJob) Jitter() time.Duration {
 func (j *CleanupAsyncTasksJob) Name() string {
 	return "cleanup_async_tasks_job"
 }
+
+type CleanupPropertyShareLinksJob struct {
+	BusinessDB   db.Implementor
+	PastInterval time.Duration
+}
+
+var _ common.PeriodicJob = (*CleanupPropertyShareLinksJob)(nil)
+
+type CleanupPropertyShareLinksParams struct {
+	PastInterval time.Duration `json:"past_interval"`
+}
+
+func (j *CleanupPropertyShareLinksJob) NewParams() any {
+	return &CleanupPropertyShareLinksParams{
+		PastInterval: j.PastInterval,
+	}
+}
+
+func (j *CleanupPropertyShareLinksJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*CleanupPropertyShareLinksParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*CleanupPropertyShareLinksParams)
+	}
+
+	return j.BusinessDB.Impl().DeleteExpiredPropertyShareLinks(ctx, time.Now().UTC().Add(-p.PastInterval))
+}
+
+func (j *CleanupPropertyShareLinksJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *CleanupPropertyShareLinksJob) Timeout() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *CleanupPropertyShareLinksJob) Interval() time.Duration {
+	return 6 * time.Hour
+}
+
+func (j *CleanupPropertyShareLinksJob) Jitter() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *CleanupPropertyShareLinksJob) Name() string {
+	return "cleanup_property_share_links_job"
+}
+
+type CleanupPropertyBypassTokensJob struct {
+	BusinessDB   db.Implementor
+	PastInterval time.Duration
+}
+
+var _ common.PeriodicJob = (*CleanupPropertyBypassTokensJob)(nil)
+
+type CleanupPropertyBypassTokensParams struct {
+	PastInterval time.Duration `json:"past_interval"`
+}
+
+func (j *CleanupPropertyBypassTokensJob) NewParams() any {
+	return &CleanupPropertyBypassTokensParams{
+		PastInterval: j.PastInterval,
+	}
+}
+
+func (j *CleanupPropertyBypassTokensJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*CleanupPropertyBypassTokensParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*CleanupPropertyBypassTokensParams)
+	}
+
+	return j.BusinessDB.Impl().DeleteExpiredPropertyBypassTokens(ctx, time.Now().UTC().Add(-p.PastInterval))
+}
+
+func (j *CleanupPropertyBypassTokensJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *CleanupPropertyBypassTokensJob) Timeout() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *CleanupPropertyBypassTokensJob) Interval() time.Duration {
+	return 6 * time.Hour
+}
+
+func (j *CleanupPropertyBypassTokensJob) Jitter() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *CleanupPropertyBypassTokensJob) Name() string {
+	return "cleanup_property_bypass_tokens_job"
+}
+
+type CleanupAPIKeyUsageJob struct {
+	BusinessDB   db.Implementor
+	PastInterval time.Duration
+}
+
+var _ common.PeriodicJob = (*CleanupAPIKeyUsageJob)(nil)
+
+type CleanupAPIKeyUsageParams struct {
+	PastInterval time.Duration `json:"past_interval"`
+}
+
+func (j *CleanupAPIKeyUsageJob) NewParams() any {
+	return &CleanupAPIKeyUsageParams{
+		PastInterval: j.PastInterval,
+	}
+}
+
+func (j *CleanupAPIKeyUsageJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*CleanupAPIKeyUsageParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*CleanupAPIKeyUsageParams)
+	}
+
+	return j.BusinessDB.Impl().DeleteOldAPIKeysUsage(ctx, time.Now().UTC().Add(-p.PastInterval))
+}
+
+func (j *CleanupAPIKeyUsageJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *CleanupAPIKeyUsageJob) Timeout() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *CleanupAPIKeyUsageJob) Interval() time.Duration {
+	return 6 * time.Hour
+}
+
+func (j *CleanupAPIKeyUsageJob) Jitter() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *CleanupAPIKeyUsageJob) Name() string {
+	return "cleanup_apikey_usage_job"
+}
+
+// CleanupTimeSeriesJob removes expired aggregates when time series are kept in Postgres (instead of ClickHouse TTLs)
+type CleanupTimeSeriesJob struct {
+	TimeSeries *db.PostgresTimeSeries
+}
+
+var _ common.PeriodicJob = (*CleanupTimeSeriesJob)(nil)
+
+func (j *CleanupTimeSeriesJob) Timeout() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *CleanupTimeSeriesJob) Interval() time.Duration {
+	return 30 * time.Minute
+}
+
+func (j *CleanupTimeSeriesJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *CleanupTimeSeriesJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *CleanupTimeSeriesJob) Name() string {
+	return "cleanup_timeseries_job"
+}
+
+func (j *CleanupTimeSeriesJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *CleanupTimeSeriesJob) RunOnce(ctx context.Context, params any) error {
+	return j.TimeSeries.DeleteExpiredData(ctx)
+}
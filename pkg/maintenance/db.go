@@ -90,3 +90,50 @@ func (j *CleanupDeletedRecordsJob) RunOnce(ctx context.Context, params any) erro
 	before := time.Now().UTC().Add(-p.Age)
 	return j.Store.Impl().DeleteDeletedRecords(ctx, before)
 }
+
+// ValidateCacheJob samples in-memory cache of this instance and evicts entries that diverged from DB
+type ValidateCacheJob struct {
+	Store      db.Implementor
+	Metrics    common.PlatformMetrics
+	SampleSize int
+}
+
+var _ common.PeriodicJob = (*ValidateCacheJob)(nil)
+
+func (j *ValidateCacheJob) Timeout() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *ValidateCacheJob) Interval() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *ValidateCacheJob) Jitter() time.Duration {
+	return 2 * time.Minute
+}
+
+func (j *ValidateCacheJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *ValidateCacheJob) Name() string {
+	return "validate_cache_job"
+}
+
+func (j *ValidateCacheJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *ValidateCacheJob) RunOnce(ctx context.Context, params any) error {
+	stats, err := j.Store.Impl().ValidateCache(ctx, j.SampleSize)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stats {
+		j.Metrics.ObserveCacheValidation(s.Entity, s.Checked, s.Stale)
+		slog.DebugContext(ctx, "Validated cache entries", "entity", s.Entity, "checked", s.Checked, "stale", s.Stale)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+// RecordDeployJob stores a deploy marker (audit event) when the running version differs from the last recorded one
+type RecordDeployJob struct {
+	BusinessDB db.Implementor
+	GitCommit  string
+	Stage      string
+}
+
+var _ common.OneOffJob = (*RecordDeployJob)(nil)
+
+func (j *RecordDeployJob) Name() string {
+	return "record_deploy_job"
+}
+
+func (j *RecordDeployJob) InitialPause() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RecordDeployJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *RecordDeployJob) RunOnce(ctx context.Context, params any) error {
+	if len(j.GitCommit) == 0 {
+		slog.WarnContext(ctx, "Skipping deploy marker without version")
+		return nil
+	}
+
+	log, err := j.BusinessDB.Impl().RetrieveLatestTableAuditLog(ctx, db.TableNameDeploys)
+	if err == nil {
+		// other instances of the same deploy could have recorded it already
+		deploy := &db.AuditLogDeploy{}
+		if jerr := json.Unmarshal(log.NewValue, deploy); (jerr == nil) && (deploy.GitCommit == j.GitCommit) {
+			slog.DebugContext(ctx, "Deploy marker already exists", "version", j.GitCommit, "recorded", log.CreatedAt.Time)
+			return nil
+		}
+	} else if err != db.ErrRecordNotFound {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Recording deploy marker", "version", j.GitCommit, "stage", j.Stage)
+
+	event := db.NewDeployAuditLogEvent(&db.AuditLogDeploy{GitCommit: j.GitCommit, Stage: j.Stage})
+	j.BusinessDB.AuditLog().RecordEvent(ctx, event, common.AuditLogSourceSystem)
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+const (
+	// daily aggregates of the last day of the period need some time to settle
+	digestSettleTime = 1 * time.Hour
+)
+
+// StatsDigestJob sends weekly or monthly summary of requests and verifications of properties in the org to users
+// that opted in for it. Digests are only created here and are delivered by UserEmailNotificationsJob.
+type StatsDigestJob struct {
+	BusinessDB db.Implementor
+	TimeSeries common.TimeSeriesStore
+	IDHasher   common.IdentifierHasher
+	BatchSize  int
+}
+
+var _ common.PeriodicJob = (*StatsDigestJob)(nil)
+
+type StatsDigestParams struct {
+	BatchSize int `json:"batch_size"`
+}
+
+func (j *StatsDigestJob) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *StatsDigestJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *StatsDigestJob) Jitter() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *StatsDigestJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *StatsDigestJob) Name() string {
+	return "stats_digest_job"
+}
+
+func (j *StatsDigestJob) NewParams() any {
+	return &StatsDigestParams{
+		BatchSize: j.BatchSize,
+	}
+}
+
+// statsDigestPeriod returns the last complete week (starting on Monday) or month before tnow
+func statsDigestPeriod(frequency dbgen.DigestFrequency, tnow time.Time) (time.Time, time.Time) {
+	tnow = tnow.UTC()
+	today := time.Date(tnow.Year(), tnow.Month(), tnow.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch frequency {
+	case dbgen.DigestFrequencyMonthly:
+		to := time.Date(tnow.Year(), tnow.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return to.AddDate(0, -1, 0), to
+	default:
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		to := today.AddDate(0, 0, -daysSinceMonday)
+		return to.AddDate(0, 0, -7), to
+	}
+}
+
+func formatStatsDigestPeriod(frequency dbgen.DigestFrequency, from, to time.Time) string {
+	if frequency == dbgen.DigestFrequencyMonthly {
+		return from.Format("January 2006")
+	}
+
+	return fmt.Sprintf("%s - %s", from.Format("02 Jan 2006"), to.AddDate(0, 0, -1).Format("02 Jan 2006"))
+}
+
+func formatSuccessRate(stat *common.PropertyHourlyStat) string {
+	rate := stat.SuccessRate()
+	if rate < 0.0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", rate*100.0)
+}
+
+func (j *StatsDigestJob) notify(ctx context.Context, d *dbgen.GetPendingStatsDigestsRow, stats []*common.PropertyHourlyStat,
+	properties map[int32]*dbgen.Property, from, to time.Time) error {
+	frequency := d.StatsDigest.Frequency
+
+	digestContext := &email.StatsDigestContext{
+		OrgName:                   d.OrgName,
+		DigestFrequency:           string(frequency),
+		DigestPeriod:              formatStatsDigestPeriod(frequency, from, to),
+		DigestProperties:          make([]*email.StatsDigestProperty, 0, len(stats)),
+		OrgPath:                   fmt.Sprintf("%s/%s", common.OrgEndpoint, j.IDHasher.Encrypt(int(d.StatsDigest.OrgID))),
+		NotificationsSettingsPath: fmt.Sprintf("%s?%s=%s", common.SettingsEndpoint, common.ParamTab, common.NotificationsEndpoint),
+	}
+
+	var requests, verifications uint64
+	for _, s := range stats {
+		p, ok := properties[s.PropertyID]
+		if !ok {
+			continue
+		}
+
+		requests += s.Requests
+		verifications += s.SuccessCount + s.FailureCount
+
+		digestContext.DigestProperties = append(digestContext.DigestProperties, &email.StatsDigestProperty{
+			Name:          p.Name,
+			Requests:      fmt.Sprintf("%d", s.Requests),
+			Verifications: fmt.Sprintf("%d", s.SuccessCount+s.FailureCount),
+			SuccessRate:   formatSuccessRate(s),
+		})
+	}
+
+	digestContext.DigestRequests = fmt.Sprintf("%d", requests)
+	digestContext.DigestVerifications = fmt.Sprintf("%d", verifications)
+
+	_, err := j.BusinessDB.Impl().CreateUserNotification(ctx, &common.ScheduledNotification{
+		ReferenceID:  fmt.Sprintf("org/%v/digest/%s/%s", d.StatsDigest.OrgID, frequency, from.Format(time.DateOnly)),
+		UserID:       d.StatsDigest.UserID,
+		Subject:      fmt.Sprintf("[%s] Your %s summary for %s", common.PrivateCaptcha, frequency, d.OrgName),
+		Data:         digestContext,
+		DateTime:     time.Now().UTC(),
+		TemplateHash: email.StatsDigestTemplate.Hash(),
+		Persistent:   false,
+		Condition:    common.NotificationWithSubscription,
+	})
+
+	return err
+}
+
+func (j *StatsDigestJob) sendDigests(ctx context.Context, frequency dbgen.DigestFrequency, tnow time.Time, batchSize int) error {
+	from, to := statsDigestPeriod(frequency, tnow.Add(-digestSettleTime))
+	rlog := slog.With("frequency", frequency, "from", from, "to", to)
+
+	digests, err := j.BusinessDB.Impl().RetrievePendingStatsDigests(ctx, frequency, to, batchSize)
+	if err != nil {
+		return err
+	}
+
+	if len(digests) == 0 {
+		rlog.DebugContext(ctx, "No pending stats digests")
+		return nil
+	}
+
+	stats, err := j.TimeSeries.RetrievePropertiesStats(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	orgStats := make(map[int32][]*common.PropertyHourlyStat)
+	for _, d := range digests {
+		orgStats[d.StatsDigest.OrgID] = nil
+	}
+
+	batch := make(map[int32]uint)
+	for _, s := range stats {
+		if _, ok := orgStats[s.OrgID]; ok {
+			orgStats[s.OrgID] = append(orgStats[s.OrgID], s)
+			batch[s.PropertyID] = 1
+		}
+	}
+
+	properties := make(map[int32]*dbgen.Property, len(batch))
+	if len(batch) > 0 {
+		items, err := j.BusinessDB.Impl().RetrievePropertiesByID(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range items {
+			if !p.DeletedAt.Valid {
+				properties[p.ID] = p
+			}
+		}
+	}
+
+	for _, s := range orgStats {
+		sort.SliceStable(s, func(i, k int) bool { return s[i].Requests > s[k].Requests })
+	}
+
+	processed := make([]*dbgen.StatsDigest, 0, len(digests))
+	sent := 0
+
+	for _, d := range digests {
+		// digest is marked as processed even if notification failed, since duplicates are rejected anyways and
+		// missing a summary is better than retrying it indefinitely
+		processed = append(processed, &d.StatsDigest)
+
+		if len(orgStats[d.StatsDigest.OrgID]) == 0 {
+			rlog.DebugContext(ctx, "Skipping stats digest without traffic", "userID", d.StatsDigest.UserID, "orgID", d.StatsDigest.OrgID)
+			continue
+		}
+
+		if err := j.notify(ctx, d, orgStats[d.StatsDigest.OrgID], properties, from, to); err != nil {
+			rlog.ErrorContext(ctx, "Failed to create stats digest notification", "userID", d.StatsDigest.UserID,
+				"orgID", d.StatsDigest.OrgID, common.ErrAttr(err))
+			continue
+		}
+
+		sent++
+	}
+
+	if err := j.BusinessDB.Impl().MarkStatsDigestsSent(ctx, processed, to); err != nil {
+		return err
+	}
+
+	rlog.InfoContext(ctx, "Processed stats digests", "count", len(processed), "sent", sent)
+
+	return nil
+}
+
+func (j *StatsDigestJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*StatsDigestParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*StatsDigestParams)
+	}
+
+	tnow := time.Now().UTC()
+
+	var anyErr error
+	for _, frequency := range []dbgen.DigestFrequency{dbgen.DigestFrequencyWeekly, dbgen.DigestFrequencyMonthly} {
+		if err := j.sendDigests(ctx, frequency, tnow, p.BatchSize); err != nil {
+			anyErr = err
+		}
+	}
+
+	return anyErr
+}
@@ -0,0 +1,40 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestStatsDigestPeriod(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		frequency dbgen.DigestFrequency
+		tnow      time.Time
+		from      time.Time
+		to        time.Time
+	}{
+		// Friday
+		{dbgen.DigestFrequencyWeekly, time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC),
+			time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		// Monday
+		{dbgen.DigestFrequencyWeekly, time.Date(2026, 10, 12, 0, 10, 0, 0, time.UTC),
+			time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		// Sunday
+		{dbgen.DigestFrequencyWeekly, time.Date(2026, 10, 11, 23, 59, 0, 0, time.UTC),
+			time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)},
+		{dbgen.DigestFrequencyMonthly, time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC),
+			time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{dbgen.DigestFrequencyMonthly, time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC),
+			time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for i, tc := range testCases {
+		from, to := statsDigestPeriod(tc.frequency, tc.tnow)
+		if !from.Equal(tc.from) || !to.Equal(tc.to) {
+			t.Errorf("Unexpected period (%v): %v - %v", i, from, to)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+const (
+	domainLookupTimeout = 3 * time.Second
+)
+
+// CheckPropertyDomainsJob re-checks resolution of property domains (that is only validated at creation) to catch
+// abandoned or compromised domains: ones that no longer resolve or now point to loopback
+type CheckPropertyDomainsJob struct {
+	BusinessDB db.Implementor
+	Resolver   common.DomainResolver
+	IDHasher   common.IdentifierHasher
+	// how often domain of the same property is re-checked
+	CheckInterval time.Duration
+	// how many properties are checked per run
+	BatchSize int
+	// pause between DNS lookups
+	Delay time.Duration
+}
+
+var _ common.PeriodicJob = (*CheckPropertyDomainsJob)(nil)
+
+type CheckPropertyDomainsParams struct {
+	CheckInterval time.Duration `json:"check_interval"`
+	BatchSize     int           `json:"batch_size"`
+}
+
+func (j *CheckPropertyDomainsJob) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *CheckPropertyDomainsJob) Interval() time.Duration {
+	return 30 * time.Minute
+}
+
+func (j *CheckPropertyDomainsJob) Jitter() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *CheckPropertyDomainsJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *CheckPropertyDomainsJob) Name() string {
+	return "check_property_domains_job"
+}
+
+func (j *CheckPropertyDomainsJob) NewParams() any {
+	return &CheckPropertyDomainsParams{
+		CheckInterval: j.CheckInterval,
+		BatchSize:     j.BatchSize,
+	}
+}
+
+// propertyDomainStatus classifies lookup result. Transient errors (e.g. timeouts) do not change the status
+func propertyDomainStatus(names []net.IPAddr, anyNonLocal bool, err error) (dbgen.PropertyDomainStatus, bool) {
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return dbgen.PropertyDomainStatusUnresolved, true
+		}
+
+		return "", false
+	}
+
+	if len(names) == 0 {
+		return dbgen.PropertyDomainStatusUnresolved, true
+	}
+
+	if !anyNonLocal {
+		return dbgen.PropertyDomainStatusLoopback, true
+	}
+
+	return dbgen.PropertyDomainStatusOk, true
+}
+
+func propertyDomainProblem(status dbgen.PropertyDomainStatus) string {
+	switch status {
+	case dbgen.PropertyDomainStatusUnresolved:
+		return "no longer resolves"
+	case dbgen.PropertyDomainStatusLoopback:
+		return "now points to localhost"
+	default:
+		return ""
+	}
+}
+
+func (j *CheckPropertyDomainsJob) notify(ctx context.Context, p *dbgen.Property, status dbgen.PropertyDomainStatus, tnow time.Time) error {
+	settingsPath := fmt.Sprintf("%s/%s/%s/%s?%s=%s", common.OrgEndpoint, j.IDHasher.Encrypt(int(p.OrgID.Int32)),
+		common.PropertyEndpoint, j.IDHasher.Encrypt(int(p.ID)), common.ParamTab, common.SettingsEndpoint)
+
+	_, err := j.BusinessDB.Impl().CreateUserNotification(ctx, &common.ScheduledNotification{
+		// at most one notification per month for the same problem
+		ReferenceID: fmt.Sprintf("property/%v/domain/%s/%s", p.ID, status, tnow.Format("2006-01")),
+		UserID:      p.OrgOwnerID.Int32,
+		Subject:     fmt.Sprintf("[%s] Domain of your property %s", common.PrivateCaptcha, propertyDomainProblem(status)),
+		Data: &email.PropertyDomainContext{
+			PropertyName:         p.Name,
+			PropertyDomain:       p.Domain,
+			DomainProblem:        propertyDomainProblem(status),
+			PropertySettingsPath: settingsPath,
+		},
+		DateTime:     tnow,
+		TemplateHash: email.PropertyDomainTemplate.Hash(),
+		Persistent:   false,
+		Condition:    common.NotificationWithSubscription,
+	})
+
+	return err
+}
+
+func (j *CheckPropertyDomainsJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*CheckPropertyDomainsParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*CheckPropertyDomainsParams)
+	}
+
+	tnow := time.Now().UTC()
+	properties, err := j.BusinessDB.Impl().RetrievePropertiesForDomainCheck(ctx, tnow.Add(-p.CheckInterval), int32(p.BatchSize))
+	if err != nil {
+		return err
+	}
+
+	type lookupResult struct {
+		status dbgen.PropertyDomainStatus
+		ok     bool
+	}
+	// many properties can share the same domain
+	results := make(map[string]*lookupResult)
+	changed := 0
+
+	for i, prop := range properties {
+		plog := slog.With("propID", prop.ID, "domain", prop.Domain)
+
+		result, cached := results[prop.Domain]
+		if !cached {
+			if (i > 0) && (j.Delay > 0) {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(j.Delay):
+				}
+			}
+
+			names, anyNonLocal, lerr := common.LookupDomainName(ctx, j.Resolver, prop.Domain, domainLookupTimeout)
+			status, ok := propertyDomainStatus(names, anyNonLocal, lerr)
+			if !ok {
+				plog.WarnContext(ctx, "Failed to resolve property domain", common.ErrAttr(lerr))
+			}
+
+			result = &lookupResult{status: status, ok: ok}
+			results[prop.Domain] = result
+		}
+
+		status := result.status
+		if !result.ok {
+			// keep the status, but still mark property as checked so that it does not block the queue
+			status = prop.DomainStatus
+		}
+
+		if err := j.BusinessDB.Impl().UpdatePropertyDomainStatus(ctx, prop, status, tnow); err != nil {
+			return err
+		}
+
+		if prop.DomainStatus == status {
+			continue
+		}
+
+		changed++
+
+		if (prop.DomainStatus == dbgen.PropertyDomainStatusOk) && (prop.Environment == dbgen.PropertyEnvironmentProduction) {
+			if err := j.notify(ctx, prop, status, tnow); err != nil {
+				plog.ErrorContext(ctx, "Failed to create property domain notification", common.ErrAttr(err))
+			}
+		}
+	}
+
+	slog.InfoContext(ctx, "Checked property domains", "properties", len(properties), "domains", len(results), "changed", changed)
+
+	return nil
+}
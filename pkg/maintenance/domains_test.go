@@ -0,0 +1,49 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+type stubResolver struct {
+	addrs []net.IPAddr
+	err   error
+}
+
+func (r *stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return r.addrs, r.err
+}
+
+func TestPropertyDomainStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		resolver *stubResolver
+		status   dbgen.PropertyDomainStatus
+		ok       bool
+	}{
+		{"public", &stubResolver{addrs: []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}, {IP: net.ParseIP("93.184.215.14")}}}, dbgen.PropertyDomainStatusOk, true},
+		{"loopback", &stubResolver{addrs: []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}, {IP: net.ParseIP("::1")}}}, dbgen.PropertyDomainStatusLoopback, true},
+		{"empty", &stubResolver{}, dbgen.PropertyDomainStatusUnresolved, true},
+		{"notFound", &stubResolver{err: &net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}}, dbgen.PropertyDomainStatusUnresolved, true},
+		{"timeout", &stubResolver{err: &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}}, "", false},
+		{"other", &stubResolver{err: errors.New("network is unreachable")}, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, anyNonLocal, err := common.LookupDomainName(t.Context(), tc.resolver, "example.com", time.Second)
+			status, ok := propertyDomainStatus(names, anyNonLocal, err)
+			if (status != tc.status) || (ok != tc.ok) {
+				t.Errorf("Unexpected status %v (ok: %v)", status, ok)
+			}
+		})
+	}
+}
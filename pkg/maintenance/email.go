@@ -49,18 +49,20 @@ func (j *RegisterEmailTemplatesJob) RunOnce(ctx context.Context, params any) err
 
 type UserEmailNotificationsJob struct {
 	// this is the "actual" interval since we will be running as a DB-locked distributed job
-	RunInterval  time.Duration
-	Store        db.Implementor
-	Templates    []*common.EmailTemplate
-	Sender       email.Sender
-	ChunkSize    int
-	MaxAttempts  int
-	EmailFrom    common.ConfigItem
-	ReplyToEmail common.ConfigItem
-	PlanService  billing.PlanService
-	CDNURL       string
-	PortalURL    string
-	UserIDs      map[int32]struct{}
+	RunInterval time.Duration
+	Store       db.Implementor
+	Templates   []*common.EmailTemplate
+	// templates that users can opt out of, everything else is always sent
+	OptionalTemplates []*email.OptionalTemplate
+	Sender            email.Sender
+	ChunkSize         int
+	MaxAttempts       int
+	EmailFrom         common.ConfigItem
+	ReplyToEmail      common.ConfigItem
+	PlanService       billing.PlanService
+	CDNURL            string
+	PortalURL         string
+	UserIDs           map[int32]struct{}
 }
 
 var _ common.PeriodicJob = (*UserEmailNotificationsJob)(nil)
@@ -118,6 +120,86 @@ func indexTemplates(ctx context.Context, templates []*common.EmailTemplate) map[
 	return tplMap
 }
 
+func notificationUserIDs(notifications []*dbgen.GetPendingUserNotificationsRow) []int32 {
+	seen := make(map[int32]struct{}, len(notifications))
+	result := make([]int32, 0, len(notifications))
+	for _, n := range notifications {
+		if !n.UserNotification.UserID.Valid {
+			continue
+		}
+		userID := n.UserNotification.UserID.Int32
+		if _, ok := seen[userID]; !ok {
+			seen[userID] = struct{}{}
+			result = append(result, userID)
+		}
+	}
+	return result
+}
+
+func notificationOrgIDs(notifications []*dbgen.GetPendingUserNotificationsRow) []int32 {
+	seen := make(map[int32]struct{}, len(notifications))
+	result := make([]int32, 0, len(notifications))
+	for _, n := range notifications {
+		if !n.UserNotification.OrgID.Valid {
+			continue
+		}
+		orgID := n.UserNotification.OrgID.Int32
+		if _, ok := seen[orgID]; !ok {
+			seen[orgID] = struct{}{}
+			result = append(result, orgID)
+		}
+	}
+	return result
+}
+
+// splitOptedOutNotifications separates notifications of users who opted out of the template from the rest
+func splitOptedOutNotifications(notifications []*dbgen.GetPendingUserNotificationsRow,
+	templateName string,
+	optOuts map[int32]map[string]struct{}) (send []*dbgen.GetPendingUserNotificationsRow, suppressed []int32) {
+	send = make([]*dbgen.GetPendingUserNotificationsRow, 0, len(notifications))
+
+	for _, n := range notifications {
+		if n.UserNotification.UserID.Valid {
+			if templates, ok := optOuts[n.UserNotification.UserID.Int32]; ok {
+				if _, optedOut := templates[templateName]; optedOut {
+					suppressed = append(suppressed, n.UserNotification.ID)
+					continue
+				}
+			}
+		}
+
+		send = append(send, n)
+	}
+
+	return send, suppressed
+}
+
+// notificationRecipients returns addresses to deliver the notification to: billing notifications go to billing
+// contacts of the organization they concern (and to the user, unless org opted out), everything else - to the user only
+func notificationRecipients(n *dbgen.GetPendingUserNotificationsRow, billing map[int32]*common.BillingRecipients) []string {
+	if (billing == nil) || !n.UserNotification.OrgID.Valid {
+		return []string{n.Email}
+	}
+
+	recipients, ok := billing[n.UserNotification.OrgID.Int32]
+	if !ok || (len(recipients.Contacts) == 0) {
+		return []string{n.Email}
+	}
+
+	result := make([]string, 0, len(recipients.Contacts)+1)
+	if recipients.NotifyOwner {
+		result = append(result, n.Email)
+	}
+
+	for _, c := range recipients.Contacts {
+		if c != n.Email {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
 type preparedNotificationTemplate struct {
 	htmlTemplate *htmltpl.Template
 	textTemplate *texttpl.Template
@@ -244,6 +326,29 @@ func (j *UserEmailNotificationsJob) RunOnce(ctx context.Context, params any) err
 
 	templates := indexTemplates(ctx, j.Templates)
 
+	optionalTemplates := make(map[string]struct{}, len(j.OptionalTemplates))
+	for _, ot := range j.OptionalTemplates {
+		optionalTemplates[ot.Template.Name()] = struct{}{}
+	}
+
+	var billingRecipients map[int32]*common.BillingRecipients
+	var billingErr error
+	retrieveBillingRecipients := func() (map[int32]*common.BillingRecipients, error) {
+		if (billingRecipients == nil) && (billingErr == nil) {
+			billingRecipients, billingErr = j.Store.Impl().RetrieveBillingRecipients(ctx, notificationOrgIDs(notifications))
+		}
+		return billingRecipients, billingErr
+	}
+
+	var optOuts map[int32]map[string]struct{}
+	if len(optionalTemplates) > 0 {
+		optOuts, err = j.Store.Impl().RetrieveNotificationOptOuts(ctx, notificationUserIDs(notifications))
+		if err != nil {
+			// optional notifications will be skipped in this run and picked up again next time
+			slog.ErrorContext(ctx, "Failed to retrieve notification opt-outs", common.ErrAttr(err))
+		}
+	}
+
 	b := &backoff.Backoff{
 		Min:    50 * time.Millisecond,
 		Max:    1 * time.Second,
@@ -259,7 +364,49 @@ func (j *UserEmailNotificationsJob) RunOnce(ctx context.Context, params any) err
 		}
 
 		if tpl, err := j.retrieveTemplate(ctx, templates, tplHash); err == nil {
-			processedIDs := j.processNotificationsChunk(ctx, tpl, nn, b)
+			if _, ok := optionalTemplates[tpl.name]; ok {
+				if optOuts == nil {
+					slog.WarnContext(ctx, "Skipping optional notifications without opt-out preferences", "name", tpl.name, "count", len(nn))
+					continue
+				}
+
+				var suppressedIDs []int32
+				if nn, suppressedIDs = splitOptedOutNotifications(nn, tpl.name, optOuts); len(suppressedIDs) > 0 {
+					slog.InfoContext(ctx, "Suppressing opted out notifications", "name", tpl.name, "count", len(suppressedIDs))
+					if err := j.Store.Impl().MarkUserNotificationsSuppressed(ctx, suppressedIDs, time.Now().UTC()); err != nil {
+						slog.ErrorContext(ctx, "Failed to mark notifications suppressed", common.ErrAttr(err))
+					}
+				}
+
+				if len(nn) == 0 {
+					continue
+				}
+			}
+
+			if email.IsBusinessHoursTemplate(tpl.name) {
+				var deferred map[int32]time.Time
+				if nn, deferred = splitOutOfHoursNotifications(ctx, nn, time.Now().UTC()); len(deferred) > 0 {
+					slog.InfoContext(ctx, "Deferring notifications until local business hours", "name", tpl.name, "count", len(deferred))
+					if err := j.Store.Impl().RescheduleUserNotifications(ctx, deferred); err != nil {
+						slog.ErrorContext(ctx, "Failed to reschedule notifications", common.ErrAttr(err))
+					}
+				}
+
+				if len(nn) == 0 {
+					continue
+				}
+			}
+
+			var recipients map[int32]*common.BillingRecipients
+			if email.IsBillingTemplate(tpl.name) {
+				if recipients, err = retrieveBillingRecipients(); err != nil {
+					// same as with opt-outs, billing notifications will be picked up again next time
+					slog.ErrorContext(ctx, "Skipping billing notifications without billing contacts", "name", tpl.name, "count", len(nn), common.ErrAttr(err))
+					continue
+				}
+			}
+
+			processedIDs := j.processNotificationsChunk(ctx, tpl, nn, recipients, b)
 			// NOTE: potentially it's not most efficient to update them piece by piece, but it's less error-prone
 			j.updateNotifications(ctx, nn, processedIDs)
 		} else {
@@ -305,6 +452,7 @@ func isValidUserNotification(un *dbgen.UserNotification) bool {
 func (j *UserEmailNotificationsJob) processNotificationsChunk(ctx context.Context,
 	tpl *preparedNotificationTemplate,
 	notifications []*dbgen.GetPendingUserNotificationsRow,
+	billing map[int32]*common.BillingRecipients,
 	b *backoff.Backoff) []int32 {
 	emailFrom := j.EmailFrom.Value()
 	replyToEmail := j.ReplyToEmail.Value()
@@ -362,18 +510,29 @@ func (j *UserEmailNotificationsJob) processNotificationsChunk(ctx context.Contex
 			}
 		}
 
-		msg := &email.Message{
-			Subject:   un.Subject,
-			EmailTo:   n.Email,
-			EmailFrom: emailFrom,
-			NameFrom:  common.PrivateCaptchaTeam,
-			ReplyTo:   replyToEmail,
-			HTMLBody:  htmlBodyTpl.String(),
-			TextBody:  textBodyTpl.String(),
+		sent := 0
+		for _, emailTo := range notificationRecipients(n, billing) {
+			msg := &email.Message{
+				Subject:   un.Subject,
+				EmailTo:   emailTo,
+				EmailFrom: emailFrom,
+				NameFrom:  common.PrivateCaptchaTeam,
+				ReplyTo:   replyToEmail,
+				HTMLBody:  htmlBodyTpl.String(),
+				TextBody:  textBodyTpl.String(),
+				Region:    email.CountryRegion(n.Country.String),
+			}
+
+			if err := j.Sender.SendEmail(ctx, msg); err != nil {
+				nlog.ErrorContext(ctx, "Failed to send notification email", common.ErrAttr(err))
+				continue
+			}
+
+			sent++
 		}
 
-		if err := j.Sender.SendEmail(ctx, msg); err != nil {
-			nlog.ErrorContext(ctx, "Failed to send notification email", common.ErrAttr(err))
+		// NOTE: notification is not retried if at least one recipient got it, not to spam the others
+		if sent == 0 {
 			continue
 		}
 
@@ -435,6 +594,12 @@ func (j *CleanupUserNotificationsJob) RunOnce(ctx context.Context, params any) e
 		anyError = err
 	}
 
+	// payloads are referenced only by notifications, so they can go as soon as notifications are deleted
+	if err := j.Store.Impl().DeleteUnusedNotificationPayloads(ctx, tnow.AddDate(0, -p.NotificationMonths, 0)); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete unused notification payloads", common.ErrAttr(err))
+		anyError = err
+	}
+
 	return anyError
 }
 
@@ -0,0 +1,182 @@
+package maintenance
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	texttpl "text/template"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jpillora/backoff"
+)
+
+type recipientsSender struct {
+	lock       sync.Mutex
+	recipients []string
+}
+
+var _ email.Sender = (*recipientsSender)(nil)
+
+func (s *recipientsSender) SendEmail(ctx context.Context, msg *email.Message) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.recipients = append(s.recipients, msg.EmailTo)
+	return nil
+}
+
+func TestNotificationRecipients(t *testing.T) {
+	t.Parallel()
+
+	n := &dbgen.GetPendingUserNotificationsRow{
+		UserNotification: dbgen.UserNotification{
+			UserID: pgtype.Int4{Int32: 1, Valid: true},
+			OrgID:  pgtype.Int4{Int32: 2, Valid: true},
+		},
+		Email: "owner@example.com",
+	}
+
+	testCases := []struct {
+		name     string
+		billing  map[int32]*common.BillingRecipients
+		expected []string
+	}{
+		{"not billing", nil, []string{"owner@example.com"}},
+		{"no contacts", map[int32]*common.BillingRecipients{}, []string{"owner@example.com"}},
+		{"other org", map[int32]*common.BillingRecipients{
+			3: {Contacts: []string{"billing@example.com"}, NotifyOwner: false},
+		}, []string{"owner@example.com"}},
+		{"in addition", map[int32]*common.BillingRecipients{
+			2: {Contacts: []string{"billing@example.com"}, NotifyOwner: true},
+		}, []string{"owner@example.com", "billing@example.com"}},
+		{"instead of", map[int32]*common.BillingRecipients{
+			2: {Contacts: []string{"billing@example.com", "finance@example.com"}, NotifyOwner: false},
+		}, []string{"billing@example.com", "finance@example.com"}},
+		{"owner is a contact", map[int32]*common.BillingRecipients{
+			2: {Contacts: []string{"owner@example.com", "billing@example.com"}, NotifyOwner: true},
+		}, []string{"owner@example.com", "billing@example.com"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := notificationRecipients(n, tc.billing); !slices.Equal(actual, tc.expected) {
+				t.Errorf("Expected %v but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestProcessBillingNotificationsChunk(t *testing.T) {
+	t.Parallel()
+
+	sender := &recipientsSender{}
+	job := &UserEmailNotificationsJob{
+		Sender:       sender,
+		EmailFrom:    config.NewStaticValue(common.EmailFromKey, "noreply@example.com"),
+		ReplyToEmail: config.NewStaticValue(common.ReplyToEmailKey, "support@example.com"),
+	}
+
+	textTemplate, err := texttpl.New("NotificationText").Parse("Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &preparedNotificationTemplate{textTemplate: textTemplate, name: email.QuotaWarningTemplate.Name()}
+
+	notification := func(id int32, orgID int32, emailTo string) *dbgen.GetPendingUserNotificationsRow {
+		return &dbgen.GetPendingUserNotificationsRow{
+			UserNotification: dbgen.UserNotification{
+				ID:          id,
+				UserID:      pgtype.Int4{Int32: id, Valid: true},
+				OrgID:       pgtype.Int4{Int32: orgID, Valid: orgID != 0},
+				Subject:     "Usage warning",
+				ReferenceID: "quota-warning",
+				Payload:     []byte("{}"),
+				ScheduledAt: pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+				TemplateID:  pgtype.Text{String: email.QuotaWarningTemplate.Hash(), Valid: true},
+			},
+			Email: emailTo,
+		}
+	}
+
+	notifications := []*dbgen.GetPendingUserNotificationsRow{
+		notification(1, 10, "first@example.com"),
+		notification(2, 0, "second@example.com"),
+	}
+
+	billing := map[int32]*common.BillingRecipients{
+		10: {Contacts: []string{"billing@example.com"}, NotifyOwner: false},
+	}
+
+	processedIDs := job.processNotificationsChunk(t.Context(), tpl, notifications, billing, &backoff.Backoff{Min: time.Millisecond, Max: time.Millisecond})
+	if !slices.Equal(processedIDs, []int32{1, 2}) {
+		t.Errorf("Unexpected processed notifications: %v", processedIDs)
+	}
+
+	if expected := []string{"billing@example.com", "second@example.com"}; !slices.Equal(sender.recipients, expected) {
+		t.Errorf("Expected %v but got %v", expected, sender.recipients)
+	}
+}
+
+func TestNextBusinessHours(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		tnow     time.Time
+		expected time.Time
+	}{
+		{"within", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)},
+		{"early morning", time.Date(2026, 10, 14, 5, 0, 0, 0, time.UTC), time.Date(2026, 10, 14, 7, 0, 0, 0, time.UTC)},
+		{"friday evening", time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC)},
+		// DST ends on Sunday
+		{"weekend", time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 26, 8, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := nextBusinessHours(tc.tnow, loc); !actual.Equal(tc.expected) {
+				t.Errorf("Expected %v but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitOutOfHoursNotifications(t *testing.T) {
+	t.Parallel()
+
+	notification := func(id int32, timezone string) *dbgen.GetPendingUserNotificationsRow {
+		return &dbgen.GetPendingUserNotificationsRow{
+			UserNotification: dbgen.UserNotification{ID: id},
+			Timezone:         pgtype.Text{String: timezone, Valid: len(timezone) > 0},
+		}
+	}
+
+	// 14:00 in London, but 23:00 in Tokyo
+	tnow := time.Date(2026, 10, 15, 13, 0, 0, 0, time.UTC)
+	notifications := []*dbgen.GetPendingUserNotificationsRow{
+		notification(1, ""),
+		notification(2, "Europe/London"),
+		notification(3, "Asia/Tokyo"),
+		notification(4, "Not/AZone"),
+	}
+
+	send, deferred := splitOutOfHoursNotifications(t.Context(), notifications, tnow)
+	if len(send) != 3 {
+		t.Errorf("Unexpected number of notifications to send: %v", len(send))
+	}
+
+	if scheduledAt, ok := deferred[3]; !ok || !scheduledAt.Equal(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected deferred notifications: %v", deferred)
+	}
+}
@@ -0,0 +1,119 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/difficulty"
+)
+
+// RefreshDifficultyExperimentsJob keeps in-memory registry of running experiments in sync with DB (on every instance)
+type RefreshDifficultyExperimentsJob struct {
+	BusinessDB  db.Implementor
+	Experiments *difficulty.Experiments
+}
+
+var _ common.PeriodicJob = (*RefreshDifficultyExperimentsJob)(nil)
+
+func (j *RefreshDifficultyExperimentsJob) Timeout() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshDifficultyExperimentsJob) Interval() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *RefreshDifficultyExperimentsJob) Jitter() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshDifficultyExperimentsJob) Name() string {
+	return "refresh_difficulty_experiments_job"
+}
+
+func (j *RefreshDifficultyExperimentsJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *RefreshDifficultyExperimentsJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *RefreshDifficultyExperimentsJob) RunOnce(ctx context.Context, params any) error {
+	experiments, err := j.BusinessDB.Impl().RetrieveRunningDifficultyExperiments(ctx)
+	if err != nil {
+		return err
+	}
+
+	j.Experiments.Update(experiments)
+
+	slog.DebugContext(ctx, "Refreshed difficulty experiments", "count", j.Experiments.Count())
+
+	return nil
+}
+
+// ConcludeDifficultyExperimentsJob applies conclusion rules to running experiments
+type ConcludeDifficultyExperimentsJob struct {
+	BusinessDB db.Implementor
+	TimeSeries common.TimeSeriesStore
+}
+
+var _ common.PeriodicJob = (*ConcludeDifficultyExperimentsJob)(nil)
+
+func (j *ConcludeDifficultyExperimentsJob) Timeout() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *ConcludeDifficultyExperimentsJob) Interval() time.Duration {
+	return 15 * time.Minute
+}
+
+func (j *ConcludeDifficultyExperimentsJob) Jitter() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *ConcludeDifficultyExperimentsJob) Name() string {
+	return "conclude_difficulty_experiments_job"
+}
+
+func (j *ConcludeDifficultyExperimentsJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *ConcludeDifficultyExperimentsJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *ConcludeDifficultyExperimentsJob) RunOnce(ctx context.Context, params any) error {
+	experiments, err := j.BusinessDB.Impl().RetrieveRunningDifficultyExperiments(ctx)
+	if err != nil {
+		return err
+	}
+
+	tnow := time.Now().UTC()
+
+	for _, e := range experiments {
+		elog := slog.With("experimentID", e.ID, "propID", e.PropertyID)
+
+		stats, err := j.TimeSeries.RetrieveExperimentStats(ctx, e.OrgID, e.PropertyID, e.CreatedAt.Time, tnow)
+		if err != nil {
+			elog.ErrorContext(ctx, "Failed to retrieve experiment stats", common.ErrAttr(err))
+			continue
+		}
+
+		outcome, conclusion, done := difficulty.ConcludeExperiment(e, stats, tnow)
+		if !done {
+			elog.DebugContext(ctx, "Difficulty experiment is still running")
+			continue
+		}
+
+		if _, err := j.BusinessDB.Impl().ConcludeDifficultyExperiment(ctx, e.ID, outcome, conclusion, tnow); err != nil {
+			elog.ErrorContext(ctx, "Failed to conclude difficulty experiment", common.ErrAttr(err))
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package maintenance
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type HealthCheckJob struct {
@@ -19,14 +21,20 @@ type HealthCheckJob struct {
 	postgresFlag     atomic.Int32
 	clickhouseFlag   atomic.Int32
 	shuttingDownFlag atomic.Int32
-	CheckInterval    common.ConfigItem
-	Metrics          common.PlatformMetrics
-	StrictReadiness  bool
+	migrationFlag    atomic.Int32
+	// optional, used to detect that schema is older than required by this node
+	Pool          *pgxpool.Pool
+	CheckInterval common.ConfigItem
+	Metrics       common.PlatformMetrics
+	// optional, actual usage of in-memory components is reported with health
+	Footprint       *common.Footprint
+	StrictReadiness bool
 }
 
 const (
 	greenPage  = `<!DOCTYPE html><html><body style="background-color: green;"></body></html>`
 	orangePage = `<!DOCTYPE html><html><body style="background-color: orange;"></body></html>`
+	bluePage   = `<!DOCTYPE html><html><body style="background-color: blue;">Waiting for migration</body></html>`
 	redPage    = `<!DOCTYPE html><html><body style="background-color: red;"></body></html>`
 	FlagTrue   = 1
 	FlagFalse  = 0
@@ -62,12 +70,20 @@ func (hc *HealthCheckJob) RunOnce(ctx context.Context, params any) error {
 	pgStatus := hc.checkPostgres(ctx)
 	hc.postgresFlag.Store(pgStatus)
 
+	if pgStatus == FlagTrue {
+		hc.migrationFlag.Store(hc.checkSchema(ctx))
+	}
+
 	chStatus := hc.checkClickHouse(ctx)
 	hc.clickhouseFlag.Store(chStatus)
 
 	hc.Metrics.ObserveHealth((pgStatus == FlagTrue), (chStatus == FlagTrue))
 	hc.Metrics.ObserveCacheHitRatio(hc.BusinessDB.CacheHitRatio())
 
+	for _, u := range hc.Footprint.Usage() {
+		hc.Metrics.ObserveComponentUsage(u.Name, u.Entries, u.Capacity)
+	}
+
 	return nil
 }
 
@@ -91,6 +107,30 @@ func (hc *HealthCheckJob) checkPostgres(ctx context.Context) int32 {
 	return result
 }
 
+// checkSchema returns FlagTrue if node is waiting for migration (schema is older than required)
+func (hc *HealthCheckJob) checkSchema(ctx context.Context) int32 {
+	if hc.Pool == nil {
+		return FlagFalse
+	}
+
+	status, err := db.PostgresSchemaStatus(ctx, hc.Pool)
+	if err != nil {
+		// we do not block readiness if status is unknown
+		return hc.migrationFlag.Load()
+	}
+
+	if !status.Ready() {
+		slog.WarnContext(ctx, "Waiting for migration", "current", status.Current, "required", status.Required, "dirty", status.Dirty)
+		return FlagTrue
+	}
+
+	return FlagFalse
+}
+
+func (hc *HealthCheckJob) isWaitingForMigration() bool {
+	return hc.migrationFlag.Load() == FlagTrue
+}
+
 func (hc *HealthCheckJob) isPostgresHealthy() bool {
 	return hc.postgresFlag.Load() == FlagTrue
 }
@@ -118,6 +158,13 @@ func (hc *HealthCheckJob) ReadyHandler(w http.ResponseWriter, r *http.Request) {
 	shuttingDown := hc.isShuttingDown()
 	healthy := hc.isPostgresHealthy() && hc.isClickHouseHealthy()
 
+	if !shuttingDown && hc.isWaitingForMigration() {
+		// running against older schema is never safe, regardless of readiness strictness
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, bluePage)
+		return
+	}
+
 	if !shuttingDown && (healthy || !hc.StrictReadiness) {
 		w.WriteHeader(http.StatusOK)
 		if healthy {
@@ -130,3 +177,33 @@ func (hc *HealthCheckJob) ReadyHandler(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, redPage)
 	}
 }
+
+// SchemaHandler reports current and required schema versions (intended for deployment tooling)
+func (hc *HealthCheckJob) SchemaHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if hc.Pool == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	status, err := db.PostgresSchemaStatus(ctx, hc.Pool)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	response := struct {
+		*db.SchemaStatus
+		Ready bool `json:"ready"`
+	}{
+		SchemaStatus: status,
+		Ready:        status.Ready(),
+	}
+
+	w.Header().Set(common.HeaderContentType, common.ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(ctx, "Failed to encode schema status", common.ErrAttr(err))
+	}
+}
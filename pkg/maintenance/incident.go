@@ -0,0 +1,194 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	defaultIncidentPeriod = 24 * time.Hour
+	maxIncidentPeriod     = 7 * 24 * time.Hour
+	maxIncidentEvents     = 1000
+
+	incidentEventDeploy      = "deploy"
+	incidentEventConfig      = "config"
+	incidentEventMaintenance = "maintenance"
+	incidentEventProperty    = "property"
+)
+
+var (
+	errIncidentPeriod    = errors.New("to has to be after from")
+	errIncidentTooLong   = errors.New("period cannot be longer than 7 days")
+	incidentEventsTables = []string{db.TableNameDeploys, db.TableNameConfig, db.TableNameProperties, db.TableNamePropertyAccessLists}
+)
+
+// IncidentAPI returns a timeline for post-mortems via local API: hourly verify error rates and latency of all
+// properties together with deploy markers, configuration reloads and property changes
+type IncidentAPI struct {
+	BusinessDB db.Implementor
+	TimeSeries common.TimeSeriesStore
+}
+
+type incidentMetric struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Verifications uint64    `json:"verifications"`
+	Failures      uint64    `json:"failures"`
+	ErrorRate     float64   `json:"error_rate"`
+	LatencyP50    float64   `json:"latency_p50_ms"`
+	LatencyP90    float64   `json:"latency_p90_ms"`
+	LatencyP99    float64   `json:"latency_p99_ms"`
+}
+
+type incidentEvent struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Kind      string          `json:"kind"`
+	Action    string          `json:"action"`
+	Source    string          `json:"source"`
+	EntityID  int64           `json:"entity_id,omitempty"`
+	UserID    int32           `json:"user_id,omitempty"`
+	OldValue  json.RawMessage `json:"old_value,omitempty"`
+	NewValue  json.RawMessage `json:"new_value,omitempty"`
+}
+
+type incidentTimeline struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Metrics []*incidentMetric `json:"metrics"`
+	Events  []*incidentEvent  `json:"events"`
+	// metrics are not available if the time series database is down (which can be the incident itself)
+	MetricsError string `json:"metrics_error,omitempty"`
+	// there were more events than maxIncidentEvents within the period
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func parseIncidentPeriod(r *http.Request, tnow time.Time) (time.Time, time.Time, error) {
+	to := tnow
+	if value := r.URL.Query().Get("to"); len(value) > 0 {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = t.UTC()
+	}
+
+	from := to.Add(-defaultIncidentPeriod)
+	if value := r.URL.Query().Get("from"); len(value) > 0 {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = t.UTC()
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errIncidentPeriod
+	}
+
+	if to.Sub(from) > maxIncidentPeriod {
+		return time.Time{}, time.Time{}, errIncidentTooLong
+	}
+
+	return from, to, nil
+}
+
+func incidentEventKind(log *dbgen.AuditLog) string {
+	switch log.EntityTable {
+	case db.TableNameDeploys:
+		return incidentEventDeploy
+	case db.TableNameConfig:
+		oldValue, newValue := &db.AuditLogConfig{}, &db.AuditLogConfig{}
+		if (json.Unmarshal(log.OldValue, oldValue) == nil) && (json.Unmarshal(log.NewValue, newValue) == nil) &&
+			(oldValue.MaintenanceMode != newValue.MaintenanceMode) {
+			return incidentEventMaintenance
+		}
+		return incidentEventConfig
+	default:
+		return incidentEventProperty
+	}
+}
+
+func newIncidentEvent(log *dbgen.AuditLog) *incidentEvent {
+	event := &incidentEvent{
+		Timestamp: log.CreatedAt.Time.UTC(),
+		Kind:      incidentEventKind(log),
+		Action:    string(log.Action),
+		Source:    string(log.Source),
+		EntityID:  log.EntityID.Int64,
+		UserID:    log.UserID.Int32,
+	}
+
+	if len(log.OldValue) > 0 {
+		event.OldValue = json.RawMessage(log.OldValue)
+	}
+
+	if len(log.NewValue) > 0 {
+		event.NewValue = json.RawMessage(log.NewValue)
+	}
+
+	return event
+}
+
+func newIncidentMetric(stat *common.VerifyHealthStat) *incidentMetric {
+	return &incidentMetric{
+		Timestamp:     stat.Timestamp,
+		Verifications: stat.SuccessCount + stat.FailureCount,
+		Failures:      stat.FailureCount,
+		ErrorRate:     stat.ErrorRate(),
+		LatencyP50:    stat.P50,
+		LatencyP90:    stat.P90,
+		LatencyP99:    stat.P99,
+	}
+}
+
+func (a *IncidentAPI) Timeline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	from, to, err := parseIncidentPeriod(r, time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logs, err := a.BusinessDB.Impl().RetrieveSystemAuditLogs(ctx, incidentEventsTables, from, to, maxIncidentEvents+1)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	response := &incidentTimeline{
+		From:    from,
+		To:      to,
+		Metrics: []*incidentMetric{},
+		Events:  make([]*incidentEvent, 0, min(len(logs), maxIncidentEvents)),
+	}
+
+	if len(logs) > maxIncidentEvents {
+		logs = logs[:maxIncidentEvents]
+		response.Truncated = true
+	}
+
+	for _, log := range logs {
+		response.Events = append(response.Events, newIncidentEvent(&log.AuditLog))
+	}
+
+	if stats, err := a.TimeSeries.RetrieveVerifyHealth(ctx, from, to); err == nil {
+		for _, stat := range stats {
+			response.Metrics = append(response.Metrics, newIncidentMetric(stat))
+		}
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve verify health", common.ErrAttr(err))
+		response.MetricsError = err.Error()
+	}
+
+	slog.InfoContext(ctx, "Fetched incident timeline", "from", from, "to", to, "events", len(response.Events),
+		"metrics", len(response.Metrics), "truncated", response.Truncated)
+
+	common.SendJSONResponse(ctx, w, response)
+}
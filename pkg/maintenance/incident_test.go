@@ -0,0 +1,61 @@
+package maintenance
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestParseIncidentPeriod(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Date(2025, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		query string
+		from  time.Time
+		err   bool
+	}{
+		{"", tnow.Add(-defaultIncidentPeriod), false},
+		{"?from=2025-05-10T06:00:00Z", tnow.Add(-6 * time.Hour), false},
+		{"?from=2025-05-01T00:00:00Z", time.Time{}, true},
+		{"?from=2025-05-10T13:00:00Z", time.Time{}, true},
+		{"?from=yesterday", time.Time{}, true},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/maintenance/incident"+tc.query, nil)
+		from, to, err := parseIncidentPeriod(r, tnow)
+		if (err != nil) != tc.err {
+			t.Errorf("Unexpected error for %q: %v", tc.query, err)
+			continue
+		}
+
+		if (err == nil) && (!from.Equal(tc.from) || !to.Equal(tnow)) {
+			t.Errorf("Unexpected period for %q: %v - %v", tc.query, from, to)
+		}
+	}
+}
+
+func TestIncidentEventKind(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		log  *dbgen.AuditLog
+		kind string
+	}{
+		{&dbgen.AuditLog{EntityTable: db.TableNameDeploys, NewValue: []byte(`{"git_commit":"abc"}`)}, incidentEventDeploy},
+		{&dbgen.AuditLog{EntityTable: db.TableNameConfig, OldValue: []byte(`{"maintenance_mode":false,"verbose":false}`), NewValue: []byte(`{"maintenance_mode":false,"verbose":true}`)}, incidentEventConfig},
+		{&dbgen.AuditLog{EntityTable: db.TableNameConfig, OldValue: []byte(`{"maintenance_mode":false}`), NewValue: []byte(`{"maintenance_mode":true}`)}, incidentEventMaintenance},
+		{&dbgen.AuditLog{EntityTable: db.TableNameProperties}, incidentEventProperty},
+	}
+
+	for i, tc := range testCases {
+		if kind := incidentEventKind(tc.log); kind != tc.kind {
+			t.Errorf("Unexpected kind at %v: %v (expected %v)", i, kind, tc.kind)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package maintenance
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"os"
 	"sync"
 	"time"
 
@@ -14,11 +16,22 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 )
 
-func NewJobs(store db.Implementor) *jobs {
-	j := &jobs{
+var (
+	ErrJobNotFound = errors.New("job not found")
+)
+
+func NewJobs(store db.Implementor, metrics common.JobMetrics) *Jobs {
+	node, _ := os.Hostname()
+
+	j := &Jobs{
 		store:        store,
+		metrics:      metrics,
+		node:         node,
+		statuses:     make([]*jobStatus, 0),
 		periodicJobs: make([]common.PeriodicJob, 0),
 		oneOffJobs:   make([]common.OneOffJob, 0),
+		reports:      make(map[string]Report),
+		handlers:     make(map[string]http.Handler),
 	}
 
 	j.maintenanceCtx, j.maintenanceCancel = context.WithCancel(
@@ -27,10 +40,22 @@ func NewJobs(store db.Implementor) *jobs {
 	return j
 }
 
-type jobs struct {
+// Report is a read-only internal report, served on the local API
+type Report interface {
+	http.Handler
+	Name() string
+}
+
+type Jobs struct {
 	store             db.Implementor
+	metrics           common.JobMetrics
+	node              string
+	statuses          []*jobStatus
+	statusMux         sync.Mutex
 	periodicJobs      []common.PeriodicJob
 	oneOffJobs        []common.OneOffJob
+	reports           map[string]Report
+	handlers          map[string]http.Handler
 	maintenanceCancel context.CancelFunc
 	maintenanceCtx    context.Context
 	apiKey            string
@@ -39,32 +64,85 @@ type jobs struct {
 
 // Implicit logic is that lockDuration is the actual job Interval, but it is defined by the SQL lock.
 // Job's Interval() is much smaller only for the purpose of "retrying" if the previous job execution failed
-func (j *jobs) AddLocked(lockDuration time.Duration, job common.PeriodicJob) {
+func (j *Jobs) AddLocked(lockDuration time.Duration, job common.PeriodicJob) {
 	if interval := job.Interval(); interval >= lockDuration {
 		slog.Error("Periodic job interval should be less than lock duration", "job", job.Name(), "lock", lockDuration.String(), "interval", interval.String())
 	}
 
 	j.periodicJobs = append(j.periodicJobs, &UniquePeriodicJob{
-		Job:          job,
+		// observed inside of the lock so that only actual runs are recorded
+		Job:          j.observe(job, jobKindLocked),
 		Store:        j.store,
 		LockDuration: lockDuration,
+		Holder:       j.node,
 	})
 }
 
-func (j *jobs) Add(job common.PeriodicJob) {
-	j.periodicJobs = append(j.periodicJobs, job)
+func (j *Jobs) Add(job common.PeriodicJob) {
+	j.periodicJobs = append(j.periodicJobs, j.observe(job, jobKindPeriodic))
+}
+
+func (j *Jobs) AddOneOff(job common.OneOffJob) {
+	status := j.newStatus(job.Name(), jobKindOneOff)
+	j.oneOffJobs = append(j.oneOffJobs, &observedOneOffJob{job: job, status: status})
+}
+
+func (j *Jobs) newStatus(name, kind string) *jobStatus {
+	status := newJobStatus(name, kind, j.metrics)
+
+	j.statusMux.Lock()
+	j.statuses = append(j.statuses, status)
+	j.statusMux.Unlock()
+
+	return status
+}
+
+func (j *Jobs) observe(job common.PeriodicJob, kind string) common.PeriodicJob {
+	return &observedPeriodicJob{job: job, status: j.newStatus(job.Name(), kind)}
 }
 
-func (j *jobs) AddOneOff(job common.OneOffJob) {
-	j.oneOffJobs = append(j.oneOffJobs, job)
+// Status returns run history of all jobs on this node. For DB-locked jobs, it also includes current lock holder
+func (j *Jobs) Status(ctx context.Context) []*JobStatus {
+	j.statusMux.Lock()
+	statuses := make([]*jobStatus, len(j.statuses))
+	copy(statuses, j.statuses)
+	j.statusMux.Unlock()
+
+	result := make([]*JobStatus, 0, len(statuses))
+	for _, s := range statuses {
+		status := s.snapshot(j.node)
+
+		if (s.kind == jobKindLocked) && (j.store != nil) {
+			if lock, err := j.store.Impl().RetrieveLock(ctx, s.name); err == nil {
+				status.LockHolder = string(lock.Data)
+				if lock.ExpiresAt.Valid {
+					status.LockExpiresAt = optionalTime(lock.ExpiresAt.Time.UTC())
+				}
+			}
+		}
+
+		result = append(result, status)
+	}
+
+	return result
+}
+
+// AddReport has to be called before serving local API
+func (j *Jobs) AddReport(report Report) {
+	j.reports[report.Name()] = report
+}
+
+// AddHandler registers protected local API handler for the pattern (as in http.ServeMux). Has to be called before Setup()
+func (j *Jobs) AddHandler(pattern string, handler http.Handler) {
+	j.handlers[pattern] = handler
 }
 
 // spawned jobs only share common cancellation context and are not exclusive
-func (j *jobs) Spawn(job common.PeriodicJob) {
-	go common.RunPeriodicJob(j.maintenanceCtx, job)
+func (j *Jobs) Spawn(job common.PeriodicJob) {
+	go common.RunPeriodicJob(j.maintenanceCtx, j.observe(job, jobKindSpawned))
 }
 
-func (j *jobs) RunAll() {
+func (j *Jobs) RunAll() {
 	slog.DebugContext(j.maintenanceCtx, "Starting maintenance jobs", "periodic", len(j.periodicJobs), "oneoff", len(j.oneOffJobs))
 
 	// NOTE: we run jobs mutually exclusive to preserve resources for main server (those are _maintenance_ jobs anyways)
@@ -79,11 +157,11 @@ func (j *jobs) RunAll() {
 	}
 }
 
-func (j *jobs) UpdateConfig(cfg common.ConfigStore) {
+func (j *Jobs) UpdateConfig(cfg common.ConfigStore) {
 	j.apiKey = cfg.Get(common.LocalAPIKeyKey).Value()
 }
 
-func (j *jobs) Setup(mux *http.ServeMux, cfg common.ConfigStore) {
+func (j *Jobs) Setup(mux *http.ServeMux, cfg common.ConfigStore) {
 	j.apiKey = cfg.Get(common.LocalAPIKeyKey).Value()
 
 	svc := common.ServiceMiddleware("local")
@@ -91,9 +169,15 @@ func (j *jobs) Setup(mux *http.ServeMux, cfg common.ConfigStore) {
 	const maxBytes = 256 * 1024
 	mux.Handle(http.MethodPost+" /maintenance/periodic/{job}", svc(common.Recovered(http.MaxBytesHandler(j.security(http.HandlerFunc(j.handlePeriodicJob)), maxBytes))))
 	mux.Handle(http.MethodPost+" /maintenance/oneoff/{job}", svc(common.Recovered(http.MaxBytesHandler(j.security(http.HandlerFunc(j.handleOneoffJob)), maxBytes))))
+	mux.Handle(http.MethodGet+" /maintenance/report/{report}", svc(common.Recovered(j.security(http.HandlerFunc(j.handleReport)))))
+	mux.Handle(http.MethodGet+" /maintenance/jobs", svc(common.Recovered(j.security(http.HandlerFunc(j.handleStatus)))))
+
+	for pattern, handler := range j.handlers {
+		mux.Handle(pattern, svc(common.Recovered(http.MaxBytesHandler(j.security(handler), maxBytes))))
+	}
 }
 
-func (j *jobs) security(next http.Handler) http.Handler {
+func (j *Jobs) security(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -120,7 +204,48 @@ func (j *jobs) security(next http.Handler) http.Handler {
 	})
 }
 
-func (j *jobs) handlePeriodicJob(w http.ResponseWriter, r *http.Request) {
+func (j *Jobs) findPeriodicJob(name string) (common.PeriodicJob, bool) {
+	for _, job := range j.periodicJobs {
+		if job.Name() == name {
+			return job, true
+		}
+	}
+
+	return nil, false
+}
+
+func (j *Jobs) findOneOffJob(name string) (common.OneOffJob, bool) {
+	for _, job := range j.oneOffJobs {
+		if job.Name() == name {
+			return job, true
+		}
+	}
+
+	return nil, false
+}
+
+// RunNow launches registered periodic or one-off job in the background with default params. Locked jobs still
+// respect their DB lock so they will not run if another node (or previous run) holds it
+func (j *Jobs) RunNow(ctx context.Context, name string) error {
+	if job, ok := j.findPeriodicJob(name); ok {
+		slog.InfoContext(ctx, "Launching periodic job on demand", "job", name)
+		go func() {
+			_ = common.RunPeriodicJobOnce(common.CopyTraceID(ctx, context.Background()), job, job.NewParams())
+		}()
+		return nil
+	}
+
+	if job, ok := j.findOneOffJob(name); ok {
+		slog.InfoContext(ctx, "Launching one-off job on demand", "job", name)
+		go common.RunOneOffJob(common.CopyTraceID(ctx, context.Background()), job, job.NewParams())
+		return nil
+	}
+
+	slog.WarnContext(ctx, "Job to launch was not found", "job", name)
+	return ErrJobNotFound
+}
+
+func (j *Jobs) handlePeriodicJob(w http.ResponseWriter, r *http.Request) {
 	jobName, err := common.StrPathArg(r, "job")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -129,38 +254,32 @@ func (j *jobs) handlePeriodicJob(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	slog.InfoContext(ctx, "Handling on-demand periodic job launch", "job", jobName)
-	found := false
 
-	for _, job := range j.periodicJobs {
-		if job.Name() == jobName {
-			params := job.NewParams()
-			if r.Body != nil {
-				if buf, _ := io.ReadAll(r.Body); len(buf) > 0 {
-					if err := json.Unmarshal(buf, params); err != nil {
-						slog.ErrorContext(ctx, "Failed to decode params", "job", jobName, common.ErrAttr(err))
-						http.Error(w, err.Error(), http.StatusBadRequest)
-						return
-					}
-				}
-			}
+	job, ok := j.findPeriodicJob(jobName)
+	if !ok {
+		http.Error(w, fmt.Sprintf("job %v not found", jobName), http.StatusBadRequest)
+		return
+	}
 
-			go func() {
-				_ = common.RunPeriodicJobOnce(common.CopyTraceID(ctx, context.Background()), job, params)
-			}()
-			found = true
-			break
+	params := job.NewParams()
+	if r.Body != nil {
+		if buf, _ := io.ReadAll(r.Body); len(buf) > 0 {
+			if err := json.Unmarshal(buf, params); err != nil {
+				slog.ErrorContext(ctx, "Failed to decode params", "job", jobName, common.ErrAttr(err))
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 		}
 	}
 
-	if !found {
-		http.Error(w, fmt.Sprintf("job %v not found", jobName), http.StatusBadRequest)
-		return
-	}
+	go func() {
+		_ = common.RunPeriodicJobOnce(common.CopyTraceID(ctx, context.Background()), job, params)
+	}()
 
 	_, _ = w.Write([]byte("started"))
 }
 
-func (j *jobs) handleOneoffJob(w http.ResponseWriter, r *http.Request) {
+func (j *Jobs) handleOneoffJob(w http.ResponseWriter, r *http.Request) {
 	jobName, err := common.StrPathArg(r, "job")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -169,37 +288,62 @@ func (j *jobs) handleOneoffJob(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	slog.InfoContext(ctx, "Handling on-demand one-off job launch", "job", jobName)
-	found := false
 
-	for _, job := range j.oneOffJobs {
-		if job.Name() == jobName {
-			params := job.NewParams()
-			if r.Body != nil {
-				if buf, _ := io.ReadAll(r.Body); len(buf) > 0 {
-					if err := json.Unmarshal(buf, params); err != nil {
-						slog.ErrorContext(ctx, "Failed to decode params", "job", jobName, common.ErrAttr(err))
-						http.Error(w, err.Error(), http.StatusBadRequest)
-						return
-					}
-				}
+	job, ok := j.findOneOffJob(jobName)
+	if !ok {
+		http.Error(w, fmt.Sprintf("job %v not found", jobName), http.StatusBadRequest)
+		return
+	}
+
+	params := job.NewParams()
+	if r.Body != nil {
+		if buf, _ := io.ReadAll(r.Body); len(buf) > 0 {
+			if err := json.Unmarshal(buf, params); err != nil {
+				slog.ErrorContext(ctx, "Failed to decode params", "job", jobName, common.ErrAttr(err))
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
 			}
+		}
+	}
 
-			go common.RunOneOffJob(common.CopyTraceID(ctx, context.Background()), job, params)
+	go common.RunOneOffJob(common.CopyTraceID(ctx, context.Background()), job, params)
 
-			found = true
-			break
-		}
+	_, _ = w.Write([]byte("started"))
+}
+
+func (j *Jobs) handleReport(w http.ResponseWriter, r *http.Request) {
+	reportName, err := common.StrPathArg(r, "report")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	if !found {
-		http.Error(w, fmt.Sprintf("job %v not found", jobName), http.StatusBadRequest)
+	report, ok := j.reports[reportName]
+	if !ok {
+		http.Error(w, fmt.Sprintf("report %v not found", reportName), http.StatusNotFound)
 		return
 	}
 
-	_, _ = w.Write([]byte("started"))
+	slog.InfoContext(r.Context(), "Handling report request", "report", reportName)
+
+	report.ServeHTTP(w, r)
+}
+
+func (j *Jobs) handleStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	response := struct {
+		Node string       `json:"node"`
+		Jobs []*JobStatus `json:"jobs"`
+	}{
+		Node: j.node,
+		Jobs: j.Status(ctx),
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
 }
 
-func (j *jobs) Shutdown() {
+func (j *Jobs) Shutdown() {
 	slog.Debug("Shutting down maintenance jobs")
 
 	if j.maintenanceCancel != nil {
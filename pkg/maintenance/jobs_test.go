@@ -2,11 +2,13 @@ package maintenance
 
 import (
 	"context"
+	"errors"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
 )
 
 type stubOneOffJob struct {
@@ -77,7 +79,7 @@ func (j *stubPeriodicJob) wasExecuted() bool {
 }
 
 func TestOneOffJobExecution(t *testing.T) {
-	jobsManager := NewJobs(nil)
+	jobsManager := NewJobs(nil, monitoring.NewStub())
 	defer jobsManager.Shutdown()
 
 	stubJob := &stubOneOffJob{}
@@ -94,7 +96,7 @@ func TestOneOffJobExecution(t *testing.T) {
 }
 
 func TestPeriodicJobExecution(t *testing.T) {
-	jobsManager := NewJobs(nil)
+	jobsManager := NewJobs(nil, monitoring.NewStub())
 	defer jobsManager.Shutdown()
 
 	stubJob := &stubPeriodicJob{
@@ -111,3 +113,94 @@ func TestPeriodicJobExecution(t *testing.T) {
 		t.Error("PeriodicJob was not executed")
 	}
 }
+
+type failingPeriodicJob struct {
+	stubPeriodicJob
+}
+
+func (j *failingPeriodicJob) Name() string {
+	return "failingPeriodicJob"
+}
+
+func (j *failingPeriodicJob) RunOnce(ctx context.Context, params any) error {
+	return errors.New("test failure")
+}
+
+func TestPeriodicJobStatus(t *testing.T) {
+	jobsManager := NewJobs(nil, monitoring.NewStub())
+	defer jobsManager.Shutdown()
+
+	okJob := &stubPeriodicJob{interval: 10 * time.Millisecond}
+	failingJob := &failingPeriodicJob{stubPeriodicJob{interval: 10 * time.Millisecond}}
+
+	jobsManager.Add(okJob)
+	jobsManager.Add(failingJob)
+
+	jobsManager.RunAll()
+
+	time.Sleep(okJob.interval * 10)
+
+	statuses := jobsManager.Status(t.Context())
+	if len(statuses) != 2 {
+		t.Fatalf("Unexpected number of statuses: %v", len(statuses))
+	}
+
+	for _, s := range statuses {
+		if (s.Runs == 0) || (s.LastFinishedAt == nil) || (s.NextRunAt == nil) {
+			t.Errorf("Job %v was not observed: %+v", s.Name, s)
+		}
+
+		var count int64
+		for _, b := range s.Durations {
+			count += b.Count
+		}
+
+		if count != s.Runs {
+			t.Errorf("Job %v durations count %v does not match runs %v", s.Name, count, s.Runs)
+		}
+
+		switch s.Name {
+		case okJob.Name():
+			if (s.Failures != 0) || (len(s.LastError) > 0) {
+				t.Errorf("Unexpected failures of %v: %+v", s.Name, s)
+			}
+		case failingJob.Name():
+			if (s.Failures != s.Runs) || (s.LastError != "test failure") || (s.LastErrorAt == nil) {
+				t.Errorf("Unexpected failures of %v: %+v", s.Name, s)
+			}
+		default:
+			t.Errorf("Unexpected job %v", s.Name)
+		}
+	}
+}
+
+func TestRunJobNow(t *testing.T) {
+	jobsManager := NewJobs(nil, monitoring.NewStub())
+	defer jobsManager.Shutdown()
+
+	oneOffJob := &stubOneOffJob{}
+	periodicJob := &stubPeriodicJob{interval: 1 * time.Hour}
+
+	jobsManager.AddOneOff(oneOffJob)
+	jobsManager.Add(periodicJob)
+
+	for _, name := range []string{oneOffJob.Name(), periodicJob.Name()} {
+		if err := jobsManager.RunNow(t.Context(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if !oneOffJob.wasExecuted() {
+		t.Error("OneOffJob was not executed")
+	}
+
+	if !periodicJob.wasExecuted() {
+		t.Error("PeriodicJob was not executed")
+	}
+
+	if err := jobsManager.RunNow(t.Context(), "missingJob"); err != ErrJobNotFound {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	jobKindPeriodic = "periodic"
+	jobKindLocked   = "locked"
+	jobKindSpawned  = "spawned"
+	jobKindOneOff   = "oneoff"
+)
+
+var (
+	errJobPanic = errors.New("job crashed")
+)
+
+type JobDurationBucket struct {
+	// upper bound in seconds, empty for +Inf
+	LE    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+type JobStatus struct {
+	Name           string              `json:"name"`
+	Kind           string              `json:"kind"`
+	Node           string              `json:"node"`
+	Running        bool                `json:"running"`
+	Runs           int64               `json:"runs"`
+	Failures       int64               `json:"failures"`
+	LastStartedAt  *time.Time          `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time          `json:"last_finished_at,omitempty"`
+	LastDurationMs int64               `json:"last_duration_ms"`
+	LastError      string              `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time          `json:"last_error_at,omitempty"`
+	NextRunAt      *time.Time          `json:"next_run_at,omitempty"`
+	LockHolder     string              `json:"lock_holder,omitempty"`
+	LockExpiresAt  *time.Time          `json:"lock_expires_at,omitempty"`
+	Durations      []JobDurationBucket `json:"durations"`
+	// spawned jobs run on every node and cannot be launched on demand
+	Triggerable bool `json:"triggerable"`
+}
+
+// jobStatus accumulates run history of a single job on this node
+type jobStatus struct {
+	name      string
+	kind      string
+	metrics   common.JobMetrics
+	mux       sync.Mutex
+	running   bool
+	runs      int64
+	failures  int64
+	started   time.Time
+	finished  time.Time
+	duration  time.Duration
+	lastError string
+	errorAt   time.Time
+	nextRun   time.Time
+	// one more than common.JobDurationBuckets for +Inf
+	buckets []int64
+}
+
+func newJobStatus(name, kind string, metrics common.JobMetrics) *jobStatus {
+	return &jobStatus{
+		name:    name,
+		kind:    kind,
+		metrics: metrics,
+		buckets: make([]int64, len(common.JobDurationBuckets)+1),
+	}
+}
+
+func (s *jobStatus) start() time.Time {
+	tnow := time.Now().UTC()
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.running = true
+	s.started = tnow
+
+	return tnow
+}
+
+func (s *jobStatus) finish(started time.Time, err error) {
+	tnow := time.Now().UTC()
+	duration := tnow.Sub(started)
+
+	s.mux.Lock()
+	s.running = false
+	s.runs++
+	s.finished = tnow
+	s.duration = duration
+	if err != nil {
+		s.failures++
+		s.lastError = err.Error()
+		s.errorAt = tnow
+	}
+
+	i := 0
+	for (i < len(common.JobDurationBuckets)) && (duration.Seconds() > common.JobDurationBuckets[i]) {
+		i++
+	}
+	s.buckets[i]++
+	s.mux.Unlock()
+
+	s.metrics.ObserveJobRun(s.name, duration, err == nil)
+}
+
+func (s *jobStatus) Scheduled(at time.Time) {
+	s.mux.Lock()
+	s.nextRun = at.UTC()
+	s.mux.Unlock()
+
+	s.metrics.ObserveJobScheduled(s.name, at)
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func (s *jobStatus) snapshot(node string) *JobStatus {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	durations := make([]JobDurationBucket, 0, len(s.buckets))
+	for i, count := range s.buckets {
+		le := ""
+		if i < len(common.JobDurationBuckets) {
+			le = strconv.FormatFloat(common.JobDurationBuckets[i], 'f', -1, 64)
+		}
+		durations = append(durations, JobDurationBucket{LE: le, Count: count})
+	}
+
+	return &JobStatus{
+		Name:           s.name,
+		Kind:           s.kind,
+		Node:           node,
+		Running:        s.running,
+		Runs:           s.runs,
+		Failures:       s.failures,
+		LastStartedAt:  optionalTime(s.started),
+		LastFinishedAt: optionalTime(s.finished),
+		LastDurationMs: s.duration.Milliseconds(),
+		LastError:      s.lastError,
+		LastErrorAt:    optionalTime(s.errorAt),
+		NextRunAt:      optionalTime(s.nextRun),
+		Durations:      durations,
+		Triggerable:    s.kind != jobKindSpawned,
+	}
+}
+
+type observedPeriodicJob struct {
+	job    common.PeriodicJob
+	status *jobStatus
+}
+
+var _ common.PeriodicJob = (*observedPeriodicJob)(nil)
+var _ common.ScheduledJob = (*observedPeriodicJob)(nil)
+
+func (j *observedPeriodicJob) Interval() time.Duration  { return j.job.Interval() }
+func (j *observedPeriodicJob) Jitter() time.Duration    { return j.job.Jitter() }
+func (j *observedPeriodicJob) Name() string             { return j.job.Name() }
+func (j *observedPeriodicJob) NewParams() any           { return j.job.NewParams() }
+func (j *observedPeriodicJob) Trigger() <-chan struct{} { return j.job.Trigger() }
+func (j *observedPeriodicJob) Timeout() time.Duration   { return j.job.Timeout() }
+func (j *observedPeriodicJob) Scheduled(at time.Time)   { j.status.Scheduled(at) }
+
+func (j *observedPeriodicJob) RunOnce(ctx context.Context, params any) (err error) {
+	started := j.status.start()
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			j.status.finish(started, errJobPanic)
+			panic(rvr)
+		}
+		j.status.finish(started, err)
+	}()
+
+	return j.job.RunOnce(ctx, params)
+}
+
+type observedOneOffJob struct {
+	job    common.OneOffJob
+	status *jobStatus
+}
+
+var _ common.OneOffJob = (*observedOneOffJob)(nil)
+
+func (j *observedOneOffJob) Name() string                { return j.job.Name() }
+func (j *observedOneOffJob) InitialPause() time.Duration { return j.job.InitialPause() }
+func (j *observedOneOffJob) NewParams() any              { return j.job.NewParams() }
+
+func (j *observedOneOffJob) RunOnce(ctx context.Context, params any) (err error) {
+	started := j.status.start()
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			j.status.finish(started, errJobPanic)
+			panic(rvr)
+		}
+		j.status.finish(started, err)
+	}()
+
+	return j.job.RunOnce(ctx, params)
+}
@@ -22,6 +22,7 @@ func (j *mutexPeriodicJob) Name() string             { return j.job.Name() }
 func (j *mutexPeriodicJob) NewParams() any           { return j.job.NewParams() }
 func (j *mutexPeriodicJob) Trigger() <-chan struct{} { return j.job.Trigger() }
 func (j *mutexPeriodicJob) Timeout() time.Duration   { return j.job.Timeout() }
+func (j *mutexPeriodicJob) Scheduled(at time.Time)   { common.NotifyJobScheduled(j.job, at) }
 
 func (j *mutexPeriodicJob) RunOnce(ctx context.Context, params any) error {
 	slog.DebugContext(ctx, "About to acquire maintenance job mutex", "job", j.Name())
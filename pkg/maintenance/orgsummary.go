@@ -0,0 +1,113 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+// OrgSummaryJob refreshes the recent traffic in materialized user org summaries (shown on the portal landing page).
+// Orgs and property counts are maintained on writes and are also rebuilt here to fix any drift.
+type OrgSummaryJob struct {
+	BusinessDB db.Implementor
+	TimeSeries common.TimeSeriesStore
+	BatchSize  int
+}
+
+var _ common.PeriodicJob = (*OrgSummaryJob)(nil)
+
+type OrgSummaryParams struct {
+	BatchSize int `json:"batch_size"`
+}
+
+func (j *OrgSummaryJob) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *OrgSummaryJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *OrgSummaryJob) Jitter() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *OrgSummaryJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *OrgSummaryJob) Name() string {
+	return "org_summary_job"
+}
+
+func (j *OrgSummaryJob) NewParams() any {
+	return &OrgSummaryParams{
+		BatchSize: j.BatchSize,
+	}
+}
+
+func orgTrafficFromUsage(usage []*common.OrgUsageStat) map[int32]*db.OrgTraffic {
+	result := make(map[int32]*db.OrgTraffic)
+
+	for _, u := range usage {
+		t, ok := result[u.OrgID]
+		if !ok {
+			t = &db.OrgTraffic{}
+			result[u.OrgID] = t
+		}
+
+		t.Requests += u.Requests
+		t.Verifications += u.Verifications
+	}
+
+	return result
+}
+
+func (j *OrgSummaryJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*OrgSummaryParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*OrgSummaryParams)
+	}
+
+	tnow := time.Now().UTC()
+
+	usage, err := j.TimeSeries.RetrieveOrgUsage(ctx, tnow.Add(-db.OrgSummaryTrafficPeriod), tnow)
+	if err != nil {
+		return err
+	}
+
+	traffic := orgTrafficFromUsage(usage)
+
+	var afterUserID int32
+	refreshed := 0
+
+	for {
+		userIDs, err := j.BusinessDB.Impl().RetrieveUserOrgsSummaryIDs(ctx, afterUserID, p.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(userIDs) == 0 {
+			break
+		}
+
+		if err := j.BusinessDB.Impl().RefreshUserOrgsSummaries(ctx, userIDs, traffic, tnow); err != nil {
+			return err
+		}
+
+		refreshed += len(userIDs)
+		afterUserID = userIDs[len(userIDs)-1]
+
+		if len(userIDs) < p.BatchSize {
+			break
+		}
+	}
+
+	slog.InfoContext(ctx, "Refreshed user org summaries", "users", refreshed, "orgs", len(traffic))
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	orphanedDataCacheKey      = "orphaned_data_cleanup"
+	orphanedDataCheckpointTTL = 365 * 24 * time.Hour
+)
+
+// orphanedDataCheckpoint is the progress of scanning time series for data of hard-deleted entities, stored in DB cache
+type orphanedDataCheckpoint struct {
+	NextPropertyID    int32     `json:"next_property_id"`
+	NextOrgID         int32     `json:"next_org_id"`
+	DeletedProperties int       `json:"deleted_properties"`
+	DeletedOrgs       int       `json:"deleted_orgs"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// nextCursor returns where the next scan starts: it wraps to the beginning after the last batch
+func nextCursor(scanned []int32, truncated []int32, limit int) int32 {
+	if len(truncated) > 0 {
+		return truncated[len(truncated)-1]
+	}
+
+	if len(scanned) < limit {
+		return 0
+	}
+
+	return scanned[len(scanned)-1]
+}
+
+// CleanupOrphanedDataJob deletes time series data of properties and organizations that no longer exist in Postgres,
+// e.g. when GarbageCollectDataJob failed to delete analytics or data was written after the purge. The ID space is
+// scanned in batches and the number of deletes is limited to avoid overloading ClickHouse with mutations.
+type CleanupOrphanedDataJob struct {
+	Store             db.Implementor
+	TimeSeries        common.TimeSeriesStore
+	BatchSize         int
+	MaxDeletes        int
+	MaxPendingDeletes int
+}
+
+var _ common.PeriodicJob = (*CleanupOrphanedDataJob)(nil)
+
+func (j *CleanupOrphanedDataJob) Timeout() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *CleanupOrphanedDataJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *CleanupOrphanedDataJob) Jitter() time.Duration {
+	return 30 * time.Minute
+}
+
+func (j *CleanupOrphanedDataJob) Name() string {
+	return "cleanup_orphaned_data_job"
+}
+
+func (j *CleanupOrphanedDataJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+type CleanupOrphanedDataParams struct {
+	BatchSize         int `json:"batch_size"`
+	MaxDeletes        int `json:"max_deletes"`
+	MaxPendingDeletes int `json:"max_pending_deletes"`
+}
+
+func (j *CleanupOrphanedDataJob) NewParams() any {
+	return &CleanupOrphanedDataParams{
+		BatchSize:         j.BatchSize,
+		MaxDeletes:        j.MaxDeletes,
+		MaxPendingDeletes: j.MaxPendingDeletes,
+	}
+}
+
+func (j *CleanupOrphanedDataJob) loadCheckpoint(ctx context.Context) (*orphanedDataCheckpoint, error) {
+	data, err := j.Store.Impl().RetrieveFromCache(ctx, orphanedDataCacheKey)
+	if err == db.ErrCacheMiss {
+		return &orphanedDataCheckpoint{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	cp := &orphanedDataCheckpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode orphaned data checkpoint", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+func (j *CleanupOrphanedDataJob) saveCheckpoint(ctx context.Context, cp *orphanedDataCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	return j.Store.Impl().StoreInCache(ctx, orphanedDataCacheKey, data, orphanedDataCheckpointTTL)
+}
+
+type orphanedDataScan struct {
+	name        string
+	retrieve    func(ctx context.Context, afterID int32, limit int) ([]int32, error)
+	findMissing func(ctx context.Context, ids []int32) ([]int32, error)
+	delete      func(ctx context.Context, ids []int32) error
+}
+
+// cleanup processes one batch after cursor and returns the new cursor and the number of deleted IDs
+func (j *CleanupOrphanedDataJob) cleanup(ctx context.Context, s *orphanedDataScan, cursor int32, p *CleanupOrphanedDataParams, maxDeletes int) (int32, int, error) {
+	if maxDeletes <= 0 {
+		return cursor, 0, nil
+	}
+
+	ids, err := s.retrieve(ctx, cursor, p.BatchSize)
+	if err != nil {
+		return cursor, 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, 0, nil
+	}
+
+	missing, err := s.findMissing(ctx, ids)
+	if err != nil {
+		return cursor, 0, err
+	}
+
+	var truncated []int32
+	if len(missing) > maxDeletes {
+		// continue after the last deleted ID next time
+		missing = missing[:maxDeletes]
+		truncated = missing
+	}
+
+	if len(missing) > 0 {
+		if err := s.delete(ctx, missing); err != nil {
+			return cursor, 0, err
+		}
+
+		slog.InfoContext(ctx, "Deleted orphaned time series data", "entity", s.name, "count", len(missing),
+			"from", missing[0], "to", missing[len(missing)-1])
+	}
+
+	return nextCursor(ids, truncated, p.BatchSize), len(missing), nil
+}
+
+func (j *CleanupOrphanedDataJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*CleanupOrphanedDataParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*CleanupOrphanedDataParams)
+	}
+
+	pending, err := j.TimeSeries.PendingDeletesCount(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pending >= p.MaxPendingDeletes {
+		slog.WarnContext(ctx, "Skipping orphaned data cleanup due to pending deletes", "pending", pending, "max", p.MaxPendingDeletes)
+		return nil
+	}
+
+	cp, err := j.loadCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	impl := j.Store.Impl()
+	budget := p.MaxDeletes
+
+	properties := &orphanedDataScan{
+		name:        "property",
+		retrieve:    j.TimeSeries.RetrieveDataPropertyIDs,
+		findMissing: impl.FindMissingPropertyIDs,
+		delete:      j.TimeSeries.DeletePropertiesData,
+	}
+
+	next, deleted, err := j.cleanup(ctx, properties, cp.NextPropertyID, p, budget)
+	if err != nil {
+		return err
+	}
+	cp.DeletedProperties += deleted
+	if (next == 0) && (cp.NextPropertyID != 0) {
+		slog.InfoContext(ctx, "Finished scan for orphaned data", "entity", properties.name, "deleted", cp.DeletedProperties)
+		cp.DeletedProperties = 0
+	}
+	cp.NextPropertyID = next
+	budget -= deleted
+
+	orgs := &orphanedDataScan{
+		name:        "organization",
+		retrieve:    j.TimeSeries.RetrieveDataOrgIDs,
+		findMissing: impl.FindMissingOrganizationIDs,
+		delete:      j.TimeSeries.DeleteOrganizationsData,
+	}
+
+	next, deleted, err = j.cleanup(ctx, orgs, cp.NextOrgID, p, budget)
+	if err != nil {
+		return err
+	}
+	cp.DeletedOrgs += deleted
+	if (next == 0) && (cp.NextOrgID != 0) {
+		slog.InfoContext(ctx, "Finished scan for orphaned data", "entity", orgs.name, "deleted", cp.DeletedOrgs)
+		cp.DeletedOrgs = 0
+	}
+	cp.NextOrgID = next
+	cp.UpdatedAt = time.Now().UTC()
+
+	slog.DebugContext(ctx, "Scanned for orphaned data", "nextPropertyID", cp.NextPropertyID, "nextOrgID", cp.NextOrgID,
+		"properties", cp.DeletedProperties, "orgs", cp.DeletedOrgs)
+
+	return j.saveCheckpoint(ctx, cp)
+}
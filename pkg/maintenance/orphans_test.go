@@ -0,0 +1,26 @@
+package maintenance
+
+import "testing"
+
+func TestOrphanedDataNextCursor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scanned   []int32
+		truncated []int32
+		limit     int
+		expected  int32
+	}{
+		{nil, nil, 10, 0},
+		{[]int32{1, 5, 7}, nil, 10, 0},
+		{[]int32{1, 5, 7}, nil, 3, 7},
+		{[]int32{1, 5, 7}, []int32{1}, 3, 1},
+		{[]int32{1, 5, 7}, []int32{5}, 10, 5},
+	}
+
+	for i, tc := range testCases {
+		if actual := nextCursor(tc.scanned, tc.truncated, tc.limit); actual != tc.expected {
+			t.Errorf("Unexpected cursor (%v): expected %v, actual %v", i, tc.expected, actual)
+		}
+	}
+}
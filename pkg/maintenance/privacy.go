@@ -0,0 +1,55 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+// RefreshPrivacySaltJob keeps the daily salt of the "hash" privacy mode in sync with DB (on every instance)
+type RefreshPrivacySaltJob struct {
+	BusinessDB db.Implementor
+	Salt       *db.PrivacySalt
+}
+
+var _ common.PeriodicJob = (*RefreshPrivacySaltJob)(nil)
+
+func (j *RefreshPrivacySaltJob) Timeout() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshPrivacySaltJob) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *RefreshPrivacySaltJob) Jitter() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshPrivacySaltJob) Name() string {
+	return "refresh_privacy_salt_job"
+}
+
+func (j *RefreshPrivacySaltJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *RefreshPrivacySaltJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *RefreshPrivacySaltJob) RunOnce(ctx context.Context, params any) error {
+	salt, err := j.BusinessDB.Impl().RetrievePrivacySalt(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	j.Salt.Update(salt)
+
+	slog.DebugContext(ctx, "Refreshed privacy salt")
+
+	return nil
+}
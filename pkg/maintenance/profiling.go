@@ -0,0 +1,175 @@
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+var (
+	errProfileUpload = errors.New("failed to upload profile")
+)
+
+// UploadProfilesJob periodically collects CPU and heap profiles of this instance and pushes them to the
+// continuous profiling server using Pyroscope-compatible ingestion API. Per-request samples are additionally
+// labeled with service and handler ID (see monitoring.Service), static labels are attached to the whole profile.
+type UploadProfilesJob struct {
+	URL         string
+	AppName     string
+	Labels      map[string]string
+	CPUDuration time.Duration
+	Client      *http.Client
+}
+
+var _ common.PeriodicJob = (*UploadProfilesJob)(nil)
+
+func (j *UploadProfilesJob) Timeout() time.Duration {
+	return j.CPUDuration + 30*time.Second
+}
+
+func (j *UploadProfilesJob) Interval() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *UploadProfilesJob) Jitter() time.Duration {
+	return 1
+}
+
+func (j *UploadProfilesJob) Name() string {
+	return "upload_profiles_job"
+}
+
+func (j *UploadProfilesJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *UploadProfilesJob) NewParams() any {
+	return struct{}{}
+}
+
+// profileName formats application name with labels, e.g. "privatecaptcha{host=a,stage=prod}"
+func (j *UploadProfilesJob) profileName() string {
+	if len(j.Labels) == 0 {
+		return j.AppName
+	}
+
+	keys := slices.Sorted(maps.Keys(j.Labels))
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+j.Labels[k])
+	}
+
+	return j.AppName + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (j *UploadProfilesJob) upload(ctx context.Context, profile []byte, from, until time.Time) error {
+	u, err := url.Parse(j.URL)
+	if err != nil {
+		return err
+	}
+
+	u = u.JoinPath("ingest")
+
+	query := u.Query()
+	query.Set("name", j.profileName())
+	query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	query.Set("until", strconv.FormatInt(until.Unix(), 10))
+	query.Set("spyName", "gospy")
+	query.Set("sampleRate", "100")
+	u.RawQuery = query.Encode()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+
+	if _, err := part.Write(profile); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(common.HeaderContentType, writer.FormDataContentType())
+
+	client := j.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
+		return fmt.Errorf("%w: status %d", errProfileUpload, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (j *UploadProfilesJob) collectCPUProfile(ctx context.Context) ([]byte, error) {
+	var buf bytes.Buffer
+	// NOTE: this fails if CPU profile is already being collected (e.g. via /debug/pprof/profile)
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(j.CPUDuration):
+	}
+
+	pprof.StopCPUProfile()
+
+	return buf.Bytes(), nil
+}
+
+func (j *UploadProfilesJob) RunOnce(ctx context.Context, params any) error {
+	from := time.Now()
+
+	if cpu, err := j.collectCPUProfile(ctx); err == nil {
+		if err := j.upload(ctx, cpu, from, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "Failed to upload CPU profile", common.ErrAttr(err))
+		}
+	} else {
+		slog.WarnContext(ctx, "Failed to collect CPU profile", common.ErrAttr(err))
+	}
+
+	var heap bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0 /*debug*/); err != nil {
+		return err
+	}
+
+	tnow := time.Now()
+	if err := j.upload(ctx, heap.Bytes(), tnow, tnow); err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "Uploaded profiles", "cpu", j.CPUDuration)
+
+	return nil
+}
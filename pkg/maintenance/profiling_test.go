@@ -0,0 +1,56 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUploadProfiles(t *testing.T) {
+	uploads := make(chan string, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ingest" {
+			t.Errorf("Unexpected path: %v", r.URL.Path)
+		}
+
+		file, _, err := r.FormFile("profile")
+		if err != nil {
+			t.Errorf("Failed to read profile: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file.Close()
+
+		uploads <- r.URL.Query().Get("name")
+	}))
+	defer srv.Close()
+
+	job := &UploadProfilesJob{
+		URL:         srv.URL,
+		AppName:     "privatecaptcha",
+		Labels:      map[string]string{"stage": "test", "host": "a"},
+		CPUDuration: 100 * time.Millisecond,
+		Client:      srv.Client(),
+	}
+
+	if err := job.RunOnce(context.TODO(), job.NewParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	close(uploads)
+
+	count := 0
+	for name := range uploads {
+		if name != "privatecaptcha{host=a,stage=test}" {
+			t.Errorf("Unexpected profile name: %v", name)
+		}
+		count++
+	}
+
+	if count == 0 {
+		t.Error("No profiles were uploaded")
+	}
+}
@@ -0,0 +1,243 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+const (
+	quotaSandboxOrgsBatchSize = 1000
+)
+
+// MonthlyQuotaJob compares monthly requests of org owners with the requests limit of their plan and moves them
+// through quota stages (warning, degraded, exceeded). Users are notified when their stage escalates, while the
+// enforcement itself happens in the API using the registry that is refreshed by RefreshMonthlyQuotasJob.
+type MonthlyQuotaJob struct {
+	BusinessDB  db.Implementor
+	TimeSeries  common.TimeSeriesStore
+	PlanService billing.PlanService
+	Stage       string
+}
+
+var _ common.PeriodicJob = (*MonthlyQuotaJob)(nil)
+
+func (j *MonthlyQuotaJob) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *MonthlyQuotaJob) Interval() time.Duration {
+	return 30 * time.Minute
+}
+
+func (j *MonthlyQuotaJob) Jitter() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *MonthlyQuotaJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *MonthlyQuotaJob) Name() string {
+	return "monthly_quota_job"
+}
+
+func (j *MonthlyQuotaJob) NewParams() any {
+	return struct{}{}
+}
+
+func quotaConsequence(stage dbgen.QuotaStage) string {
+	switch stage {
+	case dbgen.QuotaStageWarning:
+		return fmt.Sprintf("Nothing changes for now, but after %d%% of the limit adaptive difficulty of your properties will be disabled.", db.QuotaDegradedPercent)
+	case dbgen.QuotaStageDegraded:
+		return fmt.Sprintf("Adaptive difficulty of your properties is disabled until the end of the month and after %d%% of the limit your properties will only get default puzzles without protection against spikes of traffic.", db.QuotaExceededPercent)
+	case dbgen.QuotaStageExceeded:
+		return "Your properties only get default puzzles until the end of the month and traffic is no longer tracked in the statistics."
+	default:
+		return ""
+	}
+}
+
+func (j *MonthlyQuotaJob) requestsLimit(ctx context.Context, user *dbgen.GetUsersWithSubscriptionsRow) int64 {
+	if !user.Status.Valid || !j.PlanService.IsSubscriptionActive(user.Status.String) {
+		return 0
+	}
+
+	internal := user.Source.Valid && db.IsInternalSubscription(user.Source.SubscriptionSource)
+	plan, err := j.PlanService.FindPlan(user.ExternalProductID.String, user.ExternalPriceID.String, j.Stage, internal)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to find billing plan for quota", "userID", user.User.ID, common.ErrAttr(err))
+		return 0
+	}
+
+	return plan.RequestsLimit()
+}
+
+func (j *MonthlyQuotaJob) notify(ctx context.Context, quota *dbgen.UpsertUserQuotaParams, orgID int32, periodStart time.Time) error {
+	_, err := j.BusinessDB.Impl().CreateUserNotification(ctx, &common.ScheduledNotification{
+		// at most one notification per stage within the period
+		ReferenceID: fmt.Sprintf("user/%v/quota/%s/%s", quota.UserID, quota.Stage, periodStart.Format(time.DateOnly)),
+		UserID:      quota.UserID,
+		OrgID:       orgID,
+		Subject:     fmt.Sprintf("[%s] You have used %.0f%% of your monthly requests", common.PrivateCaptcha, db.QuotaUsagePercent(quota.Requests, quota.RequestsLimit)),
+		Data: &email.QuotaWarningContext{
+			UsagePercent:      fmt.Sprintf("%.0f%%", db.QuotaUsagePercent(quota.Requests, quota.RequestsLimit)),
+			RequestsUsed:      fmt.Sprintf("%d", quota.Requests),
+			RequestsLimit:     fmt.Sprintf("%d", quota.RequestsLimit),
+			QuotaConsequence:  quotaConsequence(quota.Stage),
+			UsageSettingsPath: fmt.Sprintf("%s?%s=%s", common.SettingsEndpoint, common.ParamTab, common.UsageEndpoint),
+		},
+		DateTime:     time.Now().UTC(),
+		TemplateHash: email.QuotaWarningTemplate.Hash(),
+		Persistent:   false,
+		Condition:    common.NotificationWithSubscription,
+	})
+
+	return err
+}
+
+func (j *MonthlyQuotaJob) RunOnce(ctx context.Context, params any) error {
+	tnow := time.Now().UTC()
+	periodStart := db.QuotaPeriodStart(tnow)
+
+	usage, err := j.TimeSeries.RetrieveOrgUsage(ctx, periodStart, tnow)
+	if err != nil {
+		return err
+	}
+
+	// sandbox organizations are excluded from the plan limits
+	sandboxes, err := retrieveSandboxOrgs(ctx, j.BusinessDB, quotaSandboxOrgsBatchSize)
+	if err != nil {
+		return err
+	}
+
+	sandboxIDs := make(map[int32]struct{}, len(sandboxes))
+	for _, org := range sandboxes {
+		sandboxIDs[org.ID] = struct{}{}
+	}
+
+	requests := make(map[int32]int64)
+	// organization with most requests of each user, its billing contacts are notified too
+	topOrgs := make(map[int32]*common.OrgUsageStat)
+	for _, u := range usage {
+		if _, ok := sandboxIDs[u.OrgID]; !ok {
+			requests[u.UserID] += int64(u.Requests)
+
+			if top, ok := topOrgs[u.UserID]; !ok || (u.Requests > top.Requests) {
+				topOrgs[u.UserID] = u
+			}
+		}
+	}
+
+	userIDs := make([]int32, 0, len(requests))
+	for userID := range requests {
+		userIDs = append(userIDs, userID)
+	}
+	slices.Sort(userIDs)
+
+	users, err := j.BusinessDB.Impl().RetrieveUsersWithSubscriptions(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	previous, err := j.BusinessDB.Impl().RetrieveUserQuotas(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	escalated := 0
+
+	for _, user := range users {
+		limit := j.requestsLimit(ctx, user)
+		quota := &dbgen.UpsertUserQuotaParams{
+			UserID:        user.User.ID,
+			PeriodStart:   db.Timestampz(periodStart),
+			Requests:      requests[user.User.ID],
+			RequestsLimit: limit,
+			Stage:         db.QuotaStageForUsage(requests[user.User.ID], limit),
+		}
+
+		prevStage := dbgen.QuotaStageNormal
+		if prev, ok := previous[user.User.ID]; ok && prev.PeriodStart.Time.Equal(periodStart) {
+			prevStage = prev.Stage
+		}
+
+		if err := j.BusinessDB.Impl().UpdateUserQuota(ctx, quota); err != nil {
+			continue
+		}
+
+		if db.QuotaStageSeverity(quota.Stage) > db.QuotaStageSeverity(prevStage) {
+			escalated++
+
+			slog.InfoContext(ctx, "Monthly quota stage escalated", "userID", quota.UserID, "stage", quota.Stage,
+				"previous", prevStage, "requests", quota.Requests, "limit", quota.RequestsLimit)
+
+			var orgID int32
+			if top, ok := topOrgs[quota.UserID]; ok {
+				orgID = top.OrgID
+			}
+
+			if err := j.notify(ctx, quota, orgID, periodStart); err != nil {
+				slog.ErrorContext(ctx, "Failed to create quota notification", "userID", quota.UserID, common.ErrAttr(err))
+			}
+		}
+	}
+
+	slog.InfoContext(ctx, "Updated monthly quotas", "users", len(users), "escalated", escalated, "period", periodStart)
+
+	return nil
+}
+
+// RefreshMonthlyQuotasJob keeps in-memory registry of enforced quotas in sync with DB (on every instance)
+type RefreshMonthlyQuotasJob struct {
+	BusinessDB db.Implementor
+	Quotas     *db.MonthlyQuotas
+}
+
+var _ common.PeriodicJob = (*RefreshMonthlyQuotasJob)(nil)
+
+func (j *RefreshMonthlyQuotasJob) Timeout() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshMonthlyQuotasJob) Interval() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *RefreshMonthlyQuotasJob) Jitter() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshMonthlyQuotasJob) Name() string {
+	return "refresh_monthly_quotas_job"
+}
+
+func (j *RefreshMonthlyQuotasJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *RefreshMonthlyQuotasJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *RefreshMonthlyQuotasJob) RunOnce(ctx context.Context, params any) error {
+	quotas, err := j.BusinessDB.Impl().RetrieveEnforcedUserQuotas(ctx, db.QuotaPeriodStart(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	j.Quotas.Update(quotas)
+
+	slog.DebugContext(ctx, "Refreshed monthly quotas", "count", j.Quotas.Count())
+
+	return nil
+}
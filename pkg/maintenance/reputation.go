@@ -0,0 +1,44 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ipreputation"
+)
+
+// RefreshIPReputationJob reloads IP reputation feeds (on every instance) on schedule and when configuration is reloaded
+type RefreshIPReputationJob struct {
+	Reputation *ipreputation.Reputation
+}
+
+var _ common.PeriodicJob = (*RefreshIPReputationJob)(nil)
+
+func (j *RefreshIPReputationJob) Timeout() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *RefreshIPReputationJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *RefreshIPReputationJob) Jitter() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *RefreshIPReputationJob) Name() string {
+	return "refresh_ip_reputation_job"
+}
+
+func (j *RefreshIPReputationJob) Trigger() <-chan struct{} {
+	return j.Reputation.Trigger()
+}
+
+func (j *RefreshIPReputationJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *RefreshIPReputationJob) RunOnce(ctx context.Context, params any) error {
+	return j.Reputation.Refresh(ctx)
+}
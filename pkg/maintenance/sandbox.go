@@ -0,0 +1,175 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	sandboxResetCacheKeyPrefix = "sandbox_reset/"
+	sandboxResetCacheTTL       = 48 * time.Hour
+)
+
+// ResetSandboxOrgsJob wipes properties (with their stats) and API keys of all sandbox organizations once per day,
+// at the first run after Hour (UTC). Completed reset is marked in DB cache so it's not repeated on other nodes.
+type ResetSandboxOrgsJob struct {
+	BusinessDB db.Implementor
+	TimeSeries common.TimeSeriesStore
+	Hour       int
+	BatchSize  int
+}
+
+var _ common.PeriodicJob = (*ResetSandboxOrgsJob)(nil)
+
+type ResetSandboxOrgsParams struct {
+	Hour      int `json:"hour"`
+	BatchSize int `json:"batch_size"`
+	// Force resets sandboxes regardless of the time and the previous reset (used for manual runs)
+	Force bool `json:"force"`
+}
+
+func (j *ResetSandboxOrgsJob) Timeout() time.Duration {
+	return 30 * time.Minute
+}
+
+func (j *ResetSandboxOrgsJob) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+func (j *ResetSandboxOrgsJob) Jitter() time.Duration {
+	return 5 * time.Minute
+}
+
+func (j *ResetSandboxOrgsJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *ResetSandboxOrgsJob) Name() string {
+	return "reset_sandbox_orgs_job"
+}
+
+func (j *ResetSandboxOrgsJob) NewParams() any {
+	return &ResetSandboxOrgsParams{
+		Hour:      j.Hour,
+		BatchSize: j.BatchSize,
+	}
+}
+
+func sandboxResetCacheKey(tnow time.Time) string {
+	return sandboxResetCacheKeyPrefix + tnow.UTC().Format(time.DateOnly)
+}
+
+// isSandboxResetDue checks if the reset of the current day should happen already
+func isSandboxResetDue(tnow time.Time, hour int) bool {
+	return tnow.UTC().Hour() >= hour
+}
+
+// retrieveSandboxOrgs returns all sandbox organizations, reading them in batches
+func retrieveSandboxOrgs(ctx context.Context, store db.Implementor, batchSize int) ([]*dbgen.Organization, error) {
+	var afterID int32
+	var result []*dbgen.Organization
+
+	for {
+		orgs, err := store.Impl().RetrieveSandboxOrganizations(ctx, afterID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, orgs...)
+
+		if len(orgs) < batchSize {
+			break
+		}
+
+		afterID = orgs[len(orgs)-1].ID
+	}
+
+	return result, nil
+}
+
+// resetOrgs deletes stats of all sandbox properties at once, as every ClickHouse delete is a (heavy) mutation
+func (j *ResetSandboxOrgsJob) resetOrgs(ctx context.Context, batchSize int) (int, error) {
+	allOrgs, err := retrieveSandboxOrgs(ctx, j.BusinessDB, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	orgs := make([]*dbgen.Organization, 0, len(allOrgs))
+	propertyIDs := make([]int32, 0)
+	var anyErr error
+
+	for _, org := range allOrgs {
+		ids, err := j.BusinessDB.Impl().RetrieveOrgPropertyIDs(ctx, org.ID)
+		if err != nil {
+			anyErr = err
+			continue
+		}
+
+		orgs = append(orgs, org)
+		propertyIDs = append(propertyIDs, ids...)
+	}
+
+	if len(propertyIDs) > 0 {
+		if err := j.TimeSeries.DeletePropertiesData(ctx, propertyIDs); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete sandbox properties data", "orgs", len(orgs), "properties", len(propertyIDs), common.ErrAttr(err))
+			return 0, err
+		}
+	}
+
+	var count int
+
+	for _, org := range orgs {
+		if err := j.BusinessDB.Impl().ResetSandboxOrganization(ctx, org); err != nil {
+			anyErr = err
+			continue
+		}
+
+		count++
+	}
+
+	return count, anyErr
+}
+
+func (j *ResetSandboxOrgsJob) RunOnce(ctx context.Context, params any) error {
+	p, ok := params.(*ResetSandboxOrgsParams)
+	if !ok || (p == nil) {
+		slog.ErrorContext(ctx, "Job parameter has incorrect type", "params", params, "job", j.Name())
+		p = j.NewParams().(*ResetSandboxOrgsParams)
+	}
+
+	if p.BatchSize <= 0 {
+		p.BatchSize = j.BatchSize
+	}
+
+	tnow := time.Now().UTC()
+	cacheKey := sandboxResetCacheKey(tnow)
+
+	if !p.Force {
+		if !isSandboxResetDue(tnow, p.Hour) {
+			slog.DebugContext(ctx, "Sandbox reset is not due yet", "hour", p.Hour)
+			return nil
+		}
+
+		if _, err := j.BusinessDB.Impl().RetrieveFromCache(ctx, cacheKey); err == nil {
+			slog.DebugContext(ctx, "Sandbox organizations were already reset today")
+			return nil
+		} else if err != db.ErrCacheMiss {
+			return err
+		}
+	}
+
+	count, err := j.resetOrgs(ctx, p.BatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reset sandbox organizations", "count", count, common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Reset sandbox organizations", "count", count)
+
+	return j.BusinessDB.Impl().StoreInCache(ctx, cacheKey, []byte(tnow.Format(time.RFC3339)), sandboxResetCacheTTL)
+}
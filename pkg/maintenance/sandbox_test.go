@@ -0,0 +1,33 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSandboxResetSchedule(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		tnow time.Time
+		hour int
+		due  bool
+		key  string
+	}{
+		{time.Date(2026, 10, 16, 2, 59, 0, 0, time.UTC), 3, false, "sandbox_reset/2026-10-16"},
+		{time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC), 3, true, "sandbox_reset/2026-10-16"},
+		// missed hour is still processed on the same day
+		{time.Date(2026, 10, 16, 23, 30, 0, 0, time.UTC), 3, true, "sandbox_reset/2026-10-16"},
+		{time.Date(2026, 10, 17, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60)), 0, true, "sandbox_reset/2026-10-16"},
+	}
+
+	for i, tc := range testCases {
+		if due := isSandboxResetDue(tc.tnow, tc.hour); due != tc.due {
+			t.Errorf("Unexpected due (%v): %v", i, due)
+		}
+
+		if key := sandboxResetCacheKey(tc.tnow); key != tc.key {
+			t.Errorf("Unexpected cache key (%v): %v", i, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	businessHoursStart = 9
+	businessHoursEnd   = 17
+)
+
+// nextBusinessHours returns t if it is within business hours (weekdays, 9:00-17:00) in the location or the start
+// of the next business day otherwise
+func nextBusinessHours(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+
+	for i := 0; i < 7; i++ {
+		day := local.AddDate(0, 0, i)
+		if wd := day.Weekday(); (wd == time.Saturday) || (wd == time.Sunday) {
+			continue
+		}
+
+		end := time.Date(day.Year(), day.Month(), day.Day(), businessHoursEnd, 0, 0, 0, loc)
+		if !t.Before(end) {
+			continue
+		}
+
+		if start := time.Date(day.Year(), day.Month(), day.Day(), businessHoursStart, 0, 0, 0, loc); t.Before(start) {
+			return start.UTC()
+		}
+
+		return t
+	}
+
+	return t
+}
+
+// splitOutOfHoursNotifications separates notifications that should wait for business hours of the recipient from
+// the rest. Notifications of users without known (or valid) time zone are sent right away
+func splitOutOfHoursNotifications(ctx context.Context,
+	notifications []*dbgen.GetPendingUserNotificationsRow,
+	tnow time.Time) (send []*dbgen.GetPendingUserNotificationsRow, deferred map[int32]time.Time) {
+	send = make([]*dbgen.GetPendingUserNotificationsRow, 0, len(notifications))
+	deferred = make(map[int32]time.Time)
+	locations := make(map[string]*time.Location)
+
+	for _, n := range notifications {
+		if !n.Timezone.Valid || (len(n.Timezone.String) == 0) {
+			send = append(send, n)
+			continue
+		}
+
+		loc, ok := locations[n.Timezone.String]
+		if !ok {
+			var err error
+			if loc, err = time.LoadLocation(n.Timezone.String); err != nil {
+				slog.WarnContext(ctx, "Failed to load user time zone", "timezone", n.Timezone.String, common.ErrAttr(err))
+			}
+			locations[n.Timezone.String] = loc
+		}
+
+		if loc == nil {
+			send = append(send, n)
+			continue
+		}
+
+		if scheduledAt := nextBusinessHours(tnow, loc); scheduledAt.After(tnow) {
+			deferred[n.UserNotification.ID] = scheduledAt
+		} else {
+			send = append(send, n)
+		}
+	}
+
+	return send, deferred
+}
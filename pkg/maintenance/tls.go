@@ -0,0 +1,218 @@
+package maintenance
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+)
+
+const (
+	sessionTicketKeysCacheKey = "tls_session_ticket_keys"
+	sessionTicketKeySize      = 32
+	// unix timestamp + key
+	sessionTicketEntrySize = 8 + sessionTicketKeySize
+	// pending (not yet active) key, current one and previous ones that can only decrypt older tickets
+	maxSessionTicketKeys = 4
+	// new key is only used for encryption after every instance had a chance to pick it up for decryption
+	sessionTicketKeyActivation = 5 * time.Minute
+)
+
+var (
+	errInvalidSessionTicketKeys = errors.New("invalid session ticket keys")
+)
+
+type sessionTicketKey struct {
+	CreatedAt time.Time
+	Key       [sessionTicketKeySize]byte
+}
+
+// encodeSessionTicketKeys serializes keys (newest first) to be shared between instances via DB cache
+func encodeSessionTicketKeys(keys []*sessionTicketKey) []byte {
+	data := make([]byte, 0, len(keys)*sessionTicketEntrySize)
+
+	for _, k := range keys {
+		data = binary.BigEndian.AppendUint64(data, uint64(k.CreatedAt.Unix()))
+		data = append(data, k.Key[:]...)
+	}
+
+	return data
+}
+
+func decodeSessionTicketKeys(data []byte) ([]*sessionTicketKey, error) {
+	if (len(data) == 0) || (len(data)%sessionTicketEntrySize != 0) {
+		return nil, errInvalidSessionTicketKeys
+	}
+
+	keys := make([]*sessionTicketKey, 0, len(data)/sessionTicketEntrySize)
+
+	for i := 0; i < len(data); i += sessionTicketEntrySize {
+		k := &sessionTicketKey{
+			CreatedAt: time.Unix(int64(binary.BigEndian.Uint64(data[i:])), 0).UTC(),
+		}
+		copy(k.Key[:], data[i+8:i+sessionTicketEntrySize])
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// activeSessionTicketKeys orders keys for tls.Config.SetSessionTicketKeys(), where the first key is used for
+// encryption: the newest key that is older than activation period goes first, others are kept only for decryption
+func activeSessionTicketKeys(keys []*sessionTicketKey, tnow time.Time) [][sessionTicketKeySize]byte {
+	result := make([][sessionTicketKeySize]byte, 0, len(keys))
+
+	active := -1
+	for i, k := range keys {
+		if tnow.Sub(k.CreatedAt) >= sessionTicketKeyActivation {
+			active = i
+			break
+		}
+	}
+
+	if active == -1 {
+		// all keys are fresh (e.g. the very first rotation), so there's nothing to wait for
+		active = 0
+	}
+
+	result = append(result, keys[active].Key)
+
+	for i, k := range keys {
+		if i != active {
+			result = append(result, k.Key)
+		}
+	}
+
+	return result
+}
+
+// RotateSessionTicketKeysJob generates new TLS session ticket key, shared by all instances via DB cache
+type RotateSessionTicketKeysJob struct {
+	Store    db.Implementor
+	Rotation time.Duration
+}
+
+var _ common.PeriodicJob = (*RotateSessionTicketKeysJob)(nil)
+
+func (j *RotateSessionTicketKeysJob) Timeout() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RotateSessionTicketKeysJob) Interval() time.Duration {
+	return 10 * time.Minute
+}
+
+func (j *RotateSessionTicketKeysJob) Jitter() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *RotateSessionTicketKeysJob) Name() string {
+	return "rotate_session_ticket_keys_job"
+}
+
+func (j *RotateSessionTicketKeysJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *RotateSessionTicketKeysJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *RotateSessionTicketKeysJob) RunOnce(ctx context.Context, params any) error {
+	var keys []*sessionTicketKey
+
+	if data, err := j.Store.Impl().RetrieveFromCache(ctx, sessionTicketKeysCacheKey); err == nil {
+		if keys, err = decodeSessionTicketKeys(data); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode session ticket keys", common.ErrAttr(err))
+		}
+	} else if err != db.ErrCacheMiss {
+		return err
+	}
+
+	tnow := time.Now().UTC()
+
+	if (len(keys) > 0) && (tnow.Sub(keys[0].CreatedAt) < j.Rotation) {
+		slog.DebugContext(ctx, "Session ticket key is still fresh", "created", keys[0].CreatedAt)
+		return nil
+	}
+
+	key := &sessionTicketKey{CreatedAt: tnow}
+	if _, err := rand.Read(key.Key[:]); err != nil {
+		return err
+	}
+
+	keys = append([]*sessionTicketKey{key}, keys...)
+	if len(keys) > maxSessionTicketKeys {
+		keys = keys[:maxSessionTicketKeys]
+	}
+
+	if err := j.Store.Impl().StoreInCache(ctx, sessionTicketKeysCacheKey, encodeSessionTicketKeys(keys), maxSessionTicketKeys*j.Rotation); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Rotated session ticket keys", "count", len(keys))
+
+	return nil
+}
+
+// RefreshSessionTicketKeysJob applies shared TLS session ticket keys to the listener (on every instance)
+// so that sessions can be resumed regardless of which instance the client lands on
+type RefreshSessionTicketKeysJob struct {
+	Store     db.Implementor
+	TLSConfig *tls.Config
+}
+
+var _ common.PeriodicJob = (*RefreshSessionTicketKeysJob)(nil)
+
+func (j *RefreshSessionTicketKeysJob) Timeout() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshSessionTicketKeysJob) Interval() time.Duration {
+	return 1 * time.Minute
+}
+
+func (j *RefreshSessionTicketKeysJob) Jitter() time.Duration {
+	return 10 * time.Second
+}
+
+func (j *RefreshSessionTicketKeysJob) Name() string {
+	return "refresh_session_ticket_keys_job"
+}
+
+func (j *RefreshSessionTicketKeysJob) Trigger() <-chan struct{} {
+	return nil
+}
+
+func (j *RefreshSessionTicketKeysJob) NewParams() any {
+	return struct{}{}
+}
+
+func (j *RefreshSessionTicketKeysJob) RunOnce(ctx context.Context, params any) error {
+	data, err := j.Store.Impl().RetrieveFromCache(ctx, sessionTicketKeysCacheKey)
+	if err == db.ErrCacheMiss {
+		// until keys are rotated for the first time, Go's per-instance keys are used
+		slog.DebugContext(ctx, "Session ticket keys are not available yet")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	keys, err := decodeSessionTicketKeys(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to decode session ticket keys", common.ErrAttr(err))
+		return err
+	}
+
+	j.TLSConfig.SetSessionTicketKeys(activeSessionTicketKeys(keys, time.Now().UTC()))
+
+	slog.DebugContext(ctx, "Refreshed session ticket keys", "count", len(keys))
+
+	return nil
+}
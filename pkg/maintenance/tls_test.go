@@ -0,0 +1,69 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionTicketKeysEncoding(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC().Truncate(time.Second)
+	keys := []*sessionTicketKey{
+		{CreatedAt: tnow, Key: [sessionTicketKeySize]byte{1, 2, 3}},
+		{CreatedAt: tnow.Add(-time.Hour), Key: [sessionTicketKeySize]byte{4, 5, 6}},
+	}
+
+	decoded, err := decodeSessionTicketKeys(encodeSessionTicketKeys(keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(keys) {
+		t.Fatalf("Expected %v keys but got %v", len(keys), len(decoded))
+	}
+
+	for i := range keys {
+		if !decoded[i].CreatedAt.Equal(keys[i].CreatedAt) || (decoded[i].Key != keys[i].Key) {
+			t.Errorf("Key %v was not decoded correctly", i)
+		}
+	}
+
+	if _, err := decodeSessionTicketKeys([]byte{1, 2, 3}); err != errInvalidSessionTicketKeys {
+		t.Errorf("Expected error for truncated data but got %v", err)
+	}
+}
+
+func TestActiveSessionTicketKeys(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC()
+	pending := &sessionTicketKey{CreatedAt: tnow.Add(-time.Minute), Key: [sessionTicketKeySize]byte{1}}
+	current := &sessionTicketKey{CreatedAt: tnow.Add(-time.Hour), Key: [sessionTicketKeySize]byte{2}}
+	previous := &sessionTicketKey{CreatedAt: tnow.Add(-2 * time.Hour), Key: [sessionTicketKeySize]byte{3}}
+
+	testCases := []struct {
+		name     string
+		keys     []*sessionTicketKey
+		expected []*sessionTicketKey
+	}{
+		{"first", []*sessionTicketKey{pending}, []*sessionTicketKey{pending}},
+		{"pending", []*sessionTicketKey{pending, current, previous}, []*sessionTicketKey{current, pending, previous}},
+		{"active", []*sessionTicketKey{current, previous}, []*sessionTicketKey{current, previous}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := activeSessionTicketKeys(tc.keys, tnow)
+			if len(actual) != len(tc.expected) {
+				t.Fatalf("Expected %v keys but got %v", len(tc.expected), len(actual))
+			}
+
+			for i := range actual {
+				if actual[i] != tc.expected[i].Key {
+					t.Errorf("Unexpected key at position %v", i)
+				}
+			}
+		})
+	}
+}
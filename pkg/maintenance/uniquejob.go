@@ -15,6 +15,8 @@ type UniquePeriodicJob struct {
 	// the usual logic is that we acquire lock for a longer duration than the job interval therefore
 	// when there are multiple workers, there's a higher chance of "stealing" the work
 	LockDuration time.Duration
+	// stored with the lock to see which node is running the job
+	Holder string
 }
 
 var _ common.PeriodicJob = (*UniquePeriodicJob)(nil)
@@ -41,6 +43,8 @@ func (j *UniquePeriodicJob) NewParams() any {
 
 func (j *UniquePeriodicJob) Trigger() <-chan struct{} { return j.Job.Trigger() }
 
+func (j *UniquePeriodicJob) Scheduled(at time.Time) { common.NotifyJobScheduled(j.Job, at) }
+
 func (j *UniquePeriodicJob) acquireLock(ctx context.Context, lockName string) error {
 	tnow := time.Now().UTC()
 	if lock, err := j.Store.Impl().RetrieveLock(ctx, lockName); (err == nil) && lock.ExpiresAt.Valid && lock.ExpiresAt.Time.After(tnow) {
@@ -51,7 +55,7 @@ func (j *UniquePeriodicJob) acquireLock(ctx context.Context, lockName string) er
 	expiration := tnow.Add(j.LockDuration)
 
 	_, err := j.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) ([]*common.AuditLogEvent, error) {
-		_, err := impl.AcquireLock(ctx, lockName, nil /*data*/, expiration)
+		_, err := impl.AcquireLock(ctx, lockName, []byte(j.Holder), expiration)
 		return nil, err
 	})
 	return err
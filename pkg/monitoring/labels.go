@@ -0,0 +1,62 @@
+package monitoring
+
+import (
+	"sync"
+)
+
+const (
+	// maximum number of distinct sitekeys exposed as label values, the rest is aggregated
+	maxPropertyLabels  = 1000
+	otherPropertyLabel = "other"
+	noPropertyLabel    = "none"
+)
+
+// boundedLabels keeps cardinality of a label under control: first seen values are used as is
+// and, once the limit is reached, all new ones collapse into a single value
+type boundedLabels struct {
+	mux    sync.RWMutex
+	values map[string]struct{}
+	limit  int
+	other  string
+}
+
+func newBoundedLabels(limit int, other string) *boundedLabels {
+	return &boundedLabels{
+		values: make(map[string]struct{}),
+		limit:  limit,
+		other:  other,
+	}
+}
+
+func (l *boundedLabels) value(v string) string {
+	l.mux.RLock()
+	_, ok := l.values[v]
+	l.mux.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if _, ok := l.values[v]; ok {
+		return v
+	}
+
+	if len(l.values) >= l.limit {
+		return l.other
+	}
+
+	l.values[v] = struct{}{}
+
+	return v
+}
+
+func (l *boundedLabels) sitekey(sitekey string) string {
+	if len(sitekey) == 0 {
+		return noPropertyLabel
+	}
+
+	return l.value(sitekey)
+}
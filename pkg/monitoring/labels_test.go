@@ -0,0 +1,30 @@
+package monitoring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBoundedLabels(t *testing.T) {
+	t.Parallel()
+
+	labels := newBoundedLabels(2, otherPropertyLabel)
+
+	for i := 0; i < 2; i++ {
+		if v := labels.sitekey(fmt.Sprintf("key%d", i)); v != fmt.Sprintf("key%d", i) {
+			t.Errorf("Unexpected label %v", v)
+		}
+	}
+
+	if v := labels.sitekey("key2"); v != otherPropertyLabel {
+		t.Errorf("Unexpected label over the limit: %v", v)
+	}
+
+	if v := labels.sitekey("key0"); v != "key0" {
+		t.Errorf("Unexpected label for known value: %v", v)
+	}
+
+	if v := labels.sitekey(""); v != noPropertyLabel {
+		t.Errorf("Unexpected label for empty sitekey: %v", v)
+	}
+}
@@ -0,0 +1,53 @@
+package monitoring
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+)
+
+// NOTE: (default) alternative would be to _ import the pprof package and start http server on :6060
+func (s *Service) setupProfiling(ctx context.Context, mux *http.ServeMux) {
+	slog.DebugContext(ctx, "Enabling profiling endpoints")
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+
+	profiles := []string{"goroutine", "heap", "allocs", "threadcreate", "block", "mutex"}
+	for _, p := range profiles {
+		mux.Handle("/debug/pprof/"+p, pprof.Handler(p))
+	}
+
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// EnableProfilingLabels turns on per-request labels independently of pprof endpoints (e.g. profiles
+// can be uploaded to the continuous profiler while endpoints are disabled)
+func (s *Service) EnableProfilingLabels(enabled bool) {
+	s.profilingLabels.Store(enabled)
+}
+
+// profiled attaches service and handler labels to CPU profile samples of the request (when profiling is enabled),
+// so that e.g. verify and template-render paths can be told apart in the continuous profiler
+func (s *Service) profiled(service string, handlerID string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.profilingLabels.Load() {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		id := handlerID
+		if len(id) == 0 {
+			id = r.URL.Path
+		}
+
+		labels := runtimepprof.Labels(serviceLabel, service, handlerIDLabel, id)
+		runtimepprof.Do(r.Context(), labels, func(ctx context.Context) {
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+}
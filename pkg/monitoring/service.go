@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
@@ -24,10 +25,19 @@ const (
 	MetricsNamespacePortal   = "portal"
 	puzzleMetricsSubsystem   = "puzzle"
 	platformMetricsSubsystem = "platform"
+	jobsMetricsSubsystem     = "jobs"
 	apiMetricsSubsystem      = "api"
 	userIDLabel              = "user_id"
 	stubLabel                = "stub"
 	resultLabel              = "result"
+	priorityLabel            = "priority"
+	entityLabel              = "entity"
+	heuristicLabel           = "heuristic"
+	actionLabel              = "action"
+	jobLabel                 = "job"
+	sitekeyLabel             = "sitekey"
+	limiterLabel             = "limiter"
+	componentLabel           = "component"
 	// below is copy from go-http-metrics prometheus.go since they are not exposed publicly
 	statusCodeLabel = "code"
 	methodLabel     = "label"
@@ -43,16 +53,34 @@ type Service struct {
 	coarseCDNMiddleware    middleware.Middleware
 	portalErrorCounter     *prometheus.CounterVec
 	apiErrorCounter        *prometheus.CounterVec
+	shedCounter            *prometheus.CounterVec
 	puzzleCounter          *prometheus.CounterVec
 	verifyCounter          *prometheus.CounterVec
+	botHeuristicCounter    *prometheus.CounterVec
+	skewToleratedCounter   *prometheus.CounterVec
+	propertyPuzzleCounter  *prometheus.CounterVec
+	propertyVerifyCounter  *prometheus.CounterVec
+	propertyVerifyDuration *prometheus.HistogramVec
+	rateLimitedCounter     *prometheus.CounterVec
+	propertyLabels         *boundedLabels
 	hitRatioGauge          *prometheus.GaugeVec
+	cacheCheckedCounter    *prometheus.CounterVec
+	cacheStaleCounter      *prometheus.CounterVec
+	componentEntriesGauge  *prometheus.GaugeVec
+	componentCapacityGauge *prometheus.GaugeVec
 	clickhouseHealthGauge  *prometheus.GaugeVec
 	postgresHealthGauge    *prometheus.GaugeVec
+	jobDurationHistogram   *prometheus.HistogramVec
+	jobLastRunGauge        *prometheus.GaugeVec
+	jobNextRunGauge        *prometheus.GaugeVec
+	profilingLabels        atomic.Bool
 }
 
 var _ common.PlatformMetrics = (*Service)(nil)
 var _ common.APIMetrics = (*Service)(nil)
 var _ common.PortalMetrics = (*Service)(nil)
+var _ common.LoadShedMetrics = (*Service)(nil)
+var _ common.JobMetrics = (*Service)(nil)
 
 func traceID() string {
 	return xid.New().String()
@@ -112,6 +140,73 @@ func NewService() *Service {
 	)
 	reg.MustRegister(verifyCounter)
 
+	botHeuristicCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceAPI,
+			Subsystem: puzzleMetricsSubsystem,
+			Name:      "bot_heuristic_total",
+			Help:      "Total number of puzzle requests matched by bot heuristics",
+		},
+		[]string{heuristicLabel, actionLabel},
+	)
+	reg.MustRegister(botHeuristicCounter)
+
+	skewToleratedCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceAPI,
+			Subsystem: puzzleMetricsSubsystem,
+			Name:      "verify_skew_tolerated_total",
+			Help:      "Total number of puzzles accepted after expiration due to the allowed clock skew",
+		},
+		[]string{resultLabel},
+	)
+	reg.MustRegister(skewToleratedCounter)
+
+	propertyPuzzleCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceAPI,
+			Subsystem: puzzleMetricsSubsystem,
+			Name:      "property_create_total",
+			Help:      "Total number of puzzles created per property",
+		},
+		[]string{sitekeyLabel},
+	)
+	reg.MustRegister(propertyPuzzleCounter)
+
+	propertyVerifyCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceAPI,
+			Subsystem: puzzleMetricsSubsystem,
+			Name:      "property_verify_total",
+			Help:      "Total number of puzzle verifications per property, by verification result",
+		},
+		[]string{sitekeyLabel, resultLabel},
+	)
+	reg.MustRegister(propertyVerifyCounter)
+
+	propertyVerifyDuration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespaceAPI,
+			Subsystem: puzzleMetricsSubsystem,
+			Name:      "property_verify_duration_seconds",
+			Help:      "Duration of puzzle verifications per property",
+			Buckets:   []float64{.001, .005, .01, .05, .1, .5},
+		},
+		[]string{sitekeyLabel},
+	)
+	reg.MustRegister(propertyVerifyDuration)
+
+	rateLimitedCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "ratelimit_rejected_total",
+			Help:      "Total number of requests rejected by rate limiter",
+		},
+		[]string{limiterLabel},
+	)
+	reg.MustRegister(rateLimitedCounter)
+
 	portalErrorCounter := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "fine", // this is the same as fine http metrics below to match go-http-metrics logic
@@ -134,6 +229,17 @@ func NewService() *Service {
 	)
 	reg.MustRegister(apiErrorCounter)
 
+	shedCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "shed_total",
+			Help:      "Total number of requests rejected due to overload",
+		},
+		[]string{serviceLabel, priorityLabel},
+	)
+	reg.MustRegister(shedCounter)
+
 	clickhouseHealthGauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: MetricsNamespacePortal,
@@ -167,6 +273,84 @@ func NewService() *Service {
 	)
 	reg.MustRegister(hitRatioGauge)
 
+	cacheCheckedCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "cache_validated_total",
+			Help:      "Total number of in-memory cache entries validated against DB",
+		},
+		[]string{entityLabel},
+	)
+	reg.MustRegister(cacheCheckedCounter)
+
+	cacheStaleCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "cache_stale_total",
+			Help:      "Total number of stale in-memory cache entries found and evicted",
+		},
+		[]string{entityLabel},
+	)
+	reg.MustRegister(cacheStaleCounter)
+
+	componentEntriesGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "component_entries",
+			Help:      "Number of entries in the in-memory component (cache, buckets, buffer)",
+		},
+		[]string{componentLabel},
+	)
+	reg.MustRegister(componentEntriesGauge)
+
+	componentCapacityGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: platformMetricsSubsystem,
+			Name:      "component_capacity",
+			Help:      "Maximum number of entries in the in-memory component, as sized by the footprint profile",
+		},
+		[]string{componentLabel},
+	)
+	reg.MustRegister(componentCapacityGauge)
+
+	jobDurationHistogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: jobsMetricsSubsystem,
+			Name:      "duration_seconds",
+			Help:      "Duration of maintenance job runs",
+			Buckets:   common.JobDurationBuckets,
+		},
+		[]string{jobLabel, resultLabel},
+	)
+	reg.MustRegister(jobDurationHistogram)
+
+	jobLastRunGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: jobsMetricsSubsystem,
+			Name:      "last_run_timestamp_seconds",
+			Help:      "Unix time when maintenance job last finished, by result",
+		},
+		[]string{jobLabel, resultLabel},
+	)
+	reg.MustRegister(jobLastRunGauge)
+
+	jobNextRunGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespaceServer,
+			Subsystem: jobsMetricsSubsystem,
+			Name:      "next_run_timestamp_seconds",
+			Help:      "Unix time when periodic maintenance job is scheduled to run next",
+		},
+		[]string{jobLabel},
+	)
+	reg.MustRegister(jobNextRunGauge)
+
 	fineRecorder := prometheus_metrics.NewRecorder(prometheus_metrics.Config{
 		Prefix:          "fine",
 		Registry:        reg,
@@ -209,20 +393,35 @@ func NewService() *Service {
 			DisableMeasureInflight: true,
 			Recorder:               coarseRecorder,
 		}),
-		puzzleCounter:         puzzleCounter,
-		verifyCounter:         verifyCounter,
-		hitRatioGauge:         hitRatioGauge,
-		clickhouseHealthGauge: clickhouseHealthGauge,
-		postgresHealthGauge:   postgresHealthGauge,
-		portalErrorCounter:    portalErrorCounter,
-		apiErrorCounter:       apiErrorCounter,
+		puzzleCounter:          puzzleCounter,
+		verifyCounter:          verifyCounter,
+		botHeuristicCounter:    botHeuristicCounter,
+		skewToleratedCounter:   skewToleratedCounter,
+		propertyPuzzleCounter:  propertyPuzzleCounter,
+		propertyVerifyCounter:  propertyVerifyCounter,
+		propertyVerifyDuration: propertyVerifyDuration,
+		rateLimitedCounter:     rateLimitedCounter,
+		propertyLabels:         newBoundedLabels(maxPropertyLabels, otherPropertyLabel),
+		hitRatioGauge:          hitRatioGauge,
+		cacheCheckedCounter:    cacheCheckedCounter,
+		cacheStaleCounter:      cacheStaleCounter,
+		componentEntriesGauge:  componentEntriesGauge,
+		componentCapacityGauge: componentCapacityGauge,
+		clickhouseHealthGauge:  clickhouseHealthGauge,
+		postgresHealthGauge:    postgresHealthGauge,
+		portalErrorCounter:     portalErrorCounter,
+		apiErrorCounter:        apiErrorCounter,
+		shedCounter:            shedCounter,
+		jobDurationHistogram:   jobDurationHistogram,
+		jobLastRunGauge:        jobLastRunGauge,
+		jobNextRunGauge:        jobNextRunGauge,
 	}
 }
 
 // this belongs only to APIMetrics interface (at this time)
 func (s *Service) Handler(h http.Handler) http.Handler {
 	// handlerID is taken from the request path in this case
-	return std.Handler("", s.fineAPIMiddleware, h)
+	return std.Handler("", s.fineAPIMiddleware, s.profiled(MetricsNamespaceAPI, "", h))
 }
 
 func (s *Service) CDNHandler(h http.Handler) http.Handler {
@@ -237,7 +436,7 @@ func (s *Service) IgnoredHandler(h http.Handler) http.Handler {
 func (s *Service) HandlerIDFunc(handlerIDFunc func() string) func(http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
 		handlerID := handlerIDFunc()
-		return std.Handler(handlerID, s.finePortalMiddleware, h)
+		return std.Handler(handlerID, s.finePortalMiddleware, s.profiled(MetricsNamespacePortal, handlerID, h))
 	}
 }
 
@@ -250,6 +449,13 @@ func (s *Service) ObserveApiError(handlerID string, method string, code int) {
 	}).Inc()
 }
 
+func (s *Service) ObserveRequestShed(service string, priority string) {
+	s.shedCounter.With(prometheus.Labels{
+		serviceLabel:  service,
+		priorityLabel: priority,
+	}).Inc()
+}
+
 func (s *Service) ObserveHttpError(handlerID string, method string, code int) {
 	s.portalErrorCounter.With(prometheus.Labels{
 		handlerIDLabel:  handlerID,
@@ -265,10 +471,50 @@ func (s *Service) ObservePuzzleCreated(userID int32) {
 	}).Inc()
 }
 
+func (s *Service) ObservePropertyPuzzleCreated(sitekey string) {
+	s.propertyPuzzleCounter.With(prometheus.Labels{
+		sitekeyLabel: s.propertyLabels.sitekey(sitekey),
+	}).Inc()
+}
+
+func (s *Service) ObservePropertyPuzzleVerified(sitekey string, result string, duration time.Duration) {
+	label := s.propertyLabels.sitekey(sitekey)
+
+	s.propertyVerifyCounter.With(prometheus.Labels{
+		sitekeyLabel: label,
+		resultLabel:  result,
+	}).Inc()
+
+	if duration > 0 {
+		s.propertyVerifyDuration.With(prometheus.Labels{sitekeyLabel: label}).Observe(duration.Seconds())
+	}
+}
+
+func (s *Service) ObserveRateLimited(limiter string) {
+	s.rateLimitedCounter.With(prometheus.Labels{limiterLabel: limiter}).Inc()
+}
+
+func (s *Service) ObserveBotHeuristic(heuristic string, action string) {
+	s.botHeuristicCounter.With(prometheus.Labels{
+		heuristicLabel: heuristic,
+		actionLabel:    action,
+	}).Inc()
+}
+
+func (s *Service) ObserveVerifySkewTolerated(result string) {
+	s.skewToleratedCounter.With(prometheus.Labels{resultLabel: result}).Inc()
+}
+
 func (s *Service) ObserveCacheHitRatio(ratio float64) {
 	s.hitRatioGauge.With(prometheus.Labels{}).Set(ratio)
 }
 
+func (s *Service) ObserveCacheValidation(entity string, checked, stale int) {
+	labels := prometheus.Labels{entityLabel: entity}
+	s.cacheCheckedCounter.With(labels).Add(float64(checked))
+	s.cacheStaleCounter.With(labels).Add(float64(stale))
+}
+
 func (s *Service) ObservePuzzleVerified(userID int32, result string, isStub bool) {
 	s.verifyCounter.With(prometheus.Labels{
 		stubLabel:   strconv.FormatBool(isStub),
@@ -277,6 +523,12 @@ func (s *Service) ObservePuzzleVerified(userID int32, result string, isStub bool
 	}).Inc()
 }
 
+func (s *Service) ObserveComponentUsage(component string, entries, capacity int) {
+	labels := prometheus.Labels{componentLabel: component}
+	s.componentEntriesGauge.With(labels).Set(float64(entries))
+	s.componentCapacityGauge.With(labels).Set(float64(capacity))
+}
+
 func (s *Service) ObserveHealth(postgres, clickhouse bool) {
 	var chVal, pgVal float64
 
@@ -296,7 +548,27 @@ func (s *Service) ObserveHealth(postgres, clickhouse bool) {
 	s.clickhouseHealthGauge.With(prometheus.Labels{}).Set(chVal)
 }
 
-func (s *Service) Setup(mux *http.ServeMux) {
+func (s *Service) ObserveJobRun(job string, duration time.Duration, success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+
+	labels := prometheus.Labels{jobLabel: job, resultLabel: result}
+	s.jobDurationHistogram.With(labels).Observe(duration.Seconds())
+	s.jobLastRunGauge.With(labels).SetToCurrentTime()
+}
+
+func (s *Service) ObserveJobScheduled(job string, at time.Time) {
+	s.jobNextRunGauge.With(prometheus.Labels{jobLabel: job}).Set(float64(at.Unix()))
+}
+
+func (s *Service) Setup(mux *http.ServeMux, profiling bool) {
 	mux.Handle(http.MethodGet+" /metrics", common.Recovered(promhttp.HandlerFor(s.Registry, promhttp.HandlerOpts{Registry: s.Registry})))
-	s.setupProfiling(context.TODO(), mux)
+
+	if profiling || profilingForced {
+		s.setupProfiling(context.TODO(), mux)
+	} else {
+		slog.Log(context.TODO(), common.LevelTrace, "Profiling endpoints are not enabled")
+	}
 }
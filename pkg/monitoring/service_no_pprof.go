@@ -2,14 +2,4 @@
 
 package monitoring
 
-import (
-	"context"
-	"log/slog"
-	"net/http"
-
-	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
-)
-
-func (s *Service) setupProfiling(ctx context.Context, mux *http.ServeMux) {
-	slog.Log(ctx, common.LevelTrace, "Profiling is not enabled during compile time")
-}
+const profilingForced = false
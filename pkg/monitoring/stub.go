@@ -2,6 +2,7 @@ package monitoring
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
@@ -27,8 +28,25 @@ func (sm *stubMetrics) ObservePuzzleCreated(userID int32) {}
 
 func (sm *stubMetrics) ObservePuzzleVerified(userID int32, result string, isStub bool) {}
 
-func (sm *stubMetrics) ObserveHealth(postgres, clickhouse bool) {}
-func (sm *stubMetrics) ObserveCacheHitRatio(ratio float64)      {}
+func (sm *stubMetrics) ObservePropertyPuzzleCreated(sitekey string) {}
+
+func (sm *stubMetrics) ObservePropertyPuzzleVerified(sitekey, result string, d time.Duration) {}
+
+func (sm *stubMetrics) ObserveBotHeuristic(heuristic string, action string) {}
+
+func (sm *stubMetrics) ObserveVerifySkewTolerated(result string) {}
+
+func (sm *stubMetrics) ObserveRateLimited(limiter string) {}
+
+func (sm *stubMetrics) ObserveHealth(postgres, clickhouse bool)                       {}
+func (sm *stubMetrics) ObserveCacheHitRatio(ratio float64)                            {}
+func (sm *stubMetrics) ObserveCacheValidation(entity string, checked, stale int)      {}
+func (sm *stubMetrics) ObserveComponentUsage(component string, entries, capacity int) {}
 
 func (sm *stubMetrics) ObserveHttpError(handlerID string, method string, code int) {}
 func (sm *stubMetrics) ObserveApiError(handlerID string, method string, code int)  {}
+
+func (sm *stubMetrics) ObserveRequestShed(service string, priority string) {}
+
+func (sm *stubMetrics) ObserveJobRun(job string, duration time.Duration, success bool) {}
+func (sm *stubMetrics) ObserveJobScheduled(job string, at time.Time)                   {}
@@ -0,0 +1,218 @@
+// Package orgarchive implements portable archives of organizations (properties with settings, members' roles and
+// API key metadata without secrets), that are signed with a user passphrase so that they can be safely moved
+// to another installation, e.g. when migrating from SaaS to self-hosted enterprise edition
+package orgarchive
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	Version             = 1
+	MinPassphraseLength = 12
+	// OWASP recommendation for PBKDF2-HMAC-SHA256. It is pinned (not taken from the archive) so that the cost of
+	// opening an archive cannot be chosen by whoever submits it
+	keyIterations = 600_000
+	keyLength     = 32
+	saltLength    = 16
+)
+
+var (
+	ErrPassphrase = errors.New("passphrase is too short")
+	ErrVersion    = errors.New("unsupported archive version")
+	ErrFormat     = errors.New("archive format is not valid")
+	ErrSignature  = errors.New("archive signature does not match")
+)
+
+type Organization struct {
+	Name string `json:"name"`
+}
+
+type Property struct {
+	// reference of the property within archive, used only for twins
+	Ref                    string            `json:"ref"`
+	Name                   string            `json:"name"`
+	Domain                 string            `json:"domain"`
+	Environment            string            `json:"environment"`
+	TwinRef                string            `json:"twin_ref,omitempty"`
+	Level                  int               `json:"level"`
+	Growth                 string            `json:"growth"`
+	ValiditySeconds        int               `json:"validity_seconds"`
+	AllowSubdomains        bool              `json:"allow_subdomains,omitempty"`
+	AllowLocalhost         bool              `json:"allow_localhost,omitempty"`
+	MaxReplayCount         int               `json:"max_replay_count"`
+	ClockSkewSeconds       int               `json:"clock_skew_seconds,omitempty"`
+	RememberSeconds        int               `json:"remember_seconds,omitempty"`
+	DifferentialDifficulty bool              `json:"differential_difficulty,omitempty"`
+	BotPolicy              string            `json:"bot_policy,omitempty"`
+	DifficultyStrategy     string            `json:"difficulty_strategy,omitempty"`
+	FailureURL             string            `json:"failure_url,omitempty"`
+	FailureMessage         string            `json:"failure_message,omitempty"`
+	RequireInteraction     bool              `json:"require_interaction,omitempty"`
+	NoAutoRefresh          bool              `json:"no_auto_refresh,omitempty"`
+	ImageChallenge         bool              `json:"image_challenge,omitempty"`
+	TrustGroup             string            `json:"trust_group,omitempty"`
+	Claims                 map[string]string `json:"claims,omitempty"`
+}
+
+type Member struct {
+	Email string `json:"email"`
+	Level string `json:"level"`
+}
+
+type APIKey struct {
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	Readonly  bool      `json:"readonly,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Archive struct {
+	Version      int          `json:"version"`
+	ExportedAt   time.Time    `json:"exported_at"`
+	Organization Organization `json:"organization"`
+	Properties   []*Property  `json:"properties"`
+	Members      []*Member    `json:"members"`
+	APIKeys      []*APIKey    `json:"api_keys"`
+}
+
+// Envelope is the signed archive as it is stored and transferred. Payload is the serialized Archive
+type Envelope struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Payload    []byte `json:"payload"`
+	Signature  []byte `json:"signature"`
+}
+
+// Key is derived from the passphrase so that the passphrase itself does not need to be stored, e.g. in async task input
+type Key struct {
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Secret     []byte `json:"secret"`
+}
+
+func deriveKey(passphrase string, salt []byte, iterations int) (*Key, error) {
+	secret, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{Salt: salt, Iterations: iterations, Secret: secret}, nil
+}
+
+func NewKey(passphrase string) (*Key, error) {
+	if len([]rune(passphrase)) < MinPassphraseLength {
+		return nil, ErrPassphrase
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return deriveKey(passphrase, salt, keyIterations)
+}
+
+func (k *Key) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, k.Secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (k *Key) Seal(a *Archive) (*Envelope, error) {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Version:    a.Version,
+		Salt:       k.Salt,
+		Iterations: k.Iterations,
+		Payload:    payload,
+		Signature:  k.sign(payload),
+	}, nil
+}
+
+// Validate checks consistency of the archive that cannot be expressed with the schema
+func (a *Archive) Validate() error {
+	if len(a.Organization.Name) == 0 {
+		return ErrFormat
+	}
+
+	refs := make(map[string]*Property, len(a.Properties))
+	for _, p := range a.Properties {
+		if (p == nil) || (len(p.Ref) == 0) {
+			return ErrFormat
+		}
+
+		if _, ok := refs[p.Ref]; ok {
+			return ErrFormat
+		}
+
+		refs[p.Ref] = p
+	}
+
+	for _, p := range a.Properties {
+		if len(p.TwinRef) == 0 {
+			continue
+		}
+
+		if twin, ok := refs[p.TwinRef]; !ok || (twin == p) || (len(twin.TwinRef) > 0) {
+			return ErrFormat
+		}
+	}
+
+	return nil
+}
+
+// Check does cheap validation of the envelope, that does not require deriving the key
+func (e *Envelope) Check() error {
+	if e.Version != Version {
+		return ErrVersion
+	}
+
+	if (len(e.Salt) != saltLength) || (e.Iterations != keyIterations) || (len(e.Payload) == 0) || (len(e.Signature) == 0) {
+		return ErrFormat
+	}
+
+	return nil
+}
+
+// Open verifies envelope signature with the passphrase and returns the archive
+func Open(e *Envelope, passphrase string) (*Archive, error) {
+	if err := e.Check(); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, e.Salt, keyIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(key.sign(e.Payload), e.Signature) {
+		return nil, ErrSignature
+	}
+
+	a := &Archive{}
+	if err := json.Unmarshal(e.Payload, a); err != nil {
+		return nil, ErrFormat
+	}
+
+	if a.Version != e.Version {
+		return nil, ErrVersion
+	}
+
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
@@ -0,0 +1,104 @@
+package orgarchive
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	testPassphrase = "correct horse battery staple"
+)
+
+func testArchive() *Archive {
+	return &Archive{
+		Version:      Version,
+		ExportedAt:   time.Now().UTC(),
+		Organization: Organization{Name: "My org"},
+		Properties: []*Property{
+			{Ref: "1", Name: "prod", Domain: "example.com", Environment: "production"},
+			{Ref: "2", Name: "staging", Domain: "example.com", Environment: "staging", TwinRef: "1"},
+		},
+		Members: []*Member{{Email: "user@example.com", Level: "member"}},
+		APIKeys: []*APIKey{{Name: "ci", Scope: "portal", ExpiresAt: time.Now().UTC()}},
+	}
+}
+
+func TestNewKeyPassphrase(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewKey("short"); err != ErrPassphrase {
+		t.Errorf("Unexpected error for short passphrase: %v", err)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	t.Parallel()
+
+	key, err := NewKey(testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := key.Seal(testArchive())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Open(envelope, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (archive.Organization.Name != "My org") || (len(archive.Properties) != 2) || (archive.Properties[1].TwinRef != "1") {
+		t.Errorf("Unexpected archive contents: %+v", archive)
+	}
+
+	if _, err := Open(envelope, testPassphrase+"!"); !errors.Is(err, ErrSignature) {
+		t.Errorf("Unexpected error for wrong passphrase: %v", err)
+	}
+
+	tampered := *envelope
+	tampered.Payload = append([]byte{}, envelope.Payload...)
+	tampered.Payload[len(tampered.Payload)-2] ^= 1
+	if _, err := Open(&tampered, testPassphrase); !errors.Is(err, ErrSignature) {
+		t.Errorf("Unexpected error for tampered payload: %v", err)
+	}
+
+	unsupported := *envelope
+	unsupported.Version = Version + 1
+	if _, err := Open(&unsupported, testPassphrase); !errors.Is(err, ErrVersion) {
+		t.Errorf("Unexpected error for unsupported version: %v", err)
+	}
+
+	expensive := *envelope
+	expensive.Iterations = 10 * keyIterations
+	if _, err := Open(&expensive, testPassphrase); !errors.Is(err, ErrFormat) {
+		t.Errorf("Unexpected error for custom iterations: %v", err)
+	}
+}
+
+func TestArchiveValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		update func(a *Archive)
+		valid  bool
+	}{
+		{func(a *Archive) {}, true},
+		{func(a *Archive) { a.Organization.Name = "" }, false},
+		{func(a *Archive) { a.Properties[1].Ref = "1" }, false},
+		{func(a *Archive) { a.Properties[1].TwinRef = "3" }, false},
+		{func(a *Archive) { a.Properties[1].TwinRef = "2" }, false},
+		{func(a *Archive) { a.Properties[0].TwinRef = "2" }, false},
+	}
+
+	for i, tc := range testCases {
+		a := testArchive()
+		tc.update(a)
+
+		if err := a.Validate(); (err == nil) != tc.valid {
+			t.Errorf("Unexpected validation result (%v) for test case %v", err, i)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package orgarchive
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
+)
+
+func propertyToArchive(ctx context.Context, p *dbgen.Property) *Property {
+	flags := puzzle.WidgetFlags(p.WidgetFlags)
+
+	result := &Property{
+		Ref:                    strconv.Itoa(int(p.ID)),
+		Name:                   p.Name,
+		Domain:                 p.Domain,
+		Environment:            string(p.Environment),
+		Level:                  int(p.Level.Int16),
+		Growth:                 string(p.Growth),
+		ValiditySeconds:        int(p.ValidityInterval.Seconds()),
+		AllowSubdomains:        p.AllowSubdomains,
+		AllowLocalhost:         p.AllowLocalhost,
+		MaxReplayCount:         int(p.MaxReplayCount),
+		ClockSkewSeconds:       int(p.AllowedClockSkew.Seconds()),
+		RememberSeconds:        int(p.RememberWindow.Seconds()),
+		DifferentialDifficulty: p.DifferentialDifficulty,
+		BotPolicy:              string(p.BotPolicy),
+		DifficultyStrategy:     p.DifficultyStrategy,
+		FailureURL:             p.FailureURL,
+		FailureMessage:         p.FailureMessage,
+		RequireInteraction:     (flags & puzzle.WidgetFlagRequireInteraction) != 0,
+		NoAutoRefresh:          (flags & puzzle.WidgetFlagNoAutoRefresh) != 0,
+		ImageChallenge:         (flags & puzzle.WidgetFlagImageChallenge) != 0,
+		TrustGroup:             p.TrustGroup,
+	}
+
+	if p.TwinID.Valid {
+		result.TwinRef = strconv.Itoa(int(p.TwinID.Int32))
+	}
+
+	if len(p.Claims) > 0 {
+		// claims are stored with templates unresolved which is exactly what we need
+		if values, err := url.ParseQuery(p.Claims); err == nil {
+			result.Claims = make(map[string]string, len(values))
+			for k := range values {
+				result.Claims[k] = values.Get(k)
+			}
+		} else {
+			slog.ErrorContext(ctx, "Failed to parse property claims", "propID", p.ID, common.ErrAttr(err))
+		}
+	}
+
+	return result
+}
+
+func exportProperties(ctx context.Context, store *db.BusinessStoreImpl, org *dbgen.Organization) ([]*Property, error) {
+	result := make([]*Property, 0)
+	refs := make(map[string]struct{})
+
+	var createdAt time.Time
+	var id int32
+
+	for {
+		properties, hasMore, err := store.RetrieveOrgPropertiesAfter(ctx, org, createdAt, id, db.MaxOrgPropertiesPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range properties {
+			ap := propertyToArchive(ctx, p)
+			result = append(result, ap)
+			refs[ap.Ref] = struct{}{}
+		}
+
+		if !hasMore || (len(properties) == 0) {
+			break
+		}
+
+		last := properties[len(properties)-1]
+		createdAt, id = last.CreatedAt.Time, last.ID
+	}
+
+	// twins could have been deleted
+	for _, p := range result {
+		if _, ok := refs[p.TwinRef]; !ok {
+			p.TwinRef = ""
+		}
+	}
+
+	return result, nil
+}
+
+func exportMembers(ctx context.Context, store *db.BusinessStoreImpl, org *dbgen.Organization) ([]*Member, error) {
+	users, err := store.RetrieveOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Member, 0, len(users))
+	for _, u := range users {
+		if (u.Level == dbgen.AccessLevelOwner) || (org.UserID.Valid && (u.User.ID == org.UserID.Int32)) {
+			continue
+		}
+
+		result = append(result, &Member{Email: u.User.Email, Level: string(u.Level)})
+	}
+
+	return result, nil
+}
+
+func exportAPIKeys(ctx context.Context, store *db.BusinessStoreImpl, user *dbgen.User, org *dbgen.Organization) ([]*APIKey, error) {
+	keys, err := store.RetrieveUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*APIKey, 0)
+	for _, k := range keys {
+		if !k.OrgID.Valid || (k.OrgID.Int32 != org.ID) {
+			continue
+		}
+
+		result = append(result, &APIKey{
+			Name:      k.Name,
+			Scope:     string(k.Scope),
+			Readonly:  k.Readonly,
+			ExpiresAt: k.ExpiresAt.Time.UTC(),
+		})
+	}
+
+	return result, nil
+}
+
+// Export collects everything that can be moved to another installation. Only API keys of the user, that are
+// scoped to the organization, are included
+func Export(ctx context.Context, store *db.BusinessStoreImpl, user *dbgen.User, org *dbgen.Organization) (*Archive, error) {
+	properties, err := exportProperties(ctx, store, org)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to export org properties", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	members, err := exportMembers(ctx, store, org)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to export org members", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	apiKeys, err := exportAPIKeys(ctx, store, user, org)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to export org API keys", "orgID", org.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Exported organization", "orgID", org.ID, "properties", len(properties), "members", len(members),
+		"apiKeys", len(apiKeys))
+
+	return &Archive{
+		Version:      Version,
+		ExportedAt:   time.Now().UTC(),
+		Organization: Organization{Name: org.Name},
+		Properties:   properties,
+		Members:      members,
+		APIKeys:      apiKeys,
+	}, nil
+}
@@ -0,0 +1,210 @@
+// Package pagination implements pagination shared by list APIs: opaque cursors over a stable
+// (created_at, id) order, while legacy page/per_page parameters are still supported
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	cursorSeparator = "."
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+	ErrInvalidParams = errors.New("invalid pagination parameters")
+)
+
+// Cursor points to the last item of the previous page
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+func NewCursor(createdAt time.Time, id int64) *Cursor {
+	return &Cursor{CreatedAt: createdAt, ID: id}
+}
+
+// Encode returns an opaque token. ID is hashed in the same way as it's exposed everywhere else in the API
+func (c *Cursor) Encode(hasher common.IdentifierHasher) string {
+	value := strconv.FormatInt(c.CreatedAt.UnixMicro(), 36) + cursorSeparator + hasher.Encrypt64(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func DecodeCursor(token string, hasher common.IdentifierHasher) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	timestamp, hashedID, ok := strings.Cut(string(data), cursorSeparator)
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	micro, err := strconv.ParseInt(timestamp, 36, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := hasher.Decrypt64(hashedID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: time.UnixMicro(micro).UTC(), ID: id}, nil
+}
+
+// Precedes checks if the item (createdAt, id) goes after the cursor
+func (c *Cursor) Precedes(createdAt time.Time, id int64) bool {
+	createdAt = createdAt.Truncate(time.Microsecond)
+	if !c.CreatedAt.Equal(createdAt) {
+		return c.CreatedAt.Before(createdAt)
+	}
+
+	return c.ID < id
+}
+
+type Request struct {
+	// only used without cursor (legacy "page" parameter)
+	Page         int
+	Limit        int
+	Cursor       *Cursor
+	IncludeTotal bool
+	token        string
+}
+
+func (r *Request) Offset() int {
+	if r.Cursor != nil {
+		return 0
+	}
+
+	return r.Page * r.Limit
+}
+
+// Key uniquely identifies requested page (e.g. for ETag)
+func (r *Request) Key() []string {
+	return []string{strconv.Itoa(r.Offset()), strconv.Itoa(r.Limit), r.token, strconv.FormatBool(r.IncludeTotal)}
+}
+
+// ParsePage returns "page" parameter, tolerating invalid values like portal always did
+func ParsePage(ctx context.Context, r *http.Request) int {
+	pageParam := r.URL.Query().Get(common.ParamPage)
+	if len(pageParam) == 0 {
+		return 0
+	}
+
+	page, err := strconv.Atoi(pageParam)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert page parameter", "page", pageParam, common.ErrAttr(err))
+		return 0
+	}
+
+	return page
+}
+
+// ParseRequest reads "cursor" and "limit" parameters, falling back to legacy "page" and "per_page" ones
+func ParseRequest(ctx context.Context, r *http.Request, maxLimit int, hasher common.IdentifierHasher) (*Request, error) {
+	query := r.URL.Query()
+
+	result := &Request{
+		Limit:        maxLimit,
+		IncludeTotal: common.EnvToBool(query.Get(common.ParamIncludeTotal)),
+	}
+
+	limitParam := query.Get(common.ParamLimit)
+	if len(limitParam) == 0 {
+		limitParam = query.Get(common.ParamPerPage)
+	}
+
+	if len(limitParam) > 0 {
+		limit, err := strconv.Atoi(limitParam)
+		if (err != nil) || (limit <= 0) {
+			slog.ErrorContext(ctx, "Invalid pagination limit", "limit", limitParam, common.ErrAttr(err))
+			return nil, ErrInvalidParams
+		}
+
+		result.Limit = min(limit, maxLimit)
+	}
+
+	if token := query.Get(common.ParamCursor); len(token) > 0 {
+		cursor, err := DecodeCursor(token, hasher)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to decode pagination cursor", "cursor", token, common.ErrAttr(err))
+			return nil, err
+		}
+
+		result.Cursor = cursor
+		result.token = token
+
+		return result, nil
+	}
+
+	if result.Page = ParsePage(ctx, r); result.Page < 0 {
+		return nil, ErrInvalidParams
+	}
+
+	return result, nil
+}
+
+// Response is the pagination envelope of list APIs
+type Response struct {
+	// only set when legacy "page" parameter is used (or no cursor)
+	Page          *int   `json:"page,omitempty"`
+	PerPage       int    `json:"per_page"`
+	HasMore       bool   `json:"has_more"`
+	NextCursor    string `json:"next_cursor,omitempty"`
+	TotalEstimate *int64 `json:"total_estimate,omitempty"`
+}
+
+func (r *Request) NewResponse(hasMore bool, next *Cursor, hasher common.IdentifierHasher) *Response {
+	response := &Response{
+		PerPage: r.Limit,
+		HasMore: hasMore,
+	}
+
+	if r.Cursor == nil {
+		page := r.Page
+		response.Page = &page
+	}
+
+	if hasMore && (next != nil) {
+		response.NextCursor = next.Encode(hasher)
+	}
+
+	return response
+}
+
+// Slice paginates in-memory items that are already sorted in (created_at, id) order
+func Slice[T any](items []T, r *Request, keyFunc func(T) *Cursor) ([]T, bool, *Cursor) {
+	start := min(r.Offset(), len(items))
+
+	if r.Cursor != nil {
+		start = len(items)
+		for i, item := range items {
+			if key := keyFunc(item); r.Cursor.Precedes(key.CreatedAt, key.ID) {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := min(start+r.Limit, len(items))
+	hasMore := end < len(items)
+
+	var next *Cursor
+	if hasMore && (end > start) {
+		next = keyFunc(items[end-1])
+	}
+
+	return items[start:end], hasMore, next
+}
@@ -0,0 +1,140 @@
+package pagination
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+)
+
+var (
+	hasher = common.NewIDHasher(config.NewStaticValue(common.IDHasherSaltKey, "salt"))
+)
+
+func TestCursorEncoding(t *testing.T) {
+	t.Parallel()
+
+	cursor := NewCursor(time.Now().UTC().Truncate(time.Microsecond), 12345)
+
+	decoded, err := DecodeCursor(cursor.Encode(hasher), hasher)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || (decoded.ID != cursor.ID) {
+		t.Errorf("Expected cursor %v but got %v", cursor, decoded)
+	}
+
+	for _, token := range []string{"", "qwerty", "!!!", cursor.Encode(hasher)[1:]} {
+		if _, err := DecodeCursor(token, hasher); err != ErrInvalidCursor {
+			t.Errorf("Expected error for token %q but got %v", token, err)
+		}
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	t.Parallel()
+
+	const maxLimit = 50
+	cursor := NewCursor(time.Now().UTC().Truncate(time.Microsecond), 1)
+	token := cursor.Encode(hasher)
+
+	testCases := []struct {
+		query  string
+		err    bool
+		page   int
+		limit  int
+		cursor bool
+		total  bool
+	}{
+		{"", false, 0, maxLimit, false, false},
+		{"?page=2&per_page=10", false, 2, 10, false, false},
+		{"?page=qwerty", false, 0, maxLimit, false, false},
+		{"?page=-1", true, 0, 0, false, false},
+		{"?per_page=0", true, 0, 0, false, false},
+		{"?per_page=1000", false, 0, maxLimit, false, false},
+		{"?limit=5&per_page=10", false, 0, 5, false, false},
+		{"?limit=5&include_total=true", false, 0, 5, false, true},
+		{"?cursor=" + token + "&page=3", false, 0, maxLimit, true, false},
+		{"?cursor=qwerty", true, 0, 0, false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/"+tc.query, nil)
+
+			request, err := ParseRequest(context.TODO(), r, maxLimit, hasher)
+			if tc.err {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (request.Page != tc.page) || (request.Limit != tc.limit) || ((request.Cursor != nil) != tc.cursor) || (request.IncludeTotal != tc.total) {
+				t.Errorf("Unexpected request: %+v", request)
+			}
+		})
+	}
+}
+
+func TestSlice(t *testing.T) {
+	t.Parallel()
+
+	tnow := time.Now().UTC().Truncate(time.Microsecond)
+	// two items share the timestamp to check that ID breaks the tie
+	items := []*Cursor{
+		NewCursor(tnow, 1),
+		NewCursor(tnow.Add(time.Second), 2),
+		NewCursor(tnow.Add(time.Second), 3),
+		NewCursor(tnow.Add(2*time.Second), 4),
+		NewCursor(tnow.Add(3*time.Second), 5),
+	}
+	keyFunc := func(c *Cursor) *Cursor { return c }
+
+	// walk all items with cursors
+	request := &Request{Limit: 2}
+	var visited []int64
+	for i := 0; i < len(items); i++ {
+		page, hasMore, next := Slice(items, request, keyFunc)
+		for _, item := range page {
+			visited = append(visited, item.ID)
+		}
+
+		if !hasMore {
+			if next != nil {
+				t.Errorf("Unexpected next cursor on the last page")
+			}
+			break
+		}
+
+		request = &Request{Limit: 2, Cursor: next}
+	}
+
+	if len(visited) != len(items) {
+		t.Fatalf("Expected %v items but visited %v", len(items), visited)
+	}
+
+	for i, id := range visited {
+		if id != items[i].ID {
+			t.Errorf("Unexpected item %v at position %v", id, i)
+		}
+	}
+
+	// legacy pages
+	page, hasMore, _ := Slice(items, &Request{Page: 2, Limit: 2}, keyFunc)
+	if (len(page) != 1) || (page[0].ID != 5) || hasMore {
+		t.Errorf("Unexpected last page: %v (more: %v)", page, hasMore)
+	}
+
+	if page, hasMore, _ := Slice(items, &Request{Page: 10, Limit: 2}, keyFunc); (len(page) != 0) || hasMore {
+		t.Errorf("Unexpected page beyond the end: %v (more: %v)", page, hasMore)
+	}
+}
@@ -0,0 +1,343 @@
+package portal
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/orgarchive"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	accountDataMaxAuditLogs     = 10_000
+	accountDataMaxNotifications = 1_000
+)
+
+type accountDataUser struct {
+	ID           int32               `json:"id"`
+	Name         string              `json:"name"`
+	Email        string              `json:"email"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Subscription *dbgen.Subscription `json:"subscription,omitempty"`
+}
+
+type accountDataMembership struct {
+	OrgID     int32             `json:"org_id"`
+	Name      string            `json:"name"`
+	Level     dbgen.AccessLevel `json:"level"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// API key secrets are deliberately not exported
+type accountDataAPIKey struct {
+	Name      string            `json:"name"`
+	Enabled   bool              `json:"enabled"`
+	Scope     dbgen.ApiKeyScope `json:"scope"`
+	Readonly  bool              `json:"readonly"`
+	OrgID     *int32            `json:"org_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Notes     string            `json:"notes,omitempty"`
+}
+
+type accountDataAuditLog struct {
+	Action    dbgen.AuditLogAction `json:"action"`
+	Source    dbgen.AuditLogSource `json:"source"`
+	Table     string               `json:"table"`
+	EntityID  int64                `json:"entity_id"`
+	OldValue  json.RawMessage      `json:"old_value,omitempty"`
+	NewValue  json.RawMessage      `json:"new_value,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+type accountDataNotification struct {
+	Subject     string     `json:"subject"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+func optionalJSON(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return json.RawMessage(data)
+	}
+
+	return nil
+}
+
+func optionalTimestamp(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+
+	t := ts.Time.UTC()
+	return &t
+}
+
+type accountDataWriter struct {
+	zw *zip.Writer
+}
+
+func (w *accountDataWriter) write(name string, data any) error {
+	f, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func (s *Server) exportAccountUser(ctx context.Context, w *accountDataWriter, user *dbgen.User) error {
+	data := &accountDataUser{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.Time.UTC(),
+	}
+
+	if user.SubscriptionID.Valid {
+		subscription, err := s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve a subscription", common.ErrAttr(err))
+			return err
+		}
+		data.Subscription = subscription
+	}
+
+	return w.write("user.json", data)
+}
+
+func (s *Server) exportAccountOrganizations(ctx context.Context, w *accountDataWriter, user *dbgen.User) error {
+	orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user organizations", common.ErrAttr(err))
+		return err
+	}
+
+	memberships := make([]*accountDataMembership, 0, len(orgs))
+	for _, o := range orgs {
+		memberships = append(memberships, &accountDataMembership{
+			OrgID:     o.Organization.ID,
+			Name:      o.Organization.Name,
+			Level:     o.Level,
+			CreatedAt: o.Organization.CreatedAt.Time.UTC(),
+		})
+
+		if o.Level != dbgen.AccessLevelOwner {
+			continue
+		}
+
+		archive, err := orgarchive.Export(ctx, s.Store.Impl(), user, &o.Organization)
+		if err != nil {
+			return err
+		}
+
+		if err := w.write(fmt.Sprintf("organizations/%d.json", o.Organization.ID), archive); err != nil {
+			return err
+		}
+	}
+
+	return w.write("organizations.json", memberships)
+}
+
+func (s *Server) exportAccountAPIKeys(ctx context.Context, w *accountDataWriter, user *dbgen.User) error {
+	keys, err := s.Store.Impl().RetrieveUserAPIKeys(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user API keys", common.ErrAttr(err))
+		return err
+	}
+
+	data := make([]*accountDataAPIKey, 0, len(keys))
+	for _, k := range keys {
+		key := &accountDataAPIKey{
+			Name:      k.Name,
+			Enabled:   k.Enabled.Valid && k.Enabled.Bool,
+			Scope:     k.Scope,
+			Readonly:  k.Readonly,
+			CreatedAt: k.CreatedAt.Time.UTC(),
+			ExpiresAt: k.ExpiresAt.Time.UTC(),
+			Notes:     k.Notes.String,
+		}
+		if k.OrgID.Valid {
+			key.OrgID = &k.OrgID.Int32
+		}
+		data = append(data, key)
+	}
+
+	return w.write("api_keys.json", data)
+}
+
+func (s *Server) exportAccountAuditLogs(ctx context.Context, w *accountDataWriter, user *dbgen.User) error {
+	logs, err := s.Store.Impl().RetrieveUserAuditLogs(ctx, user, accountDataMaxAuditLogs, user.CreatedAt.Time)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user audit logs", common.ErrAttr(err))
+		return err
+	}
+
+	data := make([]*accountDataAuditLog, 0, len(logs))
+	for _, l := range logs {
+		data = append(data, &accountDataAuditLog{
+			Action:    l.AuditLog.Action,
+			Source:    l.AuditLog.Source,
+			Table:     l.AuditLog.EntityTable,
+			EntityID:  l.AuditLog.EntityID.Int64,
+			OldValue:  optionalJSON(l.AuditLog.OldValue),
+			NewValue:  optionalJSON(l.AuditLog.NewValue),
+			CreatedAt: l.AuditLog.CreatedAt.Time.UTC(),
+		})
+	}
+
+	return w.write("audit_logs.json", data)
+}
+
+func (s *Server) exportAccountNotifications(ctx context.Context, w *accountDataWriter, user *dbgen.User) error {
+	notifications, err := s.Store.Impl().RetrieveUserNotifications(ctx, user.ID, accountDataMaxNotifications)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve user notifications", common.ErrAttr(err))
+		return err
+	}
+
+	data := make([]*accountDataNotification, 0, len(notifications))
+	for _, n := range notifications {
+		data = append(data, &accountDataNotification{
+			Subject:     n.Subject,
+			ScheduledAt: n.ScheduledAt.Time.UTC(),
+			ProcessedAt: optionalTimestamp(n.ProcessedAt),
+		})
+	}
+
+	return w.write("notifications.json", data)
+}
+
+// exportAccountData sends all personal data, that we store about the user, as a ZIP archive of JSON files
+func (s *Server) exportAccountData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	// everything is collected before responding so that a failure can still be reported with a status code
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	writer := &accountDataWriter{zw: zw}
+
+	for _, export := range []func(context.Context, *accountDataWriter, *dbgen.User) error{
+		s.exportAccountUser,
+		s.exportAccountOrganizations,
+		s.exportAccountAPIKeys,
+		s.exportAccountAuditLogs,
+		s.exportAccountNotifications,
+	} {
+		if err := export(ctx, writer, user); err != nil {
+			slog.ErrorContext(ctx, "Failed to export account data", "userID", user.ID, common.ErrAttr(err))
+			s.RedirectError(http.StatusInternalServerError, w, r)
+			return
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		slog.ErrorContext(ctx, "Failed to finalize account data archive", common.ErrAttr(err))
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	slog.InfoContext(ctx, "Exported account data", "userID", user.ID, "size", buf.Len())
+
+	filename := fmt.Sprintf("private-captcha-account-%s.zip", time.Now().UTC().Format(time.DateOnly))
+	w.Header().Set(common.HeaderContentType, common.ContentTypeZip)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	common.WriteHeaders(w, common.NoCacheHeaders)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) findUserForAdmin(ctx context.Context, r *http.Request) (*dbgen.User, error) {
+	if value := r.URL.Query().Get(common.ParamID); len(value) > 0 {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, db.ErrInvalidInput
+		}
+		// unlike lookup by email, this also finds soft-deleted users
+		return s.Store.Impl().RetrieveUser(ctx, int32(id))
+	}
+
+	return s.Store.Impl().FindUserByEmail(ctx, strings.TrimSpace(r.URL.Query().Get(common.ParamEmail)))
+}
+
+// hardDeleteUser immediately removes the user with all data, skipping the soft-delete retention period
+func (s *Server) hardDeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	admin, err := s.sessionAdmin(w, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	user, err := s.findUserForAdmin(ctx, r)
+	if err != nil {
+		if (err == db.ErrRecordNotFound) || (err == db.ErrInvalidInput) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if user.ID == admin.ID {
+		http.Error(w, "Cannot delete own account.", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.cancelExternalSubscription(ctx, user); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	cacheKeys, err := s.Store.Impl().RetrieveUserCacheKeys(ctx, user.ID)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// sessions are not deleted with the user so they have to be revoked explicitly
+	if _, err := s.Sessions.DestroyOtherUserSessions(ctx, user.ID, "" /*current session ID*/); err != nil {
+		slog.ErrorContext(ctx, "Failed to destroy user sessions", "userID", user.ID, common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if err := maintenance.PurgeUsers(ctx, s.Store, s.TimeSeries, []int32{user.ID}); err != nil {
+		slog.ErrorContext(ctx, "Failed to hard delete user", "adminID", admin.ID, "userID", user.ID, common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	s.Store.Impl().EvictFromCache(ctx, cacheKeys)
+	s.Store.AuditLog().RecordEvent(ctx, db.NewHardDeleteUserAuditLogEvent(admin.ID, user), common.AuditLogSourcePortal)
+
+	slog.InfoContext(ctx, "Hard deleted user", "adminID", admin.ID, "userID", user.ID)
+
+	response := struct {
+		UserID int32 `json:"user_id"`
+	}{
+		UserID: user.ID,
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
@@ -0,0 +1,143 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	emailpkg "github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+)
+
+const (
+	adminEmailTemplate     = "email-providers/providers.html"
+	adminEmailListTemplate = "email-providers/list.html"
+	// sends via all providers in failover order
+	adminEmailAnyProvider = "any"
+)
+
+var (
+	errNoEmailProviders = errors.New("email providers are not available on this node")
+)
+
+// EmailProviders delivers emails with failover between several providers
+type EmailProviders interface {
+	emailpkg.Sender
+	SendVia(ctx context.Context, name string, msg *emailpkg.Message) error
+	Status() []*emailpkg.ProviderStatus
+}
+
+type adminEmailProvider struct {
+	Name        string
+	Configured  bool
+	Healthy     bool
+	Sent        int64
+	Failed      int64
+	Failures    int
+	LastError   string
+	LastFailure string
+	LastSuccess string
+}
+
+type adminEmailRenderContext struct {
+	AlertRenderContext
+	CsrfRenderContext
+	Email       string
+	AnyProvider string
+	Providers   []*adminEmailProvider
+}
+
+func newAdminEmailProvider(status *emailpkg.ProviderStatus) *adminEmailProvider {
+	return &adminEmailProvider{
+		Name:        status.Name,
+		Configured:  status.Configured,
+		Healthy:     status.Healthy,
+		Sent:        status.Sent,
+		Failed:      status.Failed,
+		Failures:    status.Failures,
+		LastError:   status.LastError,
+		LastFailure: formatAdminJobTime(status.LastFailure),
+		LastSuccess: formatAdminJobTime(status.LastSuccess),
+	}
+}
+
+func (s *Server) createAdminEmailContext(user *dbgen.User) *adminEmailRenderContext {
+	renderCtx := &adminEmailRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		Email:             user.Email,
+		AnyProvider:       adminEmailAnyProvider,
+		Providers:         []*adminEmailProvider{},
+	}
+
+	if s.EmailProviders != nil {
+		for _, status := range s.EmailProviders.Status() {
+			renderCtx.Providers = append(renderCtx.Providers, newAdminEmailProvider(status))
+		}
+	}
+
+	return renderCtx
+}
+
+func (s *Server) getAdminEmail(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ViewModel{Model: s.createAdminEmailContext(user), View: adminEmailTemplate}, nil
+}
+
+func (s *Server) postAdminTestEmail(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	provider := strings.TrimSpace(r.FormValue(common.ParamName))
+	email := strings.TrimSpace(r.FormValue(common.ParamEmail))
+	if len(email) == 0 {
+		email = user.Email
+	}
+
+	tnow := time.Now().UTC()
+	msg := &emailpkg.Message{
+		TextBody:  fmt.Sprintf("This is a test email sent by %s via %s provider at %s.", user.Email, provider, tnow.Format(time.RFC1123)),
+		Subject:   fmt.Sprintf("[%s] Test email", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: s.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptchaTeam,
+	}
+
+	if s.EmailProviders == nil {
+		err = errNoEmailProviders
+	} else if provider == adminEmailAnyProvider {
+		err = s.EmailProviders.SendEmail(ctx, msg)
+	} else {
+		err = s.EmailProviders.SendVia(ctx, provider, msg)
+	}
+
+	// created after sending to reflect the updated health of providers
+	renderCtx := s.createAdminEmailContext(user)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send test email", "provider", provider, "email", email, common.ErrAttr(err))
+		renderCtx.ErrorMessage = fmt.Sprintf("Failed to send test email: %v", err)
+	} else {
+		slog.InfoContext(ctx, "Admin sent test email", "userID", user.ID, "provider", provider, "email", email)
+		renderCtx.SuccessMessage = "Test email was sent to " + email + "."
+	}
+
+	return &ViewModel{Model: renderCtx, View: adminEmailListTemplate}, nil
+}
@@ -0,0 +1,160 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/maintenance"
+)
+
+const (
+	adminJobsTemplate     = "jobs/jobs.html"
+	adminJobsListTemplate = "jobs/list.html"
+	adminJobsTimeFormat   = "02 Jan 2006 15:04:05"
+)
+
+// MaintenanceJobs is the registry of background jobs running on this node
+type MaintenanceJobs interface {
+	Status(ctx context.Context) []*maintenance.JobStatus
+	RunNow(ctx context.Context, name string) error
+}
+
+type adminJob struct {
+	Name        string
+	Kind        string
+	Running     bool
+	Runs        int64
+	Failures    int64
+	LastRun     string
+	Duration    string
+	LastError   string
+	LastErrorAt string
+	NextRun     string
+	LockHolder  string
+	Triggerable bool
+}
+
+type adminJobsRenderContext struct {
+	AlertRenderContext
+	CsrfRenderContext
+	Node string
+	Jobs []*adminJob
+}
+
+func formatAdminJobTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(adminJobsTimeFormat)
+}
+
+func newAdminJob(status *maintenance.JobStatus) *adminJob {
+	job := &adminJob{
+		Name:        status.Name,
+		Kind:        status.Kind,
+		Running:     status.Running,
+		Runs:        status.Runs,
+		Failures:    status.Failures,
+		LastRun:     formatAdminJobTime(status.LastStartedAt),
+		LastError:   status.LastError,
+		LastErrorAt: formatAdminJobTime(status.LastErrorAt),
+		NextRun:     formatAdminJobTime(status.NextRunAt),
+		LockHolder:  status.LockHolder,
+		Triggerable: status.Triggerable,
+	}
+
+	if status.LastFinishedAt != nil {
+		job.Duration = (time.Duration(status.LastDurationMs) * time.Millisecond).String()
+	}
+
+	return job
+}
+
+func (s *Server) maintenanceJobsStatus(ctx context.Context) []*maintenance.JobStatus {
+	if s.MaintenanceJobs == nil {
+		return []*maintenance.JobStatus{}
+	}
+
+	return s.MaintenanceJobs.Status(ctx)
+}
+
+func (s *Server) createAdminJobsContext(ctx context.Context, user *dbgen.User) *adminJobsRenderContext {
+	statuses := s.maintenanceJobsStatus(ctx)
+
+	renderCtx := &adminJobsRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		Jobs:              make([]*adminJob, 0, len(statuses)),
+	}
+
+	for _, status := range statuses {
+		renderCtx.Node = status.Node
+		renderCtx.Jobs = append(renderCtx.Jobs, newAdminJob(status))
+	}
+
+	return renderCtx
+}
+
+func (s *Server) getAdminJobs(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ViewModel{Model: s.createAdminJobsContext(r.Context(), user), View: adminJobsTemplate}, nil
+}
+
+// getAdminJobsStatus returns the same information as the jobs page, but in JSON for scripts
+func (s *Server) getAdminJobsStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, err := s.sessionAdmin(w, r); err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	response := struct {
+		Jobs []*maintenance.JobStatus `json:"jobs"`
+	}{
+		Jobs: s.maintenanceJobsStatus(ctx),
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
+
+func (s *Server) postAdminJobRun(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := common.StrPathArg(r, common.ParamName)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse job name", common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	var runErr error
+	if s.MaintenanceJobs != nil {
+		runErr = s.MaintenanceJobs.RunNow(ctx, name)
+	} else {
+		runErr = maintenance.ErrJobNotFound
+	}
+
+	renderCtx := s.createAdminJobsContext(ctx, user)
+
+	if runErr != nil {
+		renderCtx.ErrorMessage = "Failed to start job. It could be not registered on this node."
+	} else {
+		slog.InfoContext(ctx, "Admin started maintenance job", "userID", user.ID, "job", name)
+		renderCtx.SuccessMessage = "Job " + name + " was started."
+	}
+
+	return &ViewModel{Model: renderCtx, View: adminJobsListTemplate}, nil
+}
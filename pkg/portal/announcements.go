@@ -0,0 +1,279 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	announcementsTemplate     = "announcements/announcements.html"
+	announcementsListTemplate = "announcements/list.html"
+	// format of <input type="datetime-local">
+	announcementTimeFormat   = "2006-01-02T15:04"
+	maxAnnouncementLength    = 2000
+	announcementDisplayTime  = "02 Jan 2006 15:04"
+	announcementAudienceNone = "Everyone"
+)
+
+type userAnnouncement struct {
+	ID        string
+	Message   string
+	Severity  string
+	Start     string
+	End       string
+	Audience  string
+	Scheduled bool
+}
+
+type announcementsRenderContext struct {
+	AlertRenderContext
+	CsrfRenderContext
+	Announcements []*userAnnouncement
+	Severities    []string
+	Stage         string
+}
+
+func (s *Server) isAdmin(user *dbgen.User) bool {
+	if (s.AdminEmail == nil) || (user == nil) {
+		return false
+	}
+
+	adminEmail := s.AdminEmail.Value()
+	return (len(adminEmail) > 0) && strings.EqualFold(user.Email, adminEmail)
+}
+
+func (s *Server) sessionAdmin(w http.ResponseWriter, r *http.Request) (*dbgen.User, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.isAdmin(user) {
+		slog.WarnContext(ctx, "Non-admin user attempted to access admin page", "userID", user.ID)
+		return nil, db.ErrPermissions
+	}
+
+	return user, nil
+}
+
+func (s *Server) newUserAnnouncement(n *dbgen.SystemNotification, tnow time.Time) *userAnnouncement {
+	a := &userAnnouncement{
+		ID:        s.IDHasher.Encrypt(int(n.ID)),
+		Message:   notificationHTML(n),
+		Severity:  string(n.Severity),
+		Start:     n.StartDate.Time.UTC().Format(announcementDisplayTime),
+		Scheduled: n.StartDate.Time.After(tnow),
+	}
+
+	if n.EndDate.Valid {
+		a.End = n.EndDate.Time.UTC().Format(announcementDisplayTime)
+	}
+
+	audience := make([]string, 0, 4)
+	if n.UserID.Valid {
+		audience = append(audience, fmt.Sprintf("user %d", n.UserID.Int32))
+	}
+	if n.OrgID.Valid {
+		audience = append(audience, fmt.Sprintf("org %s", s.IDHasher.Encrypt(int(n.OrgID.Int32))))
+	}
+	if n.ExternalProductID.Valid {
+		audience = append(audience, fmt.Sprintf("plan %s", n.ExternalProductID.String))
+	}
+	if n.Stage.Valid {
+		audience = append(audience, fmt.Sprintf("stage %s", n.Stage.String))
+	}
+
+	if len(audience) > 0 {
+		a.Audience = strings.Join(audience, ", ")
+	} else {
+		a.Audience = announcementAudienceNone
+	}
+
+	return a
+}
+
+func (s *Server) createAnnouncementsContext(ctx context.Context, user *dbgen.User) (*announcementsRenderContext, error) {
+	tnow := time.Now().UTC()
+
+	notifications, err := s.Store.Impl().RetrieveScheduledSystemNotifications(ctx, tnow)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx := &announcementsRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		Announcements:     make([]*userAnnouncement, 0, len(notifications)),
+		Severities: []string{
+			string(dbgen.NotificationSeverityInfo),
+			string(dbgen.NotificationSeverityWarning),
+			string(dbgen.NotificationSeverityCritical),
+		},
+		Stage: s.Stage,
+	}
+
+	for _, n := range notifications {
+		renderCtx.Announcements = append(renderCtx.Announcements, s.newUserAnnouncement(n, tnow))
+	}
+
+	return renderCtx, nil
+}
+
+func (s *Server) getAnnouncements(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, err := s.createAnnouncementsContext(r.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ViewModel{Model: renderCtx, View: announcementsTemplate}, nil
+}
+
+func parseAnnouncementTime(value string) (pgtype.Timestamptz, error) {
+	if len(value) == 0 {
+		return pgtype.Timestamptz{}, nil
+	}
+
+	t, err := time.ParseInLocation(announcementTimeFormat, value, time.UTC)
+	if err != nil {
+		return pgtype.Timestamptz{}, err
+	}
+
+	return db.Timestampz(t), nil
+}
+
+// parseAnnouncementForm returns user-facing error message if form is not valid
+func (s *Server) parseAnnouncementForm(r *http.Request, tnow time.Time) (*dbgen.CreateSystemNotificationParams, string) {
+	message := strings.TrimSpace(r.FormValue(common.ParamMessage))
+	if len(message) == 0 {
+		return nil, "Message cannot be empty."
+	}
+
+	if len(message) > maxAnnouncementLength {
+		return nil, fmt.Sprintf("Message cannot be longer than %d characters.", maxAnnouncementLength)
+	}
+
+	severity, ok := db.ParseNotificationSeverity(r.FormValue(common.ParamSeverity))
+	if !ok {
+		return nil, "Unknown severity."
+	}
+
+	arg := &dbgen.CreateSystemNotificationParams{
+		Message:    message,
+		Severity:   severity,
+		IsMarkdown: len(r.FormValue(common.ParamMarkdown)) > 0,
+	}
+
+	var err error
+	if arg.StartDate, err = parseAnnouncementTime(r.FormValue(common.ParamStart)); err != nil {
+		return nil, "Start time is not valid."
+	}
+
+	if !arg.StartDate.Valid {
+		arg.StartDate = db.Timestampz(tnow)
+	}
+
+	if arg.EndDate, err = parseAnnouncementTime(r.FormValue(common.ParamEnd)); err != nil {
+		return nil, "End time is not valid."
+	}
+
+	if arg.EndDate.Valid && !arg.EndDate.Time.After(arg.StartDate.Time) {
+		return nil, "End time has to be after start time."
+	}
+
+	if value := strings.TrimSpace(r.FormValue(common.ParamOrg)); len(value) > 0 {
+		orgID, err := s.IDHasher.Decrypt(value)
+		if err != nil {
+			return nil, "Organization ID is not valid."
+		}
+		arg.OrgID = db.Int(int32(orgID))
+	}
+
+	if value := strings.TrimSpace(r.FormValue(common.ParamProduct)); len(value) > 0 {
+		arg.ExternalProductID = db.Text(value)
+	}
+
+	if value := strings.TrimSpace(r.FormValue(common.ParamStage)); len(value) > 0 {
+		arg.Stage = db.Text(value)
+	}
+
+	return arg, ""
+}
+
+func (s *Server) postAnnouncement(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	arg, errMsg := s.parseAnnouncementForm(r, time.Now().UTC())
+	if len(errMsg) == 0 {
+		if _, err := s.Store.Impl().ScheduleSystemNotification(ctx, arg); err != nil {
+			errMsg = "Failed to schedule announcement. Please try again."
+		}
+	}
+
+	renderCtx, err := s.createAnnouncementsContext(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(errMsg) > 0 {
+		renderCtx.ErrorMessage = errMsg
+	} else {
+		renderCtx.SuccessMessage = "Announcement was scheduled."
+	}
+
+	return &ViewModel{Model: renderCtx, View: announcementsListTemplate}, nil
+}
+
+func (s *Server) deleteAnnouncement(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _, err := common.IntPathArg(r, common.ParamID, s.IDHasher)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse announcement ID", common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	_, cerr := s.Store.Impl().CancelSystemNotification(ctx, id)
+
+	renderCtx, err := s.createAnnouncementsContext(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if cerr != nil {
+		renderCtx.ErrorMessage = "Failed to cancel announcement. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Announcement was cancelled."
+	}
+
+	return &ViewModel{Model: renderCtx, View: announcementsListTemplate}, nil
+}
@@ -22,6 +22,7 @@ const (
 	auditLogsEventsTemplate = "audit/events.html"
 	auditLogTimeFormat      = "02 Jan 2006 15:04:05 MST"
 	perPageEventLogs        = 25
+	maxAuditLogsSearchLen   = 200
 )
 
 type AuditLogsRenderContext struct {
@@ -30,6 +31,8 @@ type AuditLogsRenderContext struct {
 	Page      int
 	PerPage   int
 	SeeMore   bool
+	// free text (actor, entity name or payload) that logs were filtered by
+	Search string
 }
 
 type userAuditLog struct {
@@ -153,6 +156,111 @@ func (ul *userAuditLog) initFromOrgUser(oldValue, newValue *db.AuditLogOrgUser)
 	return nil
 }
 
+func (ul *userAuditLog) initFromOrgIPAllowlist(oldValue, newValue *db.AuditLogOrgIPAllowlist) error {
+	if newValue == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = fmt.Sprintf("Organization '%s'", newValue.OrgName)
+	ul.Property = "IP allowlist"
+
+	if len(newValue.CIDRs) > 0 {
+		ul.Value = strings.Join(newValue.CIDRs, ", ")
+	} else if newValue.Recovery {
+		ul.Value = "disabled (recovery link)"
+	} else {
+		ul.Value = "disabled"
+	}
+
+	return nil
+}
+
+func (ul *userAuditLog) initFromOrgEmailDomain(oldValue, newValue *db.AuditLogOrgEmailDomain) error {
+	value := newValue
+	if value == nil {
+		value = oldValue
+	}
+
+	if value == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = fmt.Sprintf("Organization '%s'", value.OrgName)
+	ul.Property = fmt.Sprintf("Email domain '%s'", value.Domain)
+
+	if newValue != nil {
+		status := "unverified"
+		if newValue.Verified {
+			status = "verified"
+		}
+
+		if newValue.AutoJoin {
+			ul.Value = status + ", auto-join"
+		} else {
+			ul.Value = status + ", invite"
+		}
+	}
+
+	return nil
+}
+
+func (ul *userAuditLog) initFromPropertyAccessList(oldValue, newValue *db.AuditLogPropertyAccessList) error {
+	if newValue == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = fmt.Sprintf("Property '%s'", newValue.PropertyName)
+	ul.Property = "Access lists"
+
+	if (len(newValue.IPAllowlist) == 0) && (len(newValue.IPDenylist) == 0) && (len(newValue.ASNDenylist) == 0) {
+		ul.Value = "disabled"
+	} else {
+		ul.Value = fmt.Sprintf("%d allowed, %d denied IP ranges, %d denied ASNs (%s)", len(newValue.IPAllowlist),
+			len(newValue.IPDenylist), len(newValue.ASNDenylist), newValue.Policy)
+	}
+
+	return nil
+}
+
+func (ul *userAuditLog) initFromPropertyShareLink(oldValue, newValue *db.AuditLogPropertyShareLink) error {
+	value := newValue
+	if value == nil {
+		value = oldValue
+	}
+
+	if value == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = fmt.Sprintf("Property '%s'", value.PropertyName)
+	ul.Property = "Share link"
+	ul.Value = fmt.Sprintf("expires %s", time.Time(value.ExpiresAt).UTC().Format(auditLogTimeFormat))
+
+	return nil
+}
+
+func (ul *userAuditLog) initFromPropertyBypassToken(action dbgen.AuditLogAction, oldValue, newValue *db.AuditLogPropertyBypassToken) error {
+	value := newValue
+	if value == nil {
+		value = oldValue
+	}
+
+	if value == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = fmt.Sprintf("Property '%s'", value.PropertyName)
+	ul.Property = fmt.Sprintf("Bypass token '%s'", value.Name)
+
+	if action == dbgen.AuditLogActionUpdate {
+		ul.Value = fmt.Sprintf("verified with token (%d of %d uses)", value.Uses, value.MaxUses)
+	} else {
+		ul.Value = fmt.Sprintf("%d uses, expires %s", value.MaxUses, time.Time(value.ExpiresAt).UTC().Format(auditLogTimeFormat))
+	}
+
+	return nil
+}
+
 func (ul *userAuditLog) initFromProperty(oldValue, newValue *db.AuditLogProperty) error {
 	ul.Resource = "Property"
 
@@ -164,9 +272,11 @@ func (ul *userAuditLog) initFromProperty(oldValue, newValue *db.AuditLogProperty
 		} else if oldValue.OrgID != newValue.OrgID {
 			ul.Property = "Organization"
 			if len(newValue.OrgName) > 0 {
-				// TODO: Actually set Org Name for Move property audit log
 				ul.Value = newValue.OrgName
 			}
+			if (newValue.MoveLimits != nil) && newValue.MoveLimits.Grace {
+				ul.Value += " (requests limit grace)"
+			}
 		} else if oldValue.Level != newValue.Level {
 			ul.Property = "Level"
 			ul.Value = strconv.Itoa(int(newValue.Level))
@@ -186,6 +296,49 @@ func (ul *userAuditLog) initFromProperty(oldValue, newValue *db.AuditLogProperty
 		} else if oldValue.AllowLocalhost != newValue.AllowLocalhost {
 			ul.Property = "Localhost"
 			ul.Value = strconv.FormatBool(newValue.AllowLocalhost)
+		} else if oldValue.ClockSkewSec != newValue.ClockSkewSec {
+			ul.Property = "Clock skew"
+			ul.Value = fmt.Sprintf("%d second(s)", newValue.ClockSkewSec)
+		} else if oldValue.RememberSec != newValue.RememberSec {
+			ul.Property = "Remember window"
+			ul.Value = fmt.Sprintf("%d second(s)", newValue.RememberSec)
+		} else if oldValue.WidgetFlags != newValue.WidgetFlags {
+			ul.Property = "Widget flags"
+			ul.Value = fmt.Sprintf("0x%02x", newValue.WidgetFlags)
+		} else if oldValue.TwinID != newValue.TwinID {
+			ul.Property = "Production twin"
+			ul.Value = strconv.Itoa(int(newValue.TwinID))
+		} else if oldValue.TrustGroup != newValue.TrustGroup {
+			ul.Property = "Trust group"
+			ul.Value = newValue.TrustGroup
+		} else if oldValue.Claims != newValue.Claims {
+			ul.Property = "Token claims"
+			ul.Value = newValue.Claims
+		} else if oldValue.Differential != newValue.Differential {
+			ul.Property = "Differential difficulty"
+			ul.Value = strconv.FormatBool(newValue.Differential)
+		} else if oldValue.BotPolicy != newValue.BotPolicy {
+			ul.Property = "Bot policy"
+			ul.Value = newValue.BotPolicy
+		} else if oldValue.DifficultyStrategy != newValue.DifficultyStrategy {
+			ul.Property = "Difficulty strategy"
+			ul.Value = newValue.DifficultyStrategy
+		} else if oldValue.FailureURL != newValue.FailureURL {
+			ul.Property = "Failure redirect URL"
+			ul.Value = newValue.FailureURL
+		} else if oldValue.FailureMessage != newValue.FailureMessage {
+			ul.Property = "Failure message"
+			ul.Value = newValue.FailureMessage
+		} else if oldValue.EmergencyUntil != newValue.EmergencyUntil {
+			ul.Property = "Emergency mode"
+			if len(newValue.EmergencyUntil) > 0 {
+				ul.Value = fmt.Sprintf("until %s", newValue.EmergencyUntil)
+			} else {
+				ul.Value = "disabled"
+			}
+		} else if oldValue.PrivacyMode != newValue.PrivacyMode {
+			ul.Property = "Privacy mode"
+			ul.Value = newValue.PrivacyMode
 		}
 	} else if (oldValue != nil) || (newValue != nil) {
 		prop := newValue
@@ -221,6 +374,66 @@ func (ul *userAuditLog) initFromAPIKey(oldValue, newValue *db.AuditLogAPIKey) er
 	return nil
 }
 
+func (ul *userAuditLog) initFromAPIKeyIPViolation(oldValue, newValue *db.AuditLogAPIKeyIPViolation) error {
+	if newValue == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = fmt.Sprintf("API key '%s'", newValue.Name)
+	ul.Property = "Blocked request from IP"
+	ul.Value = newValue.IP
+
+	return nil
+}
+
+func (ul *userAuditLog) initFromPlanDowngrade(oldValue, newValue *db.AuditLogPlanDowngrade) error {
+	if newValue == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = "Subscription"
+	ul.Property = "Confirmed downgrade"
+	ul.Value = fmt.Sprintf("from '%s' to '%s'", newValue.FromPlan, newValue.ToPlan)
+
+	return nil
+}
+
+func (ul *userAuditLog) initFromUserDevice(oldValue, newValue *db.AuditLogUserDevice) error {
+	device := newValue
+	if device == nil {
+		device = oldValue
+	}
+
+	if device == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = "Device"
+	ul.Property = "Sign-in"
+	if newValue == nil {
+		ul.Property = "Known device"
+	}
+
+	ul.Value = fmt.Sprintf("%s on %s", device.Browser, device.OS)
+	if len(device.Country) > 0 {
+		ul.Value += fmt.Sprintf(" (%s)", device.Country)
+	}
+
+	return nil
+}
+
+func (ul *userAuditLog) initFromUserSSOLink(newValue *db.AuditLogUserSSOLink) error {
+	if newValue == nil {
+		return errUnexpectedAuditLogPayload
+	}
+
+	ul.Resource = "Single sign-on"
+	ul.Property = "Linked identity"
+	ul.Value = newValue.Issuer
+
+	return nil
+}
+
 func (ul *userAuditLog) initFromAccess(log *dbgen.AuditLog, payload *db.AuditLogAccess) error {
 	if payload == nil {
 		return errUnexpectedAuditLogPayload
@@ -260,7 +473,7 @@ func (s *Server) getAuditLogs(w http.ResponseWriter, r *http.Request) (*ViewMode
 		return nil, err
 	}
 
-	renderCtx, err := s.AuditLogsFunc(ctx, user, 14, 0)
+	renderCtx, err := s.AuditLogsFunc(ctx, user, 14, 0, "" /*search*/)
 	if err != nil {
 		return nil, err
 	}
@@ -323,6 +536,51 @@ func (s *Server) newUserAuditLog(ctx context.Context, log *dbgen.AuditLog) (*use
 			if oldOrgUser, newOrgUser, err = db.ParseAuditLogPayloads[db.AuditLogOrgUser](ctx, log); err == nil {
 				err = ul.initFromOrgUser(oldOrgUser, newOrgUser)
 			}
+		case db.TableNameOrgIPAllowlists:
+			var oldAllowlist, newAllowlist *db.AuditLogOrgIPAllowlist
+			if oldAllowlist, newAllowlist, err = db.ParseAuditLogPayloads[db.AuditLogOrgIPAllowlist](ctx, log); err == nil {
+				err = ul.initFromOrgIPAllowlist(oldAllowlist, newAllowlist)
+			}
+		case db.TableNameOrgEmailDomains:
+			var oldDomain, newDomain *db.AuditLogOrgEmailDomain
+			if oldDomain, newDomain, err = db.ParseAuditLogPayloads[db.AuditLogOrgEmailDomain](ctx, log); err == nil {
+				err = ul.initFromOrgEmailDomain(oldDomain, newDomain)
+			}
+		case db.TableNamePropertyAccessLists:
+			var oldAccessList, newAccessList *db.AuditLogPropertyAccessList
+			if oldAccessList, newAccessList, err = db.ParseAuditLogPayloads[db.AuditLogPropertyAccessList](ctx, log); err == nil {
+				err = ul.initFromPropertyAccessList(oldAccessList, newAccessList)
+			}
+		case db.TableNamePropertyShareLinks:
+			var oldShareLink, newShareLink *db.AuditLogPropertyShareLink
+			if oldShareLink, newShareLink, err = db.ParseAuditLogPayloads[db.AuditLogPropertyShareLink](ctx, log); err == nil {
+				err = ul.initFromPropertyShareLink(oldShareLink, newShareLink)
+			}
+		case db.TableNamePropertyBypassTokens:
+			var oldToken, newToken *db.AuditLogPropertyBypassToken
+			if oldToken, newToken, err = db.ParseAuditLogPayloads[db.AuditLogPropertyBypassToken](ctx, log); err == nil {
+				err = ul.initFromPropertyBypassToken(log.Action, oldToken, newToken)
+			}
+		case db.TableNameAPIKeyIPViolations:
+			var oldViolation, newViolation *db.AuditLogAPIKeyIPViolation
+			if oldViolation, newViolation, err = db.ParseAuditLogPayloads[db.AuditLogAPIKeyIPViolation](ctx, log); err == nil {
+				err = ul.initFromAPIKeyIPViolation(oldViolation, newViolation)
+			}
+		case db.TableNamePlanDowngrades:
+			var oldDowngrade, newDowngrade *db.AuditLogPlanDowngrade
+			if oldDowngrade, newDowngrade, err = db.ParseAuditLogPayloads[db.AuditLogPlanDowngrade](ctx, log); err == nil {
+				err = ul.initFromPlanDowngrade(oldDowngrade, newDowngrade)
+			}
+		case db.TableNameUserDevices:
+			var oldDevice, newDevice *db.AuditLogUserDevice
+			if oldDevice, newDevice, err = db.ParseAuditLogPayloads[db.AuditLogUserDevice](ctx, log); err == nil {
+				err = ul.initFromUserDevice(oldDevice, newDevice)
+			}
+		case db.TableNameUserSSOLinks:
+			var newLink *db.AuditLogUserSSOLink
+			if _, newLink, err = db.ParseAuditLogPayloads[db.AuditLogUserSSOLink](ctx, log); err == nil {
+				err = ul.initFromUserSSOLink(newLink)
+			}
 		}
 	}
 
@@ -355,12 +613,25 @@ func (s *Server) newUserAuditLogs(ctx context.Context, logs []*dbgen.GetUserAudi
 	return result
 }
 
-func (s *Server) retrieveAuditLogs(ctx context.Context, user *dbgen.User, days int, maxLogs int) ([]*dbgen.GetUserAuditLogsRow, error) {
-	slog.DebugContext(ctx, "About to retrieve audit logs", "days", days, "maxLogs", maxLogs, "userID", user.ID)
+func auditLogsSearchFromParam(r *http.Request) string {
+	search := strings.TrimSpace(r.URL.Query().Get(common.ParamSearch))
+	if len(search) > maxAuditLogsSearchLen {
+		search = search[:maxAuditLogsSearchLen]
+	}
+
+	return search
+}
+
+func (s *Server) retrieveAuditLogs(ctx context.Context, user *dbgen.User, days int, maxLogs int, search string) ([]*dbgen.GetUserAuditLogsRow, error) {
+	slog.DebugContext(ctx, "About to retrieve audit logs", "days", days, "maxLogs", maxLogs, "userID", user.ID, "search", len(search) > 0)
 	// cache-friendly (more stable) date
 	tnow := time.Now().UTC().Truncate(24 * time.Hour)
 	after := tnow.AddDate(0 /*years*/, 0 /*months*/, -days)
 
+	if len(search) > 0 {
+		return s.Store.Impl().SearchUserAuditLogs(ctx, user, search, maxLogs, after)
+	}
+
 	var allLogs []*dbgen.GetUserAuditLogsRow
 
 	for _, cacheDays := range []int{14, 30, 90, 180, 365} {
@@ -5,15 +5,19 @@ package portal
 import (
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"slices"
 	"strconv"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
 )
 
 func auditLogsDaysFromParam(ctx context.Context, param string) int {
@@ -44,17 +48,9 @@ func (s *Server) getAuditLogEvents(w http.ResponseWriter, r *http.Request) (*Vie
 	}
 
 	days := auditLogsDaysFromParam(ctx, r.URL.Query().Get(common.ParamDays))
+	search := auditLogsSearchFromParam(r)
 
-	pageParam := r.URL.Query().Get(common.ParamPage)
-	page := 0
-	if len(pageParam) > 0 {
-		if page, err = strconv.Atoi(pageParam); err != nil {
-			slog.ErrorContext(ctx, "Failed to convert page parameter", "page", pageParam, common.ErrAttr(err))
-			page = 0
-		}
-	}
-
-	renderCtx, err := s.AuditLogsFunc(ctx, user, days, page)
+	renderCtx, err := s.AuditLogsFunc(ctx, user, days, pagination.ParsePage(ctx, r), search)
 	if err != nil {
 		return nil, err
 	}
@@ -65,31 +61,126 @@ func (s *Server) getAuditLogEvents(w http.ResponseWriter, r *http.Request) (*Vie
 	}, nil
 }
 
-func (s *Server) exportAuditLogsCSV(w http.ResponseWriter, r *http.Request) {
+const (
+	auditLogsFormatCSV      = "csv"
+	auditLogsFormatJSON     = "json"
+	auditLogsExportPageSize = 1000
+)
+
+// auditLogsExportPeriod returns days to retrieve audit logs for and the [from, to) range to export. Explicit start and
+// end dates (YYYY-MM-DD) take precedence over the days period
+func auditLogsExportPeriod(ctx context.Context, r *http.Request, tnow time.Time) (int, time.Time, time.Time) {
+	const maxDays = 365
+	query := r.URL.Query()
+	today := tnow.UTC().Truncate(24 * time.Hour)
+
+	days := auditLogsDaysFromParam(ctx, query.Get(common.ParamDays))
+	from := today.AddDate(0 /*years*/, 0 /*months*/, -days)
+	var to time.Time
+
+	if param := query.Get(common.ParamStart); len(param) > 0 {
+		if start, err := time.Parse(time.DateOnly, param); err == nil {
+			from = start
+			if earliest := today.AddDate(0 /*years*/, 0 /*months*/, -maxDays); from.Before(earliest) {
+				from = earliest
+			}
+			days = max(1, int(math.Ceil(tnow.Sub(from).Hours()/24.0)))
+		} else {
+			slog.WarnContext(ctx, "Failed to parse audit logs start date", "value", param, common.ErrAttr(err))
+		}
+	}
+
+	if param := query.Get(common.ParamEnd); len(param) > 0 {
+		if end, err := time.Parse(time.DateOnly, param); err == nil {
+			// end date is inclusive
+			to = end.AddDate(0 /*years*/, 0 /*months*/, 1)
+		} else {
+			slog.WarnContext(ctx, "Failed to parse audit logs end date", "value", param, common.ErrAttr(err))
+		}
+	}
+
+	return days, from, to
+}
+
+// retrieveExportAuditLogs fetches all audit logs in the [from, to) range page by page (newest first), so that
+// exports are not truncated by the amount of logs that happened after the end date
+func (s *Server) retrieveExportAuditLogs(ctx context.Context, user *dbgen.User, from, to time.Time, search string) ([]*dbgen.GetUserAuditLogsRow, error) {
+	var result []*dbgen.GetUserAuditLogsRow
+	// (to, MinInt64) cursor excludes everything created at or after the (exclusive) end date
+	beforeCreatedAt, beforeID := to, int64(math.MinInt64)
+
+	for {
+		var page []*dbgen.GetUserAuditLogsRow
+		var hasMore bool
+		var err error
+
+		if len(search) > 0 {
+			page, hasMore, err = s.Store.Impl().SearchUserAuditLogsBefore(ctx, user, search, from, beforeCreatedAt, beforeID, auditLogsExportPageSize)
+		} else {
+			var logs []*dbgen.GetUserAuditLogsBeforeRow
+			logs, hasMore, err = s.Store.Impl().RetrieveUserAuditLogsBefore(ctx, user, from, beforeCreatedAt, beforeID, 0 /*offset*/, auditLogsExportPageSize)
+			for _, log := range logs {
+				page = append(page, (*dbgen.GetUserAuditLogsRow)(log))
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, page...)
+
+		if !hasMore || (len(page) == 0) {
+			break
+		}
+
+		last := page[len(page)-1].AuditLog
+		beforeCreatedAt, beforeID = last.CreatedAt.Time, last.ID
+	}
+
+	slog.DebugContext(ctx, "Retrieved audit logs for export", "userID", user.ID, "count", len(result), "search", len(search) > 0)
+
+	return result, nil
+}
+
+func (s *Server) exportAuditLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	user, err := s.SessionUser(ctx, s.Session(w, r))
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to get session user for CSV export", common.ErrAttr(err))
+		slog.ErrorContext(ctx, "Failed to get session user for audit logs export", common.ErrAttr(err))
 		s.RedirectError(http.StatusUnauthorized, w, r)
 		return
 	}
 
-	days := auditLogsDaysFromParam(ctx, r.URL.Query().Get(common.ParamDays))
+	_, from, to := auditLogsExportPeriod(ctx, r, time.Now())
 
-	logs, err := s.retrieveAuditLogs(ctx, user, days, days*1000 /*max logs*/)
+	logs, err := s.retrieveExportAuditLogs(ctx, user, from, to, auditLogsSearchFromParam(r))
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to retrieve audit logs", common.ErrAttr(err))
 		s.RedirectError(http.StatusInternalServerError, w, r)
 		return
 	}
 
-	// audit logs for UI are sorted from the newest, but in CSV we want to see from the oldest
+	// audit logs for UI are sorted from the newest, but in exports we want to see from the oldest
 	slices.Reverse(logs)
 
+	format := r.URL.Query().Get(common.ParamFormat)
+	if format == auditLogsFormatJSON {
+		s.writeAuditLogsJSON(w, r, logs, from)
+	} else {
+		format = auditLogsFormatCSV
+		s.writeAuditLogsCSV(w, r, logs, from)
+	}
+
+	slog.InfoContext(ctx, "Exported audit logs", "userID", user.ID, "format", format, "from", from, "to", to, "count", len(logs))
+}
+
+func (s *Server) writeAuditLogsCSV(w http.ResponseWriter, r *http.Request, logs []*dbgen.GetUserAuditLogsRow, from time.Time) {
+	ctx := r.Context()
+
 	// Set headers for CSV download
-	after := time.Now().AddDate(0 /*years*/, 0 /*months*/, -days)
-	filename := fmt.Sprintf("private-captcha-audit-logs-from-%s.csv", after.Format(time.DateOnly))
+	filename := fmt.Sprintf("private-captcha-audit-logs-from-%s.csv", from.Format(time.DateOnly))
 	w.Header().Set(common.HeaderContentType, common.ContentTypeCSV)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 
@@ -122,18 +213,59 @@ func (s *Server) exportAuditLogsCSV(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+}
+
+// exportedAuditLog is the published event (the same that is streamed to SIEM) with IDs obfuscated like in CSV export
+type exportedAuditLog struct {
+	*db.Event
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	EntityID string `json:"entity_id"`
+	Table    string `json:"entity_table"`
+}
 
-	slog.InfoContext(ctx, "Successfully exported audit logs to CSV", "userID", user.ID, "days", days, "count", len(logs))
+type exportedAuditLogs struct {
+	Events []*exportedAuditLog `json:"events"`
+}
+
+func (s *Server) writeAuditLogsJSON(w http.ResponseWriter, r *http.Request, logs []*dbgen.GetUserAuditLogsRow, from time.Time) {
+	ctx := r.Context()
+
+	result := &exportedAuditLogs{Events: make([]*exportedAuditLog, 0, len(logs))}
+	for _, userLog := range logs {
+		log := &userLog.AuditLog
+		event := db.NewStoredEvent(log)
+		if event == nil {
+			slog.WarnContext(ctx, "Skipping audit log of unknown event type", "auditLogID", log.ID, "table", log.EntityTable)
+			continue
+		}
+
+		result.Events = append(result.Events, &exportedAuditLog{
+			Event:    event,
+			ID:       s.IDHasher.Encrypt64(log.ID),
+			UserID:   s.IDHasher.Encrypt(int(log.UserID.Int32)),
+			EntityID: s.IDHasher.Encrypt64(log.EntityID.Int64),
+			Table:    log.EntityTable,
+		})
+	}
+
+	filename := fmt.Sprintf("private-captcha-audit-logs-from-%s.json", from.Format(time.DateOnly))
+	w.Header().Set(common.HeaderContentType, common.ContentTypeJSON)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		slog.ErrorContext(ctx, "Failed to encode audit logs", common.ErrAttr(err))
+	}
 }
 
-func (s *Server) CreateAuditLogsContext(ctx context.Context, user *dbgen.User, days int, page int) (*MainAuditLogsRenderContext, error) {
+func (s *Server) CreateAuditLogsContext(ctx context.Context, user *dbgen.User, days int, page int, search string) (*MainAuditLogsRenderContext, error) {
 	slog.DebugContext(ctx, "Creating audit logs context", "userID", user.ID, "days", days, "page", page)
 	maxLogs := maxAuditLogsForDays(days)
 	if page < 0 {
 		page = 0
 	}
 
-	allLogs, err := s.retrieveAuditLogs(ctx, user, days, maxLogs)
+	allLogs, err := s.retrieveAuditLogs(ctx, user, days, maxLogs, search)
 	if err != nil {
 		return nil, err
 	}
@@ -159,6 +291,7 @@ func (s *Server) CreateAuditLogsContext(ctx context.Context, user *dbgen.User, d
 			Count:     len(allLogs),
 			PerPage:   perPageEventLogs,
 			Page:      page,
+			Search:    search,
 		},
 		Days: days,
 		From: from,
@@ -0,0 +1,36 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuditLogsExportPeriod(t *testing.T) {
+	tnow := time.Date(2025, time.June, 15, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		query string
+		days  int
+		from  time.Time
+		to    time.Time
+	}{
+		{"", 14, today.AddDate(0, 0, -14), time.Time{}},
+		{"?days=30", 30, today.AddDate(0, 0, -30), time.Time{}},
+		{"?start=2025-06-01&end=2025-06-10", 15, time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.June, 11, 0, 0, 0, 0, time.UTC)},
+		// start is limited by the max period
+		{"?start=2020-01-01", 366, today.AddDate(0, 0, -365), time.Time{}},
+		{"?start=invalid&end=invalid", 14, today.AddDate(0, 0, -14), time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/audit/export"+tc.query, nil)
+		days, from, to := auditLogsExportPeriod(t.Context(), r, tnow)
+		if (days != tc.days) || !from.Equal(tc.from) || !to.Equal(tc.to) {
+			t.Errorf("Unexpected period for '%s': days=%v from=%v to=%v", tc.query, days, from, to)
+		}
+	}
+}
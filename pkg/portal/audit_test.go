@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -543,7 +545,7 @@ func TestCreateAuditLogsContext(t *testing.T) {
 		t.Fatalf("Failed to create account: %v", err)
 	}
 
-	renderCtx, err := server.CreateAuditLogsContext(ctx, user, 14, 0)
+	renderCtx, err := server.CreateAuditLogsContext(ctx, user, 14, 0, "" /*search*/)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -560,3 +562,49 @@ func TestCreateAuditLogsContext(t *testing.T) {
 		t.Error("Expected AuditLogs to be initialized")
 	}
 }
+
+func TestAuditLogsSearchFromParam(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{" example.com ", "example.com"},
+		{strings.Repeat("a", maxAuditLogsSearchLen+10), strings.Repeat("a", maxAuditLogsSearchLen)},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest("GET", "/events?"+url.Values{common.ParamSearch: []string{tc.query}}.Encode(), nil)
+		if actual := auditLogsSearchFromParam(req); actual != tc.expected {
+			t.Errorf("Unexpected search for %q: %q", tc.query, actual)
+		}
+	}
+}
+
+func TestCreateAuditLogsContextSearch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	renderCtx, err := server.CreateAuditLogsContext(ctx, user, 14, 0, "example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if renderCtx.Search != "example.com" {
+		t.Errorf("Unexpected search: %v", renderCtx.Search)
+	}
+
+	if renderCtx.AuditLogs == nil {
+		t.Error("Expected AuditLogs to be initialized")
+	}
+}
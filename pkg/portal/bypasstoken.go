@@ -0,0 +1,182 @@
+package portal
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	maxBypassTokenNameLength = 64
+)
+
+var (
+	bypassTokenDurations = []int{1, 7, 30, 90}
+)
+
+type userBypassToken struct {
+	ID         string
+	Name       string
+	Uses       int32
+	MaxUses    int32
+	CreatedAt  string
+	ExpiresAt  string
+	LastUsedAt string
+}
+
+func bypassTokenToUserBypassToken(token *dbgen.PropertyBypassToken, hasher common.IdentifierHasher) *userBypassToken {
+	result := &userBypassToken{
+		ID:        hasher.Encrypt(int(token.ID)),
+		Name:      token.Name,
+		Uses:      token.Uses,
+		MaxUses:   token.MaxUses,
+		CreatedAt: token.CreatedAt.Time.Format("02 Jan 2006"),
+		ExpiresAt: token.ExpiresAt.Time.UTC().Format("02 Jan 2006 15:04 UTC"),
+	}
+
+	if token.LastUsedAt.Valid {
+		result.LastUsedAt = token.LastUsedAt.Time.UTC().Format("02 Jan 2006 15:04 UTC")
+	}
+
+	return result
+}
+
+func (s *Server) propertyBypassTokens(r *http.Request, property *dbgen.Property) []*userBypassToken {
+	tokens, err := s.Store.Impl().RetrievePropertyBypassTokens(r.Context(), property)
+	if err != nil {
+		return []*userBypassToken{}
+	}
+
+	result := make([]*userBypassToken, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, bypassTokenToUserBypassToken(token, s.IDHasher))
+	}
+
+	return result
+}
+
+func (s *Server) postPropertyBypassToken(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	renderCtx, err := s.getPropertyIntegrations(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to create bypass token", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate}, nil
+	}
+
+	name := strings.TrimSpace(r.FormValue(common.ParamName))
+	if (len(name) < 3) || (len(name) > maxBypassTokenNameLength) || !db.CheckAPIKeyNameValid(ctx, name) {
+		renderCtx.ErrorMessage = "Token name has to be 3 to 64 letters, digits or spaces."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate}, nil
+	}
+
+	days, err := strconv.Atoi(r.FormValue(common.ParamDays))
+	if (err != nil) || !slices.Contains(bypassTokenDurations, days) {
+		slog.WarnContext(ctx, "Invalid bypass token duration", "days", r.FormValue(common.ParamDays))
+		renderCtx.ErrorMessage = "Invalid bypass token duration."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate}, nil
+	}
+
+	maxUses, err := strconv.Atoi(r.FormValue(common.ParamMaxUses))
+	if (err != nil) || (maxUses <= 0) || (maxUses > db.MaxBypassTokenUses) {
+		slog.WarnContext(ctx, "Invalid bypass token max uses", "maxUses", r.FormValue(common.ParamMaxUses))
+		renderCtx.ErrorMessage = "Number of uses has to be between 1 and 100000."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate}, nil
+	}
+
+	expiresAt := time.Now().UTC().AddDate(0, 0, days).Truncate(time.Second)
+
+	token, auditEvent, err := s.Store.Impl().CreatePropertyBypassToken(ctx, user, property, name, int32(maxUses), expiresAt)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to create bypass token. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate}, nil
+	}
+
+	renderCtx.BypassTokens = s.propertyBypassTokens(r, property)
+	renderCtx.NewBypassToken = token
+	renderCtx.SuccessMessage = "Bypass token was created. Copy it now, it will not be shown again."
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) deletePropertyBypassToken(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, _, err := common.IntPathArg(r, common.ParamID, s.IDHasher)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse bypass token ID", common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	renderCtx, err := s.getPropertyIntegrations(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to revoke bypass token", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate}, nil
+	}
+
+	auditEvent, err := s.Store.Impl().DeletePropertyBypassToken(ctx, user, property, tokenID)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to revoke bypass token. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate}, nil
+	}
+
+	renderCtx.BypassTokens = s.propertyBypassTokens(r, property)
+	renderCtx.SuccessMessage = "Bypass token was revoked."
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardIntegrationsTemplate, AuditEvent: auditEvent}, nil
+}
@@ -0,0 +1,52 @@
+package portal
+
+import (
+	"sync"
+
+	"github.com/medama-io/go-useragent"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+var (
+	userAgentParser = sync.OnceValue(useragent.NewParser)
+)
+
+// LoginDevice is a combination of browser, OS and country (without versions) that user signs in from. Known devices
+// are shown as an attribute of user sessions (see sessions.go)
+type LoginDevice struct {
+	UserAgent string
+	Browser   string
+	OS        string
+	Country   string
+}
+
+func newLoginDevice(userAgent string, locale *UserLocale) *LoginDevice {
+	agent := userAgentParser().Parse(userAgent)
+
+	device := &LoginDevice{
+		UserAgent: userAgent,
+		Browser:   agent.Browser().String(),
+		OS:        agent.OS().String(),
+	}
+
+	if locale != nil {
+		device.Country = locale.Country
+	}
+
+	return device
+}
+
+func findKnownDevice(devices []*dbgen.UserDevice, browser, os, country string) *dbgen.UserDevice {
+	for _, known := range devices {
+		if (known.Browser == browser) && (known.Os == os) && (known.Country == country) {
+			return known
+		}
+	}
+
+	return nil
+}
+
+func (d *LoginDevice) isKnown(devices []*dbgen.UserDevice) bool {
+	return findKnownDevice(devices, d.Browser, d.OS, d.Country) != nil
+}
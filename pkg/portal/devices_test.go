@@ -0,0 +1,41 @@
+package portal
+
+import (
+	"testing"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestLoginDeviceIsKnown(t *testing.T) {
+	t.Parallel()
+
+	const userAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+
+	device := newLoginDevice(userAgent, &UserLocale{Country: "DE"})
+	if (len(device.Browser) == 0) || (len(device.OS) == 0) {
+		t.Fatalf("Failed to parse user agent: %v", device)
+	}
+
+	if device.isKnown([]*dbgen.UserDevice{}) {
+		t.Error("Device is known without known devices")
+	}
+
+	known := []*dbgen.UserDevice{
+		{Browser: device.Browser, Os: device.OS, Country: "FR"},
+		{Browser: device.Browser, Os: device.OS, Country: "DE"},
+	}
+
+	if !device.isKnown(known) {
+		t.Error("Device is not known")
+	}
+
+	// browser version should not matter
+	newer := newLoginDevice("Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0", &UserLocale{Country: "DE"})
+	if !newer.isKnown(known) {
+		t.Error("Device with newer browser version is not known")
+	}
+
+	if other := newLoginDevice(userAgent, &UserLocale{Country: "US"}); other.isKnown(known) {
+		t.Error("Device from another country is known")
+	}
+}
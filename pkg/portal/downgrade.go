@@ -0,0 +1,224 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	settingsUsageDowngradeTemplate = "settings-usage/downgrade.html"
+)
+
+type settingsDowngradePlan struct {
+	Name      string
+	ProductID string
+	PriceID   string
+}
+
+type settingsDowngradeRenderContext struct {
+	AlertRenderContext
+	CsrfRenderContext
+	CurrentPlan  string
+	TargetPlan   string
+	ProductID    string
+	PriceID      string
+	Impact       *db.DowngradeImpact
+	ConfirmError string
+	// downgrade was requested with the billing provider
+	Done bool
+}
+
+// downgradeSubscription returns subscription of the user (with its plan) that can be changed in self-service
+func (s *Server) downgradeSubscription(ctx context.Context, user *dbgen.User) (*dbgen.Subscription, billing.Plan, error) {
+	if !user.SubscriptionID.Valid {
+		return nil, nil, db.ErrNoActiveSubscription
+	}
+
+	subscription, err := s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// internal trials and admin plans are managed by support
+	if db.IsInternalSubscription(subscription.Source) || !subscription.ExternalSubscriptionID.Valid ||
+		!s.PlanService.IsSubscriptionActive(subscription.Status) {
+		return nil, nil, db.ErrNoActiveSubscription
+	}
+
+	plan, err := s.PlanService.FindPlan(subscription.ExternalProductID, subscription.ExternalPriceID, s.Stage, false /*internal*/)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return subscription, plan, nil
+}
+
+// downgradePriceID keeps billing interval of the current subscription for the target plan
+func downgradePriceID(current billing.Plan, currentPriceID string, target billing.Plan) string {
+	currentMonthly, _ := current.PriceIDs()
+	monthly, yearly := target.PriceIDs()
+
+	if (currentPriceID == currentMonthly) && (len(monthly) > 0) {
+		return monthly
+	}
+
+	if len(yearly) > 0 {
+		return yearly
+	}
+
+	return monthly
+}
+
+// createDowngradePlans lists plans that have any of the limits lower than the current plan of the user
+func (s *Server) createDowngradePlans(ctx context.Context, user *dbgen.User) []*settingsDowngradePlan {
+	subscription, current, err := s.downgradeSubscription(ctx, user)
+	if err != nil {
+		slog.DebugContext(ctx, "Subscription cannot be downgraded", "userID", user.ID, common.ErrAttr(err))
+		return nil
+	}
+
+	currentLimits := db.NewPlanLimits(current)
+	result := make([]*settingsDowngradePlan, 0)
+
+	for _, p := range s.PlanService.Plans(s.Stage) {
+		if !p.IsValid() || (p.ProductID() == current.ProductID()) {
+			continue
+		}
+
+		if reductions := db.NewPlanReductions(currentLimits, db.NewPlanLimits(p)); len(reductions) == 0 {
+			continue
+		}
+
+		result = append(result, &settingsDowngradePlan{
+			Name:      p.Name(),
+			ProductID: p.ProductID(),
+			PriceID:   downgradePriceID(current, subscription.ExternalPriceID, p),
+		})
+	}
+
+	return result
+}
+
+func (s *Server) createDowngradeModel(ctx context.Context, user *dbgen.User, productID, priceID string) (*settingsDowngradeRenderContext, *dbgen.Subscription, billing.Plan, billing.Plan, error) {
+	if (len(productID) == 0) || (len(priceID) == 0) {
+		slog.WarnContext(ctx, "Downgrade plan is not specified", "productID", productID, "priceID", priceID)
+		return nil, nil, nil, nil, ErrInvalidRequestArg
+	}
+
+	renderCtx := &settingsDowngradeRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		ProductID:         productID,
+		PriceID:           priceID,
+	}
+
+	subscription, current, err := s.downgradeSubscription(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find subscription for downgrade", "userID", user.ID, common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Your subscription cannot be changed here. Please contact support."
+		return renderCtx, nil, nil, nil, nil
+	}
+	renderCtx.CurrentPlan = current.Name()
+
+	target, err := s.PlanService.FindPlan(productID, priceID, s.Stage, false /*internal*/)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find downgrade plan", "productID", productID, "priceID", priceID, common.ErrAttr(err))
+		return nil, nil, nil, nil, ErrInvalidRequestArg
+	}
+	renderCtx.TargetPlan = target.Name()
+
+	currentLimits, err := s.SubscriptionLimits.Limits(ctx, subscription)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve current plan limits", "userID", user.ID, common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Could not determine limits of your current plan."
+		return renderCtx, nil, nil, nil, nil
+	}
+
+	impact, err := db.NewDowngradeImpact(ctx, s.Store, user.ID, currentLimits, db.NewPlanLimits(target))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to compute downgrade impact", "userID", user.ID, common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Could not compute the impact of the plan change. Please try again."
+		return renderCtx, nil, nil, nil, nil
+	}
+	renderCtx.Impact = impact
+
+	if !impact.IsDowngrade() {
+		slog.WarnContext(ctx, "Plan change is not a downgrade", "userID", user.ID, "from", current.Name(), "to", target.Name())
+		renderCtx.ErrorMessage = "Selected plan does not lower any limits of your current plan."
+		return renderCtx, nil, nil, nil, nil
+	}
+
+	return renderCtx, subscription, current, target, nil
+}
+
+func (s *Server) getUsageDowngrade(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	renderCtx, _, _, _, err := s.createDowngradeModel(ctx, user,
+		strings.TrimSpace(query.Get(common.ParamProduct)),
+		strings.TrimSpace(query.Get(common.ParamPrice)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ViewModel{Model: renderCtx, View: settingsUsageDowngradeTemplate}, nil
+}
+
+func (s *Server) postUsageDowngrade(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	renderCtx, subscription, current, target, err := s.createDowngradeModel(ctx, user,
+		strings.TrimSpace(r.FormValue(common.ParamProduct)),
+		strings.TrimSpace(r.FormValue(common.ParamPrice)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(renderCtx.ErrorMessage) > 0 {
+		return &ViewModel{Model: renderCtx, View: settingsUsageDowngradeTemplate}, nil
+	}
+
+	if r.FormValue(common.ParamConfirm) != "true" {
+		renderCtx.ConfirmError = "Please confirm that you understand the impact of the downgrade."
+		return &ViewModel{Model: renderCtx, View: settingsUsageDowngradeTemplate}, nil
+	}
+
+	if err := s.PlanService.ChangeSubscriptionPlan(ctx, subscription.ExternalSubscriptionID.String, renderCtx.ProductID, renderCtx.PriceID); err != nil {
+		slog.ErrorContext(ctx, "Failed to change subscription plan", "userID", user.ID, "productID", renderCtx.ProductID,
+			"priceID", renderCtx.PriceID, common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Failed to change your plan. Please try again."
+		return &ViewModel{Model: renderCtx, View: settingsUsageDowngradeTemplate}, nil
+	}
+
+	slog.InfoContext(ctx, "Requested subscription downgrade", "userID", user.ID, "from", current.Name(), "to", target.Name(),
+		"properties", renderCtx.Impact.PropertiesOverLimit(), "apikeys", len(renderCtx.Impact.APIKeys))
+
+	renderCtx.Done = true
+	renderCtx.SuccessMessage = "Plan change was requested. It will take effect once confirmed by the billing provider."
+
+	auditEvent := db.NewPlanDowngradeAuditLogEvent(user, subscription, current, target, renderCtx.PriceID, renderCtx.Impact)
+
+	return &ViewModel{Model: renderCtx, View: settingsUsageDowngradeTemplate, AuditEvent: auditEvent}, nil
+}
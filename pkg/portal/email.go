@@ -37,6 +37,8 @@ type PortalMailer struct {
 	TwofactorTemplate  *common.EmailTemplate
 	WelcomeTemplate    *common.EmailTemplate
 	OrgInviteItemplate *common.EmailTemplate
+	RecoveryTemplate   *common.EmailTemplate
+	NewSignInTemplate  *common.EmailTemplate
 	uaParser           *useragent.Parser
 }
 
@@ -51,6 +53,8 @@ func NewPortalMailer(cdnURL, portalURL string, mailer emailpkg.Sender, cfg commo
 		TwofactorTemplate:  emailpkg.TwoFactorEmailTemplate,
 		WelcomeTemplate:    emailpkg.WelcomeEmailTemplate,
 		OrgInviteItemplate: emailpkg.OrgInvitationTemplate,
+		RecoveryTemplate:   emailpkg.OrgIPAllowlistRecoveryTemplate,
+		NewSignInTemplate:  emailpkg.NewSignInEmailTemplate,
 		uaParser:           useragent.NewParser(),
 	}
 }
@@ -212,3 +216,106 @@ func (pm *PortalMailer) SendOrgInvite(ctx context.Context, email, name string, o
 
 	return nil
 }
+
+func (pm *PortalMailer) SendIPAllowlistRecovery(ctx context.Context, email, orgName, clientIP, recoveryURLPath string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	data := struct {
+		emailpkg.OrgIPAllowlistRecoveryContext
+		CurrentYear int
+		CDNURL      string
+	}{
+		CDNURL:      pm.CDNURL,
+		CurrentYear: time.Now().Year(),
+		OrgIPAllowlistRecoveryContext: emailpkg.OrgIPAllowlistRecoveryContext{
+			OrgName:     orgName,
+			ClientIP:    clientIP,
+			RecoveryURL: pm.PortalURL + recoveryURLPath,
+		},
+	}
+
+	htmlBody, err := pm.RecoveryTemplate.RenderHTML(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	textBody, err := pm.RecoveryTemplate.RenderText(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	msg := &emailpkg.Message{
+		HTMLBody:  htmlBody,
+		TextBody:  textBody,
+		Subject:   fmt.Sprintf("[%s] Disable IP allowlist of the %s organization", common.PrivateCaptcha, orgName),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptchaTeam,
+	}
+
+	olog := slog.With("email", email, "org", orgName)
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		olog.ErrorContext(ctx, "Failed to send IP allowlist recovery email", common.ErrAttr(err))
+
+		return err
+	}
+
+	olog.InfoContext(ctx, "Sent IP allowlist recovery email")
+
+	return nil
+}
+
+func (pm *PortalMailer) SendNewSignIn(ctx context.Context, email string, userAgent string, location string) error {
+	if len(email) == 0 {
+		return errInvalidEmail
+	}
+
+	agent := pm.uaParser.Parse(userAgent)
+	tnow := time.Now()
+
+	data := &emailpkg.NewSignInEmailContext{
+		CDNURL:               pm.CDNURL,
+		PortalURL:            pm.PortalURL,
+		CurrentYear:          tnow.Year(),
+		Date:                 tnow.Format("02 Jan 2006 15:04:05 MST"),
+		Browser:              fmt.Sprintf("%s %s", agent.Browser().String(), agent.BrowserVersion()),
+		OS:                   agent.OS().String(),
+		Location:             location,
+		SessionsSettingsPath: fmt.Sprintf("%s?%s=%s", common.SettingsEndpoint, common.ParamTab, common.SessionsEndpoint),
+	}
+
+	htmlBody, err := pm.NewSignInTemplate.RenderHTML(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	textBody, err := pm.NewSignInTemplate.RenderText(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	msg := &emailpkg.Message{
+		HTMLBody:  htmlBody,
+		TextBody:  textBody,
+		Subject:   fmt.Sprintf("[%s] New sign-in to your account", common.PrivateCaptcha),
+		EmailTo:   email,
+		EmailFrom: pm.EmailFrom.Value(),
+		NameFrom:  common.PrivateCaptchaTeam,
+		ReplyTo:   pm.ReplyToEmail.Value(),
+	}
+
+	clog := slog.With("email", email, "browser", data.Browser, "os", data.OS)
+
+	if err := pm.Mailer.SendEmail(ctx, msg); err != nil {
+		clog.ErrorContext(ctx, "Failed to send new sign-in email", common.ErrAttr(err))
+
+		return err
+	}
+
+	clog.InfoContext(ctx, "Sent new sign-in email")
+
+	return nil
+}
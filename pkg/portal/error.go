@@ -38,6 +38,8 @@ func (s *Server) renderError(ctx context.Context, w http.ResponseWriter, code in
 		LoggedIn:    ok && loggedIn,
 		CurrentYear: time.Now().Year(),
 		CDN:         s.CDNURL,
+		ReadOnly:    s.isMaintenanceMode() || s.Standby,
+		Standby:     s.Standby,
 	}
 
 	actualData := struct {
@@ -60,7 +62,11 @@ func (s *Server) renderError(ctx context.Context, w http.ResponseWriter, code in
 	case http.StatusUnauthorized:
 		data.Detail = "You need to log in to view this page."
 	case http.StatusServiceUnavailable:
-		data.Detail = "This page is temporarily unavailable. Please check back later."
+		if s.Standby {
+			data.Detail = "Private Captcha is running in read-only disaster recovery mode. Changes cannot be saved."
+		} else {
+			data.Detail = "This page is temporarily unavailable. Please check back later."
+		}
 	default:
 		data.Detail = "Sorry, an unexpected error has occurred. Our team has been notified."
 	}
@@ -15,7 +15,7 @@ import (
 type Jobs interface {
 	OnboardUser(user *dbgen.User, plan billing.Plan) common.OneOffJob
 	OffboardUser(user *dbgen.User) common.OneOffJob
-	LoginUser(sess *session.Session) common.OneOffJob
+	LoginUser(sess *session.Session, locale *UserLocale, device *LoginDevice) common.OneOffJob
 }
 
 func (s *Server) OnboardUser(user *dbgen.User, plan billing.Plan) common.OneOffJob {
@@ -26,10 +26,14 @@ func (s *Server) OffboardUser(user *dbgen.User) common.OneOffJob {
 	return &common.StubOneOffJob{}
 }
 
-func (s *Server) LoginUser(sess *session.Session) common.OneOffJob {
+func (s *Server) LoginUser(sess *session.Session, locale *UserLocale, device *LoginDevice) common.OneOffJob {
 	return &LoginUserJob{
-		Sess:  sess,
-		Store: s.Store,
+		Sess:   sess,
+		Store:  s.Store,
+		Stage:  s.Stage,
+		Locale: locale,
+		Device: device,
+		Mailer: s.Mailer,
 	}
 }
 
@@ -55,8 +59,12 @@ func (j *onboardUserJob) RunOnce(ctx context.Context, params any) error {
 }
 
 type LoginUserJob struct {
-	Sess  *session.Session
-	Store db.Implementor
+	Sess   *session.Session
+	Store  db.Implementor
+	Stage  string
+	Locale *UserLocale
+	Device *LoginDevice
+	Mailer common.Mailer
 }
 
 func (j *LoginUserJob) Name() string {
@@ -74,12 +82,59 @@ func (j *LoginUserJob) RunOnce(ctx context.Context, params any) error {
 		j.Store.AuditLog().RecordEvent(ctx, newUserAuthAuditLogEvent(userID, common.AuditLogActionLogin), common.AuditLogSourcePortal)
 
 		slog.DebugContext(ctx, "Fetching system notification for user", "userID", userID)
-		if n, err := j.Store.Impl().RetrieveSystemUserNotification(ctx, time.Now().UTC(), userID); err == nil {
+		if n, err := j.Store.Impl().RetrieveSystemUserNotification(ctx, time.Now().UTC(), userID, j.Stage); err == nil {
 			_ = j.Sess.Set(session.KeyNotificationID, n.ID)
 		}
+
+		if j.Locale != nil {
+			_ = j.Store.Impl().UpdateUserLocale(ctx, userID, j.Locale.Timezone, j.Locale.Country)
+		}
+
+		_ = j.Sess.Set(session.KeyLastSeen, time.Now().UTC())
+
+		if j.Device != nil {
+			_ = j.Sess.Set(session.KeyUserAgent, j.Device.UserAgent)
+			_ = j.Sess.Set(session.KeyCountry, j.Device.Country)
+			j.updateDevice(ctx, userID)
+		}
 	} else {
 		slog.ErrorContext(ctx, "UserID not found in session")
 	}
 
 	return nil
 }
+
+func (j *LoginUserJob) updateDevice(ctx context.Context, userID int32) {
+	devices, err := j.Store.Impl().RetrieveUserDevices(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	isKnown := j.Device.isKnown(devices)
+
+	device, err := j.Store.Impl().UpdateUserDevice(ctx, userID, j.Device.Browser, j.Device.OS, j.Device.Country, j.Sess.ID())
+	if err != nil {
+		return
+	}
+
+	// very first sign-in (or first one after devices were introduced) is not a suspicious one
+	if isKnown || (len(devices) == 0) {
+		return
+	}
+
+	slog.InfoContext(ctx, "User signed in from a new device", "userID", userID, "deviceID", device.ID, "browser", device.Browser,
+		"os", device.Os, "country", device.Country)
+
+	j.Store.AuditLog().RecordEvent(ctx, db.NewUserDeviceAuditLogEvent(userID, device, common.AuditLogActionCreate), common.AuditLogSourcePortal)
+
+	if j.Mailer == nil {
+		return
+	}
+
+	user, err := j.Store.Impl().RetrieveUser(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	_ = j.Mailer.SendNewSignIn(ctx, user.Email, j.Device.UserAgent, j.Device.Country)
+}
@@ -0,0 +1,112 @@
+package portal
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	limitDecisionsDefaultDays = 30
+	limitDecisionsMaxDays     = 90
+	limitDecisionsMaxCount    = 100
+)
+
+type limitDecisionOutput struct {
+	CreatedAt          time.Time `json:"created_at"`
+	Resource           string    `json:"resource"`
+	Code               int       `json:"code"`
+	Description        string    `json:"description"`
+	Source             string    `json:"source"`
+	UserID             int32     `json:"user_id,omitempty"`
+	OrgID              int32     `json:"org_id,omitempty"`
+	OrgOwnerID         int32     `json:"org_owner_id,omitempty"`
+	SubscriptionID     int32     `json:"subscription_id,omitempty"`
+	SubscriptionStatus string    `json:"subscription_status,omitempty"`
+	ExternalProductID  string    `json:"product_id,omitempty"`
+	ExternalPriceID    string    `json:"price_id,omitempty"`
+	CurrentCount       int32     `json:"current_count"`
+	RequestedCount     int32     `json:"requested_count"`
+	PlanLimit          int32     `json:"plan_limit"`
+	Error              string    `json:"error,omitempty"`
+}
+
+func newLimitDecisionOutput(d *dbgen.LimitDecision) *limitDecisionOutput {
+	code := common.StatusCode(d.StatusCode)
+
+	return &limitDecisionOutput{
+		CreatedAt:          d.CreatedAt.Time.UTC(),
+		Resource:           d.Resource,
+		Code:               int(code),
+		Description:        code.String(),
+		Source:             string(d.Source),
+		UserID:             d.UserID.Int32,
+		OrgID:              d.OrgID.Int32,
+		OrgOwnerID:         d.OrgOwnerID.Int32,
+		SubscriptionID:     d.SubscriptionID.Int32,
+		SubscriptionStatus: d.SubscriptionStatus,
+		ExternalProductID:  d.ExternalProductID,
+		ExternalPriceID:    d.ExternalPriceID,
+		CurrentCount:       d.CurrentCount,
+		RequestedCount:     d.RequestedCount,
+		PlanLimit:          d.PlanLimit,
+		Error:              d.Error,
+	}
+}
+
+// getLimitDecisions returns recent rejections due to subscription limits of the user (or orgs they own) for support
+func (s *Server) getLimitDecisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, err := s.sessionAdmin(w, r); err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	email := strings.TrimSpace(r.URL.Query().Get(common.ParamEmail))
+	user, err := s.Store.Impl().FindUserByEmail(ctx, email)
+	if err != nil {
+		if (err == db.ErrRecordNotFound) || (err == db.ErrInvalidInput) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	days := limitDecisionsDefaultDays
+	if value := r.URL.Query().Get(common.ParamDays); len(value) > 0 {
+		if d, err := strconv.Atoi(value); err == nil && (d > 0) {
+			days = min(d, limitDecisionsMaxDays)
+		}
+	}
+
+	after := time.Now().UTC().AddDate(0 /*years*/, 0 /*months*/, -days)
+	decisions, err := s.Store.Impl().RetrieveUserLimitDecisions(ctx, user.ID, after, limitDecisionsMaxCount)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve limit decisions", "userID", user.ID, common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	data := make([]*limitDecisionOutput, 0, len(decisions))
+	for _, d := range decisions {
+		data = append(data, newLimitDecisionOutput(d))
+	}
+
+	response := struct {
+		UserID int32                  `json:"user_id"`
+		Data   []*limitDecisionOutput `json:"data"`
+	}{
+		UserID: user.ID,
+		Data:   data,
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
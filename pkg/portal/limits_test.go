@@ -0,0 +1,150 @@
+package portal
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+func TestRecordLimitDecision(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	subscr, err := store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.SubscriptionLimits.RecordDecision(ctx, &db.LimitDecision{
+		Resource:     db.LimitResourceProperties,
+		Code:         common.StatusSubscriptionPropertyLimitError,
+		Source:       common.AuditLogSourcePortal,
+		UserID:       user.ID,
+		Org:          org,
+		Subscription: subscr,
+		Extra:        1,
+		Requested:    2,
+	})
+
+	decisions, err := store.Impl().RetrieveUserLimitDecisions(ctx, user.ID, time.Now().UTC().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decisions) != 1 {
+		t.Fatalf("Unexpected number of limit decisions: %v", len(decisions))
+	}
+
+	d := decisions[0]
+	if (d.Resource != db.LimitResourceProperties) || (d.OrgID.Int32 != org.ID) || (d.SubscriptionID.Int32 != subscr.ID) || (d.RequestedCount != 2) {
+		t.Errorf("Unexpected limit decision: %+v", d)
+	}
+
+	if limit := int32(testPlan.PropertiesLimit()); (d.PlanLimit != limit) || (d.CurrentCount != limit+1) {
+		t.Errorf("Unexpected counts in limit decision: current %v, limit %v", d.CurrentCount, d.PlanLimit)
+	}
+}
+
+func TestGetLimitDecisionsNotAdmin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	srv := http.NewServeMux()
+	server.Setup(portalDomain(), common.NoopMiddleware).Register(srv)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/%s/%s?%s=%s", common.AdminEndpoint, common.LimitsEndpoint, common.ParamEmail, user.Email), nil)
+	req.AddCookie(cookie)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Unexpected status code %v", w.Code)
+	}
+}
+
+func TestGetTraceNotAdmin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	srv := http.NewServeMux()
+	server.Setup(portalDomain(), common.NoopMiddleware).Register(srv)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/%s/%s?%s=%s", common.AdminEndpoint, common.TraceEndpoint, common.ParamTraceID, "abc"), nil)
+	req.AddCookie(cookie)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Unexpected status code %v", w.Code)
+	}
+}
+
+func TestSeatsCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	owner, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_owner", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	member, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_member", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	if count, err := server.SubscriptionLimits.SeatsCount(ctx, owner.ID); (err != nil) || (count != 1) {
+		t.Fatalf("Unexpected seats count %v: %v", count, err)
+	}
+
+	if _, err := store.Impl().InviteUserToOrg(ctx, owner, org, member); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := server.SubscriptionLimits.SeatsCount(ctx, owner.ID); (err != nil) || (count != 2) {
+		t.Errorf("Unexpected seats count %v: %v", count, err)
+	}
+}
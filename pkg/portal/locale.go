@@ -0,0 +1,61 @@
+package portal
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// set by portal scripts from the browser's Intl API
+	timezoneCookieName = "pc_tz"
+	maxTimezoneLength  = 64
+)
+
+// UserLocale is used to deliver non-urgent emails in user's business hours and via regional email provider
+type UserLocale struct {
+	Timezone string
+	Country  string
+}
+
+func isValidTimezone(tz string) bool {
+	if (len(tz) == 0) || (len(tz) > maxTimezoneLength) || (tz == "Local") {
+		return false
+	}
+
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+func isValidCountryCode(country string) bool {
+	if len(country) != 2 {
+		return false
+	}
+
+	for _, c := range country {
+		if (c < 'A') || (c > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Server) requestLocale(r *http.Request) *UserLocale {
+	locale := &UserLocale{}
+
+	if cookie, err := r.Cookie(timezoneCookieName); err == nil {
+		if tz, err := url.QueryUnescape(cookie.Value); err == nil && isValidTimezone(tz) {
+			locale.Timezone = tz
+		}
+	}
+
+	if header := s.CountryCodeHeader.Value(); len(header) > 0 {
+		if country := strings.ToUpper(strings.TrimSpace(r.Header.Get(header))); isValidCountryCode(country) {
+			locale.Country = country
+		}
+	}
+
+	return locale
+}
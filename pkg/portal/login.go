@@ -38,6 +38,8 @@ type loginRenderContext struct {
 	NameError   string
 	CanRegister bool
 	IsRegister  bool
+	IsTwoFactor bool
+	CanSSO      bool
 }
 
 type portalPropertyOwnerSource struct {
@@ -68,6 +70,7 @@ func (s *Server) getLogin(w http.ResponseWriter, r *http.Request) (*ViewModel, e
 			},
 			CaptchaRenderContext: s.CreateCaptchaRenderContext(db.PortalLoginSitekey),
 			CanRegister:          s.canRegister.Load(),
+			CanSSO:               s.canSSO(),
 		},
 		View: loginTemplate,
 	}, nil
@@ -89,6 +92,7 @@ func (s *Server) postLogin(w http.ResponseWriter, r *http.Request) {
 		},
 		CaptchaRenderContext: s.CreateCaptchaRenderContext(db.PortalLoginSitekey),
 		CanRegister:          s.canRegister.Load(),
+		CanSSO:               s.canSSO(),
 	}
 
 	captchaSolution := r.FormValue(common.ParamPortalSolution)
@@ -14,6 +14,7 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/monitoring"
 	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 )
@@ -108,6 +109,31 @@ func TestGetLoginMaintenance(t *testing.T) {
 	}
 }
 
+func TestStandbyReadOnly(t *testing.T) {
+	t.Parallel()
+
+	standby := &Server{Standby: true, Metrics: monitoring.NewStub()}
+	handler := standby.standbyReadOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/"+common.LoginEndpoint, nil)
+		req.Header.Set(common.HeaderHtmxRequest, "true")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		expected := http.StatusServiceUnavailable
+		if method == http.MethodGet {
+			expected = http.StatusOK
+		}
+
+		if w.Code != expected {
+			t.Errorf("Unexpected status code for %s: got %v want %v", method, w.Code, expected)
+		}
+	}
+}
+
 func TestPostLogin(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
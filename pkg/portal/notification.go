@@ -6,16 +6,27 @@ import (
 	"net/http"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 )
 
+func notificationHTML(n *dbgen.SystemNotification) string {
+	if n.IsMarkdown {
+		return common.RenderMarkdown(n.Message)
+	}
+
+	return n.Message
+}
+
 func (s *Server) createSystemNotificationContext(ctx context.Context, sess *session.Session) systemNotificationContext {
 	renderCtx := systemNotificationContext{}
 
 	if notificationID, ok := sess.Get(ctx, session.KeyNotificationID).(int32); ok {
-		if notification, err := s.Store.Impl().RetrieveSystemNotification(ctx, notificationID); err == nil {
-			renderCtx.Notification = notification.Message
+		// cancelled notification can still be referenced from the session
+		if notification, err := s.Store.Impl().RetrieveSystemNotification(ctx, notificationID); (err == nil) && notification.IsActive.Bool {
+			renderCtx.Notification = notificationHTML(notification)
 			renderCtx.NotificationID = s.IDHasher.Encrypt(int(notification.ID))
+			renderCtx.NotificationSeverity = string(notification.Severity)
 		}
 	}
 
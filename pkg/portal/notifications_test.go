@@ -1,8 +1,11 @@
 package portal
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -395,3 +398,73 @@ func TestRequireSubscription(t *testing.T) {
 		t.Errorf("Unexpected number of sent emails: %v", sender.Count)
 	}
 }
+
+func TestSharedNotificationPayload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Parallel()
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	tnow := time.Now().UTC()
+	data := map[string]string{"campaign": t.Name()}
+	ids := make(map[int32]string)
+
+	for i := 0; i < 2; i++ {
+		user, _, err := db_tests.CreateNewAccountForTest(ctx, store, fmt.Sprintf("%s_%d", t.Name(), i), testPlan)
+		if err != nil {
+			t.Fatalf("failed to create new account: %v", err)
+		}
+
+		notif, err := store.Impl().CreateUserNotification(ctx, &common.ScheduledNotification{
+			ReferenceID:  "referenceID",
+			UserID:       user.ID,
+			Subject:      "subject",
+			Data:         data,
+			DateTime:     tnow.Add(-10 * time.Minute),
+			TemplateHash: email.TwoFactorEmailTemplate.Hash(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !notif.PayloadHash.Valid || (len(notif.Payload) > 0) {
+			t.Errorf("Notification payload is not shared: %+v", notif)
+		}
+
+		ids[notif.ID] = notif.PayloadHash.String
+	}
+
+	expected, _ := json.Marshal(data)
+	hashes := make(map[string]struct{})
+
+	pending, err := store.Impl().RetrievePendingUserNotifications(ctx, tnow.Add(-1*time.Hour), 1000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := 0
+	for _, p := range pending {
+		hash, ok := ids[p.UserNotification.ID]
+		if !ok {
+			continue
+		}
+		found++
+		hashes[hash] = struct{}{}
+
+		var actual bytes.Buffer
+		if err := json.Compact(&actual, p.UserNotification.Payload); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(actual.Bytes(), expected) {
+			t.Errorf("Unexpected payload: %s", p.UserNotification.Payload)
+		}
+	}
+
+	if (found != len(ids)) || (len(hashes) != 1) {
+		t.Errorf("Unexpected pending notifications: found %v, hashes %v", found, len(hashes))
+	}
+}
@@ -3,6 +3,7 @@ package portal
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"slices"
@@ -10,9 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/api"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/pagination"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 )
 
@@ -21,6 +24,10 @@ var (
 	errNoOrgs         = errors.New("user has no organizations")
 	stubUserOrg       = &userOrg{ID: "-1"}
 	propertiesPerPage = 30
+	membersPerPage    = 20
+	orderDescending   = "desc"
+	// API versions shown in "What's new" panel
+	changelogPerPage = 3
 )
 
 const (
@@ -28,6 +35,7 @@ const (
 	orgPropertiesTemplate         = "portal/properties.html"
 	orgSettingsTemplate           = "portal/org-settings.html"
 	orgMembersTemplate            = "portal/org-members.html"
+	orgMembersImportTemplate      = "portal/org-members-import.html"
 	orgAuditLogsTemplate          = "portal/org-auditlogs.html"
 	orgWizardTemplate             = "org-wizard/wizard.html"
 	portalTemplate                = "portal/portal.html"
@@ -38,9 +46,17 @@ const (
 type orgSettingsRenderContext struct {
 	AlertRenderContext
 	CsrfRenderContext
-	CurrentOrg *userOrg
-	NameError  string
-	CanEdit    bool
+	CurrentOrg          *userOrg
+	NameError           string
+	CanEdit             bool
+	BillingContacts     []*orgBillingContact
+	BillingNotifyOwner  bool
+	BillingContactError string
+	IPAllowlist         string
+	IPAllowlistError    string
+	ClientIP            string
+	EmailDomains        []*orgEmailDomain
+	EmailDomainError    string
 }
 
 type orgAuditLogsRenderContext struct {
@@ -57,18 +73,50 @@ type orgUser struct {
 	CreatedAt string
 }
 
+type orgMemberImportResult struct {
+	Line    int    `json:"line"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type orgMembersImportRenderContext struct {
+	TaskID    string
+	Finished  bool
+	Invited   int
+	Removed   int
+	Unchanged int
+	Failed    int
+	// rows that failed or have extra information
+	Details []*orgMemberImportResult
+}
+
+type orgMembersFilter struct {
+	Role   string
+	Search string
+	Sort   string
+	Order  string
+}
+
 type orgMemberRenderContext struct {
 	AlertRenderContext
 	CsrfRenderContext
+	PaginationRenderContext
+	Filter     orgMembersFilter
 	CurrentOrg *userOrg
 	Members    []*orgUser
 	CanEdit    bool
+	Import     *orgMembersImportRenderContext
 }
 
 type userOrg struct {
-	Name  string
-	ID    string
-	Level string
+	Name    string
+	ID      string
+	Level   string
+	Sandbox bool
+	// below fields are only set from the org summary on the landing page
+	Properties int64
+	Requests   string
+	HasTraffic bool
 }
 
 type orgDashboardRenderContext struct {
@@ -78,7 +126,9 @@ type orgDashboardRenderContext struct {
 	Orgs       []*userOrg
 	CurrentOrg *userOrg
 	// shortened from CurrentOrgProperties for simplicity
-	Properties []*userProperty
+	Properties   []*userProperty
+	Changelog    []*api.ChangelogEntry
+	ChangelogURL string
 }
 
 type orgWizardRenderContext struct {
@@ -106,10 +156,31 @@ func usersToOrgUsers(users []*dbgen.GetOrganizationUsersRow, hasher common.Ident
 	return result
 }
 
+func parseOrgMembersFilter(r *http.Request) orgMembersFilter {
+	values := r.URL.Query()
+
+	return orgMembersFilter{
+		Role:   strings.TrimSpace(values.Get(common.ParamRole)),
+		Search: strings.TrimSpace(values.Get(common.ParamSearch)),
+		Sort:   strings.TrimSpace(values.Get(common.ParamSort)),
+		Order:  strings.TrimSpace(values.Get(common.ParamOrder)),
+	}
+}
+
+func (f *orgMembersFilter) query() *db.OrgUsersQuery {
+	return &db.OrgUsersQuery{
+		Level:      dbgen.AccessLevel(f.Role),
+		Email:      f.Search,
+		SortBy:     f.Sort,
+		Descending: f.Order == orderDescending,
+	}
+}
+
 func orgToUserOrg(org *dbgen.Organization, userID int32, hasher common.IdentifierHasher) *userOrg {
 	uo := &userOrg{
-		Name: org.Name,
-		ID:   hasher.Encrypt(int(org.ID)),
+		Name:    org.Name,
+		ID:      hasher.Encrypt(int(org.ID)),
+		Sandbox: org.Sandbox,
 	}
 
 	if org.UserID.Int32 == userID {
@@ -123,9 +194,39 @@ func orgsToUserOrgs(orgs []*dbgen.GetUserOrganizationsRow, hasher common.Identif
 	result := make([]*userOrg, 0, len(orgs))
 	for _, org := range orgs {
 		result = append(result, &userOrg{
-			Name:  org.Organization.Name,
-			ID:    hasher.Encrypt(int(org.Organization.ID)),
-			Level: string(org.Level),
+			Name:    org.Organization.Name,
+			ID:      hasher.Encrypt(int(org.Organization.ID)),
+			Level:   string(org.Level),
+			Sandbox: org.Organization.Sandbox,
+		})
+	}
+	return result
+}
+
+func formatTrafficCount(count uint64) string {
+	switch {
+	case count >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB", float64(count)/1_000_000_000)
+	case count >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(count)/1_000_000)
+	case count >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(count)/1_000)
+	default:
+		return strconv.FormatUint(count, 10)
+	}
+}
+
+func orgSummariesToUserOrgs(summary *db.UserOrgsSummary, hasher common.IdentifierHasher) []*userOrg {
+	result := make([]*userOrg, 0, len(summary.Orgs))
+	for _, org := range summary.Orgs {
+		result = append(result, &userOrg{
+			Name:       org.Name,
+			ID:         hasher.Encrypt(int(org.ID)),
+			Level:      string(org.Level),
+			Sandbox:    org.Sandbox,
+			Properties: org.Properties,
+			Requests:   formatTrafficCount(org.Requests),
+			HasTraffic: !summary.TrafficUpdatedAt.IsZero(),
 		})
 	}
 	return result
@@ -160,11 +261,13 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 		return nil, err
 	}
 
-	orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID)
+	// landing page is rendered from the materialized summary instead of querying orgs and their properties count
+	summary, err := s.Store.Impl().RetrieveUserOrgsSummary(ctx, user.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	orgs := summary.Orgs
 	if len(orgs) == 0 {
 		slog.WarnContext(ctx, "User has no organizations")
 		return nil, errNoOrgs
@@ -172,12 +275,18 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 
 	if !s.checkUserOrgsLimit(ctx, user, len(orgs)) {
 		slog.WarnContext(ctx, "Organizations limit reached", "count", len(orgs))
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource: db.LimitResourceOrgs,
+			Code:     common.StatusOrgLimitError,
+			UserID:   user.ID,
+			Err:      errLimitedFeature,
+		})
 		return nil, errLimitedFeature
 	}
 
 	idx := -1
 	if orgID != -1 {
-		idx = slices.IndexFunc(orgs, func(o *dbgen.GetUserOrganizationsRow) bool { return o.Organization.ID == orgID })
+		idx = slices.IndexFunc(orgs, func(o *db.OrgSummary) bool { return o.ID == orgID })
 		if idx == -1 {
 			slog.WarnContext(ctx, "Org is not found in user orgs", "orgID", orgID, "userID", user.ID)
 			return nil, errInvalidPathArg
@@ -187,9 +296,16 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 	renderCtx := &orgDashboardRenderContext{
 		CsrfRenderContext:         s.CreateCsrfContext(user),
 		systemNotificationContext: s.createSystemNotificationContext(ctx, sess),
-		Orgs:                      orgsToUserOrgs(orgs, s.IDHasher),
+		Orgs:                      orgSummariesToUserOrgs(summary, s.IDHasher),
 		Properties:                []*userProperty{},
 		CurrentOrg:                stubUserOrg,
+		ChangelogURL:              s.APIURL + "/" + common.ChangelogEndpoint,
+	}
+
+	if changelog := api.Changelog(); len(changelog) > changelogPerPage {
+		renderCtx.Changelog = changelog[:changelogPerPage]
+	} else {
+		renderCtx.Changelog = changelog
 	}
 
 	if idx >= 0 {
@@ -200,9 +316,9 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 		earliestDate := time.Now()
 
 		for i, o := range orgs {
-			if (o.Level == dbgen.AccessLevelOwner) && o.Organization.CreatedAt.Time.Before(earliestDate) {
+			if (o.Level == dbgen.AccessLevelOwner) && o.CreatedAt.Before(earliestDate) {
 				earliestIdx = i
-				earliestDate = o.Organization.CreatedAt.Time
+				earliestDate = o.CreatedAt
 			}
 		}
 
@@ -213,7 +329,9 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 
 	if (0 <= idx) && (idx < len(orgs)) {
 		if orgs[idx].Level != dbgen.AccessLevelInvited {
-			if properties, hasMore, err := s.Store.Impl().RetrieveOrgProperties(ctx, &orgs[idx].Organization, 0 /*offset*/, propertiesPerPage); err == nil {
+			// properties are only looked up by the org ID
+			org := &dbgen.Organization{ID: orgs[idx].ID}
+			if properties, hasMore, err := s.Store.Impl().RetrieveOrgProperties(ctx, org, 0 /*offset*/, propertiesPerPage); err == nil {
 				renderCtx.Properties = propertiesToUserProperties(ctx, properties, s.IDHasher)
 
 				renderCtx.PaginationRenderContext = PaginationRenderContext{
@@ -225,9 +343,7 @@ func (s *Server) createOrgDashboardContext(ctx context.Context, orgID int32, ses
 				}
 
 				if hasMore {
-					if count, err := s.Store.Impl().RetrieveOrgPropertiesCount(ctx, orgs[idx].Organization.ID); err == nil {
-						renderCtx.Count = int(count)
-					}
+					renderCtx.Count = max(int(orgs[idx].Properties), len(properties))
 				}
 			}
 		}
@@ -266,6 +382,12 @@ func (s *Server) getPortal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// default org (without org in path) is not checked by private middleware
+	if (orgID == -1) && !s.isDefaultOrgIPAllowed(ctx, renderCtx.CurrentOrg) {
+		common.Redirect(s.PartsURL(common.OrgEndpoint, renderCtx.CurrentOrg.ID, common.AllowlistEndpoint), http.StatusForbidden, w, r)
+		return
+	}
+
 	s.render(w, r, portalTemplate, renderCtx)
 }
 
@@ -335,16 +457,7 @@ func (s *Server) getOrgProperties(w http.ResponseWriter, r *http.Request) (*View
 		return nil, err
 	}
 
-	pageParam := r.URL.Query().Get(common.ParamPage)
-	page := 0
-	if len(pageParam) > 0 {
-		if page, err = strconv.Atoi(pageParam); err != nil {
-			slog.ErrorContext(ctx, "Failed to convert page parameter", "page", pageParam, common.ErrAttr(err))
-			page = 0
-		}
-	}
-
-	renderCtx, err := s.createOrgPropertiesContext(ctx, org, user, page)
+	renderCtx, err := s.createOrgPropertiesContext(ctx, org, user, pagination.ParsePage(ctx, r))
 	if err != nil {
 		return nil, err
 	}
@@ -375,7 +488,16 @@ func (s *Server) getOrgMembers(w http.ResponseWriter, r *http.Request) (*ViewMod
 		return &ViewModel{Model: renderCtx, View: orgMembersTemplate}, nil
 	}
 
-	members, err := s.Store.Impl().RetrieveOrganizationUsers(ctx, org.ID)
+	page := max(pagination.ParsePage(ctx, r), 0)
+	renderCtx.Filter = parseOrgMembersFilter(r)
+	query := renderCtx.Filter.query()
+
+	members, hasMore, err := s.Store.Impl().RetrieveOrganizationUsersPage(ctx, org.ID, query, page*membersPerPage, membersPerPage)
+	if err == db.ErrInvalidInput {
+		renderCtx.Filter = orgMembersFilter{}
+		query = renderCtx.Filter.query()
+		members, hasMore, err = s.Store.Impl().RetrieveOrganizationUsersPage(ctx, org.ID, query, page*membersPerPage, membersPerPage)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to retrieve org users", common.ErrAttr(err))
 		return nil, err
@@ -383,6 +505,21 @@ func (s *Server) getOrgMembers(w http.ResponseWriter, r *http.Request) (*ViewMod
 
 	renderCtx.Members = usersToOrgUsers(members, s.IDHasher)
 
+	from := 1 + page*membersPerPage
+	renderCtx.PaginationRenderContext = PaginationRenderContext{
+		From:    from,
+		To:      from + len(members) - 1,
+		Count:   len(members),
+		Page:    page,
+		PerPage: membersPerPage,
+	}
+
+	if (page > 0) || hasMore {
+		if count, err := s.Store.Impl().RetrieveOrganizationUsersCount(ctx, org.ID, query); err == nil {
+			renderCtx.Count = int(count)
+		}
+	}
+
 	return &ViewModel{
 		Model:      renderCtx,
 		View:       orgMembersTemplate,
@@ -402,11 +539,7 @@ func (s *Server) getOrgSettings(w http.ResponseWriter, r *http.Request) (*ViewMo
 		return nil, err
 	}
 
-	renderCtx := &orgSettingsRenderContext{
-		CsrfRenderContext: s.CreateCsrfContext(user),
-		CurrentOrg:        orgToUserOrg(org, user.ID, s.IDHasher),
-		CanEdit:           org.UserID.Int32 == user.ID,
-	}
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
 
 	return &ViewModel{
 		Model:      renderCtx,
@@ -447,7 +580,7 @@ func (s *Server) getOrgAuditLogs(w http.ResponseWriter, r *http.Request) (*ViewM
 		return nil, err
 	}
 
-	renderCtx, auditEvent, err := s.createOrgAuditLogsContext(ctx, org, user)
+	renderCtx, auditEvent, err := s.createOrgAuditLogsContext(ctx, org, user, auditLogsSearchFromParam(r))
 	if err != nil {
 		return nil, err
 	}
@@ -476,11 +609,7 @@ func (s *Server) putOrg(w http.ResponseWriter, r *http.Request) (*ViewModel, err
 		return nil, err
 	}
 
-	renderCtx := &orgSettingsRenderContext{
-		CsrfRenderContext: s.CreateCsrfContext(user),
-		CurrentOrg:        orgToUserOrg(org, user.ID, s.IDHasher),
-		CanEdit:           org.UserID.Int32 == user.ID,
-	}
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
 
 	if !renderCtx.CanEdit {
 		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
@@ -0,0 +1,357 @@
+package portal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	orgIPBlockedTemplate     = "org-blocked/org-blocked.html"
+	maxOrgIPAllowlistEntries = 50
+	// recovery tokens are stored in DB cache so that emailed link works on any node
+	ipAllowlistRecoveryCacheKeyPrefix = "ip_allowlist_recovery/"
+	ipAllowlistRecoveryTTL            = 1 * time.Hour
+	ipAllowlistRecoveryTokenLen       = 32
+)
+
+type orgIPBlockedRenderContext struct {
+	AlertRenderContext
+	CsrfRenderContext
+	CurrentOrg *userOrg
+	ClientIP   string
+	CanRecover bool
+	// set when the owner opens emailed recovery link and has to confirm the reset
+	RecoveryToken string
+}
+
+func requestClientIP(ctx context.Context) netip.Addr {
+	addr, _ := ctx.Value(common.RateLimitKeyContextKey).(netip.Addr)
+	return addr
+}
+
+func splitListEntries(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return (r == ',') || (r == ';') || (r == '\n') || (r == '\r') || (r == ' ') || (r == '\t')
+	})
+}
+
+// parseIPList returns normalized IP ranges or an error message for the user
+func parseIPList(value string, maxEntries int) ([]string, string) {
+	entries := splitListEntries(value)
+
+	result := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		prefixes, err := common.ParseIPAllowlist([]string{entry})
+		if (err != nil) || (len(prefixes) != 1) {
+			return nil, "Invalid IP address or range: " + entry
+		}
+
+		if normalized := prefixes[0].String(); !slices.Contains(result, normalized) {
+			result = append(result, normalized)
+		}
+	}
+
+	if len(result) > maxEntries {
+		return nil, "Too many IP ranges (maximum is " + strconv.Itoa(maxEntries) + ")."
+	}
+
+	return result, ""
+}
+
+// parseOrgIPAllowlist returns normalized allowlist entries or an error message for the user
+func parseOrgIPAllowlist(value string) ([]string, string) {
+	return parseIPList(value, maxOrgIPAllowlistEntries)
+}
+
+// isOrgIPAllowed checks the allowlist of the org from the request path (routes without org are not restricted)
+func (s *Server) isOrgIPAllowed(r *http.Request) bool {
+	if len(r.PathValue(common.ParamOrg)) == 0 {
+		return true
+	}
+
+	ctx := r.Context()
+
+	orgID, _, err := common.IntPathArg(r, common.ParamOrg, s.IDHasher)
+	if err != nil {
+		// handler will report invalid path argument
+		return true
+	}
+
+	addr := requestClientIP(ctx)
+	allowed, err := s.Store.Impl().IsOrgIPAllowed(ctx, orgID, addr)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check org IP allowlist", "orgID", orgID, common.ErrAttr(err))
+		return false
+	}
+
+	if !allowed {
+		slog.WarnContext(ctx, "Client IP is not in org allowlist", "orgID", orgID, "ip", addr.String())
+	}
+
+	return allowed
+}
+
+func (s *Server) isDefaultOrgIPAllowed(ctx context.Context, org *userOrg) bool {
+	orgID, err := s.IDHasher.Decrypt(org.ID)
+	if err != nil {
+		// stub org
+		return true
+	}
+
+	allowed, err := s.Store.Impl().IsOrgIPAllowed(ctx, int32(orgID), requestClientIP(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check org IP allowlist", "orgID", orgID, common.ErrAttr(err))
+		return false
+	}
+
+	return allowed
+}
+
+func (s *Server) orgIPAllowlistText(ctx context.Context, org *dbgen.Organization) (string, []string) {
+	allowlist, err := s.Store.Impl().RetrieveOrgIPAllowlist(ctx, org.ID)
+	if err != nil {
+		if err != db.ErrNegativeCacheHit {
+			slog.ErrorContext(ctx, "Failed to retrieve org IP allowlist", "orgID", org.ID, common.ErrAttr(err))
+		}
+		return "", []string{}
+	}
+
+	return strings.Join(allowlist.Cidrs, "\n"), allowlist.Cidrs
+}
+
+func (s *Server) putOrgIPAllowlist(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+
+	if !renderCtx.CanEdit {
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	value := r.FormValue(common.ParamAllowlist)
+	cidrs, errorMessage := parseOrgIPAllowlist(value)
+	if len(errorMessage) > 0 {
+		renderCtx.IPAllowlist = value
+		renderCtx.IPAllowlistError = errorMessage
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	if len(cidrs) > 0 {
+		// this is a protection from locking yourself out right away
+		prefixes, _ := common.ParseIPAllowlist(cidrs)
+		if !common.IsIPAllowed(prefixes, requestClientIP(ctx)) {
+			renderCtx.IPAllowlist = value
+			renderCtx.IPAllowlistError = "Your current IP address (" + renderCtx.ClientIP + ") must be in the allowlist."
+			return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+		}
+	}
+
+	_, oldCIDRs := s.orgIPAllowlistText(ctx, org)
+	if slices.Equal(oldCIDRs, cidrs) {
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	auditEvent, err := s.Store.Impl().UpdateOrgIPAllowlist(ctx, user, org, oldCIDRs, cidrs, false /*recovery*/)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to update IP allowlist. Please try again."
+	} else {
+		renderCtx.IPAllowlist = strings.Join(cidrs, "\n")
+		renderCtx.SuccessMessage = "IP allowlist was updated."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) createOrgIPBlockedContext(org *dbgen.Organization, user *dbgen.User, addr netip.Addr) *orgIPBlockedRenderContext {
+	renderCtx := &orgIPBlockedRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		CurrentOrg:        orgToUserOrg(org, user.ID, s.IDHasher),
+		CanRecover:        org.UserID.Valid && (org.UserID.Int32 == user.ID),
+		ClientIP:          "unknown",
+	}
+
+	if addr.IsValid() {
+		renderCtx.ClientIP = addr.String()
+	}
+
+	return renderCtx
+}
+
+func (s *Server) getOrgIPBlocked(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.isOrgIPAllowed(r) {
+		common.Redirect(s.PartsURL(common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID))), http.StatusOK, w, r)
+		return &ViewModel{}, nil
+	}
+
+	return &ViewModel{
+		Model: s.createOrgIPBlockedContext(org, user, requestClientIP(ctx)),
+		View:  orgIPBlockedTemplate,
+	}, nil
+}
+
+func newIPAllowlistRecoveryToken() (string, error) {
+	data := make([]byte, ipAllowlistRecoveryTokenLen/2)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// postOrgIPAllowlistRecovery is a "break-glass" for the org owner, locked out by the IP allowlist
+func (s *Server) postOrgIPAllowlistRecovery(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := requestClientIP(ctx)
+	renderCtx := s.createOrgIPBlockedContext(org, user, addr)
+
+	if !renderCtx.CanRecover {
+		return nil, db.ErrPermissions
+	}
+
+	token, err := newIPAllowlistRecoveryToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate IP allowlist recovery token", common.ErrAttr(err))
+		return nil, err
+	}
+
+	if err := s.Store.Impl().StoreInCache(ctx, ipAllowlistRecoveryCacheKeyPrefix+token, []byte(strconv.Itoa(int(org.ID))), ipAllowlistRecoveryTTL); err != nil {
+		renderCtx.ErrorMessage = "Failed to create recovery link. Please try again."
+		return &ViewModel{Model: renderCtx, View: orgIPBlockedTemplate}, nil
+	}
+
+	recoveryPath := s.PartsURL(common.OrgEndpoint, renderCtx.CurrentOrg.ID, common.AllowlistEndpoint, common.RecoverEndpoint, token)
+	if err := s.Mailer.SendIPAllowlistRecovery(ctx, user.Email, org.Name, renderCtx.ClientIP, recoveryPath); err != nil {
+		renderCtx.ErrorMessage = "Failed to send recovery email. Please try again."
+	} else {
+		slog.InfoContext(ctx, "Sent IP allowlist recovery link", "orgID", org.ID, "userID", user.ID, "ip", renderCtx.ClientIP)
+		renderCtx.SuccessMessage = "Recovery link was sent to your email."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgIPBlockedTemplate}, nil
+}
+
+// recoveryOrg returns the org from the request path if current user is its owner, together with recovery token from the path
+func (s *Server) recoveryOrg(w http.ResponseWriter, r *http.Request) (*dbgen.User, *dbgen.Organization, string, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if !org.UserID.Valid || (org.UserID.Int32 != user.ID) {
+		return nil, nil, "", db.ErrPermissions
+	}
+
+	token, err := common.StrPathArg(r, common.ParamToken)
+	if (err != nil) || (len(token) != ipAllowlistRecoveryTokenLen) {
+		slog.WarnContext(ctx, "Invalid IP allowlist recovery token", "length", len(token), common.ErrAttr(err))
+		return nil, nil, "", errInvalidPathArg
+	}
+
+	return user, org, token, nil
+}
+
+// getOrgIPAllowlistRecovery only asks for confirmation as emailed links can be opened by prefetchers and mail scanners
+func (s *Server) getOrgIPAllowlistRecovery(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	user, org, token, err := s.recoveryOrg(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx := s.createOrgIPBlockedContext(org, user, requestClientIP(r.Context()))
+	renderCtx.RecoveryToken = token
+
+	return &ViewModel{Model: renderCtx, View: orgIPBlockedTemplate}, nil
+}
+
+func (s *Server) postOrgIPAllowlistReset(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	user, org, token, err := s.recoveryOrg(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := r.Context()
+
+	// token is one-time
+	data, err := s.Store.Impl().TakeFromCache(ctx, ipAllowlistRecoveryCacheKeyPrefix+token)
+	if err != nil {
+		if err == db.ErrCacheMiss {
+			slog.WarnContext(ctx, "IP allowlist recovery token not found", "orgID", org.ID)
+			return nil, db.ErrPermissions
+		}
+		return nil, err
+	}
+
+	if tokenOrgID, err := strconv.Atoi(string(data)); (err != nil) || (int32(tokenOrgID) != org.ID) {
+		slog.WarnContext(ctx, "IP allowlist recovery token belongs to another org", "orgID", org.ID, "tokenOrgID", string(data))
+		return nil, db.ErrPermissions
+	}
+
+	_, oldCIDRs := s.orgIPAllowlistText(ctx, org)
+
+	auditEvent, err := s.Store.Impl().UpdateOrgIPAllowlist(ctx, user, org, oldCIDRs, []string{}, true /*recovery*/)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Disabled org IP allowlist using recovery link", "orgID", org.ID, "userID", user.ID)
+
+	common.Redirect(s.PartsURL(common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID))), http.StatusOK, w, r)
+
+	return &ViewModel{AuditEvent: auditEvent}, nil
+}
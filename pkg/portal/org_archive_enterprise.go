@@ -0,0 +1,69 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/orgarchive"
+)
+
+// postOrgExport downloads signed archive of the organization, that can be imported via API into another installation
+func (s *Server) postOrgExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get session user for org export", common.ErrAttr(err))
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	if org.UserID.Int32 != user.ID {
+		slog.WarnContext(ctx, "Only org owner can export the org", "userID", user.ID, "orgID", org.ID)
+		s.RedirectError(http.StatusForbidden, w, r)
+		return
+	}
+
+	key, err := orgarchive.NewKey(r.FormValue(common.ParamPassphrase))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to derive org archive key", common.ErrAttr(err))
+		s.RedirectError(http.StatusBadRequest, w, r)
+		return
+	}
+
+	archive, err := orgarchive.Export(ctx, s.Store.Impl(), user, org)
+	if err != nil {
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	envelope, err := key.Seal(archive)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign org archive", common.ErrAttr(err))
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	filename := fmt.Sprintf("private-captcha-org-%s.json", time.Now().Format(time.DateOnly))
+	w.Header().Set(common.HeaderContentType, common.ContentTypeJSON)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	if err := json.NewEncoder(w).Encode(envelope); err != nil {
+		slog.ErrorContext(ctx, "Failed to write org archive", common.ErrAttr(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "Exported org archive", "userID", user.ID, "orgID", org.ID)
+}
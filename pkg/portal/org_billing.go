@@ -0,0 +1,226 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/badoux/checkmail"
+)
+
+const (
+	maxOrgBillingContacts = 5
+	maxEmailLength        = 254
+)
+
+type orgBillingContact struct {
+	ID        string
+	Email     string
+	CreatedAt string
+}
+
+func billingContactsToOrgBillingContacts(contacts []*dbgen.BillingContact, hasher common.IdentifierHasher) []*orgBillingContact {
+	result := make([]*orgBillingContact, 0, len(contacts))
+
+	for _, c := range contacts {
+		result = append(result, &orgBillingContact{
+			ID:        hasher.Encrypt(int(c.ID)),
+			Email:     c.Email,
+			CreatedAt: c.CreatedAt.Time.Format("02 Jan 2006"),
+		})
+	}
+
+	return result
+}
+
+func validateBillingContactEmail(ctx context.Context, ownerEmail string, contacts []*dbgen.BillingContact, contactEmail string) string {
+	if len(contactEmail) == 0 {
+		return "Email address cannot be empty."
+	}
+
+	if len(contactEmail) > maxEmailLength {
+		return "Email address is too long."
+	}
+
+	if err := checkmail.ValidateFormat(contactEmail); err != nil {
+		slog.WarnContext(ctx, "Failed to validate billing contact email format", common.ErrAttr(err))
+		return "Email address is not valid."
+	}
+
+	if strings.EqualFold(contactEmail, ownerEmail) {
+		return "Organization owner cannot be added as a billing contact."
+	}
+
+	if slices.ContainsFunc(contacts, func(c *dbgen.BillingContact) bool { return strings.EqualFold(c.Email, contactEmail) }) {
+		return "This email is already a billing contact."
+	}
+
+	if len(contacts) >= maxOrgBillingContacts {
+		return "Billing contacts limit reached."
+	}
+
+	return ""
+}
+
+func (s *Server) createOrgSettingsContext(ctx context.Context, org *dbgen.Organization, user *dbgen.User) *orgSettingsRenderContext {
+	renderCtx := &orgSettingsRenderContext{
+		CsrfRenderContext:  s.CreateCsrfContext(user),
+		CurrentOrg:         orgToUserOrg(org, user.ID, s.IDHasher),
+		CanEdit:            org.UserID.Int32 == user.ID,
+		BillingNotifyOwner: true,
+	}
+
+	if renderCtx.CanEdit {
+		if contacts, err := s.Store.Impl().RetrieveOrgBillingContacts(ctx, org); err == nil {
+			renderCtx.BillingContacts = billingContactsToOrgBillingContacts(contacts, s.IDHasher)
+		}
+
+		if notifyOwner, err := s.Store.Impl().RetrieveOrgBillingNotifyOwner(ctx, org); err == nil {
+			renderCtx.BillingNotifyOwner = notifyOwner
+		}
+
+		renderCtx.IPAllowlist, _ = s.orgIPAllowlistText(ctx, org)
+		if addr := requestClientIP(ctx); addr.IsValid() {
+			renderCtx.ClientIP = addr.String()
+		}
+
+		if s.isEnterprise() {
+			if domains, err := s.Store.Impl().RetrieveOrgEmailDomains(ctx, org); err == nil {
+				renderCtx.EmailDomains = emailDomainsToOrgEmailDomains(domains, s.IDHasher)
+			}
+		}
+	}
+
+	return renderCtx
+}
+
+func (s *Server) postOrgBillingContact(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.UserID.Int32 != user.ID {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	contacts, err := s.Store.Impl().RetrieveOrgBillingContacts(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	contactEmail := strings.TrimSpace(r.FormValue(common.ParamEmail))
+	if errorMessage := validateBillingContactEmail(ctx, user.Email, contacts, contactEmail); len(errorMessage) > 0 {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.BillingContactError = errorMessage
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	_, auditEvent, err := s.Store.Impl().AddOrgBillingContact(ctx, user, org, contactEmail)
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to add billing contact. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Billing contact was added."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) deleteOrgBillingContact(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	contactID, value, err := common.IntPathArg(r, common.ParamID, s.IDHasher)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse billing contact path parameter", "value", value, common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	if org.UserID.Int32 != user.ID {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	auditEvent, err := s.Store.Impl().DeleteOrgBillingContact(ctx, user, org, contactID)
+	if err == db.ErrRecordNotFound {
+		return nil, errInvalidPathArg
+	}
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to remove billing contact. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Billing contact was removed."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) putOrgBillingSettings(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+
+	if !renderCtx.CanEdit {
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	var auditEvent *common.AuditLogEvent
+	_, notifyOwner := r.Form[common.ParamNotifyOwner]
+	if notifyOwner != renderCtx.BillingNotifyOwner {
+		if auditEvent, err = s.Store.Impl().UpdateOrgBillingNotifyOwner(ctx, user, org, renderCtx.BillingNotifyOwner, notifyOwner); err != nil {
+			renderCtx.ErrorMessage = "Failed to update settings. Please try again."
+		} else {
+			renderCtx.SuccessMessage = "Settings were updated"
+			renderCtx.BillingNotifyOwner = notifyOwner
+		}
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
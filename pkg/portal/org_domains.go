@@ -0,0 +1,135 @@
+package portal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxOrgEmailDomains       = 10
+	emailDomainTokenLen      = 32
+	emailDomainRecordPrefix  = "_private-captcha."
+	emailDomainRecordValue   = "private-captcha-verification="
+	emailDomainLookupTimeout = 5 * time.Second
+)
+
+type orgEmailDomain struct {
+	ID          string
+	Domain      string
+	RecordName  string
+	RecordValue string
+	Verified    bool
+	AutoJoin    bool
+	CreatedAt   string
+}
+
+func emailDomainsToOrgEmailDomains(domains []*dbgen.OrgEmailDomain, hasher common.IdentifierHasher) []*orgEmailDomain {
+	result := make([]*orgEmailDomain, 0, len(domains))
+
+	for _, d := range domains {
+		result = append(result, &orgEmailDomain{
+			ID:          hasher.Encrypt(int(d.ID)),
+			Domain:      d.Domain,
+			RecordName:  emailDomainRecordPrefix + d.Domain,
+			RecordValue: emailDomainRecordValue + d.Token,
+			Verified:    d.VerifiedAt.Valid,
+			AutoJoin:    d.AutoJoin,
+			CreatedAt:   d.CreatedAt.Time.Format("02 Jan 2006"),
+		})
+	}
+
+	return result
+}
+
+func newEmailDomainToken() (string, error) {
+	data := make([]byte, emailDomainTokenLen/2)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// emailDomain returns normalized domain part of the email address
+func emailDomain(email string) string {
+	i := strings.LastIndexByte(email, '@')
+	if i == -1 {
+		return ""
+	}
+
+	return normalizeEmailDomain(email[i+1:])
+}
+
+func normalizeEmailDomain(domain string) string {
+	domain = strings.TrimSpace(domain)
+	domain = strings.TrimPrefix(domain, "@")
+	domain = strings.TrimSuffix(domain, ".")
+	return strings.ToLower(domain)
+}
+
+func validateOrgEmailDomain(ctx context.Context, domains []*dbgen.OrgEmailDomain, domain string) string {
+	if len(domain) == 0 {
+		return "Domain cannot be empty."
+	}
+
+	if len(domain) > maxEmailLength {
+		return "Domain is too long."
+	}
+
+	if !strings.Contains(domain, ".") || common.IsLocalhost(domain) || common.IsIPAddress(domain) {
+		return "Domain is not valid."
+	}
+
+	if _, err := idna.Lookup.ToASCII(domain); err != nil {
+		slog.WarnContext(ctx, "Failed to convert email domain to ASCII", "domain", domain, common.ErrAttr(err))
+		return "Domain is not valid."
+	}
+
+	if slices.ContainsFunc(domains, func(d *dbgen.OrgEmailDomain) bool { return d.Domain == domain }) {
+		return "This domain was already added."
+	}
+
+	if len(domains) >= maxOrgEmailDomains {
+		return "Email domains limit reached."
+	}
+
+	return ""
+}
+
+func hasEmailDomainRecord(records []string, token string) bool {
+	expected := emailDomainRecordValue + token
+	return slices.ContainsFunc(records, func(r string) bool { return strings.TrimSpace(r) == expected })
+}
+
+func lookupEmailDomainRecord(ctx context.Context, domain *dbgen.OrgEmailDomain) (bool, error) {
+	rctx, cancel := context.WithTimeout(ctx, emailDomainLookupTimeout)
+	defer cancel()
+
+	records, err := (&net.Resolver{}).LookupTXT(rctx, emailDomainRecordPrefix+domain.Domain)
+	if err != nil {
+		return false, fmt.Errorf("failed to lookup TXT records: %w", err)
+	}
+
+	return hasEmailDomainRecord(records, domain.Token), nil
+}
+
+func findOrgEmailDomain(domains []*dbgen.OrgEmailDomain, domainID int32) (*dbgen.OrgEmailDomain, bool) {
+	for _, d := range domains {
+		if d.ID == domainID {
+			return d, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,279 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func (s *Server) orgEmailDomainFromPath(ctx context.Context, r *http.Request, org *dbgen.Organization) (*dbgen.OrgEmailDomain, error) {
+	domainID, value, err := common.IntPathArg(r, common.ParamID, s.IDHasher)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse email domain path parameter", "value", value, common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	domains, err := s.Store.Impl().RetrieveOrgEmailDomains(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, ok := findOrgEmailDomain(domains, domainID)
+	if !ok {
+		slog.WarnContext(ctx, "Email domain not found", "orgID", org.ID, "domainID", domainID)
+		return nil, errInvalidPathArg
+	}
+
+	return domain, nil
+}
+
+// isEmailDomainVerifiedByOtherOrg checks if other organization already owns the domain
+func (s *Server) isEmailDomainVerifiedByOtherOrg(ctx context.Context, org *dbgen.Organization, domain string) bool {
+	_, verifiedOrg, err := s.Store.Impl().RetrieveVerifiedOrgEmailDomain(ctx, domain)
+	return (err == nil) && (verifiedOrg.ID != org.ID)
+}
+
+func (s *Server) postOrgEmailDomain(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.UserID.Int32 != user.ID {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	domains, err := s.Store.Impl().RetrieveOrgEmailDomains(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := normalizeEmailDomain(r.FormValue(common.ParamDomain))
+	errorMessage := validateOrgEmailDomain(ctx, domains, domain)
+	if (len(errorMessage) == 0) && s.isEmailDomainVerifiedByOtherOrg(ctx, org, domain) {
+		errorMessage = "This domain is already verified by another organization."
+	}
+
+	if len(errorMessage) > 0 {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.EmailDomainError = errorMessage
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	token, err := newEmailDomainToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate email domain token", common.ErrAttr(err))
+		return nil, err
+	}
+
+	_, auditEvent, err := s.Store.Impl().AddOrgEmailDomain(ctx, user, org, domain, token)
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to add email domain. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Email domain was added. Create the DNS record to verify it."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) postOrgEmailDomainVerify(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.UserID.Int32 != user.ID {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	domain, err := s.orgEmailDomainFromPath(ctx, r, org)
+	if err != nil {
+		return nil, err
+	}
+
+	if domain.VerifiedAt.Valid {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.SuccessMessage = "Email domain is already verified."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	if s.isEmailDomainVerifiedByOtherOrg(ctx, org, domain.Domain) {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.EmailDomainError = "This domain is already verified by another organization."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	found, err := lookupEmailDomainRecord(ctx, domain)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to lookup email domain record", "domain", domain.Domain, common.ErrAttr(err))
+	}
+
+	if !found {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.EmailDomainError = "Verification DNS record was not found. DNS changes can take some time to propagate."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	_, auditEvent, err := s.Store.Impl().VerifyOrgEmailDomain(ctx, user, org, domain)
+	if err == db.ErrRecordNotFound {
+		return nil, errInvalidPathArg
+	}
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to verify email domain. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Email domain was verified."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) putOrgEmailDomain(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.UserID.Int32 != user.ID {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	domain, err := s.orgEmailDomainFromPath(ctx, r, org)
+	if err != nil {
+		return nil, err
+	}
+
+	_, autoJoin := r.Form[common.ParamAutoJoin]
+	if autoJoin == domain.AutoJoin {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	_, auditEvent, err := s.Store.Impl().UpdateOrgEmailDomainAutoJoin(ctx, user, org, domain, autoJoin)
+	if err == db.ErrRecordNotFound {
+		return nil, errInvalidPathArg
+	}
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to update email domain. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Email domain was updated."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) deleteOrgEmailDomain(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	domainID, value, err := common.IntPathArg(r, common.ParamID, s.IDHasher)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse email domain path parameter", "value", value, common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	if org.UserID.Int32 != user.ID {
+		renderCtx := s.createOrgSettingsContext(ctx, org, user)
+		renderCtx.ErrorMessage = "Insufficient permissions to update settings."
+		return &ViewModel{Model: renderCtx, View: orgSettingsTemplate}, nil
+	}
+
+	auditEvent, err := s.Store.Impl().DeleteOrgEmailDomain(ctx, user, org, domainID)
+	if err == db.ErrRecordNotFound {
+		return nil, errInvalidPathArg
+	}
+
+	renderCtx := s.createOrgSettingsContext(ctx, org, user)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to remove email domain. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Email domain was removed."
+	}
+
+	return &ViewModel{Model: renderCtx, View: orgSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+// joinEmailDomainOrg adds newly registered user to the organization that verified domain of their email
+func (s *Server) joinEmailDomainOrg(ctx context.Context, user *dbgen.User) {
+	domain := emailDomain(user.Email)
+	if len(domain) == 0 {
+		return
+	}
+
+	verified, org, err := s.Store.Impl().RetrieveVerifiedOrgEmailDomain(ctx, domain)
+	if err != nil {
+		if err != db.ErrRecordNotFound {
+			slog.ErrorContext(ctx, "Failed to retrieve verified email domain", "domain", domain, common.ErrAttr(err))
+		}
+		return
+	}
+
+	// members that join by email domain take seats just like invited ones
+	if !s.canJoinOrg(ctx, org) {
+		return
+	}
+
+	auditEvent, err := s.Store.Impl().AddUserToOrgByEmailDomain(ctx, user, org, verified)
+	if err != nil {
+		return
+	}
+
+	s.syncOrgSeats(ctx, org)
+
+	s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
+}
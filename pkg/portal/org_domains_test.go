@@ -0,0 +1,67 @@
+package portal
+
+import (
+	"context"
+	"testing"
+
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestEmailDomain(t *testing.T) {
+	testCases := []struct {
+		email    string
+		expected string
+	}{
+		{"user@example.com", "example.com"},
+		{"User@Example.COM", "example.com"},
+		{"\"a@b\"@example.com", "example.com"},
+		{"user@example.com.", "example.com"},
+		{"example.com", ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := emailDomain(tc.email); actual != tc.expected {
+			t.Errorf("Unexpected domain of %v: %v (expected %v)", tc.email, actual, tc.expected)
+		}
+	}
+}
+
+func TestValidateOrgEmailDomain(t *testing.T) {
+	ctx := context.TODO()
+	domains := []*dbgen.OrgEmailDomain{{Domain: "example.com"}}
+
+	testCases := []struct {
+		domain string
+		valid  bool
+	}{
+		{"", false},
+		{"localhost", false},
+		{"127.0.0.1", false},
+		{"example", false},
+		{"example.com", false},
+		{"example.org", true},
+		{"mail.example.com", true},
+	}
+
+	for _, tc := range testCases {
+		if message := validateOrgEmailDomain(ctx, domains, tc.domain); (len(message) == 0) != tc.valid {
+			t.Errorf("Unexpected validation of %v: '%v'", tc.domain, message)
+		}
+	}
+}
+
+func TestHasEmailDomainRecord(t *testing.T) {
+	const token = "abcdef"
+
+	if !hasEmailDomainRecord([]string{"v=spf1 -all", " private-captcha-verification=abcdef "}, token) {
+		t.Error("Expected to find verification record")
+	}
+
+	if hasEmailDomainRecord([]string{"private-captcha-verification=abcdeg"}, token) {
+		t.Error("Unexpected verification record match")
+	}
+
+	if hasEmailDomainRecord(nil, token) {
+		t.Error("Unexpected match of empty records")
+	}
+}
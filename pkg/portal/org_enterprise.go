@@ -21,6 +21,9 @@ const (
 	errorMessageUserAlreadyMember = "User with this email is already a member of this organization."
 	errorMessageOrgMembersLimit   = "Organization members limit reached on your current plan, please upgrade to invite more."
 	errorMessageOrgSubscription   = "You need an active subscription to invite organization members."
+	errorMessageSeatsLimit        = "All seats included in your plan are taken, please upgrade in the Billing settings to invite more members."
+	errorMessageLimitsCheck       = "Failed to check limits of your subscription. Please try again."
+	errorMessageSandboxMembers    = "Sandbox organization cannot have members."
 )
 
 func (s *Server) validateOrgsLimit(ctx context.Context, user *dbgen.User) string {
@@ -38,6 +41,14 @@ func (s *Server) validateOrgsLimit(ctx context.Context, user *dbgen.User) string
 	ok, extra, err := s.SubscriptionLimits.CheckOrgsLimit(ctx, user.ID, subscr)
 	if err != nil {
 		if err == db.ErrNoActiveSubscription {
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceOrgs,
+				Code:         common.StatusOrgLimitError,
+				UserID:       user.ID,
+				Subscription: subscr,
+				Requested:    1,
+				Err:          err,
+			})
 			return activeSubscriptionForOrgError
 		}
 		return ""
@@ -47,6 +58,15 @@ func (s *Server) validateOrgsLimit(ctx context.Context, user *dbgen.User) string
 		slog.WarnContext(ctx, "Organizations limit check failed", "extra", extra, "userID", user.ID, "subscriptionID", subscr.ID,
 			"internal", db.IsInternalSubscription(subscr.Source))
 
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceOrgs,
+			Code:         common.StatusOrgLimitError,
+			UserID:       user.ID,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    1,
+		})
+
 		return "Organizations limit reached on your current plan, please upgrade to create more."
 	}
 
@@ -99,8 +119,42 @@ func (s *Server) postNewOrg(w http.ResponseWriter, r *http.Request) {
 	s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
 }
 
+// postSandboxOrg creates the personal sandbox org of the user (or opens the existing one). Sandbox does not count
+// towards the orgs limit so there's no subscription check.
+func (s *Server) postSandboxOrg(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	org, auditEvent, err := s.Store.Impl().CreateSandboxOrganization(ctx, user)
+	if err == db.ErrSandboxExists {
+		orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID)
+		if org = db.FindSandboxOrg(orgs); (err != nil) || (org == nil) {
+			s.RedirectError(http.StatusInternalServerError, w, r)
+			return
+		}
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Failed to create sandbox organization", common.ErrAttr(err))
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	common.Redirect(s.PartsURL(common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID))), http.StatusOK, w, r)
+
+	if auditEvent != nil {
+		s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
+	}
+}
+
 // here we know that user is already organization owner
 func (s *Server) validateAddOrgMemberEmail(ctx context.Context, user *dbgen.User, org *dbgen.Organization, members []*dbgen.GetOrganizationUsersRow, inviteEmail string) string {
+	if org.Sandbox {
+		return errorMessageSandboxMembers
+	}
+
 	if inviteEmail == user.Email {
 		return errorMessageSelfAlreadyMember
 	}
@@ -117,34 +171,85 @@ func (s *Server) validateAddOrgMemberEmail(ctx context.Context, user *dbgen.User
 		return errorMessageUserAlreadyMember
 	}
 
-	var subscr *dbgen.Subscription
-	var err error
+	return s.validateOrgMemberLimits(ctx, user, org)
+}
 
-	if user.SubscriptionID.Valid {
-		subscr, err = s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to retrieve user subscription", "userID", user.ID, common.ErrAttr(err))
-			return ""
-		}
+// syncOrgSeats is syncSeats() for members that were added without the owner (e.g. by verified email domain)
+func (s *Server) syncOrgSeats(ctx context.Context, org *dbgen.Organization) {
+	if !org.UserID.Valid {
+		return
 	}
 
-	ok, extra, err := s.SubscriptionLimits.CheckOrgMembersLimit(ctx, org.ID, subscr)
+	if owner, err := s.Store.Impl().RetrieveUser(ctx, org.UserID.Int32); err == nil {
+		s.syncSeats(ctx, owner)
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve org owner", "orgID", org.ID, common.ErrAttr(err))
+	}
+}
+
+// canJoinOrg is validateOrgMemberLimits() for members that are added without the owner (e.g. by verified email domain)
+func (s *Server) canJoinOrg(ctx context.Context, org *dbgen.Organization) bool {
+	if !org.UserID.Valid {
+		return false
+	}
+
+	owner, err := s.Store.Impl().RetrieveUser(ctx, org.UserID.Int32)
 	if err != nil {
-		if err == db.ErrNoActiveSubscription {
-			return errorMessageOrgSubscription
-		}
-		return ""
+		slog.ErrorContext(ctx, "Failed to retrieve org owner", "orgID", org.ID, common.ErrAttr(err))
+		return false
+	}
+
+	if errorMsg := s.validateOrgMemberLimits(ctx, owner, org); len(errorMsg) > 0 {
+		slog.WarnContext(ctx, "Cannot join organization due to limits", "orgID", org.ID, "ownerID", owner.ID, "error", errorMsg)
+		return false
+	}
+
+	return true
+}
+
+// validateSeatsLimit is called after other limits checks passed, so subscription is active
+func (s *Server) validateSeatsLimit(ctx context.Context, user *dbgen.User, org *dbgen.Organization, subscr *dbgen.Subscription) string {
+	ok, extra, err := s.SubscriptionLimits.CheckSeatsLimit(ctx, user.ID, subscr)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check seats limit", "userID", user.ID, "subscriptionID", subscr.ID, common.ErrAttr(err))
+		return errorMessageLimitsCheck
 	}
 
 	if !ok {
-		slog.WarnContext(ctx, "Organization members limit check failed", "extra", extra, "orgID", org.ID, "subscriptionID", subscr.ID,
-			"internal", db.IsInternalSubscription(subscr.Source))
-		return errorMessageOrgMembersLimit
+		slog.WarnContext(ctx, "Seats limit check failed", "extra", extra, "userID", user.ID, "subscriptionID", subscr.ID)
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceSeats,
+			Code:         common.StatusSubscriptionSeatsLimitError,
+			UserID:       user.ID,
+			Org:          org,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    1,
+		})
+
+		return errorMessageSeatsLimit
 	}
 
 	return ""
 }
 
+// syncSeats updates per-seat subscription of the organization owner in the background after members were added or
+// removed (seats are billed only for committed memberships)
+func (s *Server) syncSeats(ctx context.Context, owner *dbgen.User) {
+	if !owner.SubscriptionID.Valid {
+		return
+	}
+
+	go common.RunAdHocFunc(common.CopyTraceID(ctx, context.Background()), func(bctx context.Context) error {
+		subscr, err := s.Store.Impl().RetrieveSubscription(bctx, owner.SubscriptionID.Int32)
+		if err != nil {
+			return err
+		}
+
+		return s.SubscriptionLimits.SyncSeats(bctx, owner.ID, subscr)
+	})
+}
+
 // here we know that user is already organization owner
 func (s *Server) validateAddOrgMemberID(ctx context.Context, user *dbgen.User, org *dbgen.Organization, members []*dbgen.GetOrganizationUsersRow, inviteUserID int32) string {
 	if inviteUserID == user.ID {
@@ -158,6 +263,11 @@ func (s *Server) validateAddOrgMemberID(ctx context.Context, user *dbgen.User, o
 		return errorMessageUserAlreadyMember
 	}
 
+	return s.validateOrgMemberLimits(ctx, user, org)
+}
+
+// validateOrgMemberLimits checks subscription limits of the org owner before a new member is added to the org
+func (s *Server) validateOrgMemberLimits(ctx context.Context, user *dbgen.User, org *dbgen.Organization) string {
 	var subscr *dbgen.Subscription
 	var err error
 
@@ -165,25 +275,43 @@ func (s *Server) validateAddOrgMemberID(ctx context.Context, user *dbgen.User, o
 		subscr, err = s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed to retrieve user subscription", "userID", user.ID, common.ErrAttr(err))
-			return ""
+			return errorMessageLimitsCheck
 		}
 	}
 
 	ok, extra, err := s.SubscriptionLimits.CheckOrgMembersLimit(ctx, org.ID, subscr)
 	if err != nil {
 		if err == db.ErrNoActiveSubscription {
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceOrgMembers,
+				Code:         common.StatusOrgMembersLimitError,
+				UserID:       user.ID,
+				Org:          org,
+				Subscription: subscr,
+				Requested:    1,
+				Err:          err,
+			})
 			return errorMessageOrgSubscription
 		}
-		return ""
+		return errorMessageLimitsCheck
 	}
 
 	if !ok {
 		slog.WarnContext(ctx, "Organization members limit check failed", "extra", extra, "orgID", org.ID, "subscriptionID", subscr.ID,
 			"internal", db.IsInternalSubscription(subscr.Source))
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceOrgMembers,
+			Code:         common.StatusOrgMembersLimitError,
+			UserID:       user.ID,
+			Org:          org,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    1,
+		})
 		return errorMessageOrgMembersLimit
 	}
 
-	return ""
+	return s.validateSeatsLimit(ctx, user, org, subscr)
 }
 
 func (s *Server) postOrgMembers(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
@@ -247,6 +375,8 @@ func (s *Server) postOrgMembers(w http.ResponseWriter, r *http.Request) (*ViewMo
 		renderCtx.Members = append(renderCtx.Members, ou)
 		renderCtx.SuccessMessage = "Invite is sent."
 
+		s.syncSeats(ctx, user)
+
 		go common.RunAdHocFunc(common.CopyTraceID(ctx, context.Background()), func(bctx context.Context) error {
 			orgURLPath := s.PartsURL(common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID)))
 			return s.Mailer.SendOrgInvite(bctx, inviteUser.Email, common.GuessFirstName(inviteUser.Name),
@@ -302,6 +432,8 @@ func (s *Server) deleteOrgMembers(w http.ResponseWriter, r *http.Request) {
 		s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
 	}
 
+	s.syncSeats(ctx, user)
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -388,14 +520,17 @@ func (s *Server) deleteOrg(w http.ResponseWriter, r *http.Request) {
 		s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
 	}
 
+	s.syncSeats(ctx, user)
+
 	common.Redirect(s.RelURL("/"), http.StatusOK, w, r)
 }
 
-func (s *Server) createOrgAuditLogsContext(ctx context.Context, org *dbgen.Organization, user *dbgen.User) (*orgAuditLogsRenderContext, *common.AuditLogEvent, error) {
+func (s *Server) createOrgAuditLogsContext(ctx context.Context, org *dbgen.Organization, user *dbgen.User, search string) (*orgAuditLogsRenderContext, *common.AuditLogEvent, error) {
 	renderCtx := &orgAuditLogsRenderContext{
 		AuditLogsRenderContext: AuditLogsRenderContext{
 			AuditLogs: []*userAuditLog{},
 			SeeMore:   true,
+			Search:    search,
 		},
 		CurrentOrg: orgToUserOrg(org, user.ID, s.IDHasher),
 		CanView:    org.UserID.Int32 == user.ID,
@@ -408,7 +543,15 @@ func (s *Server) createOrgAuditLogsContext(ctx context.Context, org *dbgen.Organ
 	if renderCtx.CanView {
 		auditEvent = newAccessAuditLogEvent(user, db.TableNameOrgs, int64(org.ID), org.Name, common.AuditLogsEndpoint)
 
-		if logs, err := s.Store.Impl().RetrieveOrganizationAuditLogs(ctx, org, maxOrgAuditLogs); err == nil {
+		var logs []*dbgen.GetOrgAuditLogsRow
+		var err error
+		if len(search) > 0 {
+			logs, err = s.Store.Impl().SearchOrganizationAuditLogs(ctx, org, search, perPageEventLogs)
+		} else {
+			logs, err = s.Store.Impl().RetrieveOrganizationAuditLogs(ctx, org, maxOrgAuditLogs)
+		}
+
+		if err == nil {
 			renderCtx.AuditLogs = s.newOrganizationAuditLogs(ctx, user, logs)
 			renderCtx.PerPage = perPageEventLogs
 			renderCtx.Count = len(renderCtx.AuditLogs)
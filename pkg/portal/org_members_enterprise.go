@@ -0,0 +1,558 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	importOrgMembersHandlerID = "portal-import-org-members"
+	maxOrgMembersImportRows   = 1000
+	maxOrgMembersImportSize   = 200 * 1024
+	// role that can be set only via import to remove user from the organization
+	orgMemberRoleRemoved = "removed"
+
+	orgMemberImportUnchanged = "unchanged"
+	orgMemberImportInvited   = "invited"
+	orgMemberImportRemoved   = "removed"
+	orgMemberImportError     = "error"
+)
+
+var (
+	errImportNoHeader  = errors.New("CSV file is empty")
+	errImportNoRole    = errors.New("CSV header must contain 'role' column")
+	errImportNoUser    = errors.New("CSV header must contain 'id' or 'email' column")
+	errImportTooBig    = errors.New("CSV file contains too many rows")
+	errImportTooLarge  = errors.New("CSV file is too large")
+	errImportNoRecords = errors.New("CSV file does not contain any rows")
+)
+
+type orgMemberImportRow struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Email string `json:"email,omitempty"`
+	Role  string `json:"role"`
+}
+
+type asyncTaskImportOrgMembers struct {
+	OrgID int32                 `json:"org_id"`
+	Rows  []*orgMemberImportRow `json:"rows"`
+}
+
+func (ic *orgMembersImportRenderContext) setResults(results []*orgMemberImportResult) {
+	for _, r := range results {
+		switch r.Status {
+		case orgMemberImportInvited:
+			ic.Invited++
+		case orgMemberImportRemoved:
+			ic.Removed++
+		case orgMemberImportUnchanged:
+			ic.Unchanged++
+		default:
+			ic.Failed++
+		}
+
+		if len(r.Message) > 0 {
+			ic.Details = append(ic.Details, r)
+		}
+	}
+}
+
+func (s *Server) exportOrgMembersCSV(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get session user for CSV export", common.ErrAttr(err))
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	if org.UserID.Int32 != user.ID {
+		slog.WarnContext(ctx, "Only org owner can export members", "userID", user.ID, "orgID", org.ID)
+		s.RedirectError(http.StatusForbidden, w, r)
+		return
+	}
+
+	members, err := s.Store.Impl().RetrieveOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org users", common.ErrAttr(err))
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	filename := fmt.Sprintf("private-captcha-org-members-%s.csv", time.Now().Format(time.DateOnly))
+	w.Header().Set(common.HeaderContentType, common.ContentTypeCSV)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	header := []string{"id", "name", "email", "role", "joined_at"}
+	if err := writer.Write(header); err != nil {
+		slog.ErrorContext(ctx, "Failed to write CSV header", common.ErrAttr(err))
+		return
+	}
+
+	for i, member := range members {
+		row := []string{
+			s.IDHasher.Encrypt(int(member.User.ID)),
+			member.User.Name,
+			common.MaskEmail(member.User.Email, '*'),
+			string(member.Level),
+			member.JoinedAt.Time.Format(time.DateOnly),
+		}
+
+		if err := writer.Write(row); err != nil {
+			slog.ErrorContext(ctx, "Failed to write CSV row", "index", i, "userID", member.User.ID, common.ErrAttr(err))
+			return
+		}
+	}
+
+	slog.InfoContext(ctx, "Exported org members to CSV", "userID", user.ID, "orgID", org.ID, "count", len(members))
+}
+
+// readOrgMembersImportFile rejects files over the size limit instead of parsing a truncated prefix of them
+func readOrgMembersImportFile(r io.Reader) ([]*orgMemberImportRow, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxOrgMembersImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxOrgMembersImportSize {
+		return nil, errImportTooLarge
+	}
+
+	return readOrgMembersImport(bytes.NewReader(data))
+}
+
+func readOrgMembersImport(r io.Reader) ([]*orgMemberImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errImportNoHeader
+		}
+		return nil, err
+	}
+
+	idIndex, emailIndex, roleIndex := -1, -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "id":
+			idIndex = i
+		case "email":
+			emailIndex = i
+		case "role":
+			roleIndex = i
+		}
+	}
+
+	if roleIndex == -1 {
+		return nil, errImportNoRole
+	}
+
+	if (idIndex == -1) && (emailIndex == -1) {
+		return nil, errImportNoUser
+	}
+
+	field := func(record []string, index int) string {
+		if (index >= 0) && (index < len(record)) {
+			return strings.TrimSpace(record[index])
+		}
+		return ""
+	}
+
+	rows := make([]*orgMemberImportRow, 0)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows) == maxOrgMembersImportRows {
+			return nil, errImportTooBig
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		rows = append(rows, &orgMemberImportRow{
+			Line:  line,
+			ID:    field(record, idIndex),
+			Email: field(record, emailIndex),
+			Role:  strings.ToLower(field(record, roleIndex)),
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, errImportNoRecords
+	}
+
+	return rows, nil
+}
+
+func (s *Server) createOrgMembersRenderContext(ctx context.Context, user *dbgen.User, org *dbgen.Organization) (*orgMemberRenderContext, error) {
+	members, err := s.Store.Impl().RetrieveOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve org users", common.ErrAttr(err))
+		return nil, err
+	}
+
+	return &orgMemberRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		CurrentOrg:        orgToUserOrg(org, user.ID, s.IDHasher),
+		Members:           usersToOrgUsers(members, s.IDHasher),
+		CanEdit:           org.UserID.Int32 == user.ID,
+	}, nil
+}
+
+func (s *Server) postOrgMembersImport(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, err := s.createOrgMembersRenderContext(ctx, user, org)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		renderCtx.ErrorMessage = "Only organization owner can import members."
+		return &ViewModel{Model: renderCtx, View: orgMembersTemplate}, nil
+	}
+
+	if org.Sandbox {
+		renderCtx.ErrorMessage = errorMessageSandboxMembers
+		return &ViewModel{Model: renderCtx, View: orgMembersTemplate}, nil
+	}
+
+	file, _, err := r.FormFile(common.ParamFile)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read uploaded file", common.ErrAttr(err))
+		renderCtx.ErrorMessage = "Please select a CSV file to import."
+		return &ViewModel{Model: renderCtx, View: orgMembersTemplate}, nil
+	}
+	defer file.Close()
+
+	rows, err := readOrgMembersImportFile(file)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse members CSV", common.ErrAttr(err))
+		renderCtx.ErrorMessage = fmt.Sprintf("Failed to read CSV file: %v.", err)
+		return &ViewModel{Model: renderCtx, View: orgMembersTemplate}, nil
+	}
+
+	request := &asyncTaskImportOrgMembers{
+		OrgID: org.ID,
+		Rows:  rows,
+	}
+
+	buffer := 5 * time.Minute
+	// we schedule it for later, making "room" for immediate attempt first
+	scheduledAt := time.Now().UTC().Add(buffer)
+	task, err := s.Store.Impl().CreateNewAsyncTask(ctx, request, importOrgMembersHandlerID, user, scheduledAt, renderCtx.CurrentOrg.ID /*referenceID*/)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to start import. Please try again."
+		return &ViewModel{Model: renderCtx, View: orgMembersTemplate}, nil
+	}
+
+	taskID := db.UUIDToString(task.ID)
+	slog.InfoContext(ctx, "Scheduled org members import", "orgID", org.ID, "rows", len(rows), "taskID", taskID)
+
+	renderCtx.SuccessMessage = fmt.Sprintf("Import of %d rows has started.", len(rows))
+	renderCtx.Import = &orgMembersImportRenderContext{TaskID: taskID}
+
+	go func(bctx context.Context) {
+		handlerCtx, cancel := context.WithTimeout(bctx, buffer)
+		defer cancel()
+		if err := s.AsyncTasks.Execute(handlerCtx, task); err != nil {
+			slog.ErrorContext(bctx, "Failed to execute async task", "taskID", taskID, common.ErrAttr(err))
+		}
+	}(common.CopyTraceID(ctx, context.Background()))
+
+	return &ViewModel{Model: renderCtx, View: orgMembersTemplate}, nil
+}
+
+func (s *Server) getOrgMembersImport(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if org.UserID.Int32 != user.ID {
+		return nil, db.ErrPermissions
+	}
+
+	id, err := common.StrPathArg(r, common.ParamID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse task ID from URL", common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	uuid := db.UUIDFromString(id)
+	if !uuid.Valid {
+		slog.WarnContext(ctx, "Failed to parse task ID", "id", id)
+		return nil, errInvalidPathArg
+	}
+
+	task, err := s.Store.Impl().RetrieveAsyncTask(ctx, uuid, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Handler != importOrgMembersHandlerID {
+		slog.WarnContext(ctx, "Unexpected async task handler", "handler", task.Handler, "taskID", id)
+		return nil, errInvalidPathArg
+	}
+
+	importCtx := &orgMembersImportRenderContext{TaskID: id}
+
+	if task.ProcessedAt.Valid {
+		importCtx.Finished = true
+
+		results := make([]*orgMemberImportResult, 0)
+		if err := json.Unmarshal(task.Output, &results); err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal import results", "taskID", id, common.ErrAttr(err))
+		}
+
+		importCtx.setResults(results)
+	}
+
+	return &ViewModel{
+		Model: &orgMemberRenderContext{
+			CurrentOrg: orgToUserOrg(org, user.ID, s.IDHasher),
+			CanEdit:    true,
+			Import:     importCtx,
+		},
+		View: orgMembersImportTemplate,
+	}, nil
+}
+
+func (s *Server) handleImportOrgMembers(ctx context.Context, task *dbgen.AsyncTask) ([]byte, error) {
+	taskID := db.UUIDToString(task.ID)
+	tlog := slog.With("taskID", taskID)
+
+	tlog.DebugContext(ctx, "Processing import org members task")
+
+	params := &asyncTaskImportOrgMembers{}
+	if err := json.Unmarshal(task.Input, params); err != nil {
+		tlog.ErrorContext(ctx, "Failed to unmarshal import org members async task input", common.ErrAttr(err))
+		return nil, err
+	}
+
+	user, err := s.Store.Impl().RetrieveUser(ctx, task.UserID.Int32)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve user", "userID", task.UserID.Int32, common.ErrAttr(err))
+		return nil, err
+	}
+
+	org, err := s.Store.Impl().RetrieveUserOrganization(ctx, user, params.OrgID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve org", "orgID", params.OrgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	if org.UserID.Int32 != user.ID {
+		tlog.WarnContext(ctx, "Only org owner can import members", "userID", user.ID, "orgID", org.ID)
+		return nil, db.ErrPermissions
+	}
+
+	results, auditEvents := s.doImportOrgMembers(ctx, tlog, user, org, params.Rows)
+
+	s.Store.AuditLog().RecordEvents(ctx, auditEvents, common.AuditLogSourcePortal)
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to serialize results", common.ErrAttr(err))
+		data = nil
+	}
+
+	return data, nil
+}
+
+func (s *Server) findOrgMemberImportUser(ctx context.Context, members []*dbgen.GetOrganizationUsersRow, row *orgMemberImportRow) (*dbgen.User, dbgen.AccessLevel, string) {
+	if len(row.ID) > 0 {
+		userID, err := s.IDHasher.Decrypt(row.ID)
+		if err != nil {
+			return nil, "", "Invalid user ID."
+		}
+
+		for _, m := range members {
+			if m.User.ID == int32(userID) {
+				return &m.User, m.Level, ""
+			}
+		}
+
+		if len(row.Email) == 0 {
+			return nil, "", "User with this ID is not a member of this organization."
+		}
+	}
+
+	if strings.ContainsRune(row.Email, '*') {
+		return nil, "", "Email is masked, use the 'id' column from the export instead."
+	}
+
+	for _, m := range members {
+		if m.User.Email == row.Email {
+			return &m.User, m.Level, ""
+		}
+	}
+
+	user, err := s.Store.Impl().FindUserByEmail(ctx, row.Email)
+	if err != nil {
+		return nil, "", fmt.Sprintf("Cannot find user account with email '%s'.", row.Email)
+	}
+
+	return user, "", ""
+}
+
+func (s *Server) doImportOrgMembers(ctx context.Context, tlog *slog.Logger, user *dbgen.User, org *dbgen.Organization, rows []*orgMemberImportRow) ([]*orgMemberImportResult, []*common.AuditLogEvent) {
+	results := make([]*orgMemberImportResult, 0, len(rows))
+	auditEvents := make([]*common.AuditLogEvent, 0)
+
+	members, err := s.Store.Impl().RetrieveOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve org users", common.ErrAttr(err))
+		members = []*dbgen.GetOrganizationUsersRow{}
+	}
+
+	seen := make(map[int32]struct{})
+
+	for _, row := range rows {
+		result := &orgMemberImportResult{Line: row.Line, Status: orgMemberImportError}
+		results = append(results, result)
+
+		switch row.Role {
+		case string(dbgen.AccessLevelMember), string(dbgen.AccessLevelInvited), orgMemberRoleRemoved:
+		case string(dbgen.AccessLevelOwner):
+			result.Message = "Organization ownership cannot be changed via import."
+			continue
+		default:
+			result.Message = fmt.Sprintf("Unknown role '%s', expected one of: member, invited, removed.", row.Role)
+			continue
+		}
+
+		if (len(row.ID) == 0) && (len(row.Email) == 0) {
+			result.Message = "Either user ID or email is required."
+			continue
+		}
+
+		member, level, errorMsg := s.findOrgMemberImportUser(ctx, members, row)
+		if len(errorMsg) > 0 {
+			result.Message = errorMsg
+			continue
+		}
+
+		if member.ID == user.ID {
+			result.Message = "Organization owner cannot be changed via import."
+			continue
+		}
+
+		if _, ok := seen[member.ID]; ok {
+			result.Message = "Duplicate row for the same user."
+			continue
+		}
+		seen[member.ID] = struct{}{}
+
+		rlog := tlog.With("line", row.Line, "userID", member.ID)
+
+		if row.Role == orgMemberRoleRemoved {
+			if len(level) == 0 {
+				result.Status = orgMemberImportUnchanged
+				continue
+			}
+
+			auditEvent, err := s.Store.Impl().RemoveUserFromOrg(ctx, user, org, member.ID)
+			if err != nil {
+				rlog.ErrorContext(ctx, "Failed to remove user from org", common.ErrAttr(err))
+				result.Message = "Failed to remove user."
+				continue
+			}
+
+			auditEvents = append(auditEvents, auditEvent)
+			result.Status = orgMemberImportRemoved
+			continue
+		}
+
+		if len(level) > 0 {
+			result.Status = orgMemberImportUnchanged
+			if (level == dbgen.AccessLevelInvited) && (row.Role == string(dbgen.AccessLevelMember)) {
+				result.Message = "Invite is not accepted yet."
+			}
+			continue
+		}
+
+		if errorMsg := s.validateAddOrgMemberID(ctx, user, org, members, member.ID); len(errorMsg) > 0 {
+			result.Message = errorMsg
+			continue
+		}
+
+		auditEvent, err := s.Store.Impl().InviteUserToOrg(ctx, user, org, member)
+		if err != nil {
+			rlog.ErrorContext(ctx, "Failed to invite user to org", common.ErrAttr(err))
+			result.Message = "Failed to invite user."
+			continue
+		}
+
+		auditEvents = append(auditEvents, auditEvent)
+		members = append(members, &dbgen.GetOrganizationUsersRow{User: *member, Level: dbgen.AccessLevelInvited})
+		result.Status = orgMemberImportInvited
+
+		orgURLPath := s.PartsURL(common.OrgEndpoint, s.IDHasher.Encrypt(int(org.ID)))
+		if err := s.Mailer.SendOrgInvite(ctx, member.Email, common.GuessFirstName(member.Name),
+			org.Name, user.Email, common.GuessFirstName(user.Name), orgURLPath); err != nil {
+			rlog.ErrorContext(ctx, "Failed to send org invite", common.ErrAttr(err))
+		}
+	}
+
+	tlog.InfoContext(ctx, "Processed org members import", "orgID", org.ID, "rows", len(rows), "auditEvents", len(auditEvents))
+
+	if slices.ContainsFunc(results, func(r *orgMemberImportResult) bool {
+		return (r.Status == orgMemberImportRemoved) || (r.Status == orgMemberImportInvited)
+	}) {
+		s.syncSeats(ctx, user)
+	}
+
+	return results, auditEvents
+}
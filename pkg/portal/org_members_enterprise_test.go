@@ -0,0 +1,25 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadOrgMembersImportFileTooLarge(t *testing.T) {
+	t.Parallel()
+
+	header := "email,role\n"
+	row := "user@example.com,member\n"
+
+	fits := header + strings.Repeat(row, (maxOrgMembersImportSize-len(header))/len(row))
+	if _, err := readOrgMembersImportFile(strings.NewReader(fits)); err == errImportTooLarge {
+		t.Fatal("File within the size limit was rejected")
+	}
+
+	large := fits + strings.Repeat(row, 2)
+	if _, err := readOrgMembersImportFile(strings.NewReader(large)); err != errImportTooLarge {
+		t.Errorf("Unexpected error for large file: %v", err)
+	}
+}
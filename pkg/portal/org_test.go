@@ -1,13 +1,17 @@
 package portal
 
 import (
+	"bytes"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
@@ -387,6 +391,90 @@ func TestGetOrgMembers(t *testing.T) {
 	}
 }
 
+func TestGetOrgMembersFiltered(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	owner, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_1", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create owner account: %v", err)
+	}
+
+	member, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_2", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create member account: %v", err)
+	}
+
+	invitee, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_3", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create invitee account: %v", err)
+	}
+
+	for _, user := range []*dbgen.User{member, invitee} {
+		if _, err := store.Impl().InviteUserToOrg(ctx, owner, org, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := store.Impl().JoinOrg(ctx, org.ID, member); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := http.NewServeMux()
+	server.Setup(portalDomain(), common.NoopMiddleware).Register(srv)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, owner.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		query    url.Values
+		expected []int32
+	}{
+		{query: url.Values{common.ParamRole: []string{string(dbgen.AccessLevelMember)}}, expected: []int32{member.ID}},
+		{query: url.Values{common.ParamRole: []string{string(dbgen.AccessLevelInvited)}}, expected: []int32{invitee.ID}},
+		{query: url.Values{common.ParamSearch: []string{invitee.Email}}, expected: []int32{invitee.ID}},
+		{query: url.Values{common.ParamSort: []string{"email"}, common.ParamOrder: []string{"desc"}}, expected: sortedByEmailDesc(member, invitee)},
+	}
+
+	for i, tc := range testCases {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/org/%s/tab/members?%s", server.IDHasher.Encrypt(int(org.ID)), tc.query.Encode()), nil)
+		req.AddCookie(cookie)
+		req.SetPathValue(common.ParamOrg, server.IDHasher.Encrypt(int(org.ID)))
+
+		viewModel, err := server.getOrgMembers(httptest.NewRecorder(), req)
+		if err != nil {
+			t.Fatalf("Test case %v: unexpected error: %v", i, err)
+		}
+
+		renderCtx := viewModel.Model.(*orgMemberRenderContext)
+		if len(renderCtx.Members) != len(tc.expected) {
+			t.Fatalf("Test case %v: unexpected members count %v", i, len(renderCtx.Members))
+		}
+
+		for j, m := range renderCtx.Members {
+			if expectedID := server.IDHasher.Encrypt(int(tc.expected[j])); m.ID != expectedID {
+				t.Errorf("Test case %v: unexpected member %v at position %v", i, m.ID, j)
+			}
+		}
+	}
+}
+
+func sortedByEmailDesc(users ...*dbgen.User) []int32 {
+	sorted := slices.Clone(users)
+	slices.SortFunc(sorted, func(a, b *dbgen.User) int { return strings.Compare(b.Email, a.Email) })
+
+	result := make([]int32, 0, len(sorted))
+	for _, u := range sorted {
+		result = append(result, u.ID)
+	}
+
+	return result
+}
+
 func TestGetOrgSettings(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -756,3 +844,193 @@ func TestDeleteOrg(t *testing.T) {
 		}
 	}
 }
+
+func TestImportOrgMembers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	owner, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_1", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create owner account: %v", err)
+	}
+
+	invitee, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_2", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create invitee account: %v", err)
+	}
+
+	member, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_3", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create member account: %v", err)
+	}
+
+	if _, err := store.Impl().InviteUserToOrg(ctx, owner, org, member); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Impl().JoinOrg(ctx, org.ID, member); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := http.NewServeMux()
+	server.Setup(portalDomain(), common.NoopMiddleware).Register(srv)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, owner.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orgID := server.IDHasher.Encrypt(int(org.ID))
+
+	exportReq := httptest.NewRequest("GET", fmt.Sprintf("/org/%s/members/export", orgID), nil)
+	exportReq.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, exportReq)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected export status code %v", w.Code)
+	}
+
+	exported := w.Body.String()
+	if !strings.HasPrefix(exported, "id,name,email,role,joined_at") {
+		t.Errorf("Unexpected CSV header: %v", exported)
+	}
+
+	if strings.Contains(exported, member.Email) {
+		t.Error("Exported CSV contains unmasked email")
+	}
+
+	csvData := fmt.Sprintf("id,email,role\n%s,,removed\n,%s,member\n,%s,owner\n",
+		server.IDHasher.Encrypt(int(member.ID)), invitee.Email, invitee.Email)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(common.ParamFile, "members.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte(csvData)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/org/%s/members/import", orgID), &body)
+	req.AddCookie(cookie)
+	req.Header.Set(common.HeaderContentType, writer.FormDataContentType())
+	req.Header.Set(common.HeaderCSRFToken, server.XSRF.Token(strconv.Itoa(int(owner.ID))))
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected import status code %v", w.Code)
+	}
+
+	var invited, removed bool
+	for attempt := 0; attempt < 20 && !(invited && removed); attempt++ {
+		time.Sleep(250 * time.Millisecond)
+
+		members, err := store.Impl().RetrieveOrganizationUsers(ctx, org.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		invited, removed = false, true
+		for _, m := range members {
+			if m.User.ID == invitee.ID && m.Level == dbgen.AccessLevelInvited {
+				invited = true
+			}
+			if m.User.ID == member.ID {
+				removed = false
+			}
+		}
+	}
+
+	if !invited {
+		t.Error("User was not invited via import")
+	}
+
+	if !removed {
+		t.Error("Member was not removed via import")
+	}
+}
+
+func TestUserOrgsSummary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := store.Impl().RetrieveUserOrgsSummary(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(summary.Orgs) != 1) || (summary.Orgs[0].ID != org.ID) || (summary.Orgs[0].Properties != 0) {
+		t.Fatalf("Unexpected initial summary: %+v", summary.Orgs)
+	}
+
+	property, _, err := store.Impl().CreateNewProperty(ctx, db_tests.CreateNewPropertyParams(user.ID, "example.com"), org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary, err = store.Impl().RetrieveUserOrgsSummary(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Orgs[0].Properties != 1 {
+		t.Errorf("Unexpected properties count after creation: %v", summary.Orgs[0].Properties)
+	}
+
+	if _, err := store.Impl().SoftDeleteProperty(ctx, property, org, user); err != nil {
+		t.Fatal(err)
+	}
+
+	org2, _, err := store.Impl().CreateNewOrganization(ctx, t.Name()+"-another-org", user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary, err = store.Impl().RetrieveUserOrgsSummary(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(summary.Orgs) != 2) || (summary.Orgs[1].ID != org2.ID) {
+		t.Fatalf("Unexpected summary orgs after creating org: %+v", summary.Orgs)
+	}
+
+	if summary.Orgs[0].Properties != 0 {
+		t.Errorf("Unexpected properties count after deletion: %v", summary.Orgs[0].Properties)
+	}
+}
+
+func TestFormatTrafficCount(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		count    uint64
+		expected string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1_250, "1.2K"},
+		{3_400_000, "3.4M"},
+		{5_000_000_000, "5.0B"},
+	}
+
+	for _, tc := range testCases {
+		if actual := formatTrafficCount(tc.count); actual != tc.expected {
+			t.Errorf("Unexpected format of %v: %v (expected %v)", tc.count, actual, tc.expected)
+		}
+	}
+}
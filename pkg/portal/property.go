@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"net"
 	"net/http"
 	"strconv"
@@ -25,12 +26,16 @@ const (
 	propertyDashboardSettingsTemplate     = "property/settings.html"
 	propertyDashboardIntegrationsTemplate = "property/integrations.html"
 	propertyDashboardAuditLogsTemplate    = "property/auditlogs.html"
+	propertyMoveFormTemplate              = "property/settings-move-form.html"
 	propertyWizardTemplate                = "property-wizard/wizard.html"
 	propertySettingsPropertyID            = "371d58d2-f8b9-44e2-ac2e-e61253274bae"
 	propertySettingsTabIndex              = 2
 	propertyIntegrationsTabIndex          = 1
 	propertyAuditLogsTabIndex             = 3
 	activeSubscriptionForPropertyError    = "You need an active subscription to create new properties."
+	propertyDomainUnresolvedWarning       = "Domain of this property no longer resolves. It might have expired or been abandoned."
+	propertyDomainLoopbackWarning         = "Domain of this property now points to localhost. It might have been abandoned or compromised."
+	emergencyUntilLayout                  = "2006-01-02 15:04 MST"
 )
 
 type difficultyLevelsRenderContext struct {
@@ -62,6 +67,13 @@ type userProperty struct {
 	AllowSubdomains  bool
 	AllowLocalhost   bool
 	AllowReplay      bool
+	ImageChallenge   bool
+	Staging          bool
+	DomainWarning    string
+	FailureURL       string
+	FailureMessage   string
+	Emergency        bool
+	EmergencyUntil   string
 }
 
 type orgPropertiesRenderContext struct {
@@ -90,6 +102,19 @@ type propertySettingsRenderContext struct {
 	MinLevel int
 	MaxLevel int
 	CanMove  bool
+	// production twin of the staging property
+	Twin       *userProperty
+	AccessList propertyAccessListRenderContext
+	ShareLinks []*userShareLink
+	Privacy    propertyPrivacyRenderContext
+}
+
+// propertyPrivacyRenderContext describes how IP addresses of the end users are processed (for DPA documentation)
+type propertyPrivacyRenderContext struct {
+	Installation string
+	Override     string
+	Effective    string
+	Description  string
 }
 
 func (pc *propertySettingsRenderContext) UpdateLevels() {
@@ -103,7 +128,10 @@ func (pc *propertySettingsRenderContext) UpdateLevels() {
 
 type propertyIntegrationsRenderContext struct {
 	propertyDashboardRenderContext
-	Sitekey string
+	Sitekey      string
+	BypassTokens []*userBypassToken
+	// secret of the just created bypass token, it is not stored
+	NewBypassToken string
 }
 
 type propertyAuditLogsRenderContext struct {
@@ -118,9 +146,58 @@ type propertyStatsPoint struct {
 	Value int   `json:"y"`
 }
 
+// latency percentiles are in milliseconds
+type propertyLatencyPoint struct {
+	Date int64   `json:"x"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+}
+
+// totals for the class of visitors (differential difficulty)
+type propertyVisitorStats struct {
+	Class     string  `json:"class"`
+	Requested int     `json:"requested"`
+	Verified  int     `json:"verified"`
+	SolveRate float64 `json:"solve_rate"`
+}
+
+// verifications by the result of widget integrity check
+type propertyIntegrityStats struct {
+	OK           int     `json:"ok"`
+	Tampered     int     `json:"tampered"`
+	Patched      int     `json:"patched"`
+	Unknown      int     `json:"unknown"`
+	TamperedRate float64 `json:"tampered_rate"`
+}
+
 type propertyStatsResponse struct {
-	Requested []*propertyStatsPoint `json:"requested"`
-	Verified  []*propertyStatsPoint `json:"verified"`
+	Requested []*propertyStatsPoint   `json:"requested"`
+	Verified  []*propertyStatsPoint   `json:"verified"`
+	Latency   []*propertyLatencyPoint `json:"latency"`
+	Visitors  []*propertyVisitorStats `json:"visitors,omitempty"`
+	// verifications with bypass tokens instead of puzzles
+	Bypassed  int                     `json:"bypassed,omitempty"`
+	Integrity *propertyIntegrityStats `json:"integrity,omitempty"`
+	// puzzle requests rejected by the issuance quota
+	Throttled []*propertyStatsPoint `json:"throttled,omitempty"`
+}
+
+func periodStart(period common.TimePeriod, tnow time.Time) time.Time {
+	switch period {
+	case common.TimePeriodWeek:
+		return tnow.AddDate(0, 0, -7)
+	case common.TimePeriodMonth:
+		return tnow.AddDate(0, -1, 0)
+	case common.TimePeriodYear:
+		return tnow.AddDate(-1, 0, 0)
+	default:
+		return tnow.AddDate(0, 0, -1)
+	}
+}
+
+func roundLatency(ms float64) float64 {
+	return math.Round(ms*10) / 10
 }
 
 func createDifficultyLevelsRenderContext() difficultyLevelsRenderContext {
@@ -145,6 +222,22 @@ func propertyToUserProperty(p *dbgen.Property, hasher common.IdentifierHasher) *
 		MaxReplayCount:   max(1, int(p.MaxReplayCount)),
 		AllowSubdomains:  p.AllowSubdomains,
 		AllowLocalhost:   p.AllowLocalhost,
+		ImageChallenge:   (puzzle.WidgetFlags(p.WidgetFlags) & puzzle.WidgetFlagImageChallenge) != 0,
+		Staging:          p.Environment == dbgen.PropertyEnvironmentStaging,
+		FailureURL:       p.FailureURL,
+		FailureMessage:   p.FailureMessage,
+	}
+
+	if db.IsPropertyEmergency(p, time.Now()) {
+		up.Emergency = true
+		up.EmergencyUntil = p.EmergencyUntil.Time.UTC().Format(emergencyUntilLayout)
+	}
+
+	switch p.DomainStatus {
+	case dbgen.PropertyDomainStatusUnresolved:
+		up.DomainWarning = propertyDomainUnresolvedWarning
+	case dbgen.PropertyDomainStatusLoopback:
+		up.DomainWarning = propertyDomainLoopbackWarning
 	}
 
 	return up
@@ -287,19 +380,8 @@ func (s *Server) validateDomainName(ctx context.Context, domain string, ignoreRe
 	}
 
 	const timeout = 3 * time.Second
-	rctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-	var r net.Resolver
-	names, err := r.LookupIPAddr(rctx, domain)
+	names, anyNonLocal, err := common.LookupDomainName(ctx, &net.Resolver{}, domain, timeout)
 	if err == nil && len(names) > 0 {
-		anyNonLocal := false
-		for _, n := range names {
-			if !n.IP.IsLoopback() {
-				anyNonLocal = true
-				break
-			}
-		}
-
 		if !anyNonLocal {
 			slog.WarnContext(ctx, "Only loopback IPs are resolved", "domain", domain, "first", names[0])
 			return common.StatusPropertyDomainLocalhostError
@@ -324,9 +406,19 @@ func (s *Server) validatePropertiesLimit(ctx context.Context, org *dbgen.Organiz
 
 	isOrgOwner := org.UserID.Int32 == sessUser.ID
 
-	ok, extra, err := s.SubscriptionLimits.CheckPropertiesLimit(ctx, owner.ID, subscr)
+	ok, extra, err := s.SubscriptionLimits.CheckOrgPropertiesLimit(ctx, org, owner.ID, subscr)
 	if err != nil {
 		if err == db.ErrNoActiveSubscription {
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceProperties,
+				Code:         common.StatusSubscriptionPropertyLimitError,
+				UserID:       sessUser.ID,
+				Org:          org,
+				Subscription: subscr,
+				Requested:    1,
+				Err:          err,
+			})
+
 			if isOrgOwner {
 				return activeSubscriptionForPropertyError
 			}
@@ -336,10 +428,25 @@ func (s *Server) validatePropertiesLimit(ctx context.Context, org *dbgen.Organiz
 		return ""
 	}
 
+	if !ok && org.Sandbox {
+		slog.WarnContext(ctx, "Sandbox properties limit check failed", "extra", extra, "orgID", org.ID)
+		return fmt.Sprintf("Sandbox organization can have at most %d properties.", db.SandboxPropertiesLimit)
+	}
+
 	if !ok {
 		slog.WarnContext(ctx, "Properties limit check failed", "extra", extra, "userID", owner.ID, "subscriptionID", subscr.ID,
 			"orgOwner", isOrgOwner, "internal", db.IsInternalSubscription(subscr.Source))
 
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceProperties,
+			Code:         common.StatusSubscriptionPropertyLimitError,
+			UserID:       sessUser.ID,
+			Org:          org,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    1,
+		})
+
 		if isOrgOwner {
 			return "Properties limit reached on your current plan, please upgrade to create more."
 		}
@@ -481,32 +588,43 @@ func (s *Server) getPropertyStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	periodStr := r.PathValue(common.ParamPeriod)
-	var period common.TimePeriod
+	period := parseStatsPeriod(ctx, r.PathValue(common.ParamPeriod))
+
+	etag := common.GenerateETag(strconv.Itoa(int(user.ID)), strconv.Itoa(int(org.ID)), strconv.Itoa(int(property.ID)), period.String())
+	if etagHeader := r.Header.Get(common.HeaderIfNoneMatch); len(etagHeader) > 0 && (etagHeader == etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	cacheHeaders := map[string][]string{
+		common.HeaderETag:         []string{etag},
+		common.HeaderCacheControl: common.PrivateCacheControl1m,
+	}
+
+	common.SendJSONResponse(ctx, w, s.propertyStats(ctx, org.ID, property, period), cacheHeaders)
+}
+
+func parseStatsPeriod(ctx context.Context, periodStr string) common.TimePeriod {
 	switch periodStr {
 	case "24h":
-		period = common.TimePeriodToday
+		return common.TimePeriodToday
 	case "7d":
-		period = common.TimePeriodWeek
+		return common.TimePeriodWeek
 	case "30d":
-		period = common.TimePeriodMonth
+		return common.TimePeriodMonth
 	case "1y":
-		period = common.TimePeriodYear
+		return common.TimePeriodYear
 	default:
 		slog.ErrorContext(ctx, "Incorrect period argument", "period", periodStr)
-		period = common.TimePeriodToday
-	}
-
-	etag := common.GenerateETag(strconv.Itoa(int(user.ID)), strconv.Itoa(int(org.ID)), strconv.Itoa(int(property.ID)), period.String())
-	if etagHeader := r.Header.Get(common.HeaderIfNoneMatch); len(etagHeader) > 0 && (etagHeader == etag) {
-		w.WriteHeader(http.StatusNotModified)
-		return
+		return common.TimePeriodToday
 	}
+}
 
+func (s *Server) propertyStats(ctx context.Context, orgID int32, property *dbgen.Property, period common.TimePeriod) *propertyStatsResponse {
 	requested := []*propertyStatsPoint{}
 	verified := []*propertyStatsPoint{}
 
-	if stats, err := s.TimeSeries.RetrievePropertyStatsByPeriod(ctx, org.ID, property.ID, period); err == nil {
+	if stats, err := s.TimeSeries.RetrievePropertyStatsByPeriod(ctx, orgID, property.ID, period); err == nil {
 		anyNonZero := false
 		for _, st := range stats {
 			if (st.RequestsCount > 0) || (st.VerifiesCount > 0) {
@@ -525,17 +643,75 @@ func (s *Server) getPropertyStats(w http.ResponseWriter, r *http.Request) {
 		slog.ErrorContext(ctx, "Failed to retrieve property stats", common.ErrAttr(err))
 	}
 
-	response := propertyStatsResponse{
+	latency := []*propertyLatencyPoint{}
+
+	if stats, err := s.TimeSeries.RetrievePropertyVerifyLatencyByPeriod(ctx, orgID, property.ID, period); err == nil {
+		for _, st := range stats {
+			latency = append(latency, &propertyLatencyPoint{
+				Date: st.Timestamp.Unix(),
+				P50:  roundLatency(st.P50),
+				P90:  roundLatency(st.P90),
+				P99:  roundLatency(st.P99),
+			})
+		}
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property latency stats", common.ErrAttr(err))
+	}
+
+	response := &propertyStatsResponse{
 		Requested: requested,
 		Verified:  verified,
+		Latency:   latency,
 	}
 
-	cacheHeaders := map[string][]string{
-		common.HeaderETag:         []string{etag},
-		common.HeaderCacheControl: common.PrivateCacheControl1m,
+	tnow := time.Now().UTC()
+
+	// bypass tokens are accounted as a separate visitor class
+	if stats, err := s.TimeSeries.RetrieveVisitorStats(ctx, orgID, property.ID, periodStart(period, tnow), tnow); err == nil {
+		for _, st := range stats {
+			if st.Class == common.VisitorClassBypass {
+				response.Bypassed = st.SuccessCount
+				continue
+			}
+
+			if !property.DifferentialDifficulty {
+				continue
+			}
+
+			response.Visitors = append(response.Visitors, &propertyVisitorStats{
+				Class:     st.Class.String(),
+				Requested: st.RequestsCount,
+				Verified:  st.SuccessCount,
+				SolveRate: math.Round(st.SolveRate()*1000) / 1000,
+			})
+		}
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property visitor stats", common.ErrAttr(err))
 	}
 
-	common.SendJSONResponse(ctx, w, response, cacheHeaders)
+	if stats, err := s.TimeSeries.RetrieveIntegrityStats(ctx, orgID, property.ID, periodStart(period, tnow), tnow); err == nil {
+		if (stats.OKCount > 0) || (stats.TamperedCount > 0) || (stats.PatchedCount > 0) {
+			response.Integrity = &propertyIntegrityStats{
+				OK:           stats.OKCount,
+				Tampered:     stats.TamperedCount,
+				Patched:      stats.PatchedCount,
+				Unknown:      stats.UnknownCount,
+				TamperedRate: math.Round(stats.TamperedRate()*1000) / 1000,
+			}
+		}
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property integrity stats", common.ErrAttr(err))
+	}
+
+	if stats, err := s.TimeSeries.RetrieveQuotaStats(ctx, orgID, property.ID, periodStart(period, tnow), tnow); err == nil {
+		for _, st := range stats {
+			response.Throttled = append(response.Throttled, &propertyStatsPoint{Date: st.Timestamp.Unix(), Value: int(st.Count)})
+		}
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property quota stats", common.ErrAttr(err))
+	}
+
+	return response
 }
 
 func (s *Server) getOrgProperty(w http.ResponseWriter, r *http.Request) (*propertyDashboardRenderContext, *dbgen.Property, error) {
@@ -601,6 +777,21 @@ func (s *Server) getOrgPropertySettings(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if (property.Environment == dbgen.PropertyEnvironmentStaging) && property.TwinID.Valid {
+		if org, err := s.Org(user, r); err == nil {
+			if twin, err := s.Store.Impl().RetrieveOrgProperty(ctx, org, property.TwinID.Int32); err == nil {
+				renderCtx.Twin = propertyToUserProperty(twin, s.IDHasher)
+			} else {
+				slog.WarnContext(ctx, "Failed to retrieve production twin", "propID", property.ID, "twinID", property.TwinID.Int32, common.ErrAttr(err))
+			}
+		}
+	}
+
+	list, _ := s.propertyAccessList(ctx, property)
+	renderCtx.AccessList = newPropertyAccessListRenderContext(list)
+	renderCtx.ShareLinks = s.propertyShareLinks(r, property)
+	renderCtx.Privacy = s.propertyPrivacy(property)
+
 	renderCtx.Tab = propertySettingsTabIndex
 
 	renderCtx.UpdateLevels()
@@ -684,6 +875,7 @@ func (s *Server) getPropertyIntegrations(w http.ResponseWriter, r *http.Request)
 	renderCtx := &propertyIntegrationsRenderContext{
 		propertyDashboardRenderContext: *dashboardCtx,
 		Sitekey:                        db.UUIDToSiteKey(property.ExternalID),
+		BypassTokens:                   s.propertyBypassTokens(r, property),
 	}
 
 	renderCtx.Tab = propertyIntegrationsTabIndex
@@ -785,6 +977,21 @@ func (s *Server) putProperty(w http.ResponseWriter, r *http.Request) (*ViewModel
 		maxReplayCount = parseMaxReplayCount(ctx, r.FormValue(common.ParamMaxReplayCount))
 	}
 
+	failureURL := strings.TrimSpace(r.FormValue(common.ParamFailureURL))
+	if !db.CheckFailureURLValid(ctx, failureURL) {
+		renderCtx.ErrorMessage = common.StatusPropertyFailureURLError.String()
+		renderCtx.Property.FailureURL = failureURL
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	failureMessage := db.NormalizeFailureMessage(r.FormValue(common.ParamFailureMessage))
+
+	// other widget flags are not editable in portal yet
+	widgetFlags := puzzle.WidgetFlags(property.WidgetFlags) &^ puzzle.WidgetFlagImageChallenge
+	if _, imageChallenge := r.Form[common.ParamImageChallenge]; imageChallenge {
+		widgetFlags |= puzzle.WidgetFlagImageChallenge
+	}
+
 	var auditEvent *common.AuditLogEvent
 
 	if (name != property.Name) ||
@@ -793,7 +1000,10 @@ func (s *Server) putProperty(w http.ResponseWriter, r *http.Request) (*ViewModel
 		(validityInterval != property.ValidityInterval) ||
 		(maxReplayCount != property.MaxReplayCount) ||
 		(allowSubdomains != property.AllowSubdomains) ||
-		(allowLocalhost != property.AllowLocalhost) {
+		(allowLocalhost != property.AllowLocalhost) ||
+		(failureURL != property.FailureURL) ||
+		(failureMessage != property.FailureMessage) ||
+		(int16(widgetFlags) != property.WidgetFlags) {
 		params := &dbgen.UpdatePropertyParams{
 			ID:               property.ID,
 			Name:             name,
@@ -803,6 +1013,18 @@ func (s *Server) putProperty(w http.ResponseWriter, r *http.Request) (*ViewModel
 			AllowSubdomains:  allowSubdomains,
 			AllowLocalhost:   allowLocalhost,
 			MaxReplayCount:   maxReplayCount,
+			FailureURL:       failureURL,
+			FailureMessage:   failureMessage,
+			WidgetFlags:      int16(widgetFlags),
+			// not editable in portal yet
+			AllowedClockSkew:       property.AllowedClockSkew,
+			RememberWindow:         property.RememberWindow,
+			TwinID:                 property.TwinID,
+			TrustGroup:             property.TrustGroup,
+			Claims:                 property.Claims,
+			DifferentialDifficulty: property.DifferentialDifficulty,
+			BotPolicy:              property.BotPolicy,
+			DifficultyStrategy:     property.DifficultyStrategy,
 		}
 
 		var updatedProperty *dbgen.Property
@@ -818,6 +1040,191 @@ func (s *Server) putProperty(w http.ResponseWriter, r *http.Request) (*ViewModel
 	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate, AuditEvent: auditEvent}, nil
 }
 
+func (s *Server) promoteProperty(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, _, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to promote property", "userID", user.ID, "orgUserID", org.UserID.Int32,
+			"propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	twin, auditEvent, err := s.Store.Impl().PromoteProperty(ctx, org, user, property)
+	if err != nil {
+		if err == db.ErrPermissions {
+			renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		} else {
+			renderCtx.ErrorMessage = "Failed to promote settings. Please try again."
+		}
+	} else {
+		slog.InfoContext(ctx, "Promoted property settings", "propID", property.ID, "twinID", twin.ID, "orgID", org.ID)
+		renderCtx.SuccessMessage = fmt.Sprintf("Settings were promoted to '%s'", twin.Name)
+	}
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) postPropertyEmergency(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	return s.updatePropertyEmergency(w, r, true /*activate*/)
+}
+
+func (s *Server) deletePropertyEmergency(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	return s.updatePropertyEmergency(w, r, false /*activate*/)
+}
+
+// updatePropertyEmergency toggles emergency mode ("panic button") that is used during an active attack on the property
+func (s *Server) updatePropertyEmergency(w http.ResponseWriter, r *http.Request, activate bool) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, _, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to update property emergency mode", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	var until time.Time
+	if activate {
+		if err := r.ParseForm(); err != nil {
+			slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+			return nil, ErrInvalidRequestArg
+		}
+
+		minutes, _ := strconv.Atoi(r.FormValue(common.ParamDuration))
+		until = time.Now().UTC().Add(db.NormalizeEmergencyDuration(time.Duration(minutes) * time.Minute))
+	}
+
+	updatedProperty, auditEvent, err := s.Store.Impl().UpdatePropertyEmergency(ctx, user, property, org, until)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to update emergency mode. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	renderCtx.Property = propertyToUserProperty(updatedProperty, s.IDHasher)
+	if activate {
+		renderCtx.SuccessMessage = fmt.Sprintf("Emergency mode is active until %s", renderCtx.Property.EmergencyUntil)
+	} else {
+		renderCtx.SuccessMessage = "Emergency mode was deactivated"
+	}
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) installationPrivacyMode() common.PrivacyMode {
+	if s.PrivacyMode == nil {
+		return common.PrivacyModeDefault
+	}
+
+	return common.ParsePrivacyMode(s.PrivacyMode.Value())
+}
+
+func (s *Server) propertyPrivacy(property *dbgen.Property) propertyPrivacyRenderContext {
+	installation := s.installationPrivacyMode()
+	effective := common.EffectivePrivacyMode(installation, common.ParsePrivacyMode(property.PrivacyMode))
+
+	return propertyPrivacyRenderContext{
+		Installation: string(common.EffectivePrivacyMode(installation, common.PrivacyModeDefault)),
+		Override:     property.PrivacyMode,
+		Effective:    string(effective),
+		Description:  effective.Description(),
+	}
+}
+
+// putPropertyPrivacy sets privacy mode override of the property (it can only be stricter than installation mode)
+func (s *Server) putPropertyPrivacy(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, _, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to update property privacy mode", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	mode := common.ParsePrivacyMode(r.FormValue(common.ParamPrivacyMode))
+	if mode == common.PrivacyMode(property.PrivacyMode) {
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	updatedProperty, auditEvent, err := s.Store.Impl().UpdatePropertyPrivacyMode(ctx, user, property, org, mode)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to update privacy mode. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	renderCtx.Property = propertyToUserProperty(updatedProperty, s.IDHasher)
+	renderCtx.Privacy = s.propertyPrivacy(updatedProperty)
+	renderCtx.SuccessMessage = "Privacy mode was updated"
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
 func (s *Server) deleteProperty(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -0,0 +1,169 @@
+package portal
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+type propertyAccessListRenderContext struct {
+	IPAllowlist string
+	IPDenylist  string
+	ASNDenylist string
+	Policy      string
+	Error       string
+}
+
+func newPropertyAccessListRenderContext(list *dbgen.PropertyAccessList) propertyAccessListRenderContext {
+	result := propertyAccessListRenderContext{
+		Policy: string(dbgen.BotPolicyBlock),
+	}
+
+	if list != nil {
+		asns := make([]string, 0, len(list.AsnDenylist))
+		for _, asn := range list.AsnDenylist {
+			asns = append(asns, strconv.FormatInt(asn, 10))
+		}
+
+		result.IPAllowlist = strings.Join(list.IpAllowlist, "\n")
+		result.IPDenylist = strings.Join(list.IpDenylist, "\n")
+		result.ASNDenylist = strings.Join(asns, "\n")
+		result.Policy = string(list.Policy)
+	}
+
+	return result
+}
+
+// parseASNList returns unique AS numbers (with or without "AS" prefix) or an error message for the user
+func parseASNList(value string) ([]int64, string) {
+	entries := splitListEntries(value)
+
+	result := make([]int64, 0, len(entries))
+
+	for _, entry := range entries {
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(entry), "AS"), 10, 32)
+		if (err != nil) || (asn == 0) {
+			return nil, "Invalid AS number: " + entry
+		}
+
+		if !slices.Contains(result, int64(asn)) {
+			result = append(result, int64(asn))
+		}
+	}
+
+	if len(result) > db.MaxAccessListEntries {
+		return nil, "Too many AS numbers (maximum is " + strconv.Itoa(db.MaxAccessListEntries) + ")."
+	}
+
+	return result, ""
+}
+
+func parseAccessListPolicy(value string) dbgen.BotPolicy {
+	switch policy := dbgen.BotPolicy(value); policy {
+	case dbgen.BotPolicyMonitor, dbgen.BotPolicyMaxDifficulty, dbgen.BotPolicyBlock:
+		return policy
+	default:
+		return dbgen.BotPolicyBlock
+	}
+}
+
+// propertyAccessList returns nil (and no error) if property does not have access lists
+func (s *Server) propertyAccessList(ctx context.Context, property *dbgen.Property) (*dbgen.PropertyAccessList, error) {
+	list, err := s.Store.Impl().RetrievePropertyAccessList(ctx, property.ID)
+	if err != nil {
+		if (err == db.ErrNegativeCacheHit) || (err == db.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		slog.ErrorContext(ctx, "Failed to retrieve property access list", "propID", property.ID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (s *Server) putPropertyAccessList(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	renderCtx, _, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to update property access list", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	form := propertyAccessListRenderContext{
+		IPAllowlist: r.FormValue(common.ParamIPAllowlist),
+		IPDenylist:  r.FormValue(common.ParamIPDenylist),
+		ASNDenylist: r.FormValue(common.ParamASNDenylist),
+	}
+
+	params := &dbgen.UpsertPropertyAccessListParams{
+		Policy: parseAccessListPolicy(r.FormValue(common.ParamPolicy)),
+	}
+	form.Policy = string(params.Policy)
+
+	var errorMessage string
+	if params.IpAllowlist, errorMessage = parseIPList(form.IPAllowlist, db.MaxAccessListEntries); len(errorMessage) == 0 {
+		if params.IpDenylist, errorMessage = parseIPList(form.IPDenylist, db.MaxAccessListEntries); len(errorMessage) == 0 {
+			params.AsnDenylist, errorMessage = parseASNList(form.ASNDenylist)
+		}
+	}
+
+	if len(errorMessage) > 0 {
+		form.Error = errorMessage
+		renderCtx.AccessList = form
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	oldList, err := s.propertyAccessList(ctx, property)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to update access lists. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	list, auditEvent, err := s.Store.Impl().UpdatePropertyAccessList(ctx, user, property, oldList, params)
+	if err != nil {
+		renderCtx.AccessList = form
+		renderCtx.ErrorMessage = "Failed to update access lists. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	renderCtx.AccessList = newPropertyAccessListRenderContext(list)
+	renderCtx.SuccessMessage = "Access lists were updated."
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate, AuditEvent: auditEvent}, nil
+}
@@ -3,10 +3,12 @@
 package portal
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
@@ -81,9 +83,32 @@ func (s *Server) moveProperty(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var limits *db.PropertyMoveLimits
+	var limitsError string
+	if org.Sandbox || orgs[idx].Organization.Sandbox {
+		// otherwise sandbox would be a way around the properties limit (and moved properties would be wiped)
+		slog.WarnContext(ctx, "Attempt to move property to or from sandbox", "orgID", org.ID, "newOrgID", newOrgID)
+		limitsError = "Properties cannot be moved to or from the sandbox organization."
+	} else {
+		limits, limitsError = s.evaluatePropertyMove(ctx, user, property, &orgs[idx].Organization)
+	}
+
+	if len(limitsError) > 0 {
+		renderCtx := &propertySettingsRenderContext{
+			propertyDashboardRenderContext: propertyDashboardRenderContext{
+				AlertRenderContext: AlertRenderContext{ErrorMessage: limitsError},
+				Property:           propertyToUserProperty(property, s.IDHasher),
+				Org:                orgToUserOrg(org, user.ID, s.IDHasher),
+			},
+			Orgs: orgsToUserOrgs(orgs, s.IDHasher),
+		}
+		s.render(w, r, propertyMoveFormTemplate, renderCtx)
+		return
+	}
+
 	// TODO: Show user success message after property is moved to new org
 	// can put it in the session
-	if updatedProperty, auditEvent, err := s.Store.Impl().MoveProperty(ctx, user, property, orgs[idx]); err == nil {
+	if updatedProperty, auditEvent, err := s.Store.Impl().MoveProperty(ctx, user, property, org, orgs[idx], limits); err == nil {
 		propertyDashboardURL := s.PartsURL(common.OrgEndpoint, s.IDHasher.Encrypt(int(updatedProperty.OrgID.Int32)), common.PropertyEndpoint, s.IDHasher.Encrypt(int(updatedProperty.ID)))
 		common.Redirect(propertyDashboardURL, http.StatusOK, w, r)
 		s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
@@ -92,6 +117,128 @@ func (s *Server) moveProperty(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) monthlyRequestsUsage(ctx context.Context, userID int32, tnow time.Time) int64 {
+	monthStart := time.Date(tnow.Year(), tnow.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	sandboxIDs, err := s.Store.Impl().RetrieveUserSandboxOrgIDs(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve sandbox orgs", "userID", userID, common.ErrAttr(err))
+	}
+
+	stats, err := s.TimeSeries.RetrieveAccountStats(ctx, userID, monthStart, sandboxIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve account stats", "userID", userID, common.ErrAttr(err))
+		return 0
+	}
+
+	var count int64
+	for _, st := range stats {
+		count += int64(st.Count)
+	}
+
+	return count
+}
+
+// evaluatePropertyMove checks that the owner of the destination org can take the property on their plan. Usage is
+// attributed to the org owner at request time, so only the rest of the month is projected onto the new owner.
+// Returns a user-facing error if the move has to be rejected. Like other limit checks, it fails open on errors.
+func (s *Server) evaluatePropertyMove(ctx context.Context, user *dbgen.User, property *dbgen.Property, newOrg *dbgen.Organization) (*db.PropertyMoveLimits, string) {
+	limits := &db.PropertyMoveLimits{
+		OwnerChanged: property.OrgOwnerID.Int32 != newOrg.UserID.Int32,
+	}
+
+	if !limits.OwnerChanged {
+		return limits, ""
+	}
+
+	owner, subscr, err := s.Store.Impl().RetrieveOrgOwnerWithSubscription(ctx, newOrg, user)
+	if err != nil {
+		return limits, ""
+	}
+
+	ok, extra, err := s.SubscriptionLimits.CheckPropertiesLimit(ctx, owner.ID, subscr)
+	if err != nil {
+		if err == db.ErrNoActiveSubscription {
+			s.recordLimitDecision(ctx, &db.LimitDecision{
+				Resource:     db.LimitResourceProperties,
+				Code:         common.StatusSubscriptionPropertyLimitError,
+				UserID:       user.ID,
+				Org:          newOrg,
+				Subscription: subscr,
+				Requested:    1,
+				Err:          err,
+			})
+
+			return limits, "You need an active subscription to move properties to this organization."
+		}
+		return limits, ""
+	}
+
+	limits.PropertiesExtra = extra
+
+	if !ok {
+		slog.WarnContext(ctx, "Properties limit check failed for move", "extra", extra, "userID", owner.ID, "propID", property.ID,
+			"orgID", newOrg.ID, "subscriptionID", subscr.ID)
+
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceProperties,
+			Code:         common.StatusSubscriptionPropertyLimitError,
+			UserID:       user.ID,
+			Org:          newOrg,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    1,
+		})
+
+		return limits, "Properties limit reached on your current plan, please upgrade to move this property."
+	}
+
+	planLimits, err := s.SubscriptionLimits.Limits(ctx, subscr)
+	if err != nil {
+		return limits, ""
+	}
+
+	limits.PropertiesLimit = planLimits.Properties
+	limits.RequestsLimit = planLimits.Requests
+
+	if limits.RequestsLimit <= 0 {
+		return limits, ""
+	}
+
+	tnow := time.Now().UTC()
+	limits.RequestsUsed = s.monthlyRequestsUsage(ctx, owner.ID, tnow)
+
+	if stats, err := s.TimeSeries.RetrievePropertyStatsByPeriod(ctx, property.OrgID.Int32, property.ID, common.TimePeriodMonth); err == nil {
+		limits.RequestsProjected = db.ProjectMonthlyRequests(stats, tnow)
+	} else {
+		slog.ErrorContext(ctx, "Failed to retrieve property stats for move", "propID", property.ID, common.ErrAttr(err))
+	}
+
+	if !limits.CheckRequests() {
+		slog.WarnContext(ctx, "Requests limit check failed for move", "used", limits.RequestsUsed, "projected", limits.RequestsProjected,
+			"limit", limits.RequestsLimit, "userID", owner.ID, "propID", property.ID, "orgID", newOrg.ID)
+
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceRequests,
+			Code:         common.StatusSubscriptionRequestsLimitError,
+			UserID:       user.ID,
+			Org:          newOrg,
+			Subscription: subscr,
+			Extra:        int(limits.RequestsExtra()),
+			Requested:    int(limits.RequestsProjected),
+		})
+
+		return limits, "Traffic of this property would exceed monthly requests limit of your current plan, please upgrade to move it."
+	}
+
+	if limits.Grace {
+		slog.InfoContext(ctx, "Property is moved within requests limit grace", "used", limits.RequestsUsed,
+			"projected", limits.RequestsProjected, "limit", limits.RequestsLimit, "userID", owner.ID, "propID", property.ID)
+	}
+
+	return limits, ""
+}
+
 func (s *Server) getPropertyAuditLogs(w http.ResponseWriter, r *http.Request) (*propertyAuditLogsRenderContext, *common.AuditLogEvent, error) {
 	dashboardCtx, property, err := s.getOrgProperty(w, r)
 	if err != nil {
@@ -110,6 +257,7 @@ func (s *Server) getPropertyAuditLogs(w http.ResponseWriter, r *http.Request) (*
 		AuditLogsRenderContext: AuditLogsRenderContext{
 			AuditLogs: []*userAuditLog{},
 			SeeMore:   true,
+			Search:    auditLogsSearchFromParam(r),
 		},
 		CanView: (property.CreatorID.Int32 == user.ID) || (property.OrgOwnerID.Int32 == user.ID),
 	}
@@ -124,7 +272,12 @@ func (s *Server) getPropertyAuditLogs(w http.ResponseWriter, r *http.Request) (*
 	auditEvent := newAccessAuditLogEvent(user, db.TableNameProperties, int64(property.ID), property.Name, common.AuditLogsEndpoint)
 
 	const maxPropertyAuditLogs = 5
-	logs, err := s.Store.Impl().RetrievePropertyAuditLogs(ctx, property, maxPropertyAuditLogs)
+	var logs []*dbgen.GetPropertyAuditLogsRow
+	if search := renderCtx.Search; len(search) > 0 {
+		logs, err = s.Store.Impl().SearchPropertyAuditLogs(ctx, property, search, perPageEventLogs)
+	} else {
+		logs, err = s.Store.Impl().RetrievePropertyAuditLogs(ctx, property, maxPropertyAuditLogs)
+	}
 	if err != nil {
 		renderCtx.ErrorMessage = "Failed to retrieve property audit logs. Please try again later."
 		return renderCtx, auditEvent, nil
@@ -0,0 +1,307 @@
+package portal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	sharedReportsTemplate = "share/share.html"
+	shareLinkMACLen       = 16
+	// uuid + expiration + truncated MAC
+	shareTokenLen = 16 + 8 + shareLinkMACLen
+)
+
+var (
+	errInvalidShareToken = errors.New("share token is not valid")
+	shareLinkDurations   = []int{1, 7, 30}
+)
+
+type userShareLink struct {
+	ID        string
+	URL       string
+	CreatedAt string
+	ExpiresAt string
+}
+
+type sharedReportsRenderContext struct {
+	CsrfRenderContext
+	PropertyName string
+	Domain       string
+	StatsURL     string
+	ExpiresAt    string
+}
+
+func shareLinkMAC(key []byte, link *dbgen.PropertyShareLink) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("share-link"))
+	mac.Write(link.ExternalID.Bytes[:])
+	_ = binary.Write(mac, binary.BigEndian, link.ExpiresAt.Time.Unix())
+	_ = binary.Write(mac, binary.BigEndian, link.PropertyID)
+	return mac.Sum(nil)[:shareLinkMACLen]
+}
+
+// newShareToken binds link's identity with its expiration so that neither can be forged or extended
+func newShareToken(key []byte, link *dbgen.PropertyShareLink) string {
+	data := make([]byte, 0, shareTokenLen)
+	data = append(data, link.ExternalID.Bytes[:]...)
+	data = binary.BigEndian.AppendUint64(data, uint64(link.ExpiresAt.Time.Unix()))
+	data = append(data, shareLinkMAC(key, link)...)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseShareToken only checks the format and expiration, signature is verified against the stored link
+func parseShareToken(token string, tnow time.Time) (pgtype.UUID, []byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if (err != nil) || (len(data) != shareTokenLen) {
+		return pgtype.UUID{}, nil, errInvalidShareToken
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(data[16:24])), 0)
+	if !expiresAt.After(tnow) {
+		return pgtype.UUID{}, nil, errInvalidShareToken
+	}
+
+	uuid := pgtype.UUID{Valid: true}
+	copy(uuid.Bytes[:], data[:16])
+
+	return uuid, data[24:], nil
+}
+
+// shareTokenKey is derived from the XSRF key so that share tokens and XSRF tokens are never signed with the same key
+func (s *Server) shareTokenKey() []byte {
+	mac := hmac.New(sha256.New, []byte(s.XSRF.Key))
+	mac.Write([]byte("share-token-key"))
+	return mac.Sum(nil)
+}
+
+func (s *Server) shareLinkToUserShareLink(link *dbgen.PropertyShareLink) *userShareLink {
+	return &userShareLink{
+		ID:        s.IDHasher.Encrypt(int(link.ID)),
+		URL:       s.PartsURL(common.ShareEndpoint, newShareToken(s.shareTokenKey(), link)),
+		CreatedAt: link.CreatedAt.Time.Format("02 Jan 2006"),
+		ExpiresAt: link.ExpiresAt.Time.UTC().Format("02 Jan 2006 15:04 UTC"),
+	}
+}
+
+func (s *Server) propertyShareLinks(r *http.Request, property *dbgen.Property) []*userShareLink {
+	links, err := s.Store.Impl().RetrievePropertyShareLinks(r.Context(), property)
+	if err != nil {
+		return []*userShareLink{}
+	}
+
+	result := make([]*userShareLink, 0, len(links))
+	for _, link := range links {
+		result = append(result, s.shareLinkToUserShareLink(link))
+	}
+
+	return result
+}
+
+// sharedLink verifies the token from the path and returns the (not revoked) link with its property
+func (s *Server) sharedLink(r *http.Request) (*dbgen.PropertyShareLink, *dbgen.Property, error) {
+	ctx := r.Context()
+
+	token, err := common.StrPathArg(r, common.ParamToken)
+	if err != nil {
+		return nil, nil, errInvalidShareToken
+	}
+
+	tnow := time.Now().UTC()
+
+	externalID, mac, err := parseShareToken(token, tnow)
+	if err != nil {
+		slog.WarnContext(ctx, "Invalid share token", "length", len(token), common.ErrAttr(err))
+		return nil, nil, err
+	}
+
+	link, err := s.Store.Impl().RetrievePropertyShareLink(ctx, externalID)
+	if err != nil {
+		if (err == db.ErrNegativeCacheHit) || (err == db.ErrRecordNotFound) {
+			slog.WarnContext(ctx, "Share link not found", "externalID", db.UUIDToString(externalID))
+			return nil, nil, errInvalidShareToken
+		}
+		return nil, nil, err
+	}
+
+	if link.RevokedAt.Valid || !link.ExpiresAt.Time.After(tnow) || !hmac.Equal(mac, shareLinkMAC(s.shareTokenKey(), link)) {
+		slog.WarnContext(ctx, "Share token does not match the link", "linkID", link.ID)
+		return nil, nil, errInvalidShareToken
+	}
+
+	property, err := s.Store.Impl().RetrieveSharedProperty(ctx, link)
+	if err != nil {
+		if (err == db.ErrNegativeCacheHit) || (err == db.ErrRecordNotFound) || (err == db.ErrSoftDeleted) {
+			return nil, nil, errInvalidShareToken
+		}
+		return nil, nil, err
+	}
+
+	return link, property, nil
+}
+
+func (s *Server) getSharedPropertyReports(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	link, property, err := s.sharedLink(r)
+	if err != nil {
+		return nil, err
+	}
+
+	token := r.PathValue(common.ParamToken)
+
+	renderCtx := &sharedReportsRenderContext{
+		PropertyName: property.Name,
+		Domain:       property.Domain,
+		StatsURL:     s.PartsURL(common.ShareEndpoint, token, common.StatsEndpoint),
+		ExpiresAt:    link.ExpiresAt.Time.UTC().Format("02 Jan 2006 15:04 UTC"),
+	}
+
+	return &ViewModel{Model: renderCtx, View: sharedReportsTemplate}, nil
+}
+
+func (s *Server) getSharedPropertyStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	link, property, err := s.sharedLink(r)
+	if err != nil {
+		if err == errInvalidShareToken {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	period := parseStatsPeriod(ctx, r.PathValue(common.ParamPeriod))
+
+	etag := common.GenerateETag(strconv.Itoa(int(link.ID)), strconv.Itoa(int(property.ID)), period.String())
+	if etagHeader := r.Header.Get(common.HeaderIfNoneMatch); len(etagHeader) > 0 && (etagHeader == etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	cacheHeaders := map[string][]string{
+		common.HeaderETag:         []string{etag},
+		common.HeaderCacheControl: common.PrivateCacheControl1m,
+	}
+
+	common.SendJSONResponse(ctx, w, s.propertyStats(ctx, property.OrgID.Int32, property, period), cacheHeaders)
+}
+
+func (s *Server) postPropertyShareLink(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	renderCtx, _, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to share property reports", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	days, err := strconv.Atoi(r.FormValue(common.ParamDays))
+	if (err != nil) || !slices.Contains(shareLinkDurations, days) {
+		slog.WarnContext(ctx, "Invalid share link duration", "days", r.FormValue(common.ParamDays))
+		renderCtx.ErrorMessage = "Invalid share link duration."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	expiresAt := time.Now().UTC().AddDate(0, 0, days).Truncate(time.Second)
+
+	_, auditEvent, err := s.Store.Impl().CreatePropertyShareLink(ctx, user, property, expiresAt)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to create share link. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	renderCtx.ShareLinks = s.propertyShareLinks(r, property)
+	renderCtx.SuccessMessage = "Share link was created."
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate, AuditEvent: auditEvent}, nil
+}
+
+func (s *Server) deletePropertyShareLink(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	linkID, _, err := common.IntPathArg(r, common.ParamID, s.IDHasher)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse share link ID", common.ErrAttr(err))
+		return nil, errInvalidPathArg
+	}
+
+	renderCtx, _, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to revoke property share link", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	auditEvent, err := s.Store.Impl().DeletePropertyShareLink(ctx, user, property, linkID)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to revoke share link. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	renderCtx.ShareLinks = s.propertyShareLinks(r, property)
+	renderCtx.SuccessMessage = "Share link was revoked."
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate, AuditEvent: auditEvent}, nil
+}
@@ -0,0 +1,56 @@
+package portal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+func TestShareToken(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("key")
+	tnow := time.Now().UTC().Truncate(time.Second)
+
+	link := &dbgen.PropertyShareLink{
+		ID:         1,
+		ExternalID: db.UUIDFromString("8a4e1a0c6f0c4c579d3e0a6a4b8e2f11"),
+		PropertyID: 123,
+		ExpiresAt:  db.Timestampz(tnow.Add(24 * time.Hour)),
+	}
+
+	token := newShareToken(key, link)
+
+	externalID, mac, err := parseShareToken(token, tnow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if externalID != link.ExternalID {
+		t.Errorf("Unexpected external ID: %v", db.UUIDToString(externalID))
+	}
+
+	if string(mac) != string(shareLinkMAC(key, link)) {
+		t.Error("Signature does not match")
+	}
+
+	other := *link
+	other.PropertyID = 456
+	if string(mac) == string(shareLinkMAC(key, &other)) {
+		t.Error("Signature matches another property")
+	}
+
+	if string(mac) == string(shareLinkMAC([]byte("other"), link)) {
+		t.Error("Signature matches another key")
+	}
+
+	if _, _, err := parseShareToken(token, tnow.Add(25*time.Hour)); err != errInvalidShareToken {
+		t.Errorf("Expired token was parsed: %v", err)
+	}
+
+	if _, _, err := parseShareToken(token[1:], tnow); err != errInvalidShareToken {
+		t.Errorf("Malformed token was parsed: %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	clonePropertyHandlerID = "portal-clone-property"
+)
+
+type asyncTaskCloneProperty struct {
+	Targets    []*db.PropertyCloneTarget `json:"targets"`
+	OrgID      int32                     `json:"org_id"`
+	PropertyID int32                     `json:"property_id"`
+}
+
+type propertyCloneResult struct {
+	Domain string `json:"domain"`
+	Status string `json:"status"`
+}
+
+// domains are separated by newlines, commas or spaces, duplicates are removed
+func readPropertyCloneDomains(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return (r == ',') || unicode.IsSpace(r)
+	})
+
+	result := make([]string, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+
+	for _, field := range fields {
+		if _, ok := seen[field]; ok {
+			continue
+		}
+
+		seen[field] = struct{}{}
+		result = append(result, field)
+	}
+
+	return result
+}
+
+func (s *Server) postPropertyClone(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, _, err := s.getOrgPropertySettings(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// should hit cache right away
+	org, err := s.Org(user, r)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := s.Property(org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !renderCtx.CanEdit {
+		slog.WarnContext(ctx, "Insufficient permissions to clone property", "userID", user.ID,
+			"orgUserID", org.UserID.Int32, "propUserID", property.CreatorID.Int32)
+		renderCtx.ErrorMessage = common.StatusPropertyPermissionsError.String()
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	domains := readPropertyCloneDomains(r.FormValue(common.ParamDomains))
+	if len(domains) == 0 {
+		renderCtx.ErrorMessage = "Please enter at least one domain."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	if len(domains) > db.MaxPropertyCloneTargets {
+		slog.WarnContext(ctx, "Too many property clone domains", "count", len(domains), "max", db.MaxPropertyCloneTargets)
+		renderCtx.ErrorMessage = fmt.Sprintf("Property can be cloned to at most %d domains at once.", db.MaxPropertyCloneTargets)
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	targets := make([]*db.PropertyCloneTarget, 0, len(domains))
+	for _, value := range domains {
+		domain, err := common.ParseDomainName(value)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to parse domain name", "domain", value, common.ErrAttr(err))
+			renderCtx.ErrorMessage = fmt.Sprintf("%s: %s", value, common.StatusPropertyDomainFormatError.String())
+			return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+		}
+
+		// resolving up to a hundred domains synchronously is too slow
+		if status := s.validateDomainName(ctx, domain, true /*ignore resolve error*/); !status.Success() {
+			renderCtx.ErrorMessage = fmt.Sprintf("%s: %s", value, status.String())
+			return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+		}
+
+		targets = append(targets, &db.PropertyCloneTarget{Domain: domain})
+	}
+
+	if msg := s.validatePropertiesLimit(ctx, org, user); len(msg) > 0 {
+		renderCtx.ErrorMessage = msg
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	request := &asyncTaskCloneProperty{
+		Targets:    targets,
+		OrgID:      org.ID,
+		PropertyID: property.ID,
+	}
+
+	buffer := 5 * time.Minute
+	// we schedule it for later, making "room" for immediate attempt first
+	scheduledAt := time.Now().UTC().Add(buffer)
+	task, err := s.Store.Impl().CreateNewAsyncTask(ctx, request, clonePropertyHandlerID, user, scheduledAt, renderCtx.Property.ID /*referenceID*/)
+	if err != nil {
+		renderCtx.ErrorMessage = "Failed to start cloning. Please try again."
+		return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+	}
+
+	taskID := db.UUIDToString(task.ID)
+	slog.InfoContext(ctx, "Scheduled property clone", "propID", property.ID, "targets", len(targets), "taskID", taskID)
+
+	renderCtx.SuccessMessage = fmt.Sprintf("Cloning to %d domains has started. New properties will appear in the organization shortly.", len(targets))
+
+	go func(bctx context.Context) {
+		handlerCtx, cancel := context.WithTimeout(bctx, buffer)
+		defer cancel()
+		if err := s.AsyncTasks.Execute(handlerCtx, task); err != nil {
+			slog.ErrorContext(bctx, "Failed to execute async task", "taskID", taskID, common.ErrAttr(err))
+		}
+	}(common.CopyTraceID(ctx, context.Background()))
+
+	return &ViewModel{Model: renderCtx, View: propertyDashboardSettingsTemplate}, nil
+}
+
+func (s *Server) handleCloneProperty(ctx context.Context, task *dbgen.AsyncTask) ([]byte, error) {
+	taskID := db.UUIDToString(task.ID)
+	tlog := slog.With("taskID", taskID)
+
+	tlog.DebugContext(ctx, "Processing clone property task")
+
+	params := &asyncTaskCloneProperty{}
+	if err := json.Unmarshal(task.Input, params); err != nil {
+		tlog.ErrorContext(ctx, "Failed to unmarshal clone property async task input", common.ErrAttr(err))
+		return nil, err
+	}
+
+	user, err := s.Store.Impl().RetrieveUser(ctx, task.UserID.Int32)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve user", "userID", task.UserID.Int32, common.ErrAttr(err))
+		return nil, err
+	}
+
+	org, err := s.Store.Impl().RetrieveUserOrganization(ctx, user, params.OrgID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve org", "orgID", params.OrgID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	source, err := s.Store.Impl().RetrieveOrgProperty(ctx, org, params.PropertyID)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve source property", "propID", params.PropertyID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	results, err := s.doCloneProperty(ctx, tlog, user, org, source, params.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to serialize results", common.ErrAttr(err))
+		data = nil
+	}
+
+	return data, nil
+}
+
+func (s *Server) doCloneProperty(ctx context.Context, tlog *slog.Logger, user *dbgen.User, org *dbgen.Organization, source *dbgen.Property, targets []*db.PropertyCloneTarget) ([]*propertyCloneResult, error) {
+	owner, subscr, err := s.Store.Impl().RetrieveOrgOwnerWithSubscription(ctx, org, user)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to retrieve org owner with subscription", common.ErrAttr(err))
+		return nil, err
+	}
+
+	ok, extra, err := s.SubscriptionLimits.CheckOrgPropertiesLimit(ctx, org, owner.ID, subscr)
+	allowance := 0
+	if err == nil {
+		allowance = db.NewPropertiesAllowance(ok, extra, len(targets))
+	}
+
+	results := make([]*propertyCloneResult, len(targets))
+	createParams := make([]*dbgen.CreatePropertyParams, 0, allowance)
+	createIndices := make([]int, 0, allowance)
+
+	for i, target := range targets {
+		results[i] = &propertyCloneResult{Domain: target.Domain}
+
+		if len(createParams) >= allowance {
+			results[i].Status = common.StatusSubscriptionPropertyLimitError.String()
+			continue
+		}
+
+		createParams = append(createParams, db.ClonePropertyParams(source, user.ID, target))
+		createIndices = append(createIndices, i)
+	}
+
+	if limited := len(targets) - len(createParams); limited > 0 {
+		tlog.WarnContext(ctx, "Skipping property clone due to subscription limit", "subscrID", subscr.ID, "skipped", limited)
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource:     db.LimitResourceProperties,
+			Code:         common.StatusSubscriptionPropertyLimitError,
+			UserID:       user.ID,
+			Org:          org,
+			Subscription: subscr,
+			Extra:        extra,
+			Requested:    limited,
+			Err:          err,
+		})
+	}
+
+	if len(createParams) == 0 {
+		return results, nil
+	}
+
+	properties, auditEvents, err := s.Store.Impl().CreateNewProperties(ctx, createParams, org)
+	if err != nil {
+		tlog.ErrorContext(ctx, "Failed to create properties", "count", len(createParams), common.ErrAttr(err))
+	} else {
+		s.Store.AuditLog().RecordEvents(ctx, auditEvents, common.AuditLogSourcePortal)
+	}
+
+	for j, i := range createIndices {
+		switch {
+		case err != nil:
+			results[i].Status = common.StatusFailure.String()
+		case properties[j] == nil:
+			results[i].Status = common.StatusPropertyNameDuplicateError.String()
+		default:
+			results[i].Status = common.StatusOK.String()
+		}
+	}
+
+	tlog.InfoContext(ctx, "Cloned property", "propID", source.ID, "targets", len(targets), "created", len(auditEvents))
+
+	return results, nil
+}
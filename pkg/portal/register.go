@@ -209,6 +209,11 @@ func (s *Server) doRegister(ctx context.Context, sess *session.Session) (*dbgen.
 		return nil, nil, errIncompleteSession
 	}
 
+	return s.createAccount(ctx, email, name)
+}
+
+// createAccount creates user with an internal trial and their default organization
+func (s *Server) createAccount(ctx context.Context, email, name string) (*dbgen.User, *dbgen.Organization, error) {
 	plan := s.PlanService.GetInternalTrialPlan()
 	subscrParams := createInternalTrial(plan, s.PlanService.ActiveTrialStatus())
 
@@ -227,6 +232,8 @@ func (s *Server) doRegister(ctx context.Context, sess *session.Session) (*dbgen.
 		s.Store.AuditLog().RecordEvents(ctx, auditEvents, common.AuditLogSourcePortal)
 	}
 
+	s.joinEmailDomainOrg(ctx, user)
+
 	job := s.Jobs.OnboardUser(user, plan)
 	go common.RunOneOffJob(common.CopyTraceID(ctx, context.Background()), job, job.NewParams())
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
 )
@@ -17,6 +18,8 @@ type RenderConstants struct {
 	TwoFactorEndpoint          string
 	ResendEndpoint             string
 	RegisterEndpoint           string
+	SSOEndpoint                string
+	LinkEndpoint               string
 	SettingsEndpoint           string
 	LogoutEndpoint             string
 	NewEndpoint                string
@@ -49,26 +52,86 @@ type RenderConstants struct {
 	HeaderCSRFToken            string
 	UsageEndpoint              string
 	NotificationEndpoint       string
+	NotificationsEndpoint      string
+	Template                   string
 	ErrorEndpoint              string
 	ValidityInterval           string
 	AllowSubdomains            string
 	AllowLocalhost             string
 	AllowReplay                string
+	ImageChallenge             string
 	IgnoreError                string
 	Terms                      string
 	MaxReplayCount             string
 	MoveEndpoint               string
+	PromoteEndpoint            string
 	Org                        string
 	AuditLogsEndpoint          string
 	EventsEndpoint             string
 	Page                       string
 	ExportEndpoint             string
 	Scope                      string
+	Overlap                    string
+	ImportEndpoint             string
+	File                       string
 	APIKeyScopePuzzle          string
 	APIKeyScopePortalReadWrite string
 	APIKeyScopePortalReadOnly  string
 	PropertiesEndpoint         string
 	All                        string
+	BillingEndpoint            string
+	NotifyOwner                string
+	AllowlistEndpoint          string
+	RecoverEndpoint            string
+	Allowlist                  string
+	AdminEndpoint              string
+	AnnouncementsEndpoint      string
+	JobsEndpoint               string
+	TrialsEndpoint             string
+	Message                    string
+	Severity                   string
+	Markdown                   string
+	Start                      string
+	End                        string
+	Stage                      string
+	Product                    string
+	Passphrase                 string
+	FailureURL                 string
+	FailureMessage             string
+	MaxFailureMessageLength    int
+	Duration                   string
+	EmergencyEndpoint          string
+	Role                       string
+	Search                     string
+	Sort                       string
+	Order                      string
+	AccessListEndpoint         string
+	IPAllowlist                string
+	IPDenylist                 string
+	ASNDenylist                string
+	Policy                     string
+	BotPolicyMonitor           string
+	BotPolicyMaxDifficulty     string
+	BotPolicyBlock             string
+	ShareEndpoint              string
+	DomainsEndpoint            string
+	VerifyEndpoint             string
+	AutoJoin                   string
+	Digest                     string
+	DigestWeekly               string
+	DigestMonthly              string
+	Format                     string
+	SandboxEndpoint            string
+	PrivacyEndpoint            string
+	PrivacyMode                string
+	CloneEndpoint              string
+	Domains                    string
+	BypassEndpoint             string
+	MaxUses                    string
+	DowngradeEndpoint          string
+	Price                      string
+	Confirm                    string
+	SessionsEndpoint           string
 }
 
 func NewRenderConstants() *RenderConstants {
@@ -77,6 +140,8 @@ func NewRenderConstants() *RenderConstants {
 		TwoFactorEndpoint:          common.TwoFactorEndpoint,
 		ResendEndpoint:             common.ResendEndpoint,
 		RegisterEndpoint:           common.RegisterEndpoint,
+		SSOEndpoint:                common.SSOEndpoint,
+		LinkEndpoint:               common.LinkEndpoint,
 		SettingsEndpoint:           common.SettingsEndpoint,
 		LogoutEndpoint:             common.LogoutEndpoint,
 		OrgEndpoint:                common.OrgEndpoint,
@@ -109,26 +174,86 @@ func NewRenderConstants() *RenderConstants {
 		HeaderCSRFToken:            common.HeaderCSRFToken,
 		UsageEndpoint:              common.UsageEndpoint,
 		NotificationEndpoint:       common.NotificationEndpoint,
+		NotificationsEndpoint:      common.NotificationsEndpoint,
+		Template:                   common.ParamTemplate,
 		ErrorEndpoint:              common.ErrorEndpoint,
 		ValidityInterval:           common.ParamValidityInterval,
 		AllowSubdomains:            common.ParamAllowSubdomains,
 		AllowLocalhost:             common.ParamAllowLocalhost,
 		AllowReplay:                common.ParamAllowReplay,
+		ImageChallenge:             common.ParamImageChallenge,
 		IgnoreError:                common.ParamIgnoreError,
 		Terms:                      common.ParamTerms,
 		MaxReplayCount:             common.ParamMaxReplayCount,
 		MoveEndpoint:               common.MoveEndpoint,
+		PromoteEndpoint:            common.PromoteEndpoint,
 		Org:                        common.ParamOrg,
 		AuditLogsEndpoint:          common.AuditLogsEndpoint,
 		EventsEndpoint:             common.EventsEndpoint,
 		Page:                       common.ParamPage,
 		ExportEndpoint:             common.ExportEndpoint,
 		Scope:                      common.ParamScope,
+		Overlap:                    common.ParamOverlap,
+		ImportEndpoint:             common.ImportEndpoint,
+		File:                       common.ParamFile,
 		APIKeyScopePuzzle:          apiKeyScopePuzzle,
 		APIKeyScopePortalReadWrite: apiKeyScopePortal + apiKeyReadWriteSuffix,
 		APIKeyScopePortalReadOnly:  apiKeyScopePortal + apiKeyReadOnlySuffix,
 		PropertiesEndpoint:         common.PropertiesEndpoint,
 		All:                        common.All,
+		BillingEndpoint:            common.BillingEndpoint,
+		NotifyOwner:                common.ParamNotifyOwner,
+		AllowlistEndpoint:          common.AllowlistEndpoint,
+		RecoverEndpoint:            common.RecoverEndpoint,
+		Allowlist:                  common.ParamAllowlist,
+		AdminEndpoint:              common.AdminEndpoint,
+		AnnouncementsEndpoint:      common.AnnouncementsEndpoint,
+		JobsEndpoint:               common.JobsEndpoint,
+		TrialsEndpoint:             common.TrialsEndpoint,
+		Message:                    common.ParamMessage,
+		Severity:                   common.ParamSeverity,
+		Markdown:                   common.ParamMarkdown,
+		Start:                      common.ParamStart,
+		End:                        common.ParamEnd,
+		Stage:                      common.ParamStage,
+		Product:                    common.ParamProduct,
+		Passphrase:                 common.ParamPassphrase,
+		FailureURL:                 common.ParamFailureURL,
+		FailureMessage:             common.ParamFailureMessage,
+		MaxFailureMessageLength:    db.MaxFailureMessageLength,
+		Duration:                   common.ParamDuration,
+		EmergencyEndpoint:          common.EmergencyEndpoint,
+		Role:                       common.ParamRole,
+		Search:                     common.ParamSearch,
+		Sort:                       common.ParamSort,
+		Order:                      common.ParamOrder,
+		AccessListEndpoint:         common.AccessListEndpoint,
+		IPAllowlist:                common.ParamIPAllowlist,
+		IPDenylist:                 common.ParamIPDenylist,
+		ASNDenylist:                common.ParamASNDenylist,
+		Policy:                     common.ParamPolicy,
+		BotPolicyMonitor:           string(dbgen.BotPolicyMonitor),
+		BotPolicyMaxDifficulty:     string(dbgen.BotPolicyMaxDifficulty),
+		BotPolicyBlock:             string(dbgen.BotPolicyBlock),
+		ShareEndpoint:              common.ShareEndpoint,
+		DomainsEndpoint:            common.DomainsEndpoint,
+		VerifyEndpoint:             common.VerifyEndpoint,
+		AutoJoin:                   common.ParamAutoJoin,
+		Digest:                     common.ParamDigest,
+		DigestWeekly:               string(dbgen.DigestFrequencyWeekly),
+		DigestMonthly:              string(dbgen.DigestFrequencyMonthly),
+		Format:                     common.ParamFormat,
+		SandboxEndpoint:            common.SandboxEndpoint,
+		PrivacyEndpoint:            common.PrivacyEndpoint,
+		PrivacyMode:                common.ParamPrivacyMode,
+		CloneEndpoint:              common.CloneEndpoint,
+		Domains:                    common.ParamDomains,
+		BypassEndpoint:             common.BypassEndpoint,
+		MaxUses:                    common.ParamMaxUses,
+		DowngradeEndpoint:          common.DowngradeEndpoint,
+		Price:                      common.ParamPrice,
+		Confirm:                    common.ParamConfirm,
+		SessionsEndpoint:           common.SessionsEndpoint,
 	}
 }
 
@@ -171,6 +296,8 @@ func (s *Server) render(w http.ResponseWriter, r *http.Request, name string, dat
 		LoggedIn:    ok && loggedIn,
 		CurrentYear: time.Now().Year(),
 		CDN:         s.CDNURL,
+		ReadOnly:    s.isMaintenanceMode() || s.Standby,
+		Standby:     s.Standby,
 	}
 
 	if sess, found := s.Sessions.SessionGet(r); found {
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/api"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
 	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
@@ -165,6 +166,21 @@ func TestRenderHTML(t *testing.T) {
 			selector: "p.property-name",
 			matches:  []string{"1", "2"},
 		},
+		{
+			path:     []string{common.OrgEndpoint, "123"},
+			template: portalTemplate,
+			model: &orgDashboardRenderContext{
+				Orgs:       []*userOrg{stubOrgEx("123", dbgen.AccessLevelOwner)},
+				CurrentOrg: stubOrgEx("123", dbgen.AccessLevelOwner),
+				Properties: []*userProperty{},
+				Changelog: []*api.ChangelogEntry{
+					{Version: "1.1.0", Date: "2026-02-01", Changes: []*api.ChangelogChange{{Type: api.ChangeDeprecated, Description: "Test"}}},
+					{Version: "1.0.0", Date: "2026-01-01", Changes: []*api.ChangelogChange{{Type: api.ChangeAdded, Description: "Test"}}},
+				},
+			},
+			selector: "span.changelog-version",
+			matches:  []string{"1.1.0", "1.0.0"},
+		},
 		// same as above, but when Invited, we don't show properties
 		{
 			path:     []string{common.OrgEndpoint, "123"},
@@ -199,6 +215,29 @@ func TestRenderHTML(t *testing.T) {
 				CurrentOrg:        stubOrg("123"),
 				CsrfRenderContext: stubToken(),
 				CanEdit:           true,
+				IPAllowlist:       "203.0.113.0/24",
+				ClientIP:          "203.0.113.7",
+			},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.AllowlistEndpoint},
+			template: orgIPBlockedTemplate,
+			model: &orgIPBlockedRenderContext{
+				CurrentOrg:        stubOrg("123"),
+				CsrfRenderContext: stubToken(),
+				ClientIP:          "198.51.100.1",
+				CanRecover:        true,
+			},
+		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.AllowlistEndpoint, common.RecoverEndpoint, "token"},
+			template: orgIPBlockedTemplate,
+			model: &orgIPBlockedRenderContext{
+				CurrentOrg:        stubOrg("123"),
+				CsrfRenderContext: stubToken(),
+				ClientIP:          "198.51.100.1",
+				CanRecover:        true,
+				RecoveryToken:     "0123456789abcdef0123456789abcdef",
 			},
 		},
 		{
@@ -244,6 +283,27 @@ func TestRenderHTML(t *testing.T) {
 				Sitekey: "qwerty",
 			},
 		},
+		// property integrations with bypass tokens
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
+			template: propertyDashboardIntegrationsTemplate,
+			model: &propertyIntegrationsRenderContext{
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					AlertRenderContext: AlertRenderContext{
+						SuccessMessage: "Test",
+					},
+					CsrfRenderContext: stubToken(),
+					Property:          stubProperty("Foo", "123"),
+					Org:               stubOrg("123"),
+					CanEdit:           true,
+				},
+				Sitekey:        "qwerty",
+				NewBypassToken: "pcbt_qwerty",
+				BypassTokens: []*userBypassToken{
+					{ID: "789", Name: "CI", Uses: 1, MaxUses: 10, CreatedAt: "01 Jan 2026", ExpiresAt: "08 Jan 2026 12:00 UTC", LastUsedAt: "02 Jan 2026 12:00 UTC"},
+				},
+			},
+		},
 		// same as above, but property settings _template_
 		{
 			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
@@ -259,8 +319,27 @@ func TestRenderHTML(t *testing.T) {
 					Org:               stubOrg("123"),
 					CanEdit:           true,
 				},
+				ShareLinks: []*userShareLink{
+					{ID: "789", URL: "/share/qwerty", CreatedAt: "01 Jan 2026", ExpiresAt: "08 Jan 2026 12:00 UTC"},
+				},
 			},
 		},
+		{
+			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456", common.MoveEndpoint},
+			template: propertyMoveFormTemplate,
+			model: &propertySettingsRenderContext{
+				propertyDashboardRenderContext: propertyDashboardRenderContext{
+					AlertRenderContext: AlertRenderContext{
+						ErrorMessage: "Properties limit reached",
+					},
+					Property: stubProperty("Foo", "123"),
+					Org:      stubOrg("123"),
+				},
+				Orgs: []*userOrg{stubOrg("123"), stubOrg("456")},
+			},
+			selector: "#notification-message p",
+			matches:  []string{"Properties limit reached"},
+		},
 		// same as above, but property audit logs _template_
 		{
 			path:     []string{common.OrgEndpoint, "123", common.PropertyEndpoint, "456"},
@@ -283,6 +362,16 @@ func TestRenderHTML(t *testing.T) {
 				},
 			},
 		},
+		{
+			path:     []string{common.ShareEndpoint, "qwerty"},
+			template: sharedReportsTemplate,
+			model: &sharedReportsRenderContext{
+				PropertyName: "Foo",
+				Domain:       "example.com",
+				StatsURL:     "/share/qwerty/stats",
+				ExpiresAt:    "08 Jan 2026 12:00 UTC",
+			},
+		},
 		{
 			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint},
 			template: settingsGeneralTemplatePrefix + "page.html",
@@ -337,10 +426,124 @@ func TestRenderHTML(t *testing.T) {
 				IncludedOrgsCount:       10,
 				IncludedPropertiesCount: 50,
 				Limit:                   12345,
+				Quota: &settingsQuota{
+					Requests:        10000,
+					Limit:           12345,
+					Stage:           "warning",
+					BarPercent:      67,
+					WarningPercent:  66,
+					DegradedPercent: 83,
+					UsagePercent:    81,
+					UpdatedAt:       "02 Jan 2006 15:04 UTC",
+				},
 			},
 			selector: "",
 			matches:  []string{},
 		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.UsageEndpoint, common.DowngradeEndpoint},
+			template: settingsUsageDowngradeTemplate,
+			model: &settingsDowngradeRenderContext{
+				CsrfRenderContext: stubToken(),
+				CurrentPlan:       "Business",
+				TargetPlan:        "Starter",
+				ProductID:         "product",
+				PriceID:           "price",
+				Impact: &db.DowngradeImpact{
+					PropertiesCount: 12,
+					PropertiesLimit: 10,
+					FrozenProperties: []*dbgen.GetUserPropertiesOverLimitRow{
+						{Name: "foo", OrgName: "Org"},
+						{Name: "bar", OrgName: "Org"},
+					},
+					APIKeys:    []*dbgen.APIKey{{Name: "key", RequestsPerSecond: 20}},
+					Reductions: []*db.PlanReduction{{Name: "Properties", Current: "50", Target: "10"}},
+				},
+			},
+			selector: "li.downgrade-property",
+			matches:  []string{"foo (Org)", "bar (Org)"},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.NotificationsEndpoint},
+			template: settingsNotificationsTemplatePrefix + "page.html",
+			model: &settingsNotificationsRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.NotificationsEndpoint,
+					Tabs:              CreateTabViewModels(common.NotificationsEndpoint, server.SettingsTabs),
+				},
+				Preferences: []*userNotificationPreference{
+					{Name: "foo", Title: "Foo", Description: "Foo emails", Enabled: true},
+					{Name: "bar", Title: "Bar", Description: "Bar emails", Enabled: false},
+				},
+			},
+			selector: "label.notification-title",
+			matches:  []string{"Foo", "Bar"},
+		},
+		{
+			path:     []string{common.SettingsEndpoint, common.TabEndpoint, common.SessionsEndpoint},
+			template: settingsSessionsTemplatePrefix + "page.html",
+			model: &settingsSessionsRenderContext{
+				SettingsCommonRenderContext: SettingsCommonRenderContext{
+					CsrfRenderContext: stubToken(),
+					Email:             "foo@bar.com",
+					ActiveTabID:       common.SessionsEndpoint,
+					Tabs:              CreateTabViewModels(common.SessionsEndpoint, server.SettingsTabs),
+				},
+				Sessions: []*userSession{
+					{ID: "abc", Browser: "Firefox", OS: "Linux", Country: "DE", LastSeen: "02 Jan 2026 10:00", Current: true, KnownDevice: true, FirstSeen: "01 Jan 2026"},
+					{ID: "def"},
+				},
+			},
+			selector: "p.session-name",
+			matches:  []string{"Firefox on Linux", "Unknown browser"},
+		},
+		{
+			path:     []string{common.AdminEndpoint, common.AnnouncementsEndpoint},
+			template: announcementsTemplate,
+			model: &announcementsRenderContext{
+				CsrfRenderContext: stubToken(),
+				Announcements: []*userAnnouncement{
+					{ID: "abc", Message: "<strong>Maintenance</strong> tonight", Severity: "warning", Start: "01 Jan 2026 10:00", Audience: announcementAudienceNone},
+					{ID: "def", Message: "New plan", Severity: "info", Start: "02 Jan 2026 10:00", End: "03 Jan 2026 10:00", Audience: "stage test", Scheduled: true},
+				},
+				Severities: []string{"info", "warning", "critical"},
+				Stage:      common.StageTest,
+			},
+			selector: "p.announcement-message",
+			matches:  []string{"Maintenance tonight", "New plan"},
+		},
+		{
+			path:     []string{common.AdminEndpoint, common.JobsEndpoint},
+			template: adminJobsTemplate,
+			model: &adminJobsRenderContext{
+				CsrfRenderContext: stubToken(),
+				Node:              "node-1",
+				Jobs: []*adminJob{
+					{Name: "garbage_collect_data", Kind: "locked", Runs: 3, LastRun: "01 Jan 2026 10:00:00", Duration: "1.5s", NextRun: "02 Jan 2026 10:00:00", LockHolder: "node-2", Triggerable: true},
+					{Name: "health_check_job", Kind: "spawned", Runs: 10, Failures: 1, LastError: "timeout", LastErrorAt: "01 Jan 2026 09:00:00"},
+				},
+			},
+			selector: "p.job-name",
+			matches:  []string{"garbage_collect_data", "health_check_job"},
+		},
+		{
+			path:     []string{common.AdminEndpoint, common.EmailEndpoint},
+			template: adminEmailTemplate,
+			model: &adminEmailRenderContext{
+				CsrfRenderContext: stubToken(),
+				Email:             "admin@example.com",
+				AnyProvider:       adminEmailAnyProvider,
+				Providers: []*adminEmailProvider{
+					{Name: "ses", Configured: true, Healthy: true, Sent: 10, LastSuccess: "01 Jan 2026 10:00:00"},
+					{Name: "smtp", Configured: true, Failed: 2, Failures: 2, LastError: "connection refused", LastFailure: "01 Jan 2026 09:00:00"},
+					{Name: "mailgun"},
+				},
+			},
+			selector: "p.email-provider",
+			matches:  []string{"ses", "smtp", "mailgun"},
+		},
 		{
 			path:     []string{common.AuditLogsEndpoint},
 			template: auditLogsTemplate,
@@ -387,3 +590,29 @@ func TestRenderHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderReadOnlyBanner(t *testing.T) {
+	model := &orgDashboardRenderContext{
+		Orgs:       []*userOrg{stubOrg("123")},
+		CurrentOrg: stubOrg("123"),
+		Properties: []*userProperty{stubProperty("1", "123")},
+	}
+
+	for _, readOnly := range []bool{false, true} {
+		reqCtx := &RequestContext{Path: server.RelURL(common.OrgEndpoint + "/123"), LoggedIn: true, ReadOnly: readOnly}
+		buf, err := server.RenderResponse(t.Context(), portalTemplate, model, reqCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		document := portal_tests.ParseHTML(t, buf)
+		if found := len(document.Find("#read-only-banner").Nodes) > 0; found != readOnly {
+			t.Errorf("Unexpected read-only banner presence (%v) for read-only %v", found, readOnly)
+		}
+
+		// cached properties are still visible
+		if len(document.Find("p.property-name").Nodes) != 1 {
+			t.Errorf("Expected property to be rendered (read-only %v)", readOnly)
+		}
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/puzzle"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/ratelimit"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/sso"
 )
 
 var (
@@ -62,7 +63,7 @@ type ViewModel struct {
 	AuditEvent *common.AuditLogEvent
 }
 type ViewModelHandler func(http.ResponseWriter, *http.Request) (*ViewModel, error)
-type AuditLogsConstructor func(context.Context, *dbgen.User, int, int) (*MainAuditLogsRenderContext, error)
+type AuditLogsConstructor func(ctx context.Context, user *dbgen.User, days int, page int, search string) (*MainAuditLogsRenderContext, error)
 
 type RequestContext struct {
 	Path        string
@@ -71,6 +72,10 @@ type RequestContext struct {
 	UserName    string
 	UserEmail   string
 	CDN         string
+	// portal serves last-known data from cache while the database is in maintenance
+	ReadOnly bool
+	// portal serves data from a database replica during disaster recovery
+	Standby bool
 }
 
 type PaginationRenderContext struct {
@@ -86,8 +91,9 @@ type CsrfRenderContext struct {
 }
 
 type systemNotificationContext struct {
-	Notification   string
-	NotificationID string
+	Notification         string
+	NotificationID       string
+	NotificationSeverity string
 }
 
 type AlertRenderContext struct {
@@ -145,6 +151,21 @@ type Server struct {
 	AuditLogsFunc      AuditLogsConstructor
 	SubscriptionLimits db.SubscriptionLimits
 	EmailVerifier      common.EmailVerifier
+	AsyncTasks         db.AsyncTasks
+	LoadShedder        *common.LoadShedder
+	AdminEmail         common.ConfigItem
+	EmailFrom          common.ConfigItem
+	// nil when emails are not sent by this process
+	EmailProviders EmailProviders
+	// nil when background jobs are not running together with portal
+	MaintenanceJobs MaintenanceJobs
+	// nil if single sign-on is not configured
+	SSO             *sso.OIDCProvider
+	SSODefaultOrg   common.ConfigItem
+	SSOProvisioning common.ConfigItem
+	PrivacyMode     common.ConfigItem
+	// standby deployment serves portal from a read-only database replica (disaster recovery)
+	Standby bool
 }
 
 func (s *Server) createSettingsTabs() []*SettingsTab {
@@ -167,6 +188,18 @@ func (s *Server) createSettingsTabs() []*SettingsTab {
 			TemplatePrefix: settingsUsageTemplatePrefix,
 			ModelHandler:   s.getUsageSettings,
 		},
+		{
+			ID:             common.NotificationsEndpoint,
+			Name:           "Notifications",
+			TemplatePrefix: settingsNotificationsTemplatePrefix,
+			ModelHandler:   s.getNotificationsSettings,
+		},
+		{
+			ID:             common.SessionsEndpoint,
+			Name:           "Sessions",
+			TemplatePrefix: settingsSessionsTemplatePrefix,
+			ModelHandler:   s.getSessionsSettings,
+		},
 	}
 }
 
@@ -188,6 +221,8 @@ func (s *Server) Init(ctx context.Context, templateBuilder *TemplatesBuilder, gi
 	s.RenderConstants = NewRenderConstants()
 	s.AuditLogsFunc = s.CreateAuditLogsContext
 
+	s.RegisterTaskHandlers(ctx)
+
 	platformCtx := &PlatformRenderContext{
 		GitCommit:  gitCommit,
 		Enterprise: s.isEnterprise(),
@@ -206,7 +241,7 @@ func (s *Server) UpdateConfig(ctx context.Context, cfg common.ConfigStore) {
 	oldMaintenanceMode := s.maintenanceMode.Swap(maintenanceMode)
 
 	registrationAllowed := config.AsBool(cfg.Get(common.RegistrationAllowedKey))
-	s.canRegister.Store(registrationAllowed)
+	s.canRegister.Store(registrationAllowed && !s.Standby)
 
 	if oldMaintenanceMode != maintenanceMode {
 		slog.InfoContext(ctx, "Maintenance mode change", "old", oldMaintenanceMode, "new", maintenanceMode)
@@ -254,14 +289,26 @@ func (s *Server) MiddlewarePublicChain(rg *common.RouteGenerator, security alice
 	return alice.New(svc, common.Recovered, security, s.Metrics.HandlerIDFunc(rg.LastPath), ratelimiter, cop.Handler, monitoring.Logged)
 }
 
+func (s *Server) MiddlewareSharedChain(rg *common.RouteGenerator, security alice.Constructor) alice.Chain {
+	const (
+		sharedLeakyBucketCap = 5
+		sharedLeakInterval   = 10 * time.Second
+	)
+
+	ratelimiter := s.RateLimiter.RateLimitExFunc(sharedLeakyBucketCap, sharedLeakInterval)
+	svc := common.ServiceMiddleware(PortalService)
+
+	return alice.New(svc, common.Recovered, security, s.Metrics.HandlerIDFunc(rg.LastPath), ratelimiter, monitoring.Logged)
+}
+
 func (s *Server) MiddlewarePrivateRead(public alice.Chain) alice.Chain {
 	internalTimeout := common.TimeoutHandler(10 * time.Second)
-	return public.Append(s.maintenance, internalTimeout, s.private)
+	return public.Append(s.maintenanceReadOnly, internalTimeout, s.private)
 }
 
 func (s *Server) MiddlewarePrivateWrite(public alice.Chain) alice.Chain {
 	internalTimeout := common.TimeoutHandler(10 * time.Second)
-	return public.Append(s.maintenance, defaultMaxBytesHandler, internalTimeout, s.csrf(s.csrfUserIDKeyFunc), s.private)
+	return public.Append(s.maintenance, s.standbyReadOnly, defaultMaxBytesHandler, internalTimeout, s.csrf(s.csrfUserIDKeyFunc), s.private)
 }
 
 func (s *Server) setupWithPrefix(rg *common.RouteGenerator, security alice.Constructor) {
@@ -273,19 +320,32 @@ func (s *Server) setupWithPrefix(rg *common.RouteGenerator, security alice.Const
 
 	// separately configured "public" ones
 	public := s.MiddlewarePublicChain(rg, security)
+	// under overload, fragments (tabs, charts) are shed before full pages and actions
+	normal := public.Append(s.LoadShedder.Middleware(common.PriorityNormal))
+	low := public.Append(s.LoadShedder.Middleware(common.PriorityLow))
 	publicTimeout := common.TimeoutHandler(2 * time.Second)
-	openRead := public.Append(s.maintenance, publicTimeout)
+	openRead := normal.Append(s.maintenance, publicTimeout)
 	rg.Handle(rg.Get(common.LoginEndpoint), openRead.Append(common.Cached), s.Handler(s.getLogin))
 	rg.Handle(rg.Get(common.RegisterEndpoint), openRead.Append(common.Cached), s.Handler(s.getRegister))
 	rg.Handle(rg.Get(common.ErrorEndpoint, arg(common.ParamCode)), public, http.HandlerFunc(s.error))
 	rg.Handle(rg.Get(common.ExpiredEndpoint), public, http.HandlerFunc(s.expired))
 	rg.Handle(rg.Get(common.LogoutEndpoint), public, http.HandlerFunc(s.logout))
+	rg.Handle(rg.Get(common.SSOEndpoint), openRead, http.HandlerFunc(s.getSSOLogin))
+	rg.Handle(rg.Get(common.SSOEndpoint, common.CallbackEndpoint), openRead, http.HandlerFunc(s.getSSOCallback))
+
+	// share links are accessible without login so they are rate limited much stricter than the rest
+	shared := s.MiddlewareSharedChain(rg, security).Append(s.LoadShedder.Middleware(common.PriorityLow), s.maintenance, publicTimeout)
+	rg.Handle(rg.Get(common.ShareEndpoint, arg(common.ParamToken)), shared, s.Handler(s.getSharedPropertyReports))
+	rg.Handle(rg.Get(common.ShareEndpoint, arg(common.ParamToken), common.StatsEndpoint, arg(common.ParamPeriod)), shared, http.HandlerFunc(s.getSharedPropertyStats))
 
 	// openWrite is protected by captcha, other "write" handlers are protected by CSRF token / auth
-	openWrite := public.Append(s.maintenance, defaultMaxBytesHandler, publicTimeout)
+	openWrite := normal.Append(s.maintenance, s.standbyReadOnly, defaultMaxBytesHandler, publicTimeout)
 	csrfEmail := openWrite.Append(s.csrf(s.csrfUserEmailKeyFunc))
-	privateWrite := s.MiddlewarePrivateWrite(public)
-	privateRead := s.MiddlewarePrivateRead(public)
+	privateWrite := s.MiddlewarePrivateWrite(normal)
+	privateRead := s.MiddlewarePrivateRead(normal)
+	fragmentRead := s.MiddlewarePrivateRead(low)
+	unrestrictedRead := normal.Append(s.maintenance, common.TimeoutHandler(10*time.Second), s.privateUnrestricted)
+	unrestrictedWrite := normal.Append(s.maintenance, s.standbyReadOnly, defaultMaxBytesHandler, common.TimeoutHandler(10*time.Second), s.csrf(s.csrfUserIDKeyFunc), s.privateUnrestricted)
 
 	rg.Handle(rg.Post(common.LoginEndpoint), openWrite, http.HandlerFunc(s.postLogin))
 	rg.Handle(rg.Post(common.RegisterEndpoint), openWrite, http.HandlerFunc(s.postRegister))
@@ -293,34 +353,70 @@ func (s *Server) setupWithPrefix(rg *common.RouteGenerator, security alice.Const
 	rg.Handle(rg.Post(common.ResendEndpoint), csrfEmail, http.HandlerFunc(s.resend2fa))
 	rg.Handle(rg.Get(common.OrgEndpoint, common.NewEndpoint), privateRead, s.Handler(s.getNewOrg))
 	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg)), privateRead, http.HandlerFunc(s.getPortal))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.DashboardEndpoint), privateRead, s.Handler(s.getOrgDashboard))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.MembersEndpoint), privateRead, s.Handler(s.getOrgMembers))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.SettingsEndpoint), privateRead, s.Handler(s.getOrgSettings))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.EventsEndpoint), privateRead, s.Handler(s.getOrgAuditLogs))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.DashboardEndpoint), fragmentRead, s.Handler(s.getOrgDashboard))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.MembersEndpoint), fragmentRead, s.Handler(s.getOrgMembers))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.SettingsEndpoint), fragmentRead, s.Handler(s.getOrgSettings))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.TabEndpoint, common.EventsEndpoint), fragmentRead, s.Handler(s.getOrgAuditLogs))
 	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.EditEndpoint), privateWrite, s.Handler(s.putOrg))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.BillingEndpoint), privateWrite, s.Handler(s.postOrgBillingContact))
+	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.BillingEndpoint), privateWrite, s.Handler(s.putOrgBillingSettings))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.BillingEndpoint, arg(common.ParamID)), privateWrite, s.Handler(s.deleteOrgBillingContact))
+	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.AllowlistEndpoint), privateWrite, s.Handler(s.putOrgIPAllowlist))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.AllowlistEndpoint), unrestrictedRead, s.Handler(s.getOrgIPBlocked))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.AllowlistEndpoint, common.RecoverEndpoint), unrestrictedWrite, s.Handler(s.postOrgIPAllowlistRecovery))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.AllowlistEndpoint, common.RecoverEndpoint, arg(common.ParamToken)), unrestrictedRead, s.Handler(s.getOrgIPAllowlistRecovery))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.AllowlistEndpoint, common.RecoverEndpoint, arg(common.ParamToken)), unrestrictedWrite, s.Handler(s.postOrgIPAllowlistReset))
 	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertiesEndpoint), privateRead, s.Handler(s.getOrgProperties))
 	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, common.NewEndpoint), privateRead, s.Handler(s.getNewOrgProperty))
 	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, common.NewEndpoint), privateWrite, http.HandlerFunc(s.postNewOrgProperty))
 	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty)), privateRead, s.Handler(s.getPropertyDashboard))
 	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.EditEndpoint), privateWrite, s.Handler(s.putProperty))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.PromoteEndpoint), privateWrite, s.Handler(s.promoteProperty))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.EmergencyEndpoint), privateWrite, s.Handler(s.postPropertyEmergency))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.EmergencyEndpoint), privateWrite, s.Handler(s.deletePropertyEmergency))
+	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.PrivacyEndpoint), privateWrite, s.Handler(s.putPropertyPrivacy))
+	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.AccessListEndpoint), privateWrite, s.Handler(s.putPropertyAccessList))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.ShareEndpoint), privateWrite, s.Handler(s.postPropertyShareLink))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.ShareEndpoint, arg(common.ParamID)), privateWrite, s.Handler(s.deletePropertyShareLink))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.BypassEndpoint), privateWrite, s.Handler(s.postPropertyBypassToken))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.BypassEndpoint, arg(common.ParamID)), privateWrite, s.Handler(s.deletePropertyBypassToken))
 	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.DeleteEndpoint), privateWrite, http.HandlerFunc(s.deleteProperty))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.ReportsEndpoint), privateRead, s.Handler(s.getPropertyReportsTab))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.SettingsEndpoint), privateRead, s.Handler(s.getPropertySettingsTab))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.IntegrationsEndpoint), privateRead, s.Handler(s.getPropertyIntegrationsTab))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.EventsEndpoint), privateRead, s.Handler(s.getPropertyAuditLogsTab))
-	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.StatsEndpoint, arg(common.ParamPeriod)), privateRead, http.HandlerFunc(s.getPropertyStats))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.ReportsEndpoint), fragmentRead, s.Handler(s.getPropertyReportsTab))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.SettingsEndpoint), fragmentRead, s.Handler(s.getPropertySettingsTab))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.IntegrationsEndpoint), fragmentRead, s.Handler(s.getPropertyIntegrationsTab))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.TabEndpoint, common.EventsEndpoint), fragmentRead, s.Handler(s.getPropertyAuditLogsTab))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.StatsEndpoint, arg(common.ParamPeriod)), fragmentRead, http.HandlerFunc(s.getPropertyStats))
 
+	rg.Handle(rg.Get(common.SSOEndpoint, common.LinkEndpoint), privateRead, http.HandlerFunc(s.getSSOLink))
 	rg.Handle(rg.Get(common.SettingsEndpoint), privateRead, s.Handler(s.getSettings))
-	rg.Handle(rg.Get(common.SettingsEndpoint, common.TabEndpoint, arg(common.ParamTab)), privateRead, s.Handler(s.getSettingsTab))
+	rg.Handle(rg.Get(common.SettingsEndpoint, common.TabEndpoint, arg(common.ParamTab)), fragmentRead, s.Handler(s.getSettingsTab))
 	rg.Handle(rg.Post(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint, common.EmailEndpoint), privateWrite, s.Handler(s.editEmail))
 	rg.Handle(rg.Put(common.SettingsEndpoint, common.TabEndpoint, common.GeneralEndpoint), privateWrite, s.Handler(s.putGeneralSettings))
 	rg.Handle(rg.Post(common.SettingsEndpoint, common.TabEndpoint, common.APIKeysEndpoint, common.NewEndpoint), privateWrite, s.Handler(s.postAPIKeySettings))
+	rg.Handle(rg.Put(common.SettingsEndpoint, common.TabEndpoint, common.NotificationsEndpoint), privateWrite, s.Handler(s.putNotificationsSettings))
+	rg.Handle(rg.Get(common.SettingsEndpoint, common.TabEndpoint, common.UsageEndpoint, common.DowngradeEndpoint), fragmentRead, s.Handler(s.getUsageDowngrade))
+	rg.Handle(rg.Post(common.SettingsEndpoint, common.TabEndpoint, common.UsageEndpoint, common.DowngradeEndpoint), privateWrite, s.Handler(s.postUsageDowngrade))
 
 	rg.Handle(rg.Get(common.AuditLogsEndpoint), privateRead, s.Handler(s.getAuditLogs))
 
-	rg.Handle(rg.Get(common.UserEndpoint, common.StatsEndpoint), privateRead, http.HandlerFunc(s.getAccountStats))
+	rg.Handle(rg.Get(common.AdminEndpoint, common.AnnouncementsEndpoint), privateRead, s.Handler(s.getAnnouncements))
+	rg.Handle(rg.Post(common.AdminEndpoint, common.AnnouncementsEndpoint), privateWrite, s.Handler(s.postAnnouncement))
+	rg.Handle(rg.Delete(common.AdminEndpoint, common.AnnouncementsEndpoint, arg(common.ParamID)), privateWrite, s.Handler(s.deleteAnnouncement))
+	rg.Handle(rg.Get(common.AdminEndpoint, common.LimitsEndpoint), privateRead, http.HandlerFunc(s.getLimitDecisions))
+	rg.Handle(rg.Get(common.AdminEndpoint, common.TraceEndpoint), privateRead, http.HandlerFunc(s.getTrace))
+	rg.Handle(rg.Delete(common.AdminEndpoint, common.UserEndpoint), privateWrite, http.HandlerFunc(s.hardDeleteUser))
+	rg.Handle(rg.Get(common.AdminEndpoint, common.JobsEndpoint), privateRead, s.Handler(s.getAdminJobs))
+	rg.Handle(rg.Get(common.AdminEndpoint, common.JobsEndpoint, common.StatusEndpoint), privateRead, http.HandlerFunc(s.getAdminJobsStatus))
+	rg.Handle(rg.Post(common.AdminEndpoint, common.JobsEndpoint, arg(common.ParamName)), privateWrite, s.Handler(s.postAdminJobRun))
+	rg.Handle(rg.Get(common.AdminEndpoint, common.EmailEndpoint), privateRead, s.Handler(s.getAdminEmail))
+	rg.Handle(rg.Post(common.AdminEndpoint, common.EmailEndpoint), privateWrite, s.Handler(s.postAdminTestEmail))
+
+	rg.Handle(rg.Get(common.UserEndpoint, common.StatsEndpoint), fragmentRead, http.HandlerFunc(s.getAccountStats))
+	rg.Handle(rg.Get(common.UserEndpoint, common.ExportEndpoint), privateRead, http.HandlerFunc(s.exportAccountData))
 	rg.Handle(rg.Post(common.APIKeysEndpoint, arg(common.ParamKey)), privateWrite, s.Handler(s.rotateAPIKey))
 	rg.Handle(rg.Delete(common.APIKeysEndpoint, arg(common.ParamKey)), privateWrite, http.HandlerFunc(s.deleteAPIKey))
+	rg.Handle(rg.Delete(common.SessionsEndpoint, arg(common.ParamID)), privateWrite, http.HandlerFunc(s.deleteUserSession))
+	rg.Handle(rg.Delete(common.SessionsEndpoint), privateWrite, s.Handler(s.deleteOtherUserSessions))
 	rg.Handle(rg.Delete(common.UserEndpoint), privateWrite, http.HandlerFunc(s.deleteAccount))
 	rg.Handle(rg.Delete(common.NotificationEndpoint, arg(common.ParamID)), openWrite.Append(s.private), http.HandlerFunc(s.dismissNotification))
 	rg.Handle(rg.Post(common.ErrorEndpoint), privateRead, http.HandlerFunc(s.postClientSideError))
@@ -338,6 +434,7 @@ func (s *Server) setupCatchAllWithPrefix(router *http.ServeMux, prefix string, c
 	router.Handle(http.MethodGet+" "+prefix+common.ErrorEndpoint+"/", chain.ThenFunc(s.notFound))
 	router.Handle(http.MethodGet+" "+prefix+common.SettingsEndpoint+"/", chain.ThenFunc(s.notFound))
 	router.Handle(http.MethodGet+" "+prefix+common.UserEndpoint+"/", chain.ThenFunc(s.notFound))
+	router.Handle(http.MethodGet+" "+prefix+common.ShareEndpoint+"/", chain.ThenFunc(s.notFound))
 }
 
 func (s *Server) isMaintenanceMode() bool {
@@ -370,7 +467,7 @@ func (s *Server) Handler(modelFunc ViewModelHandler) http.Handler {
 				s.RedirectError(http.StatusNotAcceptable, w, r)
 			case db.ErrMaintenance:
 				s.RedirectError(http.StatusServiceUnavailable, w, r)
-			case errRegistrationDisabled:
+			case errRegistrationDisabled, errInvalidShareToken:
 				s.RedirectError(http.StatusNotFound, w, r)
 			case errLimitedFeature:
 				s.RedirectError(http.StatusPaymentRequired, w, r)
@@ -405,7 +502,42 @@ func (s *Server) maintenance(next http.Handler) http.Handler {
 	})
 }
 
+// maintenanceReadOnly lets signed-in users view cached pages (e.g. their sitekeys) during maintenance
+func (s *Server) maintenanceReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.isMaintenanceMode() && (r.Method != http.MethodGet) && (r.Method != http.MethodHead) {
+			slog.Log(r.Context(), common.LevelTrace, "Rejecting request under maintenance mode", "method", r.Method)
+			s.RedirectError(http.StatusServiceUnavailable, w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// standbyReadOnly rejects all changes as database is a read-only replica in standby
+func (s *Server) standbyReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Standby && (r.Method != http.MethodGet) && (r.Method != http.MethodHead) {
+			slog.Log(r.Context(), common.LevelTrace, "Rejecting request in standby mode", "method", r.Method)
+			s.RedirectError(http.StatusServiceUnavailable, w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) private(next http.Handler) http.Handler {
+	return s.privateEx(next, true /*checkIPAllowlist*/)
+}
+
+// privateUnrestricted is used only for pages that let users, blocked by org IP allowlist, recover access
+func (s *Server) privateUnrestricted(next http.Handler) http.Handler {
+	return s.privateEx(next, false /*checkIPAllowlist*/)
+}
+
+func (s *Server) privateEx(next http.Handler, checkIPAllowlist bool) http.Handler {
 	const (
 		// "authenticated" means when we "legitimize" IP address using business logic
 		authenticatedBucketCap = 20
@@ -428,13 +560,21 @@ func (s *Server) private(next http.Handler) http.Handler {
 			}
 
 			if step == loginStepCompleted {
+				s.Sessions.Touch(ctx, sess, time.Now().UTC())
+
 				// update limits each time as rate limiting gets cleaned up frequently (impact shouldn't be much in portal)
 				s.RateLimiter.UpdateRequestLimits(r, authenticatedBucketCap, authenticatedLeakInterval)
 
 				ctx = context.WithValue(ctx, common.LoggedInContextKey, true)
 				ctx = context.WithValue(ctx, common.SessionContextKey, sess)
+				r = r.WithContext(ctx)
+
+				if checkIPAllowlist && !s.isOrgIPAllowed(r) {
+					common.Redirect(s.PartsURL(common.OrgEndpoint, r.PathValue(common.ParamOrg), common.AllowlistEndpoint), http.StatusForbidden, w, r)
+					return
+				}
 
-				next.ServeHTTP(w, r.WithContext(ctx))
+				next.ServeHTTP(w, r)
 				return
 			} else {
 				slog.WarnContext(ctx, "Session present, but login not finished", "step", step)
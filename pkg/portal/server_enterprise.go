@@ -5,6 +5,7 @@ package portal
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -43,13 +44,37 @@ func (s *Server) setupEnterprise(rg *common.RouteGenerator, privateRead, private
 	}
 
 	rg.Handle(rg.Post(common.OrgEndpoint, common.NewEndpoint), privateWrite, http.HandlerFunc(s.postNewOrg))
+	rg.Handle(rg.Post(common.OrgEndpoint, common.SandboxEndpoint), privateWrite, http.HandlerFunc(s.postSandboxOrg))
 	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint), privateWrite, s.Handler(s.postOrgMembers))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint, common.ExportEndpoint), privateRead, http.HandlerFunc(s.exportOrgMembersCSV))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint, common.ImportEndpoint), privateWrite, s.Handler(s.postOrgMembersImport))
+	rg.Handle(rg.Get(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint, common.ImportEndpoint, arg(common.ParamID)), privateRead, s.Handler(s.getOrgMembersImport))
 	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint, arg(common.ParamUser)), privateWrite, http.HandlerFunc(s.deleteOrgMembers))
 	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint), privateWrite, http.HandlerFunc(s.joinOrg))
 	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.MembersEndpoint), privateWrite, http.HandlerFunc(s.leaveOrg))
 	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.DeleteEndpoint), privateWrite, http.HandlerFunc(s.deleteOrg))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.ExportEndpoint), privateWrite, http.HandlerFunc(s.postOrgExport))
 	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.MoveEndpoint), privateWrite, http.HandlerFunc(s.moveProperty))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.PropertyEndpoint, arg(common.ParamProperty), common.CloneEndpoint), privateWrite, s.Handler(s.postPropertyClone))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.DomainsEndpoint), privateWrite, s.Handler(s.postOrgEmailDomain))
+	rg.Handle(rg.Post(common.OrgEndpoint, arg(common.ParamOrg), common.DomainsEndpoint, arg(common.ParamID), common.VerifyEndpoint), privateWrite, s.Handler(s.postOrgEmailDomainVerify))
+	rg.Handle(rg.Put(common.OrgEndpoint, arg(common.ParamOrg), common.DomainsEndpoint, arg(common.ParamID)), privateWrite, s.Handler(s.putOrgEmailDomain))
+	rg.Handle(rg.Delete(common.OrgEndpoint, arg(common.ParamOrg), common.DomainsEndpoint, arg(common.ParamID)), privateWrite, s.Handler(s.deleteOrgEmailDomain))
 
 	rg.Handle(rg.Get(common.AuditLogsEndpoint, common.EventsEndpoint), privateRead, s.Handler(s.getAuditLogEvents))
-	rg.Handle(rg.Get(common.AuditLogsEndpoint, common.ExportEndpoint), privateRead, http.HandlerFunc(s.exportAuditLogsCSV))
+	rg.Handle(rg.Get(common.AuditLogsEndpoint, common.ExportEndpoint), privateRead, http.HandlerFunc(s.exportAuditLogs))
+
+	rg.Handle(rg.Get(common.AdminEndpoint, common.TrialsEndpoint), privateRead, s.Handler(s.getTrials))
+	rg.Handle(rg.Post(common.AdminEndpoint, common.TrialsEndpoint), privateWrite, s.Handler(s.postTrial))
+	rg.Handle(rg.Post(common.AdminEndpoint, common.TrialsEndpoint, common.NewEndpoint), privateWrite, http.HandlerFunc(s.postTrialAPI))
+}
+
+func (s *Server) RegisterTaskHandlers(ctx context.Context) {
+	if ok := s.AsyncTasks.Register(importOrgMembersHandlerID, s.handleImportOrgMembers); !ok {
+		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", importOrgMembersHandlerID)
+	}
+
+	if ok := s.AsyncTasks.Register(clonePropertyHandlerID, s.handleCloneProperty); !ok {
+		slog.ErrorContext(ctx, "Failed to register async task handler", "handler", clonePropertyHandlerID)
+	}
 }
@@ -47,6 +47,22 @@ func (s *Server) setupEnterprise(*common.RouteGenerator, alice.Chain, alice.Chai
 	// BUMP
 }
 
+func (s *Server) RegisterTaskHandlers(ctx context.Context) {
+	// BUMP
+}
+
+func (s *Server) joinEmailDomainOrg(ctx context.Context, user *dbgen.User) {
+	// BUMP
+}
+
+func (s *Server) canJoinOrg(ctx context.Context, org *dbgen.Organization) bool {
+	return true
+}
+
+func (s *Server) syncOrgSeats(ctx context.Context, org *dbgen.Organization) {
+	// BUMP
+}
+
 func auditLogsDaysFromParam(ctx context.Context, _ string) int {
 	return 14
 }
@@ -78,7 +94,7 @@ func newStubAuditLog() *userAuditLog {
 	}
 }
 
-func (s *Server) createOrgAuditLogsContext(ctx context.Context, org *dbgen.Organization, user *dbgen.User) (*orgAuditLogsRenderContext, *common.AuditLogEvent, error) {
+func (s *Server) createOrgAuditLogsContext(ctx context.Context, org *dbgen.Organization, user *dbgen.User, search string) (*orgAuditLogsRenderContext, *common.AuditLogEvent, error) {
 	renderCtx := &orgAuditLogsRenderContext{
 		AuditLogsRenderContext: AuditLogsRenderContext{},
 		CurrentOrg:             orgToUserOrg(org, user.ID, s.IDHasher),
@@ -126,7 +142,7 @@ func (s *Server) getPropertyAuditLogs(w http.ResponseWriter, r *http.Request) (*
 	return renderCtx, nil, nil
 }
 
-func (s *Server) CreateAuditLogsContext(ctx context.Context, user *dbgen.User, days int, page int) (*MainAuditLogsRenderContext, error) {
+func (s *Server) CreateAuditLogsContext(ctx context.Context, user *dbgen.User, days int, page int, search string) (*MainAuditLogsRenderContext, error) {
 	logs := make([]*userAuditLog, 0)
 	const maxAuditLogs = 8
 	for i := 0; i < maxAuditLogs; i++ {
@@ -141,6 +157,7 @@ func (s *Server) CreateAuditLogsContext(ctx context.Context, user *dbgen.User, d
 			Count:     len(logs),
 			PerPage:   perPageEventLogs,
 			Page:      0,
+			Search:    search,
 		},
 		Days: days,
 		From: 1,
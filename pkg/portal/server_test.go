@@ -78,6 +78,7 @@ func TestMain(m *testing.M) {
 			PlatformCtx:        platformCtx,
 			SubscriptionLimits: &db.StubSubscriptionLimits{},
 			EmailVerifier:      &PortalEmailVerifier{},
+			AsyncTasks:         maintenance.NewAsyncTasksJob(store),
 		}
 
 		ctx := context.TODO()
@@ -111,7 +112,7 @@ func TestMain(m *testing.M) {
 		timeSeries = db.NewMemoryTimeSeries()
 	}
 
-	levels := difficulty.NewLevels(timeSeries, 100, 5*time.Minute)
+	levels := difficulty.NewLevels(timeSeries, 100, 5*time.Minute, nil /*footprint*/)
 	levels.Init(2*time.Second, 5*time.Minute)
 	defer levels.Shutdown()
 
@@ -142,6 +143,7 @@ func TestMain(m *testing.M) {
 		UserLimiter:        api.NewUserLimiter(store),
 		SubscriptionLimits: db.NewSubscriptionLimits(common.StageTest, store, planService),
 		EmailVerifier:      &PortalEmailVerifier{},
+		AsyncTasks:         maintenance.NewAsyncTasksJob(store),
 	}
 
 	ctx := context.TODO()
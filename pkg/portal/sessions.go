@@ -0,0 +1,214 @@
+package portal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+)
+
+type userSession struct {
+	ID       string
+	Browser  string
+	OS       string
+	Country  string
+	LastSeen string
+	Current  bool
+	// session was created on a known device (we send an email on sign-ins from unknown ones)
+	KnownDevice bool
+	// when the known device was first seen
+	FirstSeen string
+}
+
+type settingsSessionsRenderContext struct {
+	SettingsCommonRenderContext
+	Sessions []*userSession
+}
+
+// sessionHandle identifies session in the UI without exposing the session ID itself
+func sessionHandle(sid string) string {
+	hash := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(hash[:16])
+}
+
+func newUserSession(ctx context.Context, sess *session.Session, currentSID string, devices []*dbgen.UserDevice) *userSession {
+	us := &userSession{
+		ID:      sessionHandle(sess.ID()),
+		Current: sess.ID() == currentSID,
+	}
+
+	if userAgent, ok := sess.Get(ctx, session.KeyUserAgent).(string); ok && (len(userAgent) > 0) {
+		agent := userAgentParser().Parse(userAgent)
+		us.Browser = agent.Browser().String()
+		us.OS = agent.OS().String()
+	}
+
+	if country, ok := sess.Get(ctx, session.KeyCountry).(string); ok {
+		us.Country = country
+	}
+
+	if lastSeen := sess.Data().LastSeen(); !lastSeen.IsZero() {
+		us.LastSeen = lastSeen.UTC().Format("02 Jan 2006 15:04")
+	}
+
+	if len(us.Browser) > 0 {
+		if device := findKnownDevice(devices, us.Browser, us.OS, us.Country); device != nil {
+			us.KnownDevice = true
+			us.FirstSeen = device.CreatedAt.Time.UTC().Format("02 Jan 2006")
+		}
+	}
+
+	return us
+}
+
+func (s *Server) createSessionsSettingsModel(ctx context.Context, user *dbgen.User, sessionID string) (*settingsSessionsRenderContext, error) {
+	sessions, err := s.Sessions.UserSessions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := s.Store.Impl().RetrieveUserDevices(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx := &settingsSessionsRenderContext{
+		SettingsCommonRenderContext: s.CreateSettingsCommonRenderContext(common.SessionsEndpoint, user),
+		Sessions:                    make([]*userSession, 0, len(sessions)),
+	}
+
+	for _, sess := range sessions {
+		renderCtx.Sessions = append(renderCtx.Sessions, newUserSession(ctx, sess, sessionID, devices))
+	}
+
+	return renderCtx, nil
+}
+
+func (s *Server) getSessionsSettings(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	sess := s.Session(w, r)
+	user, err := s.SessionUser(ctx, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, err := s.createSessionsSettingsModel(ctx, user, sess.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ViewModel{Model: renderCtx}, nil
+}
+
+// deleteUserSession signs the user out of another browser (current session is closed via logout). Device of the
+// session is removed from the known ones, so that the next sign-in from it is reported again.
+func (s *Server) deleteUserSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess := s.Session(w, r)
+	user, err := s.SessionUser(ctx, sess)
+	if err != nil {
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	handle := r.PathValue(common.ParamID)
+	if handle == sessionHandle(sess.ID()) {
+		slog.WarnContext(ctx, "Cannot delete current session", "userID", user.ID)
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := s.Sessions.UserSessions(ctx, user.ID)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	sid := ""
+	for _, us := range sessions {
+		if sessionHandle(us.ID()) == handle {
+			sid = us.ID()
+			break
+		}
+	}
+
+	if len(sid) == 0 {
+		slog.WarnContext(ctx, "User session not found", "userID", user.ID, "handle", handle)
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	if err := s.Sessions.DestroyUserSession(ctx, user.ID, sid); err != nil {
+		if err == session.ErrSessionMissing {
+			http.Error(w, "", http.StatusNotFound)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.Store.AuditLog().RecordEvent(ctx, newUserAuthAuditLogEvent(user.ID, common.AuditLogActionLogout), common.AuditLogSourcePortal)
+
+	s.forgetSessionDevice(ctx, user, sid)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) forgetSessionDevice(ctx context.Context, user *dbgen.User, sid string) {
+	devices, err := s.Store.Impl().RetrieveUserDevices(ctx, user.ID)
+	if err != nil {
+		return
+	}
+
+	for _, d := range devices {
+		if d.SessionID != sid {
+			continue
+		}
+
+		device, err := s.Store.Impl().DeleteUserDevice(ctx, user, d.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to delete session device", "userID", user.ID, "deviceID", d.ID, common.ErrAttr(err))
+			return
+		}
+
+		s.Store.AuditLog().RecordEvent(ctx, db.NewUserDeviceAuditLogEvent(user.ID, device, common.AuditLogActionDelete), common.AuditLogSourcePortal)
+		slog.InfoContext(ctx, "Removed device of deleted session", "userID", user.ID, "deviceID", device.ID)
+
+		return
+	}
+}
+
+func (s *Server) deleteOtherUserSessions(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	sess := s.Session(w, r)
+	user, err := s.SessionUser(ctx, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.Sessions.DestroyOtherUserSessions(ctx, user.ID, sess.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, err := s.createSessionsSettingsModel(ctx, user, sess.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	vm := &ViewModel{Model: renderCtx, View: settingsSessionsContentTemplate}
+	if count > 0 {
+		vm.AuditEvent = newUserAuthAuditLogEvent(user.ID, common.AuditLogActionLogout)
+	}
+
+	return vm, nil
+}
@@ -7,10 +7,10 @@ import (
 	"log/slog"
 	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
@@ -22,14 +22,18 @@ import (
 
 const (
 	// Content-specific template names
-	settingsGeneralTemplatePrefix = "settings-general/"
-	settingsAPIKeysTemplatePrefix = "settings-apikeys/"
-	settingsUsageTemplatePrefix   = "settings-usage/"
+	settingsGeneralTemplatePrefix       = "settings-general/"
+	settingsAPIKeysTemplatePrefix       = "settings-apikeys/"
+	settingsUsageTemplatePrefix         = "settings-usage/"
+	settingsNotificationsTemplatePrefix = "settings-notifications/"
+	settingsSessionsTemplatePrefix      = "settings-sessions/"
 
 	// Other templates
-	settingsGeneralFormTemplate    = "settings-general/form.html"
-	settingsAPIKeysContentTemplate = "settings-apikeys/content.html"
-	apiKeyRowTemplate              = "settings-apikeys/key.html"
+	settingsGeneralFormTemplate       = "settings-general/form.html"
+	settingsNotificationsFormTemplate = "settings-notifications/form.html"
+	settingsAPIKeysContentTemplate    = "settings-apikeys/content.html"
+	settingsSessionsContentTemplate   = "settings-sessions/content.html"
+	apiKeyRowTemplate                 = "settings-apikeys/key.html"
 
 	// notifications
 	apiKeyExpirationNotificationDays = 14
@@ -40,6 +44,8 @@ const (
 
 	apiKeyScopePuzzle = "captcha"
 	apiKeyScopePortal = "portal"
+
+	maxAPIKeyAllowedIPs = 50
 )
 
 var (
@@ -79,7 +85,46 @@ type settingsUsageRenderContext struct {
 	OrgsCount               int
 	IncludedPropertiesCount int
 	IncludedOrgsCount       int
-	Limit                   int64
+	// only for per-seat plans
+	SeatsCount         int
+	IncludedSeatsCount int
+	Limit              int64
+	// monthly requests quota, as of the last evaluation (only if it was evaluated in the current month)
+	Quota *settingsQuota
+	// plans with lower limits that subscription can be changed to
+	DowngradePlans []*settingsDowngradePlan
+}
+
+type settingsQuota struct {
+	Requests int64
+	Limit    int64
+	Stage    string
+	// usage bar is capped at the "exceeded" threshold, so all values are in percent of that
+	BarPercent      int
+	WarningPercent  int
+	DegradedPercent int
+	UsagePercent    int
+	UpdatedAt       string
+}
+
+type userNotificationPreference struct {
+	Name        string
+	Title       string
+	Description string
+	Enabled     bool
+}
+
+type userStatsDigest struct {
+	OrgID     string
+	OrgName   string
+	Frequency string
+	orgID     int32
+}
+
+type settingsNotificationsRenderContext struct {
+	SettingsCommonRenderContext
+	Preferences []*userNotificationPreference
+	Digests     []*userStatsDigest
 }
 
 type settingsGeneralRenderContext struct {
@@ -90,6 +135,8 @@ type settingsGeneralRenderContext struct {
 	TwoFactorError string
 	TwoFactorEmail string
 	EditEmail      bool
+	CanSSO         bool
+	SSOLinked      bool
 }
 
 type userAPIKey struct {
@@ -102,6 +149,13 @@ type userAPIKey struct {
 	OrgName           string
 	ExpiresSoon       bool
 	ReadOnly          bool
+	// set while previous secret is still valid after rotation
+	PreviousExpiresAt string
+	LastUsedAt        string
+	AllowedCIDRs      string
+	// requests that were rejected because of AllowedCIDRs
+	IPViolations      int64
+	LastIPViolationAt string
 }
 
 type settingsAPIKeysRenderContext struct {
@@ -109,6 +163,7 @@ type settingsAPIKeysRenderContext struct {
 	Name       string
 	NameError  string
 	OrgError   string
+	IPsError   string
 	Keys       []*userAPIKey
 	Orgs       []*userOrg
 	CreateOpen bool
@@ -131,6 +186,16 @@ func apiKeyToUserAPIKey(key *dbgen.APIKey, tnow time.Time, hasher common.Identif
 		scope = apiKeyScopePuzzle
 	}
 
+	var previousExpiresAt string
+	if key.PreviousExpiresAt.Valid && key.PreviousExpiresAt.Time.After(tnow) {
+		previousExpiresAt = key.PreviousExpiresAt.Time.UTC().Format("02 Jan 2006 15:04 UTC")
+	}
+
+	var lastIPViolationAt string
+	if key.LastIpViolationAt.Valid {
+		lastIPViolationAt = key.LastIpViolationAt.Time.UTC().Format("02 Jan 2006 15:04 UTC")
+	}
+
 	return &userAPIKey{
 		ID:                hasher.Encrypt(int(key.ID)),
 		Name:              key.Name,
@@ -139,6 +204,10 @@ func apiKeyToUserAPIKey(key *dbgen.APIKey, tnow time.Time, hasher common.Identif
 		RequestsPerMinute: int(requestsPerMinute),
 		Scope:             scope,
 		ReadOnly:          key.Readonly,
+		PreviousExpiresAt: previousExpiresAt,
+		AllowedCIDRs:      strings.Join(key.AllowedCidrs, ", "),
+		IPViolations:      key.IpViolations,
+		LastIPViolationAt: lastIPViolationAt,
 	}
 }
 
@@ -251,10 +320,19 @@ func (s *Server) CreateSettingsCommonRenderContext(activeTabID string, user *dbg
 }
 
 func (s *Server) createGeneralSettingsModel(ctx context.Context, user *dbgen.User) *settingsGeneralRenderContext {
-	return &settingsGeneralRenderContext{
+	renderCtx := &settingsGeneralRenderContext{
 		SettingsCommonRenderContext: s.CreateSettingsCommonRenderContext(common.GeneralEndpoint, user),
 		Name:                        user.Name,
+		CanSSO:                      s.canSSO(),
+	}
+
+	if renderCtx.CanSSO {
+		if links, err := s.Store.Impl().RetrieveUserSSOLinks(ctx, user.ID); err == nil {
+			renderCtx.SSOLinked = slices.ContainsFunc(links, func(l *dbgen.UserSSOLink) bool { return l.Issuer == s.SSO.Issuer })
+		}
 	}
+
+	return renderCtx
 }
 
 func (s *Server) getGeneralSettings(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
@@ -390,6 +468,27 @@ func (s *Server) putGeneralSettings(w http.ResponseWriter, r *http.Request) (*Vi
 	return &ViewModel{Model: renderCtx, View: settingsGeneralFormTemplate, AuditEvent: auditEvent}, nil
 }
 
+func (s *Server) cancelExternalSubscription(ctx context.Context, user *dbgen.User) error {
+	if !user.SubscriptionID.Valid {
+		return nil
+	}
+
+	subscription, err := s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve a subscription", common.ErrAttr(err))
+		return err
+	}
+
+	if s.PlanService.IsSubscriptionActive(subscription.Status) && subscription.ExternalSubscriptionID.Valid {
+		if err := s.PlanService.CancelSubscription(ctx, subscription.ExternalSubscriptionID.String); err != nil {
+			slog.ErrorContext(ctx, "Failed to cancel external subscription", "userID", user.ID, common.ErrAttr(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *Server) deleteAccount(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, err := s.SessionUser(ctx, s.Session(w, r))
@@ -398,21 +497,9 @@ func (s *Server) deleteAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if user.SubscriptionID.Valid {
-		subscription, err := s.Store.Impl().RetrieveSubscription(ctx, user.SubscriptionID.Int32)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to retrieve a subscription", common.ErrAttr(err))
-			s.RedirectError(http.StatusInternalServerError, w, r)
-			return
-		}
-
-		if s.PlanService.IsSubscriptionActive(subscription.Status) && subscription.ExternalSubscriptionID.Valid {
-			if err := s.PlanService.CancelSubscription(ctx, subscription.ExternalSubscriptionID.String); err != nil {
-				slog.ErrorContext(ctx, "Failed to cancel external subscription", "userID", user.ID, common.ErrAttr(err))
-				s.RedirectError(http.StatusInternalServerError, w, r)
-				return
-			}
-		}
+	if err := s.cancelExternalSubscription(ctx, user); err != nil {
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
 	}
 
 	if auditEvents, err := s.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) ([]*common.AuditLogEvent, error) {
@@ -445,6 +532,14 @@ func (s *Server) createAPIKeysSettingsModel(ctx context.Context, user *dbgen.Use
 		Keys:                        apiKeysToUserAPIKeys(keys, time.Now().UTC(), s.IDHasher),
 	}
 
+	if lastUsed, err := s.Store.Impl().RetrieveAPIKeysLastUsed(ctx, user.ID); err == nil {
+		for i, key := range keys {
+			if t, ok := lastUsed[key.ID]; ok {
+				renderCtx.Keys[i].LastUsedAt = t.UTC().Format("02 Jan 2006")
+			}
+		}
+	}
+
 	if orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID); err == nil {
 		renderCtx.Orgs = orgsToUserOrgs(orgs, s.IDHasher)
 
@@ -497,6 +592,27 @@ func apiKeyDaysFromParam(ctx context.Context, param string) int {
 	}
 }
 
+// returns for how long previous secret stays valid after rotation
+func apiKeyOverlapFromParam(ctx context.Context, param string) time.Duration {
+	if len(param) == 0 {
+		return 0
+	}
+
+	i, err := strconv.Atoi(param)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert overlap", "value", param, common.ErrAttr(err))
+		return 0
+	}
+
+	switch i {
+	case 0, 1, 24, 168:
+		return time.Duration(i) * time.Hour
+	default:
+		slog.WarnContext(ctx, "Unsupported API key rotation overlap", "value", i)
+		return 0
+	}
+}
+
 // NOTE: ReferenceID logic should stay the same forever for correct deduplication in DB
 func apiKeyExpirationReference(id int32) string {
 	return fmt.Sprintf("apikey/%v/expiration", id)
@@ -548,28 +664,6 @@ func createAPIKeyExpiredNotification(key *dbgen.APIKey, userKey *userAPIKey) *co
 	}
 }
 
-func checkAPIKeyNameValid(ctx context.Context, name string) bool {
-	const allowedPunctuation = "-_.()[]"
-
-	for i, r := range name {
-		switch {
-		case unicode.IsLetter(r):
-			continue
-		case unicode.IsDigit(r):
-			continue
-		case unicode.IsSpace(r):
-			continue
-		case strings.ContainsRune(allowedPunctuation, r):
-			continue
-		default:
-			slog.WarnContext(ctx, "Name contains invalid characters", "position", i, "rune", r)
-			return false
-		}
-	}
-
-	return true
-}
-
 func parseAPIKeyScope(scope string) (dbgen.ApiKeyScope, bool, error) {
 	switch scope {
 	case apiKeyScopePortal + apiKeyReadWriteSuffix:
@@ -605,7 +699,7 @@ func (s *Server) postAPIKeySettings(w http.ResponseWriter, r *http.Request) (*Vi
 		return &ViewModel{Model: renderCtx, View: settingsAPIKeysContentTemplate}, nil
 	}
 
-	if !checkAPIKeyNameValid(ctx, formName) {
+	if !db.CheckAPIKeyNameValid(ctx, formName) {
 		renderCtx.NameError = "Name contains invalid characters."
 		renderCtx.CreateOpen = true
 		return &ViewModel{Model: renderCtx, View: settingsAPIKeysContentTemplate}, nil
@@ -662,6 +756,13 @@ func (s *Server) postAPIKeySettings(w http.ResponseWriter, r *http.Request) (*Vi
 		}
 	}
 
+	allowedCIDRs, errorMsg := parseIPList(r.FormValue(common.ParamAllowlist), maxAPIKeyAllowedIPs)
+	if len(errorMsg) > 0 {
+		renderCtx.IPsError = errorMsg
+		renderCtx.CreateOpen = true
+		return &ViewModel{Model: renderCtx, View: settingsAPIKeysContentTemplate}, nil
+	}
+
 	// current logic is that initial values will be set per plan and adjusted manually in DB if requested by customer
 	burst := max(minAPIKeyRequestsBurst, int32(apiKeyRequestsPerSecond*5))
 	days := apiKeyDaysFromParam(ctx, r.FormValue(common.ParamDays))
@@ -676,6 +777,7 @@ func (s *Server) postAPIKeySettings(w http.ResponseWriter, r *http.Request) (*Vi
 		Scope:             scope,
 		Readonly:          readOnly,
 		OrgID:             pgOrgID,
+		AllowedCidrs:      allowedCIDRs,
 	}
 	newKey, auditEvent, err := s.Store.Impl().CreateAPIKey(ctx, user, params)
 	if err == nil {
@@ -729,7 +831,9 @@ func (s *Server) rotateAPIKey(w http.ResponseWriter, r *http.Request) (*ViewMode
 		return nil, errInvalidPathArg
 	}
 
-	key, auditEvent, err := s.Store.Impl().RotateAPIKey(ctx, user, keyID)
+	overlap := apiKeyOverlapFromParam(ctx, r.FormValue(common.ParamOverlap))
+
+	key, auditEvent, err := s.Store.Impl().RotateAPIKey(ctx, user, keyID, overlap)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to rotate the API key", "keyID", keyID, common.ErrAttr(err))
 		return nil, err
@@ -823,7 +927,13 @@ func (s *Server) getAccountStats(w http.ResponseWriter, r *http.Request) {
 
 	data := []*point{}
 
-	if stats, err := s.TimeSeries.RetrieveAccountStats(ctx, user.ID, timeFrom); err == nil {
+	// sandbox traffic is not billed so it's not shown in usage either
+	sandboxIDs, err := s.Store.Impl().RetrieveUserSandboxOrgIDs(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve sandbox orgs", "userID", user.ID, common.ErrAttr(err))
+	}
+
+	if stats, err := s.TimeSeries.RetrieveAccountStats(ctx, user.ID, timeFrom, sandboxIDs); err == nil {
 		anyNonZero := false
 		for _, st := range stats {
 			if st.Count > 0 {
@@ -874,15 +984,30 @@ func (s *Server) createUsageSettingsModel(ctx context.Context, user *dbgen.User)
 			slog.ErrorContext(ctx, "Failed to retrieve user subscription for usage tab", common.ErrAttr(err))
 			renderCtx.ErrorMessage = "Could not load subscription details for usage limits."
 		} else {
-			renderCtx.Limit, _ = s.SubscriptionLimits.RequestsLimit(ctx, subscription)
-			renderCtx.IncludedPropertiesCount, _ = s.SubscriptionLimits.PropertiesLimit(ctx, subscription)
-			renderCtx.IncludedOrgsCount, _ = s.SubscriptionLimits.OrgsLimit(ctx, subscription)
+			if limits, err := s.SubscriptionLimits.Limits(ctx, subscription); err == nil {
+				renderCtx.Limit = limits.Requests
+				renderCtx.IncludedPropertiesCount = limits.Properties
+				renderCtx.IncludedOrgsCount = limits.Orgs
+				renderCtx.IncludedSeatsCount = limits.Seats
+			}
+
+			if renderCtx.IncludedSeatsCount > 0 {
+				if count, err := s.SubscriptionLimits.SeatsCount(ctx, user.ID); err == nil {
+					renderCtx.SeatsCount = count
+				}
+			}
+
+			renderCtx.DowngradePlans = s.createDowngradePlans(ctx, user)
 		}
 	} else {
 		slog.DebugContext(ctx, "User does not have a subscription", "tab", "usage", "userID", user.ID)
 		renderCtx.WarningMessage = "You don't have an active subscription."
 	}
 
+	if renderCtx.Limit > 0 {
+		renderCtx.Quota = s.createSettingsQuota(ctx, user, time.Now().UTC())
+	}
+
 	if (renderCtx.Limit == 0) ||
 		(renderCtx.IncludedOrgsCount == 0) ||
 		(renderCtx.IncludedPropertiesCount == 0) {
@@ -894,6 +1019,33 @@ func (s *Server) createUsageSettingsModel(ctx context.Context, user *dbgen.User)
 	return renderCtx
 }
 
+func (s *Server) createSettingsQuota(ctx context.Context, user *dbgen.User, tnow time.Time) *settingsQuota {
+	quota, err := s.Store.Impl().RetrieveUserQuota(ctx, user.ID)
+	if err != nil {
+		if err != db.ErrRecordNotFound {
+			slog.ErrorContext(ctx, "Failed to retrieve user quota for usage tab", "userID", user.ID, common.ErrAttr(err))
+		}
+		return nil
+	}
+
+	if !quota.PeriodStart.Time.Equal(db.QuotaPeriodStart(tnow)) || (quota.RequestsLimit <= 0) {
+		return nil
+	}
+
+	usage := db.QuotaUsagePercent(quota.Requests, quota.RequestsLimit)
+
+	return &settingsQuota{
+		Requests:        quota.Requests,
+		Limit:           quota.RequestsLimit,
+		Stage:           string(quota.Stage),
+		BarPercent:      int(min(usage*100/db.QuotaExceededPercent, 100)),
+		WarningPercent:  db.QuotaWarningPercent * 100 / db.QuotaExceededPercent,
+		DegradedPercent: db.QuotaDegradedPercent * 100 / db.QuotaExceededPercent,
+		UsagePercent:    int(usage),
+		UpdatedAt:       quota.UpdatedAt.Time.Format("02 Jan 2006 15:04 MST"),
+	}
+}
+
 func (s *Server) getUsageSettings(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
 	ctx := r.Context()
 
@@ -906,3 +1058,153 @@ func (s *Server) getUsageSettings(w http.ResponseWriter, r *http.Request) (*View
 
 	return &ViewModel{Model: renderCtx}, nil
 }
+
+func (s *Server) createNotificationsSettingsModel(ctx context.Context, user *dbgen.User) (*settingsNotificationsRenderContext, error) {
+	optOuts, err := s.Store.Impl().RetrieveUserNotificationOptOuts(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	optedOut := make(map[string]struct{}, len(optOuts))
+	for _, name := range optOuts {
+		optedOut[name] = struct{}{}
+	}
+
+	optionalTemplates := email.OptionalTemplates()
+	preferences := make([]*userNotificationPreference, 0, len(optionalTemplates))
+	for _, ot := range optionalTemplates {
+		_, ok := optedOut[ot.Template.Name()]
+		preferences = append(preferences, &userNotificationPreference{
+			Name:        ot.Template.Name(),
+			Title:       ot.Title,
+			Description: ot.Description,
+			Enabled:     !ok,
+		})
+	}
+
+	orgs, err := s.Store.Impl().RetrieveUserOrganizations(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	frequencies, err := s.Store.Impl().RetrieveUserStatsDigests(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([]*userStatsDigest, 0, len(orgs))
+	for _, o := range orgs {
+		if o.Level == dbgen.AccessLevelInvited {
+			continue
+		}
+
+		digests = append(digests, &userStatsDigest{
+			OrgID:     s.IDHasher.Encrypt(int(o.Organization.ID)),
+			OrgName:   o.Organization.Name,
+			Frequency: string(frequencies[o.Organization.ID]),
+			orgID:     o.Organization.ID,
+		})
+	}
+
+	return &settingsNotificationsRenderContext{
+		SettingsCommonRenderContext: s.CreateSettingsCommonRenderContext(common.NotificationsEndpoint, user),
+		Preferences:                 preferences,
+		Digests:                     digests,
+	}, nil
+}
+
+func parseDigestFrequency(value string) (dbgen.NullDigestFrequency, bool) {
+	switch value {
+	case "":
+		return dbgen.NullDigestFrequency{}, true
+	case string(dbgen.DigestFrequencyWeekly), string(dbgen.DigestFrequencyMonthly):
+		return dbgen.NullDigestFrequency{DigestFrequency: dbgen.DigestFrequency(value), Valid: true}, true
+	default:
+		return dbgen.NullDigestFrequency{}, false
+	}
+}
+
+func (s *Server) getNotificationsSettings(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	renderCtx, err := s.createNotificationsSettingsModel(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ViewModel{Model: renderCtx}, nil
+}
+
+func (s *Server) putNotificationsSettings(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	user, err := s.SessionUser(ctx, s.Session(w, r))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	enabled := make(map[string]struct{})
+	for _, name := range r.Form[common.ParamTemplate] {
+		enabled[name] = struct{}{}
+	}
+
+	renderCtx, err := s.createNotificationsSettingsModel(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	anyError := false
+	for _, p := range renderCtx.Preferences {
+		_, ok := enabled[p.Name]
+		if ok == p.Enabled {
+			continue
+		}
+
+		if err := s.Store.Impl().UpdateNotificationOptOut(ctx, user.ID, p.Name, !ok /*opt out*/); err != nil {
+			anyError = true
+			continue
+		}
+
+		p.Enabled = ok
+	}
+
+	for _, d := range renderCtx.Digests {
+		value := r.FormValue(fmt.Sprintf("%s-%s", common.ParamDigest, d.OrgID))
+		if value == d.Frequency {
+			continue
+		}
+
+		frequency, ok := parseDigestFrequency(value)
+		if !ok {
+			slog.WarnContext(ctx, "Invalid digest frequency", "value", value, "orgID", d.orgID)
+			anyError = true
+			continue
+		}
+
+		if err := s.Store.Impl().UpdateStatsDigest(ctx, user.ID, d.orgID, frequency); err != nil {
+			anyError = true
+			continue
+		}
+
+		d.Frequency = value
+	}
+
+	if anyError {
+		renderCtx.ErrorMessage = "Failed to update notification preferences. Please try again."
+	} else {
+		renderCtx.SuccessMessage = "Notification preferences were updated."
+	}
+
+	return &ViewModel{Model: renderCtx, View: settingsNotificationsFormTemplate}, nil
+}
@@ -182,6 +182,102 @@ func TestRotateAPIKey(t *testing.T) {
 	}
 }
 
+func TestRotateAPIKeyOverlap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create owner account: %v", err)
+	}
+
+	srv := http.NewServeMux()
+	server.Setup(portalDomain(), common.NoopMiddleware).Register(srv)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tnow := time.Now().UTC()
+	key, _, err := store.Impl().CreateAPIKey(ctx, user, tests.CreateNewPuzzleAPIKeyParams("My API Key", tnow, 24*time.Hour, 10.0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretOld := db.UUIDToSecret(key.ExternalID)
+
+	form := url.Values{}
+	form.Set(common.ParamOverlap, "1")
+
+	csrfToken := server.XSRF.Token(strconv.Itoa(int(user.ID)))
+	req := httptest.NewRequest("POST", fmt.Sprintf("/apikeys/%v", server.IDHasher.Encrypt(int(key.ID))), strings.NewReader(form.Encode()))
+	req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+	req.AddCookie(cookie)
+	req.Header.Set(common.HeaderCSRFToken, csrfToken)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code %v", resp.StatusCode)
+	}
+
+	oldKey, err := store.Impl().RetrieveAPIKey(ctx, secretOld)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if oldKey.ID != key.ID {
+		t.Errorf("Unexpected key resolved by previous secret: %v", oldKey.ID)
+	}
+
+	if secret := db.UUIDToSecret(oldKey.ExternalID); secret == secretOld {
+		t.Error("Key external ID was not rotated")
+	}
+
+	if !oldKey.PreviousExpiresAt.Valid || !oldKey.PreviousExpiresAt.Time.After(tnow.Add(59*time.Minute)) {
+		t.Errorf("Unexpected previous secret expiration: %v", oldKey.PreviousExpiresAt)
+	}
+}
+
+func TestDeleteRotatedAPIKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create owner account: %v", err)
+	}
+
+	key, _, err := store.Impl().CreateAPIKey(ctx, user, tests.CreateNewPuzzleAPIKeyParams("My API Key", time.Now(), 24*time.Hour, 10.0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretOld := db.UUIDToSecret(key.ExternalID)
+
+	if _, _, err := store.Impl().RotateAPIKey(ctx, user, key.ID, 1*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// puts previous secret in cache
+	if _, err := store.Impl().RetrieveAPIKey(ctx, secretOld); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Impl().DeleteAPIKey(ctx, user, key.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if oldKey, err := store.Impl().RetrieveAPIKey(ctx, secretOld); (err == nil) && (oldKey != nil) {
+		t.Errorf("Previous secret of deleted key is still valid")
+	}
+}
+
 func TestGetSettings(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -0,0 +1,350 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/config"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/sso"
+)
+
+const (
+	ssoCookieName      = "pcsso"
+	ssoCookieLifetime  = 10 * time.Minute
+	ssoNoncePurpose    = "nonce"
+	ssoVerifierPurpose = "pkce"
+	ssoDefaultUserName = "SSO User"
+	// marks login attempts that link SSO identity to the account of the logged in user
+	ssoLinkPrefix    = "link."
+	ssoNotLinkedText = "Account with this email already exists. Sign in with email and link single sign-on in account settings."
+)
+
+var (
+	errSSOProvisioningDisabled = errors.New("sso user provisioning is disabled")
+	errSSONotLinked            = errors.New("sso identity is not linked to the existing account")
+)
+
+// login attempt is bound to the browser with a short-lived random cookie instead of a session, so that nothing
+// is persisted for anonymous visitors and callback can be served by any node
+func (s *Server) ssoCookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     ssoCookieName,
+		Value:    value,
+		Path:     s.RelURL(common.SSOEndpoint),
+		HttpOnly: true,
+		Secure:   s.Sessions.SecureCookie || (r.TLS != nil) || (r.Header.Get("X-Forwarded-Proto") == "https"),
+		// Lax is required for the cookie to be sent with the top-level redirect back from IdP
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	}
+}
+
+// SSO login creates users and sessions, which is not possible on a standby replica
+func (s *Server) canSSO() bool {
+	return (s.SSO != nil) && !s.Standby
+}
+
+func (s *Server) getSSOLogin(w http.ResponseWriter, r *http.Request) {
+	s.startSSO(w, r, false /*link*/)
+}
+
+// getSSOLink is only available to logged in users, which is what makes linking SSO identity to the account explicit
+func (s *Server) getSSOLink(w http.ResponseWriter, r *http.Request) {
+	s.startSSO(w, r, true /*link*/)
+}
+
+func (s *Server) startSSO(w http.ResponseWriter, r *http.Request, link bool) {
+	ctx := r.Context()
+
+	if !s.canSSO() {
+		slog.WarnContext(ctx, "SSO is not available")
+		s.RedirectError(http.StatusNotFound, w, r)
+		return
+	}
+
+	secret := sso.RandomToken()
+	authURL, err := s.SSO.AuthURL(ctx, s.XSRF.Token(secret), sso.DeriveToken(secret, ssoNoncePurpose), sso.DeriveToken(secret, ssoVerifierPurpose))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create SSO authorization URL", common.ErrAttr(err))
+		s.RedirectError(http.StatusServiceUnavailable, w, r)
+		return
+	}
+
+	value := secret
+	if link {
+		value = ssoLinkPrefix + secret
+	}
+
+	http.SetCookie(w, s.ssoCookie(r, value, int(ssoCookieLifetime.Seconds())))
+	w.Header().Set(common.HeaderCacheControl, "no-store")
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (s *Server) getSSOCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !s.canSSO() {
+		slog.WarnContext(ctx, "SSO is not available")
+		s.RedirectError(http.StatusNotFound, w, r)
+		return
+	}
+
+	cookie, err := r.Cookie(ssoCookieName)
+	if (err != nil) || (len(cookie.Value) == 0) {
+		slog.WarnContext(ctx, "SSO cookie is missing", common.ErrAttr(err))
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	// login attempt can be used only once
+	http.SetCookie(w, s.ssoCookie(r, "", -1))
+
+	query := r.URL.Query()
+	if idpError := query.Get("error"); len(idpError) > 0 {
+		slog.WarnContext(ctx, "IdP returned an error", "error", idpError, "description", query.Get("error_description"))
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	secret, link := strings.CutPrefix(cookie.Value, ssoLinkPrefix)
+	if state := query.Get("state"); !s.XSRF.VerifyToken(state, secret) {
+		slog.WarnContext(ctx, "SSO state is not valid")
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	identity, err := s.SSO.Exchange(ctx, query.Get(common.ParamCode), sso.DeriveToken(secret, ssoVerifierPurpose),
+		sso.DeriveToken(secret, ssoNoncePurpose), time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to complete SSO login", common.ErrAttr(err))
+		s.RedirectError(http.StatusUnauthorized, w, r)
+		return
+	}
+
+	if link {
+		s.linkSSO(w, r, identity)
+		return
+	}
+
+	user, provisioned, err := s.ssoUser(ctx, identity)
+	if err != nil {
+		switch err {
+		case errSSOProvisioningDisabled:
+			s.RedirectError(http.StatusForbidden, w, r)
+		case errSSONotLinked:
+			s.render(w, r, loginTemplate, &loginRenderContext{
+				CsrfRenderContext:    CsrfRenderContext{Token: s.XSRF.Token("")},
+				CaptchaRenderContext: s.CreateCaptchaRenderContext(db.PortalLoginSitekey),
+				CanRegister:          s.canRegister.Load(),
+				CanSSO:               s.canSSO(),
+				EmailError:           ssoNotLinkedText,
+			})
+		default:
+			s.RedirectError(http.StatusInternalServerError, w, r)
+		}
+		return
+	}
+
+	sess := s.Sessions.SessionStart(w, r)
+	ctx = context.WithValue(ctx, common.SessionIDContextKey, sess.ID())
+
+	if !provisioned {
+		// SSO replaces only the email step of the login, existing accounts still need the second factor
+		s.ssoTwoFactor(w, r.WithContext(ctx), sess, user)
+		return
+	}
+
+	_ = sess.Set(session.KeyUserID, user.ID)
+	_ = sess.Set(session.KeyUserName, user.Name)
+	_ = sess.Set(session.KeyLoginStep, loginStepCompleted)
+	_ = sess.Set(session.KeyPersistent, true)
+
+	locale := s.requestLocale(r)
+	job := s.Jobs.LoginUser(sess, locale, newLoginDevice(r.UserAgent(), locale))
+	go common.RunOneOffJob(common.CopyTraceID(ctx, context.Background()), job, job.NewParams())
+
+	slog.InfoContext(ctx, "User logged in with SSO", "userID", user.ID, "subject", identity.Subject)
+
+	if returnURL, ok := sess.Get(ctx, session.KeyReturnURL).(string); ok && (len(returnURL) > 0) {
+		_ = sess.Delete(session.KeyReturnURL)
+		common.Redirect(s.RelURL(returnURL), http.StatusOK, w, r)
+	} else {
+		common.Redirect(s.RelURL("/"), http.StatusOK, w, r)
+	}
+}
+
+func (s *Server) ssoTwoFactor(w http.ResponseWriter, r *http.Request, sess *session.Session, user *dbgen.User) {
+	ctx := r.Context()
+
+	if step, ok := sess.Get(ctx, session.KeyLoginStep).(int); ok && (step == loginStepCompleted) {
+		slog.DebugContext(ctx, "User seem to be already logged in", "userID", user.ID)
+		common.Redirect(s.RelURL("/"), http.StatusOK, w, r)
+		return
+	}
+
+	code := twoFactorCode(ctx)
+	location := r.Header.Get(s.CountryCodeHeader.Value())
+
+	if err := s.Mailer.SendTwoFactor(ctx, user.Email, code, r.UserAgent(), location); err != nil {
+		slog.ErrorContext(ctx, "Failed to send email message", common.ErrAttr(err))
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	_ = sess.Set(session.KeyLoginStep, loginStepSignInVerify)
+	_ = sess.Set(session.KeyUserEmail, user.Email)
+	_ = sess.Set(session.KeyUserName, user.Name)
+	_ = sess.Set(session.KeyTwoFactorCode, code)
+	_ = sess.Set(session.KeyTwoFactorCodeTimestamp, time.Now().UTC())
+	_ = sess.Set(session.KeyUserID, user.ID)
+	_ = sess.Set(session.KeyPersistent, true)
+
+	s.render(w, r, loginTemplate, &loginRenderContext{
+		CsrfRenderContext: CsrfRenderContext{
+			Token: s.XSRF.Token(user.Email),
+		},
+		Email:       common.MaskEmail(user.Email, '*'),
+		IsTwoFactor: true,
+	})
+}
+
+// linkSSO links SSO identity to the account of the logged in user (with the same email)
+func (s *Server) linkSSO(w http.ResponseWriter, r *http.Request, identity *sso.Identity) {
+	ctx := r.Context()
+
+	sess, found := s.Sessions.SessionGet(r)
+	if !found {
+		slog.WarnContext(ctx, "Session is missing for SSO link")
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	if step, ok := sess.Get(ctx, session.KeyLoginStep).(int); !ok || (step != loginStepCompleted) {
+		slog.WarnContext(ctx, "User is not logged in for SSO link", "step", step)
+		common.Redirect(s.RelURL(common.LoginEndpoint), http.StatusUnauthorized, w, r)
+		return
+	}
+
+	user, err := s.SessionUser(ctx, sess)
+	if err != nil {
+		s.RedirectError(http.StatusInternalServerError, w, r)
+		return
+	}
+
+	if !strings.EqualFold(user.Email, identity.Email) {
+		slog.WarnContext(ctx, "SSO identity email does not match the user", "userID", user.ID)
+		s.RedirectError(http.StatusForbidden, w, r)
+		return
+	}
+
+	auditEvent, err := s.Store.Impl().LinkUserSSO(ctx, user, s.SSO.Issuer, identity.Subject)
+	if err != nil {
+		if err == db.ErrAlreadyLinked {
+			s.RedirectError(http.StatusForbidden, w, r)
+		} else {
+			s.RedirectError(http.StatusInternalServerError, w, r)
+		}
+		return
+	}
+
+	if auditEvent != nil {
+		s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
+	}
+
+	common.Redirect(s.RelURL(common.SettingsEndpoint), http.StatusOK, w, r)
+}
+
+// ssoUser finds user that linked SSO identity or provisions a new one if it is allowed. Existing accounts with the
+// same email are never used implicitly, since IdP does not prove that it is the same person.
+func (s *Server) ssoUser(ctx context.Context, identity *sso.Identity) (*dbgen.User, bool, error) {
+	user, err := s.Store.Impl().FindUserBySSOLink(ctx, s.SSO.Issuer, identity.Subject)
+	if err == nil {
+		return user, false, nil
+	}
+
+	if err != db.ErrRecordNotFound {
+		slog.ErrorContext(ctx, "Failed to find SSO user by link", common.ErrAttr(err))
+		return nil, false, err
+	}
+
+	if _, err := s.Store.Impl().FindUserByEmail(ctx, identity.Email); err == nil {
+		slog.WarnContext(ctx, "SSO identity is not linked to the existing user", "subject", identity.Subject)
+		return nil, false, errSSONotLinked
+	} else if err != db.ErrRecordNotFound {
+		slog.ErrorContext(ctx, "Failed to find SSO user by email", common.ErrAttr(err))
+		return nil, false, err
+	}
+
+	if (s.SSOProvisioning == nil) || !config.AsBool(s.SSOProvisioning) {
+		slog.WarnContext(ctx, "SSO user does not exist and provisioning is disabled", "email", identity.Email)
+		return nil, false, errSSOProvisioningDisabled
+	}
+
+	name := identity.Name
+	if !isUserNameValid(name) {
+		// names from IdP can contain anything, but it is not a reason to not let user in (they can change it later)
+		name = strings.Map(func(r rune) rune {
+			if isUserNameValid(string(r)) {
+				return r
+			}
+			return -1
+		}, name)
+	}
+
+	if name = strings.TrimSpace(name); len(name) == 0 {
+		name = ssoDefaultUserName
+	}
+
+	user, _, err = s.createAccount(ctx, identity.Email, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	slog.InfoContext(ctx, "Provisioned SSO user", "userID", user.ID)
+
+	auditEvent, err := s.Store.Impl().LinkUserSSO(ctx, user, s.SSO.Issuer, identity.Subject)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if auditEvent != nil {
+		s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
+	}
+
+	s.joinDefaultOrg(ctx, user)
+
+	return user, true, nil
+}
+
+func (s *Server) joinDefaultOrg(ctx context.Context, user *dbgen.User) {
+	if s.SSODefaultOrg == nil {
+		return
+	}
+
+	value := strings.TrimSpace(s.SSODefaultOrg.Value())
+	if len(value) == 0 {
+		return
+	}
+
+	orgID, err := s.IDHasher.Decrypt(value)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to decode default SSO org", "org", value, common.ErrAttr(err))
+		return
+	}
+
+	org, auditEvent, err := s.Store.Impl().AddUserToDefaultOrg(ctx, user, int32(orgID), s.canJoinOrg)
+	if (err != nil) || (auditEvent == nil) {
+		return
+	}
+
+	s.Store.AuditLog().RecordEvent(ctx, auditEvent, common.AuditLogSourcePortal)
+	s.syncOrgSeats(ctx, org)
+}
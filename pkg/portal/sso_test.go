@@ -0,0 +1,48 @@
+package portal
+
+import (
+	"testing"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+)
+
+func TestLinkUserSSO(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	const issuer = "https://idp.example.com"
+
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	if _, err := store.Impl().FindUserBySSOLink(ctx, issuer, t.Name()); err != db.ErrRecordNotFound {
+		t.Fatalf("Unexpected error for not linked identity: %v", err)
+	}
+
+	if auditEvent, err := store.Impl().LinkUserSSO(ctx, user, issuer, t.Name()); (err != nil) || (auditEvent == nil) {
+		t.Fatalf("Failed to link SSO identity: %v", err)
+	}
+
+	linked, err := store.Impl().FindUserBySSOLink(ctx, issuer, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if linked.ID != user.ID {
+		t.Errorf("Unexpected linked user: %v (expected %v)", linked.ID, user.ID)
+	}
+
+	other, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_other", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	if _, err := store.Impl().LinkUserSSO(ctx, other, issuer, t.Name()); err != db.ErrAlreadyLinked {
+		t.Errorf("Unexpected error for identity linked to another user: %v", err)
+	}
+}
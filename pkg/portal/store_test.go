@@ -199,7 +199,7 @@ func TestSystemNotification(t *testing.T) {
 		t.Fatalf("Failed to create new account: %v", err)
 	}
 
-	if _, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID); err != db.ErrRecordNotFound {
+	if _, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID, common.StageTest); err != db.ErrRecordNotFound {
 		t.Errorf("Unexpected result for user notification: %v", err)
 	}
 
@@ -208,7 +208,7 @@ func TestSystemNotification(t *testing.T) {
 		t.Error(err)
 	}
 
-	if n, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID); (err != nil) || (n.ID != generalNotification.ID) {
+	if n, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID, common.StageTest); (err != nil) || (n.ID != generalNotification.ID) {
 		t.Errorf("Cannot retrieve generic user notification: %v", err)
 	}
 
@@ -218,11 +218,70 @@ func TestSystemNotification(t *testing.T) {
 	}
 
 	// specific notification has precedence over general one, even though both are active AND system notification is "fresher"
-	if n, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID); (err != nil) || (n.ID != userNotification.ID) {
+	if n, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID, common.StageTest); (err != nil) || (n.ID != userNotification.ID) {
 		t.Errorf("Cannot retrieve specific user notification: %v", err)
 	}
 }
 
+func TestSystemNotificationAudience(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	tnow := time.Now().UTC()
+
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create new account: %v", err)
+	}
+
+	otherStage, err := store.Impl().ScheduleSystemNotification(ctx, &dbgen.CreateSystemNotificationParams{
+		Message:   "other stage",
+		StartDate: db.Timestampz(tnow.Add(-1 * time.Minute)),
+		UserID:    db.Int(user.ID),
+		Severity:  dbgen.NotificationSeverityCritical,
+		Stage:     db.Text("other"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orgNotification, err := store.Impl().ScheduleSystemNotification(ctx, &dbgen.CreateSystemNotificationParams{
+		Message:    "**org**",
+		StartDate:  db.Timestampz(tnow.Add(-2 * time.Minute)),
+		EndDate:    db.Timestampz(tnow.Add(1 * time.Hour)),
+		UserID:     db.Int(user.ID),
+		Severity:   dbgen.NotificationSeverityWarning,
+		IsMarkdown: true,
+		OrgID:      db.Int(org.ID),
+		Stage:      db.Text(common.StageTest),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID, common.StageTest); (err != nil) || (n.ID != orgNotification.ID) {
+		t.Errorf("Cannot retrieve org notification: %v", err)
+	}
+
+	if n, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID, "other"); (err != nil) || (n.ID != otherStage.ID) {
+		t.Errorf("Cannot retrieve stage notification: %v", err)
+	}
+
+	if _, err := store.Impl().CancelSystemNotification(ctx, orgNotification.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Impl().CancelSystemNotification(ctx, orgNotification.ID); err != db.ErrRecordNotFound {
+		t.Errorf("Unexpected result of second cancel: %v", err)
+	}
+
+	if n, err := store.Impl().RetrieveSystemUserNotification(ctx, tnow, user.ID, common.StageTest); (err == nil) && (n.ID == orgNotification.ID) {
+		t.Errorf("Retrieved cancelled notification")
+	}
+}
+
 // despite being called "Test Update Subscription", what we're actually checking are:
 // - ability to find existing user account in `CreateNewAccount()`
 // - not relying on cache inside the transaction
@@ -79,10 +79,31 @@ func discoverLayout(ctx context.Context, efs *embed.FS, templateRootDir string,
 	return layout, nil
 }
 
+// rewriteFS modifies template sources before they are parsed
+type rewriteFS struct {
+	fs      fs.FS
+	rewrite func(string) string
+}
+
+func (r *rewriteFS) Open(name string) (fs.File, error) {
+	return r.fs.Open(name)
+}
+
+// ReadFile implements fs.ReadFileFS that is used by template.ParseFS()
+func (r *rewriteFS) ReadFile(name string) ([]byte, error) {
+	content, err := fs.ReadFile(r.fs, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(r.rewrite(string(content))), nil
+}
+
 type TemplatesBuilder struct {
 	layers          []*FileSystemTemplateLayout
 	templateRootDir string
 	functions       template.FuncMap
+	rewrite         func(string) string
 }
 
 func NewTemplatesBuilder() *TemplatesBuilder {
@@ -134,6 +155,11 @@ func (b *TemplatesBuilder) AddFunctions(ctx context.Context, ff template.FuncMap
 	}
 }
 
+// SetRewriter sets a function that modifies template sources of all layers before parsing (e.g. asset URLs)
+func (b *TemplatesBuilder) SetRewriter(rewrite func(string) string) {
+	b.rewrite = rewrite
+}
+
 // layerName is for identification/debugging purposes.
 func (b *TemplatesBuilder) AddFS(ctx context.Context, efs *embed.FS, layerName string) error {
 	layout, err := discoverLayout(ctx, efs, b.templateRootDir, layerName)
@@ -202,7 +228,12 @@ func (b *TemplatesBuilder) Build(ctx context.Context) (*Templates, error) {
 			slog.Log(ctx, common.LevelTrace, "Parsing files for bundle from layer",
 				"bundle", bundleName, "layer", layer.LayerName, "count", len(filesToParse), "files", filesToParse)
 
-			if _, err := htmlTmpl.ParseFS(layer.FS, filesToParse...); err != nil {
+			var layerFS fs.FS = layer.FS
+			if b.rewrite != nil {
+				layerFS = &rewriteFS{fs: layer.FS, rewrite: b.rewrite}
+			}
+
+			if _, err := htmlTmpl.ParseFS(layerFS, filesToParse...); err != nil {
 				slog.ErrorContext(ctx, "Failed to parse templates", "layer", layer.LayerName, "bundle", bundleName, common.ErrAttr(err))
 				return nil, err
 			}
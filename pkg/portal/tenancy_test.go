@@ -0,0 +1,220 @@
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+const (
+	tenancyVictimName = "tenancy-victim"
+	tenancyPwnedName  = "tenancy-pwned"
+)
+
+var (
+	routeParamRegexp = regexp.MustCompile(`\{[^}]+\}`)
+)
+
+// join/leave operate on the membership of the session user only (and are a no-op without an invite)
+var tenancySelfRoutes = map[string]bool{
+	http.MethodPut + " /" + common.OrgEndpoint + "/{" + common.ParamOrg + "}/" + common.MembersEndpoint:    true,
+	http.MethodDelete + " /" + common.OrgEndpoint + "/{" + common.ParamOrg + "}/" + common.MembersEndpoint: true,
+}
+
+type tenantFixture struct {
+	user     *dbgen.User
+	org      *dbgen.Organization
+	property *dbgen.Property
+	apiKey   *dbgen.APIKey
+}
+
+func newTenantFixture(ctx context.Context, name string) (*tenantFixture, error) {
+	user, org, err := db_tests.CreateNewAccountForTest(ctx, store, name, testPlan)
+	if err != nil {
+		return nil, err
+	}
+
+	propertyParams := db_tests.CreateNewPropertyParams(user.ID, "example.com")
+	propertyParams.Name = tenancyVictimName
+	property, _, err := store.Impl().CreateNewProperty(ctx, propertyParams, org)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, _, err := store.Impl().CreateAPIKey(ctx, user, db_tests.CreateNewPuzzleAPIKeyParams(tenancyVictimName, time.Now(), 24*time.Hour, 10.0))
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenantFixture{
+		user:     user,
+		org:      org,
+		property: property,
+		apiKey:   apiKey,
+	}, nil
+}
+
+// tenancyRoutePath substitutes path parameters of the (tenant-scoped) route with IDs of victim's resources
+func tenancyRoutePath(pattern string, victim *tenantFixture) (string, string, bool) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return "", "", false
+	}
+
+	// strip the domain part of the pattern
+	if i := strings.Index(path, "/"); i > 0 {
+		path = path[i:]
+	}
+
+	if !strings.Contains(path, "{"+common.ParamOrg+"}") && !strings.Contains(path, "{"+common.ParamKey+"}") {
+		return "", "", false
+	}
+
+	replacer := strings.NewReplacer(
+		"{"+common.ParamOrg+"}", server.IDHasher.Encrypt(int(victim.org.ID)),
+		"{"+common.ParamProperty+"}", server.IDHasher.Encrypt(int(victim.property.ID)),
+		"{"+common.ParamUser+"}", server.IDHasher.Encrypt(int(victim.user.ID)),
+		"{"+common.ParamKey+"}", server.IDHasher.Encrypt(int(victim.apiKey.ID)),
+		"{"+common.ParamID+"}", server.IDHasher.Encrypt(1),
+		"{"+common.ParamPeriod+"}", "24h",
+	)
+	path = replacer.Replace(path)
+
+	return method, path, !routeParamRegexp.MatchString(path)
+}
+
+// isTenancyViolation checks if cross-tenant request was not rejected: any successful response or a redirect,
+// other than to the error (or login) page, means that the handler did not check access to the resource
+func isTenancyViolation(resp *http.Response) bool {
+	if resp.StatusCode >= http.StatusBadRequest {
+		return false
+	}
+
+	if (resp.StatusCode >= http.StatusMultipleChoices) && (resp.StatusCode < http.StatusBadRequest) {
+		location, err := resp.Location()
+		if err != nil {
+			return true
+		}
+
+		return !strings.HasPrefix(location.Path, "/"+common.ErrorEndpoint) &&
+			!strings.HasPrefix(location.Path, "/"+common.LoginEndpoint)
+	}
+
+	return true
+}
+
+func checkVictimIntact(ctx context.Context, t *testing.T, victim, attacker *tenantFixture) {
+	org, err := store.Impl().RetrieveUserOrganization(ctx, victim.user, victim.org.ID)
+	if err != nil {
+		t.Fatalf("Victim org is not accessible: %v", err)
+	}
+
+	if org.Name != victim.org.Name {
+		t.Errorf("Victim org was renamed to %v", org.Name)
+	}
+
+	property, err := store.Impl().RetrieveOrgProperty(ctx, org, victim.property.ID)
+	if err != nil {
+		t.Fatalf("Victim property is not accessible: %v", err)
+	}
+
+	if (property.Name != tenancyVictimName) || (property.OrgID != victim.property.OrgID) {
+		t.Errorf("Victim property was modified")
+	}
+
+	keys, err := store.Impl().RetrieveUserAPIKeys(ctx, victim.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (len(keys) != 1) || (keys[0].ExternalID != victim.apiKey.ExternalID) {
+		t.Errorf("Victim API key was modified")
+	}
+
+	members, err := store.Impl().RetrieveOrganizationUsers(ctx, victim.org.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range members {
+		if m.User.ID == attacker.user.ID {
+			t.Errorf("Attacker got into victim's org with level %v", m.Level)
+		}
+	}
+}
+
+func TestPortalTenantIsolation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := common.TraceContext(t.Context(), t.Name())
+
+	victim, err := newTenantFixture(ctx, t.Name()+"_victim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attacker, err := newTenantFixture(ctx, t.Name()+"_attacker")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := http.NewServeMux()
+	rg := server.Setup(portalDomain(), common.NoopMiddleware)
+	rg.Register(srv)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, attacker.user.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csrfToken := server.XSRF.Token(strconv.Itoa(int(attacker.user.ID)))
+
+	for _, pattern := range rg.Patterns() {
+		method, path, ok := tenancyRoutePath(pattern, victim)
+		if !ok {
+			continue
+		}
+
+		// join/leave requests are still sent, but membership is validated separately in checkVictimIntact()
+		self := tenancySelfRoutes[method+" "+strings.TrimPrefix(pattern, method+" "+portalDomain())]
+
+		t.Run(strings.ReplaceAll(path, "/", "_"), func(t *testing.T) {
+			form := url.Values{}
+			form.Set(common.ParamCSRFToken, csrfToken)
+			form.Set(common.ParamName, tenancyPwnedName)
+			form.Set(common.ParamDomain, "example.com")
+			form.Set(common.ParamEmail, attacker.user.Email)
+
+			req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+			req.AddCookie(cookie)
+			req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+			req.Header.Set(common.HeaderCSRFToken, csrfToken)
+
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if !self && isTenancyViolation(resp) {
+				t.Errorf("Cross-tenant request %s %s was not rejected (status %v)", method, path, resp.StatusCode)
+			}
+		})
+	}
+
+	checkVictimIntact(ctx, t, victim, attacker)
+}
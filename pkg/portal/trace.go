@@ -0,0 +1,111 @@
+package portal
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+)
+
+const (
+	traceMaxRecords = 100
+)
+
+type traceVerifyOutput struct {
+	Timestamp  time.Time `json:"timestamp"`
+	UserID     int32     `json:"user_id"`
+	OrgID      int32     `json:"org_id"`
+	PropertyID int32     `json:"property_id"`
+	PuzzleID   uint64    `json:"puzzle_id"`
+	Status     int8      `json:"status"`
+	DurationUs uint32    `json:"duration_us,omitempty"`
+}
+
+type traceAuditOutput struct {
+	CreatedAt time.Time `json:"created_at"`
+	UserID    int32     `json:"user_id,omitempty"`
+	UserEmail string    `json:"user_email,omitempty"`
+	Action    string    `json:"action"`
+	Source    string    `json:"source"`
+	Table     string    `json:"table"`
+	EntityID  int64     `json:"entity_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+}
+
+func newTraceAuditOutput(r *dbgen.GetTraceAuditLogsRow) *traceAuditOutput {
+	return &traceAuditOutput{
+		CreatedAt: r.AuditLog.CreatedAt.Time.UTC(),
+		UserID:    r.AuditLog.UserID.Int32,
+		UserEmail: r.Email.String,
+		Action:    string(r.AuditLog.Action),
+		Source:    string(r.AuditLog.Source),
+		Table:     r.AuditLog.EntityTable,
+		EntityID:  r.AuditLog.EntityID.Int64,
+		SessionID: r.AuditLog.SessionID,
+	}
+}
+
+// getTrace correlates everything that is known about a single request by its trace ID for support. Log lines are
+// only available while they are still kept in memory of this instance.
+func (s *Server) getTrace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if _, err := s.sessionAdmin(w, r); err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	traceID := strings.TrimSpace(r.URL.Query().Get(common.ParamTraceID))
+	if len(traceID) == 0 {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	records, err := s.TimeSeries.RetrieveVerifyTraces(ctx, traceID, traceMaxRecords)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve verify traces", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	auditLogs, err := s.Store.Impl().RetrieveTraceAuditLogs(ctx, traceID, traceMaxRecords)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	verifications := make([]*traceVerifyOutput, 0, len(records))
+	for _, v := range records {
+		verifications = append(verifications, &traceVerifyOutput{
+			Timestamp:  v.Timestamp.UTC(),
+			UserID:     v.UserID,
+			OrgID:      v.OrgID,
+			PropertyID: v.PropertyID,
+			PuzzleID:   v.PuzzleID,
+			Status:     v.Status,
+			DurationUs: v.DurationUs,
+		})
+	}
+
+	events := make([]*traceAuditOutput, 0, len(auditLogs))
+	for _, a := range auditLogs {
+		events = append(events, newTraceAuditOutput(a))
+	}
+
+	response := struct {
+		TraceID       string                 `json:"trace_id"`
+		Logs          []*common.TraceLogLine `json:"logs"`
+		Verifications []*traceVerifyOutput   `json:"verifications"`
+		AuditLogs     []*traceAuditOutput    `json:"audit_logs"`
+	}{
+		TraceID:       traceID,
+		Logs:          common.RecentTraceLogs(traceID),
+		Verifications: verifications,
+		AuditLogs:     events,
+	}
+
+	common.SendJSONResponse(ctx, w, response, common.NoCacheHeaders)
+}
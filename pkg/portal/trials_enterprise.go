@@ -0,0 +1,309 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/billing"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	dbgen "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/generated"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	"github.com/badoux/checkmail"
+)
+
+const (
+	trialsTemplate       = "trials/trials.html"
+	trialsResultTemplate = "trials/result.html"
+	maxInternalTrialDays = 365
+	// how long before the end of internal trial user is reminded about it
+	internalTrialExpirationNotificationDays = 3
+)
+
+type trialPlan struct {
+	ProductID string
+	Name      string
+}
+
+type trialsRenderContext struct {
+	AlertRenderContext
+	CsrfRenderContext
+	Plans       []*trialPlan
+	DefaultDays int
+	MaxDays     int
+}
+
+type internalTrialInput struct {
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	ProductID string `json:"product"`
+	Days      int    `json:"days"`
+}
+
+type internalTrialOutput struct {
+	UserID         int32     `json:"user_id"`
+	SubscriptionID int32     `json:"subscription_id"`
+	Email          string    `json:"email"`
+	Plan           string    `json:"plan"`
+	TrialEndsAt    time.Time `json:"trial_ends_at"`
+	NewUser        bool      `json:"new_user"`
+}
+
+func (s *Server) createTrialsContext(user *dbgen.User) *trialsRenderContext {
+	plans := s.PlanService.TrialPlans(s.Stage)
+
+	renderCtx := &trialsRenderContext{
+		CsrfRenderContext: s.CreateCsrfContext(user),
+		Plans:             make([]*trialPlan, 0, len(plans)),
+		DefaultDays:       s.PlanService.GetInternalTrialPlan().TrialDays(),
+		MaxDays:           maxInternalTrialDays,
+	}
+
+	for _, p := range plans {
+		renderCtx.Plans = append(renderCtx.Plans, &trialPlan{ProductID: p.ProductID(), Name: p.Name()})
+	}
+
+	return renderCtx
+}
+
+func (s *Server) getTrials(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	user, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ViewModel{Model: s.createTrialsContext(user), View: trialsTemplate}, nil
+}
+
+func (s *Server) findTrialPlan(productID string) billing.Plan {
+	for _, p := range s.PlanService.TrialPlans(s.Stage) {
+		if p.ProductID() == productID {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// validateInternalTrial returns user-facing error message if input is not valid
+func (s *Server) validateInternalTrial(input *internalTrialInput) (billing.Plan, string) {
+	input.Email = strings.TrimSpace(input.Email)
+	input.Name = strings.TrimSpace(input.Name)
+
+	if err := checkmail.ValidateFormat(input.Email); err != nil {
+		return nil, "Email address is not valid."
+	}
+
+	plan := s.findTrialPlan(strings.TrimSpace(input.ProductID))
+	if plan == nil {
+		return nil, "Unknown plan."
+	}
+
+	if input.Days == 0 {
+		input.Days = plan.TrialDays()
+	}
+
+	if (input.Days < 1) || (input.Days > maxInternalTrialDays) {
+		return nil, fmt.Sprintf("Trial duration has to be between 1 and %d days.", maxInternalTrialDays)
+	}
+
+	if len(input.Name) == 0 {
+		input.Name, _, _ = strings.Cut(input.Email, "@")
+	}
+
+	return plan, ""
+}
+
+// NOTE: ReferenceID logic should stay the same forever for correct deduplication in DB
+func internalTrialExpirationReference(subscriptionID int32) string {
+	return fmt.Sprintf("trial/%v/expiration", subscriptionID)
+}
+
+// NOTE: ReferenceID logic should stay the same forever for correct deduplication in DB
+func internalTrialExpiredReference(subscriptionID int32) string {
+	return fmt.Sprintf("trial/%v/expired", subscriptionID)
+}
+
+// orgID is the organization trial was created with (if any), its billing contacts are notified too
+func createInternalTrialNotifications(user *dbgen.User, orgID int32, plan billing.Plan, trialEndsAt, tnow time.Time) []*common.ScheduledNotification {
+	trialCtx := email.TrialContext{
+		PlanName:            plan.Name(),
+		TrialEndDate:        trialEndsAt.Format("02 Jan 2006"),
+		BillingSettingsPath: fmt.Sprintf("%s?%s=%s", common.SettingsEndpoint, common.ParamTab, common.UsageEndpoint),
+	}
+
+	expirationAt := trialEndsAt.AddDate(0, 0, -internalTrialExpirationNotificationDays)
+	if expirationAt.Before(tnow) {
+		expirationAt = tnow
+	}
+
+	return []*common.ScheduledNotification{
+		{
+			ReferenceID: internalTrialExpirationReference(user.SubscriptionID.Int32),
+			UserID:      user.ID,
+			OrgID:       orgID,
+			Subject:     fmt.Sprintf("[%s] Your trial ends soon", common.PrivateCaptcha),
+			Data: &email.TrialExpirationContext{
+				TrialContext: trialCtx,
+				ExpireDays:   internalTrialExpirationNotificationDays,
+			},
+			DateTime:     expirationAt,
+			TemplateHash: email.TrialExpirationTemplate.Hash(),
+			Persistent:   false,
+			Condition:    common.NotificationWithSubscription,
+		},
+		{
+			ReferenceID:  internalTrialExpiredReference(user.SubscriptionID.Int32),
+			UserID:       user.ID,
+			OrgID:        orgID,
+			Subject:      fmt.Sprintf("[%s] Your trial has ended", common.PrivateCaptcha),
+			Data:         &trialCtx,
+			DateTime:     trialEndsAt,
+			TemplateHash: email.TrialExpiredTemplate.Hash(),
+			Persistent:   false,
+			Condition:    common.NotificationWithSubscription,
+		},
+	}
+}
+
+// grantInternalTrial creates (or replaces internal) subscription for the user with such email, creating the user if needed
+func (s *Server) grantInternalTrial(ctx context.Context, admin *dbgen.User, input *internalTrialInput, plan billing.Plan) (*internalTrialOutput, error) {
+	tnow := time.Now().UTC()
+	trialEndsAt := tnow.AddDate(0, 0, input.Days)
+
+	subscrParams := createInternalTrial(plan, s.PlanService.ActiveTrialStatus())
+	subscrParams.TrialEndsAt = db.Timestampz(trialEndsAt)
+
+	var user *dbgen.User
+	var org *dbgen.Organization
+
+	if auditEvents, err := s.Store.WithTx(ctx, func(impl *db.BusinessStoreImpl) ([]*common.AuditLogEvent, error) {
+		var err error
+		var auditEvents []*common.AuditLogEvent
+		user, org, auditEvents, err = impl.CreateNewAccount(ctx, subscrParams, input.Email, input.Name, common.DefaultOrgName, -1 /*existing user ID*/)
+		return auditEvents, err
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to grant internal trial", "adminID", admin.ID, common.ErrAttr(err))
+		return nil, err
+	} else {
+		s.Store.AuditLog().RecordEvents(ctx, auditEvents, common.AuditLogSourcePortal)
+	}
+
+	slog.InfoContext(ctx, "Granted internal trial", "adminID", admin.ID, "userID", user.ID, "subscriptionID", user.SubscriptionID.Int32,
+		"product", plan.ProductID(), "days", input.Days, "newUser", org != nil)
+
+	var orgID int32
+	if org != nil {
+		orgID = org.ID
+	}
+
+	for _, n := range createInternalTrialNotifications(user, orgID, plan, trialEndsAt, tnow) {
+		if _, err := s.Store.Impl().CreateUserNotification(ctx, n); err != nil {
+			slog.ErrorContext(ctx, "Failed to schedule internal trial notification", "userID", user.ID, "reference", n.ReferenceID, common.ErrAttr(err))
+		}
+	}
+
+	if org != nil {
+		job := s.Jobs.OnboardUser(user, plan)
+		go common.RunOneOffJob(common.CopyTraceID(ctx, context.Background()), job, job.NewParams())
+	}
+
+	return &internalTrialOutput{
+		UserID:         user.ID,
+		SubscriptionID: user.SubscriptionID.Int32,
+		Email:          user.Email,
+		Plan:           plan.Name(),
+		TrialEndsAt:    trialEndsAt,
+		NewUser:        org != nil,
+	}, nil
+}
+
+func (s *Server) postTrial(w http.ResponseWriter, r *http.Request) (*ViewModel, error) {
+	ctx := r.Context()
+
+	admin, err := s.sessionAdmin(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to read request body", common.ErrAttr(err))
+		return nil, ErrInvalidRequestArg
+	}
+
+	input := &internalTrialInput{
+		Email:     r.FormValue(common.ParamEmail),
+		Name:      r.FormValue(common.ParamName),
+		ProductID: r.FormValue(common.ParamProduct),
+	}
+
+	renderCtx := s.createTrialsContext(admin)
+
+	if value := strings.TrimSpace(r.FormValue(common.ParamDays)); len(value) > 0 {
+		if input.Days, err = strconv.Atoi(value); err != nil {
+			renderCtx.ErrorMessage = "Trial duration is not valid."
+			return &ViewModel{Model: renderCtx, View: trialsResultTemplate}, nil
+		}
+	}
+
+	plan, errMsg := s.validateInternalTrial(input)
+	if len(errMsg) == 0 {
+		if output, err := s.grantInternalTrial(ctx, admin, input, plan); err == nil {
+			renderCtx.SuccessMessage = fmt.Sprintf("Trial of %s plan was granted to %s until %s.", output.Plan, output.Email, output.TrialEndsAt.Format("02 Jan 2006"))
+		} else if err == db.ErrDuplicateAccount {
+			errMsg = "User already has a paid subscription."
+		} else {
+			errMsg = "Failed to grant trial. Please try again."
+		}
+	}
+
+	if len(errMsg) > 0 {
+		renderCtx.ErrorMessage = errMsg
+	}
+
+	return &ViewModel{Model: renderCtx, View: trialsResultTemplate}, nil
+}
+
+// postTrialAPI is JSON counterpart of postTrial for automation
+func (s *Server) postTrialAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	admin, err := s.sessionAdmin(w, r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	input := &internalTrialInput{}
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		slog.WarnContext(ctx, "Failed to decode internal trial request", common.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	plan, errMsg := s.validateInternalTrial(input)
+	if len(errMsg) > 0 {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	output, err := s.grantInternalTrial(ctx, admin, input, plan)
+	if err != nil {
+		if err == db.ErrDuplicateAccount {
+			http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.SendJSONResponse(ctx, w, output, common.NoCacheHeaders)
+}
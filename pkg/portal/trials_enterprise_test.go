@@ -0,0 +1,169 @@
+//go:build enterprise
+
+package portal
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	db_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/db/tests"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/email"
+	portal_tests "github.com/PrivateCaptcha/PrivateCaptcha/pkg/portal/tests"
+)
+
+func TestGrantInternalTrial(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	admin, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name()+"_admin", testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	input := &internalTrialInput{
+		Email:     strings.ToLower(t.Name()) + "@privatecaptcha.com",
+		ProductID: server.PlanService.GetInternalTrialPlan().ProductID(),
+		Days:      30,
+	}
+
+	plan, errMsg := server.validateInternalTrial(input)
+	if len(errMsg) > 0 {
+		t.Fatal(errMsg)
+	}
+
+	output, err := server.grantInternalTrial(ctx, admin, input, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !output.NewUser {
+		t.Error("Expected new user to be created")
+	}
+
+	subscr, err := store.Impl().RetrieveSubscription(ctx, output.SubscriptionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if subscr.Status != server.PlanService.ActiveTrialStatus() {
+		t.Errorf("Unexpected subscription status: %v", subscr.Status)
+	}
+
+	if days := time.Until(subscr.TrialEndsAt.Time).Hours() / 24; (days < 29) || (days > 30) {
+		t.Errorf("Unexpected trial duration: %v days", days)
+	}
+
+	// granting again replaces internal subscription of the same user
+	input.Days = 7
+	again, err := server.grantInternalTrial(ctx, admin, input, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (again.UserID != output.UserID) || again.NewUser || (again.SubscriptionID == output.SubscriptionID) {
+		t.Errorf("Unexpected second grant: %+v", again)
+	}
+}
+
+func TestInternalTrialNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	tnow := time.Now().UTC()
+	user, org, err := db_tests.CreateNewAccountForTest(t.Context(), store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := createInternalTrialNotifications(user, org.ID, testPlan, tnow.Add(24*time.Hour), tnow)
+	if len(notifications) != 2 {
+		t.Fatalf("Unexpected number of notifications: %v", len(notifications))
+	}
+
+	// trial is shorter than the reminder period
+	if !notifications[0].DateTime.Equal(tnow) {
+		t.Errorf("Unexpected expiration reminder time: %v", notifications[0].DateTime)
+	}
+
+	if notifications[1].TemplateHash != email.TrialExpiredTemplate.Hash() {
+		t.Errorf("Unexpected expired notification template")
+	}
+
+	for _, n := range notifications {
+		if n.OrgID != org.ID {
+			t.Errorf("Unexpected notification org: %v", n.OrgID)
+		}
+	}
+}
+
+func TestPostTrialAPINotAdmin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := t.Context()
+	user, _, err := db_tests.CreateNewAccountForTest(ctx, store, t.Name(), testPlan)
+	if err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	srv := http.NewServeMux()
+	server.Setup(portalDomain(), common.NoopMiddleware).Register(srv)
+
+	cookie, err := portal_tests.AuthenticateSuite(ctx, user.Email, srv, server.XSRF, server.Sessions.CookieName, server.Mailer.(*email.StubMailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"email":"sales@example.com","days":14}`)
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/%s/%s/%s", common.AdminEndpoint, common.TrialsEndpoint, common.NewEndpoint), bytes.NewReader(body))
+	req.AddCookie(cookie)
+	req.Header.Set(common.HeaderContentType, common.ContentTypeJSON)
+	req.Header.Set(common.HeaderCSRFToken, server.XSRF.Token(fmt.Sprintf("%d", user.ID)))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Unexpected status code %v", w.Code)
+	}
+}
+
+func TestRenderTrials(t *testing.T) {
+	model := &trialsRenderContext{
+		CsrfRenderContext: stubToken(),
+		Plans: []*trialPlan{
+			{ProductID: "prod_1", Name: "Internal Trial"},
+			{ProductID: "prod_2", Name: "Professional"},
+		},
+		DefaultDays: 14,
+		MaxDays:     maxInternalTrialDays,
+	}
+
+	path := server.RelURL(strings.Join([]string{common.AdminEndpoint, common.TrialsEndpoint}, "/"))
+	buf, err := server.RenderResponse(t.Context(), trialsTemplate, model, &RequestContext{Path: server.RelURL(path)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := portal_tests.ParseHTML(t, buf)
+	selection := document.Find("option.trial-plan")
+	expected := []string{"Internal Trial", "Professional"}
+	if len(expected) != len(selection.Nodes) {
+		t.Fatalf("Expected %v matches, but got %v", len(expected), len(selection.Nodes))
+	}
+	for i, node := range selection.Nodes {
+		if nodeText := portal_tests.Text(node); expected[i] != nodeText {
+			t.Errorf("Expected match %v at %v, but got %v", expected[i], i, nodeText)
+		}
+	}
+}
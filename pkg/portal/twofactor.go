@@ -90,7 +90,8 @@ func (s *Server) postTwoFactor(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	job := s.Jobs.LoginUser(sess)
+	locale := s.requestLocale(r)
+	job := s.Jobs.LoginUser(sess, locale, newLoginDevice(r.UserAgent(), locale))
 	go common.RunOneOffJob(common.CopyTraceID(ctx, context.Background()), job, job.NewParams())
 
 	_ = sess.Set(session.KeyLoginStep, loginStepCompleted)
@@ -53,6 +53,13 @@ func (s *Server) Org(user *dbgen.User, r *http.Request) (*dbgen.Organization, er
 
 	if !s.checkUserOrgAccess(user, org) {
 		slog.ErrorContext(ctx, "User cannot use this org", "userID", user.ID, "orgID", orgID, "enterprise", s.isEnterprise())
+		s.recordLimitDecision(ctx, &db.LimitDecision{
+			Resource: db.LimitResourceSubscription,
+			Code:     common.StatusSubscriptionInactiveError,
+			UserID:   user.ID,
+			Org:      org,
+			Err:      errLimitedFeature,
+		})
 		return nil, errLimitedFeature
 	}
 
@@ -125,6 +132,11 @@ func (s *Server) SessionUser(ctx context.Context, sess *session.Session) (*dbgen
 	return user, nil
 }
 
+func (s *Server) recordLimitDecision(ctx context.Context, decision *db.LimitDecision) {
+	decision.Source = common.AuditLogSourcePortal
+	s.SubscriptionLimits.RecordDecision(ctx, decision)
+}
+
 func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	sess := s.Session(w, r)
@@ -5,16 +5,31 @@ import (
 	"encoding"
 	"net/http"
 	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
 
 type VerifyResult struct {
 	UserID     int32
 	OrgID      int32
 	PropertyID int32
+	SiteKey    string
 	PuzzleID   uint64
 	Error      VerifyError
 	CreatedAt  time.Time
 	Domain     string
+	// puzzle was accepted only due to allowed clock skew after expiration
+	SkewTolerated bool
+	// puzzle was issued without work due to a recent solve by the same end user
+	Remembered bool
+	// claims of the property owner embedded into the (signed) puzzle
+	Claims map[string]string
+	// arm of the difficulty experiment that puzzle was issued from
+	ExperimentArm common.ExperimentArm
+	// classification of the end user that puzzle was issued to
+	VisitorClass common.VisitorClass
+	// result of checking the widget integrity beacon
+	Integrity common.IntegrityStatus
 }
 
 func (vr *VerifyResult) Valid() bool {
@@ -58,12 +73,25 @@ type Puzzle interface {
 	Init(validityPeriod time.Duration) error
 	HashKey() uint64
 	IsStub() bool
+	IsRemembered() bool
 	IsZero() bool
 	Difficulty() uint8
 	SolutionsCount() int
 	PuzzleID() uint64
 	PropertyID() [PropertyIDSize]byte
 	Expiration() time.Time
+	WidgetFlags() WidgetFlags
+	SetWidgetFlags(flags WidgetFlags)
+	VisitorClass() common.VisitorClass
+	SetVisitorClass(vc common.VisitorClass)
+	StickySince() time.Time
+	SetStickySince(t time.Time)
+	StickyFingerprint() uint32
+	SetStickyFingerprint(fp uint32)
+	DifficultyLease() time.Time
+	SetDifficultyLease(t time.Time)
+	Claims() map[string]string
+	SetClaims(claims map[string]string) error
 	Serialize(ctx context.Context, salt *Salt, extraSalt []byte) (*PuzzlePayload, error)
 }
 
@@ -0,0 +1,281 @@
+package puzzle
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"math"
+	randv2 "math/rand/v2"
+)
+
+const (
+	// solutions part of the verify payload starts with this prefix when end user answered image challenge instead
+	// of solving the compute puzzle (it cannot be confused with solutions as it is not a valid base64)
+	ImageSolutionPrefix = "img:"
+	ImageCodeLength     = 8
+	// characters that are hard to confuse with each other (no 0/O/Q/D, 1/I/L, 2/Z, 5/S, 8/B etc.)
+	imageCodeAlphabet = "ACEFHJKMNPRTUVWXY34679"
+	// glyphs are defined on a grid of this size and scaled to pixels
+	imageGlyphWidth  = 4
+	imageGlyphHeight = 6
+	imageGlyphScale  = 6
+	imageCellWidth   = 36
+	imageStrokeWidth = 2
+	imagePadding     = 14
+	imageNoiseLines  = 6
+	imageNoiseDots   = 400
+	// sine wave distortion applied to the whole image
+	imageWaveAmplitude = 4
+	imageWavePeriod    = 48
+)
+
+var (
+	errImageAnswerLength = errors.New("image challenge answer has invalid length")
+	imageChallengeDomain = []byte("pc-image-challenge")
+	// strokes of the code characters as polylines (pairs of x, y coordinates on the glyph grid)
+	imageGlyphStrokes = map[byte][][]float64{
+		'A': {{0, 6, 2, 0, 4, 6}, {1, 3.5, 3, 3.5}},
+		'C': {{4, 1, 3, 0, 1, 0, 0, 1, 0, 5, 1, 6, 3, 6, 4, 5}},
+		'E': {{4, 0, 0, 0, 0, 6, 4, 6}, {0, 3, 3, 3}},
+		'F': {{4, 0, 0, 0, 0, 6}, {0, 3, 3, 3}},
+		'H': {{0, 0, 0, 6}, {4, 0, 4, 6}, {0, 3, 4, 3}},
+		'J': {{1, 0, 4, 0}, {3, 0, 3, 5, 2, 6, 1, 6, 0, 5}},
+		'K': {{0, 0, 0, 6}, {4, 0, 0, 3.5}, {1.3, 2.5, 4, 6}},
+		'M': {{0, 6, 0, 0, 2, 3, 4, 0, 4, 6}},
+		'N': {{0, 6, 0, 0, 4, 6, 4, 0}},
+		'P': {{0, 6, 0, 0, 3, 0, 4, 1, 4, 2, 3, 3, 0, 3}},
+		'R': {{0, 6, 0, 0, 3, 0, 4, 1, 4, 2, 3, 3, 0, 3}, {2, 3, 4, 6}},
+		'T': {{0, 0, 4, 0}, {2, 0, 2, 6}},
+		'U': {{0, 0, 0, 5, 1, 6, 3, 6, 4, 5, 4, 0}},
+		'V': {{0, 0, 2, 6, 4, 0}},
+		'W': {{0, 0, 1, 6, 2, 2, 3, 6, 4, 0}},
+		'X': {{0, 0, 4, 6}, {4, 0, 0, 6}},
+		'Y': {{0, 0, 2, 3, 4, 0}, {2, 3, 2, 6}},
+		'3': {{0, 0, 4, 0, 2, 2.5, 3, 2.5, 4, 3.5, 4, 5, 3, 6, 1, 6, 0, 5}},
+		'4': {{3, 6, 3, 0, 0, 4, 4, 4}},
+		'6': {{3, 0, 1, 0, 0, 1, 0, 5, 1, 6, 3, 6, 4, 5, 4, 4, 3, 3, 0, 3}},
+		'7': {{0, 0, 4, 0, 1.5, 6}},
+		'9': {{4, 3, 1, 3, 0, 2, 0, 1, 1, 0, 3, 0, 4, 1, 4, 5, 3, 6, 1, 6}},
+	}
+)
+
+// ImageChallengeCode derives the code, shown to the end user in the image challenge, from the signed puzzle bytes.
+// Code is not stored anywhere, so any node that knows the key can issue and verify image challenges.
+func ImageChallengeCode(key, puzzleData []byte) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(imageChallengeDomain)
+	_, _ = mac.Write(puzzleData)
+	sum := mac.Sum(nil)
+
+	code := make([]byte, ImageCodeLength)
+	value := binary.LittleEndian.Uint64(sum[:8])
+	for i := range code {
+		code[i] = imageCodeAlphabet[value%uint64(len(imageCodeAlphabet))]
+		value /= uint64(len(imageCodeAlphabet))
+	}
+
+	return string(code)
+}
+
+// RenderImageChallenge draws code characters as randomly rotated, scaled and skewed strokes, distorts the whole image
+// with a sine wave and adds noise, so that it stays readable for humans while being not trivial for OCR. Image
+// challenge is an accessibility fallback and is expected to be weaker than the compute puzzle, which is why it is
+// only served for properties that opted in (and every puzzle can only be answered once).
+func RenderImageChallenge(code string) ([]byte, error) {
+	width := 2*imagePadding + len(code)*imageCellWidth
+	height := 2*imagePadding + imageGlyphHeight*imageGlyphScale
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	fillGray(img, 0xFF)
+
+	for i := 0; i < imageNoiseDots; i++ {
+		img.SetGray(randv2.IntN(width), randv2.IntN(height), color.Gray{Y: uint8(120 + randv2.IntN(100))})
+	}
+
+	for i := 0; i < len(code); i++ {
+		strokes, ok := imageGlyphStrokes[code[i]]
+		if !ok {
+			continue
+		}
+
+		cx := float64(imagePadding+i*imageCellWidth+imageCellWidth/2) + randFloat(-3, 3)
+		cy := float64(height)/2 + randFloat(-5, 5)
+		angle := randFloat(-0.35, 0.35)
+		scale := imageGlyphScale * randFloat(0.85, 1.15)
+		skew := randFloat(-0.3, 0.3)
+		sin, cos := math.Sincos(angle)
+		shade := color.Gray{Y: uint8(randv2.IntN(70))}
+
+		transform := func(x, y float64) (float64, float64) {
+			u := (x - imageGlyphWidth/2) * scale
+			v := (y - imageGlyphHeight/2) * scale
+			u += skew * v
+			return cx + u*cos - v*sin, cy + u*sin + v*cos
+		}
+
+		for _, stroke := range strokes {
+			for j := 2; j+1 < len(stroke); j += 2 {
+				x1, y1 := transform(stroke[j-2], stroke[j-1])
+				x2, y2 := transform(stroke[j], stroke[j+1])
+				drawThickLine(img, x1, y1, x2, y2, imageStrokeWidth, shade)
+			}
+		}
+	}
+
+	img = waveDistort(img)
+
+	for i := 0; i < imageNoiseLines; i++ {
+		x1, y1 := randv2.IntN(width), randv2.IntN(height)
+		x2, y2 := randv2.IntN(width), randv2.IntN(height)
+		drawLine(img, x1, y1, x2, y2, color.Gray{Y: uint8(60 + randv2.IntN(100))})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func randFloat(lo, hi float64) float64 {
+	return lo + randv2.Float64()*(hi-lo)
+}
+
+func fillGray(img *image.Gray, y uint8) {
+	for i := range img.Pix {
+		img.Pix[i] = y
+	}
+}
+
+// waveDistort shifts rows and columns of the image by sine waves with random phases
+func waveDistort(src *image.Gray) *image.Gray {
+	bounds := src.Bounds()
+	dst := image.NewGray(bounds)
+	fillGray(dst, 0xFF)
+
+	phaseX, phaseY := randFloat(0, 2*math.Pi), randFloat(0, 2*math.Pi)
+	periodX := imageWavePeriod * randFloat(0.8, 1.2)
+	periodY := imageWavePeriod * randFloat(0.8, 1.2)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sx := x + int(math.Round(imageWaveAmplitude*math.Sin(2*math.Pi*float64(y)/periodY+phaseX)))
+			sy := y + int(math.Round(imageWaveAmplitude*math.Sin(2*math.Pi*float64(x)/periodX+phaseY)))
+			if image.Pt(sx, sy).In(bounds) {
+				dst.SetGray(x, y, src.GrayAt(sx, sy))
+			}
+		}
+	}
+
+	return dst
+}
+
+func drawThickLine(img *image.Gray, x1, y1, x2, y2 float64, radius int, c color.Gray) {
+	steps := int(math.Ceil(2*math.Hypot(x2-x1, y2-y1))) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Round(x1 + t*(x2-x1)))
+		y := int(math.Round(y1 + t*(y2-y1)))
+
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if dx*dx+dy*dy <= radius*radius {
+					img.SetGray(x+dx, y+dy, c)
+				}
+			}
+		}
+	}
+}
+
+func drawLine(img *image.Gray, x1, y1, x2, y2 int, c color.Gray) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := sign(x2-x1), sign(y2-y1)
+	e := dx + dy
+
+	for {
+		img.SetGray(x1, y1, c)
+		if (x1 == x2) && (y1 == y2) {
+			return
+		}
+
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x1 += sx
+		} else {
+			e += dx
+			y1 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// ImageVerifyPayload is a puzzle that was "solved" by answering image challenge instead of computing solutions
+type ImageVerifyPayload struct {
+	*VerifyPayload
+	answer []byte
+	key    []byte
+}
+
+var _ SolutionPayload = (*ImageVerifyPayload)(nil)
+
+func ParseImageVerifyPayload[T any, TPuzzle PuzzleConstraint[T]](ctx context.Context, payload []byte, key []byte) (*ImageVerifyPayload, error) {
+	answer, puzzlePayload, ok := bytes.Cut(bytes.TrimPrefix(payload, []byte(ImageSolutionPrefix)), dotBytes)
+	if !ok {
+		return nil, errWrongPartsNumber
+	}
+
+	if len(answer) != ImageCodeLength {
+		slog.WarnContext(ctx, "Unexpected image challenge answer length", "length", len(answer))
+		return nil, errImageAnswerLength
+	}
+
+	vp, err := ParsePuzzlePayload[T, TPuzzle](ctx, puzzlePayload)
+	if err != nil {
+		return nil, err
+	}
+
+	// code is shown in upper case, but end users should not have to care
+	return &ImageVerifyPayload{VerifyPayload: vp, answer: bytes.ToUpper(answer), key: key}, nil
+}
+
+// ImageChallengeCode returns the code that is expected as an answer for this puzzle
+func (vp *VerifyPayload) ImageChallengeCode(key []byte) string {
+	return ImageChallengeCode(key, vp.puzzleData)
+}
+
+func (ip *ImageVerifyPayload) VerifySolutions(ctx context.Context) (*Metadata, VerifyError) {
+	// otherwise image challenge could be used to bypass compute puzzles of any property
+	if (ip.puzzle.WidgetFlags() & WidgetFlagImageChallenge) == 0 {
+		slog.WarnContext(ctx, "Image challenge is not enabled for the puzzle")
+		return &Metadata{}, InvalidSolutionError
+	}
+
+	expected := ip.ImageChallengeCode(ip.key)
+	if !hmac.Equal([]byte(expected), ip.answer) {
+		slog.WarnContext(ctx, "Image challenge answer is not valid")
+		return &Metadata{}, InvalidSolutionError
+	}
+
+	return &Metadata{}, VerifyNoError
+}
@@ -0,0 +1,139 @@
+package puzzle
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func imageTestPayload(t *testing.T, flags WidgetFlags) []byte {
+	propertyID := [16]byte{}
+	randInit(propertyID[:])
+	p := NewComputePuzzle(NextPuzzleID(), propertyID, 123)
+	_ = p.Init(DefaultValidityPeriod)
+	p.SetWidgetFlags(flags)
+
+	puzzleData, err := p.Serialize(t.Context(), NewSalt([]byte("salt")), nil /*extra salt*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := puzzleData.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestImageChallengeCode(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("key")
+	code := ImageChallengeCode(key, []byte("puzzle"))
+
+	if len(code) != ImageCodeLength {
+		t.Fatalf("Unexpected code length: %v", len(code))
+	}
+
+	if ImageChallengeCode(key, []byte("puzzle")) != code {
+		t.Error("Code is not deterministic")
+	}
+
+	if ImageChallengeCode([]byte("other"), []byte("puzzle")) == code {
+		t.Error("Code does not depend on the key")
+	}
+
+	for i := 0; i < len(code); i++ {
+		if !strings.ContainsRune(imageCodeAlphabet, rune(code[i])) {
+			t.Errorf("Unexpected code character: %c", code[i])
+		}
+	}
+}
+
+func TestImageGlyphs(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < len(imageCodeAlphabet); i++ {
+		if _, ok := imageGlyphStrokes[imageCodeAlphabet[i]]; !ok {
+			t.Errorf("Missing glyph for %c", imageCodeAlphabet[i])
+		}
+	}
+}
+
+func TestImageVerifySolutions(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	key := []byte("key")
+
+	testCases := []struct {
+		name   string
+		flags  WidgetFlags
+		answer func(code string) string
+		result VerifyError
+	}{
+		{"valid", WidgetFlagImageChallenge, func(code string) string { return code }, VerifyNoError},
+		{"lower case", WidgetFlagImageChallenge, func(code string) string { return strings.ToLower(code) }, VerifyNoError},
+		{"wrong answer", WidgetFlagImageChallenge, func(code string) string { return string(code[1:]) + string(code[0]+1) }, InvalidSolutionError},
+		{"not enabled", WidgetFlagNoAutoRefresh, func(code string) string { return code }, InvalidSolutionError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			puzzlePayload := imageTestPayload(t, tc.flags)
+			vp, err := ParsePuzzlePayload[ComputePuzzle](ctx, puzzlePayload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			answer := tc.answer(vp.ImageChallengeCode(key))
+			payload := append([]byte(ImageSolutionPrefix+answer+"."), puzzlePayload...)
+
+			ip, err := ParseImageVerifyPayload[ComputePuzzle](ctx, payload, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, verr := ip.VerifySolutions(ctx); verr != tc.result {
+				t.Errorf("Unexpected verify result: %v", verr)
+			}
+		})
+	}
+}
+
+func TestParseImageVerifyPayloadFail(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	puzzlePayload := imageTestPayload(t, WidgetFlagImageChallenge)
+
+	if _, err := ParseImageVerifyPayload[ComputePuzzle](ctx, append([]byte(ImageSolutionPrefix+"123."), puzzlePayload...), nil); err != errImageAnswerLength {
+		t.Errorf("Unexpected error for short answer: %v", err)
+	}
+
+	if _, err := ParseImageVerifyPayload[ComputePuzzle](ctx, []byte(ImageSolutionPrefix+"ACEF3467"), nil); err != errWrongPartsNumber {
+		t.Errorf("Unexpected error without puzzle: %v", err)
+	}
+}
+
+func TestRenderImageChallenge(t *testing.T) {
+	t.Parallel()
+
+	data, err := RenderImageChallenge(imageCodeAlphabet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bounds := img.Bounds(); (bounds.Dx() == 0) || (bounds.Dy() == 0) {
+		t.Errorf("Unexpected image size: %v", bounds)
+	}
+}
@@ -8,9 +8,12 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"encoding/binary"
+	"errors"
 	"hash/fnv"
 	"io"
 	"log/slog"
+	"math"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -22,22 +25,65 @@ const (
 	PropertyIDSize        = 16
 	UserDataSize          = 16
 	DefaultValidityPeriod = 30 * time.Minute
+	MaxClockSkew          = 10 * time.Minute
+	MaxRememberWindow     = 24 * time.Hour
 	puzzleVersion         = 1
 	solutionsCount        = 16
 )
 
+// options are TLV-encoded (type, length, value) entries optionally appended after user data.
+// Unknown types are skipped so that both server and widget can be extended independently.
+const (
+	optionWidgetFlags uint8 = 1
+	// url-encoded claims of the property owner, returned on successful verification (covered by signature)
+	optionClaims uint8 = 2
+	// classification of the end user by differential difficulty (covered by signature)
+	optionVisitorClass uint8 = 3
+	// start of the window during which difficulty is pinned for the end user, as seconds before expiration, followed
+	// by the (truncated) fingerprint of the end user, so that the puzzle cannot be replayed by others (covered by signature)
+	optionStickySince     uint8 = 4
+	stickySinceOptionSize       = 8
+	// end of the window during which difficulty is known to stay the same, as seconds before expiration (covered by signature)
+	optionDifficultyLease     uint8 = 5
+	difficultyLeaseOptionSize       = 4
+	// version, property ID, puzzle ID, difficulty, solutions count, expiration, user data
+	puzzleFixedSize = 1 + PropertyIDSize + 8 + 1 + 1 + 4 + UserDataSize
+	// puzzle with all options has to fit into solver's buffer together with the solution
+	MaxClaimsSize = PuzzleBytesLength - SolutionLength - puzzleFixedSize - 3 /*widget flags*/ - 3 /*visitor class*/ - 2 /*claims header*/
+)
+
+// WidgetFlags change widget behavior per property without shipping new widget bundles
+type WidgetFlags uint8
+
+const (
+	// widget will not start solving until the end user interacts with it (even in "auto" start mode)
+	WidgetFlagRequireInteraction WidgetFlags = 1 << iota
+	// widget will not fetch a new puzzle automatically when the current one expires
+	WidgetFlagNoAutoRefresh
+	// widget offers image challenge as a fallback for end users that cannot solve the compute puzzle
+	WidgetFlagImageChallenge
+)
+
 var (
-	dotBytes = []byte(".")
+	dotBytes            = []byte(".")
+	errPuzzleOptionSize = errors.New("puzzle option is truncated")
+	ErrClaimsTooLarge   = errors.New("puzzle claims are too large")
 )
 
 type ComputePuzzle struct {
-	version        uint8
-	difficulty     uint8
-	solutionsCount uint8
-	propertyID     [PropertyIDSize]byte
-	puzzleID       uint64
-	expiration     time.Time
-	userData       []byte
+	version           uint8
+	difficulty        uint8
+	solutionsCount    uint8
+	propertyID        [PropertyIDSize]byte
+	puzzleID          uint64
+	expiration        time.Time
+	userData          []byte
+	widgetFlags       WidgetFlags
+	claims            []byte
+	visitorClass      common.VisitorClass
+	stickySince       time.Time
+	stickyFingerprint uint32
+	leaseUntil        time.Time
 }
 
 var _ Puzzle = (*ComputePuzzle)(nil)
@@ -54,6 +100,13 @@ func NewComputePuzzle(puzzleID uint64, propertyID [PropertyIDSize]byte, difficul
 	}
 }
 
+// NewRememberedPuzzle creates a puzzle without solutions, issued to end users that recently solved a regular puzzle
+func NewRememberedPuzzle(puzzleID uint64, propertyID [PropertyIDSize]byte) *ComputePuzzle {
+	p := NewComputePuzzle(puzzleID, propertyID, 0 /*difficulty*/)
+	p.solutionsCount = 0
+	return p
+}
+
 func (p *ComputePuzzle) Init(validityPeriod time.Duration) error {
 	if _, err := io.ReadFull(rand.Reader, p.userData); err != nil {
 		return err
@@ -69,6 +122,59 @@ func (p *ComputePuzzle) Difficulty() uint8                { return p.difficulty
 func (p *ComputePuzzle) SolutionsCount() int              { return int(p.solutionsCount) }
 func (p *ComputePuzzle) Expiration() time.Time            { return p.expiration }
 func (p *ComputePuzzle) PropertyID() [PropertyIDSize]byte { return p.propertyID }
+func (p *ComputePuzzle) WidgetFlags() WidgetFlags         { return p.widgetFlags }
+func (p *ComputePuzzle) SetWidgetFlags(flags WidgetFlags) { p.widgetFlags = flags }
+
+func (p *ComputePuzzle) VisitorClass() common.VisitorClass      { return p.visitorClass }
+func (p *ComputePuzzle) SetVisitorClass(vc common.VisitorClass) { p.visitorClass = vc }
+
+func (p *ComputePuzzle) StickySince() time.Time     { return p.stickySince }
+func (p *ComputePuzzle) SetStickySince(t time.Time) { p.stickySince = t }
+
+func (p *ComputePuzzle) StickyFingerprint() uint32      { return p.stickyFingerprint }
+func (p *ComputePuzzle) SetStickyFingerprint(fp uint32) { p.stickyFingerprint = fp }
+
+func (p *ComputePuzzle) DifficultyLease() time.Time     { return p.leaseUntil }
+func (p *ComputePuzzle) SetDifficultyLease(t time.Time) { p.leaseUntil = t }
+
+func (p *ComputePuzzle) Claims() map[string]string {
+	if len(p.claims) == 0 {
+		return nil
+	}
+
+	values, err := url.ParseQuery(string(p.claims))
+	if err != nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(values))
+	for key := range values {
+		result[key] = values.Get(key)
+	}
+
+	return result
+}
+
+func (p *ComputePuzzle) SetClaims(claims map[string]string) error {
+	if len(claims) == 0 {
+		p.claims = nil
+		return nil
+	}
+
+	values := make(url.Values, len(claims))
+	for key, value := range claims {
+		values.Set(key, value)
+	}
+
+	// Encode() sorts by key so the same claims always produce the same puzzle bytes
+	encoded := values.Encode()
+	if len(encoded) > MaxClaimsSize {
+		return ErrClaimsTooLarge
+	}
+
+	p.claims = []byte(encoded)
+	return nil
+}
 
 func (p *ComputePuzzle) HashKey() uint64 {
 	hasher := fnv.New64a()
@@ -96,6 +202,10 @@ func (p *ComputePuzzle) IsStub() bool {
 	return p.puzzleID == 0
 }
 
+func (p *ComputePuzzle) IsRemembered() bool {
+	return (p.solutionsCount == 0) && !p.IsZero()
+}
+
 func (p *ComputePuzzle) IsZero() bool {
 	return (p.difficulty == 0) && (p.puzzleID == 0) && p.expiration.IsZero()
 }
@@ -141,6 +251,57 @@ func (p *ComputePuzzle) WriteTo(w io.Writer) (int64, error) {
 	}
 	n += int64(len(p.userData))
 
+	// options are only written when set so that puzzles without them stay the same for older widgets
+	if p.widgetFlags != 0 {
+		if nn, err := w.Write([]byte{optionWidgetFlags, 1, byte(p.widgetFlags)}); err != nil {
+			return n + int64(nn), err
+		}
+		n += 3
+	}
+
+	if p.visitorClass != common.VisitorClassNone {
+		if nn, err := w.Write([]byte{optionVisitorClass, 1, byte(p.visitorClass)}); err != nil {
+			return n + int64(nn), err
+		}
+		n += 3
+	}
+
+	// sticky window and difficulty lease are optional so they do not take space from claims (only written if there's room left)
+	optionalSize := len(p.claims)
+	if !p.stickySince.IsZero() && !p.expiration.IsZero() && (optionalSize+stickySinceOptionSize <= MaxClaimsSize) {
+		age := min(max(p.expiration.Unix()-p.stickySince.Unix(), 0), math.MaxUint16)
+		option := []byte{optionStickySince, 6, 0, 0, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint16(option[2:], uint16(age))
+		binary.LittleEndian.PutUint32(option[4:], p.stickyFingerprint)
+		if nn, err := w.Write(option); err != nil {
+			return n + int64(nn), err
+		}
+		n += int64(len(option))
+		optionalSize += len(option)
+	}
+
+	if !p.leaseUntil.IsZero() && !p.expiration.IsZero() && (optionalSize+difficultyLeaseOptionSize <= MaxClaimsSize) {
+		remaining := min(max(p.expiration.Unix()-p.leaseUntil.Unix(), 0), math.MaxUint16)
+		option := []byte{optionDifficultyLease, 2, 0, 0}
+		binary.LittleEndian.PutUint16(option[2:], uint16(remaining))
+		if nn, err := w.Write(option); err != nil {
+			return n + int64(nn), err
+		}
+		n += int64(len(option))
+	}
+
+	if len(p.claims) > 0 {
+		if nn, err := w.Write([]byte{optionClaims, byte(len(p.claims))}); err != nil {
+			return n + int64(nn), err
+		}
+		n += 2
+
+		if nn, err := w.Write(p.claims); err != nil {
+			return n + int64(nn), err
+		}
+		n += int64(len(p.claims))
+	}
+
 	return n, nil
 }
 
@@ -153,7 +314,7 @@ func (p *ComputePuzzle) MarshalBinary() ([]byte, error) {
 }
 
 func (p *ComputePuzzle) UnmarshalBinary(data []byte) error {
-	if len(data) < (PropertyIDSize + 8 + UserDataSize + 7) {
+	if len(data) < puzzleFixedSize {
 		return io.ErrShortBuffer
 	}
 
@@ -182,7 +343,60 @@ func (p *ComputePuzzle) UnmarshalBinary(data []byte) error {
 
 	p.userData = make([]byte, UserDataSize)
 	copy(p.userData, data[offset:offset+UserDataSize])
-	//offset += UserDataSize
+	offset += UserDataSize
+
+	return p.unmarshalOptions(data[offset:])
+}
+
+func (p *ComputePuzzle) unmarshalOptions(data []byte) error {
+	p.widgetFlags = 0
+	p.claims = nil
+	p.visitorClass = common.VisitorClassNone
+	p.stickySince = time.Time{}
+	p.stickyFingerprint = 0
+	p.leaseUntil = time.Time{}
+
+	for offset := 0; offset < len(data); {
+		if offset+2 > len(data) {
+			return errPuzzleOptionSize
+		}
+
+		optionType, optionLen := data[offset], int(data[offset+1])
+		offset += 2
+
+		if offset+optionLen > len(data) {
+			return errPuzzleOptionSize
+		}
+
+		value := data[offset : offset+optionLen]
+		offset += optionLen
+
+		switch optionType {
+		case optionWidgetFlags:
+			if len(value) > 0 {
+				p.widgetFlags = WidgetFlags(value[0])
+			}
+		case optionClaims:
+			p.claims = bytes.Clone(value)
+		case optionVisitorClass:
+			if len(value) > 0 {
+				p.visitorClass = common.VisitorClass(value[0])
+			}
+		case optionStickySince:
+			if (len(value) >= 2) && !p.expiration.IsZero() {
+				p.stickySince = p.expiration.Add(-time.Duration(binary.LittleEndian.Uint16(value)) * time.Second)
+			}
+			if len(value) >= 6 {
+				p.stickyFingerprint = binary.LittleEndian.Uint32(value[2:])
+			}
+		case optionDifficultyLease:
+			if (len(value) >= 2) && !p.expiration.IsZero() {
+				p.leaseUntil = p.expiration.Add(-time.Duration(binary.LittleEndian.Uint16(value)) * time.Second)
+			}
+		default:
+			// skip unknown options for forward compatibility
+		}
+	}
 
 	return nil
 }
@@ -193,6 +407,13 @@ type PuzzlePayload struct {
 }
 
 func (p *ComputePuzzle) Serialize(ctx context.Context, salt *Salt, extraSalt []byte) (*PuzzlePayload, error) {
+	// signing with the property salt allows to verify puzzles of the property without the (global) salt
+	salt = salt.PropertySalt(p.propertyID)
+	if salt == nil {
+		slog.ErrorContext(ctx, "Salt was derived for another property")
+		return nil, ErrSignKeyMismatch
+	}
+
 	// First write to hasher
 	hasher := hmac.New(sha1.New, salt.Data())
 	puzzleSize, err := p.WriteTo(hasher)
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"io"
 	"math/rand"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
 
 func randInit(data []byte) {
@@ -71,6 +75,30 @@ func checkPuzzles(oldPuzzle, newPuzzle *ComputePuzzle, t *testing.T) {
 	if !bytes.Equal(oldPuzzle.userData, newPuzzle.userData) {
 		t.Errorf("UserData does not match")
 	}
+
+	if oldPuzzle.WidgetFlags() != newPuzzle.WidgetFlags() {
+		t.Errorf("WidgetFlags do not match: old (%v), new (%v)", oldPuzzle.WidgetFlags(), newPuzzle.WidgetFlags())
+	}
+
+	if oldPuzzle.VisitorClass() != newPuzzle.VisitorClass() {
+		t.Errorf("VisitorClass does not match: old (%v), new (%v)", oldPuzzle.VisitorClass(), newPuzzle.VisitorClass())
+	}
+
+	if !oldPuzzle.StickySince().Equal(newPuzzle.StickySince()) {
+		t.Errorf("StickySince does not match: old (%v), new (%v)", oldPuzzle.StickySince(), newPuzzle.StickySince())
+	}
+
+	if oldPuzzle.StickyFingerprint() != newPuzzle.StickyFingerprint() {
+		t.Errorf("StickyFingerprint does not match: old (%v), new (%v)", oldPuzzle.StickyFingerprint(), newPuzzle.StickyFingerprint())
+	}
+
+	if !oldPuzzle.DifficultyLease().Equal(newPuzzle.DifficultyLease()) {
+		t.Errorf("DifficultyLease does not match: old (%v), new (%v)", oldPuzzle.DifficultyLease(), newPuzzle.DifficultyLease())
+	}
+
+	if !bytes.Equal(oldPuzzle.claims, newPuzzle.claims) {
+		t.Errorf("Claims do not match: old (%s), new (%s)", oldPuzzle.claims, newPuzzle.claims)
+	}
 }
 
 func TestPuzzleMarshalling(t *testing.T) {
@@ -97,6 +125,118 @@ func TestPuzzleMarshalling(t *testing.T) {
 	checkPuzzles(puzzle, &newPuzzle, t)
 }
 
+func TestPuzzleWidgetFlagsMarshalling(t *testing.T) {
+	t.Parallel()
+	propertyID := [16]byte{}
+	randInit(propertyID[:])
+
+	puzzle := NewComputePuzzle(NextPuzzleID(), propertyID, 123)
+	_ = puzzle.Init(DefaultValidityPeriod)
+
+	plainData, err := puzzle.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error marshalling: %v", err)
+	}
+
+	puzzle.SetWidgetFlags(WidgetFlagRequireInteraction | WidgetFlagNoAutoRefresh)
+
+	data, err := puzzle.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error marshalling: %v", err)
+	}
+
+	// older clients only read the fixed-size prefix
+	if !bytes.HasPrefix(data, plainData) {
+		t.Errorf("Puzzle with options does not start with plain puzzle bytes")
+	}
+
+	if len(data)+SolutionLength > PuzzleBytesLength {
+		t.Errorf("Puzzle with options is too large: %v", len(data))
+	}
+
+	var newPuzzle ComputePuzzle
+	if err := newPuzzle.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Error unmarshalling: %v", err)
+	}
+
+	checkPuzzles(puzzle, &newPuzzle, t)
+
+	// unknown options are skipped
+	extended := append(append([]byte{}, data...), 0xFF, 2, 1, 2)
+	if err := newPuzzle.UnmarshalBinary(extended); err != nil {
+		t.Fatalf("Error unmarshalling puzzle with unknown option: %v", err)
+	}
+
+	checkPuzzles(puzzle, &newPuzzle, t)
+
+	if err := newPuzzle.UnmarshalBinary(data[:len(data)-1]); err != errPuzzleOptionSize {
+		t.Errorf("Unexpected error for truncated option: %v", err)
+	}
+}
+
+func TestPuzzleClaimsMarshalling(t *testing.T) {
+	t.Parallel()
+	propertyID := [16]byte{}
+	randInit(propertyID[:])
+
+	puzzle := NewComputePuzzle(NextPuzzleID(), propertyID, 123)
+	_ = puzzle.Init(DefaultValidityPeriod)
+	puzzle.SetWidgetFlags(WidgetFlagRequireInteraction)
+	puzzle.SetVisitorClass(common.VisitorClassFirstSeen)
+	puzzle.SetStickySince(time.Unix(time.Now().Unix(), 0))
+	puzzle.SetStickyFingerprint(0xdeadbeef)
+	puzzle.SetDifficultyLease(time.Unix(time.Now().Add(time.Minute).Unix(), 0))
+
+	if err := puzzle.SetClaims(map[string]string{"form": "signup", "environment": "prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := puzzle.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error marshalling: %v", err)
+	}
+
+	var newPuzzle ComputePuzzle
+	if err := newPuzzle.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Error unmarshalling: %v", err)
+	}
+
+	checkPuzzles(puzzle, &newPuzzle, t)
+
+	if claims := newPuzzle.Claims(); (len(claims) != 2) || (claims["form"] != "signup") || (claims["environment"] != "prod") {
+		t.Errorf("Unexpected claims: %v", claims)
+	}
+
+	if err := puzzle.SetClaims(map[string]string{"key": strings.Repeat("a", MaxClaimsSize)}); err != ErrClaimsTooLarge {
+		t.Errorf("Unexpected error for large claims: %v", err)
+	}
+
+	if err := puzzle.SetClaims(map[string]string{"key": strings.Repeat("a", MaxClaimsSize-len("key="))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err = puzzle.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data)+SolutionLength > PuzzleBytesLength {
+		t.Errorf("Puzzle with max claims is too large: %v", len(data))
+	}
+
+	// neither sticky window nor difficulty lease fit with max claims so they are omitted
+	if err := newPuzzle.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !newPuzzle.StickySince().IsZero() {
+		t.Errorf("Unexpected sticky window with max claims: %v", newPuzzle.StickySince())
+	}
+
+	if !newPuzzle.DifficultyLease().IsZero() {
+		t.Errorf("Unexpected difficulty lease with max claims: %v", newPuzzle.DifficultyLease())
+	}
+}
+
 func TestZeroPuzzleMarshalling(t *testing.T) {
 	t.Parallel()
 	// Create a sample Puzzle
@@ -186,3 +326,97 @@ func TestPuzzlePayloadSuffix(t *testing.T) {
 	}
 	puzzleRef[len(puzzleData.puzzleBase64)+1]--
 }
+
+func TestParsePuzzlePayload(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	propertyID := [16]byte{}
+	randInit(propertyID[:])
+	p := NewComputePuzzle(NextPuzzleID(), propertyID, 123)
+	_ = p.Init(DefaultValidityPeriod)
+	p.SetStickySince(time.Unix(time.Now().Unix(), 0))
+
+	salt := NewSalt([]byte("salt"))
+	extraSalt := []byte("property")
+	puzzleData, err := p.Serialize(ctx, salt, extraSalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	puzzleData.Write(&buf)
+
+	payload, err := ParsePuzzlePayload[ComputePuzzle](ctx, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkPuzzles(p, payload.Puzzle().(*ComputePuzzle), t)
+
+	if err := payload.VerifySignature(ctx, salt, extraSalt); err != nil {
+		t.Errorf("Failed to verify signature: %v", err)
+	}
+
+	if err := payload.VerifySignature(ctx, salt, []byte("other")); err == nil {
+		t.Error("Signature is valid with different property salt")
+	}
+
+	if _, err := ParsePuzzlePayload[ComputePuzzle](ctx, append([]byte("solutions."), buf.Bytes()...)); err != errWrongPartsNumber {
+		t.Errorf("Unexpected error for verify payload: %v", err)
+	}
+}
+
+func TestPropertySaltSignature(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	propertyID := [16]byte{}
+	randInit(propertyID[:])
+	p := NewComputePuzzle(NextPuzzleID(), propertyID, 123)
+	_ = p.Init(DefaultValidityPeriod)
+
+	salt := NewSalt([]byte("salt"))
+	puzzleData, err := p.Serialize(ctx, salt, nil /*property salt*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	puzzleData.Write(&buf)
+
+	payload, err := ParsePuzzlePayload[ComputePuzzle](ctx, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := payload.VerifySignature(ctx, salt, nil /*extra salt*/); err != nil {
+		t.Errorf("Failed to verify signature with salt: %v", err)
+	}
+
+	data, err := salt.PropertySalt(propertyID).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	derived, err := UnmarshalPropertySalt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := payload.VerifySignature(ctx, derived, nil /*extra salt*/); err != nil {
+		t.Errorf("Failed to verify signature with property salt: %v", err)
+	}
+
+	otherID := propertyID
+	otherID[0]++
+	if err := payload.VerifySignature(ctx, salt.PropertySalt(otherID), nil /*extra salt*/); err != ErrSignKeyMismatch {
+		t.Errorf("Unexpected error for other property salt: %v", err)
+	}
+
+	if _, err := salt.MarshalBinary(); err == nil {
+		t.Error("Salt itself can be marshalled")
+	}
+}
@@ -1,10 +1,22 @@
 package puzzle
 
-import "hash/fnv"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"hash/fnv"
+)
+
+var (
+	propertySaltLabel = []byte("property-salt:")
+	errSaltNotDerived = errors.New("salt is not derived for a property")
+)
 
 type Salt struct {
 	data        []byte
 	fingerprint byte
+	// set only for salts that were derived for a single property
+	propertyID *[PropertyIDSize]byte
 }
 
 func NewSalt(data []byte) *Salt {
@@ -18,6 +30,15 @@ func NewSalt(data []byte) *Salt {
 	}
 }
 
+// NewPropertySalt restores salt that was derived with PropertySalt() (fingerprint is the one of the original salt)
+func NewPropertySalt(propertyID [PropertyIDSize]byte, fingerprint byte, data []byte) *Salt {
+	return &Salt{
+		data:        data,
+		fingerprint: fingerprint,
+		propertyID:  &propertyID,
+	}
+}
+
 func (s *Salt) Data() []byte {
 	return s.data
 }
@@ -25,3 +46,55 @@ func (s *Salt) Data() []byte {
 func (s *Salt) Fingerprint() byte {
 	return s.fingerprint
 }
+
+// PropertyID returns false for salts that were not derived for a property
+func (s *Salt) PropertyID() ([PropertyIDSize]byte, bool) {
+	if s.propertyID == nil {
+		return [PropertyIDSize]byte{}, false
+	}
+
+	return *s.propertyID, true
+}
+
+// PropertySalt derives the salt that signs puzzles of a single property, so that it can be given out (e.g. to the
+// verification proxy) without exposing the salt itself. Returns nil if salt was already derived for another property.
+func (s *Salt) PropertySalt(propertyID [PropertyIDSize]byte) *Salt {
+	if s.propertyID != nil {
+		if *s.propertyID == propertyID {
+			return s
+		}
+
+		return nil
+	}
+
+	mac := hmac.New(sha256.New, s.data)
+	_, _ = mac.Write(propertySaltLabel)
+	_, _ = mac.Write(propertyID[:])
+
+	return NewPropertySalt(propertyID, s.fingerprint, mac.Sum(nil))
+}
+
+// MarshalBinary is only supported for property salts, that can be given out
+func (s *Salt) MarshalBinary() ([]byte, error) {
+	if s.propertyID == nil {
+		return nil, errSaltNotDerived
+	}
+
+	data := make([]byte, 0, PropertyIDSize+1+len(s.data))
+	data = append(data, s.propertyID[:]...)
+	data = append(data, s.fingerprint)
+	data = append(data, s.data...)
+
+	return data, nil
+}
+
+// UnmarshalPropertySalt is the reverse of MarshalBinary()
+func UnmarshalPropertySalt(data []byte) (*Salt, error) {
+	if len(data) <= PropertyIDSize+1 {
+		return nil, errSaltNotDerived
+	}
+
+	propertyID := [PropertyIDSize]byte(data[:PropertyIDSize])
+
+	return NewPropertySalt(propertyID, data[PropertyIDSize], data[PropertyIDSize+1:]), nil
+}
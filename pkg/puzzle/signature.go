@@ -9,6 +9,8 @@ import (
 const (
 	signatureVersion       = 1
 	flagWithExtra    uint8 = 1 << iota
+	// puzzle is signed with the salt derived for its property (see Salt.PropertySalt())
+	flagPropertySalt
 )
 
 type signature struct {
@@ -21,6 +23,10 @@ type signature struct {
 func newSignature(hash []byte, salt *Salt, extraSalt []byte) *signature {
 	var flags uint8 = 0
 
+	if _, ok := salt.PropertyID(); ok {
+		flags |= flagPropertySalt
+	}
+
 	if len(extraSalt) > 0 {
 		flags |= flagWithExtra
 	}
@@ -37,6 +43,10 @@ func (s *signature) HasExtra() bool {
 	return s.Flags&flagWithExtra != 0
 }
 
+func (s *signature) HasPropertySalt() bool {
+	return s.Flags&flagPropertySalt != 0
+}
+
 func (s *signature) BinarySize() int {
 	return 3 + len(s.Hash)
 }
@@ -18,8 +18,21 @@ import (
 const (
 	PuzzleBytesLength = 128
 	SolutionLength    = 8
-	metadataVersion   = 1
-	metadataLength    = 1 + 1 + 1 + 4
+	metadataVersion   = 2
+	metadataLengthV1  = 1 + 1 + 1 + 4
+	// v2 adds integrity beacon: environment flags and truncated hash of the widget script
+	metadataLengthV2 = metadataLengthV1 + 1 + ScriptHashLength
+	ScriptHashLength = 8
+)
+
+// IntegrityFlags are results of execution environment checks, performed by the widget
+type IntegrityFlags uint8
+
+const (
+	// some of the native browser APIs, used by the widget, were replaced
+	IntegrityFlagNativePatched IntegrityFlags = 1 << 0
+	// widget could not read its own script to compute the hash
+	IntegrityFlagNoScript IntegrityFlags = 1 << 1
 )
 
 var (
@@ -31,9 +44,22 @@ var (
 )
 
 type Metadata struct {
-	errorCode     uint8
-	wasmFlag      bool
-	elapsedMillis uint32
+	errorCode      uint8
+	wasmFlag       bool
+	elapsedMillis  uint32
+	integrityFlags IntegrityFlags
+	scriptHash     [ScriptHashLength]byte
+}
+
+func metadataLength(version byte) (int, error) {
+	switch version {
+	case 1:
+		return metadataLengthV1, nil
+	case 2:
+		return metadataLengthV2, nil
+	default:
+		return 0, errInvalidVersion
+	}
 }
 
 func (m *Metadata) MarshalBinary() ([]byte, error) {
@@ -58,19 +84,31 @@ func (m *Metadata) MarshalBinary() ([]byte, error) {
 		return buf.Bytes(), err
 	}
 
+	if err := binary.Write(&buf, binary.LittleEndian, uint8(m.integrityFlags)); err != nil {
+		return buf.Bytes(), err
+	}
+
+	if _, err := buf.Write(m.scriptHash[:]); err != nil {
+		return buf.Bytes(), err
+	}
+
 	return buf.Bytes(), nil
 }
 
 func (m *Metadata) UnmarshalBinary(data []byte) error {
-	if len(data) < metadataLength {
+	if len(data) == 0 {
 		return io.ErrShortBuffer
 	}
 
 	var offset = 0
 
 	version := data[offset]
-	if version != 1 {
-		return errInvalidVersion
+	length, err := metadataLength(version)
+	if err != nil {
+		return err
+	}
+	if len(data) < length {
+		return io.ErrShortBuffer
 	}
 	offset += 1
 
@@ -81,7 +119,15 @@ func (m *Metadata) UnmarshalBinary(data []byte) error {
 	offset += 1
 
 	m.elapsedMillis = binary.LittleEndian.Uint32(data[offset : offset+4])
-	offset += 4 // nolint:ineffassign
+	offset += 4
+
+	if version >= 2 {
+		m.integrityFlags = IntegrityFlags(data[offset])
+		offset += 1
+
+		copy(m.scriptHash[:], data[offset:offset+ScriptHashLength])
+		offset += ScriptHashLength // nolint:ineffassign
+	}
 
 	return nil
 }
@@ -110,6 +156,23 @@ func (m *Metadata) ElapsedMillis() uint32 {
 	return m.elapsedMillis
 }
 
+func (m *Metadata) IntegrityFlags() IntegrityFlags {
+	if m == nil {
+		return 0
+	}
+
+	return m.integrityFlags
+}
+
+// ScriptHash returns the widget script hash reported by the client (can be tampered) or nil if it was not sent
+func (m *Metadata) ScriptHash() []byte {
+	if (m == nil) || (m.scriptHash == [ScriptHashLength]byte{}) {
+		return nil
+	}
+
+	return m.scriptHash[:]
+}
+
 type Solutions struct {
 	Buffer   []byte
 	Metadata *Metadata
@@ -141,12 +204,20 @@ func NewSolutions(data []byte) (*Solutions, error) {
 		return nil, errEmptyDecodedSolutions
 	}
 
+	length, err := metadataLength(decodedBytes[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(decodedBytes) < length {
+		return nil, io.ErrShortBuffer
+	}
+
 	metadata := &Metadata{}
-	if err := metadata.UnmarshalBinary(decodedBytes[:metadataLength]); err != nil {
+	if err := metadata.UnmarshalBinary(decodedBytes[:length]); err != nil {
 		return nil, err
 	}
 
-	solutionsBytes := decodedBytes[metadataLength:]
+	solutionsBytes := decodedBytes[length:]
 
 	if len(solutionsBytes)%SolutionLength != 0 {
 		return nil, errInvalidSolutionLength
@@ -1,6 +1,7 @@
 package puzzle
 
 import (
+	"encoding/base64"
 	"testing"
 	"time"
 )
@@ -61,3 +62,56 @@ func TestZeroDifficulty(t *testing.T) {
 		t.Errorf("Zero difficulty should suffice. Solutions count %v, expected %v", count, puzzle.SolutionsCount())
 	}
 }
+
+func TestMetadataIntegrityRoundtrip(t *testing.T) {
+	t.Parallel()
+
+	metadata := &Metadata{
+		errorCode:      3,
+		wasmFlag:       true,
+		elapsedMillis:  1234,
+		integrityFlags: IntegrityFlagNativePatched,
+		scriptHash:     [ScriptHashLength]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+
+	solutions := &Solutions{Buffer: make([]byte, 2*SolutionLength), Metadata: metadata}
+
+	parsed, err := NewSolutions([]byte(solutions.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *parsed.Metadata != *metadata {
+		t.Errorf("Metadata mismatch: %+v, expected %+v", parsed.Metadata, metadata)
+	}
+
+	if len(parsed.Buffer) != 2*SolutionLength {
+		t.Errorf("Unexpected solutions length %v", len(parsed.Buffer))
+	}
+}
+
+func TestMetadataLegacyVersion(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, metadataLengthV1+SolutionLength)
+	data[0] = 1
+	data[1] = 5
+	data[2] = 1
+
+	parsed, err := NewSolutions([]byte(base64.StdEncoding.EncodeToString(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (parsed.Metadata.ErrorCode() != 5) || !parsed.Metadata.WasmFlag() {
+		t.Errorf("Unexpected metadata: %+v", parsed.Metadata)
+	}
+
+	if parsed.Metadata.ScriptHash() != nil {
+		t.Error("Legacy metadata should not have script hash")
+	}
+
+	if len(parsed.Buffer) != SolutionLength {
+		t.Errorf("Unexpected solutions length %v", len(parsed.Buffer))
+	}
+}
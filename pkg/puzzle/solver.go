@@ -11,6 +11,8 @@ import (
 )
 
 type ComputeSolver struct {
+	// marks solutions as computed with WebAssembly, same as the widget does
+	Wasm bool
 }
 
 func (s *ComputeSolver) solveOne(buf []byte, threshold uint32) []byte {
@@ -110,7 +112,7 @@ func (s *ComputeSolver) Solve(p Puzzle) (*Solutions, error) {
 		Metadata: &Metadata{
 			errorCode:     0,
 			elapsedMillis: uint32(elapsed.Milliseconds()),
-			wasmFlag:      false,
+			wasmFlag:      s.Wasm,
 		},
 	}, nil
 }
@@ -71,6 +71,30 @@ func TestSolver(t *testing.T) {
 	}
 }
 
+func TestSolverWasmFlag(t *testing.T) {
+	t.Parallel()
+
+	p := NewComputePuzzle(NextPuzzleID(), [16]byte{}, 10)
+	if err := p.Init(DefaultValidityPeriod); err != nil {
+		t.Fatal(err)
+	}
+
+	solver := &ComputeSolver{Wasm: true}
+	solutions, err := solver.Solve(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := NewSolutions([]byte(solutions.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !parsed.Metadata.WasmFlag() {
+		t.Error("Wasm flag is not set in solutions metadata")
+	}
+}
+
 func benchmarkDifficulty(difficulty uint8, b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		p := NewComputePuzzle(0, [16]byte{}, difficulty)
@@ -112,6 +112,30 @@ func ParseVerifyPayload[T any, TPuzzle PuzzleConstraint[T]](ctx context.Context,
 		return nil, errEmptyPayloadPart
 	}
 
+	return parsePuzzleParts[T, TPuzzle](ctx, solutionsBytes, puzzleBytesB64, signatureBytesB64)
+}
+
+// ParsePuzzlePayload parses puzzle in the form it was issued to the widget (without solutions)
+func ParsePuzzlePayload[T any, TPuzzle PuzzleConstraint[T]](ctx context.Context, payload []byte) (*VerifyPayload, error) {
+	if len(payload) == 0 {
+		return nil, errPayloadEmpty
+	}
+
+	if dotsCount := bytes.Count(payload, dotBytes); dotsCount != 1 {
+		slog.WarnContext(ctx, "Unexpected number of dots in puzzle payload", "dots", dotsCount)
+		return nil, errWrongPartsNumber
+	}
+
+	puzzleBytesB64, signatureBytesB64, _ := bytes.Cut(payload, dotBytes)
+	if len(puzzleBytesB64) == 0 || len(signatureBytesB64) == 0 {
+		slog.WarnContext(ctx, "Parts of the puzzle payload are missing", "puzzle", len(puzzleBytesB64), "signature", len(signatureBytesB64))
+		return nil, errEmptyPayloadPart
+	}
+
+	return parsePuzzleParts[T, TPuzzle](ctx, nil /*solutions*/, puzzleBytesB64, signatureBytesB64)
+}
+
+func parsePuzzleParts[T any, TPuzzle PuzzleConstraint[T]](ctx context.Context, solutionsBytes, puzzleBytesB64, signatureBytesB64 []byte) (*VerifyPayload, error) {
 	puzzleBytesLength := base64.StdEncoding.DecodedLen(len(puzzleBytesB64))
 	puzzleBytes := make([]byte, puzzleBytesLength)
 	n, err := base64.StdEncoding.Decode(puzzleBytes, puzzleBytesB64)
@@ -169,6 +193,15 @@ func (vp *VerifyPayload) VerifySignature(ctx context.Context, salt *Salt, extraS
 		return ErrSignKeyMismatch
 	}
 
+	if vp.signature.HasPropertySalt() {
+		if salt = salt.PropertySalt(vp.puzzle.PropertyID()); salt == nil {
+			return ErrSignKeyMismatch
+		}
+	} else if _, ok := salt.PropertyID(); ok {
+		// older puzzles can only be verified with the original salt
+		return ErrSignKeyMismatch
+	}
+
 	hasher := hmac.New(sha1.New, salt.Data())
 
 	if _, werr := hasher.Write(vp.puzzleData); werr != nil {
@@ -46,7 +46,13 @@ type HTTPRateLimiter interface {
 	UpdateLimits(capacity leakybucket.TLevel, leakInterval time.Duration)
 }
 
+type Metrics interface {
+	ObserveRateLimited(limiter string)
+}
+
 type httpRateLimiter[TKey comparable] struct {
+	name            string
+	metrics         Metrics
 	rejectedHandler http.HandlerFunc
 	buckets         *leakybucket.Manager[TKey, leakybucket.ConstLeakyBucket[TKey], *leakybucket.ConstLeakyBucket[TKey]]
 	strategy        realclientip.Strategy
@@ -82,7 +88,7 @@ func (l *httpRateLimiter[TKey]) RateLimitExFunc(initCapacity leakybucket.TLevel,
 					"key", key, "host", r.Host, "path", r.URL.Path, "method", r.Method,
 					"level", addResult.CurrLevel, "capacity", addResult.Capacity, "resetAfter", addResult.ResetAfter.String(),
 					"retryAfter", addResult.RetryAfter.String(), "found", addResult.Found)
-				l.rejectedHandler.ServeHTTP(w, r)
+				l.reject(w, r)
 			}
 		})
 	}
@@ -109,11 +115,19 @@ func (l *httpRateLimiter[TKey]) RateLimit(next http.Handler) http.Handler {
 				"key", key, "host", r.Host, "path", r.URL.Path, "method", r.Method,
 				"level", addResult.CurrLevel, "capacity", addResult.Capacity, "resetAfter", addResult.ResetAfter.String(),
 				"retryAfter", addResult.RetryAfter.String(), "found", addResult.Found)
-			l.rejectedHandler.ServeHTTP(w, r)
+			l.reject(w, r)
 		}
 	})
 }
 
+func (l *httpRateLimiter[TKey]) reject(w http.ResponseWriter, r *http.Request) {
+	if l.metrics != nil {
+		l.metrics.ObserveRateLimited(l.name)
+	}
+
+	l.rejectedHandler.ServeHTTP(w, r)
+}
+
 func (l *httpRateLimiter[TKey]) UpdateRequestLimits(r *http.Request, capacity leakybucket.TLevel, leakInterval time.Duration) {
 	ctx := r.Context()
 	key, ok := ctx.Value(common.RateLimitKeyContextKey).(TKey)
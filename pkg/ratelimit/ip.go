@@ -33,7 +33,7 @@ func NewIPAddrBuckets(maxBuckets int, bucketCap uint32, leakInterval time.Durati
 	return leakybucket.NewManager[netip.Addr, leakybucket.ConstLeakyBucket[netip.Addr]](maxBuckets, bucketCap, leakInterval)
 }
 
-func NewIPAddrRateLimiter(header string, buckets *IPAddrBuckets) *httpRateLimiter[netip.Addr] {
+func NewIPAddrRateLimiter(header string, buckets *IPAddrBuckets, metrics Metrics) *httpRateLimiter[netip.Addr] {
 	var strategy realclientip.Strategy
 
 	if len(header) > 0 {
@@ -47,6 +47,8 @@ func NewIPAddrRateLimiter(header string, buckets *IPAddrBuckets) *httpRateLimite
 	}
 
 	limiter := &httpRateLimiter[netip.Addr]{
+		name:               "ip",
+		metrics:            metrics,
 		rejectedHandler:    defaultRejectedHandler,
 		strategy:           strategy,
 		buckets:            buckets,
@@ -33,6 +33,9 @@ const (
 	KeyNotificationID
 	KeyReturnURL
 	KeyTwoFactorCodeTimestamp
+	KeyUserAgent
+	KeyCountry
+	KeyLastSeen
 	// Add new fields _above_
 	SESSION_KEYS_COUNT
 )
@@ -57,6 +60,12 @@ func (key SessionKey) String() string {
 		return "NotificationID"
 	case KeyReturnURL:
 		return "ReturnURL"
+	case KeyUserAgent:
+		return "UserAgent"
+	case KeyCountry:
+		return "Country"
+	case KeyLastSeen:
+		return "LastSeen"
 	default:
 		return "SessionKey"
 	}
@@ -138,6 +147,24 @@ func (sd *SessionData) ID() string {
 	return sd.sid
 }
 
+// UserID returns ID of the signed in user (sessions are started before sign in)
+func (sd *SessionData) UserID() (int32, bool) {
+	v, ok := sd.get(KeyUserID)
+	if !ok {
+		return 0, false
+	}
+
+	userID, ok := v.(int32)
+	return userID, ok
+}
+
+// LastSeen returns time of the latest request in this session (zero if it was not recorded)
+func (sd *SessionData) LastSeen() time.Time {
+	v, _ := sd.get(KeyLastSeen)
+	t, _ := v.(time.Time)
+	return t
+}
+
 func (sd *SessionData) Has(key SessionKey) bool {
 	sd.lock.Lock()
 	defer sd.lock.Unlock()
@@ -213,8 +240,12 @@ func (s *Session) Delete(key SessionKey) error {
 
 type Store interface {
 	Start(ctx context.Context, interval time.Duration)
+	Shutdown()
+	TTL() time.Duration
 	Init(ctx context.Context, session *Session) error
 	Read(ctx context.Context, sid string, skipCache bool) (*Session, error)
 	Update(session *Session) error
 	Destroy(ctx context.Context, sid string) error
+	// ListUser returns all known sessions of the user, including the ones that were started on other nodes
+	ListUser(ctx context.Context, userID int32) ([]*Session, error)
 }
@@ -5,12 +5,18 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"time"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 	"github.com/rs/xid"
 )
 
+const (
+	// last seen time is updated with this precision to not persist session on every request
+	lastSeenInterval = 5 * time.Minute
+)
+
 type Manager struct {
 	CookieName   string
 	Store        Store
@@ -129,3 +135,79 @@ func (m *Manager) SessionDestroy(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Cache-Control", `no-cache="Set-Cookie"`)
 	}
 }
+
+// Touch records the time of the latest request in the session
+func (m *Manager) Touch(ctx context.Context, sess *Session, tnow time.Time) {
+	if tnow.Sub(sess.data.LastSeen()) < lastSeenInterval {
+		return
+	}
+
+	if err := sess.Set(KeyLastSeen, tnow); err != nil {
+		slog.ErrorContext(ctx, "Failed to update session last seen time", common.SessionIDAttr(sess.ID()), common.ErrAttr(err))
+	}
+}
+
+// UserSessions returns active sessions of the user, most recently seen first
+func (m *Manager) UserSessions(ctx context.Context, userID int32) ([]*Session, error) {
+	sessions, err := m.Store.ListUser(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list user sessions", "userID", userID, common.ErrAttr(err))
+		return nil, err
+	}
+
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		return b.data.LastSeen().Compare(a.data.LastSeen())
+	})
+
+	return sessions, nil
+}
+
+// DestroyUserSession signs the user out of the session, that could have been started on another device
+func (m *Manager) DestroyUserSession(ctx context.Context, userID int32, sid string) error {
+	sess, err := m.Store.Read(ctx, sid, false /*skip cache*/)
+	if err != nil {
+		return err
+	}
+
+	if owner, ok := sess.data.UserID(); !ok || (owner != userID) {
+		slog.WarnContext(ctx, "Session does not belong to the user", common.SessionIDAttr(sid), "userID", userID)
+		return ErrSessionMissing
+	}
+
+	if err := m.Store.Destroy(ctx, sid); err != nil {
+		slog.ErrorContext(ctx, "Failed to destroy user session", common.SessionIDAttr(sid), common.ErrAttr(err))
+		return err
+	}
+
+	slog.InfoContext(ctx, "Destroyed user session", common.SessionIDAttr(sid), "userID", userID)
+
+	return nil
+}
+
+// DestroyOtherUserSessions signs the user out of all sessions except the current one
+func (m *Manager) DestroyOtherUserSessions(ctx context.Context, userID int32, currentSID string) (int, error) {
+	sessions, err := m.Store.ListUser(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list user sessions", "userID", userID, common.ErrAttr(err))
+		return 0, err
+	}
+
+	count := 0
+
+	for _, sess := range sessions {
+		if sess.ID() == currentSID {
+			continue
+		}
+
+		if err := m.Store.Destroy(ctx, sess.ID()); err != nil {
+			slog.ErrorContext(ctx, "Failed to destroy user session", common.SessionIDAttr(sess.ID()), common.ErrAttr(err))
+			continue
+		}
+
+		count++
+	}
+
+	slog.InfoContext(ctx, "Destroyed other user sessions", "userID", userID, "count", count, "total", len(sessions))
+
+	return count, nil
+}
@@ -0,0 +1,366 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	maxIdleConns   = 16
+)
+
+var (
+	ErrNil           = errors.New("redis: nil reply")
+	errInvalidReply  = errors.New("redis: invalid reply")
+	errUnexpectedOK  = errors.New("redis: unexpected reply")
+	errClientClosed  = errors.New("redis: client is closed")
+	errEmptyCommand  = errors.New("redis: empty command")
+	errReplyTooLarge = errors.New("redis: reply is too large")
+)
+
+const (
+	maxBulkSize = 64 * 1024 * 1024
+)
+
+type replyError string
+
+func (e replyError) Error() string { return "redis: " + string(e) }
+
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+// Client is a minimal RESP2 client with a pool of idle connections, that covers the commands sessions need
+type Client struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	idle     chan *conn
+	closed   chan struct{}
+}
+
+func NewClient(addr, password string, db int) *Client {
+	return &Client{
+		addr:     addr,
+		password: password,
+		db:       db,
+		timeout:  defaultTimeout,
+		idle:     make(chan *conn, maxIdleConns),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{Conn: nc, reader: bufio.NewReader(nc), writer: bufio.NewWriter(nc)}
+
+	if len(c.password) > 0 {
+		if _, err := c.roundTrip(ctx, cn, "AUTH", c.password); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+
+	if c.db != 0 {
+		if _, err := c.roundTrip(ctx, cn, "SELECT", strconv.Itoa(c.db)); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case <-c.closed:
+		return nil, errClientClosed
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+		return c.dial(ctx)
+	}
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case <-c.closed:
+		_ = cn.Close()
+	case c.idle <- cn:
+	default:
+		_ = cn.Close()
+	}
+}
+
+func (c *Client) roundTrip(ctx context.Context, cn *conn, args ...string) (any, error) {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if err := writeCommand(cn.writer, args); err != nil {
+		return nil, err
+	}
+
+	if err := cn.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	return readReply(cn.reader)
+}
+
+// Do sends a single command and returns its reply: string, int64, []byte, []any or nil
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	if len(args) == 0 {
+		return nil, errEmptyCommand
+	}
+
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := c.roundTrip(ctx, cn, args...)
+	if err != nil {
+		var rerr replyError
+		if errors.As(err, &rerr) {
+			// connection is still in a consistent state
+			c.put(cn)
+		} else {
+			_ = cn.Close()
+		}
+
+		slog.ErrorContext(ctx, "Failed to execute Redis command", "command", args[0], common.ErrAttr(err))
+		return nil, err
+	}
+
+	c.put(cn)
+
+	return reply, nil
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	reply, err := c.Do(ctx, "PING")
+	if err != nil {
+		return err
+	}
+
+	if s, ok := reply.(string); !ok || (s != "PONG") {
+		return errUnexpectedOK
+	}
+
+	return nil
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply == nil {
+		return nil, ErrNil
+	}
+
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, errInvalidReply
+	}
+
+	return data, nil
+}
+
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	if s, ok := reply.(string); !ok || (s != "OK") {
+		return errUnexpectedOK
+	}
+
+	return nil
+}
+
+func (c *Client) Del(ctx context.Context, key string) error {
+	_, err := c.Do(ctx, "DEL", key)
+	return err
+}
+
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	reply, err := c.Do(ctx, "EXISTS", key)
+	if err != nil {
+		return false, err
+	}
+
+	n, ok := reply.(int64)
+	if !ok {
+		return false, errInvalidReply
+	}
+
+	return n > 0, nil
+}
+
+func (c *Client) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := c.Do(ctx, "PEXPIRE", key, strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (c *Client) SAdd(ctx context.Context, key string, members ...string) error {
+	_, err := c.Do(ctx, append([]string{"SADD", key}, members...)...)
+	return err
+}
+
+func (c *Client) SRem(ctx context.Context, key string, members ...string) error {
+	_, err := c.Do(ctx, append([]string{"SREM", key}, members...)...)
+	return err
+}
+
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	reply, err := c.Do(ctx, "SMEMBERS", key)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, errInvalidReply
+	}
+
+	members := make([]string, 0, len(items))
+	for _, item := range items {
+		data, ok := item.([]byte)
+		if !ok {
+			return nil, errInvalidReply
+		}
+		members = append(members, string(data))
+	}
+
+	return members, nil
+}
+
+func (c *Client) Close() {
+	select {
+	case <-c.closed:
+		return
+	default:
+		close(c.closed)
+	}
+
+	for {
+		select {
+		case cn := <-c.idle:
+			_ = cn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func writeCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if (len(line) < 3) || (line[len(line)-2] != '\r') {
+		return "", errInvalidReply
+	}
+
+	return line[:len(line)-2], nil
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := line[1:]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, replyError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, errInvalidReply
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		if size > maxBulkSize {
+			return nil, errReplyTooLarge
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, errInvalidReply
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, errInvalidReply
+	}
+}
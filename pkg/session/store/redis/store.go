@@ -0,0 +1,268 @@
+package redis
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/db"
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+	"github.com/maypok86/otter/v2"
+)
+
+const (
+	sessionKeyPrefix = "pc:session:"
+	// set of session IDs of the user
+	userSessionsKeyPrefix = "pc:user_sessions:"
+	// marks destroyed sessions so that other nodes do not keep or re-persist them
+	revokedSessionKeyPrefix = "pc:session_revoked:"
+	sessionBatchSize        = 20
+	sessionTTL              = 3 * time.Hour
+	maxCachedCount          = 100_000
+)
+
+// Store keeps sessions in memory of this node and persists the ones that have persist key to Redis, so that they
+// are shared between nodes and survive restarts. It is a drop-in replacement of db.SessionStore.
+type Store struct {
+	client        *Client
+	cache         common.Cache[string, *session.SessionData]
+	persistChan   chan string
+	batchSize     int
+	processCancel context.CancelFunc
+	persistKey    session.SessionKey
+	ttl           time.Duration
+}
+
+var _ session.Store = (*Store)(nil)
+
+func NewStore(client *Client, persistKey session.SessionKey) (*Store, error) {
+	cache, err := db.NewMemoryCacheEx[string, *session.SessionData]("sessions", maxCachedCount, session.NewSessionData("") /*missing*/, time.Minute,
+		func(o *otter.Options[string, *session.SessionData]) {
+			o.ExpiryCalculator = otter.ExpiryAccessing[string, *session.SessionData](sessionTTL)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		client:        client,
+		cache:         cache,
+		persistChan:   make(chan string, sessionBatchSize),
+		batchSize:     sessionBatchSize,
+		processCancel: func() {},
+		persistKey:    persistKey,
+		ttl:           sessionTTL,
+	}, nil
+}
+
+func sessionKey(sid string) string {
+	return sessionKeyPrefix + sid
+}
+
+func revokedSessionKey(sid string) string {
+	return revokedSessionKeyPrefix + sid
+}
+
+func userSessionsKey(userID int32) string {
+	return userSessionsKeyPrefix + strconv.Itoa(int(userID))
+}
+
+func (s *Store) Start(ctx context.Context, interval time.Duration) {
+	var cancelCtx context.Context
+	cancelCtx, s.processCancel = context.WithCancel(
+		context.WithValue(ctx, common.TraceIDContextKey, "persist_session"))
+	go common.ProcessBatchMap(cancelCtx, s.persistChan, interval, s.batchSize, s.batchSize*100, s.persistSessions)
+}
+
+func (s *Store) Shutdown() {
+	slog.Debug("Shutting down persisting sessions to Redis")
+	s.processCancel()
+	close(s.persistChan)
+	s.client.Close()
+}
+
+func (s *Store) TTL() time.Duration {
+	return s.ttl
+}
+
+func (s *Store) Init(ctx context.Context, sess *session.Session) error {
+	return s.cache.Set(ctx, sess.ID(), sess.Data())
+}
+
+func (s *Store) readPersisted(ctx context.Context, sid string) (*session.SessionData, error) {
+	data, err := s.client.Get(ctx, sessionKey(sid))
+	if err != nil {
+		if err == ErrNil {
+			slog.DebugContext(ctx, "Session data not found in Redis", common.SessionIDAttr(sid))
+			return nil, session.ErrSessionMissing
+		}
+
+		return nil, err
+	}
+
+	sd := session.NewSessionData(sid)
+	if err := sd.UnmarshalBinary(data); err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal session data from Redis", common.SessionIDAttr(sid), common.ErrAttr(err))
+		return nil, err
+	}
+
+	slog.Log(ctx, common.LevelTrace, "Unmarshaled session data from binary", common.SessionIDAttr(sid), "fields", sd.Size())
+
+	return sd, nil
+}
+
+func (s *Store) Read(ctx context.Context, sid string, skipCache bool) (*session.Session, error) {
+	if len(sid) == 0 {
+		return nil, session.ErrSessionMissing
+	}
+
+	// session could have been destroyed on another node while still being cached in memory of this one
+	if revoked, err := s.client.Exists(ctx, revokedSessionKey(sid)); err != nil {
+		slog.ErrorContext(ctx, "Failed to check if session is revoked", common.SessionIDAttr(sid), common.ErrAttr(err))
+		return nil, err
+	} else if revoked {
+		slog.DebugContext(ctx, "User session was revoked", common.SessionIDAttr(sid))
+		_ = s.cache.Delete(ctx, sid)
+		return nil, session.ErrSessionMissing
+	}
+
+	if !skipCache {
+		if sd, err := s.cache.Get(ctx, sid); err == nil {
+			return session.NewSession(sd, s), nil
+		} else if err == db.ErrNegativeCacheHit {
+			return nil, session.ErrSessionMissing
+		}
+	}
+
+	sd, err := s.readPersisted(ctx, sid)
+	if err != nil {
+		if (err == session.ErrSessionMissing) && !skipCache {
+			_ = s.cache.SetMissing(ctx, sid)
+		}
+
+		return nil, err
+	}
+
+	// same as with DB store, we do not re-cache it yet and let external changes to be merged first
+	if !skipCache {
+		_ = s.cache.Set(ctx, sid, sd)
+	}
+
+	return session.NewSession(sd, s), nil
+}
+
+func (s *Store) Update(sess *session.Session) error {
+	s.persistChan <- sess.ID()
+
+	return nil
+}
+
+func (s *Store) Destroy(ctx context.Context, sid string) error {
+	// session can still be cached in memory of other nodes, that will check the marker on read
+	if err := s.client.Set(ctx, revokedSessionKey(sid), []byte{1}, s.ttl); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark session as revoked", common.SessionIDAttr(sid), common.ErrAttr(err))
+		return err
+	}
+
+	// otherwise stale session ID will be removed when user sessions are listed
+	if sd, err := s.cache.Get(ctx, sid); err == nil {
+		if userID, ok := sd.UserID(); ok {
+			_ = s.client.SRem(ctx, userSessionsKey(userID), sid)
+		}
+	}
+
+	if found := s.cache.Delete(ctx, sid); !found {
+		slog.WarnContext(ctx, "User session was not found in memory cache to delete")
+	}
+
+	return s.client.Del(ctx, sessionKey(sid))
+}
+
+func (s *Store) persistSessions(ctx context.Context, batch map[string]uint) error {
+	count := 0
+
+	for sid := range batch {
+		sd, err := s.cache.Get(ctx, sid)
+		if err != nil {
+			continue
+		}
+
+		if !sd.Has(s.persistKey) {
+			slog.Log(ctx, common.LevelTrace, "Skipping persisting session without persist key", common.SessionIDAttr(sid))
+			continue
+		}
+
+		// sessions destroyed on other nodes should not be brought back
+		if revoked, err := s.client.Exists(ctx, revokedSessionKey(sid)); (err != nil) || revoked {
+			slog.DebugContext(ctx, "Skipping persisting revoked session", common.SessionIDAttr(sid), "revoked", revoked)
+			if revoked {
+				_ = s.cache.Delete(ctx, sid)
+			}
+			continue
+		}
+
+		data, err := sd.MarshalBinary()
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to marshal session", common.SessionIDAttr(sid), common.ErrAttr(err))
+			continue
+		}
+
+		// we actually do not care if we failed to save sessions
+		if err := s.client.Set(ctx, sessionKey(sid), data, s.ttl); err == nil {
+			count++
+		} else {
+			continue
+		}
+
+		if userID, ok := sd.UserID(); ok {
+			key := userSessionsKey(userID)
+			if err := s.client.SAdd(ctx, key, sid); err == nil {
+				_ = s.client.Expire(ctx, key, s.ttl)
+			}
+		}
+	}
+
+	slog.DebugContext(ctx, "Saved persisted sessions to Redis", "count", count)
+
+	return nil
+}
+
+func (s *Store) ListUser(ctx context.Context, userID int32) ([]*session.Session, error) {
+	key := userSessionsKey(userID)
+
+	sids, err := s.client.SMembers(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*session.Session, 0, len(sids))
+	missing := make([]string, 0)
+
+	for _, sid := range sids {
+		// sessions of other nodes are not cached here as they would get stale
+		sess, err := s.Read(ctx, sid, true /*skip cache*/)
+		if err != nil {
+			if err == session.ErrSessionMissing {
+				missing = append(missing, sid)
+			}
+			continue
+		}
+
+		if owner, ok := sess.Data().UserID(); !ok || (owner != userID) {
+			missing = append(missing, sid)
+			continue
+		}
+
+		result = append(result, sess)
+	}
+
+	if len(missing) > 0 {
+		if err := s.client.SRem(ctx, key, missing...); err == nil {
+			slog.DebugContext(ctx, "Deleted missing user sessions from Redis", "userID", userID, "count", len(missing))
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,383 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/session"
+)
+
+// fakeServer understands just enough of RESP to serve PING, GET, SET, DEL, EXISTS and a few set commands
+type fakeServer struct {
+	listener net.Listener
+	mux      sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+	sets     map[string]map[string]struct{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on loopback: %v", err)
+	}
+
+	s := &fakeServer{
+		listener: listener,
+		values:   make(map[string]string),
+		ttls:     make(map[string]string),
+		sets:     make(map[string]map[string]struct{}),
+	}
+
+	go s.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return s
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		items := reply.([]any)
+		args := make([]string, 0, len(items))
+		for _, item := range items {
+			args = append(args, string(item.([]byte)))
+		}
+
+		var response string
+
+		s.mux.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			response = "+PONG\r\n"
+		case "GET":
+			if v, ok := s.values[args[1]]; ok {
+				response = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				response = "$-1\r\n"
+			}
+		case "SET":
+			s.values[args[1]] = args[2]
+			if len(args) == 5 {
+				s.ttls[args[1]] = args[4]
+			}
+			response = "+OK\r\n"
+		case "DEL":
+			_, ok := s.values[args[1]]
+			delete(s.values, args[1])
+			if ok {
+				response = ":1\r\n"
+			} else {
+				response = ":0\r\n"
+			}
+		case "EXISTS":
+			if _, ok := s.values[args[1]]; ok {
+				response = ":1\r\n"
+			} else {
+				response = ":0\r\n"
+			}
+		case "SADD":
+			if _, ok := s.sets[args[1]]; !ok {
+				s.sets[args[1]] = make(map[string]struct{})
+			}
+			for _, member := range args[2:] {
+				s.sets[args[1]][member] = struct{}{}
+			}
+			response = fmt.Sprintf(":%d\r\n", len(args)-2)
+		case "SREM":
+			for _, member := range args[2:] {
+				delete(s.sets[args[1]], member)
+			}
+			response = fmt.Sprintf(":%d\r\n", len(args)-2)
+		case "SMEMBERS":
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "*%d\r\n", len(s.sets[args[1]]))
+			for member := range s.sets[args[1]] {
+				fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(member), member)
+			}
+			response = sb.String()
+		case "PEXPIRE":
+			s.ttls[args[1]] = args[2]
+			response = ":1\r\n"
+		default:
+			response = "-ERR unknown command\r\n"
+		}
+		s.mux.Unlock()
+
+		if _, err := conn.Write([]byte(response)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) ttl(key string) string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.ttls[key]
+}
+
+func (s *fakeServer) has(key string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	_, ok := s.values[key]
+	return ok
+}
+
+func TestClientCommands(t *testing.T) {
+	srv := newFakeServer(t)
+	client := NewClient(srv.listener.Addr().String(), "", 0)
+	defer client.Close()
+
+	ctx := t.Context()
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Get(ctx, "missing"); err != ErrNil {
+		t.Errorf("Unexpected error for missing key: %v", err)
+	}
+
+	value := []byte("binary\r\nvalue")
+	if err := client.Set(ctx, "key", value, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := client.Get(ctx, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != string(value) {
+		t.Errorf("Unexpected value: %q", data)
+	}
+
+	if ttl := srv.ttl("key"); ttl != "60000" {
+		t.Errorf("Unexpected TTL: %v", ttl)
+	}
+
+	if _, err := client.Do(ctx, "UNKNOWN"); err == nil {
+		t.Error("Expected error for unknown command")
+	}
+
+	if err := client.Del(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if srv.has("key") {
+		t.Error("Key was not deleted")
+	}
+}
+
+func TestStorePersistKey(t *testing.T) {
+	srv := newFakeServer(t)
+	client := NewClient(srv.listener.Addr().String(), "", 0)
+
+	store, err := NewStore(client, session.KeyPersistent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	transient := session.NewSession(session.NewSessionData("transient"), store)
+	persistent := session.NewSession(session.NewSessionData("persistent"), store)
+
+	for _, s := range []*session.Session{transient, persistent} {
+		if err := store.Init(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := persistent.Set(session.KeyUserEmail, "foo@bar.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.persistSessions(ctx, map[string]uint{"transient": 1, "persistent": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if srv.has(sessionKey("persistent")) {
+		t.Fatal("Session without persist key was saved")
+	}
+
+	if err := persistent.Set(session.KeyPersistent, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.persistSessions(ctx, map[string]uint{"transient": 1, "persistent": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !srv.has(sessionKey("persistent")) || srv.has(sessionKey("transient")) {
+		t.Fatal("Unexpected sessions saved to Redis")
+	}
+
+	sess, err := store.Read(ctx, "persistent", true /*skip cache*/)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if email, _ := sess.Get(ctx, session.KeyUserEmail).(string); email != "foo@bar.com" {
+		t.Errorf("Unexpected session value: %v", email)
+	}
+
+	if err := store.Destroy(ctx, "persistent"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Read(ctx, "persistent", false /*skip cache*/); err != session.ErrSessionMissing {
+		t.Errorf("Unexpected error after destroy: %v", err)
+	}
+
+	store.processCancel()
+	client.Close()
+}
+
+func TestStoreListUser(t *testing.T) {
+	srv := newFakeServer(t)
+	client := NewClient(srv.listener.Addr().String(), "", 0)
+	defer client.Close()
+
+	store, err := NewStore(client, session.KeyPersistent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	const userID int32 = 123
+
+	sids := []string{"first", "second", "anonymous"}
+	for _, sid := range sids {
+		sess := session.NewSession(session.NewSessionData(sid), store)
+		if err := store.Init(ctx, sess); err != nil {
+			t.Fatal(err)
+		}
+		_ = sess.Set(session.KeyPersistent, true)
+		if sid != "anonymous" {
+			_ = sess.Set(session.KeyUserID, userID)
+		}
+	}
+
+	batch := map[string]uint{}
+	for _, sid := range sids {
+		batch[sid] = 1
+	}
+
+	if err := store.persistSessions(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl := srv.ttl(userSessionsKey(userID)); ttl != strconv.FormatInt(sessionTTL.Milliseconds(), 10) {
+		t.Errorf("Unexpected user sessions TTL: %v", ttl)
+	}
+
+	sessions, err := store.ListUser(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("Unexpected number of user sessions: %v", len(sessions))
+	}
+
+	// session that expired in Redis is removed from the user set
+	if err := client.Del(ctx, sessionKey("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	if sessions, _ := store.ListUser(ctx, userID); (len(sessions) != 1) || (sessions[0].ID() != "second") {
+		t.Errorf("Unexpected user sessions after expiration: %v", len(sessions))
+	}
+
+	if members, _ := client.SMembers(ctx, userSessionsKey(userID)); len(members) != 1 {
+		t.Errorf("Expired session was not removed from user set: %v", members)
+	}
+
+	if err := store.Destroy(ctx, "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	if members, _ := client.SMembers(ctx, userSessionsKey(userID)); len(members) != 0 {
+		t.Errorf("Destroyed session was not removed from user set: %v", members)
+	}
+}
+
+func TestStoreDestroyOnOtherNode(t *testing.T) {
+	srv := newFakeServer(t)
+	ctx := t.Context()
+
+	stores := make([]*Store, 0, 2)
+	for range 2 {
+		client := NewClient(srv.listener.Addr().String(), "", 0)
+		defer client.Close()
+
+		store, err := NewStore(client, session.KeyPersistent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, store)
+	}
+
+	const sid = "shared"
+
+	sess := session.NewSession(session.NewSessionData(sid), stores[0])
+	if err := stores[0].Init(ctx, sess); err != nil {
+		t.Fatal(err)
+	}
+	_ = sess.Set(session.KeyPersistent, true)
+
+	if err := stores[0].persistSessions(ctx, map[string]uint{sid: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// second node caches the session in memory
+	if _, err := stores[1].Read(ctx, sid, false /*skip cache*/); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stores[0].Destroy(ctx, sid); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stores[1].Read(ctx, sid, false /*skip cache*/); err != session.ErrSessionMissing {
+		t.Errorf("Unexpected error reading session destroyed on other node: %v", err)
+	}
+
+	// pending update on the first node should not bring it back either
+	_ = stores[0].cache.Set(ctx, sid, sess.Data())
+	if err := stores[0].persistSessions(ctx, map[string]uint{sid: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if srv.has(sessionKey(sid)) {
+		t.Error("Revoked session was persisted again")
+	}
+}
@@ -0,0 +1,359 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// how many bytes of the IdP response we are ready to read
+	maxResponseSize = 1 << 20
+	// tolerated difference between our clock and IdP clock
+	clockSkew = 2 * time.Minute
+)
+
+var (
+	ErrNotConfigured    = errors.New("sso is not configured")
+	ErrInvalidIDToken   = errors.New("invalid id token")
+	ErrIssuerMismatch   = errors.New("issuer mismatch")
+	ErrAudienceMismatch = errors.New("audience mismatch")
+	ErrNonceMismatch    = errors.New("nonce mismatch")
+	ErrTokenExpired     = errors.New("id token expired")
+	ErrEmailMissing     = errors.New("email claim is missing")
+	ErrEmailNotVerified = errors.New("email is not verified")
+	errUnexpectedStatus = errors.New("unexpected status code")
+)
+
+// Identity is what we know about the user after a successful SSO login
+type Identity struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+type tokenResponse struct {
+	IDToken     string `json:"id_token"`
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// audience can be either a single string or an array of them
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = []string{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+	return nil
+}
+
+type idTokenClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	Expiry            int64    `json:"exp"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     any      `json:"email_verified"`
+	Name              string   `json:"name"`
+	GivenName         string   `json:"given_name"`
+	FamilyName        string   `json:"family_name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// emailVerified requires explicit claim: IdPs that do not send it (e.g. Entra ID) can let users set arbitrary emails
+func (c *idTokenClaims) emailVerified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return common.EnvToBool(v)
+	default:
+		return false
+	}
+}
+
+func (c *idTokenClaims) name() string {
+	if len(c.Name) > 0 {
+		return c.Name
+	}
+
+	if name := strings.TrimSpace(c.GivenName + " " + c.FamilyName); len(name) > 0 {
+		return name
+	}
+
+	if len(c.PreferredUsername) > 0 && !strings.Contains(c.PreferredUsername, "@") {
+		return c.PreferredUsername
+	}
+
+	if i := strings.Index(c.Email, "@"); i > 0 {
+		return c.Email[:i]
+	}
+
+	return c.Email
+}
+
+// OIDCProvider implements authorization code flow (with PKCE) of OpenID Connect
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Client       *http.Client
+	lock         sync.Mutex
+	discovery    *discoveryDocument
+}
+
+func NewOIDCProvider(issuer, clientID, clientSecret, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{
+		Issuer:       strings.TrimRight(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		Client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewOIDCProviderFromConfig returns nil if SSO is not configured for the deployment
+func NewOIDCProviderFromConfig(ctx context.Context, cfg common.ConfigStore, redirectURL string) *OIDCProvider {
+	issuer := strings.TrimSpace(cfg.Get(common.SSOIssuerKey).Value())
+	clientID := strings.TrimSpace(cfg.Get(common.SSOClientIDKey).Value())
+	if (len(issuer) == 0) || (len(clientID) == 0) {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "Configured OIDC single sign-on", "issuer", issuer)
+
+	return NewOIDCProvider(issuer, clientID, cfg.Get(common.SSOClientSecretKey).Value(), redirectURL)
+}
+
+func (p *OIDCProvider) fetchJSON(req *http.Request, v any) error {
+	req.Header.Set(common.HeaderAccept, common.ContentTypeJSON)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		slog.ErrorContext(req.Context(), "Unexpected response from IdP", "url", req.URL.String(), "code", resp.StatusCode,
+			"body", string(body))
+		return errUnexpectedStatus
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// discover caches only successful responses so that IdP downtime during startup does not break SSO until restart
+func (p *OIDCProvider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Issuer+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &discoveryDocument{}
+	if err := p.fetchJSON(req, doc); err != nil {
+		slog.ErrorContext(ctx, "Failed to fetch OIDC discovery document", "issuer", p.Issuer, common.ErrAttr(err))
+		return nil, err
+	}
+
+	if strings.TrimRight(doc.Issuer, "/") != p.Issuer {
+		slog.ErrorContext(ctx, "OIDC discovery issuer mismatch", "expected", p.Issuer, "actual", doc.Issuer)
+		return nil, ErrIssuerMismatch
+	}
+
+	p.discovery = doc
+
+	return doc, nil
+}
+
+// AuthURL returns URL of the IdP where user should be redirected to login
+func (p *OIDCProvider) AuthURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("scope", strings.Join(p.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange redeems authorization code for the ID token and returns the identity of the user
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string, tnow time.Time) (*Identity, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(common.HeaderContentType, common.ContentTypeURLEncoded)
+	// client_secret_basic requires form-encoding of the credentials (RFC 6749, section 2.3.1)
+	req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+
+	tokens := &tokenResponse{}
+	if err := p.fetchJSON(req, tokens); err != nil {
+		slog.ErrorContext(ctx, "Failed to exchange OIDC authorization code", common.ErrAttr(err))
+		return nil, err
+	}
+
+	claims, err := parseIDToken(tokens.IDToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse ID token", common.ErrAttr(err))
+		return nil, err
+	}
+
+	if err := p.validate(claims, nonce, tnow); err != nil {
+		slog.WarnContext(ctx, "ID token is not valid", "subject", claims.Subject, common.ErrAttr(err))
+		return nil, err
+	}
+
+	return &Identity{
+		Subject: claims.Subject,
+		Email:   strings.TrimSpace(claims.Email),
+		Name:    strings.TrimSpace(claims.name()),
+	}, nil
+}
+
+func (p *OIDCProvider) validate(claims *idTokenClaims, nonce string, tnow time.Time) error {
+	if strings.TrimRight(claims.Issuer, "/") != p.Issuer {
+		return ErrIssuerMismatch
+	}
+
+	audienceFound := false
+	for _, aud := range claims.Audience {
+		if aud == p.ClientID {
+			audienceFound = true
+			break
+		}
+	}
+
+	if !audienceFound {
+		return ErrAudienceMismatch
+	}
+
+	if tnow.After(time.Unix(claims.Expiry, 0).Add(clockSkew)) {
+		return ErrTokenExpired
+	}
+
+	if (len(nonce) == 0) || (claims.Nonce != nonce) {
+		return ErrNonceMismatch
+	}
+
+	if len(claims.Email) == 0 {
+		return ErrEmailMissing
+	}
+
+	if !claims.emailVerified() {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+// ID token is received directly from the token endpoint over TLS, so per OIDC Core (section 3.1.3.7) TLS server
+// validation is used instead of checking the token signature
+func parseIDToken(token string) (*idTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims := &idTokenClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	return claims, nil
+}
+
+// RandomToken returns URL-safe random string suitable for state, nonce and PKCE verifier
+func RandomToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DeriveToken deterministically derives a separate secret (e.g. nonce or verifier) from the random one
+func DeriveToken(secret, purpose string) string {
+	h := sha256.Sum256([]byte(purpose + ":" + secret))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+func CodeChallenge(verifier string) string {
+	h := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
@@ -0,0 +1,143 @@
+package sso
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID     = "portal"
+	testClientSecret = "secret"
+	testCode         = "code"
+)
+
+func unsignedToken(t *testing.T, claims map[string]any) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func newTestIdP(t *testing.T, claims func(issuer string) map[string]any) *httptest.Server {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&discoveryDocument{
+			Issuer:                srv.URL,
+			AuthorizationEndpoint: srv.URL + "/authorize",
+			TokenEndpoint:         srv.URL + "/token",
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if clientID, secret, ok := r.BasicAuth(); !ok || (clientID != testClientID) || (secret != testClientSecret) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if (r.FormValue("code") != testCode) || (len(r.FormValue("code_verifier")) == 0) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(&tokenResponse{
+			IDToken:   unsignedToken(t, claims(srv.URL)),
+			TokenType: "Bearer",
+		})
+	})
+
+	return srv
+}
+
+func TestOIDCAuthURL(t *testing.T) {
+	t.Parallel()
+
+	idp := newTestIdP(t, nil)
+	provider := NewOIDCProvider(idp.URL, testClientID, testClientSecret, "https://portal.example.com/sso/callback")
+
+	authURL, err := provider.AuthURL(t.Context(), "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := u.Query()
+	if q.Get("client_id") != testClientID || q.Get("state") != "state" || q.Get("nonce") != "nonce" {
+		t.Errorf("Unexpected auth URL: %v", authURL)
+	}
+
+	if q.Get("code_challenge") != CodeChallenge("verifier") {
+		t.Errorf("Unexpected code challenge: %v", q.Get("code_challenge"))
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	t.Parallel()
+
+	const nonce = "nonce"
+	tnow := time.Now()
+
+	testCases := []struct {
+		name   string
+		modify func(claims map[string]any)
+		err    error
+	}{
+		{"valid", func(claims map[string]any) {}, nil},
+		{"audience array", func(claims map[string]any) { claims["aud"] = []string{"other", testClientID} }, nil},
+		{"wrong audience", func(claims map[string]any) { claims["aud"] = "other" }, ErrAudienceMismatch},
+		{"wrong issuer", func(claims map[string]any) { claims["iss"] = "https://evil.example.com" }, ErrIssuerMismatch},
+		{"wrong nonce", func(claims map[string]any) { claims["nonce"] = "other" }, ErrNonceMismatch},
+		{"expired", func(claims map[string]any) { claims["exp"] = tnow.Add(-1 * time.Hour).Unix() }, ErrTokenExpired},
+		{"no email", func(claims map[string]any) { delete(claims, "email") }, ErrEmailMissing},
+		{"unverified email", func(claims map[string]any) { claims["email_verified"] = false }, ErrEmailNotVerified},
+		{"no email verification", func(claims map[string]any) { delete(claims, "email_verified") }, ErrEmailNotVerified},
+		{"verified email string", func(claims map[string]any) { claims["email_verified"] = "true" }, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			idp := newTestIdP(t, func(issuer string) map[string]any {
+				claims := map[string]any{
+					"iss":            issuer,
+					"sub":            "123",
+					"aud":            testClientID,
+					"exp":            tnow.Add(5 * time.Minute).Unix(),
+					"nonce":          nonce,
+					"email":          "user@example.com",
+					"email_verified": true,
+					"name":           "Jane Doe",
+				}
+				tc.modify(claims)
+				return claims
+			})
+
+			provider := NewOIDCProvider(idp.URL, testClientID, testClientSecret, "https://portal.example.com/sso/callback")
+
+			identity, err := provider.Exchange(t.Context(), testCode, "verifier", nonce, tnow)
+			if err != tc.err {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if (err == nil) && ((identity.Email != "user@example.com") || (identity.Name != "Jane Doe") || (identity.Subject != "123")) {
+				t.Errorf("Unexpected identity: %+v", identity)
+			}
+		})
+	}
+}
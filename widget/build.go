@@ -1,10 +1,12 @@
 package widget
 
 import (
+	"crypto/sha256"
 	"embed"
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/PrivateCaptcha/PrivateCaptcha/pkg/common"
 )
@@ -12,6 +14,34 @@ import (
 //go:embed static
 var staticFiles embed.FS
 
+const scriptPath = "static/js/privatecaptcha.js"
+
+func StaticFiles() *embed.FS {
+	return &staticFiles
+}
+
+// ScriptHash returns SHA-256 of the bundled widget script or nil if the widget was not built
+func ScriptHash() []byte {
+	data, err := staticFiles.ReadFile(scriptPath)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// TurnstileScript serves widget script in place of Cloudflare Turnstile one (the widget switches to compatibility mode
+// based on its own URL), so that sites integrated with Turnstile only need to change the script host and sitekey
+func TurnstileScript(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = strings.TrimPrefix(scriptPath, "static/")
+		r2.URL.RawPath = ""
+		next.ServeHTTP(w, r2)
+	})
+}
+
 func Static(gitHash string) http.HandlerFunc {
 	sub, _ := fs.Sub(staticFiles, "static")
 	srv := http.FileServer(http.FS(sub))
@@ -1,6 +1,7 @@
 'use strict';
 
-import { CaptchaWidget, RECAPTCHA_COMPAT } from './widget.js';
+import { CaptchaWidget, RECAPTCHA_COMPAT, TURNSTILE_COMPAT } from './widget.js';
+import { TurnstileAPI } from './turnstile.js';
 
 window.privateCaptcha = {
     setup: setupPrivateCaptcha,
@@ -10,13 +11,25 @@ window.privateCaptcha = {
 };
 
 const RENDER_EXPLICIT = "explicit";
+// same path as Cloudflare Turnstile script, so that only the host needs to be changed
+const TURNSTILE_SCRIPT_PATH = 'turnstile/v0/api.js';
+
+if (getBaseOptions().compat === TURNSTILE_COMPAT) {
+    // sites can call turnstile.render() right after the script was loaded, before the DOM is ready
+    window.turnstile = new TurnstileAPI();
+}
 
 /**
  * Finds all captcha elements on the page
  * @param {string} compatMode
  */
 function findCaptchaElements(compatMode) {
-    const selector = (compatMode === RECAPTCHA_COMPAT) ? '.g-recaptcha' : '.private-captcha';
+    let selector = '.private-captcha';
+    if (compatMode === RECAPTCHA_COMPAT) {
+        selector = '.g-recaptcha';
+    } else if (compatMode === TURNSTILE_COMPAT) {
+        selector = '.cf-turnstile';
+    }
     const elements = document.querySelectorAll(selector);
     if (elements.length === 0) {
         console.warn(`'PrivateCaptcha: No element was found with ${selector} class`);
@@ -28,7 +41,7 @@ function getBaseOptions() {
     let scriptTag;
     const scripts = document.getElementsByTagName('script');
     for (let script of scripts) {
-        if (script.src.includes('widget/js/privatecaptcha.js')) {
+        if (script.src.includes('widget/js/privatecaptcha.js') || script.src.includes(TURNSTILE_SCRIPT_PATH)) {
             scriptTag = script;
             break;
         }
@@ -37,12 +50,17 @@ function getBaseOptions() {
     let options = {
         compat: null,
         render: null,
+        onload: null,
     };
 
     if (scriptTag) {
         const scriptUrl = new URL(scriptTag.src);
         const params = scriptUrl.searchParams;
 
+        if (scriptUrl.pathname.endsWith(TURNSTILE_SCRIPT_PATH)) {
+            options.compat = TURNSTILE_COMPAT;
+        }
+
         const compatMode = params.get('compat');
         if (compatMode && (typeof compatMode === 'string') && (compatMode.length > 0)) {
             options.compat = compatMode;
@@ -52,6 +70,11 @@ function getBaseOptions() {
         if (renderMode && (typeof renderMode === 'string') && (renderMode.length > 0)) {
             options.render = renderMode;
         }
+
+        const onload = params.get('onload');
+        if (onload && (typeof onload === 'string') && (onload.length > 0)) {
+            options.onload = onload;
+        }
     }
 
     return options;
@@ -60,6 +83,11 @@ function getBaseOptions() {
 function setupPrivateCaptcha() {
     let options = getBaseOptions();
 
+    if (options.compat === TURNSTILE_COMPAT) {
+        setupTurnstile(options);
+        return;
+    }
+
     if (options.render !== RENDER_EXPLICIT) {
         let autoWidget = window.privateCaptcha.autoWidget;
 
@@ -76,6 +104,27 @@ function setupPrivateCaptcha() {
     }
 }
 
+/**
+ * Cloudflare Turnstile compatibility layer: widgets are tracked by ID in window.turnstile
+ * @param {Object} options
+ */
+function setupTurnstile(options) {
+    if (options.render !== RENDER_EXPLICIT) {
+        const elements = findCaptchaElements(options.compat);
+        for (let htmlElement of elements) {
+            window.turnstile.render(htmlElement);
+        }
+    }
+
+    if (options.onload && (typeof window[options.onload] === 'function')) {
+        try {
+            window[options.onload]();
+        } catch (e) {
+            console.error('[privatecaptcha] Error in onload callback:', e);
+        }
+    }
+}
+
 /**
  * Google reCAPTCHA (and hCAPTCHA) compatibility layer: render
  * @param {HTMLElement} element
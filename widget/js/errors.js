@@ -5,3 +5,4 @@ export const ERROR_NOT_CONFIGURED = 1;
 export const ERROR_ZERO_PUZZLE = 2;
 export const ERROR_FETCH_PUZZLE = 3;
 export const ERROR_SOLVE_PUZZLE = 4;
+export const ERROR_QUOTA_EXCEEDED = 5;
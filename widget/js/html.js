@@ -1,7 +1,7 @@
 'use strict';
 
 import { ProgressRing } from './progress.js';
-import { SafeHTMLElement } from "./utils.js";
+import { SafeHTMLElement, escapeHTML } from "./utils.js";
 import styles from "./styles.css" with { type: 'css' };
 import * as i18n from './strings.js';
 import * as errors from './errors.js';
@@ -17,6 +17,7 @@ export const STATE_READY = 'ready';
 export const STATE_IN_PROGRESS = 'inprogress';
 export const STATE_VERIFIED = 'verified';
 export const STATE_INVALID = 'invalid';
+export const STATE_IMAGE = 'image';
 
 export const DISPLAY_POPUP = 'popup';
 const DISPLAY_HIDDEN = 'hidden';
@@ -26,6 +27,10 @@ const CHECKBOX_ID = 'pc-checkbox';
 const PROGRESS_ID = 'pc-progress';
 const DEBUG_ID = 'pc-debug';
 const DEBUG_ERROR_CLASS = 'warn';
+const IMAGE_LINK_ID = 'pc-image-link';
+const IMAGE_INPUT_ID = 'pc-image-input';
+const IMAGE_FORM_ID = 'pc-image-form';
+const IMAGE_CODE_LENGTH = 8;
 
 const privateCaptchaSVG = `<svg viewBox="0 0 39.4 41.99" xml:space="preserve" xmlns="http://www.w3.org/2000/svg" class="pc-logo" preserveAspectRatio="xMidYMid meet">
 <path d="M0 0v30.62l4.29 2.48V4.85h30.83v23.29l-15.41 8.9-6.83-3.94v-4.95l6.83 3.94 11.12-6.42V9.91H8.58v25.66l11.12 6.42 19.7-11.37V0Zm12.87 14.86h13.66v8.32l-6.83 3.94-6.83-3.94z" fill="currentColor"/>
@@ -80,6 +85,11 @@ export class CaptchaElement extends SafeHTMLElement {
         this._root = this.attachShadow({ mode: 'open' });
         this._debug = this.getAttribute('debug');
         this._error = null;
+        this._failureMessage = '';
+        this._quotaExceeded = false;
+        // image challenge fallback is enabled per property and is offered only while puzzle is being solved
+        this._imageChallenge = false;
+        this._imageURL = '';
         this._displayMode = this.getAttribute('display-mode');
         this._lang = this.getAttribute('lang');
         if (!(this._lang in i18n.STRINGS)) {
@@ -123,6 +133,7 @@ export class CaptchaElement extends SafeHTMLElement {
         let activeArea = '';
         let bindCheckEvent = false;
         let showPopupIfNeeded = false;
+        let bindImageEvents = false;
         const strings = i18n.STRINGS[this._lang];
 
         switch (state) {
@@ -142,6 +153,17 @@ export class CaptchaElement extends SafeHTMLElement {
             case STATE_IN_PROGRESS:
                 const text = strings[i18n.VERIFYING];
                 activeArea = `<progress-ring id="${PROGRESS_ID}" stroke="12" progress="0"></progress-ring><label for="${PROGRESS_ID}">${text}<span class="dots"><span>.</span><span>.</span><span>.</span></span></label>`;
+                if (this._imageChallenge) {
+                    activeArea += `<a href="#" id="${IMAGE_LINK_ID}">${strings[i18n.USE_IMAGE]}</a>`;
+                    bindImageEvents = true;
+                }
+                showPopupIfNeeded = canShow;
+                break;
+            case STATE_IMAGE:
+                activeArea = `<form id="${IMAGE_FORM_ID}" class="pc-image-challenge"><img src="${this._imageURL}" alt="${strings[i18n.TYPE_CODE]}">` +
+                    `<input id="${IMAGE_INPUT_ID}" type="text" autocapitalize="characters" autocomplete="off" spellcheck="false" pattern="[A-Za-z0-9]{${IMAGE_CODE_LENGTH}}" maxlength="${IMAGE_CODE_LENGTH}" placeholder="${strings[i18n.TYPE_CODE]}" aria-label="${strings[i18n.TYPE_CODE]}" required>` +
+                    `<button type="submit">${strings[i18n.SUBMIT]}</button></form>`;
+                bindImageEvents = true;
                 showPopupIfNeeded = canShow;
                 break;
             case STATE_VERIFIED:
@@ -149,7 +171,8 @@ export class CaptchaElement extends SafeHTMLElement {
                 showPopupIfNeeded = canShow;
                 break;
             case STATE_INVALID:
-                activeArea = checkbox('invalid') + label(strings[i18n.UNAVAILABLE], CHECKBOX_ID);
+                const fallback = this._quotaExceeded ? strings[i18n.BUSY] : strings[i18n.UNAVAILABLE];
+                activeArea = checkbox('invalid') + label(this._failureMessage ? escapeHTML(this._failureMessage) : fallback, CHECKBOX_ID);
                 break;
             default:
                 console.error(`[privatecaptcha][progress] unknown state: ${state}`);
@@ -183,6 +206,13 @@ export class CaptchaElement extends SafeHTMLElement {
                 console.warn('[privatecaptcha][progress] checkbox not found in the Shadow DOM');
             }
         }
+
+        if (bindImageEvents) {
+            const link = this._root.getElementById(IMAGE_LINK_ID);
+            if (link) { link.addEventListener('click', this.onImageRequested.bind(this)); }
+            const form = this._root.getElementById(IMAGE_FORM_ID);
+            if (form) { form.addEventListener('submit', this.onImageAnswered.bind(this)); }
+        }
     }
 
     _syncHostClass(showPopupIfNeeded) {
@@ -222,6 +252,53 @@ export class CaptchaElement extends SafeHTMLElement {
         }
     }
 
+    /**
+     * @param {Event} event
+     */
+    onImageRequested(event) {
+        event.preventDefault();
+        this.dispatchEvent(new CustomEvent("privatecaptcha:image", {
+            bubbles: true,
+            composed: true
+        }));
+    }
+
+    /**
+     * @param {Event} event
+     */
+    onImageAnswered(event) {
+        event.preventDefault();
+        const input = this._root.getElementById(IMAGE_INPUT_ID);
+        const answer = input ? input.value.trim() : '';
+        if (answer.length !== IMAGE_CODE_LENGTH) {
+            if (input) { input.focus(); }
+            return;
+        }
+
+        this.dispatchEvent(new CustomEvent("privatecaptcha:imageanswer", {
+            bubbles: true,
+            composed: true,
+            detail: { answer: answer }
+        }));
+    }
+
+    /**
+     * @param {boolean} enabled puzzle allows to answer image challenge instead of solving it
+     */
+    setImageChallenge(enabled) {
+        this._imageChallenge = enabled;
+    }
+
+    /**
+     * @param {string} url object URL of the image challenge to show in the image state
+     */
+    setImageURL(url) {
+        if (this._imageURL && (this._imageURL !== url)) {
+            URL.revokeObjectURL(this._imageURL);
+        }
+        this._imageURL = url || '';
+    }
+
     /**
      * @param {number} percent
      */
@@ -247,6 +324,15 @@ export class CaptchaElement extends SafeHTMLElement {
         this._error = value;
     }
 
+    /**
+     * @param {string} message custom text (configured per property) to show instead of a generic "unavailable"
+     * @param {boolean} quotaExceeded puzzle quota of the property is exhausted (generic text is "busy" instead)
+     */
+    setFailureMessage(message, quotaExceeded = false) {
+        this._failureMessage = message || '';
+        this._quotaExceeded = quotaExceeded;
+    }
+
     /**
      * @param {string} text
      * @param {boolean} error
@@ -0,0 +1,91 @@
+'use strict';
+
+// NOTE: keep in sync with puzzle.IntegrityFlags on the server
+export const INTEGRITY_FLAG_NATIVE_PATCHED = 1 << 0;
+export const INTEGRITY_FLAG_NO_SCRIPT = 1 << 1;
+export const SCRIPT_HASH_LENGTH = 8;
+
+// this is only available during synchronous evaluation of the (non-module) script
+const currentScript = (typeof document !== 'undefined') ? document.currentScript : null;
+let scriptHash = null;
+let hashPromise = null;
+
+function isNative(fn) {
+    try {
+        return (typeof fn === 'function') && /\{\s*\[native code\]\s*\}\s*$/.test(Function.prototype.toString.call(fn));
+    } catch (e) {
+        return false;
+    }
+}
+
+// well-known wrappers (zone.js, Sentry and similar error trackers) keep a reference to the original function
+const ORIGINAL_FUNCTION_KEYS = ['__zone_symbol__OriginalDelegate', '__sentry_original__', '__original__'];
+
+function unwrapKnown(fn) {
+    for (let i = 0; (i < ORIGINAL_FUNCTION_KEYS.length) && fn; i++) {
+        try {
+            const original = fn[ORIGINAL_FUNCTION_KEYS[i]];
+            if (typeof original === 'function') { return original; }
+        } catch (e) {
+            // ignore getters that throw
+        }
+    }
+    return fn;
+}
+
+// polyfills (e.g. whatwg-fetch) replace APIs that are missing in the browser and mark themselves
+function isPolyfill(fn) {
+    return (typeof fn === 'function') && (fn.polyfill === true);
+}
+
+function isNativePatched() {
+    const fns = [window.fetch, window.Worker, Function.prototype.toString];
+    if (window.crypto && window.crypto.subtle) {
+        fns.push(window.crypto.subtle.digest);
+    }
+    return !fns.every((fn) => isPolyfill(fn) || isNative(unwrapKnown(fn)));
+}
+
+/**
+ * Computes (truncated) SHA-256 of the widget script, as it was delivered to the browser
+ * @returns {Promise<Uint8Array|null>}
+ */
+export function computeScriptHash() {
+    if (hashPromise) { return hashPromise; }
+
+    if (!currentScript || !currentScript.src || !window.crypto || !window.crypto.subtle) {
+        hashPromise = Promise.resolve(null);
+        return hashPromise;
+    }
+
+    hashPromise = fetch(currentScript.src, { cache: 'force-cache', credentials: 'omit' })
+        .then((response) => {
+            if (!response.ok) { throw new Error(`script fetch failed with ${response.status}`); }
+            return response.arrayBuffer();
+        })
+        .then((buffer) => window.crypto.subtle.digest('SHA-256', buffer))
+        .then((digest) => {
+            scriptHash = new Uint8Array(digest).slice(0, SCRIPT_HASH_LENGTH);
+            return scriptHash;
+        })
+        .catch((e) => {
+            console.warn('[privatecaptcha] failed to compute script hash', e);
+            return null;
+        });
+
+    return hashPromise;
+}
+
+/**
+ * @returns {{flags: number, hash: Uint8Array}} integrity beacon, that is bound into the solutions payload
+ */
+export function integrityBeacon() {
+    let flags = 0;
+    if (isNativePatched()) { flags |= INTEGRITY_FLAG_NATIVE_PATCHED; }
+    if (!scriptHash) { flags |= INTEGRITY_FLAG_NO_SCRIPT; }
+
+    return {
+        flags: flags,
+        hash: scriptHash || new Uint8Array(SCRIPT_HASH_LENGTH),
+    };
+}
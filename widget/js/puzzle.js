@@ -3,13 +3,57 @@
 import { decode } from 'base64-arraybuffer';
 
 const PUZZLE_BUFFER_LENGTH = 128;
+// TLV-encoded options (type, length, value) that server can append after user data
+const OPTION_WIDGET_FLAGS = 1;
+// end of the window during which puzzle difficulty is known to stay the same (seconds before expiration)
+const OPTION_DIFFICULTY_LEASE = 5;
+export const WIDGET_FLAG_REQUIRE_INTERACTION = 1 << 0;
+export const WIDGET_FLAG_NO_AUTO_REFRESH = 1 << 1;
+export const WIDGET_FLAG_IMAGE_CHALLENGE = 1 << 2;
 // RequestTimeout, Conflict, TooManyRequests
 const ACCEPTABLE_CLIENT_ERRORS = [408, 409, 429];
+const CODE_QUOTA_EXCEEDED = 'quota_exceeded';
+
+/**
+ * Puzzle cannot be served and property owner configured what end users should see instead.
+ */
+export class PuzzleFailure extends Error {
+    /**
+     * @param {string} message
+     * @param {string} url
+     * @param {string} code
+     */
+    constructor(message, url, code) {
+        super(message || 'Captcha is not available');
+        this.name = 'PuzzleFailure';
+        this.failureMessage = message || '';
+        this.failureURL = url || '';
+        this.code = code || '';
+    }
+
+    /**
+     * Property issued too many puzzles recently (retrying right away will not help)
+     * @returns {boolean}
+     */
+    isQuotaExceeded() {
+        return CODE_QUOTA_EXCEEDED === this.code;
+    }
+}
+
+/**
+ * @param {string} endpoint
+ * @param {string} sitekey
+ * @param {string | null} rememberProof solution of a recently solved puzzle (if any)
+ * @param {string | null} stickyPuzzle previous puzzle received on this page (keeps difficulty stable)
+ */
+export async function getPuzzle(endpoint, sitekey, rememberProof = null, stickyPuzzle = null) {
+    const headers = [["x-pc-captcha-version", "1"]];
+    if (rememberProof) { headers.push(["x-pc-remember", rememberProof]); }
+    if (stickyPuzzle) { headers.push(["x-pc-sticky", stickyPuzzle]); }
 
-export async function getPuzzle(endpoint, sitekey) {
     try {
         const response = await fetchWithBackoff(`${endpoint}?sitekey=${sitekey}`,
-            { headers: [["x-pc-captcha-version", "1"]], mode: "cors" },
+            { headers: headers, mode: "cors" },
             5 /*max attempts*/
         );
 
@@ -17,7 +61,10 @@ export async function getPuzzle(endpoint, sitekey) {
             const data = await response.text();
             return data;
         } else {
-            let json = await response.json();
+            const json = await response.json().catch(() => null);
+            if (json && (json.failure_url || json.failure_message || json.code)) {
+                throw new PuzzleFailure(json.failure_message, json.failure_url, json.code);
+            }
             if (json && json.error) {
                 throw Error(json.error);
             }
@@ -30,6 +77,59 @@ export async function getPuzzle(endpoint, sitekey) {
     throw Error('Internal error');
 };
 
+/**
+ * Sends solution of a puzzle, that was solved on this device, to a kiosk device waiting for it (handoff).
+ * @param {string} puzzleEndpoint
+ * @param {string} handoffID
+ * @param {string} payload
+ */
+export async function submitHandoff(puzzleEndpoint, handoffID, payload) {
+    const endpoint = puzzleEndpoint.replace(/\/puzzle\/?$/, '/handoff');
+
+    try {
+        const response = await fetchWithBackoff(`${endpoint}/${encodeURIComponent(handoffID)}`,
+            { method: "POST", body: payload, headers: [["content-type", "text/plain"]], mode: "cors" },
+            3 /*max attempts*/
+        );
+        if (!response.ok) {
+            throw new Error(`Handoff submission failed. status=${response.status}`);
+        }
+    } catch (err) {
+        console.error('[privatecaptcha]', err);
+        throw err;
+    }
+}
+
+/**
+ * Fetches image challenge (digits to type in) for the puzzle, as a fallback when compute puzzle cannot be solved.
+ * @param {string} puzzleEndpoint
+ * @param {string} sitekey
+ * @param {string} puzzleData puzzle as it was received from the server
+ * @returns {Promise<Blob>} PNG image
+ */
+export async function getImageChallenge(puzzleEndpoint, sitekey, puzzleData) {
+    const endpoint = puzzleEndpoint.replace(/\/puzzle\/?$/, '/puzzle/image');
+
+    try {
+        const response = await fetchWithBackoff(`${endpoint}?sitekey=${sitekey}`,
+            { method: "POST", body: puzzleData, headers: [["content-type", "text/plain"]], mode: "cors" },
+            3 /*max attempts*/
+        );
+        if (!response.ok) {
+            throw new Error(`Image challenge request failed. status=${response.status}`);
+        }
+        return await response.blob();
+    } catch (err) {
+        console.error('[privatecaptcha]', err);
+        throw err;
+    }
+}
+
+function isJSONResponse(response) {
+    const contentType = response.headers.get('content-type') || '';
+    return contentType.startsWith('application/json');
+}
+
 function wait(delay) {
     return new Promise((resolve) => setTimeout(resolve, delay));
 }
@@ -51,8 +151,11 @@ async function fetchWithBackoff(url, options, maxAttempts, initialDelay = 800, m
 
             if ((response.status >= 400) && (response.status < 500) &&
                 !ACCEPTABLE_CLIENT_ERRORS.includes(response.status)) {
-                // we don't retry on most client errors
-                break;
+                // we don't retry on most client errors, but the body can explain what to do instead
+                return response;
+            } else if (isJSONResponse(response)) {
+                // rate limits are plain text, but exceeded property quota is explained in the body
+                return response;
             } else {
                 continue;
             }
@@ -89,6 +192,8 @@ export class Puzzle {
         this.solutionsCount = null;
         this.expirationTimestamp = null;
         this.userData = null;
+        this.widgetFlags = 0;
+        this.leaseTimestamp = 0;
 
         this.signature = null;
 
@@ -123,12 +228,12 @@ export class Puzzle {
         this.expirationTimestamp = readUInt32LE(data, offset);
         offset += 4;
 
-        offset += 4; // AccountID
-
         const userDataSize = 16;
         this.userData = data.slice(offset, offset + userDataSize);
         offset += userDataSize;
 
+        this.parseOptions(data, offset);
+
         let sourceBuffer = data;
         if (sourceBuffer.length < PUZZLE_BUFFER_LENGTH) {
             const enlargedBuffer = new Uint8Array(PUZZLE_BUFFER_LENGTH);
@@ -139,6 +244,45 @@ export class Puzzle {
         }
     }
 
+    parseOptions(data, offset) {
+        while (offset + 2 <= data.length) {
+            const type = data[offset];
+            const length = data[offset + 1];
+            offset += 2;
+
+            if (offset + length > data.length) {
+                console.warn('[privatecaptcha] puzzle option is truncated');
+                break;
+            }
+
+            // unknown options are skipped
+            if ((OPTION_WIDGET_FLAGS === type) && (length > 0)) {
+                this.widgetFlags = data[offset];
+            } else if ((OPTION_DIFFICULTY_LEASE === type) && (length >= 2) && this.expirationTimestamp) {
+                this.leaseTimestamp = this.expirationTimestamp - (data[offset] | (data[offset + 1] << 8));
+            }
+
+            offset += length;
+        }
+    }
+
+    requiresInteraction() {
+        return (this.widgetFlags & WIDGET_FLAG_REQUIRE_INTERACTION) !== 0;
+    }
+
+    imageChallenge() {
+        return (this.widgetFlags & WIDGET_FLAG_IMAGE_CHALLENGE) !== 0;
+    }
+
+    autoRefresh() {
+        return (this.widgetFlags & WIDGET_FLAG_NO_AUTO_REFRESH) === 0;
+    }
+
+    // leased difficulty does not depend on the end user so there's no point in pinning it
+    hasDifficultyLease() {
+        return this.leaseTimestamp > 0;
+    }
+
     isZero() {
         return (this.ID === 0n) && (this.difficulty === 0) && (this.expirationTimestamp === 0);
     }
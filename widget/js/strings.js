@@ -4,9 +4,13 @@ export const CLICK_TO_VERIFY = 'click_to_verify';
 export const VERIFYING = 'verifying';
 export const SUCCESS = 'success';
 export const UNAVAILABLE = 'unavailable';
+export const BUSY = 'busy';
 export const INCOMPLETE = 'incomplete';
 export const ERROR = 'error';
 export const TESTING = 'testing';
+export const USE_IMAGE = 'use_image';
+export const TYPE_CODE = 'type_code';
+export const SUBMIT = 'submit';
 
 export const STRINGS = {
     'en': {
@@ -14,98 +18,142 @@ export const STRINGS = {
         [VERIFYING]: 'Verifying',
         [SUCCESS]: 'Verified',
         [UNAVAILABLE]: 'Not available',
+        [BUSY]: 'Busy, try again later',
         [INCOMPLETE]: 'incomplete',
         [ERROR]: 'error',
         [TESTING]: 'testing',
+        [USE_IMAGE]: 'Use image instead',
+        [TYPE_CODE]: 'Type the characters',
+        [SUBMIT]: 'Verify',
     },
     'de': {
         [CLICK_TO_VERIFY]: 'Zum Bestätigen klicken',
         [VERIFYING]: 'Wird überprüft',
         [SUCCESS]: 'Verifiziert',
         [UNAVAILABLE]: 'Nicht verfügbar',
+        [BUSY]: 'Ausgelastet, später erneut versuchen',
         [INCOMPLETE]: 'unvollständig',
         [ERROR]: 'fehler',
         [TESTING]: 'testmodus',
+        [USE_IMAGE]: 'Bild verwenden',
+        [TYPE_CODE]: 'Zeichen eingeben',
+        [SUBMIT]: 'Bestätigen',
     },
     'es': {
         [CLICK_TO_VERIFY]: 'Haz clic para verificar',
         [VERIFYING]: 'Verificando',
         [SUCCESS]: 'Verificado',
         [UNAVAILABLE]: 'No disponible',
+        [BUSY]: 'Ocupado, inténtalo más tarde',
         [INCOMPLETE]: 'incompleto',
         [ERROR]: 'error',
         [TESTING]: 'prueba',
+        [USE_IMAGE]: 'Usar imagen',
+        [TYPE_CODE]: 'Escribe los caracteres',
+        [SUBMIT]: 'Verificar',
     },
     'fr': {
         [CLICK_TO_VERIFY]: 'Cliquez pour vérifier',
         [VERIFYING]: 'Vérification en cours',
         [SUCCESS]: 'Vérifié',
         [UNAVAILABLE]: 'Non disponible',
+        [BUSY]: 'Occupé, réessayez plus tard',
         [INCOMPLETE]: 'incomplet',
         [ERROR]: 'erreur',
         [TESTING]: 'test',
+        [USE_IMAGE]: 'Utiliser une image',
+        [TYPE_CODE]: 'Saisissez les caractères',
+        [SUBMIT]: 'Vérifier',
     },
     'it': {
         [CLICK_TO_VERIFY]: 'Clicca per verificare',
         [VERIFYING]: 'Verifica in corso',
         [SUCCESS]: 'Verificato',
         [UNAVAILABLE]: 'Non disponibile',
+        [BUSY]: 'Occupato, riprova più tardi',
         [INCOMPLETE]: 'incompleto',
         [ERROR]: 'errore',
         [TESTING]: 'test',
+        [USE_IMAGE]: 'Usa immagine',
+        [TYPE_CODE]: 'Digita i caratteri',
+        [SUBMIT]: 'Verifica',
     },
     'nl': {
         [CLICK_TO_VERIFY]: 'Klik om te verifiëren',
         [VERIFYING]: 'Bezig met verifiëren',
         [SUCCESS]: 'Geverifieerd',
         [UNAVAILABLE]: 'Niet beschikbaar',
+        [BUSY]: 'Bezet, probeer het later opnieuw',
         [INCOMPLETE]: 'onvolledig',
         [ERROR]: 'fout',
         [TESTING]: 'testen',
+        [USE_IMAGE]: 'Afbeelding gebruiken',
+        [TYPE_CODE]: 'Typ de tekens',
+        [SUBMIT]: 'Verifiëren',
     },
     'sv': {
         [CLICK_TO_VERIFY]: 'Klicka för att verifiera',
         [VERIFYING]: 'Verifierar',
         [SUCCESS]: 'Verifierad',
         [UNAVAILABLE]: 'Inte tillgänglig',
+        [BUSY]: 'Upptagen, försök igen senare',
         [INCOMPLETE]: 'ofullständig',
         [ERROR]: 'fel',
         [TESTING]: 'test',
+        [USE_IMAGE]: 'Använd bild',
+        [TYPE_CODE]: 'Skriv tecknen',
+        [SUBMIT]: 'Verifiera',
     },
     'no': {
         [CLICK_TO_VERIFY]: 'Klikk for å bekrefte',
         [VERIFYING]: 'Bekrefter',
         [SUCCESS]: 'Bekreftet',
         [UNAVAILABLE]: 'Ikke tilgjengelig',
+        [BUSY]: 'Opptatt, prøv igjen senere',
         [INCOMPLETE]: 'ufullstendig',
         [ERROR]: 'feil',
         [TESTING]: 'test',
+        [USE_IMAGE]: 'Bruk bilde',
+        [TYPE_CODE]: 'Skriv inn tegnene',
+        [SUBMIT]: 'Bekreft',
     },
     'pl': {
         [CLICK_TO_VERIFY]: 'Kliknij, aby zweryfikować',
         [VERIFYING]: 'Weryfikowanie',
         [SUCCESS]: 'Zweryfikowano',
         [UNAVAILABLE]: 'Niedostępne',
+        [BUSY]: 'Zajęte, spróbuj później',
         [INCOMPLETE]: 'niekompletne',
         [ERROR]: 'błąd',
         [TESTING]: 'test',
+        [USE_IMAGE]: 'Użyj obrazka',
+        [TYPE_CODE]: 'Wpisz znaki',
+        [SUBMIT]: 'Zweryfikuj',
     },
     'fi': {
         [CLICK_TO_VERIFY]: 'Napsauta vahvistaaksesi',
         [VERIFYING]: 'Vahvistetaan',
         [SUCCESS]: 'Vahvistettu',
         [UNAVAILABLE]: 'Ei saatavilla',
+        [BUSY]: 'Varattu, yritä myöhemmin uudelleen',
         [INCOMPLETE]: 'epätäydellinen',
         [ERROR]: 'virhe',
         [TESTING]: 'testi',
+        [USE_IMAGE]: 'Käytä kuvaa',
+        [TYPE_CODE]: 'Kirjoita merkit',
+        [SUBMIT]: 'Vahvista',
     },
     'et': {
         [CLICK_TO_VERIFY]: 'Klõpsa kinnitamiseks',
         [VERIFYING]: 'Kinnitamine',
         [SUCCESS]: 'Kinnitatud',
         [UNAVAILABLE]: 'Pole saadaval',
+        [BUSY]: 'Hõivatud, proovi hiljem uuesti',
         [INCOMPLETE]: 'puudulik',
         [ERROR]: 'viga',
         [TESTING]: 'test',
+        [USE_IMAGE]: 'Kasuta pilti',
+        [TYPE_CODE]: 'Sisesta märgid',
+        [SUBMIT]: 'Kinnita',
     }
 };
@@ -0,0 +1,224 @@
+'use strict';
+
+import { CaptchaWidget, TURNSTILE_COMPAT } from './widget.js';
+import * as errors from './errors.js';
+
+// https://developers.cloudflare.com/turnstile/troubleshooting/client-side-errors/error-codes/
+const TURNSTILE_INVALID_SITEKEY = '400020';
+const TURNSTILE_CLIENT_ERROR = '300010';
+const TURNSTILE_CHALLENGE_ERROR = '600010';
+
+const EXECUTION_EXECUTE = 'execute';
+
+/**
+ * @param {number} errorCode one of the codes from errors.js
+ * @returns {string} closest Turnstile client-side error code
+ */
+export function turnstileErrorCode(errorCode) {
+    switch (errorCode) {
+        case errors.ERROR_NOT_CONFIGURED:
+            return TURNSTILE_INVALID_SITEKEY;
+        case errors.ERROR_SOLVE_PUZZLE:
+            return TURNSTILE_CHALLENGE_ERROR;
+        default:
+            return TURNSTILE_CLIENT_ERROR;
+    }
+}
+
+/**
+ * @param {string | Function | undefined} callback function or name of the global function
+ * @returns {Function | null}
+ */
+function resolveCallback(callback) {
+    if (typeof callback === 'function') { return callback; }
+    if (callback && (typeof callback === 'string') && (typeof window[callback] === 'function')) { return window[callback]; }
+    return null;
+}
+
+function invokeCallback(callback, ...args) {
+    if (!callback) { return; }
+    try {
+        callback(...args);
+    } catch (e) {
+        console.error('[privatecaptcha] Error in Turnstile callback:', e);
+    }
+}
+
+/**
+ * Turnstile supports "auto" theme that follows preferences of the end user
+ * @param {string} theme
+ * @returns {string}
+ */
+function resolveTheme(theme) {
+    if (theme === 'auto') {
+        const prefersDark = window.matchMedia && window.matchMedia('(prefers-color-scheme: dark)').matches;
+        return prefersDark ? 'dark' : 'light';
+    }
+    return theme;
+}
+
+/**
+ * Cloudflare Turnstile compatibility layer: widgets are referenced by ID and callbacks receive the response token
+ */
+export class TurnstileAPI {
+    constructor() {
+        this._widgets = new Map();
+        this._lastID = 0;
+    }
+
+    /**
+     * @param {string | HTMLElement} container element or CSS selector
+     * @param {Object} params same as data-* attributes of Turnstile widget (sitekey, callback, error-callback etc.)
+     * @returns {string | undefined} widget ID or undefined if widget cannot be rendered
+     */
+    render(container, params = {}) {
+        const element = (typeof container === 'string') ? document.querySelector(container) : container;
+        if (!element) {
+            console.error(`[privatecaptcha] Turnstile container was not found: ${container}`);
+            return undefined;
+        }
+
+        if (element.dataset['attached']) {
+            console.warn('[privatecaptcha] Turnstile widget was already rendered');
+            return element.dataset['turnstileId'];
+        }
+
+        const data = element.dataset;
+        const get = (name, dataName) => (params[name] !== undefined) ? params[name] : data[dataName];
+
+        const options = { compat: TURNSTILE_COMPAT };
+        const sitekey = get('sitekey', 'sitekey');
+        if (sitekey) { options.sitekey = sitekey; }
+        const theme = get('theme', 'theme');
+        if (theme) { options.theme = resolveTheme(theme); }
+        const lang = get('language', 'language');
+        if (lang && (lang !== 'auto')) { options.lang = lang.split('-')[0].toLowerCase(); }
+        const fieldName = get('response-field-name', 'responseFieldName');
+        if (fieldName) { options.fieldName = fieldName; }
+        if (get('execution', 'execution') === EXECUTION_EXECUTE) { options.startMode = 'click'; }
+
+        const callbacks = {
+            success: resolveCallback(get('callback', 'callback')),
+            error: resolveCallback(get('error-callback', 'errorCallback')),
+            expired: resolveCallback(get('expired-callback', 'expiredCallback')),
+        };
+
+        const widget = new CaptchaWidget(element, options);
+        element.dataset['attached'] = '1';
+
+        this._lastID++;
+        const id = `pc-turnstile-${this._lastID}`;
+        element.dataset['turnstileId'] = id;
+        const entry = { widget: widget, element: element, options: options, expired: false, removed: false };
+        this._widgets.set(id, entry);
+
+        // listeners cannot be detached from the container, so removed widgets are only muted
+        element.addEventListener('privatecaptcha:finish', () => {
+            if (entry.removed) { return; }
+            entry.expired = false;
+            invokeCallback(callbacks.success, widget.solution());
+        });
+        element.addEventListener('privatecaptcha:error', () => {
+            if (entry.removed) { return; }
+            invokeCallback(callbacks.error, turnstileErrorCode(widget.errorCode()));
+        });
+        element.addEventListener('privatecaptcha:expire', () => {
+            if (entry.removed) { return; }
+            entry.expired = true;
+            invokeCallback(callbacks.expired, '');
+        });
+
+        return id;
+    }
+
+    /**
+     * Turnstile methods without widget ID refer to the first rendered widget
+     * @param {string | HTMLElement | undefined} widgetID
+     */
+    _find(widgetID) {
+        if (widgetID === undefined) {
+            return this._widgets.values().next().value || null;
+        }
+
+        if (widgetID instanceof HTMLElement) {
+            widgetID = widgetID.dataset['turnstileId'];
+        } else if (!this._widgets.has(widgetID)) {
+            // container selector can be used instead of widget ID
+            try {
+                const element = document.querySelector(widgetID);
+                widgetID = element ? element.dataset['turnstileId'] : widgetID;
+            } catch (e) {
+                return null;
+            }
+        }
+
+        return this._widgets.get(widgetID) || null;
+    }
+
+    /**
+     * @param {string | HTMLElement | undefined} widgetID
+     * @returns {string | undefined} response token
+     */
+    getResponse(widgetID) {
+        const entry = this._find(widgetID);
+        return entry ? (entry.widget.solution() || undefined) : undefined;
+    }
+
+    /**
+     * @param {string | HTMLElement | undefined} widgetID
+     */
+    reset(widgetID) {
+        const entry = this._find(widgetID);
+        if (entry) {
+            entry.expired = false;
+            entry.widget.reset(entry.options);
+        }
+    }
+
+    /**
+     * @param {string | HTMLElement | undefined} widgetID
+     */
+    remove(widgetID) {
+        const entry = this._find(widgetID);
+        if (!entry) { return; }
+
+        entry.removed = true;
+        entry.widget.reset(entry.options);
+        entry.element.innerHTML = '';
+        delete entry.element.dataset['attached'];
+        this._widgets.delete(entry.element.dataset['turnstileId']);
+        delete entry.element.dataset['turnstileId'];
+    }
+
+    /**
+     * @param {string | HTMLElement | undefined} widgetID
+     * @returns {boolean}
+     */
+    isExpired(widgetID) {
+        const entry = this._find(widgetID);
+        return entry ? entry.expired : false;
+    }
+
+    /**
+     * @param {string | HTMLElement} container widget ID, element or CSS selector
+     * @param {Object} params used to render the widget if it was not rendered before
+     */
+    execute(container, params = {}) {
+        let entry = this._find(container);
+        if (!entry) {
+            const id = this.render(container, Object.assign({ execution: EXECUTION_EXECUTE }, params));
+            entry = id ? this._widgets.get(id) : null;
+        }
+
+        if (entry) {
+            entry.widget.execute();
+        }
+    }
+
+    /**
+     * @param {Function} callback invoked when API is ready (script is not loaded asynchronously by us)
+     */
+    ready(callback) {
+        invokeCallback(callback);
+    }
+}
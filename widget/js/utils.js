@@ -1,3 +1,16 @@
 'use strict';
 
 export const SafeHTMLElement = typeof HTMLElement !== 'undefined' ? HTMLElement : Object;
+
+/**
+ * @param {string} text
+ * @returns {string} text that is safe to insert into HTML
+ */
+export function escapeHTML(text) {
+    return String(text)
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
+}
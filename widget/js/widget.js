@@ -1,9 +1,10 @@
 'use strict';
 
-import { getPuzzle, Puzzle } from './puzzle.js'
+import { getPuzzle, getImageChallenge, submitHandoff, Puzzle, PuzzleFailure } from './puzzle.js'
 import { WorkersPool } from './workerspool.js'
-import { CaptchaElement, STATE_EMPTY, STATE_ERROR, STATE_READY, STATE_IN_PROGRESS, STATE_VERIFIED, STATE_LOADING, STATE_INVALID, DISPLAY_POPUP, DISPLAY_WIDGET } from './html.js';
+import { CaptchaElement, STATE_EMPTY, STATE_ERROR, STATE_READY, STATE_IN_PROGRESS, STATE_VERIFIED, STATE_LOADING, STATE_INVALID, STATE_IMAGE, DISPLAY_POPUP, DISPLAY_WIDGET } from './html.js';
 import * as errors from './errors.js';
+import { computeScriptHash } from './integrity.js';
 
 if (typeof window !== "undefined") {
     window.customElements.define('private-captcha', CaptchaElement);
@@ -11,7 +12,12 @@ if (typeof window !== "undefined") {
 
 const PUZZLE_ENDPOINT_URL = 'https://api.privatecaptcha.com/puzzle';
 const PUZZLE_EU_ENDPOINT_URL = 'https://api.eu.privatecaptcha.com/puzzle';
+// server decides on the actual remember window, this is only the upper bound for keeping proofs around
+const REMEMBER_PROOF_MAX_AGE_MILLIS = 24 * 60 * 60 * 1000;
 export const RECAPTCHA_COMPAT = 'recaptcha';
+export const TURNSTILE_COMPAT = 'turnstile';
+// query parameter added by the server to the URL that kiosk devices display as a QR code
+const HANDOFF_QUERY_PARAM = 'pc-handoff';
 
 
 /**
@@ -25,6 +31,48 @@ function findParentFormElement(element) {
     return element;
 }
 
+function handoffFromLocation() {
+    if (typeof window === 'undefined' || !window.location) { return null; }
+    try {
+        return new URLSearchParams(window.location.search).get(HANDOFF_QUERY_PARAM);
+    } catch (e) {
+        return null;
+    }
+}
+
+function rememberProofKey(sitekey) {
+    return `privatecaptcha:remember:${sitekey}`;
+}
+
+/**
+ * @param {string} sitekey
+ * @returns {string | null}
+ */
+function loadRememberProof(sitekey) {
+    try {
+        const item = JSON.parse(window.localStorage.getItem(rememberProofKey(sitekey)));
+        if (item && item.payload && ((Date.now() - item.timestamp) < REMEMBER_PROOF_MAX_AGE_MILLIS)) {
+            return item.payload;
+        }
+    } catch (e) {
+        // localStorage can be unavailable (e.g. disabled cookies)
+    }
+
+    return null;
+}
+
+/**
+ * @param {string} sitekey
+ * @param {string} payload
+ */
+function saveRememberProof(sitekey, payload) {
+    try {
+        window.localStorage.setItem(rememberProofKey(sitekey), JSON.stringify({ payload: payload, timestamp: Date.now() }));
+    } catch (e) {
+        // localStorage can be unavailable (e.g. disabled cookies)
+    }
+}
+
 export class CaptchaWidget {
     /**
      * @param {HTMLElement} element
@@ -33,16 +81,19 @@ export class CaptchaWidget {
     constructor(element, options = {}) {
         this._element = element;
         this._puzzle = null;
+        this._stickyPuzzle = null; // last received puzzle, kept across resets to avoid difficulty oscillation
         this._expiryTimeout = null;
         this._state = STATE_EMPTY;
         this._lastProgress = null;
         this._solution = null;
+        this._imageAnswer = null; // code typed by end user instead of solving the puzzle (image challenge)
         this._userStarted = false; // aka 'user started while we were initializing'
         this._apiTriggered = false; // aka execute() for programmatic triggering
         this._options = {};
         this._errorCode = errors.ERROR_NO_ERROR;
 
         this.setOptions(options);
+        computeScriptHash();
 
         this._workersPool = new WorkersPool({
             workersReady: this.onWorkersReady.bind(this),
@@ -59,6 +110,8 @@ export class CaptchaWidget {
             form.addEventListener('focusin', this.onFocusIn.bind(this), { passive: true });
             this._element.innerHTML = `<private-captcha display-mode="${this._options.displayMode}" lang="${this._options.lang}" theme="${this._options.theme}" extra-styles="${this._options.styles}"${this._options.debug ? ' debug="true"' : ''}></private-captcha>`;
             this._element.addEventListener('privatecaptcha:checked', this.onChecked.bind(this));
+            this._element.addEventListener('privatecaptcha:image', this.onImageRequested.bind(this));
+            this._element.addEventListener('privatecaptcha:imageanswer', this.onImageAnswered.bind(this));
 
             if (this._options.storeVariable) {
                 this._element[this._options.storeVariable] = this;
@@ -89,6 +142,8 @@ export class CaptchaWidget {
         let defaultField = "private-captcha-solution";
         if (options.hasOwnProperty('compat') && options.compat === RECAPTCHA_COMPAT) {
             defaultField = "g-recaptcha-response";
+        } else if (options.hasOwnProperty('compat') && options.compat === TURNSTILE_COMPAT) {
+            defaultField = "cf-turnstile-response";
         }
 
         let sitekey = "";
@@ -107,6 +162,7 @@ export class CaptchaWidget {
             theme: this._element.dataset["theme"] || "light",
             styles: this._element.dataset["styles"] || "",
             storeVariable: this._element.dataset["storeVariable"] || null,
+            handoff: this._element.dataset["handoff"] || handoffFromLocation(),
         }, options);
 
         if ('auto' === this._options.lang) {
@@ -132,6 +188,7 @@ export class CaptchaWidget {
 
         this._puzzle = null;
         this._solution = null;
+        this._imageAnswer = null;
         this._errorCode = errors.ERROR_NO_ERROR;
 
         const sitekey = this.checkConfigured();
@@ -147,15 +204,17 @@ export class CaptchaWidget {
             this._workersPool.reset();
         }
 
-        const startWorkers = ('auto' === this._options.startMode) || autoStart;
-
         try {
             this.setState(STATE_LOADING);
             this.setProgressState(STATE_LOADING);
             this.trace(`fetching puzzle. sitekey=${sitekey}`);
-            const puzzleData = await getPuzzle(this._options.puzzleEndpoint, sitekey);
+            const puzzleData = await getPuzzle(this._options.puzzleEndpoint, sitekey, loadRememberProof(sitekey), this._stickyPuzzle);
             this._puzzle = new Puzzle(puzzleData);
             if (this._puzzle && this._puzzle.isZero()) { this._errorCode = errors.ERROR_ZERO_PUZZLE; }
+            else { this._stickyPuzzle = this._puzzle.hasDifficultyLease() ? null : puzzleData; }
+            this.setImageChallenge(!this._puzzle.isZero() && (this._puzzle.solutionsCount > 0) && this._puzzle.imageChallenge());
+            // server can require end user interaction per property regardless of the start mode
+            const startWorkers = (('auto' === this._options.startMode) && !this._puzzle.requiresInteraction()) || autoStart;
             const expirationMillis = this._puzzle.expirationMillis();
             this.trace(`parsed puzzle buffer. isZero=${this._puzzle.isZero()} ttl=${expirationMillis / 1000}`);
             if (this._expiryTimeout) { clearTimeout(this._expiryTimeout); }
@@ -165,6 +224,10 @@ export class CaptchaWidget {
         } catch (e) {
             console.error('[privatecaptcha]', e);
             if (this._expiryTimeout) { clearTimeout(this._expiryTimeout); }
+            if (e instanceof PuzzleFailure) {
+                this.onPuzzleFailure(e);
+                return;
+            }
             this._errorCode = errors.ERROR_FETCH_PUZZLE;
             this.setState(STATE_ERROR);
             this.setProgressState((this._userStarted || this._apiTriggered) ? STATE_VERIFIED : STATE_EMPTY);
@@ -175,6 +238,25 @@ export class CaptchaWidget {
         }
     }
 
+    /**
+     * Shows what property owner configured for end users instead of a generic error
+     * @param {PuzzleFailure} failure
+     */
+    onPuzzleFailure(failure) {
+        if (failure.failureURL) {
+            this.trace(`redirecting after puzzle failure. url=${failure.failureURL}`);
+            window.location.assign(failure.failureURL);
+            return;
+        }
+
+        const quotaExceeded = failure.isQuotaExceeded();
+        this._errorCode = quotaExceeded ? errors.ERROR_QUOTA_EXCEEDED : errors.ERROR_FETCH_PUZZLE;
+        const pcElement = this._element.querySelector('private-captcha');
+        if (pcElement) { pcElement.setFailureMessage(failure.failureMessage, quotaExceeded); }
+        this.setState(STATE_INVALID);
+        this.setProgressState(STATE_INVALID);
+    }
+
     /**
      * Ensures that we have a sitekey available (defined or passed through options)
      * @returns {string | null}
@@ -294,7 +376,9 @@ export class CaptchaWidget {
 
         this._puzzle = null;
         this._solution = null;
+        this._imageAnswer = null;
         this._errorCode = errors.ERROR_NO_ERROR;
+        this.setImageChallenge(false);
         this.setState(STATE_EMPTY);
         this.setProgressState(STATE_EMPTY);
         this.ensureNoSolutionField();
@@ -323,6 +407,17 @@ export class CaptchaWidget {
         this.setState(STATE_EMPTY);
         this.setProgressState(STATE_EMPTY);
         this.ensureNoSolutionField();
+        this.dispatchEvent("expire");
+
+        if (this._puzzle && !this._puzzle.autoRefresh()) {
+            this.trace('skipping puzzle refresh on expiration');
+            if (this._workersPool) { this._workersPool.reset(); }
+            this._puzzle = null;
+            this._userStarted = false;
+            this._apiTriggered = false;
+            return;
+        }
+
         this.init(this._userStarted);
     }
 
@@ -333,6 +428,13 @@ export class CaptchaWidget {
         return this._solution;
     }
 
+    /**
+     * @returns {number} one of the codes from errors.js
+     */
+    errorCode() {
+        return this._errorCode;
+    }
+
     /**
      * @param {FocusEvent} event
      */
@@ -426,6 +528,70 @@ export class CaptchaWidget {
         }
     }
 
+    /**
+     * @param {boolean} enabled
+     */
+    setImageChallenge(enabled) {
+        const pcElement = this._element.querySelector('private-captcha');
+        if (pcElement) { pcElement.setImageChallenge(enabled); }
+    }
+
+    /**
+     * End user gave up on waiting for the puzzle to be solved and asked for the image challenge instead
+     * @param {Event} event
+     */
+    async onImageRequested(event) {
+        if (event) { event.stopPropagation(); }
+
+        if (!this._puzzle || !this._puzzle.imageChallenge() || (STATE_IN_PROGRESS !== this._state)) {
+            this.trace(`skipping image challenge request. state=${this._state}`);
+            return;
+        }
+
+        this.trace('requesting image challenge');
+
+        try {
+            const puzzle = this._puzzle;
+            const image = await getImageChallenge(this._options.puzzleEndpoint, this._options.sitekey || this._element.dataset["sitekey"], puzzle.rawData);
+            // puzzle could have been solved or expired in the meantime
+            if ((puzzle !== this._puzzle) || (STATE_IN_PROGRESS !== this._state)) { return; }
+
+            if (this._workersPool) { this._workersPool.stop(); }
+            const pcElement = this._element.querySelector('private-captcha');
+            if (pcElement) { pcElement.setImageURL(URL.createObjectURL(image)); }
+            this.setState(STATE_IMAGE);
+            this.setProgressState(STATE_IMAGE);
+        } catch (e) {
+            console.error('[privatecaptcha] Failed to load image challenge:', e);
+        }
+    }
+
+    /**
+     * @param {CustomEvent} event
+     */
+    onImageAnswered(event) {
+        if (event) { event.stopPropagation(); }
+
+        if (STATE_IMAGE !== this._state) {
+            console.warn(`[privatecaptcha] image challenge is not active. state=${this._state}`);
+            return;
+        }
+
+        const answer = (event && event.detail) ? String(event.detail.answer).toUpperCase() : '';
+        if (!/^[A-Z0-9]+$/.test(answer)) {
+            console.warn('[privatecaptcha] image challenge answer is not valid');
+            return;
+        }
+
+        this.trace('image challenge answered');
+
+        this._imageAnswer = answer;
+        this.setState(STATE_VERIFIED);
+        this.setProgressState(STATE_VERIFIED);
+        this.saveSolutions();
+        this.signalFinished();
+    }
+
     /**
      * @param {boolean} autoStart
      */
@@ -490,7 +656,8 @@ export class CaptchaWidget {
     }
 
     saveSolutions() {
-        const solutions = this._workersPool.serializeSolutions(this._errorCode);
+        // server does not validate image challenge answer before verification, so it is sent instead of solutions
+        const solutions = this._imageAnswer ? `img:${this._imageAnswer}` : this._workersPool.serializeSolutions(this._errorCode);
         const payload = `${solutions}.${this._puzzle ? this._puzzle.rawData : ''}`;
 
         this.ensureNoSolutionField();
@@ -498,7 +665,18 @@ export class CaptchaWidget {
 
         this._solution = payload;
 
+        // only "full" solved puzzles can be used as a proof for getting a remembered (no-work) puzzle later
+        if (this._puzzle && !this._puzzle.isZero() && (this._puzzle.solutionsCount > 0) && !this._imageAnswer && (errors.ERROR_NO_ERROR === this._errorCode)) {
+            saveRememberProof(this._options.sitekey || this._element.dataset["sitekey"], payload);
+        }
+
         this.trace(`saved solutions. field=${this._options.fieldName} payload=${payload}`);
+
+        if (this._options.handoff && (errors.ERROR_NO_ERROR === this._errorCode)) {
+            submitHandoff(this._options.puzzleEndpoint, this._options.handoff, payload)
+                .then(() => this.trace(`submitted handoff solution. id=${this._options.handoff}`))
+                .catch((e) => console.error('[privatecaptcha] Failed to submit handoff solution:', e));
+        }
     }
 
     /**
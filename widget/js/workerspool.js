@@ -1,7 +1,8 @@
 import { encode } from 'base64-arraybuffer';
 import PuzzleWorker from './puzzle.worker.js';
+import { integrityBeacon, SCRIPT_HASH_LENGTH } from './integrity.js';
 
-const METADATA_VERSION = 1;
+const METADATA_VERSION = 2;
 
 export class WorkersPool {
     constructor(callbacks = {}, debug = false) {
@@ -182,7 +183,7 @@ export class WorkersPool {
     }
 
     writeMetadata(errorCode) {
-        const metadataSize = 1 + 1 + 1 + 4;
+        const metadataSize = 1 + 1 + 1 + 4 + 1 + SCRIPT_HASH_LENGTH;
         const binaryData = new Uint8Array(metadataSize);
         let currentIndex = 0;
 
@@ -199,6 +200,11 @@ export class WorkersPool {
         binaryData[currentIndex++] = (elapsedMillis >> 16) & 0xFF;
         binaryData[currentIndex++] = (elapsedMillis >> 24) & 0xFF;
 
+        const beacon = integrityBeacon();
+        binaryData[currentIndex++] = beacon.flags & 0xFF;
+        binaryData.set(beacon.hash, currentIndex);
+        currentIndex += SCRIPT_HASH_LENGTH;
+
         return binaryData;
     }
 
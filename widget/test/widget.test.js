@@ -217,3 +217,45 @@ test('CaptchaWidget execute() fires started event and callback', async (t) => {
 
     console.log('✓ Widget started test passed');
 });
+
+test('Turnstile render() passes token to callback and response field', async (t) => {
+    document.body.innerHTML = `
+        <form>
+            <div id="turnstile-container"
+                 data-callback="testTurnstileCallback">
+            </div>
+        </form>
+    `;
+
+    const { TurnstileAPI } = await import('../js/turnstile.js');
+    const turnstile = new TurnstileAPI();
+
+    const tokenPromise = new Promise((resolve, reject) => {
+        const timeout = setTimeout(() => {
+            reject(new Error('Callback timeout after 5000ms'));
+        }, 5000);
+
+        global.window.testTurnstileCallback = (token) => {
+            clearTimeout(timeout);
+            resolve(token);
+        };
+    });
+
+    const widgetID = turnstile.render('#turnstile-container', { sitekey: testSitekey });
+    assert.ok(widgetID, 'Should return widget ID');
+
+    turnstile.execute(widgetID);
+
+    const token = await tokenPromise;
+    assert.ok(token, 'Callback should receive a token');
+    assert.strictEqual(turnstile.getResponse(widgetID), token, 'getResponse() should return the same token');
+
+    const field = document.querySelector('input[name="cf-turnstile-response"]');
+    assert.ok(field, 'Should add Turnstile response field');
+    assert.strictEqual(field.value, token, 'Response field should contain the token');
+
+    turnstile.remove(widgetID);
+    assert.strictEqual(turnstile.getResponse(widgetID), undefined, 'Removed widget should not have a response');
+
+    console.log('✓ Turnstile render test passed');
+});
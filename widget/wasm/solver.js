@@ -0,0 +1,53 @@
+// Glue for the WebAssembly build of the Go puzzle solver (cmd/solverwasm).
+// wasm_exec.js, published alongside, has to be loaded first (e.g. with importScripts() in a worker) as it defines `Go`.
+
+const SOLVE_FUNCTION = 'privateCaptchaSolve';
+
+let solverPromise = null;
+
+async function instantiate(wasmURL, importObject) {
+    if (WebAssembly.instantiateStreaming) {
+        return await WebAssembly.instantiateStreaming(fetch(wasmURL), importObject);
+    }
+
+    const response = await fetch(wasmURL);
+    const bytes = await response.arrayBuffer();
+    return await WebAssembly.instantiate(bytes, importObject);
+}
+
+export function loadSolver(wasmURL) {
+    if (!solverPromise) {
+        solverPromise = (async () => {
+            if (typeof Go === 'undefined') {
+                throw new Error('wasm_exec.js is not loaded');
+            }
+
+            const go = new Go();
+            const result = await instantiate(wasmURL, go.importObject);
+            // run() only resolves when the Go program exits, which it does not
+            go.run(result.instance);
+
+            const fn = globalThis[SOLVE_FUNCTION];
+            if (typeof fn !== 'function') {
+                throw new Error('solver function is not registered');
+            }
+
+            return fn;
+        })();
+
+        solverPromise.catch(() => { solverPromise = null; });
+    }
+
+    return solverPromise;
+}
+
+// solve() returns payload in the same format as the widget submits (solutions.puzzle)
+export async function solve(wasmURL, puzzle) {
+    const fn = await loadSolver(wasmURL);
+    const result = fn(puzzle);
+    if (result.error) {
+        throw new Error(result.error);
+    }
+
+    return result.solution;
+}